@@ -0,0 +1,823 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: infinitydex/v1/swap.proto
+
+package infinitydexv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SwapRequest is a swap to quote, simulate or start
+type SwapRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SourceToken        *Token  `protobuf:"bytes,1,opt,name=source_token,json=sourceToken,proto3" json:"source_token,omitempty"`
+	DestinationToken   *Token  `protobuf:"bytes,2,opt,name=destination_token,json=destinationToken,proto3" json:"destination_token,omitempty"`
+	Amount             string  `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"` // Base-unit integer
+	SourceAddress      string  `protobuf:"bytes,4,opt,name=source_address,json=sourceAddress,proto3" json:"source_address,omitempty"`
+	DestinationAddress string  `protobuf:"bytes,5,opt,name=destination_address,json=destinationAddress,proto3" json:"destination_address,omitempty"`
+	Slippage           float64 `protobuf:"fixed64,6,opt,name=slippage,proto3" json:"slippage,omitempty"`
+	RefundAddress      string  `protobuf:"bytes,7,opt,name=refund_address,json=refundAddress,proto3" json:"refund_address,omitempty"`
+	RequestId          string  `protobuf:"bytes,8,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`                     // Assigned by the server when empty
+	MinOutputAmount    string  `protobuf:"bytes,9,opt,name=min_output_amount,json=minOutputAmount,proto3" json:"min_output_amount,omitempty"` // Base-unit integer; skips confirmation for same-chain wrapped swaps
+}
+
+func (x *SwapRequest) Reset() {
+	*x = SwapRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_swap_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwapRequest) ProtoMessage() {}
+
+func (x *SwapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_swap_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwapRequest.ProtoReflect.Descriptor instead.
+func (*SwapRequest) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_swap_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SwapRequest) GetSourceToken() *Token {
+	if x != nil {
+		return x.SourceToken
+	}
+	return nil
+}
+
+func (x *SwapRequest) GetDestinationToken() *Token {
+	if x != nil {
+		return x.DestinationToken
+	}
+	return nil
+}
+
+func (x *SwapRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetSourceAddress() string {
+	if x != nil {
+		return x.SourceAddress
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetDestinationAddress() string {
+	if x != nil {
+		return x.DestinationAddress
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetSlippage() float64 {
+	if x != nil {
+		return x.Slippage
+	}
+	return 0
+}
+
+func (x *SwapRequest) GetRefundAddress() string {
+	if x != nil {
+		return x.RefundAddress
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetMinOutputAmount() string {
+	if x != nil {
+		return x.MinOutputAmount
+	}
+	return ""
+}
+
+// GetSwapRequest names a swap
+type GetSwapRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+}
+
+func (x *GetSwapRequest) Reset() {
+	*x = GetSwapRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_swap_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSwapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSwapRequest) ProtoMessage() {}
+
+func (x *GetSwapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_swap_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSwapRequest.ProtoReflect.Descriptor instead.
+func (*GetSwapRequest) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_swap_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetSwapRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+// SwapQuote is the price of a swap
+type SwapQuote struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SourceToken      *Token                 `protobuf:"bytes,1,opt,name=source_token,json=sourceToken,proto3" json:"source_token,omitempty"`
+	DestinationToken *Token                 `protobuf:"bytes,2,opt,name=destination_token,json=destinationToken,proto3" json:"destination_token,omitempty"`
+	InputAmount      string                 `protobuf:"bytes,3,opt,name=input_amount,json=inputAmount,proto3" json:"input_amount,omitempty"`
+	OutputAmount     string                 `protobuf:"bytes,4,opt,name=output_amount,json=outputAmount,proto3" json:"output_amount,omitempty"`
+	Fee              *Fee                   `protobuf:"bytes,5,opt,name=fee,proto3" json:"fee,omitempty"`
+	Path             []string               `protobuf:"bytes,6,rep,name=path,proto3" json:"path,omitempty"`
+	PriceImpact      float64                `protobuf:"fixed64,7,opt,name=price_impact,json=priceImpact,proto3" json:"price_impact,omitempty"`
+	ExchangeRate     float64                `protobuf:"fixed64,8,opt,name=exchange_rate,json=exchangeRate,proto3" json:"exchange_rate,omitempty"`
+	Bridge           string                 `protobuf:"bytes,9,opt,name=bridge,proto3" json:"bridge,omitempty"`
+	MidPrice         float64                `protobuf:"fixed64,10,opt,name=mid_price,json=midPrice,proto3" json:"mid_price,omitempty"`
+	PriceAsOf        *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=price_as_of,json=priceAsOf,proto3" json:"price_as_of,omitempty"`
+}
+
+func (x *SwapQuote) Reset() {
+	*x = SwapQuote{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_swap_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwapQuote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwapQuote) ProtoMessage() {}
+
+func (x *SwapQuote) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_swap_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwapQuote.ProtoReflect.Descriptor instead.
+func (*SwapQuote) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_swap_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SwapQuote) GetSourceToken() *Token {
+	if x != nil {
+		return x.SourceToken
+	}
+	return nil
+}
+
+func (x *SwapQuote) GetDestinationToken() *Token {
+	if x != nil {
+		return x.DestinationToken
+	}
+	return nil
+}
+
+func (x *SwapQuote) GetInputAmount() string {
+	if x != nil {
+		return x.InputAmount
+	}
+	return ""
+}
+
+func (x *SwapQuote) GetOutputAmount() string {
+	if x != nil {
+		return x.OutputAmount
+	}
+	return ""
+}
+
+func (x *SwapQuote) GetFee() *Fee {
+	if x != nil {
+		return x.Fee
+	}
+	return nil
+}
+
+func (x *SwapQuote) GetPath() []string {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *SwapQuote) GetPriceImpact() float64 {
+	if x != nil {
+		return x.PriceImpact
+	}
+	return 0
+}
+
+func (x *SwapQuote) GetExchangeRate() float64 {
+	if x != nil {
+		return x.ExchangeRate
+	}
+	return 0
+}
+
+func (x *SwapQuote) GetBridge() string {
+	if x != nil {
+		return x.Bridge
+	}
+	return ""
+}
+
+func (x *SwapQuote) GetMidPrice() float64 {
+	if x != nil {
+		return x.MidPrice
+	}
+	return 0
+}
+
+func (x *SwapQuote) GetPriceAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PriceAsOf
+	}
+	return nil
+}
+
+// SwapResult is the outcome of a finished or simulated swap
+type SwapResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId      string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Success        bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	SourceTx       *Transaction           `protobuf:"bytes,3,opt,name=source_tx,json=sourceTx,proto3" json:"source_tx,omitempty"`
+	DestinationTx  *Transaction           `protobuf:"bytes,4,opt,name=destination_tx,json=destinationTx,proto3" json:"destination_tx,omitempty"`
+	BridgeTx       *Transaction           `protobuf:"bytes,5,opt,name=bridge_tx,json=bridgeTx,proto3" json:"bridge_tx,omitempty"`
+	InputAmount    string                 `protobuf:"bytes,6,opt,name=input_amount,json=inputAmount,proto3" json:"input_amount,omitempty"`
+	OutputAmount   string                 `protobuf:"bytes,7,opt,name=output_amount,json=outputAmount,proto3" json:"output_amount,omitempty"`
+	Fee            *Fee                   `protobuf:"bytes,8,opt,name=fee,proto3" json:"fee,omitempty"`
+	CompletionTime *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=completion_time,json=completionTime,proto3" json:"completion_time,omitempty"`
+	Status         string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	ErrorMessage   string                 `protobuf:"bytes,11,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	ErrorCode      string                 `protobuf:"bytes,12,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+}
+
+func (x *SwapResult) Reset() {
+	*x = SwapResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_swap_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwapResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwapResult) ProtoMessage() {}
+
+func (x *SwapResult) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_swap_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwapResult.ProtoReflect.Descriptor instead.
+func (*SwapResult) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_swap_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SwapResult) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SwapResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SwapResult) GetSourceTx() *Transaction {
+	if x != nil {
+		return x.SourceTx
+	}
+	return nil
+}
+
+func (x *SwapResult) GetDestinationTx() *Transaction {
+	if x != nil {
+		return x.DestinationTx
+	}
+	return nil
+}
+
+func (x *SwapResult) GetBridgeTx() *Transaction {
+	if x != nil {
+		return x.BridgeTx
+	}
+	return nil
+}
+
+func (x *SwapResult) GetInputAmount() string {
+	if x != nil {
+		return x.InputAmount
+	}
+	return ""
+}
+
+func (x *SwapResult) GetOutputAmount() string {
+	if x != nil {
+		return x.OutputAmount
+	}
+	return ""
+}
+
+func (x *SwapResult) GetFee() *Fee {
+	if x != nil {
+		return x.Fee
+	}
+	return nil
+}
+
+func (x *SwapResult) GetCompletionTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletionTime
+	}
+	return nil
+}
+
+func (x *SwapResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SwapResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *SwapResult) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+// SwapResponse is a swap's status, with its quote or result when it has one
+type SwapResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string      `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Status    string      `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Quote     *SwapQuote  `protobuf:"bytes,3,opt,name=quote,proto3" json:"quote,omitempty"`
+	Result    *SwapResult `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`
+	Sandbox   bool        `protobuf:"varint,5,opt,name=sandbox,proto3" json:"sandbox,omitempty"`
+	Archived  bool        `protobuf:"varint,6,opt,name=archived,proto3" json:"archived,omitempty"`
+	Region    string      `protobuf:"bytes,7,opt,name=region,proto3" json:"region,omitempty"`
+}
+
+func (x *SwapResponse) Reset() {
+	*x = SwapResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_swap_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwapResponse) ProtoMessage() {}
+
+func (x *SwapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_swap_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwapResponse.ProtoReflect.Descriptor instead.
+func (*SwapResponse) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_swap_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SwapResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SwapResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SwapResponse) GetQuote() *SwapQuote {
+	if x != nil {
+		return x.Quote
+	}
+	return nil
+}
+
+func (x *SwapResponse) GetResult() *SwapResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *SwapResponse) GetSandbox() bool {
+	if x != nil {
+		return x.Sandbox
+	}
+	return false
+}
+
+func (x *SwapResponse) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *SwapResponse) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+var File_infinitydex_v1_swap_proto protoreflect.FileDescriptor
+
+var file_infinitydex_v1_swap_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2f, 0x76, 0x31,
+	0x2f, 0x73, 0x77, 0x61, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x69, 0x6e, 0x66,
+	0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1a, 0x69, 0x6e,
+	0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x79, 0x70,
+	0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x89, 0x03, 0x0a, 0x0b, 0x53, 0x77, 0x61,
+	0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x38, 0x0a, 0x0c, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x6f, 0x6b,
+	0x65, 0x6e, 0x12, 0x42, 0x0a, 0x11, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x6f, 0x6b, 0x65, 0x6e, 0x52, 0x10, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25,
+	0x0a, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x2f, 0x0a, 0x13, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x12, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x6c, 0x69, 0x70, 0x70, 0x61,
+	0x67, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x73, 0x6c, 0x69, 0x70, 0x70, 0x61,
+	0x67, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65, 0x66, 0x75, 0x6e, 0x64, 0x5f, 0x61, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x72, 0x65, 0x66, 0x75,
+	0x6e, 0x64, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x6d, 0x69, 0x6e, 0x5f,
+	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x6d, 0x69, 0x6e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x41, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x22, 0x2f, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x53, 0x77, 0x61, 0x70, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x49, 0x64, 0x22, 0xc5, 0x03, 0x0a, 0x09, 0x53, 0x77, 0x61, 0x70, 0x51, 0x75,
+	0x6f, 0x74, 0x65, 0x12, 0x38, 0x0a, 0x0c, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x74, 0x6f,
+	0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6e, 0x66, 0x69,
+	0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
+	0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x42, 0x0a,
+	0x11, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e,
+	0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52,
+	0x10, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x41, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x03, 0x66, 0x65, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74,
+	0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x65, 0x65, 0x52, 0x03, 0x66, 0x65, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04,
+	0x70, 0x61, 0x74, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x69, 0x6d,
+	0x70, 0x61, 0x63, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x70, 0x72, 0x69, 0x63,
+	0x65, 0x49, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x63, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c,
+	0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x72,
+	0x69, 0x64, 0x67, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x69, 0x64, 0x5f, 0x70, 0x72, 0x69, 0x63,
+	0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x6d, 0x69, 0x64, 0x50, 0x72, 0x69, 0x63,
+	0x65, 0x12, 0x3a, 0x0a, 0x0b, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x61, 0x73, 0x5f, 0x6f, 0x66,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x09, 0x70, 0x72, 0x69, 0x63, 0x65, 0x41, 0x73, 0x4f, 0x66, 0x22, 0x8d, 0x04,
+	0x0a, 0x0a, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1d, 0x0a, 0x0a,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x38, 0x0a, 0x09, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f,
+	0x74, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e,
+	0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x12,
+	0x42, 0x0a, 0x0e, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74,
+	0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69,
+	0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0d, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x54, 0x78, 0x12, 0x38, 0x0a, 0x09, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x74, 0x78,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74,
+	0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x08, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x54, 0x78, 0x12, 0x21, 0x0a,
+	0x0c, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x41,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78,
+	0x2e, 0x76, 0x31, 0x2e, 0x46, 0x65, 0x65, 0x52, 0x03, 0x66, 0x65, 0x65, 0x12, 0x43, 0x0a, 0x0f,
+	0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x22, 0xf8, 0x01,
+	0x0a, 0x0c, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2f, 0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64,
+	0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52,
+	0x05, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74,
+	0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x61,
+	0x6e, 0x64, 0x62, 0x6f, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x61, 0x6e,
+	0x64, 0x62, 0x6f, 0x78, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x32, 0xb0, 0x02, 0x0a, 0x0b, 0x53, 0x77, 0x61,
+	0x70, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x45, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x51,
+	0x75, 0x6f, 0x74, 0x65, 0x12, 0x1b, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64,
+	0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1c, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x49, 0x0a, 0x0c, 0x53, 0x69, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x53, 0x77, 0x61, 0x70, 0x12,
+	0x1b, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x69,
+	0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77,
+	0x61, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x09, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x53, 0x77, 0x61, 0x70, 0x12, 0x1b, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69,
+	0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64,
+	0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x47, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x53, 0x77, 0x61, 0x70, 0x12, 0x1e, 0x2e,
+	0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x74, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e,
+	0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x53,
+	0x77, 0x61, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x3a, 0x5a, 0x38, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69,
+	0x74, 0x79, 0x2d, 0x64, 0x65, 0x78, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x69, 0x6e, 0x66, 0x69, 0x6e,
+	0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2f, 0x76, 0x31, 0x3b, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69,
+	0x74, 0x79, 0x64, 0x65, 0x78, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_infinitydex_v1_swap_proto_rawDescOnce sync.Once
+	file_infinitydex_v1_swap_proto_rawDescData = file_infinitydex_v1_swap_proto_rawDesc
+)
+
+func file_infinitydex_v1_swap_proto_rawDescGZIP() []byte {
+	file_infinitydex_v1_swap_proto_rawDescOnce.Do(func() {
+		file_infinitydex_v1_swap_proto_rawDescData = protoimpl.X.CompressGZIP(file_infinitydex_v1_swap_proto_rawDescData)
+	})
+	return file_infinitydex_v1_swap_proto_rawDescData
+}
+
+var file_infinitydex_v1_swap_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_infinitydex_v1_swap_proto_goTypes = []any{
+	(*SwapRequest)(nil),           // 0: infinitydex.v1.SwapRequest
+	(*GetSwapRequest)(nil),        // 1: infinitydex.v1.GetSwapRequest
+	(*SwapQuote)(nil),             // 2: infinitydex.v1.SwapQuote
+	(*SwapResult)(nil),            // 3: infinitydex.v1.SwapResult
+	(*SwapResponse)(nil),          // 4: infinitydex.v1.SwapResponse
+	(*Token)(nil),                 // 5: infinitydex.v1.Token
+	(*Fee)(nil),                   // 6: infinitydex.v1.Fee
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+	(*Transaction)(nil),           // 8: infinitydex.v1.Transaction
+}
+var file_infinitydex_v1_swap_proto_depIdxs = []int32{
+	5,  // 0: infinitydex.v1.SwapRequest.source_token:type_name -> infinitydex.v1.Token
+	5,  // 1: infinitydex.v1.SwapRequest.destination_token:type_name -> infinitydex.v1.Token
+	5,  // 2: infinitydex.v1.SwapQuote.source_token:type_name -> infinitydex.v1.Token
+	5,  // 3: infinitydex.v1.SwapQuote.destination_token:type_name -> infinitydex.v1.Token
+	6,  // 4: infinitydex.v1.SwapQuote.fee:type_name -> infinitydex.v1.Fee
+	7,  // 5: infinitydex.v1.SwapQuote.price_as_of:type_name -> google.protobuf.Timestamp
+	8,  // 6: infinitydex.v1.SwapResult.source_tx:type_name -> infinitydex.v1.Transaction
+	8,  // 7: infinitydex.v1.SwapResult.destination_tx:type_name -> infinitydex.v1.Transaction
+	8,  // 8: infinitydex.v1.SwapResult.bridge_tx:type_name -> infinitydex.v1.Transaction
+	6,  // 9: infinitydex.v1.SwapResult.fee:type_name -> infinitydex.v1.Fee
+	7,  // 10: infinitydex.v1.SwapResult.completion_time:type_name -> google.protobuf.Timestamp
+	2,  // 11: infinitydex.v1.SwapResponse.quote:type_name -> infinitydex.v1.SwapQuote
+	3,  // 12: infinitydex.v1.SwapResponse.result:type_name -> infinitydex.v1.SwapResult
+	0,  // 13: infinitydex.v1.SwapService.GetQuote:input_type -> infinitydex.v1.SwapRequest
+	0,  // 14: infinitydex.v1.SwapService.SimulateSwap:input_type -> infinitydex.v1.SwapRequest
+	0,  // 15: infinitydex.v1.SwapService.StartSwap:input_type -> infinitydex.v1.SwapRequest
+	1,  // 16: infinitydex.v1.SwapService.GetSwap:input_type -> infinitydex.v1.GetSwapRequest
+	4,  // 17: infinitydex.v1.SwapService.GetQuote:output_type -> infinitydex.v1.SwapResponse
+	4,  // 18: infinitydex.v1.SwapService.SimulateSwap:output_type -> infinitydex.v1.SwapResponse
+	4,  // 19: infinitydex.v1.SwapService.StartSwap:output_type -> infinitydex.v1.SwapResponse
+	4,  // 20: infinitydex.v1.SwapService.GetSwap:output_type -> infinitydex.v1.SwapResponse
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_infinitydex_v1_swap_proto_init() }
+func file_infinitydex_v1_swap_proto_init() {
+	if File_infinitydex_v1_swap_proto != nil {
+		return
+	}
+	file_infinitydex_v1_types_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_infinitydex_v1_swap_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*SwapRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_infinitydex_v1_swap_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GetSwapRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_infinitydex_v1_swap_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*SwapQuote); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_infinitydex_v1_swap_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*SwapResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_infinitydex_v1_swap_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*SwapResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_infinitydex_v1_swap_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_infinitydex_v1_swap_proto_goTypes,
+		DependencyIndexes: file_infinitydex_v1_swap_proto_depIdxs,
+		MessageInfos:      file_infinitydex_v1_swap_proto_msgTypes,
+	}.Build()
+	File_infinitydex_v1_swap_proto = out.File
+	file_infinitydex_v1_swap_proto_rawDesc = nil
+	file_infinitydex_v1_swap_proto_goTypes = nil
+	file_infinitydex_v1_swap_proto_depIdxs = nil
+}
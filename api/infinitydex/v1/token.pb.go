@@ -0,0 +1,332 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: infinitydex/v1/token.proto
+
+package infinitydexv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ListTokensRequest filters the tokens; an empty chain ID lists every chain's
+type ListTokensRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChainId int64 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+}
+
+func (x *ListTokensRequest) Reset() {
+	*x = ListTokensRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_token_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListTokensRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTokensRequest) ProtoMessage() {}
+
+func (x *ListTokensRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_token_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTokensRequest.ProtoReflect.Descriptor instead.
+func (*ListTokensRequest) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_token_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListTokensRequest) GetChainId() int64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+// TokenDetails is a token with its metadata and USD price
+type TokenDetails struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token       *Token  `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	CoingeckoId string  `protobuf:"bytes,2,opt,name=coingecko_id,json=coingeckoId,proto3" json:"coingecko_id,omitempty"`
+	Verified    bool    `protobuf:"varint,3,opt,name=verified,proto3" json:"verified,omitempty"`
+	PriceUsd    float64 `protobuf:"fixed64,4,opt,name=price_usd,json=priceUsd,proto3" json:"price_usd,omitempty"`
+}
+
+func (x *TokenDetails) Reset() {
+	*x = TokenDetails{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_token_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TokenDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenDetails) ProtoMessage() {}
+
+func (x *TokenDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_token_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenDetails.ProtoReflect.Descriptor instead.
+func (*TokenDetails) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_token_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TokenDetails) GetToken() *Token {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *TokenDetails) GetCoingeckoId() string {
+	if x != nil {
+		return x.CoingeckoId
+	}
+	return ""
+}
+
+func (x *TokenDetails) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+func (x *TokenDetails) GetPriceUsd() float64 {
+	if x != nil {
+		return x.PriceUsd
+	}
+	return 0
+}
+
+// ListTokensResponse lists tokens by chain, then symbol
+type ListTokensResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tokens  []*TokenDetails `protobuf:"bytes,1,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	Sandbox bool            `protobuf:"varint,2,opt,name=sandbox,proto3" json:"sandbox,omitempty"`
+}
+
+func (x *ListTokensResponse) Reset() {
+	*x = ListTokensResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_infinitydex_v1_token_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListTokensResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTokensResponse) ProtoMessage() {}
+
+func (x *ListTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_infinitydex_v1_token_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTokensResponse.ProtoReflect.Descriptor instead.
+func (*ListTokensResponse) Descriptor() ([]byte, []int) {
+	return file_infinitydex_v1_token_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListTokensResponse) GetTokens() []*TokenDetails {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+func (x *ListTokensResponse) GetSandbox() bool {
+	if x != nil {
+		return x.Sandbox
+	}
+	return false
+}
+
+var File_infinitydex_v1_token_proto protoreflect.FileDescriptor
+
+var file_infinitydex_v1_token_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2f, 0x76, 0x31,
+	0x2f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x69, 0x6e,
+	0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x1a, 0x1a, 0x69, 0x6e,
+	0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x79, 0x70,
+	0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x2e, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a,
+	0x08, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x22, 0x97, 0x01, 0x0a, 0x0c, 0x54, 0x6f, 0x6b,
+	0x65, 0x6e, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x12, 0x2b, 0x0a, 0x05, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e,
+	0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x52,
+	0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x69, 0x6e, 0x67, 0x65,
+	0x63, 0x6b, 0x6f, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f,
+	0x69, 0x6e, 0x67, 0x65, 0x63, 0x6b, 0x6f, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x76, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x76, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x65, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x75,
+	0x73, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x70, 0x72, 0x69, 0x63, 0x65, 0x55,
+	0x73, 0x64, 0x22, 0x64, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x06, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e,
+	0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x44,
+	0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x06, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x73, 0x61, 0x6e, 0x64, 0x62, 0x6f, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x07, 0x73, 0x61, 0x6e, 0x64, 0x62, 0x6f, 0x78, 0x32, 0x63, 0x0a, 0x0c, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x53, 0x0a, 0x0a, 0x4c, 0x69, 0x73, 0x74,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x12, 0x21, 0x2e, 0x69, 0x6e, 0x66, 0x69, 0x6e, 0x69, 0x74,
+	0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x69, 0x6e, 0x66, 0x69,
+	0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x54,
+	0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x3a, 0x5a,
+	0x38, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x69, 0x6e, 0x66, 0x69,
+	0x6e, 0x69, 0x74, 0x79, 0x2d, 0x64, 0x65, 0x78, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x69, 0x6e, 0x66,
+	0x69, 0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x2f, 0x76, 0x31, 0x3b, 0x69, 0x6e, 0x66, 0x69,
+	0x6e, 0x69, 0x74, 0x79, 0x64, 0x65, 0x78, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_infinitydex_v1_token_proto_rawDescOnce sync.Once
+	file_infinitydex_v1_token_proto_rawDescData = file_infinitydex_v1_token_proto_rawDesc
+)
+
+func file_infinitydex_v1_token_proto_rawDescGZIP() []byte {
+	file_infinitydex_v1_token_proto_rawDescOnce.Do(func() {
+		file_infinitydex_v1_token_proto_rawDescData = protoimpl.X.CompressGZIP(file_infinitydex_v1_token_proto_rawDescData)
+	})
+	return file_infinitydex_v1_token_proto_rawDescData
+}
+
+var file_infinitydex_v1_token_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_infinitydex_v1_token_proto_goTypes = []any{
+	(*ListTokensRequest)(nil),  // 0: infinitydex.v1.ListTokensRequest
+	(*TokenDetails)(nil),       // 1: infinitydex.v1.TokenDetails
+	(*ListTokensResponse)(nil), // 2: infinitydex.v1.ListTokensResponse
+	(*Token)(nil),              // 3: infinitydex.v1.Token
+}
+var file_infinitydex_v1_token_proto_depIdxs = []int32{
+	3, // 0: infinitydex.v1.TokenDetails.token:type_name -> infinitydex.v1.Token
+	1, // 1: infinitydex.v1.ListTokensResponse.tokens:type_name -> infinitydex.v1.TokenDetails
+	0, // 2: infinitydex.v1.TokenService.ListTokens:input_type -> infinitydex.v1.ListTokensRequest
+	2, // 3: infinitydex.v1.TokenService.ListTokens:output_type -> infinitydex.v1.ListTokensResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_infinitydex_v1_token_proto_init() }
+func file_infinitydex_v1_token_proto_init() {
+	if File_infinitydex_v1_token_proto != nil {
+		return
+	}
+	file_infinitydex_v1_types_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_infinitydex_v1_token_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*ListTokensRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_infinitydex_v1_token_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*TokenDetails); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_infinitydex_v1_token_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ListTokensResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_infinitydex_v1_token_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_infinitydex_v1_token_proto_goTypes,
+		DependencyIndexes: file_infinitydex_v1_token_proto_depIdxs,
+		MessageInfos:      file_infinitydex_v1_token_proto_msgTypes,
+	}.Build()
+	File_infinitydex_v1_token_proto = out.File
+	file_infinitydex_v1_token_proto_rawDesc = nil
+	file_infinitydex_v1_token_proto_goTypes = nil
+	file_infinitydex_v1_token_proto_depIdxs = nil
+}
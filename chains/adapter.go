@@ -0,0 +1,107 @@
+// Package chains defines the adapters the DEX submits transactions to its chains through
+package chains
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+// ErrTxReverted is returned for transactions that were mined but reverted
+var ErrTxReverted = errors.New("transaction reverted")
+
+// ErrNoPrivateRelay is returned for private transactions on chains the adapter has no private relay for
+var ErrNoPrivateRelay = errors.New("no private relay for the chain")
+
+// TxRequest is a call to build into a transaction from the adapter's account
+type TxRequest struct {
+	ChainID int64
+	To      string
+	Value   *big.Int // optional, native token sent with the call
+	Data    []byte
+
+	// Private sends the transaction through the chain's private relay rather than the public mempool, where it
+	// could be front-run or sandwiched
+	Private bool
+}
+
+// Tx is a transaction built for a chain; Raw and Hash are set once it is signed
+type Tx struct {
+	ChainID  int64    `json:"chainId"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Nonce    uint64   `json:"nonce"`
+	Value    *big.Int `json:"value"`
+	Data     []byte   `json:"data"`
+	GasLimit uint64   `json:"gasLimit"`
+
+	// Chains with a base fee are charged MaxFeePerGas and MaxPriorityFeePerGas; others are charged GasPrice
+	GasPrice             *big.Int `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas,omitempty"`
+
+	Raw  []byte `json:"raw,omitempty"`
+	Hash string `json:"hash,omitempty"`
+
+	// Private transactions, and their replacements, are only broadcast through the chain's private relay
+	Private bool `json:"private,omitempty"`
+
+	// Replaces holds the hashes of earlier versions of the transaction, with the same nonce and lower fees.
+	// Whichever version is mined carries out the transaction.
+	Replaces []string `json:"replaces,omitempty"`
+}
+
+// Receipt is the outcome of a mined transaction
+type Receipt struct {
+	TxHash            string   `json:"txHash"`
+	BlockNumber       uint64   `json:"blockNumber"`
+	GasUsed           uint64   `json:"gasUsed"`
+	EffectiveGasPrice *big.Int `json:"effectiveGasPrice"`
+	Success           bool     `json:"success"`
+	Logs              []Log    `json:"logs,omitempty"`
+}
+
+// Log is an event emitted by a contract during a mined transaction
+type Log struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    []byte   `json:"data"`
+}
+
+// GasCost returns what the transaction paid for gas, in the chain's native token base units
+func (r Receipt) GasCost() *big.Int {
+	if r.EffectiveGasPrice == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(r.GasUsed), r.EffectiveGasPrice)
+}
+
+// ChainAdapter builds, signs and submits transactions on a family of chains
+type ChainAdapter interface {
+	// BuildTx fills in the nonce, gas limit and fees of a call from the adapter's account
+	BuildTx(ctx context.Context, req TxRequest) (*Tx, error)
+
+	// SignTx signs a built transaction, setting its raw bytes and hash
+	SignTx(ctx context.Context, tx *Tx) (*Tx, error)
+
+	// Broadcast submits a signed transaction and returns its hash
+	Broadcast(ctx context.Context, tx *Tx) (string, error)
+
+	// WaitForReceipt waits until the transaction is mined. Reverted transactions return their receipt and ErrTxReverted.
+	WaitForReceipt(ctx context.Context, chainID int64, hash string) (*Receipt, error)
+
+	// ReplaceTx signs a replacement for a broadcast transaction that is stuck unmined: the same call and nonce with
+	// higher fees. The replacement lists the transaction's hash in its Replaces.
+	ReplaceTx(ctx context.Context, tx *Tx) (*Tx, error)
+
+	// DiscardTx gives back the nonce of a built transaction that will never be broadcast, so the next one reuses it
+	// instead of leaving a gap that would hold up every later transaction
+	DiscardTx(tx *Tx)
+
+	// Balance returns how much of a token an address holds, in the token's base units. An empty token is the chain's
+	// native token.
+	Balance(ctx context.Context, chainID int64, owner, token string) (*big.Int, error)
+
+	// GasCost returns what units of gas cost at the chain's current fees, in its native token's base units
+	GasCost(ctx context.Context, chainID int64, units uint64) (*big.Int, error)
+}
@@ -0,0 +1,99 @@
+package evm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/infinity-dex/chains"
+)
+
+// transferEvent is the ERC-20 Transfer(address,address,uint256) event signature
+var transferEvent = "0x" + hex.EncodeToString(Keccak256([]byte("Transfer(address,address,uint256)")))
+
+// EncodeCall ABI-encodes a call to a function such as "transfer(address,uint256)". Arguments may be address strings,
+// non-negative *big.Int or uint64 integers, bools, and []byte for dynamic bytes.
+func EncodeCall(signature string, args ...interface{}) ([]byte, error) {
+	data, err := EncodeArgs(args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signature, err)
+	}
+	return append(Keccak256([]byte(signature))[:4], data...), nil
+}
+
+// EncodeArgs ABI-encodes values as a tuple, as Solidity's abi.encode does. Dynamic bytes are placed after the
+// static words, each referenced by its offset.
+func EncodeArgs(args ...interface{}) ([]byte, error) {
+	head := make([]byte, 0, 32*len(args))
+	var tail []byte
+	for i, arg := range args {
+		if data, ok := arg.([]byte); ok {
+			head = append(head, padded(big.NewInt(int64(32*len(args)+len(tail))), 32)...)
+			tail = append(tail, padded(big.NewInt(int64(len(data))), 32)...)
+			tail = append(tail, data...)
+			tail = append(tail, make([]byte, (32-len(data)%32)%32)...)
+			continue
+		}
+		word, err := encodeWord(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+		head = append(head, word...)
+	}
+	return append(head, tail...), nil
+}
+
+// encodeWord ABI-encodes a static value into a 32-byte word
+func encodeWord(arg interface{}) ([]byte, error) {
+	switch v := arg.(type) {
+	case string:
+		address, err := decodeAddress(v)
+		if err != nil {
+			return nil, err
+		}
+		return append(make([]byte, 12), address...), nil
+	case *big.Int:
+		if v == nil || v.Sign() < 0 || v.BitLen() > 256 {
+			return nil, fmt.Errorf("integer %v is not a uint256", v)
+		}
+		return padded(v, 32), nil
+	case uint64:
+		return padded(new(big.Int).SetUint64(v), 32), nil
+	case bool:
+		if v {
+			return padded(big.NewInt(1), 32), nil
+		}
+		return make([]byte, 32), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", arg)
+	}
+}
+
+// decodeAddress decodes a 0x-prefixed 20-byte hex address
+func decodeAddress(address string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil || len(raw) != 20 {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	return raw, nil
+}
+
+// TransferredTo sums the ERC-20 Transfer events of token to recipient among a receipt's logs
+func TransferredTo(logs []chains.Log, token, recipient string) *big.Int {
+	total := big.NewInt(0)
+	to, err := decodeAddress(recipient)
+	if err != nil {
+		return total
+	}
+	topic := "0x" + hex.EncodeToString(append(make([]byte, 12), to...))
+	for _, log := range logs {
+		if !strings.EqualFold(log.Address, token) || len(log.Topics) != 3 || len(log.Data) != 32 {
+			continue
+		}
+		if strings.EqualFold(log.Topics[0], transferEvent) && strings.EqualFold(log.Topics[2], topic) {
+			total.Add(total, new(big.Int).SetBytes(log.Data))
+		}
+	}
+	return total
+}
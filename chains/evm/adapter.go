@@ -0,0 +1,386 @@
+// Package evm submits transactions to EVM chains over JSON-RPC, signed by a signer.Signer
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/chains/signer"
+)
+
+const (
+	// defaultPollInterval is how often WaitForReceipt checks whether a transaction was mined
+	defaultPollInterval = 2 * time.Second
+
+	// gasLimitMarginPercent is added to gas estimates, as state can change between the estimate and the transaction
+	gasLimitMarginPercent = 20
+
+	// dynamicFeeTxType is the EIP-2718 type of EIP-1559 transactions
+	dynamicFeeTxType = 0x02
+
+	// replacementBumpPercent raises the fees of replacement transactions. Nodes only accept a replacement paying at
+	// least 10% more than the transaction it replaces.
+	replacementBumpPercent = 15
+)
+
+// RPC sends JSON-RPC requests to a chain, decoding the result into out
+type RPC interface {
+	Invoke(ctx context.Context, chainID int64, method string, params []interface{}, out interface{}) error
+}
+
+// Adapter implements chains.ChainAdapter for EVM chains, sending transactions from a single account
+type Adapter struct {
+	rpc          RPC
+	relays       map[int64]RPC // Private relays by chain ID
+	signer       signer.Signer
+	nonces       *NonceManager
+	pollInterval time.Duration
+}
+
+// NewAdapter creates an adapter sending transactions signed by signer through rpc
+func NewAdapter(rpc RPC, signer signer.Signer) *Adapter {
+	return &Adapter{
+		rpc:          rpc,
+		relays:       make(map[int64]RPC),
+		signer:       signer,
+		nonces:       NewNonceManager(),
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// SetPollInterval sets how often WaitForReceipt checks whether a transaction was mined
+func (a *Adapter) SetPollInterval(interval time.Duration) {
+	a.pollInterval = interval
+}
+
+// SetPrivateRelay sends the chain's private transactions to relay, a Flashbots Protect or MEV Blocker-style
+// endpoint taking eth_sendRawTransaction, which hands them to block builders without putting them in the public
+// mempool
+func (a *Adapter) SetPrivateRelay(chainID int64, relay RPC) {
+	a.relays[chainID] = relay
+}
+
+// Address returns the account the adapter sends transactions from
+func (a *Adapter) Address() string {
+	return a.signer.Address()
+}
+
+// BuildTx fills in the next nonce from the adapter's nonce manager, an estimated gas limit and the chain's current fees. Chains reporting a base fee
+// get an EIP-1559 transaction paying up to twice the base fee plus the suggested priority fee; others a legacy one.
+func (a *Adapter) BuildTx(ctx context.Context, req chains.TxRequest) (*chains.Tx, error) {
+	if _, err := decodeAddress(req.To); err != nil {
+		return nil, err
+	}
+	if _, ok := a.relays[req.ChainID]; req.Private && !ok {
+		return nil, chains.ErrNoPrivateRelay
+	}
+	value := req.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	tx := &chains.Tx{
+		ChainID: req.ChainID,
+		From:    a.signer.Address(),
+		To:      req.To,
+		Value:   value,
+		Data:    req.Data,
+		Private: req.Private,
+	}
+
+	call := map[string]string{
+		"from":  tx.From,
+		"to":    tx.To,
+		"value": quantity(value),
+		"data":  "0x" + hex.EncodeToString(req.Data),
+	}
+	var estimate string
+	if err := a.rpc.Invoke(ctx, req.ChainID, "eth_estimateGas", []interface{}{call}, &estimate); err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	gas, err := parseQuantity(estimate)
+	if err != nil {
+		return nil, err
+	}
+	tx.GasLimit = gas.Uint64() * (100 + gasLimitMarginPercent) / 100
+
+	if tx.GasPrice, tx.MaxFeePerGas, tx.MaxPriorityFeePerGas, err = currentFees(ctx, a.rpc, req.ChainID); err != nil {
+		return nil, err
+	}
+	// The nonce is taken last, so a build that fails never holds one
+	if tx.Nonce, err = a.nonces.Next(ctx, a.rpc, req.ChainID, tx.From); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// DiscardTx gives the nonce of a built transaction back to the nonce manager
+func (a *Adapter) DiscardTx(tx *chains.Tx) {
+	a.nonces.Release(tx.ChainID, tx.From, tx.Nonce)
+}
+
+// ReplaceTx signs the transaction again with its nonce and fees raised by replacementBumpPercent, or to the chain's
+// current fees when those are higher
+func (a *Adapter) ReplaceTx(ctx context.Context, tx *chains.Tx) (*chains.Tx, error) {
+	if tx.Hash == "" {
+		return nil, errors.New("transaction is not signed")
+	}
+	gasPrice, maxFee, priorityFee, err := currentFees(ctx, a.rpc, tx.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := *tx
+	replacement.Raw, replacement.Hash = nil, ""
+	replacement.Replaces = append(append([]string(nil), tx.Replaces...), tx.Hash)
+	if tx.MaxFeePerGas != nil {
+		replacement.MaxFeePerGas = maxAmount(bumpFee(tx.MaxFeePerGas), maxFee)
+		replacement.MaxPriorityFeePerGas = maxAmount(bumpFee(tx.MaxPriorityFeePerGas), priorityFee)
+	} else {
+		replacement.GasPrice = maxAmount(bumpFee(tx.GasPrice), gasPrice)
+	}
+	return a.SignTx(ctx, &replacement)
+}
+
+// bumpFee raises a fee by replacementBumpPercent, rounding up
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+replacementBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// maxAmount returns the higher of two amounts; a nil amount is ignored
+func maxAmount(a, b *big.Int) *big.Int {
+	if b == nil || a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// currentFees returns the chain's gas price when it has no base fee. Otherwise it returns a fee cap of twice the base
+// fee plus the suggested priority fee, and that priority fee.
+func currentFees(ctx context.Context, rpc RPC, chainID int64) (gasPrice, maxFee, priorityFee *big.Int, err error) {
+	var block struct {
+		BaseFeePerGas string `json:"baseFeePerGas"`
+	}
+	if err := rpc.Invoke(ctx, chainID, "eth_getBlockByNumber", []interface{}{"latest", false}, &block); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	if block.BaseFeePerGas == "" {
+		var price string
+		if err := rpc.Invoke(ctx, chainID, "eth_gasPrice", []interface{}{}, &price); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get gas price: %w", err)
+		}
+		gasPrice, err = parseQuantity(price)
+		return gasPrice, nil, nil, err
+	}
+
+	baseFee, err := parseQuantity(block.BaseFeePerGas)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var tip string
+	if err := rpc.Invoke(ctx, chainID, "eth_maxPriorityFeePerGas", []interface{}{}, &tip); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get priority fee: %w", err)
+	}
+	if priorityFee, err = parseQuantity(tip); err != nil {
+		return nil, nil, nil, err
+	}
+	return nil, new(big.Int).Add(new(big.Int).Lsh(baseFee, 1), priorityFee), priorityFee, nil
+}
+
+// balanceOfFunction is the ERC-20 function token balances are read with
+const balanceOfFunction = "balanceOf(address)"
+
+// Balance returns owner's balance of token at the latest block, read with eth_getBalance for the native token and
+// balanceOf otherwise
+func (a *Adapter) Balance(ctx context.Context, chainID int64, owner, token string) (*big.Int, error) {
+	if _, err := decodeAddress(owner); err != nil {
+		return nil, err
+	}
+	var result string
+	if token == "" {
+		if err := a.rpc.Invoke(ctx, chainID, "eth_getBalance", []interface{}{owner, "latest"}, &result); err != nil {
+			return nil, fmt.Errorf("failed to get balance: %w", err)
+		}
+		return parseQuantity(result)
+	}
+
+	data, err := EncodeCall(balanceOfFunction, owner)
+	if err != nil {
+		return nil, err
+	}
+	call := map[string]string{
+		"to":   token,
+		"data": "0x" + hex.EncodeToString(data),
+	}
+	if err := a.rpc.Invoke(ctx, chainID, "eth_call", []interface{}{call, "latest"}, &result); err != nil {
+		return nil, fmt.Errorf("failed to get token balance: %w", err)
+	}
+	balance, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil || len(balance) < 32 {
+		return nil, fmt.Errorf("invalid balance %q of token %s", result, token)
+	}
+	return new(big.Int).SetBytes(balance[:32]), nil
+}
+
+// GasCost prices units of gas at the fee cap BuildTx would set, or the gas price on chains without a base fee, so
+// it is the most the gas can cost
+func (a *Adapter) GasCost(ctx context.Context, chainID int64, units uint64) (*big.Int, error) {
+	gasPrice, maxFee, _, err := currentFees(ctx, a.rpc, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if maxFee != nil {
+		gasPrice = maxFee
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(units), gasPrice), nil
+}
+
+// SignTx signs an EIP-1559 transaction, or a legacy one with EIP-155 replay protection when it has no fee cap
+func (a *Adapter) SignTx(ctx context.Context, tx *chains.Tx) (*chains.Tx, error) {
+	if !strings.EqualFold(tx.From, a.signer.Address()) {
+		return nil, fmt.Errorf("transaction is from %s, not the adapter's account", tx.From)
+	}
+	to, err := decodeAddress(tx.To)
+	if err != nil {
+		return nil, err
+	}
+	chainID := big.NewInt(tx.ChainID)
+	signed := *tx
+
+	if tx.MaxFeePerGas != nil {
+		fields := rlpList{chainID, tx.Nonce, tx.MaxPriorityFeePerGas, tx.MaxFeePerGas, tx.GasLimit, to, tx.Value, tx.Data, rlpList{}}
+		payload, err := rlpEncode(fields)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := a.signer.SignDigest(ctx, Keccak256([]byte{dynamicFeeTxType}, payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+		payload, err = rlpEncode(append(fields, uint64(signature.Recovery), signature.R, signature.S))
+		if err != nil {
+			return nil, err
+		}
+		signed.Raw = append([]byte{dynamicFeeTxType}, payload...)
+	} else {
+		payload, err := rlpEncode(rlpList{tx.Nonce, tx.GasPrice, tx.GasLimit, to, tx.Value, tx.Data, chainID, uint64(0), uint64(0)})
+		if err != nil {
+			return nil, err
+		}
+		signature, err := a.signer.SignDigest(ctx, Keccak256(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+		v := new(big.Int).Add(new(big.Int).Lsh(chainID, 1), big.NewInt(35+int64(signature.Recovery)))
+		if signed.Raw, err = rlpEncode(rlpList{tx.Nonce, tx.GasPrice, tx.GasLimit, to, tx.Value, tx.Data, v, signature.R, signature.S}); err != nil {
+			return nil, err
+		}
+	}
+	signed.Hash = "0x" + hex.EncodeToString(Keccak256(signed.Raw))
+	return &signed, nil
+}
+
+// Broadcast submits a signed transaction with eth_sendRawTransaction, to the chain's private relay when it is private
+func (a *Adapter) Broadcast(ctx context.Context, tx *chains.Tx) (string, error) {
+	if len(tx.Raw) == 0 {
+		return "", errors.New("transaction is not signed")
+	}
+	rpc := a.rpc
+	if tx.Private {
+		// Never fall back to the public mempool, which would expose the transaction the relay is meant to hide
+		relay, ok := a.relays[tx.ChainID]
+		if !ok {
+			return "", chains.ErrNoPrivateRelay
+		}
+		rpc = relay
+	}
+	var hash string
+	if err := rpc.Invoke(ctx, tx.ChainID, "eth_sendRawTransaction", []interface{}{"0x" + hex.EncodeToString(tx.Raw)}, &hash); err != nil {
+		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	return hash, nil
+}
+
+// WaitForReceipt polls for the transaction's receipt until it is mined or ctx is done
+func (a *Adapter) WaitForReceipt(ctx context.Context, chainID int64, hash string) (*chains.Receipt, error) {
+	for {
+		var result *struct {
+			BlockNumber       string   `json:"blockNumber"`
+			GasUsed           string   `json:"gasUsed"`
+			EffectiveGasPrice string   `json:"effectiveGasPrice"`
+			Status            string   `json:"status"`
+			Logs              []rpcLog `json:"logs"`
+		}
+		if err := a.rpc.Invoke(ctx, chainID, "eth_getTransactionReceipt", []interface{}{hash}, &result); err != nil {
+			return nil, fmt.Errorf("failed to get receipt: %w", err)
+		}
+		if result != nil && result.BlockNumber != "" {
+			receipt := &chains.Receipt{TxHash: hash, Success: result.Status == "0x1"}
+			block, err := parseQuantity(result.BlockNumber)
+			if err != nil {
+				return nil, err
+			}
+			receipt.BlockNumber = block.Uint64()
+			if gasUsed, err := parseQuantity(result.GasUsed); err == nil {
+				receipt.GasUsed = gasUsed.Uint64()
+			}
+			if price, err := parseQuantity(result.EffectiveGasPrice); err == nil {
+				receipt.EffectiveGasPrice = price
+			}
+			if receipt.Logs, err = decodeLogs(result.Logs); err != nil {
+				return nil, err
+			}
+			if !receipt.Success {
+				return receipt, chains.ErrTxReverted
+			}
+			return receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(a.pollInterval):
+		}
+	}
+}
+
+// rpcLog is a log as JSON-RPC receipts report it
+type rpcLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// decodeLogs decodes the logs of a JSON-RPC receipt
+func decodeLogs(logs []rpcLog) ([]chains.Log, error) {
+	var decoded []chains.Log
+	for _, log := range logs {
+		data, err := hex.DecodeString(strings.TrimPrefix(log.Data, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log data: %w", err)
+		}
+		decoded = append(decoded, chains.Log{Address: log.Address, Topics: log.Topics, Data: data})
+	}
+	return decoded, nil
+}
+
+// parseQuantity parses a JSON-RPC hex quantity such as 0x1a
+func parseQuantity(value string) (*big.Int, error) {
+	quantity, ok := new(big.Int).SetString(strings.TrimPrefix(value, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid RPC quantity %q", value)
+	}
+	return quantity, nil
+}
+
+// quantity formats an integer as a JSON-RPC hex quantity
+func quantity(value *big.Int) string {
+	return fmt.Sprintf("0x%x", value)
+}
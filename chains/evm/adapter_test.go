@@ -0,0 +1,461 @@
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/chains/signer"
+)
+
+// eip155Key is the private key of the example transaction in EIP-155
+const eip155Key = "4646464646464646464646464646464646464646464646464646464646464646"
+
+// fakeRPC answers JSON-RPC methods with canned results, recording the calls it receives
+type fakeRPC struct {
+	results map[string]interface{}
+	calls   []string
+	params  map[string][]interface{}
+}
+
+func (f *fakeRPC) Invoke(ctx context.Context, chainID int64, method string, params []interface{}, out interface{}) error {
+	f.calls = append(f.calls, method)
+	if f.params == nil {
+		f.params = make(map[string][]interface{})
+	}
+	f.params[method] = params
+	result, ok := f.results[method]
+	if !ok {
+		return errors.New("unexpected method " + method)
+	}
+	if err, ok := result.(error); ok {
+		return err
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func TestSignTx(t *testing.T) {
+	key, err := signer.NewPrivateKeySigner(eip155Key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	adapter := NewAdapter(&fakeRPC{}, key)
+
+	t.Run("Legacy", func(t *testing.T) {
+		value, _ := new(big.Int).SetString("1000000000000000000", 10)
+		signed, err := adapter.SignTx(context.Background(), &chains.Tx{
+			ChainID:  1,
+			From:     key.Address(),
+			To:       "0x3535353535353535353535353535353535353535",
+			Nonce:    9,
+			Value:    value,
+			GasLimit: 21000,
+			GasPrice: big.NewInt(20_000_000_000),
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+		if hex.EncodeToString(signed.Raw) != expected {
+			t.Errorf("Expected EIP-155 example transaction, got %x", signed.Raw)
+		}
+		if signed.Hash != "0x33469b22e9f636356c4160a87eb19df52b7412e8eac32a4a55ffe88ea8350788" {
+			t.Errorf("Unexpected hash %s", signed.Hash)
+		}
+	})
+
+	t.Run("DynamicFee", func(t *testing.T) {
+		tx := &chains.Tx{
+			ChainID:              1,
+			From:                 key.Address(),
+			To:                   "0x3535353535353535353535353535353535353535",
+			Value:                big.NewInt(0),
+			Data:                 []byte{0xde, 0xad},
+			GasLimit:             50000,
+			MaxFeePerGas:         big.NewInt(40_000_000_000),
+			MaxPriorityFeePerGas: big.NewInt(1_000_000_000),
+		}
+		signed, err := adapter.SignTx(context.Background(), tx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if signed.Raw[0] != dynamicFeeTxType {
+			t.Errorf("Expected typed transaction, got prefix %x", signed.Raw[0])
+		}
+		again, _ := adapter.SignTx(context.Background(), tx)
+		if again.Hash != signed.Hash {
+			t.Error("Expected deterministic signatures")
+		}
+		if len(tx.Raw) != 0 {
+			t.Error("Expected the unsigned transaction to be left unchanged")
+		}
+	})
+
+	t.Run("OtherAccount", func(t *testing.T) {
+		_, err := adapter.SignTx(context.Background(), &chains.Tx{
+			ChainID:  1,
+			From:     "0x3535353535353535353535353535353535353535",
+			To:       "0x3535353535353535353535353535353535353535",
+			GasPrice: big.NewInt(1),
+		})
+		if err == nil {
+			t.Error("Expected error signing for another account")
+		}
+	})
+}
+
+func TestBuildTx(t *testing.T) {
+	key, _ := signer.NewPrivateKeySigner(eip155Key)
+
+	t.Run("DynamicFee", func(t *testing.T) {
+		rpc := &fakeRPC{results: map[string]interface{}{
+			"eth_getTransactionCount":  "0x7",
+			"eth_estimateGas":          "0x186a0",
+			"eth_getBlockByNumber":     map[string]string{"baseFeePerGas": "0x3b9aca00"},
+			"eth_maxPriorityFeePerGas": "0x77359400",
+		}}
+		tx, err := NewAdapter(rpc, key).BuildTx(context.Background(), chains.TxRequest{
+			ChainID: 1,
+			To:      "0x3535353535353535353535353535353535353535",
+			Data:    []byte{0x01},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if tx.Nonce != 7 || tx.GasLimit != 120000 {
+			t.Errorf("Expected nonce 7 and gas limit 120000, got %d and %d", tx.Nonce, tx.GasLimit)
+		}
+		if tx.MaxFeePerGas.Int64() != 4_000_000_000 || tx.MaxPriorityFeePerGas.Int64() != 2_000_000_000 {
+			t.Errorf("Unexpected fees %s and %s", tx.MaxFeePerGas, tx.MaxPriorityFeePerGas)
+		}
+		if tx.GasPrice != nil {
+			t.Error("Expected no legacy gas price")
+		}
+		if call := rpc.params["eth_estimateGas"][0].(map[string]string); call["from"] != key.Address() || call["data"] != "0x01" {
+			t.Errorf("Unexpected estimate call %v", call)
+		}
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		rpc := &fakeRPC{results: map[string]interface{}{
+			"eth_getTransactionCount": "0x0",
+			"eth_estimateGas":         "0x5208",
+			"eth_getBlockByNumber":    map[string]string{},
+			"eth_gasPrice":            "0x12a05f200",
+		}}
+		tx, err := NewAdapter(rpc, key).BuildTx(context.Background(), chains.TxRequest{
+			ChainID: 56,
+			To:      "0x3535353535353535353535353535353535353535",
+			Value:   big.NewInt(1),
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if tx.GasPrice.Int64() != 5_000_000_000 || tx.MaxFeePerGas != nil {
+			t.Errorf("Expected a legacy gas price, got %v and %v", tx.GasPrice, tx.MaxFeePerGas)
+		}
+	})
+
+	t.Run("Nonces", func(t *testing.T) {
+		rpc := &fakeRPC{results: map[string]interface{}{
+			"eth_getTransactionCount":  "0x7",
+			"eth_estimateGas":          "0x5208",
+			"eth_getBlockByNumber":     map[string]string{"baseFeePerGas": "0x64"},
+			"eth_maxPriorityFeePerGas": "0xa",
+		}}
+		adapter := NewAdapter(rpc, key)
+		req := chains.TxRequest{ChainID: 1, To: "0x3535353535353535353535353535353535353535"}
+		build := func() uint64 {
+			tx, err := adapter.BuildTx(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			return tx.Nonce
+		}
+
+		// Transactions built before either is broadcast get their own nonces
+		first, second, third := build(), build(), build()
+		if first != 7 || second != 8 || third != 9 {
+			t.Fatalf("Expected nonces 7, 8 and 9, got %d, %d and %d", first, second, third)
+		}
+
+		// Discarded nonces are reused, lowest first
+		adapter.DiscardTx(&chains.Tx{ChainID: 1, From: key.Address(), Nonce: 8})
+		adapter.DiscardTx(&chains.Tx{ChainID: 1, From: key.Address(), Nonce: 9})
+		if nonce := build(); nonce != 8 {
+			t.Errorf("Expected the discarded nonce 8, got %d", nonce)
+		}
+		if nonce := build(); nonce != 9 {
+			t.Errorf("Expected the discarded nonce 9, got %d", nonce)
+		}
+
+		// The chain's pending nonce wins once other transactions were broadcast
+		rpc.results["eth_getTransactionCount"] = "0x14"
+		if nonce := build(); nonce != 20 {
+			t.Errorf("Expected the pending nonce 20, got %d", nonce)
+		}
+	})
+
+	t.Run("InvalidAddress", func(t *testing.T) {
+		if _, err := NewAdapter(&fakeRPC{}, key).BuildTx(context.Background(), chains.TxRequest{ChainID: 1, To: "0x12"}); err == nil {
+			t.Error("Expected error for an invalid address")
+		}
+	})
+}
+
+func TestReplaceTx(t *testing.T) {
+	key, _ := signer.NewPrivateKeySigner(eip155Key)
+	rpc := &fakeRPC{results: map[string]interface{}{
+		"eth_getBlockByNumber":     map[string]string{"baseFeePerGas": "0x64"},
+		"eth_maxPriorityFeePerGas": "0xa",
+	}}
+	adapter := NewAdapter(rpc, key)
+	tx, err := adapter.SignTx(context.Background(), &chains.Tx{
+		ChainID:              1,
+		From:                 key.Address(),
+		To:                   "0x3535353535353535353535353535353535353535",
+		Nonce:                4,
+		Value:                big.NewInt(0),
+		GasLimit:             21000,
+		MaxFeePerGas:         big.NewInt(1000),
+		MaxPriorityFeePerGas: big.NewInt(100),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	replacement, err := adapter.ReplaceTx(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if replacement.Nonce != 4 || replacement.Hash == tx.Hash || len(replacement.Replaces) != 1 || replacement.Replaces[0] != tx.Hash {
+		t.Errorf("Expected a replacement with the same nonce listing the original, got %+v", replacement)
+	}
+	if replacement.MaxFeePerGas.Int64() != 1150 || replacement.MaxPriorityFeePerGas.Int64() != 115 {
+		t.Errorf("Expected fees raised 15%%, got %s and %s", replacement.MaxFeePerGas, replacement.MaxPriorityFeePerGas)
+	}
+
+	// Fees rise to the chain's current fees when those are higher
+	rpc.results["eth_getBlockByNumber"] = map[string]string{"baseFeePerGas": "0x3e8"}
+	rpc.results["eth_maxPriorityFeePerGas"] = "0xc8"
+	again, err := adapter.ReplaceTx(context.Background(), replacement)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if again.MaxFeePerGas.Int64() != 2200 || again.MaxPriorityFeePerGas.Int64() != 200 || len(again.Replaces) != 2 {
+		t.Errorf("Expected the current fees, got %s and %s replacing %v", again.MaxFeePerGas, again.MaxPriorityFeePerGas, again.Replaces)
+	}
+}
+
+func TestBalanceAndGasCost(t *testing.T) {
+	key, _ := signer.NewPrivateKeySigner(eip155Key)
+	owner := "0x3535353535353535353535353535353535353535"
+	token := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	rpc := &fakeRPC{results: map[string]interface{}{
+		"eth_getBalance":           "0xde0b6b3a7640000",
+		"eth_call":                 "0x" + hex.EncodeToString(padded(big.NewInt(2_500_000), 32)),
+		"eth_getBlockByNumber":     map[string]string{"baseFeePerGas": "0x3b9aca00"},
+		"eth_maxPriorityFeePerGas": "0x77359400",
+	}}
+	adapter := NewAdapter(rpc, key)
+
+	native, err := adapter.Balance(context.Background(), 1, owner, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if native.String() != "1000000000000000000" {
+		t.Errorf("Expected a native balance of 1e18, got %s", native)
+	}
+
+	balance, err := adapter.Balance(context.Background(), 1, owner, token)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if balance.Int64() != 2_500_000 {
+		t.Errorf("Expected a token balance of 2500000, got %s", balance)
+	}
+	call := rpc.params["eth_call"][0].(map[string]string)
+	if call["to"] != token || call["data"][:10] != "0x70a08231" {
+		t.Errorf("Expected a balanceOf call to the token, got %v", call)
+	}
+
+	// Priced at the fee cap: twice the 1 gwei base fee plus the 2 gwei tip
+	cost, err := adapter.GasCost(context.Background(), 1, 21000)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cost.Int64() != 21000*4_000_000_000 {
+		t.Errorf("Expected a gas cost of %d, got %s", 21000*4_000_000_000, cost)
+	}
+
+	if _, err := adapter.Balance(context.Background(), 1, "0x12", ""); err == nil {
+		t.Error("Expected an invalid owner to be refused")
+	}
+}
+
+func TestBroadcastAndWait(t *testing.T) {
+	const (
+		token     = "0x3333333333333333333333333333333333333333"
+		dex       = "0x2222222222222222222222222222222222222222"
+		recipient = "0x1234567890abcdef1234567890abcdef12345678"
+	)
+	key, _ := signer.NewPrivateKeySigner(eip155Key)
+	rpc := &fakeRPC{results: map[string]interface{}{
+		"eth_sendRawTransaction": "0xabc",
+		"eth_getTransactionReceipt": map[string]interface{}{
+			"blockNumber":       "0x10",
+			"gasUsed":           "0x5208",
+			"effectiveGasPrice": "0x3b9aca00",
+			"status":            "0x1",
+			"logs": []map[string]interface{}{{
+				"address": token,
+				"topics": []string{
+					transferEvent,
+					"0x000000000000000000000000" + dex[2:],
+					"0x000000000000000000000000" + recipient[2:],
+				},
+				"data": "0x00000000000000000000000000000000000000000000000000000000000003e8",
+			}},
+		},
+	}}
+	adapter := NewAdapter(rpc, key)
+
+	if _, err := adapter.Broadcast(context.Background(), &chains.Tx{ChainID: 1}); err == nil {
+		t.Error("Expected error broadcasting an unsigned transaction")
+	}
+	hash, err := adapter.Broadcast(context.Background(), &chains.Tx{ChainID: 1, Raw: []byte{0x01}})
+	if err != nil || hash != "0xabc" {
+		t.Fatalf("Expected hash 0xabc, got %q, %v", hash, err)
+	}
+	if rpc.params["eth_sendRawTransaction"][0] != "0x01" {
+		t.Errorf("Unexpected raw transaction %v", rpc.params["eth_sendRawTransaction"])
+	}
+
+	receipt, err := adapter.WaitForReceipt(context.Background(), 1, hash)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if receipt.BlockNumber != 16 || receipt.GasCost().Int64() != 21000*1_000_000_000 {
+		t.Errorf("Unexpected receipt %+v", receipt)
+	}
+	if received := TransferredTo(receipt.Logs, token, recipient); received.Int64() != 1000 {
+		t.Errorf("Expected 1000 transferred to the recipient, got %s", received)
+	}
+	if received := TransferredTo(receipt.Logs, token, dex); received.Sign() != 0 {
+		t.Errorf("Expected nothing transferred to the DEX, got %s", received)
+	}
+
+	t.Run("Reverted", func(t *testing.T) {
+		rpc.results["eth_getTransactionReceipt"] = map[string]string{"blockNumber": "0x10", "status": "0x0"}
+		if _, err := adapter.WaitForReceipt(context.Background(), 1, hash); !errors.Is(err, chains.ErrTxReverted) {
+			t.Errorf("Expected ErrTxReverted, got %v", err)
+		}
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		rpc.results["eth_getTransactionReceipt"] = nil
+		adapter.SetPollInterval(time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := adapter.WaitForReceipt(ctx, 1, hash); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	})
+}
+
+func TestPrivateBroadcast(t *testing.T) {
+	key, _ := signer.NewPrivateKeySigner(eip155Key)
+	rpc := &fakeRPC{results: map[string]interface{}{
+		"eth_getTransactionCount":  "0x0",
+		"eth_estimateGas":          "0x5208",
+		"eth_getBlockByNumber":     map[string]string{"baseFeePerGas": "0x3b9aca00"},
+		"eth_maxPriorityFeePerGas": "0x77359400",
+		"eth_sendRawTransaction":   "0xpublic",
+	}}
+	relay := &fakeRPC{results: map[string]interface{}{"eth_sendRawTransaction": "0xprivate"}}
+	adapter := NewAdapter(rpc, key)
+	request := chains.TxRequest{ChainID: 1, To: "0x3535353535353535353535353535353535353535", Private: true}
+
+	if _, err := adapter.BuildTx(context.Background(), request); !errors.Is(err, chains.ErrNoPrivateRelay) {
+		t.Fatalf("Expected ErrNoPrivateRelay without a relay, got %v", err)
+	}
+	if _, err := adapter.Broadcast(context.Background(), &chains.Tx{ChainID: 1, Raw: []byte{0x01}, Private: true}); !errors.Is(err, chains.ErrNoPrivateRelay) {
+		t.Errorf("Expected ErrNoPrivateRelay broadcasting without a relay, got %v", err)
+	}
+
+	adapter.SetPrivateRelay(1, relay)
+	tx, err := adapter.BuildTx(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !tx.Private {
+		t.Fatal("Expected a private transaction")
+	}
+	signed, err := adapter.SignTx(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	replacement, err := adapter.ReplaceTx(context.Background(), signed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, tx := range []*chains.Tx{signed, replacement} {
+		if hash, err := adapter.Broadcast(context.Background(), tx); err != nil || hash != "0xprivate" {
+			t.Errorf("Expected the relay's hash, got %q, %v", hash, err)
+		}
+	}
+	for _, call := range rpc.calls {
+		if call == "eth_sendRawTransaction" {
+			t.Error("Private transaction sent to the public RPC")
+		}
+	}
+
+	if hash, err := adapter.Broadcast(context.Background(), &chains.Tx{ChainID: 1, Raw: []byte{0x01}}); err != nil || hash != "0xpublic" {
+		t.Errorf("Expected public transactions to go to the chain's RPC, got %q, %v", hash, err)
+	}
+}
+
+func TestEncodeCall(t *testing.T) {
+	data, err := EncodeCall("transfer(address,uint256)", "0x3535353535353535353535353535353535353535", big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "a9059cbb" +
+		"0000000000000000000000003535353535353535353535353535353535353535" +
+		"0000000000000000000000000000000000000000000000000000000000000001"
+	if hex.EncodeToString(data) != expected {
+		t.Errorf("Unexpected calldata %x", data)
+	}
+
+	t.Run("DynamicBytes", func(t *testing.T) {
+		data, err := EncodeCall("execute(address,uint256,bytes)", "0x3535353535353535353535353535353535353535", uint64(0), []byte{0xa9, 0x05, 0x9c, 0xbb})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := "b61d27f6" +
+			"0000000000000000000000003535353535353535353535353535353535353535" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000060" +
+			"0000000000000000000000000000000000000000000000000000000000000004" +
+			"a9059cbb00000000000000000000000000000000000000000000000000000000"
+		if hex.EncodeToString(data) != expected {
+			t.Errorf("Unexpected calldata %x", data)
+		}
+	})
+
+	if _, err := EncodeCall("transfer(address,uint256)", "0x35", big.NewInt(1)); err == nil {
+		t.Error("Expected error for an invalid address")
+	}
+	if _, err := EncodeCall("f(uint256)", big.NewInt(-1)); err == nil {
+		t.Error("Expected error for a negative integer")
+	}
+}
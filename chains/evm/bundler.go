@@ -0,0 +1,271 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/chains"
+)
+
+const (
+	// EntryPointV07 is the ERC-4337 v0.7 EntryPoint contract, deployed at the same address on every chain
+	EntryPointV07 = "0x0000000071727De22E5E9d8BAf0edAc6f37da032"
+
+	// executeFunction is the call a smart account makes on its owner's behalf, as in the reference SimpleAccount
+	executeFunction = "execute(address,uint256,bytes)"
+
+	// getNonceFunction returns an account's next EntryPoint nonce for a nonce key
+	getNonceFunction = "getNonce(address,uint192)"
+)
+
+// dummySignature stands in for the owner's signature while a user operation's gas is estimated. Accounts reject it
+// without reverting, so validation is estimated at its full cost.
+var dummySignature, _ = hex.DecodeString("fffffffffffffffffffffffffffffff0000000000000000000000000000000007aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa1c")
+
+// delegationPrefix starts the code of EIP-7702 accounts, which delegate to a contract but are still key-controlled
+var delegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// Bundler implements chains.UserOperationSender for EVM chains, reading accounts and fees from each chain's nodes
+// and submitting user operations to each chain's ERC-4337 bundler
+type Bundler struct {
+	chain        RPC
+	bundler      RPC
+	entryPoint   string
+	pollInterval time.Duration
+}
+
+// NewBundler creates a bundler client reading chain state through chain and submitting user operations to the
+// EntryPoint v0.7 through bundler
+func NewBundler(chain, bundler RPC) *Bundler {
+	return &Bundler{
+		chain:        chain,
+		bundler:      bundler,
+		entryPoint:   EntryPointV07,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// SetPollInterval sets how often WaitForUserOperation checks whether an operation was included
+func (b *Bundler) SetPollInterval(interval time.Duration) {
+	b.pollInterval = interval
+}
+
+// AccountType reports an address as a smart account when contract code is deployed at it
+func (b *Bundler) AccountType(ctx context.Context, chainID int64, address string) (chains.AccountType, error) {
+	if _, err := decodeAddress(address); err != nil {
+		return "", err
+	}
+	var result string
+	if err := b.chain.Invoke(ctx, chainID, "eth_getCode", []interface{}{address, "latest"}, &result); err != nil {
+		return "", fmt.Errorf("failed to get code: %w", err)
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid code: %w", err)
+	}
+	if len(code) == 0 || (len(code) == 23 && bytes.HasPrefix(code, delegationPrefix)) {
+		return chains.AccountTypeEOA, nil
+	}
+	return chains.AccountTypeSmart, nil
+}
+
+// BuildUserOperation builds an unsigned user operation calling execute on sender's account. Its gas limits are the
+// bundler's estimates with a margin; chains without a base fee pay their gas price as both fee fields.
+func (b *Bundler) BuildUserOperation(ctx context.Context, sender string, req chains.TxRequest) (*chains.UserOperation, error) {
+	if _, err := decodeAddress(sender); err != nil {
+		return nil, err
+	}
+	callData, err := AccountCallData(req)
+	if err != nil {
+		return nil, err
+	}
+	op := &chains.UserOperation{Sender: sender, CallData: callData, Signature: dummySignature}
+
+	nonceCall, err := EncodeCall(getNonceFunction, sender, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	var nonce string
+	call := map[string]string{"to": b.entryPoint, "data": "0x" + hex.EncodeToString(nonceCall)}
+	if err := b.chain.Invoke(ctx, req.ChainID, "eth_call", []interface{}{call, "latest"}, &nonce); err != nil {
+		return nil, fmt.Errorf("failed to get account nonce: %w", err)
+	}
+	if op.Nonce, err = parseQuantity(nonce); err != nil {
+		return nil, err
+	}
+
+	gasPrice, maxFee, priorityFee, err := currentFees(ctx, b.chain, req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	if gasPrice != nil {
+		maxFee, priorityFee = gasPrice, gasPrice
+	}
+	op.MaxFeePerGas, op.MaxPriorityFeePerGas = maxFee, priorityFee
+
+	var estimate struct {
+		PreVerificationGas   string `json:"preVerificationGas"`
+		VerificationGasLimit string `json:"verificationGasLimit"`
+		CallGasLimit         string `json:"callGasLimit"`
+	}
+	if err := b.bundler.Invoke(ctx, req.ChainID, "eth_estimateUserOperationGas", []interface{}{op, b.entryPoint}, &estimate); err != nil {
+		return nil, fmt.Errorf("failed to estimate user operation gas: %w", err)
+	}
+	if op.PreVerificationGas, err = parseQuantity(estimate.PreVerificationGas); err != nil {
+		return nil, err
+	}
+	if op.VerificationGasLimit, err = parseQuantity(estimate.VerificationGasLimit); err != nil {
+		return nil, err
+	}
+	if op.CallGasLimit, err = parseQuantity(estimate.CallGasLimit); err != nil {
+		return nil, err
+	}
+	op.VerificationGasLimit = withGasMargin(op.VerificationGasLimit)
+	op.CallGasLimit = withGasMargin(op.CallGasLimit)
+
+	op.Signature = nil
+	return op, nil
+}
+
+// UserOperationHash returns the EntryPoint v0.7 hash of an operation on a chain, which the account's owner signs
+func (b *Bundler) UserOperationHash(chainID int64, op *chains.UserOperation) ([]byte, error) {
+	var initCode []byte
+	if op.Factory != "" {
+		factory, err := decodeAddress(op.Factory)
+		if err != nil {
+			return nil, err
+		}
+		initCode = append(factory, op.FactoryData...)
+	}
+	var paymasterAndData []byte
+	if op.Paymaster != "" {
+		paymaster, err := decodeAddress(op.Paymaster)
+		if err != nil {
+			return nil, err
+		}
+		paymasterAndData = append(paymaster, padded(orZero(op.PaymasterVerificationGasLimit), 16)...)
+		paymasterAndData = append(paymasterAndData, padded(orZero(op.PaymasterPostOpGasLimit), 16)...)
+		paymasterAndData = append(paymasterAndData, op.PaymasterData...)
+	}
+	// Gas limits and fees are packed in pairs of 128-bit values
+	accountGasLimits := append(padded(orZero(op.VerificationGasLimit), 16), padded(orZero(op.CallGasLimit), 16)...)
+	gasFees := append(padded(orZero(op.MaxPriorityFeePerGas), 16), padded(orZero(op.MaxFeePerGas), 16)...)
+
+	packed, err := EncodeArgs(
+		op.Sender,
+		orZero(op.Nonce),
+		bytes32(Keccak256(initCode)),
+		bytes32(Keccak256(op.CallData)),
+		bytes32(accountGasLimits),
+		orZero(op.PreVerificationGas),
+		bytes32(gasFees),
+		bytes32(Keccak256(paymasterAndData)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user operation: %w", err)
+	}
+	encoded, err := EncodeArgs(bytes32(Keccak256(packed)), b.entryPoint, uint64(chainID))
+	if err != nil {
+		return nil, err
+	}
+	return Keccak256(encoded), nil
+}
+
+// SendUserOperation submits a signed user operation to the chain's bundler
+func (b *Bundler) SendUserOperation(ctx context.Context, chainID int64, op *chains.UserOperation) (string, error) {
+	if len(op.Signature) == 0 {
+		return "", fmt.Errorf("user operation is not signed")
+	}
+	var hash string
+	if err := b.bundler.Invoke(ctx, chainID, "eth_sendUserOperation", []interface{}{op, b.entryPoint}, &hash); err != nil {
+		return "", fmt.Errorf("failed to send user operation: %w", err)
+	}
+	return hash, nil
+}
+
+// WaitForUserOperation polls the bundler until the operation is included or ctx is done. The receipt reports the
+// bundle transaction, the gas the operation was charged and the operation's own logs.
+func (b *Bundler) WaitForUserOperation(ctx context.Context, chainID int64, hash string) (*chains.Receipt, error) {
+	for {
+		var result *struct {
+			Success       bool     `json:"success"`
+			ActualGasUsed string   `json:"actualGasUsed"`
+			ActualGasCost string   `json:"actualGasCost"`
+			Logs          []rpcLog `json:"logs"`
+			Receipt       struct {
+				TransactionHash string `json:"transactionHash"`
+				BlockNumber     string `json:"blockNumber"`
+			} `json:"receipt"`
+		}
+		if err := b.bundler.Invoke(ctx, chainID, "eth_getUserOperationReceipt", []interface{}{hash}, &result); err != nil {
+			return nil, fmt.Errorf("failed to get user operation receipt: %w", err)
+		}
+		if result != nil {
+			receipt := &chains.Receipt{TxHash: result.Receipt.TransactionHash, Success: result.Success}
+			block, err := parseQuantity(result.Receipt.BlockNumber)
+			if err != nil {
+				return nil, err
+			}
+			receipt.BlockNumber = block.Uint64()
+			// The EntryPoint charges the operation's gas at a single price, so the cost divides exactly
+			gasUsed, err := parseQuantity(result.ActualGasUsed)
+			if err != nil {
+				return nil, err
+			}
+			gasCost, err := parseQuantity(result.ActualGasCost)
+			if err != nil {
+				return nil, err
+			}
+			receipt.GasUsed = gasUsed.Uint64()
+			if gasUsed.Sign() > 0 {
+				receipt.EffectiveGasPrice = new(big.Int).Quo(gasCost, gasUsed)
+			}
+			if receipt.Logs, err = decodeLogs(result.Logs); err != nil {
+				return nil, err
+			}
+			if !receipt.Success {
+				return receipt, chains.ErrTxReverted
+			}
+			return receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(b.pollInterval):
+		}
+	}
+}
+
+// AccountCallData returns the call data of a user operation making an account carry out req
+func AccountCallData(req chains.TxRequest) ([]byte, error) {
+	value := req.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	return EncodeCall(executeFunction, req.To, value, req.Data)
+}
+
+// withGasMargin adds the gas limit margin to an estimate
+func withGasMargin(gas *big.Int) *big.Int {
+	margined := new(big.Int).Mul(gas, big.NewInt(100+gasLimitMarginPercent))
+	return margined.Quo(margined, big.NewInt(100))
+}
+
+// bytes32 returns a 32-byte value as an integer, which encodes to the same ABI word
+func bytes32(value []byte) *big.Int {
+	return new(big.Int).SetBytes(value)
+}
+
+// orZero returns value, or zero when it is nil
+func orZero(value *big.Int) *big.Int {
+	if value == nil {
+		return big.NewInt(0)
+	}
+	return value
+}
@@ -0,0 +1,139 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/chains"
+)
+
+func TestBundler(t *testing.T) {
+	const (
+		account = "0x1234567890abcdef1234567890abcdef12345678"
+		dex     = "0x2222222222222222222222222222222222222222"
+	)
+	node := &fakeRPC{results: map[string]interface{}{
+		"eth_call":                 "0x0000000000000000000000000000000000000000000000000000000000000007",
+		"eth_getBlockByNumber":     map[string]string{"baseFeePerGas": "0x64"},
+		"eth_maxPriorityFeePerGas": "0xa",
+	}}
+	bundlerRPC := &fakeRPC{results: map[string]interface{}{
+		"eth_estimateUserOperationGas": map[string]string{
+			"preVerificationGas":   "0xc350",
+			"verificationGasLimit": "0x186a0",
+			"callGasLimit":         "0x30d40",
+		},
+		"eth_sendUserOperation": "0xop",
+		"eth_getUserOperationReceipt": map[string]interface{}{
+			"success":       true,
+			"actualGasUsed": "0x186a0",
+			"actualGasCost": "0xf42400",
+			"logs":          []interface{}{},
+			"receipt":       map[string]string{"transactionHash": "0xbundle", "blockNumber": "0x20"},
+		},
+	}}
+	bundler := NewBundler(node, bundlerRPC)
+
+	t.Run("AccountType", func(t *testing.T) {
+		for code, expected := range map[string]chains.AccountType{
+			"0x": chains.AccountTypeEOA,
+			"0xef01002222222222222222222222222222222222222222": chains.AccountTypeEOA,
+			"0x6080604052": chains.AccountTypeSmart,
+		} {
+			node.results["eth_getCode"] = code
+			accountType, err := bundler.AccountType(context.Background(), 1, account)
+			if err != nil || accountType != expected {
+				t.Errorf("Expected code %s to be %s, got %s, %v", code, expected, accountType, err)
+			}
+		}
+	})
+
+	req := chains.TxRequest{ChainID: 1, To: dex, Data: []byte{0x01, 0x02}}
+	op, err := bundler.BuildUserOperation(context.Background(), account, req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	callData, _ := AccountCallData(req)
+	if string(op.CallData) != string(callData) || op.Nonce.Int64() != 7 || len(op.Signature) != 0 {
+		t.Errorf("Unexpected user operation %+v", op)
+	}
+	if op.MaxFeePerGas.Int64() != 210 || op.MaxPriorityFeePerGas.Int64() != 10 {
+		t.Errorf("Expected twice the base fee plus the tip, got %s and %s", op.MaxFeePerGas, op.MaxPriorityFeePerGas)
+	}
+	if op.PreVerificationGas.Int64() != 50000 || op.VerificationGasLimit.Int64() != 120000 || op.CallGasLimit.Int64() != 240000 {
+		t.Errorf("Expected estimated gas with a margin, got %+v", op)
+	}
+	if estimated := bundlerRPC.params["eth_estimateUserOperationGas"]; estimated[1] != EntryPointV07 {
+		t.Errorf("Expected the estimate against the v0.7 EntryPoint, got %v", estimated)
+	}
+	if len(dummySignature) != 65 {
+		t.Errorf("Expected a 65-byte dummy signature, got %d bytes", len(dummySignature))
+	}
+
+	t.Run("Hash", func(t *testing.T) {
+		hash, err := bundler.UserOperationHash(1, op)
+		if err != nil || len(hash) != 32 {
+			t.Fatalf("Expected a 32-byte hash, got %x, %v", hash, err)
+		}
+		other, _ := bundler.UserOperationHash(10, op)
+		if string(hash) == string(other) {
+			t.Error("Expected the hash to depend on the chain")
+		}
+		signed := *op
+		signed.Signature = []byte{0x01}
+		if withSignature, _ := bundler.UserOperationHash(1, &signed); string(withSignature) != string(hash) {
+			t.Error("Expected the hash not to cover the signature")
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		raw, err := json.Marshal(op)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var decoded chains.UserOperation
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if decoded.Nonce.Cmp(op.Nonce) != 0 || string(decoded.CallData) != string(op.CallData) || decoded.CallGasLimit.Cmp(op.CallGasLimit) != 0 {
+			t.Errorf("Expected the operation to round-trip, got %s", raw)
+		}
+	})
+
+	t.Run("SendAndWait", func(t *testing.T) {
+		if _, err := bundler.SendUserOperation(context.Background(), 1, op); err == nil {
+			t.Error("Expected error sending an unsigned operation")
+		}
+		signed := *op
+		signed.Signature = []byte{0x01}
+		hash, err := bundler.SendUserOperation(context.Background(), 1, &signed)
+		if err != nil || hash != "0xop" {
+			t.Fatalf("Expected hash 0xop, got %q, %v", hash, err)
+		}
+
+		receipt, err := bundler.WaitForUserOperation(context.Background(), 1, hash)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if receipt.TxHash != "0xbundle" || receipt.BlockNumber != 32 || receipt.GasCost().Int64() != 16_000_000 {
+			t.Errorf("Unexpected receipt %+v", receipt)
+		}
+
+		bundlerRPC.results["eth_getUserOperationReceipt"].(map[string]interface{})["success"] = false
+		if _, err := bundler.WaitForUserOperation(context.Background(), 1, hash); !errors.Is(err, chains.ErrTxReverted) {
+			t.Errorf("Expected ErrTxReverted, got %v", err)
+		}
+
+		bundlerRPC.results["eth_getUserOperationReceipt"] = nil
+		bundler.SetPollInterval(time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := bundler.WaitForUserOperation(ctx, 1, hash); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	})
+
+}
@@ -0,0 +1,23 @@
+package evm
+
+import (
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak256 returns the Keccak-256 hash Ethereum uses of the concatenated data
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, part := range data {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// padded returns n big-endian, left-padded with zeros to size bytes
+func padded(n *big.Int, size int) []byte {
+	out := make([]byte, size)
+	n.FillBytes(out)
+	return out
+}
@@ -0,0 +1,83 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// nonceKey identifies an account on a chain
+type nonceKey struct {
+	chainID int64
+	address string // lower case
+}
+
+// NonceManager hands out the nonces of an account's transactions on each chain. The chain's pending nonce only counts
+// broadcast transactions, so concurrent transactions built before either is broadcast would otherwise share a nonce.
+// Nonces handed out are tracked per process; transactions other processes broadcast are picked up from the chain.
+type NonceManager struct {
+	next     map[nonceKey]uint64   // one past the highest nonce handed out
+	released map[nonceKey][]uint64 // nonces given back below next, lowest first
+	mu       sync.Mutex
+}
+
+// NewNonceManager creates a nonce manager that has handed out no nonces
+func NewNonceManager() *NonceManager {
+	return &NonceManager{
+		next:     make(map[nonceKey]uint64),
+		released: make(map[nonceKey][]uint64),
+	}
+}
+
+// Next returns the nonce of the account's next transaction: the lowest nonce given back, or else the chain's pending
+// nonce or one past the highest handed out, whichever is higher
+func (m *NonceManager) Next(ctx context.Context, rpc RPC, chainID int64, address string) (uint64, error) {
+	var count string
+	if err := rpc.Invoke(ctx, chainID, "eth_getTransactionCount", []interface{}{address, "pending"}, &count); err != nil {
+		return 0, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	n, err := parseQuantity(count)
+	if err != nil {
+		return 0, err
+	}
+	pending := n.Uint64()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := nonceKey{chainID, strings.ToLower(address)}
+	// Nonces given back that the chain has since used were filled by another process
+	released := m.released[key]
+	for len(released) > 0 && released[0] < pending {
+		released = released[1:]
+	}
+	if len(released) > 0 {
+		m.released[key] = released[1:]
+		return released[0], nil
+	}
+	delete(m.released, key)
+
+	nonce := max(pending, m.next[key])
+	m.next[key] = nonce + 1
+	return nonce, nil
+}
+
+// Release gives back a nonce handed out for a transaction that will never be broadcast
+func (m *NonceManager) Release(chainID int64, address string, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := nonceKey{chainID, strings.ToLower(address)}
+	if nonce+1 == m.next[key] {
+		m.next[key] = nonce
+		return
+	}
+	if nonce >= m.next[key] {
+		return
+	}
+	released := append(m.released[key], nonce)
+	sort.Slice(released, func(i, j int) bool { return released[i] < released[j] })
+	m.released[key] = released
+}
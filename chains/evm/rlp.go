@@ -0,0 +1,51 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// rlpList is a list of items to RLP-encode
+type rlpList []interface{}
+
+// rlpEncode RLP-encodes byte strings, unsigned integers (uint64 or non-negative *big.Int; nil is zero) and lists of them
+func rlpEncode(item interface{}) ([]byte, error) {
+	switch v := item.(type) {
+	case []byte:
+		if len(v) == 1 && v[0] < 0x80 {
+			return []byte{v[0]}, nil
+		}
+		return append(rlpHeader(0x80, len(v)), v...), nil
+	case uint64:
+		return rlpEncode(new(big.Int).SetUint64(v))
+	case *big.Int:
+		if v == nil {
+			return rlpEncode([]byte{})
+		}
+		if v.Sign() < 0 {
+			return nil, fmt.Errorf("cannot RLP-encode negative integer %s", v)
+		}
+		return rlpEncode(v.Bytes())
+	case rlpList:
+		var payload []byte
+		for _, element := range v {
+			encoded, err := rlpEncode(element)
+			if err != nil {
+				return nil, err
+			}
+			payload = append(payload, encoded...)
+		}
+		return append(rlpHeader(0xc0, len(payload)), payload...), nil
+	default:
+		return nil, fmt.Errorf("cannot RLP-encode %T", item)
+	}
+}
+
+// rlpHeader returns the prefix of a string (offset 0x80) or list (offset 0xc0) payload of the given length
+func rlpHeader(offset byte, length int) []byte {
+	if length < 56 {
+		return []byte{offset + byte(length)}
+	}
+	size := new(big.Int).SetInt64(int64(length)).Bytes()
+	return append([]byte{offset + 55 + byte(len(size))}, size...)
+}
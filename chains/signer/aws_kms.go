@@ -0,0 +1,201 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// AWSCredentials are the credentials requests to AWS KMS are signed with
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// AWSCredentialsFromEnv reads credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+func AWSCredentialsFromEnv() (AWSCredentials, error) {
+	credentials := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return credentials, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	return credentials, nil
+}
+
+// AWSKMSSigner signs with an ECC_SECG_P256K1 key that never leaves AWS KMS
+type AWSKMSSigner struct {
+	client      *http.Client
+	endpoint    string
+	region      string
+	keyID       string
+	credentials AWSCredentials
+	public      *secp256k1.PublicKey
+	address     string
+	now         func() time.Time
+}
+
+// NewAWSKMSSigner creates a signer for the KMS key keyID (an ID, ARN or alias) in region, reading its public key.
+// endpoint defaults to the region's KMS endpoint.
+func NewAWSKMSSigner(ctx context.Context, client *http.Client, endpoint, region, keyID string, credentials AWSCredentials) (*AWSKMSSigner, error) {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", region)
+	}
+	s := &AWSKMSSigner{
+		client:      client,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		region:      region,
+		keyID:       keyID,
+		credentials: credentials,
+		now:         time.Now,
+	}
+
+	var key struct {
+		PublicKey []byte `json:"PublicKey"` // DER SubjectPublicKeyInfo
+		KeySpec   string `json:"KeySpec"`
+	}
+	if err := s.call(ctx, "GetPublicKey", map[string]string{"KeyId": keyID}, &key); err != nil {
+		return nil, fmt.Errorf("failed to get KMS public key: %w", err)
+	}
+	if key.KeySpec != "ECC_SECG_P256K1" {
+		return nil, fmt.Errorf("KMS key %s is %s, not ECC_SECG_P256K1", keyID, key.KeySpec)
+	}
+	public, err := parsePublicKey(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	s.public = public
+	s.address = publicKeyAddress(public)
+	return s, nil
+}
+
+// Address returns the KMS key's account address
+func (s *AWSKMSSigner) Address() string {
+	return s.address
+}
+
+// SignDigest signs a digest with the KMS key
+func (s *AWSKMSSigner) SignDigest(ctx context.Context, digest []byte) (*Signature, error) {
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("digest must be 32 bytes, got %d", len(digest))
+	}
+	request := map[string]interface{}{
+		"KeyId":            s.keyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}
+	var response struct {
+		Signature []byte `json:"Signature"` // DER
+	}
+	if err := s.call(ctx, "Sign", request, &response); err != nil {
+		return nil, fmt.Errorf("failed to sign with KMS: %w", err)
+	}
+	return kmsSignature(response.Signature, digest, s.public)
+}
+
+// call sends a KMS API request signed with Signature Version 4
+func (s *AWSKMSSigner) call(ctx context.Context, action string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	SignV4(req, body, s.credentials, s.region, "kms", s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		json.Unmarshal(raw, &failure)
+		return fmt.Errorf("KMS returned status %d: %s %s", resp.StatusCode, failure.Type, failure.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// SignV4 signs an AWS API request with Signature Version 4, covering its host and every header set on it
+func SignV4(req *http.Request, body []byte, credentials AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,185 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+const (
+	// gcpKMSEndpoint is the Cloud KMS REST API
+	gcpKMSEndpoint = "https://cloudkms.googleapis.com"
+
+	// gcpMetadataTokenURL returns access tokens of the service account a GCP workload runs as
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// TokenSource returns an OAuth access token for Google APIs
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns a token source always returning token
+func StaticToken(token string) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// MetadataToken returns a token source reading the workload's service account tokens from the GCP metadata
+// server, reusing each token until a minute before it expires
+func MetadataToken(client *http.Client) TokenSource {
+	var token string
+	var expires time.Time
+	var mu sync.Mutex
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Now().Before(expires) {
+			return token, nil
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to get access token: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("metadata server returned status %d", resp.StatusCode)
+		}
+		var response struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return "", fmt.Errorf("invalid access token: %w", err)
+		}
+		token = response.AccessToken
+		expires = time.Now().Add(time.Duration(response.ExpiresIn)*time.Second - time.Minute)
+		return token, nil
+	}
+}
+
+// GCPKMSSigner signs with an EC_SIGN_SECP256K1_SHA256 key version that never leaves Google Cloud KMS
+type GCPKMSSigner struct {
+	client     *http.Client
+	endpoint   string
+	keyVersion string
+	token      TokenSource
+	public     *secp256k1.PublicKey
+	address    string
+}
+
+// NewGCPKMSSigner creates a signer for keyVersion, the resource name
+// projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*, reading its public key.
+// endpoint defaults to the Cloud KMS API.
+func NewGCPKMSSigner(ctx context.Context, client *http.Client, endpoint, keyVersion string, token TokenSource) (*GCPKMSSigner, error) {
+	if endpoint == "" {
+		endpoint = gcpKMSEndpoint
+	}
+	s := &GCPKMSSigner{
+		client:     client,
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		keyVersion: keyVersion,
+		token:      token,
+	}
+
+	var key struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := s.call(ctx, http.MethodGet, "/publicKey", nil, &key); err != nil {
+		return nil, fmt.Errorf("failed to get KMS public key: %w", err)
+	}
+	if key.Algorithm != "EC_SIGN_SECP256K1_SHA256" {
+		return nil, fmt.Errorf("KMS key %s is %s, not EC_SIGN_SECP256K1_SHA256", keyVersion, key.Algorithm)
+	}
+	block, _ := pem.Decode([]byte(key.PEM))
+	if block == nil {
+		return nil, errors.New("invalid KMS public key PEM")
+	}
+	public, err := parsePublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	s.public = public
+	s.address = publicKeyAddress(public)
+	return s, nil
+}
+
+// Address returns the KMS key's account address
+func (s *GCPKMSSigner) Address() string {
+	return s.address
+}
+
+// SignDigest signs a digest with the KMS key
+func (s *GCPKMSSigner) SignDigest(ctx context.Context, digest []byte) (*Signature, error) {
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("digest must be 32 bytes, got %d", len(digest))
+	}
+	request := map[string]interface{}{
+		"digest": map[string][]byte{"sha256": digest},
+	}
+	var response struct {
+		Signature []byte `json:"signature"` // DER
+	}
+	if err := s.call(ctx, http.MethodPost, ":asymmetricSign", request, &response); err != nil {
+		return nil, fmt.Errorf("failed to sign with KMS: %w", err)
+	}
+	return kmsSignature(response.Signature, digest, s.public)
+}
+
+// call sends a Cloud KMS request about the key version; suffix is appended to its resource path
+func (s *GCPKMSSigner) call(ctx context.Context, method, suffix string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		encoded, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+"/v1/"+s.keyVersion+suffix, body)
+	if err != nil {
+		return err
+	}
+	token, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		json.Unmarshal(raw, &failure)
+		return fmt.Errorf("KMS returned status %d: %s", resp.StatusCode, failure.Error.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
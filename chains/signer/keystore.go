@@ -0,0 +1,143 @@
+package signer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// keystoreFile is a Web3 Secret Storage (version 3) keystore, as written by geth, Foundry and most wallets
+type keystoreFile struct {
+	Address string `json:"address"`
+	Crypto  struct {
+		Cipher       string `json:"cipher"`
+		CipherText   string `json:"ciphertext"`
+		CipherParams struct {
+			IV string `json:"iv"`
+		} `json:"cipherparams"`
+		KDF       string          `json:"kdf"`
+		KDFParams json.RawMessage `json:"kdfparams"`
+		MAC       string          `json:"mac"`
+	} `json:"crypto"`
+	Version int `json:"version"`
+}
+
+// scryptParams are the key derivation parameters of scrypt keystores
+type scryptParams struct {
+	DKLen int    `json:"dklen"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Salt  string `json:"salt"`
+}
+
+// pbkdf2Params are the key derivation parameters of PBKDF2 keystores
+type pbkdf2Params struct {
+	DKLen int    `json:"dklen"`
+	C     int    `json:"c"`
+	PRF   string `json:"prf"`
+	Salt  string `json:"salt"`
+}
+
+// LoadKeystore creates a signer from the key in an encrypted keystore file. The decrypted key only lives in the
+// signer's memory.
+func LoadKeystore(path, password string) (*PrivateKeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	return DecryptKeystore(data, password)
+}
+
+// DecryptKeystore creates a signer from the key in keystore JSON
+func DecryptKeystore(data []byte, password string) (*PrivateKeySigner, error) {
+	var keystore keystoreFile
+	if err := json.Unmarshal(data, &keystore); err != nil {
+		return nil, fmt.Errorf("invalid keystore: %w", err)
+	}
+	if keystore.Version != 3 {
+		return nil, fmt.Errorf("unsupported keystore version %d", keystore.Version)
+	}
+	if keystore.Crypto.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("unsupported keystore cipher %q", keystore.Crypto.Cipher)
+	}
+	cipherText, err := hex.DecodeString(keystore.Crypto.CipherText)
+	if err != nil {
+		return nil, errors.New("invalid keystore ciphertext")
+	}
+	iv, err := hex.DecodeString(keystore.Crypto.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, errors.New("invalid keystore IV")
+	}
+	mac, err := hex.DecodeString(keystore.Crypto.MAC)
+	if err != nil {
+		return nil, errors.New("invalid keystore MAC")
+	}
+
+	derived, err := deriveKeystoreKey(keystore.Crypto.KDF, keystore.Crypto.KDFParams, password)
+	if err != nil {
+		return nil, err
+	}
+	// The MAC proves the password before anything is decrypted with it
+	if subtle.ConstantTimeCompare(keccak256(derived[16:32], cipherText), mac) != 1 {
+		return nil, errors.New("wrong keystore password")
+	}
+
+	block, err := aes.NewCipher(derived[:16])
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, len(cipherText))
+	cipher.NewCTR(block, iv).XORKeyStream(key, cipherText)
+	defer clear(key)
+
+	signer, err := newPrivateKeySigner(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore key: %w", err)
+	}
+	if keystore.Address != "" && !strings.EqualFold(strings.TrimPrefix(keystore.Address, "0x"), strings.TrimPrefix(signer.Address(), "0x")) {
+		return nil, fmt.Errorf("keystore key belongs to %s, not %s", signer.Address(), keystore.Address)
+	}
+	return signer, nil
+}
+
+// deriveKeystoreKey derives the 32-byte key that encrypts and authenticates a keystore from its password
+func deriveKeystoreKey(kdf string, params json.RawMessage, password string) ([]byte, error) {
+	switch kdf {
+	case "scrypt":
+		var p scryptParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid scrypt parameters: %w", err)
+		}
+		salt, err := hex.DecodeString(p.Salt)
+		if err != nil || p.DKLen < 32 {
+			return nil, errors.New("invalid scrypt parameters")
+		}
+		return scrypt.Key([]byte(password), salt, p.N, p.R, p.P, p.DKLen)
+	case "pbkdf2":
+		var p pbkdf2Params
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid pbkdf2 parameters: %w", err)
+		}
+		salt, err := hex.DecodeString(p.Salt)
+		if err != nil || p.DKLen < 32 || p.C <= 0 {
+			return nil, errors.New("invalid pbkdf2 parameters")
+		}
+		if p.PRF != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported pbkdf2 function %q", p.PRF)
+		}
+		return pbkdf2.Key([]byte(password), salt, p.C, p.DKLen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("unsupported keystore key derivation %q", kdf)
+	}
+}
@@ -0,0 +1,67 @@
+package signer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pbkdf2Keystore is the PBKDF2 test vector from the Web3 Secret Storage definition, with the address of its key
+const pbkdf2Keystore = `{
+	"address": "008aeeda4d805471df9b2a5b0f38a0c3bcba786b",
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+		"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+		"kdf": "pbkdf2",
+		"kdfparams": {
+			"c": 262144,
+			"dklen": 32,
+			"prf": "hmac-sha256",
+			"salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"
+		},
+		"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+	},
+	"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+	"version": 3
+}`
+
+func TestDecryptKeystore(t *testing.T) {
+	signer, err := DecryptKeystore([]byte(pbkdf2Keystore), "testpassword")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected, _ := NewPrivateKeySigner("7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	if signer.Address() != expected.Address() {
+		t.Errorf("Expected key of %s, got %s", expected.Address(), signer.Address())
+	}
+	if !strings.EqualFold(signer.Address(), "0x008aeeda4d805471df9b2a5b0f38a0c3bcba786b") {
+		t.Errorf("Expected the keystore's address, got %s", signer.Address())
+	}
+
+	if _, err := DecryptKeystore([]byte(pbkdf2Keystore), "wrongpassword"); err == nil {
+		t.Error("Expected error decrypting with the wrong password")
+	}
+	wrongAddress := strings.Replace(pbkdf2Keystore, "008aeeda", "108aeeda", 1)
+	if _, err := DecryptKeystore([]byte(wrongAddress), "testpassword"); err == nil {
+		t.Error("Expected error when the key doesn't match the keystore's address")
+	}
+	unsupported := strings.Replace(pbkdf2Keystore, `"kdf": "pbkdf2"`, `"kdf": "argon2"`, 1)
+	if _, err := DecryptKeystore([]byte(unsupported), "testpassword"); err == nil {
+		t.Error("Expected error for an unsupported key derivation")
+	}
+}
+
+func TestLoadKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if err := os.WriteFile(path, []byte(pbkdf2Keystore), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeystore(path, "testpassword"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := LoadKeystore(filepath.Join(t.TempDir(), "missing.json"), "testpassword"); err == nil {
+		t.Error("Expected error loading a missing keystore")
+	}
+}
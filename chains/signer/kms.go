@@ -0,0 +1,64 @@
+package signer
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// oidSecp256k1 identifies the secp256k1 curve in public key encodings
+var oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+// subjectPublicKeyInfo is a DER-encoded public key, as KMSes return them
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// parsePublicKey parses a DER SubjectPublicKeyInfo holding a secp256k1 key. The standard library doesn't support
+// the curve, so the point is read directly.
+func parsePublicKey(der []byte) (*secp256k1.PublicKey, error) {
+	var info subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 {
+		return nil, errors.New("invalid public key encoding")
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidSecp256k1) {
+		return nil, errors.New("public key is not a secp256k1 key")
+	}
+	return secp256k1.ParsePubKey(info.PublicKey.RightAlign())
+}
+
+// kmsSignature converts a DER signature of digest by a KMS into a low-s signature with the recovery ID of public.
+// KMSes return either s, and no recovery ID, so the ID is found by recovering each candidate key.
+func kmsSignature(der, digest []byte, public *secp256k1.PublicKey) (*Signature, error) {
+	parsed, err := ecdsa.ParseDERSignature(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KMS signature: %w", err)
+	}
+	r, s := parsed.R(), parsed.S()
+	if s.IsOverHalfOrder() {
+		s.Negate()
+	}
+
+	compact := make([]byte, 65)
+	r.PutBytesUnchecked(compact[1:33])
+	s.PutBytesUnchecked(compact[33:65])
+	for recovery := byte(0); recovery < 2; recovery++ {
+		compact[0] = 27 + recovery
+		recovered, _, err := ecdsa.RecoverCompact(compact, digest)
+		if err == nil && recovered.IsEqual(public) {
+			return &Signature{
+				R:        new(big.Int).SetBytes(compact[1:33]),
+				S:        new(big.Int).SetBytes(compact[33:65]),
+				Recovery: recovery,
+			}, nil
+		}
+	}
+	return nil, errors.New("KMS signature does not match the key's public key")
+}
@@ -0,0 +1,185 @@
+package signer
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// kmsKey stands in for a key held by a KMS
+type kmsKey struct {
+	local *PrivateKeySigner
+}
+
+// publicKeyDER returns the key's DER SubjectPublicKeyInfo, as KMSes return public keys
+func (k kmsKey) publicKeyDER(t *testing.T) []byte {
+	t.Helper()
+	curve, _ := asn1.Marshal(oidSecp256k1)
+	der, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}, // id-ecPublicKey
+			Parameters: asn1.RawValue{FullBytes: curve},
+		},
+		PublicKey: asn1.BitString{Bytes: k.local.key.PubKey().SerializeUncompressed(), BitLength: 65 * 8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// signDER signs digest, returning the high-s form of the signature in DER, which KMSes are free to return
+func (k kmsKey) signDER(t *testing.T, digest []byte) []byte {
+	t.Helper()
+	signature, err := k.local.SignDigest(context.Background(), digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	highS := new(big.Int).Sub(secp256k1.S256().N, signature.S)
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{signature.R, highS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func newKMSKey(t *testing.T) kmsKey {
+	t.Helper()
+	local, err := NewPrivateKeySigner(eip155Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kmsKey{local: local}
+}
+
+// checkSignature checks that signature is low-s and recovers to address
+func checkSignature(t *testing.T, signature *Signature, digest []byte, address string) {
+	t.Helper()
+	if signature.S.Cmp(new(big.Int).Rsh(secp256k1.S256().N, 1)) > 0 {
+		t.Error("Expected a low-s signature")
+	}
+	if recovered := recoverAddress(t, signature, digest); recovered != address {
+		t.Errorf("Expected signature to recover %s, got %s", address, recovered)
+	}
+}
+
+func TestAWSKMSSigner(t *testing.T) {
+	key := newKMSKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("Expected a SigV4 signed request, got %q", r.Header.Get("Authorization"))
+		}
+		var request struct {
+			KeyId       string
+			Message     []byte
+			MessageType string
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		if request.KeyId != "alias/swaps" {
+			t.Errorf("Unexpected key %s", request.KeyId)
+		}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			json.NewEncoder(w).Encode(map[string]interface{}{"KeySpec": "ECC_SECG_P256K1", "PublicKey": key.publicKeyDER(t)})
+		case "TrentService.Sign":
+			if request.MessageType != "DIGEST" {
+				t.Errorf("Expected a digest to be signed, got %s", request.MessageType)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"Signature": key.signDER(t, request.Message)})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"UnknownOperationException"}`))
+		}
+	}))
+	defer server.Close()
+
+	signer, err := NewAWSKMSSigner(context.Background(), server.Client(), server.URL, "us-east-1", "alias/swaps", AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if signer.Address() != key.local.Address() {
+		t.Errorf("Expected address %s, got %s", key.local.Address(), signer.Address())
+	}
+	digest := keccak256([]byte("transaction"))
+	signature, err := signer.SignDigest(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkSignature(t, signature, digest, signer.Address())
+}
+
+func TestGCPKMSSigner(t *testing.T) {
+	key := newKMSKey(t)
+	const keyVersion = "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/v1/" + keyVersion + "/publicKey":
+			block := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: key.publicKeyDER(t)})
+			json.NewEncoder(w).Encode(map[string]string{"algorithm": "EC_SIGN_SECP256K1_SHA256", "pem": string(block)})
+		case "/v1/" + keyVersion + ":asymmetricSign":
+			var request struct {
+				Digest struct {
+					SHA256 string `json:"sha256"`
+				} `json:"digest"`
+			}
+			json.NewDecoder(r.Body).Decode(&request)
+			digest, _ := base64.StdEncoding.DecodeString(request.Digest.SHA256)
+			json.NewEncoder(w).Encode(map[string][]byte{"signature": key.signDER(t, digest)})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	signer, err := NewGCPKMSSigner(context.Background(), server.Client(), server.URL, keyVersion, StaticToken("token"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if signer.Address() != key.local.Address() {
+		t.Errorf("Expected address %s, got %s", key.local.Address(), signer.Address())
+	}
+	digest := keccak256([]byte("transaction"))
+	signature, err := signer.SignDigest(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkSignature(t, signature, digest, signer.Address())
+}
+
+func TestKMSSignatureFromAnotherKey(t *testing.T) {
+	key := newKMSKey(t)
+	other, _ := NewPrivateKeySigner("7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	digest := keccak256([]byte("transaction"))
+	if _, err := kmsSignature(key.signDER(t, digest), digest, other.key.PubKey()); err == nil {
+		t.Error("Expected error for a signature by another key")
+	}
+	if _, err := kmsSignature([]byte{0x30, 0x00}, digest, key.local.key.PubKey()); err == nil {
+		t.Error("Expected error for an invalid signature")
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	credentials := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	SignV4(req, nil, credentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if authorization := req.Header.Get("Authorization"); authorization != expected {
+		t.Errorf("Expected %s, got %s", expected, authorization)
+	}
+}
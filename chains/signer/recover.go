@@ -0,0 +1,49 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// PersonalMessageDigest returns the EIP-191 digest wallets sign for personal_sign: the Keccak-256 hash of the
+// message prefixed with "\x19Ethereum Signed Message:\n" and its length
+func PersonalMessageDigest(message []byte) []byte {
+	return keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), message)
+}
+
+// TypedDataDigest returns the EIP-712 digest wallets sign for eth_signTypedData_v4 from the domain separator and
+// the hash of the signed struct
+func TypedDataDigest(domainSeparator, structHash []byte) []byte {
+	return keccak256([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+// RecoverAddress returns the checksummed address of the account that signed digest. The signature is the 65 bytes
+// wallets return: r, s, then v as 27 or 28, or as the bare recovery ID 0 or 1.
+func RecoverAddress(digest, signature []byte) (string, error) {
+	if len(digest) != 32 {
+		return "", fmt.Errorf("digest must be 32 bytes, got %d", len(digest))
+	}
+	if len(signature) != 65 {
+		return "", fmt.Errorf("signature must be 65 bytes, got %d", len(signature))
+	}
+
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", errors.New("signature has an invalid recovery ID")
+	}
+
+	// A compact signature puts the recovery code first, 27 plus the recovery ID for uncompressed keys
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], signature[:64])
+	public, _, err := ecdsa.RecoverCompact(compact, digest)
+	if err != nil {
+		return "", fmt.Errorf("failed to recover signer: %w", err)
+	}
+	return publicKeyAddress(public), nil
+}
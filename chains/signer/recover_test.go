@@ -0,0 +1,66 @@
+package signer
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+)
+
+// walletSignature returns a signature in the r, s, v form wallets return
+func walletSignature(t *testing.T, signer *PrivateKeySigner, digest []byte) []byte {
+	t.Helper()
+	signature, err := signer.SignDigest(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	out := make([]byte, 65)
+	signature.R.FillBytes(out[:32])
+	signature.S.FillBytes(out[32:64])
+	out[64] = 27 + signature.Recovery
+	return out
+}
+
+func TestPersonalMessageDigest(t *testing.T) {
+	// ethers.js hashMessage("Hello World")
+	digest := hex.EncodeToString(PersonalMessageDigest([]byte("Hello World")))
+	if digest != "a1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2" {
+		t.Errorf("Unexpected personal message digest %s", digest)
+	}
+}
+
+func TestRecoverAddress(t *testing.T) {
+	signer, err := NewPrivateKeySigner(eip155Key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	digest := PersonalMessageDigest([]byte("message"))
+	signature := walletSignature(t, signer, digest)
+
+	if address, err := RecoverAddress(digest, signature); err != nil || address != signer.Address() {
+		t.Errorf("Expected signature to recover %s, got %s (%v)", signer.Address(), address, err)
+	}
+
+	// Some wallets return the bare recovery ID as v
+	bare := append([]byte(nil), signature...)
+	bare[64] -= 27
+	if address, err := RecoverAddress(digest, bare); err != nil || address != signer.Address() {
+		t.Errorf("Expected a bare recovery ID to recover %s, got %s (%v)", signer.Address(), address, err)
+	}
+
+	// A signature of another digest recovers another account
+	other := PersonalMessageDigest([]byte("other message"))
+	if address, err := RecoverAddress(other, signature); err == nil && address == signer.Address() {
+		t.Error("Expected a signature of another digest not to recover the signer")
+	}
+
+	invalidV := append([]byte(nil), signature...)
+	invalidV[64] = 30
+	for name, invalid := range map[string][]byte{"short": signature[:64], "bad recovery ID": invalidV} {
+		if _, err := RecoverAddress(digest, invalid); err == nil {
+			t.Errorf("Expected error recovering a %s signature", name)
+		}
+	}
+	if _, err := RecoverAddress(digest[:31], signature); err == nil {
+		t.Error("Expected error recovering a short digest")
+	}
+}
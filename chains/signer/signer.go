@@ -0,0 +1,121 @@
+// Package signer signs transactions for EVM accounts whose keys are held by a backend: an in-memory key injected
+// through the environment, an encrypted keystore file, or a cloud KMS. Callers only ever see addresses and
+// signatures, never key material.
+package signer
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// Signer signs digests for an EVM account
+type Signer interface {
+	// Address returns the account's address with its EIP-55 checksum
+	Address() string
+	// SignDigest signs a 32-byte digest, returning the low-s signature and its recovery ID
+	SignDigest(ctx context.Context, digest []byte) (*Signature, error)
+}
+
+// Signature is a secp256k1 signature with the recovery ID that recovers the signer's public key from it
+type Signature struct {
+	R        *big.Int
+	S        *big.Int
+	Recovery byte // 0 or 1
+}
+
+// PrivateKeySigner signs with a secp256k1 key held in memory. Curve arithmetic is constant time, so signing
+// doesn't leak the key through timing.
+type PrivateKeySigner struct {
+	key     *secp256k1.PrivateKey
+	address string
+}
+
+// NewPrivateKeySigner creates a signer from a hex-encoded 32-byte secp256k1 private key, with or without 0x
+func NewPrivateKeySigner(hexKey string) (*PrivateKeySigner, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, errors.New("private key must be 32 hex-encoded bytes")
+	}
+	return newPrivateKeySigner(raw)
+}
+
+// newPrivateKeySigner creates a signer from a raw 32-byte private key
+func newPrivateKeySigner(raw []byte) (*PrivateKeySigner, error) {
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(raw); overflow || scalar.IsZero() {
+		return nil, errors.New("private key is out of range")
+	}
+	key := secp256k1.NewPrivateKey(&scalar)
+	return &PrivateKeySigner{key: key, address: publicKeyAddress(key.PubKey())}, nil
+}
+
+// FromEnv creates a signer from the hex private key in the environment variable name, then removes the variable
+// so child processes and later readers of the environment never see the key
+func FromEnv(name string) (*PrivateKeySigner, error) {
+	hexKey, ok := os.LookupEnv(name)
+	if !ok || hexKey == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	signer, err := NewPrivateKeySigner(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key in %s: %w", name, err)
+	}
+	os.Unsetenv(name)
+	return signer, nil
+}
+
+// Address returns the key's account address
+func (s *PrivateKeySigner) Address() string {
+	return s.address
+}
+
+// SignDigest signs a digest with a deterministic RFC 6979 nonce
+func (s *PrivateKeySigner) SignDigest(ctx context.Context, digest []byte) (*Signature, error) {
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("digest must be 32 bytes, got %d", len(digest))
+	}
+	// A compact signature is the recovery code, 27 plus the recovery ID for uncompressed keys, then r and s
+	compact := ecdsa.SignCompact(s.key, digest, false)
+	return &Signature{
+		R:        new(big.Int).SetBytes(compact[1:33]),
+		S:        new(big.Int).SetBytes(compact[33:65]),
+		Recovery: compact[0] - 27,
+	}, nil
+}
+
+// publicKeyAddress returns the checksummed address of a public key: the last 20 bytes of the Keccak-256 hash of
+// the uncompressed key without its 0x04 prefix
+func publicKeyAddress(key *secp256k1.PublicKey) string {
+	return ChecksumAddress(keccak256(key.SerializeUncompressed()[1:])[12:])
+}
+
+// ChecksumAddress formats a 20-byte address with its EIP-55 mixed-case checksum
+func ChecksumAddress(address []byte) string {
+	lower := hex.EncodeToString(address)
+	hash := hex.EncodeToString(keccak256([]byte(lower)))
+	out := []byte(lower)
+	for i, c := range out {
+		if c >= 'a' && hash[i] >= '8' {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
+
+// keccak256 returns the Keccak-256 hash Ethereum uses
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, part := range data {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,81 @@
+package signer
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// eip155Key is the private key of the example transaction in EIP-155
+const eip155Key = "4646464646464646464646464646464646464646464646464646464646464646"
+
+func TestPrivateKeySigner(t *testing.T) {
+	signer, err := NewPrivateKeySigner("0x" + eip155Key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if signer.Address() != "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F" {
+		t.Errorf("Expected EIP-155 example address, got %s", signer.Address())
+	}
+
+	digest := keccak256([]byte("message"))
+	signature, err := signer.SignDigest(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if address := recoverAddress(t, signature, digest); address != signer.Address() {
+		t.Errorf("Expected signature to recover %s, got %s", signer.Address(), address)
+	}
+	if _, err := signer.SignDigest(context.Background(), digest[:31]); err == nil {
+		t.Error("Expected error signing a short digest")
+	}
+
+	for _, invalid := range []string{"", "0x1234", "zz" + eip155Key[2:], "0000000000000000000000000000000000000000000000000000000000000000"} {
+		if _, err := NewPrivateKeySigner(invalid); err == nil {
+			t.Errorf("Expected error parsing %q", invalid)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TEST_SIGNER_KEY", eip155Key)
+
+	signer, err := FromEnv("TEST_SIGNER_KEY")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if signer.Address() != "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F" {
+		t.Errorf("Expected EIP-155 example address, got %s", signer.Address())
+	}
+	if _, ok := os.LookupEnv("TEST_SIGNER_KEY"); ok {
+		t.Error("Expected the key to be removed from the environment")
+	}
+	if _, err := FromEnv("TEST_SIGNER_KEY"); err == nil {
+		t.Error("Expected error reading an unset variable")
+	}
+}
+
+// recoverAddress returns the address that signed digest
+func recoverAddress(t *testing.T, signature *Signature, digest []byte) string {
+	t.Helper()
+	compact := make([]byte, 65)
+	compact[0] = 27 + signature.Recovery
+	signature.R.FillBytes(compact[1:33])
+	signature.S.FillBytes(compact[33:65])
+	public, _, err := ecdsa.RecoverCompact(compact, digest)
+	if err != nil {
+		t.Fatalf("Failed to recover public key: %v", err)
+	}
+	return publicKeyAddress(public)
+}
+
+func TestChecksumAddress(t *testing.T) {
+	// An EIP-55 test vector
+	raw, _ := hex.DecodeString("5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	if address := ChecksumAddress(raw); address != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Errorf("Unexpected checksum address %s", address)
+	}
+}
@@ -0,0 +1,177 @@
+package chains
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// AccountType is the kind of account a transfer is sent from
+type AccountType string
+
+// Account types
+const (
+	// AccountTypeEOA is an account controlled by a private key, including EIP-7702 accounts delegating to code
+	AccountTypeEOA AccountType = "eoa"
+	// AccountTypeSmart is a smart contract account, which sends ERC-4337 user operations instead of transactions
+	AccountTypeSmart AccountType = "smart"
+)
+
+// UserOperation is an ERC-4337 v0.7 user operation. It encodes to JSON in the bundler RPC format, with hex
+// quantities and data.
+type UserOperation struct {
+	Sender               string
+	Nonce                *big.Int
+	Factory              string // optional, deploys the account with FactoryData on its first operation
+	FactoryData          []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+
+	// Optional paymaster sponsoring the operation's gas
+	Paymaster                     string
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	PaymasterData                 []byte
+
+	Signature []byte
+}
+
+// UserOperationSender submits user operations from smart accounts through a chain's bundler
+type UserOperationSender interface {
+	// AccountType reports whether an address is a smart account
+	AccountType(ctx context.Context, chainID int64, address string) (AccountType, error)
+
+	// BuildUserOperation builds an unsigned user operation making sender's account carry out the call, with the
+	// account's next nonce, estimated gas limits and the chain's current fees
+	BuildUserOperation(ctx context.Context, sender string, req TxRequest) (*UserOperation, error)
+
+	// UserOperationHash returns the hash the account's owner signs
+	UserOperationHash(chainID int64, op *UserOperation) ([]byte, error)
+
+	// SendUserOperation submits a signed user operation and returns its hash
+	SendUserOperation(ctx context.Context, chainID int64, op *UserOperation) (string, error)
+
+	// WaitForUserOperation waits until the operation is included. Reverted operations return their receipt and
+	// ErrTxReverted.
+	WaitForUserOperation(ctx context.Context, chainID int64, hash string) (*Receipt, error)
+}
+
+// userOperationJSON is a UserOperation in the bundler RPC format
+type userOperationJSON struct {
+	Sender                        string `json:"sender"`
+	Nonce                         string `json:"nonce"`
+	Factory                       string `json:"factory,omitempty"`
+	FactoryData                   string `json:"factoryData,omitempty"`
+	CallData                      string `json:"callData"`
+	CallGasLimit                  string `json:"callGasLimit"`
+	VerificationGasLimit          string `json:"verificationGasLimit"`
+	PreVerificationGas            string `json:"preVerificationGas"`
+	MaxFeePerGas                  string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas          string `json:"maxPriorityFeePerGas"`
+	Paymaster                     string `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit string `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       string `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 string `json:"paymasterData,omitempty"`
+	Signature                     string `json:"signature"`
+}
+
+// MarshalJSON encodes the operation in the bundler RPC format
+func (op UserOperation) MarshalJSON() ([]byte, error) {
+	out := userOperationJSON{
+		Sender:               op.Sender,
+		Nonce:                hexQuantity(op.Nonce),
+		CallData:             hexData(op.CallData),
+		CallGasLimit:         hexQuantity(op.CallGasLimit),
+		VerificationGasLimit: hexQuantity(op.VerificationGasLimit),
+		PreVerificationGas:   hexQuantity(op.PreVerificationGas),
+		MaxFeePerGas:         hexQuantity(op.MaxFeePerGas),
+		MaxPriorityFeePerGas: hexQuantity(op.MaxPriorityFeePerGas),
+		Signature:            hexData(op.Signature),
+	}
+	if op.Factory != "" {
+		out.Factory = op.Factory
+		out.FactoryData = hexData(op.FactoryData)
+	}
+	if op.Paymaster != "" {
+		out.Paymaster = op.Paymaster
+		out.PaymasterVerificationGasLimit = hexQuantity(op.PaymasterVerificationGasLimit)
+		out.PaymasterPostOpGasLimit = hexQuantity(op.PaymasterPostOpGasLimit)
+		out.PaymasterData = hexData(op.PaymasterData)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes an operation in the bundler RPC format
+func (op *UserOperation) UnmarshalJSON(data []byte) error {
+	var in userOperationJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	decoded := UserOperation{Sender: in.Sender, Factory: in.Factory, Paymaster: in.Paymaster}
+	quantities := []struct {
+		field string
+		value string
+		out   **big.Int
+	}{
+		{"nonce", in.Nonce, &decoded.Nonce},
+		{"callGasLimit", in.CallGasLimit, &decoded.CallGasLimit},
+		{"verificationGasLimit", in.VerificationGasLimit, &decoded.VerificationGasLimit},
+		{"preVerificationGas", in.PreVerificationGas, &decoded.PreVerificationGas},
+		{"maxFeePerGas", in.MaxFeePerGas, &decoded.MaxFeePerGas},
+		{"maxPriorityFeePerGas", in.MaxPriorityFeePerGas, &decoded.MaxPriorityFeePerGas},
+		{"paymasterVerificationGasLimit", in.PaymasterVerificationGasLimit, &decoded.PaymasterVerificationGasLimit},
+		{"paymasterPostOpGasLimit", in.PaymasterPostOpGasLimit, &decoded.PaymasterPostOpGasLimit},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		value, ok := new(big.Int).SetString(strings.TrimPrefix(q.value, "0x"), 16)
+		if !ok || value.Sign() < 0 {
+			return fmt.Errorf("invalid %s %q", q.field, q.value)
+		}
+		*q.out = value
+	}
+
+	fields := []struct {
+		field string
+		value string
+		out   *[]byte
+	}{
+		{"factoryData", in.FactoryData, &decoded.FactoryData},
+		{"callData", in.CallData, &decoded.CallData},
+		{"paymasterData", in.PaymasterData, &decoded.PaymasterData},
+		{"signature", in.Signature, &decoded.Signature},
+	}
+	for _, f := range fields {
+		value, err := hex.DecodeString(strings.TrimPrefix(f.value, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", f.field, err)
+		}
+		*f.out = value
+	}
+
+	*op = decoded
+	return nil
+}
+
+// hexQuantity formats an integer as a hex quantity, zero when nil
+func hexQuantity(value *big.Int) string {
+	if value == nil {
+		return "0x0"
+	}
+	return fmt.Sprintf("0x%x", value)
+}
+
+// hexData formats bytes as 0x-prefixed hex
+func hexData(data []byte) string {
+	return "0x" + hex.EncodeToString(data)
+}
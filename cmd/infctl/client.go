@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// apiKeyHeader carries the API key, as the API server reads it
+const apiKeyHeader = "X-API-Key"
+
+// swapRequest is the body of the swap endpoints
+type swapRequest struct {
+	SourceToken        types.Token `json:"sourceToken"`
+	DestinationToken   types.Token `json:"destinationToken"`
+	Amount             string      `json:"amount"`
+	SourceAddress      string      `json:"sourceAddress"`
+	DestinationAddress string      `json:"destinationAddress"`
+	Slippage           float64     `json:"slippage"`
+	MinOutputAmount    string      `json:"minOutputAmount,omitempty"`
+}
+
+// swapResponse is returned by the swap endpoints
+type swapResponse struct {
+	RequestID string                `json:"requestId"`
+	Status    string                `json:"status"`
+	Quote     *types.SwapQuote      `json:"quote,omitempty"`
+	Result    *types.SwapResult     `json:"result,omitempty"`
+	Policy    *types.PolicyDecision `json:"policy,omitempty"`
+	Sandbox   bool                  `json:"sandbox,omitempty"`
+	Archived  bool                  `json:"archived,omitempty"`
+	Region    string                `json:"region,omitempty"`
+	Deposit   *depositInstructions  `json:"deposit,omitempty"`
+}
+
+// depositInstructions tell where to send the deposit funding a swap
+type depositInstructions struct {
+	Address   string      `json:"address"`
+	Token     types.Token `json:"token"`
+	Amount    string      `json:"amount"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// apiError is an error response of the API
+type apiError struct {
+	Status    int    `json:"-"`
+	Message   string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId"`
+}
+
+// Error formats the error with its code and request ID, which the API's operators look requests up by
+func (e *apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("HTTP %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("%s: %s (request %s)", e.Code, e.Message, e.RequestID)
+}
+
+// apiClient calls the REST API
+type apiClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// newAPIClient creates a client of the API at baseURL, sending apiKey when set
+func newAPIClient(baseURL, apiKey string) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// get decodes the response of a GET request into out
+func (c *apiClient) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// post sends body and decodes the response into out
+func (c *apiClient) post(ctx context.Context, path string, body, out interface{}) error {
+	return c.do(ctx, http.MethodPost, path, body, out)
+}
+
+// do sends a request, decoding a successful response into out and an error response into an apiError. Swaps the
+// tenant's policy stopped come back as a 403 swap response, which is decoded into out too.
+func (c *apiClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &apiError{Status: resp.StatusCode}
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Message == "" {
+			if swap, ok := out.(*swapResponse); ok && json.Unmarshal(data, swap) == nil && swap.Policy != nil {
+				return &apiError{Status: resp.StatusCode, Message: fmt.Sprintf("swap %s by policy", swap.Status)}
+			}
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"github.com/spf13/cobra"
+)
+
+// terminalStatuses are the swap statuses --watch stops at
+var terminalStatuses = map[string]bool{
+	"completed": true,
+	"failed":    true,
+	"cancelled": true,
+	"denied":    true,
+	"simulated": true,
+	"timeout":   true,
+}
+
+// swapFlags are the flags selecting the tokens and amount of a swap
+type swapFlags struct {
+	from, to           string
+	chain, toChain     string
+	amount             string
+	address, toAddress string
+	slippage           float64
+	minOutput          string
+}
+
+// register adds the swap flags to a command
+func (f *swapFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.from, "from", "", "symbol of the token to sell, e.g. ETH or uETH")
+	cmd.Flags().StringVar(&f.to, "to", "", "symbol of the token to buy")
+	cmd.Flags().StringVar(&f.chain, "chain", "ethereum", "chain of the token to sell: name, chain ID or CAIP-2 ID")
+	cmd.Flags().StringVar(&f.toChain, "to-chain", "", "chain of the token to buy (default --chain)")
+	cmd.Flags().StringVar(&f.amount, "amount", "", "amount to sell, in whole tokens, e.g. 1.5")
+	cmd.Flags().StringVar(&f.address, "address", "", "address selling the tokens")
+	cmd.Flags().StringVar(&f.toAddress, "to-address", "", "address receiving the tokens (default --address)")
+	cmd.Flags().Float64Var(&f.slippage, "slippage", 0.5, "maximum slippage in percent")
+	cmd.Flags().StringVar(&f.minOutput, "min-output", "", "minimum amount to receive, in whole tokens; the swap fails rather than deliver less")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("amount")
+}
+
+// swapRequest builds the swap request the flags select, resolving the tokens against those the API lists
+func (c *cli) swapRequest(ctx context.Context, client *apiClient, f *swapFlags) (swapRequest, error) {
+	sourceChain, err := parseChain(f.chain)
+	if err != nil {
+		return swapRequest{}, err
+	}
+	destinationChain := sourceChain
+	if f.toChain != "" {
+		if destinationChain, err = parseChain(f.toChain); err != nil {
+			return swapRequest{}, err
+		}
+	}
+
+	var tokens tokensResponse
+	if err := client.get(ctx, "/api/v1/tokens", nil, &tokens); err != nil {
+		return swapRequest{}, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	source, err := resolveToken(tokens.Tokens, f.from, sourceChain)
+	if err != nil {
+		return swapRequest{}, err
+	}
+	destination, err := resolveToken(tokens.Tokens, f.to, destinationChain)
+	if err != nil {
+		return swapRequest{}, err
+	}
+
+	amount, err := services.ToBaseUnits(f.amount, source.Decimals)
+	if err != nil {
+		return swapRequest{}, fmt.Errorf("invalid --amount: %w", err)
+	}
+	request := swapRequest{
+		SourceToken:        source,
+		DestinationToken:   destination,
+		Amount:             amount.String(),
+		SourceAddress:      f.address,
+		DestinationAddress: f.toAddress,
+		Slippage:           f.slippage,
+	}
+	if request.DestinationAddress == "" {
+		request.DestinationAddress = f.address
+	}
+	if f.minOutput != "" {
+		minOutput, err := services.ToBaseUnits(f.minOutput, destination.Decimals)
+		if err != nil {
+			return swapRequest{}, fmt.Errorf("invalid --min-output: %w", err)
+		}
+		request.MinOutputAmount = minOutput.String()
+	}
+	return request, nil
+}
+
+// quoteCommand quotes a swap
+func (c *cli) quoteCommand() *cobra.Command {
+	var flags swapFlags
+	cmd := &cobra.Command{
+		Use:   "quote",
+		Short: "Quote a swap",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := c.client()
+			request, err := c.swapRequest(cmd.Context(), client, &flags)
+			if err != nil {
+				return err
+			}
+			var resp swapResponse
+			if err := client.post(cmd.Context(), "/api/v1/swap/quote", request, &resp); err != nil {
+				return err
+			}
+			return c.printSwap(resp)
+		},
+	}
+	flags.register(cmd)
+	return cmd
+}
+
+// swapCommand starts and confirms a swap, optionally watching it until it finishes
+func (c *cli) swapCommand() *cobra.Command {
+	var flags swapFlags
+	var noConfirm, watch bool
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "swap",
+		Short: "Start a swap",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.address == "" {
+				return errors.New("--address is required to swap")
+			}
+			client := c.client()
+			request, err := c.swapRequest(cmd.Context(), client, &flags)
+			if err != nil {
+				return err
+			}
+			var resp swapResponse
+			if err := client.post(cmd.Context(), "/api/v1/swap", request, &resp); err != nil {
+				return err
+			}
+			// Swap workflows wait for confirmation after quoting, except deposit-funded ones, which their deposit
+			// confirms; the signal is kept until they do
+			if !noConfirm && resp.Deposit == nil && resp.Status == "pending" {
+				var confirmed swapResponse
+				if err := client.post(cmd.Context(), "/api/v1/swap/"+url.PathEscape(resp.RequestID)+"/confirm", nil, &confirmed); err != nil {
+					return fmt.Errorf("swap %s started but not confirmed: %w", resp.RequestID, err)
+				}
+			}
+			if watch {
+				return c.watchSwap(cmd.Context(), client, resp.RequestID, interval)
+			}
+			return c.printSwap(resp)
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().BoolVar(&noConfirm, "no-confirm", false, "start the swap without confirming it")
+	cmd.Flags().BoolVar(&watch, "watch", false, "poll the swap's status until it finishes")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "how often --watch polls")
+	return cmd
+}
+
+// statusCommand shows a swap's status, optionally watching it until it finishes
+func (c *cli) statusCommand() *cobra.Command {
+	var watch bool
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "status REQUEST_ID",
+		Short: "Show a swap's status",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := c.client()
+			if watch {
+				return c.watchSwap(cmd.Context(), client, args[0], interval)
+			}
+			resp, err := swapStatus(cmd.Context(), client, args[0])
+			if err != nil {
+				return err
+			}
+			return c.printSwap(resp)
+		},
+	}
+	cmd.Flags().BoolVar(&watch, "watch", false, "poll the swap's status until it finishes")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "how often --watch polls")
+	return cmd
+}
+
+// swapStatus returns the status of a swap
+func swapStatus(ctx context.Context, client *apiClient, requestID string) (swapResponse, error) {
+	var resp swapResponse
+	err := client.get(ctx, "/api/v1/swap/"+url.PathEscape(requestID), nil, &resp)
+	return resp, err
+}
+
+// watchSwap polls a swap's status every interval, printing each change, until the swap finishes or ctx is done
+func (c *cli) watchSwap(ctx context.Context, client *apiClient, requestID string, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := ""
+	for {
+		resp, err := swapStatus(ctx, client, requestID)
+		if err != nil {
+			return err
+		}
+		if terminalStatuses[resp.Status] {
+			return c.printSwap(resp)
+		}
+		if resp.Status != last && !c.json {
+			fmt.Fprintf(c.out, "%s  %s\n", time.Now().Format(time.TimeOnly), resp.Status)
+			last = resp.Status
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tokensCommand lists the tradable tokens
+func (c *cli) tokensCommand() *cobra.Command {
+	var chain string
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "List the tradable tokens",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var chainID int64
+			if chain != "" {
+				var err error
+				if chainID, err = parseChain(chain); err != nil {
+					return err
+				}
+			}
+			var resp tokensResponse
+			if err := c.client().get(cmd.Context(), "/api/v1/tokens", nil, &resp); err != nil {
+				return err
+			}
+			if chainID != 0 {
+				filtered := resp.Tokens[:0]
+				for _, token := range resp.Tokens {
+					if types.CanonicalChainID(token.ChainID) == chainID {
+						filtered = append(filtered, token)
+					}
+				}
+				resp.Tokens = filtered
+			}
+			if c.json {
+				return c.printJSON(resp)
+			}
+
+			w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CHAIN\tSYMBOL\tNAME\tDECIMALS\tPRICE USD\tVERIFIED\tADDRESS")
+			for _, token := range resp.Tokens {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%t\t%s\n", token.ChainName, token.Symbol, token.Name, token.Decimals, formatUSD(token.PriceUSD), token.Verified, token.Address)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVar(&chain, "chain", "", "only list the tokens of a chain: name, chain ID or CAIP-2 ID")
+	return cmd
+}
+
+// poolsCommand lists the liquidity pools
+func (c *cli) poolsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pools",
+		Short: "List the liquidity pools, largest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp struct {
+				Pools []services.LiquidityPool `json:"pools"`
+			}
+			if err := c.client().get(cmd.Context(), "/api/v1/pools", nil, &resp); err != nil {
+				return err
+			}
+			if c.json {
+				return c.printJSON(resp)
+			}
+
+			w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tPAIR\tTVL USD\tAPR\tFEE TIER")
+			for _, pool := range resp.Pools {
+				pair := pool.Pair.BaseToken.Symbol + "/" + pool.Pair.QuoteToken.Symbol
+				fmt.Fprintf(w, "%s\t%s\t%s\t%.2f%%\t%d\n", pool.ID, pair, formatUSD(pool.TVL), pool.APR, pool.FeeTier)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// pricesCommand lists the latest prices, optionally of some symbols or one chain
+func (c *cli) pricesCommand() *cobra.Command {
+	var chain string
+	cmd := &cobra.Command{
+		Use:   "prices [SYMBOL...]",
+		Short: "List the latest token prices",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if len(args) > 0 {
+				query.Set("symbols", strings.Join(args, ","))
+			}
+			if chain != "" {
+				chainID, err := parseChain(chain)
+				if err != nil {
+					return err
+				}
+				query.Set("chainId", strconv.FormatInt(chainID, 10))
+			}
+			var resp pricesResponse
+			if err := c.client().get(cmd.Context(), "/api/v1/prices", query, &resp); err != nil {
+				return err
+			}
+			if c.json {
+				return c.printJSON(resp)
+			}
+
+			w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SYMBOL\tCHAIN\tPRICE USD\t24H\tSOURCE\tUPDATED")
+			for _, price := range resp.Prices {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%+.2f%%\t%s\t%s\n", price.Symbol, price.ChainName, formatUSD(price.PriceUSD), price.Change24h, price.Source, price.LastUpdated.Format(time.RFC3339))
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if resp.Stale {
+				fmt.Fprintf(c.out, "Prices are from the %s and may be stale\n", resp.Source)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&chain, "chain", "", "only list the prices of a chain: name, chain ID or CAIP-2 ID")
+	return cmd
+}
+
+// tokensResponse is returned by the tokens endpoint
+type tokensResponse struct {
+	Tokens  []types.TokenDetails `json:"tokens"`
+	Sandbox bool                 `json:"sandbox,omitempty"`
+}
+
+// pricesResponse is returned by the prices endpoint
+type pricesResponse struct {
+	Prices      []types.TokenPrice `json:"prices"`
+	Source      string             `json:"source"`
+	LastUpdated time.Time          `json:"lastUpdated"`
+	Stale       bool               `json:"stale,omitempty"`
+}
+
+// parseChain parses a chain name, chain ID or CAIP-2 ID into a canonical chain ID
+func parseChain(raw string) (int64, error) {
+	if chain, ok := types.GetChainByName(raw); ok {
+		return chain.ID, nil
+	}
+	return types.ParseChainID(raw)
+}
+
+// resolveToken finds a token on a chain among the tokens the API lists, by symbol. A native token's symbol also
+// matches the Universal wrapped token representing it, e.g. ETH matches uETH, whose decimals it shares.
+func resolveToken(tokens []types.TokenDetails, symbol string, chainID int64) (types.Token, error) {
+	var underlying *types.Token
+	for i := range tokens {
+		token := tokens[i].Token
+		if types.CanonicalChainID(token.ChainID) != chainID {
+			continue
+		}
+		if strings.EqualFold(token.Symbol, symbol) {
+			return token, nil
+		}
+		if token.IsWrapped && strings.EqualFold(token.UnderlyingSymbol(), symbol) && underlying == nil {
+			underlying = &types.Token{
+				Symbol:    token.UnderlyingSymbol(),
+				Decimals:  token.Decimals,
+				ChainID:   token.ChainID,
+				ChainName: token.ChainName,
+			}
+		}
+	}
+	if underlying != nil {
+		return *underlying, nil
+	}
+	return types.Token{}, fmt.Errorf("no token %s on chain %d; see infctl tokens", symbol, chainID)
+}
+
+// printSwap prints a swap response
+func (c *cli) printSwap(resp swapResponse) error {
+	if c.json {
+		return c.printJSON(resp)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Request ID:\t%s\n", resp.RequestID)
+	fmt.Fprintf(w, "Status:\t%s\n", resp.Status)
+	if resp.Sandbox {
+		fmt.Fprintf(w, "Sandbox:\tyes\n")
+	}
+	if resp.Region != "" {
+		fmt.Fprintf(w, "Region:\t%s\n", resp.Region)
+	}
+	if deposit := resp.Deposit; deposit != nil {
+		amount := deposit.Amount
+		if base, ok := new(big.Int).SetString(deposit.Amount, 10); ok {
+			amount = services.FromBaseUnits(base, deposit.Token.Decimals)
+		}
+		fmt.Fprintf(w, "Deposit:\t%s %s to %s by %s\n", amount, deposit.Token.Symbol, deposit.Address, deposit.ExpiresAt.Format(time.RFC3339))
+	}
+	if quote := resp.Quote; quote != nil {
+		fmt.Fprintf(w, "Input:\t%s %s\n", formatAmount(quote.InputAmount, quote.SourceToken.Decimals), quote.SourceToken.Symbol)
+		fmt.Fprintf(w, "Output:\t%s %s\n", formatAmount(quote.OutputAmount, quote.DestinationToken.Decimals), quote.DestinationToken.Symbol)
+		fmt.Fprintf(w, "Rate:\t%g\n", quote.ExchangeRate)
+		fmt.Fprintf(w, "Price impact:\t%.4f%%\n", quote.PriceImpact)
+		fmt.Fprintf(w, "Fees:\t%s\n", formatUSD(quote.Fee.TotalFeeUSD))
+		if len(quote.Path) > 0 {
+			fmt.Fprintf(w, "Path:\t%s\n", strings.Join(quote.Path, " -> "))
+		}
+		if quote.Bridge != "" {
+			fmt.Fprintf(w, "Bridge:\t%s\n", quote.Bridge)
+		}
+	}
+	if result := resp.Result; result != nil {
+		fmt.Fprintf(w, "Input:\t%s\n", formatAmount(result.InputAmount, 0))
+		fmt.Fprintf(w, "Output:\t%s\n", formatAmount(result.OutputAmount, 0))
+		fmt.Fprintf(w, "Fees:\t%s\n", formatUSD(result.Fee.TotalFeeUSD))
+		for _, tx := range []struct {
+			name string
+			tx   types.Transaction
+		}{{"Source tx", result.SourceTx}, {"Bridge tx", result.BridgeTx}, {"Destination tx", result.DestinationTx}} {
+			if tx.tx.Hash != "" {
+				fmt.Fprintf(w, "%s:\t%s (%s)\n", tx.name, tx.tx.Hash, tx.tx.Status)
+			}
+		}
+		if result.ErrorMessage != "" {
+			fmt.Fprintf(w, "Error:\t%s\n", result.ErrorMessage)
+		}
+		if result.ErrorCode != "" {
+			fmt.Fprintf(w, "Error code:\t%s\n", result.ErrorCode)
+		}
+	}
+	return w.Flush()
+}
+
+// printJSON prints a response as indented JSON
+func (c *cli) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// formatAmount formats a base-unit amount in whole tokens; results, which don't carry their tokens, stay in base
+// units
+func formatAmount(amount *big.Int, decimals int) string {
+	if amount == nil {
+		return "-"
+	}
+	if decimals == 0 {
+		return amount.String() + " (base units)"
+	}
+	return services.FromBaseUnits(amount, decimals)
+}
+
+// formatUSD formats a USD value, or - when there is none
+func formatUSD(value float64) string {
+	if value == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.2f", value)
+}
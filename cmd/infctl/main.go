@@ -0,0 +1,77 @@
+// Command infctl quotes, starts and tracks swaps and lists tokens, pools and prices through the Infinity DEX REST
+// API. The API is read from --api-url or INFCTL_API_URL and the API key from --api-key or INFCTL_API_KEY.
+// infctl migrate applies the database migrations, connecting with the workers' DB_* environment variables.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// Defaults of the global flags
+const (
+	defaultAPIURL = "http://localhost:8080"
+	apiURLEnv     = "INFCTL_API_URL"
+	apiKeyEnv     = "INFCTL_API_KEY"
+)
+
+// cli is the state shared by infctl's commands
+type cli struct {
+	apiURL string
+	apiKey string
+	json   bool
+	out    io.Writer
+}
+
+// client returns a client of the API the flags point at
+func (c *cli) client() *apiClient {
+	return newAPIClient(c.apiURL, c.apiKey)
+}
+
+// newRootCommand creates the infctl command, writing its output to out
+func newRootCommand(out io.Writer) *cobra.Command {
+	c := &cli{out: out}
+
+	apiURL := os.Getenv(apiURLEnv)
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+
+	root := &cobra.Command{
+		Use:           "infctl",
+		Short:         "Quote, start and track Infinity DEX swaps",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetOut(out)
+	root.PersistentFlags().StringVar(&c.apiURL, "api-url", apiURL, "base URL of the API server (env "+apiURLEnv+")")
+	root.PersistentFlags().StringVar(&c.apiKey, "api-key", os.Getenv(apiKeyEnv), "API key; sandbox keys run swaps in the sandbox (env "+apiKeyEnv+")")
+	root.PersistentFlags().BoolVar(&c.json, "json", false, "print the API's JSON responses instead of tables")
+
+	root.AddCommand(
+		c.quoteCommand(),
+		c.swapCommand(),
+		c.statusCommand(),
+		c.tokensCommand(),
+		c.poolsCommand(),
+		c.pricesCommand(),
+		c.migrateCommand(),
+	)
+	return root
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCommand(os.Stdout).ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI serves the endpoints infctl calls, recording the swap requests it receives
+type fakeAPI struct {
+	mu        sync.Mutex
+	requests  []swapRequest
+	confirmed []string
+	polls     int
+	apiKeys   []string
+}
+
+func (f *fakeAPI) handler() http.Handler {
+	uETH := types.Token{Symbol: "uETH", Name: "Universal ETH", Decimals: 18, Address: "0xeth", ChainID: 1, ChainName: "Ethereum", IsWrapped: true}
+	usdc := types.Token{Symbol: "USDC", Name: "USD Coin", Decimals: 6, Address: "0xusdc", ChainID: 1, ChainName: "Ethereum"}
+	quote := &types.SwapQuote{
+		SourceToken:      uETH,
+		DestinationToken: usdc,
+		InputAmount:      big.NewInt(1_500_000_000_000_000_000),
+		OutputAmount:     big.NewInt(4_500_000_000),
+		Fee:              types.Fee{TotalFeeUSD: 4.5},
+		Path:             []string{"uETH", "USDC"},
+		ExchangeRate:     3000,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/tokens", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r)
+		writeTestJSON(w, http.StatusOK, tokensResponse{Tokens: []types.TokenDetails{
+			{Token: uETH, Verified: true, PriceUSD: 3000},
+			{Token: usdc, Verified: true, PriceUSD: 1},
+		}})
+	})
+	mux.HandleFunc("POST /api/v1/swap/quote", func(w http.ResponseWriter, r *http.Request) {
+		f.decode(r)
+		writeTestJSON(w, http.StatusOK, swapResponse{RequestID: "quote-1", Status: "quote_ready", Quote: quote})
+	})
+	mux.HandleFunc("POST /api/v1/swap", func(w http.ResponseWriter, r *http.Request) {
+		f.decode(r)
+		writeTestJSON(w, http.StatusAccepted, swapResponse{RequestID: "swap-1", Status: "pending"})
+	})
+	mux.HandleFunc("POST /api/v1/swap/{id}/confirm", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.confirmed = append(f.confirmed, r.PathValue("id"))
+		f.mu.Unlock()
+		writeTestJSON(w, http.StatusOK, swapResponse{RequestID: r.PathValue("id"), Status: "confirmed"})
+	})
+	mux.HandleFunc("GET /api/v1/swap/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "swap-1" {
+			writeTestJSON(w, http.StatusNotFound, apiError{Message: "swap not found", Code: "WORKFLOW_NOT_FOUND", RequestID: "req-1"})
+			return
+		}
+		f.mu.Lock()
+		f.polls++
+		polls := f.polls
+		f.mu.Unlock()
+
+		// The swap runs for two polls, then completes
+		if polls < 3 {
+			writeTestJSON(w, http.StatusOK, swapResponse{RequestID: "swap-1", Status: "running"})
+			return
+		}
+		writeTestJSON(w, http.StatusOK, swapResponse{RequestID: "swap-1", Status: "completed", Result: &types.SwapResult{
+			RequestID:    "swap-1",
+			Success:      true,
+			SourceTx:     types.Transaction{Hash: "0xsource", Status: "completed"},
+			InputAmount:  quote.InputAmount,
+			OutputAmount: quote.OutputAmount,
+		}})
+	})
+	mux.HandleFunc("GET /api/v1/pools", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]interface{}{"pools": []services.LiquidityPool{
+			{ID: "pool-1", Pair: services.TokenPair{BaseToken: services.Token{Symbol: "uETH"}, QuoteToken: services.Token{Symbol: "USDC"}}, TVL: 1_000_000, APR: 12.5, FeeTier: 30},
+		}})
+	})
+	mux.HandleFunc("GET /api/v1/prices", func(w http.ResponseWriter, r *http.Request) {
+		var prices []types.TokenPrice
+		for _, symbol := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+			prices = append(prices, types.TokenPrice{Symbol: symbol, ChainName: "Ethereum", PriceUSD: 3000, Change24h: -1.25, Source: "coingecko"})
+		}
+		writeTestJSON(w, http.StatusOK, pricesResponse{Prices: prices, Source: "cache", Stale: r.URL.Query().Get("chainId") == "1"})
+	})
+	return mux
+}
+
+func (f *fakeAPI) record(r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.apiKeys = append(f.apiKeys, r.Header.Get(apiKeyHeader))
+}
+
+func (f *fakeAPI) decode(r *http.Request) {
+	var request swapRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		panic(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, request)
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// run runs infctl against the fake API, returning its output
+func run(t *testing.T, api *fakeAPI, args ...string) (string, error) {
+	t.Helper()
+	srv := httptest.NewServer(api.handler())
+	t.Cleanup(srv.Close)
+
+	var out bytes.Buffer
+	cmd := newRootCommand(&out)
+	cmd.SetArgs(append([]string{"--api-url", srv.URL, "--api-key", "key-1"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestQuote(t *testing.T) {
+	api := &fakeAPI{}
+	out, err := run(t, api, "quote", "--from", "ETH", "--to", "usdc", "--chain", "ethereum", "--amount", "1.5")
+	require.NoError(t, err)
+
+	require.Len(t, api.requests, 1)
+	request := api.requests[0]
+	// ETH resolves to the native token uETH wraps, with its decimals
+	assert.Equal(t, "ETH", request.SourceToken.Symbol)
+	assert.Equal(t, 18, request.SourceToken.Decimals)
+	assert.Equal(t, "USDC", request.DestinationToken.Symbol)
+	assert.Equal(t, "1500000000000000000", request.Amount)
+	assert.Equal(t, 0.5, request.Slippage)
+	assert.Equal(t, []string{"key-1"}, api.apiKeys)
+
+	assert.Contains(t, out, "quote_ready")
+	assert.Contains(t, out, "4500 USDC")
+	assert.Contains(t, out, "uETH -> USDC")
+	assert.Contains(t, out, "$4.50")
+}
+
+func TestQuoteErrors(t *testing.T) {
+	_, err := run(t, &fakeAPI{}, "quote", "--from", "BTC", "--to", "USDC", "--amount", "1")
+	assert.ErrorContains(t, err, "no token BTC on chain 1")
+
+	_, err = run(t, &fakeAPI{}, "quote", "--from", "ETH", "--to", "USDC", "--chain", "atlantis", "--amount", "1")
+	assert.Error(t, err)
+
+	_, err = run(t, &fakeAPI{}, "quote", "--from", "ETH", "--to", "USDC", "--amount", "one")
+	assert.ErrorContains(t, err, "invalid --amount")
+
+	_, err = run(t, &fakeAPI{}, "quote", "--from", "ETH", "--to", "USDC")
+	assert.ErrorContains(t, err, `"amount" not set`)
+}
+
+func TestSwap(t *testing.T) {
+	t.Run("ConfirmsAndWatches", func(t *testing.T) {
+		api := &fakeAPI{}
+		out, err := run(t, api, "swap", "--from", "uETH", "--to", "USDC", "--amount", "1.5", "--address", "0xuser",
+			"--slippage", "1", "--watch", "--interval", "10ms")
+		require.NoError(t, err)
+
+		require.Len(t, api.requests, 1)
+		assert.Equal(t, "0xuser", api.requests[0].SourceAddress)
+		assert.Equal(t, "0xuser", api.requests[0].DestinationAddress)
+		assert.Equal(t, 1.0, api.requests[0].Slippage)
+		assert.Equal(t, []string{"swap-1"}, api.confirmed)
+		assert.Equal(t, 3, api.polls)
+
+		assert.Contains(t, out, "running\n")
+		assert.Contains(t, out, "completed")
+		assert.Contains(t, out, "0xsource (completed)")
+	})
+
+	t.Run("NoConfirm", func(t *testing.T) {
+		api := &fakeAPI{}
+		_, err := run(t, api, "swap", "--from", "uETH", "--to", "USDC", "--amount", "1", "--address", "0xuser", "--to-address", "0xother", "--min-output", "2900.5", "--no-confirm")
+		require.NoError(t, err)
+
+		require.Len(t, api.requests, 1)
+		assert.Equal(t, "0xother", api.requests[0].DestinationAddress)
+		assert.Equal(t, "2900500000", api.requests[0].MinOutputAmount)
+		assert.Empty(t, api.confirmed)
+	})
+
+	t.Run("RequiresAddress", func(t *testing.T) {
+		_, err := run(t, &fakeAPI{}, "swap", "--from", "uETH", "--to", "USDC", "--amount", "1")
+		assert.ErrorContains(t, err, "--address is required")
+	})
+}
+
+func TestStatus(t *testing.T) {
+	api := &fakeAPI{}
+	out, err := run(t, api, "--json", "status", "swap-1")
+	require.NoError(t, err)
+	var resp swapResponse
+	require.NoError(t, json.Unmarshal([]byte(out), &resp))
+	assert.Equal(t, "running", resp.Status)
+
+	_, err = run(t, api, "status", "missing")
+	var apiErr *apiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Equal(t, "WORKFLOW_NOT_FOUND: swap not found (request req-1)", err.Error())
+}
+
+func TestWatchStopsWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, swapResponse{RequestID: "swap-1", Status: "running"})
+	}))
+	defer srv.Close()
+
+	c := &cli{out: &bytes.Buffer{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.watchSwap(ctx, newAPIClient(srv.URL, ""), "swap-1", 10*time.Millisecond)
+	assert.ErrorIs(t, err, ctx.Err())
+}
+
+func TestListings(t *testing.T) {
+	api := &fakeAPI{}
+
+	out, err := run(t, api, "tokens", "--chain", "1")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "SYMBOL")
+	assert.Contains(t, lines[1], "uETH")
+	assert.Contains(t, lines[1], "$3000.00")
+
+	out, err = run(t, api, "tokens", "--chain", "solana")
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(out, "\n"))
+
+	out, err = run(t, api, "pools")
+	require.NoError(t, err)
+	assert.Contains(t, out, "uETH/USDC")
+	assert.Contains(t, out, "12.50%")
+
+	out, err = run(t, api, "prices", "ETH", "BTC", "--chain", "ethereum")
+	require.NoError(t, err)
+	assert.Contains(t, out, "ETH ")
+	assert.Contains(t, out, "BTC ")
+	assert.Contains(t, out, "-1.25%")
+	assert.Contains(t, out, "may be stale")
+}
+
+func TestMigrateRejectsBadVersions(t *testing.T) {
+	for _, version := range []string{"abc", "1.5"} {
+		_, err := run(t, &fakeAPI{}, "migrate", "to", version)
+		assert.ErrorContains(t, err, "invalid version", version)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/infinity-dex/db/migrations"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+// migrateCommand applies and inspects the database migrations embedded in infctl. Unlike the other commands it
+// connects to the database directly, with the DB_* environment variables the workers use.
+func (c *cli) migrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply or undo database migrations (connects with DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME)",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply the schema and every pending migration",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.withDatabase(func(pool *pgxpool.Pool) error {
+					if err := migrations.Up(cmd.Context(), pool); err != nil {
+						return err
+					}
+					return c.printMigrationStatus(cmd.Context(), pool)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "to VERSION",
+			Short: "Migrate up or down to a version; 0 undoes every migration that can be undone",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseInt(args[0], 10, 32)
+				if err != nil || version < 0 {
+					return fmt.Errorf("invalid version %q", args[0])
+				}
+				return c.withDatabase(func(pool *pgxpool.Pool) error {
+					if err := migrations.To(cmd.Context(), pool, int32(version)); err != nil {
+						return err
+					}
+					return c.printMigrationStatus(cmd.Context(), pool)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show the database's migration version and the newest one",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.withDatabase(func(pool *pgxpool.Pool) error {
+					return c.printMigrationStatus(cmd.Context(), pool)
+				})
+			},
+		},
+	)
+	return cmd
+}
+
+// withDatabase calls fn with a pool connected to the database of the DB_* environment variables
+func (c *cli) withDatabase(fn func(*pgxpool.Pool) error) error {
+	dbConfig, err := temporal_config.LoadDBConfig()
+	if err != nil {
+		return err
+	}
+	pool, err := temporal_config.NewDBPool(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	return fn(pool)
+}
+
+// printMigrationStatus prints the database's migration version
+func (c *cli) printMigrationStatus(ctx context.Context, pool *pgxpool.Pool) error {
+	status, err := migrations.CurrentStatus(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(status)
+	}
+	pending := "up to date"
+	if status.Pending() {
+		pending = fmt.Sprintf("%d pending", status.Latest-status.Current)
+	}
+	_, err = fmt.Fprintf(c.out, "Version %d of %d (%s)\n", status.Current, status.Latest, pending)
+	return err
+}
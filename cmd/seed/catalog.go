@@ -0,0 +1,98 @@
+package main
+
+import "github.com/infinity-dex/services/types"
+
+// nativeAddress is the placeholder address of EVM chains' gas tokens
+const nativeAddress = "0x0000000000000000000000000000000000000000"
+
+// solanaChainID is the canonical chain ID of Solana
+const solanaChainID = 1399811149
+
+// seedToken is a token the seed writes to the registry, with the market it is given
+type seedToken struct {
+	Symbol      string
+	Name        string
+	Decimals    int
+	ChainID     int64
+	Address     string
+	CoinGeckoID string
+	PriceUSD    float64 // Current price; history is walked back from it
+	Volatility  float64 // Daily volatility of the price, e.g. 0.04 for 4%
+	Volume24h   float64
+	Supply      float64 // Circulating supply, for the market cap
+	Listed      bool    // Added as if approved through a listing request, in demo mode only
+}
+
+// stable reports whether the token is a dollar stablecoin, whose price stays pinned near $1
+func (t seedToken) stable() bool {
+	return t.PriceUSD == 1
+}
+
+// token returns the registry token
+func (t seedToken) token(chainName string) types.Token {
+	return types.Token{
+		Symbol:    t.Symbol,
+		Name:      t.Name,
+		Decimals:  t.Decimals,
+		Address:   t.Address,
+		ChainID:   t.ChainID,
+		ChainName: chainName,
+	}
+}
+
+// catalog is the seeded tokens on every chain the default config has. Only tokens on configured chains are
+// seeded. Addresses are the tokens' mainnet contracts.
+var catalog = []seedToken{
+	{Symbol: "ETH", Name: "Ether", Decimals: 18, ChainID: 1, Address: nativeAddress, CoinGeckoID: "ethereum", PriceUSD: 3150, Volatility: 0.035, Volume24h: 14e9, Supply: 120e6},
+	{Symbol: "WBTC", Name: "Wrapped Bitcoin", Decimals: 8, ChainID: 1, Address: "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599", CoinGeckoID: "wrapped-bitcoin", PriceUSD: 64200, Volatility: 0.03, Volume24h: 310e6, Supply: 154e3},
+	{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: 1, Address: "0xA0b86991c6218b36c1d19D4a2E9Eb0cE3606eB48", CoinGeckoID: "usd-coin", PriceUSD: 1, Volume24h: 6.2e9, Supply: 33e9},
+	{Symbol: "USDT", Name: "Tether USD", Decimals: 6, ChainID: 1, Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7", CoinGeckoID: "tether", PriceUSD: 1, Volume24h: 41e9, Supply: 110e9},
+	{Symbol: "DAI", Name: "Dai Stablecoin", Decimals: 18, ChainID: 1, Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F", CoinGeckoID: "dai", PriceUSD: 1, Volume24h: 180e6, Supply: 5.3e9},
+	{Symbol: "LINK", Name: "Chainlink", Decimals: 18, ChainID: 1, Address: "0x514910771AF9Ca656af840dff83E8264EcF986CA", CoinGeckoID: "chainlink", PriceUSD: 14.2, Volatility: 0.05, Volume24h: 420e6, Supply: 587e6, Listed: true},
+	{Symbol: "UNI", Name: "Uniswap", Decimals: 18, ChainID: 1, Address: "0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984", CoinGeckoID: "uniswap", PriceUSD: 7.6, Volatility: 0.055, Volume24h: 150e6, Supply: 600e6, Listed: true},
+
+	{Symbol: "MATIC", Name: "Polygon", Decimals: 18, ChainID: 137, Address: nativeAddress, CoinGeckoID: "matic-network", PriceUSD: 0.52, Volatility: 0.05, Volume24h: 260e6, Supply: 9.9e9},
+	{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: 137, Address: "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359", CoinGeckoID: "usd-coin", PriceUSD: 1, Volume24h: 240e6, Supply: 33e9},
+	{Symbol: "USDT", Name: "Tether USD", Decimals: 6, ChainID: 137, Address: "0xc2132D05D31c914a87C6611C10748AEb04B58e8F", CoinGeckoID: "tether", PriceUSD: 1, Volume24h: 190e6, Supply: 110e9},
+	{Symbol: "DAI", Name: "Dai Stablecoin", Decimals: 18, ChainID: 137, Address: "0x8f3Cf7ad23Cd3CaDbD9735AFf958023239c6A063", CoinGeckoID: "dai", PriceUSD: 1, Volume24h: 21e6, Supply: 5.3e9},
+
+	{Symbol: "SOL", Name: "Solana", Decimals: 9, ChainID: solanaChainID, Address: "So11111111111111111111111111111111111111112", CoinGeckoID: "solana", PriceUSD: 148, Volatility: 0.05, Volume24h: 2.8e9, Supply: 465e6},
+	{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: solanaChainID, Address: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", CoinGeckoID: "usd-coin", PriceUSD: 1, Volume24h: 1.1e9, Supply: 33e9},
+	{Symbol: "USDT", Name: "Tether USD", Decimals: 6, ChainID: solanaChainID, Address: "Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB", CoinGeckoID: "tether", PriceUSD: 1, Volume24h: 95e6, Supply: 110e9},
+
+	{Symbol: "AVAX", Name: "Avalanche", Decimals: 18, ChainID: 43114, Address: nativeAddress, CoinGeckoID: "avalanche-2", PriceUSD: 27.4, Volatility: 0.05, Volume24h: 310e6, Supply: 406e6},
+	{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: 43114, Address: "0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E", CoinGeckoID: "usd-coin", PriceUSD: 1, Volume24h: 120e6, Supply: 33e9},
+	{Symbol: "USDT", Name: "Tether USD", Decimals: 6, ChainID: 43114, Address: "0x9702230A8Ea53601f5cD2dc00fDBc13d4dF4A8c7", CoinGeckoID: "tether", PriceUSD: 1, Volume24h: 64e6, Supply: 110e9},
+	{Symbol: "DAI", Name: "Dai Stablecoin", Decimals: 18, ChainID: 43114, Address: "0xd586E7F844cEa2F87f50152665BCbc2C279D8d70", CoinGeckoID: "dai", PriceUSD: 1, Volume24h: 4e6, Supply: 5.3e9},
+
+	{Symbol: "BNB", Name: "BNB", Decimals: 18, ChainID: 56, Address: nativeAddress, CoinGeckoID: "binancecoin", PriceUSD: 585, Volatility: 0.03, Volume24h: 1.6e9, Supply: 146e6},
+	{Symbol: "USDC", Name: "USD Coin", Decimals: 18, ChainID: 56, Address: "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d", CoinGeckoID: "usd-coin", PriceUSD: 1, Volume24h: 310e6, Supply: 33e9},
+	{Symbol: "USDT", Name: "Tether USD", Decimals: 18, ChainID: 56, Address: "0x55d398326f99059fF775485246999027B3197955", CoinGeckoID: "tether", PriceUSD: 1, Volume24h: 2.2e9, Supply: 110e9},
+}
+
+// demoPool is a pool seeded in demo mode, with the liquidity it is given
+type demoPool struct {
+	ID         string
+	BaseToken  string
+	QuoteToken string
+	ChainID    int64
+	FeeTier    int
+	TVL        float64 // USD value of the liquidity added, split between the demo LPs
+	APR        float64 // Percent
+}
+
+// demoPools are seeded alongside the configured pools in demo mode; configured pools with the same ID win
+var demoPools = []demoPool{
+	{ID: "eth-usdc-3000", BaseToken: "ETH", QuoteToken: "USDC", ChainID: 1, FeeTier: 3000, TVL: 18.4e6, APR: 11.2},
+	{ID: "eth-usdc-500", BaseToken: "ETH", QuoteToken: "USDC", ChainID: 1, FeeTier: 500, TVL: 42.7e6, APR: 6.8},
+	{ID: "wbtc-eth-3000", BaseToken: "WBTC", QuoteToken: "ETH", ChainID: 1, FeeTier: 3000, TVL: 9.1e6, APR: 8.4},
+	{ID: "usdc-usdt-100", BaseToken: "USDC", QuoteToken: "USDT", ChainID: 1, FeeTier: 100, TVL: 25.3e6, APR: 3.1},
+	{ID: "link-eth-3000", BaseToken: "LINK", QuoteToken: "ETH", ChainID: 1, FeeTier: 3000, TVL: 1.9e6, APR: 14.6},
+	{ID: "matic-usdc-3000", BaseToken: "MATIC", QuoteToken: "USDC", ChainID: 137, FeeTier: 3000, TVL: 2.4e6, APR: 17.9},
+	{ID: "sol-usdc-3000", BaseToken: "SOL", QuoteToken: "USDC", ChainID: solanaChainID, FeeTier: 3000, TVL: 6.6e6, APR: 21.5},
+	{ID: "avax-usdc-3000", BaseToken: "AVAX", QuoteToken: "USDC", ChainID: 43114, FeeTier: 3000, TVL: 1.3e6, APR: 15.2},
+	{ID: "bnb-usdt-500", BaseToken: "BNB", QuoteToken: "USDT", ChainID: 56, FeeTier: 500, TVL: 4.8e6, APR: 9.7},
+}
+
+// demoLiquidityShares splits each demo pool's liquidity between demo LPs, largest first
+var demoLiquidityShares = []float64{0.55, 0.3, 0.15}
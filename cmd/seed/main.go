@@ -0,0 +1,74 @@
+// Command seed populates the database with a token registry, prices and liquidity pools, so local environments
+// start from a usable state. With --demo it also writes price history, pools with LP positions and sample swaps,
+// then keeps prices moving until it is interrupted.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/infinity-dex/services/repository"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path to the configuration file")
+	demo := flag.Bool("demo", false, "also seed price history, pools with liquidity and sample swaps, then keep prices moving")
+	days := flag.Int("days", 30, "days of hourly price history to seed in demo mode")
+	swaps := flag.Int("swaps", 200, "sample swaps to seed in demo mode")
+	tick := flag.Duration("tick", 15*time.Second, "how often demo mode moves prices; 0 exits once seeded")
+	flag.Parse()
+
+	cfg, err := temporal_config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if *days < 1 {
+		log.Fatalf("--days must be at least 1")
+	}
+
+	// The seed may be the first thing run against a fresh database, so it sets up the schema like the price worker
+	dbConfig, err := temporal_config.LoadDBConfig()
+	if err != nil {
+		log.Fatalf("Failed to load database config: %v", err)
+	}
+	dbPool, err := temporal_config.NewDBPool(dbConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	defer dbPool.Close()
+	if err := temporal_config.MigrateDatabase(dbPool); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	seeder := NewSeeder(cfg, Stores{
+		Prices:       repository.NewPriceRepository(dbPool),
+		Metadata:     repository.NewTokenMetadataRepository(dbPool),
+		ListedTokens: repository.NewListedTokenRepository(dbPool),
+		Pools:        repository.NewPoolRepository(dbPool),
+		SwapArchive:  repository.NewSwapArchiveRepository(dbPool),
+		SwapStats:    repository.NewSwapStatsRepository(dbPool),
+	}, *days, *swaps)
+	summary, err := seeder.Seed(ctx, *demo)
+	if err != nil {
+		log.Fatalf("Failed to seed: %v", err)
+	}
+	log.Printf("Seeded %d tokens (%d listed), %d prices, %d pools with %d demo positions, %d sample swaps and %d candles",
+		summary.Tokens, summary.ListedTokens, summary.Prices, summary.Pools, summary.Positions, summary.Swaps, summary.Candles)
+	if summary.HistoryKept > 0 {
+		log.Printf("Kept the existing price history of %d tokens", summary.HistoryKept)
+	}
+
+	if *demo && *tick > 0 {
+		log.Printf("Moving demo prices every %s; interrupt to stop", *tick)
+		seeder.RunDemo(ctx, *tick)
+	}
+}
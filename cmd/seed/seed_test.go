@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePriceStore keeps prices the way update_token_price does: the latest saved price is current, and a price
+// is added to the history when it differs from the current one
+type fakePriceStore struct {
+	current map[string]types.TokenPrice
+	history map[string][]types.TokenPrice
+	saves   int
+	rollups []string
+}
+
+func newFakePriceStore() *fakePriceStore {
+	return &fakePriceStore{current: make(map[string]types.TokenPrice), history: make(map[string][]types.TokenPrice)}
+}
+
+func (f *fakePriceStore) SaveTokenPrices(ctx context.Context, prices []types.TokenPrice) error {
+	f.saves++
+	for _, price := range prices {
+		key := seriesKey(price.Symbol, price.ChainID)
+		if current, ok := f.current[key]; !ok || current.PriceUSD != price.PriceUSD {
+			f.history[key] = append(f.history[key], price)
+		}
+		f.current[key] = price
+	}
+	return nil
+}
+
+func (f *fakePriceStore) GetTokenPriceHistory(ctx context.Context, symbol string, chainID int64, start, end time.Time) ([]types.TokenPriceHistory, error) {
+	var history []types.TokenPriceHistory
+	for _, price := range f.history[seriesKey(symbol, chainID)] {
+		if !price.LastUpdated.Before(start) && !price.LastUpdated.After(end) {
+			history = append(history, types.TokenPriceHistory{Symbol: symbol, ChainID: chainID, PriceUSD: price.PriceUSD, Timestamp: price.LastUpdated})
+		}
+	}
+	return history, nil
+}
+
+func (f *fakePriceStore) RollupCandles(ctx context.Context, interval string) (int64, error) {
+	f.rollups = append(f.rollups, interval)
+	return 1, nil
+}
+
+// seedConfig configures Ethereum and Solana with one pool that a demo pool shares its ID with
+func seedConfig() temporal_config.Config {
+	cfg := temporal_config.DefaultConfig()
+	cfg.Chains = map[string]temporal_config.ChainConfig{
+		"ethereum": {Name: "Ethereum", ChainID: 1},
+		"solana":   {Name: "Solana", ChainID: solanaChainID},
+	}
+	cfg.Pools = []temporal_config.PoolConfig{{ID: "eth-usdc-3000", BaseToken: "ETH", QuoteToken: "USDC", ChainID: 1, FeeTier: 3000}}
+	return cfg
+}
+
+func newTestSeeder() (*Seeder, *fakePriceStore, Stores) {
+	prices := newFakePriceStore()
+	stores := Stores{
+		Prices:       prices,
+		Metadata:     services.NewInMemoryTokenMetadataStore(),
+		ListedTokens: services.NewInMemoryListedTokenStore(),
+		Pools:        services.NewInMemoryPoolStore(),
+		SwapArchive:  services.NewInMemorySwapArchive(),
+		SwapStats:    services.NewInMemorySwapStatsStore(),
+	}
+	seeder := NewSeeder(seedConfig(), stores, 7, 40)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	seeder.now = func() time.Time { return now }
+	return seeder, prices, stores
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	seeder, prices, stores := newTestSeeder()
+
+	summary, err := seeder.Seed(ctx, false)
+	require.NoError(t, err)
+
+	// Only the configured chains' tokens are seeded, without the listed ones
+	assert.Equal(t, 8, summary.Tokens)
+	assert.Zero(t, summary.ListedTokens)
+	metadata, err := stores.Metadata.FindTokenMetadata(ctx, 1, []string{"USDC", "LINK"})
+	require.NoError(t, err)
+	require.Len(t, metadata, 1)
+	assert.Equal(t, 6, metadata[0].Decimals)
+	assert.Equal(t, "0xA0b86991c6218b36c1d19D4a2E9Eb0cE3606eB48", metadata[0].Address)
+
+	// Current prices only, at the catalog price
+	assert.Equal(t, 3150.0, prices.current[seriesKey("ETH", 1)].PriceUSD)
+	assert.Len(t, prices.history[seriesKey("ETH", 1)], 1)
+	assert.Empty(t, prices.rollups)
+
+	// The configured pool, without liquidity
+	assert.Equal(t, 1, summary.Pools)
+	state, err := stores.Pools.GetPool(ctx, "eth-usdc-3000")
+	require.NoError(t, err)
+	assert.Empty(t, state.Positions)
+	assert.Zero(t, summary.Swaps)
+}
+
+func TestSeedDemo(t *testing.T) {
+	ctx := context.Background()
+	seeder, prices, stores := newTestSeeder()
+
+	summary, err := seeder.Seed(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, 10, summary.Tokens)
+	assert.Equal(t, 2, summary.ListedTokens)
+
+	t.Run("PriceHistory", func(t *testing.T) {
+		history := prices.history[seriesKey("ETH", 1)]
+		require.Len(t, history, 7*24+1)
+		assert.Equal(t, seeder.now().Add(-7*24*time.Hour), history[0].LastUpdated)
+		// The walk ends at the current price, which is the latest saved
+		assert.Equal(t, 3150.0, prices.current[seriesKey("ETH", 1)].PriceUSD)
+		assert.Equal(t, seeder.now(), prices.current[seriesKey("ETH", 1)].LastUpdated)
+		for _, price := range history {
+			assert.InDelta(t, 3150, price.PriceUSD, 3150*0.5)
+			assert.Equal(t, types.PriceSourceSeed, price.Source)
+		}
+		for _, price := range prices.history[seriesKey("USDT", solanaChainID)] {
+			assert.InDelta(t, 1, price.PriceUSD, 0.01)
+		}
+		assert.ElementsMatch(t, []string{"1d", "1h", "1m", "5m"}, prices.rollups)
+	})
+
+	t.Run("Pools", func(t *testing.T) {
+		// The configured pool plus the demo pools on configured chains
+		assert.Equal(t, 6, summary.Pools)
+		assert.Equal(t, 6*len(demoLiquidityShares), summary.Positions)
+		state, err := stores.Pools.GetPool(ctx, "sol-usdc-3000")
+		require.NoError(t, err)
+		assert.Len(t, state.Positions, len(demoLiquidityShares))
+		assert.Equal(t, 6.6e6, state.Pool.TVL)
+		// Half the liquidity is in each token: $3.3M of SOL at $148, in lamports
+		assert.InDelta(t, 3.3e6/148*1e9, float64(state.Pool.Reserves["SOL"].Int64()), 1e6)
+		assert.InDelta(t, 3.3e6*1e6, float64(state.Pool.Reserves["USDC"].Int64()), 1e3)
+		_, err = stores.Pools.GetPool(ctx, "matic-usdc-3000")
+		assert.ErrorIs(t, err, types.ErrPoolNotFound)
+	})
+
+	t.Run("Swaps", func(t *testing.T) {
+		assert.Equal(t, 40, summary.Swaps)
+		failed := 0
+		for i := 1; i <= 40; i++ {
+			swap, err := stores.SwapArchive.GetArchivedSwap(ctx, fmt.Sprintf("demo-swap-%04d", i))
+			require.NoError(t, err)
+			// Solana has no EVM addresses, so its tokens aren't swapped
+			assert.Equal(t, int64(1), swap.Request.SourceToken.ChainID)
+			assert.NotEqual(t, swap.Request.SourceToken.Symbol, swap.Request.DestinationToken.Symbol)
+			assert.True(t, swap.ClosedAt.After(swap.StartedAt))
+			assert.False(t, swap.StartedAt.Before(seeder.now().Add(-8*24*time.Hour)))
+			assert.Positive(t, swap.Request.Amount.Sign())
+			if swap.Result.Success {
+				assert.Positive(t, swap.Result.OutputAmount.Sign())
+				assert.Equal(t, types.SwapStatusCompleted, swap.Result.Status)
+			} else {
+				failed++
+				assert.Equal(t, types.SwapErrorQuoteDrift, swap.Result.ErrorCode)
+			}
+		}
+		assert.Less(t, failed, 20)
+
+		// Every swap is counted, failed ones without volume
+		totals, err := stores.SwapStats.SwapTotals(ctx, time.Time{})
+		require.NoError(t, err)
+		var swaps, succeeded int64
+		var volume float64
+		for _, total := range totals {
+			swaps += total.Swaps
+			succeeded += total.Succeeded
+			volume += total.VolumeUSD
+		}
+		assert.Equal(t, int64(40), swaps)
+		assert.Equal(t, int64(40-failed), succeeded)
+		assert.Positive(t, volume)
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		historyLength := len(prices.history[seriesKey("ETH", 1)])
+		state, err := stores.Pools.GetPool(ctx, "eth-usdc-500")
+		require.NoError(t, err)
+
+		again, err := seeder.Seed(ctx, true)
+		require.NoError(t, err)
+		assert.Equal(t, 10, again.HistoryKept)
+		assert.Len(t, prices.history[seriesKey("ETH", 1)], historyLength)
+		repeated, err := stores.Pools.GetPool(ctx, "eth-usdc-500")
+		require.NoError(t, err)
+		assert.Equal(t, state.Pool.Reserves, repeated.Pool.Reserves)
+		assert.Len(t, repeated.Positions, len(demoLiquidityShares))
+	})
+}
+
+func TestTick(t *testing.T) {
+	ctx := context.Background()
+	seeder, prices, _ := newTestSeeder()
+	_, err := seeder.Seed(ctx, true)
+	require.NoError(t, err)
+
+	later := seeder.now().Add(15 * time.Second)
+	seeder.now = func() time.Time { return later }
+	saves := prices.saves
+	require.NoError(t, seeder.Tick(ctx, 15*time.Second, rand.New(rand.NewSource(1))))
+	assert.Equal(t, saves+1, prices.saves)
+
+	eth := prices.current[seriesKey("ETH", 1)]
+	assert.Equal(t, later, eth.LastUpdated)
+	assert.NotEqual(t, 3150.0, eth.PriceUSD)
+	// A 15 second step of 3.5% daily volatility moves the price well under 1%
+	assert.Less(t, math.Abs(eth.PriceUSD/3150-1), 0.01)
+	assert.InDelta(t, 1, prices.current[seriesKey("USDC", 1)].PriceUSD, 0.01)
+}
+
+func TestBaseUnits(t *testing.T) {
+	amount, err := baseUnits(1.5, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "1500000", amount.String())
+
+	amount, err = baseUnits(0.000123, 18)
+	require.NoError(t, err)
+	assert.Equal(t, 15, len(amount.String()))
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+	enumspb "go.temporal.io/api/enums/v1"
+)
+
+// PriceOracleTaskQueue is the name of the task queue used for price oracle workflows
+const PriceOracleTaskQueue = "price-oracle-queue"
+
+// defaultAuditLimit is the number of audit entries returned when no limit is given
+const defaultAuditLimit = 100
+
+// stuckSwapAge is how long a swap workflow may run before operators can retry or refund it
+const stuckSwapAge = 30 * time.Minute
+
+// errSwapNotRemediable is returned when a swap to retry or refund neither failed nor is stuck
+var errSwapNotRemediable = errors.New("only failed or stuck swaps can be retried or refunded")
+
+// AdminAction describes a remediation operators can run
+type AdminAction struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Params      []string `json:"params,omitempty"`
+	Available   bool     `json:"available"`
+
+	workflow  interface{}
+	taskQueue string
+	run       func(s *Server, input temporal_workflows.AdminActionInput) error // Runs the action on this server instead of in a workflow
+}
+
+// adminActions lists the remediations exposed by the admin API
+var adminActions = []AdminAction{
+	{
+		Name:        temporal_workflows.AdminActionRetrySwap,
+		Description: "Re-run a swap workflow from the quote or execute step without user confirmation",
+		Params:      []string{"requestId", "step"},
+		Available:   true,
+		workflow:    temporal_workflows.RetrySwapWorkflow,
+		taskQueue:   SwapTaskQueue,
+	},
+	{
+		Name:        temporal_workflows.AdminActionForceRefund,
+		Description: "Mark a swap refunded and record a refund of its input to the sender",
+		Params:      []string{"requestId"},
+		Available:   true,
+		workflow:    temporal_workflows.ForceRefundWorkflow,
+		taskQueue:   SwapTaskQueue,
+	},
+	{
+		Name:        temporal_workflows.AdminActionResolveDeadLetter,
+		Description: "Requeue a dead-lettered swap from the quote or execute step, or refund it, stopping it first if it is stuck",
+		Params:      []string{"requestId", "resolution", "step"},
+		Available:   true,
+		workflow:    temporal_workflows.ResolveDeadLetterWorkflow,
+		taskQueue:   SwapTaskQueue,
+	},
+	{
+		Name:        temporal_workflows.AdminActionResyncTokenRegistry,
+		Description: "Reload wrapped tokens from Universal for the given chains, or every configured chain",
+		Params:      []string{"chainIds"},
+		Available:   true,
+		workflow:    temporal_workflows.ResyncTokenRegistryWorkflow,
+		taskQueue:   SwapTaskQueue,
+	},
+	{
+		Name:        temporal_workflows.AdminActionFlushPriceCache,
+		Description: "Delete the price cache so the next update refetches every price source",
+		Available:   true,
+		workflow:    temporal_workflows.FlushPriceCacheWorkflow,
+		taskQueue:   PriceOracleTaskQueue,
+	},
+	{
+		Name:        temporal_workflows.AdminActionMigratePool,
+		Description: "Move every position and reserve of a pool into another pool of the same tokens, e.g. a new fee tier",
+		Params:      []string{"sourcePoolId", "targetPoolId"},
+		Available:   true,
+		workflow:    temporal_workflows.MigratePoolWorkflow,
+		taskQueue:   SwapTaskQueue,
+	},
+	{
+		Name:        temporal_workflows.AdminActionReopenCircuitBreaker,
+		Description: "Close a tripped circuit breaker on this server, named global or chain:<chainId>, letting swaps through again",
+		Params:      []string{"name"},
+		Available:   true,
+		run:         reopenCircuitBreaker,
+	},
+}
+
+// AdminActionRequestBody represents an admin action request; the operator is the owner of the admin API key
+type AdminActionRequestBody struct {
+	Reason string            `json:"reason"`
+	Params map[string]string `json:"params"`
+}
+
+// AdminActionResponse is returned when an admin action is started
+type AdminActionResponse struct {
+	ActionID string `json:"actionId"`
+	Action   string `json:"action"`
+	Status   string `json:"status"`
+}
+
+// AuditResponse is returned by the audit log endpoint
+type AuditResponse struct {
+	Entries []temporal_activities.AuditEntry `json:"entries"`
+}
+
+// isAdmin reports whether the request was made with an admin API key
+func (s *Server) isAdmin(r *http.Request) bool {
+	_, ok := s.adminKeys[r.Header.Get(apiKeyHeader)]
+	return ok
+}
+
+// adminOperator returns the operator an admin request is audited as: the name configured with its
+// API key, or the key's fingerprint
+func (s *Server) adminOperator(r *http.Request) string {
+	return s.adminKeys[r.Header.Get(apiKeyHeader)]
+}
+
+// requireAdmin rejects requests without an admin API key, or without a passkey session when
+// passkeys are enabled, reporting whether the request may proceed
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !s.isAdmin(r) {
+		errorResponse(w, http.StatusForbidden, "admin API key required")
+		return false
+	}
+	if s.adminGate != nil && !s.adminGate.validSession(r) {
+		errorResponse(w, http.StatusUnauthorized, "passkey session required; log in with /api/v1/admin/webauthn/login")
+		return false
+	}
+	return true
+}
+
+// adminActionsHandler lists the available admin actions
+func (s *Server) adminActionsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]AdminAction{"actions": adminActions})
+}
+
+// runAdminActionHandler starts an admin action workflow, auditing who asked for it and why
+func (s *Server) runAdminActionHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	name := r.PathValue("action")
+	var action *AdminAction
+	for i := range adminActions {
+		if adminActions[i].Name == name {
+			action = &adminActions[i]
+		}
+	}
+	if action == nil {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("unknown admin action: %s", name))
+		return
+	}
+	if !action.Available {
+		errorResponse(w, http.StatusNotImplemented, fmt.Sprintf("admin action %s is not available", name))
+		return
+	}
+
+	var body AdminActionRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if strings.TrimSpace(body.Reason) == "" {
+		errorResponse(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	operator := s.adminOperator(r)
+
+	if action.run == nil && s.temporalClient == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "admin actions require Temporal")
+		return
+	}
+	if s.auditLog == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "admin actions require the audit log")
+		return
+	}
+
+	input := temporal_workflows.AdminActionInput{
+		Action:   action.Name,
+		Operator: operator,
+		Reason:   body.Reason,
+		Params:   body.Params,
+	}
+	if err := s.prepareAdminInput(r.Context(), &input); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errSwapNotRemediable) || errors.Is(err, errDeadLetterResolved) || errors.Is(err, services.ErrPoolNotMigratable) {
+			status = http.StatusConflict
+		}
+		errorResponse(w, status, err.Error())
+		return
+	}
+
+	actionID := fmt.Sprintf("admin-%s-%s", action.Name, uuid.New().String())
+	entry := temporal_activities.AuditEntry{
+		ActionID: actionID,
+		Action:   action.Name,
+		Operator: operator,
+		Reason:   body.Reason,
+		Params:   body.Params,
+		Status:   temporal_activities.AuditStatusRequested,
+	}
+	// Never run an action that could not be audited
+	if err := s.auditLog.Record(entry); err != nil {
+		log.Printf("Failed to record admin action %s: %v", actionID, err)
+		errorResponse(w, http.StatusInternalServerError, "failed to record audit entry")
+		return
+	}
+
+	if action.run != nil {
+		s.runLocalAdminAction(w, action, input, entry)
+		return
+	}
+
+	options, _ := s.workflowOptions(actionID, action.taskQueue)
+	if _, err := s.temporalClient.ExecuteWorkflow(r.Context(), options, action.workflow, input); err != nil {
+		entry.Status = temporal_activities.AuditStatusFailed
+		entry.Message = fmt.Sprintf("failed to start workflow: %v", err)
+		if auditErr := s.auditLog.Record(entry); auditErr != nil {
+			log.Printf("Failed to record admin action %s: %v", actionID, auditErr)
+		}
+		errorResponse(w, http.StatusInternalServerError, entry.Message)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, AdminActionResponse{
+		ActionID: actionID,
+		Action:   action.Name,
+		Status:   temporal_activities.AuditStatusRequested,
+	})
+}
+
+// runLocalAdminAction runs an action on this server and records how it ended
+func (s *Server) runLocalAdminAction(w http.ResponseWriter, action *AdminAction, input temporal_workflows.AdminActionInput, entry temporal_activities.AuditEntry) {
+	err := action.run(s, input)
+	entry.Status = temporal_activities.AuditStatusCompleted
+	if err != nil {
+		entry.Status = temporal_activities.AuditStatusFailed
+		entry.Message = err.Error()
+	}
+	if auditErr := s.auditLog.Record(entry); auditErr != nil {
+		log.Printf("Failed to record admin action %s: %v", entry.ActionID, auditErr)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrBreakerNotFound) {
+			status = http.StatusNotFound
+		}
+		errorResponse(w, status, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminActionResponse{
+		ActionID: entry.ActionID,
+		Action:   action.Name,
+		Status:   temporal_activities.AuditStatusCompleted,
+	})
+}
+
+// adminAuditHandler returns the most recent audit log entries, newest first
+func (s *Server) adminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.auditLog == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "audit log unavailable")
+		return
+	}
+
+	limit := defaultAuditLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q", raw))
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := s.auditLog.List(limit)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to read audit log: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AuditResponse{Entries: entries})
+}
+
+// prepareAdminInput validates an action's params and fills in what its workflow needs
+func (s *Server) prepareAdminInput(ctx context.Context, input *temporal_workflows.AdminActionInput) error {
+	switch input.Action {
+	case temporal_workflows.AdminActionRetrySwap:
+		requestID := input.Params["requestId"]
+		if requestID == "" {
+			return errors.New("params.requestId is required")
+		}
+		if step := input.Params["step"]; step != "" && step != temporal_workflows.SwapStepQuote && step != temporal_workflows.SwapStepExecute {
+			return fmt.Errorf("params.step must be %s or %s", temporal_workflows.SwapStepQuote, temporal_workflows.SwapStepExecute)
+		}
+		swap, err := s.swapWorkflowInput(ctx, requestID, "")
+		if err != nil {
+			return fmt.Errorf("swap %s not found: %v", requestID, err)
+		}
+		if err := s.checkSwapRemediable(ctx, requestID); err != nil {
+			return err
+		}
+		input.Swap = swap
+
+	case temporal_workflows.AdminActionForceRefund:
+		requestID := input.Params["requestId"]
+		if requestID == "" {
+			return errors.New("params.requestId is required")
+		}
+		if err := s.checkSwapRemediable(ctx, requestID); err != nil {
+			return err
+		}
+
+	case temporal_workflows.AdminActionResolveDeadLetter:
+		return s.prepareResolveDeadLetter(ctx, input)
+
+	case temporal_workflows.AdminActionResyncTokenRegistry:
+		if raw := input.Params["chainIds"]; raw != "" {
+			for _, part := range strings.Split(raw, ",") {
+				chainID, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid chain ID: %q", part)
+				}
+				input.ChainIDs = append(input.ChainIDs, chainID)
+			}
+		} else {
+			for _, chain := range s.config().Chains {
+				input.ChainIDs = append(input.ChainIDs, chain.ChainID)
+			}
+		}
+
+	case temporal_workflows.AdminActionReopenCircuitBreaker:
+		if s.breakers == nil {
+			return errors.New("circuit breakers are disabled")
+		}
+		if input.Params["name"] == "" {
+			return errors.New("params.name is required")
+		}
+
+	case temporal_workflows.AdminActionMigratePool:
+		source, target := input.Params["sourcePoolId"], input.Params["targetPoolId"]
+		if source == "" || target == "" {
+			return errors.New("params.sourcePoolId and params.targetPoolId are required")
+		}
+		// Refuse migrations that can't run now; the workflow plans again before draining the source
+		if _, err := s.liquidityService.PlanPoolMigration(ctx, source, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolMigrationPlanHandler reports what migrating a pool into the target pool would do, without changing either
+func (s *Server) poolMigrationPlanHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		errorResponse(w, http.StatusBadRequest, "target is required")
+		return
+	}
+
+	plan, err := s.liquidityService.PlanPoolMigration(r.Context(), r.PathValue("id"), target)
+	switch {
+	case errors.Is(err, types.ErrPoolNotFound):
+		codedErrorResponse(w, ErrorCodePoolNotFound, err.Error())
+		return
+	case errors.Is(err, services.ErrPoolNotMigratable):
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to plan migration: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// checkSwapRemediable returns an error wrapping errSwapNotRemediable unless the swap failed or is stuck
+func (s *Server) checkSwapRemediable(ctx context.Context, requestID string) error {
+	desc, err := s.temporalClient.DescribeWorkflowExecution(ctx, requestID, "")
+	if err != nil {
+		archived, ok := s.archivedSwap(ctx, requestID)
+		if !ok {
+			return fmt.Errorf("swap %s not found: %v", requestID, err)
+		}
+		return swapRemediable(enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED, archived, time.Time{}, time.Now())
+	}
+
+	info := desc.GetWorkflowExecutionInfo()
+	var result *types.SwapResult
+	if info.GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED {
+		result = &types.SwapResult{}
+		getCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := s.temporalClient.GetWorkflow(getCtx, requestID, "").Get(getCtx, result); err != nil {
+			return fmt.Errorf("failed to get swap result: %v", err)
+		}
+	}
+	return swapRemediable(info.GetStatus(), result, info.GetStartTime().AsTime(), time.Now())
+}
+
+// swapRemediable returns an error wrapping errSwapNotRemediable unless a swap workflow with the given status
+// and result failed, or is still running stuckSwapAge after it started
+func swapRemediable(status enumspb.WorkflowExecutionStatus, result *types.SwapResult, started, now time.Time) error {
+	switch status {
+	case enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING, enumspb.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW:
+		if now.Sub(started) < stuckSwapAge {
+			return fmt.Errorf("%w: swap is still running", errSwapNotRemediable)
+		}
+		return nil
+	case enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		if result != nil && result.Success {
+			return fmt.Errorf("%w: swap completed", errSwapNotRemediable)
+		}
+		return nil
+	default:
+		// Failed, timed out, cancelled or terminated
+		return nil
+	}
+}
+
+// swapWorkflowInput loads the input a swap workflow was started with from its history; an empty run ID loads the
+// latest run
+func (s *Server) swapWorkflowInput(ctx context.Context, requestID string, runID string) (*temporal_workflows.SwapWorkflowInput, error) {
+	iter := s.temporalClient.GetWorkflowHistory(ctx, requestID, runID, false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	if !iter.HasNext() {
+		return nil, errors.New("empty workflow history")
+	}
+	event, err := iter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	started := event.GetWorkflowExecutionStartedEventAttributes()
+	if started == nil {
+		return nil, errors.New("workflow history does not start with the workflow input")
+	}
+
+	var input temporal_workflows.SwapWorkflowInput
+	if err := s.dataConverter.FromPayloads(started.GetInput(), &input); err != nil {
+		return nil, fmt.Errorf("failed to decode swap input: %w", err)
+	}
+	return &input, nil
+}
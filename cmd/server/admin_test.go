@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+)
+
+func TestAdminEndpoints(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	auditLog, err := temporal_activities.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	s.auditLog = auditLog
+
+	body := AdminActionRequestBody{
+		Reason: "stuck price cache",
+	}
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/actions", nil, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		sandboxKey := s.config().Sandbox.APIKeys[0]
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/admin/actions/flush_price_cache", body, sandboxKey)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("ListActions", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/actions", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp map[string][]AdminAction
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		names := make([]string, 0, len(resp["actions"]))
+		for _, action := range resp["actions"] {
+			names = append(names, action.Name)
+		}
+		assert.ElementsMatch(t, []string{
+			temporal_workflows.AdminActionRetrySwap,
+			temporal_workflows.AdminActionForceRefund,
+			temporal_workflows.AdminActionResolveDeadLetter,
+			temporal_workflows.AdminActionResyncTokenRegistry,
+			temporal_workflows.AdminActionFlushPriceCache,
+			temporal_workflows.AdminActionMigratePool,
+			temporal_workflows.AdminActionReopenCircuitBreaker,
+		}, names)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/admin/actions/bogus", body, adminKey)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/admin/actions/reopen_circuit_breaker", body, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "params.name is required")
+
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/admin/actions/flush_price_cache", AdminActionRequestBody{}, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("MigrationPlan", func(t *testing.T) {
+		ctx := context.Background()
+		pair := services.TokenPair{
+			BaseToken:  services.Token{Symbol: "ETH", ChainID: 1},
+			QuoteToken: services.Token{Symbol: "USDC", ChainID: 1},
+		}
+		require.NoError(t, s.liquidityService.SeedPool(ctx, "migrate-3000", pair, 3000, ""))
+		require.NoError(t, s.liquidityService.SeedPool(ctx, "migrate-500", pair, 500, ""))
+		_, err := s.liquidityService.AddLiquidityOnce(ctx, "", "migrate-3000", "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "ETH", big.NewInt(1000))
+		require.NoError(t, err)
+
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/pools/migrate-3000/migration?target=migrate-500", nil, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/pools/migrate-3000/migration?target=migrate-500", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var plan types.PoolMigrationPlan
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&plan))
+		require.Len(t, plan.Positions, 1)
+		assert.Equal(t, "1000", plan.Positions[0].TargetTokensOwned.String())
+		assert.Equal(t, 100.0, plan.Positions[0].TargetShare)
+
+		// The dry run leaves the pools alone, so the reverse migration has nothing to move
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/pools/migrate-500/migration?target=migrate-3000", nil, adminKey)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/pools/migrate-3000/migration?target=missing", nil, adminKey)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/pools/migrate-3000/migration", nil, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("RequiresTemporal", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/admin/actions/flush_price_cache", body, adminKey)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		require.NoError(t, auditLog.Record(temporal_activities.AuditEntry{ActionID: "a1", Action: "flush_price_cache", Status: temporal_activities.AuditStatusRequested}))
+		require.NoError(t, auditLog.Record(temporal_activities.AuditEntry{ActionID: "a1", Action: "flush_price_cache", Status: temporal_activities.AuditStatusCompleted}))
+
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/audit?limit=1", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp AuditResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Entries, 1)
+		assert.Equal(t, temporal_activities.AuditStatusCompleted, resp.Entries[0].Status)
+	})
+}
+
+func TestSwapRemediable(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		status     enumspb.WorkflowExecutionStatus
+		result     *types.SwapResult
+		started    time.Time
+		remediable bool
+	}{
+		{"Running", enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING, nil, now.Add(-time.Minute), false},
+		{"Stuck", enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING, nil, now.Add(-time.Hour), true},
+		{"Succeeded", enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED, &types.SwapResult{Success: true}, now, false},
+		{"CompletedUnsuccessfully", enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED, &types.SwapResult{Success: false}, now, true},
+		{"Failed", enumspb.WORKFLOW_EXECUTION_STATUS_FAILED, nil, now, true},
+		{"TimedOut", enumspb.WORKFLOW_EXECUTION_STATUS_TIMED_OUT, nil, now, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := swapRemediable(tt.status, tt.result, tt.started, now)
+			if tt.remediable {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errSwapNotRemediable)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	enumspb "go.temporal.io/api/enums/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+// closedSwapsQuery lists the swap workflows that have finished
+const closedSwapsQuery = "WorkflowType = 'SwapWorkflow' AND ExecutionStatus != 'Running'"
+
+// archiveBackfillPageSize is how many closed swap workflows a backfill lists at a time
+const archiveBackfillPageSize = 100
+
+// BackfillSwapArchive archives closed swap workflows missing from the swap archive, such as swaps that finished
+// before swap workflows archived themselves, while Temporal still has their history. It returns how many swaps it
+// archived; a swap that can't be archived is logged and skipped.
+func (s *Server) BackfillSwapArchive(ctx context.Context) (int, error) {
+	if s.temporalClient == nil || s.swapArchive == nil {
+		return 0, errors.New("backfilling the swap archive needs Temporal and the swap archive")
+	}
+
+	archived := 0
+	var pageToken []byte
+	for {
+		resp, err := s.temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     s.config().Region.Namespace,
+			PageSize:      archiveBackfillPageSize,
+			NextPageToken: pageToken,
+			Query:         closedSwapsQuery,
+		})
+		if err != nil {
+			return archived, fmt.Errorf("failed to list closed swaps: %w", err)
+		}
+
+		for _, execution := range resp.GetExecutions() {
+			requestID := execution.GetExecution().GetWorkflowId()
+			if _, err := s.swapArchive.GetArchivedSwap(ctx, requestID); err == nil {
+				continue
+			} else if !errors.Is(err, types.ErrArchivedSwapNotFound) {
+				return archived, err
+			}
+
+			if err := s.backfillSwap(ctx, execution); err != nil {
+				log.Printf("Failed to backfill archived swap %s: %v", requestID, err)
+				continue
+			}
+			archived++
+		}
+
+		pageToken = resp.GetNextPageToken()
+		if len(pageToken) == 0 {
+			return archived, nil
+		}
+	}
+}
+
+// backfillSwap archives a closed swap workflow from its history. Swaps whose workflow didn't complete are archived
+// as failed with the status they closed with.
+func (s *Server) backfillSwap(ctx context.Context, execution *workflowpb.WorkflowExecutionInfo) error {
+	workflowID := execution.GetExecution().GetWorkflowId()
+	runID := execution.GetExecution().GetRunId()
+
+	input, err := s.swapWorkflowInput(ctx, workflowID, runID)
+	if err != nil {
+		return err
+	}
+
+	var result types.SwapResult
+	if execution.GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED {
+		if err := s.temporalClient.GetWorkflow(ctx, workflowID, runID).Get(ctx, &result); err != nil {
+			return fmt.Errorf("failed to get swap result: %w", err)
+		}
+	} else {
+		result = types.SwapResult{
+			Success:      false,
+			Status:       types.SwapStatusFailed,
+			ErrorMessage: fmt.Sprintf("swap workflow %s", execution.GetStatus()),
+		}
+	}
+	result.RequestID = workflowID
+
+	request := input.Request
+	request.RequestID = workflowID
+	swap := types.ArchivedSwap{
+		RequestID:  workflowID,
+		WorkflowID: workflowID,
+		RunID:      runID,
+		Request:    request,
+		Result:     result,
+		StartedAt:  execution.GetStartTime().AsTime(),
+		ClosedAt:   execution.GetCloseTime().AsTime(),
+		ArchivedAt: time.Now(),
+	}
+	if err := s.swapArchive.ArchiveSwap(ctx, swap); err != nil {
+		return err
+	}
+	// Swaps that archive themselves are counted by the swap workers; backfilled ones are counted here
+	if err := s.analytics.RecordSwap(ctx, swap); err != nil {
+		log.Printf("Failed to record stats of swap %s: %v", workflowID, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// closedSwapsClient serves closed swap workflows from a fixed list, one execution per page
+type closedSwapsClient struct {
+	client.Client
+	executions []*workflowpb.WorkflowExecutionInfo
+	inputs     map[string]temporal_workflows.SwapWorkflowInput // map[workflowID]input
+	results    map[string]types.SwapResult                     // map[workflowID]result
+}
+
+func (c *closedSwapsClient) ListWorkflow(ctx context.Context, request *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	page := 0
+	if len(request.NextPageToken) > 0 {
+		page = int(request.NextPageToken[0])
+	}
+	resp := &workflowservice.ListWorkflowExecutionsResponse{
+		Executions: c.executions[page : page+1],
+	}
+	if page+1 < len(c.executions) {
+		resp.NextPageToken = []byte{byte(page + 1)}
+	}
+	return resp, nil
+}
+
+func (c *closedSwapsClient) GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType enumspb.HistoryEventFilterType) client.HistoryEventIterator {
+	payloads, _ := converter.GetDefaultDataConverter().ToPayloads(c.inputs[workflowID])
+	return &historyEvents{events: []*historypb.HistoryEvent{{
+		Attributes: &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{
+			WorkflowExecutionStartedEventAttributes: &historypb.WorkflowExecutionStartedEventAttributes{Input: payloads},
+		},
+	}}}
+}
+
+func (c *closedSwapsClient) GetWorkflow(ctx context.Context, workflowID string, runID string) client.WorkflowRun {
+	return &completedRun{result: c.results[workflowID]}
+}
+
+// historyEvents iterates over a fixed workflow history
+type historyEvents struct {
+	events []*historypb.HistoryEvent
+}
+
+func (h *historyEvents) HasNext() bool {
+	return len(h.events) > 0
+}
+
+func (h *historyEvents) Next() (*historypb.HistoryEvent, error) {
+	event := h.events[0]
+	h.events = h.events[1:]
+	return event, nil
+}
+
+// completedRun is a workflow run that completed with a result
+type completedRun struct {
+	client.WorkflowRun
+	result types.SwapResult
+}
+
+func (r *completedRun) Get(ctx context.Context, valuePtr interface{}) error {
+	data, err := json.Marshal(r.result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, valuePtr)
+}
+
+func TestBackfillSwapArchive(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	archive := services.NewInMemorySwapArchive()
+	s.SetSwapArchive(archive)
+
+	closed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	execution := func(id string, status enumspb.WorkflowExecutionStatus) *workflowpb.WorkflowExecutionInfo {
+		return &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: id, RunId: id + "-run"},
+			Status:    status,
+			StartTime: timestamppb.New(closed.Add(-time.Minute)),
+			CloseTime: timestamppb.New(closed),
+		}
+	}
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "ETH", ChainID: 1},
+		DestinationToken: types.Token{Symbol: "USDC", ChainID: 1},
+		Amount:           big.NewInt(1000),
+	}
+	s.temporalClient = &closedSwapsClient{
+		executions: []*workflowpb.WorkflowExecutionInfo{
+			execution("swap-archived", enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED),
+			execution("swap-completed", enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED),
+			execution("swap-timed-out", enumspb.WORKFLOW_EXECUTION_STATUS_TIMED_OUT),
+		},
+		inputs: map[string]temporal_workflows.SwapWorkflowInput{
+			"swap-completed": {Request: request},
+			"swap-timed-out": {Request: request},
+		},
+		results: map[string]types.SwapResult{
+			"swap-completed": {Success: true, Status: types.SwapStatusCompleted, OutputAmount: big.NewInt(990)},
+		},
+	}
+
+	// Swaps that archived themselves are left alone
+	require.NoError(t, archive.ArchiveSwap(ctx, types.ArchivedSwap{RequestID: "swap-archived", RunID: "original"}))
+
+	archived, err := s.BackfillSwapArchive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	existing, err := archive.GetArchivedSwap(ctx, "swap-archived")
+	require.NoError(t, err)
+	assert.Equal(t, "original", existing.RunID)
+
+	completed, err := archive.GetArchivedSwap(ctx, "swap-completed")
+	require.NoError(t, err)
+	assert.True(t, completed.Result.Success)
+	assert.Equal(t, "swap-completed", completed.Request.RequestID)
+	assert.Equal(t, "swap-completed-run", completed.RunID)
+	assert.Equal(t, 0, completed.Request.Amount.Cmp(big.NewInt(1000)))
+	assert.True(t, completed.ClosedAt.Equal(closed))
+
+	timedOut, err := archive.GetArchivedSwap(ctx, "swap-timed-out")
+	require.NoError(t, err)
+	assert.False(t, timedOut.Result.Success)
+	assert.Equal(t, types.SwapStatusFailed, timedOut.Result.Status)
+
+	// A second backfill finds nothing left to archive
+	archived, err = s.BackfillSwapArchive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, archived)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"go.temporal.io/api/enums/v1"
+)
+
+// newSwapAttester creates the attester signing with the configured key, or returns nil, disabling
+// attestations, when none is configured. LoadConfig has already refused invalid keys.
+func newSwapAttester(cfg temporal_config.AttestationConfig) *services.SwapAttester {
+	if cfg.SigningKey == "" {
+		log.Printf("No ATTESTATION.SIGNING_KEY configured; swap attestations are disabled")
+		return nil
+	}
+	seed, err := hex.DecodeString(cfg.SigningKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		log.Printf("Invalid ATTESTATION.SIGNING_KEY; swap attestations are disabled")
+		return nil
+	}
+	return services.NewSwapAttester(ed25519.NewKeyFromSeed(seed))
+}
+
+// AttestationKeyResponse is the key swap attestations are signed with
+type AttestationKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"publicKey"` // Hex
+}
+
+// attestationKeyHandler returns the public key attestations verify against, for verifiers to pin
+func (s *Server) attestationKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if s.attester == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "swap attestations are unavailable")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AttestationKeyResponse{
+		Algorithm: "ed25519",
+		PublicKey: hex.EncodeToString(s.attester.PublicKey()),
+	})
+}
+
+// swapAttestationHandler exports a signed attestation of a completed swap
+func (s *Server) swapAttestationHandler(w http.ResponseWriter, r *http.Request) {
+	if s.attester == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "swap attestations are unavailable")
+		return
+	}
+
+	requestID := r.PathValue("id")
+	result, code, err := s.completedSwap(r, requestID)
+	if err != nil {
+		codedErrorResponse(w, code, err.Error())
+		return
+	}
+
+	attestation, err := s.attester.Attest(result)
+	if err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, attestation)
+}
+
+// completedSwap returns the result of a completed swap, or the code of the error to respond with when it is not completed
+func (s *Server) completedSwap(r *http.Request, requestID string) (*types.SwapResult, ErrorCode, error) {
+	if !s.useTemporal(r) {
+		result, err := s.swapServiceFor(r).GetSwapStatus(r.Context(), requestID)
+		if err != nil {
+			archived, ok := s.archivedSwap(r.Context(), requestID)
+			if !ok {
+				return nil, ErrorCodeWorkflowNotFound, err
+			}
+			result = archived
+		}
+		if !result.Success {
+			return nil, ErrorCodeConflict, fmt.Errorf("swap is %s; only completed swaps can be attested", swapStatus(result))
+		}
+		return result, "", nil
+	}
+
+	desc, err := s.temporalClient.DescribeWorkflowExecution(r.Context(), requestID, "")
+	if err != nil {
+		if archived, ok := s.archivedSwap(r.Context(), requestID); ok && archived.Success {
+			return archived, "", nil
+		}
+		return nil, ErrorCodeWorkflowNotFound, fmt.Errorf("swap workflow not found: %v", err)
+	}
+	if desc.GetWorkflowExecutionInfo().GetStatus() != enums.WORKFLOW_EXECUTION_STATUS_COMPLETED {
+		return nil, ErrorCodeConflict, fmt.Errorf("swap has not completed; only completed swaps can be attested")
+	}
+
+	var result types.SwapResult
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	if err := s.temporalClient.GetWorkflow(ctx, requestID, "").Get(ctx, &result); err != nil {
+		return nil, ErrorCodeInternal, fmt.Errorf("failed to get swap result: %v", err)
+	}
+	if !result.Success {
+		return nil, ErrorCodeConflict, fmt.Errorf("swap is %s; only completed swaps can be attested", swapStatus(&result))
+	}
+	return &result, "", nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwapAttestationEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	sandboxKey := testSandboxKey
+
+	t.Run("Completed", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), sandboxKey)
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		var swap SwapResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&swap))
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/swap/"+swap.RequestID+"/attestation", nil, sandboxKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var attestation types.SwapAttestation
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&attestation))
+		assert.Equal(t, swap.RequestID, attestation.RequestID)
+		assert.NotEmpty(t, attestation.TxHashes)
+		assert.Len(t, attestation.Steps, len(attestation.TxHashes))
+		require.NoError(t, services.VerifyAttestation(&attestation, s.attester.PublicKey()))
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/attestations/key", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var key AttestationKeyResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&key))
+		assert.Equal(t, "ed25519", key.Algorithm)
+		assert.Equal(t, hex.EncodeToString(s.attester.PublicKey()), key.PublicKey)
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), "")
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		var swap SwapResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&swap))
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/swap/"+swap.RequestID+"/attestation", nil, "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/swap/unknown/attestation", nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("ConfiguredKey", func(t *testing.T) {
+		first := newSwapAttester(temporal_config.AttestationConfig{SigningKey: testAttestationKey})
+		second := newSwapAttester(temporal_config.AttestationConfig{SigningKey: testAttestationKey})
+		require.NotNil(t, first)
+		assert.Equal(t, first.PublicKey(), second.PublicKey())
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		assert.Nil(t, newSwapAttester(temporal_config.AttestationConfig{}))
+
+		disabled := newTestServer(t)
+		disabled.attester = nil
+		rec := doRequest(t, disabled, http.MethodGet, "/api/v1/attestations/key", nil, "")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+}
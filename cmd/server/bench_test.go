@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func BenchmarkTokensResponseJSON(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		tokens := make([]types.TokenDetails, 0, size)
+		for i := 0; i < size; i++ {
+			tokens = append(tokens, types.TokenDetails{
+				Token: types.Token{
+					Symbol:    fmt.Sprintf("uTKN%d", i),
+					Name:      fmt.Sprintf("Universal Token %d", i),
+					Decimals:  18,
+					Address:   fmt.Sprintf("0x%040x", i),
+					ChainID:   int64(i%5 + 1),
+					ChainName: "Ethereum",
+					LogoURI:   fmt.Sprintf("https://assets.example.com/tkn%d.png", i),
+					IsWrapped: true,
+				},
+				CoinGeckoID: fmt.Sprintf("token-%d", i),
+				Verified:    true,
+				PriceUSD:    float64(i) + 0.5,
+			})
+		}
+		resp := TokensResponse{Tokens: tokens}
+
+		b.Run(fmt.Sprintf("tokens=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := json.NewEncoder(io.Discard).Encode(resp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSwapQuoteHandler(b *testing.B) {
+	s := newTestServer(b)
+	body := testSwapBody()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rec := doRequest(b, s, http.MethodPost, "/api/v1/swap/quote", body, "")
+		if rec.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/infinity-dex/services"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+)
+
+// BreakersResponse is returned by the admin circuit breakers endpoint
+type BreakersResponse struct {
+	Breakers []services.BreakerStatus `json:"breakers"`
+}
+
+// newCircuitBreakers creates the circuit breakers of cfg, nil when they are disabled
+func newCircuitBreakers(cfg temporal_config.BreakersConfig) *services.CircuitBreakers {
+	if !cfg.Enabled {
+		return nil
+	}
+	return services.NewCircuitBreakers(breakerSettings(cfg.Chain), breakerSettings(cfg.Global))
+}
+
+// breakerSettings converts a breaker's configuration, whose failure rate is a percentage
+func breakerSettings(cfg temporal_config.BreakerConfig) services.BreakerSettings {
+	return services.BreakerSettings{
+		Window:      cfg.Window,
+		MinCalls:    cfg.MinCalls,
+		FailureRate: cfg.FailureRatePct / 100,
+		Cooldown:    cfg.Cooldown,
+	}
+}
+
+// adminBreakersHandler returns the state of the global circuit breaker and of each chain's
+func (s *Server) adminBreakersHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.breakers == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "circuit breakers are disabled")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, BreakersResponse{Breakers: s.breakers.Statuses()})
+}
+
+// reopenCircuitBreaker closes the breaker an admin action names, letting swaps on its chains through again
+func reopenCircuitBreaker(s *Server, input temporal_workflows.AdminActionInput) error {
+	return s.breakers.Reset(input.Params["name"])
+}
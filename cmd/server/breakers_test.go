@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	auditLog, err := temporal_activities.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	s.auditLog = auditLog
+	require.NotNil(t, s.breakers, "breakers are enabled by default")
+
+	rpcDown := errors.New("rpc down")
+	for i := 0; i < s.config().Breakers.Chain.MinCalls; i++ {
+		s.breakers.RecordChainCall(types.ChainIDEthereum, rpcDown)
+	}
+
+	t.Run("RejectsSwaps", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+		assert.Equal(t, ErrorCodeChainUnavailable, decodeError(t, rec).Code)
+
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), "")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+	})
+
+	t.Run("ChainDegraded", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/chains", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp ChainsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		for _, chain := range resp.Chains {
+			if chain.ChainID == types.ChainIDEthereum {
+				assert.Equal(t, chainStatusDegraded, chain.Status)
+			} else {
+				assert.Equal(t, chainStatusActive, chain.Status, chain.Name)
+			}
+		}
+	})
+
+	t.Run("ListBreakers", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/breakers", nil, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/breakers", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp BreakersResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Breakers, 2)
+		assert.Equal(t, services.GlobalBreakerName, resp.Breakers[0].Name)
+		assert.Equal(t, services.BreakerClosed, resp.Breakers[0].State)
+		assert.Equal(t, "chain:1", resp.Breakers[1].Name)
+		assert.Equal(t, services.BreakerOpen, resp.Breakers[1].State)
+		assert.Equal(t, 1, resp.Breakers[1].Trips)
+		assert.NotNil(t, resp.Breakers[1].RetryAt)
+	})
+
+	t.Run("Reopen", func(t *testing.T) {
+		path := "/api/v1/admin/actions/reopen_circuit_breaker"
+		rec := doRequest(t, s, http.MethodPost, path, AdminActionRequestBody{Reason: "RPC provider recovered"}, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "name is required")
+
+		body := AdminActionRequestBody{Reason: "RPC provider recovered", Params: map[string]string{"name": "chain:10"}}
+		rec = doRequest(t, s, http.MethodPost, path, body, adminKey)
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+		body.Params["name"] = "chain:1"
+		rec = doRequest(t, s, http.MethodPost, path, body, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp AdminActionResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, temporal_activities.AuditStatusCompleted, resp.Status)
+
+		entries, err := auditLog.List(1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, resp.ActionID, entries[0].ActionID)
+		assert.Equal(t, temporal_activities.AuditStatusCompleted, entries[0].Status)
+
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+}
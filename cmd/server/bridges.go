@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+// BridgeReliabilityResponse lists the reliability stats of every bridge
+type BridgeReliabilityResponse struct {
+	Bridges []services.BridgeStats `json:"bridges"`
+}
+
+// BridgeQuotesResponse compares the bridges for a transfer, in the order cross-chain swaps choose them
+type BridgeQuotesResponse struct {
+	From        int64               `json:"from"`
+	To          int64               `json:"to"`
+	Token       string              `json:"token"`
+	Amount      string              `json:"amount"`
+	Selected    string              `json:"selected,omitempty"` // The bridge a swap would use; empty when the corridor allows none of them
+	Quotes      []BridgeQuote       `json:"quotes"`
+	Unavailable []UnavailableBridge `json:"unavailable,omitempty"`
+}
+
+// BridgeQuote is a bridge's quote for a transfer, with the reliability the router weighs it by
+type BridgeQuote struct {
+	Bridge           string  `json:"bridge"`
+	Fee              string  `json:"fee"`         // In the token's smallest unit
+	AdjustedFee      float64 `json:"adjustedFee"` // The fee scaled by the bridge's reliability penalty, which the router compares
+	EstimatedTimeSec int64   `json:"estimatedTimeSec"`
+	ExpectedTimeSec  int64   `json:"expectedTimeSec"` // The estimate, or the median completion time of a bridge that has been slower
+	MinAmount        string  `json:"minAmount,omitempty"`
+	MaxAmount        string  `json:"maxAmount,omitempty"`
+	SuccessRate      float64 `json:"successRate"`
+	Samples          int     `json:"samples"`
+	Unreliable       bool    `json:"unreliable,omitempty"`
+	Allowed          bool    `json:"allowed"` // The corridor's policy allows the bridge
+}
+
+// UnavailableBridge is a bridge unable to carry a transfer
+type UnavailableBridge struct {
+	Bridge string `json:"bridge"`
+	Reason string `json:"reason"`
+}
+
+// SetBridgeOutcomeStore sets the store bridge outcomes are read from, as recorded by the swap worker
+func (s *Server) SetBridgeOutcomeStore(store services.BridgeOutcomeStore) {
+	s.bridgeReliability.SetStore(store)
+}
+
+// bridgeReliabilityHandler returns the recent reliability of every bridge used for cross-chain swaps
+func (s *Server) bridgeReliabilityHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.bridgeReliability.AllStats(r.Context())
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load bridge outcomes: %v", err))
+		return
+	}
+
+	// Universal carries every cross-chain swap, so report it even before its first transfer
+	hasUniversal := false
+	for _, bridge := range stats {
+		if bridge.Bridge == services.BridgeUniversal {
+			hasUniversal = true
+		}
+	}
+	if !hasUniversal {
+		universal, err := s.bridgeReliability.Stats(r.Context(), services.BridgeUniversal)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load bridge outcomes: %v", err))
+			return
+		}
+		stats = append(stats, universal)
+		sort.Slice(stats, func(i, j int) bool {
+			return stats[i].Bridge < stats[j].Bridge
+		})
+	}
+
+	writeJSON(w, http.StatusOK, BridgeReliabilityResponse{Bridges: stats})
+}
+
+// getBridgeReliabilityHandler returns the recent reliability of one bridge
+func (s *Server) getBridgeReliabilityHandler(w http.ResponseWriter, r *http.Request) {
+	bridge := r.PathValue("bridge")
+	stats, err := s.bridgeReliability.Stats(r.Context(), bridge)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load bridge outcomes: %v", err))
+		return
+	}
+	if stats.Samples == 0 && bridge != services.BridgeUniversal {
+		errorResponse(w, http.StatusNotFound, "bridge not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// bridgeQuotesHandler quotes a transfer through every bridge, ranked the way cross-chain swaps choose their bridge
+func (s *Server) bridgeQuotesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := types.ParseChainID(query.Get("from"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid from: %q", query.Get("from")))
+		return
+	}
+	to, err := types.ParseChainID(query.Get("to"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid to: %q", query.Get("to")))
+		return
+	}
+	if from == to {
+		errorResponse(w, http.StatusBadRequest, "from and to must be different chains")
+		return
+	}
+	amount, ok := new(big.Int).SetString(query.Get("amount"), 10)
+	if !ok || amount.Sign() <= 0 {
+		errorResponse(w, http.StatusBadRequest, "invalid amount: must be a positive base-unit integer")
+		return
+	}
+	token, err := s.tokenService.GetToken(query.Get("token"))
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("token %q not found", query.Get("token")))
+		return
+	}
+
+	comparison, err := s.bridgeQuoter.CompareBridges(r.Context(), tokenOnChain(token, from), tokenOnChain(token, to), amount)
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, fmt.Sprintf("failed to quote bridges: %v", err))
+		return
+	}
+
+	resp := BridgeQuotesResponse{
+		From:   from,
+		To:     to,
+		Token:  token.Symbol,
+		Amount: amount.String(),
+		Quotes: make([]BridgeQuote, 0, len(comparison.Quotes)),
+	}
+	for _, quote := range comparison.Quotes {
+		if resp.Selected == "" && quote.Allowed {
+			resp.Selected = quote.Bridge
+		}
+		resp.Quotes = append(resp.Quotes, BridgeQuote{
+			Bridge:           quote.Bridge,
+			Fee:              amountString(quote.Fee),
+			AdjustedFee:      quote.Cost,
+			EstimatedTimeSec: int64(quote.EstimatedTime.Seconds()),
+			ExpectedTimeSec:  int64(quote.ExpectedTime.Seconds()),
+			MinAmount:        amountString(quote.MinAmount),
+			MaxAmount:        amountString(quote.MaxAmount),
+			SuccessRate:      quote.Stats.SuccessRate,
+			Samples:          quote.Stats.Samples,
+			Unreliable:       quote.Unreliable,
+			Allowed:          quote.Allowed,
+		})
+	}
+	for bridge, reason := range comparison.Unavailable {
+		resp.Unavailable = append(resp.Unavailable, UnavailableBridge{Bridge: bridge, Reason: reason})
+	}
+	sort.Slice(resp.Unavailable, func(i, j int) bool {
+		return resp.Unavailable[i].Bridge < resp.Unavailable[j].Bridge
+	})
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// tokenOnChain returns token as it is on chainID. Tokens are listed on one chain, so elsewhere the token has no
+// known address and bridges identify it by symbol.
+func tokenOnChain(token types.Token, chainID int64) types.Token {
+	if token.ChainID == chainID {
+		return token
+	}
+	token.ChainID = chainID
+	token.ChainName = ""
+	if chain, ok := types.GetChain(chainID); ok {
+		token.ChainName = chain.Name
+	}
+	token.Address = ""
+	return token
+}
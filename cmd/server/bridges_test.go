@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridgeReliabilityEndpoints(t *testing.T) {
+	s := newTestServer(t)
+
+	t.Run("UniversalWithoutHistory", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/bridges/reliability", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp BridgeReliabilityResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Bridges, 1)
+		assert.Equal(t, services.BridgeUniversal, resp.Bridges[0].Bridge)
+		assert.Equal(t, 0, resp.Bridges[0].Samples)
+	})
+
+	s.bridgeReliability.Record(context.Background(), types.BridgeOutcome{Bridge: services.BridgeUniversal, Success: true, Duration: 5 * time.Minute})
+	s.bridgeReliability.Record(context.Background(), types.BridgeOutcome{Bridge: "hop", Success: false})
+
+	t.Run("List", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/bridges/reliability", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp BridgeReliabilityResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Bridges, 2)
+		assert.Equal(t, "hop", resp.Bridges[0].Bridge)
+		assert.Equal(t, 0.0, resp.Bridges[0].SuccessRate)
+		assert.Equal(t, services.BridgeUniversal, resp.Bridges[1].Bridge)
+		assert.Equal(t, 300.0, resp.Bridges[1].MedianCompletion)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/bridges/universal/reliability", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var stats services.BridgeStats
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+		assert.Equal(t, 1, stats.Samples)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/bridges/unknown/reliability", nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestBridgeQuotesEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	s.tokenService.UpsertToken(types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDEthereum, ChainName: "Ethereum", Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"})
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/bridge-quotes?from=1&to=137&token=USDC&amount=1000000", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp BridgeQuotesResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, int64(types.ChainIDEthereum), resp.From)
+	assert.Equal(t, int64(types.ChainIDPolygon), resp.To)
+	assert.Equal(t, services.BridgeUniversal, resp.Selected)
+	require.Len(t, resp.Quotes, 1)
+	assert.Equal(t, services.BridgeUniversal, resp.Quotes[0].Bridge)
+	assert.NotEmpty(t, resp.Quotes[0].Fee)
+	assert.Equal(t, int64(600), resp.Quotes[0].EstimatedTimeSec)
+	assert.True(t, resp.Quotes[0].Allowed)
+
+	for _, path := range []string{
+		"/api/v1/bridge-quotes?from=1&to=1&token=USDC&amount=1000000",
+		"/api/v1/bridge-quotes?from=1&to=137&token=USDC&amount=-5",
+		"/api/v1/bridge-quotes?from=x&to=137&token=USDC&amount=1000000",
+	} {
+		rec := doRequest(t, s, http.MethodGet, path, nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, path)
+	}
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/bridge-quotes?from=1&to=137&token=NOPE&amount=1000000", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// Chain statuses reported by the chains endpoint
+const (
+	chainStatusActive   = "active"
+	chainStatusInactive = "inactive"
+	chainStatusDegraded = "degraded" // Its circuit breaker, or the global one, has tripped
+)
+
+// ChainExplorer holds a chain's block explorer URL templates.
+// {txHash} and {address} are replaced by the transaction hash and the account or token address.
+type ChainExplorer struct {
+	URL     string `json:"url"`
+	Tx      string `json:"tx"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+// ChainInfo describes a configured chain and what it supports
+type ChainInfo struct {
+	ChainID       int64         `json:"chainId"`
+	CAIP2         string        `json:"caip2"`
+	Name          string        `json:"name"`
+	Status        string        `json:"status"` // active, degraded or inactive
+	Features      []string      `json:"features"`
+	Confirmations int           `json:"confirmations"`
+	Explorer      ChainExplorer `json:"explorer"`
+	GasPrice      string        `json:"gasPrice,omitempty"`     // Last gas price read, in wei
+	BlockTimeMs   int64         `json:"blockTimeMs,omitempty"`  // Average time between recent blocks
+	PrivateRelay  bool          `json:"privateRelay,omitempty"` // Swaps on the chain can ask for privateExecution
+}
+
+// ChainGasResponse is returned by the chain gas endpoint. Prices are in wei.
+type ChainGasResponse struct {
+	ChainID     int64     `json:"chainId"`
+	GasPrice    string    `json:"gasPrice"`
+	BaseFee     string    `json:"baseFee,omitempty"`
+	PriorityFee string    `json:"priorityFee,omitempty"`
+	BlockTimeMs int64     `json:"blockTimeMs,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ChainsResponse is returned by the chains endpoint
+type ChainsResponse struct {
+	Chains []ChainInfo `json:"chains"`
+}
+
+// newChainService creates a chain service tracking the configured chains, reading gas prices from gas. Chains start
+// active; gas polling marks a chain inactive while its RPC endpoints fail.
+func newChainService(cfg temporal_config.Config, gas services.GasReader) *services.ChainService {
+	chainService := services.NewChainService()
+	chainService.SetGasReader(gas)
+	for _, chain := range cfg.Chains {
+		chainService.AddChain(types.ChainStatus{
+			Name:     chain.Name,
+			ChainID:  chain.ChainID,
+			IsActive: true,
+		})
+	}
+	return chainService
+}
+
+// checkChainFeature returns an error and its HTTP status when a configured chain doesn't support feature or is
+// inactive, or when a chain's circuit breaker is open. Chains that aren't configured are otherwise left to the
+// services to reject.
+func (s *Server) checkChainFeature(chainID int64, feature string) (int, error) {
+	for _, chain := range s.config().Chains {
+		if chain.ChainID != types.CanonicalChainID(chainID) {
+			continue
+		}
+		if !slices.Contains(chain.SupportedFeatures(), feature) {
+			return http.StatusBadRequest, fmt.Errorf("chain %s does not support %s", chain.Name, feature)
+		}
+		if status, err := s.chainService.GetChain(chain.ChainID); err == nil && !status.IsActive {
+			return http.StatusServiceUnavailable, fmt.Errorf("%w: chain %s is inactive", services.ErrChainUnavailable, chain.Name)
+		}
+		break
+	}
+	if s.breakers != nil {
+		if err := s.breakers.CheckChain(chainID); err != nil {
+			return http.StatusServiceUnavailable, err
+		}
+	}
+	return 0, nil
+}
+
+// checkSwapChains checks a swap's chains support it: a swap on its chain, or a bridge out of the source chain and
+// into the destination chain
+func (s *Server) checkSwapChains(request types.SwapRequest) (int, error) {
+	source, destination := request.SourceToken.ChainID, request.DestinationToken.ChainID
+	if types.CanonicalChainID(source) == types.CanonicalChainID(destination) {
+		return s.checkChainFeature(source, temporal_config.ChainFeatureSwap)
+	}
+	if status, err := s.checkChainFeature(source, temporal_config.ChainFeatureBridgeOut); err != nil {
+		return status, err
+	}
+	return s.checkChainFeature(destination, temporal_config.ChainFeatureBridgeIn)
+}
+
+// checkPrivateExecution checks a swap asking for private execution can have it: its source chain has a private relay
+// and the swap is sent as a transaction of its own, not a smart account's user operation
+func (s *Server) checkPrivateExecution(request types.SwapRequest) error {
+	if !request.PrivateExecution {
+		return nil
+	}
+	if request.UserOperation != nil {
+		return errors.New("privateExecution is not available for swaps from smart accounts")
+	}
+	if len(s.config().PrivateRelayURLs()[types.CanonicalChainID(request.SourceToken.ChainID)]) == 0 {
+		return errors.New("privateExecution is not available on the source chain")
+	}
+	return nil
+}
+
+// listChainsHandler returns every configured chain with its status, features, confirmation requirements and explorer links
+func (s *Server) listChainsHandler(w http.ResponseWriter, r *http.Request) {
+	chains := make([]ChainInfo, 0, len(s.config().Chains))
+	for _, chain := range s.config().Chains {
+		info := ChainInfo{
+			ChainID:       chain.ChainID,
+			CAIP2:         chain.CAIP2(),
+			Name:          chain.Name,
+			Status:        chainStatusActive,
+			Features:      chain.SupportedFeatures(),
+			Confirmations: chain.Confirmations,
+			Explorer:      chainExplorer(chain),
+			PrivateRelay:  chain.PrivateRelayURL != "",
+		}
+		if s.breakers != nil && s.breakers.ChainState(chain.ChainID) != services.BreakerClosed {
+			info.Status = chainStatusDegraded
+		}
+		if chainStatus, err := s.chainService.GetChain(chain.ChainID); err == nil {
+			if !chainStatus.IsActive {
+				info.Status = chainStatusInactive
+			}
+			info.GasPrice = weiString(chainStatus.GasPrice)
+			info.BlockTimeMs = chainStatus.BlockTimeMs
+		}
+
+		chains = append(chains, info)
+	}
+
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].ChainID < chains[j].ChainID
+	})
+
+	writeJSON(w, http.StatusOK, ChainsResponse{Chains: chains})
+}
+
+// chainGasHandler returns the last gas price, EIP-1559 fees and block time read for a chain
+func (s *Server) chainGasHandler(w http.ResponseWriter, r *http.Request) {
+	chainID, err := types.ParseChainID(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid chain ID")
+		return
+	}
+
+	chain, err := s.chainService.GetChain(chainID)
+	if errors.Is(err, services.ErrChainNotFound) {
+		errorResponse(w, http.StatusNotFound, "chain not found")
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if chain.GasPrice == nil {
+		errorResponse(w, http.StatusServiceUnavailable, services.ErrGasPriceUnavailable.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ChainGasResponse{
+		ChainID:     chain.ChainID,
+		GasPrice:    weiString(chain.GasPrice),
+		BaseFee:     weiString(chain.BaseFee),
+		PriorityFee: weiString(chain.PriorityFee),
+		BlockTimeMs: chain.BlockTimeMs,
+		UpdatedAt:   chain.GasUpdatedAt,
+	})
+}
+
+// weiString formats an amount in wei, empty when it is unknown
+func weiString(amount *big.Int) string {
+	if amount == nil {
+		return ""
+	}
+	return amount.String()
+}
+
+// chainExplorer returns the explorer URL templates of a chain, empty when it has no explorer configured
+func chainExplorer(chain temporal_config.ChainConfig) ChainExplorer {
+	base := strings.TrimSuffix(chain.ExplorerURL, "/")
+	if base == "" {
+		return ChainExplorer{}
+	}
+
+	explorer := ChainExplorer{
+		URL:     base,
+		Tx:      base + "/tx/{txHash}",
+		Address: base + "/address/{address}",
+		Token:   base + "/token/{address}",
+	}
+	// Solana explorers show tokens on their address page
+	if c, ok := types.GetChain(chain.ChainID); ok && c.Namespace == "solana" {
+		explorer.Token = explorer.Address
+	}
+	return explorer
+}
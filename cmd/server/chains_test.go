@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListChains(t *testing.T) {
+	s := newTestServer(t)
+	polygon := s.config().Chains["polygon"]
+	polygon.Features = []string{temporal_config.ChainFeatureSwap}
+	s.config().Chains["polygon"] = polygon
+	require.NoError(t, s.chainService.UpdateChainStatus(context.Background(), types.ChainIDPolygon, false, nil))
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/chains", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp ChainsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Chains, len(s.config().Chains))
+
+	chains := make(map[int64]ChainInfo)
+	for i, chain := range resp.Chains {
+		if i > 0 {
+			assert.Less(t, resp.Chains[i-1].ChainID, chain.ChainID, "chains are sorted by ID")
+		}
+		chains[chain.ChainID] = chain
+	}
+
+	ethereum := chains[types.ChainIDEthereum]
+	assert.Equal(t, "eip155:1", ethereum.CAIP2)
+	assert.Equal(t, chainStatusActive, ethereum.Status)
+	assert.Equal(t, temporal_config.AllChainFeatures, ethereum.Features)
+	assert.Equal(t, 12, ethereum.Confirmations)
+	assert.Equal(t, "https://etherscan.io/tx/{txHash}", ethereum.Explorer.Tx)
+	assert.Equal(t, "https://etherscan.io/token/{address}", ethereum.Explorer.Token)
+	assert.True(t, ethereum.PrivateRelay)
+	assert.False(t, chains[types.ChainIDPolygon].PrivateRelay)
+
+	assert.Equal(t, chainStatusInactive, chains[types.ChainIDPolygon].Status)
+	assert.Equal(t, []string{temporal_config.ChainFeatureSwap}, chains[types.ChainIDPolygon].Features)
+
+	solana := chains[types.ChainIDSolana]
+	assert.Equal(t, "https://explorer.solana.com/address/{address}", solana.Explorer.Token)
+}
+
+func TestPrivateExecutionChecked(t *testing.T) {
+	s := newTestServer(t)
+
+	body := testSwapBody()
+	body.PrivateExecution = true
+	request, err := swapRequestFromBody(body, 0.5)
+	require.NoError(t, err)
+	assert.True(t, request.PrivateExecution)
+	assert.NoError(t, s.checkPrivateExecution(request), "Ethereum has a private relay")
+
+	body.SourceToken = types.Token{Symbol: "MATIC", Decimals: 18, ChainID: types.ChainIDPolygon, ChainName: "Polygon"}
+	body.DestinationToken = types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDPolygon, ChainName: "Polygon"}
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "privateExecution")
+}
+
+func TestChainGas(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/chains/1/gas", nil, "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "no gas price read yet")
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/chains/999999/gas", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/chains/abc/gas", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	require.NoError(t, s.chainService.UpdateGasPrice(types.ChainIDEthereum, big.NewInt(30_000_000_000)))
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/chains/eip155:1/gas", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp ChainGasResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, types.ChainIDEthereum, resp.ChainID)
+	assert.Equal(t, "30000000000", resp.GasPrice)
+	assert.False(t, resp.UpdatedAt.IsZero())
+}
+
+func TestChainFeaturesEnforced(t *testing.T) {
+	s := newTestServer(t)
+	ethereum := s.config().Chains["ethereum"]
+	ethereum.Features = []string{temporal_config.ChainFeatureBridgeOut}
+	s.config().Chains["ethereum"] = ethereum
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/pools/eth-usdc-3000/liquidity", map[string]interface{}{
+		"userAddress": "0x9999999999999999999999999999999999999999",
+		"token":       types.Token{Symbol: "ETH", ChainID: 1},
+		"amount":      "1000",
+	}, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	ethereum.Features = nil
+	s.config().Chains["ethereum"] = ethereum
+	require.NoError(t, s.chainService.UpdateChainStatus(context.Background(), types.ChainIDEthereum, false, nil))
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+)
+
+// PolicyReviewRequestBody represents an operator's policy review of a held swap
+type PolicyReviewRequestBody struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+// SetPolicyStore sets the store of tenant policy documents swaps are evaluated against
+func (s *Server) SetPolicyStore(store services.PolicyStore) {
+	s.policyStore = store
+	s.policyEngine = services.NewDocumentPolicyEngine(store, s.prices)
+}
+
+// SetIPCountries geolocates client addresses with countries when no trusted proxy reports the country
+func (s *Server) SetIPCountries(countries *services.IPCountries) {
+	s.ipCountries = countries
+}
+
+// complianceContext identifies the tenant and origin of a request
+func (s *Server) complianceContext(r *http.Request) types.ComplianceContext {
+	tenantID, ok := s.tenantKeys[r.Header.Get(apiKeyHeader)]
+	if !ok {
+		tenantID = types.DefaultTenantID
+	}
+
+	cc := types.ComplianceContext{
+		TenantID: tenantID,
+		ClientIP: r.RemoteAddr,
+	}
+	ip := s.clientIP(r)
+	if ip.IsValid() {
+		cc.ClientIP = ip.String()
+	}
+	cc.Country = s.clientCountry(r, ip)
+	return cc
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only believed from a trusted proxy: its hops are
+// read from the nearest, and the first that isn't itself a trusted proxy is the client.
+func (s *Server) clientIP(r *http.Request) netip.Addr {
+	peer := remoteIP(r)
+	if !s.trustedProxy(peer) {
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Nothing past a malformed hop can be trusted
+			return peer
+		}
+		peer = hop.Unmap()
+		if !s.trustedProxy(peer) {
+			return peer
+		}
+	}
+	return peer
+}
+
+// clientCountry returns the country a request came from: the country header when one is configured and the request
+// came from a trusted proxy, otherwise the client address geolocated with the IP database. It is empty when neither
+// knows the country.
+func (s *Server) clientCountry(r *http.Request, ip netip.Addr) string {
+	if header := s.config().Compliance.CountryHeader; header != "" && s.trustedProxy(remoteIP(r)) {
+		// "XX" and "T1" are Cloudflare's unknown and Tor markers
+		if country := strings.ToUpper(strings.TrimSpace(r.Header.Get(header))); len(country) == 2 && country != "XX" && country != "T1" {
+			return country
+		}
+	}
+	if s.ipCountries != nil && ip.IsValid() {
+		if country, ok := s.ipCountries.Country(ip); ok {
+			return country
+		}
+	}
+	return ""
+}
+
+// trustedProxy reports whether an address belongs to one of the configured proxies
+func (s *Server) trustedProxy(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the address of the connection's peer, or the zero address when it can't be parsed
+func remoteIP(r *http.Request) netip.Addr {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		addr, _ := netip.ParseAddr(r.RemoteAddr)
+		return addr.Unmap()
+	}
+	return addrPort.Addr().Unmap()
+}
+
+// policyStatus maps a policy decision to the status reported for a swap it stopped
+func policyStatus(decision types.PolicyDecision) string {
+	if decision.Outcome == types.PolicyReview {
+		return "pending_review"
+	}
+	return "denied"
+}
+
+// getPolicyHandler returns a tenant's policy document
+func (s *Server) getPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	doc, err := s.policyStore.GetPolicy(r.Context(), r.PathValue("tenantId"))
+	if errors.Is(err, types.ErrPolicyNotFound) {
+		errorResponse(w, http.StatusNotFound, "policy not found")
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load policy: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// putPolicyHandler replaces a tenant's policy document, bumping its version
+func (s *Server) putPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var doc types.PolicyDocument
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	doc.TenantID = r.PathValue("tenantId")
+	if err := services.ValidatePolicyDocument(doc); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	doc.Version = 1
+	existing, err := s.policyStore.GetPolicy(r.Context(), doc.TenantID)
+	if err == nil {
+		doc.Version = existing.Version + 1
+	} else if !errors.Is(err, types.ErrPolicyNotFound) {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load policy: %v", err))
+		return
+	}
+	doc.UpdatedAt = time.Now().UTC()
+
+	if err := s.policyStore.SavePolicy(r.Context(), doc); err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to save policy: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// reviewSwapHandler approves or rejects a swap held for policy review
+func (s *Server) reviewSwapHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var body PolicyReviewRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if strings.TrimSpace(body.Reason) == "" {
+		errorResponse(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	if s.temporalClient == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "policy reviews require Temporal")
+		return
+	}
+
+	requestID := r.PathValue("id")
+	review := temporal_workflows.PolicyReview{
+		Approved: body.Approved,
+		Operator: s.adminOperator(r),
+		Reason:   body.Reason,
+	}
+	if err := s.temporalClient.SignalWorkflow(r.Context(), requestID, "", temporal_workflows.PolicyReviewSignal, review); err != nil {
+		codedErrorResponse(w, ErrorCodeWorkflowNotFound, fmt.Sprintf("failed to review swap: %v", err))
+		return
+	}
+
+	status := "rejected"
+	if body.Approved {
+		status = "approved"
+	}
+	if s.auditLog != nil {
+		entry := temporal_activities.AuditEntry{
+			ActionID: requestID,
+			Action:   "policy_review",
+			Operator: s.adminOperator(r),
+			Reason:   body.Reason,
+			Params:   map[string]string{"decision": status},
+			Status:   temporal_activities.AuditStatusCompleted,
+		}
+		if err := s.auditLog.Record(entry); err != nil {
+			log.Printf("Failed to record policy review of %s: %v", requestID, err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, SwapResponse{RequestID: requestID, Status: status})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompliancePolicies(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	s.tenantKeys["acme-key"] = "acme"
+	// Test requests come from 192.0.2.1, standing in for the edge proxy
+	s.config().Compliance.CountryHeader = "CF-IPCountry"
+	s.trustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+
+	t.Run("PutPolicy", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPut, "/api/v1/admin/policies/acme", types.PolicyDocument{}, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(t, s, http.MethodPut, "/api/v1/admin/policies/acme", types.PolicyDocument{ReviewAboveUSD: 10, DenyAboveUSD: 1}, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		for version := 1; version <= 2; version++ {
+			rec = doRequest(t, s, http.MethodPut, "/api/v1/admin/policies/acme", types.PolicyDocument{BlockedCountries: []string{"KP"}}, adminKey)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var doc types.PolicyDocument
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+			assert.Equal(t, "acme", doc.TenantID)
+			assert.Equal(t, version, doc.Version)
+		}
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/policies/acme", nil, adminKey)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/policies/other", nil, adminKey)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	swapFrom := func(country, apiKey string) *http.Response {
+		var buf bytes.Buffer
+		require.NoError(t, json.NewEncoder(&buf).Encode(testSwapBody()))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/swap", &buf)
+		req.Header.Set(apiKeyHeader, apiKey)
+		req.Header.Set("CF-IPCountry", country)
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	t.Run("DeniedSwap", func(t *testing.T) {
+		resp := swapFrom("KP", "acme-key")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		var body SwapResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "denied", body.Status)
+		require.NotNil(t, body.Policy)
+		assert.Equal(t, types.PolicyDeny, body.Policy.Outcome)
+		assert.Equal(t, 2, body.Policy.PolicyVersion)
+	})
+
+	t.Run("AllowedSwap", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, swapFrom("US", "acme-key").StatusCode)
+
+		// Other tenants are not bound by acme's policy
+		assert.Equal(t, http.StatusAccepted, swapFrom("KP", "").StatusCode)
+	})
+
+	t.Run("ReviewRequiresTemporal", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/admin/swaps/swap-1/review", PolicyReviewRequestBody{Approved: true, Reason: "verified"}, adminKey)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+}
+
+func TestComplianceContext(t *testing.T) {
+	cfg := temporal_config.DefaultConfig()
+	cfg.Compliance.Tenants = []temporal_config.TenantConfig{{ID: "acme", APIKeys: []string{"acme-key"}}}
+	cfg.Compliance.TrustedProxies = []string{"10.0.0.0/8"}
+	cfg.Compliance.CountryHeader = "CF-IPCountry"
+	sdk, err := newMockSDK(cfg)
+	require.NoError(t, err)
+	s := NewServer(cfg, sdk, nil)
+	countries, err := services.ParseIPCountries(strings.NewReader("203.0.113.0,203.0.113.255,FR\n198.51.100.0,198.51.100.255,KP\n"))
+	require.NoError(t, err)
+	s.SetIPCountries(countries)
+
+	request := func(remoteAddr string, headers map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/swap", nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return req
+	}
+
+	t.Run("TrustedProxy", func(t *testing.T) {
+		cc := s.complianceContext(request("10.0.0.1:5000", map[string]string{apiKeyHeader: "acme-key", "CF-IPCountry": "de"}))
+		assert.Equal(t, "acme", cc.TenantID)
+		assert.Equal(t, "DE", cc.Country)
+		assert.Equal(t, "10.0.0.1", cc.ClientIP)
+
+		// An unknown country header falls back to geolocating the forwarded client
+		cc = s.complianceContext(request("10.0.0.1:5000", map[string]string{"CF-IPCountry": "XX", "X-Forwarded-For": "198.51.100.9, 203.0.113.7, 10.0.0.2"}))
+		assert.Equal(t, types.DefaultTenantID, cc.TenantID)
+		assert.Equal(t, "203.0.113.7", cc.ClientIP)
+		assert.Equal(t, "FR", cc.Country)
+	})
+
+	t.Run("UntrustedPeer", func(t *testing.T) {
+		// Headers from a client connecting directly are ignored, and its own address is geolocated
+		cc := s.complianceContext(request("198.51.100.9:5000", map[string]string{"CF-IPCountry": "US", "X-Forwarded-For": "203.0.113.7"}))
+		assert.Equal(t, "198.51.100.9", cc.ClientIP)
+		assert.Equal(t, "KP", cc.Country)
+
+		cc = s.complianceContext(request("[2001:db8::1]:5000", map[string]string{"CF-IPCountry": "US"}))
+		assert.Equal(t, "2001:db8::1", cc.ClientIP)
+		assert.Empty(t, cc.Country)
+	})
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minCompressSize is the smallest response body worth compressing; smaller bodies barely shrink, or grow
+const minCompressSize = 1024
+
+// Pooled compressors, reset onto each response, spare allocating their buffers per request
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() interface{} { return zlib.NewWriter(io.Discard) }}
+)
+
+// compressibleTypes are the content types worth compressing; the others, like WebSocket upgrades, pass through
+var compressibleTypes = map[string]bool{
+	"application/json":     true,
+	"application/x-ndjson": true,
+	"text/csv":             true,
+	"text/html":            true,
+	"text/plain":           true,
+}
+
+// serveCompressed serves a request through the mux, compressing the response with gzip or deflate when the client
+// accepts it and SERVER.COMPRESSION is on
+func (s *Server) serveCompressed(w http.ResponseWriter, r *http.Request) {
+	encoding := ""
+	if s.config().Server.Compression && r.Method != http.MethodHead && r.Header.Get("Upgrade") == "" {
+		encoding = acceptedEncoding(r.Header.Get("Accept-Encoding"))
+	}
+	if encoding == "" {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	compressing := &compressingWriter{ResponseWriter: w, encoding: encoding}
+	defer compressing.Close()
+	s.mux.ServeHTTP(compressing, r)
+}
+
+// acceptedEncoding returns the encoding to compress a response with for an Accept-Encoding header, gzip before
+// deflate, or "" for neither
+func acceptedEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] || accepted["*"] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressingWriter compresses a response of a compressible type once it has written minCompressSize bytes or
+// flushed. Smaller responses are written as they are when the writer is closed.
+type compressingWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	status     int // set once the handler has written its header
+	decided    bool
+	compressor io.WriteCloser // nil until decided to compress
+	buffered   []byte
+}
+
+// WriteHeader holds the header back until the writer decides whether to compress
+func (w *compressingWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if !w.compressible() {
+		w.decide(false)
+	}
+}
+
+// Write buffers the start of the body, then compresses the rest as it comes
+func (w *compressingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.buffered = append(w.buffered, b...)
+		if len(w.buffered) < minCompressSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush compresses what the handler has written so far, so streamed responses keep streaming
+func (w *compressingWriter) Flush() {
+	if w.status == 0 {
+		return
+	}
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.compressor.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close writes the buffered body of a response too small to compress, or finishes the compressed body
+func (w *compressingWriter) Close() {
+	if w.status == 0 {
+		return
+	}
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			return
+		}
+	}
+	if w.compressor == nil {
+		return
+	}
+	if err := w.compressor.Close(); err != nil {
+		log.Printf("Error compressing response: %v", err)
+	}
+	switch compressor := w.compressor.(type) {
+	case *gzip.Writer:
+		gzipWriters.Put(compressor)
+	case *zlib.Writer:
+		zlibWriters.Put(compressor)
+	}
+	w.compressor = nil
+}
+
+// Hijack hands the connection to WebSocket handlers
+func (w *compressingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer cannot be hijacked")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *compressingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible reports whether the response has a body of a type worth compressing that isn't encoded already
+func (w *compressingWriter) compressible() bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && compressibleTypes[mediaType]
+}
+
+// decide writes the held header, compressed or not, then the buffered body
+func (w *compressingWriter) decide(compress bool) error {
+	w.decided = true
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// A compressed body differs byte for byte, so its tag is only weakly equal to the uncompressed one's
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		if w.encoding == "gzip" {
+			compressor := gzipWriters.Get().(*gzip.Writer)
+			compressor.Reset(w.ResponseWriter)
+			w.compressor = compressor
+		} else {
+			compressor := zlibWriters.Get().(*zlib.Writer)
+			compressor.Reset(w.ResponseWriter)
+			w.compressor = compressor
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buffered := w.buffered
+	w.buffered = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	if w.compressor != nil {
+		_, err := w.compressor.Write(buffered)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffered)
+	return err
+}
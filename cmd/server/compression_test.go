@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                       "",
+		"gzip":                   "gzip",
+		"deflate, gzip;q=0.5":    "gzip",
+		"deflate":                "deflate",
+		"gzip;q=0, deflate":      "deflate",
+		"GZIP":                   "gzip",
+		"br, *":                  "gzip",
+		"identity":               "",
+		"gzip; q=0, deflate;q=0": "",
+	} {
+		assert.Equal(t, expected, acceptedEncoding(header), header)
+	}
+}
+
+func TestResponseCompression(t *testing.T) {
+	s := newTestServer(t)
+
+	get := func(t *testing.T, s *Server, path, acceptEncoding string, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+	plain := get(t, s, "/api/v1/openapi.json", "", nil)
+	require.Equal(t, http.StatusOK, plain.Code)
+	require.Greater(t, plain.Body.Len(), minCompressSize)
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+
+	t.Run("Gzip", func(t *testing.T) {
+		rec := get(t, s, "/api/v1/openapi.json", "gzip, deflate", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		assert.Less(t, rec.Body.Len(), plain.Body.Len())
+
+		reader, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, plain.Body.String(), string(body))
+	})
+
+	t.Run("Deflate", func(t *testing.T) {
+		rec := get(t, s, "/api/v1/openapi.json", "deflate", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "deflate", rec.Header().Get("Content-Encoding"))
+
+		reader, err := zlib.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, plain.Body.String(), string(body))
+	})
+
+	t.Run("SmallResponsesAreNotCompressed", func(t *testing.T) {
+		rec := get(t, s, "/health", "gzip", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.True(t, json.Valid(rec.Body.Bytes()), rec.Body.String())
+	})
+
+	t.Run("CompressedETagsAreWeak", func(t *testing.T) {
+		// Enough tokens to compress the list
+		tokens := make([]types.Token, 0, 50)
+		for i := 0; i < 50; i++ {
+			tokens = append(tokens, types.Token{Symbol: fmt.Sprintf("uT%02d", i), Name: "Universal test token", ChainID: 1, IsWrapped: true})
+		}
+		s := newTestServer(t)
+		s.priceCacheDir = ""
+		s.universalSDK = universalsdk.NewMockSDK(universalsdk.MockSDKConfig{WrappedTokens: map[int64][]types.Token{1: tokens}})
+
+		rec := get(t, s, "/api/v1/tokens", "gzip", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		etag := rec.Header().Get("ETag")
+		assert.Regexp(t, `^W/"`, etag)
+
+		rec = get(t, s, "/api/v1/tokens", "gzip", http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		s.config().Server.Compression = false
+		defer func() { s.config().Server.Compression = true }()
+
+		rec := get(t, s, "/api/v1/openapi.json", "gzip", nil)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, plain.Body.String(), rec.Body.String())
+	})
+}
+
+func TestEncodeJSONStream(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	prices := []types.TokenPrice{
+		{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, LastUpdated: updated},
+		{Symbol: "<script>", ChainID: 137, PriceUSD: 0.5, LastUpdated: updated},
+	}
+
+	for name, items := range map[string][]types.TokenPrice{"Items": prices, "Empty": {}, "Nil": nil} {
+		t.Run(name, func(t *testing.T) {
+			expected := PricesResponse{Prices: items, Source: priceSourceDatabase, LastUpdated: updated}
+			if expected.Prices == nil {
+				expected.Prices = []types.TokenPrice{}
+			}
+			var want bytes.Buffer
+			require.NoError(t, json.NewEncoder(&want).Encode(expected))
+
+			var got bytes.Buffer
+			require.NoError(t, encodeJSONStream(&got, PricesResponse{Source: priceSourceDatabase, LastUpdated: updated}, "prices", items))
+			assert.Equal(t, want.String(), got.String())
+		})
+	}
+
+	t.Run("MissingField", func(t *testing.T) {
+		var got bytes.Buffer
+		assert.Error(t, encodeJSONStream(&got, PricesResponse{}, "tokens", prices))
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// redacted replaces secrets in the effective configuration
+const redacted = "[redacted]"
+
+// secretConfigKeys are the configuration keys whose values are never shown
+var secretConfigKeys = map[string]bool{
+	"API_KEY":           true,
+	"API_KEYS":          true,
+	"COINGECKO_API_KEY": true,
+	"ENROLLMENT_TOKENS": true,
+	"KEYSTORE_PASSWORD": true,
+	"PRIVATE_KEY":       true,
+	"SIGNING_KEY":       true,
+	"SIGNING_SECRET":    true,
+	"SMTP_PASSWORD":     true,
+}
+
+// ConfigResponse is returned by the admin configuration endpoint
+type ConfigResponse struct {
+	Config map[string]interface{} `json:"config"` // Keyed like the configuration file
+}
+
+// config returns the server's current configuration, which ApplyConfig may replace at any time. Read it once per
+// request when its values must agree with each other.
+func (s *Server) config() *temporal_config.Config {
+	return s.configs.Load()
+}
+
+// ApplyConfig makes cfg the server's configuration. Settings read per request, such as the default slippage,
+// compliance rules and webhook secrets, take effect at once, and the chains' RPC endpoints are replaced.
+// Settings the server was built with, such as its ports, Temporal connection, region and API keys, wait for a
+// restart.
+func (s *Server) ApplyConfig(cfg temporal_config.Config) {
+	s.configs.Store(&cfg)
+	s.rpcClient.SetRPCURLs(cfg.RPCURLs())
+	log.Printf("Applied configuration: %d chains, default slippage %.2f%%", len(cfg.Chains), cfg.Swap.DefaultSlippage)
+}
+
+// adminConfigHandler returns the configuration the server is running with, secrets redacted
+func (s *Server) adminConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	config, _ := redactedConfig(reflect.ValueOf(*s.config())).(map[string]interface{})
+	writeJSON(w, http.StatusOK, ConfigResponse{Config: config})
+}
+
+// redactedConfig converts a configuration value to JSON values keyed like the configuration file, redacting secrets
+// and every URL's credentials, path and query, where RPC providers put API keys
+func redactedConfig(v reflect.Value) interface{} {
+	if duration, ok := v.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key := field.Tag.Get("mapstructure")
+			if key == "" || !field.IsExported() {
+				continue
+			}
+			if secretConfigKeys[key] && !v.Field(i).IsZero() {
+				fields[key] = redacted
+				continue
+			}
+			fields[key] = redactedConfig(v.Field(i))
+		}
+		return fields
+	case reflect.Map:
+		values := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = redactedConfig(iter.Value())
+		}
+		return values
+	case reflect.Slice:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = redactedConfig(v.Index(i))
+		}
+		return values
+	case reflect.String:
+		return redactedURL(v.String())
+	default:
+		return v.Interface()
+	}
+}
+
+// redactedURL keeps only the scheme and host of a URL with anything after them; other values are returned as they are
+func redactedURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return value
+	}
+	if u.User == nil && strings.Trim(u.Path, "/") == "" && u.RawQuery == "" {
+		return value
+	}
+	return u.Scheme + "://" + u.Host + "/" + redacted
+}
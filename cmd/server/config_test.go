@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig(t *testing.T) {
+	s := newTestServer(t)
+
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": "0x3b9aca00"}`))
+	}))
+	defer rpc.Close()
+
+	cfg := *s.config()
+	cfg.Swap.DefaultSlippage = 2
+	ethereum := cfg.Chains["ethereum"]
+	ethereum.RPC = []string{rpc.URL}
+	cfg.Chains = map[string]temporal_config.ChainConfig{"ethereum": ethereum}
+	s.ApplyConfig(cfg)
+
+	assert.Equal(t, 2.0, s.config().Swap.DefaultSlippage)
+	gasPrice, err := s.rpcClient.GasPrice(context.Background(), ethereum.ChainID)
+	require.NoError(t, err, "the chain's RPC endpoints are replaced")
+	assert.Equal(t, int64(1_000_000_000), gasPrice.Int64())
+}
+
+func TestSwapRequestDefaultSlippage(t *testing.T) {
+	body := testSwapBody()
+	body.Slippage = 0
+	request, err := swapRequestFromBody(body, 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, request.Slippage, "a body without a slippage gets the default")
+
+	body.Slippage = 0.3
+	request, err = swapRequestFromBody(body, 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, 0.3, request.Slippage)
+}
+
+func TestAdminConfig(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	cfg := *s.config()
+	cfg.Universal.APIKey = "universal-secret"
+	cfg.Execution.PrivateKey = "0x4646464646464646464646464646464646464646464646464646464646464646"
+	cfg.Prices.RedisURL = "redis://:hunter2@cache:6379/0"
+	cfg.Notifications.SMTPPassword = "smtp-secret"
+	ethereum := cfg.Chains["ethereum"]
+	ethereum.RPC = []string{"https://eth-mainnet.g.alchemy.com/v2/alchemy-key", "${ETH_RPC_URL}"}
+	cfg.Chains = map[string]temporal_config.ChainConfig{"ethereum": ethereum}
+	s.ApplyConfig(cfg)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/config", nil, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/config", nil, adminKey)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	body := rec.Body.String()
+	for _, secret := range []string{"universal-secret", "4646464646", "hunter2", "alchemy-key", "smtp-secret", testSandboxKey} {
+		assert.NotContains(t, body, secret)
+	}
+
+	var resp struct {
+		Config struct {
+			Swap struct {
+				DefaultSlippage float64 `json:"DEFAULT_SLIPPAGE"`
+				MaxSwapTime     string  `json:"MAX_SWAP_TIME"`
+			} `json:"SWAP"`
+			Chains map[string]struct {
+				RPC []string `json:"RPC"`
+			} `json:"CHAINS"`
+			Prices struct {
+				RedisURL string `json:"REDIS_URL"`
+			} `json:"PRICES"`
+		} `json:"config"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, cfg.Swap.DefaultSlippage, resp.Config.Swap.DefaultSlippage)
+	assert.Equal(t, cfg.Swap.MaxSwapTime.String(), resp.Config.Swap.MaxSwapTime)
+	assert.Equal(t, []string{"https://eth-mainnet.g.alchemy.com/[redacted]", "${ETH_RPC_URL}"}, resp.Config.Chains["ethereum"].RPC)
+	assert.Equal(t, "redis://cache:6379/[redacted]", resp.Config.Prices.RedisURL)
+}
+
+func TestSwapRequestTranches(t *testing.T) {
+	s := newTestServer(t)
+
+	body := testSwapBody()
+	body.Amount = "1000"
+	body.Tranches = &TranchesBody{Size: "300"}
+	request, err := swapRequestFromBody(body, 0.5)
+	require.NoError(t, err)
+	require.NoError(t, s.checkTranches(&request, body))
+	assert.Equal(t, "300", request.Tranches.Size.String())
+	assert.Equal(t, s.config().Swap.TrancheDelay, request.Tranches.Delay, "a body without a delay gets the default")
+	assert.Len(t, request.Tranches.Amounts(request.Amount), 4)
+
+	body.Tranches.Delay = "0s"
+	request, err = swapRequestFromBody(body, 0.5)
+	require.NoError(t, err)
+	require.NoError(t, s.checkTranches(&request, body))
+	assert.Zero(t, request.Tranches.Delay)
+
+	for name, tranches := range map[string]TranchesBody{
+		"zero size":        {Size: "0"},
+		"fractional size":  {Size: "0.0000000000000000001"}, // Finer than ETH's 18 decimals
+		"negative delay":   {Size: "300", Delay: "-1s"},
+		"unparsable delay": {Size: "300", Delay: "soon"},
+	} {
+		body.Tranches = &tranches
+		_, err := swapRequestFromBody(body, 0.5)
+		assert.Error(t, err, name)
+	}
+
+	for name, tranches := range map[string]TranchesBody{
+		"too many tranches": {Size: "1"},
+		"delay too long":    {Size: "300", Delay: "1h"},
+	} {
+		body.Tranches = &tranches
+		request, err := swapRequestFromBody(body, 0.5)
+		require.NoError(t, err, name)
+		assert.Error(t, s.checkTranches(&request, body), name)
+	}
+
+	body.Tranches = &TranchesBody{Size: "300"}
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "Temporal")
+}
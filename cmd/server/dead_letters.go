@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+)
+
+const (
+	// defaultDeadLettersLimit is the number of dead letters returned when no limit is given
+	defaultDeadLettersLimit = 100
+	// maxDeadLettersLimit is the most dead letters returned at once
+	maxDeadLettersLimit = 1000
+)
+
+// errDeadLetterResolved is returned when resolving a swap whose dead letter an operator already resolved
+var errDeadLetterResolved = errors.New("dead letter already resolved")
+
+// DeadLettersResponse is returned by the dead letters endpoint
+type DeadLettersResponse struct {
+	DeadLetters []types.DeadLetter `json:"deadLetters"`
+}
+
+// SetDeadLetterStore sets the store of the swaps the swap workers dead-lettered
+func (s *Server) SetDeadLetterStore(store services.DeadLetterStore) {
+	s.deadLetters = store
+}
+
+// adminDeadLettersHandler lists the swaps the swap workers found stuck or failed, most recently detected first,
+// with ?status (open, requeued or refunded; default any) up to ?limit (default 100)
+func (s *Server) adminDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	status := types.DeadLetterStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", types.DeadLetterOpen, types.DeadLetterRequeued, types.DeadLetterRefunded:
+	default:
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid status %q: must be %s, %s or %s",
+			status, types.DeadLetterOpen, types.DeadLetterRequeued, types.DeadLetterRefunded))
+		return
+	}
+	limit := defaultDeadLettersLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxDeadLettersLimit {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q: must be between 1 and %d", raw, maxDeadLettersLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	letters, err := s.deadLetters.ListDeadLetters(r.Context(), status, limit)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list dead letters: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, DeadLettersResponse{DeadLetters: letters})
+}
+
+// prepareResolveDeadLetter validates a dead letter resolution and fills in the open dead letter, and for requeues
+// the swap's original input
+func (s *Server) prepareResolveDeadLetter(ctx context.Context, input *temporal_workflows.AdminActionInput) error {
+	requestID := input.Params["requestId"]
+	if requestID == "" {
+		return errors.New("params.requestId is required")
+	}
+	resolution := input.Params["resolution"]
+	if resolution != temporal_workflows.DeadLetterRequeue && resolution != temporal_workflows.DeadLetterRefund {
+		return fmt.Errorf("params.resolution must be %s or %s", temporal_workflows.DeadLetterRequeue, temporal_workflows.DeadLetterRefund)
+	}
+	if step := input.Params["step"]; step != "" && step != temporal_workflows.SwapStepQuote && step != temporal_workflows.SwapStepExecute {
+		return fmt.Errorf("params.step must be %s or %s", temporal_workflows.SwapStepQuote, temporal_workflows.SwapStepExecute)
+	}
+
+	letter, err := s.deadLetters.GetDeadLetter(ctx, requestID)
+	if errors.Is(err, types.ErrDeadLetterNotFound) {
+		return fmt.Errorf("swap %s has no dead letter", requestID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get dead letter of swap %s: %v", requestID, err)
+	}
+	if letter.Status != types.DeadLetterOpen {
+		return fmt.Errorf("%w: swap %s was %s by %s", errDeadLetterResolved, requestID, letter.Status, letter.ResolvedBy)
+	}
+	input.DeadLetter = letter
+
+	if resolution == temporal_workflows.DeadLetterRequeue {
+		swap, err := s.swapWorkflowInput(ctx, requestID, letter.RunID)
+		if err != nil {
+			return fmt.Errorf("swap %s not found: %v", requestID, err)
+		}
+		input.Swap = swap
+	}
+	return nil
+}
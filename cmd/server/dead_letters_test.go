@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminDeadLetters(t *testing.T) {
+	const adminKey = "admin-test-key"
+	ctx := context.Background()
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	store := services.NewInMemoryDeadLetterStore()
+	s.SetDeadLetterStore(store)
+
+	now := time.Now().UTC()
+	for _, letter := range []types.DeadLetter{
+		{RequestID: "swap-stuck", RunID: "run-1", Reason: types.DeadLetterStuck, Detail: "ExecuteSwapActivity on attempt 4: rpc timeout", DetectedAt: now, Status: types.DeadLetterOpen},
+		{RequestID: "swap-failed", RunID: "run-2", Reason: types.DeadLetterFailed, DetectedAt: now.Add(-time.Minute), Status: types.DeadLetterOpen},
+	} {
+		_, err := store.RecordDeadLetter(ctx, letter)
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.ResolveDeadLetter(ctx, "swap-failed", types.DeadLetterRefunded, "alice", "admin-1", now))
+
+	t.Run("List", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/dead-letters", nil, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/dead-letters?status=open", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp DeadLettersResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.DeadLetters, 1)
+		assert.Equal(t, "swap-stuck", resp.DeadLetters[0].RequestID)
+		assert.Equal(t, types.DeadLetterStuck, resp.DeadLetters[0].Reason)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/dead-letters", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Len(t, resp.DeadLetters, 2)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/dead-letters?status=lost", nil, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/dead-letters?limit=0", nil, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("PrepareResolution", func(t *testing.T) {
+		request := types.SwapRequest{
+			RequestID:        "swap-stuck",
+			SourceToken:      types.Token{Symbol: "ETH", ChainID: 1},
+			DestinationToken: types.Token{Symbol: "USDC", ChainID: 1},
+			Amount:           big.NewInt(1000),
+		}
+		s.temporalClient = &closedSwapsClient{
+			inputs: map[string]temporal_workflows.SwapWorkflowInput{"swap-stuck": {Request: request}},
+		}
+		defer func() { s.temporalClient = nil }()
+
+		input := temporal_workflows.AdminActionInput{
+			Action: temporal_workflows.AdminActionResolveDeadLetter,
+			Params: map[string]string{"requestId": "swap-stuck", "resolution": temporal_workflows.DeadLetterRequeue},
+		}
+		require.NoError(t, s.prepareAdminInput(ctx, &input))
+		require.NotNil(t, input.DeadLetter)
+		assert.Equal(t, "run-1", input.DeadLetter.RunID)
+		require.NotNil(t, input.Swap)
+		assert.Equal(t, "swap-stuck", input.Swap.Request.RequestID)
+
+		// Refunds don't need the swap's input
+		input = temporal_workflows.AdminActionInput{
+			Action: temporal_workflows.AdminActionResolveDeadLetter,
+			Params: map[string]string{"requestId": "swap-stuck", "resolution": temporal_workflows.DeadLetterRefund},
+		}
+		require.NoError(t, s.prepareAdminInput(ctx, &input))
+		assert.NotNil(t, input.DeadLetter)
+		assert.Nil(t, input.Swap)
+
+		for name, params := range map[string]map[string]string{
+			"missing request":    {"resolution": temporal_workflows.DeadLetterRefund},
+			"unknown resolution": {"requestId": "swap-stuck", "resolution": "ignore"},
+			"unknown step":       {"requestId": "swap-stuck", "resolution": temporal_workflows.DeadLetterRequeue, "step": "bridge"},
+			"no dead letter":     {"requestId": "swap-healthy", "resolution": temporal_workflows.DeadLetterRefund},
+		} {
+			input := temporal_workflows.AdminActionInput{Action: temporal_workflows.AdminActionResolveDeadLetter, Params: params}
+			assert.Error(t, s.prepareAdminInput(ctx, &input), name)
+		}
+
+		input = temporal_workflows.AdminActionInput{
+			Action: temporal_workflows.AdminActionResolveDeadLetter,
+			Params: map[string]string{"requestId": "swap-failed", "resolution": temporal_workflows.DeadLetterRefund},
+		}
+		assert.ErrorIs(t, s.prepareAdminInput(ctx, &input), errDeadLetterResolved)
+	})
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+)
+
+const (
+	// depositPollInterval is how often deposit addresses are checked for expected deposits
+	depositPollInterval = 15 * time.Second
+
+	// webhookAttempts is how many times a webhook is sent before it is given up on
+	webhookAttempts = 3
+
+	// webhookRetryDelay is the wait before the first webhook retry; it doubles after each attempt
+	webhookRetryDelay = 2 * time.Second
+
+	// depositReceivedEvent is the event of the webhook sent when a swap's deposit arrives
+	depositReceivedEvent = "deposit.received"
+)
+
+// DepositInstructions tells the client where to send the deposit funding a swap
+type DepositInstructions struct {
+	Address   string      `json:"address"`
+	Token     types.Token `json:"token"`
+	Amount    string      `json:"amount"` // Minimum amount, in base units
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// DepositWebhook is the body POSTed to a swap's webhook URL when its deposit arrives
+type DepositWebhook struct {
+	Event     string        `json:"event"`
+	RequestID string        `json:"requestId"`
+	Deposit   types.Deposit `json:"deposit"`
+}
+
+// newDepositWatcher creates a watcher reading transfers with indexer, using each chain's configured confirmations
+func newDepositWatcher(cfg temporal_config.Config, indexer services.TransferIndexer) *services.DepositWatcher {
+	confirmations := make(map[int64]int, len(cfg.Chains))
+	for _, chain := range cfg.Chains {
+		confirmations[chain.ChainID] = chain.Confirmations
+	}
+	return services.NewDepositWatcher(indexer, confirmations)
+}
+
+// SetDepositStore replaces the store the deposit watcher keeps expected deposits in
+func (s *Server) SetDepositStore(store services.DepositStore) {
+	s.deposits.SetStore(store)
+}
+
+// validateWebhookURL checks a client's webhook URL is an https URL whose host resolves only to public addresses
+func (s *Server) validateWebhookURL(ctx context.Context, webhookURL string) error {
+	if s.config().Webhooks.SigningSecret == "" {
+		return errors.New("webhooks are not configured")
+	}
+	return services.CheckWebhookURL(ctx, webhookURL)
+}
+
+// expectDeposit starts watching for the deposit funding a swap. It returns the HTTP status to fail the request with.
+func (s *Server) expectDeposit(ctx context.Context, request types.SwapRequest, webhookURL string) (*types.ExpectedDeposit, int, error) {
+	if webhookURL != "" {
+		if err := s.validateWebhookURL(ctx, webhookURL); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	address := s.depositAddress(request.SourceToken.ChainID)
+	if address == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("deposits are not accepted on chain %d", request.SourceToken.ChainID)
+	}
+
+	expected := types.ExpectedDeposit{
+		RequestID:  request.RequestID,
+		Token:      request.SourceToken,
+		From:       request.SourceAddress,
+		Address:    address,
+		Amount:     request.Amount,
+		WebhookURL: webhookURL,
+		ExpiresAt:  time.Now().Add(temporal_workflows.DepositTimeout),
+	}
+	if err := s.deposits.Expect(ctx, expected); err != nil {
+		if errors.Is(err, services.ErrDepositNotSupported) {
+			return nil, http.StatusBadRequest, err
+		}
+		return nil, http.StatusServiceUnavailable, err
+	}
+	return &expected, 0, nil
+}
+
+// depositAddress returns the deposit address configured for a chain
+func (s *Server) depositAddress(chainID int64) string {
+	chainID = types.CanonicalChainID(chainID)
+	for _, chain := range s.config().Chains {
+		if chain.ChainID == chainID {
+			return chain.DepositAddress
+		}
+	}
+	return ""
+}
+
+// onDeposit signals the swap waiting for a deposit that arrived and notifies the client's webhook
+func (s *Server) onDeposit(ctx context.Context, deposit types.Deposit, expected types.ExpectedDeposit) {
+	if s.temporalClient != nil {
+		if err := s.temporalClient.SignalWorkflow(ctx, deposit.RequestID, "", temporal_workflows.DepositReceivedSignal, deposit); err != nil {
+			log.Printf("Failed to signal deposit for swap %s: %v", deposit.RequestID, err)
+		}
+	}
+	if err := events.Publish(ctx, s.events, events.DepositReceivedTopic, events.DepositReceived{Deposit: deposit, Expected: expected}); err != nil {
+		log.Printf("Failed to publish deposit for swap %s: %v", deposit.RequestID, err)
+	}
+}
+
+// sendWebhook POSTs payload to a client's webhook URL, retrying failed attempts with backoff
+func (s *Server) sendWebhook(ctx context.Context, webhookURL string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := webhookRetryDelay
+	for attempt := 1; ; attempt++ {
+		err = services.PostWebhook(ctx, s.webhookClient, webhookURL, s.config().Webhooks.SigningSecret, body)
+		if err == nil || attempt == webhookAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	if err != nil {
+		log.Printf("Failed to send webhook to %s: %v", webhookURL, err)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// Bounds of the admin top errors listing
+const (
+	defaultTopErrorsWindow = 24 * time.Hour
+	defaultTopErrorsLimit  = 20
+	maxTopErrorsLimit      = 200
+)
+
+// TopErrorsResponse lists the most frequent errors seen since a time
+type TopErrorsResponse struct {
+	Since  time.Time         `json:"since"`
+	Errors []types.ErrorStat `json:"errors"`
+}
+
+// newErrorReporter creates the reporter counting the server's errors, sending them to Sentry when a DSN is
+// configured
+func newErrorReporter(cfg temporal_config.ErrorsConfig, environment string) *services.ErrorReporter {
+	reporter := services.NewErrorReporter(services.NewInMemoryErrorStore())
+	if cfg.SentryDSN != "" {
+		sink, err := services.NewSentrySink(&http.Client{Timeout: 5 * time.Second}, cfg.SentryDSN, environment)
+		if err != nil {
+			log.Printf("Sending errors to Sentry disabled: %v", err)
+			return reporter
+		}
+		reporter.SetSink(sink)
+	}
+	return reporter
+}
+
+// SetErrorStore sets the store error counts are flushed to
+func (s *Server) SetErrorStore(store services.ErrorStore) {
+	s.errorReporter.SetStore(store)
+}
+
+// errorReportingWriter notes the error response written through it, so the error can be reported with the route
+// that returned it
+type errorReportingWriter struct {
+	http.ResponseWriter
+	code    ErrorCode
+	message string
+}
+
+// Flush flushes the underlying writer, so streamed responses keep streaming
+func (w *errorReportingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hands the connection to WebSocket handlers
+func (w *errorReportingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer cannot be hijacked")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *errorReportingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// noteError records an error response on the error reporting writer w writes through, if any
+func noteError(w http.ResponseWriter, code ErrorCode, message string) {
+	for {
+		if reporting, ok := w.(*errorReportingWriter); ok {
+			reporting.code, reporting.message = code, message
+			return
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = unwrapper.Unwrap()
+	}
+}
+
+// reportAPIError reports the error response a request was served, by the route pattern it matched
+func (s *Server) reportAPIError(r *http.Request, w *errorReportingWriter) {
+	if w.code != "" && r.Pattern != "" {
+		s.errorReporter.ReportCode(types.ErrorOriginAPI, r.Pattern, string(w.code), w.message)
+	}
+}
+
+// adminErrorsHandler lists the errors of the API, workflows, activities and SDK calls that occurred most often,
+// among those seen in the last ?window (default 24h), up to ?limit (default 20)
+func (s *Server) adminErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	window := defaultTopErrorsWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid window %q: must be a positive duration such as 24h", value))
+			return
+		}
+		window = parsed
+	}
+	limit := defaultTopErrorsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxTopErrorsLimit {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q: must be between 1 and %d", value, maxTopErrorsLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	since := time.Now().UTC().Add(-window)
+	top, err := s.errorReporter.TopErrors(r.Context(), since, limit)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load errors: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, TopErrorsResponse{Since: since, Errors: top})
+}
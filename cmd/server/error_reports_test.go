@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorReports(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	s.tenantKeys["acme-key"] = "acme"
+
+	tooSmall := testSwapBody()
+	tooSmall.Amount = "1"
+	for i := 0; i < 3; i++ {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", tooSmall, "")
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	}
+	// Errors of metered requests are reported too
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/pools/missing", nil, "acme-key")
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/errors", nil, "acme-key")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("TopErrors", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/errors?window=1h", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp TopErrorsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.NotEmpty(t, resp.Errors)
+		top := resp.Errors[0]
+		assert.Equal(t, types.ErrorOriginAPI, top.Origin)
+		assert.Equal(t, "POST /api/v1/swap/quote", top.Operation)
+		assert.Equal(t, string(ErrorCodeAmountTooSmall), top.Code)
+		assert.Equal(t, int64(3), top.Count)
+
+		operations := map[string]bool{}
+		for _, stat := range resp.Errors {
+			operations[stat.Operation] = true
+		}
+		assert.True(t, operations["GET /api/v1/pools/{id}"], "Expected the pool lookup error, got %+v", resp.Errors)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/errors?limit=1", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp TopErrorsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Len(t, resp.Errors, 1)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		for _, query := range []string{"window=yesterday", "window=-1h", "limit=0", "limit=1000"} {
+			rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/errors?"+query, nil, adminKey)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+}
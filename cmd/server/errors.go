@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+// requestIDHeader carries the ID of an API request, given by the client or assigned by the server
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest request ID accepted from clients; longer ones are replaced
+const maxRequestIDLength = 128
+
+// ErrorCode is a machine-readable error code clients can branch on. Codes are versioned with the API: within
+// /api/v1 a code keeps its meaning and HTTP status, and new failures get new codes rather than changing old ones.
+type ErrorCode string
+
+// Codes of failures clients commonly handle
+const (
+	ErrorCodeTokenNotFound         ErrorCode = "TOKEN_NOT_FOUND"
+	ErrorCodeAmountTooSmall        ErrorCode = "AMOUNT_TOO_SMALL"
+	ErrorCodeSlippageExceeded      ErrorCode = "SLIPPAGE_EXCEEDED"
+	ErrorCodeWorkflowNotFound      ErrorCode = "WORKFLOW_NOT_FOUND"
+	ErrorCodePoolNotFound          ErrorCode = "POOL_NOT_FOUND"
+	ErrorCodeInsufficientLiquidity ErrorCode = "INSUFFICIENT_LIQUIDITY"
+	ErrorCodePriceUnavailable      ErrorCode = "PRICE_UNAVAILABLE"
+	ErrorCodeCircuitBreakerTripped ErrorCode = "CIRCUIT_BREAKER_TRIPPED"
+	ErrorCodeChainUnavailable      ErrorCode = "CHAIN_UNAVAILABLE"
+	ErrorCodeGasSponsorship        ErrorCode = "GAS_SPONSORSHIP_UNAVAILABLE"
+	ErrorCodeQuoteNotFound         ErrorCode = "QUOTE_NOT_FOUND"
+	ErrorCodeQuoteExpired          ErrorCode = "QUOTE_EXPIRED"
+	ErrorCodePriceImpactTooHigh    ErrorCode = "PRICE_IMPACT_TOO_HIGH"
+	ErrorCodeLimitExceeded         ErrorCode = "LIMIT_EXCEEDED"
+)
+
+// Codes of failures without a more specific code, one per HTTP status
+const (
+	ErrorCodeInvalidRequest     ErrorCode = "INVALID_REQUEST"
+	ErrorCodeUnauthorized       ErrorCode = "UNAUTHORIZED"
+	ErrorCodeForbidden          ErrorCode = "FORBIDDEN"
+	ErrorCodeNotFound           ErrorCode = "NOT_FOUND"
+	ErrorCodeConflict           ErrorCode = "CONFLICT"
+	ErrorCodeTooManyRequests    ErrorCode = "TOO_MANY_REQUESTS"
+	ErrorCodeInternal           ErrorCode = "INTERNAL_ERROR"
+	ErrorCodeNotImplemented     ErrorCode = "NOT_IMPLEMENTED"
+	ErrorCodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
+)
+
+// errorCodeStatus is the HTTP status each error code is returned with
+var errorCodeStatus = map[ErrorCode]int{
+	ErrorCodeTokenNotFound:         http.StatusNotFound,
+	ErrorCodeAmountTooSmall:        http.StatusBadRequest,
+	ErrorCodeSlippageExceeded:      http.StatusBadRequest,
+	ErrorCodeWorkflowNotFound:      http.StatusNotFound,
+	ErrorCodePoolNotFound:          http.StatusNotFound,
+	ErrorCodeInsufficientLiquidity: http.StatusBadRequest,
+	ErrorCodePriceUnavailable:      http.StatusServiceUnavailable,
+	ErrorCodeCircuitBreakerTripped: http.StatusServiceUnavailable,
+	ErrorCodeChainUnavailable:      http.StatusServiceUnavailable,
+	ErrorCodeGasSponsorship:        http.StatusBadRequest,
+	ErrorCodeQuoteNotFound:         http.StatusNotFound,
+	ErrorCodeQuoteExpired:          http.StatusBadRequest,
+	ErrorCodePriceImpactTooHigh:    http.StatusBadRequest,
+	ErrorCodeLimitExceeded:         http.StatusBadRequest,
+
+	ErrorCodeInvalidRequest:     http.StatusBadRequest,
+	ErrorCodeUnauthorized:       http.StatusUnauthorized,
+	ErrorCodeForbidden:          http.StatusForbidden,
+	ErrorCodeNotFound:           http.StatusNotFound,
+	ErrorCodeConflict:           http.StatusConflict,
+	ErrorCodeTooManyRequests:    http.StatusTooManyRequests,
+	ErrorCodeInternal:           http.StatusInternalServerError,
+	ErrorCodeNotImplemented:     http.StatusNotImplemented,
+	ErrorCodeServiceUnavailable: http.StatusServiceUnavailable,
+}
+
+// statusErrorCodes is the code of errors without a more specific code, by HTTP status
+var statusErrorCodes = map[int]ErrorCode{
+	http.StatusBadRequest:          ErrorCodeInvalidRequest,
+	http.StatusUnauthorized:        ErrorCodeUnauthorized,
+	http.StatusForbidden:           ErrorCodeForbidden,
+	http.StatusNotFound:            ErrorCodeNotFound,
+	http.StatusConflict:            ErrorCodeConflict,
+	http.StatusTooManyRequests:     ErrorCodeTooManyRequests,
+	http.StatusInternalServerError: ErrorCodeInternal,
+	http.StatusNotImplemented:      ErrorCodeNotImplemented,
+	http.StatusServiceUnavailable:  ErrorCodeServiceUnavailable,
+}
+
+// serviceErrorCodes codes the service errors clients can act on
+var serviceErrorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{services.ErrAmountTooSmall, ErrorCodeAmountTooSmall},
+	{services.ErrOutputBelowMinimum, ErrorCodeSlippageExceeded},
+	{services.ErrQuoteDrift, ErrorCodeSlippageExceeded},
+	{services.ErrInsufficientPoolLiquidity, ErrorCodeInsufficientLiquidity},
+	{services.ErrStalePrice, ErrorCodePriceUnavailable},
+	{services.ErrPriceUnavailable, ErrorCodePriceUnavailable},
+	{services.ErrCircuitBreakerTripped, ErrorCodeCircuitBreakerTripped},
+	{services.ErrChainUnavailable, ErrorCodeChainUnavailable},
+	{types.ErrPoolNotFound, ErrorCodePoolNotFound},
+	{types.ErrGasSponsorshipUnavailable, ErrorCodeGasSponsorship},
+	{services.ErrQuoteExpired, ErrorCodeQuoteExpired},
+	{services.ErrPriceImpactTooHigh, ErrorCodePriceImpactTooHigh},
+	{types.ErrSwapLimitExceeded, ErrorCodeLimitExceeded},
+	{types.ErrSwapVolumeBooked, ErrorCodeConflict},
+}
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error     string    `json:"error"`
+	Code      ErrorCode `json:"code"`
+	RequestID string    `json:"requestId"` // Also in the X-Request-ID header; quote it when reporting a problem
+}
+
+// apiError is a failed API call, returned by logic shared between the REST and gRPC APIs and written as either's
+// error response
+type apiError struct {
+	status  int
+	code    ErrorCode
+	message string
+}
+
+// Error returns the message of the error
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// newAPIError creates an error with the generic code of its status
+func newAPIError(status int, message string) *apiError {
+	code, ok := statusErrorCodes[status]
+	if !ok {
+		code = ErrorCodeInternal
+		if status < http.StatusInternalServerError {
+			code = ErrorCodeInvalidRequest
+		}
+	}
+	return &apiError{status: status, code: code, message: message}
+}
+
+// codedAPIError creates an error with a code, at the code's status
+func codedAPIError(code ErrorCode, message string) *apiError {
+	return &apiError{status: errorCodeStatus[code], code: code, message: message}
+}
+
+// serviceAPIError creates an error for a service error, with its specific code when it has one and otherwise with
+// the generic code of status
+func serviceAPIError(status int, err error) *apiError {
+	for _, coded := range serviceErrorCodes {
+		if errors.Is(err, coded.err) {
+			return codedAPIError(coded.code, err.Error())
+		}
+	}
+	return newAPIError(status, err.Error())
+}
+
+// errorResponse writes an error response with the generic code of its status
+func errorResponse(w http.ResponseWriter, status int, message string) {
+	writeAPIError(w, newAPIError(status, message))
+}
+
+// codedErrorResponse writes an error response with a code, at the code's status
+func codedErrorResponse(w http.ResponseWriter, code ErrorCode, message string) {
+	writeAPIError(w, codedAPIError(code, message))
+}
+
+// serviceErrorResponse writes an error response for a service error, with its specific code when it has one and
+// otherwise with the generic code of status
+func serviceErrorResponse(w http.ResponseWriter, status int, err error) {
+	writeAPIError(w, serviceAPIError(status, err))
+}
+
+// writeAPIError writes the error response of an API error
+func writeAPIError(w http.ResponseWriter, err *apiError) {
+	writeError(w, err.status, err.code, err.message)
+}
+
+// writeError writes an error response carrying the ID of the request, which ServeHTTP sets on the response, and
+// notes the error for reporting
+func writeError(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	noteError(w, code, message)
+	writeJSON(w, status, ErrorResponse{
+		Error:     message,
+		Code:      code,
+		RequestID: w.Header().Get(requestIDHeader),
+	})
+}
+
+// validRequestID reports whether a client's request ID can be echoed back: printable ASCII without spaces, of
+// reasonable length
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
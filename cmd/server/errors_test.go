@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeError decodes an error response, checking it carries the response's request ID
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+
+	var resp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.NotEmpty(t, resp.Error)
+	assert.NotEmpty(t, resp.RequestID)
+	assert.Equal(t, rec.Header().Get(requestIDHeader), resp.RequestID)
+	return resp
+}
+
+func TestErrorCodes(t *testing.T) {
+	s := newTestServer(t)
+
+	t.Run("Specific", func(t *testing.T) {
+		tooSmall := testSwapBody()
+		tooSmall.Amount = "1"
+		// The test server has no price policy to price sponsored gas with
+		sponsored := testSwapBody()
+		sponsored.SponsorGas = true
+
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			body   interface{}
+			status int
+			code   ErrorCode
+		}{
+			{"AmountTooSmall", http.MethodPost, "/api/v1/swap/quote", tooSmall, http.StatusBadRequest, ErrorCodeAmountTooSmall},
+			{"WorkflowNotFound", http.MethodGet, "/api/v1/swap/missing", nil, http.StatusNotFound, ErrorCodeWorkflowNotFound},
+			{"PoolNotFound", http.MethodGet, "/api/v1/pools/missing", nil, http.StatusNotFound, ErrorCodePoolNotFound},
+			{"GasSponsorship", http.MethodPost, "/api/v1/swap/quote", sponsored, http.StatusBadRequest, ErrorCodeGasSponsorship},
+			{"Generic", http.MethodPost, "/api/v1/swap/quote", map[string]string{"amount": "one"}, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := doRequest(t, s, tt.method, tt.path, tt.body, "")
+				require.Equal(t, tt.status, rec.Code, rec.Body.String())
+				assert.Equal(t, tt.code, decodeError(t, rec).Code)
+			})
+		}
+	})
+
+	t.Run("RequestID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/pools/missing", nil)
+		req.Header.Set(requestIDHeader, "client-trace-42")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, "client-trace-42", decodeError(t, rec).RequestID)
+
+		// Unusable IDs are replaced rather than echoed
+		for _, id := range []string{"has spaces", strings.Repeat("x", maxRequestIDLength+1)} {
+			req = httptest.NewRequest(http.MethodGet, "/api/v1/pools/missing", nil)
+			req.Header.Set(requestIDHeader, id)
+			rec = httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.NotEqual(t, id, decodeError(t, rec).RequestID)
+		}
+	})
+
+	t.Run("EveryCodeHasStatus", func(t *testing.T) {
+		for _, coded := range serviceErrorCodes {
+			assert.Contains(t, errorCodeStatus, coded.code)
+		}
+		for status, code := range statusErrorCodes {
+			assert.Equal(t, status, errorCodeStatus[code])
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// newEventBus creates the bus the server's domain events are published to, forwarding them to the configured
+// external bus
+func newEventBus(cfg temporal_config.EventsConfig) *events.Bus {
+	bus := events.NewBus()
+	switch {
+	case cfg.NATSURL != "":
+		forwarder, err := events.NewNATSForwarder(cfg.NATSURL)
+		if err != nil {
+			log.Printf("Event forwarding disabled: %v", err)
+			return bus
+		}
+		bus.SetForwarder(forwarder, cfg.SubjectPrefix, cfg.Buffer)
+	case cfg.KafkaRESTURL != "":
+		bus.SetForwarder(events.NewKafkaRESTForwarder(&http.Client{Timeout: 5 * time.Second}, cfg.KafkaRESTURL), cfg.SubjectPrefix, cfg.Buffer)
+	}
+	return bus
+}
+
+// publishSwapStarted announces a swap the server started
+func (s *Server) publishSwapStarted(r *http.Request, request types.SwapRequest) {
+	_, tenant, _ := s.meteredKey(r)
+	event := events.SwapStarted{
+		RequestID:        request.RequestID,
+		SourceToken:      request.SourceToken,
+		DestinationToken: request.DestinationToken,
+		Amount:           request.Amount,
+		Tenant:           tenant,
+		Sandbox:          s.isSandbox(r),
+		StartedAt:        time.Now().UTC(),
+	}
+	if err := events.Publish(r.Context(), s.events, events.SwapStartedTopic, event); err != nil {
+		log.Printf("Failed to publish start of swap %s: %v", request.RequestID, err)
+	}
+}
+
+// notifyDeposit sends the webhook of a swap whose deposit arrived, if the client asked for one.
+// Webhooks retry with backoff, so each is sent on its own rather than holding up the next deposit's.
+func (s *Server) notifyDeposit(ctx context.Context, event events.DepositReceived) {
+	if event.Expected.WebhookURL == "" {
+		return
+	}
+	go s.sendWebhook(ctx, event.Expected.WebhookURL, DepositWebhook{
+		Event:     depositReceivedEvent,
+		RequestID: event.Deposit.RequestID,
+		Deposit:   event.Deposit,
+	})
+}
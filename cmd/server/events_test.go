@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainEvents(t *testing.T) {
+	s := newTestServer(t)
+	s.tenantKeys["acme-key"] = "acme"
+
+	t.Run("SwapStarted", func(t *testing.T) {
+		started := make(chan events.SwapStarted, 1)
+		sub := events.Subscribe(s.events, events.SwapStartedTopic, 1, events.Block, func(ctx context.Context, event events.SwapStarted) {
+			started <- event
+		})
+		defer sub.Close()
+
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), "acme-key")
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		var response SwapResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+		select {
+		case event := <-started:
+			assert.Equal(t, response.RequestID, event.RequestID)
+			assert.Equal(t, "acme", event.Tenant)
+			assert.Equal(t, "ETH", event.SourceToken.Symbol)
+			assert.Equal(t, "1000000000000000000", event.Amount.String())
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the swap to be announced")
+		}
+	})
+
+	t.Run("DepositWebhook", func(t *testing.T) {
+		received := make(chan DepositWebhook, 1)
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var webhook DepositWebhook
+			json.NewDecoder(r.Body).Decode(&webhook)
+			received <- webhook
+		}))
+		defer target.Close()
+		s.config().Webhooks.SigningSecret = "secret"
+		s.webhookClient = target.Client()
+
+		deposit := types.Deposit{RequestID: "swap-1", ChainID: 1, TxHash: "0xabc"}
+		s.onDeposit(context.Background(), deposit, types.ExpectedDeposit{RequestID: "swap-1", WebhookURL: target.URL})
+
+		select {
+		case webhook := <-received:
+			assert.Equal(t, depositReceivedEvent, webhook.Event)
+			assert.Equal(t, "0xabc", webhook.Deposit.TxHash)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the deposit webhook")
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	infinitydexv1 "github.com/infinity-dex/api/infinitydex/v1"
+	"github.com/infinity-dex/services/types"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcErrorDomain is the domain of the ErrorInfo detail of gRPC errors, whose reason is the API error code
+const grpcErrorDomain = "infinity-dex"
+
+// grpcCodes is the gRPC code of API errors, by HTTP status; other statuses are Internal
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:         codes.InvalidArgument,
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusNotFound:           codes.NotFound,
+	http.StatusConflict:           codes.AlreadyExists,
+	http.StatusTooManyRequests:    codes.ResourceExhausted,
+	http.StatusNotImplemented:     codes.Unimplemented,
+	http.StatusServiceUnavailable: codes.Unavailable,
+}
+
+// grpcRequestKey is the context key of the HTTP request a gRPC call is served as
+type grpcRequestKey struct{}
+
+// newGRPCServer creates the gRPC API, serving swaps, prices and tokens like the REST API. Calls are served as HTTP
+// requests built from their metadata, so API keys, request IDs and forwarded addresses are sent as the REST API's
+// headers, in lowercase.
+func (s *Server) newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(s.grpcInterceptor))
+	infinitydexv1.RegisterSwapServiceServer(server, &swapGRPCService{server: s})
+	infinitydexv1.RegisterPriceServiceServer(server, &priceGRPCService{server: s})
+	infinitydexv1.RegisterTokenServiceServer(server, &tokenGRPCService{server: s})
+	return server
+}
+
+// grpcInterceptor serves a gRPC call as its HTTP request, sending the request ID back in the x-request-id header,
+// converting API errors to gRPC errors and reporting them, and metering the call by its method
+func (s *Server) grpcInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	r := grpcHTTPRequest(ctx, info.FullMethod)
+	requestID := r.Header.Get(requestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = uuid.New().String()
+		r.Header.Set(requestIDHeader, requestID)
+	}
+	header := metadata.Pairs(strings.ToLower(requestIDHeader), requestID)
+	if s.config().Region.ID != "" {
+		header.Set(strings.ToLower(regionHeader), s.config().Region.ID)
+	}
+	grpc.SetHeader(ctx, header)
+
+	resp, err := handler(context.WithValue(ctx, grpcRequestKey{}, r), req)
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		s.errorReporter.ReportCode(types.ErrorOriginAPI, info.FullMethod, string(apiErr.code), apiErr.message)
+		err = grpcStatus(apiErr, requestID).Err()
+	}
+	if keyID, tenant, ok := s.meteredKey(r); ok {
+		var bytes int64
+		if message, ok := resp.(proto.Message); ok && err == nil {
+			bytes = int64(proto.Size(message))
+		}
+		s.usage.RecordRequest(keyID, tenant, info.FullMethod, bytes)
+	}
+	return resp, err
+}
+
+// grpcHTTPRequest builds the HTTP request a gRPC call is served as: its metadata as headers, the peer as the remote
+// address and its method as the path and matched pattern
+func grpcHTTPRequest(ctx context.Context, method string) *http.Request {
+	r := (&http.Request{
+		Method:  http.MethodPost,
+		URL:     &url.URL{Path: method},
+		Header:  http.Header{},
+		Pattern: method,
+	}).WithContext(ctx)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		// Pseudo-headers such as :authority aren't metadata clients sent
+		if strings.HasPrefix(key, ":") {
+			continue
+		}
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+	return r
+}
+
+// grpcRequest returns the HTTP request the gRPC call of ctx is served as
+func grpcRequest(ctx context.Context) *http.Request {
+	if r, ok := ctx.Value(grpcRequestKey{}).(*http.Request); ok {
+		return r
+	}
+	return grpcHTTPRequest(ctx, "")
+}
+
+// grpcStatus converts an API error to a gRPC status carrying its code and the request ID in an ErrorInfo detail
+func grpcStatus(err *apiError, requestID string) *status.Status {
+	code, ok := grpcCodes[err.status]
+	if !ok {
+		code = codes.Internal
+	}
+	st := status.New(code, err.message)
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   string(err.code),
+		Domain:   grpcErrorDomain,
+		Metadata: map[string]string{"requestId": requestID},
+	})
+	if detailErr != nil {
+		return st
+	}
+	return detailed
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	infinitydexv1 "github.com/infinity-dex/api/infinitydex/v1"
+	"github.com/infinity-dex/services/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// swapGRPCService serves SwapService with the REST API's swap logic
+type swapGRPCService struct {
+	infinitydexv1.UnimplementedSwapServiceServer
+	server *Server
+}
+
+// GetQuote quotes a swap
+func (g *swapGRPCService) GetQuote(ctx context.Context, req *infinitydexv1.SwapRequest) (*infinitydexv1.SwapResponse, error) {
+	request, _, err := swapRequestFromProto(req, g.server.config().Swap.DefaultSlippage)
+	if err != nil {
+		return nil, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	return swapReply(g.server.quoteSwap(grpcRequest(ctx), request))
+}
+
+// SimulateSwap projects a swap's result without executing it
+func (g *swapGRPCService) SimulateSwap(ctx context.Context, req *infinitydexv1.SwapRequest) (*infinitydexv1.SwapResponse, error) {
+	request, _, err := swapRequestFromProto(req, g.server.config().Swap.DefaultSlippage)
+	if err != nil {
+		return nil, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	return swapReply(g.server.simulateSwap(grpcRequest(ctx), request))
+}
+
+// StartSwap starts a swap, through Temporal when the server has it
+func (g *swapGRPCService) StartSwap(ctx context.Context, req *infinitydexv1.SwapRequest) (*infinitydexv1.SwapResponse, error) {
+	request, body, err := swapRequestFromProto(req, g.server.config().Swap.DefaultSlippage)
+	if err != nil {
+		return nil, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	return swapReply(g.server.startSwap(grpcRequest(ctx), request, body))
+}
+
+// GetSwap returns a swap's status and, once finished, its result
+func (g *swapGRPCService) GetSwap(ctx context.Context, req *infinitydexv1.GetSwapRequest) (*infinitydexv1.SwapResponse, error) {
+	if req.GetRequestId() == "" {
+		return nil, newAPIError(http.StatusBadRequest, "request_id is required")
+	}
+	return swapReply(g.server.swapStatusOf(grpcRequest(ctx), req.GetRequestId()))
+}
+
+// priceGRPCService serves PriceService from the latest prices the REST API serves
+type priceGRPCService struct {
+	infinitydexv1.UnimplementedPriceServiceServer
+	server *Server
+}
+
+// ListPrices returns the latest prices, optionally of some symbols or one chain
+func (g *priceGRPCService) ListPrices(ctx context.Context, req *infinitydexv1.ListPricesRequest) (*infinitydexv1.PricesResponse, error) {
+	resp, err := g.server.latestPrices(ctx)
+	if err != nil {
+		return nil, newAPIError(http.StatusServiceUnavailable, err.Error())
+	}
+	resp.Prices = filterPrices(resp.Prices, req.GetSymbols(), req.GetChainId())
+	return pricesToProto(resp), nil
+}
+
+// GetPrice returns the latest prices of a symbol on every chain it trades on
+func (g *priceGRPCService) GetPrice(ctx context.Context, req *infinitydexv1.GetPriceRequest) (*infinitydexv1.PricesResponse, error) {
+	if req.GetSymbol() == "" {
+		return nil, newAPIError(http.StatusBadRequest, "symbol is required")
+	}
+	resp, err := g.server.latestPrices(ctx)
+	if err != nil {
+		return nil, newAPIError(http.StatusServiceUnavailable, err.Error())
+	}
+	resp.Prices = filterPrices(resp.Prices, []string{req.GetSymbol()}, req.GetChainId())
+	if len(resp.Prices) == 0 {
+		return nil, codedAPIError(ErrorCodeTokenNotFound, fmt.Sprintf("no price for %s", req.GetSymbol()))
+	}
+	return pricesToProto(resp), nil
+}
+
+// tokenGRPCService serves TokenService with the tokens the REST API lists
+type tokenGRPCService struct {
+	infinitydexv1.UnimplementedTokenServiceServer
+	server *Server
+}
+
+// ListTokens returns the wrapped and listed tokens of every configured chain, or of one
+func (g *tokenGRPCService) ListTokens(ctx context.Context, req *infinitydexv1.ListTokensRequest) (*infinitydexv1.ListTokensResponse, error) {
+	resp := &infinitydexv1.ListTokensResponse{Sandbox: g.server.isSandbox(grpcRequest(ctx))}
+	// ListTokensResponse has no warnings; chains that failed are logged and left out
+	tokens, _ := g.server.tokenDetails(ctx)
+	for _, details := range tokens {
+		if req.GetChainId() != 0 && details.ChainID != req.GetChainId() {
+			continue
+		}
+		resp.Tokens = append(resp.Tokens, &infinitydexv1.TokenDetails{
+			Token:       tokenToProto(details.Token),
+			CoingeckoId: details.CoinGeckoID,
+			Verified:    details.Verified,
+			PriceUsd:    details.PriceUSD,
+		})
+	}
+	return resp, nil
+}
+
+// swapReply converts the outcome of a swap call to its gRPC reply. Swaps the tenant's policy stopped, which the
+// REST API answers with a 403 swap response, fail with PermissionDenied.
+func swapReply(response SwapResponse, status int, err *apiError) (*infinitydexv1.SwapResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusForbidden && response.Policy != nil {
+		message := fmt.Sprintf("swap %s by policy", response.Status)
+		reasons := make([]string, 0, len(response.Policy.Reasons))
+		for _, reason := range response.Policy.Reasons {
+			reasons = append(reasons, reason.Message)
+		}
+		if len(reasons) > 0 {
+			message += ": " + strings.Join(reasons, "; ")
+		}
+		return nil, newAPIError(status, message)
+	}
+	return swapResponseToProto(response), nil
+}
+
+// swapRequestFromProto parses a gRPC swap request like a REST swap request body
+func swapRequestFromProto(req *infinitydexv1.SwapRequest, defaultSlippage float64) (types.SwapRequest, SwapRequestBody, error) {
+	body := SwapRequestBody{
+		SourceToken:        tokenFromProto(req.GetSourceToken()),
+		DestinationToken:   tokenFromProto(req.GetDestinationToken()),
+		Amount:             req.GetAmount(),
+		SourceAddress:      req.GetSourceAddress(),
+		DestinationAddress: req.GetDestinationAddress(),
+		Slippage:           req.GetSlippage(),
+		RefundAddress:      req.GetRefundAddress(),
+		RequestID:          req.GetRequestId(),
+		MinOutputAmount:    req.GetMinOutputAmount(),
+	}
+	request, err := swapRequestFromBody(body, defaultSlippage)
+	return request, body, err
+}
+
+// tokenFromProto converts a gRPC token
+func tokenFromProto(token *infinitydexv1.Token) types.Token {
+	return types.Token{
+		Symbol:    token.GetSymbol(),
+		Name:      token.GetName(),
+		Decimals:  int(token.GetDecimals()),
+		Address:   token.GetAddress(),
+		ChainID:   token.GetChainId(),
+		ChainName: token.GetChainName(),
+		LogoURI:   token.GetLogoUri(),
+		IsWrapped: token.GetIsWrapped(),
+	}
+}
+
+// tokenToProto converts a token to gRPC
+func tokenToProto(token types.Token) *infinitydexv1.Token {
+	return &infinitydexv1.Token{
+		Symbol:    token.Symbol,
+		Name:      token.Name,
+		Decimals:  int32(token.Decimals),
+		Address:   token.Address,
+		ChainId:   token.ChainID,
+		ChainName: token.ChainName,
+		LogoUri:   token.LogoURI,
+		IsWrapped: token.IsWrapped,
+	}
+}
+
+// swapResponseToProto converts a swap response to gRPC
+func swapResponseToProto(response SwapResponse) *infinitydexv1.SwapResponse {
+	resp := &infinitydexv1.SwapResponse{
+		RequestId: response.RequestID,
+		Status:    response.Status,
+		Sandbox:   response.Sandbox,
+		Archived:  response.Archived,
+		Region:    response.Region,
+	}
+	if quote := response.Quote; quote != nil {
+		resp.Quote = &infinitydexv1.SwapQuote{
+			SourceToken:      tokenToProto(quote.SourceToken),
+			DestinationToken: tokenToProto(quote.DestinationToken),
+			InputAmount:      amountString(quote.InputAmount),
+			OutputAmount:     amountString(quote.OutputAmount),
+			Fee:              feeToProto(quote.Fee),
+			Path:             quote.Path,
+			PriceImpact:      quote.PriceImpact,
+			ExchangeRate:     quote.ExchangeRate,
+			Bridge:           quote.Bridge,
+			MidPrice:         quote.MidPrice,
+			PriceAsOf:        timestampProto(quote.PriceAsOf),
+		}
+	}
+	if result := response.Result; result != nil {
+		resp.Result = &infinitydexv1.SwapResult{
+			RequestId:      result.RequestID,
+			Success:        result.Success,
+			SourceTx:       transactionToProto(result.SourceTx),
+			DestinationTx:  transactionToProto(result.DestinationTx),
+			BridgeTx:       transactionToProto(result.BridgeTx),
+			InputAmount:    amountString(result.InputAmount),
+			OutputAmount:   amountString(result.OutputAmount),
+			Fee:            feeToProto(result.Fee),
+			CompletionTime: timestampProto(result.CompletionTime),
+			Status:         string(result.Status),
+			ErrorMessage:   result.ErrorMessage,
+			ErrorCode:      result.ErrorCode,
+		}
+	}
+	return resp
+}
+
+// feeToProto converts swap fees to gRPC
+func feeToProto(fee types.Fee) *infinitydexv1.Fee {
+	return &infinitydexv1.Fee{
+		GasFee:      amountString(fee.GasFee),
+		ProtocolFee: amountString(fee.ProtocolFee),
+		NetworkFee:  amountString(fee.NetworkFee),
+		BridgeFee:   amountString(fee.BridgeFee),
+		TotalFeeUsd: fee.TotalFeeUSD,
+	}
+}
+
+// transactionToProto converts a transaction to gRPC
+func transactionToProto(tx types.Transaction) *infinitydexv1.Transaction {
+	return &infinitydexv1.Transaction{
+		Id:          tx.ID,
+		Type:        tx.Type,
+		Hash:        tx.Hash,
+		Status:      tx.Status,
+		FromAddress: tx.FromAddress,
+		ToAddress:   tx.ToAddress,
+		SourceChain: tx.SourceChain,
+		DestChain:   tx.DestChain,
+		Amount:      amountString(tx.Amount),
+		Timestamp:   timestampProto(tx.Timestamp),
+		BlockNumber: tx.BlockNumber,
+	}
+}
+
+// pricesToProto converts a prices response to gRPC
+func pricesToProto(resp PricesResponse) *infinitydexv1.PricesResponse {
+	prices := make([]*infinitydexv1.TokenPrice, 0, len(resp.Prices))
+	for _, price := range resp.Prices {
+		prices = append(prices, &infinitydexv1.TokenPrice{
+			Symbol:       price.Symbol,
+			Name:         price.Name,
+			Address:      price.Address,
+			ChainId:      price.ChainID,
+			ChainName:    price.ChainName,
+			PriceUsd:     price.PriceUSD,
+			Change_24H:   price.Change24h,
+			Volume_24H:   price.Volume24h,
+			MarketCapUsd: price.MarketCapUSD,
+			LastUpdated:  timestampProto(price.LastUpdated),
+			Source:       string(price.Source),
+			IsVerified:   price.IsVerified,
+		})
+	}
+	return &infinitydexv1.PricesResponse{
+		Prices:      prices,
+		Source:      resp.Source,
+		LastUpdated: timestampProto(resp.LastUpdated),
+		Stale:       resp.Stale,
+	}
+}
+
+// amountString formats a base-unit amount, empty when it isn't set
+func amountString(amount *big.Int) string {
+	if amount == nil {
+		return ""
+	}
+	return amount.String()
+}
+
+// timestampProto converts a time to gRPC, unset when it is zero
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	infinitydexv1 "github.com/infinity-dex/api/infinitydex/v1"
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// dialGRPC serves the gRPC API of s on a loopback port and returns a connection to it
+func dialGRPC(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := s.newGRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// testSwapProto is the gRPC form of testSwapBody
+func testSwapProto() *infinitydexv1.SwapRequest {
+	body := testSwapBody()
+	return &infinitydexv1.SwapRequest{
+		SourceToken:        tokenToProto(body.SourceToken),
+		DestinationToken:   tokenToProto(body.DestinationToken),
+		Amount:             body.Amount,
+		SourceAddress:      body.SourceAddress,
+		DestinationAddress: body.DestinationAddress,
+		Slippage:           body.Slippage,
+	}
+}
+
+func TestGRPCAPI(t *testing.T) {
+	s := newTestServer(t)
+	s.tenantKeys["acme-key"] = "acme"
+	conn := dialGRPC(t, s)
+	swaps := infinitydexv1.NewSwapServiceClient(conn)
+	ctx := context.Background()
+
+	t.Run("ListTokens", func(t *testing.T) {
+		resp, err := infinitydexv1.NewTokenServiceClient(conn).ListTokens(ctx, &infinitydexv1.ListTokensRequest{ChainId: 1})
+		require.NoError(t, err)
+		require.Len(t, resp.Tokens, 1)
+		assert.Equal(t, "uETH", resp.Tokens[0].Token.Symbol)
+		assert.False(t, resp.Sandbox)
+	})
+
+	t.Run("GetQuote", func(t *testing.T) {
+		resp, err := swaps.GetQuote(ctx, testSwapProto())
+		require.NoError(t, err)
+		assert.Equal(t, "quote_ready", resp.Status)
+		require.NotNil(t, resp.Quote)
+		assert.Equal(t, "1000000000000000000", resp.Quote.InputAmount)
+		assert.NotEmpty(t, resp.Quote.OutputAmount)
+	})
+
+	t.Run("SandboxSwap", func(t *testing.T) {
+		sandboxCtx := metadata.AppendToOutgoingContext(ctx, "x-api-key", testSandboxKey)
+		started, err := swaps.StartSwap(sandboxCtx, testSwapProto())
+		require.NoError(t, err)
+		assert.True(t, started.Sandbox)
+		require.NotEmpty(t, started.RequestId)
+
+		resp, err := swaps.GetSwap(sandboxCtx, &infinitydexv1.GetSwapRequest{RequestId: started.RequestId})
+		require.NoError(t, err)
+		assert.Equal(t, started.RequestId, resp.RequestId)
+		require.NotNil(t, resp.Result)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tooSmall := testSwapProto()
+		tooSmall.Amount = "1"
+		var header metadata.MD
+		_, err := swaps.GetQuote(metadata.AppendToOutgoingContext(ctx, "x-request-id", "grpc-test-1"), tooSmall, grpc.Header(&header))
+		require.Error(t, err)
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		require.Len(t, st.Details(), 1)
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, string(ErrorCodeAmountTooSmall), info.Reason)
+		assert.Equal(t, "grpc-test-1", info.Metadata["requestId"])
+		assert.Equal(t, []string{"grpc-test-1"}, header.Get("x-request-id"))
+
+		_, err = swaps.GetSwap(ctx, &infinitydexv1.GetSwapRequest{RequestId: "missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		// Without a price store or cache there are no prices to serve
+		_, err = infinitydexv1.NewPriceServiceClient(conn).GetPrice(ctx, &infinitydexv1.GetPriceRequest{Symbol: "ETH"})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Metering", func(t *testing.T) {
+		acmeCtx := metadata.AppendToOutgoingContext(ctx, "x-api-key", "acme-key")
+		_, err := swaps.StartSwap(acmeCtx, testSwapProto())
+		require.NoError(t, err)
+
+		report, err := s.usage.Report(ctx, time.Now().UTC().Format(types.UsageMonthFormat))
+		require.NoError(t, err)
+		var calls []types.APIUsage
+		for _, usage := range report.Endpoints {
+			if usage.Tenant == "acme" {
+				calls = append(calls, usage)
+			}
+		}
+		require.Len(t, calls, 1)
+		assert.Equal(t, infinitydexv1.SwapService_StartSwap_FullMethodName, calls[0].Endpoint)
+		assert.Equal(t, int64(1), calls[0].Requests)
+		assert.Positive(t, calls[0].EgressBytes)
+	})
+
+	t.Run("ReportsErrors", func(t *testing.T) {
+		top, err := s.errorReporter.TopErrors(ctx, time.Now().Add(-time.Hour), 10)
+		require.NoError(t, err)
+		operations := map[string]string{}
+		for _, stat := range top {
+			operations[stat.Code] = stat.Operation
+		}
+		assert.Equal(t, infinitydexv1.SwapService_GetQuote_FullMethodName, operations[string(ErrorCodeAmountTooSmall)])
+	})
+}
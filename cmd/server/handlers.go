@@ -0,0 +1,930 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/interfaces"
+	"github.com/infinity-dex/services/types"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+	"go.temporal.io/api/enums/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+// SwapRequestBody is the JSON body accepted by the swap endpoints
+type SwapRequestBody struct {
+	SourceToken        types.Token   `json:"sourceToken"`
+	DestinationToken   types.Token   `json:"destinationToken"`
+	Amount             string        `json:"amount"` // Raw base-unit integer, or whole tokens with a decimal point like "1.5"
+	SourceAddress      string        `json:"sourceAddress"`
+	DestinationAddress string        `json:"destinationAddress"`
+	Slippage           float64       `json:"slippage"`
+	RefundAddress      string        `json:"refundAddress,omitempty"`
+	RequestID          string        `json:"requestId,omitempty"`
+	MinOutputAmount    string        `json:"minOutputAmount,omitempty"`  // Like amount, in the destination token; skips confirmation for same-chain wrapped swaps
+	Deposit            bool          `json:"deposit,omitempty"`          // Fund the swap with a deposit to the chain's deposit address instead of confirming it
+	WebhookURL         string        `json:"webhookUrl,omitempty"`       // Notified when the swap's deposit arrives
+	CallbackURL        string        `json:"callbackUrl,omitempty"`      // POSTed the swap's result when it finishes; needs Temporal
+	PrivateExecution   bool          `json:"privateExecution,omitempty"` // Send the swap's transaction through the chain's private relay, away from sandwich attacks
+	Tranches           *TranchesBody `json:"tranches,omitempty"`         // Split the swap into tranches executed one after another; needs Temporal
+	SponsorGas         bool          `json:"sponsorGas,omitempty"`       // Have the protocol pay the destination chain's gas, recouped through the network fee
+	QuoteID            string        `json:"quoteId,omitempty"`          // Execute at no worse than this firm quote, without confirming it
+	Force              bool          `json:"force,omitempty"`            // Execute even when the swap's price impact is over its pool's limit
+
+	// UserOperation is the signed ERC-4337 operation a smart account source sends the swap with
+	UserOperation *chains.UserOperation `json:"userOperation,omitempty"`
+
+	// Permit is the source address's signed permit letting the chain's DEX contract pull the swap's source tokens
+	Permit *PermitBody `json:"permit,omitempty"`
+}
+
+// PermitBody is a signed EIP-2612 or Permit2 permit, with the members of the typed data from
+// GET /api/v1/permits/typed-data
+type PermitBody struct {
+	Kind      string `json:"kind"` // eip2612 or permit2
+	Owner     string `json:"owner"`
+	Spender   string `json:"spender"`
+	Value     string `json:"value"` // Raw base-unit integer
+	Nonce     string `json:"nonce"`
+	Deadline  int64  `json:"deadline"`          // Unix seconds
+	Version   string `json:"version,omitempty"` // EIP-2612 domain version; defaults to "1"
+	Signature string `json:"signature"`         // 0x-prefixed 65-byte signature of the typed data
+}
+
+// TranchesBody splits a large swap into tranches, each quoted again before it runs, to reduce its price impact
+type TranchesBody struct {
+	Size  string `json:"size"`            // Like amount; the last tranche takes the remainder
+	Delay string `json:"delay,omitempty"` // Wait between tranches, e.g. "1m"; defaults to the configured delay
+}
+
+// SwapResponse is returned when a swap is started or its status is queried
+type SwapResponse struct {
+	RequestID string                `json:"requestId"`
+	Status    string                `json:"status"`
+	Quote     *types.SwapQuote      `json:"quote,omitempty"`
+	Result    *types.SwapResult     `json:"result,omitempty"`
+	Policy    *types.PolicyDecision `json:"policy,omitempty"`
+	Sandbox   bool                  `json:"sandbox,omitempty"`
+	Archived  bool                  `json:"archived,omitempty"` // Served from the swap archive after its workflow history was deleted
+	Region    string                `json:"region,omitempty"`   // Region whose workers run the swap
+	Deposit   *DepositInstructions  `json:"deposit,omitempty"`  // Where to send the deposit funding the swap
+	Amounts   *SwapAmounts          `json:"amounts,omitempty"`  // The swap's amounts with their tokens' decimals
+}
+
+// SwapAmounts are a swap's amounts with the decimals of their tokens, formatted in whole tokens alongside the raw
+// base units
+type SwapAmounts struct {
+	Input     types.Amount  `json:"input"`
+	Output    *types.Amount `json:"output,omitempty"`    // Quoted, or filled once the swap has a result
+	MinOutput *types.Amount `json:"minOutput,omitempty"` // The request's minOutputAmount
+}
+
+// TokensResponse is returned by the tokens endpoint
+type TokensResponse struct {
+	Tokens     []types.TokenDetails `json:"tokens"`
+	NextCursor string               `json:"nextCursor,omitempty"` // Pass as cursor for the next page; empty on the last
+	Warnings   []string             `json:"warnings,omitempty"`   // Chains whose tokens couldn't be fetched and are missing
+	Sandbox    bool                 `json:"sandbox,omitempty"`
+}
+
+// healthHandler reports that the server is up, for liveness probes
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "ok",
+		"temporal": s.temporalClient != nil,
+		"time":     time.Now().UTC(),
+	})
+}
+
+// getTokensHandler returns the wrapped tokens of every configured chain with their metadata and USD prices
+func (s *Server) getTokensHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseTokenQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tokens, warnings := s.tokenDetails(r.Context())
+	page, next := query.page(tokens)
+	resp := TokensResponse{NextCursor: next, Warnings: warnings, Sandbox: s.isSandbox(r)}
+	writeJSONWithETag(w, r, func(out io.Writer) error {
+		return encodeJSONStream(out, resp, "tokens", page)
+	})
+}
+
+// tokenDetails returns the wrapped and listed tokens of every configured chain with their metadata and USD prices,
+// by chain, then symbol, then address. Uncached chains are first fetched together in one Universal SDK call; the
+// chains it leaves out are fetched at once, each for up to SERVER.TOKEN_FETCH_TIMEOUT. The tokens of chains that fail
+// are left out, with a warning for each.
+func (s *Server) tokenDetails(ctx context.Context) ([]types.TokenDetails, []string) {
+	chains := s.config().Chains
+	names := make([]string, 0, len(chains))
+	chainIDs := make([]int64, 0, len(chains))
+	for name, chain := range chains {
+		names = append(names, name)
+		chainIDs = append(chainIDs, chain.ChainID)
+	}
+	sort.Strings(names)
+
+	// A failed batch leaves each chain to be fetched and reported on its own
+	warmCtx, cancel := context.WithTimeout(ctx, s.config().Server.TokenFetchTimeout)
+	if err := s.tokenLists.Warm(warmCtx, chainIDs); err != nil {
+		log.Printf("Failed to get the wrapped tokens of all chains at once: %v", err)
+	}
+	cancel()
+
+	// Each chain writes its own slots, so the results need no lock
+	chainTokens := make([][]types.Token, len(names))
+	chainErrs := make([]error, len(names))
+	var g errgroup.Group
+	for i, name := range names {
+		g.Go(func() error {
+			chainTokens[i], chainErrs[i] = s.chainTokens(ctx, chains[name].ChainID)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var tokens []types.Token
+	var warnings []string
+	for i, name := range names {
+		if err := chainErrs[i]; err != nil {
+			log.Printf("Failed to get wrapped tokens for %s: %v", name, err)
+			warnings = append(warnings, fmt.Sprintf("failed to get tokens for %s: %v", name, err))
+			continue
+		}
+		tokens = append(tokens, chainTokens[i]...)
+	}
+
+	// Tokens approved through listing requests, which the swap worker may have approved
+	listed, err := s.listingService.ListedTokens(ctx)
+	if err != nil {
+		log.Printf("Failed to get listed tokens: %v", err)
+	}
+	for _, token := range listed {
+		duplicate := false
+		for _, existing := range tokens {
+			if existing.ChainID == token.ChainID && strings.EqualFold(existing.Address, token.Address) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			tokens = append(tokens, token)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return keyOfToken(tokens[i]).less(keyOfToken(tokens[j]))
+	})
+
+	// Prices are best effort; tokens are still listed without them
+	var prices []types.TokenPrice
+	if resp, err := s.latestPrices(ctx); err == nil {
+		prices = resp.Prices
+	}
+	details, err := s.tokenMetadata.Enrich(ctx, tokens, prices)
+	if err != nil {
+		log.Printf("Failed to enrich tokens with metadata: %v", err)
+		details = make([]types.TokenDetails, 0, len(tokens))
+		for _, token := range tokens {
+			details = append(details, types.TokenDetails{Token: token})
+		}
+	}
+	return details, warnings
+}
+
+// chainTokens returns a chain's cached wrapped tokens, waiting up to SERVER.TOKEN_FETCH_TIMEOUT when they have to be
+// fetched
+func (s *Server) chainTokens(ctx context.Context, chainID int64) ([]types.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config().Server.TokenFetchTimeout)
+	defer cancel()
+	return s.tokenLists.Tokens(ctx, chainID)
+}
+
+// swapQuoteHandler returns a quote for a swap
+func (s *Server) swapQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	request, _, err := decodeSwapRequest(r, s.config().Swap.DefaultSlippage)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	response, status, apiErr := s.quoteSwap(r, request)
+	writeSwapResponse(w, withAmounts(response, &request), status, apiErr)
+}
+
+// swapSimulateHandler projects the result of a swap without executing it
+func (s *Server) swapSimulateHandler(w http.ResponseWriter, r *http.Request) {
+	request, _, err := decodeSwapRequest(r, s.config().Swap.DefaultSlippage)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	response, status, apiErr := s.simulateSwap(r, request)
+	writeSwapResponse(w, withAmounts(response, &request), status, apiErr)
+}
+
+// swapHandler starts a swap, through Temporal when available
+func (s *Server) swapHandler(w http.ResponseWriter, r *http.Request) {
+	request, body, err := decodeSwapRequest(r, s.config().Swap.DefaultSlippage)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	response, status, apiErr := s.startSwap(r, request, body)
+	writeSwapResponse(w, withAmounts(response, &request), status, apiErr)
+}
+
+// swapStatusHandler returns the status of a swap
+func (s *Server) swapStatusHandler(w http.ResponseWriter, r *http.Request) {
+	response, status, apiErr := s.swapStatusOf(r, r.PathValue("id"))
+	writeSwapResponse(w, withAmounts(response, nil), status, apiErr)
+}
+
+// quoteSwap quotes a swap
+func (s *Server) quoteSwap(r *http.Request, request types.SwapRequest) (SwapResponse, int, *apiError) {
+	if status, err := s.checkSwapChains(request); err != nil {
+		return SwapResponse{}, 0, serviceAPIError(status, err)
+	}
+
+	quote, err := s.swapServiceFor(r).GetSwapQuote(r.Context(), request)
+	if err != nil {
+		return SwapResponse{}, 0, serviceAPIError(http.StatusBadRequest, err)
+	}
+	// Firm quotes are kept for swaps to reference until they expire
+	if quote.QuoteID != "" {
+		keyID, _, _ := s.meteredKey(r)
+		if err := s.quotes.SaveQuote(r.Context(), *quote, keyID); err != nil {
+			return SwapResponse{}, 0, newAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to save quote: %v", err))
+		}
+	}
+
+	return SwapResponse{
+		RequestID: request.RequestID,
+		Status:    "quote_ready",
+		Quote:     quote,
+		Sandbox:   s.isSandbox(r),
+	}, http.StatusOK, nil
+}
+
+// simulateSwap projects the result of a swap without executing it. The swap is checked and quoted like one being
+// started, including the tenant's policy and the pool's liquidity, but nothing is reserved, recorded or sent
+// on-chain. Swap workflows started with DryRun set do the same through Temporal.
+func (s *Server) simulateSwap(r *http.Request, request types.SwapRequest) (SwapResponse, int, *apiError) {
+	if status, err := s.checkSwapChains(request); err != nil {
+		return SwapResponse{}, 0, serviceAPIError(status, err)
+	}
+	if status, err := s.checkSourceAccount(r.Context(), request); err != nil {
+		return SwapResponse{}, 0, newAPIError(status, err.Error())
+	}
+	if err := s.checkPrivateExecution(request); err != nil {
+		return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := s.checkPermit(request); err != nil {
+		return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	if request.RequestID == "" {
+		request.RequestID = fmt.Sprintf("simulation-%s", uuid.New().String())
+	}
+	request.DryRun = true
+
+	var policy *types.PolicyDecision
+	if s.config().Compliance.Enabled {
+		decision, err := s.policyEngine.Evaluate(r.Context(), services.PolicyInput{Compliance: s.complianceContext(r), Request: request})
+		if err != nil {
+			return SwapResponse{}, 0, newAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to evaluate policy: %v", err))
+		}
+		if decision.Outcome != types.PolicyAllow {
+			return SwapResponse{
+				RequestID: request.RequestID,
+				Status:    policyStatus(decision),
+				Policy:    &decision,
+				Sandbox:   s.isSandbox(r),
+			}, http.StatusForbidden, nil
+		}
+		policy = &decision
+	}
+
+	result, err := s.swapServiceFor(r).SimulateSwap(r.Context(), request)
+	if err != nil {
+		return SwapResponse{}, 0, serviceAPIError(http.StatusBadRequest, err)
+	}
+
+	return SwapResponse{
+		RequestID: request.RequestID,
+		Status:    swapStatus(result),
+		Result:    result,
+		Policy:    policy,
+		Sandbox:   s.isSandbox(r),
+	}, http.StatusOK, nil
+}
+
+// startSwap starts a swap, through Temporal when available
+func (s *Server) startSwap(r *http.Request, request types.SwapRequest, body SwapRequestBody) (SwapResponse, int, *apiError) {
+	if status, err := s.checkSwapChains(request); err != nil {
+		return SwapResponse{}, 0, serviceAPIError(status, err)
+	}
+	if status, err := s.checkSourceAccount(r.Context(), request); err != nil {
+		return SwapResponse{}, 0, newAPIError(status, err.Error())
+	}
+	if err := s.checkPrivateExecution(request); err != nil {
+		return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := s.checkPermit(request); err != nil {
+		return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	if request.RequestID == "" {
+		request.RequestID = fmt.Sprintf("swap-%s", uuid.New().String())
+	}
+	if body.Deposit && !s.useTemporal(r) {
+		return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, "deposit-funded swaps need Temporal")
+	}
+	if request.Tranches != nil && !s.useTemporal(r) {
+		return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, "tranched swaps need Temporal")
+	}
+	if err := s.checkTranches(&request, body); err != nil {
+		return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	if body.QuoteID != "" {
+		quote, apiErr := s.firmQuote(r, request, body.QuoteID)
+		if apiErr != nil {
+			return SwapResponse{}, 0, apiErr
+		}
+		request.FirmQuote = quote
+	}
+	if request.CallbackURL != "" {
+		if !s.useTemporal(r) {
+			return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, "swap callbacks need Temporal")
+		}
+		keyID, _, _ := s.meteredKey(r)
+		if err := s.validateCallbackURL(r.Context(), keyID, request.CallbackURL); err != nil {
+			return SwapResponse{}, 0, newAPIError(http.StatusBadRequest, err.Error())
+		}
+		request.CallbackKeyID = keyID
+	}
+
+	var compliance *types.ComplianceContext
+	if s.config().Compliance.Enabled {
+		cc := s.complianceContext(r)
+		compliance = &cc
+	}
+
+	if s.useTemporal(r) {
+		if apiErr := s.reserveSwapVolume(r, request); apiErr != nil {
+			return SwapResponse{}, 0, apiErr
+		}
+		if apiErr := s.useFirmQuote(r, request); apiErr != nil {
+			s.releaseSwapVolume(r, request)
+			return SwapResponse{}, 0, apiErr
+		}
+
+		// Watch for the deposit before the workflow starts, so none is missed
+		var instructions *DepositInstructions
+		if body.Deposit {
+			expected, status, err := s.expectDeposit(r.Context(), request, body.WebhookURL)
+			if err != nil {
+				s.releaseSwapVolume(r, request)
+				return SwapResponse{}, 0, newAPIError(status, err.Error())
+			}
+			request.DepositAddress = expected.Address
+			instructions = &DepositInstructions{
+				Address:   expected.Address,
+				Token:     expected.Token,
+				Amount:    expected.Amount.String(),
+				ExpiresAt: expected.ExpiresAt,
+			}
+		}
+
+		options, region := s.workflowOptions(request.RequestID, SwapTaskQueue)
+		// The workflow evaluates the policy so held swaps can wait for review, retrying its steps as configured and
+		// running those calling a chain on the chain's task queue in the region it was routed to
+		input := temporal_workflows.SwapWorkflowInput{
+			Request:     request,
+			Compliance:  compliance,
+			Retries:     s.config().Retries.Policies(),
+			ChainQueues: s.config().ChainTaskQueues(region),
+		}
+		if _, err := s.temporalClient.ExecuteWorkflow(r.Context(), options, temporal_workflows.SwapWorkflow, input); err != nil {
+			if body.Deposit {
+				s.deposits.Cancel(r.Context(), request.RequestID)
+			}
+			s.releaseSwapVolume(r, request)
+			return SwapResponse{}, 0, newAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to start swap workflow: %v", err))
+		}
+
+		s.recordSwapUsage(r, request)
+		s.publishSwapStarted(r, request)
+		return SwapResponse{RequestID: request.RequestID, Status: "pending", Region: region, Deposit: instructions}, http.StatusAccepted, nil
+	}
+
+	if compliance != nil {
+		decision, err := s.policyEngine.Evaluate(r.Context(), services.PolicyInput{Compliance: *compliance, Request: request})
+		if err != nil {
+			return SwapResponse{}, 0, newAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to evaluate policy: %v", err))
+		}
+		// In-process swaps cannot be held, so swaps needing review are refused like denied ones
+		if decision.Outcome != types.PolicyAllow {
+			return SwapResponse{
+				RequestID: request.RequestID,
+				Status:    policyStatus(decision),
+				Policy:    &decision,
+				Sandbox:   s.isSandbox(r),
+			}, http.StatusForbidden, nil
+		}
+	}
+
+	if apiErr := s.reserveSwapVolume(r, request); apiErr != nil {
+		return SwapResponse{}, 0, apiErr
+	}
+	if apiErr := s.useFirmQuote(r, request); apiErr != nil {
+		s.releaseSwapVolume(r, request)
+		return SwapResponse{}, 0, apiErr
+	}
+	svc := s.swapServiceFor(r)
+	requestID, err := svc.ExecuteSwap(r.Context(), request)
+	if err != nil {
+		s.releaseSwapVolume(r, request)
+		return SwapResponse{}, 0, serviceAPIError(http.StatusBadRequest, err)
+	}
+
+	s.recordSwapUsage(r, request)
+	s.publishSwapStarted(r, request)
+
+	result, err := svc.GetSwapStatus(r.Context(), requestID)
+	if err != nil {
+		return SwapResponse{}, 0, newAPIError(http.StatusInternalServerError, err.Error())
+	}
+
+	return SwapResponse{
+		RequestID: requestID,
+		Status:    swapStatus(result),
+		Result:    result,
+		Sandbox:   s.isSandbox(r),
+	}, http.StatusAccepted, nil
+}
+
+// reserveSwapVolume books a swap's value against the swap limits once everything else about it has been accepted,
+// refusing request IDs already booked. Sandbox swaps move no real funds, so they are held to no limits.
+func (s *Server) reserveSwapVolume(r *http.Request, request types.SwapRequest) *apiError {
+	if s.isSandbox(r) {
+		return nil
+	}
+	if err := s.swapLimiter.Reserve(r.Context(), request); err != nil {
+		return serviceAPIError(http.StatusInternalServerError, err)
+	}
+	return nil
+}
+
+// releaseSwapVolume deletes the booking of a swap that failed to start, so it counts against no limit
+func (s *Server) releaseSwapVolume(r *http.Request, request types.SwapRequest) {
+	if s.isSandbox(r) {
+		return
+	}
+	if err := s.swapLimiter.Release(r.Context(), request.RequestID); err != nil {
+		log.Printf("Failed to release swap limits for %s: %v", request.RequestID, err)
+	}
+}
+
+// swapStatusOf returns the status of a swap
+func (s *Server) swapStatusOf(r *http.Request, requestID string) (SwapResponse, int, *apiError) {
+	if s.useTemporal(r) {
+		return s.workflowStatus(r, requestID)
+	}
+
+	result, err := s.swapServiceFor(r).GetSwapStatus(r.Context(), requestID)
+	if err != nil {
+		if archived, ok := s.archivedSwap(r.Context(), requestID); ok {
+			return SwapResponse{RequestID: requestID, Status: swapStatus(archived), Result: archived, Archived: true}, http.StatusOK, nil
+		}
+		return SwapResponse{}, 0, codedAPIError(ErrorCodeWorkflowNotFound, err.Error())
+	}
+
+	return SwapResponse{
+		RequestID: requestID,
+		Status:    swapStatus(result),
+		Result:    result,
+		Sandbox:   s.isSandbox(r),
+	}, http.StatusOK, nil
+}
+
+// writeSwapResponse writes a swap response with its status, or the error producing it failed with
+func writeSwapResponse(w http.ResponseWriter, response SwapResponse, status int, err *apiError) {
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, status, response)
+}
+
+// withAmounts adds a swap's amounts, formatted with its tokens' decimals, to its response. The tokens are the
+// request's, or the quote's when the request isn't known; responses with neither are returned unchanged.
+func withAmounts(response SwapResponse, request *types.SwapRequest) SwapResponse {
+	var source, destination types.Token
+	var input, minOutput *big.Int
+	switch {
+	case request != nil:
+		source, destination, input, minOutput = request.SourceToken, request.DestinationToken, request.Amount, request.MinOutputAmount
+	case response.Quote != nil:
+		source, destination, input = response.Quote.SourceToken, response.Quote.DestinationToken, response.Quote.InputAmount
+	default:
+		return response
+	}
+
+	amounts := &SwapAmounts{Input: types.NewAmount(input, source.Decimals)}
+	output := (*big.Int)(nil)
+	if response.Quote != nil {
+		output = response.Quote.OutputAmount
+	}
+	if response.Result != nil && response.Result.OutputAmount != nil {
+		output = response.Result.OutputAmount
+	}
+	if output != nil {
+		amount := types.NewAmount(output, destination.Decimals)
+		amounts.Output = &amount
+	}
+	if minOutput != nil {
+		amount := types.NewAmount(minOutput, destination.Decimals)
+		amounts.MinOutput = &amount
+	}
+	response.Amounts = amounts
+	return response
+}
+
+// SetSwapArchive sets the archive finished swaps are looked up in once Temporal no longer has them
+func (s *Server) SetSwapArchive(store services.SwapArchiveStore) {
+	s.swapArchive = store
+}
+
+// SetTokenMetadataStore sets the store token metadata is read from, as refreshed by the price worker
+func (s *Server) SetTokenMetadataStore(store services.TokenMetadataStore) {
+	s.tokenMetadata = services.NewTokenMetadataService(store)
+	if s.shadowPricer != nil {
+		s.shadowPricer.SetTokenMetadata(s.tokenMetadata)
+	}
+}
+
+// SetGasSponsorshipStore sets the store sponsored gas is booked in, shared with the swap workers
+func (s *Server) SetGasSponsorshipStore(store services.GasSponsorshipStore) {
+	if s.gasSponsor != nil {
+		s.gasSponsor.SetStore(store)
+	}
+}
+
+// SetSwapVolumeStore sets the store swap values are booked against the swap limits in, shared with the swap workers
+func (s *Server) SetSwapVolumeStore(store services.SwapVolumeStore) {
+	if s.swapLimiter != nil {
+		s.swapLimiter.SetStore(store)
+	}
+}
+
+// archivedSwap returns the result of a swap from the swap archive
+func (s *Server) archivedSwap(ctx context.Context, requestID string) (*types.SwapResult, bool) {
+	if s.swapArchive == nil {
+		return nil, false
+	}
+
+	swap, err := s.swapArchive.GetArchivedSwap(ctx, requestID)
+	if err != nil {
+		if !errors.Is(err, types.ErrArchivedSwapNotFound) {
+			log.Printf("Failed to look up archived swap %s: %v", requestID, err)
+		}
+		return nil, false
+	}
+	return &swap.Result, true
+}
+
+// confirmSwapHandler confirms a quoted swap
+func (s *Server) confirmSwapHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := r.PathValue("id")
+
+	if s.useTemporal(r) {
+		if err := s.temporalClient.SignalWorkflow(r.Context(), requestID, "", "confirm_swap", true); err != nil {
+			codedErrorResponse(w, ErrorCodeWorkflowNotFound, fmt.Sprintf("failed to confirm swap: %v", err))
+			return
+		}
+		writeJSON(w, http.StatusOK, SwapResponse{RequestID: requestID, Status: "confirmed"})
+		return
+	}
+
+	// In-process swaps execute on submission, so confirmation only reports their status
+	s.swapStatusHandler(w, r)
+}
+
+// cancelSwapHandler cancels a swap
+func (s *Server) cancelSwapHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := r.PathValue("id")
+
+	if s.useTemporal(r) {
+		if err := s.temporalClient.SignalWorkflow(r.Context(), requestID, "", "cancel_swap", true); err != nil {
+			codedErrorResponse(w, ErrorCodeWorkflowNotFound, fmt.Sprintf("failed to cancel swap: %v", err))
+			return
+		}
+		if err := s.deposits.Cancel(r.Context(), requestID); err != nil {
+			log.Printf("Failed to stop watching for the deposit of swap %s: %v", requestID, err)
+		}
+		writeJSON(w, http.StatusOK, SwapResponse{RequestID: requestID, Status: "cancelled"})
+		return
+	}
+
+	if err := s.swapServiceFor(r).CancelSwap(r.Context(), requestID); err != nil {
+		codedErrorResponse(w, ErrorCodeWorkflowNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SwapResponse{RequestID: requestID, Status: "cancelled", Sandbox: s.isSandbox(r)})
+}
+
+// workflowStatus reports the status of a swap workflow
+func (s *Server) workflowStatus(r *http.Request, workflowID string) (SwapResponse, int, *apiError) {
+	desc, err := s.temporalClient.DescribeWorkflowExecution(r.Context(), workflowID, "")
+	if err != nil {
+		if archived, ok := s.archivedSwap(r.Context(), workflowID); ok {
+			return SwapResponse{RequestID: workflowID, Status: swapStatus(archived), Result: archived, Archived: true}, http.StatusOK, nil
+		}
+		return SwapResponse{}, 0, codedAPIError(ErrorCodeWorkflowNotFound, fmt.Sprintf("swap workflow not found: %v", err))
+	}
+
+	response := SwapResponse{RequestID: workflowID, Status: "running"}
+	switch desc.GetWorkflowExecutionInfo().GetStatus() {
+	case enums.WORKFLOW_EXECUTION_STATUS_RUNNING:
+	case enums.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		var result types.SwapResult
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		if err := s.temporalClient.GetWorkflow(ctx, workflowID, "").Get(ctx, &result); err != nil {
+			return SwapResponse{}, 0, newAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to get swap result: %v", err))
+		}
+		response.Result = &result
+		response.Status = swapStatus(&result)
+	default:
+		response.Status = "failed"
+	}
+
+	return response, http.StatusOK, nil
+}
+
+// swapServiceFor returns the swap service serving the request
+func (s *Server) swapServiceFor(r *http.Request) interfaces.SwapServiceInterface {
+	if s.isSandbox(r) {
+		return s.sandbox
+	}
+	return s.swapService
+}
+
+// useTemporal reports whether the request should be executed through Temporal
+func (s *Server) useTemporal(r *http.Request) bool {
+	return s.temporalClient != nil && !s.isSandbox(r)
+}
+
+// decodeSwapRequest parses a swap request body, returning it with the request
+func decodeSwapRequest(r *http.Request, defaultSlippage float64) (types.SwapRequest, SwapRequestBody, error) {
+	var body SwapRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return types.SwapRequest{}, body, fmt.Errorf("invalid request body: %w", err)
+	}
+	request, err := swapRequestFromBody(body, defaultSlippage)
+	return request, body, err
+}
+
+// parseAmount parses an amount of token in base units, or in whole tokens when it has a decimal point, so "1.5"
+// USDC is 1500000 base units. Integers stay base units, as they were before whole tokens were accepted.
+func parseAmount(value string, token types.Token) (*big.Int, error) {
+	if strings.Contains(value, ".") {
+		amount, err := types.ParseAmount(value, token.Decimals)
+		if err != nil {
+			return nil, err
+		}
+		return amount.Value, nil
+	}
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, errors.New("must be a base-unit integer or whole tokens like 1.5")
+	}
+	return amount, nil
+}
+
+// swapRequestFromBody parses the amounts of a swap request body, returning the request it makes. A body without a
+// slippage gets defaultSlippage.
+func swapRequestFromBody(body SwapRequestBody, defaultSlippage float64) (types.SwapRequest, error) {
+	amount, err := parseAmount(body.Amount, body.SourceToken)
+	if err != nil {
+		return types.SwapRequest{}, fmt.Errorf("invalid amount: %w", err)
+	}
+	for _, token := range []types.Token{body.SourceToken, body.DestinationToken} {
+		if err := types.CheckNativeToken(token); err != nil {
+			return types.SwapRequest{}, err
+		}
+	}
+
+	var minOutput *big.Int
+	if body.MinOutputAmount != "" {
+		minOutput, err = parseAmount(body.MinOutputAmount, body.DestinationToken)
+		if err != nil {
+			return types.SwapRequest{}, fmt.Errorf("invalid minOutputAmount: %w", err)
+		}
+		if minOutput.Sign() < 0 {
+			return types.SwapRequest{}, errors.New("invalid minOutputAmount: must not be negative")
+		}
+	}
+
+	var tranches *types.TrancheOptions
+	if body.Tranches != nil {
+		size, err := parseAmount(body.Tranches.Size, body.SourceToken)
+		if err != nil {
+			return types.SwapRequest{}, fmt.Errorf("invalid tranches.size: %w", err)
+		}
+		if size.Sign() <= 0 {
+			return types.SwapRequest{}, errors.New("invalid tranches.size: must be positive")
+		}
+		tranches = &types.TrancheOptions{Size: size}
+		if body.Tranches.Delay != "" {
+			delay, err := time.ParseDuration(body.Tranches.Delay)
+			if err != nil || delay < 0 {
+				return types.SwapRequest{}, errors.New("invalid tranches.delay: must be a duration like 30s")
+			}
+			tranches.Delay = delay
+		}
+	}
+
+	var permit *types.Permit
+	if body.Permit != nil {
+		value, ok := new(big.Int).SetString(body.Permit.Value, 10)
+		if !ok {
+			return types.SwapRequest{}, errors.New("invalid permit.value: must be a base-unit integer")
+		}
+		nonce, ok := new(big.Int).SetString(body.Permit.Nonce, 10)
+		if !ok {
+			return types.SwapRequest{}, errors.New("invalid permit.nonce: must be an integer")
+		}
+		permit = &types.Permit{
+			Kind:      body.Permit.Kind,
+			Owner:     body.Permit.Owner,
+			Spender:   body.Permit.Spender,
+			Value:     value,
+			Nonce:     nonce,
+			Deadline:  body.Permit.Deadline,
+			Version:   body.Permit.Version,
+			Signature: body.Permit.Signature,
+		}
+	}
+
+	slippage := body.Slippage
+	if slippage == 0 {
+		slippage = defaultSlippage
+	}
+
+	return types.SwapRequest{
+		SourceToken:        body.SourceToken,
+		DestinationToken:   body.DestinationToken,
+		Amount:             amount,
+		SourceAddress:      body.SourceAddress,
+		DestinationAddress: body.DestinationAddress,
+		Slippage:           slippage,
+		Deadline:           time.Now().Add(15 * time.Minute),
+		RefundAddress:      body.RefundAddress,
+		RequestID:          body.RequestID,
+		MinOutputAmount:    minOutput,
+		UserOperation:      body.UserOperation,
+		Permit:             permit,
+		CallbackURL:        body.CallbackURL,
+		PrivateExecution:   body.PrivateExecution,
+		SponsorGas:         body.SponsorGas,
+		Tranches:           tranches,
+		ForcePriceImpact:   body.Force,
+	}, nil
+}
+
+// checkTranches gives a tranched swap without a delay the configured one and refuses more tranches, or a longer
+// delay between them, than the configuration allows
+func (s *Server) checkTranches(request *types.SwapRequest, body SwapRequestBody) error {
+	if request.Tranches == nil {
+		return nil
+	}
+	cfg := s.config().Swap
+	if body.Tranches.Delay == "" {
+		request.Tranches.Delay = cfg.TrancheDelay
+	}
+	if request.Tranches.Delay > cfg.MaxTrancheDelay {
+		return fmt.Errorf("invalid tranches.delay: must be at most %s", cfg.MaxTrancheDelay)
+	}
+	if count := request.Tranches.TrancheCount(request.Amount); count.Cmp(big.NewInt(int64(cfg.MaxTranches))) > 0 {
+		return fmt.Errorf("invalid tranches.size: splits the swap into %s tranches, at most %d are allowed", count, cfg.MaxTranches)
+	}
+	return nil
+}
+
+// swapStatus maps a swap result to an API status string
+func swapStatus(result *types.SwapResult) string {
+	switch {
+	case result.Status != "":
+		return string(result.Status)
+	case result.Success:
+		return "completed"
+	case result.ErrorMessage == "Swap in progress":
+		// Results recorded by swap workflows before they carried a status
+		return "pending"
+	default:
+		return "failed"
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// writeJSONStream writes v like writeJSON, but encodes the items of its list one at a time, so a list of thousands
+// isn't held in memory a second time as JSON. v must leave the list nil, under the JSON key field.
+func writeJSONStream[T any](w http.ResponseWriter, status int, v interface{}, field string, items []T) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := encodeJSONStream(w, v, field, items); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// encodeJSONStream encodes v to out as json.Encoder would, with items in place of the null under the key field
+func encodeJSONStream[T any](out io.Writer, v interface{}, field string, items []T) error {
+	envelope, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	key := `"` + field + `":`
+	before, after, found := bytes.Cut(envelope, []byte(key+"null"))
+	if !found {
+		return fmt.Errorf("response has no null %s to stream its items into", field)
+	}
+
+	// Writes in chunks; a bufio.Writer keeps its first error, returned by Flush
+	buffered := bufio.NewWriter(out)
+	buffered.Write(before)
+	buffered.WriteString(key + "[")
+	for i, item := range items {
+		if i > 0 {
+			buffered.WriteByte(',')
+		}
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		buffered.Write(encoded)
+	}
+	buffered.WriteByte(']')
+	buffered.Write(after)
+	buffered.WriteByte('\n')
+	return buffered.Flush()
+}
+
+// writeJSONWithETag writes the JSON encode writes as a 200 response tagged with a hash of it, or an empty 304 when the
+// request's If-None-Match already names that tag, so clients polling an unchanged response don't download it again.
+// The body is encoded twice, once to hash it, rather than held in memory.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, encode func(io.Writer) error) {
+	hash := sha256.New()
+	if err := encode(hash); err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to encode response: %v", err))
+		return
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := encode(w); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header names etag, weakly or strongly, or is *
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
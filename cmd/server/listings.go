@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+)
+
+// ListingRequestBody is the JSON body accepted by the listing submission endpoint
+type ListingRequestBody struct {
+	Token            types.Token               `json:"token"`
+	ProjectURL       string                    `json:"projectUrl"`
+	ContactEmail     string                    `json:"contactEmail"`
+	Description      string                    `json:"description"`
+	SubmitterAddress string                    `json:"submitterAddress"`
+	Liquidity        types.LiquidityCommitment `json:"liquidity"`
+}
+
+// ListingReviewRequestBody represents an operator's review of a listing request
+type ListingReviewRequestBody struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+// ListingsResponse is returned by the review queue endpoint
+type ListingsResponse struct {
+	Listings []types.ListingRequest `json:"listings"`
+}
+
+// SetListedTokenStore sets the store of tokens added by approved listings and adds them to the registry
+func (s *Server) SetListedTokenStore(store services.ListedTokenStore) {
+	s.listingService.SetTokenStore(store)
+	if _, err := services.RestoreListedTokens(context.Background(), store, s.tokenService); err != nil {
+		log.Printf("Failed to restore listed tokens: %v", err)
+	}
+}
+
+// submitListingHandler accepts a token listing request and starts its risk checks
+func (s *Server) submitListingHandler(w http.ResponseWriter, r *http.Request) {
+	var body ListingRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	listing, err := s.listingService.Submit(types.ListingRequest{
+		Token:            body.Token,
+		ProjectURL:       body.ProjectURL,
+		ContactEmail:     body.ContactEmail,
+		Description:      body.Description,
+		SubmitterAddress: body.SubmitterAddress,
+		Liquidity:        body.Liquidity,
+	})
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.temporalClient != nil {
+		options, _ := s.workflowOptions(listing.ID, SwapTaskQueue)
+		if _, err := s.temporalClient.ExecuteWorkflow(r.Context(), options, temporal_workflows.TokenListingWorkflow, listing); err != nil {
+			errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to start listing workflow: %v", err))
+			return
+		}
+		writeJSON(w, http.StatusAccepted, listing)
+		return
+	}
+
+	listing, err = s.listingService.RunChecks(r.Context(), listing.ID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to run listing checks: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, listing)
+}
+
+// getListingHandler returns the status of a listing request
+func (s *Server) getListingHandler(w http.ResponseWriter, r *http.Request) {
+	listing, err := s.listing(r.Context(), r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, listing)
+}
+
+// adminListingsHandler returns the listing review queue, or the listings with the given status
+func (s *Server) adminListingsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = types.ListingStatusPendingReview
+	case "all":
+		status = ""
+	case types.ListingStatusChecking, types.ListingStatusPendingReview, types.ListingStatusApproved, types.ListingStatusRejected:
+	default:
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid status: %q", status))
+		return
+	}
+
+	// Listings still in flight may have moved on in their workflows
+	for _, listing := range s.listingService.List("") {
+		if listing.Status == types.ListingStatusChecking || listing.Status == types.ListingStatusPendingReview {
+			if _, err := s.listing(r.Context(), listing.ID); err != nil {
+				log.Printf("Failed to refresh listing %s: %v", listing.ID, err)
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, ListingsResponse{Listings: s.listingService.List(status)})
+}
+
+// reviewListingHandler approves or rejects a listing request waiting for review
+func (s *Server) reviewListingHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var body ListingReviewRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if strings.TrimSpace(body.Reason) == "" {
+		errorResponse(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	id := r.PathValue("id")
+	listing, err := s.listing(r.Context(), id)
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if listing.Status != types.ListingStatusPendingReview {
+		errorResponse(w, http.StatusConflict, fmt.Sprintf("listing is %s, not pending review", listing.Status))
+		return
+	}
+
+	review := types.ListingReview{
+		Approved: body.Approved,
+		Operator: s.adminOperator(r),
+		Reason:   body.Reason,
+	}
+	if s.temporalClient != nil {
+		if err := s.temporalClient.SignalWorkflow(r.Context(), id, "", temporal_workflows.ListingReviewSignal, review); err != nil {
+			errorResponse(w, http.StatusNotFound, fmt.Sprintf("failed to review listing: %v", err))
+			return
+		}
+	} else {
+		listing, err = s.listingService.Review(r.Context(), id, review)
+		if err != nil {
+			errorResponse(w, http.StatusConflict, fmt.Sprintf("failed to review listing: %v", err))
+			return
+		}
+	}
+
+	decision := types.ListingStatusRejected
+	if body.Approved {
+		decision = types.ListingStatusApproved
+	}
+	if s.auditLog != nil {
+		entry := temporal_activities.AuditEntry{
+			ActionID: id,
+			Action:   "listing_review",
+			Operator: s.adminOperator(r),
+			Reason:   body.Reason,
+			Params: map[string]string{
+				"decision": decision,
+				"symbol":   listing.Token.Symbol,
+				"chainId":  fmt.Sprint(listing.Token.ChainID),
+				"address":  listing.Token.Address,
+			},
+			Status: temporal_activities.AuditStatusCompleted,
+		}
+		if err := s.auditLog.Record(entry); err != nil {
+			log.Printf("Failed to record listing review of %s: %v", id, err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, listing)
+}
+
+// listing returns a listing request, refreshed from its workflow when listings run on Temporal
+func (s *Server) listing(ctx context.Context, id string) (types.ListingRequest, error) {
+	if s.temporalClient != nil {
+		value, err := s.temporalClient.QueryWorkflow(ctx, id, "", temporal_workflows.ListingStatusQuery)
+		if err == nil {
+			var listing types.ListingRequest
+			if err := value.Get(&listing); err != nil {
+				return types.ListingRequest{}, fmt.Errorf("failed to decode listing: %w", err)
+			}
+			s.listingService.Save(listing)
+			return listing, nil
+		}
+	}
+
+	listing, err := s.listingService.Get(id)
+	if errors.Is(err, types.ErrListingNotFound) {
+		return types.ListingRequest{}, fmt.Errorf("listing %s not found", id)
+	}
+	return listing, err
+}
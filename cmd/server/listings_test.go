@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubERC20 answers every contract call as a well-behaved 18-decimal token with symbol NEW
+type stubERC20 struct{}
+
+func (stubERC20) GetCode(ctx context.Context, chainID int64, address string) ([]byte, error) {
+	return []byte{0x60, 0x80}, nil
+}
+
+func (stubERC20) Call(ctx context.Context, chainID int64, from, to, data string) ([]byte, error) {
+	word := func(n int64) []byte {
+		return big.NewInt(n).FillBytes(make([]byte, 32))
+	}
+	switch data[:10] {
+	case "0x313ce567": // decimals()
+		return word(18), nil
+	case "0x95d89b41": // symbol() as bytes32
+		symbol := make([]byte, 32)
+		copy(symbol, "NEW")
+		return symbol, nil
+	default: // balanceOf() and transfer()
+		return word(1000), nil
+	}
+}
+
+func (t stubERC20) CallWithStorage(ctx context.Context, chainID int64, from, to, data string, storage services.StorageOverrides) ([]byte, error) {
+	return t.Call(ctx, chainID, from, to, data)
+}
+
+func testListingBody() ListingRequestBody {
+	return ListingRequestBody{
+		Token: types.Token{
+			Symbol:   "NEW",
+			Name:     "New Token",
+			Decimals: 18,
+			Address:  "0x1111111111111111111111111111111111111111",
+			ChainID:  1,
+		},
+		ProjectURL:       "https://new-token.example",
+		ContactEmail:     "team@new-token.example",
+		SubmitterAddress: "0x3333333333333333333333333333333333333333",
+		Liquidity: types.LiquidityCommitment{
+			PoolAddress: "0x2222222222222222222222222222222222222222",
+			AmountUSD:   250000,
+			LockDays:    365,
+		},
+	}
+}
+
+func TestListingEndpoints(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "listings@infinity-dex"
+	auditLog, err := temporal_activities.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	s.auditLog = auditLog
+	s.listingService.SetChecker(services.NewListingChecker(stubERC20{}, s.tokenService, s.config().Listings.MinLiquidityUSD))
+
+	submit := func(t *testing.T, body ListingRequestBody) types.ListingRequest {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/listings", body, "")
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		var listing types.ListingRequest
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&listing))
+		return listing
+	}
+	review := ListingReviewRequestBody{Approved: true, Reason: "liquidity verified"}
+
+	t.Run("Validation", func(t *testing.T) {
+		body := testListingBody()
+		body.Token.Address = "not-an-address"
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/listings", body, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("ApproveAddsToken", func(t *testing.T) {
+		listing := submit(t, testListingBody())
+		require.Equal(t, types.ListingStatusPendingReview, listing.Status, listing.Message)
+		assert.NotEmpty(t, listing.Checks)
+
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/listings", nil, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/listings", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var queue ListingsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&queue))
+		require.Len(t, queue.Listings, 1)
+		assert.Equal(t, listing.ID, queue.Listings[0].ID)
+
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/admin/listings/"+listing.ID+"/review", ListingReviewRequestBody{Approved: true}, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/admin/listings/"+listing.ID+"/review", review, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/listings/"+listing.ID, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&listing))
+		assert.Equal(t, types.ListingStatusApproved, listing.Status)
+		require.NotNil(t, listing.Review)
+		assert.Equal(t, "listings@infinity-dex", listing.Review.Operator)
+
+		token, err := s.tokenService.GetToken("NEW")
+		require.NoError(t, err)
+		assert.Equal(t, "0x1111111111111111111111111111111111111111", token.Address)
+
+		entries, err := s.auditLog.List(10)
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		assert.Equal(t, "listing_review", entries[0].Action)
+		assert.Equal(t, types.ListingStatusApproved, entries[0].Params["decision"])
+
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/admin/listings/"+listing.ID+"/review", review, adminKey)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("DuplicateRejected", func(t *testing.T) {
+		listing := submit(t, testListingBody())
+		assert.Equal(t, types.ListingStatusRejected, listing.Status)
+		assert.Contains(t, listing.Message, services.ListingCheckDuplicate)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/listings/listing-missing", nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestListedTokensServed(t *testing.T) {
+	s := newTestServer(t)
+
+	// The swap worker saved a token it approved to the shared store
+	store := services.NewInMemoryListedTokenStore()
+	listed := types.Token{Symbol: "NEW", Name: "New Token", Decimals: 18, Address: "0x1111111111111111111111111111111111111111", ChainID: 1, ChainName: "Ethereum"}
+	require.NoError(t, store.SaveListedToken(context.Background(), listed))
+	s.SetListedTokenStore(store)
+
+	token, err := s.tokenService.GetToken("NEW")
+	require.NoError(t, err, "Expected the listed token restored into the registry")
+	assert.Equal(t, listed.Address, token.Address)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/tokens", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp TokensResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+	var symbols []string
+	for _, token := range resp.Tokens {
+		symbols = append(symbols, token.Symbol)
+	}
+	assert.Contains(t, symbols, "NEW")
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/repository"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_payloads "github.com/infinity-dex/temporal/payloads"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
+)
+
+// priceFeedInterval is how often the price feed reconnects to price notifications, or checks for
+// refreshed prices when there is no price database
+const priceFeedInterval = 5 * time.Second
+
+// gasPollInterval is how often chain gas prices and block times are read from the RPC endpoints
+const gasPollInterval = 15 * time.Second
+
+// RunServer starts the Infinity DEX API server
+func RunServer() {
+	configPath := flag.String("config", "", "path to the configuration file")
+	flag.Parse()
+
+	log.Println("Starting Infinity DEX API server...")
+
+	// Load configuration
+	cfg, err := temporal_config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Create a Temporal client; the server falls back to in-process execution without one
+	var temporalClient client.Client
+	c, err := client.Dial(client.Options{
+		HostPort:      cfg.Temporal.HostPort,
+		Namespace:     cfg.Region.Namespace,
+		DataConverter: temporal_payloads.NewDataConverter(temporal_payloads.OptionsFromConfig(cfg.Temporal.Payloads)),
+	})
+	if err != nil {
+		log.Printf("Temporal unavailable, executing swaps in-process: %v", err)
+	} else {
+		temporalClient = c
+		defer c.Close()
+	}
+
+	sdk, err := newMockSDK(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create Universal SDK: %v", err)
+	}
+	server := NewServer(cfg, sdk, temporalClient)
+
+	// Apply edits to the configuration file, and reload it on SIGHUP, without a restart
+	configs := temporal_config.NewWatcher(*configPath, cfg)
+	configs.OnReload(server.ApplyConfig)
+	configs.Watch()
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go func() {
+		for range reloadChan {
+			if _, err := configs.Reload(); err != nil {
+				log.Printf("Keeping the previous configuration, failed to reload it: %v", err)
+			}
+		}
+	}()
+	if path := cfg.Compliance.GeoIPDatabase; path != "" {
+		countries, err := services.LoadIPCountries(path)
+		if err != nil {
+			log.Fatalf("Failed to load GeoIP database: %v", err)
+		}
+		server.SetIPCountries(countries)
+	}
+
+	// Serve prices from the database, falling back to the price cache while it is unreachable
+	dbConfig, err := temporal_config.LoadDBConfig()
+	if err != nil {
+		log.Fatalf("Failed to load database config: %v", err)
+	}
+	dbPool, err := temporal_config.NewDBPool(dbConfig)
+	if err != nil {
+		log.Printf("Price database unavailable, serving cached prices until it is back: %v", err)
+		dbPool, err = temporal_config.NewLazyDBPool(dbConfig)
+	}
+	if err != nil {
+		log.Printf("Failed to create price database pool: %v", err)
+	} else {
+		defer dbPool.Close()
+		server.SetDatabase(dbPool)
+		server.SetPriceStore(repository.NewPriceRepository(dbPool))
+		server.SetPolicyStore(repository.NewPolicyRepository(dbPool))
+		server.SetSwapArchive(repository.NewSwapArchiveRepository(dbPool))
+		server.SetSwapStatsStore(repository.NewSwapStatsRepository(dbPool))
+		server.SetTokenMetadataStore(repository.NewTokenMetadataRepository(dbPool))
+		server.SetParameterStore(repository.NewParameterRepository(dbPool))
+		server.SetDepositStore(repository.NewDepositRepository(dbPool))
+		server.SetPoolStore(repository.NewPoolRepository(dbPool))
+		server.SetBridgeOutcomeStore(repository.NewBridgeOutcomeRepository(dbPool))
+		server.SetListedTokenStore(repository.NewListedTokenRepository(dbPool))
+		server.SetUsageStore(repository.NewUsageRepository(dbPool))
+		server.SetErrorStore(repository.NewErrorRepository(dbPool))
+		server.SetWebhookSecretStore(repository.NewWebhookSecretRepository(dbPool))
+		server.SetGasSponsorshipStore(repository.NewGasSponsorshipRepository(dbPool))
+		server.SetSwapVolumeStore(repository.NewSwapVolumeRepository(dbPool))
+		server.SetQuoteStore(repository.NewQuoteRepository(dbPool))
+		server.SetPriceAlertStore(repository.NewPriceAlertRepository(dbPool))
+		server.SetDeadLetterStore(repository.NewDeadLetterRepository(dbPool))
+	}
+
+	// Push price updates to WebSocket clients as the price worker refreshes them
+	feedCtx, stopFeed := context.WithCancel(context.Background())
+	defer stopFeed()
+	server.StartPriceFeed(feedCtx, priceFeedInterval)
+	server.chainService.StartGasPolling(feedCtx, gasPollInterval)
+	// Refetch the cached token lists halfway through their TTL, so requests never wait for an expired one
+	server.tokenLists.StartRefreshing(feedCtx, cfg.Server.TokenCacheTTL/2)
+	server.deposits.StartPolling(feedCtx, depositPollInterval)
+	server.StartQuoteCleanup(feedCtx, quoteCleanupInterval)
+	server.regions.StartHealthChecks(feedCtx, cfg.Region.HealthCheckInterval)
+	server.usage.StartFlushing(feedCtx, usageFlushInterval)
+	server.errorReporter.StartFlushing(feedCtx, cfg.Errors.FlushInterval)
+	if cfg.Universal.Middleware.StatsInterval > 0 {
+		server.sdkMetrics.StartLogging(feedCtx, cfg.Universal.Middleware.StatsInterval)
+	}
+	if server.shadowPricer != nil {
+		server.shadowPricer.Start(feedCtx, cfg.Swap.ShadowPriceInterval)
+	}
+
+	// Archive swaps that closed without archiving themselves while Temporal still has their history
+	if temporalClient != nil && server.swapArchive != nil {
+		go func() {
+			archived, err := server.BackfillSwapArchive(feedCtx)
+			if err != nil {
+				log.Printf("Failed to backfill swap archive: %v", err)
+			}
+			log.Printf("Backfilled %d swaps into the swap archive", archived)
+		}()
+	}
+
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      server,
+		ReadTimeout:  cfg.Server.Timeout,
+		WriteTimeout: cfg.Server.Timeout,
+	}
+
+	go func() {
+		log.Printf("API server listening on %s", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("API server failed: %v", err)
+		}
+	}()
+
+	// Serve the gRPC API alongside REST, on its own port
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != 0 {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.GRPCPort))
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		grpcServer = server.newGRPCServer()
+		go func() {
+			log.Printf("gRPC API listening on %s", listener.Addr())
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("gRPC API failed: %v", err)
+			}
+		}()
+	}
+
+	// Wait for termination signal
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+	<-signalChan
+
+	log.Println("Shutting down API server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down API server: %v", err)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	// Keep the usage metered since the last flush
+	if err := server.usage.Flush(ctx); err != nil {
+		log.Printf("Failed to flush API usage: %v", err)
+	}
+	// Keep the errors counted since the last flush
+	if err := server.errorReporter.Flush(ctx); err != nil {
+		log.Printf("Failed to flush error counts: %v", err)
+	}
+	// Let subscribers finish the events already published and forward them
+	server.events.Close()
+}
+
+// Main function to be called from other packages
+func main() {
+	RunServer()
+}
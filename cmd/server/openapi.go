@@ -0,0 +1,284 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+// swaggerUIVersion is the swagger-ui-dist release the docs page loads
+const swaggerUIVersion = "5.17.14"
+
+// openAPIDocument is an OpenAPI 3 document
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Security   []map[string][]string                   `json:"security"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+// openAPIInfo describes the API
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// openAPIComponents are the schemas and security schemes operations refer to
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+// openAPISecurityScheme is an API key scheme
+type openAPISecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// openAPIOperation is an operation on a path
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+// openAPIParameter is a path or query parameter
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+// openAPIRequestBody is the JSON body of an operation
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+// openAPIResponse is a response of an operation
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+// openAPIMediaType is the schema of a body
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+// apiOperation documents a route of the REST API. Request and response are zero values of the structs the handler
+// decodes and writes, so the spec follows them as they change.
+type apiOperation struct {
+	pattern  string // Route pattern, as registered in routes()
+	id       string
+	summary  string
+	tag      string
+	query    []apiQueryParam
+	request  interface{}
+	status   int
+	response interface{}
+}
+
+// apiQueryParam is a query parameter of an operation
+type apiQueryParam struct {
+	name        string
+	description string
+	required    bool
+}
+
+// Query parameters shared by price operations
+var (
+	chainIDParam = apiQueryParam{name: "chainId", description: "Chain ID or CAIP-2 ID; needed when the symbol trades on several chains"}
+	fromParam    = apiQueryParam{name: "from", description: "RFC 3339 time or Unix seconds; default 24 hours before to"}
+	toParam      = apiQueryParam{name: "to", description: "RFC 3339 time or Unix seconds; default now"}
+)
+
+// apiOperations documents the public REST API. Admin routes are operator-only and not documented here.
+var apiOperations = []apiOperation{
+	{pattern: "GET /api/v1/chains", id: "listChains", summary: "List the configured chains and their features", tag: "Chains", response: ChainsResponse{}},
+	{pattern: "GET /api/v1/chains/{id}/gas", id: "getChainGas", summary: "Get a chain's latest gas price, fees and block time", tag: "Chains", response: ChainGasResponse{}},
+	{pattern: "GET /api/v1/tokens", id: "listTokens", summary: "List the tradable tokens with their metadata and USD prices, by chain, symbol and address", tag: "Tokens", query: []apiQueryParam{{name: "search", description: "Part of a symbol or name, in any case, or a whole address"}, {name: "symbol", description: "Symbol of the token or, for wrapped tokens, of its underlying token"}, {name: "chainId", description: "Chain ID or CAIP-2 ID"}, {name: "wrapped", description: "true for Universal wrapped tokens only, false for the others"}, {name: "limit", description: "Most tokens to return, 1 to 1000; every match when omitted"}, {name: "cursor", description: "nextCursor of the previous page"}}, response: TokensResponse{}},
+
+	{pattern: "POST /api/v1/swap/quote", id: "quoteSwap", summary: "Quote a swap", tag: "Swaps", request: SwapRequestBody{}, response: SwapResponse{}},
+	{pattern: "POST /api/v1/swap/simulate", id: "simulateSwap", summary: "Project a swap's result without executing it", tag: "Swaps", request: SwapRequestBody{}, response: SwapResponse{}},
+	{pattern: "POST /api/v1/swap", id: "startSwap", summary: "Start a swap", tag: "Swaps", request: SwapRequestBody{}, status: http.StatusAccepted, response: SwapResponse{}},
+	{pattern: "POST /api/v1/swap/user-operation", id: "buildUserOperation", summary: "Build the user operation a smart account sends a swap with", tag: "Swaps", request: SwapRequestBody{}, response: UserOperationResponse{}},
+	{pattern: "GET /api/v1/permits/typed-data", id: "getPermitTypedData", summary: "Build the typed data an owner signs to permit a swap's token transfer", tag: "Swaps", query: []apiQueryParam{{name: "kind", description: "eip2612 (default) or permit2"}, {name: "token", description: "Token symbol", required: true}, {name: "owner", description: "Source address signing the permit", required: true}, {name: "amount", description: "Base-unit amount to permit", required: true}, {name: "nonce", description: "Permit nonce; read from the token for eip2612 and random for permit2 when omitted"}, {name: "deadline", description: "Unix time the permit expires; defaults to 30 minutes ahead"}, {name: "version", description: "EIP-2612 domain version of the token; defaults to 1"}}, response: PermitTypedDataResponse{}},
+	{pattern: "GET /api/v1/swap/{id}", id: "getSwap", summary: "Get a swap's status and result", tag: "Swaps", response: SwapResponse{}},
+	{pattern: "POST /api/v1/swap/{id}/confirm", id: "confirmSwap", summary: "Confirm a quoted swap", tag: "Swaps", response: SwapResponse{}},
+	{pattern: "POST /api/v1/swap/{id}/cancel", id: "cancelSwap", summary: "Cancel a swap", tag: "Swaps", response: SwapResponse{}},
+	{pattern: "GET /api/v1/swap/{id}/attestation", id: "getSwapAttestation", summary: "Export a signed attestation of a completed swap", tag: "Swaps", response: types.SwapAttestation{}},
+	{pattern: "GET /api/v1/attestations/key", id: "getAttestationKey", summary: "Get the public key swap attestations are signed with", tag: "Swaps", response: AttestationKeyResponse{}},
+
+	{pattern: "GET /api/v1/transfers/{transactionId}", id: "getTransfer", summary: "Track a cross-chain transfer", tag: "Transfers", response: TransferResponse{}},
+	{pattern: "GET /api/v1/transfers/{transactionId}/ws", id: "streamTransfer", summary: "Stream a cross-chain transfer's progress over a WebSocket", tag: "Transfers", status: http.StatusSwitchingProtocols},
+	{pattern: "GET /api/v1/bridge-quotes", id: "listBridgeQuotes", summary: "Compare the bridges' fees, times and limits for a transfer", tag: "Transfers", query: []apiQueryParam{{name: "from", description: "Source chain ID or CAIP-2 ID", required: true}, {name: "to", description: "Destination chain ID or CAIP-2 ID", required: true}, {name: "token", description: "Token symbol", required: true}, {name: "amount", description: "Base-unit amount", required: true}}, response: BridgeQuotesResponse{}},
+	{pattern: "GET /api/v1/bridges/reliability", id: "listBridgeReliability", summary: "Get every bridge's recent reliability", tag: "Transfers", response: BridgeReliabilityResponse{}},
+	{pattern: "GET /api/v1/bridges/{bridge}/reliability", id: "getBridgeReliability", summary: "Get a bridge's recent reliability", tag: "Transfers", response: services.BridgeStats{}},
+
+	{pattern: "GET /api/v1/pools", id: "listPools", summary: "List the liquidity pools, largest first", tag: "Pools", response: PoolsResponse{}},
+	{pattern: "GET /api/v1/pools/{id}", id: "getPool", summary: "Get a liquidity pool", tag: "Pools", response: services.LiquidityPool{}},
+	{pattern: "GET /api/v1/pools/{id}/positions", id: "listPoolPositions", summary: "List a pool's LP positions", tag: "Pools", response: PositionsResponse{}},
+	{pattern: "GET /api/v1/positions", id: "listUserPositions", summary: "List an address's LP positions", tag: "Pools", query: []apiQueryParam{{name: "address", required: true}}, response: PositionsResponse{}},
+	{pattern: "POST /api/v1/pools/{id}/liquidity", id: "addLiquidity", summary: "Add liquidity to a pool", tag: "Pools", request: LiquidityRequestBody{}, status: http.StatusAccepted, response: LiquidityResponse{}},
+	{pattern: "POST /api/v1/pools/{id}/liquidity/remove", id: "removeLiquidity", summary: "Remove liquidity from a pool", tag: "Pools", request: LiquidityRequestBody{}, status: http.StatusAccepted, response: LiquidityResponse{}},
+	{pattern: "GET /api/v1/pools/{id}/fees", id: "getPendingFees", summary: "Get an LP's unclaimed fees", tag: "Pools", query: []apiQueryParam{{name: "address", required: true}}, response: FeesResponse{}},
+	{pattern: "POST /api/v1/pools/{id}/fees/claim", id: "claimFees", summary: "Claim an LP's fees", tag: "Pools", request: ClaimFeesRequestBody{}, response: FeesResponse{}},
+
+	{pattern: "GET /api/v1/prices", id: "listPrices", summary: "List the latest prices; send Accept: application/x-ndjson to stream them", tag: "Prices", query: []apiQueryParam{{name: "symbols", description: "Comma-separated symbols"}, {name: "chainId", description: "Chain ID or CAIP-2 ID"}}, response: PricesResponse{}},
+	{pattern: "GET /api/v1/prices/ws", id: "streamPrices", summary: "Stream price updates over a WebSocket", tag: "Prices", status: http.StatusSwitchingProtocols},
+	{pattern: "GET /api/v1/prices/{symbol}", id: "getPrice", summary: "Get the latest prices of a symbol", tag: "Prices", query: []apiQueryParam{{name: "chainId", description: "Chain ID or CAIP-2 ID"}}, response: PricesResponse{}},
+	{pattern: "GET /api/v1/prices/{symbol}/history", id: "getPriceHistory", summary: "Get a symbol's price history", tag: "Prices", query: []apiQueryParam{fromParam, toParam, {name: "interval", description: "Go duration to downsample to, e.g. 15m"}, chainIDParam}, response: PriceHistoryResponse{}},
+	{pattern: "GET /api/v1/prices/{symbol}/twap", id: "getAveragePrice", summary: "Get a symbol's time- and volume-weighted average price", tag: "Prices", query: []apiQueryParam{{name: "window", description: "5m, 1h or 24h; default 1h"}, chainIDParam}, response: types.PriceAverage{}},
+	{pattern: "GET /api/v1/prices/{symbol}/candles", id: "getCandles", summary: "Get a symbol's OHLCV candles", tag: "Prices", query: []apiQueryParam{{name: "interval", description: "1m, 5m, 1h or 1d; default 1h"}, fromParam, toParam, chainIDParam}, response: CandlesResponse{}},
+
+	{pattern: "GET /api/v1/stats", id: "getSwapStats", summary: "Get swap volume, fee and success rate statistics over the last 24 hours and 7 days", tag: "Stats", response: types.SwapStats{}},
+	{pattern: "GET /api/v1/users/{address}/swaps", id: "listUserSwaps", summary: "List the finished swaps an address sent or received; private details need a wallet signature", tag: "Swaps", query: []apiQueryParam{{name: "limit", description: "Most swaps to return, 1 to 200; defaults to 50"}}, response: UserSwapsResponse{}},
+
+	{pattern: "PUT /api/v1/webhooks/secret", id: "putWebhookSecret", summary: "Register the secret the API key's swap callbacks are signed with; generated when none is given", tag: "Webhooks", request: WebhookSecretRequestBody{}, response: WebhookSecretResponse{}},
+	{pattern: "POST /api/v1/webhooks/verify", id: "verifyWebhook", summary: "Send a signed test webhook to a URL", tag: "Webhooks", request: WebhookVerifyRequestBody{}, response: WebhookVerifyResponse{}},
+
+	{pattern: "POST /api/v1/price-alerts", id: "createPriceAlert", summary: "Register an alert notified by webhook or email once a token's price crosses a threshold", tag: "Price Alerts", request: PriceAlertRequestBody{}, status: http.StatusCreated, response: types.PriceAlert{}},
+	{pattern: "GET /api/v1/price-alerts", id: "listPriceAlerts", summary: "List the API key's price alerts, newest first", tag: "Price Alerts", response: PriceAlertsResponse{}},
+	{pattern: "GET /api/v1/price-alerts/{id}", id: "getPriceAlert", summary: "Get a price alert", tag: "Price Alerts", response: types.PriceAlert{}},
+	{pattern: "DELETE /api/v1/price-alerts/{id}", id: "deletePriceAlert", summary: "Delete a price alert", tag: "Price Alerts", status: http.StatusNoContent},
+
+	{pattern: "GET /api/v1/sandbox/balances/{address}", id: "getSandboxBalances", summary: "Get an address's sandbox balances; needs a sandbox key", tag: "Sandbox", response: SandboxBalancesResponse{}},
+
+	{pattern: "POST /api/v1/listings", id: "submitListing", summary: "Request a token listing", tag: "Listings", request: ListingRequestBody{}, status: http.StatusAccepted, response: types.ListingRequest{}},
+	{pattern: "GET /api/v1/listings/{id}", id: "getListing", summary: "Get a listing request's status", tag: "Listings", response: types.ListingRequest{}},
+}
+
+// openAPISpec is the API's OpenAPI document, built on first use
+var openAPISpec = sync.OnceValue(buildOpenAPIDocument)
+
+// buildOpenAPIDocument builds the OpenAPI document of apiOperations
+func buildOpenAPIDocument() *openAPIDocument {
+	schemas := newOpenAPISchemas()
+	errorSchema := schemas.schemaOf(reflect.TypeOf(ErrorResponse{}))
+
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "Infinity DEX API",
+			Version:     "v1",
+			Description: "Cross-chain swaps, prices and liquidity. Errors carry a machine-readable code; see ErrorResponse.",
+		},
+		// The API key is optional: it selects the sandbox or a tenant's policy and usage
+		Security: []map[string][]string{{}, {"apiKey": {}}},
+		Paths:    map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				"apiKey": {Type: "apiKey", In: "header", Name: apiKeyHeader, Description: "Sandbox or tenant API key"},
+			},
+		},
+	}
+
+	for _, op := range apiOperations {
+		method, path, _ := strings.Cut(op.pattern, " ")
+		operation := &openAPIOperation{
+			OperationID: op.id,
+			Summary:     op.summary,
+			Tags:        []string{op.tag},
+			Responses: map[string]*openAPIResponse{
+				"default": {Description: "Error", Content: jsonContent(errorSchema)},
+			},
+		}
+
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				operation.Parameters = append(operation.Parameters, openAPIParameter{
+					Name:     strings.Trim(segment, "{}"),
+					In:       "path",
+					Required: true,
+					Schema:   &openAPISchema{Type: "string"},
+				})
+			}
+		}
+		for _, param := range op.query {
+			operation.Parameters = append(operation.Parameters, openAPIParameter{
+				Name:        param.name,
+				In:          "query",
+				Description: param.description,
+				Required:    param.required,
+				Schema:      &openAPISchema{Type: "string"},
+			})
+		}
+
+		if op.request != nil {
+			operation.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content:  jsonContent(schemas.schemaOf(reflect.TypeOf(op.request))),
+			}
+		}
+
+		status := op.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := &openAPIResponse{Description: http.StatusText(status)}
+		if op.response != nil {
+			response.Content = jsonContent(schemas.schemaOf(reflect.TypeOf(op.response)))
+		}
+		operation.Responses[strconv.Itoa(status)] = response
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*openAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = operation
+	}
+
+	doc.Components.Schemas = schemas.components
+	return doc
+}
+
+// jsonContent is a JSON body of a schema
+func jsonContent(schema *openAPISchema) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// openAPIHandler serves the OpenAPI document of the REST API
+func (s *Server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, openAPISpec())
+}
+
+// docsHandler serves Swagger UI for the OpenAPI document
+func (s *Server) docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUIPage))
+}
+
+// swaggerUIPage is the Swagger UI page, loading Swagger UI from unpkg
+var swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Infinity DEX API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "/api/v1/openapi.json", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
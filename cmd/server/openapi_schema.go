@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/services/types"
+)
+
+// openAPISchema is an OpenAPI 3 schema object
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+}
+
+// Schemas of types encoding/json doesn't encode by their fields
+var (
+	bigIntType        = reflect.TypeOf(big.Int{})
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	userOperationType = reflect.TypeOf(chains.UserOperation{})
+	amountType        = reflect.TypeOf(types.Amount{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// openAPISchemas builds the schemas of Go types as encoding/json encodes them. Named structs become components
+// referenced by name, so every operation returning a type shares its schema and the spec changes with the type.
+type openAPISchemas struct {
+	components map[string]*openAPISchema
+	names      map[reflect.Type]string
+}
+
+// newOpenAPISchemas creates an empty set of component schemas
+func newOpenAPISchemas() *openAPISchemas {
+	return &openAPISchemas{components: map[string]*openAPISchema{}, names: map[reflect.Type]string{}}
+}
+
+// schemaOf returns the schema of a Go type, adding the components it references
+func (s *openAPISchemas) schemaOf(t reflect.Type) *openAPISchema {
+	if t.Kind() == reflect.Pointer {
+		schema := s.schemaOf(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+
+	switch t {
+	case bigIntType:
+		return &openAPISchema{Type: "integer", Description: "Integer of any size, in base units for amounts"}
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &openAPISchema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+	case amountType:
+		return &openAPISchema{Type: "object", Description: "Amount with its base-unit value as a string, its token's decimals, and formatted in whole tokens"}
+	case userOperationType:
+		return &openAPISchema{Type: "object", Description: "ERC-4337 v0.7 user operation in the bundler RPC format, with hex quantities and data"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return &openAPISchema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &openAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + s.component(t)}
+	default:
+		// Interfaces encode as whatever they hold
+		return &openAPISchema{}
+	}
+}
+
+// component returns the component name of a named struct, adding its schema the first time it is seen
+func (s *openAPISchemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	// Types of the same name in different packages are told apart by package
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = string(unicode.ToUpper(rune(pkg[0]))) + pkg[1:] + name
+	}
+	// Registered before its fields are built, so types referring to themselves terminate
+	s.names[t] = name
+	s.components[name] = &openAPISchema{}
+	*s.components[name] = *s.structSchema(t)
+	return name
+}
+
+// structSchema returns the object schema of a struct's JSON fields. Fields that are always encoded are required.
+func (s *openAPISchemas) structSchema(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+	s.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the JSON fields of a struct to an object schema, including those of embedded structs
+func (s *openAPISchemas) addFields(schema *openAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = s.schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
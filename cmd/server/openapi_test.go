@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routePattern matches the routes registered in routes()
+var routePattern = regexp.MustCompile(`s\.mux\.HandleFunc\("([A-Z]+ /[^"]*)"`)
+
+func TestOpenAPI(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/openapi.json", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var doc openAPIDocument
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	t.Run("DocumentsEveryPublicRoute", func(t *testing.T) {
+		source, err := os.ReadFile("server.go")
+		require.NoError(t, err)
+		documented := map[string]bool{}
+		for _, op := range apiOperations {
+			documented[op.pattern] = true
+		}
+
+		for _, match := range routePattern.FindAllStringSubmatch(string(source), -1) {
+			pattern := match[1]
+			_, path, _ := strings.Cut(pattern, " ")
+			if !strings.HasPrefix(path, "/api/v1/") || strings.HasPrefix(path, "/api/v1/admin/") || path == "/api/v1/openapi.json" || path == "/api/v1/docs" {
+				continue
+			}
+			assert.True(t, documented[pattern], "route %s is not in apiOperations", pattern)
+		}
+	})
+
+	t.Run("OperationsMatchRoutes", func(t *testing.T) {
+		for _, op := range apiOperations {
+			method, path, _ := strings.Cut(op.pattern, " ")
+			req := httptest.NewRequest(method, strings.NewReplacer("{", "", "}", "").Replace(path), nil)
+			_, pattern := s.mux.Handler(req)
+			assert.Equal(t, op.pattern, pattern)
+		}
+	})
+
+	t.Run("SchemasFollowStructs", func(t *testing.T) {
+		swap := doc.Components.Schemas["SwapResponse"]
+		require.NotNil(t, swap)
+		assert.Equal(t, []string{"requestId", "status"}, swap.Required)
+		assert.Equal(t, "#/components/schemas/SwapResult", swap.Properties["result"].Ref)
+		assert.Equal(t, "#/components/schemas/SwapQuote", swap.Properties["quote"].Ref)
+
+		// Embedded structs' fields are flattened, like encoding/json does
+		details := doc.Components.Schemas["TokenDetails"]
+		require.NotNil(t, details)
+		assert.Contains(t, details.Properties, "symbol")
+		assert.Contains(t, details.Properties, "coingeckoId")
+
+		result := doc.Components.Schemas["SwapResult"]
+		require.NotNil(t, result)
+		assert.Equal(t, "integer", result.Properties["outputAmount"].Type)
+		assert.Equal(t, "date-time", result.Properties["completionTime"].Format)
+
+		start := doc.Paths["/api/v1/swap"]["post"]
+		require.NotNil(t, start)
+		assert.Equal(t, "#/components/schemas/SwapRequestBody", start.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Contains(t, start.Responses, "202")
+		assert.Contains(t, start.Responses, "default")
+	})
+
+	t.Run("ReferencesResolve", func(t *testing.T) {
+		body, err := json.Marshal(doc)
+		require.NoError(t, err)
+		for _, ref := range regexp.MustCompile(`"\$ref":"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(body), -1) {
+			assert.Contains(t, doc.Components.Schemas, ref[1])
+		}
+	})
+
+	t.Run("SwaggerUI", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/docs", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), `url: "/api/v1/openapi.json"`)
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+)
+
+// ParameterRequest is the body of a parameter update
+type ParameterRequest struct {
+	Value       string `json:"value"`
+	Description string `json:"description"`
+	Reason      string `json:"reason,omitempty"` // Recorded in the audit log
+}
+
+// ParametersResponse is returned by the parameter list endpoint
+type ParametersResponse struct {
+	Parameters []types.Parameter `json:"parameters"`
+}
+
+// SetParameterStore reads parameters and rules from store instead of memory
+func (s *Server) SetParameterStore(store services.ParameterStore) {
+	s.parameterStore = store
+	s.rules.SetStore(store)
+}
+
+// listParametersHandler returns the stored parameters, optionally only those starting with ?prefix=
+func (s *Server) listParametersHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	params, err := s.parameterStore.ListParameters(r.Context(), r.URL.Query().Get("prefix"))
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list parameters: %v", err))
+		return
+	}
+	if params == nil {
+		params = []types.Parameter{}
+	}
+
+	writeJSON(w, http.StatusOK, ParametersResponse{Parameters: params})
+}
+
+// getParameterHandler returns a parameter
+func (s *Server) getParameterHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	param, err := s.parameterStore.GetParameter(r.Context(), r.PathValue("name"))
+	if errors.Is(err, types.ErrParameterNotFound) {
+		errorResponse(w, http.StatusNotFound, "parameter not found")
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load parameter: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, param)
+}
+
+// putParameterHandler creates or replaces a parameter, bumping its version. Rule expressions must compile.
+func (s *Server) putParameterHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var req ParameterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	param := types.Parameter{
+		Name:        r.PathValue("name"),
+		Value:       req.Value,
+		Description: req.Description,
+	}
+	if !services.IsRuleName(param.Name) {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("parameter names must start with %s, %s or %s",
+			services.RulePrefixAlert, services.RulePrefixBreaker, services.RulePrefixFee))
+		return
+	}
+	if err := services.ValidateRule(param.Name, param.Value); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	param.UpdatedAt = time.Now().UTC()
+
+	saved, err := s.parameterStore.SaveParameter(r.Context(), param)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to save parameter: %v", err))
+		return
+	}
+	s.auditParameterChange(r, "set_parameter", req.Reason, map[string]string{
+		"name":    saved.Name,
+		"value":   saved.Value,
+		"version": strconv.Itoa(saved.Version),
+	})
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// deleteParameterHandler removes a parameter
+func (s *Server) deleteParameterHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	name := r.PathValue("name")
+	err := s.parameterStore.DeleteParameter(r.Context(), name)
+	if errors.Is(err, types.ErrParameterNotFound) {
+		errorResponse(w, http.StatusNotFound, "parameter not found")
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete parameter: %v", err))
+		return
+	}
+	s.auditParameterChange(r, "delete_parameter", r.URL.Query().Get("reason"), map[string]string{"name": name})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// auditParameterChange records a parameter change in the admin audit log as made by the request's operator
+func (s *Server) auditParameterChange(r *http.Request, action, reason string, params map[string]string) {
+	if s.auditLog == nil {
+		return
+	}
+	entry := temporal_activities.AuditEntry{
+		ActionID: params["name"],
+		Action:   action,
+		Operator: s.adminOperator(r),
+		Reason:   reason,
+		Params:   params,
+		Status:   temporal_activities.AuditStatusCompleted,
+	}
+	if err := s.auditLog.Record(entry); err != nil {
+		log.Printf("Failed to record %s of %s: %v", action, params["name"], err)
+	}
+}
+
+// checkPriceAlerts evaluates the alert rules against each updated price, logging the alerts that fire.
+// Rules see symbol, chainId, priceUSD, change24h, volume24h, source and verified.
+func (s *Server) checkPriceAlerts(ctx context.Context, prices []types.TokenPrice) []string {
+	var fired []string
+	for _, price := range prices {
+		matched, err := s.rules.Matching(ctx, services.RulePrefixAlert, map[string]interface{}{
+			"symbol":    price.Symbol,
+			"chainId":   price.ChainID,
+			"priceUSD":  price.PriceUSD,
+			"change24h": price.Change24h,
+			"volume24h": price.Volume24h,
+			"source":    string(price.Source),
+			"verified":  price.IsVerified,
+		})
+		if err != nil {
+			log.Printf("Failed to evaluate alert rules for %s: %v", price.Symbol, err)
+		}
+		for _, rule := range matched {
+			log.Printf("ALERT %s: %s on chain %d at $%f", rule, price.Symbol, price.ChainID, price.PriceUSD)
+			fired = append(fired, rule+":"+types.GetPriceKey(price.Symbol, price.ChainID))
+		}
+	}
+	return fired
+}
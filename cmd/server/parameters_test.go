@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParameters(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	auditLog, err := temporal_activities.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	s.auditLog = auditLog
+	path := "/api/v1/admin/parameters/alert.usdc_depeg"
+
+	t.Run("Put", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodPut, path, ParameterRequest{Value: "true"}, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(t, s, http.MethodPut, path, ParameterRequest{Value: "priceUSD <"}, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "expressions must compile")
+
+		rec = doRequest(t, s, http.MethodPut, "/api/v1/admin/parameters/threshold", ParameterRequest{Value: "1"}, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "names need a rule prefix")
+
+		for version := 1; version <= 2; version++ {
+			rec = doRequest(t, s, http.MethodPut, path, ParameterRequest{Value: `symbol == "USDC" && priceUSD < 0.98`, Description: "USDC depeg"}, adminKey)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var param types.Parameter
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&param))
+			assert.Equal(t, version, param.Version)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, path, nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/parameters?prefix=fee.", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp ParametersResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Empty(t, resp.Parameters)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/parameters", nil, adminKey)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Parameters, 1)
+		assert.Equal(t, "alert.usdc_depeg", resp.Parameters[0].Name)
+	})
+
+	t.Run("PriceAlerts", func(t *testing.T) {
+		fired := s.checkPriceAlerts(context.Background(), []types.TokenPrice{
+			{Symbol: "USDC", ChainID: 1, PriceUSD: 0.95},
+			{Symbol: "USDC", ChainID: 137, PriceUSD: 1},
+			{Symbol: "USDT", ChainID: 1, PriceUSD: 0.95},
+		})
+		assert.Equal(t, []string{"alert.usdc_depeg:" + types.GetPriceKey("USDC", 1)}, fired)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodDelete, path, nil, adminKey)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = doRequest(t, s, http.MethodDelete, path, nil, adminKey)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Audited", func(t *testing.T) {
+		entries, err := auditLog.List(10)
+		require.NoError(t, err)
+		var actions []string
+		for _, entry := range entries {
+			assert.Equal(t, "oncall@infinity-dex", entry.Operator)
+			assert.Equal(t, "alert.usdc_depeg", entry.Params["name"])
+			actions = append(actions, entry.Action+":"+entry.Params["version"])
+		}
+		assert.ElementsMatch(t, []string{"set_parameter:1", "set_parameter:2", "delete_parameter:"}, actions)
+	})
+}
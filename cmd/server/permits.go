@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+)
+
+// defaultPermitLifetime is how long permits built without a deadline stay valid; longer than swaps' own deadline,
+// so a permit signed for a swap outlives it
+const defaultPermitLifetime = 30 * time.Minute
+
+// noncesFunction reads an EIP-2612 token's next permit nonce for an owner
+const noncesFunction = "nonces(address)"
+
+// PermitTypedDataResponse is returned by the permit typed data endpoint: the unsigned permit, the typed data its
+// owner signs with eth_signTypedData_v4, and the typed data's digest
+type PermitTypedDataResponse struct {
+	Permit    PermitBody          `json:"permit"`
+	TypedData *services.TypedData `json:"typedData"`
+	Digest    string              `json:"digest"`
+}
+
+// checkPermit checks a swap carrying a permit can pull its source tokens with it: the permit is signed by the source
+// address for the DEX contract executing the swap, for at least its amount. A permit can be used once, so tranched
+// swaps can't carry one.
+func (s *Server) checkPermit(request types.SwapRequest) error {
+	if request.Permit == nil {
+		return nil
+	}
+	if request.Tranches != nil {
+		return errors.New("tranched swaps can't use a permit")
+	}
+	if err := temporal_activities.CheckSwapPermit(s.swapContracts(), request, time.Now()); err != nil {
+		return fmt.Errorf("invalid permit: %w", err)
+	}
+	return nil
+}
+
+// permitTypedDataHandler builds the permit an owner signs to let the DEX contract of a token's chain pull amount of
+// the token for a swap. An EIP-2612 permit without a nonce gets the token's next nonce for the owner; a Permit2
+// permit gets a random one.
+func (s *Server) permitTypedDataHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	kind := query.Get("kind")
+	if kind == "" {
+		kind = types.PermitEIP2612
+	}
+	if kind != types.PermitEIP2612 && kind != types.PermitPermit2 {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid kind %q: must be eip2612 or permit2", kind))
+		return
+	}
+	token, err := s.tokenService.GetToken(query.Get("token"))
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("token %q not found", query.Get("token")))
+		return
+	}
+	amount, ok := new(big.Int).SetString(query.Get("amount"), 10)
+	if !ok || amount.Sign() <= 0 {
+		errorResponse(w, http.StatusBadRequest, "invalid amount: must be a positive base-unit integer")
+		return
+	}
+	owner := query.Get("owner")
+	if _, err := evm.EncodeArgs(owner); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid owner %q: must be an EVM address", owner))
+		return
+	}
+	spender := s.swapContracts()[types.CanonicalChainID(token.ChainID)].DEX
+	if spender == "" {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("%s's chain has no DEX contract to permit", token.Symbol))
+		return
+	}
+
+	deadline := time.Now().Add(defaultPermitLifetime).Unix()
+	if raw := query.Get("deadline"); raw != "" {
+		if deadline, err = strconv.ParseInt(raw, 10, 64); err != nil || deadline <= time.Now().Unix() {
+			errorResponse(w, http.StatusBadRequest, "invalid deadline: must be a future Unix time")
+			return
+		}
+	}
+	var nonce *big.Int
+	if raw := query.Get("nonce"); raw != "" {
+		if nonce, ok = new(big.Int).SetString(raw, 10); !ok || nonce.Sign() < 0 {
+			errorResponse(w, http.StatusBadRequest, "invalid nonce: must be a non-negative integer")
+			return
+		}
+	} else if nonce, err = s.permitNonce(r.Context(), kind, token, owner); err != nil {
+		errorResponse(w, http.StatusBadGateway, fmt.Sprintf("failed to read the permit nonce: %v", err))
+		return
+	}
+
+	permit := types.Permit{
+		Kind:     kind,
+		Owner:    owner,
+		Spender:  spender,
+		Value:    amount,
+		Nonce:    nonce,
+		Deadline: deadline,
+		Version:  query.Get("version"),
+	}
+	typedData, err := services.PermitTypedData(permit, token)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	digest, err := services.PermitDigest(permit, token)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PermitTypedDataResponse{
+		Permit: PermitBody{
+			Kind:     permit.Kind,
+			Owner:    permit.Owner,
+			Spender:  permit.Spender,
+			Value:    permit.Value.String(),
+			Nonce:    permit.Nonce.String(),
+			Deadline: permit.Deadline,
+			Version:  permit.Version,
+		},
+		TypedData: typedData,
+		Digest:    "0x" + hex.EncodeToString(digest),
+	})
+}
+
+// permitNonce returns the nonce of owner's next permit of token: the token's own count of the owner's EIP-2612
+// permits, or a random Permit2 nonce, which Permit2 accepts in any order
+func (s *Server) permitNonce(ctx context.Context, kind string, token types.Token, owner string) (*big.Int, error) {
+	if kind == types.PermitPermit2 {
+		return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	}
+	data, err := evm.EncodeCall(noncesFunction, owner)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.rpcClient.Call(ctx, types.CanonicalChainID(token.ChainID), "", token.Address, "0x"+hex.EncodeToString(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) < 32 {
+		return nil, fmt.Errorf("invalid nonce %x", result)
+	}
+	return new(big.Int).SetBytes(result[:32]), nil
+}
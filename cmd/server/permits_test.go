@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinity-dex/chains/signer"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermitSwaps(t *testing.T) {
+	const dex = "0x2222222222222222222222222222222222222222"
+	s := newTestServer(t)
+	ethereum := s.config().Chains["ethereum"]
+	ethereum.DEXAddress = dex
+	s.config().Chains["ethereum"] = ethereum
+	usdc := types.Token{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: types.ChainIDEthereum, ChainName: "Ethereum", Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}
+	s.tokenService.UpsertToken(usdc)
+
+	// The token counts the owner's permits
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": "0x0000000000000000000000000000000000000000000000000000000000000005"}`))
+	}))
+	defer rpc.Close()
+	s.rpcClient = temporal_activities.NewEVMRPCClient(http.DefaultClient, map[int64][]string{types.ChainIDEthereum: {rpc.URL}})
+
+	key, err := signer.NewPrivateKeySigner("4646464646464646464646464646464646464646464646464646464646464646")
+	require.NoError(t, err)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/permits/typed-data?token=USDC&amount=1000000000000000000&owner="+key.Address(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var built PermitTypedDataResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&built))
+	assert.Equal(t, types.PermitEIP2612, built.Permit.Kind)
+	assert.Equal(t, dex, built.Permit.Spender, "the DEX contract pulls the tokens")
+	assert.Equal(t, "5", built.Permit.Nonce, "the owner's next nonce is read from the token")
+	assert.Equal(t, "Permit", built.TypedData.PrimaryType)
+	assert.Equal(t, "USD Coin", built.TypedData.Domain["name"])
+
+	digest, err := hex.DecodeString(built.Digest[2:])
+	require.NoError(t, err)
+	signature, err := key.SignDigest(context.Background(), digest)
+	require.NoError(t, err)
+	raw := make([]byte, 65)
+	signature.R.FillBytes(raw[:32])
+	signature.S.FillBytes(raw[32:64])
+	raw[64] = 27 + signature.Recovery
+	built.Permit.Signature = "0x" + hex.EncodeToString(raw)
+
+	body := testSwapBody()
+	body.SourceToken = usdc
+	body.DestinationToken = types.Token{Symbol: "DAI", Decimals: 18, Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F", ChainID: types.ChainIDEthereum, ChainName: "Ethereum"}
+	body.Amount = "1000000000000000000"
+	body.SourceAddress = key.Address()
+	body.Permit = &built.Permit
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	// The permit must cover the swap's amount
+	larger := body
+	larger.Amount = "1000000000000000001"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", larger, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	// A permit signed for other terms is refused
+	tampered := *body.Permit
+	tampered.Deadline++
+	forged := body
+	forged.Permit = &tampered
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", forged, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "invalid permit")
+
+	// Each tranche would need its own permit
+	tranched := body
+	tranched.Tranches = &TranchesBody{Size: "500000000000000000"}
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", tranched, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	// Permit2 permits get a random nonce and are signed for the Permit2 contract
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/permits/typed-data?kind=permit2&token=USDC&amount=1000000000000000000&owner="+key.Address(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&built))
+	assert.Equal(t, "PermitTransferFrom", built.TypedData.PrimaryType)
+	assert.Equal(t, types.Permit2Address, built.TypedData.Domain["verifyingContract"])
+
+	for _, query := range []string{
+		"kind=permit3&token=USDC&amount=1&owner=" + key.Address(),
+		"token=USDC&amount=0&owner=" + key.Address(),
+		"token=USDC&amount=1&owner=bob",
+		"token=USDC&amount=1&deadline=1&owner=" + key.Address(),
+	} {
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/permits/typed-data?"+query, nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/permits/typed-data?token=DOGE&amount=1&owner="+key.Address(), nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
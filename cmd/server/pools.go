@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+)
+
+// LiquidityRequestBody is the JSON body accepted by the liquidity endpoints
+type LiquidityRequestBody struct {
+	UserAddress string      `json:"userAddress"`
+	Token       types.Token `json:"token"`
+	Amount      string      `json:"amount"` // Raw base-unit integer
+	RequestID   string      `json:"requestId,omitempty"`
+}
+
+// LiquidityResponse is returned when liquidity is added or removed
+type LiquidityResponse struct {
+	RequestID string                 `json:"requestId"`
+	PoolID    string                 `json:"poolId"`
+	Status    string                 `json:"status"`
+	Result    *types.LiquidityResult `json:"result,omitempty"`
+}
+
+// PoolsResponse is returned by the pool listing endpoint
+type PoolsResponse struct {
+	Pools []services.LiquidityPool `json:"pools"`
+}
+
+// PositionsResponse is returned by the position listing endpoints
+type PositionsResponse struct {
+	Positions []services.LiquidityPosition `json:"positions"`
+}
+
+// SetPoolStore replaces the store liquidity pools are kept in, shared with the swap worker, and seeds the configured pools into it
+func (s *Server) SetPoolStore(store services.PoolStore) {
+	s.liquidityService.SetStore(store)
+	seedPools(context.Background(), s.liquidityService, s.config().Pools)
+}
+
+// seedPools creates the configured pools that don't exist yet
+func seedPools(ctx context.Context, liquidityService *services.LiquidityService, pools []temporal_config.PoolConfig) {
+	for _, pool := range pools {
+		pair := services.TokenPair{
+			BaseToken:  services.Token{Symbol: pool.BaseToken, ChainID: pool.ChainID},
+			QuoteToken: services.Token{Symbol: pool.QuoteToken, ChainID: pool.ChainID},
+		}
+		if err := liquidityService.SeedPool(ctx, pool.ID, pair, pool.FeeTier, pool.Address); err != nil {
+			log.Printf("Failed to seed pool %s: %v", pool.ID, err)
+		}
+	}
+}
+
+// listPoolsHandler returns all liquidity pools, largest TVL first
+func (s *Server) listPoolsHandler(w http.ResponseWriter, r *http.Request) {
+	pools := s.liquidityService.GetAllPools(r.Context())
+	sort.Slice(pools, func(i, j int) bool {
+		if pools[i].TVL != pools[j].TVL {
+			return pools[i].TVL > pools[j].TVL
+		}
+		return pools[i].ID < pools[j].ID
+	})
+
+	writeJSON(w, http.StatusOK, PoolsResponse{Pools: pools})
+}
+
+// getPoolHandler returns a single liquidity pool
+func (s *Server) getPoolHandler(w http.ResponseWriter, r *http.Request) {
+	pool, err := s.liquidityService.GetPool(r.Context(), r.PathValue("id"))
+	if err != nil {
+		serviceErrorResponse(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pool)
+}
+
+// poolPositionsHandler returns the LP positions in a pool
+func (s *Server) poolPositionsHandler(w http.ResponseWriter, r *http.Request) {
+	positions, err := s.liquidityService.GetPoolPositions(r.Context(), r.PathValue("id"))
+	if err != nil {
+		serviceErrorResponse(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PositionsResponse{Positions: positions})
+}
+
+// userPositionsHandler returns the LP positions held by an address across all pools
+func (s *Server) userPositionsHandler(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		errorResponse(w, http.StatusBadRequest, "address query parameter is required")
+		return
+	}
+
+	positions := s.liquidityService.GetUserPositions(r.Context(), address)
+	if positions == nil {
+		positions = []services.LiquidityPosition{}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].PoolID < positions[j].PoolID
+	})
+
+	writeJSON(w, http.StatusOK, PositionsResponse{Positions: positions})
+}
+
+// ClaimFeesRequestBody is the JSON body accepted by the fee claim endpoint
+type ClaimFeesRequestBody struct {
+	UserAddress string `json:"userAddress"`
+}
+
+// FeesResponse is returned by the fee endpoints. Fees are raw base-unit amounts by token symbol.
+type FeesResponse struct {
+	PoolID      string             `json:"poolId"`
+	UserAddress string             `json:"userAddress"`
+	PendingFees types.TokenAmounts `json:"pendingFees"`
+	Claimed     types.TokenAmounts `json:"claimed,omitempty"`
+}
+
+// pendingFeesHandler returns the fees an address can claim from a pool
+func (s *Server) pendingFeesHandler(w http.ResponseWriter, r *http.Request) {
+	poolID := r.PathValue("id")
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		errorResponse(w, http.StatusBadRequest, "address query parameter is required")
+		return
+	}
+
+	pending, err := s.liquidityService.GetPendingFees(r.Context(), poolID, address)
+	if err != nil {
+		serviceErrorResponse(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, FeesResponse{PoolID: poolID, UserAddress: address, PendingFees: pending})
+}
+
+// claimFeesHandler pays out the fees an address has accrued in a pool
+func (s *Server) claimFeesHandler(w http.ResponseWriter, r *http.Request) {
+	var body ClaimFeesRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if body.UserAddress == "" {
+		errorResponse(w, http.StatusBadRequest, "userAddress is required")
+		return
+	}
+
+	poolID := r.PathValue("id")
+	claimed, err := s.liquidityService.ClaimFees(r.Context(), poolID, body.UserAddress)
+	if err != nil {
+		serviceErrorResponse(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, FeesResponse{
+		PoolID:      poolID,
+		UserAddress: body.UserAddress,
+		PendingFees: types.TokenAmounts{},
+		Claimed:     claimed,
+	})
+}
+
+// addLiquidityHandler adds liquidity to a pool
+func (s *Server) addLiquidityHandler(w http.ResponseWriter, r *http.Request) {
+	s.handleLiquidity(w, r, "add")
+}
+
+// removeLiquidityHandler removes liquidity from a pool
+func (s *Server) removeLiquidityHandler(w http.ResponseWriter, r *http.Request) {
+	s.handleLiquidity(w, r, "remove")
+}
+
+// handleLiquidity runs a liquidity operation, through Temporal when available
+func (s *Server) handleLiquidity(w http.ResponseWriter, r *http.Request, action string) {
+	request, err := decodeLiquidityRequest(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Liquidity is wrapped into and unwrapped from universal tokens
+	if status, err := s.checkChainFeature(request.Token.ChainID, temporal_config.ChainFeatureWrap); err != nil {
+		serviceErrorResponse(w, status, err)
+		return
+	}
+	if request.RequestID == "" {
+		request.RequestID = fmt.Sprintf("%s-liquidity-%s", action, uuid.New().String())
+	}
+
+	if s.temporalClient != nil {
+		workflowFn := temporal_workflows.AddLiquidityWorkflow
+		if action == "remove" {
+			workflowFn = temporal_workflows.RemoveLiquidityWorkflow
+		}
+
+		options, _ := s.workflowOptions(request.RequestID, SwapTaskQueue)
+		input := temporal_workflows.LiquidityWorkflowInput{Request: request, Retries: s.config().Retries.Policies()}
+		if _, err := s.temporalClient.ExecuteWorkflow(r.Context(), options, workflowFn, input); err != nil {
+			errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to start liquidity workflow: %v", err))
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, LiquidityResponse{RequestID: request.RequestID, PoolID: request.PoolID, Status: "pending"})
+		return
+	}
+
+	if _, err := s.liquidityService.GetPool(r.Context(), request.PoolID); err != nil {
+		serviceErrorResponse(w, http.StatusNotFound, err)
+		return
+	}
+
+	result := &types.LiquidityResult{
+		RequestID: request.RequestID,
+		PoolID:    request.PoolID,
+		Action:    action,
+		Amount:    request.Amount,
+	}
+
+	if action == "add" {
+		position, err := s.liquidityService.AddLiquidityOnce(r.Context(), "mint:"+request.RequestID, request.PoolID, request.UserAddress, request.Token.Symbol, request.Amount)
+		if err != nil {
+			serviceErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		converted := types.LiquidityPosition(*position)
+		result.Position = &converted
+	} else {
+		if err := s.liquidityService.RemoveLiquidityOnce(r.Context(), "burn:"+request.RequestID, request.PoolID, request.UserAddress, request.Token.Symbol, request.Amount); err != nil {
+			serviceErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	result.Success = true
+	result.CompletionTime = time.Now()
+
+	writeJSON(w, http.StatusOK, LiquidityResponse{RequestID: request.RequestID, PoolID: request.PoolID, Status: "completed", Result: result})
+}
+
+// decodeLiquidityRequest parses a liquidity request body
+func decodeLiquidityRequest(r *http.Request) (types.LiquidityRequest, error) {
+	var body LiquidityRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return types.LiquidityRequest{}, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	amount, ok := new(big.Int).SetString(body.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return types.LiquidityRequest{}, errors.New("invalid amount: must be a positive base-unit integer")
+	}
+	if body.UserAddress == "" {
+		return types.LiquidityRequest{}, errors.New("userAddress is required")
+	}
+
+	return types.LiquidityRequest{
+		PoolID:      r.PathValue("id"),
+		UserAddress: body.UserAddress,
+		Token:       body.Token,
+		Amount:      amount,
+		RequestID:   body.RequestID,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+// PriceAlertRequestBody registers a price alert, notified by webhook, email or both
+type PriceAlertRequestBody struct {
+	Symbol     string  `json:"symbol"`
+	ChainID    int64   `json:"chainId,omitempty"`
+	Threshold  float64 `json:"threshold"`
+	Direction  string  `json:"direction"`
+	WebhookURL string  `json:"webhookUrl,omitempty"`
+	Email      string  `json:"email,omitempty"`
+}
+
+// PriceAlertsResponse lists an API key's price alerts
+type PriceAlertsResponse struct {
+	Alerts []types.PriceAlert `json:"alerts"`
+}
+
+// SetPriceAlertStore sets the store price alerts are kept in
+func (s *Server) SetPriceAlertStore(store services.PriceAlertStore) {
+	s.priceAlerts = store
+}
+
+// createPriceAlertHandler registers a price alert for the calling API key
+func (s *Server) createPriceAlertHandler(w http.ResponseWriter, r *http.Request) {
+	keyID, _, ok := s.meteredKey(r)
+	if !ok {
+		codedErrorResponse(w, ErrorCodeUnauthorized, "price alerts need a tenant or sandbox API key")
+		return
+	}
+
+	var body PriceAlertRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	alert, err := services.NewPriceAlert(keyID, types.PriceAlert{
+		Symbol:     body.Symbol,
+		ChainID:    body.ChainID,
+		Threshold:  body.Threshold,
+		Direction:  body.Direction,
+		WebhookURL: body.WebhookURL,
+		Email:      body.Email,
+	}, time.Now().UTC())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if alert.WebhookURL != "" {
+		if err := s.validateCallbackURL(r.Context(), keyID, alert.WebhookURL); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if alert.Email != "" && s.config().Notifications.SMTPAddr == "" {
+		errorResponse(w, http.StatusBadRequest, services.ErrEmailDisabled.Error())
+		return
+	}
+
+	alerts, err := s.priceAlerts.ListPriceAlerts(r.Context(), keyID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list price alerts: %v", err))
+		return
+	}
+	active := 0
+	for _, existing := range alerts {
+		if existing.Status == types.PriceAlertStatusActive {
+			active++
+		}
+	}
+	if active >= services.MaxPriceAlerts {
+		errorResponse(w, http.StatusConflict, fmt.Sprintf("at most %d price alerts may be active; delete one first", services.MaxPriceAlerts))
+		return
+	}
+
+	if err := s.priceAlerts.CreatePriceAlert(r.Context(), alert); err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to save price alert: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, alert)
+}
+
+// listPriceAlertsHandler returns the calling API key's price alerts, newest first
+func (s *Server) listPriceAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	keyID, _, ok := s.meteredKey(r)
+	if !ok {
+		codedErrorResponse(w, ErrorCodeUnauthorized, "price alerts need a tenant or sandbox API key")
+		return
+	}
+
+	alerts, err := s.priceAlerts.ListPriceAlerts(r.Context(), keyID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list price alerts: %v", err))
+		return
+	}
+	if alerts == nil {
+		alerts = []types.PriceAlert{}
+	}
+	writeJSON(w, http.StatusOK, PriceAlertsResponse{Alerts: alerts})
+}
+
+// getPriceAlertHandler returns one of the calling API key's price alerts
+func (s *Server) getPriceAlertHandler(w http.ResponseWriter, r *http.Request) {
+	keyID, _, ok := s.meteredKey(r)
+	if !ok {
+		codedErrorResponse(w, ErrorCodeUnauthorized, "price alerts need a tenant or sandbox API key")
+		return
+	}
+
+	alert, err := s.priceAlerts.GetPriceAlert(r.Context(), keyID, r.PathValue("id"))
+	if errors.Is(err, types.ErrPriceAlertNotFound) {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get price alert: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, alert)
+}
+
+// deletePriceAlertHandler deletes one of the calling API key's price alerts
+func (s *Server) deletePriceAlertHandler(w http.ResponseWriter, r *http.Request) {
+	keyID, _, ok := s.meteredKey(r)
+	if !ok {
+		codedErrorResponse(w, ErrorCodeUnauthorized, "price alerts need a tenant or sandbox API key")
+		return
+	}
+
+	err := s.priceAlerts.DeletePriceAlert(r.Context(), keyID, r.PathValue("id"))
+	if errors.Is(err, types.ErrPriceAlertNotFound) {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete price alert: %v", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
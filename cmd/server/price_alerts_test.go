@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceAlerts(t *testing.T) {
+	s := newTestServer(t)
+	s.tenantKeys["acme-key"] = "acme"
+	s.tenantKeys["other-key"] = "other"
+	body := PriceAlertRequestBody{Symbol: "eth", Threshold: 4000, Direction: types.PriceAlertAbove, Email: "ops@example.com"}
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/price-alerts", body, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Email alerts need an SMTP server to be sent through
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/price-alerts", body, "acme-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "email notifications are not configured")
+
+	cfg := *s.config()
+	cfg.Notifications.SMTPAddr = "smtp.example.com:587"
+	cfg.Notifications.SMTPFrom = "alerts@example.com"
+	s.configs.Store(&cfg)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/price-alerts", body, "acme-key")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created types.PriceAlert
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+	assert.Equal(t, "ETH", created.Symbol)
+	assert.Equal(t, types.PriceAlertStatusActive, created.Status)
+	assert.NotEmpty(t, created.ID)
+
+	// Webhook alerts need a secret to sign with, like swap callbacks
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/price-alerts", PriceAlertRequestBody{Symbol: "ETH", Threshold: 1000, Direction: types.PriceAlertBelow, WebhookURL: "https://example.com/hook"}, "acme-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no webhook secret")
+
+	for name, invalid := range map[string]PriceAlertRequestBody{
+		"NoSymbol":    {Threshold: 1, Direction: types.PriceAlertAbove, Email: "ops@example.com"},
+		"NoThreshold": {Symbol: "ETH", Direction: types.PriceAlertAbove, Email: "ops@example.com"},
+		"Direction":   {Symbol: "ETH", Threshold: 1, Direction: "sideways", Email: "ops@example.com"},
+		"NoChannel":   {Symbol: "ETH", Threshold: 1, Direction: types.PriceAlertAbove},
+		"Email":       {Symbol: "ETH", Threshold: 1, Direction: types.PriceAlertAbove, Email: "not an email"},
+	} {
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/price-alerts", invalid, "acme-key")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/price-alerts", nil, "acme-key")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list PriceAlertsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	require.Len(t, list.Alerts, 1)
+	assert.Equal(t, created.ID, list.Alerts[0].ID)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/price-alerts/"+created.ID, nil, "acme-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Other keys don't see or delete the alert
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/price-alerts", nil, "other-key")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"alerts":[]}`, rec.Body.String())
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/price-alerts/"+created.ID, nil, "other-key")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = doRequest(t, s, http.MethodDelete, "/api/v1/price-alerts/"+created.ID, nil, "other-key")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/v1/price-alerts/"+created.ID, nil, "acme-key")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/price-alerts/"+created.ID, nil, "acme-key")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// priceFeedBuffer is the number of updates queued per subscriber before updates are dropped
+	priceFeedBuffer = 16
+
+	// priceFeedWriteTimeout bounds how long a slow client can block its writer
+	priceFeedWriteTimeout = 10 * time.Second
+)
+
+// PriceFeedMessage is pushed to WebSocket price feed clients
+type PriceFeedMessage struct {
+	Type      string             `json:"type"` // snapshot, update or error
+	Prices    []types.TokenPrice `json:"prices,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// PriceFeedCommand is sent by clients to change their subscription
+type PriceFeedCommand struct {
+	Action  string   `json:"action"` // subscribe or unsubscribe
+	Symbols []string `json:"symbols"`
+}
+
+// PriceBroker fans out price updates to subscribers
+type PriceBroker struct {
+	subscribers map[*PriceSubscription]bool
+	mu          sync.RWMutex
+}
+
+// PriceSubscription is a subscriber with its symbol filter; an empty filter receives everything
+type PriceSubscription struct {
+	updates chan []types.TokenPrice
+	symbols map[string]bool
+	mu      sync.RWMutex
+}
+
+// NewPriceBroker creates a new price broker
+func NewPriceBroker() *PriceBroker {
+	return &PriceBroker{
+		subscribers: make(map[*PriceSubscription]bool),
+	}
+}
+
+// Subscribe registers a subscriber for the given symbols
+func (b *PriceBroker) Subscribe(symbols []string) *PriceSubscription {
+	sub := &PriceSubscription{
+		updates: make(chan []types.TokenPrice, priceFeedBuffer),
+		symbols: make(map[string]bool),
+	}
+	sub.add(symbols)
+
+	b.mu.Lock()
+	b.subscribers[sub] = true
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its update channel
+func (b *PriceBroker) Unsubscribe(sub *PriceSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[sub] {
+		delete(b.subscribers, sub)
+		close(sub.updates)
+	}
+}
+
+// Publish sends each subscriber the prices matching its filter.
+// Subscribers that fall behind miss the update rather than blocking the broker.
+func (b *PriceBroker) Publish(prices []types.TokenPrice) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		matched := sub.filter(prices)
+		if len(matched) == 0 {
+			continue
+		}
+
+		select {
+		case sub.updates <- matched:
+		default:
+			log.Printf("Price feed subscriber is behind, dropping update of %d prices", len(matched))
+		}
+	}
+}
+
+// add adds symbols to the subscription filter
+func (s *PriceSubscription) add(symbols []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, symbol := range symbols {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			s.symbols[symbol] = true
+		}
+	}
+}
+
+// remove removes symbols from the subscription filter
+func (s *PriceSubscription) remove(symbols []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, symbol := range symbols {
+		delete(s.symbols, strings.ToUpper(strings.TrimSpace(symbol)))
+	}
+}
+
+// filter returns the prices the subscription is interested in
+func (s *PriceSubscription) filter(prices []types.TokenPrice) []types.TokenPrice {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.symbols) == 0 {
+		return prices
+	}
+
+	var matched []types.TokenPrice
+	for _, price := range prices {
+		if s.symbols[strings.ToUpper(price.Symbol)] {
+			matched = append(matched, price)
+		}
+	}
+	return matched
+}
+
+// PriceUpdateListener announces prices saved by the price worker
+type PriceUpdateListener interface {
+	// ListenForPriceUpdates calls notify for each saved batch until ctx is done or listening fails
+	ListenForPriceUpdates(ctx context.Context, notify func()) error
+}
+
+// StartPriceFeed publishes prices as the price worker saves them. With a price store that announces
+// saved prices, each ScheduledPriceUpdateWorkflow run is pushed as soon as it is committed; the
+// feed reconnects every interval while the database is unreachable. Without one, the feed polls
+// the latest prices every interval and publishes the ones with newer LastUpdated timestamps.
+func (s *Server) StartPriceFeed(ctx context.Context, interval time.Duration) {
+	go func() {
+		lastSeen := make(map[string]time.Time)
+		publish := func() {
+			if resp, err := s.latestPrices(ctx); err == nil {
+				if changed := changedPrices(resp.Prices, lastSeen); len(changed) > 0 {
+					s.priceBroker.Publish(changed)
+					s.checkPriceAlerts(ctx, changed)
+				}
+			}
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		listener, listening := s.priceStore.(PriceUpdateListener)
+		for {
+			// Catch up on anything saved before listening started
+			publish()
+			if listening {
+				if err := listener.ListenForPriceUpdates(ctx, publish); err != nil && ctx.Err() == nil {
+					log.Printf("Price update notifications interrupted, reconnecting: %v", err)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// changedPrices returns the prices updated since they were last seen, recording them as seen
+func changedPrices(prices []types.TokenPrice, lastSeen map[string]time.Time) []types.TokenPrice {
+	var changed []types.TokenPrice
+	for _, price := range prices {
+		key := types.GetPriceKey(price.Symbol, price.ChainID)
+		if seen, ok := lastSeen[key]; ok && !price.LastUpdated.After(seen) {
+			continue
+		}
+		lastSeen[key] = price.LastUpdated
+		changed = append(changed, price)
+	}
+	return changed
+}
+
+// priceFeedHandler streams price updates over a WebSocket.
+// Clients pick symbols with ?symbols=ETH,SOL and can change them later by sending PriceFeedCommands.
+func (s *Server) priceFeedHandler(w http.ResponseWriter, r *http.Request) {
+	var symbols []string
+	if raw := r.URL.Query().Get("symbols"); raw != "" {
+		symbols = strings.Split(raw, ",")
+	}
+
+	server := websocket.Server{
+		Handshake: s.checkFeedOrigin,
+		Handler: func(ws *websocket.Conn) {
+			s.servePriceFeed(ws, symbols)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkFeedOrigin accepts WebSocket connections from the configured CORS origin
+func (s *Server) checkFeedOrigin(config *websocket.Config, r *http.Request) error {
+	allowed := s.config().Server.CORSAllowOrigin
+	if allowed == "" || allowed == "*" || r.Header.Get("Origin") == allowed {
+		return nil
+	}
+	return websocket.ErrBadWebSocketOrigin
+}
+
+// servePriceFeed sends a snapshot, then pushes updates until the client disconnects
+func (s *Server) servePriceFeed(ws *websocket.Conn, symbols []string) {
+	defer ws.Close()
+
+	// Feed connections outlive the HTTP server's request timeouts
+	ws.SetDeadline(time.Time{})
+
+	sub := s.priceBroker.Subscribe(symbols)
+	defer s.priceBroker.Unsubscribe(sub)
+
+	if resp, err := s.latestPrices(ws.Request().Context()); err == nil {
+		if err := sendFeedMessage(ws, PriceFeedMessage{Type: "snapshot", Prices: sub.filter(resp.Prices), Timestamp: time.Now().UTC()}); err != nil {
+			return
+		}
+	}
+
+	// Read subscription changes until the client goes away
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	commands := make(chan PriceFeedCommand)
+	go func() {
+		defer close(done)
+		for {
+			var cmd PriceFeedCommand
+			if err := websocket.JSON.Receive(ws, &cmd); err != nil {
+				return
+			}
+			select {
+			case commands <- cmd:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		case cmd := <-commands:
+			switch cmd.Action {
+			case "subscribe":
+				sub.add(cmd.Symbols)
+			case "unsubscribe":
+				sub.remove(cmd.Symbols)
+			default:
+				if err := sendFeedMessage(ws, PriceFeedMessage{Type: "error", Error: "unknown action: " + cmd.Action, Timestamp: time.Now().UTC()}); err != nil {
+					return
+				}
+			}
+		case prices, ok := <-sub.updates:
+			if !ok {
+				return
+			}
+			if err := sendFeedMessage(ws, PriceFeedMessage{Type: "update", Prices: prices, Timestamp: time.Now().UTC()}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendFeedMessage writes a message to a feed client
+func sendFeedMessage(ws *websocket.Conn, msg PriceFeedMessage) error {
+	ws.SetWriteDeadline(time.Now().Add(priceFeedWriteTimeout))
+	return websocket.JSON.Send(ws, msg)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// receiveFeedMessage reads the next feed message, failing the test after a timeout
+func receiveFeedMessage(t *testing.T, ws *websocket.Conn) PriceFeedMessage {
+	t.Helper()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg PriceFeedMessage
+	require.NoError(t, websocket.JSON.Receive(ws, &msg))
+	return msg
+}
+
+func TestPriceFeed(t *testing.T) {
+	s := newTestServer(t)
+	s.priceCacheDir = ""
+	s.SetPriceStore(&fakePriceStore{
+		prices: []types.TokenPrice{
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 3000},
+			{Symbol: "SOL", ChainID: 999, PriceUSD: 150},
+		},
+	})
+
+	httpServer := httptest.NewServer(s)
+	defer httpServer.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/api/v1/prices/ws?symbols=eth"
+	ws, err := websocket.Dial(wsURL, "", httpServer.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	// The snapshot only holds subscribed symbols
+	msg := receiveFeedMessage(t, ws)
+	assert.Equal(t, "snapshot", msg.Type)
+	require.Len(t, msg.Prices, 1)
+	assert.Equal(t, "ETH", msg.Prices[0].Symbol)
+
+	// Updates for other symbols are filtered out
+	s.priceBroker.Publish([]types.TokenPrice{{Symbol: "SOL", ChainID: 999, PriceUSD: 151}})
+	s.priceBroker.Publish([]types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 3010}})
+	msg = receiveFeedMessage(t, ws)
+	assert.Equal(t, "update", msg.Type)
+	require.Len(t, msg.Prices, 1)
+	assert.Equal(t, 3010.0, msg.Prices[0].PriceUSD)
+
+	// Subscribing to SOL over the socket adds it to the filter
+	require.NoError(t, websocket.JSON.Send(ws, PriceFeedCommand{Action: "subscribe", Symbols: []string{"SOL"}}))
+	require.NoError(t, websocket.JSON.Send(ws, PriceFeedCommand{Action: "bogus"}))
+	msg = receiveFeedMessage(t, ws)
+	assert.Equal(t, "error", msg.Type)
+
+	s.priceBroker.Publish([]types.TokenPrice{{Symbol: "SOL", ChainID: 999, PriceUSD: 152}})
+	msg = receiveFeedMessage(t, ws)
+	require.Len(t, msg.Prices, 1)
+	assert.Equal(t, "SOL", msg.Prices[0].Symbol)
+}
+
+func TestChangedPrices(t *testing.T) {
+	now := time.Now()
+	lastSeen := make(map[string]time.Time)
+	prices := []types.TokenPrice{
+		{Symbol: "ETH", ChainID: 1, LastUpdated: now},
+		{Symbol: "SOL", ChainID: 999, LastUpdated: now},
+	}
+
+	assert.Len(t, changedPrices(prices, lastSeen), 2)
+	assert.Empty(t, changedPrices(prices, lastSeen))
+
+	prices[1].LastUpdated = now.Add(time.Minute)
+	changed := changedPrices(prices, lastSeen)
+	require.Len(t, changed, 1)
+	assert.Equal(t, "SOL", changed[0].Symbol)
+}
+
+// notifyingPriceStore announces saved prices like the price repository does
+type notifyingPriceStore struct {
+	fakePriceStore
+	saved chan []types.TokenPrice
+	mu    sync.Mutex
+}
+
+func (f *notifyingPriceStore) GetLatestTokenPrices(ctx context.Context) ([]types.TokenPrice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.prices, nil
+}
+
+func (f *notifyingPriceStore) ListenForPriceUpdates(ctx context.Context, notify func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case prices := <-f.saved:
+			f.mu.Lock()
+			f.prices = prices
+			f.mu.Unlock()
+			notify()
+		}
+	}
+}
+
+func TestPriceFeedPushesSavedPrices(t *testing.T) {
+	s := newTestServer(t)
+	s.priceCacheDir = ""
+	now := time.Now()
+	store := &notifyingPriceStore{
+		fakePriceStore: fakePriceStore{prices: []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, LastUpdated: now}}},
+		saved:          make(chan []types.TokenPrice),
+	}
+	s.SetPriceStore(store)
+
+	sub := s.priceBroker.Subscribe(nil)
+	defer s.priceBroker.Unsubscribe(sub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The interval is far longer than the test, so only the notification can publish the update
+	s.StartPriceFeed(ctx, time.Hour)
+
+	receive := func() []types.TokenPrice {
+		select {
+		case prices := <-sub.updates:
+			return prices
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a price update")
+			return nil
+		}
+	}
+
+	initial := receive()
+	require.Len(t, initial, 1)
+	assert.Equal(t, 3000.0, initial[0].PriceUSD)
+
+	store.saved <- []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 3010, LastUpdated: now.Add(time.Minute)}}
+	updated := receive()
+	require.Len(t, updated, 1)
+	assert.Equal(t, 3010.0, updated[0].PriceUSD)
+}
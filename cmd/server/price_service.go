@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_workflows "github.com/infinity-dex/temporal/workflows"
+)
+
+// livePriceTimeout bounds how long a quote waits for the price worker to fetch a price no cache has
+const livePriceTimeout = 20 * time.Second
+
+// newPriceService creates the price service quotes and valuations read prices through: memory, then Redis when
+// configured, then the price database once SetPriceStore is called, then the price worker's cache file, and last a
+// live fetch by the price worker
+func (s *Server) newPriceService(cfg temporal_config.PricesConfig) *services.PriceService {
+	var fallbacks []services.OraclePriceLookup
+	if s.priceCacheDir != "" {
+		fallbacks = append(fallbacks, temporal_activities.NewPriceCacheLookup(s.priceCacheDir))
+	}
+	if cfg.LiveFetch && s.temporalClient != nil {
+		fallbacks = append(fallbacks, workflowPriceLookup{server: s})
+	}
+
+	prices := services.NewPriceService(cfg.MemoryCacheSize, cfg.MemoryCacheTTL, fallbacks...)
+	if cfg.RedisURL != "" {
+		cache, err := services.NewRedisPriceCache(cfg.RedisURL)
+		if err != nil {
+			log.Printf("Shared price cache disabled: %v", err)
+		} else {
+			prices.SetSharedCache(cache, cfg.RedisTTL)
+			s.readiness[redisDependency] = pingCheck(cache.Ping)
+		}
+	}
+	return prices
+}
+
+// workflowPriceLookup fetches a token's price from the price sources by running the price oracle workflow for it
+type workflowPriceLookup struct {
+	server *Server
+}
+
+// OraclePrice runs the price oracle workflow for a token and picks its price from the result. Lookups of a token
+// running at the same time, from this server or another, share one workflow run.
+func (l workflowPriceLookup) OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error) {
+	ctx, cancel := context.WithTimeout(ctx, livePriceTimeout)
+	defer cancel()
+
+	options, _ := l.server.workflowOptions("price-lookup-"+strings.ToUpper(symbol), PriceOracleTaskQueue)
+	options.WorkflowRunTimeout = livePriceTimeout
+	retry := l.server.config().Retries.PriceFetch.Policy()
+	request := types.PriceFetchRequest{Symbols: []string{symbol}, Retry: &retry}
+	run, err := l.server.temporalClient.ExecuteWorkflow(ctx, options, temporal_workflows.PriceOracleWorkflow, request)
+	if err != nil {
+		return types.TokenPrice{}, fmt.Errorf("failed to start price lookup of %s: %w", symbol, err)
+	}
+
+	var result types.PriceFetchResult
+	if err := run.Get(ctx, &result); err != nil {
+		return types.TokenPrice{}, fmt.Errorf("price lookup of %s failed: %w", symbol, err)
+	}
+	price, ok := services.BestTokenPrice(result.Prices, symbol, chainID)
+	if !ok {
+		return types.TokenPrice{}, fmt.Errorf("no price for %s", symbol)
+	}
+	return price, nil
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+)
+
+const (
+	// priceSourceDatabase marks prices read from the price database
+	priceSourceDatabase = "database"
+
+	// priceSourceCache marks prices read from the price worker's cache file
+	priceSourceCache = "cache"
+
+	// defaultHistoryWindow is the history range returned when no from/to is given
+	defaultHistoryWindow = 24 * time.Hour
+
+	// defaultAverageWindow is the TWAP window used when none is given
+	defaultAverageWindow = "1h"
+
+	// defaultCandleInterval is the candle interval used when none is given
+	defaultCandleInterval = "1h"
+
+	// maxCandles caps how many candles one request can ask for
+	maxCandles = 1500
+
+	// priceRetryAfter is the Retry-After hint, in seconds, sent while the price database is down
+	priceRetryAfter = "30"
+
+	// ndjsonContentType is the content type of responses streamed as one JSON value per line
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// PriceStore provides the latest and historical token prices
+type PriceStore interface {
+	GetLatestTokenPrices(ctx context.Context) ([]types.TokenPrice, error)
+	GetTokenPriceHistory(ctx context.Context, symbol string, chainID int64, startTime, endTime time.Time) ([]types.TokenPriceHistory, error)
+	GetTokenPriceAverage(ctx context.Context, symbol string, chainID int64, start, end time.Time) (*types.PriceAverage, error)
+	GetCandles(ctx context.Context, symbol string, chainID int64, interval string, startTime, endTime time.Time) ([]types.Candle, error)
+}
+
+// PricesResponse is returned by the price endpoints
+type PricesResponse struct {
+	Prices      []types.TokenPrice `json:"prices"`
+	Source      string             `json:"source"` // database or cache
+	LastUpdated time.Time          `json:"lastUpdated"`
+	Stale       bool               `json:"stale,omitempty"` // Served from the cache while the database is unavailable
+}
+
+// PriceHistoryResponse is returned by the price history endpoint
+type PriceHistoryResponse struct {
+	Symbol   string                    `json:"symbol"`
+	ChainID  int64                     `json:"chainId"`
+	From     time.Time                 `json:"from"`
+	To       time.Time                 `json:"to"`
+	Interval string                    `json:"interval,omitempty"`
+	History  []types.TokenPriceHistory `json:"history"`
+}
+
+// CandlesResponse is returned by the candles endpoint
+type CandlesResponse struct {
+	Symbol   string         `json:"symbol"`
+	ChainID  int64          `json:"chainId"`
+	Interval string         `json:"interval"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Candles  []types.Candle `json:"candles"`
+}
+
+// SetPriceStore sets the store backing the price endpoints and quotes; without one prices are read from the cache
+func (s *Server) SetPriceStore(store PriceStore) {
+	s.priceStore = store
+	s.prices.SetStore(store)
+}
+
+// listPricesHandler returns the latest token prices, optionally filtered by chainId and symbols
+func (s *Server) listPricesHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.latestPrices(r.Context())
+	if err != nil {
+		errorResponse(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	chainID, err := parseChainIDParam(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var symbols []string
+	if raw := r.URL.Query().Get("symbols"); raw != "" {
+		symbols = strings.Split(raw, ",")
+	}
+
+	resp.Prices = filterPrices(resp.Prices, symbols, chainID)
+	if acceptsNDJSON(r) {
+		streamPrices(w, resp)
+		return
+	}
+	writePrices(w, resp)
+}
+
+// getPriceHandler returns the latest prices of a symbol on every chain it trades on
+func (s *Server) getPriceHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.latestPrices(r.Context())
+	if err != nil {
+		errorResponse(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	chainID, err := parseChainIDParam(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	symbol := r.PathValue("symbol")
+	resp.Prices = filterPrices(resp.Prices, []string{symbol}, chainID)
+	if len(resp.Prices) == 0 {
+		codedErrorResponse(w, ErrorCodeTokenNotFound, fmt.Sprintf("no price for %s", symbol))
+		return
+	}
+
+	writePrices(w, resp)
+}
+
+// priceHistoryHandler returns the price history of a symbol between from and to,
+// downsampled to one point per interval when an interval is given
+func (s *Server) priceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if s.priceStore == nil {
+		w.Header().Set("Retry-After", priceRetryAfter)
+		errorResponse(w, http.StatusServiceUnavailable, "price history requires the price database")
+		return
+	}
+
+	query := r.URL.Query()
+	symbol := r.PathValue("symbol")
+
+	to := time.Now().UTC()
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := parseTimeParam(raw)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid to: %v", err))
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultHistoryWindow)
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := parseTimeParam(raw)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid from: %v", err))
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		errorResponse(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	var interval time.Duration
+	if raw := query.Get("interval"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid interval: %q", raw))
+			return
+		}
+		interval = parsed
+	}
+
+	chainID, err := parseChainIDParam(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	symbol, chainID = s.resolvePriceSymbol(r.Context(), symbol, chainID)
+	if chainID == 0 {
+		codedErrorResponse(w, ErrorCodeTokenNotFound, fmt.Sprintf("no price for %s; pass chainId", symbol))
+		return
+	}
+
+	history, err := s.priceStore.GetTokenPriceHistory(r.Context(), symbol, chainID, from, to)
+	if err != nil {
+		// History only lives in the database, so there is no cache to fall back to
+		log.Printf("Failed to load price history: %v", err)
+		w.Header().Set("Retry-After", priceRetryAfter)
+		errorResponse(w, http.StatusServiceUnavailable, "price history temporarily unavailable")
+		return
+	}
+
+	// Oldest first reads naturally on a chart
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp)
+	})
+	if interval > 0 {
+		history = downsampleHistory(history, interval)
+	}
+	writeJSONStream(w, http.StatusOK, PriceHistoryResponse{
+		Symbol:   symbol,
+		ChainID:  chainID,
+		From:     from,
+		To:       to,
+		Interval: query.Get("interval"),
+	}, "history", history)
+}
+
+// priceTWAPHandler returns the time- and volume-weighted average price of a symbol over a window
+func (s *Server) priceTWAPHandler(w http.ResponseWriter, r *http.Request) {
+	if s.priceStore == nil {
+		w.Header().Set("Retry-After", priceRetryAfter)
+		errorResponse(w, http.StatusServiceUnavailable, "average prices require the price database")
+		return
+	}
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = defaultAverageWindow
+	}
+	if _, err := services.ParsePriceWindow(window); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	chainID, err := parseChainIDParam(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	symbol, chainID := s.resolvePriceSymbol(r.Context(), r.PathValue("symbol"), chainID)
+	if chainID == 0 {
+		codedErrorResponse(w, ErrorCodeTokenNotFound, fmt.Sprintf("no price for %s; pass chainId", symbol))
+		return
+	}
+
+	avg, err := services.NewPriceAnalyticsService(s.priceStore).GetAveragePrice(r.Context(), symbol, chainID, window)
+	if errors.Is(err, services.ErrNoPriceHistory) {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to compute average price: %v", err)
+		w.Header().Set("Retry-After", priceRetryAfter)
+		errorResponse(w, http.StatusServiceUnavailable, "average prices temporarily unavailable")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, avg)
+}
+
+// priceCandlesHandler returns the OHLCV candles of a symbol between from and to, for charting
+func (s *Server) priceCandlesHandler(w http.ResponseWriter, r *http.Request) {
+	if s.priceStore == nil {
+		w.Header().Set("Retry-After", priceRetryAfter)
+		errorResponse(w, http.StatusServiceUnavailable, "candles require the price database")
+		return
+	}
+
+	query := r.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = defaultCandleInterval
+	}
+	d, ok := types.CandleIntervals[interval]
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid interval %q; use 1m, 5m, 1h or 1d", interval))
+		return
+	}
+
+	to := time.Now().UTC()
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := parseTimeParam(raw)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid to: %v", err))
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultHistoryWindow)
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := parseTimeParam(raw)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid from: %v", err))
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		errorResponse(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if to.Sub(from)/d > maxCandles {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("range covers more than %d %s candles; use a longer interval", maxCandles, interval))
+		return
+	}
+
+	chainID, err := parseChainIDParam(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	symbol, chainID := s.resolvePriceSymbol(r.Context(), r.PathValue("symbol"), chainID)
+	if chainID == 0 {
+		codedErrorResponse(w, ErrorCodeTokenNotFound, fmt.Sprintf("no price for %s; pass chainId", symbol))
+		return
+	}
+
+	// Include the candle in progress at from
+	candles, err := s.priceStore.GetCandles(r.Context(), symbol, chainID, interval, from.Truncate(d), to)
+	if err != nil {
+		log.Printf("Failed to load candles: %v", err)
+		w.Header().Set("Retry-After", priceRetryAfter)
+		errorResponse(w, http.StatusServiceUnavailable, "candles temporarily unavailable")
+		return
+	}
+	if candles == nil {
+		candles = []types.Candle{}
+	}
+
+	writeJSON(w, http.StatusOK, CandlesResponse{
+		Symbol:   symbol,
+		ChainID:  chainID,
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Candles:  candles,
+	})
+}
+
+// resolvePriceSymbol resolves the stored symbol casing, and the chain when none is given, from the latest prices.
+// The chain stays 0 when it cannot be resolved.
+func (s *Server) resolvePriceSymbol(ctx context.Context, symbol string, chainID int64) (string, int64) {
+	if latest, err := s.latestPrices(ctx); err == nil {
+		if prices := filterPrices(latest.Prices, []string{symbol}, chainID); len(prices) > 0 {
+			return prices[0].Symbol, prices[0].ChainID
+		}
+	}
+	return symbol, chainID
+}
+
+// latestPrices reads the latest prices from the database, falling back to the price cache
+func (s *Server) latestPrices(ctx context.Context) (PricesResponse, error) {
+	if s.priceStore != nil {
+		prices, err := s.priceStore.GetLatestTokenPrices(ctx)
+		if err == nil {
+			return PricesResponse{Prices: prices, Source: priceSourceDatabase, LastUpdated: newestUpdate(prices)}, nil
+		}
+		log.Printf("Failed to load prices from database, falling back to cache: %v", err)
+	}
+
+	if s.priceCacheDir == "" {
+		return PricesResponse{}, errors.New("no price data available")
+	}
+
+	cache, err := temporal_activities.ReadPriceCache(s.priceCacheDir)
+	if err != nil {
+		return PricesResponse{}, fmt.Errorf("no price data available: %w", err)
+	}
+
+	prices := make([]types.TokenPrice, 0, len(cache.Prices))
+	for _, price := range cache.Prices {
+		prices = append(prices, price)
+	}
+
+	return PricesResponse{Prices: prices, Source: priceSourceCache, LastUpdated: cache.LastUpdated, Stale: true}, nil
+}
+
+// writePrices writes a prices response with headers describing where the prices came from and how old they are
+func writePrices(w http.ResponseWriter, resp PricesResponse) {
+	setPriceHeaders(w, resp)
+	prices := resp.Prices
+	resp.Prices = nil
+	writeJSONStream(w, http.StatusOK, resp, "prices", prices)
+}
+
+// streamPrices writes the prices of a response as newline-delimited JSON, one price per line, flushing after each
+// so clients can process prices as they arrive. The response's source and age are sent in headers only.
+func streamPrices(w http.ResponseWriter, resp PricesResponse) {
+	setPriceHeaders(w, resp)
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for _, price := range resp.Prices {
+		if err := encoder.Encode(price); err != nil {
+			log.Printf("Error streaming prices: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// acceptsNDJSON reports whether the client asked for a newline-delimited JSON stream
+func acceptsNDJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), ndjsonContentType)
+}
+
+// setPriceHeaders sets the headers describing where a response's prices came from and how old they are
+func setPriceHeaders(w http.ResponseWriter, resp PricesResponse) {
+	w.Header().Set("X-Price-Source", resp.Source)
+	if !resp.LastUpdated.IsZero() {
+		age := time.Since(resp.LastUpdated)
+		if age < 0 {
+			age = 0
+		}
+		w.Header().Set("Age", strconv.FormatInt(int64(age.Seconds()), 10))
+	}
+	if resp.Stale {
+		w.Header().Set("X-Price-Stale", "true")
+	}
+}
+
+// filterPrices keeps the prices matching symbols (case-insensitive) and chainID, sorted by symbol and chain.
+// Empty symbols or a zero chainID match everything.
+func filterPrices(prices []types.TokenPrice, symbols []string, chainID int64) []types.TokenPrice {
+	result := make([]types.TokenPrice, 0, len(prices))
+	for _, price := range prices {
+		if chainID != 0 && price.ChainID != chainID {
+			continue
+		}
+		if len(symbols) > 0 {
+			found := false
+			for _, symbol := range symbols {
+				if strings.EqualFold(price.Symbol, strings.TrimSpace(symbol)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		result = append(result, price)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Symbol != result[j].Symbol {
+			return result[i].Symbol < result[j].Symbol
+		}
+		return result[i].ChainID < result[j].ChainID
+	})
+
+	return result
+}
+
+// downsampleHistory keeps the last point of each interval bucket; history must be sorted oldest first
+func downsampleHistory(history []types.TokenPriceHistory, interval time.Duration) []types.TokenPriceHistory {
+	var result []types.TokenPriceHistory
+	for _, h := range history {
+		bucket := h.Timestamp.Truncate(interval)
+		if n := len(result); n > 0 && result[n-1].Timestamp.Truncate(interval).Equal(bucket) {
+			result[n-1] = h
+			continue
+		}
+		result = append(result, h)
+	}
+	return result
+}
+
+// newestUpdate returns the most recent LastUpdated of prices
+func newestUpdate(prices []types.TokenPrice) time.Time {
+	var newest time.Time
+	for _, price := range prices {
+		if price.LastUpdated.After(newest) {
+			newest = price.LastUpdated
+		}
+	}
+	return newest
+}
+
+// parseChainIDParam parses the optional chainId query parameter, returning 0 when absent.
+// Numeric and CAIP-2 IDs are accepted and resolved to the canonical chain ID.
+func parseChainIDParam(r *http.Request) (int64, error) {
+	raw := r.URL.Query().Get("chainId")
+	if raw == "" {
+		return 0, nil
+	}
+
+	chainID, err := types.ParseChainID(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chainId: %q", raw)
+	}
+	return chainID, nil
+}
+
+// parseTimeParam parses an RFC 3339 timestamp or Unix seconds
+func parseTimeParam(raw string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
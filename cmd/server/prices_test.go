@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePriceStore is an in-memory PriceStore
+type fakePriceStore struct {
+	prices  []types.TokenPrice
+	history []types.TokenPriceHistory
+	candles []types.Candle
+	err     error
+}
+
+func (f *fakePriceStore) GetLatestTokenPrices(ctx context.Context) ([]types.TokenPrice, error) {
+	return f.prices, f.err
+}
+
+func (f *fakePriceStore) GetTokenPriceHistory(ctx context.Context, symbol string, chainID int64, startTime, endTime time.Time) ([]types.TokenPriceHistory, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []types.TokenPriceHistory
+	for _, h := range f.history {
+		if h.Symbol == symbol && h.ChainID == chainID && !h.Timestamp.Before(startTime) && !h.Timestamp.After(endTime) {
+			result = append(result, h)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakePriceStore) GetTokenPriceAverage(ctx context.Context, symbol string, chainID int64, start, end time.Time) (*types.PriceAverage, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var samples []types.TokenPriceHistory
+	var prior *types.TokenPriceHistory
+	for i, h := range f.history {
+		if h.Symbol != symbol || h.ChainID != chainID {
+			continue
+		}
+		if !h.Timestamp.Before(start) {
+			samples = append(samples, h)
+		} else if prior == nil || h.Timestamp.After(prior.Timestamp) {
+			prior = &f.history[i]
+		}
+	}
+	avg, ok := services.AveragePrices(samples, prior, start, end)
+	if !ok {
+		return nil, nil
+	}
+	return &avg, nil
+}
+
+func (f *fakePriceStore) GetCandles(ctx context.Context, symbol string, chainID int64, interval string, startTime, endTime time.Time) ([]types.Candle, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []types.Candle
+	for _, c := range f.candles {
+		if c.Symbol == symbol && c.ChainID == chainID && c.Interval == interval && !c.Start.Before(startTime) && !c.Start.After(endTime) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func TestPriceEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	s.priceCacheDir = ""
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	store := &fakePriceStore{
+		prices: []types.TokenPrice{
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, Source: types.PriceSourceCoinGecko, LastUpdated: now},
+			{Symbol: "ETH", ChainID: 10, PriceUSD: 3001, Source: types.PriceSourceUniversal, LastUpdated: now.Add(-time.Minute)},
+			{Symbol: "SOL", ChainID: 999, PriceUSD: 150, Source: types.PriceSourceJupiter, LastUpdated: now},
+		},
+		history: []types.TokenPriceHistory{
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 2990, Timestamp: now.Add(-50 * time.Minute)},
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 2995, Timestamp: now.Add(-40 * time.Minute)},
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, Timestamp: now.Add(-10 * time.Minute)},
+		},
+		candles: []types.Candle{
+			{Symbol: "ETH", ChainID: 1, Interval: "1h", Start: now.Add(-3 * time.Hour), Open: 2900, High: 2950, Low: 2890, Close: 2940, Samples: 240},
+			{Symbol: "ETH", ChainID: 1, Interval: "1h", Start: now.Add(-time.Hour), Open: 2990, High: 3005, Low: 2985, Close: 3000, Samples: 240},
+		},
+	}
+
+	// Without a store or cache there is nothing to serve
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/prices", nil, "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	s.SetPriceStore(store)
+
+	t.Run("List", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/prices?chainId=1", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp PricesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, priceSourceDatabase, resp.Source)
+		assert.False(t, resp.Stale)
+		assert.Empty(t, rec.Header().Get("X-Price-Stale"))
+		require.Len(t, resp.Prices, 1)
+		assert.Equal(t, types.PriceSourceCoinGecko, resp.Prices[0].Source)
+		assert.True(t, resp.LastUpdated.Equal(now))
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/prices", nil)
+		req.Header.Set("Accept", ndjsonContentType)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, ndjsonContentType, rec.Header().Get("Content-Type"))
+		assert.Equal(t, priceSourceDatabase, rec.Header().Get("X-Price-Source"))
+		assert.True(t, rec.Flushed)
+
+		// One price per line
+		assert.Equal(t, 3, strings.Count(rec.Body.String(), "\n"))
+		decoder := json.NewDecoder(rec.Body)
+		var symbols []string
+		for decoder.More() {
+			var price types.TokenPrice
+			require.NoError(t, decoder.Decode(&price))
+			symbols = append(symbols, price.Symbol)
+		}
+		assert.Equal(t, []string{"ETH", "ETH", "SOL"}, symbols)
+	})
+
+	t.Run("Symbol", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/prices/eth", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp PricesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Len(t, resp.Prices, 2)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/prices/DOGE", nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		path := "/api/v1/prices/eth/history?chainId=1&interval=30m&from=" + now.Add(-2*time.Hour).Format(time.RFC3339)
+		rec := doRequest(t, s, http.MethodGet, path, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp PriceHistoryResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "ETH", resp.Symbol)
+
+		// The two points in the first half hour collapse into the later one
+		require.Len(t, resp.History, 2)
+		assert.Equal(t, 2995.0, resp.History[0].PriceUSD)
+		assert.Equal(t, 3000.0, resp.History[1].PriceUSD)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/prices/ETH/history?interval=bogus", nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("TWAP", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/prices/eth/twap?window=1h", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp types.PriceAverage
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "ETH", resp.Symbol)
+		assert.Equal(t, int64(1), resp.ChainID)
+		assert.Equal(t, "1h", resp.Window)
+		assert.GreaterOrEqual(t, resp.TWAP, 2990.0)
+		assert.LessOrEqual(t, resp.TWAP, 3000.0)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/prices/ETH/twap?window=7d", nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/prices/SOL/twap?window=5m", nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Candles", func(t *testing.T) {
+		path := "/api/v1/prices/eth/candles?interval=1h&from=" + now.Add(-90*time.Minute).Format(time.RFC3339)
+		rec := doRequest(t, s, http.MethodGet, path, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp CandlesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "ETH", resp.Symbol)
+		assert.Equal(t, "1h", resp.Interval)
+
+		// The candle in progress at from is included
+		require.Len(t, resp.Candles, 1)
+		assert.Equal(t, 3000.0, resp.Candles[0].Close)
+
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/prices/ETH/candles?interval=2h", nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		// Too many candles for one request
+		path = "/api/v1/prices/ETH/candles?interval=1m&from=" + now.Add(-48*time.Hour).Format(time.RFC3339)
+		rec = doRequest(t, s, http.MethodGet, path, nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("CacheFallback", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		cache := types.PriceCache{
+			Prices: map[string]types.TokenPrice{
+				types.GetPriceKey("ETH", 1): {Symbol: "ETH", ChainID: 1, PriceUSD: 2999, Source: types.PriceSourceFallback},
+			},
+			LastUpdated: now,
+		}
+		data, err := json.Marshal(cache)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, temporal_activities.PriceCacheFile), data, 0644))
+
+		s.priceCacheDir = cacheDir
+		store.err = errors.New("database down")
+		defer func() { store.err = nil }()
+
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/prices", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		assert.Equal(t, "true", rec.Header().Get("X-Price-Stale"))
+		assert.Equal(t, priceSourceCache, rec.Header().Get("X-Price-Source"))
+		assert.NotEmpty(t, rec.Header().Get("Age"))
+
+		var resp PricesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, priceSourceCache, resp.Source)
+		assert.True(t, resp.Stale)
+		require.Len(t, resp.Prices, 1)
+		assert.Equal(t, 2999.0, resp.Prices[0].PriceUSD)
+
+		// History has no cache to fall back to
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/prices/ETH/history?chainId=1", nil, "")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, priceRetryAfter, rec.Header().Get("Retry-After"))
+	})
+}
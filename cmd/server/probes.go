@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/client"
+)
+
+// Dependencies the readiness probe checks
+const (
+	temporalDependency     = "temporal"
+	databaseDependency     = "database"
+	redisDependency        = "redis"
+	priceSourcesDependency = "priceSources"
+)
+
+// Statuses of the readiness probe and of each dependency it checks
+const (
+	readinessReady    = "ready"
+	readinessNotReady = "not_ready"
+
+	dependencyOK       = "ok"
+	dependencyFailing  = "failing"
+	dependencyDisabled = "disabled" // Not configured; the server runs without it
+)
+
+// dependencyCheck checks a dependency is reachable, returning a detail to report with its status
+type dependencyCheck func(ctx context.Context) (string, error)
+
+// DatabasePinger is the database pool the readiness probe pings, such as a *pgxpool.Pool
+type DatabasePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DependencyStatus is the result of checking one dependency
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latencyMs"`
+}
+
+// ReadinessResponse is returned by the readiness probe
+type ReadinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	Time         time.Time                   `json:"time"`
+}
+
+// newReadinessChecks returns the dependency checks of a server talking to temporalClient, which may be nil, and
+// fetching prices from the default price sources. The database and Redis are disabled until they are set.
+func newReadinessChecks(cfg temporal_config.Config, sdk universalsdk.SDK, temporalClient client.Client) map[string]dependencyCheck {
+	checks := map[string]dependencyCheck{
+		temporalDependency: nil,
+		databaseDependency: nil,
+		redisDependency:    nil,
+	}
+	if temporalClient != nil {
+		checks[temporalDependency] = func(ctx context.Context) (string, error) {
+			_, err := temporalClient.CheckHealth(ctx, &client.CheckHealthRequest{})
+			return cfg.Region.Namespace, err
+		}
+	}
+
+	// Price sources are pinged the way the price worker calls them, through its proxy and at its mirrors
+	httpClient := &http.Client{Timeout: cfg.Server.ReadinessTimeout}
+	if outbound, err := temporal_activities.NewOutboundTransport(temporal_activities.OutboundOptionsFromConfig(cfg)); err != nil {
+		log.Printf("Invalid outbound HTTP config, pinging price sources directly: %v", err)
+	} else {
+		httpClient = outbound.Client(cfg.Server.ReadinessTimeout)
+	}
+	sources := temporal_activities.DefaultPriceSources(sdk, httpClient)
+	if source, ok := sources.Get(types.PriceSourceCoinGecko); ok {
+		if err := source.(*temporal_activities.CoinGeckoPriceSource).SetAPIKey(cfg.Prices.CoinGeckoPlan, cfg.Prices.CoinGeckoAPIKey); err != nil {
+			log.Printf("Invalid CoinGecko config, pinging the public API: %v", err)
+		}
+	}
+	for _, mirror := range cfg.Prices.BaseURLs {
+		if err := sources.SetBaseURL(types.PriceSource(mirror.Source), mirror.URL); err != nil {
+			log.Printf("Ignoring price source base URL: %v", err)
+		}
+	}
+	checks[priceSourcesDependency] = cachedCheck(func(ctx context.Context) (string, error) {
+		source, err := sources.PingAny(ctx)
+		return string(source), err
+	}, cfg.Server.PriceSourceCheckInterval)
+	return checks
+}
+
+// pingCheck checks a dependency with its ping
+func pingCheck(ping func(ctx context.Context) error) dependencyCheck {
+	return func(ctx context.Context) (string, error) {
+		return "", ping(ctx)
+	}
+}
+
+// cachedCheck reuses the last result of check for interval, so probes don't spend a rate-limited dependency's quota.
+// Checks cut short by the probe's context are not reused.
+func cachedCheck(check dependencyCheck, interval time.Duration) dependencyCheck {
+	var mu sync.Mutex
+	var checkedAt time.Time
+	var detail string
+	var err error
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checkedAt.IsZero() && time.Since(checkedAt) < interval {
+			return detail, err
+		}
+		detail, err = check(ctx)
+		if ctx.Err() == nil {
+			checkedAt = time.Now()
+		}
+		return detail, err
+	}
+}
+
+// SetDatabase sets the database pool the readiness probe pings
+func (s *Server) SetDatabase(db DatabasePinger) {
+	s.readiness[databaseDependency] = pingCheck(db.Ping)
+}
+
+// readinessHandler checks every dependency at once and reports the server ready while every configured one is
+// reachable. Kubernetes takes the server out of its Service while it answers 503.
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	resp := ReadinessResponse{
+		Status:       readinessReady,
+		Dependencies: make(map[string]DependencyStatus, len(s.readiness)),
+		Time:         time.Now().UTC(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range s.readiness {
+		if check == nil {
+			mu.Lock()
+			resp.Dependencies[name] = DependencyStatus{Status: dependencyDisabled}
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), s.config().Server.ReadinessTimeout)
+			defer cancel()
+
+			started := time.Now()
+			detail, err := check(ctx)
+			status := DependencyStatus{Status: dependencyOK, Detail: detail, LatencyMS: time.Since(started).Milliseconds()}
+			if err != nil {
+				status.Status = dependencyFailing
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Dependencies[name] = status
+			if err != nil {
+				resp.Status = readinessNotReady
+			}
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if resp.Status != readinessReady {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, resp)
+}
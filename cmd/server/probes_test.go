@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDatabase is a DatabasePinger failing with err
+type stubDatabase struct {
+	err error
+}
+
+func (d *stubDatabase) Ping(ctx context.Context) error {
+	return d.err
+}
+
+func TestLivenessProbe(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodGet, "/livez", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestReadinessProbe(t *testing.T) {
+	s := newTestServer(t)
+	// Keep the probe off the real price APIs
+	s.readiness[priceSourcesDependency] = func(ctx context.Context) (string, error) {
+		return "coingecko", nil
+	}
+	db := &stubDatabase{}
+	s.SetDatabase(db)
+
+	readiness := func() (int, ReadinessResponse) {
+		rec := doRequest(t, s, http.MethodGet, "/readyz", nil, "")
+		var resp ReadinessResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return rec.Code, resp
+	}
+
+	code, resp := readiness()
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, readinessReady, resp.Status)
+	// The test server runs without Temporal or Redis, so they don't hold readiness back
+	assert.Equal(t, dependencyDisabled, resp.Dependencies[temporalDependency].Status)
+	assert.Equal(t, dependencyDisabled, resp.Dependencies[redisDependency].Status)
+	assert.Equal(t, dependencyOK, resp.Dependencies[databaseDependency].Status)
+	assert.Equal(t, dependencyOK, resp.Dependencies[priceSourcesDependency].Status)
+	assert.Equal(t, "coingecko", resp.Dependencies[priceSourcesDependency].Detail, "the source that answered is reported")
+
+	db.err = errors.New("connection refused")
+	code, resp = readiness()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, readinessNotReady, resp.Status)
+	assert.Equal(t, dependencyFailing, resp.Dependencies[databaseDependency].Status)
+	assert.Equal(t, "connection refused", resp.Dependencies[databaseDependency].Error)
+	assert.Equal(t, dependencyOK, resp.Dependencies[priceSourcesDependency].Status)
+}
+
+func TestReadinessProbeTimeout(t *testing.T) {
+	s := newTestServer(t)
+	s.config().Server.ReadinessTimeout = 10 * time.Millisecond
+	s.readiness[priceSourcesDependency] = func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	rec := doRequest(t, s, http.MethodGet, "/readyz", nil, "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestCachedCheck(t *testing.T) {
+	calls := 0
+	check := cachedCheck(func(ctx context.Context) (string, error) {
+		calls++
+		return "binance", nil
+	}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		detail, err := check(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "binance", detail)
+	}
+	assert.Equal(t, 1, calls, "the result is reused within the interval")
+
+	// A check cut short by its probe is retried by the next one
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	check = cachedCheck(func(ctx context.Context) (string, error) {
+		calls++
+		return "", ctx.Err()
+	}, time.Hour)
+	check(canceled)
+	check(context.Background())
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// quoteCleanupInterval is how often expired firm quotes are deleted
+const quoteCleanupInterval = time.Minute
+
+// newQuoteSigner creates the signer making quotes firm for SWAP.QUOTE_TTL. It signs with the attestation key, so
+// quotes verify against the same public key as attestations. Without a key or TTL it returns nil, disabling firm
+// quotes.
+func newQuoteSigner(cfg temporal_config.Config) *services.QuoteSigner {
+	seed, err := hex.DecodeString(cfg.Attestation.SigningKey)
+	if cfg.Swap.QuoteTTL <= 0 || err != nil || len(seed) != ed25519.SeedSize {
+		return nil
+	}
+	return services.NewQuoteSigner(ed25519.NewKeyFromSeed(seed), cfg.Swap.QuoteTTL)
+}
+
+// SetQuoteStore sets the store firm quotes are kept in until they expire, shared with the other API servers
+func (s *Server) SetQuoteStore(store services.QuoteStore) {
+	s.quotes = store
+}
+
+// firmQuote returns the firm quote a swap references by ID, checking it was signed by this server for the swap's API
+// key, has not been used or expired and was quoted for the swap's tokens and amount. A tranched swap is quoted again
+// tranche by tranche, so it can't execute at a firm quote.
+func (s *Server) firmQuote(r *http.Request, request types.SwapRequest, quoteID string) (*types.SwapQuote, *apiError) {
+	if s.quoteSigner == nil {
+		return nil, newAPIError(http.StatusBadRequest, "firm quotes are not enabled")
+	}
+	if request.Tranches != nil {
+		return nil, newAPIError(http.StatusBadRequest, "tranched swaps can't execute at a firm quote")
+	}
+	keyID, _, _ := s.meteredKey(r)
+	quote, err := s.quotes.GetQuote(r.Context(), quoteID, keyID)
+	if apiErr := quoteAPIError(quoteID, err); apiErr != nil {
+		return nil, apiErr
+	}
+	if err := services.VerifyQuote(quote, s.quoteSigner.PublicKey()); err != nil {
+		return nil, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := services.CheckFirmQuote(request, quote, time.Now()); err != nil {
+		return nil, serviceAPIError(http.StatusBadRequest, err)
+	}
+	return &quote, nil
+}
+
+// useFirmQuote marks the firm quote a swap executes at used, just before the swap starts, so no other swap can
+// execute at it
+func (s *Server) useFirmQuote(r *http.Request, request types.SwapRequest) *apiError {
+	if request.FirmQuote == nil {
+		return nil
+	}
+	keyID, _, _ := s.meteredKey(r)
+	return quoteAPIError(request.FirmQuote.QuoteID, s.quotes.UseQuote(r.Context(), request.FirmQuote.QuoteID, keyID))
+}
+
+// quoteAPIError returns the API error of a failure to get or use a quote, or nil
+func quoteAPIError(quoteID string, err error) *apiError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, types.ErrQuoteNotFound):
+		return codedAPIError(ErrorCodeQuoteNotFound, fmt.Sprintf("quote %s not found", quoteID))
+	case errors.Is(err, types.ErrQuoteUsed):
+		return codedAPIError(ErrorCodeConflict, fmt.Sprintf("quote %s has already been used", quoteID))
+	default:
+		return newAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to get quote: %v", err))
+	}
+}
+
+// StartQuoteCleanup deletes the expired firm quotes every interval until ctx is done
+func (s *Server) StartQuoteCleanup(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := s.quotes.DeleteExpiredQuotes(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to delete expired quotes: %v", err)
+			}
+		}
+	}()
+}
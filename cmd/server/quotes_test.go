@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirmQuoteSwaps(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var quoted SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&quoted))
+	require.NotNil(t, quoted.Quote)
+	assert.NotEmpty(t, quoted.Quote.QuoteID)
+	assert.NotEmpty(t, quoted.Quote.Signature)
+	assert.False(t, quoted.Quote.ExpiresAt.IsZero(), "firm quotes expire")
+
+	body := testSwapBody()
+	body.QuoteID = quoted.Quote.QuoteID
+
+	// A quote backs swaps from the key it was quoted to only
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, testSandboxKey)
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	assert.Equal(t, ErrorCodeQuoteNotFound, decodeError(t, rec).Code)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	// and backs one swap
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	assert.Equal(t, ErrorCodeConflict, decodeError(t, rec).Code)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&quoted))
+	body.QuoteID = quoted.Quote.QuoteID
+
+	// The swap must be the one quoted
+	larger := body
+	larger.Amount = "2000000000000000000"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", larger, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	unknown := body
+	unknown.QuoteID = "not-a-quote"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", unknown, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	assert.Equal(t, ErrorCodeQuoteNotFound, decodeError(t, rec).Code)
+
+	// Without an attestation key quotes aren't firm
+	s.quoteSigner = nil
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	// regionHeader tells clients and load balancers which region served a request, so they can keep a swap's
+	// follow-up calls in the region that started it
+	regionHeader = "X-Region"
+	// pollerStaleAfter is how long after its last poll a worker no longer counts as serving a task queue
+	pollerStaleAfter = 2 * time.Minute
+)
+
+// Workflow memo keys recording where a workflow was started and where it runs
+const (
+	memoOriginRegion = "originRegion"
+	memoRegion       = "region"
+)
+
+// queueHealthFunc reports whether workers are polling a task queue
+type queueHealthFunc func(ctx context.Context, taskQueue string) (bool, error)
+
+// regionRouter picks the region whose workers run new workflows. Workflows stay in this region, next to the
+// server that started them, and fail over in FAILOVER order while this region has no live swap workers.
+type regionRouter struct {
+	config  temporal_config.RegionConfig
+	health  queueHealthFunc // nil disables health checks, keeping every workflow in this region
+	healthy map[string]bool // map[region]swap workers polling; regions not checked yet count as healthy
+	mu      sync.RWMutex
+}
+
+// newRegionRouter creates a region router; health may be nil
+func newRegionRouter(cfg temporal_config.RegionConfig, health queueHealthFunc) *regionRouter {
+	return &regionRouter{
+		config:  cfg,
+		health:  health,
+		healthy: make(map[string]bool),
+	}
+}
+
+// Route returns the region to start a workflow in and that region's name for the task queue. Only swap
+// workers are health checked, so other task queues always stay in this region.
+func (r *regionRouter) Route(taskQueue string) (region, regionQueue string) {
+	region = r.config.ID
+	if taskQueue == SwapTaskQueue {
+		region = r.healthyRegion()
+	}
+	return region, temporal_config.RegionScoped(taskQueue, region)
+}
+
+// healthyRegion returns the first region in failover order whose swap workers are up, or this region when none are
+func (r *regionRouter) healthyRegion() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, region := range r.config.Regions() {
+		if healthy, checked := r.healthy[region]; !checked || healthy {
+			return region
+		}
+	}
+	return r.config.ID
+}
+
+// Memo returns the memo recording a workflow's origin region and the region it was routed to
+func (r *regionRouter) Memo(region string) map[string]interface{} {
+	if r.config.ID == "" && region == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		memoOriginRegion: r.config.ID,
+		memoRegion:       region,
+	}
+}
+
+// CheckHealth checks the swap workers of every region. A region whose check fails keeps its last state.
+func (r *regionRouter) CheckHealth(ctx context.Context) {
+	if r.health == nil {
+		return
+	}
+
+	for _, region := range r.config.Regions() {
+		healthy, err := r.health(ctx, temporal_config.RegionScoped(SwapTaskQueue, region))
+		if err != nil {
+			log.Printf("Failed to check swap workers in region %q: %v", region, err)
+			continue
+		}
+
+		r.mu.Lock()
+		if previous, checked := r.healthy[region]; checked && previous != healthy {
+			log.Printf("Swap workers in region %q healthy: %v", region, healthy)
+		}
+		r.healthy[region] = healthy
+		r.mu.Unlock()
+	}
+}
+
+// StartHealthChecks checks every region's swap workers every interval until ctx is done. Single-region
+// deployments have nothing to fail over to and are not checked.
+func (r *regionRouter) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(r.config.Regions()) < 2 || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			r.CheckHealth(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// temporalQueueHealth reports a task queue healthy while a worker has polled it recently
+func temporalQueueHealth(c client.Client) queueHealthFunc {
+	return func(ctx context.Context, taskQueue string) (bool, error) {
+		resp, err := c.DescribeTaskQueue(ctx, taskQueue, enumspb.TASK_QUEUE_TYPE_WORKFLOW)
+		if err != nil {
+			return false, err
+		}
+		for _, poller := range resp.GetPollers() {
+			if time.Since(poller.GetLastAccessTime().AsTime()) < pollerStaleAfter {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// workflowOptions returns the options starting workflow id in the region the router picks for taskQueue, and that region
+func (s *Server) workflowOptions(id, taskQueue string) (client.StartWorkflowOptions, string) {
+	region, regionQueue := s.regions.Route(taskQueue)
+	return client.StartWorkflowOptions{
+		ID:        id,
+		TaskQueue: regionQueue,
+		Memo:      s.regions.Memo(region),
+	}, region
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionRouter(t *testing.T) {
+	ctx := context.Background()
+	cfg := temporal_config.RegionConfig{ID: "us-east-1", Failover: []string{"eu-west-1", "ap-south-1"}}
+	healthy := map[string]bool{
+		"swap-queue-us-east-1":  true,
+		"swap-queue-eu-west-1":  true,
+		"swap-queue-ap-south-1": true,
+	}
+	failing := map[string]bool{}
+	router := newRegionRouter(cfg, func(ctx context.Context, taskQueue string) (bool, error) {
+		if failing[taskQueue] {
+			return false, errors.New("temporal unavailable")
+		}
+		return healthy[taskQueue], nil
+	})
+
+	t.Run("StaysInRegion", func(t *testing.T) {
+		router.CheckHealth(ctx)
+		region, queue := router.Route(SwapTaskQueue)
+		assert.Equal(t, "us-east-1", region)
+		assert.Equal(t, "swap-queue-us-east-1", queue)
+		assert.Equal(t, map[string]interface{}{memoOriginRegion: "us-east-1", memoRegion: "us-east-1"}, router.Memo(region))
+	})
+
+	t.Run("FailsOverInOrder", func(t *testing.T) {
+		healthy["swap-queue-us-east-1"] = false
+		router.CheckHealth(ctx)
+		region, queue := router.Route(SwapTaskQueue)
+		assert.Equal(t, "eu-west-1", region)
+		assert.Equal(t, "swap-queue-eu-west-1", queue)
+
+		// Only swap workers are health checked
+		region, queue = router.Route(PriceOracleTaskQueue)
+		assert.Equal(t, "us-east-1", region)
+		assert.Equal(t, "price-oracle-queue-us-east-1", queue)
+	})
+
+	t.Run("FailedCheckKeepsLastState", func(t *testing.T) {
+		failing["swap-queue-eu-west-1"] = true
+		healthy["swap-queue-eu-west-1"] = false
+		router.CheckHealth(ctx)
+		region, _ := router.Route(SwapTaskQueue)
+		assert.Equal(t, "eu-west-1", region)
+	})
+
+	t.Run("NoHealthyRegion", func(t *testing.T) {
+		delete(failing, "swap-queue-eu-west-1")
+		healthy["swap-queue-ap-south-1"] = false
+		router.CheckHealth(ctx)
+		region, _ := router.Route(SwapTaskQueue)
+		assert.Equal(t, "us-east-1", region, "swaps queue in their own region until a worker returns")
+	})
+
+	t.Run("Recovers", func(t *testing.T) {
+		healthy["swap-queue-us-east-1"] = true
+		router.CheckHealth(ctx)
+		region, _ := router.Route(SwapTaskQueue)
+		assert.Equal(t, "us-east-1", region)
+	})
+
+	t.Run("SingleRegion", func(t *testing.T) {
+		single := newRegionRouter(temporal_config.RegionConfig{}, nil)
+		region, queue := single.Route(SwapTaskQueue)
+		assert.Empty(t, region)
+		assert.Equal(t, SwapTaskQueue, queue)
+		assert.Nil(t, single.Memo(region))
+	})
+}
+
+func TestRegionHeader(t *testing.T) {
+	s := newTestServer(t)
+	rec := doRequest(t, s, http.MethodGet, "/health", nil, "")
+	assert.Empty(t, rec.Header().Get(regionHeader))
+
+	s.config().Region.ID = "us-east-1"
+	rec = doRequest(t, s, http.MethodGet, "/health", nil, "")
+	assert.Equal(t, "us-east-1", rec.Header().Get(regionHeader))
+}
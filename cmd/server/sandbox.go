@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math/big"
+	"net/http"
+)
+
+// SandboxBalancesResponse is returned by the sandbox balances endpoint
+type SandboxBalancesResponse struct {
+	Address  string              `json:"address"`
+	Balances map[string]*big.Int `json:"balances"`
+	Sandbox  bool                `json:"sandbox"`
+}
+
+// isSandbox reports whether the request was made with a sandbox API key
+func (s *Server) isSandbox(r *http.Request) bool {
+	if s.sandbox == nil {
+		return false
+	}
+	return s.sandboxKeys[r.Header.Get(apiKeyHeader)]
+}
+
+// sandboxBalancesHandler returns the fake balances of an address in the sandbox
+func (s *Server) sandboxBalancesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isSandbox(r) {
+		errorResponse(w, http.StatusForbidden, "sandbox API key required")
+		return
+	}
+
+	address := r.PathValue("address")
+	writeJSON(w, http.StatusOK, SandboxBalancesResponse{
+		Address:  address,
+		Balances: s.sandbox.GetBalances(address),
+		Sandbox:  true,
+	})
+}
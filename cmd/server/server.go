@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/interfaces"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_payloads "github.com/infinity-dex/temporal/payloads"
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+const (
+	// SwapTaskQueue is the name of the task queue used for swap workflows
+	SwapTaskQueue = "swap-queue"
+
+	// apiKeyHeader carries the caller's API key
+	apiKeyHeader = "X-API-Key"
+)
+
+// Server exposes the Infinity DEX services over HTTP
+type Server struct {
+	configs            atomic.Pointer[temporal_config.Config] // replaced as a whole by ApplyConfig; read it through config
+	rpcClient          *temporal_activities.EVMRPCClient
+	universalSDK       universalsdk.SDK
+	tokenLists         *services.TokenListCache // each chain's wrapped tokens, fetched from universalSDK
+	tokenService       *services.TokenService
+	transactionService *services.TransactionService
+	swapService        interfaces.SwapServiceInterface
+	bridgeQuoter       *services.SwapService // the live swap service, which quotes every bridge
+	liquidityService   *services.LiquidityService
+	bridgeReliability  *services.BridgeReliability
+	chainService       *services.ChainService
+	breakers           *services.CircuitBreakers // nil when circuit breakers are disabled
+	listingService     *services.ListingService
+	sandbox            *services.SandboxService
+	sandboxKeys        map[string]bool
+	adminKeys          map[string]string     // map[apiKey]operator
+	adminGate          *AdminGate            // nil when admin passkeys are disabled
+	tenantKeys         map[string]string     // map[apiKey]tenantID
+	trustedProxies     []netip.Prefix        // peers whose forwarding and country headers are believed
+	ipCountries        *services.IPCountries // nil when no IP database is configured
+	policyStore        services.PolicyStore
+	policyEngine       services.PolicyEngine
+	auditLog           *temporal_activities.AuditLog // nil when the audit log cannot be opened
+	attester           *services.SwapAttester        // nil when no signing key is configured
+	priceStore         PriceStore                    // nil when the price database is unavailable
+	prices             *services.PriceService        // prices of single tokens, for quotes and valuations
+	swapArchive        services.SwapArchiveStore     // nil when the database is unavailable
+	analytics          *services.AnalyticsService
+	tokenMetadata      *services.TokenMetadataService
+	parameterStore     services.ParameterStore
+	rules              *services.RuleSet
+	gasSponsor         *services.GasSponsor  // nil without a price staleness policy to price gas with
+	swapLimiter        *services.SwapLimiter // nil without a price staleness policy to value swaps with
+	quoteSigner        *services.QuoteSigner // nil without an attestation key or quote TTL
+	quotes             services.QuoteStore
+	priceCacheDir      string
+	priceBroker        *PriceBroker
+	temporalClient     client.Client           // nil when Temporal is unavailable
+	dataConverter      converter.DataConverter // decodes workflow histories the way the workers encoded them
+	regions            *regionRouter
+	readiness          map[string]dependencyCheck // map[dependency]check; nil checks are dependencies not configured
+	deposits           *services.DepositWatcher
+	bundler            chains.UserOperationSender // nil when no chain has a bundler
+	usage              *services.UsageMeter
+	errorReporter      *services.ErrorReporter
+	shadowPricer       *services.ShadowPricer // nil when shadow pricing is disabled
+	events             *events.Bus
+	webhookClient      *http.Client
+	webhookSecrets     services.WebhookSecretStore
+	priceAlerts        services.PriceAlertStore
+	deadLetters        services.DeadLetterStore
+	sdkMetrics         *universalsdk.CallMetrics
+	mux                *http.ServeMux
+}
+
+// NewServer creates a new API server; temporalClient may be nil
+func NewServer(cfg temporal_config.Config, sdk universalsdk.SDK, temporalClient client.Client) *Server {
+	errorReporter := newErrorReporter(cfg.Errors, cfg.Environment)
+	sdk = services.NewReportingSDK(sdk, errorReporter)
+	breakers := newCircuitBreakers(cfg.Breakers)
+	if breakers != nil {
+		sdk = services.NewBreakerSDK(sdk, breakers)
+	}
+	// Calls are counted, cached and retried around the breakers, so each attempt counts against them
+	sdkMetrics := universalsdk.NewCallMetrics()
+	sdk = universalsdk.Chain(sdk, temporal_activities.SDKMiddleware(cfg.Universal.Middleware, sdkMetrics)...)
+
+	tokenService := services.NewTokenService()
+	transactionService := services.NewTransactionService()
+	liquidityService := services.NewLiquidityService()
+	swapService := services.NewSwapService(tokenService, transactionService, sdk)
+	swapService.SetLiquidityService(liquidityService)
+	bridgeReliability := services.NewBridgeReliability(0)
+	bridgeRouter := services.NewBridgeRouter(bridgeReliability)
+	for _, corridor := range cfg.Bridges.Corridors {
+		bridgeRouter.SetCorridorPolicy(
+			services.BridgeCorridor{SourceChainID: cfg.Chains[corridor.From].ChainID, DestinationChainID: cfg.Chains[corridor.To].ChainID},
+			services.CorridorPolicy{Prefer: services.BridgePreference(corridor.Prefer), Bridges: corridor.Bridges})
+	}
+	swapService.SetBridgeRouter(bridgeRouter)
+	if cfg.Swap.AcrossAPIURL != "" {
+		swapService.AddBridge(services.NewAcrossBridge(&http.Client{Timeout: 5 * time.Second}, cfg.Swap.AcrossAPIURL))
+	}
+	for _, provider := range cfg.Bridges.Providers {
+		swapService.AddBridge(services.NewHTTPBridge(&http.Client{Timeout: 5 * time.Second}, provider.Name, provider.URL, cfg.ChainIDs(provider.Chains)))
+	}
+	listingService := services.NewListingService(tokenService)
+	rpcClient := temporal_activities.NewEVMRPCClient(&http.Client{Timeout: 10 * time.Second}, cfg.RPCURLs())
+	var gasReader services.GasReader = rpcClient
+	if breakers != nil {
+		gasReader = services.NewBreakerGasReader(rpcClient, breakers)
+	}
+	listingChecker := services.NewListingChecker(rpcClient, tokenService, cfg.Listings.MinLiquidityUSD)
+	listingChecker.SetRouters(cfg.RouterAddresses())
+	listingService.SetChecker(listingChecker)
+
+	s := &Server{
+		rpcClient:          rpcClient,
+		universalSDK:       sdk,
+		tokenService:       tokenService,
+		transactionService: transactionService,
+		swapService:        swapService,
+		bridgeQuoter:       swapService,
+		liquidityService:   liquidityService,
+		bridgeReliability:  bridgeReliability,
+		chainService:       newChainService(cfg, gasReader),
+		breakers:           breakers,
+		listingService:     listingService,
+		priceBroker:        NewPriceBroker(),
+		sandboxKeys:        make(map[string]bool),
+		adminKeys:          make(map[string]string),
+		tenantKeys:         make(map[string]string),
+		temporalClient:     temporalClient,
+		dataConverter:      temporal_payloads.NewDataConverter(temporal_payloads.OptionsFromConfig(cfg.Temporal.Payloads)),
+		attester:           newSwapAttester(cfg.Attestation),
+		readiness:          newReadinessChecks(cfg, sdk, temporalClient),
+		tokenMetadata:      services.NewTokenMetadataService(services.NewInMemoryTokenMetadataStore()),
+		deposits:           newDepositWatcher(cfg, rpcClient),
+		bundler:            newBundler(cfg, rpcClient),
+		usage:              services.NewUsageMeter(services.NewInMemoryUsageStore()),
+		errorReporter:      errorReporter,
+		events:             newEventBus(cfg.Events),
+		webhookClient:      services.NewWebhookClient(),
+		webhookSecrets:     services.NewInMemoryWebhookSecretStore(),
+		priceAlerts:        services.NewInMemoryPriceAlertStore(),
+		quoteSigner:        newQuoteSigner(cfg),
+		quotes:             services.NewInMemoryQuoteStore(),
+		deadLetters:        services.NewInMemoryDeadLetterStore(),
+		sdkMetrics:         sdkMetrics,
+		mux:                http.NewServeMux(),
+	}
+	s.configs.Store(&cfg)
+	// Fetched through the server so the SDK it uses, wrapped or replaced after construction, serves the tokens
+	s.tokenLists = services.NewTokenListCache(func(ctx context.Context, chainID int64) ([]types.Token, error) {
+		return s.universalSDK.GetWrappedTokens(ctx, chainID)
+	}, cfg.Server.TokenCacheTTL, cfg.Server.TokenFetchTimeout)
+	s.tokenLists.SetBatchFetcher(func(ctx context.Context) (map[int64][]types.Token, error) {
+		return s.universalSDK.GetAllWrappedTokens(ctx)
+	})
+	s.deposits.SetHandler(s.onDeposit)
+	events.Subscribe(s.events, events.DepositReceivedTopic, cfg.Events.Buffer, events.Block, s.notifyDeposit)
+	s.shadowPricer = newShadowPricer(cfg, swapService, liquidityService, s.tokenMetadata)
+
+	var queueHealth queueHealthFunc
+	if temporalClient != nil {
+		queueHealth = temporalQueueHealth(temporalClient)
+	}
+	s.regions = newRegionRouter(cfg.Region, queueHealth)
+
+	if cacheDir, err := temporal_activities.DefaultPriceCacheDir(); err == nil {
+		s.priceCacheDir = cacheDir
+	}
+	s.prices = s.newPriceService(cfg.Prices)
+	if cfg.Swap.MaxPriceAge > 0 {
+		pricePolicy := services.NewPriceStalenessPolicy(s.prices, cfg.Swap.MaxPriceAge)
+		swapService.SetPricePolicy(pricePolicy)
+		gasEstimator := services.NewGasEstimator(s.chainService, pricePolicy, cfg.Swap.GasMultiplier)
+		swapService.SetGasEstimator(gasEstimator)
+		s.gasSponsor = services.NewGasSponsor(gasEstimator, cfg.GasSponsorPolicies())
+		swapService.SetGasSponsor(s.gasSponsor)
+		s.swapLimiter = services.NewSwapLimiter(cfg.SwapLimits(), pricePolicy)
+	}
+
+	// Swaps are valued at the price oracle's prices when they're counted
+	s.analytics = services.NewAnalyticsService(s.prices)
+
+	// LoadConfig has already refused invalid ranges
+	for _, cidr := range cfg.Compliance.TrustedProxies {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			s.trustedProxies = append(s.trustedProxies, prefix.Masked())
+		}
+	}
+	for _, tenant := range cfg.Compliance.Tenants {
+		for _, key := range tenant.APIKeys {
+			s.tenantKeys[key] = tenant.ID
+		}
+	}
+	seedPools(context.Background(), liquidityService, cfg.Pools)
+	s.SetPolicyStore(services.NewInMemoryPolicyStore())
+	s.parameterStore = services.NewInMemoryParameterStore()
+	s.rules = services.NewRuleSet(s.parameterStore)
+	swapService.SetRules(s.rules)
+	swapService.SetQuoteSigner(s.quoteSigner)
+	swapService.SetPriceImpactLimits(cfg.PriceImpactLimits())
+
+	adminGate, err := NewAdminGate(cfg.Admin.WebAuthn)
+	if err != nil {
+		// Fail closed: without its passkeys the admin API stays off
+		log.Printf("Admin passkeys unavailable, admin API disabled: %v", err)
+	} else {
+		s.adminGate = adminGate
+		for _, key := range cfg.Admin.APIKeys {
+			s.adminKeys[key] = "admin-" + keyOwner(key)
+		}
+		for _, operator := range cfg.Admin.Operators {
+			s.adminKeys[operator.APIKey] = operator.Name
+		}
+	}
+	auditLogPath, _ := temporal_activities.AuditLogPath(cfg.Admin.AuditLogPath)
+	if auditLog, err := temporal_activities.NewAuditLog(auditLogPath); err == nil {
+		s.auditLog = auditLog
+	} else {
+		log.Printf("Audit log unavailable, admin actions disabled: %v", err)
+	}
+
+	if cfg.Sandbox.Enabled {
+		startingBalance, ok := new(big.Int).SetString(cfg.Sandbox.StartingBalance, 10)
+		if !ok {
+			startingBalance = big.NewInt(0)
+		}
+		s.sandbox = services.NewSandboxService(startingBalance)
+		for _, key := range cfg.Sandbox.APIKeys {
+			s.sandboxKeys[key] = true
+		}
+	}
+
+	s.routes()
+	return s
+}
+
+// routes registers all HTTP handlers
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /health", s.healthHandler)
+	s.mux.HandleFunc("GET /livez", s.healthHandler)
+	s.mux.HandleFunc("GET /readyz", s.readinessHandler)
+	s.mux.HandleFunc("GET /api/v1/openapi.json", s.openAPIHandler)
+	s.mux.HandleFunc("GET /api/v1/docs", s.docsHandler)
+
+	s.mux.HandleFunc("GET /api/v1/chains", s.listChainsHandler)
+	s.mux.HandleFunc("GET /api/v1/chains/{id}/gas", s.chainGasHandler)
+	s.mux.HandleFunc("GET /api/v1/tokens", s.getTokensHandler)
+
+	s.mux.HandleFunc("POST /api/v1/swap/quote", s.swapQuoteHandler)
+	s.mux.HandleFunc("POST /api/v1/swap/simulate", s.swapSimulateHandler)
+	s.mux.HandleFunc("POST /api/v1/swap", s.swapHandler)
+	s.mux.HandleFunc("POST /api/v1/swap/user-operation", s.buildUserOperationHandler)
+	s.mux.HandleFunc("GET /api/v1/permits/typed-data", s.permitTypedDataHandler)
+	s.mux.HandleFunc("GET /api/v1/swap/{id}", s.swapStatusHandler)
+	s.mux.HandleFunc("POST /api/v1/swap/{id}/confirm", s.confirmSwapHandler)
+	s.mux.HandleFunc("POST /api/v1/swap/{id}/cancel", s.cancelSwapHandler)
+	s.mux.HandleFunc("GET /api/v1/swap/{id}/attestation", s.swapAttestationHandler)
+	s.mux.HandleFunc("GET /api/v1/attestations/key", s.attestationKeyHandler)
+
+	s.mux.HandleFunc("GET /api/v1/transfers/{transactionId}", s.transferHandler)
+	s.mux.HandleFunc("GET /api/v1/transfers/{transactionId}/ws", s.transferFeedHandler)
+
+	s.mux.HandleFunc("GET /api/v1/bridge-quotes", s.bridgeQuotesHandler)
+	s.mux.HandleFunc("GET /api/v1/bridges/reliability", s.bridgeReliabilityHandler)
+	s.mux.HandleFunc("GET /api/v1/bridges/{bridge}/reliability", s.getBridgeReliabilityHandler)
+
+	s.mux.HandleFunc("GET /api/v1/pools", s.listPoolsHandler)
+	s.mux.HandleFunc("GET /api/v1/pools/{id}", s.getPoolHandler)
+	s.mux.HandleFunc("GET /api/v1/pools/{id}/positions", s.poolPositionsHandler)
+	s.mux.HandleFunc("GET /api/v1/positions", s.userPositionsHandler)
+	s.mux.HandleFunc("POST /api/v1/pools/{id}/liquidity", s.addLiquidityHandler)
+	s.mux.HandleFunc("POST /api/v1/pools/{id}/liquidity/remove", s.removeLiquidityHandler)
+	s.mux.HandleFunc("GET /api/v1/pools/{id}/fees", s.pendingFeesHandler)
+	s.mux.HandleFunc("POST /api/v1/pools/{id}/fees/claim", s.claimFeesHandler)
+
+	s.mux.HandleFunc("GET /api/v1/prices", s.listPricesHandler)
+	s.mux.HandleFunc("GET /api/v1/prices/ws", s.priceFeedHandler)
+	s.mux.HandleFunc("GET /api/v1/prices/{symbol}", s.getPriceHandler)
+	s.mux.HandleFunc("GET /api/v1/prices/{symbol}/history", s.priceHistoryHandler)
+	s.mux.HandleFunc("GET /api/v1/prices/{symbol}/twap", s.priceTWAPHandler)
+	s.mux.HandleFunc("GET /api/v1/prices/{symbol}/candles", s.priceCandlesHandler)
+
+	s.mux.HandleFunc("GET /api/v1/stats", s.statsHandler)
+
+	s.mux.HandleFunc("GET /api/v1/users/{address}/swaps", s.userSwapsHandler)
+
+	s.mux.HandleFunc("PUT /api/v1/webhooks/secret", s.putWebhookSecretHandler)
+	s.mux.HandleFunc("POST /api/v1/webhooks/verify", s.verifyWebhookHandler)
+
+	s.mux.HandleFunc("POST /api/v1/price-alerts", s.createPriceAlertHandler)
+	s.mux.HandleFunc("GET /api/v1/price-alerts", s.listPriceAlertsHandler)
+	s.mux.HandleFunc("GET /api/v1/price-alerts/{id}", s.getPriceAlertHandler)
+	s.mux.HandleFunc("DELETE /api/v1/price-alerts/{id}", s.deletePriceAlertHandler)
+
+	s.mux.HandleFunc("GET /api/v1/sandbox/balances/{address}", s.sandboxBalancesHandler)
+
+	s.mux.HandleFunc("POST /api/v1/listings", s.submitListingHandler)
+	s.mux.HandleFunc("GET /api/v1/listings/{id}", s.getListingHandler)
+
+	s.mux.HandleFunc("GET /api/v1/admin/actions", s.adminActionsHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/actions/{action}", s.runAdminActionHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/audit", s.adminAuditHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/dead-letters", s.adminDeadLettersHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/usage", s.adminUsageHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/errors", s.adminErrorsHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/shadow-prices", s.adminShadowPricesHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/breakers", s.adminBreakersHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/config", s.adminConfigHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/policies/{tenantId}", s.getPolicyHandler)
+	s.mux.HandleFunc("PUT /api/v1/admin/policies/{tenantId}", s.putPolicyHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/swaps/{id}/review", s.reviewSwapHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/pools/{id}/migration", s.poolMigrationPlanHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/parameters", s.listParametersHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/parameters/{name}", s.getParameterHandler)
+	s.mux.HandleFunc("PUT /api/v1/admin/parameters/{name}", s.putParameterHandler)
+	s.mux.HandleFunc("DELETE /api/v1/admin/parameters/{name}", s.deleteParameterHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/listings", s.adminListingsHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/listings/{id}/review", s.reviewListingHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/webauthn/register/begin", s.beginRegistrationHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/webauthn/register/finish", s.finishRegistrationHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/webauthn/login/begin", s.beginLoginHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/webauthn/login/finish", s.finishLoginHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/webauthn/recover", s.recoverHandler)
+	s.mux.HandleFunc("POST /api/v1/admin/webauthn/recovery-codes", s.regenerateRecoveryCodesHandler)
+	s.mux.HandleFunc("GET /api/v1/admin/webauthn/credentials", s.listCredentialsHandler)
+	s.mux.HandleFunc("DELETE /api/v1/admin/webauthn/credentials/{id}", s.removeCredentialHandler)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", s.config().Server.CORSAllowOrigin)
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+apiKeyHeader+", "+adminSessionHeader+", "+enrollmentTokenHeader+", "+requestIDHeader+", "+
+		walletSignatureHeader+", "+walletSignatureExpiresHeader+", "+walletSignatureSchemeHeader)
+	w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Every response carries the request's ID, so errors can be traced to the logs of the request
+	requestID := r.Header.Get(requestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = uuid.New().String()
+	}
+	w.Header().Set(requestIDHeader, requestID)
+
+	if s.isSandbox(r) {
+		w.Header().Set("X-Sandbox", "true")
+	}
+	if s.config().Region.ID != "" {
+		w.Header().Set(regionHeader, s.config().Region.ID)
+	}
+
+	// Error responses are reported with the route that returned them
+	reporting := &errorReportingWriter{ResponseWriter: w}
+	defer s.reportAPIError(r, reporting)
+
+	if keyID, tenant, ok := s.meteredKey(r); ok {
+		s.serveMetered(reporting, r, keyID, tenant)
+		return
+	}
+	s.serveCompressed(reporting, r)
+}
+
+// newMockSDK creates a mock Universal SDK serving the wrapped tokens listed in the chain config, running the
+// configured mock scenario if any
+func newMockSDK(cfg temporal_config.Config) (universalsdk.SDK, error) {
+	wrappedTokens := make(map[int64][]types.Token)
+	for _, chain := range cfg.Chains {
+		for _, symbol := range chain.WrappedTokens {
+			wrappedTokens[chain.ChainID] = append(wrappedTokens[chain.ChainID], types.Token{
+				Symbol:    symbol,
+				Name:      "Universal " + strings.TrimPrefix(symbol, "u"),
+				Decimals:  18,
+				ChainID:   chain.ChainID,
+				ChainName: chain.Name,
+				IsWrapped: true,
+			})
+		}
+	}
+
+	sdkConfig := universalsdk.MockSDKConfig{
+		WrappedTokens: wrappedTokens,
+		Latency:       200 * time.Millisecond,
+		FailureRate:   0.05, // 5% failure rate for testing
+	}
+	if path := cfg.Universal.MockScenario; path != "" {
+		scenario, err := universalsdk.LoadScenario(path)
+		if err != nil {
+			return nil, err
+		}
+		sdkConfig = scenario.Apply(sdkConfig)
+	}
+	return universalsdk.NewMockSDK(sdkConfig), nil
+}
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/infinity-dex/universalsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSandboxKey is the API key whose requests run in the test server's sandbox
+const testSandboxKey = "sandbox-test-key"
+
+// testAttestationKey is the seed the test server signs swap attestations with
+const testAttestationKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+// newTestServer creates a server backed by a zero-latency mock SDK and no Temporal client, with the sandbox on
+func newTestServer(t testing.TB) *Server {
+	t.Helper()
+
+	cfg := temporal_config.DefaultConfig()
+	// Quote at demo rates; tests that need oracle prices set a price policy
+	cfg.Swap.MaxPriceAge = 0
+	cfg.Sandbox.Enabled = true
+	cfg.Sandbox.APIKeys = []string{testSandboxKey}
+	cfg.Attestation.SigningKey = testAttestationKey
+	sdk := universalsdk.NewMockSDK(universalsdk.MockSDKConfig{
+		WrappedTokens: map[int64][]types.Token{
+			1: {{Symbol: "uETH", Name: "Universal ETH", Decimals: 18, ChainID: 1, ChainName: "Ethereum", IsWrapped: true}},
+		},
+	})
+
+	return NewServer(cfg, sdk, nil)
+}
+
+// doRequest sends a request to the server and returns the recorded response
+func doRequest(t testing.TB, s *Server, method, path string, body interface{}, apiKey string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	if apiKey != "" {
+		req.Header.Set(apiKeyHeader, apiKey)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func testSwapBody() SwapRequestBody {
+	return SwapRequestBody{
+		SourceToken:        types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1, ChainName: "Ethereum"},
+		DestinationToken:   types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1, ChainName: "Ethereum"},
+		Amount:             "1000000000000000000",
+		SourceAddress:      "0x1234567890abcdef1234567890abcdef12345678",
+		DestinationAddress: "0x1234567890abcdef1234567890abcdef12345678",
+		Slippage:           0.5,
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodGet, "/health", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestSandboxSwap(t *testing.T) {
+	s := newTestServer(t)
+	sandboxKey := testSandboxKey
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), sandboxKey)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get("X-Sandbox"))
+
+	var resp SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.True(t, resp.Sandbox)
+	assert.Equal(t, "completed", resp.Status)
+
+	// The fill is visible through the status endpoint
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/swap/"+resp.RequestID, nil, sandboxKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Balances are only visible to sandbox keys
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/sandbox/balances/0x1234567890abcdef1234567890abcdef12345678", nil, sandboxKey)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/sandbox/balances/0x1234567890abcdef1234567890abcdef12345678", nil, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestSwapWithoutSandbox(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), "")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("X-Sandbox"))
+
+	var resp SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.False(t, resp.Sandbox)
+	assert.Equal(t, "pending", resp.Status)
+}
+
+func TestSwapMinOutputAmount(t *testing.T) {
+	s := newTestServer(t)
+
+	body := testSwapBody()
+	body.MinOutputAmount = "-1"
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// 1 ETH quotes about 2000 USDC, so asking for 1M refuses the swap
+	body.MinOutputAmount = "1000000000000000000000000"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "minimum output")
+}
+
+func TestSwapSimulation(t *testing.T) {
+	s := newTestServer(t)
+	address := testSwapBody().SourceAddress
+
+	for _, apiKey := range []string{"", testSandboxKey} {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/simulate", testSwapBody(), apiKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp SwapResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "simulated", resp.Status)
+		require.NotNil(t, resp.Result)
+		assert.True(t, resp.Result.Success)
+		assert.Positive(t, resp.Result.OutputAmount.Sign())
+
+		// Nothing was executed
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/swap/"+resp.RequestID, nil, apiKey)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+	assert.Equal(t, s.config().Sandbox.StartingBalance, s.sandbox.GetBalance(address, "ETH").String())
+
+	// A swap that would be refused is refused
+	body := testSwapBody()
+	body.MinOutputAmount = "1000000000000000000000000"
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/simulate", body, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, ErrorCodeSlippageExceeded, decodeError(t, rec).Code)
+}
+
+func TestAddAndRemoveLiquidity(t *testing.T) {
+	s := newTestServer(t)
+
+	pool, err := s.liquidityService.CreatePool(context.Background(), services.TokenPair{
+		BaseToken:  services.Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: services.Token{Symbol: "USDC", ChainID: 1},
+	}, 3000, "0x3333333333333333333333333333333333333333")
+	require.NoError(t, err)
+
+	body := LiquidityRequestBody{
+		UserAddress: "0x1234567890abcdef1234567890abcdef12345678",
+		Token:       types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1, ChainName: "Ethereum"},
+		Amount:      "1000",
+	}
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/pools/"+pool.ID+"/liquidity", body, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp LiquidityResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.NotNil(t, resp.Result)
+	require.NotNil(t, resp.Result.Position)
+	assert.Equal(t, "1000", resp.Result.Position.TokensOwned.String())
+
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/pools/"+pool.ID+"/liquidity/remove", body, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Removing again fails since the position is gone
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/pools/"+pool.ID+"/liquidity/remove", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/pools/missing/liquidity", body, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPoolEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	pool, err := s.liquidityService.CreatePool(ctx, services.TokenPair{
+		BaseToken:  services.Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: services.Token{Symbol: "USDC", ChainID: 1},
+	}, 3000, "0x3333333333333333333333333333333333333333")
+	require.NoError(t, err)
+	require.NoError(t, s.liquidityService.UpdatePoolStats(ctx, pool.ID, 1500000, 12.5))
+
+	user := "0x1234567890abcdef1234567890abcdef12345678"
+	_, err = s.liquidityService.AddLiquidity(ctx, pool.ID, user, big.NewInt(1000))
+	require.NoError(t, err)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/pools", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var pools PoolsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&pools))
+	require.Len(t, pools.Pools, 1)
+	assert.Equal(t, 1500000.0, pools.Pools[0].TVL)
+	assert.Equal(t, 12.5, pools.Pools[0].APR)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/pools/"+pool.ID, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/pools/missing", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/pools/"+pool.ID+"/positions", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var positions PositionsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&positions))
+	require.Len(t, positions.Positions, 1)
+	assert.Equal(t, user, positions.Positions[0].UserAddress)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/positions?address="+user, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&positions))
+	assert.Len(t, positions.Positions, 1)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/positions", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestNativeTokenSwaps(t *testing.T) {
+	s := newTestServer(t)
+
+	native := testSwapBody()
+	native.SourceToken.Address = types.NativeTokenAddress
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", native, "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Ethereum's native token is ETH
+	matic := native
+	matic.SourceToken.Symbol = "MATIC"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", matic, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "invalid native token")
+}
+
+func TestQuoteAmounts(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	body := rec.Body.String()
+	var quoted SwapResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &quoted))
+	require.NotNil(t, quoted.Quote)
+	require.NotNil(t, quoted.Amounts)
+
+	// Amounts carry their tokens' decimals and are formatted in whole tokens
+	require.NotNil(t, quoted.Amounts.Output)
+	assert.Equal(t, "1", quoted.Amounts.Input.String())
+	assert.Equal(t, 18, quoted.Amounts.Input.Decimals)
+	assert.Equal(t, 6, quoted.Amounts.Output.Decimals)
+	assert.Equal(t, quoted.Quote.OutputAmount.String(), quoted.Amounts.Output.Value.String())
+	assert.Contains(t, body, `"formatted":"1"`)
+}
+
+func TestDecimalAmounts(t *testing.T) {
+	s := newTestServer(t)
+
+	// Whole tokens with a decimal point are converted with the token's decimals; integers stay base units
+	decimal := testSwapBody()
+	decimal.Amount = "1.5"
+	raw := testSwapBody()
+	raw.Amount = "1500000000000000000"
+	for name, body := range map[string]SwapRequestBody{"decimal": decimal, "raw": raw} {
+		rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", body, "")
+		require.Equal(t, http.StatusOK, rec.Code, name+": "+rec.Body.String())
+		var quoted SwapResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&quoted))
+		assert.Equal(t, "1500000000000000000", quoted.Quote.InputAmount.String(), name)
+		assert.Equal(t, "1.5", quoted.Amounts.Input.String(), name)
+	}
+
+	// The minimum output is in the destination token
+	decimal.MinOutputAmount = "0.5"
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", decimal, "")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var started SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&started))
+	require.NotNil(t, started.Amounts)
+	require.NotNil(t, started.Amounts.MinOutput)
+	assert.Equal(t, "500000", started.Amounts.MinOutput.Value.String())
+
+	// Finer than the token's decimals, or not a number
+	for _, amount := range []string{"1.0000000000000000001", "1.5.0", "-1.5", "one"} {
+		body := testSwapBody()
+		body.Amount = amount
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", body, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, amount)
+	}
+}
+
+func TestPriceImpactLimit(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	pool, err := s.liquidityService.CreatePool(ctx, services.TokenPair{
+		BaseToken:  services.Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: services.Token{Symbol: "USDC", ChainID: 1},
+	}, 3000, "0x3333333333333333333333333333333333333333")
+	require.NoError(t, err)
+	_, err = s.liquidityService.AddLiquidity(ctx, pool.ID, "0x9999999999999999999999999999999999999999", big.NewInt(9_000_000_000_000_000_000))
+	require.NoError(t, err)
+
+	// Selling 1 ETH into a 9 ETH reserve moves the pool's price by 10%
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", testSwapBody(), "")
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	resp := decodeError(t, rec)
+	assert.Equal(t, ErrorCodePriceImpactTooHigh, resp.Code)
+	assert.Contains(t, resp.Error, "10.00%")
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	forced := testSwapBody()
+	forced.Force = true
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", forced, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var quoted SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&quoted))
+	assert.InDelta(t, 10.0, quoted.Quote.PriceImpact, 0.0001)
+}
+
+func TestSwapFeesAccrueToPool(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	pool, err := s.liquidityService.CreatePool(ctx, services.TokenPair{
+		BaseToken:  services.Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: services.Token{Symbol: "USDC", ChainID: 1},
+	}, 3000, "0x3333333333333333333333333333333333333333")
+	require.NoError(t, err)
+
+	provider := "0x9999999999999999999999999999999999999999"
+	_, err = s.liquidityService.AddLiquidity(ctx, pool.ID, provider, big.NewInt(1000))
+	require.NoError(t, err)
+
+	// The swap dwarfs the pool, so its price impact is over the limit
+	body := testSwapBody()
+	body.Force = true
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var swap SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&swap))
+
+	// Nothing accrues until the swap settles
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/pools/"+pool.ID+"/fees?address="+provider, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var fees FeesResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&fees))
+	assert.True(t, fees.PendingFees.IsZero())
+
+	txs, err := s.transactionService.GetTransactionsByWorkflowID(ctx, swap.RequestID)
+	require.NoError(t, err)
+	for _, tx := range txs {
+		require.NoError(t, s.transactionService.UpdateTransactionStatus(ctx, tx.ID, "completed"))
+	}
+	// Seeing the settled swap twice accrues its fees once
+	for i := 0; i < 2; i++ {
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/swap/"+swap.RequestID, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	// 0.3% of the 1 ETH input goes to the only provider, in ETH
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/pools/"+pool.ID+"/fees?address="+provider, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&fees))
+	require.Contains(t, fees.PendingFees, "ETH")
+	assert.Equal(t, "3000000000000000", fees.PendingFees["ETH"].String())
+
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/pools/"+pool.ID+"/fees/claim", ClaimFeesRequestBody{UserAddress: provider}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&fees))
+	assert.Equal(t, "3000000000000000", fees.Claimed["ETH"].String())
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/pools/"+pool.ID+"/fees?address="+provider, nil, "")
+	var remaining FeesResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&remaining))
+	assert.True(t, remaining.PendingFees.IsZero())
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/pools/"+pool.ID+"/fees?address=0xunknown", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestArchivedSwapLookup(t *testing.T) {
+	s := newTestServer(t)
+	archive := services.NewInMemorySwapArchive()
+	s.SetSwapArchive(archive)
+
+	require.NoError(t, archive.ArchiveSwap(context.Background(), types.ArchivedSwap{
+		RequestID:  "swap-archived",
+		WorkflowID: "swap-archived",
+		Result: types.SwapResult{
+			RequestID:    "swap-archived",
+			Success:      true,
+			SourceTx:     types.Transaction{Hash: "0xsource"},
+			InputAmount:  big.NewInt(1000),
+			OutputAmount: big.NewInt(990),
+		},
+	}))
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/swap/swap-archived", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var swap SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&swap))
+	assert.True(t, swap.Archived)
+	assert.Equal(t, "completed", swap.Status)
+	require.NotNil(t, swap.Result)
+	assert.Equal(t, "990", swap.Result.OutputAmount.String())
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/swap/swap-archived/attestation", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/swap/swap-missing", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTokensIncludeMetadataAndPrices(t *testing.T) {
+	s := newTestServer(t)
+	s.priceCacheDir = ""
+	s.SetPriceStore(&fakePriceStore{
+		prices: []types.TokenPrice{{Symbol: "eth", ChainID: 1, PriceUSD: 3000}},
+	})
+	store := services.NewInMemoryTokenMetadataStore()
+	s.SetTokenMetadataStore(store)
+
+	require.NoError(t, store.SaveTokenMetadata(context.Background(), []types.TokenMetadata{
+		{ChainID: 1, Address: "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", Symbol: "ETH", Name: "Ether", Decimals: 18, LogoURI: "https://assets.example.com/eth.png", CoinGeckoID: "ethereum", Verified: true},
+		{ChainID: 1, Address: "0x1111111111111111111111111111111111111111", Symbol: "ETH", Name: "Fake Ether", Decimals: 18, LogoURI: "https://assets.example.com/fake.png"},
+	}))
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/tokens", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp TokensResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Tokens, 1)
+
+	token := resp.Tokens[0]
+	assert.Equal(t, "uETH", token.Symbol)
+	assert.Equal(t, "https://assets.example.com/eth.png", token.LogoURI)
+	assert.Equal(t, "ethereum", token.CoinGeckoID)
+	assert.True(t, token.Verified)
+	assert.Equal(t, 3000.0, token.PriceUSD)
+}
+
+func TestTokensETag(t *testing.T) {
+	s := newTestServer(t)
+	s.priceCacheDir = ""
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/tokens", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tokens", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotModified, rec.Code, ifNoneMatch)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+	}
+
+	// A changed token list gets a new tag
+	store := services.NewInMemoryTokenMetadataStore()
+	s.SetTokenMetadataStore(store)
+	require.NoError(t, store.SaveTokenMetadata(context.Background(), []types.TokenMetadata{
+		{ChainID: 1, Address: "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", Symbol: "ETH", Name: "Ether", Decimals: 18, Verified: true},
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tokens", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+}
+
+// slowTokensSDK is a Universal SDK whose wrapped token lookups hang on some chains until their context ends
+type slowTokensSDK struct {
+	universalsdk.SDK
+	hang map[int64]bool
+}
+
+func (s *slowTokensSDK) GetWrappedTokens(ctx context.Context, chainID int64) ([]types.Token, error) {
+	if s.hang[chainID] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return s.SDK.GetWrappedTokens(ctx, chainID)
+}
+
+func TestTokensReportFailedChains(t *testing.T) {
+	s := newTestServer(t)
+	s.priceCacheDir = ""
+	s.config().Server.TokenFetchTimeout = 50 * time.Millisecond
+	s.universalSDK = &slowTokensSDK{SDK: s.universalSDK, hang: map[int64]bool{137: true}}
+
+	started := time.Now()
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/tokens", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Less(t, time.Since(started), time.Second, "a hanging chain is given up on after its timeout")
+
+	var resp TokensResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Tokens, 1, "the tokens of the chains that answered are listed")
+	assert.Equal(t, "uETH", resp.Tokens[0].Symbol)
+
+	// The mock SDK only has Ethereum tokens, and Polygon hangs; warnings are by chain name
+	require.Len(t, resp.Warnings, 4)
+	assert.Contains(t, resp.Warnings[0], "avalanche")
+	assert.Contains(t, resp.Warnings[2], "polygon: "+context.DeadlineExceeded.Error())
+}
+
+// fakeOracle serves fixed oracle prices by symbol
+type fakeOracle map[string]types.TokenPrice
+
+func (f fakeOracle) OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error) {
+	return f[symbol], nil
+}
+
+func TestSwapQuotePriceStaleness(t *testing.T) {
+	s := newTestServer(t)
+	oracle := fakeOracle{
+		"ETH":  {Symbol: "ETH", PriceUSD: 2000, LastUpdated: time.Now().Add(-time.Minute)},
+		"USDC": {Symbol: "USDC", PriceUSD: 1, LastUpdated: time.Now()},
+	}
+	s.swapService.(*services.SwapService).SetPricePolicy(services.NewPriceStalenessPolicy(oracle, 5*time.Minute))
+
+	body := testSwapBody()
+	body.DestinationToken.Decimals = 18
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", body, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp SwapResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.NotNil(t, resp.Quote)
+	assert.WithinDuration(t, oracle["ETH"].LastUpdated, resp.Quote.PriceAsOf, time.Millisecond)
+
+	oracle["ETH"] = types.TokenPrice{Symbol: "ETH", PriceUSD: 2000, LastUpdated: time.Now().Add(-time.Hour)}
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/quote", body, "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "stale")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// ShadowPricesResponse is how our quotes compare with DEX aggregators' per pool pair
+type ShadowPricesResponse struct {
+	Pairs []types.PairCompetitiveness `json:"pairs"`
+}
+
+// newShadowPricer creates the shadow pricer comparing our quotes against ParaSwap's, or nil when it is disabled
+func newShadowPricer(cfg temporal_config.Config, swaps services.SwapQuoter, liquidity *services.LiquidityService, metadata *services.TokenMetadataService) *services.ShadowPricer {
+	if cfg.Swap.ParaSwapAPIURL == "" || cfg.Swap.ShadowPriceInterval <= 0 {
+		return nil
+	}
+	pricer := services.NewShadowPricer(swaps, liquidity, metadata, services.DefaultShadowSamples)
+	pricer.AddAggregator(services.NewParaSwapQuoter(&http.Client{Timeout: 5 * time.Second}, cfg.Swap.ParaSwapAPIURL))
+	return pricer
+}
+
+// adminShadowPricesHandler returns how our quotes for each pool pair compare with DEX aggregators' recent quotes
+func (s *Server) adminShadowPricesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.shadowPricer == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "shadow pricing is disabled")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ShadowPricesResponse{Pairs: s.shadowPricer.Stats()})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowPrices(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	paraSwap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"priceRoute": {"destAmount": "3000000000"}}`))
+	}))
+	defer paraSwap.Close()
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+
+	t.Run("Disabled", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/shadow-prices", nil, adminKey)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	cfg := *s.config()
+	cfg.Swap.ParaSwapAPIURL = paraSwap.URL
+	s.shadowPricer = newShadowPricer(cfg, s.swapService, s.liquidityService, s.tokenMetadata)
+	require.NotNil(t, s.shadowPricer)
+
+	ctx := context.Background()
+	metadata := services.NewInMemoryTokenMetadataStore()
+	require.NoError(t, metadata.SaveTokenMetadata(ctx, []types.TokenMetadata{
+		{ChainID: 1, Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Symbol: "USDC", Decimals: 6},
+		{ChainID: 137, Address: "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359", Symbol: "USDC", Decimals: 6},
+	}))
+	s.SetTokenMetadataStore(metadata)
+
+	eth := services.Token{Symbol: "ETH", ChainID: 1}
+	polygonUSDC := services.Token{Symbol: "USDC", ChainID: 137}
+	require.NoError(t, s.liquidityService.SeedPool(ctx, "eth-usdc-polygon", services.TokenPair{BaseToken: eth, QuoteToken: polygonUSDC}, 500, ""))
+	// ParaSwap only quotes swaps within a chain
+	assert.Equal(t, 0, s.shadowPricer.Sample(ctx))
+
+	usdc := services.Token{Symbol: "USDC", ChainID: 1}
+	require.NoError(t, s.liquidityService.SeedPool(ctx, "eth-usdc-3000", services.TokenPair{BaseToken: eth, QuoteToken: usdc}, 3000, ""))
+	require.Equal(t, 1, s.shadowPricer.Sample(ctx))
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/shadow-prices", nil, testSandboxKey)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/shadow-prices", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var response ShadowPricesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+		require.Len(t, response.Pairs, 1)
+		stats := response.Pairs[0]
+		assert.Equal(t, "ETH/USDC", stats.Pair)
+		assert.Equal(t, int64(1), stats.ChainID)
+		assert.Equal(t, services.AggregatorParaSwap, stats.Aggregator)
+		assert.Equal(t, 1, stats.Samples)
+		assert.Equal(t, stats.LastBPS, stats.P50BPS)
+	})
+}
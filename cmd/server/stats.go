@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/infinity-dex/services"
+)
+
+// SetSwapStatsStore sets the store finished swaps are counted in, shared with the swap workers
+func (s *Server) SetSwapStatsStore(store services.SwapStatsStore) {
+	s.analytics.SetStore(store)
+}
+
+// statsHandler returns the volume, fee and success rate statistics of the swaps that finished in the last 24 hours
+// and 7 days
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.analytics.Stats(r.Context())
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load swap stats: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
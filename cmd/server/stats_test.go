@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsEndpoint(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/stats", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var empty types.SwapStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&empty))
+	require.Len(t, empty.Windows, 2)
+	assert.Zero(t, empty.Windows[0].Swaps)
+	assert.NotNil(t, empty.Windows[0].Pairs)
+
+	for i, success := range []bool{true, true, false} {
+		swap := types.ArchivedSwap{
+			RequestID: []string{"swap-1", "swap-2", "swap-3"}[i],
+			Request: types.SwapRequest{
+				SourceToken:      types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1},
+				DestinationToken: types.Token{Symbol: "USDC", Decimals: 6, ChainID: 8453},
+				Amount:           big.NewInt(1e18),
+			},
+			Result:   types.SwapResult{Success: success, Fee: types.Fee{TotalFeeUSD: 2}},
+			ClosedAt: time.Now().Add(-time.Hour),
+		}
+		require.NoError(t, s.analytics.RecordSwap(context.Background(), swap))
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/v1/stats", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stats types.SwapStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	require.Len(t, stats.Windows, 2)
+	for _, window := range stats.Windows {
+		assert.Equal(t, int64(3), window.Swaps)
+		assert.InDelta(t, 2.0/3, window.SuccessRate, 1e-9)
+		assert.Equal(t, 2.0, window.AverageFeeUSD)
+		require.Len(t, window.Pairs, 1)
+		assert.Equal(t, "uETH/USDC", window.Pairs[0].Pair)
+		require.Len(t, window.Chains, 1)
+		assert.Equal(t, "Ethereum", window.Chains[0].ChainName)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// maxTokensLimit is the most tokens returned in one page
+const maxTokensLimit = 1000
+
+// tokenQuery filters and pages the token list
+type tokenQuery struct {
+	search  string // lowercased; matches symbols and names by substring and addresses exactly
+	symbol  string // matches a token's symbol or its underlying token's symbol, in any case
+	chainID int64
+	wrapped *bool
+	limit   int       // 0 returns every match
+	after   *tokenKey // the last token of the previous page
+}
+
+// tokenKey is a token's place in the token list, which is sorted by chain, then symbol, then address
+type tokenKey struct {
+	chainID int64
+	symbol  string
+	address string
+}
+
+func keyOfToken(token types.Token) tokenKey {
+	return tokenKey{chainID: token.ChainID, symbol: token.Symbol, address: strings.ToLower(token.Address)}
+}
+
+// less reports whether k sorts before other
+func (k tokenKey) less(other tokenKey) bool {
+	if k.chainID != other.chainID {
+		return k.chainID < other.chainID
+	}
+	if k.symbol != other.symbol {
+		return k.symbol < other.symbol
+	}
+	return k.address < other.address
+}
+
+// cursor encodes the key as an opaque page cursor
+func (k tokenKey) cursor() string {
+	raw := strconv.FormatInt(k.chainID, 10) + "\n" + k.symbol + "\n" + k.address
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// parseTokenCursor decodes a cursor made by tokenKey.cursor
+func parseTokenCursor(cursor string) (tokenKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return tokenKey{}, errors.New("invalid cursor")
+	}
+	parts := strings.Split(string(raw), "\n")
+	if len(parts) != 3 {
+		return tokenKey{}, errors.New("invalid cursor")
+	}
+	chainID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return tokenKey{}, errors.New("invalid cursor")
+	}
+	return tokenKey{chainID: chainID, symbol: parts[1], address: parts[2]}, nil
+}
+
+// parseTokenQuery reads the token list's search, symbol, chainId, wrapped, limit and cursor parameters
+func parseTokenQuery(values url.Values) (tokenQuery, error) {
+	query := tokenQuery{
+		search: strings.ToLower(strings.TrimSpace(values.Get("search"))),
+		symbol: strings.TrimSpace(values.Get("symbol")),
+	}
+	if raw := values.Get("chainId"); raw != "" {
+		chainID, err := types.ParseChainID(raw)
+		if err != nil {
+			return tokenQuery{}, fmt.Errorf("invalid chainId: %w", err)
+		}
+		query.chainID = chainID
+	}
+	if raw := values.Get("wrapped"); raw != "" {
+		wrapped, err := strconv.ParseBool(raw)
+		if err != nil {
+			return tokenQuery{}, fmt.Errorf("invalid wrapped: %q; must be true or false", raw)
+		}
+		query.wrapped = &wrapped
+	}
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxTokensLimit {
+			return tokenQuery{}, fmt.Errorf("invalid limit: %q; must be 1 to %d", raw, maxTokensLimit)
+		}
+		query.limit = limit
+	}
+	if raw := values.Get("cursor"); raw != "" {
+		after, err := parseTokenCursor(raw)
+		if err != nil {
+			return tokenQuery{}, err
+		}
+		query.after = &after
+	}
+	return query, nil
+}
+
+// matches reports whether a token passes the query's filters
+func (q tokenQuery) matches(token types.Token) bool {
+	if q.chainID != 0 && token.ChainID != q.chainID {
+		return false
+	}
+	if q.wrapped != nil && token.IsWrapped != *q.wrapped {
+		return false
+	}
+	if q.symbol != "" && !strings.EqualFold(token.Symbol, q.symbol) && !strings.EqualFold(token.UnderlyingSymbol(), q.symbol) {
+		return false
+	}
+	if q.search != "" &&
+		!strings.Contains(strings.ToLower(token.Symbol), q.search) &&
+		!strings.Contains(strings.ToLower(token.Name), q.search) &&
+		!strings.EqualFold(token.Address, q.search) {
+		return false
+	}
+	return true
+}
+
+// page returns the tokens matching the query after its cursor, up to its limit, and the cursor of the next page when
+// more tokens match. The tokens must be in token list order.
+func (q tokenQuery) page(tokens []types.TokenDetails) ([]types.TokenDetails, string) {
+	page := make([]types.TokenDetails, 0, len(tokens))
+	for _, token := range tokens {
+		if q.after != nil && !q.after.less(keyOfToken(token.Token)) {
+			continue
+		}
+		if !q.matches(token.Token) {
+			continue
+		}
+		if q.limit > 0 && len(page) == q.limit {
+			return page, keyOfToken(page[len(page)-1].Token).cursor()
+		}
+		page = append(page, token)
+	}
+	return page, ""
+}
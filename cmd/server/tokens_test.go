@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokensQuery(t *testing.T) {
+	s := newTestServer(t)
+	s.priceCacheDir = ""
+	s.universalSDK = universalsdk.NewMockSDK(universalsdk.MockSDKConfig{
+		WrappedTokens: map[int64][]types.Token{
+			1: {
+				{Symbol: "uETH", Name: "Universal ETH", Decimals: 18, ChainID: 1, Address: "0x01", IsWrapped: true},
+				{Symbol: "uUSDC", Name: "Universal USD Coin", Decimals: 6, ChainID: 1, Address: "0x02", IsWrapped: true},
+				{Symbol: "DAI", Name: "Dai Stablecoin", Decimals: 18, ChainID: 1, Address: "0x03"},
+			},
+			137: {
+				{Symbol: "uETH", Name: "Universal ETH", Decimals: 18, ChainID: 137, Address: "0x11", IsWrapped: true},
+				{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: 137, Address: "0x12"},
+			},
+		},
+	})
+
+	listTokens := func(t *testing.T, query string) TokensResponse {
+		t.Helper()
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/tokens"+query, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp TokensResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return resp
+	}
+	symbols := func(tokens []types.TokenDetails) []string {
+		result := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			result = append(result, token.Symbol)
+		}
+		return result
+	}
+
+	t.Run("SortedByChainThenSymbol", func(t *testing.T) {
+		resp := listTokens(t, "")
+		assert.Equal(t, []string{"DAI", "uETH", "uUSDC", "USDC", "uETH"}, symbols(resp.Tokens))
+		assert.Empty(t, resp.NextCursor)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		assert.Equal(t, []string{"uUSDC", "USDC"}, symbols(listTokens(t, "?search=usd%20coin").Tokens))
+		assert.Equal(t, []string{"DAI"}, symbols(listTokens(t, "?search=0X03").Tokens))
+		assert.Equal(t, []string{"uETH", "uETH"}, symbols(listTokens(t, "?symbol=eth").Tokens))
+		assert.Equal(t, []string{"DAI", "USDC"}, symbols(listTokens(t, "?wrapped=false").Tokens))
+		assert.Equal(t, []string{"USDC", "uETH"}, symbols(listTokens(t, "?chainId=eip155:137").Tokens))
+		assert.Equal(t, []string{"uUSDC"}, symbols(listTokens(t, "?search=usd&wrapped=true").Tokens))
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		var pages [][]string
+		cursor := ""
+		for {
+			query := "?limit=2"
+			if cursor != "" {
+				query += "&cursor=" + cursor
+			}
+			resp := listTokens(t, query)
+			pages = append(pages, symbols(resp.Tokens))
+			if resp.NextCursor == "" {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+		assert.Equal(t, [][]string{{"DAI", "uETH"}, {"uUSDC", "USDC"}, {"uETH"}}, pages)
+
+		// Filters apply to every page
+		first := listTokens(t, "?wrapped=true&limit=2")
+		assert.Equal(t, []string{"uETH", "uUSDC"}, symbols(first.Tokens))
+		second := listTokens(t, "?wrapped=true&limit=2&cursor="+first.NextCursor)
+		assert.Equal(t, []string{"uETH"}, symbols(second.Tokens))
+		assert.Equal(t, int64(137), second.Tokens[0].ChainID)
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("RejectsInvalidQueries", func(t *testing.T) {
+		for _, query := range []string{"?limit=0", "?limit=1001", "?limit=many", "?wrapped=maybe", "?cursor=!!", "?chainId=nowhere"} {
+			rec := doRequest(t, s, http.MethodGet, "/api/v1/tokens"+query, nil, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"golang.org/x/net/websocket"
+)
+
+// Transfer hops, in the order funds move through them
+const (
+	hopSource      = "source"
+	hopBridge      = "bridge"
+	hopDestination = "destination"
+)
+
+// Hop states
+const (
+	hopWaiting   = "waiting"   // Not started
+	hopPending   = "pending"   // Submitted, awaiting confirmation
+	hopCompleted = "completed" // Confirmed
+	hopFailed    = "failed"
+)
+
+// transferHopDurations are typical hop durations used to estimate arrival
+var transferHopDurations = map[string]time.Duration{
+	hopSource:      2 * time.Minute,
+	hopBridge:      10 * time.Minute,
+	hopDestination: 2 * time.Minute,
+}
+
+// TransferHop is one leg of a cross-chain transfer
+type TransferHop struct {
+	Name   string `json:"name"` // source, bridge or destination
+	Chain  string `json:"chain,omitempty"`
+	TxHash string `json:"txHash,omitempty"`
+	Status string `json:"status"` // waiting, pending, completed or failed
+}
+
+// TransferResponse is the bridge-level view of a transfer returned by the transfers endpoint
+type TransferResponse struct {
+	TransactionID string        `json:"transactionId"`
+	Status        string        `json:"status"` // pending, completed or failed
+	CurrentHop    string        `json:"currentHop,omitempty"`
+	Hops          []TransferHop `json:"hops"`
+	StartedAt     *time.Time    `json:"startedAt,omitempty"`
+	CompletedAt   *time.Time    `json:"completedAt,omitempty"`
+	ETA           *time.Time    `json:"eta,omitempty"`
+	ErrorMessage  string        `json:"errorMessage,omitempty"`
+}
+
+// transferHandler reports where a transfer's funds are, combining the transactions
+// recorded by the transaction service with the bridge status from Universal
+func (s *Server) transferHandler(w http.ResponseWriter, r *http.Request) {
+	transactionID := r.PathValue("transactionId")
+	indexed := s.indexedTransfer(r.Context(), transactionID)
+
+	status, err := s.universalSDK.GetTransactionStatus(r.Context(), transactionID)
+	if err != nil {
+		log.Printf("Failed to get bridge status for %s: %v", transactionID, err)
+		status = nil
+	}
+
+	if len(indexed) == 0 && status == nil {
+		errorResponse(w, http.StatusNotFound, "transfer not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, buildTransferResponse(transactionID, indexed, status, time.Now().UTC()))
+}
+
+// indexedTransfer returns the transactions the transaction service recorded for a transfer
+func (s *Server) indexedTransfer(ctx context.Context, transactionID string) []types.Transaction {
+	// Swaps record their source and destination transactions under the swap request ID
+	indexed, _ := s.transactionService.GetTransactionsByWorkflowID(ctx, transactionID)
+	if len(indexed) == 0 {
+		if tx, err := s.transactionService.GetTransaction(ctx, transactionID); err == nil {
+			indexed = []types.Transaction{*tx}
+		}
+	}
+	return indexed
+}
+
+// transferFeedHandler streams a transfer's view over a WebSocket: the current one, then a new one each time Universal
+// reports the bridge status changing. The socket is closed once the transfer completes or fails.
+func (s *Server) transferFeedHandler(w http.ResponseWriter, r *http.Request) {
+	transactionID := r.PathValue("transactionId")
+	// The watch lasts as long as the connection
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	indexed := s.indexedTransfer(ctx, transactionID)
+	statuses, err := s.universalSDK.WatchTransaction(ctx, transactionID)
+	if err != nil {
+		log.Printf("Failed to watch bridge status for %s: %v", transactionID, err)
+		if len(indexed) == 0 {
+			errorResponse(w, http.StatusNotFound, "transfer not found")
+			return
+		}
+	}
+
+	server := websocket.Server{
+		Handshake: s.checkFeedOrigin,
+		Handler: func(ws *websocket.Conn) {
+			s.serveTransferFeed(ctx, ws, transactionID, indexed, statuses)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// serveTransferFeed sends the transfer's view for each status it's watched through, until the transfer is final or
+// the client disconnects. Without a watch, the view of its recorded transactions is sent alone.
+func (s *Server) serveTransferFeed(ctx context.Context, ws *websocket.Conn, transactionID string, indexed []types.Transaction, statuses <-chan universalsdk.TransactionStatus) {
+	defer ws.Close()
+
+	// Feed connections outlive the HTTP server's request timeouts
+	ws.SetDeadline(time.Time{})
+
+	if statuses == nil {
+		_ = sendTransfer(ws, buildTransferResponse(transactionID, indexed, nil, time.Now().UTC()))
+		return
+	}
+
+	// Clients send nothing; reading notices when they go away
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var discard json.RawMessage
+		for websocket.JSON.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		case status, ok := <-statuses:
+			if !ok {
+				return
+			}
+			// Swaps record their destination transaction while the bridge delivers
+			indexed = s.indexedTransfer(ctx, transactionID)
+			if err := sendTransfer(ws, buildTransferResponse(transactionID, indexed, &status, time.Now().UTC())); err != nil {
+				return
+			}
+			if universalsdk.IsFinalStatus(status.Status) {
+				return
+			}
+		}
+	}
+}
+
+// sendTransfer writes a transfer's view to a feed client
+func sendTransfer(ws *websocket.Conn, resp TransferResponse) error {
+	ws.SetWriteDeadline(time.Now().Add(priceFeedWriteTimeout))
+	return websocket.JSON.Send(ws, resp)
+}
+
+// buildTransferResponse merges indexed transactions and bridge status into a hop-by-hop view.
+// status may be nil when the bridge could not be queried.
+func buildTransferResponse(transactionID string, indexed []types.Transaction, status *universalsdk.TransactionStatus, now time.Time) TransferResponse {
+	resp := TransferResponse{TransactionID: transactionID}
+
+	hops := []TransferHop{{Name: hopSource}, {Name: hopBridge}, {Name: hopDestination}}
+	var startedAt time.Time
+	for _, tx := range indexed {
+		switch tx.Type {
+		case "swap_dest":
+			hops[2].Chain = tx.DestChain
+			hops[2].TxHash = tx.Hash
+		default:
+			hops[0].Chain = tx.SourceChain
+			hops[0].TxHash = tx.Hash
+			if hops[2].Chain == "" {
+				hops[2].Chain = tx.DestChain
+			}
+		}
+		if startedAt.IsZero() || tx.Timestamp.Before(startedAt) {
+			startedAt = tx.Timestamp
+		}
+	}
+	if !startedAt.IsZero() {
+		resp.StartedAt = &startedAt
+	}
+
+	resp.Status = indexedStatus(indexed)
+	if status != nil {
+		resp.Status = status.Status
+		resp.ErrorMessage = status.ErrorMessage
+		if hops[0].TxHash == "" {
+			hops[0].TxHash = status.SourceTxHash
+		}
+		hops[1].TxHash = status.BridgeTxHash
+		if status.DestTxHash != "" {
+			hops[2].TxHash = status.DestTxHash
+		}
+		if !status.CompletionTime.IsZero() {
+			completedAt := status.CompletionTime
+			resp.CompletedAt = &completedAt
+		}
+	}
+
+	// A hop is done once a later hop has a transaction; the last submitted hop is in flight
+	for i := range hops {
+		switch {
+		case resp.Status == "completed":
+			hops[i].Status = hopCompleted
+		case hops[i].TxHash == "":
+			hops[i].Status = hopWaiting
+		default:
+			hops[i].Status = hopPending
+			for _, later := range hops[i+1:] {
+				if later.TxHash != "" {
+					hops[i].Status = hopCompleted
+					break
+				}
+			}
+		}
+	}
+
+	if resp.Status != "completed" {
+		// The first unfinished hop is where the funds are
+		current := 0
+		for current < len(hops)-1 && hops[current].Status == hopCompleted {
+			current++
+		}
+		resp.CurrentHop = hops[current].Name
+
+		if resp.Status == "failed" {
+			hops[current].Status = hopFailed
+		} else {
+			var remaining time.Duration
+			for _, hop := range hops[current:] {
+				remaining += transferHopDurations[hop.Name]
+			}
+			eta := now.Add(remaining)
+			resp.ETA = &eta
+		}
+	}
+
+	resp.Hops = hops
+	return resp
+}
+
+// indexedStatus derives a transfer status from its recorded transactions
+func indexedStatus(indexed []types.Transaction) string {
+	if len(indexed) == 0 {
+		return "pending"
+	}
+	for _, tx := range indexed {
+		if tx.Status == "failed" {
+			return "failed"
+		}
+	}
+	for _, tx := range indexed {
+		if tx.Status != "completed" {
+			return "pending"
+		}
+	}
+	return "completed"
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// statusSDK is a Universal SDK returning set transaction statuses, and watching them by polling
+type statusSDK struct {
+	universalsdk.SDK
+	mu       sync.Mutex
+	statuses map[string]*universalsdk.TransactionStatus
+}
+
+func (s *statusSDK) GetTransactionStatus(ctx context.Context, transactionID string) (*universalsdk.TransactionStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, ok := s.statuses[transactionID]; ok {
+		return status, nil
+	}
+	return nil, errors.New("transaction not found")
+}
+
+func (s *statusSDK) WatchTransaction(ctx context.Context, transactionID string) (<-chan universalsdk.TransactionStatus, error) {
+	return universalsdk.PollTransaction(ctx, s, transactionID, 10*time.Millisecond)
+}
+
+func (s *statusSDK) setStatus(status *universalsdk.TransactionStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[status.TransactionID] = status
+}
+
+func TestTransferEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	sdk := &statusSDK{SDK: s.universalSDK, statuses: map[string]*universalsdk.TransactionStatus{
+		"bridging": {TransactionID: "bridging", Status: "pending", SourceTxHash: "0xsrc", BridgeTxHash: "0xbridge"},
+		"done":     {TransactionID: "done", Status: "completed", SourceTxHash: "0xsrc", BridgeTxHash: "0xbridge", DestTxHash: "0xdst", CompletionTime: time.Now()},
+		"failed":   {TransactionID: "failed", Status: "failed", SourceTxHash: "0xsrc", ErrorMessage: "bridge rejected transfer"},
+	}}
+	s.universalSDK = sdk
+
+	getTransfer := func(t *testing.T, id string) TransferResponse {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/transfers/"+id, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp TransferResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return resp
+	}
+
+	t.Run("InFlight", func(t *testing.T) {
+		resp := getTransfer(t, "bridging")
+		assert.Equal(t, "pending", resp.Status)
+		assert.Equal(t, hopBridge, resp.CurrentHop)
+		require.Len(t, resp.Hops, 3)
+		assert.Equal(t, hopCompleted, resp.Hops[0].Status)
+		assert.Equal(t, hopPending, resp.Hops[1].Status)
+		assert.Equal(t, hopWaiting, resp.Hops[2].Status)
+		require.NotNil(t, resp.ETA)
+		assert.True(t, resp.ETA.After(time.Now()))
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		resp := getTransfer(t, "done")
+		assert.Empty(t, resp.CurrentHop)
+		assert.Nil(t, resp.ETA)
+		assert.NotNil(t, resp.CompletedAt)
+		for _, hop := range resp.Hops {
+			assert.Equal(t, hopCompleted, hop.Status)
+		}
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		resp := getTransfer(t, "failed")
+		assert.Equal(t, hopSource, resp.CurrentHop)
+		assert.Equal(t, hopFailed, resp.Hops[0].Status)
+		assert.Equal(t, "bridge rejected transfer", resp.ErrorMessage)
+		assert.Nil(t, resp.ETA)
+	})
+
+	t.Run("Indexed", func(t *testing.T) {
+		// Known to the transaction service but not yet to the bridge
+		_, err := s.transactionService.CreateTransaction(context.Background(), types.Transaction{
+			ID: "tx-1", Type: "swap_source", Hash: "0xindexed", Status: "pending", SourceChain: "Ethereum", DestChain: "Ethereum",
+			WorkflowID: "swap-1", Timestamp: time.Now(),
+		})
+		require.NoError(t, err)
+
+		resp := getTransfer(t, "swap-1")
+		assert.Equal(t, "pending", resp.Status)
+		assert.Equal(t, hopSource, resp.CurrentHop)
+		assert.Equal(t, "0xindexed", resp.Hops[0].TxHash)
+		assert.Equal(t, "Ethereum", resp.Hops[0].Chain)
+		assert.NotNil(t, resp.StartedAt)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/transfers/unknown", nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestTransferFeed(t *testing.T) {
+	s := newTestServer(t)
+	sdk := &statusSDK{SDK: s.universalSDK, statuses: map[string]*universalsdk.TransactionStatus{
+		"bridging": {TransactionID: "bridging", Status: "pending", SourceTxHash: "0xsrc", BridgeTxHash: "0xbridge"},
+	}}
+	s.universalSDK = sdk
+
+	httpServer := httptest.NewServer(s)
+	defer httpServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/api/v1/transfers/bridging/ws"
+	ws, err := websocket.Dial(wsURL, "", httpServer.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	// The current view comes first
+	var resp TransferResponse
+	require.NoError(t, websocket.JSON.Receive(ws, &resp))
+	assert.Equal(t, "pending", resp.Status)
+	assert.Equal(t, hopBridge, resp.CurrentHop)
+
+	// The bridge completing is pushed, then the feed ends
+	sdk.setStatus(&universalsdk.TransactionStatus{TransactionID: "bridging", Status: "completed", SourceTxHash: "0xsrc", BridgeTxHash: "0xbridge", DestTxHash: "0xdst", CompletionTime: time.Now()})
+	require.NoError(t, websocket.JSON.Receive(ws, &resp))
+	assert.Equal(t, "completed", resp.Status)
+	assert.Equal(t, "0xdst", resp.Hops[2].TxHash)
+	assert.ErrorIs(t, websocket.JSON.Receive(ws, &resp), io.EOF)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/transfers/unknown/ws", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
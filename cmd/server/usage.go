@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+// usageFlushInterval is how often metered API usage is added to the usage store
+const usageFlushInterval = time.Minute
+
+// usageCSVHeader is the header row of CSV usage exports. Endpoint rows fill the request columns and swap rows the
+// volume columns.
+var usageCSVHeader = []string{"kind", "key_id", "tenant", "month", "endpoint", "requests", "egress_bytes", "chain_id", "token", "swaps", "amount"}
+
+// meteredWriter counts the response bytes written through it
+type meteredWriter struct {
+	http.ResponseWriter
+	bytes int64
+}
+
+// Write counts and writes response bytes
+func (w *meteredWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush flushes the underlying writer, so streamed responses keep streaming
+func (w *meteredWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hands the connection to WebSocket handlers. Bytes sent over a hijacked connection are not counted.
+func (w *meteredWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer cannot be hijacked")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *meteredWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// SetUsageStore sets the store metered API usage is flushed to
+func (s *Server) SetUsageStore(store services.UsageStore) {
+	s.usage.SetStore(store)
+}
+
+// meteredKey returns the fingerprint and tenant of a request's API key. Only tenant and sandbox keys are metered;
+// admin keys and keys the server doesn't know are not.
+func (s *Server) meteredKey(r *http.Request) (keyID, tenant string, ok bool) {
+	key := r.Header.Get(apiKeyHeader)
+	if tenant, ok := s.tenantKeys[key]; ok {
+		return keyOwner(key), tenant, true
+	}
+	if s.sandboxKeys[key] {
+		return keyOwner(key), "", true
+	}
+	return "", "", false
+}
+
+// serveMetered serves a request from a metered key, counting it against the route it matched
+func (s *Server) serveMetered(w http.ResponseWriter, r *http.Request, keyID, tenant string) {
+	// Compressed responses are metered by the bytes sent
+	metered := &meteredWriter{ResponseWriter: w}
+	s.serveCompressed(metered, r)
+	// The mux sets the pattern of the route it served; unmatched requests aren't billed
+	if r.Pattern != "" {
+		s.usage.RecordRequest(keyID, tenant, r.Pattern, metered.bytes)
+	}
+}
+
+// recordSwapUsage counts a started swap against the request's API key
+func (s *Server) recordSwapUsage(r *http.Request, request types.SwapRequest) {
+	if keyID, tenant, ok := s.meteredKey(r); ok {
+		s.usage.RecordSwap(keyID, tenant, request.SourceToken, request.Amount)
+	}
+}
+
+// adminUsageHandler exports every API key's usage in a month (?month=YYYY-MM, default the current month) as JSON,
+// or as CSV with ?format=csv
+func (s *Server) adminUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().UTC().Format(types.UsageMonthFormat)
+	} else if _, err := time.Parse(types.UsageMonthFormat, month); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid month %q: must be YYYY-MM", month))
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid format %q: must be json or csv", format))
+		return
+	}
+
+	report, err := s.usage.Report(r.Context(), month)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to load usage: %v", err))
+		return
+	}
+
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, report)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"usage-%s.csv\"", month))
+	writer := csv.NewWriter(w)
+	writer.Write(usageCSVHeader)
+	for _, u := range report.Endpoints {
+		writer.Write([]string{"endpoint", u.KeyID, u.Tenant, u.Month, u.Endpoint, strconv.FormatInt(u.Requests, 10), strconv.FormatInt(u.EgressBytes, 10), "", "", "", ""})
+	}
+	for _, v := range report.SwapVolume {
+		writer.Write([]string{"swap", v.KeyID, v.Tenant, v.Month, "", "", "", strconv.FormatInt(v.ChainID, 10), v.Token, strconv.FormatInt(v.Swaps, 10), v.Amount.String()})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageMetering(t *testing.T) {
+	const adminKey = "admin-test-key"
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "billing@infinity-dex"
+	s.tenantKeys["acme-key"] = "acme"
+
+	rec := doRequest(t, s, http.MethodGet, "/api/v1/tokens", nil, "acme-key")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	tokensBytes := int64(rec.Body.Len())
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", testSwapBody(), "acme-key")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	// Requests without a known key aren't metered
+	doRequest(t, s, http.MethodGet, "/api/v1/tokens", nil, "")
+	doRequest(t, s, http.MethodGet, "/api/v1/tokens", nil, "unknown-key")
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		// Refused requests are metered too
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/usage", nil, "acme-key")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/usage", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var report types.UsageReport
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+
+		assert.Equal(t, time.Now().UTC().Format(types.UsageMonthFormat), report.Month)
+		require.Len(t, report.Endpoints, 3)
+		tokens := report.Endpoints[1]
+		assert.Equal(t, keyOwner("acme-key"), tokens.KeyID)
+		assert.Equal(t, "acme", tokens.Tenant)
+		assert.Equal(t, "GET /api/v1/tokens", tokens.Endpoint)
+		assert.Equal(t, int64(1), tokens.Requests)
+		assert.Equal(t, tokensBytes, tokens.EgressBytes)
+
+		require.Len(t, report.SwapVolume, 1)
+		assert.Equal(t, "ETH", report.SwapVolume[0].Token)
+		assert.Equal(t, "1000000000000000000", report.SwapVolume[0].Amount.String())
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/usage?format=csv", nil, adminKey)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		rows, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 5)
+		assert.Equal(t, usageCSVHeader, rows[0])
+		assert.Equal(t, []string{"swap", keyOwner("acme-key"), "acme", rows[4][3], "", "", "", "1", "ETH", "1", "1000000000000000000"}, rows[4])
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rec := doRequest(t, s, http.MethodGet, "/api/v1/admin/usage?month=october", nil, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec = doRequest(t, s, http.MethodGet, "/api/v1/admin/usage?format=xml", nil, adminKey)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// UserOperationResponse is returned by the user operation endpoint: the unsigned operation carrying out a swap from a
+// smart account, and the hash the account's owner signs
+type UserOperationResponse struct {
+	UserOperation *chains.UserOperation `json:"userOperation"`
+	Hash          string                `json:"hash"`
+	EntryPoint    string                `json:"entryPoint"`
+}
+
+// newBundler creates the bundler smart account swaps are built and checked with, or nil when no chain has one
+func newBundler(cfg temporal_config.Config, node evm.RPC) chains.UserOperationSender {
+	urls := cfg.BundlerURLs()
+	if len(urls) == 0 {
+		return nil
+	}
+	return evm.NewBundler(node, temporal_activities.NewEVMRPCClient(&http.Client{Timeout: 10 * time.Second}, urls))
+}
+
+// swapContracts returns the contracts swaps are executed through on each EVM chain
+func (s *Server) swapContracts() map[int64]temporal_activities.ChainContracts {
+	contracts := make(map[int64]temporal_activities.ChainContracts)
+	for _, chain := range s.config().Chains {
+		if info, ok := types.GetChain(chain.ChainID); ok && info.Namespace == "eip155" {
+			contracts[chain.ChainID] = temporal_activities.ChainContracts{Universal: chain.UniversalAddress, DEX: chain.DEXAddress}
+		}
+	}
+	return contracts
+}
+
+// hasBundler reports whether swaps from smart accounts on the chain go through a bundler
+func (s *Server) hasBundler(chainID int64) bool {
+	return s.bundler != nil && len(s.config().BundlerURLs()[types.CanonicalChainID(chainID)]) > 0
+}
+
+// checkUserOperationSwap checks a swap can be sent as a user operation: it stays on one chain that has a bundler and
+// a DEX contract
+func (s *Server) checkUserOperationSwap(request types.SwapRequest) error {
+	chainID := types.CanonicalChainID(request.SourceToken.ChainID)
+	if !s.hasBundler(chainID) {
+		return errors.New("the source chain does not accept user operations")
+	}
+	if chainID != types.CanonicalChainID(request.DestinationToken.ChainID) || s.swapContracts()[chainID].DEX == "" {
+		return errors.New("only same-chain swaps on chains with a DEX contract can be sent from smart accounts")
+	}
+	return nil
+}
+
+// checkSourceAccount checks a swap from a smart account carries a signed user operation doing exactly the swap.
+// Swaps from externally owned accounts are left as they are.
+func (s *Server) checkSourceAccount(ctx context.Context, request types.SwapRequest) (int, error) {
+	if request.UserOperation != nil {
+		if err := s.checkUserOperationSwap(request); err != nil {
+			return http.StatusBadRequest, err
+		}
+		if err := temporal_activities.CheckSwapUserOperation(s.swapContracts(), request); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid userOperation: %w", err)
+		}
+		return 0, nil
+	}
+	if !s.hasBundler(request.SourceToken.ChainID) {
+		return 0, nil
+	}
+
+	accountType, err := s.bundler.AccountType(ctx, types.CanonicalChainID(request.SourceToken.ChainID), request.SourceAddress)
+	if err != nil {
+		log.Printf("Failed to look up the account type of %s: %v", request.SourceAddress, err)
+		return http.StatusServiceUnavailable, errors.New("failed to look up the source account")
+	}
+	if accountType == chains.AccountTypeSmart {
+		return http.StatusBadRequest, errors.New("swaps from smart accounts need a signed userOperation; build one with POST /api/v1/swap/user-operation")
+	}
+	return 0, nil
+}
+
+// buildUserOperationHandler builds the unsigned user operation a smart account sends a swap with. The account's owner
+// signs the returned hash and submits the swap with the signed operation.
+func (s *Server) buildUserOperationHandler(w http.ResponseWriter, r *http.Request) {
+	request, _, err := decodeSwapRequest(r, s.config().Swap.DefaultSlippage)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if status, err := s.checkSwapChains(request); err != nil {
+		serviceErrorResponse(w, status, err)
+		return
+	}
+
+	if err := s.checkUserOperationSwap(request); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.MinOutputAmount == nil {
+		errorResponse(w, http.StatusBadRequest, "minOutputAmount is required: the signed operation fixes the swap's minimum output")
+		return
+	}
+
+	chainID := types.CanonicalChainID(request.SourceToken.ChainID)
+	call, err := temporal_activities.SwapCall(s.swapContracts(), request, request.MinOutputAmount)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	op, err := s.bundler.BuildUserOperation(r.Context(), request.SourceAddress, call)
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, fmt.Sprintf("failed to build user operation: %v", err))
+		return
+	}
+	hash, err := s.bundler.UserOperationHash(chainID, op)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, UserOperationResponse{
+		UserOperation: op,
+		Hash:          "0x" + hex.EncodeToString(hash),
+		EntryPoint:    evm.EntryPointV07,
+	})
+}
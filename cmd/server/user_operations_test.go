@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/chains/evm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBundler treats the accounts in smart as smart accounts and hashes operations by their call data
+type fakeBundler struct {
+	smart map[string]bool
+}
+
+func (f *fakeBundler) AccountType(ctx context.Context, chainID int64, address string) (chains.AccountType, error) {
+	if f.smart[address] {
+		return chains.AccountTypeSmart, nil
+	}
+	return chains.AccountTypeEOA, nil
+}
+
+func (f *fakeBundler) BuildUserOperation(ctx context.Context, sender string, req chains.TxRequest) (*chains.UserOperation, error) {
+	callData, err := evm.AccountCallData(req)
+	if err != nil {
+		return nil, err
+	}
+	return &chains.UserOperation{Sender: sender, Nonce: big.NewInt(3), CallData: callData}, nil
+}
+
+func (f *fakeBundler) UserOperationHash(chainID int64, op *chains.UserOperation) ([]byte, error) {
+	return evm.Keccak256(op.CallData), nil
+}
+
+func (f *fakeBundler) SendUserOperation(ctx context.Context, chainID int64, op *chains.UserOperation) (string, error) {
+	return "", nil
+}
+
+func (f *fakeBundler) WaitForUserOperation(ctx context.Context, chainID int64, hash string) (*chains.Receipt, error) {
+	return nil, nil
+}
+
+func TestSmartAccountSwaps(t *testing.T) {
+	s := newTestServer(t)
+	ethereum := s.config().Chains["ethereum"]
+	ethereum.DEXAddress = "0x2222222222222222222222222222222222222222"
+	ethereum.BundlerURL = "http://bundler.invalid"
+	s.config().Chains["ethereum"] = ethereum
+
+	body := testSwapBody()
+	body.DestinationToken.Address = "0x4444444444444444444444444444444444444444"
+	s.bundler = &fakeBundler{smart: map[string]bool{body.SourceAddress: true}}
+
+	// Smart accounts can't swap without a user operation
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "userOperation")
+
+	// The operation fixes the minimum output, so one is required
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/user-operation", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	body.MinOutputAmount = "1500000000"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap/user-operation", body, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var built UserOperationResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&built))
+	assert.Equal(t, evm.EntryPointV07, built.EntryPoint)
+	assert.Equal(t, "0x"+hex.EncodeToString(evm.Keccak256(built.UserOperation.CallData)), built.Hash)
+
+	// Unsigned operations are refused
+	body.UserOperation = built.UserOperation
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	built.UserOperation.Signature = []byte{0x01}
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	// An operation signed for another swap is refused
+	tampered := body
+	tampered.MinOutputAmount = "1"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", tampered, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	// Externally owned accounts swap as before
+	eoa := testSwapBody()
+	eoa.SourceAddress = "0x9999999999999999999999999999999999999999"
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/swap", eoa, "")
+	assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+}
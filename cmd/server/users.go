@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+const (
+	// walletSignatureHeader carries a hex signature proving the caller owns the wallet whose swaps they view
+	walletSignatureHeader = "X-Wallet-Signature"
+
+	// walletSignatureExpiresHeader carries the Unix time the wallet signature expires, which the signature covers
+	walletSignatureExpiresHeader = "X-Wallet-Signature-Expires"
+
+	// walletSignatureSchemeHeader names how the wallet signature was made: eip191 (the default) or eip712
+	walletSignatureSchemeHeader = "X-Wallet-Signature-Scheme"
+
+	// defaultUserSwapsLimit is the number of swaps returned when no limit is given
+	defaultUserSwapsLimit = 50
+
+	// maxUserSwapsLimit is the most swaps returned at once
+	maxUserSwapsLimit = 200
+)
+
+// UserSwapsResponse is returned by the user swap history endpoint
+type UserSwapsResponse struct {
+	Address string `json:"address"`
+	// Authenticated is set when a valid wallet signature was sent, so the swaps include their private details
+	Authenticated bool                 `json:"authenticated"`
+	Swaps         []types.ArchivedSwap `json:"swaps"`
+}
+
+// userSwapsHandler returns the finished swaps an address sent or received, most recent first. Refund and deposit
+// addresses and user operations are only included for callers that prove they own the address with a wallet
+// signature.
+func (s *Server) userSwapsHandler(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	if s.swapArchive == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "swap archive unavailable")
+		return
+	}
+
+	limit := defaultUserSwapsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxUserSwapsLimit {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q; must be 1 to %d", raw, maxUserSwapsLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	authenticated := false
+	if r.Header.Get(walletSignatureHeader) != "" {
+		if err := s.verifyWalletSignature(r, address); err != nil {
+			codedErrorResponse(w, ErrorCodeUnauthorized, err.Error())
+			return
+		}
+		authenticated = true
+	}
+
+	swaps, err := s.swapArchive.ListSwapsByAddress(r.Context(), address, limit)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to list swaps: %v", err))
+		return
+	}
+	if !authenticated {
+		for i := range swaps {
+			redactSwap(&swaps[i])
+		}
+	}
+
+	writeJSON(w, http.StatusOK, UserSwapsResponse{Address: address, Authenticated: authenticated, Swaps: swaps})
+}
+
+// verifyWalletSignature checks the request's wallet signature was made by address and hasn't expired
+func (s *Server) verifyWalletSignature(r *http.Request, address string) error {
+	signature, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(walletSignatureHeader), "0x"))
+	if err != nil {
+		return fmt.Errorf("%s must be hex-encoded", walletSignatureHeader)
+	}
+	expires, err := strconv.ParseInt(r.Header.Get(walletSignatureExpiresHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a Unix time", walletSignatureExpiresHeader)
+	}
+	scheme := services.WalletAuthScheme(r.Header.Get(walletSignatureSchemeHeader))
+	if scheme == "" {
+		scheme = services.WalletAuthPersonalSign
+	}
+
+	err = services.VerifyWalletAuth(services.WalletAuth{
+		Address:   address,
+		ExpiresAt: time.Unix(expires, 0),
+		Scheme:    scheme,
+		Signature: signature,
+	}, time.Now())
+	if errors.Is(err, services.ErrWalletAuthExpired) {
+		return fmt.Errorf("%v; sign a new message", err)
+	}
+	return err
+}
+
+// redactSwap removes the details of a swap only its owner may see
+func redactSwap(swap *types.ArchivedSwap) {
+	swap.Request.RefundAddress = ""
+	swap.Request.DepositAddress = ""
+	swap.Request.UserOperation = nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/chains/signer"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getUserSwaps requests an address's swaps with the given wallet signature headers
+func getUserSwaps(t *testing.T, s *Server, path string, headers map[string]string) (*httptest.ResponseRecorder, UserSwapsResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	var response UserSwapsResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	}
+	return rec, response
+}
+
+// walletSignatureHeaders signs a personal_sign wallet auth for key's address
+func walletSignatureHeaders(t *testing.T, key *signer.PrivateKeySigner, expiresAt time.Time) map[string]string {
+	t.Helper()
+	digest := signer.PersonalMessageDigest([]byte(services.WalletAuthMessage(key.Address(), expiresAt)))
+	signature, err := key.SignDigest(context.Background(), digest)
+	require.NoError(t, err)
+	raw := make([]byte, 65)
+	signature.R.FillBytes(raw[:32])
+	signature.S.FillBytes(raw[32:64])
+	raw[64] = 27 + signature.Recovery
+	return map[string]string{
+		walletSignatureHeader:        "0x" + hex.EncodeToString(raw),
+		walletSignatureExpiresHeader: strconv.FormatInt(expiresAt.Unix(), 10),
+	}
+}
+
+func TestUserSwaps(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+
+	rec, _ := getUserSwaps(t, s, "/api/v1/users/0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F/swaps", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	archive := services.NewInMemorySwapArchive()
+	s.SetSwapArchive(archive)
+
+	key, err := signer.NewPrivateKeySigner("4646464646464646464646464646464646464646464646464646464646464646")
+	require.NoError(t, err)
+	owner := key.Address()
+	other := "0x1234567890abcdef1234567890abcdef12345678"
+	closed := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i, swap := range []struct {
+		id, source, destination string
+	}{
+		{"swap-sent", strings.ToLower(owner), other},
+		{"swap-received", other, owner},
+		{"swap-unrelated", other, other},
+	} {
+		require.NoError(t, archive.ArchiveSwap(ctx, types.ArchivedSwap{
+			RequestID: swap.id,
+			Request: types.SwapRequest{
+				RequestID:          swap.id,
+				SourceAddress:      swap.source,
+				DestinationAddress: swap.destination,
+				RefundAddress:      swap.source,
+				DepositAddress:     "0xdeposit",
+			},
+			Result:   types.SwapResult{RequestID: swap.id, Success: true},
+			ClosedAt: closed.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	path := "/api/v1/users/" + owner + "/swaps"
+	rec, response := getUserSwaps(t, s, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.False(t, response.Authenticated)
+	require.Len(t, response.Swaps, 2)
+	// Most recent first, matching the address in any case
+	assert.Equal(t, "swap-received", response.Swaps[0].RequestID)
+	assert.Equal(t, "swap-sent", response.Swaps[1].RequestID)
+	for _, swap := range response.Swaps {
+		assert.Empty(t, swap.Request.RefundAddress)
+		assert.Empty(t, swap.Request.DepositAddress)
+	}
+
+	rec, response = getUserSwaps(t, s, path, walletSignatureHeaders(t, key, time.Now().Add(5*time.Minute)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.True(t, response.Authenticated)
+	require.Len(t, response.Swaps, 2)
+	assert.Equal(t, other, response.Swaps[0].Request.RefundAddress)
+	assert.Equal(t, "0xdeposit", response.Swaps[1].Request.DepositAddress)
+
+	rec, response = getUserSwaps(t, s, path+"?limit=1", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, response.Swaps, 1)
+
+	t.Run("rejects invalid signatures", func(t *testing.T) {
+		// Signed by the owner, but for another address
+		headers := walletSignatureHeaders(t, key, time.Now().Add(5*time.Minute))
+		rec, _ := getUserSwaps(t, s, "/api/v1/users/"+other+"/swaps", headers)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, _ = getUserSwaps(t, s, path, walletSignatureHeaders(t, key, time.Now().Add(-time.Minute)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		headers[walletSignatureSchemeHeader] = string(services.WalletAuthTypedData)
+		rec, _ = getUserSwaps(t, s, path, headers)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, _ = getUserSwaps(t, s, path, map[string]string{walletSignatureHeader: "0xzz", walletSignatureExpiresHeader: "1"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	for _, limit := range []string{"0", "abc", "201"} {
+		rec, _ := getUserSwaps(t, s, path+"?limit="+limit, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "limit %s", limit)
+	}
+}
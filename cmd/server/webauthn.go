@@ -0,0 +1,530 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+const (
+	// adminSessionHeader carries the session issued by a passkey login
+	adminSessionHeader = "X-Admin-Session"
+
+	// enrollmentTokenHeader carries the one-time token enrolling an API key's first passkey needs
+	enrollmentTokenHeader = "X-Enrollment-Token"
+
+	// webAuthnChallengeTTL is how long a ceremony has to complete
+	webAuthnChallengeTTL = 5 * time.Minute
+
+	// Ceremonies a challenge is issued for
+	ceremonyRegister = "register"
+	ceremonyLogin    = "login"
+)
+
+// webAuthnChallenge is an outstanding ceremony challenge
+type webAuthnChallenge struct {
+	value   []byte
+	expires time.Time
+}
+
+// adminSession is a passkey login bound to the admin API key it was made with
+type adminSession struct {
+	owner   string
+	expires time.Time
+}
+
+// AdminGate requires admin API requests to carry a passkey session on top of the API key
+type AdminGate struct {
+	verifier         *services.WebAuthnVerifier
+	store            *services.WebAuthnStore
+	rpName           string
+	sessionTTL       time.Duration
+	enrollmentTokens map[string]bool              // hex SHA-256 of the configured enrollment tokens
+	challenges       map[string]webAuthnChallenge // map[owner/ceremony]challenge
+	sessions         map[string]adminSession      // map[token]session
+	now              func() time.Time
+	mu               sync.Mutex
+}
+
+// NewAdminGate creates a passkey gate from config, returning nil when passkeys are disabled
+func NewAdminGate(cfg temporal_config.WebAuthnConfig) (*AdminGate, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	path := cfg.CredentialsPath
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(homeDir, ".infinity-dex", "webauthn.json")
+	}
+	store, err := services.NewWebAuthnStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionTTL := cfg.SessionTTL
+	if sessionTTL <= 0 {
+		sessionTTL = 15 * time.Minute
+	}
+
+	enrollmentTokens := make(map[string]bool, len(cfg.EnrollmentTokens))
+	for _, hash := range cfg.EnrollmentTokens {
+		enrollmentTokens[strings.ToLower(strings.TrimSpace(hash))] = true
+	}
+
+	return &AdminGate{
+		verifier:         services.NewWebAuthnVerifier(cfg.RPID, cfg.Origins),
+		store:            store,
+		rpName:           cfg.RPName,
+		sessionTTL:       sessionTTL,
+		enrollmentTokens: enrollmentTokens,
+		challenges:       make(map[string]webAuthnChallenge),
+		sessions:         make(map[string]adminSession),
+		now:              time.Now,
+	}, nil
+}
+
+// keyOwner identifies an admin API key without keeping the key itself
+func keyOwner(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// newChallenge issues a challenge for an owner's ceremony, replacing any outstanding one
+func (g *AdminGate) newChallenge(owner, ceremony string) ([]byte, error) {
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.challenges[owner+"/"+ceremony] = webAuthnChallenge{value: value, expires: g.now().Add(webAuthnChallengeTTL)}
+	return value, nil
+}
+
+// takeChallenge consumes an owner's outstanding challenge; each challenge can only be answered once
+func (g *AdminGate) takeChallenge(owner, ceremony string) ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := owner + "/" + ceremony
+	challenge, ok := g.challenges[key]
+	delete(g.challenges, key)
+	if !ok || g.now().After(challenge.expires) {
+		return nil, errors.New("no outstanding challenge; begin the ceremony again")
+	}
+	return challenge.value, nil
+}
+
+// newSession starts a session for owner
+func (g *AdminGate) newSession(owner string) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(raw)
+	expires := g.now().Add(g.sessionTTL).UTC()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Drop expired sessions while we hold the lock
+	now := g.now()
+	for t, session := range g.sessions {
+		if now.After(session.expires) {
+			delete(g.sessions, t)
+		}
+	}
+	g.sessions[token] = adminSession{owner: owner, expires: expires}
+	return token, expires, nil
+}
+
+// validSession reports whether the request carries an unexpired session made with its API key
+func (g *AdminGate) validSession(r *http.Request) bool {
+	token := r.Header.Get(adminSessionHeader)
+	if token == "" {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	session, ok := g.sessions[token]
+	return ok && session.owner == keyOwner(r.Header.Get(apiKeyHeader)) && g.now().Before(session.expires)
+}
+
+// WebAuthnRegisterBody starts or finishes enrolling a passkey
+type WebAuthnRegisterBody struct {
+	Name       string                             `json:"name"`
+	Credential types.WebAuthnRegistrationResponse `json:"credential"`
+}
+
+// WebAuthnRegisterResponse is returned when a passkey is enrolled
+type WebAuthnRegisterResponse struct {
+	Credential    types.WebAuthnCredential `json:"credential"`
+	RecoveryCodes []string                 `json:"recoveryCodes,omitempty"` // Only returned when first issued
+}
+
+// WebAuthnSessionResponse is returned when a passkey login or recovery code starts a session
+type WebAuthnSessionResponse struct {
+	Session   string    `json:"session"` // Send in the X-Admin-Session header
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// WebAuthnRecoverBody exchanges a recovery code for a session
+type WebAuthnRecoverBody struct {
+	Code string `json:"code"`
+}
+
+// requireAdminGate rejects requests without an admin API key or when passkeys are disabled,
+// returning the key's owner
+func (s *Server) requireAdminGate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !s.isAdmin(r) {
+		errorResponse(w, http.StatusForbidden, "admin API key required")
+		return "", false
+	}
+	if s.adminGate == nil {
+		errorResponse(w, http.StatusNotFound, "passkeys are not enabled")
+		return "", false
+	}
+	return keyOwner(r.Header.Get(apiKeyHeader)), true
+}
+
+// validEnrollmentToken reports whether token is a configured enrollment token that has not been used
+func (g *AdminGate) validEnrollmentToken(token string) bool {
+	return token != "" && g.enrollmentTokens[services.HashEnrollmentToken(token)] && !g.store.EnrollmentTokenUsed(token)
+}
+
+// requireEnrollment checks the request may enroll a passkey, so a leaked API key cannot add its own:
+// the first passkey of an API key needs an unused enrollment token handed out out of band, later ones
+// need a passkey session. It returns the enrollment token the request spends, if any.
+func (s *Server) requireEnrollment(w http.ResponseWriter, r *http.Request, owner string) (string, bool) {
+	if len(s.adminGate.store.Credentials(owner)) > 0 {
+		if !s.adminGate.validSession(r) {
+			errorResponse(w, http.StatusUnauthorized, "passkey session required to enroll another passkey")
+			return "", false
+		}
+		return "", true
+	}
+
+	token := r.Header.Get(enrollmentTokenHeader)
+	if !s.adminGate.validEnrollmentToken(token) {
+		errorResponse(w, http.StatusUnauthorized, "a valid enrollment token is required to enroll the first passkey")
+		return "", false
+	}
+	return token, true
+}
+
+// beginRegistrationHandler returns the options for navigator.credentials.create()
+func (s *Server) beginRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := s.requireEnrollment(w, r, owner); !ok {
+		return
+	}
+
+	challenge, err := s.adminGate.newChallenge(owner, ceremonyRegister)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "failed to issue challenge")
+		return
+	}
+
+	exclude := []types.PublicKeyCredentialDescriptor{}
+	for _, c := range s.adminGate.store.Credentials(owner) {
+		exclude = append(exclude, types.PublicKeyCredentialDescriptor{Type: "public-key", ID: c.ID})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"publicKey": map[string]interface{}{
+			"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+			"rp":        map[string]string{"id": s.adminGate.verifier.RPID(), "name": s.adminGate.rpName},
+			"user": map[string]string{
+				"id":          base64.RawURLEncoding.EncodeToString([]byte(owner)),
+				"name":        "admin-" + owner,
+				"displayName": "Admin key " + owner,
+			},
+			"pubKeyCredParams": []map[string]interface{}{
+				{"type": "public-key", "alg": types.COSEAlgES256},
+				{"type": "public-key", "alg": types.COSEAlgEdDSA},
+				{"type": "public-key", "alg": types.COSEAlgRS256},
+			},
+			"timeout":            webAuthnChallengeTTL.Milliseconds(),
+			"attestation":        "none",
+			"excludeCredentials": exclude,
+			"authenticatorSelection": map[string]string{
+				"residentKey":      "preferred",
+				"userVerification": "preferred",
+			},
+		},
+	})
+}
+
+// finishRegistrationHandler enrolls a passkey, issuing recovery codes with the first one
+func (s *Server) finishRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+	token, ok := s.requireEnrollment(w, r, owner)
+	if !ok {
+		return
+	}
+
+	var body WebAuthnRegisterBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	challenge, err := s.adminGate.takeChallenge(owner, ceremonyRegister)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	credential, err := s.adminGate.verifier.VerifyRegistration(challenge, owner, body.Credential)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("passkey registration failed: %v", err))
+		return
+	}
+	credential.Name = body.Name
+	if token != "" {
+		// Spend the token before enrolling, so two ceremonies racing on one token cannot both succeed
+		used, err := s.adminGate.store.UseEnrollmentToken(token)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !used {
+			errorResponse(w, http.StatusUnauthorized, "enrollment token has already been used")
+			return
+		}
+	}
+	if err := s.adminGate.store.AddCredential(*credential); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	resp := WebAuthnRegisterResponse{Credential: *credential}
+	if !s.adminGate.store.HasRecoveryCodes(owner) {
+		codes, err := s.adminGate.store.ReplaceRecoveryCodes(owner)
+		if err != nil {
+			log.Printf("Failed to issue recovery codes: %v", err)
+		}
+		resp.RecoveryCodes = codes
+	}
+
+	params := map[string]string{"credentialId": credential.ID, "name": credential.Name}
+	if token != "" {
+		params["enrollmentToken"] = services.HashEnrollmentToken(token)[:16]
+	}
+	s.auditPasskey("webauthn_enroll", owner, params)
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// beginLoginHandler returns the options for navigator.credentials.get()
+func (s *Server) beginLoginHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+
+	credentials := s.adminGate.store.Credentials(owner)
+	if len(credentials) == 0 {
+		errorResponse(w, http.StatusConflict, "no passkey enrolled for this API key")
+		return
+	}
+
+	challenge, err := s.adminGate.newChallenge(owner, ceremonyLogin)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "failed to issue challenge")
+		return
+	}
+
+	allow := make([]types.PublicKeyCredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		allow = append(allow, types.PublicKeyCredentialDescriptor{Type: "public-key", ID: c.ID})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"publicKey": map[string]interface{}{
+			"challenge":        base64.RawURLEncoding.EncodeToString(challenge),
+			"rpId":             s.adminGate.verifier.RPID(),
+			"allowCredentials": allow,
+			"timeout":          webAuthnChallengeTTL.Milliseconds(),
+			"userVerification": "preferred",
+		},
+	})
+}
+
+// finishLoginHandler verifies a passkey assertion and starts a session
+func (s *Server) finishLoginHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+
+	var body types.WebAuthnAssertionResponse
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	challenge, err := s.adminGate.takeChallenge(owner, ceremonyLogin)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	credential, err := s.adminGate.store.Credential(owner, body.ID)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "passkey is not enrolled for this API key")
+		return
+	}
+	signCount, err := s.adminGate.verifier.VerifyAssertion(challenge, credential, body)
+	if err != nil {
+		s.auditPasskey("webauthn_login_failed", owner, map[string]string{"credentialId": credential.ID, "error": err.Error()})
+		errorResponse(w, http.StatusUnauthorized, fmt.Sprintf("passkey login failed: %v", err))
+		return
+	}
+	if err := s.adminGate.store.RecordUse(owner, credential.ID, signCount, s.adminGate.now().UTC()); err != nil {
+		log.Printf("Failed to record passkey use: %v", err)
+	}
+
+	s.startAdminSession(w, "webauthn_login", owner, map[string]string{"credentialId": credential.ID})
+}
+
+// recoverHandler exchanges a recovery code for a session, so an operator who lost their passkey can enroll a new one
+func (s *Server) recoverHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+
+	var body WebAuthnRecoverBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Code == "" {
+		errorResponse(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	valid, err := s.adminGate.store.UseRecoveryCode(owner, body.Code)
+	if err != nil {
+		log.Printf("Failed to record recovery code use: %v", err)
+	}
+	if !valid {
+		s.auditPasskey("webauthn_recovery_failed", owner, nil)
+		errorResponse(w, http.StatusUnauthorized, "invalid recovery code")
+		return
+	}
+
+	s.startAdminSession(w, "webauthn_recovery", owner, nil)
+}
+
+// regenerateRecoveryCodesHandler replaces the recovery codes of the session's API key
+func (s *Server) regenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := s.adminGate.store.ReplaceRecoveryCodes(owner)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.auditPasskey("webauthn_recovery_codes", owner, nil)
+	writeJSON(w, http.StatusOK, map[string][]string{"recoveryCodes": codes})
+}
+
+// listCredentialsHandler lists the passkeys enrolled with the request's API key
+func (s *Server) listCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+
+	credentials := s.adminGate.store.Credentials(owner)
+	if credentials == nil {
+		credentials = []types.WebAuthnCredential{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]types.WebAuthnCredential{"credentials": credentials})
+}
+
+// removeCredentialHandler removes a passkey enrolled with the request's API key
+func (s *Server) removeCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	owner, ok := s.requireAdminGate(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	if err := s.adminGate.store.RemoveCredential(owner, id); err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, services.ErrLastCredential) {
+			status = http.StatusConflict
+		}
+		errorResponse(w, status, err.Error())
+		return
+	}
+
+	s.auditPasskey("webauthn_remove", owner, map[string]string{"credentialId": id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startAdminSession issues a session and audits how it was obtained
+func (s *Server) startAdminSession(w http.ResponseWriter, action, owner string, params map[string]string) {
+	token, expires, err := s.adminGate.newSession(owner)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "failed to start session")
+		return
+	}
+
+	s.auditPasskey(action, owner, params)
+	writeJSON(w, http.StatusOK, WebAuthnSessionResponse{Session: token, ExpiresAt: expires})
+}
+
+// auditPasskey records a passkey event in the audit log
+func (s *Server) auditPasskey(action, owner string, params map[string]string) {
+	if s.auditLog == nil {
+		return
+	}
+	entry := temporal_activities.AuditEntry{
+		Action:   action,
+		Operator: "admin-" + owner,
+		Params:   params,
+		Status:   temporal_activities.AuditStatusCompleted,
+	}
+	if err := s.auditLog.Record(entry); err != nil {
+		log.Printf("Failed to record %s: %v", action, err)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:3000"
+)
+
+// softPasskey is a software authenticator answering WebAuthn ceremonies
+type softPasskey struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	signCount uint32
+}
+
+func newSoftPasskey(t *testing.T, id string) *softPasskey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &softPasskey{key: key, id: []byte(id)}
+}
+
+func (p *softPasskey) clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": testOrigin})
+	return data
+}
+
+func (p *softPasskey) authData(flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, p.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(p.id)))
+		data = append(data, p.id...)
+	}
+	return data
+}
+
+func (p *softPasskey) register(challenge string) types.WebAuthnRegistrationResponse {
+	publicKey, _ := x509.MarshalPKIXPublicKey(&p.key.PublicKey)
+
+	var resp types.WebAuthnRegistrationResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(p.id)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(p.clientData("webauthn.create", challenge))
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(p.authData(0x41, true))
+	resp.Response.PublicKey = base64.RawURLEncoding.EncodeToString(publicKey)
+	resp.Response.PublicKeyAlgorithm = types.COSEAlgES256
+	return resp
+}
+
+func (p *softPasskey) assert(challenge string) types.WebAuthnAssertionResponse {
+	p.signCount++
+	clientData := p.clientData("webauthn.get", challenge)
+	authData := p.authData(0x01, false)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, _ := ecdsa.SignASN1(rand.Reader, p.key, digest[:])
+
+	var resp types.WebAuthnAssertionResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(p.id)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientData)
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+	return resp
+}
+
+// doAdminRequest sends a request with an admin API key and passkey session
+func doAdminRequest(t *testing.T, s *Server, method, path string, body interface{}, apiKey, session string) *httptest.ResponseRecorder {
+	t.Helper()
+	return doEnrollmentRequest(t, s, method, path, body, apiKey, session, "")
+}
+
+// doEnrollmentRequest sends an admin request that also carries an enrollment token
+func doEnrollmentRequest(t *testing.T, s *Server, method, path string, body interface{}, apiKey, session, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set(apiKeyHeader, apiKey)
+	if session != "" {
+		req.Header.Set(adminSessionHeader, session)
+	}
+	if token != "" {
+		req.Header.Set(enrollmentTokenHeader, token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+// beginCeremony starts a ceremony and returns its challenge
+func beginCeremony(t *testing.T, s *Server, path, apiKey, session string) string {
+	t.Helper()
+	return beginEnrollment(t, s, path, apiKey, session, "")
+}
+
+// beginEnrollment starts a ceremony with an enrollment token and returns its challenge
+func beginEnrollment(t *testing.T, s *Server, path, apiKey, session, token string) string {
+	t.Helper()
+
+	rec := doEnrollmentRequest(t, s, http.MethodPost, path, nil, apiKey, session, token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var options struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&options))
+	require.NotEmpty(t, options.PublicKey.Challenge)
+	return options.PublicKey.Challenge
+}
+
+func TestAdminPasskeys(t *testing.T) {
+	const (
+		adminKey        = "admin-test-key"
+		enrollmentToken = "enroll-test-token"
+	)
+
+	s := newTestServer(t)
+	s.adminKeys[adminKey] = "oncall@infinity-dex"
+	auditLog, err := temporal_activities.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	s.auditLog = auditLog
+
+	cfg := temporal_config.DefaultConfig().Admin.WebAuthn
+	cfg.Enabled = true
+	cfg.CredentialsPath = filepath.Join(t.TempDir(), "webauthn.json")
+	cfg.EnrollmentTokens = []string{services.HashEnrollmentToken(enrollmentToken)}
+	gate, err := NewAdminGate(cfg)
+	require.NoError(t, err)
+	s.adminGate = gate
+
+	passkey := newSoftPasskey(t, "passkey-1")
+	var recoveryCodes []string
+	var session string
+
+	t.Run("AdminRoutesNeedSession", func(t *testing.T) {
+		rec := doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/actions", nil, adminKey, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/actions", nil, adminKey, "bogus")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("LoginBeforeEnrollment", func(t *testing.T) {
+		rec := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/login/begin", nil, adminKey, "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("FirstPasskeyNeedsEnrollmentToken", func(t *testing.T) {
+		rec := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/register/begin", nil, adminKey, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = doEnrollmentRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/register/begin", nil, adminKey, "", "wrong-token")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Enroll", func(t *testing.T) {
+		challenge := beginEnrollment(t, s, "/api/v1/admin/webauthn/register/begin", adminKey, "", enrollmentToken)
+
+		body := WebAuthnRegisterBody{Name: "yubikey", Credential: passkey.register(challenge)}
+		rec := doEnrollmentRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/register/finish", body, adminKey, "", enrollmentToken)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var resp WebAuthnRegisterResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "yubikey", resp.Credential.Name)
+		assert.Len(t, resp.RecoveryCodes, 10)
+		recoveryCodes = resp.RecoveryCodes
+
+		// Replaying the enrollment needs a session now that the key has a passkey
+		rec = doEnrollmentRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/register/finish", body, adminKey, "", enrollmentToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		// The token was spent, so another key cannot enroll with it
+		s.adminKeys["second-admin-key"] = "second@infinity-dex"
+		rec = doEnrollmentRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/register/begin", nil, "second-admin-key", "", enrollmentToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("SecondPasskeyNeedsSession", func(t *testing.T) {
+		rec := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/register/begin", nil, adminKey, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		challenge := beginCeremony(t, s, "/api/v1/admin/webauthn/login/begin", adminKey, "")
+
+		assertion := passkey.assert(challenge)
+		rec := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/login/finish", assertion, adminKey, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		// A challenge can only be answered once
+		replay := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/login/finish", assertion, adminKey, "")
+		assert.Equal(t, http.StatusBadRequest, replay.Code)
+
+		var resp WebAuthnSessionResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.NotEmpty(t, resp.Session)
+		session = resp.Session
+
+		rec = doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/actions", nil, adminKey, session)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// Sessions are bound to the key they were made with
+		s.adminKeys["other-admin-key"] = "other@infinity-dex"
+		rec = doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/actions", nil, "other-admin-key", session)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("ForgedAssertion", func(t *testing.T) {
+		challenge := beginCeremony(t, s, "/api/v1/admin/webauthn/login/begin", adminKey, "")
+
+		impostor := newSoftPasskey(t, "passkey-1")
+		rec := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/login/finish", impostor.assert(challenge), adminKey, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Recover", func(t *testing.T) {
+		rec := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/recover", WebAuthnRecoverBody{Code: "not-a-code"}, adminKey, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/recover", WebAuthnRecoverBody{Code: recoveryCodes[0]}, adminKey, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp WebAuthnSessionResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+		// The recovered session can enroll a replacement passkey
+		replacement := newSoftPasskey(t, "passkey-2")
+		challenge := beginCeremony(t, s, "/api/v1/admin/webauthn/register/begin", adminKey, resp.Session)
+		body := WebAuthnRegisterBody{Name: "phone", Credential: replacement.register(challenge)}
+		rec = doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/register/finish", body, adminKey, resp.Session)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var registered WebAuthnRegisterResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&registered))
+		assert.Empty(t, registered.RecoveryCodes, "recovery codes are only issued with the first passkey")
+
+		rec = doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/webauthn/recover", WebAuthnRecoverBody{Code: recoveryCodes[0]}, adminKey, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Credentials", func(t *testing.T) {
+		rec := doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/webauthn/credentials", nil, adminKey, session)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp map[string][]types.WebAuthnCredential
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp["credentials"], 2)
+
+		id := resp["credentials"][0].ID
+		rec = doAdminRequest(t, s, http.MethodDelete, "/api/v1/admin/webauthn/credentials/"+id, nil, adminKey, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec = doAdminRequest(t, s, http.MethodDelete, "/api/v1/admin/webauthn/credentials/"+id, nil, adminKey, session)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		// The last passkey stays, or the key alone could enroll a new one
+		last := resp["credentials"][1].ID
+		rec = doAdminRequest(t, s, http.MethodDelete, "/api/v1/admin/webauthn/credentials/"+last, nil, adminKey, session)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Audited", func(t *testing.T) {
+		entries, err := s.auditLog.List(0)
+		require.NoError(t, err)
+
+		var actions []string
+		for _, entry := range entries {
+			actions = append(actions, entry.Action)
+		}
+		assert.Contains(t, actions, "webauthn_enroll")
+		assert.Contains(t, actions, "webauthn_login")
+		assert.Contains(t, actions, "webauthn_login_failed")
+		assert.Contains(t, actions, "webauthn_recovery")
+		assert.Contains(t, actions, "webauthn_remove")
+	})
+}
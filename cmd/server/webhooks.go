@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+// webhookVerifyEvent is the event of the ping sent to check a webhook URL
+const webhookVerifyEvent = "webhook.verify"
+
+// WebhookSecretRequestBody registers an API key's webhook secret; without one a secret is generated
+type WebhookSecretRequestBody struct {
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookSecretResponse returns the secret an API key's webhooks are now signed with
+type WebhookSecretResponse struct {
+	Secret string `json:"secret"`
+}
+
+// WebhookVerifyRequestBody names the URL to send a signed test webhook to
+type WebhookVerifyRequestBody struct {
+	URL string `json:"url"`
+}
+
+// WebhookVerifyResponse reports how the test webhook was answered
+type WebhookVerifyResponse struct {
+	Delivered  bool   `json:"delivered"`
+	StatusCode int    `json:"statusCode,omitempty"` // Set when the URL answered with a non-2xx status
+	Error      string `json:"error,omitempty"`
+}
+
+// webhookPing is the body of the test webhook sent by the verify endpoint
+type webhookPing struct {
+	Event  string    `json:"event"`
+	SentAt time.Time `json:"sentAt"`
+}
+
+// SetWebhookSecretStore sets the store API keys' registered webhook secrets are kept in
+func (s *Server) SetWebhookSecretStore(store services.WebhookSecretStore) {
+	s.webhookSecrets = store
+}
+
+// validateCallbackURL checks a swap's callback URL can be sent to, signed with the secret of the key with keyID
+func (s *Server) validateCallbackURL(ctx context.Context, keyID, callbackURL string) error {
+	_, err := services.WebhookSecret(ctx, s.webhookSecrets, keyID)
+	if errors.Is(err, types.ErrWebhookSecretNotFound) {
+		return errors.New("no webhook secret to sign callbacks with; register one at /api/v1/webhooks/secret")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get webhook secret: %w", err)
+	}
+	return services.CheckWebhookURL(ctx, callbackURL)
+}
+
+// putWebhookSecretHandler registers the secret the calling API key's swap callbacks are signed with
+func (s *Server) putWebhookSecretHandler(w http.ResponseWriter, r *http.Request) {
+	keyID, _, ok := s.meteredKey(r)
+	if !ok {
+		codedErrorResponse(w, ErrorCodeUnauthorized, "webhook secrets need a tenant or sandbox API key")
+		return
+	}
+
+	var body WebhookSecretRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	secret := body.Secret
+	if secret == "" {
+		generated, err := services.GenerateWebhookSecret()
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to generate webhook secret: %v", err))
+			return
+		}
+		secret = generated
+	} else if len(secret) < services.MinWebhookSecretLength {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("secret must be at least %d characters", services.MinWebhookSecretLength))
+		return
+	}
+
+	if err := s.webhookSecrets.SetWebhookSecret(r.Context(), keyID, secret); err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to save webhook secret: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, WebhookSecretResponse{Secret: secret})
+}
+
+// verifyWebhookHandler sends a signed test webhook to a URL, so clients can check they receive and verify callbacks
+// before relying on them
+func (s *Server) verifyWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	keyID, _, ok := s.meteredKey(r)
+	if !ok {
+		codedErrorResponse(w, ErrorCodeUnauthorized, "verifying webhooks needs a tenant or sandbox API key")
+		return
+	}
+
+	var body WebhookVerifyRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if err := s.validateCallbackURL(r.Context(), keyID, body.URL); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	secret, err := services.WebhookSecret(r.Context(), s.webhookSecrets, keyID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get webhook secret: %v", err))
+		return
+	}
+
+	ping, err := json.Marshal(webhookPing{Event: webhookVerifyEvent, SentAt: time.Now().UTC()})
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to encode webhook: %v", err))
+		return
+	}
+	response := WebhookVerifyResponse{Delivered: true}
+	if err := services.PostWebhook(r.Context(), s.webhookClient, body.URL, secret, ping); err != nil {
+		response.Delivered = false
+		response.Error = err.Error()
+		var statusErr *services.WebhookStatusError
+		if errors.As(err, &statusErr) {
+			response.StatusCode = statusErr.StatusCode
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutWebhookSecret(t *testing.T) {
+	s := newTestServer(t)
+	s.tenantKeys["acme-key"] = "acme"
+
+	rec := doRequest(t, s, http.MethodPut, "/api/v1/webhooks/secret", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = doRequest(t, s, http.MethodPut, "/api/v1/webhooks/secret", nil, "acme-key")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var generated WebhookSecretResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&generated))
+	assert.True(t, strings.HasPrefix(generated.Secret, "whsec_"))
+
+	secret := strings.Repeat("s", 32)
+	rec = doRequest(t, s, http.MethodPut, "/api/v1/webhooks/secret", WebhookSecretRequestBody{Secret: secret}, "acme-key")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	stored, err := s.webhookSecrets.GetWebhookSecret(context.Background(), keyOwner("acme-key"))
+	require.NoError(t, err)
+	assert.Equal(t, secret, stored)
+
+	rec = doRequest(t, s, http.MethodPut, "/api/v1/webhooks/secret", WebhookSecretRequestBody{Secret: "short"}, "acme-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	s := newTestServer(t)
+	s.tenantKeys["acme-key"] = "acme"
+	s.config().Webhooks.SigningSecret = strings.Repeat("d", 32)
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/webhooks/verify", WebhookVerifyRequestBody{URL: "https://example.com/hook"}, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The deposit webhook secret is not shared with keys that registered none
+	rec = doRequest(t, s, http.MethodPost, "/api/v1/webhooks/verify", WebhookVerifyRequestBody{URL: "https://example.com/hook"}, "acme-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no webhook secret")
+
+	require.NoError(t, s.webhookSecrets.SetWebhookSecret(context.Background(), keyOwner("acme-key"), strings.Repeat("s", 32)))
+	for _, url := range []string{"http://example.com/hook", "https://127.0.0.1/hook"} {
+		rec = doRequest(t, s, http.MethodPost, "/api/v1/webhooks/verify", WebhookVerifyRequestBody{URL: url}, "acme-key")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, url)
+	}
+}
+
+func TestSwapCallbackNeedsTemporal(t *testing.T) {
+	s := newTestServer(t)
+	body := testSwapBody()
+	body.CallbackURL = "https://example.com/hook"
+
+	rec := doRequest(t, s, http.MethodPost, "/api/v1/swap", body, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "swap callbacks need Temporal")
+}
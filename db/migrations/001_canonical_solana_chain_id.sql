@@ -0,0 +1,39 @@
+-- Canonicalize Solana chain IDs
+--
+-- Some price sources stored Solana tokens under the placeholder chain ID 999 while
+-- others used 1399811149. Move everything onto the canonical 1399811149, merging
+-- tokens that exist under both IDs. Runs in the migration's transaction. Merged
+-- tokens can't be split again, so there is no down migration. Safe to run
+-- more than once.
+
+-- Tokens stored under both IDs: move their prices and history to the canonical token
+UPDATE token_prices tp
+SET token_id = canonical.id
+FROM tokens legacy
+JOIN tokens canonical ON canonical.symbol = legacy.symbol AND canonical.chain_id = 1399811149
+WHERE legacy.chain_id = 999 AND tp.token_id = legacy.id;
+
+UPDATE token_price_history tph
+SET token_id = canonical.id
+FROM tokens legacy
+JOIN tokens canonical ON canonical.symbol = legacy.symbol AND canonical.chain_id = 1399811149
+WHERE legacy.chain_id = 999 AND tph.token_id = legacy.id;
+
+DELETE FROM tokens legacy
+USING tokens canonical
+WHERE legacy.chain_id = 999
+  AND canonical.chain_id = 1399811149
+  AND canonical.symbol = legacy.symbol;
+
+-- Tokens only stored under the legacy ID keep their rows and just change chain
+UPDATE tokens
+SET chain_id = 1399811149,
+    chain_name = 'Solana',
+    updated_at = CURRENT_TIMESTAMP
+WHERE chain_id = 999;
+
+-- Older Jupiter rows used a lowercase chain name
+UPDATE tokens
+SET chain_name = 'Solana'
+WHERE chain_id = 1399811149 AND chain_name <> 'Solana';
+
@@ -0,0 +1,16 @@
+-- Compliance policies
+--
+-- Per-tenant KYC/AML policy documents evaluated before swaps start. The
+-- document holds the JSON policy; version is kept alongside it for auditing.
+-- Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS compliance_policies (
+    tenant_id TEXT PRIMARY KEY,
+    version INTEGER NOT NULL DEFAULT 1,
+    document JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS compliance_policies;
@@ -0,0 +1,25 @@
+-- Token price candles
+--
+-- OHLCV candles rolled up from token_price_history by the price worker after
+-- every price update, one row per token, interval (1m, 5m, 1h or 1d) and
+-- bucket. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS token_price_candles (
+    token_id INTEGER NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    candle_interval VARCHAR(4) NOT NULL,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    open DECIMAL(24, 12) NOT NULL,
+    high DECIMAL(24, 12) NOT NULL,
+    low DECIMAL(24, 12) NOT NULL,
+    close DECIMAL(24, 12) NOT NULL,
+    volume DECIMAL(24, 6),
+    samples INTEGER NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token_id, candle_interval, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_price_candles_interval_bucket ON token_price_candles(candle_interval, bucket_start);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS token_price_candles;
@@ -0,0 +1,24 @@
+-- Swap archive
+--
+-- Finished swaps copied out of Temporal before history retention deletes
+-- them, so swaps can be looked up long after their workflow is gone. The
+-- request and result are kept as JSON; success is kept alongside for queries.
+-- Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS swap_archive (
+    request_id TEXT PRIMARY KEY,
+    workflow_id TEXT NOT NULL,
+    run_id TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    request JSONB NOT NULL,
+    result JSONB NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_swap_archive_closed_at ON swap_archive (closed_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS swap_archive;
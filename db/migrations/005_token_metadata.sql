@@ -0,0 +1,26 @@
+-- Token metadata
+--
+-- Logos, decimals, CoinGecko IDs and verification status gathered from
+-- published token lists (CoinGecko, Jupiter). The tokens endpoint enriches
+-- its responses with them. EVM addresses are stored lowercase.
+-- Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS token_metadata (
+    chain_id BIGINT NOT NULL,
+    address TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    name TEXT NOT NULL,
+    decimals INTEGER NOT NULL,
+    logo_uri TEXT NOT NULL DEFAULT '',
+    coingecko_id TEXT NOT NULL DEFAULT '',
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    sources TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chain_id, address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_metadata_symbol ON token_metadata (chain_id, UPPER(symbol));
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS token_metadata;
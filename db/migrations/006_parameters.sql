@@ -0,0 +1,18 @@
+-- Parameters
+--
+-- Operator-managed parameters read at runtime. Alert conditions (alert.*),
+-- circuit breaker rules (breaker.*) and fee overrides (fee.*) are stored
+-- here as expressions instead of hard-coded thresholds.
+-- Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS parameters (
+    name TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS parameters;
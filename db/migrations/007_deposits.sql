@@ -0,0 +1,29 @@
+-- Deposits
+--
+-- Deposits pending deposit-funded swaps expect, with the next block the
+-- deposit watcher scans for each, and the transfers that fulfilled a
+-- deposit, so a restarted server neither misses nor reuses a deposit.
+-- Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS expected_deposits (
+    request_id TEXT PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    token JSONB NOT NULL,
+    from_address TEXT NOT NULL,
+    deposit_address TEXT NOT NULL,
+    amount NUMERIC(78, 0) NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    next_block BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS deposit_transfers (
+    transfer_key TEXT PRIMARY KEY,
+    request_id TEXT NOT NULL,
+    fulfilled_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS deposit_transfers;
+DROP TABLE IF EXISTS expected_deposits;
@@ -0,0 +1,24 @@
+-- Liquidity pools
+--
+-- Liquidity pools with their positions and swap reservations, shared by the
+-- API server and the swap worker, and the IDs of the pool operations (LP
+-- mints and burns) already applied, so a retried operation applies once.
+-- Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS liquidity_pools (
+    id TEXT PRIMARY KEY,
+    state JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS liquidity_operations (
+    operation_id TEXT PRIMARY KEY,
+    pool_id TEXT NOT NULL REFERENCES liquidity_pools (id),
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS liquidity_operations;
+DROP TABLE IF EXISTS liquidity_pools;
@@ -0,0 +1,21 @@
+-- Bridge outcomes
+--
+-- The outcome of each cross-chain swap's bridge transfer, recorded by the swap
+-- worker when the swap settles and read by the API server and the worker to
+-- score bridges. Each swap has one outcome. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS bridge_outcomes (
+    request_id TEXT PRIMARY KEY,
+    bridge TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    quoted_fee NUMERIC(78, 0),
+    actual_fee NUMERIC(78, 0),
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_bridge_outcomes_bridge_recorded_at ON bridge_outcomes (bridge, recorded_at DESC);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS bridge_outcomes;
@@ -0,0 +1,16 @@
+-- Candle rollup positions
+--
+-- The newest token_price_history row each candle interval has been rolled up
+-- to. Rows are tracked by ID rather than by timestamp so history the outbox
+-- saves late, with timestamps older than the newest candle, is still rolled
+-- up. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS candle_rollups (
+    candle_interval VARCHAR(4) PRIMARY KEY,
+    last_history_id INTEGER NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS candle_rollups;
@@ -0,0 +1,22 @@
+-- Listed tokens
+--
+-- Tokens added to the registry by approved listing requests. The swap worker
+-- writes them when a listing is approved and the API server reads them, so
+-- approved tokens are listed by both and survive restarts. Safe to run more
+-- than once.
+
+CREATE TABLE IF NOT EXISTS listed_tokens (
+    chain_id BIGINT NOT NULL,
+    address TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    name TEXT NOT NULL,
+    decimals INTEGER NOT NULL,
+    chain_name TEXT NOT NULL DEFAULT '',
+    logo_uri TEXT NOT NULL DEFAULT '',
+    listed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chain_id, address)
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS listed_tokens;
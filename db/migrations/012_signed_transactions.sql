@@ -0,0 +1,20 @@
+-- Signed transactions
+--
+-- Transactions the swap worker signed for wraps, unwraps and same-chain swaps,
+-- stored before they are broadcast. A retried activity rebroadcasts the stored
+-- transaction instead of signing another with a new nonce, so a crash between
+-- signing and broadcasting never sends an operation twice. Safe to run more
+-- than once.
+
+CREATE TABLE IF NOT EXISTS signed_transactions (
+    key TEXT PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    tx_hash TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    tx JSONB NOT NULL,
+    signed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS signed_transactions;
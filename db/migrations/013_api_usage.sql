@@ -0,0 +1,35 @@
+-- API usage
+--
+-- Monthly totals of each API key's requests per endpoint and swap volume per
+-- source token, added to by the API servers as they flush their usage meters
+-- and exported for usage-based billing. Keys are stored as fingerprints, never
+-- in full. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS api_usage (
+    key_id TEXT NOT NULL,
+    tenant TEXT NOT NULL DEFAULT '',
+    month TEXT NOT NULL,
+    endpoint TEXT NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    egress_bytes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, month, endpoint)
+);
+
+CREATE TABLE IF NOT EXISTS api_swap_volume (
+    key_id TEXT NOT NULL,
+    tenant TEXT NOT NULL DEFAULT '',
+    month TEXT NOT NULL,
+    chain_id BIGINT NOT NULL,
+    token TEXT NOT NULL,
+    swaps BIGINT NOT NULL DEFAULT 0,
+    amount NUMERIC(78, 0) NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, month, chain_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_usage_month ON api_usage (month);
+CREATE INDEX IF NOT EXISTS idx_api_swap_volume_month ON api_swap_volume (month);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS api_swap_volume;
+DROP TABLE IF EXISTS api_usage;
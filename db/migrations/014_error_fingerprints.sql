@@ -0,0 +1,23 @@
+-- Error fingerprints
+--
+-- Occurrence counts of each recurring error of the API servers, workflows,
+-- activities and Universal SDK calls, keyed by fingerprint and added to by
+-- every process as it flushes its error reporter. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS error_fingerprints (
+    fingerprint TEXT PRIMARY KEY,
+    origin TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    code TEXT NOT NULL DEFAULT '',
+    error_type TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL DEFAULT '',
+    occurrences BIGINT NOT NULL DEFAULT 0,
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_fingerprints_last_seen ON error_fingerprints (last_seen);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS error_fingerprints;
@@ -0,0 +1,24 @@
+-- Swap statistics
+--
+-- One row per finished swap, with its pair, chains, outcome and USD volume
+-- and fees, written by the swap workers as they archive swaps and by the API
+-- servers for in-process swaps. Added up over rolling windows for the
+-- volume, fee and success rate statistics of GET /api/v1/stats. Safe to run
+-- more than once.
+
+CREATE TABLE IF NOT EXISTS swap_stats (
+    request_id TEXT PRIMARY KEY,
+    pair TEXT NOT NULL,
+    source_chain_id BIGINT NOT NULL,
+    destination_chain_id BIGINT NOT NULL,
+    success BOOLEAN NOT NULL,
+    volume_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    fee_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    finished_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_swap_stats_finished_at ON swap_stats (finished_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS swap_stats;
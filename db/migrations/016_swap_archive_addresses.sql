@@ -0,0 +1,16 @@
+-- Swap archive addresses
+--
+-- Indexes the lower-cased source and destination addresses of archived swaps
+-- so GET /api/v1/users/{address}/swaps can list an address's swaps without
+-- scanning the archive. Safe to run more than once.
+
+CREATE INDEX IF NOT EXISTS idx_swap_archive_source_address
+    ON swap_archive (lower(request->>'sourceAddress'), closed_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_swap_archive_destination_address
+    ON swap_archive (lower(request->>'destinationAddress'), closed_at DESC);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS idx_swap_archive_destination_address;
+DROP INDEX IF EXISTS idx_swap_archive_source_address;
@@ -0,0 +1,16 @@
+-- Webhook secrets
+--
+-- The secret each API key registered for signing the webhooks sent to it,
+-- such as swap callbacks. Keys are identified by their key ID, a hash of the
+-- API key, never by the key itself. Written by the API servers and read by
+-- the swap workers. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS webhook_secrets (
+    key_id TEXT PRIMARY KEY,
+    secret TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS webhook_secrets;
@@ -0,0 +1,32 @@
+-- Idempotent price writes
+--
+-- Saved prices are unique per token, source and time, so a batch the price
+-- outbox replays after a lost commit acknowledgement is skipped instead of
+-- written twice. Duplicates saved before the indexes existed are removed
+-- first, keeping the newest current price and the oldest history row. Safe to
+-- run more than once.
+
+DELETE FROM token_prices a
+USING token_prices b
+WHERE a.token_id = b.token_id
+    AND a.source = b.source
+    AND a.last_updated = b.last_updated
+    AND a.id < b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_token_prices_token_source_updated
+    ON token_prices (token_id, source, last_updated);
+
+DELETE FROM token_price_history a
+USING token_price_history b
+WHERE a.token_id = b.token_id
+    AND a.source = b.source
+    AND a.timestamp = b.timestamp
+    AND a.id > b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_token_price_history_token_source_timestamp
+    ON token_price_history (token_id, source, timestamp);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS idx_token_price_history_token_source_timestamp;
+DROP INDEX IF EXISTS idx_token_prices_token_source_updated;
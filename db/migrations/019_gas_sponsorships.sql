@@ -0,0 +1,25 @@
+-- Gas sponsorships
+--
+-- The destination chain gas the protocol paid for each swap that asked for
+-- sponsorship, and the source tokens it recouped through the swap's network
+-- fee. Recorded by the API servers and swap workers alike, which sum a
+-- chain's recent sponsorships against its daily cap. Safe to run more than
+-- once.
+
+CREATE TABLE IF NOT EXISTS gas_sponsorships (
+    request_id TEXT PRIMARY KEY,
+    chain_id BIGINT NOT NULL,
+    gas_units BIGINT NOT NULL,
+    gas_cost NUMERIC(78, 0) NOT NULL,
+    cost_usd DOUBLE PRECISION NOT NULL,
+    token TEXT NOT NULL,
+    recouped NUMERIC(78, 0) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gas_sponsorships_chain_created
+    ON gas_sponsorships (chain_id, created_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS gas_sponsorships;
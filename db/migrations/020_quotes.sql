@@ -0,0 +1,18 @@
+-- Firm quotes
+--
+-- Signed quotes a swap may reference by ID until they expire, so a swap can
+-- be started on another API server than the one that quoted it. The quote is
+-- kept as JSON, not JSONB, so it reads back exactly as it was signed. Saving
+-- a quote deletes the expired ones. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS quotes (
+    quote_id TEXT PRIMARY KEY,
+    quote JSON NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_quotes_expires_at ON quotes (expires_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS quotes;
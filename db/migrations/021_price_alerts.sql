@@ -0,0 +1,28 @@
+-- Price alerts
+--
+-- Alerts clients register to be notified by webhook or email once a token's
+-- USD price crosses a threshold. Each scheduled price update marks the active
+-- alerts it crossed as triggered, so every alert fires once however many
+-- workers evaluate it. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS price_alerts (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    chain_id BIGINT NOT NULL DEFAULT 0,
+    threshold DOUBLE PRECISION NOT NULL,
+    direction TEXT NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    email TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    triggered_at TIMESTAMP WITH TIME ZONE,
+    trigger_price DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alerts_owner ON price_alerts (owner, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_alerts_active ON price_alerts (symbol) WHERE status = 'active';
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS price_alerts;
@@ -0,0 +1,28 @@
+-- Swap volumes
+--
+-- The USD value of each swap booked against the daily swap limits, with the
+-- source address, token pair and source chain it counts towards. Recorded by
+-- the API servers and swap workers alike, which sum the last 24 hours of
+-- volume against each limit. Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS swap_volumes (
+    request_id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL DEFAULT '',
+    chain_id BIGINT NOT NULL,
+    pair TEXT NOT NULL,
+    amount_usd DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_swap_volumes_owner_created
+    ON swap_volumes (owner, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_swap_volumes_pair_created
+    ON swap_volumes (pair, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_swap_volumes_chain_created
+    ON swap_volumes (chain_id, created_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS swap_volumes;
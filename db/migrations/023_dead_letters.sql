@@ -0,0 +1,27 @@
+-- Dead-lettered swaps
+--
+-- Swap workflows the swap workers' housekeeping scan found still running
+-- past SWAP.MAX_SWAP_TIME with an activity retrying, or failed after an
+-- activity ran out of retries. Each workflow run is recorded once; operators
+-- requeue or refund the swap through the admin API, which resolves it.
+-- Safe to run more than once.
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+    request_id TEXT NOT NULL,
+    run_id TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    detail TEXT NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_by TEXT,
+    action_id TEXT,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (request_id, run_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_status ON dead_letters (status, detected_at DESC);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS dead_letters;
@@ -0,0 +1,14 @@
+-- Firm quote usage
+--
+-- Ties each firm quote to the API key it was quoted to, and records when a
+-- swap used it, so a quote backs one swap from its own key. Expired quotes
+-- are deleted by the API servers' periodic cleanup rather than on save.
+-- Safe to run more than once.
+
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS key_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS used_at TIMESTAMP WITH TIME ZONE;
+
+---- create above / drop below ----
+
+ALTER TABLE quotes DROP COLUMN IF EXISTS used_at;
+ALTER TABLE quotes DROP COLUMN IF EXISTS key_id;
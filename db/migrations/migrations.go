@@ -0,0 +1,102 @@
+// Package migrations applies the database schema and the versioned migrations in this directory, which are embedded
+// in the binaries that use them. Migrations are numbered from 001 without gaps. The SQL above a
+// "---- create above / drop below ----" line migrates up and the SQL below it migrates back down; a migration
+// without it can't be undone. Each migration runs in a transaction, and the applied version is recorded in
+// VersionTable, so only migrations newer than it run.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"log"
+
+	"github.com/infinity-dex/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/tern/v2/migrate"
+)
+
+// VersionTable is the table recording the version of the last applied migration
+const VersionTable = "public.schema_version"
+
+//go:embed *.sql
+var files embed.FS
+
+// Status is the migration version of a database
+type Status struct {
+	Current int32 `json:"current"` // Version of the last applied migration; 0 before any was applied
+	Latest  int32 `json:"latest"`  // Version of the newest embedded migration
+}
+
+// Pending reports whether embedded migrations are waiting to be applied
+func (s Status) Pending() bool {
+	return s.Current < s.Latest
+}
+
+// Up applies the schema and then every migration newer than the database's version
+func Up(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := pool.Exec(ctx, db.Schema); err != nil {
+		return fmt.Errorf("unable to apply schema: %w", err)
+	}
+	return withMigrator(ctx, pool, func(m *migrate.Migrator) error {
+		return m.Migrate(ctx)
+	})
+}
+
+// To migrates the database up or down to version. Migrating down undoes migrations but never the schema.
+func To(ctx context.Context, pool *pgxpool.Pool, version int32) error {
+	return withMigrator(ctx, pool, func(m *migrate.Migrator) error {
+		if current, err := m.GetCurrentVersion(ctx); err == nil && current < version {
+			if _, err := pool.Exec(ctx, db.Schema); err != nil {
+				return fmt.Errorf("unable to apply schema: %w", err)
+			}
+		}
+		return m.MigrateTo(ctx, version)
+	})
+}
+
+// CurrentStatus returns the database's migration version and the newest embedded one
+func CurrentStatus(ctx context.Context, pool *pgxpool.Pool) (Status, error) {
+	var status Status
+	err := withMigrator(ctx, pool, func(m *migrate.Migrator) error {
+		current, err := m.GetCurrentVersion(ctx)
+		if err != nil {
+			return err
+		}
+		status = Status{Current: current, Latest: int32(len(m.Migrations))}
+		return nil
+	})
+	return status, err
+}
+
+// withMigrator calls fn with a migrator of the embedded migrations on a connection of pool. The migrator creates
+// VersionTable when it is missing.
+func withMigrator(ctx context.Context, pool *pgxpool.Pool, fn func(*migrate.Migrator) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	m, err := newMigrator(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// newMigrator creates a migrator of the embedded migrations that logs each migration it applies
+func newMigrator(ctx context.Context, conn *pgx.Conn) (*migrate.Migrator, error) {
+	m, err := migrate.NewMigrator(ctx, conn, VersionTable)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create migrator: %w", err)
+	}
+	if err := m.LoadMigrations(files); err != nil {
+		return nil, fmt.Errorf("unable to load migrations: %w", err)
+	}
+	m.OnStart = func(sequence int32, name, direction, _ string) {
+		log.Printf("Migrating %s: %s", direction, name)
+	}
+	return m, nil
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/tern/v2/migrate"
+)
+
+func TestMigrationsLoad(t *testing.T) {
+	// A migrator without a connection only loads migrations
+	m, err := migrate.NewMigrator(context.Background(), nil, VersionTable)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	if err := m.LoadMigrations(files); err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(m.Migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+
+	for _, migration := range m.Migrations {
+		if strings.Contains(strings.ToUpper(migration.UpSQL), "BEGIN;") {
+			t.Errorf("Migration %s manages its own transaction; migrations already run in one", migration.Name)
+		}
+		// Only data migrations that can't be undone may leave out a down migration
+		if migration.DownSQL == "" && migration.Name != "001_canonical_solana_chain_id.sql" {
+			t.Errorf("Migration %s has no down migration", migration.Name)
+		}
+	}
+}
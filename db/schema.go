@@ -0,0 +1,10 @@
+// Package db embeds the token price schema that the versioned migrations in db/migrations build on.
+package db
+
+import _ "embed"
+
+// Schema is schema.sql: the token and price tables, the latest prices view and the update_token_price function.
+// Every statement in it is idempotent, so it is applied before the migrations on every run.
+//
+//go:embed schema.sql
+var Schema string
@@ -120,7 +120,8 @@ BEGIN
     VALUES (
         v_token_id, p_price_usd, p_change_24h, p_volume_24h, p_market_cap_usd, 
         p_source, p_last_updated, CURRENT_TIMESTAMP
-    );
+    )
+    ON CONFLICT DO NOTHING;
     
     -- Insert into history if price changed or it's a new day
     IF v_price_changed THEN
@@ -131,7 +132,8 @@ BEGIN
         VALUES (
             v_token_id, p_price_usd, p_change_24h, p_volume_24h, p_market_cap_usd, 
             p_source, p_last_updated
-        );
+        )
+        ON CONFLICT DO NOTHING;
     END IF;
 END;
 $$ LANGUAGE plpgsql; 
\ No newline at end of file
@@ -0,0 +1,78 @@
+#!/usr/bin/env bash
+#
+# Benchmark harness for catching performance regressions before a release.
+#
+#   scripts/bench.sh run              Run the benchmarks on the working tree (bench/new.txt)
+#   scripts/bench.sh baseline [ref]   Run the benchmarks at a git ref (bench/old.txt, default: main)
+#   scripts/bench.sh compare          Compare old against new with benchstat and fail on regressions
+#
+# Environment:
+#   BENCH_COUNT      Runs per benchmark, benchstat needs several (default: 10)
+#   BENCH_PATTERN    Benchmark name filter (default: .)
+#   BENCH_PKGS       Packages to benchmark (default: ./...)
+#   BENCH_THRESHOLD  Allowed slowdown in percent before compare fails (default: 10)
+#   BENCHSTAT        benchstat command (default: go run golang.org/x/perf/cmd/benchstat@latest)
+
+set -euo pipefail
+
+ROOT="$(cd "$(dirname "$0")/.." && pwd)"
+BENCH_DIR="$ROOT/bench"
+BENCH_COUNT="${BENCH_COUNT:-10}"
+BENCH_PATTERN="${BENCH_PATTERN:-.}"
+BENCH_PKGS="${BENCH_PKGS:-./...}"
+BENCH_THRESHOLD="${BENCH_THRESHOLD:-10}"
+BENCHSTAT="${BENCHSTAT:-go run golang.org/x/perf/cmd/benchstat@latest}"
+
+usage() {
+	sed -n '3,15p' "$0" | sed 's/^# \{0,1\}//'
+	exit 1
+}
+
+# run_benchmarks <dir> <output>
+run_benchmarks() {
+	echo "Running benchmarks in $1 -> $2"
+	(cd "$1" && go test -run '^$' -bench "$BENCH_PATTERN" -benchmem -count "$BENCH_COUNT" $BENCH_PKGS) | tee "$2"
+}
+
+mkdir -p "$BENCH_DIR"
+
+case "${1:-}" in
+run)
+	run_benchmarks "$ROOT" "$BENCH_DIR/new.txt"
+	;;
+baseline)
+	ref="${2:-main}"
+	worktree="$(mktemp -d)"
+	trap 'git -C "$ROOT" worktree remove --force "$worktree"' EXIT
+	git -C "$ROOT" worktree add --detach "$worktree" "$ref" >/dev/null
+	run_benchmarks "$worktree" "$BENCH_DIR/old.txt"
+	;;
+compare)
+	for f in old new; do
+		if [ ! -f "$BENCH_DIR/$f.txt" ]; then
+			echo "Missing $BENCH_DIR/$f.txt; run 'baseline' and 'run' first" >&2
+			exit 1
+		fi
+	done
+
+	$BENCHSTAT "$BENCH_DIR/old.txt" "$BENCH_DIR/new.txt" | tee "$BENCH_DIR/compare.txt"
+
+	# benchstat only prints a delta when the change is statistically significant,
+	# so any "+N%" above the threshold in the sec/op table is a real regression
+	$BENCHSTAT -format csv -filter '.unit:sec/op' "$BENCH_DIR/old.txt" "$BENCH_DIR/new.txt" 2>/dev/null |
+		awk -F, -v threshold="$BENCH_THRESHOLD" '
+			$6 ~ /^\+[0-9.]+%$/ {
+				delta = substr($6, 2, length($6) - 2) + 0
+				if (delta > threshold) {
+					printf "REGRESSION: %s is %s slower (threshold %s%%)\n", $1, $6, threshold
+					failed = 1
+				}
+			}
+			END { exit failed }
+		'
+	echo "No regressions above ${BENCH_THRESHOLD}%"
+	;;
+*)
+	usage
+	;;
+esac
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// AggregatorParaSwap is the ParaSwap DEX aggregator
+const AggregatorParaSwap = "paraswap"
+
+// paraSwapNativeAddress is the address ParaSwap gives a chain's native token
+const paraSwapNativeAddress = "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"
+
+// ParaSwapQuoter quotes swaps with the ParaSwap prices API
+type ParaSwapQuoter struct {
+	client  *http.Client
+	baseURL string
+}
+
+// paraSwapPriceResponse is the part of a ParaSwap prices response used for comparison
+type paraSwapPriceResponse struct {
+	PriceRoute struct {
+		DestAmount string `json:"destAmount"` // In the destination token's smallest unit
+	} `json:"priceRoute"`
+}
+
+// NewParaSwapQuoter creates a quoter for the ParaSwap API at baseURL, e.g. https://api.paraswap.io
+func NewParaSwapQuoter(client *http.Client, baseURL string) *ParaSwapQuoter {
+	return &ParaSwapQuoter{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Name returns the aggregator's name
+func (q *ParaSwapQuoter) Name() string {
+	return AggregatorParaSwap
+}
+
+// QuoteOutput quotes selling amount of source for destination through ParaSwap. ParaSwap only quotes swaps within
+// one EVM chain, and needs both tokens' decimals.
+func (q *ParaSwapQuoter) QuoteOutput(ctx context.Context, source, destination types.Token, amount *big.Int) (*big.Int, error) {
+	if source.ChainID != destination.ChainID || source.Decimals == 0 || destination.Decimals == 0 {
+		return nil, nil
+	}
+	sourceAddress, ok := paraSwapAddress(source)
+	if !ok {
+		return nil, nil
+	}
+	destinationAddress, ok := paraSwapAddress(destination)
+	if !ok {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	query.Set("srcToken", sourceAddress)
+	query.Set("destToken", destinationAddress)
+	query.Set("srcDecimals", strconv.Itoa(source.Decimals))
+	query.Set("destDecimals", strconv.Itoa(destination.Decimals))
+	query.Set("amount", amount.String())
+	query.Set("side", "SELL")
+	query.Set("network", strconv.FormatInt(source.ChainID, 10))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, q.baseURL+"/prices?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("paraswap returned status %d", resp.StatusCode)
+	}
+
+	var price paraSwapPriceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&price); err != nil {
+		return nil, fmt.Errorf("invalid paraswap response: %w", err)
+	}
+	output, ok := new(big.Int).SetString(price.PriceRoute.DestAmount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid paraswap destination amount %q", price.PriceRoute.DestAmount)
+	}
+	return output, nil
+}
+
+// paraSwapAddress returns the address ParaSwap knows a token by: its contract, or the native placeholder for the
+// chain's gas token
+func paraSwapAddress(token types.Token) (string, bool) {
+	chain, ok := types.GetChain(token.ChainID)
+	if !ok || chain.Namespace != "eip155" {
+		return "", false
+	}
+	if token.IsNative() {
+		return paraSwapNativeAddress, true
+	}
+	if token.Address != "" {
+		return token.Address, true
+	}
+	return "", false
+}
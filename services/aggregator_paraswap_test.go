@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestParaSwapQuoteOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/prices" || query.Get("srcToken") != paraSwapNativeAddress || query.Get("destDecimals") != "6" ||
+			query.Get("network") != "1" || query.Get("side") != "SELL" || query.Get("amount") != "1000000000000000000" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"priceRoute": {"srcAmount": "1000000000000000000", "destAmount": "3012345678"}}`))
+	}))
+	defer server.Close()
+
+	quoter := NewParaSwapQuoter(server.Client(), server.URL+"/")
+	eth := types.Token{Symbol: "ETH", ChainID: types.ChainIDEthereum, Decimals: 18}
+	usdc := types.Token{Symbol: "USDC", ChainID: types.ChainIDEthereum, Decimals: 6, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}
+
+	output, err := quoter.QuoteOutput(context.Background(), eth, usdc, big.NewInt(1e18))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output == nil || output.String() != "3012345678" {
+		t.Errorf("Expected 3012345678, got %v", output)
+	}
+
+	// Cross-chain swaps and tokens without an address aren't quoted
+	polygonUSDC := usdc
+	polygonUSDC.ChainID = types.ChainIDPolygon
+	if output, err := quoter.QuoteOutput(context.Background(), eth, polygonUSDC, big.NewInt(1e18)); err != nil || output != nil {
+		t.Errorf("Expected no quote for a cross-chain swap, got %v, %v", output, err)
+	}
+	dai := types.Token{Symbol: "DAI", ChainID: types.ChainIDEthereum, Decimals: 18}
+	if output, err := quoter.QuoteOutput(context.Background(), eth, dai, big.NewInt(1e18)); err != nil || output != nil {
+		t.Errorf("Expected no quote for a token without an address, got %v, %v", output, err)
+	}
+}
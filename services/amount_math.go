@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// feeTierDenominator is the denominator of pool fee tiers (3000 = 0.3%)
+const feeTierDenominator = 1000000
+
+// ToBaseUnits converts a decimal string such as "1.5" to base units using the token decimals
+func ToBaseUnits(value string, decimals int) (*big.Int, error) {
+	amount, err := types.ParseAmount(value, decimals)
+	if err != nil {
+		return nil, err
+	}
+	return amount.Value, nil
+}
+
+// FromBaseUnits formats a base-unit amount as a decimal string using the token decimals
+func FromBaseUnits(amount *big.Int, decimals int) string {
+	return types.NewAmount(amount, decimals).String()
+}
+
+// ApplySlippage returns the minimum acceptable amount for a slippage tolerance in percent (0.5 = 0.5%)
+func ApplySlippage(amount *big.Int, slippage float64) *big.Int {
+	if amount == nil || amount.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	// Work in basis points so the result is exact integer math
+	bps := int64(math.Round(slippage * 100))
+	if bps < 0 || math.IsNaN(slippage) {
+		bps = 0
+	}
+	if bps > 10000 {
+		bps = 10000
+	}
+
+	result := new(big.Int).Mul(amount, big.NewInt(10000-bps))
+	return result.Quo(result, big.NewInt(10000))
+}
+
+// GetAmountOut returns the constant-product output for amountIn against the pool reserves,
+// after deducting the pool fee tier (in hundredths of a basis point)
+func GetAmountOut(amountIn, reserveIn, reserveOut *big.Int, feeTier int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() <= 0 {
+		return nil, errors.New("invalid input amount")
+	}
+	if reserveIn == nil || reserveOut == nil || reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil, errors.New("insufficient liquidity")
+	}
+	if feeTier < 0 || feeTier >= feeTierDenominator {
+		return nil, fmt.Errorf("invalid fee tier: %d", feeTier)
+	}
+
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(int64(feeTierDenominator-feeTier)))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(feeTierDenominator))
+	denominator.Add(denominator, amountInWithFee)
+
+	return numerator.Quo(numerator, denominator), nil
+}
+
+// SelectSwapPath returns the token symbols a swap routes through.
+// Same-chain swaps trade directly; cross-chain swaps wrap into Universal tokens,
+// bridge, and unwrap on the destination chain.
+func SelectSwapPath(source, destination types.Token) []string {
+	if source.ChainID == destination.ChainID {
+		return []string{source.Symbol, destination.Symbol}
+	}
+
+	path := []string{source.Symbol}
+	appendStep := func(symbol string) {
+		if path[len(path)-1] != symbol {
+			path = append(path, symbol)
+		}
+	}
+
+	if !source.IsWrapped {
+		appendStep("u" + source.Symbol)
+	}
+	if !destination.IsWrapped {
+		appendStep("u" + destination.Symbol)
+	}
+	appendStep(destination.Symbol)
+
+	return path
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestToBaseUnits(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int
+		expected string
+		wantErr  bool
+	}{
+		{"1.5", 18, "1500000000000000000", false},
+		{"1", 6, "1000000", false},
+		{".25", 2, "25", false},
+		{"0.000001", 6, "1", false},
+		{"0.0000001", 6, "", true},
+		{"-1", 6, "", true},
+		{"1e18", 6, "", true},
+		{"", 6, "", true},
+	}
+
+	for _, tt := range tests {
+		result, err := ToBaseUnits(tt.value, tt.decimals)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ToBaseUnits(%q, %d): expected error, got %s", tt.value, tt.decimals, result.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ToBaseUnits(%q, %d): unexpected error: %v", tt.value, tt.decimals, err)
+			continue
+		}
+		if result.String() != tt.expected {
+			t.Errorf("ToBaseUnits(%q, %d): expected %s, got %s", tt.value, tt.decimals, tt.expected, result.String())
+		}
+	}
+}
+
+func TestFromBaseUnits(t *testing.T) {
+	if got := FromBaseUnits(big.NewInt(1500000), 6); got != "1.5" {
+		t.Errorf("Expected '1.5', got '%s'", got)
+	}
+	if got := FromBaseUnits(big.NewInt(1), 6); got != "0.000001" {
+		t.Errorf("Expected '0.000001', got '%s'", got)
+	}
+	if got := FromBaseUnits(big.NewInt(42), 0); got != "42" {
+		t.Errorf("Expected '42', got '%s'", got)
+	}
+}
+
+func TestGetAmountOut(t *testing.T) {
+	// 1000 in against 1,000,000/1,000,000 reserves with 0.3% fee
+	out, err := GetAmountOut(big.NewInt(1000), big.NewInt(1000000), big.NewInt(1000000), 3000)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.Cmp(big.NewInt(996)) != 0 {
+		t.Errorf("Expected 996, got %s", out.String())
+	}
+
+	if _, err := GetAmountOut(big.NewInt(1000), big.NewInt(0), big.NewInt(1000000), 3000); err == nil {
+		t.Error("Expected error for empty reserves, got nil")
+	}
+}
+
+func TestSelectSwapPath(t *testing.T) {
+	eth := types.Token{Symbol: "ETH", ChainID: 1}
+	usdc := types.Token{Symbol: "USDC", ChainID: 1}
+	matic := types.Token{Symbol: "MATIC", ChainID: 137}
+
+	if path := SelectSwapPath(eth, usdc); len(path) != 2 {
+		t.Errorf("Expected direct same-chain path, got %v", path)
+	}
+
+	path := SelectSwapPath(eth, matic)
+	expected := []string{"ETH", "uETH", "uMATIC", "MATIC"}
+	if len(path) != len(expected) {
+		t.Fatalf("Expected path %v, got %v", expected, path)
+	}
+	for i := range expected {
+		if path[i] != expected[i] {
+			t.Errorf("Expected path %v, got %v", expected, path)
+			break
+		}
+	}
+}
+
+func FuzzBaseUnitsRoundTrip(f *testing.F) {
+	f.Add(int64(1500000), 6)
+	f.Add(int64(1), 18)
+	f.Add(int64(0), 0)
+
+	f.Fuzz(func(t *testing.T, raw int64, decimals int) {
+		if raw < 0 || decimals < 0 || decimals > 36 {
+			t.Skip()
+		}
+
+		amount := big.NewInt(raw)
+		formatted := FromBaseUnits(amount, decimals)
+		parsed, err := ToBaseUnits(formatted, decimals)
+		if err != nil {
+			t.Fatalf("ToBaseUnits(%q, %d) failed: %v", formatted, decimals, err)
+		}
+		if parsed.Cmp(amount) != 0 {
+			t.Fatalf("Round trip mismatch: %s -> %q -> %s", amount.String(), formatted, parsed.String())
+		}
+	})
+}
+
+func FuzzApplySlippage(f *testing.F) {
+	f.Add(int64(1000000), 0.5)
+	f.Add(int64(1), 100.0)
+	f.Add(int64(999), -3.0)
+
+	f.Fuzz(func(t *testing.T, raw int64, slippage float64) {
+		if raw < 0 {
+			t.Skip()
+		}
+
+		amount := big.NewInt(raw)
+		minOut := ApplySlippage(amount, slippage)
+		if minOut.Sign() < 0 {
+			t.Fatalf("Negative minimum output %s for amount %d", minOut.String(), raw)
+		}
+		if minOut.Cmp(amount) > 0 {
+			t.Fatalf("Minimum output %s exceeds amount %d", minOut.String(), raw)
+		}
+
+		// Monotonic in the input amount
+		larger := ApplySlippage(new(big.Int).Add(amount, big.NewInt(1)), slippage)
+		if larger.Cmp(minOut) < 0 {
+			t.Fatalf("ApplySlippage not monotonic: %s then %s", minOut.String(), larger.String())
+		}
+	})
+}
+
+func FuzzGetAmountOut(f *testing.F) {
+	f.Add(int64(1000), int64(1000000), int64(1000000), 3000)
+	f.Add(int64(1), int64(1), int64(1), 0)
+	f.Add(int64(1<<62), int64(7), int64(1<<40), 10000)
+
+	f.Fuzz(func(t *testing.T, amountIn, reserveIn, reserveOut int64, feeTier int) {
+		out, err := GetAmountOut(big.NewInt(amountIn), big.NewInt(reserveIn), big.NewInt(reserveOut), feeTier)
+		if err != nil {
+			return
+		}
+
+		if out.Sign() < 0 {
+			t.Fatalf("Negative output %s", out.String())
+		}
+		if out.Cmp(big.NewInt(reserveOut)) >= 0 {
+			t.Fatalf("Output %s drains reserve %d", out.String(), reserveOut)
+		}
+
+		// Monotonic in the input amount
+		more, err := GetAmountOut(new(big.Int).Add(big.NewInt(amountIn), big.NewInt(1)), big.NewInt(reserveIn), big.NewInt(reserveOut), feeTier)
+		if err != nil {
+			t.Fatalf("Larger input failed: %v", err)
+		}
+		if more.Cmp(out) < 0 {
+			t.Fatalf("GetAmountOut not monotonic: %s then %s", out.String(), more.String())
+		}
+	})
+}
+
+func FuzzSelectSwapPath(f *testing.F) {
+	f.Add("ETH", int64(1), false, "USDC", int64(1), false)
+	f.Add("ETH", int64(1), false, "MATIC", int64(137), false)
+	f.Add("uUSDC", int64(1), true, "uUSDC", int64(137), true)
+
+	f.Fuzz(func(t *testing.T, srcSymbol string, srcChain int64, srcWrapped bool, dstSymbol string, dstChain int64, dstWrapped bool) {
+		source := types.Token{Symbol: srcSymbol, ChainID: srcChain, IsWrapped: srcWrapped}
+		destination := types.Token{Symbol: dstSymbol, ChainID: dstChain, IsWrapped: dstWrapped}
+
+		path := SelectSwapPath(source, destination)
+		if len(path) == 0 || len(path) > 4 {
+			t.Fatalf("Unexpected path length %d: %v", len(path), path)
+		}
+		if path[0] != srcSymbol {
+			t.Fatalf("Path %v does not start at %q", path, srcSymbol)
+		}
+		if path[len(path)-1] != dstSymbol {
+			t.Fatalf("Path %v does not end at %q", path, dstSymbol)
+		}
+		for i := 1; i < len(path) && srcChain != dstChain; i++ {
+			if path[i] == path[i-1] {
+				t.Fatalf("Path %v repeats %q", path, path[i])
+			}
+		}
+	})
+}
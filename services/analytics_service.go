@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// StatsWindow is a window swap statistics are reported over
+type StatsWindow struct {
+	Name     string
+	Duration time.Duration
+}
+
+// StatsWindows are the windows swap statistics are reported over
+var StatsWindows = []StatsWindow{
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+}
+
+// SwapStatsStore persists the finished swaps analytics count, so the API servers and swap workers report from one
+// history
+type SwapStatsStore interface {
+	// RecordSwap stores a finished swap, replacing any earlier record of it
+	RecordSwap(ctx context.Context, record types.SwapRecord) error
+	// SwapTotals returns the totals of the swaps that finished since a time, per pair and chains
+	SwapTotals(ctx context.Context, since time.Time) ([]types.SwapTotals, error)
+}
+
+// totalsKey identifies the swaps of a pair between two chains
+type totalsKey struct {
+	pair                              string
+	sourceChainID, destinationChainID int64
+}
+
+// InMemorySwapStatsStore is a SwapStatsStore for running without a database
+type InMemorySwapStatsStore struct {
+	records map[string]types.SwapRecord // map[requestID]SwapRecord
+	mu      sync.RWMutex
+}
+
+// NewInMemorySwapStatsStore creates an empty swap stats store
+func NewInMemorySwapStatsStore() *InMemorySwapStatsStore {
+	return &InMemorySwapStatsStore{
+		records: make(map[string]types.SwapRecord),
+	}
+}
+
+// RecordSwap stores a finished swap
+func (s *InMemorySwapStatsStore) RecordSwap(ctx context.Context, record types.SwapRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.RequestID] = record
+	return nil
+}
+
+// SwapTotals adds up the swaps that finished since a time
+func (s *InMemorySwapStatsStore) SwapTotals(ctx context.Context, since time.Time) ([]types.SwapTotals, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	totals := make(map[totalsKey]*types.SwapTotals)
+	for _, record := range s.records {
+		if record.FinishedAt.Before(since) {
+			continue
+		}
+		key := totalsKey{record.Pair, record.SourceChainID, record.DestinationChainID}
+		total, ok := totals[key]
+		if !ok {
+			total = &types.SwapTotals{Pair: record.Pair, SourceChainID: record.SourceChainID, DestinationChainID: record.DestinationChainID}
+			totals[key] = total
+		}
+		total.Swaps++
+		if record.Success {
+			total.Succeeded++
+		}
+		total.VolumeUSD += record.VolumeUSD
+		total.FeesUSD += record.FeeUSD
+	}
+
+	result := make([]types.SwapTotals, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	return result, nil
+}
+
+// NewSwapRecord returns how analytics count a finished swap, valuing its input at the source token's price
+func NewSwapRecord(swap types.ArchivedSwap, sourcePriceUSD float64) types.SwapRecord {
+	request, result := swap.Request, swap.Result
+	record := types.SwapRecord{
+		RequestID:          swap.RequestID,
+		Pair:               request.SourceToken.Symbol + "/" + request.DestinationToken.Symbol,
+		SourceChainID:      types.CanonicalChainID(request.SourceToken.ChainID),
+		DestinationChainID: types.CanonicalChainID(request.DestinationToken.ChainID),
+		Success:            result.Success,
+		FinishedAt:         swap.ClosedAt,
+	}
+	if record.RequestID == "" {
+		record.RequestID = result.RequestID
+	}
+	if record.FinishedAt.IsZero() {
+		record.FinishedAt = result.CompletionTime
+	}
+	if !result.Success {
+		return record
+	}
+
+	record.FeeUSD = result.Fee.TotalFeeUSD
+	if amount := swapInput(swap); amount != nil && sourcePriceUSD > 0 {
+		record.VolumeUSD = types.NewAmount(amount, request.SourceToken.Decimals).Float64() * sourcePriceUSD
+	}
+	return record
+}
+
+// swapInput returns the amount a swap spent, or the amount requested when its result doesn't say
+func swapInput(swap types.ArchivedSwap) *big.Int {
+	if swap.Result.InputAmount != nil {
+		return swap.Result.InputAmount
+	}
+	return swap.Request.Amount
+}
+
+// AnalyticsService aggregates finished swaps into volume, fee and success rate statistics
+type AnalyticsService struct {
+	store  SwapStatsStore
+	prices PriceLookup // optional; without it swaps have no volume
+	now    func() time.Time
+	mu     sync.RWMutex
+}
+
+// NewAnalyticsService creates a service valuing swaps with prices, keeping them in memory until SetStore is called
+func NewAnalyticsService(prices PriceLookup) *AnalyticsService {
+	return &AnalyticsService{
+		store:  NewInMemorySwapStatsStore(),
+		prices: prices,
+		now:    time.Now,
+	}
+}
+
+// SetStore replaces the store swaps are kept in
+func (a *AnalyticsService) SetStore(store SwapStatsStore) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.store = store
+}
+
+// statsStore returns the store swaps are kept in
+func (a *AnalyticsService) statsStore() SwapStatsStore {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.store
+}
+
+// RecordSwap counts a finished swap, valuing it at the source token's current price. Simulated swaps executed
+// nothing and aren't counted. Swaps whose token has no price are counted without volume.
+func (a *AnalyticsService) RecordSwap(ctx context.Context, swap types.ArchivedSwap) error {
+	if swap.Result.Status == types.SwapStatusSimulated {
+		return nil
+	}
+
+	var price float64
+	if swap.Result.Success && a.prices != nil {
+		token := swap.Request.SourceToken
+		var err error
+		if price, err = a.prices.PriceUSD(ctx, token.Symbol, token.ChainID); err != nil {
+			log.Printf("Recording swap %s without volume: %v", swap.RequestID, err)
+		}
+	}
+	record := NewSwapRecord(swap, price)
+	if record.FinishedAt.IsZero() {
+		record.FinishedAt = a.now()
+	}
+	return a.statsStore().RecordSwap(ctx, record)
+}
+
+// Stats returns the statistics of the swaps that finished in each of StatsWindows
+func (a *AnalyticsService) Stats(ctx context.Context) (*types.SwapStats, error) {
+	now := a.now().UTC()
+	stats := &types.SwapStats{Windows: make([]types.SwapStatsWindow, 0, len(StatsWindows)), GeneratedAt: now}
+	for _, window := range StatsWindows {
+		since := now.Add(-window.Duration)
+		totals, err := a.statsStore().SwapTotals(ctx, since)
+		if err != nil {
+			return nil, err
+		}
+		stats.Windows = append(stats.Windows, summarizeSwaps(window.Name, since, totals))
+	}
+	return stats, nil
+}
+
+// swapTally adds up swap totals
+type swapTally struct {
+	swaps, succeeded int64
+	volumeUSD        float64
+	feesUSD          float64
+}
+
+// add adds a pair's totals to the tally
+func (t *swapTally) add(totals types.SwapTotals) {
+	t.swaps += totals.Swaps
+	t.succeeded += totals.Succeeded
+	t.volumeUSD += totals.VolumeUSD
+	t.feesUSD += totals.FeesUSD
+}
+
+// summary derives the rates and averages of the tally
+func (t swapTally) summary() types.SwapStatsSummary {
+	summary := types.SwapStatsSummary{Swaps: t.swaps, Succeeded: t.succeeded, VolumeUSD: t.volumeUSD}
+	if t.swaps > 0 {
+		summary.SuccessRate = float64(t.succeeded) / float64(t.swaps)
+	}
+	if t.succeeded > 0 {
+		summary.AverageFeeUSD = t.feesUSD / float64(t.succeeded)
+	}
+	return summary
+}
+
+// summarizeSwaps summarizes a window's totals overall, per pair and per source chain
+func summarizeSwaps(name string, since time.Time, totals []types.SwapTotals) types.SwapStatsWindow {
+	var overall swapTally
+	pairs := make(map[string]*swapTally)
+	chains := make(map[int64]*swapTally)
+	for _, total := range totals {
+		overall.add(total)
+		if pairs[total.Pair] == nil {
+			pairs[total.Pair] = &swapTally{}
+		}
+		pairs[total.Pair].add(total)
+		if chains[total.SourceChainID] == nil {
+			chains[total.SourceChainID] = &swapTally{}
+		}
+		chains[total.SourceChainID].add(total)
+	}
+
+	window := types.SwapStatsWindow{
+		Window:           name,
+		Since:            since,
+		SwapStatsSummary: overall.summary(),
+		Pairs:            make([]types.PairSwapStats, 0, len(pairs)),
+		Chains:           make([]types.ChainSwapStats, 0, len(chains)),
+	}
+	for pair, tally := range pairs {
+		window.Pairs = append(window.Pairs, types.PairSwapStats{Pair: pair, SwapStatsSummary: tally.summary()})
+	}
+	for chainID, tally := range chains {
+		stats := types.ChainSwapStats{ChainID: chainID, SwapStatsSummary: tally.summary()}
+		if chain, ok := types.GetChain(chainID); ok {
+			stats.ChainName = chain.Name
+		}
+		window.Chains = append(window.Chains, stats)
+	}
+
+	sort.Slice(window.Pairs, func(i, j int) bool {
+		a, b := window.Pairs[i], window.Pairs[j]
+		if a.VolumeUSD != b.VolumeUSD {
+			return a.VolumeUSD > b.VolumeUSD
+		}
+		return a.Pair < b.Pair
+	})
+	sort.Slice(window.Chains, func(i, j int) bool {
+		a, b := window.Chains[i], window.Chains[j]
+		if a.VolumeUSD != b.VolumeUSD {
+			return a.VolumeUSD > b.VolumeUSD
+		}
+		return a.ChainID < b.ChainID
+	})
+	return window
+}
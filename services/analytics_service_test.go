@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// finishedSwap builds a finished swap of amount whole source tokens
+func finishedSwap(requestID string, source, destination types.Token, amount int64, success bool, fee float64, closedAt time.Time) types.ArchivedSwap {
+	input := new(big.Int).Mul(big.NewInt(amount), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(source.Decimals)), nil))
+	status := types.SwapStatusCompleted
+	if !success {
+		status = types.SwapStatusFailed
+	}
+	return types.ArchivedSwap{
+		RequestID: requestID,
+		Request:   types.SwapRequest{RequestID: requestID, SourceToken: source, DestinationToken: destination, Amount: input},
+		Result:    types.SwapResult{RequestID: requestID, Success: success, Status: status, InputAmount: input, Fee: types.Fee{TotalFeeUSD: fee}},
+		ClosedAt:  closedAt,
+	}
+}
+
+func TestAnalyticsService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	analytics := NewAnalyticsService(fixedPrices{"ETH": 2000, "USDC": 1})
+	analytics.now = func() time.Time { return now }
+
+	eth := types.Token{Symbol: "ETH", Decimals: 18, ChainID: types.ChainIDEthereum}
+	usdc := types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDEthereum}
+	baseUSDC := types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDBase}
+	solUSDC := types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.LegacyChainIDSolana}
+	link := types.Token{Symbol: "LINK", Decimals: 18, ChainID: types.ChainIDEthereum}
+
+	for _, swap := range []types.ArchivedSwap{
+		finishedSwap("swap-1", eth, usdc, 2, true, 6, now.Add(-time.Hour)),
+		finishedSwap("swap-2", eth, usdc, 1, false, 3, now.Add(-2*time.Hour)),
+		finishedSwap("swap-3", usdc, baseUSDC, 500, true, 2, now.Add(-3*time.Hour)),
+		// Older than a day, so only in the 7d window
+		finishedSwap("swap-4", eth, usdc, 10, true, 10, now.Add(-3*24*time.Hour)),
+		// Older than a week
+		finishedSwap("swap-5", eth, usdc, 100, true, 100, now.Add(-8*24*time.Hour)),
+		// Unpriced, so counted without volume
+		finishedSwap("swap-6", link, usdc, 5, true, 4, now.Add(-time.Hour)),
+		// Legacy Solana IDs count as Solana
+		finishedSwap("swap-7", solUSDC, usdc, 100, true, 1, now.Add(-time.Hour)),
+	} {
+		if err := analytics.RecordSwap(ctx, swap); err != nil {
+			t.Fatalf("Failed to record %s: %v", swap.RequestID, err)
+		}
+	}
+
+	// Simulated swaps executed nothing
+	simulated := finishedSwap("swap-8", eth, usdc, 50, true, 0, now)
+	simulated.Result.Status = types.SwapStatusSimulated
+	analytics.RecordSwap(ctx, simulated)
+	// Recording a swap again replaces it
+	analytics.RecordSwap(ctx, finishedSwap("swap-1", eth, usdc, 2, true, 6, now.Add(-time.Hour)))
+
+	stats, err := analytics.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if len(stats.Windows) != 2 || stats.Windows[0].Window != "24h" || stats.Windows[1].Window != "7d" {
+		t.Fatalf("Expected 24h and 7d windows, got %+v", stats.Windows)
+	}
+
+	day := stats.Windows[0]
+	if !day.Since.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("Expected the day to start at %s, got %s", now.Add(-24*time.Hour), day.Since)
+	}
+	if day.Swaps != 5 || day.Succeeded != 4 {
+		t.Errorf("Expected 4 of 5 swaps to succeed in the day, got %d of %d", day.Succeeded, day.Swaps)
+	}
+	if day.SuccessRate != 0.8 {
+		t.Errorf("Expected a success rate of 0.8, got %f", day.SuccessRate)
+	}
+	// 2 ETH at $2000, 500 USDC and 100 USDC; failed and unpriced swaps add no volume
+	if day.VolumeUSD != 4600 {
+		t.Errorf("Expected $4600 of volume, got %f", day.VolumeUSD)
+	}
+	// Failed swaps' fees aren't charged
+	if day.AverageFeeUSD != 13.0/4 {
+		t.Errorf("Expected an average fee of $3.25, got %f", day.AverageFeeUSD)
+	}
+
+	if len(day.Pairs) != 3 {
+		t.Fatalf("Expected 3 pairs, got %+v", day.Pairs)
+	}
+	if pair := day.Pairs[0]; pair.Pair != "ETH/USDC" || pair.Swaps != 2 || pair.SuccessRate != 0.5 || pair.VolumeUSD != 4000 || pair.AverageFeeUSD != 6 {
+		t.Errorf("Unexpected ETH/USDC stats: %+v", pair)
+	}
+	if pair := day.Pairs[1]; pair.Pair != "USDC/USDC" || pair.Swaps != 2 || pair.VolumeUSD != 600 {
+		t.Errorf("Expected USDC/USDC swaps on every chain to be one pair, got %+v", pair)
+	}
+	if pair := day.Pairs[2]; pair.Pair != "LINK/USDC" || pair.VolumeUSD != 0 || pair.Succeeded != 1 {
+		t.Errorf("Unexpected LINK/USDC stats: %+v", pair)
+	}
+
+	// Swaps count towards their source chain
+	if len(day.Chains) != 2 {
+		t.Fatalf("Expected 2 chains, got %+v", day.Chains)
+	}
+	if chain := day.Chains[0]; chain.ChainID != types.ChainIDEthereum || chain.ChainName != "Ethereum" || chain.Swaps != 4 || chain.VolumeUSD != 4500 {
+		t.Errorf("Unexpected Ethereum stats: %+v", chain)
+	}
+	if chain := day.Chains[1]; chain.ChainID != types.ChainIDSolana || chain.Swaps != 1 || chain.VolumeUSD != 100 {
+		t.Errorf("Unexpected Solana stats: %+v", chain)
+	}
+
+	week := stats.Windows[1]
+	if week.Swaps != 6 || week.VolumeUSD != 24600 {
+		t.Errorf("Expected 6 swaps and $24600 in the week, got %d and %f", week.Swaps, week.VolumeUSD)
+	}
+	if math.Abs(week.AverageFeeUSD-23.0/5) > 1e-9 {
+		t.Errorf("Expected an average fee of $4.60 in the week, got %f", week.AverageFeeUSD)
+	}
+}
+
+func TestAnalyticsServiceEmpty(t *testing.T) {
+	stats, err := NewAnalyticsService(nil).Stats(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	for _, window := range stats.Windows {
+		if window.Swaps != 0 || window.SuccessRate != 0 || window.AverageFeeUSD != 0 || window.Pairs == nil || window.Chains == nil {
+			t.Errorf("Expected empty stats with empty lists, got %+v", window)
+		}
+	}
+}
+
+func TestNewSwapRecord(t *testing.T) {
+	closedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	swap := finishedSwap("swap-1", types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1}, types.Token{Symbol: "uSOL", Decimals: 9, ChainID: types.LegacyChainIDSolana}, 3, true, 9, closedAt)
+	// The amount spent is counted, not the amount requested
+	swap.Result.InputAmount = new(big.Int).Div(swap.Request.Amount, big.NewInt(2))
+
+	record := NewSwapRecord(swap, 2000)
+	if record.Pair != "uETH/uSOL" || record.SourceChainID != 1 || record.DestinationChainID != types.ChainIDSolana {
+		t.Errorf("Unexpected pair or chains: %+v", record)
+	}
+	if record.VolumeUSD != 3000 || record.FeeUSD != 9 || !record.FinishedAt.Equal(closedAt) {
+		t.Errorf("Unexpected volume, fee or time: %+v", record)
+	}
+
+	swap.Result.Success = false
+	if record := NewSwapRecord(swap, 2000); record.VolumeUSD != 0 || record.FeeUSD != 0 || record.Success {
+		t.Errorf("Expected a failed swap to have no volume or fees, got %+v", record)
+	}
+}
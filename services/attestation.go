@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+const (
+	// attestationHashAlgorithm describes how attestation merkle trees are built: SHA-256 with
+	// RFC 6962 domain separation (0x00 before leaves, 0x01 before node pairs), and an unpaired
+	// node promoted to the next level unchanged
+	attestationHashAlgorithm = "sha256-rfc6962"
+
+	// attestationSignatureAlgorithm is the algorithm the server signs attestations with
+	attestationSignatureAlgorithm = "ed25519"
+
+	// attestationCanonicalization is how documents are encoded before they are hashed or signed: JSON with
+	// object keys sorted, no insignificant whitespace and numbers in their shortest form, as in RFC 8785
+	attestationCanonicalization = "json-sorted-keys"
+)
+
+// SwapAttester exports signed attestations of completed swaps
+type SwapAttester struct {
+	key ed25519.PrivateKey
+	now func() time.Time
+}
+
+// NewSwapAttester creates an attester signing with key
+func NewSwapAttester(key ed25519.PrivateKey) *SwapAttester {
+	return &SwapAttester{
+		key: key,
+		now: time.Now,
+	}
+}
+
+// PublicKey returns the key attestations can be verified with
+func (a *SwapAttester) PublicKey() ed25519.PublicKey {
+	return a.key.Public().(ed25519.PublicKey)
+}
+
+// Attest builds and signs the attestation of a completed swap
+func (a *SwapAttester) Attest(result *types.SwapResult) (*types.SwapAttestation, error) {
+	if result == nil || !result.Success {
+		return nil, errors.New("only completed swaps can be attested")
+	}
+
+	att := &types.SwapAttestation{
+		Version:            types.AttestationVersion,
+		RequestID:          result.RequestID,
+		SourceToken:        result.SourceTx.SourceToken,
+		InputAmount:        amountString(result.InputAmount),
+		SourceAddress:      result.SourceTx.FromAddress,
+		DestinationToken:   result.DestinationTx.DestToken,
+		OutputAmount:       amountString(result.OutputAmount),
+		DestinationAddress: result.DestinationTx.ToAddress,
+		FeeUSD:             result.Fee.TotalFeeUSD,
+		TxHashes:           []string{},
+		HashAlgorithm:      attestationHashAlgorithm,
+		CompletedAt:        result.CompletionTime,
+		IssuedAt:           a.now().UTC(),
+		SignatureAlgorithm: attestationSignatureAlgorithm,
+		Canonicalization:   attestationCanonicalization,
+		PublicKey:          hex.EncodeToString(a.PublicKey()),
+	}
+
+	steps := []struct {
+		name  string
+		tx    types.Transaction
+		token types.Token
+	}{
+		{"source", result.SourceTx, result.SourceTx.SourceToken},
+		{"bridge", result.BridgeTx, result.BridgeTx.SourceToken},
+		{"destination", result.DestinationTx, result.DestinationTx.DestToken},
+	}
+	var leaves [][]byte
+	for _, step := range steps {
+		// Same-chain swaps have no bridge transaction
+		if step.tx.Hash == "" {
+			continue
+		}
+		data := types.AttestationStepData{
+			Index:     len(att.Steps),
+			Name:      step.name,
+			TxHash:    step.tx.Hash,
+			Chain:     step.tx.SourceChain,
+			Token:     step.token,
+			Amount:    amountString(step.tx.Amount),
+			Status:    step.tx.Status,
+			Timestamp: step.tx.Timestamp,
+		}
+		if step.name == "destination" {
+			data.Chain = step.tx.DestChain
+		}
+
+		leaf, err := attestationLeaf(data)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+		att.TxHashes = append(att.TxHashes, data.TxHash)
+		att.Steps = append(att.Steps, types.AttestationStep{Data: data, LeafHash: hex.EncodeToString(leaf)})
+	}
+	if len(leaves) == 0 {
+		return nil, errors.New("swap has no transactions to attest")
+	}
+
+	att.MerkleRoot = hex.EncodeToString(merkleRoot(leaves))
+	for i := range att.Steps {
+		att.Steps[i].Proof = merkleProof(leaves, i)
+	}
+
+	payload, err := canonicalJSON(att)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attestation: %w", err)
+	}
+	att.Signature = hex.EncodeToString(ed25519.Sign(a.key, payload))
+
+	return att, nil
+}
+
+// VerifyAttestation checks an attestation was signed with publicKey, the server's key obtained out of band,
+// and every step's inclusion under its merkle root. The key embedded in the attestation only identifies
+// the signer and is never trusted.
+func VerifyAttestation(att *types.SwapAttestation, publicKey ed25519.PublicKey) error {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+	if att.PublicKey != hex.EncodeToString(publicKey) {
+		return errors.New("attestation was signed with a different key")
+	}
+	if att.Canonicalization != attestationCanonicalization {
+		return fmt.Errorf("unsupported canonicalization %q", att.Canonicalization)
+	}
+	signature, err := hex.DecodeString(att.Signature)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+
+	unsigned := *att
+	unsigned.Signature = ""
+	payload, err := canonicalJSON(&unsigned)
+	if err != nil {
+		return fmt.Errorf("failed to encode attestation: %w", err)
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return errors.New("signature does not match")
+	}
+
+	root, err := hex.DecodeString(att.MerkleRoot)
+	if err != nil {
+		return errors.New("invalid merkle root")
+	}
+	for _, step := range att.Steps {
+		leaf, err := attestationLeaf(step.Data)
+		if err != nil {
+			return err
+		}
+		if !VerifyMerkleProof(leaf, step.Proof, root) {
+			return fmt.Errorf("step %d is not included under the merkle root", step.Data.Index)
+		}
+	}
+	return nil
+}
+
+// VerifyMerkleProof reports whether proof links leaf to root
+func VerifyMerkleProof(leaf []byte, proof []types.MerkleProofNode, root []byte) bool {
+	node := leaf
+	for _, sibling := range proof {
+		hash, err := hex.DecodeString(sibling.Hash)
+		if err != nil {
+			return false
+		}
+		if sibling.Left {
+			node = merkleNode(hash, node)
+		} else {
+			node = merkleNode(node, hash)
+		}
+	}
+	return bytes.Equal(node, root)
+}
+
+// attestationLeaf hashes a step's canonical JSON encoding into a merkle leaf
+func attestationLeaf(data types.AttestationStepData) ([]byte, error) {
+	encoded, err := canonicalJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attestation step: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte{0x00}, encoded...))
+	return sum[:], nil
+}
+
+// canonicalJSON encodes v so that any verifier re-encoding the same document gets the same bytes. Decoding
+// the struct's encoding into generic values sorts object keys when it is encoded again. Numbers keep the
+// encoding json.Marshal gave them, which is already their shortest form.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// merkleNode hashes a pair of child nodes
+func merkleNode(left, right []byte) []byte {
+	buf := make([]byte, 0, 1+len(left)+len(right))
+	buf = append(buf, 0x01)
+	buf = append(buf, left...)
+	buf = append(buf, right...)
+	sum := sha256.Sum256(buf)
+	return sum[:]
+}
+
+// merkleRoot returns the root of the tree over leaves
+func merkleRoot(leaves [][]byte) []byte {
+	level := leaves
+	for len(level) > 1 {
+		level = merkleLevel(level)
+	}
+	return level[0]
+}
+
+// merkleLevel hashes a tree level's node pairs into the level above it
+func merkleLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			continue
+		}
+		next = append(next, merkleNode(level[i], level[i+1]))
+	}
+	return next
+}
+
+// merkleProof returns the sibling hashes linking leaves[index] to the root
+func merkleProof(leaves [][]byte, index int) []types.MerkleProofNode {
+	proof := []types.MerkleProofNode{}
+	level := leaves
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, types.MerkleProofNode{
+				Hash: hex.EncodeToString(level[sibling]),
+				Left: sibling < index,
+			})
+		}
+
+		level = merkleLevel(level)
+		index /= 2
+	}
+	return proof
+}
+
+// amountString formats a base unit amount, treating nil as zero
+func amountString(amount *big.Int) string {
+	if amount == nil {
+		return "0"
+	}
+	return amount.String()
+}
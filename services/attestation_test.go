@@ -0,0 +1,134 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestSwapAttester(t *testing.T) {
+	publicKey, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	attester := NewSwapAttester(key)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	eth := types.Token{Symbol: "ETH", ChainID: 1, Decimals: 18}
+	usdc := types.Token{Symbol: "USDC", ChainID: 10, Decimals: 6}
+	result := &types.SwapResult{
+		RequestID:      "swap-1",
+		Success:        true,
+		SourceTx:       types.Transaction{Hash: "0xsource", Status: "completed", SourceChain: "ethereum", SourceToken: eth, Amount: big.NewInt(1000), FromAddress: "0xfrom", Timestamp: now},
+		BridgeTx:       types.Transaction{Hash: "0xbridge", Status: "completed", SourceChain: "ethereum", SourceToken: eth, Amount: big.NewInt(990), Timestamp: now},
+		DestinationTx:  types.Transaction{Hash: "0xdest", Status: "completed", DestChain: "optimism", DestToken: usdc, Amount: big.NewInt(3000), ToAddress: "0xto", Timestamp: now},
+		InputAmount:    big.NewInt(1000),
+		OutputAmount:   big.NewInt(3000),
+		CompletionTime: now,
+	}
+
+	t.Run("Attest", func(t *testing.T) {
+		att, err := attester.Attest(result)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(att.Steps) != 3 || att.Steps[1].Data.Name != "bridge" {
+			t.Fatalf("Expected source, bridge and destination steps, got %+v", att.Steps)
+		}
+		if att.InputAmount != "1000" || att.OutputAmount != "3000" {
+			t.Errorf("Expected amounts 1000 and 3000, got %s and %s", att.InputAmount, att.OutputAmount)
+		}
+		if att.Steps[2].Data.Chain != "optimism" {
+			t.Errorf("Expected the destination step on optimism, got %s", att.Steps[2].Data.Chain)
+		}
+		if err := VerifyAttestation(att, publicKey); err != nil {
+			t.Errorf("Expected the attestation to verify, got %v", err)
+		}
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		att, err := attester.Attest(result)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		att.OutputAmount = "4000"
+		if err := VerifyAttestation(att, publicKey); err == nil {
+			t.Error("Expected a tampered attestation to fail verification")
+		}
+
+		att, _ = attester.Attest(result)
+		att.Steps[0].Data.Amount = "1"
+		if err := VerifyAttestation(att, publicKey); err == nil {
+			t.Error("Expected a tampered step to fail verification")
+		}
+	})
+
+	t.Run("OtherKey", func(t *testing.T) {
+		att, err := attester.Attest(result)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		// A forger signing with their own key embeds it in the attestation
+		otherPublic, otherKey, _ := ed25519.GenerateKey(nil)
+		forged, _ := NewSwapAttester(otherKey).Attest(result)
+		if err := VerifyAttestation(forged, publicKey); err == nil {
+			t.Error("Expected an attestation signed with another key to fail verification")
+		}
+		if err := VerifyAttestation(att, otherPublic); err == nil {
+			t.Error("Expected verification against the wrong key to fail")
+		}
+	})
+
+	t.Run("Canonical", func(t *testing.T) {
+		encoded, err := canonicalJSON(types.MerkleProofNode{Hash: "<ab>", Left: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := `{"hash":"<ab>","left":true}`; string(encoded) != want {
+			t.Errorf("Expected %s, got %s", want, encoded)
+		}
+	})
+
+	t.Run("SameChain", func(t *testing.T) {
+		sameChain := *result
+		sameChain.BridgeTx = types.Transaction{}
+		att, err := attester.Attest(&sameChain)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(att.Steps) != 2 || len(att.TxHashes) != 2 {
+			t.Errorf("Expected two steps without a bridge, got %d", len(att.Steps))
+		}
+		if err := VerifyAttestation(att, publicKey); err != nil {
+			t.Errorf("Expected the attestation to verify, got %v", err)
+		}
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		if _, err := attester.Attest(&types.SwapResult{RequestID: "swap-2"}); err == nil {
+			t.Error("Expected an error attesting an incomplete swap")
+		}
+	})
+
+	t.Run("MerkleProofs", func(t *testing.T) {
+		// Odd-sized trees promote the unpaired node
+		var leaves [][]byte
+		for i := 0; i < 5; i++ {
+			leaf, _ := attestationLeaf(types.AttestationStepData{Index: i})
+			leaves = append(leaves, leaf)
+		}
+		root := merkleRoot(leaves)
+		for i, leaf := range leaves {
+			if !VerifyMerkleProof(leaf, merkleProof(leaves, i), root) {
+				t.Errorf("Expected the proof of leaf %d to verify against %s", i, hex.EncodeToString(root))
+			}
+		}
+		if VerifyMerkleProof(leaves[0], merkleProof(leaves, 1), root) {
+			t.Error("Expected a proof to fail for the wrong leaf")
+		}
+	})
+}
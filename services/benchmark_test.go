@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func BenchmarkGetSwapQuote(b *testing.B) {
+	service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+	ctx := context.Background()
+
+	requests := map[string]types.SwapRequest{
+		"same-chain": {
+			SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+			DestinationToken: types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1},
+			Amount:           big.NewInt(1000000000000000000),
+			Slippage:         0.5,
+		},
+		"cross-chain": {
+			SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+			DestinationToken: types.Token{Symbol: "MATIC", Decimals: 18, ChainID: 137},
+			Amount:           big.NewInt(1000000000000000000),
+			Slippage:         0.5,
+		},
+	}
+
+	for name, request := range requests {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := service.GetSwapQuote(ctx, request); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetAmountOut(b *testing.B) {
+	amountIn := big.NewInt(1000000000000000000)
+	reserveIn, _ := new(big.Int).SetString("5000000000000000000000", 10)
+	reserveOut, _ := new(big.Int).SetString("10000000000000", 10)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetAmountOut(amountIn, reserveIn, reserveOut, 3000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// newBenchTokenService creates a token service holding n tokens spread over five chains
+func newBenchTokenService(b *testing.B, n int) *TokenService {
+	b.Helper()
+
+	service := NewTokenService()
+	for i := 0; i < n; i++ {
+		token := types.Token{
+			Symbol:  fmt.Sprintf("TKN%d", i),
+			Name:    fmt.Sprintf("Token %d", i),
+			ChainID: int64(i%5 + 1),
+		}
+		if err := service.AddToken(token); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return service
+}
+
+func BenchmarkTokenLookup(b *testing.B) {
+	service := newBenchTokenService(b, 1000)
+
+	b.Run("GetToken", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := service.GetToken("TKN500"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("GetTokensByChain", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			service.GetTokensByChain(3)
+		}
+	})
+
+	b.Run("GetAllTokens", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			service.GetAllTokens()
+		}
+	})
+}
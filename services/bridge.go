@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// Statuses of a bridge transfer
+const (
+	BridgeTransferPending   = "pending"
+	BridgeTransferCompleted = "completed"
+	BridgeTransferFailed    = "failed" // Including transfers the bridge refunded on the source chain
+)
+
+// BridgeTransferStatus is where a transfer through a bridge is
+type BridgeTransferStatus struct {
+	Status       string `json:"status"` // pending, completed or failed
+	DestTxHash   string `json:"destTxHash,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// Bridge carries transfers across chains. It quotes them like any BridgeQuoter and tracks the transfers it carries.
+type Bridge interface {
+	BridgeQuoter
+	// TransferStatus returns the status of a transfer from the chain sourceChainID, identified by transferID: the ID
+	// the bridge gave the transfer, or the hash of its source transaction for bridges that don't give one
+	TransferStatus(ctx context.Context, sourceChainID int64, transferID string) (*BridgeTransferStatus, error)
+}
+
+// HTTPBridge is a bridge reached through a quote service speaking the bridge protocol below, such as one run in
+// front of Wormhole, Axelar or LayerZero. It carries transfers between the chains it is configured with only.
+//
+//	GET {url}/quote?sourceChainId=1&destinationChainId=137&sourceToken=0x..&destinationToken=0x..&amount=1000000
+//	  200 {"fee": "2500", "estimatedTimeSec": 900, "minAmount": "10000", "maxAmount": "1000000000000"}, amounts in
+//	      the source token's smallest unit and the limits optional
+//	  204 when the bridge can't carry the transfer
+//	GET {url}/transfers/{transferId}?sourceChainId=1
+//	  200 {"status": "pending|completed|failed", "destTxHash": "0x..", "errorMessage": ".."}
+//
+// Tokens are identified by address, or by symbol when they have none.
+type HTTPBridge struct {
+	client  *http.Client
+	name    string
+	baseURL string
+	chains  map[int64]bool
+}
+
+// httpBridgeQuote is a bridge protocol quote
+type httpBridgeQuote struct {
+	Fee              string `json:"fee"`
+	EstimatedTimeSec int64  `json:"estimatedTimeSec"`
+	MinAmount        string `json:"minAmount,omitempty"`
+	MaxAmount        string `json:"maxAmount,omitempty"`
+}
+
+// NewHTTPBridge creates the bridge name, quoted and tracked by the service at baseURL, connecting the chains
+// chainIDs
+func NewHTTPBridge(client *http.Client, name, baseURL string, chainIDs []int64) *HTTPBridge {
+	chains := make(map[int64]bool, len(chainIDs))
+	for _, chainID := range chainIDs {
+		chains[types.CanonicalChainID(chainID)] = true
+	}
+	return &HTTPBridge{
+		client:  client,
+		name:    name,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		chains:  chains,
+	}
+}
+
+// Name returns the bridge's name
+func (b *HTTPBridge) Name() string {
+	return b.name
+}
+
+// QuoteTransfer quotes a transfer between two of the bridge's chains
+func (b *HTTPBridge) QuoteTransfer(ctx context.Context, source, destination types.Token, amount *big.Int) (*BridgeCandidate, error) {
+	if !b.chains[types.CanonicalChainID(source.ChainID)] || !b.chains[types.CanonicalChainID(destination.ChainID)] {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	query.Set("sourceChainId", strconv.FormatInt(source.ChainID, 10))
+	query.Set("destinationChainId", strconv.FormatInt(destination.ChainID, 10))
+	query.Set("sourceToken", bridgeTokenID(source))
+	query.Set("destinationToken", bridgeTokenID(destination))
+	query.Set("amount", amount.String())
+
+	var quote httpBridgeQuote
+	found, err := b.get(ctx, "/quote?"+query.Encode(), &quote)
+	if err != nil || !found {
+		return nil, err
+	}
+	fee, ok := new(big.Int).SetString(quote.Fee, 10)
+	if !ok || fee.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s fee %q", b.name, quote.Fee)
+	}
+
+	return &BridgeCandidate{
+		Bridge:        b.name,
+		Fee:           fee,
+		EstimatedTime: time.Duration(quote.EstimatedTimeSec) * time.Second,
+		MinAmount:     parseBridgeLimit(quote.MinAmount),
+		MaxAmount:     parseBridgeLimit(quote.MaxAmount),
+	}, nil
+}
+
+// TransferStatus returns the status of a transfer the bridge carried
+func (b *HTTPBridge) TransferStatus(ctx context.Context, sourceChainID int64, transferID string) (*BridgeTransferStatus, error) {
+	query := url.Values{}
+	query.Set("sourceChainId", strconv.FormatInt(sourceChainID, 10))
+
+	var status BridgeTransferStatus
+	found, err := b.get(ctx, "/transfers/"+url.PathEscape(transferID)+"?"+query.Encode(), &status)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s has no transfer %s", b.name, transferID)
+	}
+	switch status.Status {
+	case BridgeTransferPending, BridgeTransferCompleted, BridgeTransferFailed:
+		return &status, nil
+	default:
+		return nil, fmt.Errorf("invalid %s transfer status %q", b.name, status.Status)
+	}
+}
+
+// get decodes the JSON response to a GET of path, reporting false when the service has no content for it
+func (b *HTTPBridge) get(ctx context.Context, path string, response interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s returned status %d", b.name, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return false, fmt.Errorf("invalid %s response: %w", b.name, err)
+	}
+	return true, nil
+}
+
+// bridgeTokenID identifies a token to a bridge: by address where it has one, else by symbol
+func bridgeTokenID(token types.Token) string {
+	if token.Address != "" {
+		return token.Address
+	}
+	return token.Symbol
+}
+
+// parseBridgeLimit parses a bridge's transfer limit, nil when the bridge gives none or an invalid one
+func parseBridgeLimit(limit string) *big.Int {
+	amount, ok := new(big.Int).SetString(limit, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil
+	}
+	return amount
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// BridgeAcross is the Across Protocol bridge
+const BridgeAcross = "across"
+
+// AcrossBridge quotes transfers between EVM chains with the Across suggested-fees API and tracks them with its deposit
+// status API
+type AcrossBridge struct {
+	client  *http.Client
+	baseURL string
+}
+
+// acrossFeesResponse is the part of an Across suggested-fees response used for quoting
+type acrossFeesResponse struct {
+	TotalRelayFee struct {
+		Total string `json:"total"` // In the input token's smallest unit
+	} `json:"totalRelayFee"`
+	EstimatedFillTimeSec int64 `json:"estimatedFillTimeSec"`
+	IsAmountTooLow       bool  `json:"isAmountTooLow"`
+	Limits               struct {
+		MinDeposit string `json:"minDeposit"`
+		MaxDeposit string `json:"maxDeposit"`
+	} `json:"limits"`
+}
+
+// NewAcrossBridge creates a quoter for the Across API at baseURL, e.g. https://app.across.to/api
+func NewAcrossBridge(client *http.Client, baseURL string) *AcrossBridge {
+	return &AcrossBridge{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Name returns the bridge's name
+func (b *AcrossBridge) Name() string {
+	return BridgeAcross
+}
+
+// QuoteTransfer quotes a transfer through Across. Across only carries ERC-20 tokens between EVM chains,
+// and refuses amounts too small to cover its relay fee.
+func (b *AcrossBridge) QuoteTransfer(ctx context.Context, source, destination types.Token, amount *big.Int) (*BridgeCandidate, error) {
+	if !isEVMToken(source) || !isEVMToken(destination) {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	query.Set("inputToken", source.Address)
+	query.Set("outputToken", destination.Address)
+	query.Set("originChainId", strconv.FormatInt(source.ChainID, 10))
+	query.Set("destinationChainId", strconv.FormatInt(destination.ChainID, 10))
+	query.Set("amount", amount.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"/suggested-fees?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("across returned status %d", resp.StatusCode)
+	}
+
+	var fees acrossFeesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&fees); err != nil {
+		return nil, fmt.Errorf("invalid across response: %w", err)
+	}
+	if fees.IsAmountTooLow {
+		return nil, nil
+	}
+	fee, ok := new(big.Int).SetString(fees.TotalRelayFee.Total, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid across relay fee %q", fees.TotalRelayFee.Total)
+	}
+
+	return &BridgeCandidate{
+		Bridge:        BridgeAcross,
+		Fee:           fee,
+		EstimatedTime: time.Duration(fees.EstimatedFillTimeSec) * time.Second,
+		MinAmount:     parseBridgeLimit(fees.Limits.MinDeposit),
+		MaxAmount:     parseBridgeLimit(fees.Limits.MaxDeposit),
+	}, nil
+}
+
+// acrossDepositStatus is the part of an Across deposit status response used for tracking transfers
+type acrossDepositStatus struct {
+	Status string `json:"status"` // pending, filled, expired or refunded
+	FillTx string `json:"fillTx"`
+}
+
+// TransferStatus returns the status of the Across deposit made in the source transaction transferID
+func (b *AcrossBridge) TransferStatus(ctx context.Context, sourceChainID int64, transferID string) (*BridgeTransferStatus, error) {
+	query := url.Values{}
+	query.Set("originChainId", strconv.FormatInt(sourceChainID, 10))
+	query.Set("depositTxHash", transferID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"/deposit/status?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("across returned status %d", resp.StatusCode)
+	}
+	var deposit acrossDepositStatus
+	if err := json.NewDecoder(resp.Body).Decode(&deposit); err != nil {
+		return nil, fmt.Errorf("invalid across response: %w", err)
+	}
+
+	switch deposit.Status {
+	case "pending":
+		return &BridgeTransferStatus{Status: BridgeTransferPending}, nil
+	case "filled":
+		return &BridgeTransferStatus{Status: BridgeTransferCompleted, DestTxHash: deposit.FillTx}, nil
+	case "expired", "refunded":
+		return &BridgeTransferStatus{Status: BridgeTransferFailed, ErrorMessage: "deposit " + deposit.Status}, nil
+	default:
+		return nil, fmt.Errorf("invalid across deposit status %q", deposit.Status)
+	}
+}
+
+// isEVMToken reports whether a token is a contract on an EVM chain
+func isEVMToken(token types.Token) bool {
+	chain, ok := types.GetChain(token.ChainID)
+	return ok && chain.Namespace == "eip155" && token.Address != "" && !token.IsNative()
+}
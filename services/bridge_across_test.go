@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestAcrossBridgeQuoteTransfer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/api/suggested-fees" || query.Get("originChainId") != "1" || query.Get("destinationChainId") != "137" || query.Get("amount") != "1000000" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"totalRelayFee": {"pct": "2500000000000000", "total": "2500"}, "estimatedFillTimeSec": 12, "isAmountTooLow": false, "limits": {"minDeposit": "1000", "maxDeposit": "5000000000"}}`))
+	}))
+	defer server.Close()
+
+	bridge := NewAcrossBridge(server.Client(), server.URL+"/api/")
+	usdc := types.Token{Symbol: "USDC", ChainID: types.ChainIDEthereum, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}
+	polygonUSDC := types.Token{Symbol: "USDC", ChainID: types.ChainIDPolygon, Address: "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"}
+
+	candidate, err := bridge.QuoteTransfer(context.Background(), usdc, polygonUSDC, big.NewInt(1000000))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if candidate == nil || candidate.Bridge != BridgeAcross || candidate.Fee.Int64() != 2500 || candidate.EstimatedTime != 12*time.Second {
+		t.Errorf("Unexpected candidate %+v", candidate)
+	}
+	if candidate != nil && (candidate.MinAmount.Int64() != 1000 || candidate.MaxAmount.Int64() != 5000000000) {
+		t.Errorf("Expected Across's deposit limits, got %v and %v", candidate.MinAmount, candidate.MaxAmount)
+	}
+
+	// Native tokens and non-EVM chains aren't quoted
+	eth := types.Token{Symbol: "ETH", ChainID: types.ChainIDEthereum, Address: types.NativeTokenAddress}
+	if candidate, err := bridge.QuoteTransfer(context.Background(), eth, polygonUSDC, big.NewInt(1000000)); err != nil || candidate != nil {
+		t.Errorf("Expected no quote for a native token, got %+v, %v", candidate, err)
+	}
+}
+
+func TestAcrossBridgeTransferStatus(t *testing.T) {
+	statuses := map[string]string{
+		"0xpending":  `{"status": "pending"}`,
+		"0xfilled":   `{"status": "filled", "fillTx": "0xfill"}`,
+		"0xrefunded": `{"status": "refunded"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/api/deposit/status" || query.Get("originChainId") != "1" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		w.Write([]byte(statuses[query.Get("depositTxHash")]))
+	}))
+	defer server.Close()
+	bridge := NewAcrossBridge(server.Client(), server.URL+"/api")
+
+	for transferID, expected := range map[string]BridgeTransferStatus{
+		"0xpending":  {Status: BridgeTransferPending},
+		"0xfilled":   {Status: BridgeTransferCompleted, DestTxHash: "0xfill"},
+		"0xrefunded": {Status: BridgeTransferFailed, ErrorMessage: "deposit refunded"},
+	} {
+		status, err := bridge.TransferStatus(context.Background(), types.ChainIDEthereum, transferID)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", transferID, err)
+		}
+		if *status != expected {
+			t.Errorf("Expected %+v for %s, got %+v", expected, transferID, *status)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// BridgeOutcomeStore persists bridge transfer outcomes, so the API server and the swap worker score
+// bridges from one history
+type BridgeOutcomeStore interface {
+	// RecordOutcome stores a swap's bridge outcome. It reports false, changing nothing, when the swap's
+	// outcome was recorded before.
+	RecordOutcome(ctx context.Context, outcome types.BridgeOutcome) (bool, error)
+	// RecentOutcomes returns up to limit of the most recent outcomes of each bridge, oldest first
+	RecentOutcomes(ctx context.Context, limit int) (map[string][]types.BridgeOutcome, error)
+}
+
+// InMemoryBridgeOutcomeStore is a BridgeOutcomeStore for running without a database.
+// It keeps only the most recent outcomes of each bridge.
+type InMemoryBridgeOutcomeStore struct {
+	outcomes map[string][]types.BridgeOutcome // map[bridge]outcomes, oldest first
+	recorded map[string]bool                  // request IDs of the kept outcomes
+	keep     int
+	mu       sync.RWMutex
+}
+
+// NewInMemoryBridgeOutcomeStore creates an empty store keeping the last keep outcomes of each bridge
+func NewInMemoryBridgeOutcomeStore(keep int) *InMemoryBridgeOutcomeStore {
+	return &InMemoryBridgeOutcomeStore{
+		outcomes: make(map[string][]types.BridgeOutcome),
+		recorded: make(map[string]bool),
+		keep:     keep,
+	}
+}
+
+// RecordOutcome stores an outcome, evicting the bridge's oldest outcome once it has keep of them
+func (s *InMemoryBridgeOutcomeStore) RecordOutcome(ctx context.Context, outcome types.BridgeOutcome) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if outcome.RequestID != "" {
+		if s.recorded[outcome.RequestID] {
+			return false, nil
+		}
+		s.recorded[outcome.RequestID] = true
+	}
+
+	outcomes := append(s.outcomes[outcome.Bridge], outcome)
+	sort.SliceStable(outcomes, func(i, j int) bool {
+		return outcomes[i].At.Before(outcomes[j].At)
+	})
+	if len(outcomes) > s.keep {
+		for _, evicted := range outcomes[:len(outcomes)-s.keep] {
+			delete(s.recorded, evicted.RequestID)
+		}
+		outcomes = outcomes[len(outcomes)-s.keep:]
+	}
+	s.outcomes[outcome.Bridge] = outcomes
+	return true, nil
+}
+
+// RecentOutcomes returns copies of the most recent outcomes of each bridge
+func (s *InMemoryBridgeOutcomeStore) RecentOutcomes(ctx context.Context, limit int) (map[string][]types.BridgeOutcome, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recent := make(map[string][]types.BridgeOutcome, len(s.outcomes))
+	for bridge, outcomes := range s.outcomes {
+		if len(outcomes) > limit {
+			outcomes = outcomes[len(outcomes)-limit:]
+		}
+		recent[bridge] = append([]types.BridgeOutcome(nil), outcomes...)
+	}
+	return recent, nil
+}
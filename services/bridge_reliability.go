@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// BridgeUniversal is the Universal.xyz bridge
+const BridgeUniversal = "universal"
+
+const (
+	// defaultReliabilityWindow is how many recent outcomes are scored per bridge
+	defaultReliabilityWindow = 200
+
+	// reliabilityHalfLife is the age at which an outcome counts half as much as a fresh one,
+	// so a bridge that was unreliable recently is penalized more than one that failed last week
+	reliabilityHalfLife = 6 * time.Hour
+)
+
+// BridgeStats summarizes a bridge's recent reliability
+type BridgeStats struct {
+	Bridge            string     `json:"bridge"`
+	Samples           int        `json:"samples"`
+	SuccessRate       float64    `json:"successRate"`      // Recency-weighted, 0-1
+	MedianCompletion  float64    `json:"medianCompletion"` // Seconds, successful transfers only
+	FeeAccuracy       float64    `json:"feeAccuracy"`      // 1 when actual fees match quotes, 0-1
+	LastFailureAt     *time.Time `json:"lastFailureAt,omitempty"`
+	LastSuccessAt     *time.Time `json:"lastSuccessAt,omitempty"`
+	ConsecutiveErrors int        `json:"consecutiveErrors"`
+}
+
+// BridgeReliability scores bridges from the recent outcomes in its store
+type BridgeReliability struct {
+	store  BridgeOutcomeStore
+	window int
+	now    func() time.Time
+	mu     sync.RWMutex
+}
+
+// NewBridgeReliability creates a tracker scoring the last window outcomes per bridge, kept in memory until
+// SetStore is called; a window of 0 uses the default
+func NewBridgeReliability(window int) *BridgeReliability {
+	if window <= 0 {
+		window = defaultReliabilityWindow
+	}
+	return &BridgeReliability{
+		store:  NewInMemoryBridgeOutcomeStore(window),
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// SetStore replaces the store outcomes are kept in
+func (r *BridgeReliability) SetStore(store BridgeOutcomeStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// outcomeStore returns the store outcomes are kept in
+func (r *BridgeReliability) outcomeStore() BridgeOutcomeStore {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.store
+}
+
+// Record adds a swap's bridge outcome, reporting false when the swap's outcome was already recorded
+func (r *BridgeReliability) Record(ctx context.Context, outcome types.BridgeOutcome) (bool, error) {
+	if outcome.At.IsZero() {
+		outcome.At = r.now()
+	}
+	return r.outcomeStore().RecordOutcome(ctx, outcome)
+}
+
+// Stats returns the reliability stats of a bridge; a bridge without outcomes has zero samples
+func (r *BridgeReliability) Stats(ctx context.Context, bridge string) (BridgeStats, error) {
+	outcomes, err := r.outcomeStore().RecentOutcomes(ctx, r.window)
+	if err != nil {
+		return BridgeStats{}, err
+	}
+	return bridgeStats(bridge, outcomes[bridge], r.now()), nil
+}
+
+// AllStats returns the stats of every bridge with recorded outcomes, ordered by name
+func (r *BridgeReliability) AllStats(ctx context.Context) ([]BridgeStats, error) {
+	outcomes, err := r.outcomeStore().RecentOutcomes(ctx, r.window)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now()
+	stats := make([]BridgeStats, 0, len(outcomes))
+	for bridge, bridgeOutcomes := range outcomes {
+		stats = append(stats, bridgeStats(bridge, bridgeOutcomes, now))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Bridge < stats[j].Bridge
+	})
+	return stats, nil
+}
+
+// bridgeStats summarizes outcomes, weighting each by its age
+func bridgeStats(bridge string, outcomes []types.BridgeOutcome, now time.Time) BridgeStats {
+	stats := BridgeStats{Bridge: bridge, Samples: len(outcomes), SuccessRate: 1, FeeAccuracy: 1}
+	if len(outcomes) == 0 {
+		return stats
+	}
+
+	var totalWeight, successWeight float64
+	var feeWeight, accuracySum float64
+	var durations []time.Duration
+	for _, outcome := range outcomes {
+		weight := math.Pow(0.5, now.Sub(outcome.At).Hours()/reliabilityHalfLife.Hours())
+		totalWeight += weight
+
+		if outcome.Success {
+			successWeight += weight
+			durations = append(durations, outcome.Duration)
+			at := outcome.At
+			stats.LastSuccessAt = &at
+			stats.ConsecutiveErrors = 0
+		} else {
+			at := outcome.At
+			stats.LastFailureAt = &at
+			stats.ConsecutiveErrors++
+		}
+
+		if accuracy, ok := feeAccuracy(outcome.QuotedFee, outcome.ActualFee); ok {
+			feeWeight += weight
+			accuracySum += weight * accuracy
+		}
+	}
+
+	if totalWeight > 0 {
+		stats.SuccessRate = successWeight / totalWeight
+	}
+	if feeWeight > 0 {
+		stats.FeeAccuracy = accuracySum / feeWeight
+	}
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		median := durations[len(durations)/2]
+		if len(durations)%2 == 0 {
+			median = (durations[len(durations)/2-1] + median) / 2
+		}
+		stats.MedianCompletion = median.Seconds()
+	}
+
+	return stats
+}
+
+// feeAccuracy scores how close an actual fee was to its quote: 1 for an exact match,
+// falling to 0 when the actual fee is double the quote or more. Fees below the quote count as accurate.
+func feeAccuracy(quoted, actual *big.Int) (float64, bool) {
+	if quoted == nil || actual == nil || quoted.Sign() <= 0 {
+		return 0, false
+	}
+	if actual.Cmp(quoted) <= 0 {
+		return 1, true
+	}
+
+	over := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Sub(actual, quoted)), new(big.Float).SetInt(quoted))
+	ratio, _ := over.Float64()
+	return math.Max(0, 1-ratio), true
+}
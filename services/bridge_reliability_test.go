@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestBridgeReliability(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	reliability := NewBridgeReliability(4)
+	reliability.now = func() time.Time { return now }
+
+	t.Run("NoHistory", func(t *testing.T) {
+		stats, _ := reliability.Stats(ctx, "hop")
+		if stats.Samples != 0 || stats.SuccessRate != 1 || stats.FeeAccuracy != 1 {
+			t.Errorf("Expected neutral stats without history, got %+v", stats)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: "hop", Success: true, Duration: 2 * time.Minute, QuotedFee: big.NewInt(100), ActualFee: big.NewInt(100), At: now})
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: "hop", Success: true, Duration: 4 * time.Minute, QuotedFee: big.NewInt(100), ActualFee: big.NewInt(150), At: now})
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: "hop", Success: false, At: now})
+
+		stats, _ := reliability.Stats(ctx, "hop")
+		if stats.Samples != 3 {
+			t.Errorf("Expected 3 samples, got %d", stats.Samples)
+		}
+		if stats.SuccessRate < 0.66 || stats.SuccessRate > 0.67 {
+			t.Errorf("Expected success rate ~0.67, got %f", stats.SuccessRate)
+		}
+		if stats.MedianCompletion != 180 {
+			t.Errorf("Expected median of 180s, got %f", stats.MedianCompletion)
+		}
+		if stats.FeeAccuracy != 0.75 {
+			t.Errorf("Expected fee accuracy 0.75, got %f", stats.FeeAccuracy)
+		}
+		if stats.ConsecutiveErrors != 1 || stats.LastFailureAt == nil {
+			t.Errorf("Expected one trailing failure, got %+v", stats)
+		}
+	})
+
+	t.Run("RecentOutcomesWeighMore", func(t *testing.T) {
+		stale := NewBridgeReliability(0)
+		stale.now = func() time.Time { return now }
+		stale.Record(ctx, types.BridgeOutcome{Bridge: "hop", Success: false, At: now.Add(-48 * time.Hour)})
+		stale.Record(ctx, types.BridgeOutcome{Bridge: "hop", Success: true, At: now})
+
+		if stats, _ := stale.Stats(ctx, "hop"); stats.SuccessRate < 0.99 {
+			t.Errorf("Expected an old failure to barely count, got success rate %f", stats.SuccessRate)
+		}
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			reliability.Record(ctx, types.BridgeOutcome{Bridge: "hop", Success: true, At: now})
+		}
+		if stats, _ := reliability.Stats(ctx, "hop"); stats.Samples != 4 || stats.SuccessRate != 1 {
+			t.Errorf("Expected only the last 4 outcomes to be kept, got %+v", stats)
+		}
+	})
+}
+
+func TestBridgeRouter(t *testing.T) {
+	ctx := context.Background()
+	reliability := NewBridgeReliability(0)
+	router := NewBridgeRouter(reliability)
+
+	candidates := []BridgeCandidate{
+		{Bridge: "cheap", Fee: big.NewInt(100), EstimatedTime: time.Minute},
+		{Bridge: "pricey", Fee: big.NewInt(150), EstimatedTime: time.Minute},
+	}
+
+	selected, err := router.SelectBridge(ctx, candidates)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if selected.Bridge != "cheap" {
+		t.Errorf("Expected the cheapest bridge without history, got %s", selected.Bridge)
+	}
+
+	// A few recent failures make the cheap bridge cost more than the reliable one
+	for i := 0; i < 8; i++ {
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: "cheap", Success: i%3 != 0, Duration: time.Minute})
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: "pricey", Success: true, Duration: time.Minute})
+	}
+	if selected, _ := router.SelectBridge(ctx, candidates); selected.Bridge != "pricey" {
+		t.Errorf("Expected the recently unreliable bridge to be penalized, got %s", selected.Bridge)
+	}
+
+	// Unreliable bridges lose even when much cheaper
+	for i := 0; i < 20; i++ {
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: "cheap", Success: false})
+	}
+	candidates[0].Fee = big.NewInt(1)
+	if selected, _ := router.SelectBridge(ctx, candidates); selected.Bridge != "pricey" {
+		t.Errorf("Expected the unreliable bridge to be a last resort, got %s", selected.Bridge)
+	}
+
+	if _, err := router.SelectBridge(ctx, nil); err == nil {
+		t.Error("Expected error without candidates, got nil")
+	}
+}
+
+func TestBridgeRouterCorridors(t *testing.T) {
+	ctx := context.Background()
+	reliability := NewBridgeReliability(0)
+	router := NewBridgeRouter(reliability)
+
+	candidates := []BridgeCandidate{
+		{Bridge: "slow", Fee: big.NewInt(100), EstimatedTime: 20 * time.Minute},
+		{Bridge: "fast", Fee: big.NewInt(150), EstimatedTime: 2 * time.Minute},
+		{Bridge: "faster", Fee: big.NewInt(300), EstimatedTime: time.Minute},
+	}
+	polygon := BridgeCorridor{SourceChainID: types.ChainIDEthereum, DestinationChainID: types.ChainIDPolygon}
+	arbitrum := BridgeCorridor{SourceChainID: types.ChainIDEthereum, DestinationChainID: types.ChainIDArbitrum}
+	router.SetCorridorPolicy(polygon, CorridorPolicy{Prefer: BridgePreferFastest, Bridges: []string{"slow", "fast"}})
+	router.SetCorridorPolicy(arbitrum, CorridorPolicy{Prefer: BridgePreferFastest})
+
+	if selected, _ := router.Route(ctx, BridgeCorridor{SourceChainID: types.ChainIDPolygon, DestinationChainID: types.ChainIDEthereum}, candidates); selected.Bridge != "slow" {
+		t.Errorf("Expected the cheapest bridge on a corridor without a policy, got %s", selected.Bridge)
+	}
+	if selected, _ := router.Route(ctx, arbitrum, candidates); selected.Bridge != "faster" {
+		t.Errorf("Expected the fastest bridge, got %s", selected.Bridge)
+	}
+	if selected, _ := router.Route(ctx, polygon, candidates); selected.Bridge != "fast" {
+		t.Errorf("Expected the fastest of the corridor's bridges, got %s", selected.Bridge)
+	}
+
+	// A bridge slower than its estimate is expected to take as long as it has been taking
+	for i := 0; i < minReliabilitySamples; i++ {
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: "faster", Success: true, Duration: 10 * time.Minute})
+	}
+	if selected, _ := router.Route(ctx, arbitrum, candidates); selected.Bridge != "fast" {
+		t.Errorf("Expected the bridge that has been slow passed over, got %s", selected.Bridge)
+	}
+
+	if _, err := router.Route(ctx, polygon, candidates[2:]); err == nil {
+		t.Error("Expected an error when none of the corridor's bridges can carry the transfer")
+	}
+
+	// Ranking orders every candidate the way Route chooses, the bridges the corridor doesn't allow last
+	ranked := router.Rank(ctx, polygon, candidates)
+	if len(ranked) != 3 || ranked[0].Bridge != "fast" || ranked[1].Bridge != "slow" || ranked[2].Bridge != "faster" || ranked[2].Allowed {
+		t.Errorf("Unexpected ranking %+v", ranked)
+	}
+	if ranked[2].ExpectedTime != 10*time.Minute || ranked[2].Stats.Samples != minReliabilitySamples {
+		t.Errorf("Expected the slow bridge's history in its ranking, got %+v", ranked[2])
+	}
+}
+
+func TestBridgeReliabilityRecordsOnce(t *testing.T) {
+	ctx := context.Background()
+	reliability := NewBridgeReliability(0)
+
+	outcome := types.BridgeOutcome{RequestID: "swap-1", Bridge: "hop", Success: true}
+	if recorded, err := reliability.Record(ctx, outcome); err != nil || !recorded {
+		t.Fatalf("Expected the first outcome recorded, got %v, %v", recorded, err)
+	}
+	outcome.Success = false
+	if recorded, _ := reliability.Record(ctx, outcome); recorded {
+		t.Error("Expected a second outcome of the same swap to be ignored")
+	}
+	if stats, _ := reliability.Stats(ctx, "hop"); stats.Samples != 1 || stats.SuccessRate != 1 {
+		t.Errorf("Expected only the first outcome, got %+v", stats)
+	}
+}
+
+// stubBridge quotes every transfer at a fixed fee, carrying transfers of at least min when set
+type stubBridge struct {
+	name string
+	fee  int64
+	min  int64
+}
+
+func (b stubBridge) Name() string {
+	return b.name
+}
+
+func (b stubBridge) QuoteTransfer(ctx context.Context, source, destination types.Token, amount *big.Int) (*BridgeCandidate, error) {
+	candidate := &BridgeCandidate{Bridge: b.name, Fee: big.NewInt(b.fee), EstimatedTime: universalBridgeTime}
+	if b.min > 0 {
+		candidate.MinAmount = big.NewInt(b.min)
+	}
+	return candidate, nil
+}
+
+func TestSwapServiceSelectsBridge(t *testing.T) {
+	ctx := context.Background()
+	transactionService := NewTransactionService()
+	service := NewSwapService(NewTokenService(), transactionService, &MockUniversalSDK{})
+	reliability := NewBridgeReliability(0)
+	service.SetBridgeRouter(NewBridgeRouter(reliability))
+	service.AddBridge(stubBridge{name: "hop", fee: 100})
+
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "ETH", ChainID: types.ChainIDEthereum, ChainName: "Ethereum"},
+		DestinationToken: types.Token{Symbol: "ETH", ChainID: types.ChainIDPolygon, ChainName: "Polygon"},
+		Amount:           big.NewInt(1000000000000000000),
+		RequestID:        "cross-chain-1",
+	}
+
+	quote, err := service.GetSwapQuote(ctx, request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if quote.Bridge != BridgeUniversal {
+		t.Errorf("Expected the cheaper %s bridge, got %q", BridgeUniversal, quote.Bridge)
+	}
+
+	// Once Universal keeps failing, the pricier bridge carries the swap at its own fee
+	for i := 0; i < 10; i++ {
+		reliability.Record(ctx, types.BridgeOutcome{Bridge: BridgeUniversal, Success: false})
+	}
+	quote, err = service.GetSwapQuote(ctx, request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if quote.Bridge != "hop" || quote.Fee.BridgeFee.Int64() != 100 {
+		t.Errorf("Expected hop at its fee of 100, got %q at %v", quote.Bridge, quote.Fee.BridgeFee)
+	}
+
+	// The swap reports the fees charged when it executed, not a fresh estimate
+	requestID, err := service.ExecuteSwap(ctx, request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	result, err := service.GetSwapStatus(ctx, requestID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Fee.BridgeFee.Int64() != 100 {
+		t.Errorf("Expected the charged bridge fee of 100, got %v", result.Fee.BridgeFee)
+	}
+}
+
+func TestSwapServiceCompareBridges(t *testing.T) {
+	ctx := context.Background()
+	service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+	service.SetBridgeRouter(NewBridgeRouter(NewBridgeReliability(0)))
+	service.AddBridge(stubBridge{name: "hop", fee: 100})
+	service.AddBridge(stubBridge{name: "stargate", fee: 1, min: 1000})
+
+	eth := types.Token{Symbol: "ETH", ChainID: types.ChainIDEthereum, ChainName: "Ethereum"}
+	polygonETH := types.Token{Symbol: "ETH", ChainID: types.ChainIDPolygon, ChainName: "Polygon"}
+	comparison, err := service.CompareBridges(ctx, eth, polygonETH, big.NewInt(10))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(comparison.Quotes) != 2 || comparison.Quotes[0].Bridge != BridgeUniversal || comparison.Quotes[1].Bridge != "hop" {
+		t.Errorf("Expected Universal then hop, got %+v", comparison.Quotes)
+	}
+	if reason := comparison.Unavailable["stargate"]; reason != "amount below the bridge's minimum of 1000" {
+		t.Errorf("Expected stargate unavailable below its minimum, got %q", reason)
+	}
+
+	if _, err := service.CompareBridges(ctx, eth, eth, big.NewInt(10)); err == nil {
+		t.Error("Expected an error comparing bridges within a chain")
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+const (
+	// minReliabilitySamples is how many outcomes a bridge needs before its history affects routing
+	minReliabilitySamples = 5
+
+	// unreliableSuccessRate is the success rate below which a bridge is only used as a last resort
+	unreliableSuccessRate = 0.5
+)
+
+// BridgeCandidate is a bridge able to carry a transfer, with its quoted cost and time
+type BridgeCandidate struct {
+	Bridge        string
+	Fee           *big.Int
+	EstimatedTime time.Duration
+	MinAmount     *big.Int // The smallest transfer the bridge carries; nil when it has no minimum
+	MaxAmount     *big.Int // The largest transfer the bridge carries; nil when it has no maximum
+}
+
+// BridgeQuoter quotes transfers through a bridge other than Universal, whose fees come with the SDK's estimate
+type BridgeQuoter interface {
+	// Name returns the bridge's name
+	Name() string
+	// QuoteTransfer quotes moving amount of source to destination's chain, or returns nil when the bridge
+	// can't carry the transfer
+	QuoteTransfer(ctx context.Context, source, destination types.Token, amount *big.Int) (*BridgeCandidate, error)
+}
+
+// BridgePreference is what a corridor chooses its bridge for
+type BridgePreference string
+
+const (
+	BridgePreferCheapest BridgePreference = "cheapest" // The lowest reliability-adjusted fee, the default
+	BridgePreferFastest  BridgePreference = "fastest"  // The shortest expected transfer time
+)
+
+// BridgeCorridor is the route of transfers from one chain to another
+type BridgeCorridor struct {
+	SourceChainID      int64
+	DestinationChainID int64
+}
+
+// CorridorPolicy is how a corridor chooses its bridge
+type CorridorPolicy struct {
+	Prefer  BridgePreference
+	Bridges []string // The bridges the corridor may use; empty allows every bridge
+}
+
+// BridgeRouter selects the bridge for cross-chain transfers, penalizing bridges that were recently unreliable
+type BridgeRouter struct {
+	reliability *BridgeReliability
+
+	mu       sync.RWMutex
+	policies map[BridgeCorridor]CorridorPolicy
+
+	// Penalty weights; a bridge's fee is scaled by 1 + the weighted penalties
+	FailureWeight float64 // Per unit of failure rate
+	LatencyWeight float64 // Per unit of median completion time over the estimate
+	FeeWeight     float64 // Per unit of fee inaccuracy
+}
+
+// NewBridgeRouter creates a bridge router scoring bridges with the given reliability history
+func NewBridgeRouter(reliability *BridgeReliability) *BridgeRouter {
+	return &BridgeRouter{
+		reliability:   reliability,
+		policies:      make(map[BridgeCorridor]CorridorPolicy),
+		FailureWeight: 4,
+		LatencyWeight: 1,
+		FeeWeight:     1,
+	}
+}
+
+// Reliability returns the reliability history the router scores bridges with
+func (r *BridgeRouter) Reliability() *BridgeReliability {
+	return r.reliability
+}
+
+// SetCorridorPolicy makes transfers through corridor choose their bridge by policy instead of the lowest fee among
+// every bridge
+func (r *BridgeRouter) SetCorridorPolicy(corridor BridgeCorridor, policy CorridorPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.policies[canonicalCorridor(corridor)] = policy
+}
+
+// RankedBridge is a candidate as the router scored it
+type RankedBridge struct {
+	BridgeCandidate
+	Stats        BridgeStats   // The bridge's reliability history
+	Cost         float64       // The fee scaled by the bridge's reliability penalty
+	ExpectedTime time.Duration // The estimate, or the median completion time of a bridge that has been slower
+	Unreliable   bool          // Below the unreliable success rate, so only used as a last resort
+	Allowed      bool          // The corridor's policy allows the bridge
+}
+
+// Route selects the bridge for a transfer through corridor among the candidates the corridor's policy allows, by
+// the corridor's preference
+func (r *BridgeRouter) Route(ctx context.Context, corridor BridgeCorridor, candidates []BridgeCandidate) (BridgeCandidate, error) {
+	ranked := r.Rank(ctx, corridor, candidates)
+	if len(ranked) == 0 {
+		return BridgeCandidate{}, errors.New("no bridge candidates")
+	}
+	if !ranked[0].Allowed {
+		policy := r.policy(corridor)
+		return BridgeCandidate{}, fmt.Errorf("none of the bridges allowed from chain %d to chain %d (%s) can carry the transfer",
+			corridor.SourceChainID, corridor.DestinationChainID, strings.Join(policy.Bridges, ", "))
+	}
+	return ranked[0].BridgeCandidate, nil
+}
+
+// Rank scores the candidates for a transfer through corridor and orders them the way Route chooses: the ones the
+// corridor's policy allows first, each group best first by the corridor's preference
+func (r *BridgeRouter) Rank(ctx context.Context, corridor BridgeCorridor, candidates []BridgeCandidate) []RankedBridge {
+	return r.rank(ctx, candidates, r.policy(corridor))
+}
+
+// SelectBridge returns the candidate with the lowest reliability-adjusted fee.
+// Bridges below the unreliable success rate are only chosen when every candidate is unreliable.
+// When the reliability history can't be read, candidates are ranked as if no bridge had history.
+func (r *BridgeRouter) SelectBridge(ctx context.Context, candidates []BridgeCandidate) (BridgeCandidate, error) {
+	ranked := r.rank(ctx, candidates, CorridorPolicy{})
+	if len(ranked) == 0 {
+		return BridgeCandidate{}, errors.New("no bridge candidates")
+	}
+	return ranked[0].BridgeCandidate, nil
+}
+
+// policy returns corridor's policy, the zero policy choosing the cheapest of every bridge when it has none
+func (r *BridgeRouter) policy(corridor BridgeCorridor) CorridorPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.policies[canonicalCorridor(corridor)]
+}
+
+// rank scores candidates and orders them by policy: the allowed ones first, then reliable ones before unreliable,
+// then by the lowest reliability-adjusted fee, or by the shortest expected time with the fee breaking ties
+func (r *BridgeRouter) rank(ctx context.Context, candidates []BridgeCandidate, policy CorridorPolicy) []RankedBridge {
+	history := make(map[string]BridgeStats)
+	if len(candidates) > 0 {
+		if all, err := r.reliability.AllStats(ctx); err != nil {
+			log.Printf("Selecting a bridge without reliability history: %v", err)
+		} else {
+			for _, stats := range all {
+				history[stats.Bridge] = stats
+			}
+		}
+	}
+
+	ranked := make([]RankedBridge, 0, len(candidates))
+	for _, candidate := range candidates {
+		stats, ok := history[candidate.Bridge]
+		if !ok {
+			stats = BridgeStats{Bridge: candidate.Bridge, SuccessRate: 1, FeeAccuracy: 1}
+		}
+		penalty, unreliable := r.penalty(stats, candidate.EstimatedTime)
+
+		fee := 0.0
+		if candidate.Fee != nil {
+			fee, _ = new(big.Float).SetInt(candidate.Fee).Float64()
+		}
+		ranked = append(ranked, RankedBridge{
+			BridgeCandidate: candidate,
+			Stats:           stats,
+			Cost:            fee * (1 + penalty),
+			ExpectedTime:    expectedTime(stats, candidate.EstimatedTime),
+			Unreliable:      unreliable,
+			Allowed:         len(policy.Bridges) == 0 || slices.Contains(policy.Bridges, candidate.Bridge),
+		})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Allowed != b.Allowed {
+			return a.Allowed
+		}
+		if a.Unreliable != b.Unreliable {
+			return !a.Unreliable
+		}
+		if policy.Prefer == BridgePreferFastest && a.ExpectedTime != b.ExpectedTime {
+			return a.ExpectedTime < b.ExpectedTime
+		}
+		if a.Cost != b.Cost {
+			return a.Cost < b.Cost
+		}
+		if a.EstimatedTime != b.EstimatedTime {
+			return a.EstimatedTime < b.EstimatedTime
+		}
+		return a.Bridge < b.Bridge
+	})
+	return ranked
+}
+
+// penalty converts a bridge's stats into a fee multiplier penalty and reports whether the bridge is unreliable.
+// Bridges with too little history are not penalized.
+func (r *BridgeRouter) penalty(stats BridgeStats, estimate time.Duration) (float64, bool) {
+	if stats.Samples < minReliabilitySamples {
+		return 0, false
+	}
+
+	penalty := r.FailureWeight * (1 - stats.SuccessRate)
+	penalty += r.FeeWeight * (1 - stats.FeeAccuracy)
+	if estimate > 0 && stats.MedianCompletion > 0 {
+		penalty += r.LatencyWeight * math.Max(0, stats.MedianCompletion/estimate.Seconds()-1)
+	}
+
+	return penalty, stats.SuccessRate < unreliableSuccessRate
+}
+
+// expectedTime is how long a bridge is expected to take: its estimate, or its median completion time when it has
+// enough history and has been slower than that
+func expectedTime(stats BridgeStats, estimate time.Duration) time.Duration {
+	if stats.Samples < minReliabilitySamples {
+		return estimate
+	}
+	return max(estimate, time.Duration(stats.MedianCompletion*float64(time.Second)))
+}
+
+// canonicalCorridor keys a corridor by its chains' canonical IDs
+func canonicalCorridor(corridor BridgeCorridor) BridgeCorridor {
+	return BridgeCorridor{
+		SourceChainID:      types.CanonicalChainID(corridor.SourceChainID),
+		DestinationChainID: types.CanonicalChainID(corridor.DestinationChainID),
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestHTTPBridge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/quote":
+			if query.Get("sourceChainId") != "1" || query.Get("destinationToken") != "SOL" || query.Get("amount") != "1000000" {
+				t.Errorf("Unexpected request %s", r.URL)
+			}
+			if query.Get("sourceToken") == "0xsmall" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Write([]byte(`{"fee": "2500", "estimatedTimeSec": 900, "minAmount": "10000"}`))
+		case "/transfers/0xsource":
+			if query.Get("sourceChainId") != "1" {
+				t.Errorf("Unexpected request %s", r.URL)
+			}
+			w.Write([]byte(`{"status": "completed", "destTxHash": "0xdest"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	bridge := NewHTTPBridge(server.Client(), "wormhole", server.URL+"/", []int64{types.ChainIDEthereum, types.ChainIDSolana})
+	usdc := types.Token{Symbol: "USDC", ChainID: types.ChainIDEthereum, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}
+	sol := types.Token{Symbol: "SOL", ChainID: types.ChainIDSolana}
+
+	candidate, err := bridge.QuoteTransfer(context.Background(), usdc, sol, big.NewInt(1000000))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if candidate == nil || candidate.Bridge != "wormhole" || candidate.Fee.Int64() != 2500 || candidate.EstimatedTime != 15*time.Minute {
+		t.Errorf("Unexpected candidate %+v", candidate)
+	}
+	if candidate != nil && (candidate.MinAmount.Int64() != 10000 || candidate.MaxAmount != nil) {
+		t.Errorf("Expected a minimum and no maximum, got %v and %v", candidate.MinAmount, candidate.MaxAmount)
+	}
+
+	// Transfers the service can't carry, or to chains the bridge doesn't connect, aren't quoted
+	small := types.Token{Symbol: "SMALL", ChainID: types.ChainIDEthereum, Address: "0xsmall"}
+	if candidate, err := bridge.QuoteTransfer(context.Background(), small, sol, big.NewInt(1000000)); err != nil || candidate != nil {
+		t.Errorf("Expected no quote, got %+v, %v", candidate, err)
+	}
+	polygonUSDC := types.Token{Symbol: "USDC", ChainID: types.ChainIDPolygon, Address: "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"}
+	if candidate, err := bridge.QuoteTransfer(context.Background(), usdc, polygonUSDC, big.NewInt(1000000)); err != nil || candidate != nil {
+		t.Errorf("Expected no quote to an unconnected chain, got %+v, %v", candidate, err)
+	}
+
+	status, err := bridge.TransferStatus(context.Background(), types.ChainIDEthereum, "0xsource")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.Status != BridgeTransferCompleted || status.DestTxHash != "0xdest" {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if _, err := bridge.TransferStatus(context.Background(), types.ChainIDEthereum, "0xmissing"); err == nil {
+		t.Error("Expected an error for an unknown transfer")
+	}
+}
@@ -3,25 +3,58 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 	"math/big"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
 )
 
-// ChainService provides functionality for blockchain-related operations
+// ErrChainNotFound is returned for chains the chain service does not track
+var ErrChainNotFound = errors.New("chain not found")
+
+// ErrGasPriceUnavailable is returned when a chain's gas price has not been read yet
+var ErrGasPriceUnavailable = errors.New("gas price not available yet")
+
+const (
+	// feeHistoryBlocks is how many recent blocks the priority fee is taken from
+	feeHistoryBlocks = 20
+	// blockTimeBlocks is how many recent blocks the block time is averaged over
+	blockTimeBlocks = 100
+)
+
+// GasReader reads gas prices and block times from a chain's RPC endpoints
+type GasReader interface {
+	GasPrice(ctx context.Context, chainID int64) (*big.Int, error)
+	// FeeHistory returns the next block's base fee and the median priority fee of recent blocks
+	FeeHistory(ctx context.Context, chainID int64, blocks int) (baseFee, priorityFee *big.Int, err error)
+	BlockTime(ctx context.Context, chainID int64, blocks int) (time.Duration, error)
+	EstimateGas(ctx context.Context, chainID int64, from, to string, data []byte) (*big.Int, error)
+}
+
+// ChainService tracks the status of blockchains, including their live gas prices and block times
 type ChainService struct {
-	chains map[int64]ChainStatus // map[chainID]ChainStatus
+	chains map[int64]types.ChainStatus // map[chainID]ChainStatus
+	gas    GasReader                   // optional, reads gas prices for RefreshGas
 	mu     sync.RWMutex
 }
 
 // NewChainService creates a new chain service instance
 func NewChainService() *ChainService {
 	return &ChainService{
-		chains: make(map[int64]ChainStatus),
+		chains: make(map[int64]types.ChainStatus),
 	}
 }
 
+// SetGasReader sets where RefreshGas and EstimateGas read from
+func (s *ChainService) SetGasReader(gas GasReader) {
+	s.gas = gas
+}
+
 // AddChain adds a new blockchain to the service
-func (s *ChainService) AddChain(chain ChainStatus) error {
+func (s *ChainService) AddChain(chain types.ChainStatus) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -34,38 +67,38 @@ func (s *ChainService) AddChain(chain ChainStatus) error {
 }
 
 // GetChain retrieves a blockchain by its ID
-func (s *ChainService) GetChain(chainID int64) (ChainStatus, error) {
+func (s *ChainService) GetChain(chainID int64) (types.ChainStatus, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	chain, exists := s.chains[chainID]
+	chain, exists := s.chains[types.CanonicalChainID(chainID)]
 	if !exists {
-		return ChainStatus{}, errors.New("chain not found")
+		return types.ChainStatus{}, ErrChainNotFound
 	}
 
 	return chain, nil
 }
 
-// GetAllChains retrieves all blockchains
-func (s *ChainService) GetAllChains() []ChainStatus {
+// GetAllChains retrieves all blockchains, ordered by chain ID
+func (s *ChainService) GetAllChains() []types.ChainStatus {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	result := make([]ChainStatus, 0, len(s.chains))
+	result := make([]types.ChainStatus, 0, len(s.chains))
 	for _, chain := range s.chains {
 		result = append(result, chain)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChainID < result[j].ChainID
+	})
 
 	return result
 }
 
 // GetActiveChains retrieves all active blockchains
-func (s *ChainService) GetActiveChains() []ChainStatus {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	var result []ChainStatus
-	for _, chain := range s.chains {
+func (s *ChainService) GetActiveChains() []types.ChainStatus {
+	var result []types.ChainStatus
+	for _, chain := range s.GetAllChains() {
 		if chain.IsActive {
 			result = append(result, chain)
 		}
@@ -74,40 +107,81 @@ func (s *ChainService) GetActiveChains() []ChainStatus {
 	return result
 }
 
-// UpdateChainStatus updates the status of a blockchain
-func (s *ChainService) UpdateChainStatus(chainID int64, isActive bool) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	chain, exists := s.chains[chainID]
-	if !exists {
-		return errors.New("chain not found")
+// GetChainStatus returns the status of a blockchain
+func (s *ChainService) GetChainStatus(ctx context.Context, chainID int64) (*types.ChainStatus, error) {
+	chain, err := s.GetChain(chainID)
+	if err != nil {
+		return nil, err
 	}
+	return &chain, nil
+}
 
-	chain.IsActive = isActive
-	s.chains[chainID] = chain
+// GetAllChainStatuses returns the status of every blockchain
+func (s *ChainService) GetAllChainStatuses(ctx context.Context) []types.ChainStatus {
+	return s.GetAllChains()
+}
 
-	return nil
+// UpdateChainStatus updates whether a blockchain is active and, when gasPrice is not nil, its gas price
+func (s *ChainService) UpdateChainStatus(ctx context.Context, chainID int64, isActive bool, gasPrice *big.Int) error {
+	return s.update(chainID, func(chain *types.ChainStatus) {
+		chain.IsActive = isActive
+		if gasPrice != nil {
+			chain.GasPrice = gasPrice
+			chain.GasUpdatedAt = time.Now()
+		}
+	})
 }
 
 // UpdateGasPrice updates the gas price for a blockchain
 func (s *ChainService) UpdateGasPrice(chainID int64, gasPrice *big.Int) error {
+	return s.update(chainID, func(chain *types.ChainStatus) {
+		chain.GasPrice = gasPrice
+		chain.GasUpdatedAt = time.Now()
+	})
+}
+
+// update applies fn to a tracked blockchain
+func (s *ChainService) update(chainID int64, fn func(chain *types.ChainStatus)) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	chainID = types.CanonicalChainID(chainID)
 	chain, exists := s.chains[chainID]
 	if !exists {
-		return errors.New("chain not found")
+		return ErrChainNotFound
 	}
 
-	chain.GasPrice = gasPrice
+	fn(&chain)
 	s.chains[chainID] = chain
 
 	return nil
 }
 
+// GetGasPrice returns the last gas price read for a blockchain
+func (s *ChainService) GetGasPrice(ctx context.Context, chainID int64) (*big.Int, error) {
+	chain, err := s.GetChain(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if chain.GasPrice == nil {
+		return nil, ErrGasPriceUnavailable
+	}
+	return new(big.Int).Set(chain.GasPrice), nil
+}
+
+// EstimateGas estimates the gas a call would use on a blockchain
+func (s *ChainService) EstimateGas(ctx context.Context, chainID int64, fromAddress, toAddress string, data []byte) (*big.Int, error) {
+	if _, err := s.GetChain(chainID); err != nil {
+		return nil, err
+	}
+	if s.gas == nil {
+		return nil, errors.New("no gas reader configured")
+	}
+	return s.gas.EstimateGas(ctx, chainID, fromAddress, toAddress, data)
+}
+
 // GetChainByName retrieves a blockchain by its name
-func (s *ChainService) GetChainByName(name string) (ChainStatus, error) {
+func (s *ChainService) GetChainByName(name string) (types.ChainStatus, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -117,19 +191,77 @@ func (s *ChainService) GetChainByName(name string) (ChainStatus, error) {
 		}
 	}
 
-	return ChainStatus{}, errors.New("chain not found")
+	return types.ChainStatus{}, ErrChainNotFound
 }
 
 // EstimateTransactionTime estimates the time for a transaction to be confirmed
 func (s *ChainService) EstimateTransactionTime(ctx context.Context, chainID int64) (int, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	chain, exists := s.chains[chainID]
-	if !exists {
-		return 0, errors.New("chain not found")
+	chain, err := s.GetChain(chainID)
+	if err != nil {
+		return 0, err
 	}
 
 	// Return the block time as an estimate (in seconds)
 	return chain.BlockTime, nil
 }
+
+// RefreshGas reads the gas price, fees and block time of every EVM chain. Chains whose RPC endpoints fail keep
+// their last readings and are marked inactive until a read succeeds again; the returned error joins the failures.
+func (s *ChainService) RefreshGas(ctx context.Context) error {
+	if s.gas == nil {
+		return errors.New("no gas reader configured")
+	}
+
+	var errs []error
+	for _, chain := range s.GetAllChains() {
+		if c, ok := types.GetChain(chain.ChainID); ok && c.Namespace != "eip155" {
+			continue
+		}
+
+		gasPrice, err := s.gas.GasPrice(ctx, chain.ChainID)
+		if err != nil {
+			errs = append(errs, err)
+			s.update(chain.ChainID, func(chain *types.ChainStatus) {
+				chain.IsActive = false
+			})
+			continue
+		}
+		// Fee history and block time are best effort; chains without EIP-1559 have no fee history
+		baseFee, priorityFee, feeErr := s.gas.FeeHistory(ctx, chain.ChainID, feeHistoryBlocks)
+		blockTime, blockErr := s.gas.BlockTime(ctx, chain.ChainID, blockTimeBlocks)
+
+		s.update(chain.ChainID, func(chain *types.ChainStatus) {
+			chain.IsActive = true
+			chain.GasPrice = gasPrice
+			chain.GasUpdatedAt = time.Now()
+			if feeErr == nil {
+				chain.BaseFee = baseFee
+				chain.PriorityFee = priorityFee
+			}
+			if blockErr == nil {
+				chain.BlockTime = int((blockTime + time.Second/2) / time.Second)
+				chain.BlockTimeMs = blockTime.Milliseconds()
+			}
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// StartGasPolling refreshes gas prices every interval until ctx is done
+func (s *ChainService) StartGasPolling(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.RefreshGas(ctx); err != nil {
+				log.Printf("Failed to refresh gas prices: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
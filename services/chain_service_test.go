@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// fakeGasReader returns fixed gas readings, failing for chains in failing
+type fakeGasReader struct {
+	failing map[int64]bool
+}
+
+func (f *fakeGasReader) GasPrice(ctx context.Context, chainID int64) (*big.Int, error) {
+	if f.failing[chainID] {
+		return nil, errors.New("rpc unavailable")
+	}
+	return big.NewInt(30_000_000_000), nil
+}
+
+func (f *fakeGasReader) FeeHistory(ctx context.Context, chainID int64, blocks int) (*big.Int, *big.Int, error) {
+	return big.NewInt(20_000_000_000), big.NewInt(1_000_000_000), nil
+}
+
+func (f *fakeGasReader) BlockTime(ctx context.Context, chainID int64, blocks int) (time.Duration, error) {
+	return 2100 * time.Millisecond, nil
+}
+
+func (f *fakeGasReader) EstimateGas(ctx context.Context, chainID int64, from, to string, data []byte) (*big.Int, error) {
+	return big.NewInt(21000), nil
+}
+
+func TestChainServiceGas(t *testing.T) {
+	ctx := context.Background()
+	service := NewChainService()
+	service.SetGasReader(&fakeGasReader{failing: map[int64]bool{types.ChainIDPolygon: true}})
+	for _, chain := range []types.ChainStatus{
+		{Name: "ethereum", ChainID: types.ChainIDEthereum, IsActive: true},
+		{Name: "polygon", ChainID: types.ChainIDPolygon, IsActive: true},
+		{Name: "solana", ChainID: types.ChainIDSolana, IsActive: true},
+	} {
+		if err := service.AddChain(chain); err != nil {
+			t.Fatalf("Failed to add chain: %v", err)
+		}
+	}
+
+	t.Run("BeforeRefresh", func(t *testing.T) {
+		if _, err := service.GetGasPrice(ctx, types.ChainIDEthereum); !errors.Is(err, ErrGasPriceUnavailable) {
+			t.Errorf("Expected ErrGasPriceUnavailable, got %v", err)
+		}
+		if _, err := service.GetGasPrice(ctx, 424242); !errors.Is(err, ErrChainNotFound) {
+			t.Errorf("Expected ErrChainNotFound, got %v", err)
+		}
+	})
+
+	t.Run("RefreshGas", func(t *testing.T) {
+		if err := service.RefreshGas(ctx); err == nil {
+			t.Error("Expected the polygon failure to be reported")
+		}
+
+		status, err := service.GetChainStatus(ctx, types.ChainIDEthereum)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if status.GasPrice.Int64() != 30_000_000_000 || status.BaseFee.Int64() != 20_000_000_000 || status.PriorityFee.Int64() != 1_000_000_000 {
+			t.Errorf("Unexpected gas readings: %+v", status)
+		}
+		if status.BlockTime != 2 || status.BlockTimeMs != 2100 {
+			t.Errorf("Expected a 2.1s block time, got %ds / %dms", status.BlockTime, status.BlockTimeMs)
+		}
+
+		if _, err := service.GetGasPrice(ctx, types.ChainIDPolygon); !errors.Is(err, ErrGasPriceUnavailable) {
+			t.Errorf("Expected polygon to have no gas price, got %v", err)
+		}
+		if polygon, _ := service.GetChain(types.ChainIDPolygon); polygon.IsActive {
+			t.Error("Expected polygon to be marked inactive while its RPC fails")
+		}
+		if !status.IsActive {
+			t.Error("Expected ethereum to stay active")
+		}
+		if _, err := service.GetGasPrice(ctx, types.ChainIDSolana); !errors.Is(err, ErrGasPriceUnavailable) {
+			t.Errorf("Expected solana to be skipped, got %v", err)
+		}
+	})
+
+	t.Run("UpdateChainStatus", func(t *testing.T) {
+		if err := service.UpdateChainStatus(ctx, types.ChainIDPolygon, false, big.NewInt(50)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if active := service.GetActiveChains(); len(active) != 2 {
+			t.Errorf("Expected 2 active chains, got %d", len(active))
+		}
+		gasPrice, err := service.GetGasPrice(ctx, types.ChainIDPolygon)
+		if err != nil || gasPrice.Int64() != 50 {
+			t.Errorf("Expected a gas price of 50, got %v (%v)", gasPrice, err)
+		}
+	})
+
+	t.Run("EstimateGas", func(t *testing.T) {
+		gas, err := service.EstimateGas(ctx, types.ChainIDEthereum, "", "0xto", nil)
+		if err != nil || gas.Int64() != 21000 {
+			t.Errorf("Expected 21000 gas, got %v (%v)", gas, err)
+		}
+	})
+}
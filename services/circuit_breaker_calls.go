@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/universalsdk"
+)
+
+// NewBreakerSDK wraps a Universal SDK so the outcomes of its calls trip breakers: the global breaker and the
+// breakers of the chains each call involves
+func NewBreakerSDK(sdk universalsdk.SDK, breakers *CircuitBreakers) universalsdk.SDK {
+	return universalsdk.Chain(sdk, BreakerMiddleware(breakers))
+}
+
+// BreakerMiddleware counts the outcome of every SDK call against the global breaker and the breakers of the chains
+// the call involves; GetAllWrappedTokens, GetTransactionStatus and WatchTransaction name no chain, so only the global
+// breaker counts them
+func BreakerMiddleware(breakers *CircuitBreakers) universalsdk.Middleware {
+	return func(next universalsdk.Invoker) universalsdk.Invoker {
+		return func(ctx context.Context, call universalsdk.Call) (interface{}, error) {
+			result, err := next(ctx, call)
+			breakers.RecordSDKCall(err, call.ChainIDs...)
+			return result, err
+		}
+	}
+}
+
+// breakerGasReader is a GasReader counting the outcome of every RPC call against the called chain's breaker
+type breakerGasReader struct {
+	gas      GasReader
+	breakers *CircuitBreakers
+}
+
+// NewBreakerGasReader wraps a GasReader so failing RPC calls trip the called chain's breaker
+func NewBreakerGasReader(gas GasReader, breakers *CircuitBreakers) GasReader {
+	return &breakerGasReader{gas: gas, breakers: breakers}
+}
+
+// GasPrice reads a chain's gas price
+func (r *breakerGasReader) GasPrice(ctx context.Context, chainID int64) (*big.Int, error) {
+	price, err := r.gas.GasPrice(ctx, chainID)
+	r.breakers.RecordChainCall(chainID, err)
+	return price, err
+}
+
+// FeeHistory reads a chain's base fee and median priority fee. Chains without EIP-1559 fail it normally, so only
+// its successes are counted.
+func (r *breakerGasReader) FeeHistory(ctx context.Context, chainID int64, blocks int) (*big.Int, *big.Int, error) {
+	baseFee, priorityFee, err := r.gas.FeeHistory(ctx, chainID, blocks)
+	if err == nil {
+		r.breakers.RecordChainCall(chainID, nil)
+	}
+	return baseFee, priorityFee, err
+}
+
+// BlockTime reads a chain's average block time
+func (r *breakerGasReader) BlockTime(ctx context.Context, chainID int64, blocks int) (time.Duration, error) {
+	blockTime, err := r.gas.BlockTime(ctx, chainID, blocks)
+	r.breakers.RecordChainCall(chainID, err)
+	return blockTime, err
+}
+
+// EstimateGas estimates the gas of a transaction. Transactions that would revert fail it normally, so only its
+// successes are counted.
+func (r *breakerGasReader) EstimateGas(ctx context.Context, chainID int64, from, to string, data []byte) (*big.Int, error) {
+	gas, err := r.gas.EstimateGas(ctx, chainID, from, to, data)
+	if err == nil {
+		r.breakers.RecordChainCall(chainID, nil)
+	}
+	return gas, err
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+)
+
+// ErrChainUnavailable is returned for swaps on a chain whose circuit breaker, or the global one, is open
+var ErrChainUnavailable = errors.New("chain unavailable")
+
+// ErrBreakerNotFound is returned when resetting a circuit breaker that doesn't exist
+var ErrBreakerNotFound = errors.New("circuit breaker not found")
+
+// GlobalBreakerName names the breaker tripped by failing SDK calls on any chain; while it is open every chain is
+// unavailable
+const GlobalBreakerName = "global"
+
+// breakerBuckets is how many slices a breaker's window is counted in; older slices drop out as the window slides
+const breakerBuckets = 10
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // Calls go through and are counted
+	BreakerOpen     = "open"      // Swaps are rejected until the cooldown ends
+	BreakerHalfOpen = "half_open" // Calls go through again; the next outcome closes or reopens the breaker
+)
+
+// BreakerSettings configure when a circuit breaker trips and how long it stays open
+type BreakerSettings struct {
+	Window      time.Duration // Calls are counted over this long
+	MinCalls    int           // Calls in the window before the breaker can trip
+	FailureRate float64       // Share of the calls in the window, 0-1, that trips the breaker when they fail
+	Cooldown    time.Duration // How long a tripped breaker stays open
+}
+
+// BreakerStatus describes a circuit breaker for operators
+type BreakerStatus struct {
+	Name        string     `json:"name"`
+	ChainID     int64      `json:"chainId,omitempty"` // Absent for the global breaker
+	State       string     `json:"state"`
+	Calls       int        `json:"calls"`    // In the current window
+	Failures    int        `json:"failures"` // In the current window
+	FailureRate float64    `json:"failureRate"`
+	Trips       int        `json:"trips"` // Times the breaker opened since it was created
+	OpenedAt    *time.Time `json:"openedAt,omitempty"`
+	RetryAt     *time.Time `json:"retryAt,omitempty"` // When an open breaker lets calls through again
+}
+
+// breakerBucket counts the calls in one slice of a breaker's window
+type breakerBucket struct {
+	start    time.Time
+	calls    int
+	failures int
+}
+
+// circuitBreaker trips when too many of the calls in its sliding window fail. The caller holds the breakers' lock.
+type circuitBreaker struct {
+	settings BreakerSettings
+	state    string
+	buckets  [breakerBuckets]breakerBucket
+	openedAt time.Time
+	trips    int
+}
+
+// bucket returns the bucket counting calls made at now, clearing it when it last counted an older slice
+func (b *circuitBreaker) bucket(now time.Time) *breakerBucket {
+	width := b.settings.Window / breakerBuckets
+	if width <= 0 {
+		width = 1
+	}
+	start := now.Truncate(width)
+	bucket := &b.buckets[int(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	return bucket
+}
+
+// counts returns the calls and failures within the window ending at now
+func (b *circuitBreaker) counts(now time.Time) (calls, failures int) {
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < b.settings.Window {
+			calls += bucket.calls
+			failures += bucket.failures
+		}
+	}
+	return calls, failures
+}
+
+// currentState returns the breaker's state at now, moving an open breaker whose cooldown ended to half open
+func (b *circuitBreaker) currentState(now time.Time) string {
+	if b.state == BreakerOpen && !now.Before(b.openedAt.Add(b.settings.Cooldown)) {
+		b.state = BreakerHalfOpen
+	}
+	return b.state
+}
+
+// record counts a call's outcome, tripping or closing the breaker
+func (b *circuitBreaker) record(now time.Time, failed bool) {
+	switch b.currentState(now) {
+	case BreakerOpen:
+		// Calls already in flight when the breaker tripped don't extend its cooldown
+		return
+	case BreakerHalfOpen:
+		if failed {
+			b.open(now)
+		} else {
+			b.reset()
+		}
+		return
+	}
+
+	bucket := b.bucket(now)
+	bucket.calls++
+	if failed {
+		bucket.failures++
+	}
+	calls, failures := b.counts(now)
+	if calls >= b.settings.MinCalls && float64(failures) >= b.settings.FailureRate*float64(calls) && failures > 0 {
+		b.open(now)
+	}
+}
+
+// open trips the breaker
+func (b *circuitBreaker) open(now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.trips++
+}
+
+// reset closes the breaker and forgets the calls it counted
+func (b *circuitBreaker) reset() {
+	b.state = BreakerClosed
+	b.buckets = [breakerBuckets]breakerBucket{}
+	b.openedAt = time.Time{}
+}
+
+// CircuitBreakers tracks the failure rate of SDK calls and chain RPCs per chain, with a global breaker over every
+// SDK call. A chain whose breaker is open, or every chain while the global breaker is open, is unavailable until
+// the breaker's cooldown ends; after it the next call closes the breaker again or, failing, reopens it.
+type CircuitBreakers struct {
+	chainSettings BreakerSettings
+	global        *circuitBreaker
+	chains        map[int64]*circuitBreaker // map[chainID]breaker, created on a chain's first call
+	now           func() time.Time
+	mu            sync.Mutex
+}
+
+// NewCircuitBreakers creates the breakers of every chain, each tripped as chain says, and the global breaker
+func NewCircuitBreakers(chain, global BreakerSettings) *CircuitBreakers {
+	return &CircuitBreakers{
+		chainSettings: chain,
+		global:        &circuitBreaker{settings: global, state: BreakerClosed},
+		chains:        make(map[int64]*circuitBreaker),
+		now:           time.Now,
+	}
+}
+
+// chain returns a chain's breaker, creating it if needed; the caller holds the lock
+func (c *CircuitBreakers) chain(chainID int64) *circuitBreaker {
+	chainID = types.CanonicalChainID(chainID)
+	breaker, ok := c.chains[chainID]
+	if !ok {
+		breaker = &circuitBreaker{settings: c.chainSettings, state: BreakerClosed}
+		c.chains[chainID] = breaker
+	}
+	return breaker
+}
+
+// RecordSDKCall counts the outcome of an SDK call on the given chains against their breakers and the global one.
+// Calls the caller gave up on are not counted. Failures that aren't universalsdk.Retryable, such as amounts too small
+// to cover fees, are the request's fault rather than Universal's, so they count as successes.
+func (c *CircuitBreakers) RecordSDKCall(err error, chainIDs ...int64) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	failed := universalsdk.Retryable(err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.global.record(now, failed)
+	c.recordChains(now, failed, chainIDs)
+}
+
+// RecordChainCall counts the outcome of an RPC call to a chain against its breaker
+func (c *CircuitBreakers) RecordChainCall(chainID int64, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.recordChains(c.now(), err != nil, []int64{chainID})
+}
+
+// recordChains counts an outcome once against each distinct chain; the caller holds the lock
+func (c *CircuitBreakers) recordChains(now time.Time, failed bool, chainIDs []int64) {
+	seen := make(map[int64]bool, len(chainIDs))
+	for _, chainID := range chainIDs {
+		chainID = types.CanonicalChainID(chainID)
+		if chainID == 0 || seen[chainID] {
+			continue
+		}
+		seen[chainID] = true
+		c.chain(chainID).record(now, failed)
+	}
+}
+
+// CheckChain returns ErrChainUnavailable when a chain's breaker or the global breaker is open
+func (c *CircuitBreakers) CheckChain(chainID int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.global.currentState(now) == BreakerOpen {
+		return fmt.Errorf("%w: Universal SDK calls are failing", ErrChainUnavailable)
+	}
+	if breaker, ok := c.chains[types.CanonicalChainID(chainID)]; ok && breaker.currentState(now) == BreakerOpen {
+		return fmt.Errorf("%w: calls to chain %d are failing", ErrChainUnavailable, chainID)
+	}
+	return nil
+}
+
+// ChainState returns the state of a chain's breaker, open when the global breaker is
+func (c *CircuitBreakers) ChainState(chainID int64) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.global.currentState(now) == BreakerOpen {
+		return BreakerOpen
+	}
+	if breaker, ok := c.chains[types.CanonicalChainID(chainID)]; ok {
+		return breaker.currentState(now)
+	}
+	return BreakerClosed
+}
+
+// Statuses describes the global breaker and then each chain's, by chain ID
+func (c *CircuitBreakers) Statuses() []BreakerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	statuses := []BreakerStatus{breakerStatus(GlobalBreakerName, 0, c.global, now)}
+	chainIDs := make([]int64, 0, len(c.chains))
+	for chainID := range c.chains {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Slice(chainIDs, func(i, j int) bool { return chainIDs[i] < chainIDs[j] })
+	for _, chainID := range chainIDs {
+		statuses = append(statuses, breakerStatus(ChainBreakerName(chainID), chainID, c.chains[chainID], now))
+	}
+	return statuses
+}
+
+// Reset closes a breaker, named GlobalBreakerName or by ChainBreakerName, and forgets the calls it counted
+func (c *CircuitBreakers) Reset(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if name == GlobalBreakerName {
+		c.global.reset()
+		return nil
+	}
+	raw, ok := strings.CutPrefix(name, "chain:")
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBreakerNotFound, name)
+	}
+	chainID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBreakerNotFound, name)
+	}
+	breaker, ok := c.chains[types.CanonicalChainID(chainID)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBreakerNotFound, name)
+	}
+	breaker.reset()
+	return nil
+}
+
+// ChainBreakerName names a chain's breaker, e.g. chain:1
+func ChainBreakerName(chainID int64) string {
+	return "chain:" + strconv.FormatInt(chainID, 10)
+}
+
+// breakerStatus describes a breaker at now
+func breakerStatus(name string, chainID int64, breaker *circuitBreaker, now time.Time) BreakerStatus {
+	status := BreakerStatus{
+		Name:    name,
+		ChainID: chainID,
+		State:   breaker.currentState(now),
+		Trips:   breaker.trips,
+	}
+	status.Calls, status.Failures = breaker.counts(now)
+	if status.Calls > 0 {
+		status.FailureRate = float64(status.Failures) / float64(status.Calls)
+	}
+	if !breaker.openedAt.IsZero() {
+		openedAt := breaker.openedAt
+		status.OpenedAt = &openedAt
+		if status.State == BreakerOpen {
+			retryAt := openedAt.Add(breaker.settings.Cooldown)
+			status.RetryAt = &retryAt
+		}
+	}
+	return status
+}
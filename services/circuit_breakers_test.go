@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/universalsdk"
+)
+
+// newTestBreakers creates breakers tripping when half of at least 4 calls in a minute fail, on a controlled clock
+func newTestBreakers() (*CircuitBreakers, *time.Time) {
+	settings := BreakerSettings{Window: time.Minute, MinCalls: 4, FailureRate: 0.5, Cooldown: 30 * time.Second}
+	breakers := NewCircuitBreakers(settings, settings)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breakers.now = func() time.Time { return now }
+	return breakers, &now
+}
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	breakers, now := newTestBreakers()
+	rpcDown := errors.New("rpc down")
+
+	// Too few calls to judge, however many fail
+	for i := 0; i < 3; i++ {
+		breakers.RecordChainCall(1, rpcDown)
+	}
+	if err := breakers.CheckChain(1); err != nil {
+		t.Fatalf("Breaker tripped before MinCalls: %v", err)
+	}
+
+	// Failures that slid out of the window no longer count
+	*now = now.Add(time.Minute)
+	breakers.RecordChainCall(1, nil)
+	breakers.RecordChainCall(1, nil)
+	breakers.RecordChainCall(1, nil)
+	breakers.RecordChainCall(1, rpcDown)
+	if err := breakers.CheckChain(1); err != nil {
+		t.Fatalf("Breaker tripped at a 25%% failure rate: %v", err)
+	}
+
+	breakers.RecordChainCall(1, rpcDown)
+	breakers.RecordChainCall(1, rpcDown)
+	if err := breakers.CheckChain(1); !errors.Is(err, ErrChainUnavailable) {
+		t.Fatalf("CheckChain = %v, want ErrChainUnavailable at a 50%% failure rate", err)
+	}
+	if err := breakers.CheckChain(137); err != nil {
+		t.Errorf("Another chain's breaker tripped: %v", err)
+	}
+	if state := breakers.ChainState(1); state != BreakerOpen {
+		t.Errorf("State = %s, want open", state)
+	}
+}
+
+func TestCircuitBreakerRecovers(t *testing.T) {
+	breakers, now := newTestBreakers()
+	for i := 0; i < 4; i++ {
+		breakers.RecordChainCall(1, errors.New("rpc down"))
+	}
+
+	*now = now.Add(30 * time.Second)
+	if state := breakers.ChainState(1); state != BreakerHalfOpen {
+		t.Fatalf("State after the cooldown = %s, want half open", state)
+	}
+	if err := breakers.CheckChain(1); err != nil {
+		t.Fatalf("Half open breaker rejected a swap: %v", err)
+	}
+
+	// A failure while half open reopens the breaker for another cooldown
+	breakers.RecordChainCall(1, errors.New("still down"))
+	if state := breakers.ChainState(1); state != BreakerOpen {
+		t.Fatalf("State after a half open failure = %s, want open", state)
+	}
+	*now = now.Add(30 * time.Second)
+	breakers.RecordChainCall(1, nil)
+	if state := breakers.ChainState(1); state != BreakerClosed {
+		t.Fatalf("State after a half open success = %s, want closed", state)
+	}
+
+	statuses := breakers.Statuses()
+	if len(statuses) != 2 || statuses[1].Name != "chain:1" || statuses[1].Trips != 2 || statuses[1].Calls != 0 {
+		t.Errorf("Statuses = %+v, want chain:1 closed with its window cleared after 2 trips", statuses)
+	}
+}
+
+func TestGlobalCircuitBreaker(t *testing.T) {
+	breakers, _ := newTestBreakers()
+	sdkDown := errors.New("sdk down")
+
+	// Calls the caller gave up on say nothing about the SDK
+	for i := 0; i < 4; i++ {
+		breakers.RecordSDKCall(context.Canceled, 1)
+	}
+	if err := breakers.CheckChain(1); err != nil {
+		t.Fatalf("Canceled calls tripped a breaker: %v", err)
+	}
+
+	// Nor do requests the SDK refused as they were
+	for i := 0; i < 4; i++ {
+		breakers.RecordSDKCall(universalsdk.ErrInsufficientAmount, 1)
+	}
+	if err := breakers.CheckChain(1); err != nil {
+		t.Fatalf("Refused requests tripped a breaker: %v", err)
+	}
+
+	// Failures spread over chains trip only the global breaker, which makes every chain unavailable
+	for _, chainID := range []int64{1, 10, 137, 8453} {
+		breakers.RecordSDKCall(sdkDown, chainID)
+	}
+	if err := breakers.CheckChain(42161); !errors.Is(err, ErrChainUnavailable) {
+		t.Fatalf("CheckChain = %v, want ErrChainUnavailable while the global breaker is open", err)
+	}
+	if state := breakers.ChainState(10); state != BreakerOpen {
+		t.Errorf("Chain state = %s, want open while the global breaker is open", state)
+	}
+
+	if err := breakers.Reset("chain:42161"); !errors.Is(err, ErrBreakerNotFound) {
+		t.Errorf("Reset of a chain without calls = %v, want ErrBreakerNotFound", err)
+	}
+	if err := breakers.Reset(GlobalBreakerName); err != nil {
+		t.Fatal(err)
+	}
+	if err := breakers.CheckChain(42161); err != nil {
+		t.Errorf("CheckChain after resetting the global breaker = %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// Policy rules
+const (
+	PolicyRuleAmount        = "amount"
+	PolicyRuleGeo           = "geo"
+	PolicyRuleTokenCategory = "token_category"
+)
+
+// PolicyInput is a swap and the context it was requested in
+type PolicyInput struct {
+	Compliance types.ComplianceContext
+	Request    types.SwapRequest
+}
+
+// PolicyEngine evaluates KYC/AML policy before a swap starts
+type PolicyEngine interface {
+	Evaluate(ctx context.Context, input PolicyInput) (types.PolicyDecision, error)
+}
+
+// PolicyStore stores per-tenant policy documents
+type PolicyStore interface {
+	// GetPolicy returns the tenant's policy, or types.ErrPolicyNotFound
+	GetPolicy(ctx context.Context, tenantID string) (*types.PolicyDocument, error)
+	SavePolicy(ctx context.Context, doc types.PolicyDocument) error
+}
+
+// PriceLookup returns token prices used to value swaps
+type PriceLookup interface {
+	PriceUSD(ctx context.Context, symbol string, chainID int64) (float64, error)
+}
+
+// InMemoryPolicyStore is a PolicyStore for running without a database
+type InMemoryPolicyStore struct {
+	policies map[string]types.PolicyDocument // map[tenantID]PolicyDocument
+	mu       sync.RWMutex
+}
+
+// NewInMemoryPolicyStore creates an empty in-memory policy store
+func NewInMemoryPolicyStore() *InMemoryPolicyStore {
+	return &InMemoryPolicyStore{
+		policies: make(map[string]types.PolicyDocument),
+	}
+}
+
+// GetPolicy returns the tenant's policy
+func (s *InMemoryPolicyStore) GetPolicy(ctx context.Context, tenantID string) (*types.PolicyDocument, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, ok := s.policies[tenantID]
+	if !ok {
+		return nil, types.ErrPolicyNotFound
+	}
+	return &doc, nil
+}
+
+// SavePolicy stores the tenant's policy, replacing any previous version
+func (s *InMemoryPolicyStore) SavePolicy(ctx context.Context, doc types.PolicyDocument) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policies[doc.TenantID] = doc
+	return nil
+}
+
+// ValidatePolicyDocument checks a policy document is well formed
+func ValidatePolicyDocument(doc types.PolicyDocument) error {
+	if doc.TenantID == "" {
+		return errors.New("tenantId is required")
+	}
+	if doc.ReviewAboveUSD < 0 || doc.DenyAboveUSD < 0 {
+		return errors.New("amount thresholds must not be negative")
+	}
+	if doc.ReviewAboveUSD > 0 && doc.DenyAboveUSD > 0 && doc.DenyAboveUSD < doc.ReviewAboveUSD {
+		return errors.New("denyAboveUsd must not be below reviewAboveUsd")
+	}
+	for category, outcome := range doc.CategoryRules {
+		if outcome != types.PolicyReview && outcome != types.PolicyDeny {
+			return fmt.Errorf("category %q rule must be %s or %s", category, types.PolicyReview, types.PolicyDeny)
+		}
+	}
+	return nil
+}
+
+// DocumentPolicyEngine evaluates the policy document stored for each tenant
+type DocumentPolicyEngine struct {
+	store  PolicyStore
+	prices PriceLookup // optional, needed for amount thresholds
+	now    func() time.Time
+}
+
+// NewDocumentPolicyEngine creates a policy engine reading tenant policies from store; prices may be nil
+func NewDocumentPolicyEngine(store PolicyStore, prices PriceLookup) *DocumentPolicyEngine {
+	return &DocumentPolicyEngine{
+		store:  store,
+		prices: prices,
+		now:    time.Now,
+	}
+}
+
+// Evaluate applies the tenant's policy to a swap. Tenants without a policy fall back to the
+// default tenant's policy, and are allowed if there is none. The decision is the most severe
+// outcome of any rule, with a reason for every rule that did not allow the swap.
+func (e *DocumentPolicyEngine) Evaluate(ctx context.Context, input PolicyInput) (types.PolicyDecision, error) {
+	tenantID := input.Compliance.TenantID
+	if tenantID == "" {
+		tenantID = types.DefaultTenantID
+	}
+
+	decision := types.PolicyDecision{
+		Outcome:     types.PolicyAllow,
+		TenantID:    tenantID,
+		EvaluatedAt: e.now().UTC(),
+	}
+
+	doc, err := e.store.GetPolicy(ctx, tenantID)
+	if errors.Is(err, types.ErrPolicyNotFound) && tenantID != types.DefaultTenantID {
+		doc, err = e.store.GetPolicy(ctx, types.DefaultTenantID)
+	}
+	if errors.Is(err, types.ErrPolicyNotFound) {
+		return decision, nil
+	}
+	if err != nil {
+		return decision, fmt.Errorf("failed to load policy for tenant %s: %w", tenantID, err)
+	}
+	decision.PolicyVersion = doc.Version
+
+	deny := func(rule, message string) {
+		decision.Reasons = append(decision.Reasons, types.PolicyReason{Rule: rule, Outcome: types.PolicyDeny, Message: message})
+	}
+	review := func(rule, message string) {
+		decision.Reasons = append(decision.Reasons, types.PolicyReason{Rule: rule, Outcome: types.PolicyReview, Message: message})
+	}
+
+	// Geo restrictions
+	country := strings.ToUpper(input.Compliance.Country)
+	switch {
+	case country == "":
+		if doc.ReviewUnknownOrigin {
+			review(PolicyRuleGeo, "request origin is unknown")
+		}
+	case containsFold(doc.BlockedCountries, country):
+		deny(PolicyRuleGeo, fmt.Sprintf("requests from %s are not allowed", country))
+	case containsFold(doc.ReviewCountries, country):
+		review(PolicyRuleGeo, fmt.Sprintf("requests from %s require review", country))
+	}
+
+	// Token category rules
+	for _, token := range []types.Token{input.Request.SourceToken, input.Request.DestinationToken} {
+		category, ok := lookupFold(doc.TokenCategories, token.Symbol)
+		if !ok {
+			continue
+		}
+		outcome, ok := lookupFold(doc.CategoryRules, category)
+		if !ok {
+			continue
+		}
+		message := fmt.Sprintf("%s is in category %s", token.Symbol, category)
+		if outcome == types.PolicyDeny {
+			deny(PolicyRuleTokenCategory, message)
+		} else {
+			review(PolicyRuleTokenCategory, message)
+		}
+	}
+
+	// Amount thresholds
+	if doc.ReviewAboveUSD > 0 || doc.DenyAboveUSD > 0 {
+		amountUSD, err := e.amountUSD(ctx, input.Request)
+		switch {
+		case err != nil:
+			// Without a price the thresholds cannot be checked, so a human has to
+			review(PolicyRuleAmount, fmt.Sprintf("swap value could not be determined: %v", err))
+		case doc.DenyAboveUSD > 0 && amountUSD > doc.DenyAboveUSD:
+			deny(PolicyRuleAmount, fmt.Sprintf("swap value $%.2f exceeds the $%.2f limit", amountUSD, doc.DenyAboveUSD))
+		case doc.ReviewAboveUSD > 0 && amountUSD > doc.ReviewAboveUSD:
+			review(PolicyRuleAmount, fmt.Sprintf("swap value $%.2f exceeds the $%.2f review threshold", amountUSD, doc.ReviewAboveUSD))
+		}
+	}
+
+	for _, reason := range decision.Reasons {
+		if reason.Outcome == types.PolicyDeny {
+			decision.Outcome = types.PolicyDeny
+			break
+		}
+		decision.Outcome = types.PolicyReview
+	}
+
+	return decision, nil
+}
+
+// amountUSD values the swap's input amount
+func (e *DocumentPolicyEngine) amountUSD(ctx context.Context, request types.SwapRequest) (float64, error) {
+	if e.prices == nil {
+		return 0, errors.New("no price source configured")
+	}
+	if request.Amount == nil {
+		return 0, errors.New("missing amount")
+	}
+
+	price, err := e.prices.PriceUSD(ctx, request.SourceToken.Symbol, request.SourceToken.ChainID)
+	if err != nil {
+		return 0, err
+	}
+	return types.NewAmount(request.Amount, request.SourceToken.Decimals).Float64() * price, nil
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupFold looks up key in m ignoring case; policy documents may come from case-folding config loaders
+func lookupFold(m map[string]string, key string) (string, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
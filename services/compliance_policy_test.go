@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// fixedPrices is a PriceLookup with fixed prices by symbol
+type fixedPrices map[string]float64
+
+func (p fixedPrices) PriceUSD(ctx context.Context, symbol string, chainID int64) (float64, error) {
+	if price, ok := p[symbol]; ok {
+		return price, nil
+	}
+	return 0, errors.New("no price")
+}
+
+func TestDocumentPolicyEngine(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryPolicyStore()
+	engine := NewDocumentPolicyEngine(store, fixedPrices{"ETH": 2000})
+
+	swap := func(symbol string, ether int64) types.SwapRequest {
+		amount := new(big.Int).Mul(big.NewInt(ether), big.NewInt(1000000000000000000))
+		return types.SwapRequest{
+			SourceToken:      types.Token{Symbol: symbol, Decimals: 18, ChainID: 1},
+			DestinationToken: types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1},
+			Amount:           amount,
+		}
+	}
+	evaluate := func(t *testing.T, tenantID, country string, request types.SwapRequest) types.PolicyDecision {
+		t.Helper()
+		decision, err := engine.Evaluate(ctx, PolicyInput{
+			Compliance: types.ComplianceContext{TenantID: tenantID, Country: country},
+			Request:    request,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return decision
+	}
+
+	t.Run("NoPolicy", func(t *testing.T) {
+		if decision := evaluate(t, "acme", "US", swap("ETH", 1000)); decision.Outcome != types.PolicyAllow {
+			t.Errorf("Expected allow without a policy, got %+v", decision)
+		}
+	})
+
+	store.SavePolicy(ctx, types.PolicyDocument{
+		TenantID:         "acme",
+		Version:          3,
+		ReviewAboveUSD:   10000,
+		DenyAboveUSD:     100000,
+		BlockedCountries: []string{"KP"},
+		ReviewCountries:  []string{"ru"},
+		TokenCategories:  map[string]string{"XMR": "privacy", "MEME": "unvetted"},
+		CategoryRules:    map[string]string{"privacy": types.PolicyDeny, "unvetted": types.PolicyReview},
+	})
+	store.SavePolicy(ctx, types.PolicyDocument{TenantID: types.DefaultTenantID, Version: 1, BlockedCountries: []string{"IR"}})
+
+	t.Run("Allow", func(t *testing.T) {
+		decision := evaluate(t, "acme", "US", swap("ETH", 1))
+		if decision.Outcome != types.PolicyAllow || decision.PolicyVersion != 3 || len(decision.Reasons) != 0 {
+			t.Errorf("Expected allow under policy v3, got %+v", decision)
+		}
+	})
+
+	t.Run("Amount", func(t *testing.T) {
+		if decision := evaluate(t, "acme", "US", swap("ETH", 10)); decision.Outcome != types.PolicyReview {
+			t.Errorf("Expected review for a $20,000 swap, got %+v", decision)
+		}
+		if decision := evaluate(t, "acme", "US", swap("ETH", 100)); decision.Outcome != types.PolicyDeny {
+			t.Errorf("Expected deny for a $200,000 swap, got %+v", decision)
+		}
+		// Unpriced tokens cannot be checked against thresholds
+		decision := evaluate(t, "acme", "US", swap("UNI", 1))
+		if decision.Outcome != types.PolicyReview || decision.Reasons[0].Rule != PolicyRuleAmount {
+			t.Errorf("Expected review for an unpriced swap, got %+v", decision)
+		}
+	})
+
+	t.Run("Geo", func(t *testing.T) {
+		if decision := evaluate(t, "acme", "kp", swap("ETH", 1)); decision.Outcome != types.PolicyDeny {
+			t.Errorf("Expected deny from a blocked country, got %+v", decision)
+		}
+		if decision := evaluate(t, "acme", "RU", swap("ETH", 1)); decision.Outcome != types.PolicyReview {
+			t.Errorf("Expected review from a review country, got %+v", decision)
+		}
+	})
+
+	t.Run("TokenCategory", func(t *testing.T) {
+		request := swap("ETH", 1)
+		request.DestinationToken.Symbol = "XMR"
+		if decision := evaluate(t, "acme", "US", request); decision.Outcome != types.PolicyDeny {
+			t.Errorf("Expected deny for a privacy token, got %+v", decision)
+		}
+	})
+
+	t.Run("MostSevereWins", func(t *testing.T) {
+		decision := evaluate(t, "acme", "RU", swap("ETH", 100))
+		if decision.Outcome != types.PolicyDeny || len(decision.Reasons) != 2 {
+			t.Errorf("Expected deny with both reasons, got %+v", decision)
+		}
+	})
+
+	t.Run("DefaultTenant", func(t *testing.T) {
+		decision := evaluate(t, "unknown", "IR", swap("ETH", 1))
+		if decision.Outcome != types.PolicyDeny || decision.TenantID != "unknown" {
+			t.Errorf("Expected the default policy to apply, got %+v", decision)
+		}
+	})
+}
+
+func TestValidatePolicyDocument(t *testing.T) {
+	valid := types.PolicyDocument{TenantID: "acme", ReviewAboveUSD: 100, DenyAboveUSD: 1000}
+	if err := ValidatePolicyDocument(valid); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	invalid := []types.PolicyDocument{
+		{},
+		{TenantID: "acme", ReviewAboveUSD: 1000, DenyAboveUSD: 100},
+		{TenantID: "acme", DenyAboveUSD: -1},
+		{TenantID: "acme", CategoryRules: map[string]string{"privacy": "maybe"}},
+	}
+	for _, doc := range invalid {
+		if err := ValidatePolicyDocument(doc); err == nil {
+			t.Errorf("Expected error for %+v, got nil", doc)
+		}
+	}
+}
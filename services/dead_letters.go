@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// DeadLetterStore keeps the swaps found stuck or failed until operators requeue or refund them
+type DeadLetterStore interface {
+	// RecordDeadLetter stores a dead letter unless one is already stored for the swap's workflow run, reporting
+	// whether it was new
+	RecordDeadLetter(ctx context.Context, letter types.DeadLetter) (bool, error)
+	// GetDeadLetter returns the most recently detected dead letter of a swap, or types.ErrDeadLetterNotFound
+	GetDeadLetter(ctx context.Context, requestID string) (*types.DeadLetter, error)
+	// ListDeadLetters returns up to limit dead letters with the status, or of any status when it is empty, most
+	// recently detected first
+	ListDeadLetters(ctx context.Context, status types.DeadLetterStatus, limit int) ([]types.DeadLetter, error)
+	// ResolveDeadLetter marks a swap's open dead letters requeued or refunded by an operator's admin action, or
+	// returns types.ErrDeadLetterNotFound when it has none open
+	ResolveDeadLetter(ctx context.Context, requestID string, status types.DeadLetterStatus, operator, actionID string, at time.Time) error
+}
+
+// InMemoryDeadLetterStore is a DeadLetterStore for running without a database
+type InMemoryDeadLetterStore struct {
+	letters map[string]types.DeadLetter // map[requestID/runID]DeadLetter
+	mu      sync.RWMutex
+}
+
+// NewInMemoryDeadLetterStore creates an empty in-memory dead letter store
+func NewInMemoryDeadLetterStore() *InMemoryDeadLetterStore {
+	return &InMemoryDeadLetterStore{
+		letters: make(map[string]types.DeadLetter),
+	}
+}
+
+// RecordDeadLetter stores a dead letter unless one is already stored for the swap's workflow run
+func (s *InMemoryDeadLetterStore) RecordDeadLetter(ctx context.Context, letter types.DeadLetter) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := letter.RequestID + "/" + letter.RunID
+	if _, ok := s.letters[key]; ok {
+		return false, nil
+	}
+	s.letters[key] = letter
+	return true, nil
+}
+
+// GetDeadLetter returns the most recently detected dead letter of a swap
+func (s *InMemoryDeadLetterStore) GetDeadLetter(ctx context.Context, requestID string) (*types.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *types.DeadLetter
+	for _, letter := range s.letters {
+		if letter.RequestID == requestID && (latest == nil || letter.DetectedAt.After(latest.DetectedAt)) {
+			letter := letter
+			latest = &letter
+		}
+	}
+	if latest == nil {
+		return nil, types.ErrDeadLetterNotFound
+	}
+	return latest, nil
+}
+
+// ListDeadLetters returns up to limit dead letters with the status, most recently detected first
+func (s *InMemoryDeadLetterStore) ListDeadLetters(ctx context.Context, status types.DeadLetterStatus, limit int) ([]types.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	letters := []types.DeadLetter{}
+	for _, letter := range s.letters {
+		if status == "" || letter.Status == status {
+			letters = append(letters, letter)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if !letters[i].DetectedAt.Equal(letters[j].DetectedAt) {
+			return letters[i].DetectedAt.After(letters[j].DetectedAt)
+		}
+		return letters[i].RequestID < letters[j].RequestID
+	})
+	if len(letters) > limit {
+		letters = letters[:limit]
+	}
+	return letters, nil
+}
+
+// ResolveDeadLetter marks a swap's open dead letters requeued or refunded
+func (s *InMemoryDeadLetterStore) ResolveDeadLetter(ctx context.Context, requestID string, status types.DeadLetterStatus, operator, actionID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resolved := false
+	for key, letter := range s.letters {
+		if letter.RequestID != requestID || letter.Status != types.DeadLetterOpen {
+			continue
+		}
+		letter.Status = status
+		letter.ResolvedBy = operator
+		letter.ActionID = actionID
+		letter.ResolvedAt = &at
+		s.letters[key] = letter
+		resolved = true
+	}
+	if !resolved {
+		return types.ErrDeadLetterNotFound
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestInMemoryDeadLetterStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryDeadLetterStore()
+	now := time.Now().UTC()
+
+	stuck := types.DeadLetter{RequestID: "swap-1", RunID: "run-1", Reason: types.DeadLetterStuck, DetectedAt: now.Add(-time.Hour), Status: types.DeadLetterOpen}
+	failed := types.DeadLetter{RequestID: "swap-2", RunID: "run-1", Reason: types.DeadLetterFailed, DetectedAt: now, Status: types.DeadLetterOpen}
+	for _, letter := range []types.DeadLetter{stuck, failed} {
+		if isNew, err := store.RecordDeadLetter(ctx, letter); err != nil || !isNew {
+			t.Fatalf("Expected dead letter of %s to be recorded, got %v, %v", letter.RequestID, isNew, err)
+		}
+	}
+
+	// The same run is recorded once, even when a later scan finds it failed
+	again := stuck
+	again.Reason = types.DeadLetterFailed
+	if isNew, err := store.RecordDeadLetter(ctx, again); err != nil || isNew {
+		t.Errorf("Expected the run to be recorded once, got %v, %v", isNew, err)
+	}
+	if letter, err := store.GetDeadLetter(ctx, "swap-1"); err != nil || letter.Reason != types.DeadLetterStuck {
+		t.Errorf("Expected the first dead letter to be kept, got %+v, %v", letter, err)
+	}
+
+	letters, err := store.ListDeadLetters(ctx, types.DeadLetterOpen, 10)
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) != 2 || letters[0].RequestID != "swap-2" {
+		t.Errorf("Expected both dead letters, most recent first, got %+v", letters)
+	}
+	if letters, _ := store.ListDeadLetters(ctx, "", 1); len(letters) != 1 {
+		t.Errorf("Expected the limit to apply, got %d dead letters", len(letters))
+	}
+
+	if err := store.ResolveDeadLetter(ctx, "swap-1", types.DeadLetterRefunded, "alice", "admin-1", now); err != nil {
+		t.Fatalf("Failed to resolve dead letter: %v", err)
+	}
+	letter, err := store.GetDeadLetter(ctx, "swap-1")
+	if err != nil || letter.Status != types.DeadLetterRefunded || letter.ResolvedBy != "alice" || letter.ActionID != "admin-1" || letter.ResolvedAt == nil {
+		t.Errorf("Expected the dead letter to be refunded by alice, got %+v, %v", letter, err)
+	}
+	if letters, _ := store.ListDeadLetters(ctx, types.DeadLetterOpen, 10); len(letters) != 1 {
+		t.Errorf("Expected one open dead letter left, got %+v", letters)
+	}
+
+	// A swap without an open dead letter can't be resolved again
+	if err := store.ResolveDeadLetter(ctx, "swap-1", types.DeadLetterRequeued, "bob", "admin-2", now); !errors.Is(err, types.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound, got %v", err)
+	}
+	if _, err := store.GetDeadLetter(ctx, "swap-3"); !errors.Is(err, types.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound, got %v", err)
+	}
+}
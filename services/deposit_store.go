@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// DepositStore persists the deposits a DepositWatcher expects and the transfers that fulfilled them,
+// so a restarted watcher picks up where it stopped
+type DepositStore interface {
+	// SaveExpectedDeposit stores an expected deposit, replacing any earlier one of the same swap
+	SaveExpectedDeposit(ctx context.Context, expected types.ExpectedDeposit) error
+	// ExpectedDeposits returns the deposits still expected
+	ExpectedDeposits(ctx context.Context) ([]types.ExpectedDeposit, error)
+	// AdvanceDeposits moves the next block of the swaps' expected deposits forward to nextBlock
+	AdvanceDeposits(ctx context.Context, requestIDs []string, nextBlock uint64) error
+	// FulfilDeposit records that a transfer fulfilled a swap's expected deposit and stops expecting it.
+	// It reports false, changing nothing, when the transfer already fulfilled a deposit or the deposit is no longer expected.
+	FulfilDeposit(ctx context.Context, requestID, transferKey string) (bool, error)
+	// DeleteExpectedDeposit stops expecting a swap's deposit
+	DeleteExpectedDeposit(ctx context.Context, requestID string) error
+}
+
+// InMemoryDepositStore is a DepositStore for running without a database
+type InMemoryDepositStore struct {
+	expected map[string]types.ExpectedDeposit // by request ID
+	used     map[string]string                // request IDs by transfer key
+	mu       sync.Mutex
+}
+
+// NewInMemoryDepositStore creates an empty in-memory deposit store
+func NewInMemoryDepositStore() *InMemoryDepositStore {
+	return &InMemoryDepositStore{
+		expected: make(map[string]types.ExpectedDeposit),
+		used:     make(map[string]string),
+	}
+}
+
+// SaveExpectedDeposit stores an expected deposit
+func (s *InMemoryDepositStore) SaveExpectedDeposit(ctx context.Context, expected types.ExpectedDeposit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expected[expected.RequestID] = expected
+	return nil
+}
+
+// ExpectedDeposits returns the deposits still expected, oldest expiry first
+func (s *InMemoryDepositStore) ExpectedDeposits(ctx context.Context) ([]types.ExpectedDeposit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deposits := make([]types.ExpectedDeposit, 0, len(s.expected))
+	for _, expected := range s.expected {
+		deposits = append(deposits, expected)
+	}
+	sort.Slice(deposits, func(i, j int) bool { return deposits[i].ExpiresAt.Before(deposits[j].ExpiresAt) })
+	return deposits, nil
+}
+
+// AdvanceDeposits moves the next block of expected deposits forward
+func (s *InMemoryDepositStore) AdvanceDeposits(ctx context.Context, requestIDs []string, nextBlock uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, requestID := range requestIDs {
+		if expected, ok := s.expected[requestID]; ok && expected.NextBlock < nextBlock {
+			expected.NextBlock = nextBlock
+			s.expected[requestID] = expected
+		}
+	}
+	return nil
+}
+
+// FulfilDeposit records the transfer that fulfilled an expected deposit
+func (s *InMemoryDepositStore) FulfilDeposit(ctx context.Context, requestID, transferKey string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.used[transferKey]; ok {
+		return false, nil
+	}
+	if _, ok := s.expected[requestID]; !ok {
+		return false, nil
+	}
+	s.used[transferKey] = requestID
+	delete(s.expected, requestID)
+	return true, nil
+}
+
+// DeleteExpectedDeposit stops expecting a deposit
+func (s *InMemoryDepositStore) DeleteExpectedDeposit(ctx context.Context, requestID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.expected, requestID)
+	return nil
+}
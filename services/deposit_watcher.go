@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// maxDepositScanBlocks caps how many blocks one poll reads transfer logs for, per token and deposit address
+const maxDepositScanBlocks = 2000
+
+// ErrDepositNotSupported is returned for deposits the watcher cannot index
+var ErrDepositNotSupported = errors.New("deposits are only indexed for ERC-20 tokens on EVM chains")
+
+// TransferIndexer reads ERC-20 transfers from a chain's indexer
+type TransferIndexer interface {
+	// BlockNumber returns the chain's latest block
+	BlockNumber(ctx context.Context, chainID int64) (uint64, error)
+
+	// TransferLogs returns the transfers of a token contract to an address within a block range, inclusive
+	TransferLogs(ctx context.Context, chainID int64, token, to string, fromBlock, toBlock uint64) ([]types.TokenTransfer, error)
+}
+
+// DepositHandler is called for each expected deposit that arrives
+type DepositHandler func(ctx context.Context, deposit types.Deposit, expected types.ExpectedDeposit)
+
+// DepositWatcher watches deposit addresses for the deposits pending swaps expect. A transfer fulfils the oldest
+// expected deposit of the same token, sender and address it covers, once it has the chain's confirmations.
+// Expected deposits and the transfers that fulfilled them are kept in a DepositStore, so none are lost on restart.
+type DepositWatcher struct {
+	indexer       TransferIndexer
+	store         DepositStore
+	confirmations map[int64]uint64
+	handler       DepositHandler
+}
+
+// NewDepositWatcher creates a watcher reading transfers from indexer and keeping expected deposits in memory.
+// Deposits are final after the chain's confirmations, in blocks.
+func NewDepositWatcher(indexer TransferIndexer, confirmations map[int64]int) *DepositWatcher {
+	w := &DepositWatcher{
+		indexer:       indexer,
+		store:         NewInMemoryDepositStore(),
+		confirmations: make(map[int64]uint64, len(confirmations)),
+	}
+	for chainID, blocks := range confirmations {
+		if blocks > 0 {
+			w.confirmations[types.CanonicalChainID(chainID)] = uint64(blocks)
+		}
+	}
+	return w
+}
+
+// SetStore replaces the store expected deposits are kept in
+func (w *DepositWatcher) SetStore(store DepositStore) {
+	w.store = store
+}
+
+// SetHandler sets the function called for each deposit that arrives
+func (w *DepositWatcher) SetHandler(handler DepositHandler) {
+	w.handler = handler
+}
+
+// Expect starts watching for a deposit. Only transfers in blocks after the current one count.
+func (w *DepositWatcher) Expect(ctx context.Context, expected types.ExpectedDeposit) error {
+	chain, ok := types.GetChain(types.CanonicalChainID(expected.Token.ChainID))
+	if !ok || chain.Namespace != "eip155" || expected.Token.Address == "" || expected.Token.IsNative() {
+		return ErrDepositNotSupported
+	}
+	expected.Token.ChainID = chain.ID
+	if expected.Amount == nil || expected.Amount.Sign() <= 0 {
+		return errors.New("expected deposit amount must be positive")
+	}
+
+	head, err := w.indexer.BlockNumber(ctx, chain.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read chain %d: %w", chain.ID, err)
+	}
+	expected.NextBlock = head + 1
+	return w.store.SaveExpectedDeposit(ctx, expected)
+}
+
+// Cancel stops watching for a swap's deposit
+func (w *DepositWatcher) Cancel(ctx context.Context, requestID string) error {
+	return w.store.DeleteExpectedDeposit(ctx, requestID)
+}
+
+// Pending returns the deposits still expected, oldest expiry first
+func (w *DepositWatcher) Pending(ctx context.Context) ([]types.ExpectedDeposit, error) {
+	deposits, err := w.store.ExpectedDeposits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(deposits, func(i, j int) bool { return deposits[i].ExpiresAt.Before(deposits[j].ExpiresAt) })
+	return deposits, nil
+}
+
+// depositGroup is the pending deposits sharing a chain, token and deposit address, which one log query covers
+type depositGroup struct {
+	chainID int64
+	token   string
+	address string
+	pending []types.ExpectedDeposit
+}
+
+// Poll reads the confirmed transfers to every watched deposit address, calls the handler for each expected deposit
+// that arrived and drops expired ones
+func (w *DepositWatcher) Poll(ctx context.Context) error {
+	pending, err := w.store.ExpectedDeposits(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load expected deposits: %w", err)
+	}
+
+	now := time.Now()
+	var errs []error
+	groups := make(map[string]*depositGroup)
+	var order []string
+	for _, expected := range pending {
+		if !expected.ExpiresAt.IsZero() && now.After(expected.ExpiresAt) {
+			if err := w.store.DeleteExpectedDeposit(ctx, expected.RequestID); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		key := fmt.Sprintf("%d:%s:%s", expected.Token.ChainID, strings.ToLower(expected.Token.Address), strings.ToLower(expected.Address))
+		group, ok := groups[key]
+		if !ok {
+			group = &depositGroup{chainID: expected.Token.ChainID, token: expected.Token.Address, address: expected.Address}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.pending = append(group.pending, expected)
+	}
+
+	heads := make(map[int64]uint64)
+	for _, key := range order {
+		group := groups[key]
+		head, ok := heads[group.chainID]
+		if !ok {
+			latest, err := w.indexer.BlockNumber(ctx, group.chainID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("chain %d: %w", group.chainID, err))
+				continue
+			}
+			head = latest
+			if confirmations := w.confirmations[group.chainID]; confirmations > 0 {
+				head = subtractBlocks(latest, confirmations-1)
+			}
+			heads[group.chainID] = head
+		}
+		if err := w.scan(ctx, group, head); err != nil {
+			errs = append(errs, fmt.Errorf("chain %d: %w", group.chainID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scan matches a group's transfers up to the confirmed head to its pending deposits, oldest expected first
+func (w *DepositWatcher) scan(ctx context.Context, group *depositGroup, head uint64) error {
+	from := group.pending[0].NextBlock
+	for _, expected := range group.pending[1:] {
+		from = min(from, expected.NextBlock)
+	}
+	if from > head {
+		return nil
+	}
+	to := min(head, from+maxDepositScanBlocks-1)
+
+	transfers, err := w.indexer.TransferLogs(ctx, group.chainID, group.token, group.address, from, to)
+	if err != nil {
+		return err
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber < transfers[j].BlockNumber
+		}
+		return transfers[i].LogIndex < transfers[j].LogIndex
+	})
+	sort.Slice(group.pending, func(i, j int) bool {
+		return group.pending[i].ExpiresAt.Before(group.pending[j].ExpiresAt)
+	})
+
+	fulfilled := make(map[string]bool)
+	for _, transfer := range transfers {
+		for _, expected := range group.pending {
+			if fulfilled[expected.RequestID] ||
+				transfer.BlockNumber < expected.NextBlock ||
+				!strings.EqualFold(transfer.From, expected.From) ||
+				transfer.Amount == nil || transfer.Amount.Cmp(expected.Amount) < 0 {
+				continue
+			}
+			// The store decides atomically, so a transfer never fulfils two deposits
+			transferKey := fmt.Sprintf("%d:%s:%d", group.chainID, strings.ToLower(transfer.TxHash), transfer.LogIndex)
+			ok, err := w.store.FulfilDeposit(ctx, expected.RequestID, transferKey)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			fulfilled[expected.RequestID] = true
+
+			deposit := types.Deposit{
+				RequestID:   expected.RequestID,
+				ChainID:     group.chainID,
+				Token:       expected.Token,
+				TxHash:      transfer.TxHash,
+				BlockNumber: transfer.BlockNumber,
+				From:        transfer.From,
+				To:          transfer.To,
+				Amount:      transfer.Amount,
+			}
+			log.Printf("Deposit for swap %s arrived in %s", deposit.RequestID, deposit.TxHash)
+			if w.handler != nil {
+				w.handler(ctx, deposit, expected)
+			}
+			break
+		}
+	}
+
+	var waiting []string
+	for _, expected := range group.pending {
+		if !fulfilled[expected.RequestID] {
+			waiting = append(waiting, expected.RequestID)
+		}
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+	return w.store.AdvanceDeposits(ctx, waiting, to+1)
+}
+
+// StartPolling polls for deposits every interval until ctx is done
+func (w *DepositWatcher) StartPolling(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := w.Poll(ctx); err != nil {
+				log.Printf("Failed to poll deposits: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// subtractBlocks returns block - n, or 0 when n is larger
+func subtractBlocks(block, n uint64) uint64 {
+	if n > block {
+		return 0
+	}
+	return block - n
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// fakeIndexer serves a chain head and the transfers in its blocks
+type fakeIndexer struct {
+	head      uint64
+	transfers []types.TokenTransfer
+	queries   [][2]uint64
+	err       error
+}
+
+func (f *fakeIndexer) BlockNumber(ctx context.Context, chainID int64) (uint64, error) {
+	return f.head, f.err
+}
+
+func (f *fakeIndexer) TransferLogs(ctx context.Context, chainID int64, token, to string, fromBlock, toBlock uint64) ([]types.TokenTransfer, error) {
+	f.queries = append(f.queries, [2]uint64{fromBlock, toBlock})
+	var matched []types.TokenTransfer
+	for _, transfer := range f.transfers {
+		if transfer.To == to && transfer.BlockNumber >= fromBlock && transfer.BlockNumber <= toBlock {
+			matched = append(matched, transfer)
+		}
+	}
+	return matched, nil
+}
+
+func TestDepositWatcher(t *testing.T) {
+	const (
+		depositAddress = "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
+		user           = "0x1234567890abcdef1234567890abcdef12345678"
+	)
+	usdc := types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1, Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"}
+	ctx := context.Background()
+
+	expect := func(requestID string, amount int64) types.ExpectedDeposit {
+		return types.ExpectedDeposit{
+			RequestID: requestID,
+			Token:     usdc,
+			From:      user,
+			Address:   depositAddress,
+			Amount:    big.NewInt(amount),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+	transfer := func(hash string, block uint64, from string, amount int64) types.TokenTransfer {
+		return types.TokenTransfer{Token: usdc.Address, TxHash: hash, BlockNumber: block, From: from, To: depositAddress, Amount: big.NewInt(amount)}
+	}
+
+	t.Run("MatchesConfirmedDeposit", func(t *testing.T) {
+		indexer := &fakeIndexer{head: 100}
+		watcher := NewDepositWatcher(indexer, map[int64]int{1: 3})
+		var arrived []types.Deposit
+		watcher.SetHandler(func(ctx context.Context, deposit types.Deposit, expected types.ExpectedDeposit) {
+			arrived = append(arrived, deposit)
+		})
+		if err := watcher.Expect(ctx, expect("swap-1", 1000)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Transfers before the deposit was expected, from someone else or too small do not count
+		indexer.transfers = []types.TokenTransfer{
+			transfer("0xold", 100, user, 1000),
+			transfer("0xother", 101, "0x9999999999999999999999999999999999999999", 1000),
+			transfer("0xsmall", 101, user, 999),
+			transfer("0xdeposit", 102, user, 1000),
+		}
+		indexer.head = 103
+		if err := watcher.Poll(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(arrived) != 0 {
+			t.Fatalf("Expected the deposit to wait for 3 confirmations, got %+v", arrived)
+		}
+
+		indexer.head = 104
+		if err := watcher.Poll(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(arrived) != 1 || arrived[0].TxHash != "0xdeposit" || arrived[0].RequestID != "swap-1" || arrived[0].ChainID != 1 {
+			t.Fatalf("Expected the deposit in 0xdeposit, got %+v", arrived)
+		}
+		if pending, _ := watcher.Pending(ctx); len(pending) != 0 {
+			t.Error("Expected the deposit to stop being watched")
+		}
+		if indexer.queries[0] != [2]uint64{101, 101} || indexer.queries[1] != [2]uint64{102, 102} {
+			t.Errorf("Expected each block to be scanned once, got %v", indexer.queries)
+		}
+	})
+
+	t.Run("TransferFulfilsOneDeposit", func(t *testing.T) {
+		indexer := &fakeIndexer{head: 100}
+		watcher := NewDepositWatcher(indexer, nil)
+		var arrived []string
+		watcher.SetHandler(func(ctx context.Context, deposit types.Deposit, expected types.ExpectedDeposit) {
+			arrived = append(arrived, deposit.RequestID+":"+deposit.TxHash)
+		})
+		first := expect("swap-1", 1000)
+		second := expect("swap-2", 1000)
+		second.ExpiresAt = first.ExpiresAt.Add(time.Minute)
+		watcher.Expect(ctx, second)
+		watcher.Expect(ctx, first)
+
+		indexer.transfers = []types.TokenTransfer{transfer("0xa", 101, user, 1000)}
+		indexer.head = 101
+		watcher.Poll(ctx)
+		indexer.transfers = append(indexer.transfers, transfer("0xb", 102, user, 1000))
+		indexer.head = 102
+		watcher.Poll(ctx)
+
+		if len(arrived) != 2 || arrived[0] != "swap-1:0xa" || arrived[1] != "swap-2:0xb" {
+			t.Errorf("Expected each transfer to fulfil the oldest deposit, got %v", arrived)
+		}
+	})
+
+	t.Run("ResumesFromStore", func(t *testing.T) {
+		indexer := &fakeIndexer{head: 100}
+		store := NewInMemoryDepositStore()
+		watcher := NewDepositWatcher(indexer, nil)
+		watcher.SetStore(store)
+		watcher.Expect(ctx, expect("swap-1", 1000))
+		indexer.head = 110
+		watcher.Poll(ctx)
+
+		// A restarted watcher continues from the stored cursor and does not reuse fulfilled transfers
+		indexer.transfers = []types.TokenTransfer{transfer("0xdeposit", 105, user, 1000), transfer("0xlate", 112, user, 1000)}
+		indexer.head = 112
+		restarted := NewDepositWatcher(indexer, nil)
+		restarted.SetStore(store)
+		var arrived []string
+		restarted.SetHandler(func(ctx context.Context, deposit types.Deposit, expected types.ExpectedDeposit) {
+			arrived = append(arrived, deposit.TxHash)
+		})
+		if err := restarted.Poll(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(arrived) != 1 || arrived[0] != "0xlate" {
+			t.Errorf("Expected only the transfer after the stored cursor to count, got %v", arrived)
+		}
+		if last := indexer.queries[len(indexer.queries)-1]; last != [2]uint64{111, 112} {
+			t.Errorf("Expected the scan to resume at block 111, got %v", last)
+		}
+
+		store.SaveExpectedDeposit(ctx, expect("swap-2", 1000))
+		if ok, _ := store.FulfilDeposit(ctx, "swap-2", "1:0xlate:0"); ok {
+			t.Error("Expected a used transfer not to fulfil another deposit")
+		}
+	})
+
+	t.Run("DropsExpiredAndCancelled", func(t *testing.T) {
+		watcher := NewDepositWatcher(&fakeIndexer{head: 100}, nil)
+		expired := expect("swap-1", 1000)
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		watcher.Expect(ctx, expired)
+		watcher.Expect(ctx, expect("swap-2", 1000))
+		watcher.Cancel(ctx, "swap-2")
+
+		if err := watcher.Poll(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if pending, _ := watcher.Pending(ctx); len(pending) != 0 {
+			t.Errorf("Expected no pending deposits, got %+v", pending)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		watcher := NewDepositWatcher(&fakeIndexer{}, nil)
+		native := expect("swap-1", 1000)
+		native.Token = types.Token{Symbol: "ETH", ChainID: 1}
+		if err := watcher.Expect(ctx, native); !errors.Is(err, ErrDepositNotSupported) {
+			t.Errorf("Expected ErrDepositNotSupported for a native token, got %v", err)
+		}
+		solana := expect("swap-2", 1000)
+		solana.Token.ChainID = types.ChainIDSolana
+		if err := watcher.Expect(ctx, solana); !errors.Is(err, ErrDepositNotSupported) {
+			t.Errorf("Expected ErrDepositNotSupported for Solana, got %v", err)
+		}
+	})
+
+	t.Run("ChainUnavailable", func(t *testing.T) {
+		watcher := NewDepositWatcher(&fakeIndexer{err: errors.New("rpc down")}, nil)
+		if err := watcher.Expect(ctx, expect("swap-1", 1000)); err == nil {
+			t.Error("Expected an error when the chain cannot be read")
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// maxErrorMessageLength is how much of an error's message is kept with its fingerprint
+const maxErrorMessageLength = 500
+
+// variablePattern matches the parts of error messages that differ between occurrences of the same error: UUIDs,
+// hex strings such as addresses and hashes, and numbers
+var variablePattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|0x[0-9a-fA-F]+|[0-9]+(\.[0-9]+)?`)
+
+// ErrorStore persists error occurrence counts, so the top errors survive restarts and cover every process
+type ErrorStore interface {
+	// AddErrors adds occurrence counts to the stored counts of their fingerprints
+	AddErrors(ctx context.Context, stats []types.ErrorStat) error
+	// TopErrors returns up to limit fingerprints last seen at or after since, most occurrences first
+	TopErrors(ctx context.Context, since time.Time, limit int) ([]types.ErrorStat, error)
+}
+
+// ErrorSink receives the errors counted since the last flush, such as an error tracker
+type ErrorSink interface {
+	SendErrors(ctx context.Context, stats []types.ErrorStat) error
+}
+
+// InMemoryErrorStore is an ErrorStore for running without a database
+type InMemoryErrorStore struct {
+	stats map[string]types.ErrorStat
+	mu    sync.RWMutex
+}
+
+// NewInMemoryErrorStore creates an empty error store
+func NewInMemoryErrorStore() *InMemoryErrorStore {
+	return &InMemoryErrorStore{stats: make(map[string]types.ErrorStat)}
+}
+
+// AddErrors adds occurrence counts to the stored counts
+func (s *InMemoryErrorStore) AddErrors(ctx context.Context, stats []types.ErrorStat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stat := range stats {
+		s.stats[stat.Fingerprint] = mergeErrorStats(s.stats[stat.Fingerprint], stat)
+	}
+	return nil
+}
+
+// TopErrors returns the most frequent fingerprints seen since a time
+func (s *InMemoryErrorStore) TopErrors(ctx context.Context, since time.Time, limit int) ([]types.ErrorStat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	top := []types.ErrorStat{}
+	for _, stat := range s.stats {
+		if !stat.LastSeen.Before(since) {
+			top = append(top, stat)
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Fingerprint < top[j].Fingerprint
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+// mergeErrorStats adds a newer count of a fingerprint to an older one; either may be empty
+func mergeErrorStats(older, newer types.ErrorStat) types.ErrorStat {
+	if older.Count == 0 {
+		return newer
+	}
+	if newer.Count == 0 {
+		return older
+	}
+	newer.Count += older.Count
+	if older.FirstSeen.Before(newer.FirstSeen) {
+		newer.FirstSeen = older.FirstSeen
+	}
+	if older.LastSeen.After(newer.LastSeen) {
+		newer.LastSeen, newer.Message = older.LastSeen, older.Message
+	}
+	return newer
+}
+
+// ErrorFingerprint identifies recurrences of an error. Errors with a code are told apart by origin, operation, code
+// and type; errors without one also by their innermost message, with IDs, addresses and numbers taken out.
+func ErrorFingerprint(origin types.ErrorOrigin, operation, code, errType, message string) string {
+	parts := []string{string(origin), operation, code, errType}
+	if code == "" {
+		parts = append(parts, variablePattern.ReplaceAllString(message, "#"))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// typedError is an error carrying a machine-readable type, such as a Temporal application error
+type typedError interface {
+	error
+	Type() string
+}
+
+// ErrorReporter fingerprints the errors of the API, workflows, activities and SDK calls and counts their
+// occurrences. Counts are kept in memory and added to the store and sent to the sink when flushed, so reporting
+// adds no write to the failing request.
+type ErrorReporter struct {
+	store   ErrorStore
+	sink    ErrorSink // nil when errors are only stored
+	pending map[string]types.ErrorStat
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// NewErrorReporter creates a reporter flushing its counts to store
+func NewErrorReporter(store ErrorStore) *ErrorReporter {
+	return &ErrorReporter{
+		store:   store,
+		pending: make(map[string]types.ErrorStat),
+		now:     time.Now,
+	}
+}
+
+// SetStore sets the store counts are flushed to
+func (r *ErrorReporter) SetStore(store ErrorStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// SetSink sets where counts are sent when flushed, in addition to the store
+func (r *ErrorReporter) SetSink(sink ErrorSink) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sink = sink
+}
+
+// Report counts an error of an operation. Its code is the type of the outermost typed error in its chain, such as
+// a Temporal application error, and its type the Go type of the innermost error.
+func (r *ErrorReporter) Report(origin types.ErrorOrigin, operation string, err error) {
+	if err == nil {
+		return
+	}
+	var code string
+	var typed typedError
+	if errors.As(err, &typed) {
+		code = typed.Type()
+	}
+	root := err
+	for next := errors.Unwrap(root); next != nil; next = errors.Unwrap(root) {
+		root = next
+	}
+	r.record(origin, operation, code, fmt.Sprintf("%T", root), root.Error(), err.Error())
+}
+
+// ReportCode counts an error known by its code rather than its value, such as an API error response
+func (r *ErrorReporter) ReportCode(origin types.ErrorOrigin, operation, code, message string) {
+	r.record(origin, operation, code, "", message, message)
+}
+
+// record counts an occurrence of a fingerprint
+func (r *ErrorReporter) record(origin types.ErrorOrigin, operation, code, errType, rootMessage, message string) {
+	if len(message) > maxErrorMessageLength {
+		message = message[:maxErrorMessageLength]
+	}
+	fingerprint := ErrorFingerprint(origin, operation, code, errType, rootMessage)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now().UTC()
+	r.pending[fingerprint] = mergeErrorStats(r.pending[fingerprint], types.ErrorStat{
+		Fingerprint: fingerprint,
+		Origin:      origin,
+		Operation:   operation,
+		Code:        code,
+		Type:        errType,
+		Message:     message,
+		Count:       1,
+		FirstSeen:   now,
+		LastSeen:    now,
+	})
+}
+
+// Flush sends the counts recorded since the last flush to the sink and adds them to the store. Counts the store
+// refuses are kept for the next flush, which sends them to the sink again; the sink is told about errors while the
+// database is down.
+func (r *ErrorReporter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending, store, sink := r.pending, r.store, r.sink
+	r.pending = make(map[string]types.ErrorStat)
+	r.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	stats := make([]types.ErrorStat, 0, len(pending))
+	for _, stat := range pending {
+		stats = append(stats, stat)
+	}
+	if sink != nil {
+		if err := sink.SendErrors(ctx, stats); err != nil {
+			log.Printf("Failed to send errors to the error tracker: %v", err)
+		}
+	}
+	if err := store.AddErrors(ctx, stats); err != nil {
+		r.mu.Lock()
+		for _, stat := range stats {
+			r.pending[stat.Fingerprint] = mergeErrorStats(stat, r.pending[stat.Fingerprint])
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// StartFlushing flushes the reporter every interval until ctx is done
+func (r *ErrorReporter) StartFlushing(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Flush(ctx); err != nil {
+					log.Printf("Failed to flush error counts: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// TopErrors flushes the reporter and returns the most frequent errors seen since a time
+func (r *ErrorReporter) TopErrors(ctx context.Context, since time.Time, limit int) ([]types.ErrorStat, error) {
+	if err := r.Flush(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	store := r.store
+	r.mu.Unlock()
+	return store.TopErrors(ctx, since, limit)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// failingErrorStore refuses error counts until it is told to accept them
+type failingErrorStore struct {
+	*InMemoryErrorStore
+	fail bool
+}
+
+func (s *failingErrorStore) AddErrors(ctx context.Context, stats []types.ErrorStat) error {
+	if s.fail {
+		return errors.New("database unavailable")
+	}
+	return s.InMemoryErrorStore.AddErrors(ctx, stats)
+}
+
+// codedError is an error with a type, like a Temporal application error
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Type() string  { return e.code }
+func (e *codedError) Unwrap() error { return e.err }
+
+func TestErrorReporter(t *testing.T) {
+	ctx := context.Background()
+	store := &failingErrorStore{InMemoryErrorStore: NewInMemoryErrorStore()}
+	reporter := NewErrorReporter(store)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+
+	// Occurrences differing only in IDs, addresses and amounts share a fingerprint
+	for i := 0; i < 3; i++ {
+		reporter.Report(types.ErrorOriginActivity, "ExecuteSwapActivity",
+			fmt.Errorf("swap swap-%d: %w", i, fmt.Errorf("nonce too low for 0x%040x", i)))
+	}
+	// The code of a typed error tells errors apart, whatever their messages
+	reporter.Report(types.ErrorOriginActivity, "ExecuteSwapActivity", &codedError{"SWAP_FAILED", errors.New("first")})
+	reporter.Report(types.ErrorOriginActivity, "ExecuteSwapActivity", &codedError{"SWAP_FAILED", errors.New("second")})
+	reporter.ReportCode(types.ErrorOriginAPI, "POST /api/v1/swap", "AMOUNT_TOO_SMALL", "amount too small")
+	if err := reporter.Flush(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Counts the store refuses are kept for the next flush
+	store.fail = true
+	now = now.Add(time.Minute)
+	reporter.ReportCode(types.ErrorOriginAPI, "POST /api/v1/swap", "AMOUNT_TOO_SMALL", "amount too small")
+	if err := reporter.Flush(ctx); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+	store.fail = false
+
+	top, err := reporter.TopErrors(ctx, now.Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(top) != 3 {
+		t.Fatalf("Expected 3 fingerprints, got %+v", top)
+	}
+	if top[0].Count != 3 || top[0].Code != "" || top[0].Message != "swap swap-2: nonce too low for 0x"+fmt.Sprintf("%040x", 2) {
+		t.Errorf("Expected the nonce error first with its latest message, got %+v", top[0])
+	}
+	// Ties are ordered by fingerprint
+	byCode := map[string]types.ErrorStat{top[1].Code: top[1], top[2].Code: top[2]}
+	if api := byCode["AMOUNT_TOO_SMALL"]; api.Count != 2 || api.Origin != types.ErrorOriginAPI || !api.LastSeen.Equal(now) || api.FirstSeen.Equal(now) {
+		t.Errorf("Expected the API error seen twice, got %+v", api)
+	}
+	if coded := byCode["SWAP_FAILED"]; coded.Count != 2 || coded.Type != "*errors.errorString" || coded.Message != "second" {
+		t.Errorf("Expected the coded activity error seen twice, got %+v", coded)
+	}
+
+	// Errors not seen since are left out
+	if recent, _ := reporter.TopErrors(ctx, now, 10); len(recent) != 1 {
+		t.Errorf("Expected only the API error seen in the last minute, got %+v", recent)
+	}
+	if limited, _ := reporter.TopErrors(ctx, time.Time{}, 1); len(limited) != 1 || limited[0].Count != 3 {
+		t.Errorf("Expected only the top error, got %+v", limited)
+	}
+}
+
+func TestSentrySink(t *testing.T) {
+	var events []sentryEvent
+	var auth string
+	sentry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/store/" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("X-Sentry-Auth")
+		var event sentryEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+	}))
+	defer sentry.Close()
+
+	if _, err := NewSentrySink(sentry.Client(), "https://sentry.example.com", "test"); err == nil {
+		t.Error("Expected a DSN without a key and project to be refused")
+	}
+
+	dsn := "http://public-key@" + sentry.Listener.Addr().String() + "/42"
+	sink, err := NewSentrySink(sentry.Client(), dsn, "production")
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	reporter := NewErrorReporter(NewInMemoryErrorStore())
+	reporter.SetSink(sink)
+	reporter.ReportCode(types.ErrorOriginAPI, "POST /api/v1/swap", "AMOUNT_TOO_SMALL", "amount too small")
+	reporter.ReportCode(types.ErrorOriginAPI, "POST /api/v1/swap", "AMOUNT_TOO_SMALL", "amount too small")
+	if err := reporter.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected one event per fingerprint, got %d", len(events))
+	}
+	event := events[0]
+	want := ErrorFingerprint(types.ErrorOriginAPI, "POST /api/v1/swap", "AMOUNT_TOO_SMALL", "", "")
+	if len(event.Fingerprint) != 1 || event.Fingerprint[0] != want || event.Environment != "production" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if count, _ := event.Extra["count"].(float64); count != 2 {
+		t.Errorf("Expected the event to carry 2 occurrences, got %v", event.Extra["count"])
+	}
+	if auth != "Sentry sentry_version=7, sentry_client=infinity-dex/1.0, sentry_key=public-key" {
+		t.Errorf("Unexpected auth header %q", auth)
+	}
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+)
+
+// NewReportingSDK wraps a Universal SDK so the errors of its calls are reported, by method, to reporter
+func NewReportingSDK(sdk universalsdk.SDK, reporter *ErrorReporter) universalsdk.SDK {
+	return universalsdk.Chain(sdk, ReportingMiddleware(reporter))
+}
+
+// ReportingMiddleware reports the errors of every SDK call, by method, to reporter
+func ReportingMiddleware(reporter *ErrorReporter) universalsdk.Middleware {
+	return func(next universalsdk.Invoker) universalsdk.Invoker {
+		return func(ctx context.Context, call universalsdk.Call) (interface{}, error) {
+			result, err := next(ctx, call)
+			if err != nil {
+				reporter.Report(types.ErrorOriginSDK, call.Method, err)
+			}
+			return result, err
+		}
+	}
+}
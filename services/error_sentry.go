@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/services/types"
+)
+
+// SentrySink sends error counts to Sentry, one event per fingerprint and flush. Events carry the fingerprint, so
+// Sentry groups the occurrences of an error the way the error reporter does.
+type SentrySink struct {
+	client      *http.Client
+	storeURL    string
+	auth        string
+	environment string
+}
+
+// sentryEvent is the part of a Sentry event the sink fills in
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Transaction string            `json:"transaction"`
+	Message     string            `json:"message"`
+	Environment string            `json:"environment,omitempty"`
+	Fingerprint []string          `json:"fingerprint"`
+	Tags        map[string]string `json:"tags"`
+	Extra       map[string]any    `json:"extra"`
+}
+
+// NewSentrySink creates a sink sending to the project of a Sentry DSN, e.g.
+// https://<key>@o123.ingest.sentry.io/456
+func NewSentrySink(client *http.Client, dsn, environment string) (*SentrySink, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	projectID := strings.Trim(parsed.Path, "/")
+	if parsed.User == nil || parsed.User.Username() == "" || projectID == "" || parsed.Host == "" {
+		return nil, errors.New("invalid Sentry DSN: must be of the form https://<key>@<host>/<project>")
+	}
+
+	return &SentrySink{
+		client:      client,
+		storeURL:    fmt.Sprintf("%s://%s/api/%s/store/", parsed.Scheme, parsed.Host, projectID),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=infinity-dex/1.0, sentry_key=%s", parsed.User.Username()),
+		environment: environment,
+	}, nil
+}
+
+// SendErrors sends an event for each count, stopping at the first Sentry refuses
+func (s *SentrySink) SendErrors(ctx context.Context, stats []types.ErrorStat) error {
+	for _, stat := range stats {
+		event := sentryEvent{
+			EventID:     strings.ReplaceAll(uuid.New().String(), "-", ""),
+			Timestamp:   stat.LastSeen.UTC().Format(time.RFC3339),
+			Level:       "error",
+			Platform:    "go",
+			Logger:      string(stat.Origin),
+			Transaction: stat.Operation,
+			Message:     stat.Message,
+			Environment: s.environment,
+			Fingerprint: []string{stat.Fingerprint},
+			Tags: map[string]string{
+				"origin":    string(stat.Origin),
+				"operation": stat.Operation,
+				"code":      stat.Code,
+				"type":      stat.Type,
+			},
+			Extra: map[string]any{
+				"count":     stat.Count,
+				"firstSeen": stat.FirstSeen.UTC().Format(time.RFC3339),
+			},
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.storeURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", s.auth)
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("sentry returned %s", resp.Status)
+		}
+	}
+	return nil
+}
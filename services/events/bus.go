@@ -0,0 +1,257 @@
+// Package events is an in-process event bus. Services publish domain events to typed topics and other services
+// subscribe to them, so a service can react to another's events without importing it. Events can also be forwarded
+// to an external bus such as NATS or Kafka.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBuffer is the number of events queued per subscriber before its overflow policy applies
+	DefaultBuffer = 256
+
+	// forwardTimeout bounds how long forwarding one event to the external bus may take
+	forwardTimeout = 5 * time.Second
+)
+
+// Topic is a named stream of events of type T
+type Topic[T any] struct {
+	name string
+}
+
+// NewTopic creates a topic; its name is the subject events are forwarded under
+func NewTopic[T any](name string) Topic[T] {
+	return Topic[T]{name: name}
+}
+
+// Name returns the topic's name
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// Overflow is what happens to an event published while a subscriber's queue is full
+type Overflow int
+
+const (
+	// Block makes the publisher wait until the subscriber has room, or until the publisher's context is done.
+	// Use it for subscribers that must see every event.
+	Block Overflow = iota
+	// Drop discards the event for that subscriber. Use it for subscribers that can miss events, such as caches.
+	Drop
+)
+
+// Forwarder carries events to an external bus
+type Forwarder interface {
+	// Forward publishes an event's JSON payload under subject
+	Forward(ctx context.Context, subject string, payload []byte) error
+	// Close releases the forwarder's connection
+	Close() error
+}
+
+// forwarded is an event queued for the external bus
+type forwarded struct {
+	subject string
+	payload []byte
+}
+
+// Bus delivers published events to the subscribers of their topic. Each subscriber has its own queue and
+// goroutine, so a slow subscriber only holds up publishers when it asked to block them.
+type Bus struct {
+	subscribers map[string]map[*Subscription]bool
+	forward     chan forwarded // nil when events are not forwarded
+	forwarded   chan struct{}  // closed once forwarding stopped
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mu          sync.RWMutex
+}
+
+// Subscription is a subscriber's queue of events
+type Subscription struct {
+	bus      *Bus
+	topic    string
+	overflow Overflow
+	events   chan any      // never closed, as publishers may still hold the subscription
+	stop     chan struct{} // closed when the subscription closes, releasing blocked publishers
+	done     chan struct{} // closed once the handler has returned for the last time
+	once     sync.Once
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Bus{
+		subscribers: make(map[string]map[*Subscription]bool),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// SetForwarder, called once, forwards every event published from now on to an external bus as JSON, under the subject
+// prefix + topic name. Up to buffer events are queued; events published while the queue is full are dropped,
+// so an unreachable external bus never holds up publishers.
+func (b *Bus) SetForwarder(forwarder Forwarder, prefix string, buffer int) {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	queue := make(chan forwarded, buffer)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer forwarder.Close()
+		for event := range queue {
+			ctx, cancel := context.WithTimeout(context.Background(), forwardTimeout)
+			if err := forwarder.Forward(ctx, prefix+event.subject, event.payload); err != nil {
+				log.Printf("Failed to forward %s event: %v", event.subject, err)
+			}
+			cancel()
+		}
+	}()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.forward = queue
+	b.forwarded = done
+}
+
+// Subscribe calls handle with each event published to topic, in publish order, from a goroutine of its own.
+// Up to buffer events are queued before overflow applies. Handlers get a context that is cancelled when the bus
+// closes; they must not close their own subscription.
+func Subscribe[T any](b *Bus, topic Topic[T], buffer int, overflow Overflow, handle func(ctx context.Context, event T)) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	sub := &Subscription{
+		bus:      b,
+		topic:    topic.name,
+		overflow: overflow,
+		events:   make(chan any, buffer),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(sub.done)
+		for {
+			select {
+			case event := <-sub.events:
+				deliver(b.ctx, topic.name, handle, event.(T))
+			case <-sub.stop:
+				// Finish the events queued before the subscription closed
+				for {
+					select {
+					case event := <-sub.events:
+						deliver(b.ctx, topic.name, handle, event.(T))
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[topic.name] == nil {
+		b.subscribers[topic.name] = make(map[*Subscription]bool)
+	}
+	b.subscribers[topic.name][sub] = true
+	return sub
+}
+
+// deliver hands an event to a handler, so a handler that panics doesn't stop its subscription
+func deliver[T any](ctx context.Context, topic string, handle func(context.Context, T), event T) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Handler of %s events panicked: %v", topic, r)
+		}
+	}()
+	handle(ctx, event)
+}
+
+// Publish sends event to every subscriber of topic, and to the external bus when one is set. It returns ctx's
+// error if ctx is done while waiting for a blocking subscriber; subscribers already sent the event keep it.
+func Publish[T any](ctx context.Context, b *Bus, topic Topic[T], event T) error {
+	// Send without holding the lock, so handlers that publish can't deadlock with subscriptions closing
+	b.mu.RLock()
+	subs := make([]*Subscription, 0, len(b.subscribers[topic.name]))
+	for sub := range b.subscribers[topic.name] {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.overflow == Drop {
+			select {
+			case sub.events <- event:
+			default:
+				log.Printf("Subscriber of %s events is behind, dropping event", topic.name)
+			}
+			continue
+		}
+		select {
+		case sub.events <- event:
+		case <-sub.stop:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.forward != nil {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Failed to encode %s event for forwarding: %v", topic.name, err)
+			return nil
+		}
+		select {
+		case b.forward <- forwarded{subject: topic.name, payload: payload}:
+		default:
+			log.Printf("Event forwarding is behind, dropping %s event", topic.name)
+		}
+	}
+	return nil
+}
+
+// Close stops delivering events to the subscription and waits for the handler to finish the events already queued
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+
+		s.bus.mu.Lock()
+		delete(s.bus.subscribers[s.topic], s)
+		s.bus.mu.Unlock()
+	})
+	<-s.done
+}
+
+// Close cancels handlers' context, closes every subscription and stops forwarding once the queued events
+// have been forwarded
+func (b *Bus) Close() {
+	b.cancel()
+
+	b.mu.RLock()
+	var subs []*Subscription
+	for _, topic := range b.subscribers {
+		for sub := range topic {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.RUnlock()
+	for _, sub := range subs {
+		sub.Close()
+	}
+
+	b.mu.Lock()
+	forward, forwarded := b.forward, b.forwarded
+	b.forward = nil
+	b.mu.Unlock()
+	if forward != nil {
+		close(forward)
+		<-forwarded
+	}
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingForwarder keeps the events forwarded to it
+type recordingForwarder struct {
+	subjects []string
+	payloads [][]byte
+	closed   bool
+	mu       sync.Mutex
+}
+
+func (f *recordingForwarder) Forward(ctx context.Context, subject string, payload []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subjects = append(f.subjects, subject)
+	f.payloads = append(f.payloads, payload)
+	return nil
+}
+
+func (f *recordingForwarder) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+var testTopic = NewTopic[int]("test.numbers")
+
+func TestBusFanOut(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+
+	var first, second []int
+	var mu sync.Mutex
+	Subscribe(bus, testTopic, 0, Block, func(ctx context.Context, n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		first = append(first, n)
+	})
+	Subscribe(bus, testTopic, 0, Block, func(ctx context.Context, n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		second = append(second, n)
+		if n == 2 {
+			panic("handler bug")
+		}
+	})
+	// Events of other topics aren't delivered
+	Subscribe(bus, NewTopic[int]("test.other"), 0, Block, func(ctx context.Context, n int) {
+		t.Errorf("Unexpected event %d", n)
+	})
+
+	for n := 1; n <= 3; n++ {
+		if err := Publish(ctx, bus, testTopic, n); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// Closing waits for the queued events to be handled
+	bus.Close()
+
+	if len(first) != 3 || len(second) != 3 || first[2] != 3 || second[2] != 3 {
+		t.Errorf("Expected both subscribers to see 1, 2, 3 in order, got %v and %v", first, second)
+	}
+}
+
+func TestBusBackpressure(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+	defer bus.Close()
+
+	release := make(chan struct{})
+	handling := make(chan int, 10)
+	slow := func(ctx context.Context, n int) {
+		handling <- n
+		<-release
+	}
+
+	t.Run("Drop", func(t *testing.T) {
+		sub := Subscribe(bus, testTopic, 1, Drop, slow)
+		Publish(ctx, bus, testTopic, 1)
+		<-handling
+		// One event fits the queue; the next is dropped rather than blocking
+		Publish(ctx, bus, testTopic, 2)
+		Publish(ctx, bus, testTopic, 3)
+		close(release)
+		sub.Close()
+		if n := <-handling; n != 2 || len(handling) != 0 {
+			t.Errorf("Expected only event 2 to be queued, got %d and %d more", n, len(handling))
+		}
+		release = make(chan struct{})
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		sub := Subscribe(bus, testTopic, 1, Block, slow)
+		Publish(ctx, bus, testTopic, 1)
+		<-handling
+		Publish(ctx, bus, testTopic, 2)
+
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if err := Publish(timeout, bus, testTopic, 3); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the publisher to wait for the full queue, got %v", err)
+		}
+
+		// Closing the subscription releases blocked publishers
+		published := make(chan error)
+		go func() { published <- Publish(ctx, bus, testTopic, 4) }()
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+		}()
+		sub.Close()
+		if err := <-published; err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestBusForwarding(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+	forwarder := &recordingForwarder{}
+	bus.SetForwarder(forwarder, "infinity-dex.", 0)
+
+	if err := Publish(ctx, bus, DepositReceivedTopic, DepositReceived{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	bus.Close()
+
+	if len(forwarder.subjects) != 1 || forwarder.subjects[0] != "infinity-dex.deposit.received" || !forwarder.closed {
+		t.Fatalf("Expected one forwarded deposit and a closed forwarder, got %v", forwarder.subjects)
+	}
+	var event DepositReceived
+	if err := json.Unmarshal(forwarder.payloads[0], &event); err != nil {
+		t.Errorf("Expected a JSON payload, got %v", err)
+	}
+}
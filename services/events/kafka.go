@@ -0,0 +1,87 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// kafkaRESTContentType is the Kafka REST Proxy v2 content type of records with JSON values
+const kafkaRESTContentType = "application/vnd.kafka.json.v2+json"
+
+// KafkaRESTForwarder publishes events to Kafka through a Kafka REST Proxy, with one record per event
+type KafkaRESTForwarder struct {
+	client  *http.Client
+	baseURL string
+}
+
+// kafkaRecords is the body of a Kafka REST Proxy produce request
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaRecord is a record to produce
+type kafkaRecord struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaOffsets is the part of a produce response reporting each record's outcome
+type kafkaOffsets struct {
+	Offsets []struct {
+		Error *string `json:"error"` // Set when the record was not produced
+	} `json:"offsets"`
+}
+
+// NewKafkaRESTForwarder creates a forwarder to the Kafka REST Proxy at baseURL, e.g. http://localhost:8082
+func NewKafkaRESTForwarder(client *http.Client, baseURL string) *KafkaRESTForwarder {
+	return &KafkaRESTForwarder{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Forward produces payload to the topic named subject
+func (f *KafkaRESTForwarder) Forward(ctx context.Context, subject string, payload []byte) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Value: payload}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.baseURL+"/topics/"+url.PathEscape(subject), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaRESTContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka rest proxy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	// The proxy answers 200 even when a record was refused
+	var offsets kafkaOffsets
+	if err := json.NewDecoder(resp.Body).Decode(&offsets); err != nil {
+		return fmt.Errorf("invalid kafka rest proxy response: %w", err)
+	}
+	for _, offset := range offsets.Offsets {
+		if offset.Error != nil {
+			return fmt.Errorf("kafka refused the record: %s", *offset.Error)
+		}
+	}
+	return nil
+}
+
+// Close does nothing; the HTTP client keeps no connection of its own
+func (f *KafkaRESTForwarder) Close() error {
+	return nil
+}
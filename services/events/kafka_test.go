@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKafkaRESTForwarder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != kafkaRESTContentType || string(body) != `{"records":[{"value":{"requestId":"swap-1"}}]}` {
+			t.Errorf("Unexpected request %s: %s", r.Header.Get("Content-Type"), body)
+		}
+		switch r.URL.Path {
+		case "/topics/infinity-dex.swap.started":
+			w.Write([]byte(`{"offsets":[{"partition":0,"offset":7,"error_code":null,"error":null}]}`))
+		default:
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50002,"error":"topic not found"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	forwarder := NewKafkaRESTForwarder(server.Client(), server.URL+"/")
+	ctx := context.Background()
+	if err := forwarder.Forward(ctx, "infinity-dex.swap.started", []byte(`{"requestId":"swap-1"}`)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// Refused records fail even though the proxy answers 200
+	if err := forwarder.Forward(ctx, "infinity-dex.unknown", []byte(`{"requestId":"swap-1"}`)); err == nil {
+		t.Error("Expected the refused record to fail")
+	}
+}
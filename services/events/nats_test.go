@@ -0,0 +1,98 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATSServer accepts one connection, checks its CONNECT and sends each published message to messages
+func fakeNATSServer(t *testing.T, messages chan<- string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+		connect, _ := reader.ReadString('\n')
+		if !strings.Contains(connect, `"user":"dex"`) || !strings.Contains(connect, `"pass":"secret"`) {
+			fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+			return
+		}
+		reader.ReadString('\n') // PING
+		fmt.Fprint(conn, "PONG\r\n")
+		// Keepalives must be answered
+		fmt.Fprint(conn, "PING\r\n")
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			var subject string
+			var size int
+			if _, err := fmt.Sscanf(line, "PUB %s %d", &subject, &size); err != nil {
+				messages <- strings.TrimSpace(line)
+				continue
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			messages <- subject + " " + string(payload[:size])
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestNATSForwarder(t *testing.T) {
+	if _, err := NewNATSForwarder("http://localhost:4222"); err == nil {
+		t.Error("Expected a non-NATS URL to be refused")
+	}
+
+	messages := make(chan string, 10)
+	address := fakeNATSServer(t, messages)
+	forwarder, err := NewNATSForwarder("nats://dex:secret@" + address)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer forwarder.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := forwarder.Forward(ctx, "infinity-dex.swap.started", []byte(`{"requestId":"swap-1"}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := forwarder.Forward(ctx, "bad subject", nil); err == nil {
+		t.Error("Expected a subject with spaces to be refused")
+	}
+
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case message := <-messages:
+			got[message] = true
+		case <-ctx.Done():
+			t.Fatalf("Expected a PONG and the published event, got %v", got)
+		}
+	}
+	if !got["PONG"] || !got[`infinity-dex.swap.started {"requestId":"swap-1"}`] {
+		t.Errorf("Unexpected messages %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// SwapStarted is published when the API server starts a swap
+type SwapStarted struct {
+	RequestID        string      `json:"requestId"`
+	SourceToken      types.Token `json:"sourceToken"`
+	DestinationToken types.Token `json:"destinationToken"`
+	Amount           *big.Int    `json:"amount"`
+	Tenant           string      `json:"tenant,omitempty"`
+	Sandbox          bool        `json:"sandbox,omitempty"`
+	StartedAt        time.Time   `json:"startedAt"`
+}
+
+// TokenWrapped is published when a swap's source tokens have been wrapped into Universal tokens on the source chain
+type TokenWrapped struct {
+	RequestID string      `json:"requestId"`
+	Token     types.Token `json:"token"`
+	Amount    *big.Int    `json:"amount"`
+	TxHash    string      `json:"txHash"`
+	WrappedAt time.Time   `json:"wrappedAt"`
+}
+
+// TransferCompleted is published when a swap's output has been delivered to its destination address
+type TransferCompleted struct {
+	RequestID          string      `json:"requestId"`
+	Token              types.Token `json:"token"`
+	Amount             *big.Int    `json:"amount"`
+	DestinationAddress string      `json:"destinationAddress"`
+	TxHash             string      `json:"txHash"`
+	CompletedAt        time.Time   `json:"completedAt"`
+}
+
+// SwapCompleted is published when a swap finishes successfully
+type SwapCompleted struct {
+	RequestID        string      `json:"requestId"`
+	SourceToken      types.Token `json:"sourceToken"`
+	DestinationToken types.Token `json:"destinationToken"`
+	InputAmount      *big.Int    `json:"inputAmount"`
+	OutputAmount     *big.Int    `json:"outputAmount"`
+	Fee              types.Fee   `json:"fee"`
+	CompletedAt      time.Time   `json:"completedAt"`
+}
+
+// SwapFailed is published when a swap finishes without completing, including swaps that were cancelled, denied or
+// never confirmed
+type SwapFailed struct {
+	RequestID        string      `json:"requestId"`
+	SourceToken      types.Token `json:"sourceToken"`
+	DestinationToken types.Token `json:"destinationToken"`
+	Amount           *big.Int    `json:"amount"`
+	ErrorCode        string      `json:"errorCode,omitempty"`
+	ErrorMessage     string      `json:"errorMessage,omitempty"`
+	FailedAt         time.Time   `json:"failedAt"`
+}
+
+// DepositReceived is published when the deposit funding a swap arrives
+type DepositReceived struct {
+	Deposit  types.Deposit         `json:"deposit"`
+	Expected types.ExpectedDeposit `json:"expected"`
+}
+
+// SwapDeadLettered is published when the swap workers' housekeeping scan finds a swap stuck or failed and records it
+// for operators to requeue or refund
+type SwapDeadLettered struct {
+	DeadLetter types.DeadLetter `json:"deadLetter"`
+}
+
+var (
+	// SwapStartedTopic carries every swap the API server starts
+	SwapStartedTopic = NewTopic[SwapStarted]("swap.started")
+
+	// TokenWrappedTopic carries the wrap of every swap's source tokens, published by the swap workers
+	TokenWrappedTopic = NewTopic[TokenWrapped]("swap.token_wrapped")
+
+	// TransferCompletedTopic carries the delivery of every swap's output, published by the swap workers
+	TransferCompletedTopic = NewTopic[TransferCompleted]("swap.transfer_completed")
+
+	// SwapCompletedTopic carries every swap that completed, published by the swap workers
+	SwapCompletedTopic = NewTopic[SwapCompleted]("swap.completed")
+
+	// SwapFailedTopic carries every swap that failed, published by the swap workers
+	SwapFailedTopic = NewTopic[SwapFailed]("swap.failed")
+
+	// SwapDeadLetteredTopic carries every swap dead-lettered by the swap workers
+	SwapDeadLetteredTopic = NewTopic[SwapDeadLettered]("swap.dead_lettered")
+
+	// DepositReceivedTopic carries every deposit that funded a swap
+	DepositReceivedTopic = NewTopic[DepositReceived]("deposit.received")
+)
@@ -0,0 +1,683 @@
+// Package expr implements the small expression language operators write alert conditions, circuit breaker rules
+// and fee overrides in.
+//
+// Expressions work on numbers, strings, booleans and lists, for example:
+//
+//	priceUSD < 0.98 && symbol in ["USDC", "USDT"]
+//	crossChain ? 1.5 : max(0.5, 1 - amountUSD / 1000000)
+//
+// Operators, from lowest to highest precedence: ?: (conditional), ||, &&, == !=, < <= > >= in, + -, * / %,
+// and the unary ! and -. + also concatenates strings. Variables are looked up by name; nested values are read
+// with dots, e.g. source.symbol. The functions min, max and abs take numbers.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrType is returned when an operator or function is applied to values of the wrong type
+var ErrType = errors.New("type mismatch")
+
+// maxNesting is how deeply parentheses, conditionals, lists, calls and unary operators may nest
+const maxNesting = 32
+
+// Expression is a compiled expression
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses an expression
+func Compile(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the expression's source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate evaluates the expression with the given variables. Results are float64, string, bool or []interface{};
+// integer variables are read as float64.
+func (e *Expression) Evaluate(vars map[string]interface{}) (interface{}, error) {
+	return e.root.eval(vars)
+}
+
+// Bool evaluates an expression that must return a boolean
+func (e *Expression) Bool(vars map[string]interface{}) (bool, error) {
+	value, err := e.Evaluate(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: %q returned %T, not a boolean", ErrType, e.source, value)
+	}
+	return b, nil
+}
+
+// Number evaluates an expression that must return a number
+func (e *Expression) Number(vars map[string]interface{}) (float64, error) {
+	value, err := e.Evaluate(vars)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q returned %T, not a number", ErrType, e.source, value)
+	}
+	return n, nil
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators lists the operators and punctuation, two-character ones first so they win over their prefixes
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", "(", ")", "[", "]", ",", "."}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(source) && (unicode.IsDigit(rune(source[i])) || source[i] == '.' || source[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], pos: start})
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(source) && (source[i] == '_' || unicode.IsLetter(rune(source[i])) || unicode.IsDigit(rune(source[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			for i++; i < len(source) && rune(source[i]) != c; i++ {
+				if source[i] == '\\' && i+1 < len(source) {
+					i++
+				}
+				b.WriteByte(source[i])
+			}
+			if i >= len(source) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// Parser
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int // current nesting, up to maxNesting
+}
+
+// enter descends one nesting level, failing past maxNesting; leave returns from it
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxNesting {
+		return fmt.Errorf("expression nested deeper than %d levels at offset %d", maxNesting, p.peek().pos)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the operators or keywords
+func (p *parser) accept(texts ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOperator && tok.kind != tokenIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if tok.text == text {
+			p.next()
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		tok := p.peek()
+		return fmt.Errorf("expected %q at offset %d, found %q", text, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) expression() (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	then, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return conditionalNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// precedence lists the binary operators from lowest to highest precedence
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: op, operand: operand}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("."); !ok {
+			return n, nil
+		}
+		tok := p.next()
+		if tok.kind != tokenIdent {
+			return nil, fmt.Errorf("expected a field name at offset %d", tok.pos)
+		}
+		n = fieldNode{object: n, field: tok.text}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(strings.ReplaceAll(tok.text, "_", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", tok.text, tok.pos)
+		}
+		return literalNode{value: value}, nil
+	case tokenString:
+		return literalNode{value: tok.text}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			fn, ok := functions[tok.text]
+			if !ok {
+				return nil, fmt.Errorf("unknown function %q at offset %d", tok.text, tok.pos)
+			}
+			args, err := p.list(")")
+			if err != nil {
+				return nil, err
+			}
+			return callNode{name: tok.text, fn: fn, args: args}, nil
+		}
+		return variableNode{name: tok.text}, nil
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			n, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			items, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+			return listNode{items: items}, nil
+		}
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+// list parses comma-separated expressions up to the closing token
+func (p *parser) list(closing string) ([]node, error) {
+	var items []node
+	if _, ok := p.accept(closing); ok {
+		return items, nil
+	}
+	for {
+		item, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if _, ok := p.accept(","); !ok {
+			return items, p.expect(closing)
+		}
+	}
+}
+
+// Evaluation
+
+type node interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n variableNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undefined variable %q", n.name)
+	}
+	return normalize(value), nil
+}
+
+type fieldNode struct {
+	object node
+	field  string
+}
+
+func (n fieldNode) eval(vars map[string]interface{}) (interface{}, error) {
+	object, err := n.object.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot read field %q of %T", ErrType, n.field, object)
+	}
+	value, ok := fields[n.field]
+	if !ok {
+		return nil, fmt.Errorf("undefined field %q", n.field)
+	}
+	return normalize(value), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n listNode) eval(vars map[string]interface{}) (interface{}, error) {
+	list := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = value
+	}
+	return list, nil
+}
+
+type conditionalNode struct {
+	cond, then, otherwise node
+}
+
+func (n conditionalNode) eval(vars map[string]interface{}) (interface{}, error) {
+	cond, err := evalBool(n.cond, vars)
+	if err != nil {
+		return nil, err
+	}
+	if cond {
+		return n.then.eval(vars)
+	}
+	return n.otherwise.eval(vars)
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n unaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	if n.op == "!" {
+		value, err := evalBool(n.operand, vars)
+		return !value, err
+	}
+	value, err := evalNumber(n.operand, vars)
+	return -value, err
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	// Logical operators short-circuit
+	switch n.op {
+	case "&&", "||":
+		left, err := evalBool(n.left, vars)
+		if err != nil || left == (n.op == "||") {
+			return left, err
+		}
+		return evalBool(n.right, vars)
+	}
+
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		list, ok := right.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: in needs a list, got %T", ErrType, right)
+		}
+		for _, item := range list {
+			if equal(left, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if ls, ok := left.(string); ok {
+		rs, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s on string and %T", ErrType, n.op, right)
+		}
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+		return nil, fmt.Errorf("%w: %s on strings", ErrType, n.op)
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%w: %s on %T and %T", ErrType, n.op, left, right)
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+type callNode struct {
+	name string
+	fn   func(args []float64) (float64, error)
+	args []node
+}
+
+func (n callNode) eval(vars map[string]interface{}) (interface{}, error) {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := evalNumber(arg, vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	value, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return value, nil
+}
+
+// functions are the functions expressions can call
+var functions = map[string]func(args []float64) (float64, error){
+	"min": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("needs at least one argument")
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result, nil
+	},
+	"max": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("needs at least one argument")
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result, nil
+	},
+	"abs": func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("needs one argument")
+		}
+		return math.Abs(args[0]), nil
+	},
+}
+
+func evalBool(n node, vars map[string]interface{}) (bool, error) {
+	value, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: expected a boolean, got %T", ErrType, value)
+	}
+	return b, nil
+}
+
+func evalNumber(n node, vars map[string]interface{}) (float64, error) {
+	value, err := n.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	f, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%w: expected a number, got %T", ErrType, value)
+	}
+	return f, nil
+}
+
+// equal compares two values; values of different types are never equal
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		return false
+	}
+	return a == b
+}
+
+// normalize converts Go values from variables into the types expressions work with
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	}
+	return value
+}
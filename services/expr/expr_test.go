@@ -0,0 +1,144 @@
+package expr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	vars := map[string]interface{}{
+		"symbol":     "USDC",
+		"priceUSD":   0.97,
+		"amountUSD":  250000,
+		"crossChain": true,
+		"chainId":    int64(137),
+		"source":     map[string]interface{}{"symbol": "ETH", "chainId": int64(1)},
+		"tags":       []string{"stable", "bridged"},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   interface{}
+	}{
+		{"Arithmetic", "1 + 2 * 3 - 4 / 2", 5.0},
+		{"Parentheses", "(1 + 2) * 3", 9.0},
+		{"Modulo", "10 % 4", 2.0},
+		{"UnaryMinus", "-priceUSD + 1", 1 - 0.97},
+		{"Underscores", "1_000_000", 1e6},
+		{"Comparison", "priceUSD < 0.98", true},
+		{"IntegerVariable", "chainId == 137", true},
+		{"StringEquality", `symbol == "USDC"`, true},
+		{"SingleQuotes", `symbol != 'USDT'`, true},
+		{"StringConcat", `symbol + "/USD"`, "USDC/USD"},
+		{"In", `symbol in ["USDC", "USDT"]`, true},
+		{"NotIn", `!(symbol in ["DAI"])`, true},
+		{"InVariableList", `"stable" in tags`, true},
+		{"Field", `source.symbol == "ETH" && source.chainId == 1`, true},
+		{"And", "crossChain && amountUSD > 100000", true},
+		{"Or", "false || priceUSD > 1", false},
+		{"Conditional", "crossChain ? 1.5 : 1", 1.5},
+		{"NestedConditional", "amountUSD > 1000000 ? 0.25 : amountUSD > 100000 ? 0.5 : 1", 0.5},
+		{"Functions", "max(0.5, 1 - amountUSD / 1000000) + abs(-1) + min(3, 2)", 3.75},
+		{"ShortCircuitSkipsUndefined", "false && missing > 1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.expression)
+			if err != nil {
+				t.Fatalf("Failed to compile %q: %v", tt.expression, err)
+			}
+			got, err := e.Evaluate(vars)
+			if err != nil {
+				t.Fatalf("Failed to evaluate %q: %v", tt.expression, err)
+			}
+			if f, ok := tt.expected.(float64); ok {
+				g, ok := got.(float64)
+				if !ok || g-f > 1e-9 || f-g > 1e-9 {
+					t.Errorf("Expected %v, got %v", tt.expected, got)
+				}
+				return
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"a ? b",
+		`"unterminated`,
+		"1 # 2",
+		"unknown(1)",
+		"[1, 2",
+		"a.1",
+		"1 2",
+	} {
+		if _, err := Compile(expression); err == nil {
+			t.Errorf("Expected %q not to compile", expression)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	vars := map[string]interface{}{"price": 1.0, "symbol": "ETH"}
+
+	t.Run("UndefinedVariable", func(t *testing.T) {
+		e, _ := Compile("missing > 1")
+		if _, err := e.Evaluate(vars); err == nil {
+			t.Error("Expected an error for an undefined variable")
+		}
+	})
+
+	t.Run("TypeMismatch", func(t *testing.T) {
+		for _, expression := range []string{"symbol > 1", "price && true", `price in "ETH"`, "symbol.name", `-symbol`} {
+			e, err := Compile(expression)
+			if err != nil {
+				t.Fatalf("Failed to compile %q: %v", expression, err)
+			}
+			if _, err := e.Evaluate(vars); !errors.Is(err, ErrType) {
+				t.Errorf("Expected a type error for %q, got %v", expression, err)
+			}
+		}
+	})
+
+	t.Run("DivisionByZero", func(t *testing.T) {
+		e, _ := Compile("price / 0")
+		if _, err := e.Evaluate(vars); err == nil {
+			t.Error("Expected a division by zero error")
+		}
+	})
+
+	t.Run("ResultType", func(t *testing.T) {
+		e, _ := Compile("price + 1")
+		if _, err := e.Bool(vars); !errors.Is(err, ErrType) {
+			t.Errorf("Expected a type error for a non-boolean condition, got %v", err)
+		}
+		e, _ = Compile("price > 0")
+		if _, err := e.Number(vars); !errors.Is(err, ErrType) {
+			t.Errorf("Expected a type error for a non-numeric result, got %v", err)
+		}
+	})
+}
+
+func TestCompileNestingLimit(t *testing.T) {
+	if _, err := Compile(strings.Repeat("(", 10) + "1" + strings.Repeat(")", 10)); err != nil {
+		t.Errorf("Expected moderate nesting to compile: %v", err)
+	}
+	for _, expression := range []string{
+		strings.Repeat("(", 1000) + "1" + strings.Repeat(")", 1000),
+		strings.Repeat("[", 1000) + strings.Repeat("]", 1000),
+		strings.Repeat("!", 1000) + "true",
+		strings.Repeat("true ? ", 1000) + "1" + strings.Repeat(" : 0", 1000),
+	} {
+		if _, err := Compile(expression); err == nil {
+			t.Errorf("Expected %.20q... to be rejected as too deeply nested", expression)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// Gas each step of a swap uses on its chain
+const (
+	wrapGas     uint64 = 120_000
+	transferGas uint64 = 90_000
+	swapGas     uint64 = 180_000
+	unwrapGas   uint64 = 100_000
+)
+
+// gasTokenDecimals is the decimals of the native tokens EVM chains charge gas in
+const gasTokenDecimals = 18
+
+// ChainGasSource returns the live gas readings of a chain
+type ChainGasSource interface {
+	GetChainStatus(ctx context.Context, chainID int64) (*types.ChainStatus, error)
+}
+
+// GasEstimator prices a swap's gas from each involved chain's live gas readings
+type GasEstimator struct {
+	chains     ChainGasSource
+	prices     *PriceStalenessPolicy
+	multiplier float64
+}
+
+// NewGasEstimator creates a gas estimator converting gas costs with prices. Estimates are multiplied by multiplier
+// as a safety margin for fees rising before the swap lands; multipliers below 1 are treated as 1.
+func NewGasEstimator(chains ChainGasSource, prices *PriceStalenessPolicy, multiplier float64) *GasEstimator {
+	return &GasEstimator{
+		chains:     chains,
+		prices:     prices,
+		multiplier: math.Max(multiplier, 1),
+	}
+}
+
+// SwapGasUnits returns the gas a swap uses on each chain it touches. The source token is wrapped on its chain.
+// Same-chain swaps then swap and unwrap there; cross-chain swaps transfer the wrapped token and swap and unwrap on
+// the destination chain. Already wrapped tokens skip their wrap or unwrap.
+func SwapGasUnits(request types.SwapRequest) map[int64]uint64 {
+	source := types.CanonicalChainID(request.SourceToken.ChainID)
+	destination := types.CanonicalChainID(request.DestinationToken.ChainID)
+
+	units := make(map[int64]uint64)
+	if !request.SourceToken.IsWrapped {
+		units[source] += wrapGas
+	}
+	if source != destination {
+		units[source] += transferGas
+	}
+	units[destination] += swapGas
+	if !request.DestinationToken.IsWrapped {
+		units[destination] += unwrapGas
+	}
+	return units
+}
+
+// GasFee returns the gas a swap costs across its chains, in base units of the swap's source token. The destination
+// chain's gas of a swap asking for sponsorship is left out, since the protocol pays it. A native source token is
+// its chain's gas token, so that chain's gas is charged in it as it is, without converting through USD.
+func (e *GasEstimator) GasFee(ctx context.Context, request types.SwapRequest) (*big.Int, error) {
+	units := SwapGasUnits(request)
+	if request.SponsorGas {
+		delete(units, types.CanonicalChainID(request.DestinationToken.ChainID))
+	}
+
+	source := types.CanonicalChainID(request.SourceToken.ChainID)
+	native := big.NewInt(0)
+	var totalUSD float64
+	for chainID, units := range units {
+		gasCost, costUSD, err := e.gasCost(ctx, chainID, units)
+		if err != nil {
+			return nil, err
+		}
+		if chainID == source && request.SourceToken.IsNative() {
+			native.Add(native, gasCost)
+			continue
+		}
+		totalUSD += costUSD
+	}
+	if totalUSD == 0 {
+		return native, nil
+	}
+
+	sourcePrice, err := e.prices.Price(ctx, request.SourceToken)
+	if err != nil {
+		return nil, err
+	}
+	result := usdAmount(totalUSD, request.SourceToken, sourcePrice.PriceUSD)
+	return result.Add(result, native), nil
+}
+
+// gasCost values gas units on a chain at its current fees, including the safety multiplier, in base units of the
+// chain's native token and in USD
+func (e *GasEstimator) gasCost(ctx context.Context, chainID int64, units uint64) (*big.Int, float64, error) {
+	chain, ok := types.GetChain(chainID)
+	if !ok || chain.Namespace != "eip155" {
+		return nil, 0, fmt.Errorf("no gas readings for chain %d", chainID)
+	}
+	status, err := e.chains.GetChainStatus(ctx, chainID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// EIP-1559 chains charge the base fee plus the priority fee; others charge the legacy gas price
+	perGas := status.GasPrice
+	if status.BaseFee != nil && status.PriorityFee != nil {
+		perGas = new(big.Int).Add(status.BaseFee, status.PriorityFee)
+	}
+	if perGas == nil {
+		return nil, 0, fmt.Errorf("%w for chain %d", ErrGasPriceUnavailable, chainID)
+	}
+
+	gasTokenPrice, err := e.prices.Price(ctx, types.Token{Symbol: chain.GasToken, ChainID: chainID})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cost := types.NewAmount(new(big.Int).Mul(perGas, new(big.Int).SetUint64(units)), gasTokenDecimals).MulFloat(e.multiplier, types.RoundUp)
+	return cost.Value, cost.Float64() * gasTokenPrice.PriceUSD, nil
+}
+
+// usdAmount returns the base units of token worth usd at its USD price, rounded to the nearest base unit: the USD value
+// is already a float estimate, so rounding it up would only charge for its error
+func usdAmount(usd float64, token types.Token, price float64) *big.Int {
+	if price == 0 {
+		return new(big.Int)
+	}
+	whole := new(big.Rat).Quo(types.RatFromFloat(usd), types.RatFromFloat(price))
+	return types.AmountFromRat(whole, token.Decimals, types.RoundHalfUp).Value
+}
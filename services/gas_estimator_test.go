@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestSwapGasUnits(t *testing.T) {
+	eth := types.Token{Symbol: "ETH", ChainID: types.ChainIDEthereum}
+	uUSDC := types.Token{Symbol: "uUSDC", ChainID: types.ChainIDEthereum, IsWrapped: true}
+	matic := types.Token{Symbol: "MATIC", ChainID: types.ChainIDPolygon}
+
+	t.Run("SameChain", func(t *testing.T) {
+		units := SwapGasUnits(types.SwapRequest{SourceToken: eth, DestinationToken: uUSDC})
+		if len(units) != 1 || units[types.ChainIDEthereum] != wrapGas+swapGas {
+			t.Errorf("Expected a wrap and a swap on Ethereum, got %v", units)
+		}
+	})
+
+	t.Run("CrossChain", func(t *testing.T) {
+		units := SwapGasUnits(types.SwapRequest{SourceToken: eth, DestinationToken: matic})
+		if units[types.ChainIDEthereum] != wrapGas+transferGas {
+			t.Errorf("Expected a wrap and a transfer on Ethereum, got %d", units[types.ChainIDEthereum])
+		}
+		if units[types.ChainIDPolygon] != swapGas+unwrapGas {
+			t.Errorf("Expected a swap and an unwrap on Polygon, got %d", units[types.ChainIDPolygon])
+		}
+	})
+}
+
+func TestGasEstimator(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	policy := NewPriceStalenessPolicy(fakeOracle{
+		"ETH":   {Symbol: "ETH", PriceUSD: 2000, LastUpdated: now},
+		"MATIC": {Symbol: "MATIC", PriceUSD: 0.5, LastUpdated: now},
+		"USDC":  {Symbol: "USDC", PriceUSD: 1, LastUpdated: now},
+	}, time.Minute)
+
+	chains := NewChainService()
+	for _, chain := range []types.ChainStatus{
+		{Name: "ethereum", ChainID: types.ChainIDEthereum, IsActive: true},
+		{Name: "polygon", ChainID: types.ChainIDPolygon, IsActive: true},
+		{Name: "solana", ChainID: types.ChainIDSolana, IsActive: true},
+	} {
+		chains.AddChain(chain)
+	}
+
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: types.ChainIDEthereum},
+		DestinationToken: types.Token{Symbol: "MATIC", Decimals: 18, ChainID: types.ChainIDPolygon},
+		Amount:           big.NewInt(1_000_000_000_000_000_000),
+	}
+
+	t.Run("MissingReadings", func(t *testing.T) {
+		_, err := NewGasEstimator(chains, policy, 1).GasFee(ctx, request)
+		if !errors.Is(err, ErrGasPriceUnavailable) {
+			t.Errorf("Expected ErrGasPriceUnavailable, got %v", err)
+		}
+	})
+
+	// Ethereum charges 20 gwei base + 1 gwei priority; Polygon only reports a 100 gwei legacy gas price
+	chains.update(types.ChainIDEthereum, func(chain *types.ChainStatus) {
+		chain.GasPrice = big.NewInt(25_000_000_000)
+		chain.BaseFee = big.NewInt(20_000_000_000)
+		chain.PriorityFee = big.NewInt(1_000_000_000)
+	})
+	chains.UpdateGasPrice(types.ChainIDPolygon, big.NewInt(100_000_000_000))
+
+	// 210k gas at 21 gwei is 0.00441 ETH ($8.82); 280k gas at 100 gwei is 0.028 MATIC ($0.014)
+	tests := []struct {
+		name       string
+		multiplier float64
+		expected   float64 // ETH
+	}{
+		{"LiveFees", 1, 0.004417},
+		{"SafetyMultiplier", 1.5, 0.0066255},
+		{"MultiplierBelowOne", 0.5, 0.004417},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gasFee, err := NewGasEstimator(chains, policy, tt.multiplier).GasFee(ctx, request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, _ := new(big.Float).Quo(new(big.Float).SetInt(gasFee), big.NewFloat(1e18)).Float64()
+			if got < tt.expected*0.999 || got > tt.expected*1.001 {
+				t.Errorf("Expected %f ETH of gas, got %f", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("NativeSource", func(t *testing.T) {
+		// Native ETH pays Ethereum's gas as it is: 400k gas at 21 gwei
+		native := request
+		native.SourceToken.Address = types.NativeTokenAddress
+		native.DestinationToken = types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDEthereum}
+		gasFee, err := NewGasEstimator(chains, policy, 1).GasFee(ctx, native)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected := big.NewInt(400_000 * 21_000_000_000); gasFee.Cmp(expected) != 0 {
+			t.Errorf("Expected %s wei of gas, got %s", expected, gasFee)
+		}
+	})
+
+	t.Run("NonEVMChain", func(t *testing.T) {
+		solana := request
+		solana.DestinationToken = types.Token{Symbol: "SOL", Decimals: 9, ChainID: types.ChainIDSolana}
+		if _, err := NewGasEstimator(chains, policy, 1).GasFee(ctx, solana); err == nil {
+			t.Error("Expected an error for a chain without gas readings")
+		}
+	})
+
+	t.Run("Quote", func(t *testing.T) {
+		service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+		service.SetPricePolicy(policy)
+		estimator := NewGasEstimator(chains, policy, 1)
+		service.SetGasEstimator(estimator)
+
+		quote, err := service.GetSwapQuote(ctx, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected, _ := estimator.GasFee(ctx, request)
+		if quote.Fee.GasFee.Cmp(expected) != 0 {
+			t.Errorf("Expected the quote's gas fee to be %s, got %s", expected, quote.Fee.GasFee)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// gasSponsorWindow is the period a chain's daily sponsorship cap covers
+const gasSponsorWindow = 24 * time.Hour
+
+// GasSponsorshipStore persists the gas sponsored for swaps, so every API server and swap worker books sponsorships
+// against the same caps
+type GasSponsorshipStore interface {
+	// RecordSponsorship stores a swap's sponsorship, unless the USD cost of its chain's sponsorships since since
+	// would then exceed capUSD, when it returns types.ErrGasSponsorCapReached; a zero capUSD is unlimited. A swap's
+	// sponsorship is stored once, and recording it again changes nothing.
+	RecordSponsorship(ctx context.Context, sponsorship types.GasSponsorship, since time.Time, capUSD float64) error
+	// SponsoredSince returns the USD cost of a chain's sponsorships since since
+	SponsoredSince(ctx context.Context, chainID int64, since time.Time) (float64, error)
+}
+
+// InMemoryGasSponsorshipStore is a GasSponsorshipStore for running without a database
+type InMemoryGasSponsorshipStore struct {
+	sponsorships map[string]types.GasSponsorship // map[requestID]sponsorship
+	mu           sync.Mutex
+}
+
+// NewInMemoryGasSponsorshipStore creates an empty store
+func NewInMemoryGasSponsorshipStore() *InMemoryGasSponsorshipStore {
+	return &InMemoryGasSponsorshipStore{sponsorships: make(map[string]types.GasSponsorship)}
+}
+
+// RecordSponsorship stores a swap's sponsorship unless it would take its chain over capUSD
+func (s *InMemoryGasSponsorshipStore) RecordSponsorship(ctx context.Context, sponsorship types.GasSponsorship, since time.Time, capUSD float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sponsorships[sponsorship.RequestID]; ok {
+		return nil
+	}
+	if capUSD > 0 && s.sponsoredSince(sponsorship.ChainID, since)+sponsorship.CostUSD > capUSD {
+		return types.ErrGasSponsorCapReached
+	}
+	s.sponsorships[sponsorship.RequestID] = sponsorship
+	return nil
+}
+
+// SponsoredSince returns the USD cost of a chain's sponsorships since since
+func (s *InMemoryGasSponsorshipStore) SponsoredSince(ctx context.Context, chainID int64, since time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sponsoredSince(chainID, since), nil
+}
+
+func (s *InMemoryGasSponsorshipStore) sponsoredSince(chainID int64, since time.Time) float64 {
+	var total float64
+	for _, sponsorship := range s.sponsorships {
+		if sponsorship.ChainID == chainID && !sponsorship.CreatedAt.Before(since) {
+			total += sponsorship.CostUSD
+		}
+	}
+	return total
+}
+
+// GasSponsor pays the destination chain gas of swaps asking it to, on the chains it has a policy for, and recoups it
+// from their output. Gas is priced like the gas estimator's, and sponsorships are kept in memory until SetStore is
+// called.
+type GasSponsor struct {
+	estimator *GasEstimator
+	policies  map[int64]types.GasSponsorPolicy
+	store     GasSponsorshipStore
+	now       func() time.Time
+}
+
+// NewGasSponsor creates a sponsor pricing gas with estimator, sponsoring gas on the chains policies has a policy for
+func NewGasSponsor(estimator *GasEstimator, policies map[int64]types.GasSponsorPolicy) *GasSponsor {
+	return &GasSponsor{
+		estimator: estimator,
+		policies:  policies,
+		store:     NewInMemoryGasSponsorshipStore(),
+		now:       time.Now,
+	}
+}
+
+// SetStore books sponsorships in store
+func (g *GasSponsor) SetStore(store GasSponsorshipStore) {
+	g.store = store
+}
+
+// Quote prices the gas the protocol would pay for a swap on its destination chain, refusing swaps its chain's policy
+// doesn't cover with types.ErrGasSponsorshipUnavailable: chains without a policy, swaps whose gas costs more than the
+// chain sponsors per swap, and swaps the chain's remaining daily budget can't cover
+func (g *GasSponsor) Quote(ctx context.Context, request types.SwapRequest) (*types.GasSponsorship, error) {
+	chainID := types.CanonicalChainID(request.DestinationToken.ChainID)
+	policy, ok := g.policies[chainID]
+	if !ok {
+		return nil, fmt.Errorf("%w on chain %d", types.ErrGasSponsorshipUnavailable, chainID)
+	}
+
+	units := SwapGasUnits(request)[chainID]
+	gasCost, costUSD, err := g.estimator.gasCost(ctx, chainID, units)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to price gas: %v", types.ErrGasSponsorshipUnavailable, err)
+	}
+	if policy.MaxSwapUSD > 0 && costUSD > policy.MaxSwapUSD {
+		return nil, fmt.Errorf("%w: the swap's gas costs $%.2f, more than the $%.2f sponsored per swap", types.ErrGasSponsorshipUnavailable, costUSD, policy.MaxSwapUSD)
+	}
+	now := g.now()
+	if policy.DailyCapUSD > 0 {
+		sponsored, err := g.store.SponsoredSince(ctx, chainID, now.Add(-gasSponsorWindow))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read sponsored gas: %v", types.ErrGasSponsorshipUnavailable, err)
+		}
+		if sponsored+costUSD > policy.DailyCapUSD {
+			return nil, types.ErrGasSponsorCapReached
+		}
+	}
+
+	// Recoup the gas in the source token, like the swap's other fees
+	price, err := g.estimator.prices.Price(ctx, request.SourceToken)
+	if err != nil {
+		return nil, err
+	}
+	amount := usdAmount(costUSD, request.SourceToken, price.PriceUSD)
+
+	return &types.GasSponsorship{
+		RequestID: request.RequestID,
+		ChainID:   chainID,
+		GasUnits:  units,
+		GasCost:   gasCost,
+		CostUSD:   costUSD,
+		Token:     request.SourceToken.Symbol,
+		Recouped:  amount,
+		CreatedAt: now,
+	}, nil
+}
+
+// Record books a swap's sponsorship against its chain's daily cap, failing with types.ErrGasSponsorCapReached when
+// sponsorships booked since it was quoted used up the cap. Recording a swap's sponsorship again changes nothing.
+func (g *GasSponsor) Record(ctx context.Context, sponsorship types.GasSponsorship) error {
+	policy := g.policies[sponsorship.ChainID]
+	return g.store.RecordSponsorship(ctx, sponsorship, g.now().Add(-gasSponsorWindow), policy.DailyCapUSD)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestGasSponsor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	policy := NewPriceStalenessPolicy(fakeOracle{
+		"ETH":   {Symbol: "ETH", PriceUSD: 2000, LastUpdated: now},
+		"MATIC": {Symbol: "MATIC", PriceUSD: 0.5, LastUpdated: now},
+	}, time.Minute)
+
+	chains := NewChainService()
+	for _, chain := range []types.ChainStatus{
+		{Name: "ethereum", ChainID: types.ChainIDEthereum, IsActive: true},
+		{Name: "polygon", ChainID: types.ChainIDPolygon, IsActive: true},
+	} {
+		chains.AddChain(chain)
+	}
+	chains.UpdateGasPrice(types.ChainIDEthereum, big.NewInt(20_000_000_000))
+	chains.UpdateGasPrice(types.ChainIDPolygon, big.NewInt(100_000_000_000))
+	estimator := NewGasEstimator(chains, policy, 1)
+
+	request := types.SwapRequest{
+		RequestID:        "swap-1",
+		SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: types.ChainIDEthereum},
+		DestinationToken: types.Token{Symbol: "MATIC", Decimals: 18, ChainID: types.ChainIDPolygon},
+		Amount:           big.NewInt(1_000_000_000_000_000_000),
+		SponsorGas:       true,
+	}
+
+	t.Run("Quote", func(t *testing.T) {
+		sponsor := NewGasSponsor(estimator, map[int64]types.GasSponsorPolicy{types.ChainIDPolygon: {}})
+
+		// 280k gas at 100 gwei is 0.028 MATIC ($0.014), recouped as 0.000007 ETH
+		sponsorship, err := sponsor.Quote(ctx, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sponsorship.ChainID != types.ChainIDPolygon || sponsorship.GasUnits != 280_000 {
+			t.Errorf("Expected 280000 gas units on Polygon, got %d on chain %d", sponsorship.GasUnits, sponsorship.ChainID)
+		}
+		if sponsorship.GasCost.Cmp(big.NewInt(28_000_000_000_000_000)) != 0 {
+			t.Errorf("Expected a gas cost of 0.028 MATIC, got %s", sponsorship.GasCost)
+		}
+		recouped, _ := new(big.Float).Quo(new(big.Float).SetInt(sponsorship.Recouped), big.NewFloat(1e18)).Float64()
+		if sponsorship.Token != "ETH" || recouped < 0.000007*0.999 || recouped > 0.000007*1.001 {
+			t.Errorf("Expected 0.000007 ETH recouped, got %f %s", recouped, sponsorship.Token)
+		}
+
+		// Ethereum has no policy
+		reverse := request
+		reverse.SourceToken, reverse.DestinationToken = request.DestinationToken, request.SourceToken
+		if _, err := sponsor.Quote(ctx, reverse); !errors.Is(err, types.ErrGasSponsorshipUnavailable) {
+			t.Errorf("Expected a chain without a policy to refuse sponsorship, got %v", err)
+		}
+	})
+
+	t.Run("MaxSwap", func(t *testing.T) {
+		sponsor := NewGasSponsor(estimator, map[int64]types.GasSponsorPolicy{types.ChainIDPolygon: {MaxSwapUSD: 0.01}})
+		if _, err := sponsor.Quote(ctx, request); !errors.Is(err, types.ErrGasSponsorshipUnavailable) {
+			t.Errorf("Expected gas above the per-swap cap to be refused, got %v", err)
+		}
+	})
+
+	t.Run("DailyCap", func(t *testing.T) {
+		sponsor := NewGasSponsor(estimator, map[int64]types.GasSponsorPolicy{types.ChainIDPolygon: {DailyCapUSD: 0.02}})
+		sponsor.now = func() time.Time { return now }
+
+		first, err := sponsor.Quote(ctx, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		second := request
+		second.RequestID = "swap-2"
+		quoted, err := sponsor.Quote(ctx, second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if err := sponsor.Record(ctx, *first); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := sponsor.Record(ctx, *first); err != nil {
+			t.Errorf("Expected recording a swap's sponsorship again to do nothing, got %v", err)
+		}
+		if _, err := sponsor.Quote(ctx, second); !errors.Is(err, types.ErrGasSponsorCapReached) {
+			t.Errorf("Expected the cap to refuse another quote, got %v", err)
+		}
+		// A swap quoted before the cap was used up is refused when it executes
+		if err := sponsor.Record(ctx, *quoted); !errors.Is(err, types.ErrGasSponsorCapReached) {
+			t.Errorf("Expected the cap to refuse another sponsorship, got %v", err)
+		}
+
+		// The cap covers the last 24 hours
+		sponsor.now = func() time.Time { return now.Add(gasSponsorWindow + time.Second) }
+		if err := sponsor.Record(ctx, *quoted); err != nil {
+			t.Errorf("Expected the cap to free up after a day, got %v", err)
+		}
+	})
+
+	t.Run("Swap", func(t *testing.T) {
+		service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+		service.SetPricePolicy(policy)
+		service.SetGasEstimator(estimator)
+		if _, err := service.GetSwapQuote(ctx, request); !errors.Is(err, types.ErrGasSponsorshipUnavailable) {
+			t.Errorf("Expected sponsorship to be refused without a sponsor, got %v", err)
+		}
+
+		store := NewInMemoryGasSponsorshipStore()
+		sponsor := NewGasSponsor(estimator, map[int64]types.GasSponsorPolicy{types.ChainIDPolygon: {}})
+		sponsor.SetStore(store)
+		service.SetGasSponsor(sponsor)
+
+		unsponsored := request
+		unsponsored.SponsorGas = false
+		standard, err := service.GetSwapQuote(ctx, unsponsored)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		quote, err := service.GetSwapQuote(ctx, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if quote.GasSponsorship == nil {
+			t.Fatal("Expected the quote to carry its gas sponsorship")
+		}
+		// Polygon's gas moves from the gas fee to the network fee
+		sourceGas, _ := estimator.GasFee(ctx, request)
+		if quote.Fee.GasFee.Cmp(sourceGas) != 0 || sourceGas.Cmp(standard.Fee.GasFee) >= 0 {
+			t.Errorf("Expected a gas fee of %s without Polygon's gas, got %s", sourceGas, quote.Fee.GasFee)
+		}
+		expected := new(big.Int).Add(standard.Fee.NetworkFee, quote.GasSponsorship.Recouped)
+		if quote.Fee.NetworkFee.Cmp(expected) != 0 {
+			t.Errorf("Expected a network fee of %s, got %s", expected, quote.Fee.NetworkFee)
+		}
+
+		if _, err := service.ExecuteSwap(ctx, request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sponsored, _ := store.SponsoredSince(ctx, types.ChainIDPolygon, now.Add(-time.Minute)); sponsored != quote.GasSponsorship.CostUSD {
+			t.Errorf("Expected the executed swap's $%f of gas to be booked, got $%f", quote.GasSponsorship.CostUSD, sponsored)
+		}
+	})
+}
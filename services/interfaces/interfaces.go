@@ -50,6 +50,7 @@ type LiquidityServiceInterface interface {
 type SwapServiceInterface interface {
 	GetSwapQuote(ctx context.Context, request types.SwapRequest) (*types.SwapQuote, error)
 	ExecuteSwap(ctx context.Context, request types.SwapRequest) (string, error)
+	SimulateSwap(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error)
 	GetSwapStatus(ctx context.Context, requestID string) (*types.SwapResult, error)
 	CancelSwap(ctx context.Context, requestID string) error
 }
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// unknownCountry is the country code IP databases use for unallocated or reserved ranges
+const unknownCountry = "ZZ"
+
+// IPCountries maps IP address ranges to the country each range is allocated to
+type IPCountries struct {
+	ranges []ipCountryRange // ordered by start address
+}
+
+// ipCountryRange is an inclusive range of addresses in one country
+type ipCountryRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	country string
+}
+
+// LoadIPCountries reads an IP to country CSV file, such as the DB-IP IP to Country Lite database
+func LoadIPCountries(path string) (*IPCountries, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseIPCountries(file)
+}
+
+// ParseIPCountries reads rows of first address, last address and two-letter country code. IPv4 and IPv6 ranges
+// may be mixed; rows for unallocated ranges (ZZ) are skipped.
+func ParseIPCountries(r io.Reader) (*IPCountries, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var ranges []ipCountryRange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected first address, last address and country", line)
+		}
+
+		start, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if start.BitLen() != end.BitLen() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, start, end)
+		}
+
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if len(country) != 2 || country == unknownCountry {
+			continue
+		}
+		ranges = append(ranges, ipCountryRange{start: start, end: end, country: country})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Less(ranges[j].start)
+	})
+	return &IPCountries{ranges: ranges}, nil
+}
+
+// Country returns the country code of an address, or false when no range covers it
+func (c *IPCountries) Country(addr netip.Addr) (string, bool) {
+	addr = addr.Unmap()
+
+	// The last range starting at or before the address is the only one that can cover it
+	i := sort.Search(len(c.ranges), func(i int) bool {
+		return addr.Less(c.ranges[i].start)
+	})
+	if i == 0 {
+		return "", false
+	}
+	candidate := c.ranges[i-1]
+	if candidate.end.Less(addr) {
+		return "", false
+	}
+	return candidate.country, true
+}
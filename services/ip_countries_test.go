@@ -0,0 +1,44 @@
+package services
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestIPCountries(t *testing.T) {
+	data := `2001:db8::,2001:db8::ffff,JP
+1.0.0.0,1.0.0.255,AU
+10.0.0.0,10.255.255.255,ZZ
+1.0.4.0,1.0.7.255,au
+`
+	countries, err := ParseIPCountries(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		addr    string
+		country string
+	}{
+		{"1.0.0.0", "AU"},
+		{"1.0.0.255", "AU"},
+		{"1.0.5.1", "AU"},
+		{"::ffff:1.0.0.7", "AU"},
+		{"1.0.1.0", ""},
+		{"0.255.255.255", ""},
+		{"10.1.2.3", ""},
+		{"2001:db8::42", "JP"},
+		{"2001:db8::1:0", ""},
+	}
+	for _, tt := range tests {
+		country, ok := countries.Country(netip.MustParseAddr(tt.addr))
+		if country != tt.country || ok != (tt.country != "") {
+			t.Errorf("Country(%s) = %q, %v; expected %q", tt.addr, country, ok, tt.country)
+		}
+	}
+
+	if _, err := ParseIPCountries(strings.NewReader("1.0.0.255,1.0.0.0,AU\n")); err == nil {
+		t.Error("Expected error for a reversed range, got nil")
+	}
+}
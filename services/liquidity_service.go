@@ -3,208 +3,285 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/infinity-dex/services/types"
 )
 
+// ErrInsufficientPoolLiquidity is returned when a pool's unreserved reserve of a token cannot cover a reservation
+// or withdrawal
+var ErrInsufficientPoolLiquidity = errors.New("insufficient pool liquidity")
+
+// ErrNoPoolReserve is returned for tokens a pool holds no reserve of. Swaps into such a token are not filled
+// from the pool.
+var ErrNoPoolReserve = errors.New("pool holds no reserve of the token")
+
+// defaultReservationTTL is how long a swap holds pool liquidity before the reservation is released on its own
+const defaultReservationTTL = 2 * time.Minute
+
 // LiquidityService provides functionality for managing liquidity pools
 type LiquidityService struct {
-	pools     map[string]LiquidityPool       // map[poolID]LiquidityPool
-	positions map[string][]LiquidityPosition // map[poolID][]LiquidityPosition
-	mu        sync.RWMutex
+	store          PoolStore
+	reservationTTL time.Duration
+	mu             sync.RWMutex
 }
 
-// NewLiquidityService creates a new liquidity service instance
+// NewLiquidityService creates a new liquidity service instance keeping its pools in memory
 func NewLiquidityService() *LiquidityService {
 	return &LiquidityService{
-		pools:     make(map[string]LiquidityPool),
-		positions: make(map[string][]LiquidityPosition),
+		store:          NewInMemoryPoolStore(),
+		reservationTTL: defaultReservationTTL,
 	}
 }
 
-// CreatePool creates a new liquidity pool
-func (s *LiquidityService) CreatePool(ctx context.Context, pair TokenPair, feeTier int, address string) (*LiquidityPool, error) {
+// SetStore replaces the store pools are kept in
+func (s *LiquidityService) SetStore(store PoolStore) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.store = store
+}
 
-	// Generate a unique pool ID
-	poolID := uuid.New().String()
-
-	// Create the pool
-	pool := LiquidityPool{
-		ID:             poolID,
-		Pair:           pair,
-		TotalLiquidity: big.NewInt(0),
-		TVL:            0,
-		APR:            0,
-		FeeTier:        feeTier,
-		Address:        address,
-	}
+// SetReservationTTL sets how long a swap's liquidity reservation lasts unless it is released first
+func (s *LiquidityService) SetReservationTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reservationTTL = ttl
+}
 
-	// Store the pool
-	s.pools[poolID] = pool
-	s.positions[poolID] = []LiquidityPosition{}
+// poolStore returns the store pools are kept in
+func (s *LiquidityService) poolStore() PoolStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store
+}
 
+// CreatePool creates a new liquidity pool
+func (s *LiquidityService) CreatePool(ctx context.Context, pair TokenPair, feeTier int, address string) (*LiquidityPool, error) {
+	pool := newPool(uuid.New().String(), pair, feeTier, address)
+	if _, err := s.poolStore().CreatePool(ctx, types.PoolState{Pool: toTypesPool(pool)}); err != nil {
+		return nil, err
+	}
 	return &pool, nil
 }
 
+// SeedPool creates a pool with a fixed ID unless it already exists, so every process
+// can seed the pools it is configured with
+func (s *LiquidityService) SeedPool(ctx context.Context, poolID string, pair TokenPair, feeTier int, address string) error {
+	if poolID == "" {
+		return errors.New("pool ID is required")
+	}
+	_, err := s.poolStore().CreatePool(ctx, types.PoolState{Pool: toTypesPool(newPool(poolID, pair, feeTier, address))})
+	return err
+}
+
 // GetPool retrieves a liquidity pool by ID
 func (s *LiquidityService) GetPool(ctx context.Context, poolID string) (*LiquidityPool, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	pool, exists := s.pools[poolID]
-	if !exists {
-		return nil, errors.New("pool not found")
+	state, err := s.poolStore().GetPool(ctx, poolID)
+	if err != nil {
+		return nil, err
 	}
 
+	pool := fromTypesPool(state.Pool)
 	return &pool, nil
 }
 
 // GetPoolByTokens retrieves a liquidity pool by token pair
 func (s *LiquidityService) GetPoolByTokens(ctx context.Context, token1Symbol, token2Symbol string) (*LiquidityPool, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	states, err := s.poolStore().ListPools(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, pool := range s.pools {
-		if (pool.Pair.BaseToken.Symbol == token1Symbol && pool.Pair.QuoteToken.Symbol == token2Symbol) ||
-			(pool.Pair.BaseToken.Symbol == token2Symbol && pool.Pair.QuoteToken.Symbol == token1Symbol) {
+	for _, state := range states {
+		pair := state.Pool.Pair
+		if (pair.BaseToken.Symbol == token1Symbol && pair.QuoteToken.Symbol == token2Symbol) ||
+			(pair.BaseToken.Symbol == token2Symbol && pair.QuoteToken.Symbol == token1Symbol) {
+			pool := fromTypesPool(state.Pool)
 			return &pool, nil
 		}
 	}
 
-	return nil, errors.New("pool not found")
+	return nil, types.ErrPoolNotFound
 }
 
 // GetAllPools retrieves all liquidity pools
 func (s *LiquidityService) GetAllPools(ctx context.Context) []LiquidityPool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	states, err := s.poolStore().ListPools(ctx)
+	if err != nil {
+		return nil
+	}
 
-	result := make([]LiquidityPool, 0, len(s.pools))
-	for _, pool := range s.pools {
-		result = append(result, pool)
+	result := make([]LiquidityPool, 0, len(states))
+	for _, state := range states {
+		result = append(result, fromTypesPool(state.Pool))
 	}
 
 	return result
 }
 
-// AddLiquidity adds liquidity to a pool
+// AddLiquidity adds liquidity in a pool's base token
 func (s *LiquidityService) AddLiquidity(ctx context.Context, poolID string, userAddress string, amount *big.Int) (*LiquidityPosition, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.AddLiquidityOnce(ctx, "", poolID, userAddress, "", amount)
+}
 
-	// Check if pool exists
-	pool, exists := s.pools[poolID]
-	if !exists {
-		return nil, errors.New("pool not found")
+// AddLiquidityOnce adds amount of one of a pool's tokens to its reserves as the operation of an ID, so retrying
+// the operation doesn't add the liquidity twice. An empty token adds the base token. A retried operation returns
+// the user's current position.
+func (s *LiquidityService) AddLiquidityOnce(ctx context.Context, operationID string, poolID string, userAddress string, token string, amount *big.Int) (*LiquidityPosition, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("invalid amount")
 	}
 
+	var position LiquidityPosition
+	applied, err := s.poolStore().UpdatePool(ctx, poolID, operationID, func(state *types.PoolState) error {
+		symbol, err := poolToken(state, token)
+		if err != nil {
+			return err
+		}
+		state.Pool.Reserves = state.Pool.Reserves.Add(symbol, amount)
+		position = addPosition(state, userAddress, amount)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return s.userPosition(ctx, poolID, userAddress)
+	}
+	return &position, nil
+}
+
+// addPosition adds liquidity to the user's position in a pool's state, creating the position if needed
+func addPosition(state *types.PoolState, userAddress string, amount *big.Int) LiquidityPosition {
 	// Update pool liquidity
-	pool.TotalLiquidity = new(big.Int).Add(pool.TotalLiquidity, amount)
-	s.pools[poolID] = pool
+	state.Pool.TotalLiquidity = new(big.Int).Add(state.Pool.TotalLiquidity, amount)
 
 	// Calculate share percentage
 	share := 0.0
-	if pool.TotalLiquidity.Cmp(big.NewInt(0)) > 0 {
+	if state.Pool.TotalLiquidity.Cmp(big.NewInt(0)) > 0 {
 		shareFloat := new(big.Float).SetInt(amount)
-		totalFloat := new(big.Float).SetInt(pool.TotalLiquidity)
+		totalFloat := new(big.Float).SetInt(state.Pool.TotalLiquidity)
 		shareFloat.Quo(shareFloat, totalFloat)
 		shareFloat.Mul(shareFloat, big.NewFloat(100.0))
 		share, _ = shareFloat.Float64()
 	}
 
-	// Create or update position
-	positions := s.positions[poolID]
-	var position *LiquidityPosition
-
 	// Check if user already has a position
-	for i, pos := range positions {
+	for i, pos := range state.Positions {
 		if pos.UserAddress == userAddress {
 			// Update existing position
-			positions[i].TokensOwned = new(big.Int).Add(pos.TokensOwned, amount)
-			positions[i].Share = share
-			positions[i].Value = 0.0 // Would calculate based on token prices
-			position = &positions[i]
-			s.positions[poolID] = positions
-			return position, nil
+			state.Positions[i].TokensOwned = new(big.Int).Add(pos.TokensOwned, amount)
+			state.Positions[i].Share = share
+			state.Positions[i].Value = 0.0 // Would calculate based on token prices
+			return LiquidityPosition(state.Positions[i].Clone())
 		}
 	}
 
 	// Create new position
-	newPosition := LiquidityPosition{
-		PoolID:      poolID,
+	newPosition := types.LiquidityPosition{
+		PoolID:      state.Pool.ID,
 		UserAddress: userAddress,
-		TokensOwned: amount,
+		TokensOwned: new(big.Int).Set(amount),
 		Share:       share,
 		Value:       0.0, // Would calculate based on token prices
+		FeesOwed:    types.TokenAmounts{},
+		FeesClaimed: types.TokenAmounts{},
 	}
-
-	// Add to positions
-	s.positions[poolID] = append(s.positions[poolID], newPosition)
-	return &newPosition, nil
+	state.Positions = append(state.Positions, newPosition)
+	return LiquidityPosition(newPosition.Clone())
 }
 
-// RemoveLiquidity removes liquidity from a pool
+// RemoveLiquidity removes liquidity in a pool's base token
 func (s *LiquidityService) RemoveLiquidity(ctx context.Context, poolID string, userAddress string, amount *big.Int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.RemoveLiquidityOnce(ctx, "", poolID, userAddress, "", amount)
+}
 
-	// Check if pool exists
-	pool, exists := s.pools[poolID]
-	if !exists {
-		return errors.New("pool not found")
+// RemoveLiquidityOnce withdraws amount of one of a pool's tokens from its reserves as the operation of an ID, so
+// retrying the operation doesn't remove the liquidity twice. An empty token withdraws the base token.
+func (s *LiquidityService) RemoveLiquidityOnce(ctx context.Context, operationID string, poolID string, userAddress string, token string, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("invalid amount")
 	}
 
-	// Find user position
-	positions := s.positions[poolID]
-	for i, pos := range positions {
-		if pos.UserAddress == userAddress {
+	_, err := s.poolStore().UpdatePool(ctx, poolID, operationID, func(state *types.PoolState) error {
+		symbol, err := poolToken(state, token)
+		if err != nil {
+			return err
+		}
+
+		for i, pos := range state.Positions {
+			if pos.UserAddress != userAddress {
+				continue
+			}
+
 			// Check if user has enough liquidity
 			if pos.TokensOwned.Cmp(amount) < 0 {
 				return errors.New("insufficient liquidity")
 			}
 
-			// Update position
-			positions[i].TokensOwned = new(big.Int).Sub(pos.TokensOwned, amount)
+			// Reserves held by executing swaps can't be withdrawn
+			if available, err := availableReserve(state, symbol, time.Now()); err != nil || available.Cmp(amount) < 0 {
+				return fmt.Errorf("%w: %s", ErrInsufficientPoolLiquidity, symbol)
+			}
+			state.Pool.Reserves[symbol] = new(big.Int).Sub(state.Pool.Reserves[symbol], amount)
+
+			state.Positions[i].TokensOwned = new(big.Int).Sub(pos.TokensOwned, amount)
 
-			// Remove position if tokens owned is zero
-			if positions[i].TokensOwned.Cmp(big.NewInt(0)) == 0 {
-				// Remove position
-				s.positions[poolID] = append(positions[:i], positions[i+1:]...)
+			// Remove position if tokens owned is zero, keeping it around until unclaimed fees are claimed
+			if state.Positions[i].TokensOwned.Sign() == 0 && !hasUnclaimedFees(state.Positions[i]) {
+				state.Positions = append(state.Positions[:i], state.Positions[i+1:]...)
+			} else if state.Positions[i].TokensOwned.Sign() == 0 {
+				state.Positions[i].Share = 0
 			} else {
 				// Update share percentage
-				shareFloat := new(big.Float).SetInt(positions[i].TokensOwned)
-				totalFloat := new(big.Float).SetInt(pool.TotalLiquidity)
+				shareFloat := new(big.Float).SetInt(state.Positions[i].TokensOwned)
+				totalFloat := new(big.Float).SetInt(state.Pool.TotalLiquidity)
 				shareFloat.Quo(shareFloat, totalFloat)
 				shareFloat.Mul(shareFloat, big.NewFloat(100.0))
-				positions[i].Share, _ = shareFloat.Float64()
-				s.positions[poolID] = positions
+				state.Positions[i].Share, _ = shareFloat.Float64()
 			}
 
 			// Update pool liquidity
-			pool.TotalLiquidity = new(big.Int).Sub(pool.TotalLiquidity, amount)
-			s.pools[poolID] = pool
-
+			state.Pool.TotalLiquidity = new(big.Int).Sub(state.Pool.TotalLiquidity, amount)
 			return nil
 		}
-	}
 
-	return errors.New("position not found")
+		return errors.New("position not found")
+	})
+	return err
+}
+
+// userPosition returns a user's position in a pool
+func (s *LiquidityService) userPosition(ctx context.Context, poolID string, userAddress string) (*LiquidityPosition, error) {
+	state, err := s.poolStore().GetPool(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range state.Positions {
+		if pos.UserAddress == userAddress {
+			position := LiquidityPosition(pos)
+			return &position, nil
+		}
+	}
+	return nil, errors.New("position not found")
 }
 
 // GetUserPositions retrieves all liquidity positions for a user
 func (s *LiquidityService) GetUserPositions(ctx context.Context, userAddress string) []LiquidityPosition {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	states, err := s.poolStore().ListPools(ctx)
+	if err != nil {
+		return nil
+	}
 
 	var result []LiquidityPosition
-	for _, positions := range s.positions {
-		for _, pos := range positions {
+	for _, state := range states {
+		for _, pos := range state.Positions {
 			if pos.UserAddress == userAddress {
-				result = append(result, pos)
+				result = append(result, LiquidityPosition(pos))
 			}
 		}
 	}
@@ -214,30 +291,304 @@ func (s *LiquidityService) GetUserPositions(ctx context.Context, userAddress str
 
 // GetPoolPositions retrieves all liquidity positions for a pool
 func (s *LiquidityService) GetPoolPositions(ctx context.Context, poolID string) ([]LiquidityPosition, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	state, err := s.poolStore().GetPool(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
 
-	positions, exists := s.positions[poolID]
-	if !exists {
-		return nil, errors.New("pool not found")
+	result := make([]LiquidityPosition, len(state.Positions))
+	for i, pos := range state.Positions {
+		result[i] = LiquidityPosition(pos)
 	}
 
-	return positions, nil
+	return result, nil
 }
 
 // UpdatePoolStats updates the TVL and APR for a pool
 func (s *LiquidityService) UpdatePoolStats(ctx context.Context, poolID string, tvl float64, apr float64) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	_, err := s.poolStore().UpdatePool(ctx, poolID, "", func(state *types.PoolState) error {
+		state.Pool.TVL = tvl
+		state.Pool.APR = apr
+		return nil
+	})
+	return err
+}
 
-	pool, exists := s.pools[poolID]
-	if !exists {
-		return errors.New("pool not found")
+// AccrueFees credits the fee tier of a swap routed through a pool to its liquidity providers, in the token
+// the swap paid in. Fees are split pro rata by tokens owned; rounding dust stays with the pool.
+// A swap's fees accrue once, so settling it again returns a zero fee.
+func (s *LiquidityService) AccrueFees(ctx context.Context, poolID string, swapID string, token string, amountIn *big.Int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() <= 0 {
+		return nil, errors.New("invalid amount")
+	}
+	if swapID == "" {
+		return nil, errors.New("swap ID is required")
 	}
 
-	pool.TVL = tvl
-	pool.APR = apr
-	s.pools[poolID] = pool
+	fee := big.NewInt(0)
+	_, err := s.poolStore().UpdatePool(ctx, poolID, "fees:"+swapID, func(state *types.PoolState) error {
+		fee = accrueFees(state, token, amountIn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	return fee, nil
+}
+
+// SettleSwap applies a settled swap to a pool once: it accrues the swap's fees like AccrueFees and, when the pool
+// filled the swap, adds its input less fees to the input token's reserve and pays its output from the output
+// token's reserve. It releases the swap's reservation and returns the fee.
+func (s *LiquidityService) SettleSwap(ctx context.Context, poolID string, swapID string, tokenIn string, amountIn *big.Int, tokenOut string, amountOut *big.Int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() <= 0 || amountOut == nil || amountOut.Sign() < 0 {
+		return nil, errors.New("invalid amount")
+	}
+	if swapID == "" {
+		return nil, errors.New("swap ID is required")
+	}
+
+	fee := big.NewInt(0)
+	// Shares the operation ID of AccrueFees so a swap's fees accrue once either way
+	_, err := s.poolStore().UpdatePool(ctx, poolID, "fees:"+swapID, func(state *types.PoolState) error {
+		fee = accrueFees(state, tokenIn, amountIn)
+		if reserve, filled := state.Pool.Reserves[tokenOut]; filled {
+			state.Pool.Reserves = state.Pool.Reserves.Add(tokenIn, new(big.Int).Sub(amountIn, fee))
+			remaining := new(big.Int).Sub(reserve, amountOut)
+			if remaining.Sign() < 0 {
+				remaining.SetInt64(0)
+			}
+			state.Pool.Reserves[tokenOut] = remaining
+		}
+		delete(state.Reservations, swapID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fee, nil
+}
+
+// accrueFees credits the fee tier of amountIn to a pool's liquidity providers pro rata, returning the fee
+func accrueFees(state *types.PoolState, token string, amountIn *big.Int) *big.Int {
+	fee := new(big.Int).Mul(amountIn, big.NewInt(int64(state.Pool.FeeTier)))
+	fee.Quo(fee, big.NewInt(feeTierDenominator))
+	if fee.Sign() == 0 || state.Pool.TotalLiquidity.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	state.Pool.FeesAccrued = state.Pool.FeesAccrued.Add(token, fee)
+	for i, pos := range state.Positions {
+		if pos.TokensOwned.Sign() <= 0 {
+			continue
+		}
+		share := new(big.Int).Mul(fee, pos.TokensOwned)
+		share.Quo(share, state.Pool.TotalLiquidity)
+		state.Positions[i].FeesOwed = pos.FeesOwed.Add(token, share)
+	}
+	return fee
+}
+
+// GetPendingFees returns the fees a user can claim from a pool, by token
+func (s *LiquidityService) GetPendingFees(ctx context.Context, poolID string, userAddress string) (types.TokenAmounts, error) {
+	position, err := s.userPosition(ctx, poolID, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	return nonNilAmounts(position.FeesOwed), nil
+}
+
+// ClaimFees pays out a user's accrued fees from a pool and returns the amounts claimed, by token
+func (s *LiquidityService) ClaimFees(ctx context.Context, poolID string, userAddress string) (types.TokenAmounts, error) {
+	var claimed types.TokenAmounts
+	_, err := s.poolStore().UpdatePool(ctx, poolID, "", func(state *types.PoolState) error {
+		for i, pos := range state.Positions {
+			if pos.UserAddress != userAddress {
+				continue
+			}
+
+			claimed = nonNilAmounts(pos.FeesOwed)
+			for token, amount := range claimed {
+				state.Positions[i].FeesClaimed = state.Positions[i].FeesClaimed.Add(token, amount)
+			}
+			state.Positions[i].FeesOwed = types.TokenAmounts{}
+
+			// Withdrawn positions only stick around until their fees are claimed
+			if state.Positions[i].TokensOwned.Sign() == 0 {
+				state.Positions = append(state.Positions[:i], state.Positions[i+1:]...)
+			}
+			return nil
+		}
+		return errors.New("position not found")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// ReserveLiquidity holds amount of a pool's reserve of token, the swap's output token, until the swap settles, the
+// reservation is released or it expires. Reserving again for the same swap replaces its reservation. Pools only fill
+// swaps into tokens they hold a reserve of, so swaps into other tokens reserve nothing.
+func (s *LiquidityService) ReserveLiquidity(ctx context.Context, poolID string, swapID string, token string, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("invalid amount")
+	}
+
+	s.mu.RLock()
+	ttl := s.reservationTTL
+	s.mu.RUnlock()
+
+	_, err := s.poolStore().UpdatePool(ctx, poolID, "", func(state *types.PoolState) error {
+		now := time.Now()
+		delete(state.Reservations, swapID)
+		available, err := availableReserve(state, token, now)
+		if errors.Is(err, ErrNoPoolReserve) {
+			return nil
+		}
+		if available.Cmp(amount) < 0 {
+			return fmt.Errorf("%w: %s %s available, %s requested", ErrInsufficientPoolLiquidity, available, token, amount)
+		}
+
+		if state.Reservations == nil {
+			state.Reservations = make(map[string]types.PoolReservation)
+		}
+		state.Reservations[swapID] = types.PoolReservation{
+			Token:     token,
+			Amount:    new(big.Int).Set(amount),
+			ExpiresAt: now.Add(ttl),
+		}
+		return nil
+	})
+	return err
+}
+
+// ReleaseLiquidity releases the liquidity reserved for a swap, if any
+func (s *LiquidityService) ReleaseLiquidity(ctx context.Context, swapID string) {
+	store := s.poolStore()
+	states, err := store.ListPools(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, state := range states {
+		if _, reserved := state.Reservations[swapID]; reserved {
+			store.UpdatePool(ctx, state.Pool.ID, "", func(state *types.PoolState) error {
+				delete(state.Reservations, swapID)
+				return nil
+			})
+		}
+	}
+}
+
+// GetAvailableLiquidity returns a pool's reserve of a token not held by executing swaps,
+// or ErrNoPoolReserve when the pool holds none of the token
+func (s *LiquidityService) GetAvailableLiquidity(ctx context.Context, poolID string, token string) (*big.Int, error) {
+	state, err := s.poolStore().GetPool(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
+	return availableReserve(state, token, time.Now())
+}
+
+// availableReserve returns a pool's unreserved reserve of a token, dropping expired reservations from its state
+func availableReserve(state *types.PoolState, token string, now time.Time) (*big.Int, error) {
+	for swapID, reservation := range state.Reservations {
+		if now.After(reservation.ExpiresAt) {
+			delete(state.Reservations, swapID)
+		}
+	}
+
+	reserve, ok := state.Pool.Reserves[token]
+	if !ok {
+		return nil, ErrNoPoolReserve
+	}
+	available := new(big.Int).Set(reserve)
+	for _, reservation := range state.Reservations {
+		if reservation.Token == token {
+			available.Sub(available, reservation.Amount)
+		}
+	}
+	if available.Sign() < 0 {
+		available.SetInt64(0)
+	}
+	return available, nil
+}
+
+// poolToken returns the symbol of the pool token named by symbol, the base token when it is empty
+func poolToken(state *types.PoolState, symbol string) (string, error) {
+	pair := state.Pool.Pair
+	switch symbol {
+	case "", pair.BaseToken.Symbol:
+		return pair.BaseToken.Symbol, nil
+	case pair.QuoteToken.Symbol:
+		return symbol, nil
+	}
+	return "", fmt.Errorf("token %s is not in pool %s", symbol, state.Pool.ID)
+}
+
+// newPool returns an empty pool
+func newPool(poolID string, pair TokenPair, feeTier int, address string) LiquidityPool {
+	return LiquidityPool{
+		ID:             poolID,
+		Pair:           pair,
+		TotalLiquidity: big.NewInt(0),
+		TVL:            0,
+		APR:            0,
+		FeeTier:        feeTier,
+		Address:        address,
+		FeesAccrued:    types.TokenAmounts{},
+		Reserves:       types.TokenAmounts{},
+	}
+}
+
+// toTypesPool converts a pool to the form pool stores keep
+func toTypesPool(pool LiquidityPool) types.LiquidityPool {
+	return types.LiquidityPool{
+		ID: pool.ID,
+		Pair: types.TokenPair{
+			BaseToken:  types.Token(pool.Pair.BaseToken),
+			QuoteToken: types.Token(pool.Pair.QuoteToken),
+		},
+		TotalLiquidity: pool.TotalLiquidity,
+		TVL:            pool.TVL,
+		APR:            pool.APR,
+		FeeTier:        pool.FeeTier,
+		Address:        pool.Address,
+		FeesAccrued:    pool.FeesAccrued,
+		Reserves:       pool.Reserves,
+	}
+}
+
+// fromTypesPool converts a stored pool back
+func fromTypesPool(pool types.LiquidityPool) LiquidityPool {
+	return LiquidityPool{
+		ID: pool.ID,
+		Pair: TokenPair{
+			BaseToken:  Token(pool.Pair.BaseToken),
+			QuoteToken: Token(pool.Pair.QuoteToken),
+		},
+		TotalLiquidity: pool.TotalLiquidity,
+		TVL:            pool.TVL,
+		APR:            pool.APR,
+		FeeTier:        pool.FeeTier,
+		Address:        pool.Address,
+		FeesAccrued:    nonNilAmounts(pool.FeesAccrued),
+		Reserves:       nonNilAmounts(pool.Reserves),
+	}
+}
+
+// hasUnclaimedFees reports whether a position still has fees to claim
+func hasUnclaimedFees(position types.LiquidityPosition) bool {
+	return !position.FeesOwed.IsZero()
+}
+
+// nonNilAmounts returns a copy of amounts, empty rather than nil
+func nonNilAmounts(amounts types.TokenAmounts) types.TokenAmounts {
+	if amounts == nil {
+		return types.TokenAmounts{}
+	}
+	return amounts.Clone()
 }
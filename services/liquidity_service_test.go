@@ -2,8 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestLiquidityService(t *testing.T) {
@@ -233,3 +237,330 @@ func TestLiquidityService(t *testing.T) {
 		}
 	})
 }
+
+func TestLiquidityFees(t *testing.T) {
+	ctx := context.Background()
+	service := NewLiquidityService()
+
+	pair := TokenPair{
+		BaseToken:  Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: Token{Symbol: "USDC", ChainID: 1},
+	}
+	pool, err := service.CreatePool(ctx, pair, 3000, "0x1234567890abcdef1234567890abcdef12345678")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+
+	alice := "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	bob := "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	if _, err := service.AddLiquidity(ctx, pool.ID, alice, big.NewInt(3000)); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+	if _, err := service.AddLiquidity(ctx, pool.ID, bob, big.NewInt(1000)); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+
+	t.Run("AccrueFees", func(t *testing.T) {
+		// 0.3% of 1,000,000 is 3000, split 3:1
+		fee, err := service.AccrueFees(ctx, pool.ID, "swap-1", "ETH", big.NewInt(1000000))
+		if err != nil {
+			t.Fatalf("Failed to accrue fees: %v", err)
+		}
+		if fee.Cmp(big.NewInt(3000)) != 0 {
+			t.Errorf("Expected fee 3000, got %s", fee.String())
+		}
+
+		pending, err := service.GetPendingFees(ctx, pool.ID, alice)
+		if err != nil {
+			t.Fatalf("Failed to get pending fees: %v", err)
+		}
+		if pending["ETH"].Cmp(big.NewInt(2250)) != 0 {
+			t.Errorf("Expected 2250 ETH pending for alice, got %s", pending["ETH"])
+		}
+
+		pending, _ = service.GetPendingFees(ctx, pool.ID, bob)
+		if pending["ETH"].Cmp(big.NewInt(750)) != 0 {
+			t.Errorf("Expected 750 ETH pending for bob, got %s", pending["ETH"])
+		}
+
+		updated, _ := service.GetPool(ctx, pool.ID)
+		if updated.FeesAccrued["ETH"].Cmp(big.NewInt(3000)) != 0 {
+			t.Errorf("Expected pool to have accrued 3000 ETH, got %s", updated.FeesAccrued["ETH"])
+		}
+	})
+
+	t.Run("SwapAccruesOnce", func(t *testing.T) {
+		fee, err := service.AccrueFees(ctx, pool.ID, "swap-1", "ETH", big.NewInt(1000000))
+		if err != nil {
+			t.Fatalf("Failed to accrue fees: %v", err)
+		}
+		if fee.Sign() != 0 {
+			t.Errorf("Expected a settled swap to accrue no more fees, got %s", fee)
+		}
+
+		updated, _ := service.GetPool(ctx, pool.ID)
+		if updated.FeesAccrued["ETH"].Cmp(big.NewInt(3000)) != 0 {
+			t.Errorf("Expected pool to still have accrued 3000 ETH, got %s", updated.FeesAccrued["ETH"])
+		}
+	})
+
+	t.Run("FeesAreKeptPerToken", func(t *testing.T) {
+		if _, err := service.AccrueFees(ctx, pool.ID, "swap-2", "USDC", big.NewInt(2000000)); err != nil {
+			t.Fatalf("Failed to accrue fees: %v", err)
+		}
+
+		updated, _ := service.GetPool(ctx, pool.ID)
+		if updated.FeesAccrued["ETH"].Cmp(big.NewInt(3000)) != 0 || updated.FeesAccrued["USDC"].Cmp(big.NewInt(6000)) != 0 {
+			t.Errorf("Expected 3000 ETH and 6000 USDC accrued, got %v", updated.FeesAccrued)
+		}
+		pending, _ := service.GetPendingFees(ctx, pool.ID, alice)
+		if pending["USDC"].Cmp(big.NewInt(4500)) != 0 {
+			t.Errorf("Expected 4500 USDC pending for alice, got %s", pending["USDC"])
+		}
+	})
+
+	t.Run("ClaimFees", func(t *testing.T) {
+		claimed, err := service.ClaimFees(ctx, pool.ID, alice)
+		if err != nil {
+			t.Fatalf("Failed to claim fees: %v", err)
+		}
+		if claimed["ETH"].Cmp(big.NewInt(2250)) != 0 || claimed["USDC"].Cmp(big.NewInt(4500)) != 0 {
+			t.Errorf("Expected to claim 2250 ETH and 4500 USDC, got %v", claimed)
+		}
+
+		pending, _ := service.GetPendingFees(ctx, pool.ID, alice)
+		if !pending.IsZero() {
+			t.Errorf("Expected no pending fees after claim, got %v", pending)
+		}
+	})
+
+	t.Run("WithdrawnPositionKeepsFees", func(t *testing.T) {
+		if err := service.RemoveLiquidity(ctx, pool.ID, bob, big.NewInt(1000)); err != nil {
+			t.Fatalf("Failed to remove liquidity: %v", err)
+		}
+
+		pending, err := service.GetPendingFees(ctx, pool.ID, bob)
+		if err != nil {
+			t.Fatalf("Expected withdrawn position to keep its fees: %v", err)
+		}
+		if pending["ETH"].Cmp(big.NewInt(750)) != 0 {
+			t.Errorf("Expected 750 ETH pending for bob, got %s", pending["ETH"])
+		}
+
+		if _, err := service.ClaimFees(ctx, pool.ID, bob); err != nil {
+			t.Fatalf("Failed to claim fees: %v", err)
+		}
+		if _, err := service.GetPendingFees(ctx, pool.ID, bob); err == nil {
+			t.Error("Expected position to be removed once its fees were claimed")
+		}
+	})
+
+	t.Run("UnknownPool", func(t *testing.T) {
+		if _, err := service.AccrueFees(ctx, "missing", "swap", "ETH", big.NewInt(1000)); err == nil {
+			t.Error("Expected error for unknown pool, got nil")
+		}
+		if _, err := service.ClaimFees(ctx, "missing", alice); err == nil {
+			t.Error("Expected error for unknown pool, got nil")
+		}
+	})
+}
+
+func TestLiquidityReservations(t *testing.T) {
+	ctx := context.Background()
+	service := NewLiquidityService()
+
+	pair := TokenPair{
+		BaseToken:  Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: Token{Symbol: "USDC", ChainID: 1},
+	}
+	pool, err := service.CreatePool(ctx, pair, 3000, "0x1234567890abcdef1234567890abcdef12345678")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	alice := "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	if _, err := service.AddLiquidity(ctx, pool.ID, alice, big.NewInt(1000)); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+
+	available := func(t *testing.T) int64 {
+		liquidity, err := service.GetAvailableLiquidity(ctx, pool.ID, "ETH")
+		if err != nil {
+			t.Fatalf("Failed to get available liquidity: %v", err)
+		}
+		return liquidity.Int64()
+	}
+
+	t.Run("ConcurrentSwapsCannotOversell", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		reserved := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if err := service.ReserveLiquidity(ctx, pool.ID, fmt.Sprintf("swap-%d", i), "ETH", big.NewInt(300)); err == nil {
+					mu.Lock()
+					reserved++
+					mu.Unlock()
+				} else if !errors.Is(err, ErrInsufficientPoolLiquidity) {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if reserved != 3 {
+			t.Errorf("Expected 3 reservations to fit, got %d", reserved)
+		}
+		if got := available(t); got != 100 {
+			t.Errorf("Expected 100 unreserved, got %d", got)
+		}
+	})
+
+	t.Run("ReservedLiquidityCannotBeWithdrawn", func(t *testing.T) {
+		if err := service.RemoveLiquidity(ctx, pool.ID, alice, big.NewInt(500)); !errors.Is(err, ErrInsufficientPoolLiquidity) {
+			t.Errorf("Expected ErrInsufficientPoolLiquidity, got %v", err)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			service.ReleaseLiquidity(ctx, fmt.Sprintf("swap-%d", i))
+		}
+		if got := available(t); got != 1000 {
+			t.Errorf("Expected all liquidity to be released, got %d", got)
+		}
+	})
+
+	t.Run("ExpiredReservationsAreReleased", func(t *testing.T) {
+		service.SetReservationTTL(time.Millisecond)
+		defer service.SetReservationTTL(defaultReservationTTL)
+
+		if err := service.ReserveLiquidity(ctx, pool.ID, "swap-stuck", "ETH", big.NewInt(1000)); err != nil {
+			t.Fatalf("Failed to reserve liquidity: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if err := service.ReserveLiquidity(ctx, pool.ID, "swap-next", "ETH", big.NewInt(1000)); err != nil {
+			t.Errorf("Expected the expired reservation to be released: %v", err)
+		}
+	})
+
+	t.Run("TokenWithoutReserve", func(t *testing.T) {
+		if _, err := service.GetAvailableLiquidity(ctx, pool.ID, "USDC"); !errors.Is(err, ErrNoPoolReserve) {
+			t.Errorf("Expected ErrNoPoolReserve, got %v", err)
+		}
+		// The pool doesn't fill swaps into USDC, so they reserve nothing
+		if err := service.ReserveLiquidity(ctx, pool.ID, "swap-usdc", "USDC", big.NewInt(5000)); err != nil {
+			t.Errorf("Expected no reservation for a token without a reserve, got %v", err)
+		}
+	})
+
+	t.Run("UnknownPool", func(t *testing.T) {
+		if err := service.ReserveLiquidity(ctx, "missing", "swap", "ETH", big.NewInt(1)); err == nil {
+			t.Error("Expected error for unknown pool, got nil")
+		}
+	})
+}
+
+func TestLiquidityOperationsApplyOnce(t *testing.T) {
+	ctx := context.Background()
+	service := NewLiquidityService()
+
+	pair := TokenPair{
+		BaseToken:  Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: Token{Symbol: "USDC", ChainID: 1},
+	}
+	if err := service.SeedPool(ctx, "eth-usdc-3000", pair, 3000, ""); err != nil {
+		t.Fatalf("Failed to seed pool: %v", err)
+	}
+	// Seeding again, as every process does on startup, keeps the existing pool
+	if err := service.SeedPool(ctx, "eth-usdc-3000", pair, 500, ""); err != nil {
+		t.Fatalf("Failed to seed pool again: %v", err)
+	}
+	pool, err := service.GetPool(ctx, "eth-usdc-3000")
+	if err != nil {
+		t.Fatalf("Failed to get seeded pool: %v", err)
+	}
+	if pool.FeeTier != 3000 {
+		t.Errorf("Expected the first seed to be kept, got fee tier %d", pool.FeeTier)
+	}
+
+	alice := "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	for i := 0; i < 3; i++ {
+		position, err := service.AddLiquidityOnce(ctx, "mint:request-1", pool.ID, alice, "", big.NewInt(1000))
+		if err != nil {
+			t.Fatalf("Failed to add liquidity: %v", err)
+		}
+		if position.TokensOwned.Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("Expected a retried mint to leave 1000 owned, got %s", position.TokensOwned)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := service.RemoveLiquidityOnce(ctx, "burn:request-2", pool.ID, alice, "", big.NewInt(400)); err != nil {
+			t.Fatalf("Failed to remove liquidity: %v", err)
+		}
+	}
+	pool, _ = service.GetPool(ctx, pool.ID)
+	if pool.TotalLiquidity.Cmp(big.NewInt(600)) != 0 {
+		t.Errorf("Expected a retried burn to leave 600, got %s", pool.TotalLiquidity)
+	}
+
+	// A failed operation isn't recorded, so it can be retried once it can succeed
+	if err := service.RemoveLiquidityOnce(ctx, "burn:request-3", pool.ID, alice, "", big.NewInt(1000)); err == nil {
+		t.Fatal("Expected removing more than owned to fail")
+	}
+	if err := service.RemoveLiquidityOnce(ctx, "burn:request-3", pool.ID, alice, "", big.NewInt(1000)); err == nil {
+		t.Fatal("Expected the retried removal to fail again")
+	}
+}
+
+func TestSettleSwapMovesReserves(t *testing.T) {
+	ctx := context.Background()
+	service := NewLiquidityService()
+
+	pair := TokenPair{
+		BaseToken:  Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: Token{Symbol: "USDC", ChainID: 1},
+	}
+	pool, err := service.CreatePool(ctx, pair, 3000, "0x1234567890abcdef1234567890abcdef12345678")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	alice := "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	if _, err := service.AddLiquidityOnce(ctx, "", pool.ID, alice, "USDC", big.NewInt(5000)); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+	if _, err := service.AddLiquidityOnce(ctx, "", pool.ID, alice, "DAI", big.NewInt(5000)); err == nil {
+		t.Error("Expected adding a token outside the pair to fail")
+	}
+
+	if err := service.ReserveLiquidity(ctx, pool.ID, "swap-1", "USDC", big.NewInt(2000)); err != nil {
+		t.Fatalf("Failed to reserve liquidity: %v", err)
+	}
+	// Settling twice, as a retried settlement does, moves the reserves once
+	for i := 0; i < 2; i++ {
+		fee, err := service.SettleSwap(ctx, pool.ID, "swap-1", "ETH", big.NewInt(1000), "USDC", big.NewInt(2000))
+		if err != nil {
+			t.Fatalf("Failed to settle swap: %v", err)
+		}
+		if i == 0 && fee.Int64() != 3 {
+			t.Errorf("Expected a fee of 3, got %s", fee)
+		}
+	}
+
+	pool, _ = service.GetPool(ctx, pool.ID)
+	if got := pool.Reserves["USDC"]; got.Int64() != 3000 {
+		t.Errorf("Expected 3000 USDC left after paying the output, got %s", got)
+	}
+	if got := pool.Reserves["ETH"]; got.Int64() != 997 {
+		t.Errorf("Expected the input less fees in the ETH reserve, got %s", got)
+	}
+	available, err := service.GetAvailableLiquidity(ctx, pool.ID, "USDC")
+	if err != nil {
+		t.Fatalf("Failed to get available liquidity: %v", err)
+	}
+	if available.Int64() != 3000 {
+		t.Errorf("Expected settlement to release the reservation, got %s available", available)
+	}
+}
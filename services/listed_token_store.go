@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// ListedTokenStore persists the tokens added to the registry by approved listings, so the API server lists
+// tokens the swap worker approved and both keep them across restarts
+type ListedTokenStore interface {
+	// SaveListedToken stores a listed token, replacing an earlier listing of the same contract
+	SaveListedToken(ctx context.Context, token types.Token) error
+	// ListedTokens returns every listed token, ordered by chain and symbol
+	ListedTokens(ctx context.Context) ([]types.Token, error)
+}
+
+// InMemoryListedTokenStore is a ListedTokenStore for running without a database
+type InMemoryListedTokenStore struct {
+	tokens map[string]types.Token // map[chainID:lowercase address]Token
+	mu     sync.RWMutex
+}
+
+// NewInMemoryListedTokenStore creates an empty in-memory listed token store
+func NewInMemoryListedTokenStore() *InMemoryListedTokenStore {
+	return &InMemoryListedTokenStore{
+		tokens: make(map[string]types.Token),
+	}
+}
+
+// SaveListedToken stores a listed token
+func (s *InMemoryListedTokenStore) SaveListedToken(ctx context.Context, token types.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[fmt.Sprintf("%d:%s", token.ChainID, strings.ToLower(token.Address))] = token
+	return nil
+}
+
+// ListedTokens returns every listed token
+func (s *InMemoryListedTokenStore) ListedTokens(ctx context.Context) ([]types.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]types.Token, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].ChainID != tokens[j].ChainID {
+			return tokens[i].ChainID < tokens[j].ChainID
+		}
+		return tokens[i].Symbol < tokens[j].Symbol
+	})
+	return tokens, nil
+}
+
+// RestoreListedTokens adds the stored listed tokens to a token registry, returning how many were added
+func RestoreListedTokens(ctx context.Context, store ListedTokenStore, tokens *TokenService) (int, error) {
+	listed, err := store.ListedTokens(ctx)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, token := range listed {
+		if tokens.UpsertToken(token) {
+			added++
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/services/types"
+)
+
+// ERC-20, Uniswap V2 pair and router function selectors used by the listing checks
+const (
+	erc20Decimals  = "0x313ce567"
+	erc20Symbol    = "0x95d89b41"
+	erc20BalanceOf = "0x70a08231"
+	erc20Allowance = "0xdd62ed3e"
+	erc20Transfer  = "0xa9059cbb"
+	pairToken0     = "0x0dfe1681"
+	pairToken1     = "0xd21220a7"
+	// swapExactTokensForTokensSupportingFeeOnTransferTokens(uint256,uint256,address[],address,uint256)
+	routerSwapExactTokens = "0x5c11d795"
+)
+
+// maxStorageProbe is how many storage slots are tried when looking for a token's balance and allowance mappings
+const maxStorageProbe = 32
+
+// Listing risk check names
+const (
+	ListingCheckDuplicate     = "duplicate"
+	ListingCheckLiquidity     = "liquidity"
+	ListingCheckLiquidityLock = "liquidity_lock"
+	ListingCheckContract      = "contract"
+	ListingCheckDecimals      = "decimals"
+	ListingCheckSymbol        = "symbol"
+	ListingCheckHoneypot      = "honeypot"
+)
+
+// minListingLockDays is the liquidity lock below which reviewers are warned
+const minListingLockDays = 90
+
+// ContractCaller reads EVM contract state
+type ContractCaller interface {
+	Call(ctx context.Context, chainID int64, from, to, data string) ([]byte, error)
+	// CallWithStorage runs a call with contract storage slots replaced for that call only
+	CallWithStorage(ctx context.Context, chainID int64, from, to, data string, storage StorageOverrides) ([]byte, error)
+	GetCode(ctx context.Context, chainID int64, address string) ([]byte, error)
+}
+
+// StorageOverrides replaces contract storage for a simulated call: map[contract]map[slot]value, slots and values
+// as 0x-prefixed 32-byte hex words
+type StorageOverrides map[string]map[string]string
+
+// TokenCatalog is the token registry listings are checked against and added to
+type TokenCatalog interface {
+	GetToken(symbol string) (types.Token, error)
+	GetTokensByChain(chainID int64) []types.Token
+	AddToken(token types.Token) error
+}
+
+// ListingChecker runs the automated risk checks of listing requests
+type ListingChecker struct {
+	caller          ContractCaller
+	catalog         TokenCatalog
+	minLiquidityUSD float64
+	routers         map[int64]string // Uniswap V2-compatible router of each chain sells are simulated through
+}
+
+// NewListingChecker creates a checker reading tokens through caller and rejecting
+// listings committing less than minLiquidityUSD
+func NewListingChecker(caller ContractCaller, catalog TokenCatalog, minLiquidityUSD float64) *ListingChecker {
+	return &ListingChecker{
+		caller:          caller,
+		catalog:         catalog,
+		minLiquidityUSD: minLiquidityUSD,
+	}
+}
+
+// SetRouters sets the Uniswap V2-compatible router of each chain that sells are simulated through
+func (c *ListingChecker) SetRouters(routers map[int64]string) {
+	c.routers = routers
+}
+
+// Check runs every risk check against a listing request.
+// On-chain checks are skipped when the contract has no code, since their results would be meaningless.
+func (c *ListingChecker) Check(ctx context.Context, listing types.ListingRequest) []types.ListingCheck {
+	checks := []types.ListingCheck{
+		c.checkDuplicate(listing),
+		c.checkLiquidity(listing),
+		checkLiquidityLock(listing),
+	}
+
+	contract := c.checkContract(ctx, listing)
+	checks = append(checks, contract)
+	if !contract.Passed {
+		return checks
+	}
+
+	return append(checks,
+		c.checkDecimals(ctx, listing),
+		c.checkSymbol(ctx, listing),
+		c.checkHoneypot(ctx, listing),
+	)
+}
+
+// checkDuplicate fails if the symbol or contract is already in the registry
+func (c *ListingChecker) checkDuplicate(listing types.ListingRequest) types.ListingCheck {
+	check := types.ListingCheck{Name: ListingCheckDuplicate, Severity: types.ListingCheckBlocking}
+	token := listing.ListedToken()
+
+	if existing, err := c.catalog.GetToken(token.Symbol); err == nil {
+		check.Detail = fmt.Sprintf("Symbol %s is already listed on chain %d", token.Symbol, existing.ChainID)
+		return check
+	}
+	for _, existing := range c.catalog.GetTokensByChain(token.ChainID) {
+		if strings.EqualFold(existing.Address, token.Address) {
+			check.Detail = fmt.Sprintf("Contract is already listed as %s", existing.Symbol)
+			return check
+		}
+	}
+
+	check.Passed = true
+	return check
+}
+
+// checkLiquidity fails if the committed liquidity is below the minimum
+func (c *ListingChecker) checkLiquidity(listing types.ListingRequest) types.ListingCheck {
+	check := types.ListingCheck{Name: ListingCheckLiquidity, Severity: types.ListingCheckBlocking}
+	if listing.Liquidity.AmountUSD < c.minLiquidityUSD {
+		check.Detail = fmt.Sprintf("Committed $%.2f of liquidity, at least $%.2f is required", listing.Liquidity.AmountUSD, c.minLiquidityUSD)
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkLiquidityLock warns reviewers about liquidity that can be pulled soon after listing
+func checkLiquidityLock(listing types.ListingRequest) types.ListingCheck {
+	check := types.ListingCheck{Name: ListingCheckLiquidityLock, Severity: types.ListingCheckAdvisory}
+	if listing.Liquidity.LockDays < minListingLockDays {
+		check.Detail = fmt.Sprintf("Liquidity is locked for %d days, less than %d", listing.Liquidity.LockDays, minListingLockDays)
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkContract fails if there is no contract deployed at the token address
+func (c *ListingChecker) checkContract(ctx context.Context, listing types.ListingRequest) types.ListingCheck {
+	check := types.ListingCheck{Name: ListingCheckContract, Severity: types.ListingCheckBlocking}
+	code, err := c.caller.GetCode(ctx, listing.Token.ChainID, listing.Token.Address)
+	if err != nil {
+		check.Detail = fmt.Sprintf("Failed to read contract code: %v", err)
+		return check
+	}
+	if len(code) == 0 {
+		check.Detail = "No contract is deployed at the token address"
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkDecimals fails if the contract reports different decimals than submitted, which would misprice every swap
+func (c *ListingChecker) checkDecimals(ctx context.Context, listing types.ListingRequest) types.ListingCheck {
+	check := types.ListingCheck{Name: ListingCheckDecimals, Severity: types.ListingCheckBlocking}
+	result, err := c.caller.Call(ctx, listing.Token.ChainID, "", listing.Token.Address, erc20Decimals)
+	if err != nil {
+		check.Detail = fmt.Sprintf("Failed to read decimals: %v", err)
+		return check
+	}
+	if len(result) < 32 {
+		check.Detail = "Contract does not implement decimals()"
+		return check
+	}
+	decimals := new(big.Int).SetBytes(result[:32])
+	if !decimals.IsInt64() || decimals.Int64() != int64(listing.Token.Decimals) {
+		check.Detail = fmt.Sprintf("Contract has %s decimals, %d were submitted", decimals, listing.Token.Decimals)
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkSymbol warns reviewers if the contract reports a different symbol than submitted
+func (c *ListingChecker) checkSymbol(ctx context.Context, listing types.ListingRequest) types.ListingCheck {
+	check := types.ListingCheck{Name: ListingCheckSymbol, Severity: types.ListingCheckAdvisory}
+	result, err := c.caller.Call(ctx, listing.Token.ChainID, "", listing.Token.Address, erc20Symbol)
+	if err != nil {
+		check.Detail = fmt.Sprintf("Failed to read symbol: %v", err)
+		return check
+	}
+	symbol, ok := decodeABIString(result)
+	if !ok {
+		check.Detail = "Contract does not implement symbol()"
+		return check
+	}
+	if !strings.EqualFold(symbol, listing.Token.Symbol) {
+		check.Detail = fmt.Sprintf("Contract symbol is %q, %q was submitted", symbol, listing.Token.Symbol)
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkHoneypot simulates a buy out of the committed liquidity and a sell of it back through the chain's router.
+// Honeypots let buys through but revert sells from anyone but the wallets they allowlist, so both are simulated
+// from a fresh address; state overrides give it the tokens it sells, since it holds none.
+func (c *ListingChecker) checkHoneypot(ctx context.Context, listing types.ListingRequest) types.ListingCheck {
+	check := types.ListingCheck{Name: ListingCheckHoneypot, Severity: types.ListingCheckBlocking}
+	chainID, token, pool := listing.Token.ChainID, listing.Token.Address, listing.Liquidity.PoolAddress
+	buyer := simulationAddress(listing.ID)
+
+	amount, err := c.simulateTransfer(ctx, chainID, token, pool, buyer)
+	if err != nil {
+		check.Detail = fmt.Sprintf("Buy simulation failed: %v", err)
+		return check
+	}
+
+	router := c.routers[chainID]
+	if router == "" {
+		// Without a router the sell cannot be simulated; leave it to the reviewer
+		check.Severity = types.ListingCheckAdvisory
+		check.Detail = fmt.Sprintf("No router is configured for chain %d, so sells were not simulated", chainID)
+		return check
+	}
+	quote, err := c.pairedToken(ctx, chainID, pool, token)
+	if err != nil {
+		check.Detail = fmt.Sprintf("Failed to read the pool's tokens: %v", err)
+		return check
+	}
+	storage, err := c.sellerStorage(ctx, chainID, token, buyer, router, amount)
+	if err != nil {
+		// Tokens keeping balances outside a plain mapping can't be funded; leave them to the reviewer
+		check.Severity = types.ListingCheckAdvisory
+		check.Detail = fmt.Sprintf("Sells were not simulated: %v", err)
+		return check
+	}
+
+	data := routerSwapExactTokens +
+		encodeABIUint(amount) +
+		encodeABIUint(big.NewInt(0)) + // Any output
+		encodeABIUint(big.NewInt(5*32)) + // Offset of the path
+		encodeABIAddress(buyer) +
+		encodeABIUint(maxUint256) + // No deadline
+		encodeABIUint(big.NewInt(2)) +
+		encodeABIAddress(token) +
+		encodeABIAddress(quote)
+	if _, err := c.caller.CallWithStorage(ctx, chainID, buyer, router, data, storage); err != nil {
+		check.Detail = fmt.Sprintf("Sell simulation failed: swap through the router reverted: %v", err)
+		return check
+	}
+
+	check.Passed = true
+	return check
+}
+
+// simulateTransfer runs a transfer of a small part of from's balance to to without sending it, returning the amount
+func (c *ListingChecker) simulateTransfer(ctx context.Context, chainID int64, token, from, to string) (*big.Int, error) {
+	balance, err := c.balanceOf(ctx, chainID, token, from, nil)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Sign() == 0 {
+		return nil, fmt.Errorf("%s holds none of the token", from)
+	}
+
+	amount := new(big.Int).Div(balance, big.NewInt(1000))
+	if amount.Sign() == 0 {
+		amount.SetInt64(1)
+	}
+
+	result, err := c.caller.Call(ctx, chainID, from, token, erc20Transfer+encodeABIAddress(to)+encodeABIUint(amount))
+	if err != nil {
+		return nil, fmt.Errorf("transfer from %s reverted: %w", from, err)
+	}
+	// Tokens that predate the final ERC-20 standard return nothing
+	if len(result) >= 32 && new(big.Int).SetBytes(result[:32]).Sign() == 0 {
+		return nil, fmt.Errorf("transfer from %s returned false", from)
+	}
+	return amount, nil
+}
+
+// balanceOf reads an address's token balance, with storage overridden when storage is not nil
+func (c *ListingChecker) balanceOf(ctx context.Context, chainID int64, token, owner string, storage StorageOverrides) (*big.Int, error) {
+	result, err := c.caller.CallWithStorage(ctx, chainID, "", token, erc20BalanceOf+encodeABIAddress(owner), storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read balance of %s: %w", owner, err)
+	}
+	if len(result) < 32 {
+		return nil, errors.New("contract does not implement balanceOf()")
+	}
+	return new(big.Int).SetBytes(result[:32]), nil
+}
+
+// pairedToken returns the token a Uniswap V2 pair trades against token
+func (c *ListingChecker) pairedToken(ctx context.Context, chainID int64, pool, token string) (string, error) {
+	var pairTokens [2]string
+	for i, selector := range []string{pairToken0, pairToken1} {
+		result, err := c.caller.Call(ctx, chainID, "", pool, selector)
+		if err != nil {
+			return "", err
+		}
+		if len(result) < 32 {
+			return "", errors.New("pool is not a Uniswap V2 pair")
+		}
+		pairTokens[i] = "0x" + hex.EncodeToString(result[12:32])
+	}
+
+	switch {
+	case strings.EqualFold(pairTokens[0], token):
+		return pairTokens[1], nil
+	case strings.EqualFold(pairTokens[1], token):
+		return pairTokens[0], nil
+	}
+	return "", errors.New("pool does not trade the token")
+}
+
+// sellerStorage finds the storage slots of the seller's balance and of its allowance to the router, and returns
+// overrides setting both to amount. Only Solidity mappings declared in the first maxStorageProbe slots are found.
+func (c *ListingChecker) sellerStorage(ctx context.Context, chainID int64, token, seller, router string, amount *big.Int) (StorageOverrides, error) {
+	value := "0x" + encodeABIUint(amount)
+
+	balanceSlot, err := c.probeMapping(ctx, chainID, token, erc20BalanceOf+encodeABIAddress(seller), amount, func(slot int64) string {
+		return mappingSlot(seller, slot)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("balance storage not found: %w", err)
+	}
+	allowanceSlot, err := c.probeMapping(ctx, chainID, token, erc20Allowance+encodeABIAddress(seller)+encodeABIAddress(router), amount, func(slot int64) string {
+		return nestedMappingSlot(seller, router, slot)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("allowance storage not found: %w", err)
+	}
+
+	return StorageOverrides{token: {balanceSlot: value, allowanceSlot: value}}, nil
+}
+
+// probeMapping overrides the slot key(slot) returns for each candidate slot until reading data returns amount
+func (c *ListingChecker) probeMapping(ctx context.Context, chainID int64, token, data string, amount *big.Int, key func(slot int64) string) (string, error) {
+	value := "0x" + encodeABIUint(amount)
+	for slot := int64(0); slot < maxStorageProbe; slot++ {
+		candidate := key(slot)
+		result, err := c.caller.CallWithStorage(ctx, chainID, "", token, data, StorageOverrides{token: {candidate: value}})
+		if err != nil {
+			return "", err
+		}
+		if len(result) >= 32 && new(big.Int).SetBytes(result[:32]).Cmp(amount) == 0 {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no mapping in the first %d slots", maxStorageProbe)
+}
+
+// mappingSlot returns the storage slot of key in a Solidity mapping(address => ...) declared at slot
+func mappingSlot(key string, slot int64) string {
+	return "0x" + hex.EncodeToString(mappingKey(key, slot))
+}
+
+// nestedMappingSlot returns the storage slot of [outer][inner] in a Solidity mapping(address => mapping(address => ...))
+func nestedMappingSlot(outer, inner string, slot int64) string {
+	word, _ := hex.DecodeString(encodeABIAddress(inner))
+	return "0x" + hex.EncodeToString(evm.Keccak256(word, mappingKey(outer, slot)))
+}
+
+// mappingKey hashes an address key with a mapping's declaration slot
+func mappingKey(key string, slot int64) []byte {
+	word, _ := hex.DecodeString(encodeABIAddress(key) + encodeABIUint(big.NewInt(slot)))
+	return evm.Keccak256(word)
+}
+
+// maxUint256 is the largest ABI uint256
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// simulationAddress derives an address nobody holds the key of from the listing ID
+func simulationAddress(listingID string) string {
+	sum := sha256.Sum256([]byte("infinity-dex-listing:" + listingID))
+	return "0x" + hex.EncodeToString(sum[:20])
+}
+
+// encodeABIAddress encodes an address as a 32-byte ABI word, without 0x
+func encodeABIAddress(address string) string {
+	return fmt.Sprintf("%064s", strings.ToLower(strings.TrimPrefix(address, "0x")))
+}
+
+// encodeABIUint encodes an unsigned integer as a 32-byte ABI word, without 0x
+func encodeABIUint(n *big.Int) string {
+	return fmt.Sprintf("%064x", n)
+}
+
+// decodeABIString decodes a string return value, also accepting the bytes32 some older tokens return
+func decodeABIString(result []byte) (string, bool) {
+	if len(result) == 32 {
+		return strings.TrimRight(string(result), "\x00"), true
+	}
+	if len(result) < 64 {
+		return "", false
+	}
+	offset := new(big.Int).SetBytes(result[:32])
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(result)) {
+		return "", false
+	}
+	start := offset.Int64() + 32
+	length := new(big.Int).SetBytes(result[offset.Int64():start])
+	if !length.IsInt64() || start+length.Int64() > int64(len(result)) {
+		return "", false
+	}
+	return string(result[start : start+length.Int64()]), true
+}
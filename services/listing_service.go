@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/services/types"
+)
+
+var (
+	evmAddressPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	listingSymbolPattern = regexp.MustCompile(`^[A-Za-z0-9.]{1,11}$`)
+)
+
+// ErrListedTokenNotSaved is returned when an approved token was added to the registry but not saved
+var ErrListedTokenNotSaved = errors.New("listed token not saved")
+
+// ListingService holds token listing requests and runs them through checks and review
+// when they are not handled by Temporal
+type ListingService struct {
+	listings map[string]types.ListingRequest
+	checker  *ListingChecker
+	catalog  TokenCatalog
+	listed   ListedTokenStore
+	now      func() time.Time
+	mu       sync.RWMutex
+}
+
+// NewListingService creates a listing service adding approved tokens to catalog
+func NewListingService(catalog TokenCatalog) *ListingService {
+	return &ListingService{
+		listings: make(map[string]types.ListingRequest),
+		catalog:  catalog,
+		listed:   NewInMemoryListedTokenStore(),
+		now:      time.Now,
+	}
+}
+
+// SetChecker sets the risk checker RunChecks uses
+func (s *ListingService) SetChecker(checker *ListingChecker) {
+	s.checker = checker
+}
+
+// SetTokenStore sets the store approved tokens are saved to
+func (s *ListingService) SetTokenStore(store ListedTokenStore) {
+	s.listed = store
+}
+
+// ListedTokens returns the tokens approved listings added to the registry, including those the swap worker approved
+func (s *ListingService) ListedTokens(ctx context.Context) ([]types.Token, error) {
+	return s.listed.ListedTokens(ctx)
+}
+
+// ValidateListingRequest checks a listing request is complete and for an EVM chain
+func ValidateListingRequest(listing types.ListingRequest) error {
+	token := listing.Token
+	if !listingSymbolPattern.MatchString(token.Symbol) {
+		return errors.New("token.symbol must be 1 to 11 letters, digits or dots")
+	}
+	if strings.TrimSpace(token.Name) == "" || len(token.Name) > 64 {
+		return errors.New("token.name is required and at most 64 characters")
+	}
+	if token.Decimals < 0 || token.Decimals > 36 {
+		return errors.New("token.decimals must be between 0 and 36")
+	}
+	chain, ok := types.GetChain(token.ChainID)
+	if !ok {
+		return fmt.Errorf("unsupported chain %d", token.ChainID)
+	}
+	// The risk checks read the contract over EVM JSON-RPC
+	if chain.Namespace != "eip155" {
+		return fmt.Errorf("listings are only accepted for EVM chains, not %s", chain.Name)
+	}
+	if !evmAddressPattern.MatchString(token.Address) {
+		return errors.New("token.address must be a 0x-prefixed EVM address")
+	}
+	if !evmAddressPattern.MatchString(listing.SubmitterAddress) {
+		return errors.New("submitterAddress must be a 0x-prefixed EVM address")
+	}
+	if !evmAddressPattern.MatchString(listing.Liquidity.PoolAddress) {
+		return errors.New("liquidity.poolAddress must be a 0x-prefixed EVM address")
+	}
+	if listing.Liquidity.AmountUSD <= 0 {
+		return errors.New("liquidity.amountUsd must be positive")
+	}
+	if listing.Liquidity.LockDays < 0 {
+		return errors.New("liquidity.lockDays cannot be negative")
+	}
+	if u, err := url.Parse(listing.ProjectURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("projectUrl must be an http(s) URL")
+	}
+	if _, err := mail.ParseAddress(listing.ContactEmail); err != nil {
+		return errors.New("contactEmail must be an email address")
+	}
+	return nil
+}
+
+// Submit validates a listing request and stores it for checking, assigning its ID
+func (s *ListingService) Submit(listing types.ListingRequest) (types.ListingRequest, error) {
+	if err := ValidateListingRequest(listing); err != nil {
+		return types.ListingRequest{}, err
+	}
+
+	now := s.now().UTC()
+	listing.ID = fmt.Sprintf("listing-%s", uuid.New().String())
+	listing.Token.ChainID = types.CanonicalChainID(listing.Token.ChainID)
+	listing.Status = types.ListingStatusChecking
+	listing.Checks = nil
+	listing.Review = nil
+	listing.Message = ""
+	listing.SubmittedAt = now
+	listing.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listings[listing.ID] = listing
+	return listing, nil
+}
+
+// Get returns a listing request by ID
+func (s *ListingService) Get(id string) (types.ListingRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	listing, ok := s.listings[id]
+	if !ok {
+		return types.ListingRequest{}, types.ErrListingNotFound
+	}
+	return listing, nil
+}
+
+// List returns the listing requests with the given status, or all of them, oldest first
+func (s *ListingService) List(status string) []types.ListingRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	listings := make([]types.ListingRequest, 0, len(s.listings))
+	for _, listing := range s.listings {
+		if status == "" || listing.Status == status {
+			listings = append(listings, listing)
+		}
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		return listings[i].SubmittedAt.Before(listings[j].SubmittedAt)
+	})
+	return listings
+}
+
+// Save replaces a stored listing request, such as with the state of its workflow
+func (s *ListingService) Save(listing types.ListingRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listings[listing.ID] = listing
+}
+
+// RunChecks runs the risk checks of a listing request, queueing it for review unless a blocking check failed
+func (s *ListingService) RunChecks(ctx context.Context, id string) (types.ListingRequest, error) {
+	if s.checker == nil {
+		return types.ListingRequest{}, errors.New("listing checks are not configured")
+	}
+
+	listing, err := s.Get(id)
+	if err != nil {
+		return types.ListingRequest{}, err
+	}
+	if listing.Status != types.ListingStatusChecking {
+		return listing, nil
+	}
+
+	listing.ApplyChecks(s.checker.Check(ctx, listing), s.now().UTC())
+	s.Save(listing)
+	return listing, nil
+}
+
+// Review applies an operator's decision to a listing waiting for review, adding the token to the registry on approval
+func (s *ListingService) Review(ctx context.Context, id string, review types.ListingReview) (types.ListingRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listing, ok := s.listings[id]
+	if !ok {
+		return types.ListingRequest{}, types.ErrListingNotFound
+	}
+	if listing.Status != types.ListingStatusPendingReview {
+		return types.ListingRequest{}, fmt.Errorf("listing is %s, not pending review", listing.Status)
+	}
+
+	if review.Approved {
+		if err := ListToken(ctx, s.catalog, s.listed, listing); err != nil {
+			return types.ListingRequest{}, err
+		}
+	}
+	review.ReviewedAt = s.now().UTC()
+	listing.ApplyReview(review)
+	s.listings[id] = listing
+	return listing, nil
+}
+
+// ListToken adds an approved listing's token to the registry and saves it to store.
+// It succeeds if the token is already listed, so approvals can be retried.
+func ListToken(ctx context.Context, catalog TokenCatalog, store ListedTokenStore, listing types.ListingRequest) error {
+	token := listing.ListedToken()
+	if existing, err := catalog.GetToken(token.Symbol); err == nil {
+		if existing.ChainID != token.ChainID || !strings.EqualFold(existing.Address, token.Address) {
+			return fmt.Errorf("symbol %s was listed for another token since the request was checked", token.Symbol)
+		}
+	} else if err := catalog.AddToken(token); err != nil {
+		return err
+	}
+	if err := store.SaveListedToken(ctx, token); err != nil {
+		return fmt.Errorf("%w: %v", ErrListedTokenNotSaved, err)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+const (
+	testTokenAddress  = "0x1111111111111111111111111111111111111111"
+	testPoolAddress   = "0x2222222222222222222222222222222222222222"
+	testHolder        = "0x3333333333333333333333333333333333333333"
+	testRouterAddress = "0x5555555555555555555555555555555555555555"
+	testQuoteAddress  = "0x6666666666666666666666666666666666666666"
+)
+
+// fakeERC20 answers contract calls as an ERC-20 token paired with testQuoteAddress in a Uniswap V2 pool at
+// testPoolAddress, and as the router at testRouterAddress
+type fakeERC20 struct {
+	noCode        bool
+	decimals      int64
+	symbol        string
+	balances      map[string]int64 // map[lowercase address]balance
+	blocked       map[string]bool  // Senders whose transfers revert
+	honeypot      bool             // Router sells revert unless the seller is testHolder
+	balanceSlot   int64            // Declaration slot of the balances mapping
+	allowanceSlot int64            // Declaration slot of the allowances mapping
+}
+
+func newFakeERC20() *fakeERC20 {
+	return &fakeERC20{
+		decimals:      18,
+		symbol:        "NEW",
+		balances:      map[string]int64{testPoolAddress: 1_000_000, testHolder: 5_000},
+		blocked:       make(map[string]bool),
+		balanceSlot:   3,
+		allowanceSlot: 4,
+	}
+}
+
+func (f *fakeERC20) GetCode(ctx context.Context, chainID int64, address string) ([]byte, error) {
+	if f.noCode {
+		return nil, nil
+	}
+	return []byte{0x60, 0x80}, nil
+}
+
+func (f *fakeERC20) Call(ctx context.Context, chainID int64, from, to, data string) ([]byte, error) {
+	return f.CallWithStorage(ctx, chainID, from, to, data, nil)
+}
+
+func (f *fakeERC20) CallWithStorage(ctx context.Context, chainID int64, from, to, data string, storage StorageOverrides) ([]byte, error) {
+	word := func(n int64) []byte {
+		return new(big.Int).SetInt64(n).FillBytes(make([]byte, 32))
+	}
+	arg := func(i int) string {
+		return data[10+64*i : 10+64*(i+1)]
+	}
+	address := func(i int) string {
+		return "0x" + arg(i)[24:]
+	}
+	overridden := func(token, slot string) *big.Int {
+		value, ok := storage[token][slot]
+		if !ok {
+			return nil
+		}
+		n, _ := new(big.Int).SetString(strings.TrimPrefix(value, "0x"), 16)
+		return n
+	}
+
+	switch {
+	case to == testPoolAddress && data == pairToken0:
+		return append(make([]byte, 12), addressBytes(testQuoteAddress)...), nil
+	case to == testPoolAddress && data == pairToken1:
+		return append(make([]byte, 12), addressBytes(testTokenAddress)...), nil
+	case to == testRouterAddress && data[:10] == routerSwapExactTokens:
+		amount, _ := new(big.Int).SetString(arg(0), 16)
+		token := address(6)
+		balance := overridden(token, mappingSlot(from, f.balanceSlot))
+		allowance := overridden(token, nestedMappingSlot(from, testRouterAddress, f.allowanceSlot))
+		if balance == nil || balance.Cmp(amount) < 0 || allowance == nil || allowance.Cmp(amount) < 0 {
+			return nil, errors.New("execution reverted: TransferHelper: TRANSFER_FROM_FAILED")
+		}
+		if f.honeypot && !strings.EqualFold(from, testHolder) {
+			return nil, errors.New("execution reverted: UniswapV2: TRANSFER_FAILED")
+		}
+		return nil, nil
+	}
+
+	switch data[:10] {
+	case erc20Decimals:
+		return word(f.decimals), nil
+	case erc20Symbol:
+		padded := make([]byte, 32)
+		copy(padded, f.symbol)
+		return append(append(word(32), word(int64(len(f.symbol)))...), padded...), nil
+	case erc20BalanceOf:
+		if balance := overridden(to, mappingSlot(address(0), f.balanceSlot)); balance != nil {
+			return balance.FillBytes(make([]byte, 32)), nil
+		}
+		return word(f.balances[address(0)]), nil
+	case erc20Allowance:
+		if allowance := overridden(to, nestedMappingSlot(address(0), address(1), f.allowanceSlot)); allowance != nil {
+			return allowance.FillBytes(make([]byte, 32)), nil
+		}
+		return word(0), nil
+	case erc20Transfer:
+		if f.blocked[strings.ToLower(from)] {
+			return nil, errors.New("execution reverted")
+		}
+		return word(1), nil
+	}
+	return nil, errors.New("execution reverted")
+}
+
+// addressBytes decodes a hex address into its 20 bytes
+func addressBytes(address string) []byte {
+	raw, _ := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	return raw
+}
+
+// newTestListingChecker creates a checker simulating sells through the fake router
+func newTestListingChecker(caller ContractCaller, catalog TokenCatalog) *ListingChecker {
+	checker := NewListingChecker(caller, catalog, 50000)
+	checker.SetRouters(map[int64]string{1: testRouterAddress})
+	return checker
+}
+
+func testListing() types.ListingRequest {
+	return types.ListingRequest{
+		ID:               "listing-test",
+		Token:            types.Token{Symbol: "NEW", Name: "New Token", Decimals: 18, Address: testTokenAddress, ChainID: 1},
+		ProjectURL:       "https://new-token.example",
+		ContactEmail:     "team@new-token.example",
+		SubmitterAddress: testHolder,
+		Liquidity:        types.LiquidityCommitment{PoolAddress: testPoolAddress, AmountUSD: 100000, LockDays: 365},
+	}
+}
+
+// failedChecks returns the names of the checks that did not pass
+func failedChecks(checks []types.ListingCheck) []string {
+	var failed []string
+	for _, check := range checks {
+		if !check.Passed {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
+
+func TestListingChecker(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(token *fakeERC20, listing *types.ListingRequest, catalog *TokenService)
+		failed []string
+	}{
+		{
+			name:   "Clean",
+			modify: func(*fakeERC20, *types.ListingRequest, *TokenService) {},
+		},
+		{
+			name: "NoContract",
+			modify: func(token *fakeERC20, _ *types.ListingRequest, _ *TokenService) {
+				token.noCode = true
+			},
+			failed: []string{ListingCheckContract},
+		},
+		{
+			// Sells from the submitter are allowlisted, so only a sell from a fresh address catches it
+			name: "Honeypot",
+			modify: func(token *fakeERC20, _ *types.ListingRequest, _ *TokenService) {
+				token.honeypot = true
+			},
+			failed: []string{ListingCheckHoneypot},
+		},
+		{
+			name: "UnknownStorageLayout",
+			modify: func(token *fakeERC20, _ *types.ListingRequest, _ *TokenService) {
+				token.balanceSlot = maxStorageProbe + 1
+			},
+			failed: []string{ListingCheckHoneypot},
+		},
+		{
+			name: "EmptyPool",
+			modify: func(token *fakeERC20, _ *types.ListingRequest, _ *TokenService) {
+				delete(token.balances, testPoolAddress)
+			},
+			failed: []string{ListingCheckHoneypot},
+		},
+		{
+			name: "WrongMetadata",
+			modify: func(token *fakeERC20, _ *types.ListingRequest, _ *TokenService) {
+				token.decimals = 9
+				token.symbol = "OTHER"
+			},
+			failed: []string{ListingCheckDecimals, ListingCheckSymbol},
+		},
+		{
+			name: "ThinLiquidity",
+			modify: func(_ *fakeERC20, listing *types.ListingRequest, _ *TokenService) {
+				listing.Liquidity.AmountUSD = 10
+				listing.Liquidity.LockDays = 7
+			},
+			failed: []string{ListingCheckLiquidity, ListingCheckLiquidityLock},
+		},
+		{
+			name: "Duplicate",
+			modify: func(_ *fakeERC20, _ *types.ListingRequest, catalog *TokenService) {
+				catalog.AddToken(types.Token{Symbol: "OLD", Address: strings.ToUpper(testTokenAddress), ChainID: 1})
+			},
+			failed: []string{ListingCheckDuplicate},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := newFakeERC20()
+			listing := testListing()
+			catalog := NewTokenService()
+			tt.modify(token, &listing, catalog)
+
+			checks := newTestListingChecker(token, catalog).Check(context.Background(), listing)
+			failed := failedChecks(checks)
+			if strings.Join(failed, ",") != strings.Join(tt.failed, ",") {
+				t.Errorf("Expected failed checks %v, got %v (%+v)", tt.failed, failed, checks)
+			}
+		})
+	}
+}
+
+func TestListingService(t *testing.T) {
+	catalog := NewTokenService()
+	token := newFakeERC20()
+	service := NewListingService(catalog)
+	service.SetChecker(newTestListingChecker(token, catalog))
+	ctx := context.Background()
+
+	t.Run("Validation", func(t *testing.T) {
+		invalid := []func(*types.ListingRequest){
+			func(l *types.ListingRequest) { l.Token.Symbol = "" },
+			func(l *types.ListingRequest) { l.Token.Address = "0x1234" },
+			func(l *types.ListingRequest) { l.Token.ChainID = types.ChainIDSolana },
+			func(l *types.ListingRequest) { l.Liquidity.AmountUSD = 0 },
+			func(l *types.ListingRequest) { l.ContactEmail = "nobody" },
+			func(l *types.ListingRequest) { l.ProjectURL = "javascript:alert(1)" },
+		}
+		for i, modify := range invalid {
+			listing := testListing()
+			modify(&listing)
+			if _, err := service.Submit(listing); err == nil {
+				t.Errorf("Expected invalid listing %d to be refused", i)
+			}
+		}
+	})
+
+	t.Run("ApproveListsToken", func(t *testing.T) {
+		listing, err := service.Submit(testListing())
+		if err != nil {
+			t.Fatalf("Failed to submit listing: %v", err)
+		}
+		if listing.Status != types.ListingStatusChecking {
+			t.Errorf("Expected status %s, got %s", types.ListingStatusChecking, listing.Status)
+		}
+
+		listing, err = service.RunChecks(ctx, listing.ID)
+		if err != nil || listing.Status != types.ListingStatusPendingReview {
+			t.Fatalf("Expected listing to await review, got %s (%v, %s)", listing.Status, err, listing.Message)
+		}
+		if queue := service.List(types.ListingStatusPendingReview); len(queue) != 1 {
+			t.Errorf("Expected 1 listing in the review queue, got %d", len(queue))
+		}
+
+		listing, err = service.Review(ctx, listing.ID, types.ListingReview{Approved: true, Operator: "ops", Reason: "checks out"})
+		if err != nil || listing.Status != types.ListingStatusApproved {
+			t.Fatalf("Expected listing to be approved, got %s (%v)", listing.Status, err)
+		}
+		listed, err := catalog.GetToken("NEW")
+		if err != nil || listed.ChainName != "Ethereum" || listed.IsWrapped {
+			t.Errorf("Expected NEW to be in the registry, got %+v (%v)", listed, err)
+		}
+
+		if _, err := service.Review(ctx, listing.ID, types.ListingReview{Approved: false}); err == nil {
+			t.Error("Expected a second review to be refused")
+		}
+	})
+
+	t.Run("BlockingFailureRejects", func(t *testing.T) {
+		listing := testListing()
+		listing.Token.Symbol = "TRAP"
+		token.honeypot = true
+		defer func() { token.honeypot = false }()
+
+		listing, _ = service.Submit(listing)
+		listing, err := service.RunChecks(ctx, listing.ID)
+		if err != nil || listing.Status != types.ListingStatusRejected {
+			t.Fatalf("Expected honeypot to be rejected, got %s (%v)", listing.Status, err)
+		}
+		if !strings.Contains(listing.Message, ListingCheckHoneypot) {
+			t.Errorf("Expected the message to name the failed check, got %q", listing.Message)
+		}
+		if _, err := service.Review(ctx, listing.ID, types.ListingReview{Approved: true}); err == nil {
+			t.Error("Expected a rejected listing not to be reviewable")
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := service.Get("listing-missing"); !errors.Is(err, types.ErrListingNotFound) {
+			t.Errorf("Expected ErrListingNotFound, got %v", err)
+		}
+	})
+}
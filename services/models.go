@@ -3,6 +3,8 @@ package services
 import (
 	"math/big"
 	"time"
+
+	"github.com/infinity-dex/services/types"
 )
 
 // Token represents a cryptocurrency token
@@ -59,22 +61,26 @@ type Fee struct {
 
 // LiquidityPool represents a liquidity pool
 type LiquidityPool struct {
-	ID             string    `json:"id"`
-	Pair           TokenPair `json:"pair"`
-	TotalLiquidity *big.Int  `json:"totalLiquidity"`
-	TVL            float64   `json:"tvl"`
-	APR            float64   `json:"apr"`
-	FeeTier        int       `json:"feeTier"`
-	Address        string    `json:"address"`
+	ID             string             `json:"id"`
+	Pair           TokenPair          `json:"pair"`
+	TotalLiquidity *big.Int           `json:"totalLiquidity"`
+	TVL            float64            `json:"tvl"`
+	APR            float64            `json:"apr"`
+	FeeTier        int                `json:"feeTier"`
+	Address        string             `json:"address"`
+	FeesAccrued    types.TokenAmounts `json:"feesAccrued"` // Total swap fees credited to the pool, by token
+	Reserves       types.TokenAmounts `json:"reserves"`    // Token balances the pool fills swaps from, by token
 }
 
 // LiquidityPosition represents a user's position in a liquidity pool
 type LiquidityPosition struct {
-	PoolID      string   `json:"poolId"`
-	UserAddress string   `json:"userAddress"`
-	TokensOwned *big.Int `json:"tokensOwned"`
-	Share       float64  `json:"share"` // Percentage of pool owned
-	Value       float64  `json:"value"` // USD value of position
+	PoolID      string             `json:"poolId"`
+	UserAddress string             `json:"userAddress"`
+	TokensOwned *big.Int           `json:"tokensOwned"`
+	Share       float64            `json:"share"`       // Percentage of pool owned
+	Value       float64            `json:"value"`       // USD value of position
+	FeesOwed    types.TokenAmounts `json:"feesOwed"`    // Accrued fees not yet claimed, by token
+	FeesClaimed types.TokenAmounts `json:"feesClaimed"` // Fees claimed over the position's lifetime, by token
 }
 
 // Transaction represents a blockchain transaction
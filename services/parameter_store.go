@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// ParameterStore stores operator-managed parameters by name
+type ParameterStore interface {
+	// GetParameter returns a parameter, or types.ErrParameterNotFound
+	GetParameter(ctx context.Context, name string) (*types.Parameter, error)
+	// ListParameters returns the parameters whose names start with prefix, ordered by name
+	ListParameters(ctx context.Context, prefix string) ([]types.Parameter, error)
+	// SaveParameter stores a parameter as the next version of its name, starting at 1, and returns what was stored.
+	// The version is bumped atomically, so concurrent saves get distinct versions.
+	SaveParameter(ctx context.Context, param types.Parameter) (*types.Parameter, error)
+	// DeleteParameter removes a parameter, returning types.ErrParameterNotFound if there is none
+	DeleteParameter(ctx context.Context, name string) error
+}
+
+// InMemoryParameterStore is a ParameterStore for running without a database
+type InMemoryParameterStore struct {
+	params map[string]types.Parameter // map[name]Parameter
+	mu     sync.RWMutex
+}
+
+// NewInMemoryParameterStore creates an empty in-memory parameter store
+func NewInMemoryParameterStore() *InMemoryParameterStore {
+	return &InMemoryParameterStore{
+		params: make(map[string]types.Parameter),
+	}
+}
+
+// GetParameter returns a parameter
+func (s *InMemoryParameterStore) GetParameter(ctx context.Context, name string) (*types.Parameter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	param, ok := s.params[name]
+	if !ok {
+		return nil, types.ErrParameterNotFound
+	}
+	return &param, nil
+}
+
+// ListParameters returns the parameters whose names start with prefix
+func (s *InMemoryParameterStore) ListParameters(ctx context.Context, prefix string) ([]types.Parameter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var params []types.Parameter
+	for name, param := range s.params {
+		if strings.HasPrefix(name, prefix) {
+			params = append(params, param)
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params, nil
+}
+
+// SaveParameter stores the next version of a parameter, replacing the previous one
+func (s *InMemoryParameterStore) SaveParameter(ctx context.Context, param types.Parameter) (*types.Parameter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	param.Version = s.params[param.Name].Version + 1
+	s.params[param.Name] = param
+	return &param, nil
+}
+
+// DeleteParameter removes a parameter
+func (s *InMemoryParameterStore) DeleteParameter(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.params[name]; !ok {
+		return types.ErrParameterNotFound
+	}
+	delete(s.params, name)
+	return nil
+}
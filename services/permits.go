@@ -0,0 +1,196 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/chains/signer"
+	"github.com/infinity-dex/services/types"
+)
+
+// Permit errors
+var (
+	ErrPermitExpired = errors.New("permit has expired")
+	ErrPermitInvalid = errors.New("permit is invalid")
+)
+
+// EIP-712 domains and types of signed permits
+const (
+	eip2612DomainType    = "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"
+	eip2612PermitType    = "Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"
+	permit2DomainName    = "Permit2"
+	permit2DomainType    = "EIP712Domain(string name,uint256 chainId,address verifyingContract)"
+	permit2TransferType  = "PermitTransferFrom(TokenPermissions permitted,address spender,uint256 nonce,uint256 deadline)TokenPermissions(address token,uint256 amount)"
+	tokenPermissionsType = "TokenPermissions(address token,uint256 amount)"
+	defaultPermitVersion = "1"
+)
+
+// TypedData is EIP-712 typed data in the form eth_signTypedData_v4 takes it
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// TypedDataField is a member of an EIP-712 struct type
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// PermitTypedData returns the typed data permit's owner signs with eth_signTypedData_v4 to let its spender transfer
+// token. EIP-2612 permits are signed for the token's own domain, named after the token; Permit2 permits for the
+// Permit2 contract's, and only permit their spender to transfer once.
+func PermitTypedData(permit types.Permit, token types.Token) (*TypedData, error) {
+	if err := checkPermit(permit, token); err != nil {
+		return nil, err
+	}
+	chainID := types.CanonicalChainID(token.ChainID)
+	value := permit.Value.String()
+	nonce := permit.Nonce.String()
+	deadline := strconv.FormatInt(permit.Deadline, 10)
+
+	if permit.Kind == types.PermitEIP2612 {
+		return &TypedData{
+			Types: map[string][]TypedDataField{
+				"EIP712Domain": {{"name", "string"}, {"version", "string"}, {"chainId", "uint256"}, {"verifyingContract", "address"}},
+				"Permit":       {{"owner", "address"}, {"spender", "address"}, {"value", "uint256"}, {"nonce", "uint256"}, {"deadline", "uint256"}},
+			},
+			PrimaryType: "Permit",
+			Domain:      map[string]interface{}{"name": token.Name, "version": permitVersion(permit), "chainId": chainID, "verifyingContract": token.Address},
+			Message:     map[string]interface{}{"owner": permit.Owner, "spender": permit.Spender, "value": value, "nonce": nonce, "deadline": deadline},
+		}, nil
+	}
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain":       {{"name", "string"}, {"chainId", "uint256"}, {"verifyingContract", "address"}},
+			"PermitTransferFrom": {{"permitted", "TokenPermissions"}, {"spender", "address"}, {"nonce", "uint256"}, {"deadline", "uint256"}},
+			"TokenPermissions":   {{"token", "address"}, {"amount", "uint256"}},
+		},
+		PrimaryType: "PermitTransferFrom",
+		Domain:      map[string]interface{}{"name": permit2DomainName, "chainId": chainID, "verifyingContract": types.Permit2Address},
+		Message: map[string]interface{}{
+			"permitted": map[string]interface{}{"token": token.Address, "amount": value},
+			"spender":   permit.Spender,
+			"nonce":     nonce,
+			"deadline":  deadline,
+		},
+	}, nil
+}
+
+// PermitDigest returns the EIP-712 digest of the typed data PermitTypedData returns, which the owner signs
+func PermitDigest(permit types.Permit, token types.Token) ([]byte, error) {
+	if err := checkPermit(permit, token); err != nil {
+		return nil, err
+	}
+	chainID := big.NewInt(types.CanonicalChainID(token.ChainID))
+	deadline := big.NewInt(permit.Deadline)
+
+	var domain, message []byte
+	var err error
+	if permit.Kind == types.PermitEIP2612 {
+		if domain, err = typedStruct(eip2612DomainType, evm.Keccak256([]byte(token.Name)), evm.Keccak256([]byte(permitVersion(permit))), chainID, token.Address); err != nil {
+			return nil, err
+		}
+		message, err = typedStruct(eip2612PermitType, permit.Owner, permit.Spender, permit.Value, permit.Nonce, deadline)
+	} else {
+		if domain, err = typedStruct(permit2DomainType, evm.Keccak256([]byte(permit2DomainName)), chainID, types.Permit2Address); err != nil {
+			return nil, err
+		}
+		var permitted []byte
+		if permitted, err = typedStruct(tokenPermissionsType, token.Address, permit.Value); err != nil {
+			return nil, err
+		}
+		message, err = typedStruct(permit2TransferType, permitted, permit.Spender, permit.Nonce, deadline)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signer.TypedDataDigest(domain, message), nil
+}
+
+// VerifyPermit checks that permit was signed by its owner for token and is still valid at now. Whether the owner
+// still holds the token, or already used the permit's nonce, is only known on-chain.
+func VerifyPermit(permit types.Permit, token types.Token, now time.Time) error {
+	if !now.Before(time.Unix(permit.Deadline, 0)) {
+		return ErrPermitExpired
+	}
+	digest, err := PermitDigest(permit, token)
+	if err != nil {
+		return err
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(permit.Signature, "0x"))
+	if err != nil {
+		return fmt.Errorf("%w: signature is not hex", ErrPermitInvalid)
+	}
+	recovered, err := signer.RecoverAddress(digest, signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermitInvalid, err)
+	}
+	if !strings.EqualFold(recovered, permit.Owner) {
+		return fmt.Errorf("%w: signed by %s", ErrPermitInvalid, recovered)
+	}
+	return nil
+}
+
+// checkPermit checks permit is of a known kind and fully filled in for an ERC-20 token
+func checkPermit(permit types.Permit, token types.Token) error {
+	if permit.Kind != types.PermitEIP2612 && permit.Kind != types.PermitPermit2 {
+		return fmt.Errorf("%w: unknown kind %q", ErrPermitInvalid, permit.Kind)
+	}
+	address, err := evm.EncodeArgs(token.Address)
+	if err != nil || new(big.Int).SetBytes(address).Sign() == 0 {
+		return fmt.Errorf("%w: %s is not an ERC-20 token", ErrPermitInvalid, token.Symbol)
+	}
+	if permit.Kind == types.PermitEIP2612 && token.Name == "" {
+		return fmt.Errorf("%w: %s has no name to sign its domain with", ErrPermitInvalid, token.Symbol)
+	}
+	for name, address := range map[string]string{"owner": permit.Owner, "spender": permit.Spender} {
+		if _, err := evmAddressBytes(address); err != nil {
+			return fmt.Errorf("%w: %s %q is not an EVM address", ErrPermitInvalid, name, address)
+		}
+	}
+	if permit.Value == nil || permit.Value.Sign() <= 0 {
+		return fmt.Errorf("%w: value must be greater than zero", ErrPermitInvalid)
+	}
+	if permit.Nonce == nil || permit.Nonce.Sign() < 0 {
+		return fmt.Errorf("%w: nonce must not be negative", ErrPermitInvalid)
+	}
+	if permit.Deadline <= 0 {
+		return fmt.Errorf("%w: deadline must be a Unix time", ErrPermitInvalid)
+	}
+	return nil
+}
+
+// typedStruct returns the EIP-712 hash of a struct of type typ: the keccak256 of its type hash followed by its
+// members, each a 32-byte hash or a value EncodeArgs encodes
+func typedStruct(typ string, members ...interface{}) ([]byte, error) {
+	encoded := evm.Keccak256([]byte(typ))
+	for _, member := range members {
+		if hash, ok := member.([]byte); ok {
+			encoded = append(encoded, hash...)
+			continue
+		}
+		word, err := evm.EncodeArgs(member)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPermitInvalid, err)
+		}
+		encoded = append(encoded, word...)
+	}
+	return evm.Keccak256(encoded), nil
+}
+
+// permitVersion returns the EIP-2612 domain version a permit is signed for
+func permitVersion(permit types.Permit) string {
+	if permit.Version == "" {
+		return defaultPermitVersion
+	}
+	return permit.Version
+}
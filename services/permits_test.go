@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/chains/signer"
+	"github.com/infinity-dex/services/types"
+)
+
+// signPermit signs permit of token with key, as the owner's wallet signs its typed data
+func signPermit(t *testing.T, key *signer.PrivateKeySigner, permit types.Permit, token types.Token) types.Permit {
+	t.Helper()
+	digest, err := PermitDigest(permit, token)
+	if err != nil {
+		t.Fatalf("Failed to hash permit: %v", err)
+	}
+	signature, err := key.SignDigest(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	raw := make([]byte, 65)
+	signature.R.FillBytes(raw[:32])
+	signature.S.FillBytes(raw[32:64])
+	raw[64] = 27 + signature.Recovery
+	permit.Signature = "0x" + hex.EncodeToString(raw)
+	return permit
+}
+
+func TestPermitTypeHashes(t *testing.T) {
+	// The type hashes the token and Permit2 contracts check signatures with
+	for typ, expected := range map[string]string{
+		eip2612PermitType:    "6e71edae12b1b97f4d1f60370fef10105fa2faae0126114a169c64845d6126c9",
+		permit2TransferType:  "939c21a48a8dbe3a9a2404a1d46691e4d39f6583d6ec6b35714604c986d80106",
+		tokenPermissionsType: "618358ac3db8dc274f0cd8829da7e234bd48cd73c4a740aede1adec9846d06a1",
+	} {
+		if hash := hex.EncodeToString(evm.Keccak256([]byte(typ))); hash != expected {
+			t.Errorf("Expected the type hash of %s to be %s, got %s", typ, expected, hash)
+		}
+	}
+}
+
+func TestVerifyPermit(t *testing.T) {
+	key, err := signer.NewPrivateKeySigner("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	other, _ := signer.NewPrivateKeySigner("0101010101010101010101010101010101010101010101010101010101010101")
+	usdc := types.Token{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: 1, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}
+	now := time.Unix(1790000000, 0)
+
+	for _, kind := range []string{types.PermitEIP2612, types.PermitPermit2} {
+		permit := signPermit(t, key, types.Permit{
+			Kind:     kind,
+			Owner:    key.Address(),
+			Spender:  "0x2222222222222222222222222222222222222222",
+			Value:    big.NewInt(1000),
+			Nonce:    big.NewInt(7),
+			Deadline: now.Add(10 * time.Minute).Unix(),
+		}, usdc)
+		if err := VerifyPermit(permit, usdc, now); err != nil {
+			t.Errorf("Expected a valid %s permit, got %v", kind, err)
+		}
+		if err := VerifyPermit(permit, usdc, now.Add(10*time.Minute)); !errors.Is(err, ErrPermitExpired) {
+			t.Errorf("Expected an expired %s permit to be rejected, got %v", kind, err)
+		}
+
+		// The signature covers the value, spender and token
+		raised := permit
+		raised.Value = big.NewInt(1001)
+		spender := permit
+		spender.Spender = "0x3333333333333333333333333333333333333333"
+		token := usdc
+		token.Address = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+		for name, check := range map[string]error{
+			"raised value":  VerifyPermit(raised, usdc, now),
+			"other spender": VerifyPermit(spender, usdc, now),
+			"other token":   VerifyPermit(permit, token, now),
+		} {
+			if !errors.Is(check, ErrPermitInvalid) {
+				t.Errorf("Expected a %s permit with a(n) %s to be rejected, got %v", kind, name, check)
+			}
+		}
+
+		// Signed by someone other than the owner
+		forged := permit
+		forged.Owner = other.Address()
+		if err := VerifyPermit(forged, usdc, now); !errors.Is(err, ErrPermitInvalid) {
+			t.Errorf("Expected a %s permit signed by another key to be rejected, got %v", kind, err)
+		}
+	}
+
+	// EIP-2612 permits are signed for the token's version
+	permit := signPermit(t, key, types.Permit{Kind: types.PermitEIP2612, Owner: key.Address(), Spender: key.Address(), Value: big.NewInt(1), Nonce: big.NewInt(0), Deadline: now.Add(time.Minute).Unix(), Version: "2"}, usdc)
+	permit.Version = ""
+	if err := VerifyPermit(permit, usdc, now); !errors.Is(err, ErrPermitInvalid) {
+		t.Errorf("Expected a permit checked against another version to be rejected, got %v", err)
+	}
+
+	native := usdc
+	native.Address = "0x0000000000000000000000000000000000000000"
+	if _, err := PermitTypedData(types.Permit{Kind: types.PermitPermit2, Owner: key.Address(), Spender: key.Address(), Value: big.NewInt(1), Nonce: big.NewInt(0), Deadline: 1}, native); !errors.Is(err, ErrPermitInvalid) {
+		t.Errorf("Expected native tokens to have no permit, got %v", err)
+	}
+}
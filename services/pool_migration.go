@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// ErrPoolNotMigratable is returned when a pool's positions can't be moved into another pool
+var ErrPoolNotMigratable = errors.New("pool can't be migrated")
+
+// ErrPoolChangedSinceMigrationPlan is returned when a pool's positions or reserves changed between planning
+// its migration and executing it
+var ErrPoolChangedSinceMigrationPlan = errors.New("pool changed since its migration was planned")
+
+// PlanPoolMigration reports what moving every position and reserve of the source pool into the target pool would
+// do, without changing either. The pools must hold the same tokens on the same chains, and the source must hold
+// positions and no liquidity reserved by executing swaps.
+func (s *LiquidityService) PlanPoolMigration(ctx context.Context, sourcePoolID, targetPoolID string) (*types.PoolMigrationPlan, error) {
+	if sourcePoolID == targetPoolID {
+		return nil, fmt.Errorf("%w: source and target are the same pool", ErrPoolNotMigratable)
+	}
+
+	store := s.poolStore()
+	source, err := store.GetPool(ctx, sourcePoolID)
+	if err != nil {
+		return nil, fmt.Errorf("source pool %s: %w", sourcePoolID, err)
+	}
+	target, err := store.GetPool(ctx, targetPoolID)
+	if err != nil {
+		return nil, fmt.Errorf("target pool %s: %w", targetPoolID, err)
+	}
+
+	if err := checkSameTokens(source.Pool.Pair, target.Pool.Pair); err != nil {
+		return nil, err
+	}
+	if err := checkUnreserved(source, time.Now()); err != nil {
+		return nil, err
+	}
+	if len(source.Positions) == 0 {
+		return nil, fmt.Errorf("%w: pool %s has no positions", ErrPoolNotMigratable, sourcePoolID)
+	}
+
+	plan := types.PoolMigrationPlan{
+		SourcePoolID: sourcePoolID,
+		TargetPoolID: targetPoolID,
+		Liquidity:    big.NewInt(0),
+		Reserves:     nonNilAmounts(source.Pool.Reserves),
+	}
+	for _, pos := range source.Positions {
+		plan.Liquidity.Add(plan.Liquidity, pos.TokensOwned)
+		plan.Positions = append(plan.Positions, types.PoolMigrationPosition{
+			UserAddress: pos.UserAddress,
+			TokensOwned: new(big.Int).Set(pos.TokensOwned),
+			SourceShare: poolShare(pos.TokensOwned, source.Pool.TotalLiquidity),
+			FeesOwed:    nonNilAmounts(pos.FeesOwed),
+			FeesClaimed: nonNilAmounts(pos.FeesClaimed),
+		})
+	}
+
+	// Apply the migration to the copy of the target the store returned to report where it leaves each position
+	creditMigration(target, plan)
+	plan.TargetLiquidity = target.Pool.TotalLiquidity
+	plan.TargetReserves = target.Pool.Reserves
+	for i, planned := range plan.Positions {
+		for _, pos := range target.Positions {
+			if pos.UserAddress == planned.UserAddress {
+				plan.Positions[i].TargetTokensOwned = pos.TokensOwned
+				plan.Positions[i].TargetShare = pos.Share
+			}
+		}
+	}
+
+	return &plan, nil
+}
+
+// DrainPoolOnce removes the planned positions and reserves from a migration's source pool as the operation of an
+// ID, so retrying it doesn't drain the pool twice. It fails with ErrPoolChangedSinceMigrationPlan unless the pool
+// still holds exactly what was planned.
+func (s *LiquidityService) DrainPoolOnce(ctx context.Context, operationID string, plan types.PoolMigrationPlan) error {
+	_, err := s.poolStore().UpdatePool(ctx, plan.SourcePoolID, operationID, func(state *types.PoolState) error {
+		if err := checkUnreserved(state, time.Now()); err != nil {
+			return err
+		}
+		if !matchesPlan(state, plan) {
+			return ErrPoolChangedSinceMigrationPlan
+		}
+
+		state.Positions = nil
+		state.Pool.Reserves = types.TokenAmounts{}
+		state.Pool.TotalLiquidity = new(big.Int).Sub(state.Pool.TotalLiquidity, plan.Liquidity)
+		state.Pool.TVL = 0
+		state.Pool.APR = 0
+		return nil
+	})
+	return err
+}
+
+// CreditPoolOnce adds a migration's planned positions and reserves to a pool as the operation of an ID, so
+// retrying it doesn't add them twice. Crediting the target completes a migration; crediting the source undoes
+// its drain.
+func (s *LiquidityService) CreditPoolOnce(ctx context.Context, operationID string, poolID string, plan types.PoolMigrationPlan) error {
+	_, err := s.poolStore().UpdatePool(ctx, poolID, operationID, func(state *types.PoolState) error {
+		creditMigration(state, plan)
+		return nil
+	})
+	return err
+}
+
+// creditMigration adds a migration's positions and reserves to a pool's state, topping up the positions users
+// already hold there
+func creditMigration(state *types.PoolState, plan types.PoolMigrationPlan) {
+	for symbol, amount := range plan.Reserves {
+		state.Pool.Reserves = state.Pool.Reserves.Add(symbol, amount)
+	}
+	state.Pool.TotalLiquidity = new(big.Int).Add(state.Pool.TotalLiquidity, plan.Liquidity)
+
+	for _, planned := range plan.Positions {
+		found := false
+		for i, pos := range state.Positions {
+			if pos.UserAddress != planned.UserAddress {
+				continue
+			}
+			state.Positions[i].TokensOwned = new(big.Int).Add(pos.TokensOwned, planned.TokensOwned)
+			for symbol, amount := range planned.FeesOwed {
+				state.Positions[i].FeesOwed = state.Positions[i].FeesOwed.Add(symbol, amount)
+			}
+			for symbol, amount := range planned.FeesClaimed {
+				state.Positions[i].FeesClaimed = state.Positions[i].FeesClaimed.Add(symbol, amount)
+			}
+			found = true
+			break
+		}
+		if !found {
+			state.Positions = append(state.Positions, types.LiquidityPosition{
+				PoolID:      state.Pool.ID,
+				UserAddress: planned.UserAddress,
+				TokensOwned: new(big.Int).Set(planned.TokensOwned),
+				FeesOwed:    nonNilAmounts(planned.FeesOwed),
+				FeesClaimed: nonNilAmounts(planned.FeesClaimed),
+			})
+		}
+	}
+
+	// Every share changes with the pool's total liquidity
+	for i, pos := range state.Positions {
+		state.Positions[i].Share = poolShare(pos.TokensOwned, state.Pool.TotalLiquidity)
+	}
+}
+
+// checkSameTokens returns an error wrapping ErrPoolNotMigratable unless two pairs hold the same tokens on the same
+// chains, in either order. Token addresses may differ.
+func checkSameTokens(source, target types.TokenPair) error {
+	chains := map[string]int64{
+		target.BaseToken.Symbol:  target.BaseToken.ChainID,
+		target.QuoteToken.Symbol: target.QuoteToken.ChainID,
+	}
+	for _, token := range []types.Token{source.BaseToken, source.QuoteToken} {
+		chainID, ok := chains[token.Symbol]
+		if !ok {
+			return fmt.Errorf("%w: target pool doesn't hold %s", ErrPoolNotMigratable, token.Symbol)
+		}
+		if chainID != token.ChainID {
+			return fmt.Errorf("%w: %s is on chain %d in the source pool and chain %d in the target", ErrPoolNotMigratable, token.Symbol, token.ChainID, chainID)
+		}
+	}
+	return nil
+}
+
+// checkUnreserved returns an error wrapping ErrPoolNotMigratable while executing swaps hold some of a pool's
+// reserves, dropping expired reservations from its state
+func checkUnreserved(state *types.PoolState, now time.Time) error {
+	for swapID, reservation := range state.Reservations {
+		if now.After(reservation.ExpiresAt) {
+			delete(state.Reservations, swapID)
+		}
+	}
+	if len(state.Reservations) > 0 {
+		return fmt.Errorf("%w: %d executing swaps hold liquidity of pool %s", ErrPoolNotMigratable, len(state.Reservations), state.Pool.ID)
+	}
+	return nil
+}
+
+// matchesPlan reports whether a pool still holds exactly the positions and reserves a migration plan moves
+func matchesPlan(state *types.PoolState, plan types.PoolMigrationPlan) bool {
+	if len(state.Positions) != len(plan.Positions) || !sameAmounts(state.Pool.Reserves, plan.Reserves) {
+		return false
+	}
+	planned := make(map[string]types.PoolMigrationPosition, len(plan.Positions))
+	for _, pos := range plan.Positions {
+		planned[pos.UserAddress] = pos
+	}
+	for _, pos := range state.Positions {
+		want, ok := planned[pos.UserAddress]
+		if !ok || pos.TokensOwned.Cmp(want.TokensOwned) != 0 || !sameAmounts(pos.FeesOwed, want.FeesOwed) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameAmounts reports whether two sets of amounts are equal, treating missing amounts as zero
+func sameAmounts(a, b types.TokenAmounts) bool {
+	for _, amounts := range [][2]types.TokenAmounts{{a, b}, {b, a}} {
+		for symbol, amount := range amounts[0] {
+			other := amounts[1][symbol]
+			if other == nil {
+				other = big.NewInt(0)
+			}
+			if amount.Cmp(other) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// poolShare returns the percentage of a pool's total liquidity owned
+func poolShare(owned, total *big.Int) float64 {
+	if total == nil || total.Sign() <= 0 {
+		return 0
+	}
+	share := new(big.Float).SetInt(owned)
+	share.Quo(share, new(big.Float).SetInt(total))
+	share.Mul(share, big.NewFloat(100.0))
+	result, _ := share.Float64()
+	return result
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPoolMigration(t *testing.T) {
+	ctx := context.Background()
+	service := NewLiquidityService()
+
+	pair := TokenPair{
+		BaseToken:  Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: Token{Symbol: "USDC", ChainID: 1},
+	}
+	// The target lists the pair the other way around, with a new USDC address
+	reversed := TokenPair{
+		BaseToken:  Token{Symbol: "USDC", ChainID: 1, Address: "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359"},
+		QuoteToken: Token{Symbol: "ETH", ChainID: 1},
+	}
+	for id, p := range map[string]TokenPair{"eth-usdc-3000": pair, "eth-usdc-500": reversed} {
+		if err := service.SeedPool(ctx, id, p, 3000, ""); err != nil {
+			t.Fatalf("Failed to seed pool %s: %v", id, err)
+		}
+	}
+
+	alice := "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	bob := "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	carol := "0xcccccccccccccccccccccccccccccccccccccccc"
+	mustAdd := func(poolID, user, token string, amount int64) {
+		t.Helper()
+		if _, err := service.AddLiquidityOnce(ctx, "", poolID, user, token, big.NewInt(amount)); err != nil {
+			t.Fatalf("Failed to add liquidity: %v", err)
+		}
+	}
+	mustAdd("eth-usdc-3000", alice, "ETH", 3000)
+	mustAdd("eth-usdc-3000", bob, "USDC", 1000)
+	mustAdd("eth-usdc-500", alice, "USDC", 2000)
+	mustAdd("eth-usdc-500", carol, "ETH", 2000)
+	if _, err := service.AccrueFees(ctx, "eth-usdc-3000", "swap-1", "USDC", big.NewInt(1_000_000)); err != nil {
+		t.Fatalf("Failed to accrue fees: %v", err)
+	}
+
+	plan, err := service.PlanPoolMigration(ctx, "eth-usdc-3000", "eth-usdc-500")
+	if err != nil {
+		t.Fatalf("Failed to plan migration: %v", err)
+	}
+	if plan.Liquidity.Int64() != 4000 || plan.TargetLiquidity.Int64() != 8000 {
+		t.Errorf("Expected 4000 LP tokens moved into a pool of 8000, got %s and %s", plan.Liquidity, plan.TargetLiquidity)
+	}
+	if plan.TargetReserves["ETH"].Int64() != 5000 || plan.TargetReserves["USDC"].Int64() != 3000 {
+		t.Errorf("Unexpected target reserves %v", plan.TargetReserves)
+	}
+	for _, pos := range plan.Positions {
+		switch pos.UserAddress {
+		case alice:
+			// Alice's 3000 join the 2000 she already holds in the target
+			if pos.SourceShare != 75 || pos.TargetTokensOwned.Int64() != 5000 || pos.TargetShare != 62.5 {
+				t.Errorf("Unexpected plan for alice: %+v", pos)
+			}
+		case bob:
+			if pos.TargetTokensOwned.Int64() != 1000 || pos.TargetShare != 12.5 {
+				t.Errorf("Unexpected plan for bob: %+v", pos)
+			}
+			if pos.FeesOwed["USDC"].Int64() != 750 {
+				t.Errorf("Expected bob's unclaimed fees to move with him, got %v", pos.FeesOwed)
+			}
+		default:
+			t.Errorf("Unexpected position of %s", pos.UserAddress)
+		}
+	}
+
+	// Planning changes nothing
+	if positions, _ := service.GetPoolPositions(ctx, "eth-usdc-500"); len(positions) != 2 {
+		t.Fatalf("Expected the dry run to leave the target alone, got %d positions", len(positions))
+	}
+
+	// Retried steps apply once
+	for i := 0; i < 2; i++ {
+		if err := service.DrainPoolOnce(ctx, "migrate-out:m1", *plan); err != nil {
+			t.Fatalf("Failed to drain source: %v", err)
+		}
+		if err := service.CreditPoolOnce(ctx, "migrate-in:m1", plan.TargetPoolID, *plan); err != nil {
+			t.Fatalf("Failed to credit target: %v", err)
+		}
+	}
+
+	source, _ := service.GetPool(ctx, "eth-usdc-3000")
+	if source.TotalLiquidity.Sign() != 0 || len(source.Reserves) != 0 {
+		t.Errorf("Expected the source to be empty, got %s liquidity and reserves %v", source.TotalLiquidity, source.Reserves)
+	}
+	if positions, _ := service.GetPoolPositions(ctx, "eth-usdc-3000"); len(positions) != 0 {
+		t.Errorf("Expected no positions left in the source, got %d", len(positions))
+	}
+
+	target, _ := service.GetPool(ctx, "eth-usdc-500")
+	if target.TotalLiquidity.Cmp(plan.TargetLiquidity) != 0 || !sameAmounts(target.Reserves, plan.TargetReserves) {
+		t.Errorf("Expected the target to match the plan, got %s liquidity and reserves %v", target.TotalLiquidity, target.Reserves)
+	}
+	positions, _ := service.GetPoolPositions(ctx, "eth-usdc-500")
+	if len(positions) != 3 {
+		t.Fatalf("Expected alice, carol and bob in the target, got %d positions", len(positions))
+	}
+	for _, pos := range positions {
+		if pos.UserAddress == carol && pos.Share != 25 {
+			t.Errorf("Expected carol diluted to 25%%, got %v", pos.Share)
+		}
+		if pos.PoolID != "eth-usdc-500" {
+			t.Errorf("Expected migrated positions to belong to the target, got %s", pos.PoolID)
+		}
+	}
+	fees, _ := service.GetPendingFees(ctx, "eth-usdc-500", bob)
+	if fees["USDC"].Int64() != 750 {
+		t.Errorf("Expected bob to claim his fees from the target, got %v", fees)
+	}
+
+	// Nothing is left to migrate
+	if _, err := service.PlanPoolMigration(ctx, "eth-usdc-3000", "eth-usdc-500"); !errors.Is(err, ErrPoolNotMigratable) {
+		t.Errorf("Expected an empty pool not to be migratable, got %v", err)
+	}
+}
+
+func TestPoolMigrationRefusals(t *testing.T) {
+	ctx := context.Background()
+	service := NewLiquidityService()
+
+	ethUSDC := TokenPair{BaseToken: Token{Symbol: "ETH", ChainID: 1}, QuoteToken: Token{Symbol: "USDC", ChainID: 1}}
+	pools := map[string]TokenPair{
+		"eth-usdc-3000":    ethUSDC,
+		"eth-usdc-500":     ethUSDC,
+		"eth-usdt-3000":    {BaseToken: Token{Symbol: "ETH", ChainID: 1}, QuoteToken: Token{Symbol: "USDT", ChainID: 1}},
+		"eth-usdc-polygon": {BaseToken: Token{Symbol: "ETH", ChainID: 137}, QuoteToken: Token{Symbol: "USDC", ChainID: 137}},
+	}
+	for id, pair := range pools {
+		if err := service.SeedPool(ctx, id, pair, 3000, ""); err != nil {
+			t.Fatalf("Failed to seed pool %s: %v", id, err)
+		}
+	}
+	alice := "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	if _, err := service.AddLiquidityOnce(ctx, "", "eth-usdc-3000", alice, "USDC", big.NewInt(5000)); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+
+	for _, target := range []string{"eth-usdc-3000", "eth-usdt-3000", "eth-usdc-polygon"} {
+		if _, err := service.PlanPoolMigration(ctx, "eth-usdc-3000", target); !errors.Is(err, ErrPoolNotMigratable) {
+			t.Errorf("Expected migrating into %s to be refused, got %v", target, err)
+		}
+	}
+	if _, err := service.PlanPoolMigration(ctx, "eth-usdc-3000", "missing"); err == nil {
+		t.Error("Expected a missing target to be refused")
+	}
+
+	// Swaps holding the source's reserves must settle first
+	if err := service.ReserveLiquidity(ctx, "eth-usdc-3000", "swap-1", "USDC", big.NewInt(100)); err != nil {
+		t.Fatalf("Failed to reserve liquidity: %v", err)
+	}
+	if _, err := service.PlanPoolMigration(ctx, "eth-usdc-3000", "eth-usdc-500"); !errors.Is(err, ErrPoolNotMigratable) {
+		t.Errorf("Expected a pool with reservations not to be migratable, got %v", err)
+	}
+	service.ReleaseLiquidity(ctx, "swap-1")
+
+	// A plan made stale by a later deposit isn't executed
+	plan, err := service.PlanPoolMigration(ctx, "eth-usdc-3000", "eth-usdc-500")
+	if err != nil {
+		t.Fatalf("Failed to plan migration: %v", err)
+	}
+	if _, err := service.AddLiquidityOnce(ctx, "", "eth-usdc-3000", alice, "ETH", big.NewInt(10)); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+	if err := service.DrainPoolOnce(ctx, "migrate-out:m2", *plan); !errors.Is(err, ErrPoolChangedSinceMigrationPlan) {
+		t.Errorf("Expected a stale plan to be refused, got %v", err)
+	}
+
+	// Crediting the source back undoes a drain
+	plan, _ = service.PlanPoolMigration(ctx, "eth-usdc-3000", "eth-usdc-500")
+	if err := service.DrainPoolOnce(ctx, "migrate-out:m3", *plan); err != nil {
+		t.Fatalf("Failed to drain source: %v", err)
+	}
+	if err := service.CreditPoolOnce(ctx, "migrate-restore:m3", plan.SourcePoolID, *plan); err != nil {
+		t.Fatalf("Failed to restore source: %v", err)
+	}
+	positions, _ := service.GetPoolPositions(ctx, "eth-usdc-3000")
+	if len(positions) != 1 || positions[0].TokensOwned.Int64() != 5010 || positions[0].Share != 100 {
+		t.Errorf("Expected alice's position restored, got %+v", positions)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// PoolStore persists liquidity pools with their positions and reservations, so the API server and
+// the swap worker share one view of every pool
+type PoolStore interface {
+	// CreatePool stores a new pool. It reports false, changing nothing, when a pool with the same ID exists.
+	CreatePool(ctx context.Context, state types.PoolState) (bool, error)
+	// GetPool returns a pool's state, or types.ErrPoolNotFound
+	GetPool(ctx context.Context, poolID string) (*types.PoolState, error)
+	// ListPools returns the state of every pool
+	ListPools(ctx context.Context) ([]types.PoolState, error)
+	// UpdatePool applies update to a pool's state atomically, storing the result unless update fails.
+	// A non-empty operationID is recorded with the update; when it was recorded before, update isn't
+	// called and UpdatePool reports false.
+	UpdatePool(ctx context.Context, poolID, operationID string, update func(state *types.PoolState) error) (bool, error)
+}
+
+// InMemoryPoolStore is a PoolStore for running without a database
+type InMemoryPoolStore struct {
+	pools      map[string]types.PoolState // by pool ID
+	operations map[string]bool            // applied operation IDs
+	mu         sync.Mutex
+}
+
+// NewInMemoryPoolStore creates an empty in-memory pool store
+func NewInMemoryPoolStore() *InMemoryPoolStore {
+	return &InMemoryPoolStore{
+		pools:      make(map[string]types.PoolState),
+		operations: make(map[string]bool),
+	}
+}
+
+// CreatePool stores a new pool
+func (s *InMemoryPoolStore) CreatePool(ctx context.Context, state types.PoolState) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.pools[state.Pool.ID]; exists {
+		return false, nil
+	}
+	s.pools[state.Pool.ID] = state.Clone()
+	return true, nil
+}
+
+// GetPool returns a copy of a pool's state
+func (s *InMemoryPoolStore) GetPool(ctx context.Context, poolID string) (*types.PoolState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, exists := s.pools[poolID]
+	if !exists {
+		return nil, types.ErrPoolNotFound
+	}
+	clone := state.Clone()
+	return &clone, nil
+}
+
+// ListPools returns copies of every pool's state, ordered by pool ID
+func (s *InMemoryPoolStore) ListPools(ctx context.Context) ([]types.PoolState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make([]types.PoolState, 0, len(s.pools))
+	for _, state := range s.pools {
+		states = append(states, state.Clone())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Pool.ID < states[j].Pool.ID })
+	return states, nil
+}
+
+// UpdatePool applies update to a copy of a pool's state and keeps the copy if update succeeds
+func (s *InMemoryPoolStore) UpdatePool(ctx context.Context, poolID, operationID string, update func(state *types.PoolState) error) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, exists := s.pools[poolID]
+	if !exists {
+		return false, types.ErrPoolNotFound
+	}
+	if operationID != "" && s.operations[operationID] {
+		return false, nil
+	}
+
+	updated := state.Clone()
+	if err := update(&updated); err != nil {
+		return false, err
+	}
+	s.pools[poolID] = updated
+	if operationID != "" {
+		s.operations[operationID] = true
+	}
+	return true, nil
+}
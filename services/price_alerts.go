@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/services/types"
+)
+
+// PriceAlertTriggeredWebhookEvent is the event of the webhook POSTed when a price alert fires
+const PriceAlertTriggeredWebhookEvent = "price_alert.triggered"
+
+// MaxPriceAlerts is the most active alerts an API key may have registered
+const MaxPriceAlerts = 100
+
+// PriceAlertStore stores the price alerts clients registered, by API key ID
+type PriceAlertStore interface {
+	// CreatePriceAlert saves a new alert
+	CreatePriceAlert(ctx context.Context, alert types.PriceAlert) error
+	// GetPriceAlert returns an owner's alert, or types.ErrPriceAlertNotFound
+	GetPriceAlert(ctx context.Context, owner, id string) (types.PriceAlert, error)
+	// ListPriceAlerts returns an owner's alerts, newest first
+	ListPriceAlerts(ctx context.Context, owner string) ([]types.PriceAlert, error)
+	// DeletePriceAlert deletes an owner's alert, or returns types.ErrPriceAlertNotFound
+	DeletePriceAlert(ctx context.Context, owner, id string) error
+	// TriggerPriceAlerts marks the active alerts prices crossed as triggered at a time and returns them. Each alert
+	// is returned by one call only, however many evaluate it at once.
+	TriggerPriceAlerts(ctx context.Context, prices []types.TokenPrice, at time.Time) ([]types.PriceAlert, error)
+	// HasActivePriceAlerts reports whether any alert is still active
+	HasActivePriceAlerts(ctx context.Context) (bool, error)
+}
+
+// NewPriceAlert validates an alert an owner registers and returns it ready to save, active from now
+func NewPriceAlert(owner string, alert types.PriceAlert, now time.Time) (types.PriceAlert, error) {
+	alert.Symbol = strings.ToUpper(strings.TrimSpace(alert.Symbol))
+	if alert.Symbol == "" {
+		return types.PriceAlert{}, errors.New("symbol is required")
+	}
+	if alert.ChainID != 0 {
+		alert.ChainID = types.CanonicalChainID(alert.ChainID)
+	}
+	if alert.Threshold <= 0 {
+		return types.PriceAlert{}, errors.New("threshold must be a positive USD price")
+	}
+	if alert.Direction != types.PriceAlertAbove && alert.Direction != types.PriceAlertBelow {
+		return types.PriceAlert{}, fmt.Errorf("direction must be %s or %s", types.PriceAlertAbove, types.PriceAlertBelow)
+	}
+	if alert.WebhookURL == "" && alert.Email == "" {
+		return types.PriceAlert{}, errors.New("a webhookUrl or an email to notify is required")
+	}
+	if alert.Email != "" {
+		address, err := mail.ParseAddress(alert.Email)
+		if err != nil {
+			return types.PriceAlert{}, fmt.Errorf("invalid email: %w", err)
+		}
+		alert.Email = address.Address
+	}
+
+	alert.ID = fmt.Sprintf("alert-%s", uuid.New().String())
+	alert.Owner = owner
+	alert.Status = types.PriceAlertStatusActive
+	alert.CreatedAt = now
+	alert.TriggeredAt = nil
+	alert.TriggerPrice = 0
+	return alert, nil
+}
+
+// InMemoryPriceAlertStore is a PriceAlertStore for running without a database
+type InMemoryPriceAlertStore struct {
+	alerts map[string]types.PriceAlert // map[id]alert
+	mu     sync.Mutex
+}
+
+// NewInMemoryPriceAlertStore creates an empty in-memory price alert store
+func NewInMemoryPriceAlertStore() *InMemoryPriceAlertStore {
+	return &InMemoryPriceAlertStore{
+		alerts: make(map[string]types.PriceAlert),
+	}
+}
+
+// CreatePriceAlert saves a new alert
+func (s *InMemoryPriceAlertStore) CreatePriceAlert(ctx context.Context, alert types.PriceAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.alerts[alert.ID] = alert
+	return nil
+}
+
+// GetPriceAlert returns an owner's alert
+func (s *InMemoryPriceAlertStore) GetPriceAlert(ctx context.Context, owner, id string) (types.PriceAlert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[id]
+	if !ok || alert.Owner != owner {
+		return types.PriceAlert{}, types.ErrPriceAlertNotFound
+	}
+	return alert, nil
+}
+
+// ListPriceAlerts returns an owner's alerts, newest first
+func (s *InMemoryPriceAlertStore) ListPriceAlerts(ctx context.Context, owner string) ([]types.PriceAlert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var alerts []types.PriceAlert
+	for _, alert := range s.alerts {
+		if alert.Owner == owner {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+		}
+		return alerts[i].ID < alerts[j].ID
+	})
+	return alerts, nil
+}
+
+// DeletePriceAlert deletes an owner's alert
+func (s *InMemoryPriceAlertStore) DeletePriceAlert(ctx context.Context, owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[id]
+	if !ok || alert.Owner != owner {
+		return types.ErrPriceAlertNotFound
+	}
+	delete(s.alerts, id)
+	return nil
+}
+
+// TriggerPriceAlerts marks the active alerts prices crossed as triggered and returns them
+func (s *InMemoryPriceAlertStore) TriggerPriceAlerts(ctx context.Context, prices []types.TokenPrice, at time.Time) ([]types.PriceAlert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var triggered []types.PriceAlert
+	for id, alert := range s.alerts {
+		if alert.Status != types.PriceAlertStatusActive {
+			continue
+		}
+		price, ok := alert.CrossingPrice(prices)
+		if !ok {
+			continue
+		}
+		alert.Trigger(price.PriceUSD, at)
+		s.alerts[id] = alert
+		triggered = append(triggered, alert)
+	}
+	sort.Slice(triggered, func(i, j int) bool { return triggered[i].ID < triggered[j].ID })
+	return triggered, nil
+}
+
+// HasActivePriceAlerts reports whether any alert is still active
+func (s *InMemoryPriceAlertStore) HasActivePriceAlerts(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, alert := range s.alerts {
+		if alert.Status == types.PriceAlertStatusActive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PriceAlertWebhook is the body POSTed to a price alert's webhook URL when it fires
+type PriceAlertWebhook struct {
+	Event string           `json:"event"`
+	Alert types.PriceAlert `json:"alert"`
+}
+
+// NewPriceAlertWebhook returns the webhook announcing a fired alert
+func NewPriceAlertWebhook(alert types.PriceAlert) PriceAlertWebhook {
+	return PriceAlertWebhook{Event: PriceAlertTriggeredWebhookEvent, Alert: alert}
+}
+
+// PriceAlertEmail returns the subject and body of the email announcing a fired alert
+func PriceAlertEmail(alert types.PriceAlert) (subject, body string) {
+	subject = fmt.Sprintf("%s is %s $%g", alert.Symbol, alert.Direction, alert.Threshold)
+	body = fmt.Sprintf("Your price alert %s fired: %s reached $%g, %s your threshold of $%g.\r\n",
+		alert.ID, alert.Symbol, alert.TriggerPrice, alert.Direction, alert.Threshold)
+	if alert.TriggeredAt != nil {
+		body += fmt.Sprintf("\r\nTriggered at %s.\r\n", alert.TriggeredAt.UTC().Format(time.RFC3339))
+	}
+	return subject, body
+}
+
+// ErrEmailDisabled is returned when sending an email without an SMTP server configured
+var ErrEmailDisabled = errors.New("email notifications are not configured")
+
+// EmailSender sends plain-text emails
+type EmailSender interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer is an EmailSender sending through an SMTP server
+type SMTPMailer struct {
+	addr     string
+	from     string
+	username string
+	password string
+
+	// sendMail sends the message; replaced in tests
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer creates a mailer sending from an address through the SMTP server at addr, signing in with PLAIN
+// auth when username is set. An empty addr disables email.
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     addr,
+		from:     from,
+		username: username,
+		password: password,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Enabled reports whether the mailer has an SMTP server to send through
+func (m *SMTPMailer) Enabled() bool {
+	return m != nil && m.addr != ""
+}
+
+// Send emails subject and body to an address
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	if !m.Enabled() {
+		return ErrEmailDisabled
+	}
+	// Header values come from clients; refuse any that would inject headers
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("email address and subject must be a single line")
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, err := net.SplitHostPort(m.addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %w", m.addr, err)
+		}
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		m.from, to, subject, body)
+	if err := m.sendMail(m.addr, auth, m.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to, err)
+	}
+	return nil
+}
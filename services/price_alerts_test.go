@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestPriceAlertStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryPriceAlertStore()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	above, err := NewPriceAlert("key-1", types.PriceAlert{Symbol: "eth", Threshold: 4000, Direction: types.PriceAlertAbove, Email: "Ops <ops@example.com>"}, now)
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+	if above.Symbol != "ETH" || above.Email != "ops@example.com" || above.Status != types.PriceAlertStatusActive {
+		t.Errorf("Expected a normalized active alert, got %+v", above)
+	}
+	below, err := NewPriceAlert("key-1", types.PriceAlert{Symbol: "ETH", ChainID: 137, Threshold: 3000, Direction: types.PriceAlertBelow, WebhookURL: "https://example.com/hook"}, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+	for _, alert := range []types.PriceAlert{above, below} {
+		if err := store.CreatePriceAlert(ctx, alert); err != nil {
+			t.Fatalf("Failed to save alert: %v", err)
+		}
+	}
+
+	if active, err := store.HasActivePriceAlerts(ctx); err != nil || !active {
+		t.Errorf("Expected active alerts, got %v, %v", active, err)
+	}
+
+	alerts, _ := store.ListPriceAlerts(ctx, "key-1")
+	if len(alerts) != 2 || alerts[0].ID != below.ID {
+		t.Errorf("Expected both alerts, newest first, got %+v", alerts)
+	}
+	if _, err := store.GetPriceAlert(ctx, "key-2", above.ID); !errors.Is(err, types.ErrPriceAlertNotFound) {
+		t.Errorf("Expected another key's alert not to be found, got %v", err)
+	}
+
+	// The Ethereum price dips below 3000 but only the Polygon price is watched
+	triggered, _ := store.TriggerPriceAlerts(ctx, []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 2900}}, now)
+	if len(triggered) != 0 {
+		t.Errorf("Expected no alert on another chain's price, got %+v", triggered)
+	}
+
+	prices := []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 4100}, {Symbol: "ETH", ChainID: 137, PriceUSD: 4095}}
+	triggered, _ = store.TriggerPriceAlerts(ctx, prices, now)
+	if len(triggered) != 1 || triggered[0].ID != above.ID || triggered[0].TriggerPrice != 4100 || triggered[0].TriggeredAt == nil {
+		t.Fatalf("Expected the above alert triggered at 4100, got %+v", triggered)
+	}
+	// A triggered alert fires once
+	if again, _ := store.TriggerPriceAlerts(ctx, prices, now); len(again) != 0 {
+		t.Errorf("Expected the alert not to fire twice, got %+v", again)
+	}
+
+	if err := store.DeletePriceAlert(ctx, "key-1", above.ID); err != nil {
+		t.Fatalf("Failed to delete alert: %v", err)
+	}
+	if err := store.DeletePriceAlert(ctx, "key-1", above.ID); !errors.Is(err, types.ErrPriceAlertNotFound) {
+		t.Errorf("Expected a deleted alert not to be found, got %v", err)
+	}
+	store.TriggerPriceAlerts(ctx, []types.TokenPrice{{Symbol: "ETH", ChainID: 137, PriceUSD: 2900}}, now)
+	if active, err := store.HasActivePriceAlerts(ctx); err != nil || active {
+		t.Errorf("Expected no active alert once the last fired, got %v, %v", active, err)
+	}
+
+	for name, invalid := range map[string]types.PriceAlert{
+		"NoSymbol":    {Threshold: 1, Direction: types.PriceAlertAbove, Email: "ops@example.com"},
+		"NoThreshold": {Symbol: "ETH", Direction: types.PriceAlertAbove, Email: "ops@example.com"},
+		"Direction":   {Symbol: "ETH", Threshold: 1, Direction: "sideways", Email: "ops@example.com"},
+		"NoChannel":   {Symbol: "ETH", Threshold: 1, Direction: types.PriceAlertAbove},
+	} {
+		if _, err := NewPriceAlert("key-1", invalid, now); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	if err := NewSMTPMailer("", "", "", "").Send("ops@example.com", "subject", "body"); !errors.Is(err, ErrEmailDisabled) {
+		t.Errorf("Expected email to be disabled without an SMTP server, got %v", err)
+	}
+
+	mailer := NewSMTPMailer("smtp.example.com:587", "alerts@example.com", "user", "pass")
+	var sent string
+	mailer.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		if addr != "smtp.example.com:587" || auth == nil || from != "alerts@example.com" || len(to) != 1 || to[0] != "ops@example.com" {
+			t.Errorf("Unexpected send to %s from %s to %v", addr, from, to)
+		}
+		sent = string(msg)
+		return nil
+	}
+
+	triggeredAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	subject, body := PriceAlertEmail(types.PriceAlert{ID: "alert-1", Symbol: "ETH", Threshold: 4000, Direction: types.PriceAlertAbove, TriggerPrice: 4100, TriggeredAt: &triggeredAt})
+	if err := mailer.Send("ops@example.com", subject, body); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if !strings.Contains(sent, "Subject: ETH is above $4000\r\n") || !strings.Contains(sent, "reached $4100") {
+		t.Errorf("Unexpected email:\n%s", sent)
+	}
+
+	if err := mailer.Send("ops@example.com\r\nBcc: everyone@example.com", subject, body); err == nil {
+		t.Error("Expected a header injection to be refused")
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// ErrNoPriceHistory is returned when a token has no price samples in or before a window
+var ErrNoPriceHistory = errors.New("no price history")
+
+// priceWindows are the averaging windows PriceAnalyticsService supports
+var priceWindows = map[string]time.Duration{
+	"5m":  5 * time.Minute,
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+}
+
+// PriceHistoryStore computes average prices over the price history it keeps
+type PriceHistoryStore interface {
+	// GetTokenPriceAverage returns the TWAP and VWAP of a token between start and end, as AveragePrices
+	// computes them, or nil when the token has no samples in or before the window
+	GetTokenPriceAverage(ctx context.Context, symbol string, chainID int64, start, end time.Time) (*types.PriceAverage, error)
+}
+
+// PriceAnalyticsService computes average prices over price history, for use in slippage guards
+type PriceAnalyticsService struct {
+	store PriceHistoryStore
+	now   func() time.Time
+}
+
+// NewPriceAnalyticsService creates a price analytics service reading history from store
+func NewPriceAnalyticsService(store PriceHistoryStore) *PriceAnalyticsService {
+	return &PriceAnalyticsService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// ParsePriceWindow returns the duration of a supported window: 5m, 1h or 24h
+func ParsePriceWindow(window string) (time.Duration, error) {
+	d, ok := priceWindows[window]
+	if !ok {
+		return 0, fmt.Errorf("unsupported window %q; use 5m, 1h or 24h", window)
+	}
+	return d, nil
+}
+
+// GetAveragePrice returns the TWAP and VWAP of a token over the window ending now
+func (s *PriceAnalyticsService) GetAveragePrice(ctx context.Context, symbol string, chainID int64, window string) (*types.PriceAverage, error) {
+	d, err := ParsePriceWindow(window)
+	if err != nil {
+		return nil, err
+	}
+
+	end := s.now().UTC()
+	start := end.Add(-d)
+
+	avg, err := s.store.GetTokenPriceAverage(ctx, symbol, chainID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to average price history: %w", err)
+	}
+	if avg == nil {
+		return nil, fmt.Errorf("%w for %s on chain %d in the last %s", ErrNoPriceHistory, symbol, chainID, window)
+	}
+	avg.Symbol = symbol
+	avg.ChainID = chainID
+	avg.Window = window
+	return avg, nil
+}
+
+// Deviation returns how far price is from the token's TWAP over the window, in percent
+func (s *PriceAnalyticsService) Deviation(ctx context.Context, symbol string, chainID int64, window string, price float64) (float64, error) {
+	avg, err := s.GetAveragePrice(ctx, symbol, chainID, window)
+	if err != nil {
+		return 0, err
+	}
+	if avg.TWAP <= 0 {
+		return 0, fmt.Errorf("no TWAP for %s on chain %d", symbol, chainID)
+	}
+	return math.Abs(price-avg.TWAP) / avg.TWAP * 100, nil
+}
+
+// AveragePrices computes the averages of samples between start and end, for stores that keep price history in
+// memory; the price repository computes the same averages in the database. prior is the sample in effect at start
+// and may be nil. Each price is weighted by the time until the next sample for the TWAP. Samples only carry a
+// rolling 24h volume, so for the VWAP each price in the window is weighted by the growth in that volume since the
+// previous sample, which approximates the volume traded in between. It reports false when there is nothing to average.
+func AveragePrices(samples []types.TokenPriceHistory, prior *types.TokenPriceHistory, start, end time.Time) (types.PriceAverage, bool) {
+	avg := types.PriceAverage{Start: start, End: end}
+
+	points := make([]types.TokenPriceHistory, 0, len(samples)+1)
+	for _, sample := range samples {
+		if !sample.Timestamp.Before(start) && !sample.Timestamp.After(end) {
+			points = append(points, sample)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	avg.Samples = len(points)
+
+	if prior != nil && prior.Timestamp.Before(start) {
+		opening := *prior
+		opening.Timestamp = start
+		points = append([]types.TokenPriceHistory{opening}, points...)
+	}
+	if len(points) == 0 {
+		return avg, false
+	}
+
+	// A volume of zero is an unknown volume, so it gives no delta
+	var tradedSum, weightedVolume float64
+	for i := len(points) - avg.Samples; i < len(points); i++ {
+		if i == 0 || points[i].Volume24h <= 0 || points[i-1].Volume24h <= 0 {
+			continue
+		}
+		if traded := points[i].Volume24h - points[i-1].Volume24h; traded > 0 {
+			tradedSum += traded
+			weightedVolume += points[i].PriceUSD * traded
+		}
+	}
+	if tradedSum > 0 {
+		avg.VWAP = weightedVolume / tradedSum
+	}
+
+	var covered, weightedTime float64
+	for i, p := range points {
+		until := end
+		if i+1 < len(points) {
+			until = points[i+1].Timestamp
+		}
+		seconds := until.Sub(p.Timestamp).Seconds()
+		covered += seconds
+		weightedTime += p.PriceUSD * seconds
+	}
+	if covered > 0 {
+		avg.TWAP = weightedTime / covered
+	} else {
+		// A single sample at the very end of the window
+		avg.TWAP = points[len(points)-1].PriceUSD
+	}
+	if window := end.Sub(start).Seconds(); window > 0 {
+		avg.Coverage = math.Min(1, covered/window)
+	}
+
+	return avg, true
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// historyStore is an in-memory PriceHistoryStore
+type historyStore struct {
+	history []types.TokenPriceHistory
+}
+
+func (h *historyStore) GetTokenPriceAverage(ctx context.Context, symbol string, chainID int64, start, end time.Time) (*types.PriceAverage, error) {
+	var samples []types.TokenPriceHistory
+	var prior *types.TokenPriceHistory
+	for i := range h.history {
+		s := h.history[i]
+		if s.Symbol != symbol || s.ChainID != chainID {
+			continue
+		}
+		if s.Timestamp.Before(start) {
+			prior = &h.history[i]
+		} else {
+			samples = append(samples, s)
+		}
+	}
+	avg, ok := AveragePrices(samples, prior, start, end)
+	if !ok {
+		return nil, nil
+	}
+	return &avg, nil
+}
+
+func TestPriceAnalyticsService(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	sample := func(ago time.Duration, price, volume float64) types.TokenPriceHistory {
+		return types.TokenPriceHistory{Symbol: "ETH", ChainID: 1, PriceUSD: price, Volume24h: volume, Timestamp: now.Add(-ago)}
+	}
+	store := &historyStore{history: []types.TokenPriceHistory{
+		sample(2*time.Hour, 1000, 50),
+		sample(45*time.Minute, 2000, 100),
+		sample(15*time.Minute, 3000, 300),
+	}}
+	service := NewPriceAnalyticsService(store)
+	service.now = func() time.Time { return now }
+
+	t.Run("TWAP", func(t *testing.T) {
+		avg, err := service.GetAveragePrice(context.Background(), "ETH", 1, "1h")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		// 15m at 1000 carried in from before the window, 30m at 2000, 15m at 3000
+		if want := (15*1000 + 30*2000 + 15*3000) / 60.0; math.Abs(avg.TWAP-want) > 1e-9 {
+			t.Errorf("Expected TWAP %f, got %f", want, avg.TWAP)
+		}
+		if avg.Samples != 2 || avg.Coverage != 1 {
+			t.Errorf("Expected 2 samples covering the window, got %d covering %f", avg.Samples, avg.Coverage)
+		}
+	})
+
+	t.Run("VWAP", func(t *testing.T) {
+		avg, err := service.GetAveragePrice(context.Background(), "ETH", 1, "1h")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		// The 24h volume grew by 50 up to the 2000 sample and by 200 up to the 3000 sample
+		if want := (2000*50 + 3000*200) / 250.0; avg.VWAP != want {
+			t.Errorf("Expected VWAP %f, got %f", want, avg.VWAP)
+		}
+	})
+
+	t.Run("PartialCoverage", func(t *testing.T) {
+		avg, err := service.GetAveragePrice(context.Background(), "ETH", 1, "24h")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := 2.0 / 24; math.Abs(avg.Coverage-want) > 1e-9 {
+			t.Errorf("Expected coverage %f, got %f", want, avg.Coverage)
+		}
+	})
+
+	t.Run("NoHistory", func(t *testing.T) {
+		_, err := service.GetAveragePrice(context.Background(), "BTC", 1, "5m")
+		if !errors.Is(err, ErrNoPriceHistory) {
+			t.Errorf("Expected ErrNoPriceHistory, got %v", err)
+		}
+	})
+
+	t.Run("UnsupportedWindow", func(t *testing.T) {
+		if _, err := service.GetAveragePrice(context.Background(), "ETH", 1, "7d"); err == nil {
+			t.Error("Expected an error for an unsupported window")
+		}
+	})
+
+	t.Run("Deviation", func(t *testing.T) {
+		deviation, err := service.Deviation(context.Background(), "ETH", 1, "5m", 3300)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if math.Abs(deviation-10) > 1e-9 {
+			t.Errorf("Expected 10%% deviation from the TWAP, got %f", deviation)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrPriceImpactTooHigh is returned for swaps moving their pool's price by more than the pool's limit, unless they
+// are forced
+var ErrPriceImpactTooHigh = errors.New("price impact too high")
+
+// estimatedPriceImpact is the price impact quoted for swaps not filled from a pool, in percent
+const estimatedPriceImpact = 0.1
+
+// PriceImpactError is a swap refused for its price impact on a pool. It matches ErrPriceImpactTooHigh.
+type PriceImpactError struct {
+	PoolID string
+	Impact float64 // In percent
+	Limit  float64 // In percent
+}
+
+// Error returns the message of the error, with the swap's impact and the pool's limit
+func (e *PriceImpactError) Error() string {
+	return fmt.Sprintf("%s: the swap would move pool %s's price by %.2f%%, over its %.2f%% limit; force the swap to execute it anyway", ErrPriceImpactTooHigh, e.PoolID, e.Impact, e.Limit)
+}
+
+// Is reports whether target is ErrPriceImpactTooHigh
+func (e *PriceImpactError) Is(target error) bool {
+	return target == ErrPriceImpactTooHigh
+}
+
+// PoolPriceImpact returns how far, in percent, selling amountIn of token into a constant product pool moves its
+// price: amountIn / (reserveIn + amountIn). It reports false when the pool holds none of the token.
+func PoolPriceImpact(pool LiquidityPool, token string, amountIn *big.Int) (float64, bool) {
+	reserve, ok := pool.Reserves[token]
+	if !ok || reserve.Sign() <= 0 {
+		return 0, false
+	}
+	after := new(big.Float).SetInt(new(big.Int).Add(reserve, amountIn))
+	impact, _ := new(big.Float).Quo(new(big.Float).SetInt(amountIn), after).Float64()
+	return impact * 100, true
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestPoolPriceImpact(t *testing.T) {
+	pool := LiquidityPool{Reserves: types.TokenAmounts{"ETH": big.NewInt(900)}}
+	if impact, ok := PoolPriceImpact(pool, "ETH", big.NewInt(100)); !ok || impact != 10 {
+		t.Errorf("Expected selling 100 into a reserve of 900 to move the price by 10%%, got %v", impact)
+	}
+	if _, ok := PoolPriceImpact(pool, "USDC", big.NewInt(100)); ok {
+		t.Error("Expected no price impact on a token the pool holds none of")
+	}
+}
+
+func TestPriceImpactLimits(t *testing.T) {
+	ctx := context.Background()
+	liquidityService := NewLiquidityService()
+	pool, err := liquidityService.CreatePool(ctx, TokenPair{
+		BaseToken:  Token{Symbol: "ETH", ChainID: 1},
+		QuoteToken: Token{Symbol: "USDC", ChainID: 1},
+	}, 3000, "")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	if _, err := liquidityService.AddLiquidity(ctx, pool.ID, "0x9999999999999999999999999999999999999999", big.NewInt(9_000_000_000_000_000_000)); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+
+	service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+	service.SetLiquidityService(liquidityService)
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+		DestinationToken: types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1},
+		Amount:           big.NewInt(1_000_000_000_000_000_000),
+	}
+
+	// Selling 1 ETH into the 9 ETH reserve moves the price by 10%
+	service.SetPriceImpactLimits(types.PriceImpactLimits{Default: 5})
+	_, err = service.GetSwapQuote(ctx, request)
+	var impactErr *PriceImpactError
+	if !errors.As(err, &impactErr) || !errors.Is(err, ErrPriceImpactTooHigh) {
+		t.Fatalf("Expected the swap to be refused for its price impact, got %v", err)
+	}
+	if impactErr.Impact != 10 || impactErr.Limit != 5 {
+		t.Errorf("Expected a 10%% impact over a 5%% limit, got %v over %v", impactErr.Impact, impactErr.Limit)
+	}
+
+	forced := request
+	forced.ForcePriceImpact = true
+	quote, err := service.GetSwapQuote(ctx, forced)
+	if err != nil {
+		t.Fatalf("Expected a forced swap to be quoted, got %v", err)
+	}
+	if quote.PriceImpact != 10 {
+		t.Errorf("Expected the quote's price impact to be 10%%, got %v", quote.PriceImpact)
+	}
+
+	// The pool's own limit replaces the default
+	service.SetPriceImpactLimits(types.PriceImpactLimits{Default: 5, Pools: map[string]float64{pool.ID: 15}})
+	if _, err := service.GetSwapQuote(ctx, request); err != nil {
+		t.Errorf("Expected the pool's 15%% limit to allow the swap, got %v", err)
+	}
+}
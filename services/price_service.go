@@ -0,0 +1,269 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultPriceMemorySize is how many token prices the price service keeps in memory unless configured otherwise
+	DefaultPriceMemorySize = 1024
+
+	// priceLoadTimeout bounds one read through the price service's layers. Loads are shared by every caller asking
+	// for the same price, so they don't end when the caller that started them gives up.
+	priceLoadTimeout = 30 * time.Second
+)
+
+// SharedPriceCache is a cache of token prices shared between servers, such as Redis
+type SharedPriceCache interface {
+	// GetPrice returns the cached price under key; found is false when there is none
+	GetPrice(ctx context.Context, key string) (price types.TokenPrice, found bool, err error)
+	// SetPrice caches a price under key for ttl
+	SetPrice(ctx context.Context, key string, price types.TokenPrice, ttl time.Duration) error
+}
+
+// LatestPriceStore provides the latest saved price of every token, such as the price database
+type LatestPriceStore interface {
+	GetLatestTokenPrices(ctx context.Context) ([]types.TokenPrice, error)
+}
+
+// PriceService reads token prices through layers of caches, each only asked when the ones before it miss:
+// an in-memory LRU, the shared cache, the price store, then the fallback lookups in order. Prices found further down
+// are written back to the layers above. Concurrent reads of the same price share one load, so a burst of quotes
+// for a token asks the slower layers, and the external price APIs behind them, once.
+type PriceService struct {
+	memory    *priceLRU
+	shared    SharedPriceCache
+	sharedTTL time.Duration
+	store     LatestPriceStore
+	fallbacks []OraclePriceLookup
+	loads     singleflight.Group
+	mu        sync.RWMutex
+}
+
+// NewPriceService creates a price service keeping up to size prices in memory for memoryTTL, falling back to the
+// fallbacks in order, such as the price oracle's cache and a live fetch. A size of 0 or less uses DefaultPriceMemorySize.
+func NewPriceService(size int, memoryTTL time.Duration, fallbacks ...OraclePriceLookup) *PriceService {
+	if size <= 0 {
+		size = DefaultPriceMemorySize
+	}
+	return &PriceService{
+		memory:    newPriceLRU(size, memoryTTL),
+		fallbacks: fallbacks,
+	}
+}
+
+// SetSharedCache sets the cache shared with other servers, checked after memory; found prices are kept in it for ttl
+func (s *PriceService) SetSharedCache(cache SharedPriceCache, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shared = cache
+	s.sharedTTL = ttl
+}
+
+// SetStore sets the price store checked after the shared cache and before the fallbacks
+func (s *PriceService) SetStore(store LatestPriceStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+}
+
+// PriceUSD returns the price of a token, preferring its price on chainID
+func (s *PriceService) PriceUSD(ctx context.Context, symbol string, chainID int64) (float64, error) {
+	price, err := s.OraclePrice(ctx, symbol, chainID)
+	if err != nil {
+		return 0, err
+	}
+	return price.PriceUSD, nil
+}
+
+// OraclePrice returns the price of a token with when it was observed, preferring its price on chainID.
+// Tokens without a price on that chain use their price on the lowest chain ID they are listed on.
+func (s *PriceService) OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error) {
+	key := PriceCacheKey(symbol, chainID)
+	if price, ok := s.memory.get(key); ok {
+		return price, nil
+	}
+
+	loaded := s.loads.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), priceLoadTimeout)
+		defer cancel()
+		return s.load(loadCtx, key, symbol, chainID)
+	})
+	select {
+	case result := <-loaded:
+		if result.Err != nil {
+			return types.TokenPrice{}, result.Err
+		}
+		return result.Val.(types.TokenPrice), nil
+	case <-ctx.Done():
+		return types.TokenPrice{}, ctx.Err()
+	}
+}
+
+// load reads a price through the layers below memory, writing it back to the layers above the one it was found in
+func (s *PriceService) load(ctx context.Context, key, symbol string, chainID int64) (types.TokenPrice, error) {
+	// A load that finished while this one was waiting to start has already filled memory
+	if price, ok := s.memory.get(key); ok {
+		return price, nil
+	}
+
+	s.mu.RLock()
+	shared, sharedTTL, store := s.shared, s.sharedTTL, s.store
+	s.mu.RUnlock()
+
+	if shared != nil {
+		price, found, err := shared.GetPrice(ctx, key)
+		if err != nil {
+			log.Printf("Failed to read %s price from the shared price cache: %v", symbol, err)
+		} else if found {
+			s.memory.set(key, price)
+			return price, nil
+		}
+	}
+
+	price, err := s.loadUncached(ctx, store, symbol, chainID)
+	if err != nil {
+		return types.TokenPrice{}, err
+	}
+	s.memory.set(key, price)
+	if shared != nil {
+		if err := shared.SetPrice(ctx, key, price, sharedTTL); err != nil {
+			log.Printf("Failed to write %s price to the shared price cache: %v", symbol, err)
+		}
+	}
+	return price, nil
+}
+
+// loadUncached reads a price from the store, or else the first fallback that has it
+func (s *PriceService) loadUncached(ctx context.Context, store LatestPriceStore, symbol string, chainID int64) (types.TokenPrice, error) {
+	if store != nil {
+		prices, err := store.GetLatestTokenPrices(ctx)
+		if err != nil {
+			log.Printf("Failed to read %s price from the price store: %v", symbol, err)
+		} else if price, ok := BestTokenPrice(prices, symbol, chainID); ok {
+			return price, nil
+		}
+	}
+
+	lastErr := fmt.Errorf("no price for %s", symbol)
+	for _, lookup := range s.fallbacks {
+		price, err := lookup.OraclePrice(ctx, symbol, chainID)
+		if err == nil {
+			return price, nil
+		}
+		lastErr = err
+	}
+	return types.TokenPrice{}, lastErr
+}
+
+// PriceCacheKey is the key a token's price on a chain is cached under
+func PriceCacheKey(symbol string, chainID int64) string {
+	return strings.ToUpper(symbol) + ":" + strconv.FormatInt(types.CanonicalChainID(chainID), 10)
+}
+
+// BestTokenPrice picks a token's price from prices, preferring its price on chainID and otherwise its price on the
+// lowest chain ID it has one on. Prices of zero or less are ignored.
+func BestTokenPrice(prices []types.TokenPrice, symbol string, chainID int64) (types.TokenPrice, bool) {
+	chainID = types.CanonicalChainID(chainID)
+	var fallback *types.TokenPrice
+	for i, price := range prices {
+		if !strings.EqualFold(price.Symbol, symbol) || price.PriceUSD <= 0 {
+			continue
+		}
+		if price.ChainID == chainID {
+			return price, true
+		}
+		if fallback == nil || price.ChainID < fallback.ChainID {
+			fallback = &prices[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return types.TokenPrice{}, false
+}
+
+// priceLRU is a fixed-size, least recently used cache of token prices whose entries expire after a TTL
+type priceLRU struct {
+	size    int
+	ttl     time.Duration
+	order   *list.List               // Most recently used first
+	entries map[string]*list.Element // map[key]element of a *priceLRUEntry
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// priceLRUEntry is a price cached in a priceLRU
+type priceLRUEntry struct {
+	key       string
+	price     types.TokenPrice
+	expiresAt time.Time
+}
+
+// newPriceLRU creates an empty LRU keeping up to size prices for ttl
+func newPriceLRU(size int, ttl time.Duration) *priceLRU {
+	return &priceLRU{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// get returns the unexpired price under key
+func (c *priceLRU) get(key string) (types.TokenPrice, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return types.TokenPrice{}, false
+	}
+	entry := element.Value.(*priceLRUEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return types.TokenPrice{}, false
+	}
+	c.order.MoveToFront(element)
+	return entry.price, true
+}
+
+// set caches a price under key, evicting the least recently used price when the cache is full
+func (c *priceLRU) set(key string, price types.TokenPrice) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*priceLRUEntry)
+		entry.price = price
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&priceLRUEntry{key: key, price: price, expiresAt: expiresAt})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*priceLRUEntry).key)
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// countingPriceStore returns fixed prices, counting reads and optionally blocking them until release is closed
+type countingPriceStore struct {
+	prices  []types.TokenPrice
+	err     error
+	release chan struct{}
+	reads   atomic.Int32
+}
+
+func (s *countingPriceStore) GetLatestTokenPrices(ctx context.Context) ([]types.TokenPrice, error) {
+	s.reads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return s.prices, s.err
+}
+
+// countingLookup returns a fixed price, counting lookups
+type countingLookup struct {
+	price   types.TokenPrice
+	err     error
+	lookups atomic.Int32
+}
+
+func (l *countingLookup) OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error) {
+	l.lookups.Add(1)
+	return l.price, l.err
+}
+
+// mapPriceCache is a SharedPriceCache in a map
+type mapPriceCache struct {
+	prices map[string]types.TokenPrice
+	mu     sync.Mutex
+}
+
+func (c *mapPriceCache) GetPrice(ctx context.Context, key string) (types.TokenPrice, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	price, ok := c.prices[key]
+	return price, ok, nil
+}
+
+func (c *mapPriceCache) SetPrice(ctx context.Context, key string, price types.TokenPrice, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prices[key] = price
+	return nil
+}
+
+func TestPriceServiceReadsThroughLayers(t *testing.T) {
+	ctx := context.Background()
+	live := &countingLookup{price: types.TokenPrice{Symbol: "SOL", ChainID: 101, PriceUSD: 150}}
+	store := &countingPriceStore{prices: []types.TokenPrice{
+		{Symbol: "ETH", ChainID: 1, PriceUSD: 3000},
+		{Symbol: "ETH", ChainID: 137, PriceUSD: 2990},
+	}}
+	shared := &mapPriceCache{prices: map[string]types.TokenPrice{
+		PriceCacheKey("BTC", 1): {Symbol: "BTC", ChainID: 1, PriceUSD: 60000},
+	}}
+	prices := NewPriceService(10, time.Minute, live)
+	prices.SetSharedCache(shared, time.Minute)
+	prices.SetStore(store)
+
+	btc, err := prices.PriceUSD(ctx, "btc", 1)
+	if err != nil || btc != 60000 {
+		t.Fatalf("BTC price = %v, %v; want the shared cache's 60000", btc, err)
+	}
+	if store.reads.Load() != 0 {
+		t.Errorf("Price store read %d times for a price in the shared cache", store.reads.Load())
+	}
+
+	eth, err := prices.OraclePrice(ctx, "ETH", 137)
+	if err != nil || eth.PriceUSD != 2990 {
+		t.Fatalf("ETH price = %v, %v; want the store's Polygon price", eth.PriceUSD, err)
+	}
+	if _, ok := shared.prices[PriceCacheKey("ETH", 137)]; !ok {
+		t.Error("Store price was not written back to the shared cache")
+	}
+
+	sol, err := prices.PriceUSD(ctx, "SOL", 101)
+	if err != nil || sol != 150 {
+		t.Fatalf("SOL price = %v, %v; want the live price", sol, err)
+	}
+
+	// Every price is in memory now, so no layer is asked again
+	for _, key := range []struct {
+		symbol  string
+		chainID int64
+	}{{"BTC", 1}, {"ETH", 137}, {"SOL", 101}} {
+		if _, err := prices.PriceUSD(ctx, key.symbol, key.chainID); err != nil {
+			t.Fatalf("Failed to read %s from memory: %v", key.symbol, err)
+		}
+	}
+	if store.reads.Load() != 2 || live.lookups.Load() != 1 {
+		t.Errorf("Store read %d times and live lookups %d; want 2 (ETH, SOL) and 1 (SOL)", store.reads.Load(), live.lookups.Load())
+	}
+}
+
+func TestPriceServiceSkipsFailingLayers(t *testing.T) {
+	store := &countingPriceStore{err: errors.New("database down")}
+	failing := &countingLookup{err: errors.New("no cache file")}
+	live := &countingLookup{price: types.TokenPrice{Symbol: "ETH", ChainID: 1, PriceUSD: 3000}}
+	prices := NewPriceService(10, time.Minute, failing, live)
+	prices.SetStore(store)
+
+	price, err := prices.PriceUSD(context.Background(), "ETH", 1)
+	if err != nil || price != 3000 {
+		t.Fatalf("PriceUSD = %v, %v; want the last fallback's 3000", price, err)
+	}
+
+	missing := NewPriceService(10, time.Minute, failing)
+	if _, err := missing.PriceUSD(context.Background(), "ETH", 1); err == nil || err.Error() != "no cache file" {
+		t.Errorf("PriceUSD error = %v, want the last fallback's error", err)
+	}
+}
+
+func TestPriceServiceSharesConcurrentLoads(t *testing.T) {
+	store := &countingPriceStore{
+		prices:  []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 3000}},
+		release: make(chan struct{}),
+	}
+	prices := NewPriceService(10, time.Minute)
+	prices.SetStore(store)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := prices.PriceUSD(context.Background(), "ETH", 1); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	// Let every caller reach the load before it finishes
+	for store.reads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(store.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("PriceUSD failed: %v", err)
+	}
+	if reads := store.reads.Load(); reads != 1 {
+		t.Errorf("Store read %d times by concurrent callers, want 1", reads)
+	}
+}
+
+func TestPriceServiceCallerGivesUp(t *testing.T) {
+	store := &countingPriceStore{
+		prices:  []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 3000}},
+		release: make(chan struct{}),
+	}
+	prices := NewPriceService(10, time.Minute)
+	prices.SetStore(store)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := prices.PriceUSD(ctx, "ETH", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PriceUSD error = %v, want the caller's deadline", err)
+	}
+
+	// The load carries on for later callers
+	close(store.release)
+	if price, err := prices.PriceUSD(context.Background(), "ETH", 1); err != nil || price != 3000 {
+		t.Errorf("PriceUSD = %v, %v; want 3000", price, err)
+	}
+	if reads := store.reads.Load(); reads != 1 {
+		t.Errorf("Store read %d times, want 1", reads)
+	}
+}
+
+func TestPriceLRU(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newPriceLRU(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.set("ETH:1", types.TokenPrice{PriceUSD: 3000})
+	cache.set("BTC:1", types.TokenPrice{PriceUSD: 60000})
+	cache.get("ETH:1") // BTC is now the least recently used
+	cache.set("SOL:101", types.TokenPrice{PriceUSD: 150})
+
+	if _, ok := cache.get("BTC:1"); ok {
+		t.Error("Least recently used price was not evicted")
+	}
+	if price, ok := cache.get("ETH:1"); !ok || price.PriceUSD != 3000 {
+		t.Errorf("ETH = %v, %v; want 3000", price.PriceUSD, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.get("SOL:101"); ok {
+		t.Error("Expired price was returned")
+	}
+	if len(cache.entries) != 1 || cache.order.Len() != 1 {
+		t.Errorf("Cache holds %d entries, want only ETH's", len(cache.entries))
+	}
+}
+
+func TestBestTokenPrice(t *testing.T) {
+	prices := []types.TokenPrice{
+		{Symbol: "USDC", ChainID: 137, PriceUSD: 1.001},
+		{Symbol: "USDC", ChainID: 10, PriceUSD: 0.999},
+		{Symbol: "USDC", ChainID: 1, PriceUSD: 0},
+		{Symbol: "ETH", ChainID: 1, PriceUSD: 3000},
+	}
+
+	if price, ok := BestTokenPrice(prices, "usdc", 137); !ok || price.ChainID != 137 {
+		t.Errorf("USDC on Polygon = %+v, %v; want its Polygon price", price, ok)
+	}
+	// The zero Ethereum price is ignored, leaving Optimism as the lowest chain
+	if price, ok := BestTokenPrice(prices, "USDC", 1); !ok || price.ChainID != 10 {
+		t.Errorf("USDC on Ethereum = %+v, %v; want its Optimism price", price, ok)
+	}
+	if _, ok := BestTokenPrice(prices, "SOL", 101); ok {
+		t.Error("Found a price for a token without one")
+	}
+}
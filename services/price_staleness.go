@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+var (
+	// ErrPriceUnavailable is returned when the price oracle has no price for a token
+	ErrPriceUnavailable = errors.New("no oracle price available")
+	// ErrStalePrice is returned when a token's best oracle price is older than the staleness policy allows
+	ErrStalePrice = errors.New("oracle price is stale")
+	// ErrQuoteDrift is returned when a pair's price moved against the user by more than their slippage since it was quoted
+	ErrQuoteDrift = errors.New("price moved beyond slippage since quote")
+)
+
+// OraclePriceLookup returns the price oracle's best price of a token, including when it was observed
+type OraclePriceLookup interface {
+	OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error)
+}
+
+// PriceStalenessPolicy reads oracle prices for quoting and refuses prices older than MaxAge
+type PriceStalenessPolicy struct {
+	prices OraclePriceLookup
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewPriceStalenessPolicy creates a policy refusing prices older than maxAge; a zero maxAge accepts any age
+func NewPriceStalenessPolicy(prices OraclePriceLookup, maxAge time.Duration) *PriceStalenessPolicy {
+	return &PriceStalenessPolicy{
+		prices: prices,
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+// Price returns the oracle price of a token. Wrapped tokens are priced as their underlying token.
+func (p *PriceStalenessPolicy) Price(ctx context.Context, token types.Token) (types.TokenPrice, error) {
+	symbol := token.UnderlyingSymbol()
+	price, err := p.prices.OraclePrice(ctx, symbol, token.ChainID)
+	if err != nil {
+		return types.TokenPrice{}, fmt.Errorf("%w for %s: %v", ErrPriceUnavailable, symbol, err)
+	}
+	if price.PriceUSD <= 0 {
+		return types.TokenPrice{}, fmt.Errorf("%w for %s", ErrPriceUnavailable, symbol)
+	}
+	if age := p.now().Sub(price.LastUpdated); p.maxAge > 0 && age > p.maxAge {
+		return types.TokenPrice{}, fmt.Errorf("%w: %s price is %s old, more than %s", ErrStalePrice, symbol, age.Round(time.Second), p.maxAge)
+	}
+	return price, nil
+}
+
+// PairPrices returns the oracle prices of a swap's tokens and when the older of the two was observed
+func (p *PriceStalenessPolicy) PairPrices(ctx context.Context, request types.SwapRequest) (source, destination types.TokenPrice, asOf time.Time, err error) {
+	if source, err = p.Price(ctx, request.SourceToken); err != nil {
+		return
+	}
+	if destination, err = p.Price(ctx, request.DestinationToken); err != nil {
+		return
+	}
+	asOf = source.LastUpdated
+	if destination.LastUpdated.Before(asOf) {
+		asOf = destination.LastUpdated
+	}
+	return
+}
+
+// CheckDrift re-reads the oracle prices of a quoted swap and fails with ErrQuoteDrift when its mid price fell by more
+// than the request's slippage. Moves in the user's favor always pass, as do quotes made without oracle prices.
+func (p *PriceStalenessPolicy) CheckDrift(ctx context.Context, request types.SwapRequest, quote types.SwapQuote) error {
+	if quote.MidPrice <= 0 {
+		return nil
+	}
+	source, destination, _, err := p.PairPrices(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	current := source.PriceUSD / destination.PriceUSD
+	drift := (quote.MidPrice - current) / quote.MidPrice * 100
+	if drift > request.Slippage {
+		return fmt.Errorf("%w: %s/%s fell %.2f%%, slippage is %.2f%%", ErrQuoteDrift,
+			request.SourceToken.Symbol, request.DestinationToken.Symbol, drift, request.Slippage)
+	}
+	return nil
+}
+
+// convertAmount converts an amount of one token into another at their USD prices, accounting for decimals. The
+// result is rounded down, so a swap is never quoted more than its input is worth.
+func convertAmount(amount *big.Int, from types.Token, fromPrice float64, to types.Token, toPrice float64) *big.Int {
+	if toPrice == 0 {
+		return new(big.Int)
+	}
+	rate := new(big.Rat).Quo(types.RatFromFloat(fromPrice), types.RatFromFloat(toPrice))
+	return types.NewAmount(amount, from.Decimals).Convert(rate, to.Decimals, types.RoundDown).Value
+}
+
+// priceFee values each part of a fee charged in token, and their total, at the token's oracle price
+func priceFee(fee *types.Fee, token types.Token, price types.TokenPrice) {
+	usd := func(part *big.Int) float64 {
+		return types.NewAmount(part, token.Decimals).Float64() * price.PriceUSD
+	}
+	fee.GasFeeUSD = usd(fee.GasFee)
+	fee.ProtocolFeeUSD = usd(fee.ProtocolFee)
+	fee.NetworkFeeUSD = usd(fee.NetworkFee)
+	fee.BridgeFeeUSD = usd(fee.BridgeFee)
+	fee.TotalFeeUSD = fee.GasFeeUSD + fee.ProtocolFeeUSD + fee.NetworkFeeUSD + fee.BridgeFeeUSD
+	fee.PriceUSD = price.PriceUSD
+	fee.PriceAsOf = price.LastUpdated
+}
+
+// PriceFee values a fee charged in the swap's source token with the source token's oracle price
+func (p *PriceStalenessPolicy) PriceFee(ctx context.Context, request types.SwapRequest, fee *types.Fee) error {
+	return p.PriceFeeIn(ctx, request.SourceToken, fee)
+}
+
+// PriceFeeIn values a fee charged in token with the token's oracle price, such as the gas of a mined transaction in
+// its chain's native token
+func (p *PriceStalenessPolicy) PriceFeeIn(ctx context.Context, token types.Token, fee *types.Fee) error {
+	price, err := p.Price(ctx, token)
+	if err != nil {
+		return err
+	}
+	priceFee(fee, token, price)
+	return nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/infinity-dex/services/types"
+)
+
+// Firm quote errors
+var (
+	ErrQuoteExpired  = errors.New("quote has expired")
+	ErrQuoteInvalid  = errors.New("quote signature is invalid")
+	ErrQuoteMismatch = errors.New("swap does not match its quote")
+)
+
+// QuoteSigner makes quotes firm: it gives each an ID and an expiry, and signs it with the server's key so the quote
+// can be verified like a swap attestation
+type QuoteSigner struct {
+	key ed25519.PrivateKey
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewQuoteSigner creates a signer of quotes firm for ttl, signing with key
+func NewQuoteSigner(key ed25519.PrivateKey, ttl time.Duration) *QuoteSigner {
+	return &QuoteSigner{
+		key: key,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// PublicKey returns the key quote signatures can be verified with
+func (s *QuoteSigner) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign makes a quote firm until the signer's TTL from now
+func (s *QuoteSigner) Sign(quote *types.SwapQuote) error {
+	quote.QuoteID = uuid.New().String()
+	quote.ExpiresAt = s.now().Add(s.ttl).UTC()
+	quote.Signature = ""
+	payload, err := canonicalJSON(quote)
+	if err != nil {
+		return fmt.Errorf("failed to encode quote: %w", err)
+	}
+	quote.Signature = hex.EncodeToString(ed25519.Sign(s.key, payload))
+	return nil
+}
+
+// VerifyQuote checks a firm quote was signed with publicKey and has not been changed since
+func VerifyQuote(quote types.SwapQuote, publicKey ed25519.PublicKey) error {
+	signature, err := hex.DecodeString(quote.Signature)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return ErrQuoteInvalid
+	}
+	quote.Signature = ""
+	payload, err := canonicalJSON(quote)
+	if err != nil {
+		return fmt.Errorf("failed to encode quote: %w", err)
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return ErrQuoteInvalid
+	}
+	return nil
+}
+
+// CheckFirmQuote checks a swap can execute against a firm quote at now: the quote has not expired and was quoted
+// for the swap's tokens and amount
+func CheckFirmQuote(request types.SwapRequest, quote types.SwapQuote, now time.Time) error {
+	if quote.QuoteID == "" {
+		return fmt.Errorf("%w: the quote is not firm", ErrQuoteMismatch)
+	}
+	if !now.Before(quote.ExpiresAt) {
+		return fmt.Errorf("%w: quote %s expired at %s", ErrQuoteExpired, quote.QuoteID, quote.ExpiresAt.Format(time.RFC3339))
+	}
+	if !sameToken(request.SourceToken, quote.SourceToken) || !sameToken(request.DestinationToken, quote.DestinationToken) {
+		return fmt.Errorf("%w: quote %s is for %s to %s", ErrQuoteMismatch, quote.QuoteID, quote.SourceToken.Symbol, quote.DestinationToken.Symbol)
+	}
+	if request.Amount == nil || quote.InputAmount == nil || request.Amount.Cmp(quote.InputAmount) != 0 {
+		return fmt.Errorf("%w: quote %s is for an amount of %s", ErrQuoteMismatch, quote.QuoteID, quote.InputAmount)
+	}
+	return nil
+}
+
+// sameToken reports whether two tokens are the same token on the same chain
+func sameToken(a, b types.Token) bool {
+	return a.Symbol == b.Symbol && types.CanonicalChainID(a.ChainID) == types.CanonicalChainID(b.ChainID)
+}
+
+// QuoteStore keeps firm quotes until they expire, so a swap can reference its quote by ID on any API server. Each
+// quote is kept for the API key it was quoted to, and backs one swap from that key.
+type QuoteStore interface {
+	// SaveQuote stores a firm quote quoted to an API key
+	SaveQuote(ctx context.Context, quote types.SwapQuote, keyID string) error
+	// GetQuote returns the quote with an ID quoted to keyID, types.ErrQuoteNotFound when there is none, or
+	// types.ErrQuoteUsed once a swap has used it
+	GetQuote(ctx context.Context, quoteID, keyID string) (types.SwapQuote, error)
+	// UseQuote marks the quote with an ID quoted to keyID used by a swap, failing with types.ErrQuoteUsed when a swap
+	// already used it and types.ErrQuoteNotFound when there is none. Concurrent swaps can't both use a quote.
+	UseQuote(ctx context.Context, quoteID, keyID string) error
+	// DeleteExpiredQuotes deletes the quotes that have expired, returning how many it deleted
+	DeleteExpiredQuotes(ctx context.Context) (int64, error)
+}
+
+// storedQuote is a firm quote kept by an InMemoryQuoteStore
+type storedQuote struct {
+	quote types.SwapQuote
+	keyID string
+	used  bool
+}
+
+// InMemoryQuoteStore is a QuoteStore for running without a database
+type InMemoryQuoteStore struct {
+	quotes map[string]storedQuote // map[quoteID]quote
+	mu     sync.Mutex
+	now    func() time.Time
+}
+
+// NewInMemoryQuoteStore creates an empty store
+func NewInMemoryQuoteStore() *InMemoryQuoteStore {
+	return &InMemoryQuoteStore{
+		quotes: make(map[string]storedQuote),
+		now:    time.Now,
+	}
+}
+
+// SaveQuote stores a firm quote quoted to an API key
+func (s *InMemoryQuoteStore) SaveQuote(ctx context.Context, quote types.SwapQuote, keyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[quote.QuoteID] = storedQuote{quote: quote, keyID: keyID}
+	return nil
+}
+
+// GetQuote returns the quote with an ID quoted to keyID
+func (s *InMemoryQuoteStore) GetQuote(ctx context.Context, quoteID, keyID string) (types.SwapQuote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.quotes[quoteID]
+	if !ok || stored.keyID != keyID {
+		return types.SwapQuote{}, types.ErrQuoteNotFound
+	}
+	if stored.used {
+		return types.SwapQuote{}, types.ErrQuoteUsed
+	}
+	return stored.quote, nil
+}
+
+// UseQuote marks the quote with an ID quoted to keyID used by a swap
+func (s *InMemoryQuoteStore) UseQuote(ctx context.Context, quoteID, keyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.quotes[quoteID]
+	if !ok || stored.keyID != keyID {
+		return types.ErrQuoteNotFound
+	}
+	if stored.used {
+		return types.ErrQuoteUsed
+	}
+	stored.used = true
+	s.quotes[quoteID] = stored
+	return nil
+}
+
+// DeleteExpiredQuotes deletes the quotes that have expired
+func (s *InMemoryQuoteStore) DeleteExpiredQuotes(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var deleted int64
+	for id, stored := range s.quotes {
+		if !now.Before(stored.quote.ExpiresAt) {
+			delete(s.quotes, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestFirmQuotes(t *testing.T) {
+	ctx := context.Background()
+	publicKey, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewQuoteSigner(key, 30*time.Second)
+	now := time.Now()
+	signer.now = func() time.Time { return now }
+
+	transactions := NewTransactionService()
+	service := NewSwapService(NewTokenService(), transactions, &MockUniversalSDK{})
+	service.SetQuoteSigner(signer)
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "USDC", Decimals: 18, ChainID: types.ChainIDEthereum},
+		DestinationToken: types.Token{Symbol: "DAI", Decimals: 18, ChainID: types.ChainIDEthereum},
+		Amount:           big.NewInt(1_000_000_000_000_000_000),
+	}
+
+	quote, err := service.GetSwapQuote(ctx, request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if quote.QuoteID == "" || !quote.ExpiresAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("Expected a firm quote expiring in 30s, got %q expiring at %s", quote.QuoteID, quote.ExpiresAt)
+	}
+
+	t.Run("Signature", func(t *testing.T) {
+		if err := VerifyQuote(*quote, publicKey); err != nil {
+			t.Errorf("Expected a valid signature, got %v", err)
+		}
+
+		// The signature survives the quote being stored as JSON
+		encoded, _ := json.Marshal(quote)
+		var decoded types.SwapQuote
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("Failed to decode quote: %v", err)
+		}
+		if err := VerifyQuote(decoded, publicKey); err != nil {
+			t.Errorf("Expected the decoded quote's signature to be valid, got %v", err)
+		}
+
+		raised := *quote
+		raised.OutputAmount = new(big.Int).Add(quote.OutputAmount, big.NewInt(1))
+		if err := VerifyQuote(raised, publicKey); !errors.Is(err, ErrQuoteInvalid) {
+			t.Errorf("Expected a changed quote to be rejected, got %v", err)
+		}
+		otherKey, _, _ := ed25519.GenerateKey(nil)
+		if err := VerifyQuote(*quote, otherKey); !errors.Is(err, ErrQuoteInvalid) {
+			t.Errorf("Expected another key to reject the quote, got %v", err)
+		}
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		if err := CheckFirmQuote(request, *quote, now); err != nil {
+			t.Errorf("Expected the quote to hold for its swap, got %v", err)
+		}
+		if err := CheckFirmQuote(request, *quote, quote.ExpiresAt); !errors.Is(err, ErrQuoteExpired) {
+			t.Errorf("Expected the quote to expire, got %v", err)
+		}
+		larger := request
+		larger.Amount = big.NewInt(2_000_000_000_000_000_000)
+		reversed := request
+		reversed.SourceToken, reversed.DestinationToken = request.DestinationToken, request.SourceToken
+		for name, other := range map[string]types.SwapRequest{"larger": larger, "reversed": reversed} {
+			if err := CheckFirmQuote(other, *quote, now); !errors.Is(err, ErrQuoteMismatch) {
+				t.Errorf("Expected a %s swap not to match the quote, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Execute", func(t *testing.T) {
+		// The quote is honored though the swap would be quoted less now
+		firm := *quote
+		firm.OutputAmount = new(big.Int).Add(quote.OutputAmount, big.NewInt(1000))
+		swap := request
+		swap.RequestID = "firm-swap"
+		swap.FirmQuote = &firm
+		if _, err := service.ExecuteSwap(ctx, swap); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		txs, err := transactions.GetTransactionsByWorkflowID(ctx, "firm-swap")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, tx := range txs {
+			if tx.Type == "swap_dest" && tx.Amount.Cmp(firm.OutputAmount) != 0 {
+				t.Errorf("Expected the firm output %s to be delivered, got %s", firm.OutputAmount, tx.Amount)
+			}
+		}
+
+		expired := swap
+		expired.RequestID = "expired-swap"
+		stale := *quote
+		stale.ExpiresAt = time.Now().Add(-time.Second)
+		expired.FirmQuote = &stale
+		if _, err := service.ExecuteSwap(ctx, expired); !errors.Is(err, ErrQuoteExpired) {
+			t.Errorf("Expected an expired quote to fail the swap, got %v", err)
+		}
+	})
+
+	t.Run("Store", func(t *testing.T) {
+		store := NewInMemoryQuoteStore()
+		store.now = func() time.Time { return now }
+		if err := store.SaveQuote(ctx, *quote, "key-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if saved, err := store.GetQuote(ctx, quote.QuoteID, "key-1"); err != nil || saved.Signature != quote.Signature {
+			t.Errorf("Expected the saved quote, got %v", err)
+		}
+
+		// A quote is the key's it was quoted to
+		if _, err := store.GetQuote(ctx, quote.QuoteID, "key-2"); !errors.Is(err, types.ErrQuoteNotFound) {
+			t.Errorf("Expected another key's quote not to be found, got %v", err)
+		}
+		if err := store.UseQuote(ctx, quote.QuoteID, "key-2"); !errors.Is(err, types.ErrQuoteNotFound) {
+			t.Errorf("Expected another key's quote not to be usable, got %v", err)
+		}
+
+		// and is used once
+		if err := store.UseQuote(ctx, quote.QuoteID, "key-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := store.UseQuote(ctx, quote.QuoteID, "key-1"); !errors.Is(err, types.ErrQuoteUsed) {
+			t.Errorf("Expected a used quote not to be used again, got %v", err)
+		}
+		if _, err := store.GetQuote(ctx, quote.QuoteID, "key-1"); !errors.Is(err, types.ErrQuoteUsed) {
+			t.Errorf("Expected a used quote to be refused, got %v", err)
+		}
+
+		// Expired quotes are deleted
+		later := *quote
+		later.QuoteID = "later"
+		later.ExpiresAt = quote.ExpiresAt.Add(time.Minute)
+		store.SaveQuote(ctx, later, "key-1")
+		store.now = func() time.Time { return quote.ExpiresAt }
+		if deleted, err := store.DeleteExpiredQuotes(ctx); err != nil || deleted != 1 {
+			t.Errorf("Expected the expired quote to be deleted, got %d, %v", deleted, err)
+		}
+		if _, err := store.GetQuote(ctx, later.QuoteID, "key-1"); err != nil {
+			t.Errorf("Expected the unexpired quote to be kept, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+const (
+	// redisDefaultPort is the port Redis servers listen on unless the URL names another
+	redisDefaultPort = "6379"
+
+	// redisPriceKeyPrefix namespaces the price service's keys in a Redis database shared with other applications
+	redisPriceKeyPrefix = "infinity-dex:price:"
+)
+
+// errRedisNil is the reply to a GET of a key that doesn't exist
+var errRedisNil = errors.New("redis: nil")
+
+// RedisPriceCache is a SharedPriceCache in Redis, spoken to with the RESP protocol. It connects on the first command
+// and reconnects on the next command after the connection fails.
+type RedisPriceCache struct {
+	address  string
+	username string
+	password string
+	database int
+	conn     net.Conn
+	reader   *bufio.Reader
+	dialer   net.Dialer
+	mu       sync.Mutex
+}
+
+// NewRedisPriceCache creates a price cache in the Redis server at rawURL, e.g. redis://:password@localhost:6379/0
+func NewRedisPriceCache(rawURL string) (*RedisPriceCache, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if u.Scheme != "redis" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q: expected redis://host:port/db", rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = redisDefaultPort
+	}
+	cache := &RedisPriceCache{address: net.JoinHostPort(u.Hostname(), port)}
+	if u.User != nil {
+		cache.username = u.User.Username()
+		cache.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		cache.database, err = strconv.Atoi(db)
+		if err != nil || cache.database < 0 {
+			return nil, fmt.Errorf("invalid Redis URL %q: database must be a number", rawURL)
+		}
+	}
+	return cache, nil
+}
+
+// GetPrice returns the price cached under key
+func (c *RedisPriceCache) GetPrice(ctx context.Context, key string) (types.TokenPrice, bool, error) {
+	reply, err := c.do(ctx, "GET", redisPriceKeyPrefix+key)
+	if errors.Is(err, errRedisNil) {
+		return types.TokenPrice{}, false, nil
+	}
+	if err != nil {
+		return types.TokenPrice{}, false, err
+	}
+
+	var price types.TokenPrice
+	if err := json.Unmarshal([]byte(reply), &price); err != nil {
+		return types.TokenPrice{}, false, fmt.Errorf("invalid cached price under %s: %w", key, err)
+	}
+	return price, true, nil
+}
+
+// SetPrice caches a price under key for ttl, rounded up to whole milliseconds
+func (c *RedisPriceCache) SetPrice(ctx context.Context, key string, price types.TokenPrice, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	value, err := json.Marshal(price)
+	if err != nil {
+		return err
+	}
+	millis := (ttl + time.Millisecond - 1) / time.Millisecond
+	_, err = c.do(ctx, "SET", redisPriceKeyPrefix+key, string(value), "PX", strconv.FormatInt(int64(millis), 10))
+	return err
+}
+
+// Ping checks the server answers, connecting to it if needed
+func (c *RedisPriceCache) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, "PING")
+	return err
+}
+
+// Close closes the connection to the server, if any
+func (c *RedisPriceCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	return c.disconnect()
+}
+
+// do sends one command and returns its reply. Error replies are returned as errors; a nil reply is errRedisNil.
+func (c *RedisPriceCache) do(ctx context.Context, args ...string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return "", err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	} else {
+		c.conn.SetDeadline(time.Time{})
+	}
+
+	reply, err := c.command(args...)
+	var replyErr redisReplyError
+	if err != nil && !errors.Is(err, errRedisNil) && !errors.As(err, &replyErr) {
+		// The connection may be left mid-reply, so the next command starts on a new one
+		c.disconnect()
+		return "", fmt.Errorf("redis %s failed: %w", args[0], err)
+	}
+	return reply, err
+}
+
+// connect dials the server, authenticates and selects the database; the caller holds the lock
+func (c *RedisPriceCache) connect(ctx context.Context) error {
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+
+	if c.password != "" {
+		args := []string{"AUTH", c.password}
+		if c.username != "" {
+			args = []string{"AUTH", c.username, c.password}
+		}
+		if _, err := c.command(args...); err != nil {
+			c.disconnect()
+			return fmt.Errorf("Redis refused the connection: %w", err)
+		}
+	}
+	if c.database != 0 {
+		if _, err := c.command("SELECT", strconv.Itoa(c.database)); err != nil {
+			c.disconnect()
+			return fmt.Errorf("failed to select Redis database %d: %w", c.database, err)
+		}
+	}
+	return nil
+}
+
+// disconnect closes the connection; the caller holds the lock
+func (c *RedisPriceCache) disconnect() error {
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+	return err
+}
+
+// command writes a command as an array of bulk strings and reads its reply
+func (c *RedisPriceCache) command(args ...string) (string, error) {
+	var request strings.Builder
+	fmt.Fprintf(&request, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, request.String()); err != nil {
+		return "", err
+	}
+	return readRedisReply(c.reader)
+}
+
+// redisReplyError is an error reply from the server, such as a wrong password
+type redisReplyError string
+
+// Error returns the server's error message
+func (e redisReplyError) Error() string {
+	return string(e)
+}
+
+// readRedisReply reads a simple string, error, integer or bulk string reply
+func readRedisReply(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", redisReplyError(line[1:])
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid Redis bulk string length %q", line[1:])
+		}
+		if length < 0 {
+			return "", errRedisNil
+		}
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return "", err
+		}
+		return string(value[:length]), nil
+	default:
+		return "", fmt.Errorf("unexpected Redis reply %q", line)
+	}
+}
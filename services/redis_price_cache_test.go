@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// fakeRedisServer serves GET, SET, PING, AUTH and SELECT from a map, recording every command it receives
+func fakeRedisServer(t *testing.T, password string) (string, func() []string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	var commands []string
+	values := make(map[string]string)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				authenticated := password == ""
+				for {
+					args, err := readRedisCommand(reader)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					commands = append(commands, strings.Join(args, " "))
+					switch {
+					case args[0] == "AUTH":
+						authenticated = args[len(args)-1] == password
+						if authenticated {
+							fmt.Fprint(conn, "+OK\r\n")
+						} else {
+							fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+						}
+					case !authenticated:
+						fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+					case args[0] == "PING":
+						fmt.Fprint(conn, "+PONG\r\n")
+					case args[0] == "SELECT":
+						fmt.Fprint(conn, "+OK\r\n")
+					case args[0] == "SET":
+						values[args[1]] = args[2]
+						fmt.Fprint(conn, "+OK\r\n")
+					case args[0] == "GET":
+						if value, ok := values[args[1]]; ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+						} else {
+							fmt.Fprint(conn, "$-1\r\n")
+						}
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), commands...)
+	}
+}
+
+// readRedisCommand reads a command sent as an array of bulk strings
+func readRedisCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:length])
+	}
+	return args, nil
+}
+
+func TestRedisPriceCache(t *testing.T) {
+	address, commands := fakeRedisServer(t, "secret")
+	cache, err := NewRedisPriceCache("redis://:secret@" + address + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, found, err := cache.GetPrice(ctx, "ETH:1"); err != nil || found {
+		t.Fatalf("GetPrice of a missing key = %v, %v; want not found", found, err)
+	}
+
+	price := types.TokenPrice{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, Source: types.PriceSourceCoinGecko}
+	if err := cache.SetPrice(ctx, "ETH:1", price, 1500*time.Microsecond); err != nil {
+		t.Fatal(err)
+	}
+	cached, found, err := cache.GetPrice(ctx, "ETH:1")
+	if err != nil || !found || cached.PriceUSD != 3000 || cached.Source != types.PriceSourceCoinGecko {
+		t.Fatalf("GetPrice = %+v, %v, %v; want the price that was set", cached, found, err)
+	}
+
+	got := commands()
+	if len(got) != 5 || got[0] != "AUTH secret" || got[1] != "SELECT 2" {
+		t.Fatalf("Commands = %q, want AUTH and SELECT before the GET, SET and GET", got)
+	}
+	if !strings.HasPrefix(got[3], "SET "+redisPriceKeyPrefix+"ETH:1 ") || !strings.HasSuffix(got[3], " PX 2") {
+		t.Errorf("SET command = %q, want the prefixed key expiring in 2ms", got[3])
+	}
+}
+
+func TestRedisPriceCacheErrors(t *testing.T) {
+	for _, rawURL := range []string{"http://localhost:6379", "redis://", "redis://localhost/cache"} {
+		if _, err := NewRedisPriceCache(rawURL); err == nil {
+			t.Errorf("NewRedisPriceCache(%q) succeeded, want an invalid URL error", rawURL)
+		}
+	}
+
+	address, _ := fakeRedisServer(t, "secret")
+	cache, err := NewRedisPriceCache("redis://:wrong@" + address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, err := cache.GetPrice(ctx, "ETH:1"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("GetPrice with a wrong password = %v, want the server's WRONGPASS error", err)
+	}
+	if err := cache.Ping(ctx); err == nil {
+		t.Error("Ping with a wrong password succeeded")
+	}
+}
+
+func TestRedisPriceCachePing(t *testing.T) {
+	address, commands := fakeRedisServer(t, "")
+	cache, err := NewRedisPriceCache("redis://" + address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := cache.Ping(ctx); err != nil {
+		t.Fatalf("Ping = %v", err)
+	}
+	if got := commands(); len(got) != 1 || got[0] != "PING" {
+		t.Errorf("Commands = %q, want a PING", got)
+	}
+
+	down, err := NewRedisPriceCache("redis://127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := down.Ping(ctx); err == nil {
+		t.Error("Ping of an unreachable server succeeded")
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BridgeOutcomeRepository stores the outcomes of cross-chain swaps' bridge transfers
+type BridgeOutcomeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBridgeOutcomeRepository creates a new bridge outcome repository
+func NewBridgeOutcomeRepository(pool *pgxpool.Pool) *BridgeOutcomeRepository {
+	return &BridgeOutcomeRepository{
+		pool: pool,
+	}
+}
+
+// RecordOutcome stores a swap's bridge outcome, reporting false when the swap's outcome was recorded before
+func (r *BridgeOutcomeRepository) RecordOutcome(ctx context.Context, outcome types.BridgeOutcome) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`INSERT INTO bridge_outcomes (request_id, bridge, success, duration_ms, quoted_fee, actual_fee, recorded_at)
+		VALUES ($1, $2, $3, $4, $5::numeric, $6::numeric, $7)
+		ON CONFLICT (request_id) DO NOTHING`,
+		outcome.RequestID,
+		outcome.Bridge,
+		outcome.Success,
+		outcome.Duration.Milliseconds(),
+		numericOrNil(outcome.QuotedFee),
+		numericOrNil(outcome.ActualFee),
+		outcome.At,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RecentOutcomes returns up to limit of the most recent outcomes of each bridge, oldest first
+func (r *BridgeOutcomeRepository) RecentOutcomes(ctx context.Context, limit int) (map[string][]types.BridgeOutcome, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT request_id, bridge, success, duration_ms, quoted_fee::text, actual_fee::text, recorded_at
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY bridge ORDER BY recorded_at DESC) AS recency
+			FROM bridge_outcomes
+		) recent
+		WHERE recency <= $1
+		ORDER BY bridge, recorded_at`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outcomes := make(map[string][]types.BridgeOutcome)
+	for rows.Next() {
+		var outcome types.BridgeOutcome
+		var durationMs int64
+		var quotedFee, actualFee *string
+		if err := rows.Scan(&outcome.RequestID, &outcome.Bridge, &outcome.Success, &durationMs, &quotedFee, &actualFee, &outcome.At); err != nil {
+			return nil, err
+		}
+		outcome.Duration = time.Duration(durationMs) * time.Millisecond
+		if outcome.QuotedFee, err = parseNumeric(quotedFee); err != nil {
+			return nil, fmt.Errorf("invalid quoted fee for swap %s: %w", outcome.RequestID, err)
+		}
+		if outcome.ActualFee, err = parseNumeric(actualFee); err != nil {
+			return nil, fmt.Errorf("invalid actual fee for swap %s: %w", outcome.RequestID, err)
+		}
+		outcomes[outcome.Bridge] = append(outcomes[outcome.Bridge], outcome)
+	}
+	return outcomes, rows.Err()
+}
+
+// numericOrNil formats an amount for a NUMERIC column, keeping nil as NULL
+func numericOrNil(amount *big.Int) *string {
+	if amount == nil {
+		return nil
+	}
+	value := amount.String()
+	return &value
+}
+
+// parseNumeric parses a NUMERIC column read as text, keeping NULL as nil
+func parseNumeric(value *string) (*big.Int, error) {
+	if value == nil {
+		return nil, nil
+	}
+	amount, ok := new(big.Int).SetString(*value, 10)
+	if !ok {
+		return nil, fmt.Errorf("not an integer: %q", *value)
+	}
+	return amount, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeadLetterRepository stores dead-lettered swaps in Postgres, shared by the swap workers that find them and the
+// API servers operators resolve them through
+type DeadLetterRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDeadLetterRepository creates a new dead letter repository
+func NewDeadLetterRepository(pool *pgxpool.Pool) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		pool: pool,
+	}
+}
+
+// deadLetterColumns are the columns scanDeadLetter reads, in order
+const deadLetterColumns = `request_id, run_id, reason, detail, started_at, detected_at, status, resolved_by, action_id, resolved_at`
+
+// RecordDeadLetter stores a dead letter unless one is already stored for the swap's workflow run, reporting
+// whether it was new
+func (r *DeadLetterRepository) RecordDeadLetter(ctx context.Context, letter types.DeadLetter) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`INSERT INTO dead_letters (request_id, run_id, reason, detail, started_at, detected_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (request_id, run_id) DO NOTHING`,
+		letter.RequestID,
+		letter.RunID,
+		string(letter.Reason),
+		letter.Detail,
+		letter.StartedAt,
+		letter.DetectedAt,
+		string(letter.Status),
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetDeadLetter returns the most recently detected dead letter of a swap, or types.ErrDeadLetterNotFound
+func (r *DeadLetterRepository) GetDeadLetter(ctx context.Context, requestID string) (*types.DeadLetter, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+deadLetterColumns+` FROM dead_letters WHERE request_id = $1 ORDER BY detected_at DESC LIMIT 1`,
+		requestID,
+	)
+	letter, err := scanDeadLetter(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, types.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+// ListDeadLetters returns up to limit dead letters with the status, or of any status when it is empty, most
+// recently detected first
+func (r *DeadLetterRepository) ListDeadLetters(ctx context.Context, status types.DeadLetterStatus, limit int) ([]types.DeadLetter, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+deadLetterColumns+` FROM dead_letters
+		WHERE $1 = '' OR status = $1
+		ORDER BY detected_at DESC, request_id
+		LIMIT $2`,
+		string(status), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	letters := []types.DeadLetter{}
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, rows.Err()
+}
+
+// ResolveDeadLetter marks a swap's open dead letters requeued or refunded by an operator's admin action, or returns
+// types.ErrDeadLetterNotFound when it has none open
+func (r *DeadLetterRepository) ResolveDeadLetter(ctx context.Context, requestID string, status types.DeadLetterStatus, operator, actionID string, at time.Time) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE dead_letters SET status = $2, resolved_by = $3, action_id = $4, resolved_at = $5
+		WHERE request_id = $1 AND status = 'open'`,
+		requestID, string(status), operator, actionID, at,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// scanDeadLetter reads a row of deadLetterColumns
+func scanDeadLetter(row pgx.Row) (types.DeadLetter, error) {
+	var letter types.DeadLetter
+	var reason, status string
+	var resolvedBy, actionID *string
+	err := row.Scan(&letter.RequestID, &letter.RunID, &reason, &letter.Detail, &letter.StartedAt, &letter.DetectedAt,
+		&status, &resolvedBy, &actionID, &letter.ResolvedAt)
+	if err != nil {
+		return types.DeadLetter{}, err
+	}
+	letter.Reason = types.DeadLetterReason(reason)
+	letter.Status = types.DeadLetterStatus(status)
+	if resolvedBy != nil {
+		letter.ResolvedBy = *resolvedBy
+	}
+	if actionID != nil {
+		letter.ActionID = *actionID
+	}
+	return letter, nil
+}
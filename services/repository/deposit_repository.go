@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DepositRepository stores the deposits deposit-funded swaps expect and the transfers that fulfilled them
+type DepositRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDepositRepository creates a new deposit repository
+func NewDepositRepository(pool *pgxpool.Pool) *DepositRepository {
+	return &DepositRepository{
+		pool: pool,
+	}
+}
+
+// SaveExpectedDeposit stores an expected deposit, replacing any earlier one of the same swap
+func (r *DepositRepository) SaveExpectedDeposit(ctx context.Context, expected types.ExpectedDeposit) error {
+	token, err := json.Marshal(expected.Token)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO expected_deposits (request_id, chain_id, token, from_address, deposit_address, amount, webhook_url, expires_at, next_block)
+		VALUES ($1, $2, $3, $4, $5, $6::numeric, $7, $8, $9)
+		ON CONFLICT (request_id) DO UPDATE SET
+			chain_id = EXCLUDED.chain_id,
+			token = EXCLUDED.token,
+			from_address = EXCLUDED.from_address,
+			deposit_address = EXCLUDED.deposit_address,
+			amount = EXCLUDED.amount,
+			webhook_url = EXCLUDED.webhook_url,
+			expires_at = EXCLUDED.expires_at,
+			next_block = EXCLUDED.next_block`,
+		expected.RequestID,
+		expected.Token.ChainID,
+		token,
+		expected.From,
+		expected.Address,
+		expected.Amount.String(),
+		expected.WebhookURL,
+		expected.ExpiresAt,
+		int64(expected.NextBlock),
+	)
+	return err
+}
+
+// ExpectedDeposits returns the deposits still expected, oldest expiry first
+func (r *DepositRepository) ExpectedDeposits(ctx context.Context) ([]types.ExpectedDeposit, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT request_id, token, from_address, deposit_address, amount::text, webhook_url, expires_at, next_block
+		FROM expected_deposits
+		ORDER BY expires_at`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []types.ExpectedDeposit
+	for rows.Next() {
+		var expected types.ExpectedDeposit
+		var token []byte
+		var amount string
+		var nextBlock int64
+		if err := rows.Scan(&expected.RequestID, &token, &expected.From, &expected.Address, &amount, &expected.WebhookURL, &expected.ExpiresAt, &nextBlock); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(token, &expected.Token); err != nil {
+			return nil, fmt.Errorf("invalid token for deposit of swap %s: %w", expected.RequestID, err)
+		}
+		var ok bool
+		if expected.Amount, ok = new(big.Int).SetString(amount, 10); !ok {
+			return nil, fmt.Errorf("invalid amount %q for deposit of swap %s", amount, expected.RequestID)
+		}
+		expected.NextBlock = uint64(nextBlock)
+		deposits = append(deposits, expected)
+	}
+	return deposits, rows.Err()
+}
+
+// AdvanceDeposits moves the next block of the swaps' expected deposits forward to nextBlock
+func (r *DepositRepository) AdvanceDeposits(ctx context.Context, requestIDs []string, nextBlock uint64) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE expected_deposits SET next_block = GREATEST(next_block, $2) WHERE request_id = ANY($1)`,
+		requestIDs,
+		int64(nextBlock),
+	)
+	return err
+}
+
+// FulfilDeposit records the transfer that fulfilled a swap's expected deposit and stops expecting it, in one transaction
+func (r *DepositRepository) FulfilDeposit(ctx context.Context, requestID, transferKey string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	deleted, err := tx.Exec(ctx, `DELETE FROM expected_deposits WHERE request_id = $1`, requestID)
+	if err != nil {
+		return false, err
+	}
+	if deleted.RowsAffected() == 0 {
+		return false, nil
+	}
+	inserted, err := tx.Exec(ctx,
+		`INSERT INTO deposit_transfers (transfer_key, request_id) VALUES ($1, $2) ON CONFLICT (transfer_key) DO NOTHING`,
+		transferKey,
+		requestID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if inserted.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, tx.Commit(ctx)
+}
+
+// DeleteExpectedDeposit stops expecting a swap's deposit
+func (r *DepositRepository) DeleteExpectedDeposit(ctx context.Context, requestID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM expected_deposits WHERE request_id = $1`, requestID)
+	return err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrorRepository stores error occurrence counts in Postgres
+type ErrorRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewErrorRepository creates a new error repository
+func NewErrorRepository(pool *pgxpool.Pool) *ErrorRepository {
+	return &ErrorRepository{
+		pool: pool,
+	}
+}
+
+// AddErrors adds occurrence counts to the stored counts in one batch, which applies all of them or none
+func (r *ErrorRepository) AddErrors(ctx context.Context, stats []types.ErrorStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, stat := range stats {
+		batch.Queue(
+			`INSERT INTO error_fingerprints (fingerprint, origin, operation, code, error_type, message, occurrences, first_seen, last_seen)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (fingerprint) DO UPDATE SET
+				occurrences = error_fingerprints.occurrences + EXCLUDED.occurrences,
+				first_seen = LEAST(error_fingerprints.first_seen, EXCLUDED.first_seen),
+				message = CASE WHEN EXCLUDED.last_seen >= error_fingerprints.last_seen
+					THEN EXCLUDED.message ELSE error_fingerprints.message END,
+				last_seen = GREATEST(error_fingerprints.last_seen, EXCLUDED.last_seen)`,
+			stat.Fingerprint,
+			string(stat.Origin),
+			stat.Operation,
+			stat.Code,
+			stat.Type,
+			stat.Message,
+			stat.Count,
+			stat.FirstSeen,
+			stat.LastSeen,
+		)
+	}
+	return r.pool.SendBatch(ctx, batch).Close()
+}
+
+// TopErrors returns up to limit fingerprints last seen at or after since, most occurrences first
+func (r *ErrorRepository) TopErrors(ctx context.Context, since time.Time, limit int) ([]types.ErrorStat, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT fingerprint, origin, operation, code, error_type, message, occurrences, first_seen, last_seen
+		FROM error_fingerprints
+		WHERE last_seen >= $1
+		ORDER BY occurrences DESC, fingerprint
+		LIMIT $2`,
+		since,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []types.ErrorStat{}
+	for rows.Next() {
+		var stat types.ErrorStat
+		var origin string
+		if err := rows.Scan(&stat.Fingerprint, &origin, &stat.Operation, &stat.Code, &stat.Type, &stat.Message, &stat.Count, &stat.FirstSeen, &stat.LastSeen); err != nil {
+			return nil, err
+		}
+		stat.Origin = types.ErrorOrigin(origin)
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GasSponsorshipRepository stores the gas sponsored for swaps
+type GasSponsorshipRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewGasSponsorshipRepository creates a new gas sponsorship repository
+func NewGasSponsorshipRepository(pool *pgxpool.Pool) *GasSponsorshipRepository {
+	return &GasSponsorshipRepository{
+		pool: pool,
+	}
+}
+
+// RecordSponsorship stores a swap's sponsorship unless it would take its chain over capUSD. Sponsorships of a chain
+// are recorded one at a time, so concurrent swaps can't overrun the cap together.
+func (r *GasSponsorshipRepository) RecordSponsorship(ctx context.Context, sponsorship types.GasSponsorship, since time.Time, capUSD float64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('gas_sponsorships'), $1::int)`, sponsorship.ChainID); err != nil {
+		return err
+	}
+	var recorded bool
+	err = tx.QueryRow(ctx, `SELECT true FROM gas_sponsorships WHERE request_id = $1`, sponsorship.RequestID).Scan(&recorded)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if capUSD > 0 {
+		sponsored, err := sponsoredSince(ctx, tx, sponsorship.ChainID, since)
+		if err != nil {
+			return err
+		}
+		if sponsored+sponsorship.CostUSD > capUSD {
+			return types.ErrGasSponsorCapReached
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO gas_sponsorships (request_id, chain_id, gas_units, gas_cost, cost_usd, token, recouped, created_at)
+		VALUES ($1, $2, $3, $4::numeric, $5, $6, $7::numeric, $8)`,
+		sponsorship.RequestID,
+		sponsorship.ChainID,
+		int64(sponsorship.GasUnits),
+		numericOrNil(sponsorship.GasCost),
+		sponsorship.CostUSD,
+		sponsorship.Token,
+		numericOrNil(sponsorship.Recouped),
+		sponsorship.CreatedAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SponsoredSince returns the USD cost of a chain's sponsorships since since
+func (r *GasSponsorshipRepository) SponsoredSince(ctx context.Context, chainID int64, since time.Time) (float64, error) {
+	return sponsoredSince(ctx, r.pool, chainID, since)
+}
+
+// sponsoredSince sums a chain's sponsorships since since through q, a pool or a transaction
+func sponsoredSince(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}, chainID int64, since time.Time) (float64, error) {
+	var total float64
+	err := q.QueryRow(ctx,
+		`SELECT COALESCE(SUM(cost_usd), 0) FROM gas_sponsorships WHERE chain_id = $1 AND created_at >= $2`,
+		chainID,
+		since,
+	).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ListedTokenRepository stores the tokens added to the registry by approved listings in Postgres
+type ListedTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewListedTokenRepository creates a new listed token repository
+func NewListedTokenRepository(pool *pgxpool.Pool) *ListedTokenRepository {
+	return &ListedTokenRepository{
+		pool: pool,
+	}
+}
+
+// SaveListedToken upserts a listed token by chain and contract address
+func (r *ListedTokenRepository) SaveListedToken(ctx context.Context, token types.Token) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO listed_tokens (chain_id, address, symbol, name, decimals, chain_name, logo_uri)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (chain_id, address) DO UPDATE SET
+			symbol = EXCLUDED.symbol,
+			name = EXCLUDED.name,
+			decimals = EXCLUDED.decimals,
+			chain_name = EXCLUDED.chain_name,
+			logo_uri = EXCLUDED.logo_uri`,
+		token.ChainID,
+		strings.ToLower(token.Address),
+		token.Symbol,
+		token.Name,
+		token.Decimals,
+		token.ChainName,
+		token.LogoURI,
+	)
+	return err
+}
+
+// ListedTokens returns every listed token, ordered by chain and symbol
+func (r *ListedTokenRepository) ListedTokens(ctx context.Context) ([]types.Token, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT chain_id, address, symbol, name, decimals, chain_name, logo_uri
+		FROM listed_tokens
+		ORDER BY chain_id, symbol`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []types.Token
+	for rows.Next() {
+		var token types.Token
+		if err := rows.Scan(&token.ChainID, &token.Address, &token.Symbol, &token.Name, &token.Decimals, &token.ChainName, &token.LogoURI); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ParameterRepository stores operator-managed parameters, such as rule expressions
+type ParameterRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewParameterRepository creates a new parameter repository
+func NewParameterRepository(pool *pgxpool.Pool) *ParameterRepository {
+	return &ParameterRepository{
+		pool: pool,
+	}
+}
+
+// GetParameter returns a parameter, or types.ErrParameterNotFound
+func (r *ParameterRepository) GetParameter(ctx context.Context, name string) (*types.Parameter, error) {
+	var param types.Parameter
+	err := r.pool.QueryRow(ctx,
+		`SELECT name, value, description, version, updated_at FROM parameters WHERE name = $1`,
+		name,
+	).Scan(&param.Name, &param.Value, &param.Description, &param.Version, &param.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, types.ErrParameterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &param, nil
+}
+
+// ListParameters returns the parameters whose names start with prefix, ordered by name
+func (r *ParameterRepository) ListParameters(ctx context.Context, prefix string) ([]types.Parameter, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT name, value, description, version, updated_at FROM parameters
+		WHERE starts_with(name, $1)
+		ORDER BY name`,
+		prefix,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var params []types.Parameter
+	for rows.Next() {
+		var param types.Parameter
+		if err := rows.Scan(&param.Name, &param.Value, &param.Description, &param.Version, &param.UpdatedAt); err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, rows.Err()
+}
+
+// SaveParameter stores the next version of a parameter, replacing the previous one. The version is bumped in the
+// upsert itself, so concurrent saves can't both write the same version.
+func (r *ParameterRepository) SaveParameter(ctx context.Context, param types.Parameter) (*types.Parameter, error) {
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO parameters (name, value, description, version, updated_at)
+		VALUES ($1, $2, $3, 1, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			value = EXCLUDED.value,
+			description = EXCLUDED.description,
+			version = parameters.version + 1,
+			updated_at = EXCLUDED.updated_at
+		RETURNING version`,
+		param.Name,
+		param.Value,
+		param.Description,
+		param.UpdatedAt,
+	).Scan(&param.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &param, nil
+}
+
+// DeleteParameter removes a parameter, returning types.ErrParameterNotFound if there is none
+func (r *ParameterRepository) DeleteParameter(ctx context.Context, name string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM parameters WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrParameterNotFound
+	}
+	return nil
+}
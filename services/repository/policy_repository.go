@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PolicyRepository stores per-tenant compliance policy documents
+type PolicyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPolicyRepository creates a new policy repository
+func NewPolicyRepository(pool *pgxpool.Pool) *PolicyRepository {
+	return &PolicyRepository{
+		pool: pool,
+	}
+}
+
+// GetPolicy returns the tenant's policy document, or types.ErrPolicyNotFound
+func (r *PolicyRepository) GetPolicy(ctx context.Context, tenantID string) (*types.PolicyDocument, error) {
+	var data []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT document FROM compliance_policies WHERE tenant_id = $1`,
+		tenantID,
+	).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, types.ErrPolicyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc types.PolicyDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid policy document for tenant %s: %w", tenantID, err)
+	}
+	return &doc, nil
+}
+
+// SavePolicy stores the tenant's policy document, replacing any previous version
+func (r *PolicyRepository) SavePolicy(ctx context.Context, doc types.PolicyDocument) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO compliance_policies (tenant_id, version, document, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			version = EXCLUDED.version,
+			document = EXCLUDED.document,
+			updated_at = EXCLUDED.updated_at`,
+		doc.TenantID,
+		doc.Version,
+		data,
+		doc.UpdatedAt,
+	)
+	return err
+}
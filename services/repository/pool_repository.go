@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolRepository stores liquidity pools, with their positions and reservations, and the pool operations applied to them
+type PoolRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPoolRepository creates a new pool repository
+func NewPoolRepository(pool *pgxpool.Pool) *PoolRepository {
+	return &PoolRepository{
+		pool: pool,
+	}
+}
+
+// CreatePool stores a new pool, reporting false when a pool with the same ID exists
+func (r *PoolRepository) CreatePool(ctx context.Context, state types.PoolState) (bool, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.pool.Exec(ctx,
+		`INSERT INTO liquidity_pools (id, state) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`,
+		state.Pool.ID,
+		data,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetPool returns a pool's state, or types.ErrPoolNotFound
+func (r *PoolRepository) GetPool(ctx context.Context, poolID string) (*types.PoolState, error) {
+	var data []byte
+	err := r.pool.QueryRow(ctx, `SELECT state FROM liquidity_pools WHERE id = $1`, poolID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, types.ErrPoolNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodePoolState(poolID, data)
+}
+
+// ListPools returns the state of every pool, ordered by pool ID
+func (r *PoolRepository) ListPools(ctx context.Context) ([]types.PoolState, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, state FROM liquidity_pools ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []types.PoolState
+	for rows.Next() {
+		var poolID string
+		var data []byte
+		if err := rows.Scan(&poolID, &data); err != nil {
+			return nil, err
+		}
+		state, err := decodePoolState(poolID, data)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, *state)
+	}
+	return states, rows.Err()
+}
+
+// UpdatePool applies update to a pool's state in one transaction, holding the pool's row lock so concurrent
+// updates from other processes apply one after another. The operation ID is recorded in the same transaction.
+func (r *PoolRepository) UpdatePool(ctx context.Context, poolID, operationID string, update func(state *types.PoolState) error) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var data []byte
+	err = tx.QueryRow(ctx, `SELECT state FROM liquidity_pools WHERE id = $1 FOR UPDATE`, poolID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, types.ErrPoolNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if operationID != "" {
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO liquidity_operations (operation_id, pool_id) VALUES ($1, $2) ON CONFLICT (operation_id) DO NOTHING`,
+			operationID,
+			poolID,
+		)
+		if err != nil {
+			return false, err
+		}
+		if tag.RowsAffected() == 0 {
+			return false, nil
+		}
+	}
+
+	state, err := decodePoolState(poolID, data)
+	if err != nil {
+		return false, err
+	}
+	if err := update(state); err != nil {
+		return false, err
+	}
+
+	updated, err := json.Marshal(state)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE liquidity_pools SET state = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		poolID,
+		updated,
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// decodePoolState parses a stored pool state
+func decodePoolState(poolID string, data []byte) (*types.PoolState, error) {
+	var state types.PoolState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid state for pool %s: %w", poolID, err)
+	}
+	return &state, nil
+}
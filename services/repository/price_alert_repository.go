@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PriceAlertRepository stores the price alerts clients registered in Postgres
+type PriceAlertRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPriceAlertRepository creates a new price alert repository
+func NewPriceAlertRepository(pool *pgxpool.Pool) *PriceAlertRepository {
+	return &PriceAlertRepository{
+		pool: pool,
+	}
+}
+
+// priceAlertColumns are the columns scanPriceAlert reads, in order
+const priceAlertColumns = `id, owner, symbol, chain_id, threshold, direction, webhook_url, email, status, created_at, triggered_at, trigger_price`
+
+// CreatePriceAlert saves a new alert
+func (r *PriceAlertRepository) CreatePriceAlert(ctx context.Context, alert types.PriceAlert) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO price_alerts (`+priceAlertColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		alert.ID,
+		alert.Owner,
+		alert.Symbol,
+		alert.ChainID,
+		alert.Threshold,
+		alert.Direction,
+		alert.WebhookURL,
+		alert.Email,
+		alert.Status,
+		alert.CreatedAt,
+		alert.TriggeredAt,
+		alert.TriggerPrice,
+	)
+	return err
+}
+
+// GetPriceAlert returns an owner's alert, or types.ErrPriceAlertNotFound
+func (r *PriceAlertRepository) GetPriceAlert(ctx context.Context, owner, id string) (types.PriceAlert, error) {
+	alert, err := scanPriceAlert(r.pool.QueryRow(ctx,
+		`SELECT `+priceAlertColumns+` FROM price_alerts WHERE id = $1 AND owner = $2`,
+		id, owner,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return types.PriceAlert{}, types.ErrPriceAlertNotFound
+	}
+	return alert, err
+}
+
+// ListPriceAlerts returns an owner's alerts, newest first
+func (r *PriceAlertRepository) ListPriceAlerts(ctx context.Context, owner string) ([]types.PriceAlert, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+priceAlertColumns+` FROM price_alerts WHERE owner = $1 ORDER BY created_at DESC, id`,
+		owner,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []types.PriceAlert
+	for rows.Next() {
+		alert, err := scanPriceAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+// DeletePriceAlert deletes an owner's alert, or returns types.ErrPriceAlertNotFound
+func (r *PriceAlertRepository) DeletePriceAlert(ctx context.Context, owner, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM price_alerts WHERE id = $1 AND owner = $2`, id, owner)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrPriceAlertNotFound
+	}
+	return nil
+}
+
+// HasActivePriceAlerts reports whether any alert is still active
+func (r *PriceAlertRepository) HasActivePriceAlerts(ctx context.Context) (bool, error) {
+	var active bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM price_alerts WHERE status = $1)`,
+		types.PriceAlertStatusActive,
+	).Scan(&active)
+	return active, err
+}
+
+// TriggerPriceAlerts marks the active alerts prices crossed as triggered and returns them. An alert is only returned
+// by the update that moved it out of active, so concurrent evaluations notify it once.
+func (r *PriceAlertRepository) TriggerPriceAlerts(ctx context.Context, prices []types.TokenPrice, at time.Time) ([]types.PriceAlert, error) {
+	symbols := make([]string, 0, len(prices))
+	seen := make(map[string]bool, len(prices))
+	for _, price := range prices {
+		symbol := strings.ToUpper(price.Symbol)
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+priceAlertColumns+` FROM price_alerts WHERE status = $1 AND symbol = ANY($2) ORDER BY id`,
+		types.PriceAlertStatusActive, symbols,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var crossed []types.PriceAlert
+	for rows.Next() {
+		alert, err := scanPriceAlert(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if price, ok := alert.CrossingPrice(prices); ok {
+			alert.Trigger(price.PriceUSD, at)
+			crossed = append(crossed, alert)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var triggered []types.PriceAlert
+	for _, alert := range crossed {
+		tag, err := r.pool.Exec(ctx,
+			`UPDATE price_alerts SET status = $1, triggered_at = $2, trigger_price = $3
+			WHERE id = $4 AND status = $5`,
+			alert.Status, alert.TriggeredAt, alert.TriggerPrice, alert.ID, types.PriceAlertStatusActive,
+		)
+		if err != nil {
+			return triggered, err
+		}
+		if tag.RowsAffected() == 1 {
+			triggered = append(triggered, alert)
+		}
+	}
+	return triggered, nil
+}
+
+// scanPriceAlert reads a price alert selected with priceAlertColumns
+func scanPriceAlert(row pgx.Row) (types.PriceAlert, error) {
+	var alert types.PriceAlert
+	err := row.Scan(
+		&alert.ID,
+		&alert.Owner,
+		&alert.Symbol,
+		&alert.ChainID,
+		&alert.Threshold,
+		&alert.Direction,
+		&alert.WebhookURL,
+		&alert.Email,
+		&alert.Status,
+		&alert.CreatedAt,
+		&alert.TriggeredAt,
+		&alert.TriggerPrice,
+	)
+	return alert, err
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/infinity-dex/services/types"
@@ -11,50 +13,233 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// PriceUpdatesChannel is the Postgres notification channel announcing newly saved prices
+const PriceUpdatesChannel = "price_updates"
+
+// defaultPriceBatchSize is how many prices are staged and upserted together unless SetBatchSize is called
+const defaultPriceBatchSize = 500
+
+// PriceWriteStats counts the prices saved by a PriceRepository and the time spent saving them
+type PriceWriteStats struct {
+	Prices      int64         // Prices given to SaveTokenPrices
+	Rows        int64         // token_prices rows written; prices saved before, e.g. by a replayed batch, are skipped
+	HistoryRows int64         // token_price_history rows written for prices that changed
+	Duration    time.Duration // Time spent in committed saves
+}
+
+// RowsPerSecond returns the rate prices were saved at
+func (s PriceWriteStats) RowsPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Prices) / s.Duration.Seconds()
+}
+
 // PriceRepository handles database operations for token prices
 type PriceRepository struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	batchSize int
+
+	stats   PriceWriteStats // Totals of all committed saves
+	statsMu sync.Mutex
 }
 
 // NewPriceRepository creates a new price repository
 func NewPriceRepository(pool *pgxpool.Pool) *PriceRepository {
 	return &PriceRepository{
-		pool: pool,
+		pool:      pool,
+		batchSize: defaultPriceBatchSize,
+	}
+}
+
+// SetBatchSize sets how many prices are staged and upserted together; sizes below 1 restore the default
+func (r *PriceRepository) SetBatchSize(size int) {
+	if size < 1 {
+		size = defaultPriceBatchSize
 	}
+	r.batchSize = size
 }
 
-// SaveTokenPrices saves token prices to the database
+// WriteStats returns the totals of all prices saved so far
+func (r *PriceRepository) WriteStats() PriceWriteStats {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	return r.stats
+}
+
+// SaveTokenPrices saves token prices to the database and notifies PriceUpdatesChannel listeners once they are committed
 func (r *PriceRepository) SaveTokenPrices(ctx context.Context, prices []types.TokenPrice) error {
-	// Use a transaction for batch operations
-	return r.executeInTransaction(ctx, func(tx pgx.Tx) error {
-		for _, price := range prices {
-			if err := r.saveTokenPrice(ctx, tx, price); err != nil {
+	_, err := r.SaveTokenPricesWithStats(ctx, prices)
+	return err
+}
+
+// SaveTokenPricesWithStats saves token prices like SaveTokenPrices and returns what the save wrote. All prices are
+// saved in one transaction, in batches of the repository's batch size that are each copied into a staging table and
+// upserted with a few set-based statements. Prices already saved for the same token, source and time are skipped, so
+// saving a batch again is harmless.
+func (r *PriceRepository) SaveTokenPricesWithStats(ctx context.Context, prices []types.TokenPrice) (PriceWriteStats, error) {
+	started := time.Now()
+	stats := PriceWriteStats{Prices: int64(len(prices))}
+	err := r.executeInTransaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, createPriceStagingTable); err != nil {
+			return fmt.Errorf("failed to create price staging table: %w", err)
+		}
+		for start := 0; start < len(prices); start += r.batchSize {
+			end := min(start+r.batchSize, len(prices))
+			rows, historyRows, err := r.upsertTokenPrices(ctx, tx, prices[start:end])
+			if err != nil {
 				return err
 			}
+			stats.Rows += rows
+			stats.HistoryRows += historyRows
 		}
-		return nil
+		// Postgres delivers the notification on commit, so listeners never read prices before they are visible
+		_, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, PriceUpdatesChannel, fmt.Sprint(len(prices)))
+		return err
 	})
+	if err != nil {
+		return PriceWriteStats{}, err
+	}
+	stats.Duration = time.Since(started)
+
+	r.statsMu.Lock()
+	r.stats.Prices += stats.Prices
+	r.stats.Rows += stats.Rows
+	r.stats.HistoryRows += stats.HistoryRows
+	r.stats.Duration += stats.Duration
+	r.statsMu.Unlock()
+	return stats, nil
 }
 
-// saveTokenPrice saves a single token price to the database
-func (r *PriceRepository) saveTokenPrice(ctx context.Context, tx pgx.Tx, price types.TokenPrice) error {
-	// Call the update_token_price function
-	_, err := tx.Exec(ctx,
-		`SELECT update_token_price($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
-		price.Symbol,
-		price.Name,
-		price.Address,
-		price.ChainID,
-		price.ChainName,
-		price.PriceUSD,
-		price.Change24h,
-		price.Volume24h,
-		price.MarketCapUSD,
-		price.IsVerified,
-		string(price.Source),
-		price.LastUpdated,
-	)
-	return err
+// ListenForPriceUpdates calls notify each time saved prices are announced on PriceUpdatesChannel,
+// holding a connection until ctx is done or the connection fails
+func (r *PriceRepository) ListenForPriceUpdates(ctx context.Context, notify func()) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+PriceUpdatesChannel); err != nil {
+		return err
+	}
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return err
+		}
+		notify()
+	}
+}
+
+// createPriceStagingTable creates the table each batch of prices is copied into before being upserted. It is
+// dropped when the transaction ends.
+const createPriceStagingTable = `
+	CREATE TEMP TABLE IF NOT EXISTS price_staging (
+		seq INTEGER NOT NULL,
+		symbol TEXT NOT NULL,
+		name TEXT NOT NULL,
+		address TEXT,
+		chain_id BIGINT NOT NULL,
+		chain_name TEXT NOT NULL,
+		price_usd DOUBLE PRECISION NOT NULL,
+		change_24h DOUBLE PRECISION,
+		volume_24h DOUBLE PRECISION,
+		market_cap_usd DOUBLE PRECISION,
+		is_verified BOOLEAN NOT NULL,
+		source TEXT NOT NULL,
+		last_updated TIMESTAMP WITH TIME ZONE NOT NULL
+	) ON COMMIT DROP`
+
+// priceStagingColumns are the columns of price_staging, in the order rows are copied
+var priceStagingColumns = []string{
+	"seq", "symbol", "name", "address", "chain_id", "chain_name", "price_usd",
+	"change_24h", "volume_24h", "market_cap_usd", "is_verified", "source", "last_updated",
+}
+
+// upsertTokenPrices copies a batch of prices into price_staging and saves them with the semantics of the
+// update_token_price function: tokens are upserted, every price is added to token_prices, and prices that differ
+// from the token's previous price are added to its history. It returns the price and history rows written.
+func (r *PriceRepository) upsertTokenPrices(ctx context.Context, tx pgx.Tx, prices []types.TokenPrice) (int64, int64, error) {
+	rows := make([][]any, len(prices))
+	for i, price := range prices {
+		// Batches queued in the outbox may predate chain ID canonicalization
+		types.CanonicalizeTokenPrice(&price)
+		rows[i] = []any{
+			i,
+			price.Symbol,
+			price.Name,
+			price.Address,
+			price.ChainID,
+			price.ChainName,
+			price.PriceUSD,
+			price.Change24h,
+			price.Volume24h,
+			price.MarketCapUSD,
+			price.IsVerified,
+			string(price.Source),
+			price.LastUpdated,
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `TRUNCATE price_staging`); err != nil {
+		return 0, 0, fmt.Errorf("failed to clear price staging table: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"price_staging"}, priceStagingColumns, pgx.CopyFromRows(rows)); err != nil {
+		return 0, 0, fmt.Errorf("failed to stage prices: %w", err)
+	}
+
+	// A token priced more than once in the batch takes the details of its last price
+	_, err := tx.Exec(ctx, `
+		INSERT INTO tokens (symbol, name, address, chain_id, chain_name, is_verified, updated_at)
+		SELECT DISTINCT ON (symbol, chain_id) symbol, name, address, chain_id, chain_name, is_verified, CURRENT_TIMESTAMP
+		FROM price_staging
+		ORDER BY symbol, chain_id, seq DESC
+		ON CONFLICT (symbol, chain_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			address = COALESCE(EXCLUDED.address, tokens.address),
+			chain_name = EXCLUDED.chain_name,
+			is_verified = EXCLUDED.is_verified,
+			updated_at = EXCLUDED.updated_at`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to upsert tokens: %w", err)
+	}
+
+	// Each price is compared with the token's previous price in the batch, or else its latest saved price, which
+	// the statement sees as it was before the batch
+	var written, history int64
+	err = tx.QueryRow(ctx, `
+		WITH inserted AS (
+			INSERT INTO token_prices (token_id, price_usd, change_24h, volume_24h, market_cap_usd, source, last_updated, updated_at)
+			SELECT t.id, s.price_usd, s.change_24h, s.volume_24h, s.market_cap_usd, s.source, s.last_updated, CURRENT_TIMESTAMP
+			FROM price_staging s
+			JOIN tokens t ON t.symbol = s.symbol AND t.chain_id = s.chain_id
+			ORDER BY s.seq
+			ON CONFLICT (token_id, source, last_updated) DO NOTHING
+			RETURNING id, token_id, price_usd, change_24h, volume_24h, market_cap_usd, source, last_updated
+		),
+		compared AS (
+			SELECT i.*, LAG(i.price_usd) OVER (PARTITION BY i.token_id ORDER BY i.id) AS previous_in_batch
+			FROM inserted i
+		),
+		added AS (
+			INSERT INTO token_price_history (token_id, price_usd, change_24h, volume_24h, market_cap_usd, source, timestamp)
+			SELECT c.token_id, c.price_usd, c.change_24h, c.volume_24h, c.market_cap_usd, c.source, c.last_updated
+			FROM compared c
+			WHERE c.price_usd IS DISTINCT FROM COALESCE(c.previous_in_batch, (
+				SELECT tp.price_usd FROM token_prices tp
+				WHERE tp.token_id = c.token_id
+				ORDER BY tp.last_updated DESC
+				LIMIT 1
+			))
+			ORDER BY c.id
+			ON CONFLICT (token_id, source, timestamp) DO NOTHING
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM inserted), (SELECT COUNT(*) FROM added)`).Scan(&written, &history)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to upsert prices: %w", err)
+	}
+	return written, history, nil
 }
 
 // GetLatestTokenPrices gets the latest token prices from the database
@@ -106,6 +291,7 @@ func (r *PriceRepository) GetTokenPriceHistory(ctx context.Context, symbol strin
 		WHERE t.symbol = $1 AND t.chain_id = $2 AND tph.timestamp BETWEEN $3 AND $4
 		ORDER BY tph.timestamp DESC
 	`
+	chainID = types.CanonicalChainID(chainID)
 
 	rows, err := r.pool.Query(ctx, query, symbol, chainID, startTime, endTime)
 	if err != nil {
@@ -141,11 +327,164 @@ func (r *PriceRepository) GetTokenPriceHistory(ctx context.Context, symbol strin
 	return history, nil
 }
 
-// executeInTransaction executes a function within a transaction
-func (r *PriceRepository) executeInTransaction(ctx context.Context, fn func(pgx.Tx) error) error {
-	tx, err := r.pool.Begin(ctx)
+// GetTokenPriceAverage computes the TWAP and VWAP of a token between start and end with the semantics of
+// services.AveragePrices, or returns nil when the token has no samples in or before the window
+func (r *PriceRepository) GetTokenPriceAverage(ctx context.Context, symbol string, chainID int64, start, end time.Time) (*types.PriceAverage, error) {
+	query := `
+		WITH history AS (
+			SELECT tph.price_usd, NULLIF(tph.volume_24h, 0) AS volume_24h, tph.timestamp
+			FROM token_price_history tph
+			JOIN tokens t ON tph.token_id = t.id
+			WHERE t.symbol = $1 AND t.chain_id = $2
+		),
+		points AS (
+			-- The price in effect when the window opened covers the window up to its first sample
+			(SELECT price_usd, volume_24h, $3::timestamptz AS ts, FALSE AS in_window
+			FROM history
+			WHERE timestamp < $3
+			ORDER BY timestamp DESC
+			LIMIT 1)
+			UNION ALL
+			SELECT price_usd, volume_24h, timestamp, TRUE
+			FROM history
+			WHERE timestamp BETWEEN $3 AND $4
+		),
+		spans AS (
+			SELECT price_usd, in_window, ts,
+				EXTRACT(EPOCH FROM COALESCE(LEAD(ts) OVER (ORDER BY ts), $4::timestamptz) - ts) AS seconds,
+				-- Growth of the rolling 24h volume since the previous sample, approximating the volume traded in between
+				GREATEST(volume_24h - LAG(volume_24h) OVER (ORDER BY ts), 0) AS traded
+			FROM points
+		)
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE in_window),
+			COALESCE(SUM(seconds), 0)::float8,
+			COALESCE(SUM(price_usd * seconds) / NULLIF(SUM(seconds), 0), (ARRAY_AGG(price_usd ORDER BY ts DESC))[1])::float8,
+			COALESCE(SUM(price_usd * traded) FILTER (WHERE in_window) / NULLIF(SUM(traded) FILTER (WHERE in_window), 0), 0)::float8
+		FROM spans
+	`
+	chainID = types.CanonicalChainID(chainID)
+
+	avg := types.PriceAverage{Start: start, End: end}
+	var points int
+	var covered float64
+	var twap *float64
+	err := r.pool.QueryRow(ctx, query, symbol, chainID, start, end).Scan(&points, &avg.Samples, &covered, &twap, &avg.VWAP)
 	if err != nil {
-		return fmt.Errorf("unable to begin transaction: %w", err)
+		return nil, err
+	}
+	if points == 0 || twap == nil {
+		return nil, nil
+	}
+	avg.TWAP = *twap
+	if window := end.Sub(start).Seconds(); window > 0 {
+		avg.Coverage = math.Min(1, covered/window)
+	}
+	return &avg, nil
+}
+
+// RollupCandles rolls the price history saved since the interval's last rollup into candles, and records
+// the newest history row rolled up. Every bucket a new row falls into is aggregated again from all of its
+// history, so rows saved late still land in their candles. It returns the number of candles written.
+func (r *PriceRepository) RollupCandles(ctx context.Context, interval string) (int64, error) {
+	d, ok := types.CandleIntervals[interval]
+	if !ok {
+		return 0, fmt.Errorf("unsupported candle interval %q", interval)
+	}
+
+	query := `
+		WITH mark AS (
+			SELECT COALESCE((SELECT last_history_id FROM candle_rollups WHERE candle_interval = $1), 0) AS last_id
+		),
+		fresh AS (
+			SELECT h.id, h.token_id,
+				to_timestamp(floor(extract(epoch FROM h.timestamp) / $2::bigint) * $2::bigint) AS bucket
+			FROM token_price_history h, mark
+			WHERE h.id > mark.last_id
+		),
+		touched AS (
+			SELECT DISTINCT token_id, bucket FROM fresh
+		),
+		written AS (
+			INSERT INTO token_price_candles (token_id, candle_interval, bucket_start, open, high, low, close, volume, samples, updated_at)
+			SELECT h.token_id, $1, t.bucket,
+				(array_agg(h.price_usd ORDER BY h.timestamp ASC))[1],
+				MAX(h.price_usd),
+				MIN(h.price_usd),
+				(array_agg(h.price_usd ORDER BY h.timestamp DESC))[1],
+				(array_agg(h.volume_24h ORDER BY h.timestamp DESC))[1],
+				COUNT(*),
+				CURRENT_TIMESTAMP
+			FROM touched t
+			JOIN token_price_history h ON h.token_id = t.token_id
+				AND h.timestamp >= t.bucket AND h.timestamp < t.bucket + $2::bigint * INTERVAL '1 second'
+			GROUP BY h.token_id, t.bucket
+			ON CONFLICT (token_id, candle_interval, bucket_start) DO UPDATE SET
+				open = EXCLUDED.open,
+				high = EXCLUDED.high,
+				low = EXCLUDED.low,
+				close = EXCLUDED.close,
+				volume = EXCLUDED.volume,
+				samples = EXCLUDED.samples,
+				updated_at = EXCLUDED.updated_at
+			RETURNING 1
+		),
+		advanced AS (
+			INSERT INTO candle_rollups (candle_interval, last_history_id, updated_at)
+			SELECT $1, MAX(id), CURRENT_TIMESTAMP FROM fresh HAVING COUNT(*) > 0
+			ON CONFLICT (candle_interval) DO UPDATE SET
+				last_history_id = EXCLUDED.last_history_id,
+				updated_at = EXCLUDED.updated_at
+		)
+		SELECT COUNT(*) FROM written
+	`
+
+	var written int64
+	if err := r.pool.QueryRow(ctx, query, interval, int64(d.Seconds())).Scan(&written); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// GetCandles gets a token's candles of an interval starting between startTime and endTime, oldest first
+func (r *PriceRepository) GetCandles(ctx context.Context, symbol string, chainID int64, interval string, startTime, endTime time.Time) ([]types.Candle, error) {
+	query := `
+		SELECT c.bucket_start, c.open, c.high, c.low, c.close, COALESCE(c.volume, 0), c.samples
+		FROM token_price_candles c
+		JOIN tokens t ON c.token_id = t.id
+		WHERE t.symbol = $1 AND t.chain_id = $2 AND c.candle_interval = $3 AND c.bucket_start BETWEEN $4 AND $5
+		ORDER BY c.bucket_start ASC
+	`
+	chainID = types.CanonicalChainID(chainID)
+
+	rows, err := r.pool.Query(ctx, query, symbol, chainID, interval, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []types.Candle
+	for rows.Next() {
+		c := types.Candle{Symbol: symbol, ChainID: chainID, Interval: interval}
+		if err := rows.Scan(&c.Start, &c.Open, &c.High, &c.Low, &c.Close, &c.Volume, &c.Samples); err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return candles, nil
+}
+
+// executeInTransaction executes a function within a transaction, returning the error of a failed commit too
+func (r *PriceRepository) executeInTransaction(ctx context.Context, fn func(pgx.Tx) error) (err error) {
+	tx, beginErr := r.pool.Begin(ctx)
+	if beginErr != nil {
+		return fmt.Errorf("unable to begin transaction: %w", beginErr)
 	}
 
 	defer func() {
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// QuoteRepository stores firm quotes until they expire
+type QuoteRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewQuoteRepository creates a new quote repository
+func NewQuoteRepository(pool *pgxpool.Pool) *QuoteRepository {
+	return &QuoteRepository{
+		pool: pool,
+	}
+}
+
+// SaveQuote stores a firm quote quoted to an API key
+func (r *QuoteRepository) SaveQuote(ctx context.Context, quote types.SwapQuote, keyID string) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO quotes (quote_id, quote, expires_at, key_id) VALUES ($1, $2, $3, $4)`,
+		quote.QuoteID,
+		string(data),
+		quote.ExpiresAt,
+		keyID,
+	)
+	return err
+}
+
+// GetQuote returns the quote with an ID quoted to keyID, types.ErrQuoteNotFound when there is none, or
+// types.ErrQuoteUsed once a swap has used it
+func (r *QuoteRepository) GetQuote(ctx context.Context, quoteID, keyID string) (types.SwapQuote, error) {
+	var data string
+	var used bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT quote::text, used_at IS NOT NULL FROM quotes WHERE quote_id = $1 AND key_id = $2`,
+		quoteID,
+		keyID,
+	).Scan(&data, &used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return types.SwapQuote{}, types.ErrQuoteNotFound
+	}
+	if err != nil {
+		return types.SwapQuote{}, err
+	}
+	if used {
+		return types.SwapQuote{}, types.ErrQuoteUsed
+	}
+
+	var quote types.SwapQuote
+	if err := json.Unmarshal([]byte(data), &quote); err != nil {
+		return types.SwapQuote{}, fmt.Errorf("invalid quote %s: %w", quoteID, err)
+	}
+	return quote, nil
+}
+
+// UseQuote marks the quote with an ID quoted to keyID used by a swap. The update only matches an unused quote, so
+// concurrent swaps can't both use it.
+func (r *QuoteRepository) UseQuote(ctx context.Context, quoteID, keyID string) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE quotes SET used_at = CURRENT_TIMESTAMP WHERE quote_id = $1 AND key_id = $2 AND used_at IS NULL`,
+		quoteID,
+		keyID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	// Nothing was updated: tell a used quote from a missing one
+	if _, err := r.GetQuote(ctx, quoteID, keyID); err != nil {
+		return err
+	}
+	return types.ErrQuoteUsed
+}
+
+// DeleteExpiredQuotes deletes the quotes that have expired, returning how many it deleted
+func (r *QuoteRepository) DeleteExpiredQuotes(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM quotes WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
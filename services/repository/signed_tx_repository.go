@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/infinity-dex/chains"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SignedTxRepository stores the transactions signed for on-chain execution in Postgres
+type SignedTxRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSignedTxRepository creates a new signed transaction repository
+func NewSignedTxRepository(pool *pgxpool.Pool) *SignedTxRepository {
+	return &SignedTxRepository{
+		pool: pool,
+	}
+}
+
+// SaveSignedTx stores the transaction signed for key, reporting false when one was stored before
+func (r *SignedTxRepository) SaveSignedTx(ctx context.Context, key string, tx chains.Tx) (bool, error) {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return false, err
+	}
+	tag, err := r.pool.Exec(ctx,
+		`INSERT INTO signed_transactions (key, chain_id, tx_hash, nonce, tx)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key) DO NOTHING`,
+		key,
+		tx.ChainID,
+		tx.Hash,
+		int64(tx.Nonce),
+		data,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceSignedTx stores a replacement for key's transaction
+func (r *SignedTxRepository) ReplaceSignedTx(ctx context.Context, key string, tx chains.Tx) error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE signed_transactions SET tx_hash = $2, tx = $3, signed_at = CURRENT_TIMESTAMP WHERE key = $1`,
+		key,
+		tx.Hash,
+		data,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no signed transaction for %s", key)
+	}
+	return nil
+}
+
+// GetSignedTx returns the transaction signed for key, or nil when none was
+func (r *SignedTxRepository) GetSignedTx(ctx context.Context, key string) (*chains.Tx, error) {
+	var data []byte
+	err := r.pool.QueryRow(ctx, `SELECT tx FROM signed_transactions WHERE key = $1`, key).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tx chains.Tx
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, fmt.Errorf("invalid signed transaction %s: %w", key, err)
+	}
+	return &tx, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SwapArchiveRepository stores finished swaps in Postgres so they outlive Temporal's history retention
+type SwapArchiveRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSwapArchiveRepository creates a new swap archive repository
+func NewSwapArchiveRepository(pool *pgxpool.Pool) *SwapArchiveRepository {
+	return &SwapArchiveRepository{
+		pool: pool,
+	}
+}
+
+// ArchiveSwap stores a finished swap, replacing any earlier archive of it
+func (r *SwapArchiveRepository) ArchiveSwap(ctx context.Context, swap types.ArchivedSwap) error {
+	request, err := json.Marshal(swap.Request)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(swap.Result)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO swap_archive (request_id, workflow_id, run_id, success, request, result, started_at, closed_at, archived_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (request_id) DO UPDATE SET
+			workflow_id = EXCLUDED.workflow_id,
+			run_id = EXCLUDED.run_id,
+			success = EXCLUDED.success,
+			request = EXCLUDED.request,
+			result = EXCLUDED.result,
+			started_at = EXCLUDED.started_at,
+			closed_at = EXCLUDED.closed_at,
+			archived_at = EXCLUDED.archived_at`,
+		swap.RequestID,
+		swap.WorkflowID,
+		swap.RunID,
+		swap.Result.Success,
+		request,
+		result,
+		swap.StartedAt,
+		swap.ClosedAt,
+		swap.ArchivedAt,
+	)
+	return err
+}
+
+// GetArchivedSwap returns an archived swap, or types.ErrArchivedSwapNotFound
+func (r *SwapArchiveRepository) GetArchivedSwap(ctx context.Context, requestID string) (*types.ArchivedSwap, error) {
+	swap := types.ArchivedSwap{RequestID: requestID}
+	var request, result []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT workflow_id, run_id, request, result, started_at, closed_at, archived_at
+		FROM swap_archive WHERE request_id = $1`,
+		requestID,
+	).Scan(&swap.WorkflowID, &swap.RunID, &request, &result, &swap.StartedAt, &swap.ClosedAt, &swap.ArchivedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, types.ErrArchivedSwapNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(request, &swap.Request); err != nil {
+		return nil, fmt.Errorf("invalid archived request for swap %s: %w", requestID, err)
+	}
+	if err := json.Unmarshal(result, &swap.Result); err != nil {
+		return nil, fmt.Errorf("invalid archived result for swap %s: %w", requestID, err)
+	}
+	return &swap, nil
+}
+
+// ListSwapsByAddress returns up to limit swaps the address sent or received, most recently closed first
+func (r *SwapArchiveRepository) ListSwapsByAddress(ctx context.Context, address string, limit int) ([]types.ArchivedSwap, error) {
+	// Addresses are matched in lower case so EVM addresses match whatever their checksum casing; Involves then
+	// drops the case-sensitive addresses of other chains that only matched in lower case
+	rows, err := r.pool.Query(ctx,
+		`SELECT request_id, workflow_id, run_id, request, result, started_at, closed_at, archived_at
+		FROM swap_archive
+		WHERE lower(request->>'sourceAddress') = $1 OR lower(request->>'destinationAddress') = $1
+		ORDER BY closed_at DESC, request_id
+		LIMIT $2`,
+		strings.ToLower(address), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	swaps := []types.ArchivedSwap{}
+	for rows.Next() {
+		var swap types.ArchivedSwap
+		var request, result []byte
+		if err := rows.Scan(&swap.RequestID, &swap.WorkflowID, &swap.RunID, &request, &result, &swap.StartedAt, &swap.ClosedAt, &swap.ArchivedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(request, &swap.Request); err != nil {
+			return nil, fmt.Errorf("invalid archived request for swap %s: %w", swap.RequestID, err)
+		}
+		if err := json.Unmarshal(result, &swap.Result); err != nil {
+			return nil, fmt.Errorf("invalid archived result for swap %s: %w", swap.RequestID, err)
+		}
+		if swap.Involves(address) {
+			swaps = append(swaps, swap)
+		}
+	}
+	return swaps, rows.Err()
+}
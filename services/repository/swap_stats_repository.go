@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SwapStatsRepository stores the finished swaps analytics count in Postgres
+type SwapStatsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSwapStatsRepository creates a new swap stats repository
+func NewSwapStatsRepository(pool *pgxpool.Pool) *SwapStatsRepository {
+	return &SwapStatsRepository{
+		pool: pool,
+	}
+}
+
+// RecordSwap stores a finished swap, replacing any earlier record of it
+func (r *SwapStatsRepository) RecordSwap(ctx context.Context, record types.SwapRecord) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO swap_stats (request_id, pair, source_chain_id, destination_chain_id, success, volume_usd, fee_usd, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (request_id) DO UPDATE SET
+			pair = EXCLUDED.pair,
+			source_chain_id = EXCLUDED.source_chain_id,
+			destination_chain_id = EXCLUDED.destination_chain_id,
+			success = EXCLUDED.success,
+			volume_usd = EXCLUDED.volume_usd,
+			fee_usd = EXCLUDED.fee_usd,
+			finished_at = EXCLUDED.finished_at`,
+		record.RequestID,
+		record.Pair,
+		record.SourceChainID,
+		record.DestinationChainID,
+		record.Success,
+		record.VolumeUSD,
+		record.FeeUSD,
+		record.FinishedAt,
+	)
+	return err
+}
+
+// SwapTotals adds up the swaps that finished since a time, per pair and chains
+func (r *SwapStatsRepository) SwapTotals(ctx context.Context, since time.Time) ([]types.SwapTotals, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT pair, source_chain_id, destination_chain_id, COUNT(*), COUNT(*) FILTER (WHERE success),
+			COALESCE(SUM(volume_usd), 0), COALESCE(SUM(fee_usd), 0)
+		FROM swap_stats
+		WHERE finished_at >= $1
+		GROUP BY pair, source_chain_id, destination_chain_id`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []types.SwapTotals{}
+	for rows.Next() {
+		var total types.SwapTotals
+		if err := rows.Scan(&total.Pair, &total.SourceChainID, &total.DestinationChainID, &total.Swaps, &total.Succeeded, &total.VolumeUSD, &total.FeesUSD); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
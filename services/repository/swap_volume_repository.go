@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SwapVolumeRepository stores the value of the swaps booked against the daily swap limits
+type SwapVolumeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSwapVolumeRepository creates a new swap volume repository
+func NewSwapVolumeRepository(pool *pgxpool.Pool) *SwapVolumeRepository {
+	return &SwapVolumeRepository{
+		pool: pool,
+	}
+}
+
+// RecordSwapVolume stores a swap's volume unless it would exceed one of the limits. Volumes are recorded one at a
+// time, so concurrent swaps can't overrun a limit together.
+func (r *SwapVolumeRepository) RecordSwapVolume(ctx context.Context, volume types.SwapLimitVolume, since time.Time, limits types.SwapLimits) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('swap_volumes'))`); err != nil {
+		return err
+	}
+	var recorded bool
+	err = tx.QueryRow(ctx, `SELECT true FROM swap_volumes WHERE request_id = $1`, volume.RequestID).Scan(&recorded)
+	if err == nil {
+		return types.ErrSwapVolumeBooked
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	totals, err := swapVolumeSince(ctx, tx, volume, since)
+	if err != nil {
+		return err
+	}
+	if err := limits.Check(volume, totals); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO swap_volumes (request_id, owner, chain_id, pair, amount_usd, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		volume.RequestID,
+		volume.Owner,
+		volume.ChainID,
+		volume.Pair,
+		volume.AmountUSD,
+		volume.CreatedAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ReleaseSwapVolume deletes the volume booked under a request ID, if any
+func (r *SwapVolumeRepository) ReleaseSwapVolume(ctx context.Context, requestID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM swap_volumes WHERE request_id = $1`, requestID)
+	return err
+}
+
+// SwapVolumeSince returns the value of the swaps booked since since from the volume's source address, pair and
+// source chain
+func (r *SwapVolumeRepository) SwapVolumeSince(ctx context.Context, volume types.SwapLimitVolume, since time.Time) (types.SwapLimitTotals, error) {
+	return swapVolumeSince(ctx, r.pool, volume, since)
+}
+
+// swapVolumeSince sums the volumes since since through q, a pool or a transaction. Swaps without a source address
+// count towards no user's limit.
+func swapVolumeSince(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}, volume types.SwapLimitVolume, since time.Time) (types.SwapLimitTotals, error) {
+	var totals types.SwapLimitTotals
+	err := q.QueryRow(ctx,
+		`SELECT
+			COALESCE(SUM(amount_usd) FILTER (WHERE owner = $1 AND owner <> ''), 0),
+			COALESCE(SUM(amount_usd) FILTER (WHERE pair = $2), 0),
+			COALESCE(SUM(amount_usd) FILTER (WHERE chain_id = $3), 0)
+		FROM swap_volumes
+		WHERE created_at >= $4 AND (owner = $1 OR pair = $2 OR chain_id = $3)`,
+		volume.Owner,
+		volume.Pair,
+		volume.ChainID,
+		since,
+	).Scan(&totals.User, &totals.Pair, &totals.Chain)
+	return totals, err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TokenMetadataRepository stores token metadata gathered from token lists in Postgres
+type TokenMetadataRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTokenMetadataRepository creates a new token metadata repository
+func NewTokenMetadataRepository(pool *pgxpool.Pool) *TokenMetadataRepository {
+	return &TokenMetadataRepository{
+		pool: pool,
+	}
+}
+
+// SaveTokenMetadata upserts token metadata in one batch
+func (r *TokenMetadataRepository) SaveTokenMetadata(ctx context.Context, metadata []types.TokenMetadata) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, m := range metadata {
+		batch.Queue(
+			`INSERT INTO token_metadata (chain_id, address, symbol, name, decimals, logo_uri, coingecko_id, verified, sources, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (chain_id, address) DO UPDATE SET
+				symbol = EXCLUDED.symbol,
+				name = EXCLUDED.name,
+				decimals = EXCLUDED.decimals,
+				logo_uri = EXCLUDED.logo_uri,
+				coingecko_id = EXCLUDED.coingecko_id,
+				verified = EXCLUDED.verified,
+				sources = EXCLUDED.sources,
+				updated_at = EXCLUDED.updated_at`,
+			m.ChainID,
+			m.Address,
+			m.Symbol,
+			m.Name,
+			m.Decimals,
+			m.LogoURI,
+			m.CoinGeckoID,
+			m.Verified,
+			m.Sources,
+			m.UpdatedAt,
+		)
+	}
+	return r.pool.SendBatch(ctx, batch).Close()
+}
+
+// FindTokenMetadata returns the metadata of tokens on chainID with any of the symbols, ignoring case
+func (r *TokenMetadataRepository) FindTokenMetadata(ctx context.Context, chainID int64, symbols []string) ([]types.TokenMetadata, error) {
+	upper := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		upper[i] = strings.ToUpper(symbol)
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT chain_id, address, symbol, name, decimals, logo_uri, coingecko_id, verified, sources, updated_at
+		FROM token_metadata
+		WHERE chain_id = $1 AND UPPER(symbol) = ANY($2)
+		ORDER BY address`,
+		chainID,
+		upper,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []types.TokenMetadata
+	for rows.Next() {
+		var m types.TokenMetadata
+		if err := rows.Scan(&m.ChainID, &m.Address, &m.Symbol, &m.Name, &m.Decimals, &m.LogoURI, &m.CoinGeckoID, &m.Verified, &m.Sources, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
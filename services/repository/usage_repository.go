@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UsageRepository stores monthly API usage totals in Postgres
+type UsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewUsageRepository creates a new usage repository
+func NewUsageRepository(pool *pgxpool.Pool) *UsageRepository {
+	return &UsageRepository{
+		pool: pool,
+	}
+}
+
+// AddUsage adds usage to the stored totals in one batch, which applies all of it or none
+func (r *UsageRepository) AddUsage(ctx context.Context, usage []types.APIUsage, volume []types.SwapVolume) error {
+	if len(usage) == 0 && len(volume) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, u := range usage {
+		batch.Queue(
+			`INSERT INTO api_usage (key_id, tenant, month, endpoint, requests, egress_bytes)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (key_id, month, endpoint) DO UPDATE SET
+				tenant = EXCLUDED.tenant,
+				requests = api_usage.requests + EXCLUDED.requests,
+				egress_bytes = api_usage.egress_bytes + EXCLUDED.egress_bytes`,
+			u.KeyID,
+			u.Tenant,
+			u.Month,
+			u.Endpoint,
+			u.Requests,
+			u.EgressBytes,
+		)
+	}
+	for _, v := range volume {
+		batch.Queue(
+			`INSERT INTO api_swap_volume (key_id, tenant, month, chain_id, token, swaps, amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7::numeric)
+			ON CONFLICT (key_id, month, chain_id, token) DO UPDATE SET
+				tenant = EXCLUDED.tenant,
+				swaps = api_swap_volume.swaps + EXCLUDED.swaps,
+				amount = api_swap_volume.amount + EXCLUDED.amount`,
+			v.KeyID,
+			v.Tenant,
+			v.Month,
+			v.ChainID,
+			v.Token,
+			v.Swaps,
+			v.Amount.String(),
+		)
+	}
+	return r.pool.SendBatch(ctx, batch).Close()
+}
+
+// MonthlyUsage returns every key's totals for a month, ordered by key
+func (r *UsageRepository) MonthlyUsage(ctx context.Context, month string) (*types.UsageReport, error) {
+	report := &types.UsageReport{Month: month, Endpoints: []types.APIUsage{}, SwapVolume: []types.SwapVolume{}}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT key_id, tenant, endpoint, requests, egress_bytes
+		FROM api_usage
+		WHERE month = $1
+		ORDER BY key_id, endpoint`,
+		month,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		usage := types.APIUsage{Month: month}
+		if err := rows.Scan(&usage.KeyID, &usage.Tenant, &usage.Endpoint, &usage.Requests, &usage.EgressBytes); err != nil {
+			return nil, err
+		}
+		report.Endpoints = append(report.Endpoints, usage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.pool.Query(ctx,
+		`SELECT key_id, tenant, chain_id, token, swaps, amount::text
+		FROM api_swap_volume
+		WHERE month = $1
+		ORDER BY key_id, chain_id, token`,
+		month,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		volume := types.SwapVolume{Month: month}
+		var amount *string
+		if err := rows.Scan(&volume.KeyID, &volume.Tenant, &volume.ChainID, &volume.Token, &volume.Swaps, &amount); err != nil {
+			return nil, err
+		}
+		if volume.Amount, err = parseNumeric(amount); err != nil {
+			return nil, fmt.Errorf("invalid swap volume for key %s: %w", volume.KeyID, err)
+		}
+		report.SwapVolume = append(report.SwapVolume, volume)
+	}
+	return report, rows.Err()
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WebhookSecretRepository stores the webhook secrets clients registered, shared by the API servers and swap workers
+type WebhookSecretRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebhookSecretRepository creates a new webhook secret repository
+func NewWebhookSecretRepository(pool *pgxpool.Pool) *WebhookSecretRepository {
+	return &WebhookSecretRepository{
+		pool: pool,
+	}
+}
+
+// SetWebhookSecret registers a key's webhook secret, replacing any earlier one
+func (r *WebhookSecretRepository) SetWebhookSecret(ctx context.Context, keyID, secret string) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO webhook_secrets (key_id, secret, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (key_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			updated_at = EXCLUDED.updated_at`,
+		keyID, secret,
+	)
+	return err
+}
+
+// GetWebhookSecret returns a key's webhook secret, or types.ErrWebhookSecretNotFound
+func (r *WebhookSecretRepository) GetWebhookSecret(ctx context.Context, keyID string) (string, error) {
+	var secret string
+	err := r.pool.QueryRow(ctx, `SELECT secret FROM webhook_secrets WHERE key_id = $1`, keyID).Scan(&secret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", types.ErrWebhookSecretNotFound
+	}
+	return secret, err
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/infinity-dex/services/expr"
+	"github.com/infinity-dex/services/types"
+)
+
+// Rule kinds, by parameter name prefix. Rules are expressions in the services/expr language.
+const (
+	RulePrefixAlert   = "alert."   // Conditions raising an alert while true
+	RulePrefixBreaker = "breaker." // Conditions tripping a circuit breaker while true
+	RulePrefixFee     = "fee."     // Numeric fee overrides
+)
+
+// ErrCircuitBreakerTripped is returned for swaps a circuit breaker rule holds for
+var ErrCircuitBreakerTripped = errors.New("circuit breaker tripped")
+
+// RuleFeeProtocolMultiplier is the fee override each quote's protocol fee is multiplied by
+const RuleFeeProtocolMultiplier = RulePrefixFee + "protocol_multiplier"
+
+// rulePrefixes lists the rule kinds
+var rulePrefixes = []string{RulePrefixAlert, RulePrefixBreaker, RulePrefixFee}
+
+// IsRuleName reports whether a parameter name belongs to a rule kind
+func IsRuleName(name string) bool {
+	for _, prefix := range rulePrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRule checks that a rule's name has a rule kind prefix and its expression compiles
+func ValidateRule(name, expression string) error {
+	if !IsRuleName(name) {
+		return fmt.Errorf("rule name %q must start with one of %s", name, strings.Join(rulePrefixes, ", "))
+	}
+	if _, err := expr.Compile(expression); err != nil {
+		return fmt.Errorf("invalid rule %s: %w", name, err)
+	}
+	return nil
+}
+
+// RuleSet evaluates the rule expressions stored in a parameter store, compiling each version once
+type RuleSet struct {
+	store    ParameterStore
+	compiled map[string]compiledRule // map[name]compiledRule
+	mu       sync.Mutex
+}
+
+// compiledRule is a compiled version of a rule
+type compiledRule struct {
+	version    int
+	source     string
+	expression *expr.Expression
+}
+
+// NewRuleSet creates a rule set reading its rules from store
+func NewRuleSet(store ParameterStore) *RuleSet {
+	return &RuleSet{
+		store:    store,
+		compiled: make(map[string]compiledRule),
+	}
+}
+
+// SetStore switches the parameter store rules are read from
+func (r *RuleSet) SetStore(store ParameterStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store = store
+	r.compiled = make(map[string]compiledRule)
+}
+
+// parameters returns the parameter store rules are read from
+func (r *RuleSet) parameters() ParameterStore {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.store
+}
+
+// compile returns the compiled expression of a rule, reusing it until the rule changes
+func (r *RuleSet) compile(param types.Parameter) (*expr.Expression, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rule, ok := r.compiled[param.Name]; ok && rule.version == param.Version && rule.source == param.Value {
+		return rule.expression, nil
+	}
+	expression, err := expr.Compile(param.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %s: %w", param.Name, err)
+	}
+	r.compiled[param.Name] = compiledRule{version: param.Version, source: param.Value, expression: expression}
+	return expression, nil
+}
+
+// Number evaluates a numeric rule. ok is false when the rule is not set.
+func (r *RuleSet) Number(ctx context.Context, name string, vars map[string]interface{}) (value float64, ok bool, err error) {
+	param, err := r.parameters().GetParameter(ctx, name)
+	if errors.Is(err, types.ErrParameterNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	expression, err := r.compile(*param)
+	if err != nil {
+		return 0, false, err
+	}
+	value, err = expression.Number(vars)
+	if err != nil {
+		return 0, false, fmt.Errorf("rule %s: %w", name, err)
+	}
+	return value, true, nil
+}
+
+// Matching evaluates the boolean rules under prefix and returns the names of those that hold. Rules that fail to
+// evaluate are skipped and reported in the returned error.
+func (r *RuleSet) Matching(ctx context.Context, prefix string, vars map[string]interface{}) ([]string, error) {
+	params, err := r.parameters().ListParameters(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	var errs []error
+	for _, param := range params {
+		expression, err := r.compile(param)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		holds, err := expression.Bool(vars)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", param.Name, err))
+			continue
+		}
+		if holds {
+			matched = append(matched, param.Name)
+		}
+	}
+	return matched, errors.Join(errs...)
+}
+
+// SwapRuleVars returns the variables rules about a swap are evaluated with: source and destination (each with
+// symbol and chainId), amount in whole source tokens, amountUSD (0 without a source price) and crossChain
+func SwapRuleVars(request types.SwapRequest, sourcePriceUSD float64) map[string]interface{} {
+	amount := types.NewAmount(request.Amount, request.SourceToken.Decimals).Float64()
+	return map[string]interface{}{
+		"source":      map[string]interface{}{"symbol": request.SourceToken.Symbol, "chainId": request.SourceToken.ChainID},
+		"destination": map[string]interface{}{"symbol": request.DestinationToken.Symbol, "chainId": request.DestinationToken.ChainID},
+		"amount":      amount,
+		"amountUSD":   amount * sourcePriceUSD,
+		"crossChain":  request.SourceToken.ChainID != request.DestinationToken.ChainID,
+	}
+}
+
+// CheckBreakers fails with ErrCircuitBreakerTripped, naming the rules, when any breaker rule holds for a swap.
+// Breaker rules see the same variables as fee overrides. Rules that fail to evaluate are reported in broken and
+// don't stop the swap.
+func (r *RuleSet) CheckBreakers(ctx context.Context, request types.SwapRequest, sourcePriceUSD float64) (broken error, err error) {
+	tripped, broken := r.Matching(ctx, RulePrefixBreaker, SwapRuleVars(request, sourcePriceUSD))
+	if len(tripped) > 0 {
+		return broken, fmt.Errorf("%w: %s", ErrCircuitBreakerTripped, strings.Join(tripped, ", "))
+	}
+	return broken, nil
+}
+
+// ApplyFeeOverride multiplies a swap's protocol fee by the fee.protocol_multiplier rule, if one is set.
+// On error the fee is left unchanged.
+func (r *RuleSet) ApplyFeeOverride(ctx context.Context, request types.SwapRequest, sourcePriceUSD float64, fee *types.Fee) error {
+	multiplier, ok, err := r.Number(ctx, RuleFeeProtocolMultiplier, SwapRuleVars(request, sourcePriceUSD))
+	if err != nil || !ok {
+		return err
+	}
+	if multiplier < 0 || math.IsNaN(multiplier) || math.IsInf(multiplier, 0) {
+		return fmt.Errorf("rule %s returned invalid multiplier %v", RuleFeeProtocolMultiplier, multiplier)
+	}
+	if fee.ProtocolFee != nil {
+		fee.ProtocolFee = types.NewAmount(fee.ProtocolFee, request.SourceToken.Decimals).MulFloat(multiplier, types.RoundDown).Value
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		valid      bool
+	}{
+		{"alert.depeg", "priceUSD < 0.98", true},
+		{RuleFeeProtocolMultiplier, "crossChain ? 1.5 : 1", true},
+		{"breaker.bridge", "failureRate > 0.2", true},
+		{"fee.", "1", false},
+		{"threshold", "1", false},
+		{"alert.broken", "priceUSD <", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateRule(tt.name, tt.expression); (err == nil) != tt.valid {
+			t.Errorf("ValidateRule(%q, %q) = %v, expected valid %v", tt.name, tt.expression, err, tt.valid)
+		}
+	}
+}
+
+func TestRuleSet(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryParameterStore()
+	rules := NewRuleSet(store)
+	save := func(name, value string) {
+		store.SaveParameter(ctx, types.Parameter{Name: name, Value: value})
+	}
+
+	t.Run("NumberNotSet", func(t *testing.T) {
+		if _, ok, err := rules.Number(ctx, RuleFeeProtocolMultiplier, nil); ok || err != nil {
+			t.Errorf("Expected an unset rule, got ok=%v err=%v", ok, err)
+		}
+	})
+
+	t.Run("RecompilesNewVersions", func(t *testing.T) {
+		save(RuleFeeProtocolMultiplier, "2")
+		if value, _, _ := rules.Number(ctx, RuleFeeProtocolMultiplier, nil); value != 2 {
+			t.Errorf("Expected 2, got %f", value)
+		}
+		save(RuleFeeProtocolMultiplier, "3")
+		if value, _, _ := rules.Number(ctx, RuleFeeProtocolMultiplier, nil); value != 3 {
+			t.Errorf("Expected the new version's 3, got %f", value)
+		}
+	})
+
+	t.Run("Matching", func(t *testing.T) {
+		save("alert.low", "priceUSD < 1")
+		save("alert.high", "priceUSD > 10")
+		save("alert.broken", "missing > 1")
+		save("breaker.low", "priceUSD < 1")
+
+		matched, err := rules.Matching(ctx, RulePrefixAlert, map[string]interface{}{"priceUSD": 0.5})
+		if err == nil {
+			t.Error("Expected the broken rule to be reported")
+		}
+		if len(matched) != 1 || matched[0] != "alert.low" {
+			t.Errorf("Expected only alert.low to match, got %v", matched)
+		}
+	})
+
+	t.Run("ApplyFeeOverride", func(t *testing.T) {
+		save(RuleFeeProtocolMultiplier, "crossChain ? 2 : amountUSD >= 100000 ? 0.5 : 1")
+		request := func(amount int64, destinationChain int64) types.SwapRequest {
+			return types.SwapRequest{
+				SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+				DestinationToken: types.Token{Symbol: "USDC", Decimals: 6, ChainID: destinationChain},
+				Amount:           new(big.Int).Mul(big.NewInt(amount), big.NewInt(1_000_000_000_000_000_000)),
+			}
+		}
+
+		tests := []struct {
+			name     string
+			request  types.SwapRequest
+			expected int64
+		}{
+			{"CrossChain", request(1, 137), 2000},
+			{"Large", request(50, 1), 500},
+			{"Standard", request(1, 1), 1000},
+		}
+		for _, tt := range tests {
+			fee := &types.Fee{ProtocolFee: big.NewInt(1000)}
+			if err := rules.ApplyFeeOverride(ctx, tt.request, 2000, fee); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.name, err)
+			}
+			if fee.ProtocolFee.Int64() != tt.expected {
+				t.Errorf("%s: expected a protocol fee of %d, got %s", tt.name, tt.expected, fee.ProtocolFee)
+			}
+		}
+
+		save(RuleFeeProtocolMultiplier, "-1")
+		fee := &types.Fee{ProtocolFee: big.NewInt(1000)}
+		if err := rules.ApplyFeeOverride(ctx, request(1, 1), 2000, fee); err == nil || fee.ProtocolFee.Int64() != 1000 {
+			t.Errorf("Expected a negative multiplier to be refused, got %v with fee %s", err, fee.ProtocolFee)
+		}
+	})
+
+	t.Run("Quote", func(t *testing.T) {
+		save(RuleFeeProtocolMultiplier, "0")
+		service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+		service.SetPricePolicy(NewPriceStalenessPolicy(fakeOracle{
+			"ETH":  {Symbol: "ETH", PriceUSD: 2000, LastUpdated: time.Now()},
+			"USDC": {Symbol: "USDC", PriceUSD: 1, LastUpdated: time.Now()},
+		}, time.Minute))
+		service.SetRules(rules)
+
+		quote, err := service.GetSwapQuote(ctx, types.SwapRequest{
+			SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+			DestinationToken: types.Token{Symbol: "USDC", Decimals: 18, ChainID: 1},
+			Amount:           big.NewInt(1_000_000_000_000_000_000),
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if quote.Fee.ProtocolFee.Sign() != 0 {
+			t.Errorf("Expected the override to waive the protocol fee, got %s", quote.Fee.ProtocolFee)
+		}
+	})
+
+	t.Run("CircuitBreakers", func(t *testing.T) {
+		service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+		service.SetPricePolicy(NewPriceStalenessPolicy(fakeOracle{
+			"ETH":  {Symbol: "ETH", PriceUSD: 2000, LastUpdated: time.Now()},
+			"USDC": {Symbol: "USDC", PriceUSD: 1, LastUpdated: time.Now()},
+		}, time.Minute))
+		service.SetRules(rules)
+		request := types.SwapRequest{
+			SourceToken:      types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+			DestinationToken: types.Token{Symbol: "USDC", Decimals: 18, ChainID: 1},
+			Amount:           big.NewInt(1_000_000_000_000_000_000),
+		}
+
+		save("breaker.large", "amountUSD >= 1000")
+		if _, err := service.GetSwapQuote(ctx, request); !errors.Is(err, ErrCircuitBreakerTripped) {
+			t.Errorf("Expected ErrCircuitBreakerTripped, got %v", err)
+		}
+
+		save("breaker.large", "amountUSD >= 1000000")
+		param, _ := store.GetParameter(ctx, "breaker.large")
+		if param.Version != 2 {
+			t.Errorf("Expected saving again to bump the version to 2, got %d", param.Version)
+		}
+		// breaker.low can't be evaluated for swaps, so it doesn't halt them
+		if _, err := service.GetSwapQuote(ctx, request); err != nil {
+			t.Errorf("Expected the swap to be quoted once the breaker no longer holds, got %v", err)
+		}
+	})
+}
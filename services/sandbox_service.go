@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+)
+
+// SandboxService executes swaps against the mock Universal SDK with instant fills
+// and fake balances, so integrators can exercise the API without real funds
+type SandboxService struct {
+	transactionService *TransactionService
+	swapService        *SwapService
+	startingBalance    *big.Int
+	balances           map[string]map[string]*big.Int // map[address]map[symbol]balance
+	swaps              map[string]sandboxSwap         // Settled swaps by request ID, so cancelling can reverse them
+	mu                 sync.Mutex
+}
+
+// sandboxSwap records the balance changes of a settled sandbox swap
+type sandboxSwap struct {
+	sourceAddress string
+	sourceSymbol  string
+	amount        *big.Int
+	destAddress   string
+	destSymbol    string
+	outputAmount  *big.Int
+}
+
+// NewSandboxService creates a new sandbox service where every address starts with startingBalance of each token
+func NewSandboxService(startingBalance *big.Int) *SandboxService {
+	sdk := universalsdk.NewMockSDK(universalsdk.MockSDKConfig{
+		WrappedTokens: make(map[int64][]types.Token),
+		Latency:       0,
+		FailureRate:   0,
+	})
+
+	transactionService := NewTransactionService()
+
+	return &SandboxService{
+		transactionService: transactionService,
+		swapService:        NewSwapService(NewTokenService(), transactionService, sdk),
+		startingBalance:    new(big.Int).Set(startingBalance),
+		balances:           make(map[string]map[string]*big.Int),
+		swaps:              make(map[string]sandboxSwap),
+	}
+}
+
+// GetBalance returns the fake balance of a token held by an address
+func (s *SandboxService) GetBalance(address, symbol string) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return new(big.Int).Set(s.balanceLocked(address, symbol))
+}
+
+// GetBalances returns all fake balances an address has touched
+func (s *SandboxService) GetBalances(address string) map[string]*big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]*big.Int)
+	for symbol, balance := range s.balances[strings.ToLower(address)] {
+		result[symbol] = new(big.Int).Set(balance)
+	}
+
+	return result
+}
+
+// GetSwapQuote returns a quote for a sandbox swap
+func (s *SandboxService) GetSwapQuote(ctx context.Context, request types.SwapRequest) (*types.SwapQuote, error) {
+	return s.swapService.GetSwapQuote(ctx, request)
+}
+
+// ExecuteSwap executes a sandbox swap and fills it instantly. The source amount is debited up front and
+// credited back if the swap fails, so concurrent swaps cannot spend the same balance.
+func (s *SandboxService) ExecuteSwap(ctx context.Context, request types.SwapRequest) (string, error) {
+	if request.Amount == nil || request.Amount.Cmp(big.NewInt(0)) <= 0 {
+		return "", errors.New("invalid amount")
+	}
+
+	s.mu.Lock()
+	sourceBalance := s.balanceLocked(request.SourceAddress, request.SourceToken.Symbol)
+	if sourceBalance.Cmp(request.Amount) < 0 {
+		s.mu.Unlock()
+		return "", fmt.Errorf("insufficient sandbox balance: have %s %s", sourceBalance.String(), request.SourceToken.Symbol)
+	}
+	sourceBalance.Sub(sourceBalance, request.Amount)
+	s.mu.Unlock()
+
+	requestID, outputAmount, err := s.fill(ctx, request)
+	if err != nil {
+		s.mu.Lock()
+		s.credit(request.SourceAddress, request.SourceToken.Symbol, request.Amount)
+		s.mu.Unlock()
+		return "", err
+	}
+
+	destAddress := request.DestinationAddress
+	if destAddress == "" {
+		destAddress = request.SourceAddress
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credit(destAddress, request.DestinationToken.Symbol, outputAmount)
+	s.swaps[requestID] = sandboxSwap{
+		sourceAddress: request.SourceAddress,
+		sourceSymbol:  request.SourceToken.Symbol,
+		amount:        new(big.Int).Set(request.Amount),
+		destAddress:   destAddress,
+		destSymbol:    request.DestinationToken.Symbol,
+		outputAmount:  outputAmount,
+	}
+
+	return requestID, nil
+}
+
+// SimulateSwap checks a sandbox swap against the source's fake balance and projects its result without changing
+// any balance
+func (s *SandboxService) SimulateSwap(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error) {
+	if request.Amount != nil {
+		if sourceBalance := s.GetBalance(request.SourceAddress, request.SourceToken.Symbol); sourceBalance.Cmp(request.Amount) < 0 {
+			return nil, fmt.Errorf("insufficient sandbox balance: have %s %s", sourceBalance.String(), request.SourceToken.Symbol)
+		}
+	}
+	return s.swapService.SimulateSwap(ctx, request)
+}
+
+// fill executes a swap and completes every transaction of it immediately, returning its output amount
+func (s *SandboxService) fill(ctx context.Context, request types.SwapRequest) (string, *big.Int, error) {
+	requestID, err := s.swapService.ExecuteSwap(ctx, request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	txs, err := s.transactionService.GetTransactionsByWorkflowID(ctx, requestID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get sandbox transactions: %w", err)
+	}
+
+	outputAmount := big.NewInt(0)
+	blockNumber := uint64(time.Now().Unix())
+	for _, tx := range txs {
+		if err := s.transactionService.UpdateTransactionStatus(ctx, tx.ID, "completed"); err != nil {
+			return "", nil, fmt.Errorf("failed to fill sandbox transaction: %w", err)
+		}
+		if err := s.transactionService.UpdateTransactionBlockInfo(ctx, tx.ID, blockNumber); err != nil {
+			return "", nil, fmt.Errorf("failed to fill sandbox transaction: %w", err)
+		}
+		if tx.Type == "swap_dest" {
+			outputAmount = new(big.Int).Set(tx.Amount)
+		}
+	}
+	return requestID, outputAmount, nil
+}
+
+// GetSwapStatus returns the status of a sandbox swap
+func (s *SandboxService) GetSwapStatus(ctx context.Context, requestID string) (*types.SwapResult, error) {
+	return s.swapService.GetSwapStatus(ctx, requestID)
+}
+
+// CancelSwap cancels a sandbox swap and reverses its balance changes.
+// The output is taken back only as far as the destination balance still holds it.
+func (s *SandboxService) CancelSwap(ctx context.Context, requestID string) error {
+	if err := s.swapService.CancelSwap(ctx, requestID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	swap, ok := s.swaps[requestID]
+	if !ok {
+		return nil
+	}
+	delete(s.swaps, requestID)
+
+	destBalance := s.balanceLocked(swap.destAddress, swap.destSymbol)
+	taken := swap.outputAmount
+	if destBalance.Cmp(taken) < 0 {
+		taken = destBalance
+	}
+	destBalance.Sub(destBalance, taken)
+	s.credit(swap.sourceAddress, swap.sourceSymbol, swap.amount)
+	return nil
+}
+
+// credit adds amount to an address's balance. The caller must hold s.mu.
+func (s *SandboxService) credit(address, symbol string, amount *big.Int) {
+	balance := s.balanceLocked(address, symbol)
+	balance.Add(balance, amount)
+}
+
+// balanceLocked returns the mutable balance entry for an address, seeding it on first use.
+// The caller must hold s.mu.
+func (s *SandboxService) balanceLocked(address, symbol string) *big.Int {
+	address = strings.ToLower(address)
+
+	balances, exists := s.balances[address]
+	if !exists {
+		balances = make(map[string]*big.Int)
+		s.balances[address] = balances
+	}
+
+	balance, exists := balances[symbol]
+	if !exists {
+		balance = new(big.Int).Set(s.startingBalance)
+		balances[symbol] = balance
+	}
+
+	return balance
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestSandboxService(t *testing.T) {
+	startingBalance := new(big.Int).Mul(big.NewInt(10), big.NewInt(1000000000000000000)) // 10 tokens
+	service := NewSandboxService(startingBalance)
+	if service == nil {
+		t.Fatal("Failed to create sandbox service")
+	}
+
+	ethToken := types.Token{Symbol: "ETH", Name: "Ethereum", Decimals: 18, ChainID: 1, ChainName: "Ethereum"}
+	usdcToken := types.Token{Symbol: "USDC", Name: "USD Coin", Decimals: 6, ChainID: 1, ChainName: "Ethereum"}
+	address := "0x1234567890abcdef1234567890abcdef12345678"
+
+	request := types.SwapRequest{
+		SourceToken:        ethToken,
+		DestinationToken:   usdcToken,
+		Amount:             big.NewInt(1000000000000000000), // 1 ETH
+		SourceAddress:      address,
+		DestinationAddress: address,
+		Slippage:           0.5,
+		RequestID:          "sandbox-req-1",
+	}
+
+	// Test starting balance
+	t.Run("StartingBalance", func(t *testing.T) {
+		balance := service.GetBalance(address, "ETH")
+		if balance.Cmp(startingBalance) != 0 {
+			t.Errorf("Expected starting balance %s, got %s", startingBalance.String(), balance.String())
+		}
+	})
+
+	// Test ExecuteSwap fills instantly and moves balances
+	t.Run("ExecuteSwap", func(t *testing.T) {
+		ctx := context.Background()
+		requestID, err := service.ExecuteSwap(ctx, request)
+		if err != nil {
+			t.Fatalf("Failed to execute sandbox swap: %v", err)
+		}
+		if requestID != "sandbox-req-1" {
+			t.Errorf("Expected requestID to be 'sandbox-req-1', got '%s'", requestID)
+		}
+
+		result, err := service.GetSwapStatus(ctx, requestID)
+		if err != nil {
+			t.Fatalf("Failed to get sandbox swap status: %v", err)
+		}
+		if !result.Success {
+			t.Errorf("Expected sandbox swap to be filled, got error message '%s'", result.ErrorMessage)
+		}
+
+		expectedETH := new(big.Int).Sub(startingBalance, request.Amount)
+		if balance := service.GetBalance(address, "ETH"); balance.Cmp(expectedETH) != 0 {
+			t.Errorf("Expected ETH balance %s, got %s", expectedETH.String(), balance.String())
+		}
+
+		expectedUSDC := new(big.Int).Add(startingBalance, result.OutputAmount)
+		if balance := service.GetBalance(address, "USDC"); balance.Cmp(expectedUSDC) != 0 {
+			t.Errorf("Expected USDC balance %s, got %s", expectedUSDC.String(), balance.String())
+		}
+	})
+
+	// Test insufficient balance
+	t.Run("InsufficientBalance", func(t *testing.T) {
+		large := request
+		large.RequestID = "sandbox-req-2"
+		large.Amount = new(big.Int).Mul(startingBalance, big.NewInt(2))
+
+		if _, err := service.ExecuteSwap(context.Background(), large); err == nil {
+			t.Error("Expected error when swapping more than the sandbox balance, got nil")
+		}
+	})
+
+	// Test cancelling restores the balances the swap moved
+	t.Run("CancelRestoresBalances", func(t *testing.T) {
+		ctx := context.Background()
+		beforeETH := service.GetBalance(address, "ETH")
+		beforeUSDC := service.GetBalance(address, "USDC")
+
+		cancelled := request
+		cancelled.RequestID = "sandbox-req-3"
+		if _, err := service.ExecuteSwap(ctx, cancelled); err != nil {
+			t.Fatalf("Failed to execute sandbox swap: %v", err)
+		}
+		if err := service.CancelSwap(ctx, "sandbox-req-3"); err != nil {
+			t.Fatalf("Failed to cancel sandbox swap: %v", err)
+		}
+
+		if balance := service.GetBalance(address, "ETH"); balance.Cmp(beforeETH) != 0 {
+			t.Errorf("Expected ETH balance %s after cancel, got %s", beforeETH.String(), balance.String())
+		}
+		if balance := service.GetBalance(address, "USDC"); balance.Cmp(beforeUSDC) != 0 {
+			t.Errorf("Expected USDC balance %s after cancel, got %s", beforeUSDC.String(), balance.String())
+		}
+	})
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// AggregatorQuoter quotes swaps on an external DEX aggregator, so our own quotes can be compared against it
+type AggregatorQuoter interface {
+	// Name returns the aggregator's name
+	Name() string
+	// QuoteOutput returns the amount of destination the aggregator's best route delivers for amount of source,
+	// or nil when it can't quote the pair
+	QuoteOutput(ctx context.Context, source, destination types.Token, amount *big.Int) (*big.Int, error)
+}
+
+// SwapQuoter quotes swaps on Infinity DEX
+type SwapQuoter interface {
+	GetSwapQuote(ctx context.Context, request types.SwapRequest) (*types.SwapQuote, error)
+}
+
+// DefaultShadowSamples is how many comparisons are kept per pair and aggregator: a day at 5-minute intervals
+const DefaultShadowSamples = 288
+
+// shadowKey identifies the comparisons of one pair against one aggregator
+type shadowKey struct {
+	chainID    int64
+	pair       string
+	aggregator string
+}
+
+// shadowSeries is the latest comparisons of a pair against an aggregator, in basis points
+type shadowSeries struct {
+	diffs  []float64 // ring of at most samples differences
+	next   int       // where the next difference is written once the ring is full
+	last   float64
+	lastAt time.Time
+}
+
+// ShadowPricer compares our quotes for every pool's pair with DEX aggregators' quotes for the same swap,
+// keeping recent differences per pair to guide fee and routing tuning. Comparisons never affect quoting.
+type ShadowPricer struct {
+	swaps       SwapQuoter
+	liquidity   *LiquidityService
+	metadata    *TokenMetadataService
+	aggregators []AggregatorQuoter
+	samples     int
+	series      map[shadowKey]*shadowSeries
+	now         func() time.Time
+	mu          sync.RWMutex
+}
+
+// NewShadowPricer creates a shadow pricer comparing the quotes of swaps for the pairs of liquidity's pools,
+// keeping the latest samples comparisons (DefaultShadowSamples when not positive) per pair and aggregator.
+// Pool tokens' addresses and decimals are resolved from metadata.
+func NewShadowPricer(swaps SwapQuoter, liquidity *LiquidityService, metadata *TokenMetadataService, samples int) *ShadowPricer {
+	if samples <= 0 {
+		samples = DefaultShadowSamples
+	}
+	return &ShadowPricer{
+		swaps:     swaps,
+		liquidity: liquidity,
+		metadata:  metadata,
+		samples:   samples,
+		series:    make(map[shadowKey]*shadowSeries),
+		now:       time.Now,
+	}
+}
+
+// SetTokenMetadata sets the metadata pool tokens' addresses and decimals are resolved from
+func (p *ShadowPricer) SetTokenMetadata(metadata *TokenMetadataService) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metadata = metadata
+}
+
+// AddAggregator compares quotes against an aggregator as well
+func (p *ShadowPricer) AddAggregator(aggregator AggregatorQuoter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.aggregators = append(p.aggregators, aggregator)
+}
+
+// Sample quotes selling one whole base token of every pool's pair with us and with each aggregator, records how the
+// outputs compare, and returns the number of comparisons recorded. Pairs either side can't quote are skipped.
+func (p *ShadowPricer) Sample(ctx context.Context) int {
+	p.mu.RLock()
+	aggregators, metadata := p.aggregators, p.metadata
+	p.mu.RUnlock()
+	if len(aggregators) == 0 {
+		return 0
+	}
+
+	recorded := 0
+	seen := make(map[shadowKey]bool)
+	for _, pool := range p.liquidity.GetAllPools(ctx) {
+		// Pools of the same pair in other fee tiers get the same quotes
+		pair := pool.Pair.BaseToken.Symbol + "/" + pool.Pair.QuoteToken.Symbol
+		key := shadowKey{chainID: pool.Pair.BaseToken.ChainID, pair: pair}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		tokens, err := metadata.Resolve(ctx, []types.Token{types.Token(pool.Pair.BaseToken), types.Token(pool.Pair.QuoteToken)})
+		if err != nil {
+			log.Printf("Shadow pricing: failed to resolve %s tokens: %v", pair, err)
+			continue
+		}
+		base, quote := withGasTokenDecimals(tokens[0]), withGasTokenDecimals(tokens[1])
+		if base.Decimals == 0 {
+			continue
+		}
+		amount := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(base.Decimals)), nil)
+
+		ours, err := p.swaps.GetSwapQuote(ctx, types.SwapRequest{SourceToken: base, DestinationToken: quote, Amount: amount})
+		if err != nil {
+			log.Printf("Shadow pricing: failed to quote %s: %v", pair, err)
+			continue
+		}
+		for _, aggregator := range aggregators {
+			theirs, err := aggregator.QuoteOutput(ctx, base, quote, amount)
+			if err != nil {
+				log.Printf("Shadow pricing: %s failed to quote %s: %v", aggregator.Name(), pair, err)
+				continue
+			}
+			if theirs == nil || theirs.Sign() <= 0 {
+				continue
+			}
+			diff := outputDifferenceBPS(ours.OutputAmount, theirs)
+			log.Printf("Shadow pricing: %s on chain %d quoted %s against %s from %s (%+.1f bps)",
+				pair, base.ChainID, ours.OutputAmount, theirs, aggregator.Name(), diff)
+			key.aggregator = aggregator.Name()
+			p.record(key, diff)
+			recorded++
+		}
+	}
+	return recorded
+}
+
+// withGasTokenDecimals gives an EVM chain's gas token its decimals when no metadata had them
+func withGasTokenDecimals(token types.Token) types.Token {
+	if chain, ok := types.GetChain(token.ChainID); ok && chain.Namespace == "eip155" && token.Decimals == 0 &&
+		strings.EqualFold(token.Symbol, chain.GasToken) {
+		token.Decimals = gasTokenDecimals
+	}
+	return token
+}
+
+// outputDifferenceBPS returns how much larger ours is than theirs, in basis points of theirs
+func outputDifferenceBPS(ours, theirs *big.Int) float64 {
+	diff := new(big.Float).SetInt(new(big.Int).Sub(ours, theirs))
+	bps, _ := diff.Quo(diff.Mul(diff, big.NewFloat(10000)), new(big.Float).SetInt(theirs)).Float64()
+	return bps
+}
+
+// record adds a comparison to its series, dropping the oldest once the series is full
+func (p *ShadowPricer) record(key shadowKey, diff float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	series, ok := p.series[key]
+	if !ok {
+		series = &shadowSeries{}
+		p.series[key] = series
+	}
+	if len(series.diffs) < p.samples {
+		series.diffs = append(series.diffs, diff)
+	} else {
+		series.diffs[series.next] = diff
+		series.next = (series.next + 1) % p.samples
+	}
+	series.last = diff
+	series.lastAt = p.now()
+}
+
+// Stats returns the competitiveness of every sampled pair against each aggregator, ordered by chain, pair and aggregator
+func (p *ShadowPricer) Stats() []types.PairCompetitiveness {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make([]types.PairCompetitiveness, 0, len(p.series))
+	for key, series := range p.series {
+		diffs := append([]float64(nil), series.diffs...)
+		sort.Float64s(diffs)
+		wins := len(diffs) - sort.SearchFloat64s(diffs, 0)
+		stats = append(stats, types.PairCompetitiveness{
+			Pair:          key.pair,
+			ChainID:       key.chainID,
+			Aggregator:    key.aggregator,
+			Samples:       len(diffs),
+			WinRate:       float64(wins) / float64(len(diffs)),
+			P10BPS:        percentile(diffs, 10),
+			P50BPS:        percentile(diffs, 50),
+			P90BPS:        percentile(diffs, 90),
+			LastBPS:       series.last,
+			LastSampledAt: series.lastAt,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		if a.Pair != b.Pair {
+			return a.Pair < b.Pair
+		}
+		return a.Aggregator < b.Aggregator
+	})
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, pct float64) float64 {
+	rank := int(math.Ceil(pct / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// Start samples every interval until ctx is done
+func (p *ShadowPricer) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.Sample(ctx)
+			}
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// fixedQuoter quotes every swap for a fixed output, counting the swaps it was asked for
+type fixedQuoter struct {
+	output   *big.Int
+	requests []types.SwapRequest
+}
+
+func (q *fixedQuoter) Name() string {
+	return "fixed"
+}
+
+func (q *fixedQuoter) QuoteOutput(ctx context.Context, source, destination types.Token, amount *big.Int) (*big.Int, error) {
+	q.requests = append(q.requests, types.SwapRequest{SourceToken: source, DestinationToken: destination, Amount: amount})
+	return q.output, nil
+}
+
+func (q *fixedQuoter) GetSwapQuote(ctx context.Context, request types.SwapRequest) (*types.SwapQuote, error) {
+	q.requests = append(q.requests, request)
+	return &types.SwapQuote{OutputAmount: q.output}, nil
+}
+
+func TestShadowPricer(t *testing.T) {
+	ctx := context.Background()
+	liquidity := NewLiquidityService()
+	eth := Token{Symbol: "ETH", ChainID: types.ChainIDEthereum}
+	usdc := Token{Symbol: "USDC", ChainID: types.ChainIDEthereum}
+	liquidity.SeedPool(ctx, "eth-usdc-500", TokenPair{BaseToken: eth, QuoteToken: usdc}, 500, "")
+	liquidity.SeedPool(ctx, "eth-usdc-3000", TokenPair{BaseToken: eth, QuoteToken: usdc}, 3000, "")
+
+	store := NewInMemoryTokenMetadataStore()
+	store.SaveTokenMetadata(ctx, []types.TokenMetadata{
+		{ChainID: types.ChainIDEthereum, Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Symbol: "USDC", Decimals: 6, Verified: true},
+	})
+
+	ours := &fixedQuoter{output: big.NewInt(3000_000000)}
+	theirs := &fixedQuoter{}
+	pricer := NewShadowPricer(ours, liquidity, NewTokenMetadataService(store), 4)
+	pricer.AddAggregator(theirs)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	pricer.now = func() time.Time { return now }
+
+	// We beat the aggregator by 1%, then lose by 1% three times; the first sample drops out of the last four
+	for i, output := range []int64{2970_000000, 3030_000000, 3030_000000, 3030_000000, 3000_000000} {
+		theirs.output = big.NewInt(output)
+		if recorded := pricer.Sample(ctx); recorded != 1 {
+			t.Fatalf("Sample %d: expected 1 comparison for both fee tiers of the pair, got %d", i, recorded)
+		}
+	}
+
+	// One whole ETH is quoted, with the quote token resolved from metadata
+	request := theirs.requests[0]
+	if request.Amount.String() != "1000000000000000000" || request.DestinationToken.Decimals != 6 || request.DestinationToken.Address == "" {
+		t.Errorf("Unexpected aggregator request %+v", request)
+	}
+
+	stats := pricer.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("Expected stats for one pair, got %+v", stats)
+	}
+	got := stats[0]
+	if got.Pair != "ETH/USDC" || got.Aggregator != "fixed" || got.Samples != 4 || got.WinRate != 0.25 {
+		t.Errorf("Unexpected stats %+v", got)
+	}
+	if got.P10BPS > -99 || got.P10BPS < -100 || got.P90BPS != 0 || got.LastBPS != 0 || !got.LastSampledAt.Equal(now) {
+		t.Errorf("Unexpected differences %+v", got)
+	}
+}
+
+func TestOutputDifferenceBPS(t *testing.T) {
+	if bps := outputDifferenceBPS(big.NewInt(1010), big.NewInt(1000)); bps != 100 {
+		t.Errorf("Expected 100 bps, got %v", bps)
+	}
+	if bps := outputDifferenceBPS(big.NewInt(995), big.NewInt(1000)); bps != -50 {
+		t.Errorf("Expected -50 bps, got %v", bps)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/infinity-dex/chains"
+)
+
+// SignedTxStore persists the transactions signed for on-chain execution before they are broadcast, so a retried
+// activity rebroadcasts the transaction an earlier attempt signed instead of signing another with a new nonce
+type SignedTxStore interface {
+	// SaveSignedTx stores the transaction signed for key. It reports false, changing nothing, when a transaction
+	// was stored for key before.
+	SaveSignedTx(ctx context.Context, key string, tx chains.Tx) (bool, error)
+	// GetSignedTx returns the transaction signed for key, or nil when none was
+	GetSignedTx(ctx context.Context, key string) (*chains.Tx, error)
+	// ReplaceSignedTx stores a replacement for key's transaction, signed with the same nonce and higher fees
+	ReplaceSignedTx(ctx context.Context, key string, tx chains.Tx) error
+}
+
+// InMemorySignedTxStore is a SignedTxStore for running without a database.
+// Its transactions do not survive a worker restart.
+type InMemorySignedTxStore struct {
+	txs map[string]chains.Tx
+	mu  sync.RWMutex
+}
+
+// NewInMemorySignedTxStore creates an empty in-memory signed transaction store
+func NewInMemorySignedTxStore() *InMemorySignedTxStore {
+	return &InMemorySignedTxStore{
+		txs: make(map[string]chains.Tx),
+	}
+}
+
+// SaveSignedTx stores the transaction signed for key unless one was stored before
+func (s *InMemorySignedTxStore) SaveSignedTx(ctx context.Context, key string, tx chains.Tx) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.txs[key]; ok {
+		return false, nil
+	}
+	s.txs[key] = tx
+	return true, nil
+}
+
+// GetSignedTx returns the transaction signed for key
+func (s *InMemorySignedTxStore) GetSignedTx(ctx context.Context, key string) (*chains.Tx, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tx, ok := s.txs[key]
+	if !ok {
+		return nil, nil
+	}
+	return &tx, nil
+}
+
+// ReplaceSignedTx stores a replacement for key's transaction
+func (s *InMemorySignedTxStore) ReplaceSignedTx(ctx context.Context, key string, tx chains.Tx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.txs[key] = tx
+	return nil
+}
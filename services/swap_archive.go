@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// SwapArchiveStore keeps finished swaps for lookup after their workflow history is gone
+type SwapArchiveStore interface {
+	// ArchiveSwap stores a finished swap, replacing any earlier archive of it
+	ArchiveSwap(ctx context.Context, swap types.ArchivedSwap) error
+	// GetArchivedSwap returns an archived swap, or types.ErrArchivedSwapNotFound
+	GetArchivedSwap(ctx context.Context, requestID string) (*types.ArchivedSwap, error)
+	// ListSwapsByAddress returns up to limit swaps the address sent or received, most recently closed first
+	ListSwapsByAddress(ctx context.Context, address string, limit int) ([]types.ArchivedSwap, error)
+}
+
+// InMemorySwapArchive is a SwapArchiveStore for running without a database
+type InMemorySwapArchive struct {
+	swaps map[string]types.ArchivedSwap // map[requestID]ArchivedSwap
+	mu    sync.RWMutex
+}
+
+// NewInMemorySwapArchive creates an empty in-memory swap archive
+func NewInMemorySwapArchive() *InMemorySwapArchive {
+	return &InMemorySwapArchive{
+		swaps: make(map[string]types.ArchivedSwap),
+	}
+}
+
+// ArchiveSwap stores a finished swap
+func (a *InMemorySwapArchive) ArchiveSwap(ctx context.Context, swap types.ArchivedSwap) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.swaps[swap.RequestID] = swap
+	return nil
+}
+
+// GetArchivedSwap returns an archived swap
+func (a *InMemorySwapArchive) GetArchivedSwap(ctx context.Context, requestID string) (*types.ArchivedSwap, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	swap, ok := a.swaps[requestID]
+	if !ok {
+		return nil, types.ErrArchivedSwapNotFound
+	}
+	return &swap, nil
+}
+
+// ListSwapsByAddress returns up to limit swaps the address sent or received, most recently closed first
+func (a *InMemorySwapArchive) ListSwapsByAddress(ctx context.Context, address string, limit int) ([]types.ArchivedSwap, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	swaps := []types.ArchivedSwap{}
+	for _, swap := range a.swaps {
+		if swap.Involves(address) {
+			swaps = append(swaps, swap)
+		}
+	}
+	sort.Slice(swaps, func(i, j int) bool {
+		if !swaps[i].ClosedAt.Equal(swaps[j].ClosedAt) {
+			return swaps[i].ClosedAt.After(swaps[j].ClosedAt)
+		}
+		return swaps[i].RequestID < swaps[j].RequestID
+	})
+	if len(swaps) > limit {
+		swaps = swaps[:limit]
+	}
+	return swaps, nil
+}
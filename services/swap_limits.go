@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// swapLimitWindow is the period daily swap limits cover
+const swapLimitWindow = 24 * time.Hour
+
+// SwapVolumeStore persists the value of the swaps booked against the daily swap limits, so every API server and swap
+// worker books them against the same limits
+type SwapVolumeStore interface {
+	// RecordSwapVolume stores a swap's volume unless, with the volumes booked since since, it would exceed one of the
+	// limits, when it returns a *types.SwapLimitError. A swap's volume is stored once: recording another under the same
+	// request ID fails with types.ErrSwapVolumeBooked.
+	RecordSwapVolume(ctx context.Context, volume types.SwapLimitVolume, since time.Time, limits types.SwapLimits) error
+	// ReleaseSwapVolume deletes the volume booked under a request ID, if any
+	ReleaseSwapVolume(ctx context.Context, requestID string) error
+	// SwapVolumeSince returns the value of the swaps booked since since from the volume's source address, pair and
+	// source chain
+	SwapVolumeSince(ctx context.Context, volume types.SwapLimitVolume, since time.Time) (types.SwapLimitTotals, error)
+}
+
+// InMemorySwapVolumeStore is a SwapVolumeStore for running without a database
+type InMemorySwapVolumeStore struct {
+	volumes map[string]types.SwapLimitVolume // map[requestID]volume
+	mu      sync.Mutex
+}
+
+// NewInMemorySwapVolumeStore creates an empty store
+func NewInMemorySwapVolumeStore() *InMemorySwapVolumeStore {
+	return &InMemorySwapVolumeStore{volumes: make(map[string]types.SwapLimitVolume)}
+}
+
+// RecordSwapVolume stores a swap's volume unless it would exceed one of the limits
+func (s *InMemorySwapVolumeStore) RecordSwapVolume(ctx context.Context, volume types.SwapLimitVolume, since time.Time, limits types.SwapLimits) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.volumes[volume.RequestID]; ok {
+		return types.ErrSwapVolumeBooked
+	}
+	if err := limits.Check(volume, s.volumeSince(volume, since)); err != nil {
+		return err
+	}
+	s.volumes[volume.RequestID] = volume
+	return nil
+}
+
+// ReleaseSwapVolume deletes the volume booked under a request ID, if any
+func (s *InMemorySwapVolumeStore) ReleaseSwapVolume(ctx context.Context, requestID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.volumes, requestID)
+	return nil
+}
+
+// SwapVolumeSince returns the value of the swaps booked since since from the volume's source address, pair and chain
+func (s *InMemorySwapVolumeStore) SwapVolumeSince(ctx context.Context, volume types.SwapLimitVolume, since time.Time) (types.SwapLimitTotals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.volumeSince(volume, since), nil
+}
+
+func (s *InMemorySwapVolumeStore) volumeSince(volume types.SwapLimitVolume, since time.Time) types.SwapLimitTotals {
+	var totals types.SwapLimitTotals
+	for _, booked := range s.volumes {
+		if booked.CreatedAt.Before(since) {
+			continue
+		}
+		if volume.Owner != "" && booked.Owner == volume.Owner {
+			totals.User += booked.AmountUSD
+		}
+		if booked.Pair == volume.Pair {
+			totals.Pair += booked.AmountUSD
+		}
+		if booked.ChainID == volume.ChainID {
+			totals.Chain += booked.AmountUSD
+		}
+	}
+	return totals
+}
+
+// SwapLimiter holds swaps to the configured limits on their USD value: per swap, and over any 24 hours per source
+// address, pair and source chain. Swaps are valued with the price oracle, and their volumes are kept in memory until
+// SetStore is called.
+type SwapLimiter struct {
+	limits types.SwapLimits
+	prices *PriceStalenessPolicy
+	store  SwapVolumeStore
+	now    func() time.Time
+}
+
+// NewSwapLimiter creates a limiter holding swaps to limits, valuing them with prices
+func NewSwapLimiter(limits types.SwapLimits, prices *PriceStalenessPolicy) *SwapLimiter {
+	return &SwapLimiter{
+		limits: limits,
+		prices: prices,
+		store:  NewInMemorySwapVolumeStore(),
+		now:    time.Now,
+	}
+}
+
+// SetStore books swap volumes in store
+func (l *SwapLimiter) SetStore(store SwapVolumeStore) {
+	l.store = store
+}
+
+// Reserve books a swap's value against its limits, failing with a *types.SwapLimitError when it is worth more than
+// one of them still allows, and with types.ErrSwapVolumeBooked when its request ID is already booked, so a request ID
+// can't carry more than one swap. Swaps are not valued when no limit is set.
+func (l *SwapLimiter) Reserve(ctx context.Context, request types.SwapRequest) error {
+	if l == nil || !l.limits.Enabled() {
+		return nil
+	}
+	if request.RequestID == "" {
+		return errors.New("swap has no request ID to book its volume under")
+	}
+	volume, err := l.volume(ctx, request)
+	if err != nil {
+		return err
+	}
+	return l.store.RecordSwapVolume(ctx, volume, volume.CreatedAt.Add(-swapLimitWindow), l.limits)
+}
+
+// EnsureReserved books a swap's value like Reserve unless its request ID is already booked, as it is for swaps the
+// API server reserved, or whose booking a retried activity already made
+func (l *SwapLimiter) EnsureReserved(ctx context.Context, request types.SwapRequest) error {
+	if err := l.Reserve(ctx, request); !errors.Is(err, types.ErrSwapVolumeBooked) {
+		return err
+	}
+	return nil
+}
+
+// Release deletes a swap's booking, for swaps that stopped before moving any funds, so they count against no limit
+func (l *SwapLimiter) Release(ctx context.Context, requestID string) error {
+	if l == nil || !l.limits.Enabled() || requestID == "" {
+		return nil
+	}
+	return l.store.ReleaseSwapVolume(ctx, requestID)
+}
+
+// volume values a swap's input at its source token's oracle price
+func (l *SwapLimiter) volume(ctx context.Context, request types.SwapRequest) (types.SwapLimitVolume, error) {
+	if request.Amount == nil {
+		return types.SwapLimitVolume{}, errors.New("missing amount")
+	}
+	price, err := l.prices.Price(ctx, request.SourceToken)
+	if err != nil {
+		return types.SwapLimitVolume{}, fmt.Errorf("failed to value the swap: %w", err)
+	}
+	return types.SwapLimitVolume{
+		RequestID: request.RequestID,
+		Owner:     strings.ToLower(request.SourceAddress),
+		ChainID:   types.CanonicalChainID(request.SourceToken.ChainID),
+		Pair:      types.SwapPair(request.SourceToken.Symbol, request.DestinationToken.Symbol),
+		AmountUSD: types.NewAmount(request.Amount, request.SourceToken.Decimals).Float64() * price.PriceUSD,
+		CreatedAt: l.now(),
+	}, nil
+}
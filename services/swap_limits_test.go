@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestSwapLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	policy := NewPriceStalenessPolicy(fakeOracle{
+		"ETH":  {Symbol: "ETH", PriceUSD: 2000, LastUpdated: now},
+		"USDC": {Symbol: "USDC", PriceUSD: 1, LastUpdated: now},
+	}, time.Minute)
+
+	// swap returns a swap of whole USDC to ETH from owner
+	swap := func(id, owner string, usdc int64) types.SwapRequest {
+		return types.SwapRequest{
+			RequestID:        id,
+			SourceToken:      types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDEthereum},
+			DestinationToken: types.Token{Symbol: "ETH", Decimals: 18, ChainID: types.ChainIDEthereum},
+			Amount:           new(big.Int).Mul(big.NewInt(usdc), big.NewInt(1_000_000)),
+			SourceAddress:    owner,
+		}
+	}
+	expectLimit := func(t *testing.T, err error, limit string, remaining float64) {
+		t.Helper()
+		var limitErr *types.SwapLimitError
+		if !errors.As(err, &limitErr) || !errors.Is(err, types.ErrSwapLimitExceeded) {
+			t.Fatalf("Expected a swap limit error, got %v", err)
+		}
+		if limitErr.Limit != limit || limitErr.RemainingUSD != remaining {
+			t.Errorf("Expected the %s limit with $%.2f remaining, got %+v", limit, remaining, limitErr)
+		}
+	}
+
+	t.Run("Unlimited", func(t *testing.T) {
+		limiter := NewSwapLimiter(types.SwapLimits{}, nil)
+		if err := limiter.Reserve(ctx, swap("swap-1", "0xabc", 1_000_000)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("PerSwap", func(t *testing.T) {
+		limiter := NewSwapLimiter(types.SwapLimits{
+			MaxSwapUSD: 10_000,
+			Pairs:      map[string]types.SwapAmountLimit{"ETH/USDC": {MaxSwapUSD: 5_000}},
+		}, policy)
+		if err := limiter.Reserve(ctx, swap("swap-1", "0xabc", 5_000)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expectLimit(t, limiter.Reserve(ctx, swap("swap-2", "0xabc", 5_001)), types.SwapLimitPair, 5_000)
+	})
+
+	t.Run("UserDaily", func(t *testing.T) {
+		limiter := NewSwapLimiter(types.SwapLimits{UserDailyUSD: 1_000}, policy)
+		if err := limiter.Reserve(ctx, swap("swap-1", "0xABC", 600)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// A request ID carries one swap, however much another under it is worth
+		if err := limiter.Reserve(ctx, swap("swap-1", "0xABC", 10)); !errors.Is(err, types.ErrSwapVolumeBooked) {
+			t.Fatalf("Expected a booked request ID to be refused, got %v", err)
+		}
+		// but booking it again from the swap's workflow books nothing more
+		if err := limiter.EnsureReserved(ctx, swap("swap-1", "0xABC", 600)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expectLimit(t, limiter.Reserve(ctx, swap("swap-2", "0xabc", 500)), types.SwapLimitUser, 400)
+		if err := limiter.Reserve(ctx, swap("swap-3", "0xdef", 500)); err != nil {
+			t.Errorf("Expected another address's swap to pass, got %v", err)
+		}
+
+		// A day later the first swap no longer counts
+		limiter.now = func() time.Time { return now.Add(swapLimitWindow + time.Minute) }
+		if err := limiter.Reserve(ctx, swap("swap-2", "0xabc", 500)); err != nil {
+			t.Errorf("Expected the swap to pass the next day, got %v", err)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		limiter := NewSwapLimiter(types.SwapLimits{UserDailyUSD: 1_000}, policy)
+		if err := limiter.Reserve(ctx, swap("swap-1", "0xabc", 600)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := limiter.Release(ctx, "swap-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// The released swap no longer counts, and its request ID may be booked again
+		if err := limiter.Reserve(ctx, swap("swap-1", "0xabc", 1_000)); err != nil {
+			t.Errorf("Expected the released swap not to count, got %v", err)
+		}
+	})
+
+	t.Run("ChainDaily", func(t *testing.T) {
+		limiter := NewSwapLimiter(types.SwapLimits{
+			Chains: map[int64]types.SwapAmountLimit{types.ChainIDEthereum: {DailyUSD: 1_500}},
+		}, policy)
+		if err := limiter.Reserve(ctx, swap("swap-1", "0xabc", 1_000)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expectLimit(t, limiter.Reserve(ctx, swap("swap-2", "0xdef", 1_000)), types.SwapLimitChain, 500)
+	})
+
+	t.Run("UnpricedToken", func(t *testing.T) {
+		limiter := NewSwapLimiter(types.SwapLimits{MaxSwapUSD: 10_000}, policy)
+		request := swap("swap-1", "0xabc", 1)
+		request.SourceToken.Symbol = "UNKNOWN"
+		if err := limiter.Reserve(ctx, request); err == nil || errors.Is(err, types.ErrSwapLimitExceeded) {
+			t.Errorf("Expected a pricing error, got %v", err)
+		}
+	})
+}
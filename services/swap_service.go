@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math/big"
 	"strings"
 	"time"
@@ -18,8 +19,26 @@ type SwapService struct {
 	tokenService       *TokenService
 	transactionService *TransactionService
 	universalSDK       universalsdk.SDK
+	liquidityService   *LiquidityService       // optional, credits swap fees to pools
+	bridgeRouter       *BridgeRouter           // optional, selects bridges for cross-chain swaps
+	bridges            []BridgeQuoter          // bridges quoted alongside Universal
+	pricePolicy        *PriceStalenessPolicy   // optional, prices quotes with the oracle instead of demo rates
+	gasEstimator       *GasEstimator           // optional, prices gas from live chain fees instead of the SDK's estimate
+	gasSponsor         *GasSponsor             // optional, pays the destination chain gas of swaps asking it to
+	quoteSigner        *QuoteSigner            // optional, makes quotes firm
+	priceImpactLimits  types.PriceImpactLimits // refuses swaps moving their pool's price too far, unless forced
+	rules              *RuleSet                // optional, applies operator fee overrides
 }
 
+// ErrOutputBelowMinimum is returned when a swap's quoted output is below the caller's minimum
+var ErrOutputBelowMinimum = errors.New("quoted output is below the minimum output amount")
+
+// ErrAmountTooSmall is returned when a swap's fees take its whole output
+var ErrAmountTooSmall = errors.New("output amount too small")
+
+// universalBridgeTime is the typical time a Universal bridge transfer takes
+const universalBridgeTime = 10 * time.Minute
+
 // NewSwapService creates a new swap service instance
 func NewSwapService(tokenService *TokenService, transactionService *TransactionService, universalSDK universalsdk.SDK) *SwapService {
 	return &SwapService{
@@ -29,12 +48,62 @@ func NewSwapService(tokenService *TokenService, transactionService *TransactionS
 	}
 }
 
+// SetLiquidityService routes swap fees to the liquidity pool of the traded pair
+func (s *SwapService) SetLiquidityService(liquidityService *LiquidityService) {
+	s.liquidityService = liquidityService
+}
+
+// SetPricePolicy prices quotes with the price oracle, rejecting quotes whose prices are too old
+func (s *SwapService) SetPricePolicy(policy *PriceStalenessPolicy) {
+	s.pricePolicy = policy
+}
+
+// SetRules applies the circuit breaker and fee override rules to quotes
+func (s *SwapService) SetRules(rules *RuleSet) {
+	s.rules = rules
+}
+
+// SetGasEstimator prices quoted gas from each involved chain's live fees instead of the SDK's fixed estimate
+func (s *SwapService) SetGasEstimator(estimator *GasEstimator) {
+	s.gasEstimator = estimator
+}
+
+// SetGasSponsor sponsors the destination chain gas of swaps asking for it, recouping it through their network fee
+func (s *SwapService) SetGasSponsor(sponsor *GasSponsor) {
+	s.gasSponsor = sponsor
+}
+
+// SetQuoteSigner makes quotes firm, signing each with an ID and expiry
+func (s *SwapService) SetQuoteSigner(signer *QuoteSigner) {
+	s.quoteSigner = signer
+}
+
+// SetPriceImpactLimits refuses swaps moving their pool's price by more than its limit, unless they are forced
+func (s *SwapService) SetPriceImpactLimits(limits types.PriceImpactLimits) {
+	s.priceImpactLimits = limits
+}
+
+// SetBridgeRouter selects bridges for cross-chain swaps with router
+func (s *SwapService) SetBridgeRouter(router *BridgeRouter) {
+	s.bridgeRouter = router
+}
+
+// AddBridge quotes cross-chain swaps through bridge as well as Universal, when a bridge router is set
+func (s *SwapService) AddBridge(bridge BridgeQuoter) {
+	s.bridges = append(s.bridges, bridge)
+}
+
 // GetSwapQuote returns a quote for a swap
 func (s *SwapService) GetSwapQuote(ctx context.Context, request types.SwapRequest) (*types.SwapQuote, error) {
 	// Validate request
 	if request.Amount == nil || request.Amount.Cmp(big.NewInt(0)) <= 0 {
 		return nil, errors.New("invalid amount")
 	}
+	for _, token := range []types.Token{request.SourceToken, request.DestinationToken} {
+		if err := types.CheckNativeToken(token); err != nil {
+			return nil, err
+		}
+	}
 
 	// Get fee estimate
 	feeEstimateRequest := universalsdk.FeeEstimateRequest{
@@ -46,20 +115,80 @@ func (s *SwapService) GetSwapQuote(ctx context.Context, request types.SwapReques
 	if err != nil {
 		return nil, fmt.Errorf("failed to get fee estimate: %w", err)
 	}
+	// Keep the SDK's gas fee until every involved chain has gas readings
+	if s.gasEstimator != nil {
+		if gasFee, err := s.gasEstimator.GasFee(ctx, request); err == nil {
+			fee.GasFee = gasFee
+		}
+	}
+	// The protocol pays sponsored swaps' destination chain gas and recoups it from their output
+	var sponsorship *types.GasSponsorship
+	if request.SponsorGas {
+		if s.gasSponsor == nil {
+			return nil, types.ErrGasSponsorshipUnavailable
+		}
+		if sponsorship, err = s.gasSponsor.Quote(ctx, request); err != nil {
+			return nil, err
+		}
+		fee.NetworkFee = new(big.Int).Add(fee.NetworkFee, sponsorship.Recouped)
+	}
+	// Carry cross-chain swaps over the bridge with the lowest reliability-adjusted fee
+	var bridge string
+	var bridgeTime time.Duration
+	if request.SourceToken.ChainID != request.DestinationToken.ChainID {
+		bridgeTime = universalBridgeTime
+	}
+	if s.bridgeRouter != nil && request.SourceToken.ChainID != request.DestinationToken.ChainID {
+		corridor := BridgeCorridor{SourceChainID: request.SourceToken.ChainID, DestinationChainID: request.DestinationToken.ChainID}
+		candidates, _ := s.bridgeCandidates(ctx, request, fee.BridgeFee)
+		selected, err := s.bridgeRouter.Route(ctx, corridor, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select bridge: %w", err)
+		}
+		bridge = selected.Bridge
+		bridgeTime = selected.EstimatedTime
+		fee.BridgeFee = selected.Fee
+	}
+	// Tripped circuit breakers refuse the swap; a broken fee override leaves the standard fee in place
+	if s.rules != nil {
+		var sourcePriceUSD float64
+		if s.pricePolicy != nil {
+			if price, err := s.pricePolicy.Price(ctx, request.SourceToken); err == nil {
+				sourcePriceUSD = price.PriceUSD
+			}
+		}
+		broken, err := s.rules.CheckBreakers(ctx, request, sourcePriceUSD)
+		if broken != nil {
+			log.Printf("Skipping broken circuit breaker rules for %s: %v", request.RequestID, broken)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := s.rules.ApplyFeeOverride(ctx, request, sourcePriceUSD, fee); err != nil {
+			log.Printf("Ignoring fee override for %s: %v", request.RequestID, err)
+		}
+	}
 
-	// Calculate output amount (simplified for demo)
-	// In a real implementation, this would use price oracles, liquidity pools, etc.
-	outputAmount := big.NewInt(0)
-	if request.SourceToken.Symbol == "ETH" && request.DestinationToken.Symbol == "USDC" {
+	// Price the swap with the oracle when a staleness policy is set, refusing stale prices
+	var outputAmount *big.Int
+	var midPrice float64
+	var priceAsOf time.Time
+	if s.pricePolicy != nil {
+		sourcePrice, destinationPrice, asOf, err := s.pricePolicy.PairPrices(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		outputAmount = convertAmount(request.Amount, request.SourceToken, sourcePrice.PriceUSD, request.DestinationToken, destinationPrice.PriceUSD)
+		priceFee(fee, request.SourceToken, sourcePrice)
+		midPrice = sourcePrice.PriceUSD / destinationPrice.PriceUSD
+		priceAsOf = asOf
+	} else if request.SourceToken.Symbol == "ETH" && request.DestinationToken.Symbol == "USDC" {
+		// Without an oracle, use fixed demo rates
 		// 1 ETH = 2000 USDC (simplified)
-		ethValue := new(big.Float).SetInt(request.Amount)
-		usdcValue := new(big.Float).Mul(ethValue, big.NewFloat(2000.0))
-		outputAmount, _ = usdcValue.Int(nil)
+		outputAmount = new(big.Int).Mul(request.Amount, big.NewInt(2000))
 	} else if request.SourceToken.Symbol == "USDC" && request.DestinationToken.Symbol == "ETH" {
 		// 2000 USDC = 1 ETH (simplified)
-		usdcValue := new(big.Float).SetInt(request.Amount)
-		ethValue := new(big.Float).Quo(usdcValue, big.NewFloat(2000.0))
-		outputAmount, _ = ethValue.Int(nil)
+		outputAmount = new(big.Int).Quo(request.Amount, big.NewInt(2000))
 	} else {
 		// Default 1:1 for demo
 		outputAmount = big.NewInt(0).Set(request.Amount)
@@ -70,19 +199,32 @@ func (s *SwapService) GetSwapQuote(ctx context.Context, request types.SwapReques
 	outputAmount = big.NewInt(0).Sub(outputAmount, fee.ProtocolFee)
 	outputAmount = big.NewInt(0).Sub(outputAmount, fee.NetworkFee)
 	if outputAmount.Cmp(big.NewInt(0)) <= 0 {
-		return nil, errors.New("output amount too small")
+		return nil, ErrAmountTooSmall
 	}
 
-	// Create swap path
-	path := []string{request.SourceToken.Symbol, request.DestinationToken.Symbol}
+	// Quote against the pool's reserve of the output token not already held by executing swaps, and its price
+	// impact against the pool's reserve of the input token
+	priceImpact := estimatedPriceImpact
+	if s.liquidityService != nil {
+		if pool, err := s.liquidityService.GetPoolByTokens(ctx, request.SourceToken.Symbol, request.DestinationToken.Symbol); err == nil {
+			available, err := s.liquidityService.GetAvailableLiquidity(ctx, pool.ID, request.DestinationToken.Symbol)
+			if err == nil && available.Cmp(outputAmount) < 0 {
+				return nil, fmt.Errorf("%w: %s available, %s needed", ErrInsufficientPoolLiquidity, available, outputAmount)
+			}
+			if impact, ok := PoolPriceImpact(*pool, request.SourceToken.Symbol, request.Amount); ok {
+				priceImpact = impact
+				if limit := s.priceImpactLimits.Limit(pool.ID); limit > 0 && impact > limit && !request.ForcePriceImpact {
+					return nil, &PriceImpactError{PoolID: pool.ID, Impact: impact, Limit: limit}
+				}
+			}
+		}
+	}
 
-	// Calculate price impact (simplified)
-	priceImpact := 0.1 // 0.1%
+	// Create swap path
+	path := SelectSwapPath(request.SourceToken, request.DestinationToken)
 
 	// Calculate exchange rate
-	sourceFloat := new(big.Float).SetInt(request.Amount)
-	destFloat := new(big.Float).SetInt(outputAmount)
-	exchangeRate, _ := new(big.Float).Quo(destFloat, sourceFloat).Float64()
+	exchangeRate, _ := new(big.Rat).SetFrac(outputAmount, request.Amount).Float64()
 
 	// Create quote
 	quote := &types.SwapQuote{
@@ -94,11 +236,86 @@ func (s *SwapService) GetSwapQuote(ctx context.Context, request types.SwapReques
 		Path:             path,
 		PriceImpact:      priceImpact,
 		ExchangeRate:     exchangeRate,
+		MidPrice:         midPrice,
+		PriceAsOf:        priceAsOf,
+		Bridge:           bridge,
+		BridgeTime:       bridgeTime,
+		GasSponsorship:   sponsorship,
+	}
+	if s.quoteSigner != nil {
+		if err := s.quoteSigner.Sign(quote); err != nil {
+			return nil, err
+		}
 	}
 
 	return quote, nil
 }
 
+// BridgeComparison compares the bridges for a transfer
+type BridgeComparison struct {
+	Quotes      []RankedBridge    // The bridges able to carry the transfer, in the order the router chooses them
+	Unavailable map[string]string // map[bridge]reason, for the bridges that aren't
+}
+
+// CompareBridges quotes moving amount of source to destination's chain through every bridge, ranked by the same
+// quotes and reliability history cross-chain swaps route with
+func (s *SwapService) CompareBridges(ctx context.Context, source, destination types.Token, amount *big.Int) (*BridgeComparison, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("invalid amount")
+	}
+	if source.ChainID == destination.ChainID {
+		return nil, errors.New("bridges only carry transfers between different chains")
+	}
+	if s.bridgeRouter == nil {
+		return nil, errors.New("no bridge router")
+	}
+
+	request := types.SwapRequest{SourceToken: source, DestinationToken: destination, Amount: amount}
+	fee, err := s.universalSDK.GetFeeEstimate(ctx, universalsdk.FeeEstimateRequest{
+		SourceToken:      source,
+		DestinationToken: destination,
+		Amount:           amount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee estimate: %w", err)
+	}
+
+	candidates, unavailable := s.bridgeCandidates(ctx, request, fee.BridgeFee)
+	corridor := BridgeCorridor{SourceChainID: source.ChainID, DestinationChainID: destination.ChainID}
+	return &BridgeComparison{
+		Quotes:      s.bridgeRouter.Rank(ctx, corridor, candidates),
+		Unavailable: unavailable,
+	}, nil
+}
+
+// bridgeCandidates returns Universal, at the SDK's estimated bridge fee, and every added bridge able to carry
+// the swap, with why each of the other bridges can't
+func (s *SwapService) bridgeCandidates(ctx context.Context, request types.SwapRequest, universalFee *big.Int) ([]BridgeCandidate, map[string]string) {
+	candidates := []BridgeCandidate{{
+		Bridge:        BridgeUniversal,
+		Fee:           universalFee,
+		EstimatedTime: universalBridgeTime,
+	}}
+	unavailable := make(map[string]string)
+	for _, bridge := range s.bridges {
+		candidate, err := bridge.QuoteTransfer(ctx, request.SourceToken, request.DestinationToken, request.Amount)
+		switch {
+		case err != nil:
+			log.Printf("Failed to quote %s for %s: %v", bridge.Name(), request.RequestID, err)
+			unavailable[bridge.Name()] = fmt.Sprintf("failed to quote: %v", err)
+		case candidate == nil:
+			unavailable[bridge.Name()] = "can't carry the transfer"
+		case candidate.MinAmount != nil && request.Amount.Cmp(candidate.MinAmount) < 0:
+			unavailable[bridge.Name()] = fmt.Sprintf("amount below the bridge's minimum of %s", candidate.MinAmount)
+		case candidate.MaxAmount != nil && request.Amount.Cmp(candidate.MaxAmount) > 0:
+			unavailable[bridge.Name()] = fmt.Sprintf("amount above the bridge's maximum of %s", candidate.MaxAmount)
+		default:
+			candidates = append(candidates, *candidate)
+		}
+	}
+	return candidates, unavailable
+}
+
 // ExecuteSwap executes a swap
 func (s *SwapService) ExecuteSwap(ctx context.Context, request types.SwapRequest) (string, error) {
 	// Validate request
@@ -195,29 +412,132 @@ func (s *SwapService) ExecuteSwap(ctx context.Context, request types.SwapRequest
 	if err != nil {
 		return "", fmt.Errorf("failed to get swap quote: %w", err)
 	}
+	if quote, err = honorFirmQuote(request, quote); err != nil {
+		return "", err
+	}
+	if request.MinOutputAmount != nil && quote.OutputAmount.Cmp(request.MinOutputAmount) < 0 {
+		return "", fmt.Errorf("%w: quoted %s, minimum %s", ErrOutputBelowMinimum, quote.OutputAmount, request.MinOutputAmount)
+	}
+
+	// Book the sponsored gas against the chain's daily cap before committing to the swap
+	if quote.GasSponsorship != nil {
+		sponsorship := *quote.GasSponsorship
+		sponsorship.RequestID = requestID
+		if err := s.gasSponsor.Record(ctx, sponsorship); err != nil {
+			return "", err
+		}
+	}
+
+	// Hold the pool's output token reserve while the swap executes so concurrent swaps can't oversell it
+	if s.liquidityService != nil {
+		if pool, err := s.liquidityService.GetPoolByTokens(ctx, request.SourceToken.Symbol, request.DestinationToken.Symbol); err == nil {
+			if err := s.liquidityService.ReserveLiquidity(ctx, pool.ID, requestID, request.DestinationToken.Symbol, quote.OutputAmount); err != nil {
+				return "", fmt.Errorf("failed to reserve pool liquidity: %w", err)
+			}
+		}
+	}
 
 	// Set destination amount
 	destTx.Amount = quote.OutputAmount
 	destTx.Value = quote.OutputAmount
 
+	// The fees charged are the ones quoted at execution, which may differ from the quote the user accepted
+	sourceTx.Fee = &quote.Fee
+
 	// Create transactions
 	_, err = s.transactionService.CreateTransaction(ctx, sourceTx)
 	if err != nil {
+		s.releaseLiquidity(ctx, requestID)
 		return "", fmt.Errorf("failed to create source transaction: %w", err)
 	}
 
 	_, err = s.transactionService.CreateTransaction(ctx, destTx)
 	if err != nil {
+		s.releaseLiquidity(ctx, requestID)
 		return "", fmt.Errorf("failed to create destination transaction: %w", err)
 	}
 
 	return requestID, nil
 }
 
-// GetSwapStatus returns the status of a swap
+// SimulateSwap quotes a swap and checks its minimum output like ExecuteSwap, without reserving pool liquidity or
+// recording transactions, and returns the swap's projected result. It fails where ExecuteSwap would; the quote
+// already accounts for the pool's unreserved liquidity.
+func (s *SwapService) SimulateSwap(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error) {
+	if request.Amount == nil || request.Amount.Cmp(big.NewInt(0)) <= 0 {
+		return nil, errors.New("invalid amount")
+	}
+
+	quote, err := s.GetSwapQuote(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get swap quote: %w", err)
+	}
+	if quote, err = honorFirmQuote(request, quote); err != nil {
+		return nil, err
+	}
+	if request.MinOutputAmount != nil && quote.OutputAmount.Cmp(request.MinOutputAmount) < 0 {
+		return nil, fmt.Errorf("%w: quoted %s, minimum %s", ErrOutputBelowMinimum, quote.OutputAmount, request.MinOutputAmount)
+	}
+
+	now := time.Now()
+	return &types.SwapResult{
+		RequestID: request.RequestID,
+		Success:   true,
+		SourceTx: types.Transaction{
+			Type:        "swap_source",
+			Status:      string(types.SwapStatusSimulated),
+			FromAddress: request.SourceAddress,
+			SourceChain: request.SourceToken.ChainName,
+			DestChain:   request.SourceToken.ChainName,
+			SourceToken: request.SourceToken,
+			DestToken:   request.SourceToken,
+			Amount:      request.Amount,
+			Value:       request.Amount,
+			Fee:         &quote.Fee,
+			Timestamp:   now,
+		},
+		DestinationTx: types.Transaction{
+			Type:        "swap_dest",
+			Status:      string(types.SwapStatusSimulated),
+			ToAddress:   request.DestinationAddress,
+			SourceChain: request.DestinationToken.ChainName,
+			DestChain:   request.DestinationToken.ChainName,
+			SourceToken: request.DestinationToken,
+			DestToken:   request.DestinationToken,
+			Amount:      quote.OutputAmount,
+			Value:       quote.OutputAmount,
+			Timestamp:   now,
+		},
+		InputAmount:    request.Amount,
+		OutputAmount:   quote.OutputAmount,
+		Fee:            quote.Fee,
+		CompletionTime: now,
+		Status:         types.SwapStatusSimulated,
+	}, nil
+}
+
+// honorFirmQuote returns the quote a swap executes at: its firm quote when the swap has one and the fresh quote
+// would deliver less, or else the fresh quote. It fails once the firm quote has expired.
+func honorFirmQuote(request types.SwapRequest, quote *types.SwapQuote) (*types.SwapQuote, error) {
+	if request.FirmQuote == nil {
+		return quote, nil
+	}
+	if err := CheckFirmQuote(request, *request.FirmQuote, time.Now()); err != nil {
+		return nil, err
+	}
+	if quote.OutputAmount.Cmp(request.FirmQuote.OutputAmount) < 0 {
+		return request.FirmQuote, nil
+	}
+	return quote, nil
+}
+
+// GetSwapStatus returns the status of a swap, or types.ErrSwapNotFound if it was never submitted
 func (s *SwapService) GetSwapStatus(ctx context.Context, requestID string) (*types.SwapResult, error) {
 	// Get transactions for this swap
 	txs, err := s.transactionService.GetTransactionsByWorkflowID(ctx, requestID)
+	if errors.Is(err, ErrNoWorkflowTransactions) {
+		return nil, types.ErrSwapNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -243,16 +563,20 @@ func (s *SwapService) GetSwapStatus(ctx context.Context, requestID string) (*typ
 
 	// Determine if swap is complete
 	success := sourceTx.Status == "completed" && destTx.Status == "completed"
-
-	// Get fee estimate
-	feeEstimateRequest := universalsdk.FeeEstimateRequest{
-		SourceToken:      sourceTx.SourceToken,
-		DestinationToken: destTx.DestToken,
-		Amount:           sourceTx.Amount,
-	}
-	fee, err := s.universalSDK.GetFeeEstimate(ctx, feeEstimateRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get fee estimate: %w", err)
+	failed := isFailedTxStatus(sourceTx.Status) || isFailedTxStatus(destTx.Status)
+
+	// Report the fees the swap was charged, estimating them for swaps submitted without
+	fee := sourceTx.Fee
+	if fee == nil {
+		feeEstimateRequest := universalsdk.FeeEstimateRequest{
+			SourceToken:      sourceTx.SourceToken,
+			DestinationToken: destTx.DestToken,
+			Amount:           sourceTx.Amount,
+		}
+		fee, err = s.universalSDK.GetFeeEstimate(ctx, feeEstimateRequest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get fee estimate: %w", err)
+		}
 	}
 
 	// Create result
@@ -267,13 +591,52 @@ func (s *SwapService) GetSwapStatus(ctx context.Context, requestID string) (*typ
 		CompletionTime: time.Now(),
 	}
 
-	if !success {
+	switch {
+	case success:
+		result.Status = types.SwapStatusCompleted
+	case failed:
+		result.Status = types.SwapStatusFailed
+		result.ErrorMessage = fmt.Sprintf("Swap failed: source transaction %s, destination transaction %s", sourceTx.Status, destTx.Status)
+	default:
+		result.Status = types.SwapStatusPending
 		result.ErrorMessage = "Swap in progress"
 	}
 
+	if success || failed {
+		s.settleSwap(ctx, requestID, sourceTx, destTx, success)
+	}
+
 	return result, nil
 }
 
+// isFailedTxStatus reports whether a swap transaction ended without completing
+func isFailedTxStatus(status string) bool {
+	return status == "failed" || status == "refunded" || status == "cancelled"
+}
+
+// settleSwap credits a settled swap's fees to its pool's LPs, in the token it paid in, moves the tokens it traded
+// through the pool's reserves and releases the liquidity it held. A swap settles once however often it is seen.
+func (s *SwapService) settleSwap(ctx context.Context, requestID string, sourceTx, destTx types.Transaction, success bool) {
+	if s.liquidityService == nil {
+		return
+	}
+	if success {
+		if pool, err := s.liquidityService.GetPoolByTokens(ctx, sourceTx.SourceToken.Symbol, destTx.DestToken.Symbol); err == nil {
+			if _, err := s.liquidityService.SettleSwap(ctx, pool.ID, requestID, sourceTx.SourceToken.Symbol, sourceTx.Amount, destTx.DestToken.Symbol, destTx.Amount); err != nil {
+				log.Printf("Failed to settle swap %s in pool %s: %v", requestID, pool.ID, err)
+			}
+		}
+	}
+	s.liquidityService.ReleaseLiquidity(ctx, requestID)
+}
+
+// releaseLiquidity releases the pool liquidity reserved for a swap
+func (s *SwapService) releaseLiquidity(ctx context.Context, requestID string) {
+	if s.liquidityService != nil {
+		s.liquidityService.ReleaseLiquidity(ctx, requestID)
+	}
+}
+
 // CancelSwap cancels a swap
 func (s *SwapService) CancelSwap(ctx context.Context, requestID string) error {
 	// Get transactions for this swap
@@ -293,6 +656,7 @@ func (s *SwapService) CancelSwap(ctx context.Context, requestID string) error {
 			return fmt.Errorf("failed to update transaction status: %w", err)
 		}
 	}
+	s.releaseLiquidity(ctx, requestID)
 
 	return nil
 }
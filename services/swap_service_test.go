@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"testing"
 	"time"
@@ -29,6 +30,10 @@ func (m *MockUniversalSDK) GetWrappedTokens(ctx context.Context, chainID int64)
 	return nil, nil
 }
 
+func (m *MockUniversalSDK) GetAllWrappedTokens(ctx context.Context) (map[int64][]types.Token, error) {
+	return nil, nil
+}
+
 func (m *MockUniversalSDK) GetFeeEstimate(ctx context.Context, req universalsdk.FeeEstimateRequest) (*types.Fee, error) {
 	// Return a mock fee
 	return &types.Fee{
@@ -44,6 +49,10 @@ func (m *MockUniversalSDK) GetTransactionStatus(ctx context.Context, transaction
 	return nil, nil
 }
 
+func (m *MockUniversalSDK) WatchTransaction(ctx context.Context, transactionID string) (<-chan universalsdk.TransactionStatus, error) {
+	return nil, nil
+}
+
 func TestSwapService(t *testing.T) {
 	// Create dependencies
 	tokenService := NewTokenService()
@@ -195,6 +204,22 @@ func TestSwapService(t *testing.T) {
 		}
 	})
 
+	// Test that a minimum output above the quote refuses the swap
+	t.Run("MinOutputAmount", func(t *testing.T) {
+		ctx := context.Background()
+		limited := request
+		limited.RequestID = "req-min-output"
+		limited.MinOutputAmount = new(big.Int).Mul(amount, big.NewInt(10000))
+
+		_, err := service.ExecuteSwap(ctx, limited)
+		if !errors.Is(err, ErrOutputBelowMinimum) {
+			t.Errorf("Expected ErrOutputBelowMinimum, got %v", err)
+		}
+		if txs, _ := transactionService.GetTransactionsByWorkflowID(ctx, limited.RequestID); len(txs) != 0 {
+			t.Errorf("Expected no transactions for a refused swap, got %d", len(txs))
+		}
+	})
+
 	// Test GetSwapStatus
 	t.Run("GetSwapStatus", func(t *testing.T) {
 		ctx := context.Background()
@@ -211,6 +236,9 @@ func TestSwapService(t *testing.T) {
 		if result.Success {
 			t.Error("Expected Success to be false, got true")
 		}
+		if result.Status != types.SwapStatusPending {
+			t.Errorf("Expected Status to be pending, got '%s'", result.Status)
+		}
 		if result.SourceTx.Type != "swap_source" {
 			t.Errorf("Expected SourceTx.Type to be 'swap_source', got '%s'", result.SourceTx.Type)
 		}
@@ -231,6 +259,12 @@ func TestSwapService(t *testing.T) {
 		}
 	})
 
+	t.Run("GetSwapStatusNotFound", func(t *testing.T) {
+		if _, err := service.GetSwapStatus(context.Background(), "req-missing"); !errors.Is(err, types.ErrSwapNotFound) {
+			t.Errorf("Expected ErrSwapNotFound, got %v", err)
+		}
+	})
+
 	// Test CancelSwap
 	t.Run("CancelSwap", func(t *testing.T) {
 		ctx := context.Background()
@@ -250,6 +284,11 @@ func TestSwapService(t *testing.T) {
 			}
 		}
 
+		result, err := service.GetSwapStatus(ctx, "req-123")
+		if err != nil || result.Status != types.SwapStatusFailed {
+			t.Errorf("Expected a cancelled swap to have failed, got %+v (%v)", result, err)
+		}
+
 		// Try to cancel a non-existent swap
 		err = service.CancelSwap(ctx, "req-456")
 		if err == nil {
@@ -257,3 +296,197 @@ func TestSwapService(t *testing.T) {
 		}
 	})
 }
+
+func TestSwapLiquidityReservation(t *testing.T) {
+	ctx := context.Background()
+	liquidityService := NewLiquidityService()
+	service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+	service.SetLiquidityService(liquidityService)
+
+	eth := types.Token{Symbol: "ETH", ChainID: 1, ChainName: "Ethereum"}
+	usdc := types.Token{Symbol: "USDC", ChainID: 1, ChainName: "Ethereum"}
+	pool, err := liquidityService.CreatePool(ctx, TokenPair{BaseToken: Token{Symbol: "ETH"}, QuoteToken: Token{Symbol: "USDC"}}, 3000, "0x1234567890abcdef1234567890abcdef12345678")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	// Deep enough for one 1 ETH swap (about 2000 USDC out) but not two
+	depth, _ := new(big.Int).SetString("3000000000000000000000", 10)
+	if _, err := liquidityService.AddLiquidityOnce(ctx, "", pool.ID, "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "USDC", depth); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+
+	swap := func(requestID string) types.SwapRequest {
+		return types.SwapRequest{
+			SourceToken:      eth,
+			DestinationToken: usdc,
+			Amount:           big.NewInt(1000000000000000000),
+			RequestID:        requestID,
+		}
+	}
+
+	if _, err := service.ExecuteSwap(ctx, swap("req-first")); err != nil {
+		t.Fatalf("Failed to execute swap: %v", err)
+	}
+	if _, err := service.GetSwapQuote(ctx, swap("req-second")); !errors.Is(err, ErrInsufficientPoolLiquidity) {
+		t.Errorf("Expected the quote to see the reserved liquidity, got %v", err)
+	}
+	if _, err := service.ExecuteSwap(ctx, swap("req-second")); !errors.Is(err, ErrInsufficientPoolLiquidity) {
+		t.Errorf("Expected ErrInsufficientPoolLiquidity, got %v", err)
+	}
+
+	if err := service.CancelSwap(ctx, "req-first"); err != nil {
+		t.Fatalf("Failed to cancel swap: %v", err)
+	}
+	if _, err := service.ExecuteSwap(ctx, swap("req-second")); err != nil {
+		t.Errorf("Expected the cancelled swap to release its liquidity: %v", err)
+	}
+}
+
+func TestSwapSimulation(t *testing.T) {
+	ctx := context.Background()
+	liquidityService := NewLiquidityService()
+	service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+	service.SetLiquidityService(liquidityService)
+
+	pool, err := liquidityService.CreatePool(ctx, TokenPair{BaseToken: Token{Symbol: "ETH"}, QuoteToken: Token{Symbol: "USDC"}}, 3000, "0x1234567890abcdef1234567890abcdef12345678")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	// Deep enough for one 1 ETH swap but not two
+	depth, _ := new(big.Int).SetString("3000000000000000000000", 10)
+	if _, err := liquidityService.AddLiquidityOnce(ctx, "", pool.ID, "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "USDC", depth); err != nil {
+		t.Fatalf("Failed to add liquidity: %v", err)
+	}
+
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "ETH", ChainID: 1, ChainName: "Ethereum"},
+		DestinationToken: types.Token{Symbol: "USDC", ChainID: 1, ChainName: "Ethereum"},
+		Amount:           big.NewInt(1000000000000000000),
+		RequestID:        "req-simulated",
+		DryRun:           true,
+	}
+
+	// Simulations reserve nothing, so each sees the whole pool
+	for i := 0; i < 2; i++ {
+		result, err := service.SimulateSwap(ctx, request)
+		if err != nil {
+			t.Fatalf("Failed to simulate swap: %v", err)
+		}
+		if !result.Success || result.Status != types.SwapStatusSimulated || result.OutputAmount.Sign() <= 0 {
+			t.Errorf("Expected a successful projection, got %+v", result)
+		}
+		if result.DestinationTx.Amount.Cmp(result.OutputAmount) != 0 || result.SourceTx.Fee == nil {
+			t.Errorf("Expected projected transactions carrying the output and fees, got %+v", result)
+		}
+	}
+	if _, err := service.GetSwapStatus(ctx, "req-simulated"); !errors.Is(err, types.ErrSwapNotFound) {
+		t.Errorf("Expected no transactions to be recorded, got %v", err)
+	}
+
+	// Simulations fail where the swap would
+	request.MinOutputAmount, _ = new(big.Int).SetString("1000000000000000000000000000000", 10)
+	if _, err := service.SimulateSwap(ctx, request); !errors.Is(err, ErrOutputBelowMinimum) {
+		t.Errorf("Expected ErrOutputBelowMinimum, got %v", err)
+	}
+	request.MinOutputAmount = nil
+	request.Amount = new(big.Int).Mul(big.NewInt(2), request.Amount)
+	if _, err := service.SimulateSwap(ctx, request); !errors.Is(err, ErrInsufficientPoolLiquidity) {
+		t.Errorf("Expected ErrInsufficientPoolLiquidity, got %v", err)
+	}
+}
+
+// fakeOracle serves fixed oracle prices by symbol
+type fakeOracle map[string]types.TokenPrice
+
+func (f fakeOracle) OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error) {
+	price, ok := f[symbol]
+	if !ok {
+		return types.TokenPrice{}, errors.New("not found")
+	}
+	return price, nil
+}
+
+func TestSwapQuoteOraclePrices(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	oracle := fakeOracle{
+		"ETH":  {Symbol: "ETH", PriceUSD: 2000, LastUpdated: now.Add(-time.Minute)},
+		"USDC": {Symbol: "USDC", PriceUSD: 1, LastUpdated: now.Add(-10 * time.Second)},
+		"DAI":  {Symbol: "DAI", PriceUSD: 1, LastUpdated: now.Add(-time.Hour)},
+	}
+	service := NewSwapService(NewTokenService(), NewTransactionService(), &MockUniversalSDK{})
+	service.SetPricePolicy(NewPriceStalenessPolicy(oracle, 5*time.Minute))
+
+	request := func(destination string) types.SwapRequest {
+		return types.SwapRequest{
+			SourceToken:      types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1, IsWrapped: true},
+			DestinationToken: types.Token{Symbol: destination, Decimals: 18, ChainID: 1, IsWrapped: true},
+			Amount:           big.NewInt(1_000_000_000_000_000_000),
+		}
+	}
+
+	t.Run("prices quotes with the oracle", func(t *testing.T) {
+		quote, err := service.GetSwapQuote(ctx, request("uUSDC"))
+		if err != nil {
+			t.Fatalf("Failed to get quote: %v", err)
+		}
+		// 2000 USDC less the fees
+		expected, _ := new(big.Int).SetString("1999998300000000000000", 10)
+		if quote.OutputAmount.Cmp(expected) != 0 {
+			t.Errorf("Expected output %s, got %s", expected, quote.OutputAmount)
+		}
+		if !quote.PriceAsOf.Equal(oracle["ETH"].LastUpdated) {
+			t.Errorf("Expected the older price's time %v, got %v", oracle["ETH"].LastUpdated, quote.PriceAsOf)
+		}
+		if quote.Fee.TotalFeeUSD < 3.39 || quote.Fee.TotalFeeUSD > 3.41 {
+			t.Errorf("Expected fees of $3.40 at the oracle ETH price, got %f", quote.Fee.TotalFeeUSD)
+		}
+		// Each fee is valued at the rate the quote used
+		fee := quote.Fee
+		if fee.PriceUSD != 2000 || !fee.PriceAsOf.Equal(oracle["ETH"].LastUpdated) {
+			t.Errorf("Expected fees valued at the oracle's $2000 ETH, got $%f as of %v", fee.PriceUSD, fee.PriceAsOf)
+		}
+		if sum := fee.GasFeeUSD + fee.ProtocolFeeUSD + fee.NetworkFeeUSD + fee.BridgeFeeUSD; sum != fee.TotalFeeUSD || fee.GasFeeUSD <= 0 {
+			t.Errorf("Expected the fees' USD values to add up to $%f, got $%f", fee.TotalFeeUSD, sum)
+		}
+	})
+
+	t.Run("detects drift beyond slippage", func(t *testing.T) {
+		swap := request("uUSDC")
+		swap.Slippage = 1
+		quote, err := service.GetSwapQuote(ctx, swap)
+		if err != nil {
+			t.Fatalf("Failed to get quote: %v", err)
+		}
+		if quote.MidPrice != 2000 {
+			t.Fatalf("Expected a mid price of 2000, got %f", quote.MidPrice)
+		}
+
+		policy := NewPriceStalenessPolicy(oracle, 5*time.Minute)
+		oracle["ETH"] = types.TokenPrice{Symbol: "ETH", PriceUSD: 1990, LastUpdated: now}
+		if err := policy.CheckDrift(ctx, swap, *quote); err != nil {
+			t.Errorf("Expected a 0.5%% move within 1%% slippage to pass, got %v", err)
+		}
+		oracle["ETH"] = types.TokenPrice{Symbol: "ETH", PriceUSD: 2100, LastUpdated: now}
+		if err := policy.CheckDrift(ctx, swap, *quote); err != nil {
+			t.Errorf("Expected a move in the user's favor to pass, got %v", err)
+		}
+		oracle["ETH"] = types.TokenPrice{Symbol: "ETH", PriceUSD: 1900, LastUpdated: now}
+		if err := policy.CheckDrift(ctx, swap, *quote); !errors.Is(err, ErrQuoteDrift) {
+			t.Errorf("Expected ErrQuoteDrift for a 5%% drop, got %v", err)
+		}
+		oracle["ETH"] = types.TokenPrice{Symbol: "ETH", PriceUSD: 2000, LastUpdated: now.Add(-time.Minute)}
+	})
+
+	t.Run("rejects stale prices", func(t *testing.T) {
+		if _, err := service.GetSwapQuote(ctx, request("uDAI")); !errors.Is(err, ErrStalePrice) {
+			t.Errorf("Expected ErrStalePrice, got %v", err)
+		}
+	})
+
+	t.Run("rejects tokens without a price", func(t *testing.T) {
+		if _, err := service.GetSwapQuote(ctx, request("uUSDT")); !errors.Is(err, ErrPriceUnavailable) {
+			t.Errorf("Expected ErrPriceUnavailable, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"golang.org/x/sync/singleflight"
+)
+
+// TokenListFetcher fetches a chain's wrapped tokens, like universalsdk.SDK.GetWrappedTokens
+type TokenListFetcher func(ctx context.Context, chainID int64) ([]types.Token, error)
+
+// TokenListBatchFetcher fetches every chain's wrapped tokens in one call, like universalsdk.SDK.GetAllWrappedTokens
+type TokenListBatchFetcher func(ctx context.Context) (map[int64][]types.Token, error)
+
+// TokenListCache keeps each chain's wrapped tokens for a TTL, so token lists are served without asking the Universal
+// SDK on every request. Concurrent misses for a chain share one fetch, and StartRefreshing refetches the cached chains
+// before they expire. With a batch fetcher, Warm and Refresh fetch all chains in one call instead of one per chain.
+type TokenListCache struct {
+	fetch    TokenListFetcher
+	fetchAll TokenListBatchFetcher
+	batched  time.Time // When the last batch fetch succeeded
+	ttl      time.Duration
+	timeout  time.Duration
+	entries  map[int64]tokenListEntry
+	fetches  singleflight.Group
+	mu       sync.RWMutex
+	now      func() time.Time
+}
+
+type tokenListEntry struct {
+	tokens    []types.Token
+	fetchedAt time.Time
+}
+
+// NewTokenListCache creates a cache keeping the tokens fetch returns for ttl, giving each fetch up to timeout. A ttl
+// of 0 caches nothing, though concurrent fetches of a chain are still shared.
+func NewTokenListCache(fetch TokenListFetcher, ttl, timeout time.Duration) *TokenListCache {
+	return &TokenListCache{
+		fetch:   fetch,
+		ttl:     ttl,
+		timeout: timeout,
+		entries: make(map[int64]tokenListEntry),
+		now:     time.Now,
+	}
+}
+
+// SetBatchFetcher makes Warm and Refresh fetch the tokens of every chain in one call
+func (c *TokenListCache) SetBatchFetcher(fetchAll TokenListBatchFetcher) {
+	c.fetchAll = fetchAll
+}
+
+// Tokens returns a chain's wrapped tokens, fetching them when they aren't cached or are older than the TTL. The
+// returned slice is shared and must not be modified.
+func (c *TokenListCache) Tokens(ctx context.Context, chainID int64) ([]types.Token, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[chainID]
+	c.mu.RUnlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.tokens, nil
+	}
+	return c.load(ctx, chainID)
+}
+
+// load fetches a chain's tokens, sharing the fetch with concurrent loads of the chain. The fetch outlives a caller
+// that gives up, so the others still get its tokens, but no longer than the cache's timeout.
+func (c *TokenListCache) load(ctx context.Context, chainID int64) ([]types.Token, error) {
+	loaded := c.fetches.DoChan(strconv.FormatInt(chainID, 10), func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		defer cancel()
+		tokens, err := c.fetch(fetchCtx, chainID)
+		if err != nil {
+			return nil, err
+		}
+		if c.ttl > 0 {
+			c.mu.Lock()
+			c.entries[chainID] = tokenListEntry{tokens: tokens, fetchedAt: c.now()}
+			c.mu.Unlock()
+		}
+		return tokens, nil
+	})
+	select {
+	case result := <-loaded:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.([]types.Token), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadAll fetches the tokens of every chain in one call, sharing it with concurrent batch loads, and caches them. Like
+// load, the fetch outlives a caller that gives up, but no longer than the cache's timeout.
+func (c *TokenListCache) loadAll(ctx context.Context) error {
+	loaded := c.fetches.DoChan("all", func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		defer cancel()
+		tokens, err := c.fetchAll(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		if c.ttl > 0 {
+			c.mu.Lock()
+			fetchedAt := c.now()
+			for chainID, chainTokens := range tokens {
+				c.entries[chainID] = tokenListEntry{tokens: chainTokens, fetchedAt: fetchedAt}
+			}
+			c.batched = fetchedAt
+			c.mu.Unlock()
+		}
+		return nil, nil
+	})
+	select {
+	case result := <-loaded:
+		return result.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Warm fetches the tokens of every chain in one call when any of chainIDs isn't cached or has expired, so Tokens then
+// answers them from the cache. Chains the batch leaves out, or all of them when it fails, are still fetched one by one
+// by Tokens; the batch isn't fetched again until its TTL has passed. Without a batch fetcher or a TTL, Warm does
+// nothing.
+func (c *TokenListCache) Warm(ctx context.Context, chainIDs []int64) error {
+	if c.fetchAll == nil || c.ttl <= 0 {
+		return nil
+	}
+	c.mu.RLock()
+	stale := false
+	if c.now().Sub(c.batched) >= c.ttl {
+		for _, chainID := range chainIDs {
+			if entry, ok := c.entries[chainID]; !ok || c.now().Sub(entry.fetchedAt) >= c.ttl {
+				stale = true
+				break
+			}
+		}
+	}
+	c.mu.RUnlock()
+	if !stale {
+		return nil
+	}
+	return c.loadAll(ctx)
+}
+
+// Refresh refetches the tokens of every cached chain, in one call when the cache has a batch fetcher. A chain whose
+// fetch fails keeps its tokens until they expire; a failed batch fetch falls back to fetching each chain.
+func (c *TokenListCache) Refresh(ctx context.Context) error {
+	if c.fetchAll != nil {
+		err := c.loadAll(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to refresh all token lists at once, refreshing each chain: %v", err)
+	}
+
+	c.mu.RLock()
+	chainIDs := make([]int64, 0, len(c.entries))
+	for chainID := range c.entries {
+		chainIDs = append(chainIDs, chainID)
+	}
+	c.mu.RUnlock()
+
+	var errs []error
+	for _, chainID := range chainIDs {
+		if _, err := c.load(ctx, chainID); err != nil {
+			errs = append(errs, fmt.Errorf("chain %d: %w", chainID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StartRefreshing refreshes the cached chains every interval until ctx is done. A cache without a TTL, or an
+// interval of 0, is never refreshed.
+func (c *TokenListCache) StartRefreshing(ctx context.Context, interval time.Duration) {
+	if c.ttl <= 0 || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := c.Refresh(ctx); err != nil {
+				log.Printf("Failed to refresh token lists: %v", err)
+			}
+		}
+	}()
+}
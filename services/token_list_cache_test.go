@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestTokenListCache(t *testing.T) {
+	ctx := context.Background()
+	eth := []types.Token{{Symbol: "uETH", ChainID: 1}}
+
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	fetch := func(ctx context.Context, chainID int64) ([]types.Token, error) {
+		fetches.Add(1)
+		if failing.Load() {
+			return nil, errors.New("sdk unavailable")
+		}
+		return eth, nil
+	}
+	now := time.Now()
+	cache := NewTokenListCache(fetch, time.Minute, time.Second)
+	cache.now = func() time.Time { return now }
+
+	t.Run("ServesCachedTokens", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			tokens, err := cache.Tokens(ctx, 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(tokens) != 1 || tokens[0].Symbol != "uETH" {
+				t.Fatalf("Expected uETH, got %v", tokens)
+			}
+		}
+		if got := fetches.Load(); got != 1 {
+			t.Errorf("Expected 1 fetch, got %d", got)
+		}
+	})
+
+	t.Run("RefreshKeepsTokensOnFailure", func(t *testing.T) {
+		failing.Store(true)
+		if err := cache.Refresh(ctx); err == nil {
+			t.Error("Expected the failed refresh to be reported")
+		}
+		if _, err := cache.Tokens(ctx, 1); err != nil {
+			t.Errorf("Expected the cached tokens to be served, got %v", err)
+		}
+	})
+
+	t.Run("RefetchesExpiredTokens", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if _, err := cache.Tokens(ctx, 1); err == nil {
+			t.Error("Expected the expired tokens to be refetched and fail")
+		}
+		failing.Store(false)
+		if _, err := cache.Tokens(ctx, 1); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestTokenListCacheSharesFetches(t *testing.T) {
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	cache := NewTokenListCache(func(ctx context.Context, chainID int64) ([]types.Token, error) {
+		fetches.Add(1)
+		<-release
+		return []types.Token{{Symbol: "uETH", ChainID: chainID}}, nil
+	}, time.Minute, time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Tokens(context.Background(), 1); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	// Let every caller join the fetch before it returns
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("Expected concurrent misses to share 1 fetch, got %d", got)
+	}
+}
+
+func TestTokenListCacheGivesUpOnHangingFetch(t *testing.T) {
+	cache := NewTokenListCache(func(ctx context.Context, chainID int64) ([]types.Token, error) {
+		time.Sleep(time.Second) // ignores its context
+		return nil, nil
+	}, time.Minute, 2*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if _, err := cache.Tokens(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the caller to give up after its deadline, waited %s", elapsed)
+	}
+}
+
+func TestTokenListCacheBatchFetch(t *testing.T) {
+	ctx := context.Background()
+	var fetches, batches atomic.Int32
+	var batchFailing atomic.Bool
+	cache := NewTokenListCache(func(ctx context.Context, chainID int64) ([]types.Token, error) {
+		fetches.Add(1)
+		return nil, errors.New("chain not supported")
+	}, time.Minute, time.Second)
+	cache.SetBatchFetcher(func(ctx context.Context) (map[int64][]types.Token, error) {
+		batches.Add(1)
+		if batchFailing.Load() {
+			return nil, errors.New("sdk unavailable")
+		}
+		return map[int64][]types.Token{1: {{Symbol: "uETH", ChainID: 1}}, 137: {{Symbol: "uMATIC", ChainID: 137}}}, nil
+	})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	// One batch serves every chain it has, and isn't fetched again for a chain it left out
+	for i := 0; i < 2; i++ {
+		if err := cache.Warm(ctx, []int64{1, 137, 56}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for _, chainID := range []int64{1, 137} {
+		if tokens, err := cache.Tokens(ctx, chainID); err != nil || len(tokens) != 1 {
+			t.Errorf("Expected chain %d's tokens from the batch, got %v, %v", chainID, tokens, err)
+		}
+	}
+	if b, f := batches.Load(), fetches.Load(); b != 1 || f != 0 {
+		t.Errorf("Expected 1 batch and no chain fetches, got %d and %d", b, f)
+	}
+
+	// A failed batch refresh falls back to each chain's fetch
+	batchFailing.Store(true)
+	if err := cache.Refresh(ctx); err == nil {
+		t.Error("Expected the failed chain fetches to be reported")
+	}
+	if b, f := batches.Load(), fetches.Load(); b != 2 || f != 2 {
+		t.Errorf("Expected a second batch, then 2 chain fetches, got %d and %d", b, f)
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// TokenListSource fetches token metadata from a published token list
+type TokenListSource interface {
+	Name() string
+	// FetchTokenList returns the metadata of the list's tokens on the given chains
+	FetchTokenList(ctx context.Context, chainIDs []int64) ([]types.TokenMetadata, error)
+}
+
+// TokenMetadataStore persists token metadata
+type TokenMetadataStore interface {
+	// SaveTokenMetadata stores token metadata, replacing earlier metadata of the same tokens
+	SaveTokenMetadata(ctx context.Context, metadata []types.TokenMetadata) error
+	// FindTokenMetadata returns the metadata of tokens on chainID with any of the symbols, ignoring case
+	FindTokenMetadata(ctx context.Context, chainID int64, symbols []string) ([]types.TokenMetadata, error)
+}
+
+// TokenMetadataService gathers token metadata from token lists and enriches tokens with it
+type TokenMetadataService struct {
+	store   TokenMetadataStore
+	sources []TokenListSource // In priority order; earlier sources win when lists disagree
+}
+
+// NewTokenMetadataService creates a token metadata service refreshing from sources in priority order
+func NewTokenMetadataService(store TokenMetadataStore, sources ...TokenListSource) *TokenMetadataService {
+	return &TokenMetadataService{
+		store:   store,
+		sources: sources,
+	}
+}
+
+// Refresh fetches the token lists, merges them and stores the result.
+// It fails only when no source could be fetched, and returns how many tokens were stored.
+func (s *TokenMetadataService) Refresh(ctx context.Context, chainIDs []int64) (int, error) {
+	merged := make(map[string]*types.TokenMetadata)
+	var order []string
+	var errs []error
+	fetched := 0
+	now := time.Now()
+
+	for _, source := range s.sources {
+		list, err := source.FetchTokenList(ctx, chainIDs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			continue
+		}
+		fetched++
+
+		for _, metadata := range list {
+			if metadata.Address == "" || metadata.Symbol == "" {
+				continue
+			}
+			metadata.ChainID = types.CanonicalChainID(metadata.ChainID)
+			metadata.Address = types.NormalizeTokenAddress(metadata.ChainID, metadata.Address)
+			key := fmt.Sprintf("%d:%s", metadata.ChainID, metadata.Address)
+
+			existing, ok := merged[key]
+			if !ok {
+				metadata.Sources = []string{source.Name()}
+				metadata.UpdatedAt = now
+				merged[key] = &metadata
+				order = append(order, key)
+				continue
+			}
+			mergeTokenMetadata(existing, metadata, source.Name())
+		}
+	}
+
+	if fetched == 0 && len(s.sources) > 0 {
+		return 0, fmt.Errorf("failed to fetch any token list: %w", errors.Join(errs...))
+	}
+
+	result := make([]types.TokenMetadata, 0, len(order))
+	for _, key := range order {
+		result = append(result, *merged[key])
+	}
+	if err := s.store.SaveTokenMetadata(ctx, result); err != nil {
+		return 0, fmt.Errorf("failed to save token metadata: %w", err)
+	}
+	return len(result), nil
+}
+
+// mergeTokenMetadata fills the fields existing lacks from a lower-priority list's metadata of the same token
+func mergeTokenMetadata(existing *types.TokenMetadata, metadata types.TokenMetadata, source string) {
+	if existing.Name == "" {
+		existing.Name = metadata.Name
+	}
+	if existing.Decimals == 0 {
+		existing.Decimals = metadata.Decimals
+	}
+	if existing.LogoURI == "" {
+		existing.LogoURI = metadata.LogoURI
+	}
+	if existing.CoinGeckoID == "" {
+		existing.CoinGeckoID = metadata.CoinGeckoID
+	}
+	existing.Verified = existing.Verified || metadata.Verified
+	existing.Sources = append(existing.Sources, source)
+}
+
+// Enrich adds metadata and USD prices to tokens. Wrapped tokens use the metadata and price of their underlying token.
+// Tokens without metadata or a price are returned as they are.
+func (s *TokenMetadataService) Enrich(ctx context.Context, tokens []types.Token, prices []types.TokenPrice) ([]types.TokenDetails, error) {
+	candidates, err := s.candidates(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.TokenDetails, 0, len(tokens))
+	for _, token := range tokens {
+		details := types.TokenDetails{Token: token}
+		if metadata := bestTokenMetadata(token, candidates[token.ChainID]); metadata != nil {
+			if details.LogoURI == "" {
+				details.LogoURI = metadata.LogoURI
+			}
+			if details.Decimals == 0 && !token.IsWrapped {
+				details.Decimals = metadata.Decimals
+			}
+			details.CoinGeckoID = metadata.CoinGeckoID
+			details.Verified = metadata.Verified
+		}
+		details.PriceUSD = tokenPriceUSD(prices, token.UnderlyingSymbol(), token.ChainID)
+		result = append(result, details)
+	}
+	return result, nil
+}
+
+// Resolve fills in the addresses and decimals tokens are missing from their metadata.
+// Tokens without metadata are returned as they are.
+func (s *TokenMetadataService) Resolve(ctx context.Context, tokens []types.Token) ([]types.Token, error) {
+	candidates, err := s.candidates(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if metadata := bestTokenMetadata(token, candidates[token.ChainID]); metadata != nil && !token.IsWrapped {
+			if token.Address == "" {
+				token.Address = metadata.Address
+			}
+			if token.Decimals == 0 {
+				token.Decimals = metadata.Decimals
+			}
+		}
+		result = append(result, token)
+	}
+	return result, nil
+}
+
+// candidates looks up the metadata sharing a symbol with any of the tokens, one chain at a time
+func (s *TokenMetadataService) candidates(ctx context.Context, tokens []types.Token) (map[int64][]types.TokenMetadata, error) {
+	symbolsByChain := make(map[int64][]string)
+	for _, token := range tokens {
+		symbolsByChain[token.ChainID] = append(symbolsByChain[token.ChainID], token.UnderlyingSymbol())
+	}
+	candidates := make(map[int64][]types.TokenMetadata)
+	for chainID, symbols := range symbolsByChain {
+		found, err := s.store.FindTokenMetadata(ctx, chainID, symbols)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find token metadata: %w", err)
+		}
+		candidates[chainID] = found
+	}
+	return candidates, nil
+}
+
+// bestTokenMetadata picks the metadata of token among the candidates sharing its symbol.
+// An address match wins; otherwise verified tokens, then tokens with a CoinGecko ID, are preferred.
+func bestTokenMetadata(token types.Token, candidates []types.TokenMetadata) *types.TokenMetadata {
+	symbol := token.UnderlyingSymbol()
+	address := types.NormalizeTokenAddress(token.ChainID, token.Address)
+
+	var best *types.TokenMetadata
+	for i := range candidates {
+		candidate := &candidates[i]
+		if !strings.EqualFold(candidate.Symbol, symbol) {
+			continue
+		}
+		if address != "" && candidate.Address == address {
+			return candidate
+		}
+		if best == nil || tokenMetadataRank(*candidate) > tokenMetadataRank(*best) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// tokenMetadataRank ranks how trustworthy a symbol match is
+func tokenMetadataRank(metadata types.TokenMetadata) int {
+	rank := 0
+	if metadata.Verified {
+		rank += 2
+	}
+	if metadata.CoinGeckoID != "" {
+		rank++
+	}
+	return rank
+}
+
+// tokenPriceUSD returns a token's price on chainID, falling back to its price on any chain
+func tokenPriceUSD(prices []types.TokenPrice, symbol string, chainID int64) float64 {
+	var fallback float64
+	for _, price := range prices {
+		if !strings.EqualFold(price.Symbol, symbol) || price.PriceUSD <= 0 {
+			continue
+		}
+		if price.ChainID == chainID {
+			return price.PriceUSD
+		}
+		if fallback == 0 {
+			fallback = price.PriceUSD
+		}
+	}
+	return fallback
+}
+
+// InMemoryTokenMetadataStore is a TokenMetadataStore for running without a database
+type InMemoryTokenMetadataStore struct {
+	metadata map[string]types.TokenMetadata // map["chainID:address"]TokenMetadata
+	mu       sync.RWMutex
+}
+
+// NewInMemoryTokenMetadataStore creates an empty in-memory token metadata store
+func NewInMemoryTokenMetadataStore() *InMemoryTokenMetadataStore {
+	return &InMemoryTokenMetadataStore{
+		metadata: make(map[string]types.TokenMetadata),
+	}
+}
+
+// SaveTokenMetadata stores token metadata
+func (s *InMemoryTokenMetadataStore) SaveTokenMetadata(ctx context.Context, metadata []types.TokenMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range metadata {
+		s.metadata[fmt.Sprintf("%d:%s", m.ChainID, m.Address)] = m
+	}
+	return nil
+}
+
+// FindTokenMetadata returns the metadata of tokens on chainID with any of the symbols, ordered by address
+func (s *InMemoryTokenMetadataStore) FindTokenMetadata(ctx context.Context, chainID int64, symbols []string) ([]types.TokenMetadata, error) {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[strings.ToUpper(symbol)] = true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []types.TokenMetadata
+	for _, m := range s.metadata {
+		if m.ChainID == chainID && wanted[strings.ToUpper(m.Symbol)] {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address < result[j].Address
+	})
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// fakeTokenList serves a fixed token list
+type fakeTokenList struct {
+	name   string
+	tokens []types.TokenMetadata
+	err    error
+}
+
+func (f *fakeTokenList) Name() string {
+	return f.name
+}
+
+func (f *fakeTokenList) FetchTokenList(ctx context.Context, chainIDs []int64) ([]types.TokenMetadata, error) {
+	return f.tokens, f.err
+}
+
+func TestTokenMetadataService(t *testing.T) {
+	ctx := context.Background()
+	const usdc = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+	t.Run("merges token lists in priority order", func(t *testing.T) {
+		store := NewInMemoryTokenMetadataStore()
+		service := NewTokenMetadataService(store,
+			&fakeTokenList{name: "coingecko", tokens: []types.TokenMetadata{
+				{ChainID: 1, Address: usdc, Symbol: "USDC", Name: "USD Coin", Decimals: 6, CoinGeckoID: "usd-coin"},
+			}},
+			&fakeTokenList{name: "other", tokens: []types.TokenMetadata{
+				{ChainID: 1, Address: usdc, Symbol: "USDC", Name: "USDC", Decimals: 6, LogoURI: "https://assets.example.com/usdc.png", Verified: true},
+				{ChainID: 1, Symbol: "NOADDR", Name: "No Address"},
+			}},
+		)
+
+		count, err := service.Refresh(ctx, []int64{1})
+		if err != nil {
+			t.Fatalf("Failed to refresh: %v", err)
+		}
+		if count != 1 {
+			t.Fatalf("Expected 1 token, got %d", count)
+		}
+
+		found, err := store.FindTokenMetadata(ctx, 1, []string{"usdc"})
+		if err != nil || len(found) != 1 {
+			t.Fatalf("Expected USDC metadata, got %v (%v)", found, err)
+		}
+		metadata := found[0]
+		if metadata.Address != "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" {
+			t.Errorf("Expected a lowercase address, got %s", metadata.Address)
+		}
+		if metadata.Name != "USD Coin" || metadata.CoinGeckoID != "usd-coin" {
+			t.Errorf("Expected the first list's fields to win, got %+v", metadata)
+		}
+		if metadata.LogoURI != "https://assets.example.com/usdc.png" || !metadata.Verified {
+			t.Errorf("Expected missing fields from the second list, got %+v", metadata)
+		}
+		if len(metadata.Sources) != 2 {
+			t.Errorf("Expected both sources, got %v", metadata.Sources)
+		}
+	})
+
+	t.Run("skips failing token lists", func(t *testing.T) {
+		service := NewTokenMetadataService(NewInMemoryTokenMetadataStore(),
+			&fakeTokenList{name: "broken", err: errors.New("unavailable")},
+			&fakeTokenList{name: "other", tokens: []types.TokenMetadata{{ChainID: 1, Address: usdc, Symbol: "USDC"}}},
+		)
+		if count, err := service.Refresh(ctx, []int64{1}); err != nil || count != 1 {
+			t.Errorf("Expected the working list to be stored, got %d (%v)", count, err)
+		}
+
+		service = NewTokenMetadataService(NewInMemoryTokenMetadataStore(),
+			&fakeTokenList{name: "broken", err: errors.New("unavailable")},
+		)
+		if _, err := service.Refresh(ctx, []int64{1}); err == nil {
+			t.Error("Expected an error when every list fails")
+		}
+	})
+
+	t.Run("enriches wrapped tokens with their underlying token", func(t *testing.T) {
+		store := NewInMemoryTokenMetadataStore()
+		store.SaveTokenMetadata(ctx, []types.TokenMetadata{
+			{ChainID: 1, Address: "0x1111111111111111111111111111111111111111", Symbol: "USDC", LogoURI: "https://assets.example.com/fake.png"},
+			{ChainID: 1, Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Symbol: "USDC", LogoURI: "https://assets.example.com/usdc.png", CoinGeckoID: "usd-coin", Verified: true},
+		})
+		service := NewTokenMetadataService(store)
+
+		tokens := []types.Token{
+			{Symbol: "uUSDC", ChainID: 1, Decimals: 18, IsWrapped: true},
+			{Symbol: "USDC", ChainID: 1, Address: "0x1111111111111111111111111111111111111111"},
+			{Symbol: "UNKNOWN", ChainID: 1},
+		}
+		prices := []types.TokenPrice{{Symbol: "usdc", ChainID: 10, PriceUSD: 0.999}}
+
+		details, err := service.Enrich(ctx, tokens, prices)
+		if err != nil {
+			t.Fatalf("Failed to enrich: %v", err)
+		}
+		if details[0].LogoURI != "https://assets.example.com/usdc.png" || !details[0].Verified || details[0].Decimals != 18 {
+			t.Errorf("Expected the verified USDC metadata with the wrapped decimals, got %+v", details[0])
+		}
+		if details[0].PriceUSD != 0.999 {
+			t.Errorf("Expected the price from another chain, got %f", details[0].PriceUSD)
+		}
+		if details[1].LogoURI != "https://assets.example.com/fake.png" {
+			t.Errorf("Expected the address match to win, got %+v", details[1])
+		}
+		if details[2].LogoURI != "" || details[2].PriceUSD != 0 {
+			t.Errorf("Expected an unknown token unchanged, got %+v", details[2])
+		}
+	})
+}
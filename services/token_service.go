@@ -32,10 +32,23 @@ func (s *TokenService) AddToken(token types.Token) error {
 		return errors.New("token already exists")
 	}
 
+	token.ChainID = types.CanonicalChainID(token.ChainID)
 	s.tokens[token.Symbol] = token
 	return nil
 }
 
+// UpsertToken adds a token or replaces the existing token with the same symbol.
+// It reports whether the token was added rather than replaced.
+func (s *TokenService) UpsertToken(token types.Token) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.tokens[token.Symbol]
+	token.ChainID = types.CanonicalChainID(token.ChainID)
+	s.tokens[token.Symbol] = token
+	return !exists
+}
+
 // GetToken retrieves a token by its symbol
 func (s *TokenService) GetToken(symbol string) (types.Token, error) {
 	s.mu.RLock()
@@ -54,6 +67,7 @@ func (s *TokenService) GetTokensByChain(chainID int64) []types.Token {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	chainID = types.CanonicalChainID(chainID)
 	var result []types.Token
 	for _, token := range s.tokens {
 		if token.ChainID == chainID {
@@ -72,6 +72,20 @@ func TestTokenService(t *testing.T) {
 		}
 	})
 
+	// Test UpsertToken
+	t.Run("UpsertToken", func(t *testing.T) {
+		updated := wrappedEthToken
+		updated.Name = "Universal Ether"
+		if service.UpsertToken(updated) {
+			t.Error("Expected existing uETH token to be replaced, not added")
+		}
+		token, err := service.GetToken("uETH")
+		if err != nil || token.Name != "Universal Ether" {
+			t.Errorf("Expected updated uETH token, got %+v (err %v)", token, err)
+		}
+		service.UpsertToken(wrappedEthToken)
+	})
+
 	// Test GetToken
 	t.Run("GetToken", func(t *testing.T) {
 		token, err := service.GetToken("ETH")
@@ -61,6 +61,9 @@ func (s *TransactionService) GetTransaction(ctx context.Context, txID string) (*
 	return &tx, nil
 }
 
+// ErrNoWorkflowTransactions is returned when a workflow has recorded no transactions
+var ErrNoWorkflowTransactions = errors.New("no transactions found for workflow")
+
 // GetTransactionsByWorkflowID retrieves all transactions for a specific workflow
 func (s *TransactionService) GetTransactionsByWorkflowID(ctx context.Context, workflowID string) ([]types.Transaction, error) {
 	s.mu.RLock()
@@ -74,7 +77,7 @@ func (s *TransactionService) GetTransactionsByWorkflowID(ctx context.Context, wo
 	}
 
 	if len(result) == 0 {
-		return nil, errors.New("no transactions found for workflow")
+		return nil, ErrNoWorkflowTransactions
 	}
 
 	return result, nil
@@ -0,0 +1,55 @@
+package types
+
+import "math/big"
+
+// Contracts an allowance is granted to, by the operation that spends the token
+const (
+	AllowanceForWrap = "wrap" // The chain's Universal contract, which wraps tokens
+	AllowanceForSwap = "swap" // The chain's DEX contract, which swaps tokens
+)
+
+// Outcomes of an allowance check
+const (
+	AllowanceSkipped    = "skipped"    // The operation isn't executed on-chain, or spends the native token
+	AllowanceSufficient = "sufficient" // The contract could already spend the amount
+	AllowanceApproved   = "approved"   // An approve transaction raised the allowance
+)
+
+// AllowanceRequest asks for the executing account to let the contract carrying out an operation spend amount of
+// token before the operation runs
+type AllowanceRequest struct {
+	RequestID string   `json:"requestId"` // The wrap or swap the allowance is for
+	Operation string   `json:"operation"` // wrap or swap
+	Token     Token    `json:"token"`
+	Amount    *big.Int `json:"amount"`
+}
+
+// AllowanceResult is the outcome of an allowance check
+type AllowanceResult struct {
+	Status      string       `json:"status"` // skipped, sufficient or approved
+	Spender     string       `json:"spender,omitempty"`
+	Allowance   *big.Int     `json:"allowance,omitempty"`   // What the contract may spend after the check
+	Transaction *Transaction `json:"transaction,omitempty"` // The approve transaction, when one was sent
+}
+
+// Kinds of signed permits
+const (
+	PermitEIP2612 = "eip2612" // The token's own permit function
+	PermitPermit2 = "permit2" // A signature transfer through the canonical Permit2 contract
+)
+
+// Permit2Address is the canonical Permit2 contract, deployed at the same address on every EVM chain
+const Permit2Address = "0x000000000022D473030F116dDEE9F6B43aC78BA3"
+
+// Permit is a token owner's EIP-712 signature letting Spender transfer up to Value of a token until Deadline,
+// without an approve transaction
+type Permit struct {
+	Kind      string   `json:"kind"` // eip2612 or permit2
+	Owner     string   `json:"owner"`
+	Spender   string   `json:"spender"`
+	Value     *big.Int `json:"value"`
+	Nonce     *big.Int `json:"nonce"`
+	Deadline  int64    `json:"deadline"`          // Unix seconds
+	Version   string   `json:"version,omitempty"` // EIP-2612 domain version of the token; defaults to "1"
+	Signature string   `json:"signature"`         // 0x-prefixed 65-byte signature
+}
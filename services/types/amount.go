@@ -0,0 +1,220 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode decides which way an amount that doesn't fit its decimals is rounded
+type RoundingMode int
+
+// Rounding modes
+const (
+	RoundDown     RoundingMode = iota // Toward zero; what the swap pays out, so it never pays more than it has
+	RoundUp                           // Away from zero; what the swap charges, so it never charges less than it costs
+	RoundHalfUp                       // To the nearest, halves away from zero
+	RoundHalfEven                     // To the nearest, halves to the even neighbour
+)
+
+// Amount is an amount of a token in base units with the token's decimals, so it can be converted and formatted
+// without going through floats
+type Amount struct {
+	Value    *big.Int
+	Decimals int
+}
+
+// NewAmount creates an amount of value base units of a token with decimals
+func NewAmount(value *big.Int, decimals int) Amount {
+	return Amount{Value: value, Decimals: decimals}
+}
+
+// ParseAmount parses a decimal string such as "1.5" into base units of a token with decimals, failing when it has
+// more decimal places than the token
+func ParseAmount(value string, decimals int) (Amount, error) {
+	if decimals < 0 {
+		return Amount{}, errors.New("decimals must not be negative")
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Amount{}, errors.New("empty amount")
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return Amount{}, fmt.Errorf("invalid amount: %q", value)
+	}
+	if len(fraction) > decimals {
+		return Amount{}, fmt.Errorf("amount %q has more than %d decimal places", value, decimals)
+	}
+	for _, c := range whole + fraction {
+		if c < '0' || c > '9' {
+			return Amount{}, fmt.Errorf("invalid amount: %q", value)
+		}
+	}
+
+	digits := whole + fraction + strings.Repeat("0", decimals-len(fraction))
+	result, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount: %q", value)
+	}
+
+	return Amount{Value: result, Decimals: decimals}, nil
+}
+
+// AmountFromRat converts a number of whole tokens to base units of a token with decimals, rounding with mode
+func AmountFromRat(value *big.Rat, decimals int, mode RoundingMode) Amount {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10(decimals)))
+	return Amount{Value: roundRat(scaled, mode), Decimals: decimals}
+}
+
+// AmountFromFloat converts a number of whole tokens to base units of a token with decimals, rounding with mode.
+// The float is taken at its exact binary value; NaN and infinities are zero.
+func AmountFromFloat(value float64, decimals int, mode RoundingMode) Amount {
+	return AmountFromRat(RatFromFloat(value), decimals, mode)
+}
+
+// Rat returns the amount in whole tokens
+func (a Amount) Rat() *big.Rat {
+	if a.Value == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFrac(a.Value, pow10(a.Decimals))
+}
+
+// Float64 returns the amount in whole tokens, as near as a float gets
+func (a Amount) Float64() float64 {
+	value, _ := a.Rat().Float64()
+	return value
+}
+
+// Rescale converts the amount to base units of decimals, rounding with mode when it loses decimal places
+func (a Amount) Rescale(decimals int, mode RoundingMode) Amount {
+	return AmountFromRat(a.Rat(), decimals, mode)
+}
+
+// Mul multiplies the amount by factor, rounding the result to the amount's decimals with mode
+func (a Amount) Mul(factor *big.Rat, mode RoundingMode) Amount {
+	return a.Convert(factor, a.Decimals, mode)
+}
+
+// MulFloat multiplies the amount by factor, rounding the result to the amount's decimals with mode
+func (a Amount) MulFloat(factor float64, mode RoundingMode) Amount {
+	return a.Mul(RatFromFloat(factor), mode)
+}
+
+// Convert converts the amount into another token at rate of the other token per token, in base units of decimals
+// rounded with mode
+func (a Amount) Convert(rate *big.Rat, decimals int, mode RoundingMode) Amount {
+	return AmountFromRat(new(big.Rat).Mul(a.Rat(), rate), decimals, mode)
+}
+
+// String formats the amount in whole tokens without trailing zeros, such as "1.5"
+func (a Amount) String() string {
+	if a.Value == nil {
+		return "0"
+	}
+
+	digits := new(big.Int).Abs(a.Value).String()
+	sign := ""
+	if a.Value.Sign() < 0 {
+		sign = "-"
+	}
+	if a.Decimals <= 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= a.Decimals {
+		digits = strings.Repeat("0", a.Decimals-len(digits)+1) + digits
+	}
+
+	whole := digits[:len(digits)-a.Decimals]
+	fraction := strings.TrimRight(digits[len(digits)-a.Decimals:], "0")
+	if fraction == "" {
+		return sign + whole
+	}
+
+	return sign + whole + "." + fraction
+}
+
+// amountJSON is the JSON form of an amount: its base units as a string, to survive JSON number precision, its
+// decimals, and the amount formatted in whole tokens
+type amountJSON struct {
+	Value     string `json:"value"`
+	Decimals  int    `json:"decimals"`
+	Formatted string `json:"formatted"`
+}
+
+// MarshalJSON encodes the amount with its base units, decimals and formatted value
+func (a Amount) MarshalJSON() ([]byte, error) {
+	value := "0"
+	if a.Value != nil {
+		value = a.Value.String()
+	}
+	return json.Marshal(amountJSON{Value: value, Decimals: a.Decimals, Formatted: a.String()})
+}
+
+// UnmarshalJSON decodes an amount from its base units and decimals; the formatted value is ignored
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var decoded amountJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	value, ok := new(big.Int).SetString(decoded.Value, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount value %q", decoded.Value)
+	}
+	if decoded.Decimals < 0 {
+		return errors.New("decimals must not be negative")
+	}
+	*a = Amount{Value: value, Decimals: decoded.Decimals}
+	return nil
+}
+
+// roundRat rounds value to an integer with mode
+func roundRat(value *big.Rat, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// Step one away from zero when rounding says so; the quotient was truncated toward zero
+	away := false
+	switch mode {
+	case RoundUp:
+		away = true
+	case RoundHalfUp, RoundHalfEven:
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+		switch twice.Cmp(value.Denom()) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == RoundHalfUp || quotient.Bit(0) == 1
+		}
+	}
+	if away {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return quotient
+}
+
+// RatFromFloat returns the exact value of a float, such as a price, or zero for NaN and infinities
+func RatFromFloat(value float64) *big.Rat {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFloat64(value)
+}
+
+// pow10 returns 10^n, and 1 for negative n
+func pow10(n int) *big.Int {
+	if n <= 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
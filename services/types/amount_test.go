@@ -0,0 +1,121 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	amount, err := ParseAmount("1.5", 6)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if amount.Value.Cmp(big.NewInt(1500000)) != 0 || amount.Decimals != 6 {
+		t.Errorf("Expected 1500000 base units with 6 decimals, got %s with %d", amount.Value, amount.Decimals)
+	}
+	if amount.String() != "1.5" {
+		t.Errorf("Expected 1.5, got %s", amount)
+	}
+	for _, invalid := range []string{"", ".", "1.2345678", "-1", "1e6", "1,5"} {
+		if _, err := ParseAmount(invalid, 6); err == nil {
+			t.Errorf("Expected %q to be refused", invalid)
+		}
+	}
+
+	for _, tt := range []struct {
+		value    int64
+		decimals int
+		expected string
+	}{
+		{1, 18, "0.000000000000000001"},
+		{1000000, 6, "1"},
+		{-2500, 3, "-2.5"},
+		{42, 0, "42"},
+	} {
+		if got := NewAmount(big.NewInt(tt.value), tt.decimals).String(); got != tt.expected {
+			t.Errorf("Expected %d with %d decimals to format as %s, got %s", tt.value, tt.decimals, tt.expected, got)
+		}
+	}
+}
+
+func TestAmountRounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int64 // With 2 decimals, rescaled to 1
+		mode     RoundingMode
+		expected int64
+	}{
+		{"DownBelowHalf", 124, RoundDown, 12},
+		{"DownAboveHalf", 128, RoundDown, 12},
+		{"UpBelowHalf", 121, RoundUp, 13},
+		{"UpExact", 120, RoundUp, 12},
+		{"HalfUpAtHalf", 125, RoundHalfUp, 13},
+		{"HalfUpBelowHalf", 124, RoundHalfUp, 12},
+		{"HalfEvenToEven", 125, RoundHalfEven, 12},
+		{"HalfEvenFromOdd", 135, RoundHalfEven, 14},
+		{"HalfEvenAboveHalf", 126, RoundHalfEven, 13},
+		{"NegativeDown", -128, RoundDown, -12},
+		{"NegativeUp", -121, RoundUp, -13},
+		{"NegativeHalfUp", -125, RoundHalfUp, -13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewAmount(big.NewInt(tt.value), 2).Rescale(1, tt.mode)
+			if got.Value.Int64() != tt.expected || got.Decimals != 1 {
+				t.Errorf("Expected %d with 1 decimal, got %s with %d", tt.expected, got.Value, got.Decimals)
+			}
+		})
+	}
+}
+
+func TestAmountConvert(t *testing.T) {
+	// 1 ETH at 2000 USDC per ETH is exactly 2000 USDC, without float error creeping into the base units
+	eth := NewAmount(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil), 18)
+	if usdc := eth.Convert(big.NewRat(2000, 1), 6, RoundDown); usdc.Value.Cmp(big.NewInt(2000000000)) != 0 {
+		t.Errorf("Expected 2000000000 USDC base units, got %s", usdc.Value)
+	}
+
+	// A third of a USDC base unit is dropped paying out and charged in full charging
+	third := NewAmount(big.NewInt(1), 6)
+	if got := third.Mul(big.NewRat(1, 3), RoundDown); got.Value.Sign() != 0 {
+		t.Errorf("Expected a third of a base unit to round down to 0, got %s", got.Value)
+	}
+	if got := third.Mul(big.NewRat(1, 3), RoundUp); got.Value.Int64() != 1 {
+		t.Errorf("Expected a third of a base unit to round up to 1, got %s", got.Value)
+	}
+
+	// Amounts past float precision convert exactly
+	large, _ := new(big.Int).SetString("123456789012345678901234567", 10)
+	if got := NewAmount(large, 18).MulFloat(2, RoundDown); got.Value.Cmp(new(big.Int).Mul(large, big.NewInt(2))) != 0 {
+		t.Errorf("Expected an exact double of %s, got %s", large, got.Value)
+	}
+
+	if got := AmountFromFloat(0.5, 6, RoundDown); got.Value.Int64() != 500000 {
+		t.Errorf("Expected 0.5 to be 500000 base units, got %s", got.Value)
+	}
+	if got := NewAmount(big.NewInt(1500000), 6).Float64(); got != 1.5 {
+		t.Errorf("Expected 1.5, got %v", got)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	encoded, err := json.Marshal(NewAmount(big.NewInt(1500000), 6))
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	if string(encoded) != `{"value":"1500000","decimals":6,"formatted":"1.5"}` {
+		t.Errorf("Unexpected encoding %s", encoded)
+	}
+
+	var decoded Amount
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if decoded.Value.Cmp(big.NewInt(1500000)) != 0 || decoded.Decimals != 6 {
+		t.Errorf("Expected the amount back, got %s with %d decimals", decoded.Value, decoded.Decimals)
+	}
+	if err := json.Unmarshal([]byte(`{"value":"1.5","decimals":6}`), &decoded); err == nil {
+		t.Error("Expected a fractional value to be refused")
+	}
+}
@@ -0,0 +1,64 @@
+package types
+
+import "time"
+
+// SwapRecord is a finished swap as swap analytics count it
+type SwapRecord struct {
+	RequestID          string    `json:"requestId"`
+	Pair               string    `json:"pair"` // Source and destination token symbols, e.g. "uETH/USDC"
+	SourceChainID      int64     `json:"sourceChainId"`
+	DestinationChainID int64     `json:"destinationChainId"`
+	Success            bool      `json:"success"`
+	VolumeUSD          float64   `json:"volumeUSD"` // Input value of successful swaps; 0 for failed swaps and unpriced tokens
+	FeeUSD             float64   `json:"feeUSD"`    // Fees of successful swaps
+	FinishedAt         time.Time `json:"finishedAt"`
+}
+
+// SwapTotals are the totals of the swaps of one pair between two chains that finished in a window
+type SwapTotals struct {
+	Pair               string  `json:"pair"`
+	SourceChainID      int64   `json:"sourceChainId"`
+	DestinationChainID int64   `json:"destinationChainId"`
+	Swaps              int64   `json:"swaps"`
+	Succeeded          int64   `json:"succeeded"`
+	VolumeUSD          float64 `json:"volumeUSD"`
+	FeesUSD            float64 `json:"feesUSD"`
+}
+
+// SwapStatsSummary summarizes the swaps that finished in a window
+type SwapStatsSummary struct {
+	Swaps         int64   `json:"swaps"`
+	Succeeded     int64   `json:"succeeded"`
+	SuccessRate   float64 `json:"successRate"` // 0-1; 0 without swaps
+	VolumeUSD     float64 `json:"volumeUSD"`
+	AverageFeeUSD float64 `json:"averageFeeUSD"` // Per successful swap
+}
+
+// PairSwapStats summarizes a pair's swaps in a window, on every chain
+type PairSwapStats struct {
+	Pair string `json:"pair"`
+	SwapStatsSummary
+}
+
+// ChainSwapStats summarizes the swaps from a chain in a window
+type ChainSwapStats struct {
+	ChainID   int64  `json:"chainId"`
+	ChainName string `json:"chainName"`
+	SwapStatsSummary
+}
+
+// SwapStatsWindow summarizes the swaps that finished in a window, overall, per pair and per source chain, each
+// ordered by volume
+type SwapStatsWindow struct {
+	Window string    `json:"window"` // e.g. "24h"
+	Since  time.Time `json:"since"`
+	SwapStatsSummary
+	Pairs  []PairSwapStats  `json:"pairs"`
+	Chains []ChainSwapStats `json:"chains"`
+}
+
+// SwapStats are the swap statistics of each window
+type SwapStats struct {
+	Windows     []SwapStatsWindow `json:"windows"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+}
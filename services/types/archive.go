@@ -0,0 +1,36 @@
+package types
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrArchivedSwapNotFound is returned when a swap is not in the archive
+var ErrArchivedSwapNotFound = errors.New("archived swap not found")
+
+// ArchivedSwap is a finished swap kept after Temporal's history retention deletes its workflow
+type ArchivedSwap struct {
+	RequestID  string      `json:"requestId"`
+	WorkflowID string      `json:"workflowId"`
+	RunID      string      `json:"runId"`
+	Request    SwapRequest `json:"request"`
+	Result     SwapResult  `json:"result"`
+	StartedAt  time.Time   `json:"startedAt"`
+	ClosedAt   time.Time   `json:"closedAt"`
+	ArchivedAt time.Time   `json:"archivedAt"`
+}
+
+// Involves reports whether address sent or received the swap. EVM addresses match in any letter case, since
+// their checksum is only in the case.
+func (s ArchivedSwap) Involves(address string) bool {
+	return sameAddress(s.Request.SourceAddress, address) || sameAddress(s.Request.DestinationAddress, address)
+}
+
+// sameAddress reports whether two addresses are the same account
+func sameAddress(a, b string) bool {
+	if strings.HasPrefix(a, "0x") && strings.HasPrefix(b, "0x") {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
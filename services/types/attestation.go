@@ -0,0 +1,65 @@
+package types
+
+import "time"
+
+// AttestationVersion is the version of the swap attestation format
+const AttestationVersion = 2
+
+// SwapAttestation packages a completed swap's execution so downstream systems can build
+// verifiable attestations for audits. Steps are merkleized, and the server signs the whole document.
+type SwapAttestation struct {
+	Version   int    `json:"version"`
+	RequestID string `json:"requestId"`
+
+	// Inputs of the swap
+	SourceToken   Token  `json:"sourceToken"`
+	InputAmount   string `json:"inputAmount"` // Base units, decimal string
+	SourceAddress string `json:"sourceAddress"`
+
+	// Outputs of the swap
+	DestinationToken   Token   `json:"destinationToken"`
+	OutputAmount       string  `json:"outputAmount"` // Base units, decimal string
+	DestinationAddress string  `json:"destinationAddress"`
+	FeeUSD             float64 `json:"feeUsd"`
+
+	TxHashes []string          `json:"txHashes"` // Execution order
+	Steps    []AttestationStep `json:"steps"`
+
+	// MerkleRoot commits to the steps' leaf hashes; see HashAlgorithm for how leaves and nodes are hashed
+	MerkleRoot    string `json:"merkleRoot"`
+	HashAlgorithm string `json:"hashAlgorithm"`
+
+	CompletedAt time.Time `json:"completedAt"`
+	IssuedAt    time.Time `json:"issuedAt"`
+
+	// Server signature over the attestation's canonical JSON encoding with Signature empty
+	SignatureAlgorithm string `json:"signatureAlgorithm"`
+	Canonicalization   string `json:"canonicalization"` // How the attestation and its steps are encoded for hashing and signing
+	PublicKey          string `json:"publicKey"`        // Hex; identifies the signing key, verifiers must check it against the server's
+	Signature          string `json:"signature"`        // Hex
+}
+
+// AttestationStepData is one execution step of a swap; its canonical JSON encoding is the merkle leaf
+type AttestationStepData struct {
+	Index     int       `json:"index"`
+	Name      string    `json:"name"` // source, bridge or destination
+	TxHash    string    `json:"txHash"`
+	Chain     string    `json:"chain"`
+	Token     Token     `json:"token"`
+	Amount    string    `json:"amount"` // Base units, decimal string
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AttestationStep is a step with its leaf hash and the proof of its inclusion under the merkle root
+type AttestationStep struct {
+	Data     AttestationStepData `json:"data"`
+	LeafHash string              `json:"leafHash"` // Hex
+	Proof    []MerkleProofNode   `json:"proof"`
+}
+
+// MerkleProofNode is a sibling hash on the path from a leaf to the merkle root
+type MerkleProofNode struct {
+	Hash string `json:"hash"` // Hex
+	Left bool   `json:"left"` // The sibling is hashed on the left
+}
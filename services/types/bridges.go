@@ -0,0 +1,17 @@
+package types
+
+import (
+	"math/big"
+	"time"
+)
+
+// BridgeOutcome is the result of one swap's transfer through a bridge
+type BridgeOutcome struct {
+	RequestID string        `json:"requestId"` // Swap the transfer carried; each swap has one outcome
+	Bridge    string        `json:"bridge"`
+	Success   bool          `json:"success"`
+	Duration  time.Duration `json:"duration"`            // Time from submission to settlement
+	QuotedFee *big.Int      `json:"quotedFee,omitempty"` // Bridge fee quoted to the user
+	ActualFee *big.Int      `json:"actualFee,omitempty"` // Bridge fee charged; nil when the swap failed before charging one
+	At        time.Time     `json:"at"`
+}
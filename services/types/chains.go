@@ -0,0 +1,132 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonical chain IDs. EVM chains use their EIP-155 ID; Solana has none, so it uses
+// the widely adopted 1399811149 rather than the placeholder 999 some price sources used.
+const (
+	ChainIDEthereum  int64 = 1
+	ChainIDOptimism  int64 = 10
+	ChainIDBinance   int64 = 56
+	ChainIDPolygon   int64 = 137
+	ChainIDBase      int64 = 8453
+	ChainIDArbitrum  int64 = 42161
+	ChainIDAvalanche int64 = 43114
+	ChainIDSolana    int64 = 1399811149
+
+	// LegacyChainIDSolana is the placeholder Solana ID stored before chain IDs were canonicalized
+	LegacyChainIDSolana int64 = 999
+)
+
+// Chain describes a supported chain and its CAIP-2 identifier
+type Chain struct {
+	ID        int64   `json:"chainId"`
+	Name      string  `json:"name"`
+	CAIP2     string  `json:"caip2"`
+	AliasIDs  []int64 `json:"-"` // Non-canonical IDs that resolve to this chain
+	Namespace string  `json:"namespace"`
+	GasToken  string  `json:"gasToken"` // Symbol of the native token gas is paid in
+}
+
+var chains = []Chain{
+	{ID: ChainIDEthereum, Name: "Ethereum", CAIP2: "eip155:1", Namespace: "eip155", GasToken: "ETH"},
+	{ID: ChainIDOptimism, Name: "Optimism", CAIP2: "eip155:10", Namespace: "eip155", GasToken: "ETH"},
+	{ID: ChainIDBinance, Name: "Binance Smart Chain", CAIP2: "eip155:56", Namespace: "eip155", GasToken: "BNB"},
+	{ID: ChainIDPolygon, Name: "Polygon", CAIP2: "eip155:137", Namespace: "eip155", GasToken: "MATIC"},
+	{ID: ChainIDBase, Name: "Base", CAIP2: "eip155:8453", Namespace: "eip155", GasToken: "ETH"},
+	{ID: ChainIDArbitrum, Name: "Arbitrum", CAIP2: "eip155:42161", Namespace: "eip155", GasToken: "ETH"},
+	{ID: ChainIDAvalanche, Name: "Avalanche", CAIP2: "eip155:43114", Namespace: "eip155", GasToken: "AVAX"},
+	{ID: ChainIDSolana, Name: "Solana", CAIP2: "solana:5eykt4UsFv8P8NJdTREpY1vzqKqZKvdp", Namespace: "solana", GasToken: "SOL", AliasIDs: []int64{LegacyChainIDSolana}},
+}
+
+var (
+	chainsByID    = make(map[int64]Chain)
+	chainsByCAIP2 = make(map[string]Chain)
+	chainsByName  = make(map[string]Chain)
+)
+
+func init() {
+	for _, chain := range chains {
+		chainsByID[chain.ID] = chain
+		for _, alias := range chain.AliasIDs {
+			chainsByID[alias] = chain
+		}
+		chainsByCAIP2[chain.CAIP2] = chain
+		chainsByName[strings.ToLower(chain.Name)] = chain
+	}
+}
+
+// Chains returns the supported chains ordered by chain ID
+func Chains() []Chain {
+	result := make([]Chain, len(chains))
+	copy(result, chains)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// GetChain returns the chain for a canonical or alias chain ID
+func GetChain(chainID int64) (Chain, bool) {
+	chain, ok := chainsByID[chainID]
+	return chain, ok
+}
+
+// GetChainByName returns the chain with the given name, ignoring case
+func GetChainByName(name string) (Chain, bool) {
+	chain, ok := chainsByName[strings.ToLower(strings.TrimSpace(name))]
+	return chain, ok
+}
+
+// CanonicalChainID maps alias chain IDs to their canonical ID; unknown IDs are returned unchanged
+func CanonicalChainID(chainID int64) int64 {
+	if chain, ok := chainsByID[chainID]; ok {
+		return chain.ID
+	}
+	return chainID
+}
+
+// ChainCAIP2 returns the CAIP-2 identifier of a chain, e.g. eip155:1.
+// Unknown chains are assumed to be EVM chains.
+func ChainCAIP2(chainID int64) string {
+	if chain, ok := chainsByID[chainID]; ok {
+		return chain.CAIP2
+	}
+	return "eip155:" + strconv.FormatInt(chainID, 10)
+}
+
+// ParseChainID parses a numeric chain ID or a CAIP-2 identifier into a canonical chain ID
+func ParseChainID(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if chainID, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return CanonicalChainID(chainID), nil
+	}
+
+	if chain, ok := chainsByCAIP2[raw]; ok {
+		return chain.ID, nil
+	}
+	if namespace, reference, found := strings.Cut(raw, ":"); found && namespace == "eip155" {
+		if chainID, err := strconv.ParseInt(reference, 10, 64); err == nil && chainID > 0 {
+			return CanonicalChainID(chainID), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid chain ID: %q", raw)
+}
+
+// CanonicalizeTokenPrice rewrites a price's chain ID to the canonical ID, filling in the chain name if missing
+func CanonicalizeTokenPrice(price *TokenPrice) {
+	chain, ok := chainsByID[price.ChainID]
+	if !ok {
+		return
+	}
+	price.ChainID = chain.ID
+	if price.ChainName == "" {
+		price.ChainName = chain.Name
+	}
+}
@@ -0,0 +1,66 @@
+package types
+
+import "testing"
+
+func TestCanonicalChainID(t *testing.T) {
+	if got := CanonicalChainID(LegacyChainIDSolana); got != ChainIDSolana {
+		t.Errorf("Expected legacy Solana ID to map to %d, got %d", ChainIDSolana, got)
+	}
+	if got := CanonicalChainID(ChainIDEthereum); got != ChainIDEthereum {
+		t.Errorf("Expected Ethereum ID to be unchanged, got %d", got)
+	}
+	if got := CanonicalChainID(12345); got != 12345 {
+		t.Errorf("Expected unknown ID to be unchanged, got %d", got)
+	}
+}
+
+func TestParseChainID(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected int64
+		wantErr  bool
+	}{
+		{"1", ChainIDEthereum, false},
+		{"999", ChainIDSolana, false},
+		{"eip155:137", ChainIDPolygon, false},
+		{"eip155:424242", 424242, false},
+		{"solana:5eykt4UsFv8P8NJdTREpY1vzqKqZKvdp", ChainIDSolana, false},
+		{"solana:unknown", 0, true},
+		{"eip155:", 0, true},
+		{"ethereum", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseChainID(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseChainID(%q): expected error, got %d", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseChainID(%q): unexpected error: %v", tt.raw, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseChainID(%q): expected %d, got %d", tt.raw, tt.expected, got)
+		}
+	}
+}
+
+func TestChainCAIP2(t *testing.T) {
+	if got := ChainCAIP2(ChainIDEthereum); got != "eip155:1" {
+		t.Errorf("Expected 'eip155:1', got '%s'", got)
+	}
+	if got := ChainCAIP2(LegacyChainIDSolana); got != ChainCAIP2(ChainIDSolana) {
+		t.Errorf("Expected legacy Solana ID to share the canonical CAIP-2 ID, got '%s'", got)
+	}
+}
+
+func TestCanonicalizeTokenPrice(t *testing.T) {
+	price := TokenPrice{Symbol: "SOL", ChainID: LegacyChainIDSolana}
+	CanonicalizeTokenPrice(&price)
+	if price.ChainID != ChainIDSolana || price.ChainName != "Solana" {
+		t.Errorf("Expected canonical Solana price, got %+v", price)
+	}
+}
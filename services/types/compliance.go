@@ -0,0 +1,65 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// Policy decision outcomes, from least to most severe
+const (
+	PolicyAllow  = "allow"
+	PolicyReview = "review"
+	PolicyDeny   = "deny"
+)
+
+// DefaultTenantID is the tenant of requests whose API key belongs to no configured tenant
+const DefaultTenantID = "default"
+
+// ErrPolicyNotFound is returned when a tenant has no policy document
+var ErrPolicyNotFound = errors.New("policy not found")
+
+// ComplianceContext carries who is making a request and from where, for policy evaluation
+type ComplianceContext struct {
+	TenantID string `json:"tenantId"`
+	ClientIP string `json:"clientIp,omitempty"`
+	Country  string `json:"country,omitempty"` // ISO 3166-1 alpha-2, empty when unknown
+}
+
+// PolicyReason explains why a policy rule did not allow a request
+type PolicyReason struct {
+	Rule    string `json:"rule"` // amount, geo or token_category
+	Outcome string `json:"outcome"`
+	Message string `json:"message"`
+}
+
+// PolicyDecision is the outcome of evaluating a tenant's policy against a request
+type PolicyDecision struct {
+	Outcome       string         `json:"outcome"` // allow, review or deny
+	TenantID      string         `json:"tenantId"`
+	PolicyVersion int            `json:"policyVersion"`
+	Reasons       []PolicyReason `json:"reasons,omitempty"`
+	EvaluatedAt   time.Time      `json:"evaluatedAt"`
+}
+
+// PolicyDocument is a tenant's KYC/AML policy.
+// Rules that are not set allow every request.
+type PolicyDocument struct {
+	TenantID string `json:"tenantId"`
+	Version  int    `json:"version"`
+
+	// Amount thresholds on the swap's USD value; 0 disables a threshold
+	ReviewAboveUSD float64 `json:"reviewAboveUsd,omitempty"`
+	DenyAboveUSD   float64 `json:"denyAboveUsd,omitempty"`
+
+	// Geo restrictions on the country the request came from
+	BlockedCountries    []string `json:"blockedCountries,omitempty"`
+	ReviewCountries     []string `json:"reviewCountries,omitempty"`
+	ReviewUnknownOrigin bool     `json:"reviewUnknownOrigin,omitempty"` // Review requests whose country is unknown
+
+	// Token category rules: TokenCategories maps token symbols to a category,
+	// and CategoryRules maps categories to review or deny
+	TokenCategories map[string]string `json:"tokenCategories,omitempty"`
+	CategoryRules   map[string]string `json:"categoryRules,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
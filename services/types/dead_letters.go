@@ -0,0 +1,41 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDeadLetterNotFound is returned when a swap has no open dead letter
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetterReason is why a swap was dead-lettered
+type DeadLetterReason string
+
+const (
+	DeadLetterStuck  DeadLetterReason = "stuck"  // Still running past SWAP.MAX_SWAP_TIME with an activity retrying
+	DeadLetterFailed DeadLetterReason = "failed" // Failed after an activity ran out of retries
+)
+
+// DeadLetterStatus is where a dead-lettered swap is in its handling by operators
+type DeadLetterStatus string
+
+const (
+	DeadLetterOpen     DeadLetterStatus = "open"     // Waiting for an operator
+	DeadLetterRequeued DeadLetterStatus = "requeued" // Executed again by an operator
+	DeadLetterRefunded DeadLetterStatus = "refunded" // Refunded to its sender by an operator
+)
+
+// DeadLetter is a swap workflow the housekeeping scan found stuck or failed, kept until an operator requeues or
+// refunds it
+type DeadLetter struct {
+	RequestID  string           `json:"requestId"`
+	RunID      string           `json:"runId"`
+	Reason     DeadLetterReason `json:"reason"`
+	Detail     string           `json:"detail"` // The retrying activity or the failure
+	StartedAt  time.Time        `json:"startedAt"`
+	DetectedAt time.Time        `json:"detectedAt"`
+	Status     DeadLetterStatus `json:"status"`
+	ResolvedBy string           `json:"resolvedBy,omitempty"` // The operator who requeued or refunded the swap
+	ActionID   string           `json:"actionId,omitempty"`   // The admin action that did
+	ResolvedAt *time.Time       `json:"resolvedAt,omitempty"`
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"math/big"
+	"time"
+)
+
+// ExpectedDeposit is a deposit a swap waits for before it executes
+type ExpectedDeposit struct {
+	RequestID  string    `json:"requestId"`
+	Token      Token     `json:"token"`
+	From       string    `json:"from"`    // Address the deposit must come from
+	Address    string    `json:"address"` // Deposit address the funds are sent to
+	Amount     *big.Int  `json:"amount"`  // Minimum amount, in base units
+	WebhookURL string    `json:"webhookUrl,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	NextBlock  uint64    `json:"nextBlock"` // Next block to look for the deposit in
+}
+
+// Deposit is a confirmed transfer that fulfilled an expected deposit
+type Deposit struct {
+	RequestID   string   `json:"requestId"`
+	ChainID     int64    `json:"chainId"`
+	Token       Token    `json:"token"`
+	TxHash      string   `json:"txHash"`
+	BlockNumber uint64   `json:"blockNumber"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Amount      *big.Int `json:"amount"`
+}
+
+// TokenTransfer is an ERC-20 Transfer event
+type TokenTransfer struct {
+	Token       string   `json:"token"` // Contract address
+	TxHash      string   `json:"txHash"`
+	LogIndex    uint64   `json:"logIndex"`
+	BlockNumber uint64   `json:"blockNumber"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Amount      *big.Int `json:"amount"`
+}
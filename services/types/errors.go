@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+// ErrorOrigin is where a reported error happened
+type ErrorOrigin string
+
+// Origins of reported errors
+const (
+	ErrorOriginAPI      ErrorOrigin = "api"      // An error response of the API server
+	ErrorOriginWorkflow ErrorOrigin = "workflow" // A failed workflow
+	ErrorOriginActivity ErrorOrigin = "activity" // A failed activity attempt
+	ErrorOriginSDK      ErrorOrigin = "sdk"      // A failed Universal SDK call
+)
+
+// ErrorStat counts the occurrences of one error fingerprint: errors of the same origin, operation, code and type
+type ErrorStat struct {
+	Fingerprint string      `json:"fingerprint"`
+	Origin      ErrorOrigin `json:"origin"`
+	Operation   string      `json:"operation"` // Route pattern, workflow or activity type, or SDK method
+	Code        string      `json:"code,omitempty"`
+	Type        string      `json:"type,omitempty"` // Go type of the innermost error
+	Message     string      `json:"message"`        // Message of the latest occurrence
+	Count       int64       `json:"count"`
+	FirstSeen   time.Time   `json:"firstSeen"`
+	LastSeen    time.Time   `json:"lastSeen"`
+}
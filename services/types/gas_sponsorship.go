@@ -0,0 +1,33 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Gas sponsorship errors
+var (
+	ErrGasSponsorshipUnavailable = errors.New("gas sponsorship is not available")
+	ErrGasSponsorCapReached      = fmt.Errorf("%w: the chain's daily sponsorship cap is reached", ErrGasSponsorshipUnavailable)
+)
+
+// GasSponsorPolicy caps the gas the protocol sponsors on a chain; a zero cap is unlimited
+type GasSponsorPolicy struct {
+	MaxSwapUSD  float64 `json:"maxSwapUsd"`  // Most gas sponsored for one swap
+	DailyCapUSD float64 `json:"dailyCapUsd"` // Most gas sponsored over any 24 hours
+}
+
+// GasSponsorship is the gas the protocol pays for a swap on its destination chain, recouped from the swap's output
+// through its network fee
+type GasSponsorship struct {
+	RequestID string    `json:"requestId"`
+	ChainID   int64     `json:"chainId"`
+	GasUnits  uint64    `json:"gasUnits"`
+	GasCost   *big.Int  `json:"gasCost"` // In base units of the chain's native token
+	CostUSD   float64   `json:"costUsd"`
+	Token     string    `json:"token"`    // Symbol of the source token the gas is recouped in, like the swap's other fees
+	Recouped  *big.Int  `json:"recouped"` // In base units of Token, added to the swap's network fee
+	CreatedAt time.Time `json:"createdAt"`
+}
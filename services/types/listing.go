@@ -0,0 +1,112 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Listing request statuses
+const (
+	ListingStatusChecking      = "checking"       // Risk checks are running
+	ListingStatusPendingReview = "pending_review" // Waiting in the admin review queue
+	ListingStatusApproved      = "approved"       // Added to the token registry
+	ListingStatusRejected      = "rejected"       // By a blocking risk check, a reviewer or the review timeout
+)
+
+// Listing risk check severities
+const (
+	ListingCheckBlocking = "blocking" // A failure rejects the listing without review
+	ListingCheckAdvisory = "advisory" // A failure is flagged to the reviewer
+)
+
+// ErrListingNotFound is returned when a listing request does not exist
+var ErrListingNotFound = errors.New("listing request not found")
+
+// LiquidityCommitment is the liquidity a project commits to provide once its token is listed
+type LiquidityCommitment struct {
+	PoolAddress string  `json:"poolAddress"` // Address holding the committed liquidity
+	AmountUSD   float64 `json:"amountUsd"`
+	LockDays    int     `json:"lockDays"` // How long the liquidity stays locked after listing
+}
+
+// ListingCheck is the outcome of one automated risk check of a listing request
+type ListingCheck struct {
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+	Passed   bool   `json:"passed"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ListingReview is an operator's decision on a listing request
+type ListingReview struct {
+	Approved   bool      `json:"approved"`
+	Operator   string    `json:"operator"`
+	Reason     string    `json:"reason"`
+	ReviewedAt time.Time `json:"reviewedAt"`
+}
+
+// ListingRequest is a third party's request to add a token to the registry
+type ListingRequest struct {
+	ID               string              `json:"id"`
+	Token            Token               `json:"token"`
+	ProjectURL       string              `json:"projectUrl"`
+	ContactEmail     string              `json:"contactEmail"`
+	Description      string              `json:"description,omitempty"`
+	SubmitterAddress string              `json:"submitterAddress"` // Wallet of the project submitting the listing
+	Liquidity        LiquidityCommitment `json:"liquidity"`
+
+	Status      string         `json:"status"`
+	Checks      []ListingCheck `json:"checks,omitempty"`
+	Review      *ListingReview `json:"review,omitempty"`
+	Message     string         `json:"message,omitempty"`
+	SubmittedAt time.Time      `json:"submittedAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+// ApplyChecks records the risk check results, rejecting the listing if a blocking check failed
+// and queueing it for review otherwise
+func (l *ListingRequest) ApplyChecks(checks []ListingCheck, at time.Time) {
+	l.Checks = checks
+	l.UpdatedAt = at
+
+	var failed []string
+	for _, check := range checks {
+		if !check.Passed && check.Severity == ListingCheckBlocking {
+			failed = append(failed, check.Name)
+		}
+	}
+	if len(failed) > 0 {
+		l.Status = ListingStatusRejected
+		l.Message = fmt.Sprintf("Failed risk checks: %s", strings.Join(failed, ", "))
+		return
+	}
+	l.Status = ListingStatusPendingReview
+	l.Message = ""
+}
+
+// ApplyReview records an operator's decision on the listing
+func (l *ListingRequest) ApplyReview(review ListingReview) {
+	l.Review = &review
+	l.UpdatedAt = review.ReviewedAt
+	if review.Approved {
+		l.Status = ListingStatusApproved
+		l.Message = ""
+		return
+	}
+	l.Status = ListingStatusRejected
+	l.Message = fmt.Sprintf("Rejected in review: %s", review.Reason)
+}
+
+// ListedToken returns the registry entry for the listing's token
+func (l *ListingRequest) ListedToken() Token {
+	token := l.Token
+	token.Symbol = strings.ToUpper(token.Symbol)
+	token.ChainID = CanonicalChainID(token.ChainID)
+	token.IsWrapped = false
+	if chain, ok := GetChain(token.ChainID); ok {
+		token.ChainName = chain.Name
+	}
+	return token
+}
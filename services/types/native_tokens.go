@@ -0,0 +1,69 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NativeTokenAddress is the placeholder address of an EVM chain's native token, such as ETH or MATIC, which is a
+// balance rather than a contract. Contracts take it in place of a token address and the amount as the call's value.
+const NativeTokenAddress = "0x0000000000000000000000000000000000000000"
+
+// nativeTokenAliasAddress is the placeholder address wallets and DEX aggregators commonly give native tokens
+const nativeTokenAliasAddress = "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"
+
+// nativeTokenDecimals is the decimals of EVM chains' native tokens
+const nativeTokenDecimals = 18
+
+// ErrInvalidNativeToken is returned for a token with a native token's address that isn't its chain's native token
+var ErrInvalidNativeToken = errors.New("invalid native token")
+
+// IsNative reports whether the token is its EVM chain's native token: it has the zero or 0xEeee… placeholder
+// address, or no address and the symbol of its chain's gas token. Universal's wrapped tokens are never native.
+func (t Token) IsNative() bool {
+	chain, ok := GetChain(CanonicalChainID(t.ChainID))
+	if !ok || chain.Namespace != "eip155" || t.IsWrapped {
+		return false
+	}
+	if t.Address == "" {
+		return t.Symbol == chain.GasToken
+	}
+	return strings.EqualFold(t.Address, NativeTokenAddress) || strings.EqualFold(t.Address, nativeTokenAliasAddress)
+}
+
+// NativeToken returns the native token of an EVM chain
+func NativeToken(chainID int64) (Token, bool) {
+	chain, ok := GetChain(CanonicalChainID(chainID))
+	if !ok || chain.Namespace != "eip155" {
+		return Token{}, false
+	}
+	return Token{
+		Symbol:    chain.GasToken,
+		Name:      chain.GasToken,
+		Decimals:  nativeTokenDecimals,
+		Address:   NativeTokenAddress,
+		ChainID:   chain.ID,
+		ChainName: chain.Name,
+	}, true
+}
+
+// CheckNativeToken checks a token given a native token's address is its chain's native token, with its decimals.
+// Tokens with other addresses pass.
+func CheckNativeToken(token Token) error {
+	if token.Address == "" || (!strings.EqualFold(token.Address, NativeTokenAddress) && !strings.EqualFold(token.Address, nativeTokenAliasAddress)) {
+		return nil
+	}
+	native, ok := NativeToken(token.ChainID)
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: chain %d has no native EVM token", ErrInvalidNativeToken, token.ChainID)
+	case token.IsWrapped:
+		return fmt.Errorf("%w: wrapped %s has its own contract", ErrInvalidNativeToken, token.Symbol)
+	case token.Symbol != native.Symbol:
+		return fmt.Errorf("%w: the native token of %s is %s, not %s", ErrInvalidNativeToken, native.ChainName, native.Symbol, token.Symbol)
+	case token.Decimals != native.Decimals:
+		return fmt.Errorf("%w: %s has %d decimals, not %d", ErrInvalidNativeToken, native.Symbol, native.Decimals, token.Decimals)
+	}
+	return nil
+}
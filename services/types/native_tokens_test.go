@@ -0,0 +1,55 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsNative(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  Token
+		native bool
+	}{
+		{"ZeroAddress", Token{Symbol: "ETH", ChainID: ChainIDEthereum, Address: NativeTokenAddress}, true},
+		{"AliasAddress", Token{Symbol: "MATIC", ChainID: ChainIDPolygon, Address: "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"}, true},
+		{"GasTokenWithoutAddress", Token{Symbol: "MATIC", ChainID: ChainIDPolygon}, true},
+		{"OtherTokenWithoutAddress", Token{Symbol: "USDC", ChainID: ChainIDEthereum}, false},
+		{"Contract", Token{Symbol: "WETH", ChainID: ChainIDEthereum, Address: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"}, false},
+		{"Wrapped", Token{Symbol: "uETH", ChainID: ChainIDEthereum, Address: NativeTokenAddress, IsWrapped: true}, false},
+		{"NonEVMChain", Token{Symbol: "SOL", ChainID: ChainIDSolana}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.IsNative(); got != tt.native {
+				t.Errorf("Expected IsNative to be %v, got %v", tt.native, got)
+			}
+		})
+	}
+}
+
+func TestCheckNativeToken(t *testing.T) {
+	matic, ok := NativeToken(ChainIDPolygon)
+	if !ok || matic.Symbol != "MATIC" || matic.Decimals != 18 {
+		t.Fatalf("Expected Polygon's native token to be MATIC with 18 decimals, got %+v", matic)
+	}
+	if err := CheckNativeToken(matic); err != nil {
+		t.Errorf("Expected MATIC to be Polygon's native token, got %v", err)
+	}
+	if err := CheckNativeToken(Token{Symbol: "USDC", Decimals: 6, ChainID: ChainIDPolygon, Address: "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"}); err != nil {
+		t.Errorf("Expected contract tokens to pass, got %v", err)
+	}
+
+	ethOnPolygon := matic
+	ethOnPolygon.Symbol = "ETH"
+	decimals := matic
+	decimals.Decimals = 6
+	solana := matic
+	solana.Symbol = "SOL"
+	solana.ChainID = ChainIDSolana
+	for name, token := range map[string]Token{"other symbol": ethOnPolygon, "other decimals": decimals, "non-EVM chain": solana} {
+		if err := CheckNativeToken(token); !errors.Is(err, ErrInvalidNativeToken) {
+			t.Errorf("Expected a native token with a(n) %s to be refused, got %v", name, err)
+		}
+	}
+}
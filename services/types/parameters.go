@@ -0,0 +1,18 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrParameterNotFound is returned when the parameter store has no parameter of a name
+var ErrParameterNotFound = errors.New("parameter not found")
+
+// Parameter is an operator-managed setting, such as a rule expression, stored in the parameter store
+type Parameter struct {
+	Name        string    `json:"name"`
+	Value       string    `json:"value"`
+	Description string    `json:"description,omitempty"`
+	Version     int       `json:"version"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,138 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// ErrPoolNotFound is returned when the pool store has no pool of an ID
+var ErrPoolNotFound = errors.New("pool not found")
+
+// PoolState is a liquidity pool together with its positions and the liquidity held for executing swaps
+type PoolState struct {
+	Pool         LiquidityPool              `json:"pool"`
+	Positions    []LiquidityPosition        `json:"positions"`
+	Reservations map[string]PoolReservation `json:"reservations,omitempty"` // by swap request ID
+}
+
+// PoolReservation is part of a pool's reserve of a token held for a swap being executed
+type PoolReservation struct {
+	Token     string    `json:"token"` // The swap's output token
+	Amount    *big.Int  `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PriceImpactLimits are the largest price impacts, in percent, swaps may have on each pool unless they are forced
+type PriceImpactLimits struct {
+	Default float64            // Limit of the pools without their own; 0 is unlimited
+	Pools   map[string]float64 // map[poolID]limit
+}
+
+// Limit returns the price impact limit of a pool, 0 when it is unlimited
+func (l PriceImpactLimits) Limit(poolID string) float64 {
+	if limit, ok := l.Pools[poolID]; ok && limit > 0 {
+		return limit
+	}
+	return l.Default
+}
+
+// TokenAmounts holds amounts of several tokens, by token symbol
+type TokenAmounts map[string]*big.Int
+
+// Add adds amount of a token, returning the amounts, which are allocated when nil
+func (a TokenAmounts) Add(symbol string, amount *big.Int) TokenAmounts {
+	if a == nil {
+		a = make(TokenAmounts)
+	}
+	if current, ok := a[symbol]; ok {
+		a[symbol] = new(big.Int).Add(current, amount)
+	} else {
+		a[symbol] = new(big.Int).Set(amount)
+	}
+	return a
+}
+
+// IsZero reports whether every amount is zero
+func (a TokenAmounts) IsZero() bool {
+	for _, amount := range a {
+		if amount != nil && amount.Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the amounts, keeping nil as nil
+func (a TokenAmounts) Clone() TokenAmounts {
+	if a == nil {
+		return nil
+	}
+	clone := make(TokenAmounts, len(a))
+	for symbol, amount := range a {
+		clone[symbol] = cloneAmount(amount)
+	}
+	return clone
+}
+
+// Clone returns a copy of the state that shares no amounts with it
+func (s PoolState) Clone() PoolState {
+	clone := PoolState{Pool: s.Pool}
+	clone.Pool.TotalLiquidity = cloneAmount(s.Pool.TotalLiquidity)
+	clone.Pool.FeesAccrued = s.Pool.FeesAccrued.Clone()
+	clone.Pool.Reserves = s.Pool.Reserves.Clone()
+
+	clone.Positions = make([]LiquidityPosition, len(s.Positions))
+	for i, position := range s.Positions {
+		clone.Positions[i] = position.Clone()
+	}
+
+	if s.Reservations != nil {
+		clone.Reservations = make(map[string]PoolReservation, len(s.Reservations))
+		for swapID, reservation := range s.Reservations {
+			reservation.Amount = cloneAmount(reservation.Amount)
+			clone.Reservations[swapID] = reservation
+		}
+	}
+	return clone
+}
+
+// Clone returns a copy of the position that shares no amounts with it
+func (p LiquidityPosition) Clone() LiquidityPosition {
+	p.TokensOwned = cloneAmount(p.TokensOwned)
+	p.FeesOwed = p.FeesOwed.Clone()
+	p.FeesClaimed = p.FeesClaimed.Clone()
+	return p
+}
+
+// cloneAmount copies an amount, keeping nil as nil
+func cloneAmount(amount *big.Int) *big.Int {
+	if amount == nil {
+		return nil
+	}
+	return new(big.Int).Set(amount)
+}
+
+// PoolMigrationPlan is the result of moving every position of a pool into another pool holding the same tokens,
+// e.g. one with a different fee tier or token addresses. Positions keep their LP tokens, so each keeps its
+// share of the migrated liquidity.
+type PoolMigrationPlan struct {
+	SourcePoolID    string                  `json:"sourcePoolId"`
+	TargetPoolID    string                  `json:"targetPoolId"`
+	Liquidity       *big.Int                `json:"liquidity"`       // LP tokens moved
+	Reserves        TokenAmounts            `json:"reserves"`        // Reserves moved, by token
+	TargetLiquidity *big.Int                `json:"targetLiquidity"` // The target's total liquidity afterwards
+	TargetReserves  TokenAmounts            `json:"targetReserves"`  // The target's reserves afterwards
+	Positions       []PoolMigrationPosition `json:"positions"`
+}
+
+// PoolMigrationPosition is a position moved by a pool migration, before and after
+type PoolMigrationPosition struct {
+	UserAddress       string       `json:"userAddress"`
+	TokensOwned       *big.Int     `json:"tokensOwned"` // LP tokens moved
+	SourceShare       float64      `json:"sourceShare"` // Percentage of the source pool owned before
+	FeesOwed          TokenAmounts `json:"feesOwed"`    // Unclaimed fees moved with the position
+	FeesClaimed       TokenAmounts `json:"feesClaimed"`
+	TargetTokensOwned *big.Int     `json:"targetTokensOwned"` // LP tokens in the target afterwards, with any held there before
+	TargetShare       float64      `json:"targetShare"`       // Percentage of the target pool owned afterwards
+}
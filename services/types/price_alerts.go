@@ -0,0 +1,91 @@
+package types
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Price alert directions
+const (
+	PriceAlertAbove = "above" // Fires when the price rises to or above the threshold
+	PriceAlertBelow = "below" // Fires when the price falls to or below the threshold
+)
+
+// Price alert statuses
+const (
+	PriceAlertStatusActive    = "active"    // Evaluated against every scheduled price update
+	PriceAlertStatusTriggered = "triggered" // Fired once and no longer evaluated
+)
+
+// Channels a fired price alert is notified on
+const (
+	PriceAlertChannelWebhook = "webhook"
+	PriceAlertChannelEmail   = "email"
+)
+
+// ErrPriceAlertNotFound is returned when a price alert does not exist or belongs to another API key
+var ErrPriceAlertNotFound = errors.New("price alert not found")
+
+// PriceAlert notifies its owner once a token's price crosses a threshold
+type PriceAlert struct {
+	ID         string  `json:"id"`
+	Owner      string  `json:"owner"` // ID of the API key that registered the alert
+	Symbol     string  `json:"symbol"`
+	ChainID    int64   `json:"chainId,omitempty"` // Zero matches the token's price on any chain
+	Threshold  float64 `json:"threshold"`         // USD price
+	Direction  string  `json:"direction"`
+	WebhookURL string  `json:"webhookUrl,omitempty"`
+	Email      string  `json:"email,omitempty"`
+
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	TriggeredAt  *time.Time `json:"triggeredAt,omitempty"`
+	TriggerPrice float64    `json:"triggerPrice,omitempty"` // The USD price that fired the alert
+}
+
+// Crossed reports whether price is a price of the alert's token on the alert's side of its threshold
+func (a PriceAlert) Crossed(price TokenPrice) bool {
+	if !strings.EqualFold(a.Symbol, price.Symbol) || (a.ChainID != 0 && a.ChainID != price.ChainID) {
+		return false
+	}
+	if price.PriceUSD <= 0 {
+		return false
+	}
+	switch a.Direction {
+	case PriceAlertAbove:
+		return price.PriceUSD >= a.Threshold
+	case PriceAlertBelow:
+		return price.PriceUSD <= a.Threshold
+	}
+	return false
+}
+
+// CrossingPrice returns the first of prices that crosses the alert's threshold
+func (a PriceAlert) CrossingPrice(prices []TokenPrice) (TokenPrice, bool) {
+	for _, price := range prices {
+		if a.Crossed(price) {
+			return price, true
+		}
+	}
+	return TokenPrice{}, false
+}
+
+// Channels returns the channels the alert notifies its owner on
+func (a PriceAlert) Channels() []string {
+	var channels []string
+	if a.WebhookURL != "" {
+		channels = append(channels, PriceAlertChannelWebhook)
+	}
+	if a.Email != "" {
+		channels = append(channels, PriceAlertChannelEmail)
+	}
+	return channels
+}
+
+// Trigger marks the alert fired by price at a time
+func (a *PriceAlert) Trigger(price float64, at time.Time) {
+	a.Status = PriceAlertStatusTriggered
+	a.TriggeredAt = &at
+	a.TriggerPrice = price
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"strconv"
 	"time"
 )
 
@@ -19,6 +20,11 @@ type TokenPrice struct {
 	Source        PriceSource `json:"source"`
 	IsVerified    bool        `json:"isVerified"`
 	JupiterVolume float64     `json:"jupiterVolume,omitempty"`
+
+	// ReferenceDeviation is the percent difference from the CEX reference price, when one was available
+	ReferenceDeviation float64 `json:"referenceDeviation,omitempty"`
+	// SanityFlagged marks prices outside the CEX sanity band that were kept rather than dropped
+	SanityFlagged bool `json:"sanityFlagged,omitempty"`
 }
 
 // TokenPriceHistory represents a historical token price record
@@ -33,6 +39,43 @@ type TokenPriceHistory struct {
 	Timestamp    time.Time   `json:"timestamp"`
 }
 
+// PriceAverage is a token's time- and volume-weighted average price over a window
+type PriceAverage struct {
+	Symbol  string    `json:"symbol"`
+	ChainID int64     `json:"chainId"`
+	Window  string    `json:"window"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	TWAP    float64   `json:"twap"`
+	VWAP    float64   `json:"vwap,omitempty"` // Omitted when the 24h volume did not grow during the window
+	Samples int       `json:"samples"`
+	// Coverage is the fraction of the window covered by price samples, 0-1
+	Coverage float64 `json:"coverage"`
+}
+
+// CandleIntervals are the OHLCV candle intervals price history is rolled up into
+var CandleIntervals = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// Candle is a token's OHLCV candle over one interval
+type Candle struct {
+	Symbol   string    `json:"symbol"`
+	ChainID  int64     `json:"chainId"`
+	Interval string    `json:"interval"`
+	Start    time.Time `json:"start"`
+	Open     float64   `json:"open"`
+	High     float64   `json:"high"`
+	Low      float64   `json:"low"`
+	Close    float64   `json:"close"`
+	// Volume is the token's 24h trading volume at the close; sources only report rolling 24h volume
+	Volume  float64 `json:"volume"`
+	Samples int     `json:"samples"`
+}
+
 // PriceSource represents a source of token price data
 type PriceSource string
 
@@ -43,8 +86,16 @@ const (
 	PriceSourceCoinGecko PriceSource = "coingecko"
 	// PriceSourceJupiter represents prices from Jupiter
 	PriceSourceJupiter PriceSource = "jupiter"
+	// PriceSourceChainlink represents prices from Chainlink on-chain aggregators
+	PriceSourceChainlink PriceSource = "chainlink"
+	// PriceSourceBinance represents Binance spot prices, used as a sanity reference
+	PriceSourceBinance PriceSource = "binance"
+	// PriceSourceOKX represents OKX spot prices, used as a sanity reference
+	PriceSourceOKX PriceSource = "okx"
 	// PriceSourceFallback represents fallback hardcoded prices
 	PriceSourceFallback PriceSource = "fallback"
+	// PriceSourceSeed represents generated prices written by the seed tool for local environments and demos
+	PriceSourceSeed PriceSource = "seed"
 )
 
 // PriceFetchRequest represents a request to fetch token prices
@@ -55,6 +106,9 @@ type PriceFetchRequest struct {
 	ForceSync bool      `json:"forceSync"`
 	Timestamp time.Time `json:"timestamp"`
 	RequestID string    `json:"requestId"`
+
+	// Retry is how the fetch's activities are retried; nil uses the default policy
+	Retry *RetryPolicy `json:"retry,omitempty"`
 }
 
 // PriceFetchResult represents the result of a price fetch operation
@@ -72,7 +126,23 @@ type PriceFetchResult struct {
 type PriceCache struct {
 	Prices      map[string]TokenPrice `json:"prices"` // Map of symbol-chainId to price
 	LastUpdated time.Time             `json:"lastUpdated"`
-	ExpiresAt   time.Time             `json:"expiresAt"`
+	ExpiresAt   time.Time             `json:"expiresAt"` // When the first entry expires
+	// EntryExpiresAt holds each entry's expiry, keyed like Prices; caches written before per-entry TTLs lack it
+	EntryExpiresAt map[string]time.Time `json:"entryExpiresAt,omitempty"`
+}
+
+// EntryExpiry returns when the cached price under key expires
+func (c PriceCache) EntryExpiry(key string) time.Time {
+	if expiresAt, ok := c.EntryExpiresAt[key]; ok {
+		return expiresAt
+	}
+	return c.ExpiresAt
+}
+
+// PriceCacheLoad is the result of loading prices from the cache
+type PriceCacheLoad struct {
+	Prices       []TokenPrice `json:"prices"`       // Cached prices that are still fresh
+	StaleSymbols []string     `json:"staleSymbols"` // Requested symbols that expired or are missing from the cache
 }
 
 // PriceUpdateEvent represents an event when prices are updated
@@ -84,5 +154,5 @@ type PriceUpdateEvent struct {
 
 // GetPriceKey returns a unique key for a token price based on symbol and chain
 func GetPriceKey(symbol string, chainID int64) string {
-	return symbol + "-" + string(chainID)
+	return symbol + "-" + strconv.FormatInt(chainID, 10)
 }
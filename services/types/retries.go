@@ -0,0 +1,25 @@
+package types
+
+import "time"
+
+// RetryPolicy is how a workflow retries a failing activity; a zero field keeps the workflow's default for it
+type RetryPolicy struct {
+	InitialInterval    time.Duration `json:"initialInterval"`    // Wait before the first retry
+	BackoffCoefficient float64       `json:"backoffCoefficient"` // Each wait is this many times the last
+	MaximumInterval    time.Duration `json:"maximumInterval"`    // Longest wait between retries
+	MaximumAttempts    int32         `json:"maximumAttempts"`    // Attempts in all, the first included
+}
+
+// RetryPolicies are the retry policies of each kind of activity, carried in a workflow's input so replaying it
+// retries as it did when it started
+type RetryPolicies struct {
+	Wrap       RetryPolicy `json:"wrap"`       // Wrapping tokens into their Universal version
+	Transfer   RetryPolicy `json:"transfer"`   // Moving tokens into and out of pools
+	Swap       RetryPolicy `json:"swap"`       // Quoting, checking and executing swaps
+	Unwrap     RetryPolicy `json:"unwrap"`     // Unwrapping Universal tokens
+	PriceFetch RetryPolicy `json:"priceFetch"` // Fetching, merging and saving prices
+
+	// Budget bounds how long a swap's activities may take altogether, retries and their backoff included;
+	// zero leaves it unbounded
+	Budget time.Duration `json:"budget"`
+}
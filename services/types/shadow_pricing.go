@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+// PairCompetitiveness is how our quotes for a pair compared with a DEX aggregator's over its recent samples.
+// Differences are in basis points of the aggregator's output; positive means we quoted the larger output.
+type PairCompetitiveness struct {
+	Pair          string    `json:"pair"` // Base and quote symbols, e.g. "ETH/USDC"
+	ChainID       int64     `json:"chainId"`
+	Aggregator    string    `json:"aggregator"`
+	Samples       int       `json:"samples"`
+	WinRate       float64   `json:"winRate"` // Share of samples where we quoted at least the aggregator's output
+	P10BPS        float64   `json:"p10Bps"`
+	P50BPS        float64   `json:"p50Bps"`
+	P90BPS        float64   `json:"p90Bps"`
+	LastBPS       float64   `json:"lastBps"`
+	LastSampledAt time.Time `json:"lastSampledAt"`
+}
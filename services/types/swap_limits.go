@@ -0,0 +1,138 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSwapLimitExceeded is returned for swaps worth more than a limit allows
+var ErrSwapLimitExceeded = errors.New("swap limit exceeded")
+
+// ErrSwapVolumeBooked is returned for booking a swap under a request ID already booked against the swap limits
+var ErrSwapVolumeBooked = errors.New("request ID already booked against the swap limits")
+
+// SwapErrorLimitExceeded is the error code of swaps worth more than a swap limit allows
+const SwapErrorLimitExceeded = "LIMIT_EXCEEDED"
+
+// Limits a swap's USD value is held to
+const (
+	SwapLimitSwap  = "swap"  // Every swap's value
+	SwapLimitPair  = "pair"  // Swaps between a pair of tokens
+	SwapLimitChain = "chain" // Swaps from a chain
+	SwapLimitUser  = "user"  // Swaps from a source address, over 24 hours
+)
+
+// SwapAmountLimit caps the USD value of swaps; a zero cap is unlimited
+type SwapAmountLimit struct {
+	MaxSwapUSD float64 `json:"maxSwapUsd"` // Most one swap may be worth
+	DailyUSD   float64 `json:"dailyUsd"`   // Most the swaps may be worth together over any 24 hours
+}
+
+// SwapLimits are the USD values swaps are held to; zero values are unlimited
+type SwapLimits struct {
+	MaxSwapUSD   float64                    // Most any swap may be worth
+	UserDailyUSD float64                    // Most a source address may swap over any 24 hours
+	Pairs        map[string]SwapAmountLimit // by SwapPair
+	Chains       map[int64]SwapAmountLimit  // by source chain ID
+}
+
+// Enabled reports whether any limit is set
+func (l SwapLimits) Enabled() bool {
+	return l.MaxSwapUSD > 0 || l.UserDailyUSD > 0 || len(l.Pairs) > 0 || len(l.Chains) > 0
+}
+
+// SwapPair returns the key of the pair of two token symbols, the same whichever way round they are swapped
+func SwapPair(a, b string) string {
+	symbols := []string{strings.ToUpper(strings.TrimSpace(a)), strings.ToUpper(strings.TrimSpace(b))}
+	sort.Strings(symbols)
+	return symbols[0] + "/" + symbols[1]
+}
+
+// SwapLimitVolume is a swap's USD value, booked against the daily limits of its source address, pair and source chain
+type SwapLimitVolume struct {
+	RequestID string    `json:"requestId"`
+	Owner     string    `json:"owner,omitempty"` // Lowercased source address; empty for swaps without one
+	ChainID   int64     `json:"chainId"`         // Source chain
+	Pair      string    `json:"pair"`            // SwapPair of the source and destination tokens
+	AmountUSD float64   `json:"amountUsd"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SwapLimitTotals are the USD values of the swaps booked since a time from a volume's source address, pair and
+// source chain
+type SwapLimitTotals struct {
+	User  float64
+	Pair  float64
+	Chain float64
+}
+
+// SwapLimitError is returned for a swap worth more than one of its limits allows, with what the limit still allows
+type SwapLimitError struct {
+	Limit        string  // swap, pair, chain or user
+	Daily        bool    // The limit caps 24 hours of swaps rather than one
+	LimitUSD     float64 // The limit
+	AmountUSD    float64 // The swap's value
+	RemainingUSD float64 // What the limit still allows
+}
+
+// Error describes the limit and the allowance remaining
+func (e *SwapLimitError) Error() string {
+	if !e.Daily {
+		return fmt.Sprintf("%v: the swap is worth $%.2f, more than the $%.2f %s limit per swap",
+			ErrSwapLimitExceeded, e.AmountUSD, e.LimitUSD, e.Limit)
+	}
+	return fmt.Sprintf("%v: the swap is worth $%.2f and $%.2f of the $%.2f daily %s limit remains",
+		ErrSwapLimitExceeded, e.AmountUSD, e.RemainingUSD, e.LimitUSD, e.Limit)
+}
+
+// Unwrap returns ErrSwapLimitExceeded
+func (e *SwapLimitError) Unwrap() error {
+	return ErrSwapLimitExceeded
+}
+
+// Check returns a *SwapLimitError when the volume is worth more than one of the limits allows, given the totals
+// already booked over the last 24 hours
+func (l SwapLimits) Check(volume SwapLimitVolume, totals SwapLimitTotals) error {
+	pair := l.Pairs[volume.Pair]
+	chain := l.Chains[volume.ChainID]
+	perSwap := []struct {
+		limit string
+		cap   float64
+	}{
+		{SwapLimitSwap, l.MaxSwapUSD},
+		{SwapLimitPair, pair.MaxSwapUSD},
+		{SwapLimitChain, chain.MaxSwapUSD},
+	}
+	for _, check := range perSwap {
+		if check.cap > 0 && volume.AmountUSD > check.cap {
+			return &SwapLimitError{Limit: check.limit, LimitUSD: check.cap, AmountUSD: volume.AmountUSD, RemainingUSD: check.cap}
+		}
+	}
+
+	userDaily := l.UserDailyUSD
+	if volume.Owner == "" {
+		userDaily = 0
+	}
+	daily := []struct {
+		limit  string
+		cap    float64
+		booked float64
+	}{
+		{SwapLimitUser, userDaily, totals.User},
+		{SwapLimitPair, pair.DailyUSD, totals.Pair},
+		{SwapLimitChain, chain.DailyUSD, totals.Chain},
+	}
+	for _, check := range daily {
+		if check.cap > 0 && check.booked+volume.AmountUSD > check.cap {
+			remaining := check.cap - check.booked
+			if remaining < 0 {
+				remaining = 0
+			}
+			return &SwapLimitError{Limit: check.limit, Daily: true, LimitUSD: check.cap, AmountUSD: volume.AmountUSD, RemainingUSD: remaining}
+		}
+	}
+	return nil
+}
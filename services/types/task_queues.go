@@ -0,0 +1,19 @@
+package types
+
+// ChainTaskQueues are the task queues a swap's chain activities run on, carried in the workflow's input so it keeps
+// the routing it started with when the config changes
+type ChainTaskQueues struct {
+	Queues map[int64]string `json:"queues"` // Task queue of each partitioned chain, by canonical chain ID
+	// Sticky runs all of a swap's chain activities on its source chain's queue, including those about its
+	// destination chain, such as polling a bridge transfer
+	Sticky bool `json:"sticky"`
+}
+
+// Queue returns the task queue of a chain's activities, or "" for a chain without its own queue, whose activities
+// run on the workflow's task queue
+func (q *ChainTaskQueues) Queue(chainID int64) string {
+	if q == nil {
+		return ""
+	}
+	return q.Queues[CanonicalChainID(chainID)]
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// TokenMetadata is a token's metadata gathered from published token lists
+type TokenMetadata struct {
+	ChainID     int64     `json:"chainId"`
+	Address     string    `json:"address"`
+	Symbol      string    `json:"symbol"`
+	Name        string    `json:"name"`
+	Decimals    int       `json:"decimals"`
+	LogoURI     string    `json:"logoUri,omitempty"`
+	CoinGeckoID string    `json:"coingeckoId,omitempty"`
+	Verified    bool      `json:"verified"` // Marked verified by at least one token list
+	Sources     []string  `json:"sources"`  // Token lists the metadata came from
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TokenDetails is a token with its metadata and latest USD price, as served by the tokens endpoint
+type TokenDetails struct {
+	Token
+	CoinGeckoID string  `json:"coingeckoId,omitempty"`
+	Verified    bool    `json:"verified"`
+	PriceUSD    float64 `json:"priceUSD,omitempty"`
+}
+
+// UnderlyingSymbol returns the symbol of the token a Universal wrapped token represents, e.g. ETH for uETH
+func (t Token) UnderlyingSymbol() string {
+	if t.IsWrapped && len(t.Symbol) > 1 && t.Symbol[0] == 'u' {
+		return t.Symbol[1:]
+	}
+	return t.Symbol
+}
+
+// NormalizeTokenAddress returns address in the form token metadata is keyed by.
+// EVM addresses are case-insensitive and lowercased; other chains' addresses are case-sensitive.
+func NormalizeTokenAddress(chainID int64, address string) string {
+	if chain, ok := GetChain(chainID); ok && chain.Namespace != "eip155" {
+		return address
+	}
+	return strings.ToLower(address)
+}
@@ -1,8 +1,11 @@
 package types
 
 import (
+	"errors"
 	"math/big"
 	"time"
+
+	chainadapter "github.com/infinity-dex/chains"
 )
 
 // Token represents a cryptocurrency token
@@ -34,6 +37,89 @@ type SwapRequest struct {
 	Deadline           time.Time `json:"deadline"`
 	RefundAddress      string    `json:"refundAddress,omitempty"`
 	RequestID          string    `json:"requestId"`
+
+	// MinOutputAmount is set when the caller accepted a quote up front; the swap fails rather than deliver less
+	MinOutputAmount *big.Int `json:"minOutputAmount,omitempty"`
+
+	// DepositAddress is set for swaps funded by a deposit to it; the deposit arriving confirms the swap
+	DepositAddress string `json:"depositAddress,omitempty"`
+
+	// DepositedAmount is the amount of the deposit that funded the swap, set once it arrived
+	DepositedAmount *big.Int `json:"depositedAmount,omitempty"`
+
+	// UserOperation is the ERC-4337 user operation, signed by the source account's owner, that makes a smart
+	// account source carry out the swap. It is executed through the chain's bundler.
+	UserOperation *chainadapter.UserOperation `json:"userOperation,omitempty"`
+
+	// SponsorGas has the protocol pay the swap's gas on its destination chain, recouping it from the output
+	SponsorGas bool `json:"sponsorGas,omitempty"`
+
+	// Permit is the source address's signed permit letting the chain's DEX contract pull the swap's source tokens,
+	// so the swap needs no separate approve transaction
+	Permit *Permit `json:"permit,omitempty"`
+
+	// CallbackURL is POSTed the swap's result when the swap finishes, signed with the webhook secret of the API key
+	// identified by CallbackKeyID, or with the server's signing secret when that key registered none
+	CallbackURL   string `json:"callbackUrl,omitempty"`
+	CallbackKeyID string `json:"callbackKeyId,omitempty"`
+
+	// DryRun runs the swap's checks and pricing without reserving liquidity, recording transactions or sending
+	// anything on-chain; the swap's result is its projected outcome, with status SwapStatusSimulated
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// PrivateExecution sends the swap's transaction through the source chain's private relay instead of the public
+	// mempool, shielding it from sandwich attacks
+	PrivateExecution bool `json:"privateExecution,omitempty"`
+
+	// Tranches splits the swap into tranches executed one after another, each quoted again before it runs
+	Tranches *TrancheOptions `json:"tranches,omitempty"`
+
+	// FirmQuote is the firm quote the swap was started from. The swap needs no confirmation, fails once the quote
+	// has expired, and delivers no less than the quote's output.
+	FirmQuote *SwapQuote `json:"firmQuote,omitempty"`
+
+	// ForcePriceImpact executes the swap even when its price impact on the pool is over the pool's limit
+	ForcePriceImpact bool `json:"forcePriceImpact,omitempty"`
+}
+
+// TrancheOptions split a large swap into tranches of Size source token base units, the last taking the remainder,
+// executed Delay apart so the pool's price can recover between them
+type TrancheOptions struct {
+	Size  *big.Int      `json:"size"`
+	Delay time.Duration `json:"delay"`
+}
+
+// Amounts returns the amount of each tranche amount is split into
+func (o TrancheOptions) Amounts(amount *big.Int) []*big.Int {
+	var amounts []*big.Int
+	remaining := new(big.Int).Set(amount)
+	for remaining.Sign() > 0 {
+		tranche := new(big.Int).Set(o.Size)
+		if remaining.Cmp(o.Size) < 0 {
+			tranche.Set(remaining)
+		}
+		amounts = append(amounts, tranche)
+		remaining.Sub(remaining, tranche)
+	}
+	return amounts
+}
+
+// TrancheCount returns how many tranches amount is split into, without listing them
+func (o TrancheOptions) TrancheCount(amount *big.Int) *big.Int {
+	count, remainder := new(big.Int).QuoRem(amount, o.Size, new(big.Int))
+	if remainder.Sign() > 0 {
+		count.Add(count, big.NewInt(1))
+	}
+	return count
+}
+
+// IsFastPath reports whether the swap can be quoted and executed in one step:
+// both tokens are already wrapped on the same chain, the caller set a minimum output and the swap isn't split
+// into tranches
+func (r SwapRequest) IsFastPath() bool {
+	return r.MinOutputAmount != nil && r.Tranches == nil &&
+		r.SourceToken.IsWrapped && r.DestinationToken.IsWrapped &&
+		CanonicalChainID(r.SourceToken.ChainID) == CanonicalChainID(r.DestinationToken.ChainID)
 }
 
 // SwapQuote represents a quote for a swap
@@ -46,8 +132,28 @@ type SwapQuote struct {
 	Path             []string `json:"path"`
 	PriceImpact      float64  `json:"priceImpact"`
 	ExchangeRate     float64  `json:"exchangeRate"`
+	Bridge           string   `json:"bridge,omitempty"` // Bridge selected for cross-chain swaps
+	// BridgeTime is how long the bridge of a cross-chain swap is expected to take to deliver it
+	BridgeTime time.Duration `json:"bridgeTime,omitempty"`
+	// MidPrice is the oracle's destination tokens per source token when quoted, before fees; zero without oracle prices
+	MidPrice float64 `json:"midPrice,omitempty"`
+	// PriceAsOf is when the older of the oracle prices the quote used was observed; zero for quotes without oracle prices
+	PriceAsOf time.Time `json:"priceAsOf,omitzero"`
+	// GasSponsorship is the destination chain gas the protocol pays for a swap asking it to, included in Fee.NetworkFee
+	GasSponsorship *GasSponsorship `json:"gasSponsorship,omitempty"`
+	// QuoteID, ExpiresAt and Signature are set on firm quotes, which swaps referencing the quote ID execute at no
+	// worse than until the quote expires
+	QuoteID   string    `json:"quoteId,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	Signature string    `json:"signature,omitempty"` // Hex ed25519 signature of the quote's canonical JSON without it
 }
 
+// ErrQuoteNotFound is returned when no firm quote has an ID
+var ErrQuoteNotFound = errors.New("quote not found")
+
+// ErrQuoteUsed is returned for a firm quote a swap has already executed at
+var ErrQuoteUsed = errors.New("quote has already been used")
+
 // Fee represents the fees for a swap
 type Fee struct {
 	GasFee      *big.Int `json:"gasFee"`
@@ -55,32 +161,47 @@ type Fee struct {
 	NetworkFee  *big.Int `json:"networkFee"`
 	BridgeFee   *big.Int `json:"bridgeFee"`
 	TotalFeeUSD float64  `json:"totalFeeUSD"`
+
+	// GasFeeUSD, ProtocolFeeUSD, NetworkFeeUSD and BridgeFeeUSD value each fee with the oracle; all are zero for fees
+	// priced without it
+	GasFeeUSD      float64 `json:"gasFeeUSD,omitempty"`
+	ProtocolFeeUSD float64 `json:"protocolFeeUSD,omitempty"`
+	NetworkFeeUSD  float64 `json:"networkFeeUSD,omitempty"`
+	BridgeFeeUSD   float64 `json:"bridgeFeeUSD,omitempty"`
+	// PriceUSD is the oracle's USD price of the token the fees are charged in, when it was observed at PriceAsOf.
+	// Both are zero on the totals of fees priced at different times, such as a tranched swap's.
+	PriceUSD  float64   `json:"priceUSD,omitempty"`
+	PriceAsOf time.Time `json:"priceAsOf,omitzero"`
 }
 
 // LiquidityPool represents a liquidity pool
 type LiquidityPool struct {
-	ID             string    `json:"id"`
-	Pair           TokenPair `json:"pair"`
-	TotalLiquidity *big.Int  `json:"totalLiquidity"`
-	TVL            float64   `json:"tvl"`
-	APR            float64   `json:"apr"`
-	FeeTier        int       `json:"feeTier"`
-	Address        string    `json:"address"`
+	ID             string       `json:"id"`
+	Pair           TokenPair    `json:"pair"`
+	TotalLiquidity *big.Int     `json:"totalLiquidity"`
+	TVL            float64      `json:"tvl"`
+	APR            float64      `json:"apr"`
+	FeeTier        int          `json:"feeTier"`
+	Address        string       `json:"address"`
+	FeesAccrued    TokenAmounts `json:"feesAccrued"` // Total swap fees credited to the pool, by token
+	Reserves       TokenAmounts `json:"reserves"`    // Token balances the pool fills swaps from, by token
 }
 
 // LiquidityPosition represents a user's position in a liquidity pool
 type LiquidityPosition struct {
-	PoolID      string   `json:"poolId"`
-	UserAddress string   `json:"userAddress"`
-	TokensOwned *big.Int `json:"tokensOwned"`
-	Share       float64  `json:"share"` // Percentage of pool owned
-	Value       float64  `json:"value"` // USD value of position
+	PoolID      string       `json:"poolId"`
+	UserAddress string       `json:"userAddress"`
+	TokensOwned *big.Int     `json:"tokensOwned"`
+	Share       float64      `json:"share"`       // Percentage of pool owned
+	Value       float64      `json:"value"`       // USD value of position
+	FeesOwed    TokenAmounts `json:"feesOwed"`    // Accrued fees not yet claimed, by token
+	FeesClaimed TokenAmounts `json:"feesClaimed"` // Fees claimed over the position's lifetime, by token
 }
 
 // Transaction represents a blockchain transaction
 type Transaction struct {
 	ID          string    `json:"id"`
-	Type        string    `json:"type"` // swap, add, remove
+	Type        string    `json:"type"` // swap, add, remove, approve
 	Hash        string    `json:"hash"`
 	Status      string    `json:"status"` // pending, completed, failed
 	FromAddress string    `json:"fromAddress"`
@@ -96,15 +217,20 @@ type Transaction struct {
 	Timestamp   time.Time `json:"timestamp"`
 	BlockNumber uint64    `json:"blockNumber"`
 	WorkflowID  string    `json:"workflowId"`
+	Fee         *Fee      `json:"fee,omitempty"` // Fees charged, recorded when the transaction is submitted
 }
 
 // ChainStatus represents the status of a blockchain
 type ChainStatus struct {
-	Name      string   `json:"name"`
-	ChainID   int64    `json:"chainId"`
-	IsActive  bool     `json:"isActive"`
-	GasPrice  *big.Int `json:"gasPrice"`
-	BlockTime int      `json:"blockTime"` // Average time between blocks in seconds
+	Name         string    `json:"name"`
+	ChainID      int64     `json:"chainId"`
+	IsActive     bool      `json:"isActive"`
+	GasPrice     *big.Int  `json:"gasPrice"`
+	BaseFee      *big.Int  `json:"baseFee,omitempty"`     // Next block's EIP-1559 base fee
+	PriorityFee  *big.Int  `json:"priorityFee,omitempty"` // Median EIP-1559 priority fee of recent blocks
+	GasUpdatedAt time.Time `json:"gasUpdatedAt,omitzero"`
+	BlockTime    int       `json:"blockTime"`             // Average time between blocks in seconds
+	BlockTimeMs  int64     `json:"blockTimeMs,omitempty"` // Average time between blocks in milliseconds, for sub-second chains
 }
 
 // SwapResult represents the result of a swap operation
@@ -118,5 +244,80 @@ type SwapResult struct {
 	OutputAmount   *big.Int    `json:"outputAmount"`
 	Fee            Fee         `json:"fee"`
 	CompletionTime time.Time   `json:"completionTime"`
+	Status         SwapStatus  `json:"status,omitempty"`
 	ErrorMessage   string      `json:"errorMessage,omitempty"`
+	ErrorCode      string      `json:"errorCode,omitempty"` // Why a failed swap failed, e.g. SwapErrorQuoteDrift
+
+	// Tranches are the fills of a swap split into tranches, in order; the result totals the filled ones
+	Tranches []TrancheFill `json:"tranches,omitempty"`
+}
+
+// TrancheFill is the outcome of one tranche of a swap split into tranches
+type TrancheFill struct {
+	Index        int        `json:"index"`     // From 1
+	RequestID    string     `json:"requestId"` // The swap's request ID suffixed with the tranche's index
+	InputAmount  *big.Int   `json:"inputAmount"`
+	QuotedOutput *big.Int   `json:"quotedOutput,omitempty"` // Quoted just before the tranche ran
+	OutputAmount *big.Int   `json:"outputAmount,omitempty"`
+	Fee          *Fee       `json:"fee,omitempty"`
+	TxHash       string     `json:"txHash,omitempty"`
+	Status       SwapStatus `json:"status"` // completed, failed, or skipped when an earlier tranche stopped the swap
+	ErrorMessage string     `json:"errorMessage,omitempty"`
+	ErrorCode    string     `json:"errorCode,omitempty"`
+	ExecutedAt   *time.Time `json:"executedAt,omitempty"`
+}
+
+// SwapErrorQuoteDrift is the error code of swaps stopped because the price moved past their slippage since the quote
+const SwapErrorQuoteDrift = "QUOTE_DRIFT"
+
+// SwapErrorQuoteExpired is the error code of swaps started from a firm quote that expired before they executed
+const SwapErrorQuoteExpired = "QUOTE_EXPIRED"
+
+// SwapErrorPriceImpact is the error code of swaps that would move their pool's price by more than its limit
+const SwapErrorPriceImpact = "PRICE_IMPACT_TOO_HIGH"
+
+// SwapErrorInsufficientBalance is the error code of swaps whose source address can't pay their amount and gas
+const SwapErrorInsufficientBalance = "INSUFFICIENT_BALANCE"
+
+// SwapStatus is where a swap is in its execution
+type SwapStatus string
+
+// Swap statuses
+const (
+	SwapStatusPending   SwapStatus = "pending"
+	SwapStatusCompleted SwapStatus = "completed"
+	SwapStatusFailed    SwapStatus = "failed"
+	SwapStatusSimulated SwapStatus = "simulated" // A dry run's projected result; nothing was executed
+
+	// A swap split into tranches that stopped after filling some of them; its result totals the filled tranches
+	SwapStatusPartiallyFilled SwapStatus = "partially_filled"
+
+	// A tranche never run because an earlier one failed or the swap was cancelled
+	SwapStatusSkipped SwapStatus = "skipped"
+)
+
+// ErrSwapNotFound is returned for swaps that were never submitted
+var ErrSwapNotFound = errors.New("swap not found")
+
+// LiquidityRequest represents a user request to add or remove pool liquidity
+type LiquidityRequest struct {
+	PoolID      string   `json:"poolId"`
+	UserAddress string   `json:"userAddress"`
+	Token       Token    `json:"token"`
+	Amount      *big.Int `json:"amount"`
+	RequestID   string   `json:"requestId"`
+}
+
+// LiquidityResult represents the result of a liquidity add or remove operation
+type LiquidityResult struct {
+	RequestID      string             `json:"requestId"`
+	PoolID         string             `json:"poolId"`
+	Action         string             `json:"action"` // add, remove
+	Success        bool               `json:"success"`
+	Amount         *big.Int           `json:"amount"`
+	Position       *LiquidityPosition `json:"position,omitempty"`
+	Transaction    Transaction        `json:"transaction"`
+	WrapTxHash     string             `json:"wrapTxHash,omitempty"`
+	CompletionTime time.Time          `json:"completionTime"`
+	ErrorMessage   string             `json:"errorMessage,omitempty"`
 }
@@ -128,6 +128,31 @@ func TestSwapRequestStruct(t *testing.T) {
 	}
 }
 
+func TestSwapRequestIsFastPath(t *testing.T) {
+	uETH := Token{Symbol: "uETH", ChainID: ChainIDEthereum, IsWrapped: true}
+	uUSDC := Token{Symbol: "uUSDC", ChainID: ChainIDEthereum, IsWrapped: true}
+	minOutput := big.NewInt(1)
+
+	tests := []struct {
+		name    string
+		request SwapRequest
+		want    bool
+	}{
+		{"SameChainWrapped", SwapRequest{SourceToken: uETH, DestinationToken: uUSDC, MinOutputAmount: minOutput}, true},
+		{"NoMinimumOutput", SwapRequest{SourceToken: uETH, DestinationToken: uUSDC}, false},
+		{"Unwrapped", SwapRequest{SourceToken: Token{Symbol: "ETH", ChainID: ChainIDEthereum}, DestinationToken: uUSDC, MinOutputAmount: minOutput}, false},
+		{"CrossChain", SwapRequest{SourceToken: uETH, DestinationToken: Token{Symbol: "uUSDC", ChainID: ChainIDPolygon, IsWrapped: true}, MinOutputAmount: minOutput}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.request.IsFastPath(); got != tt.want {
+				t.Errorf("Expected IsFastPath %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestFeeStruct(t *testing.T) {
 	// Create fee
 	fee := Fee{
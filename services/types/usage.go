@@ -0,0 +1,34 @@
+package types
+
+import "math/big"
+
+// UsageMonthFormat is the time layout of usage months, in UTC
+const UsageMonthFormat = "2006-01"
+
+// APIUsage is one API key's use of an endpoint in a month
+type APIUsage struct {
+	KeyID       string `json:"keyId"`            // Fingerprint of the API key; keys themselves are never stored
+	Tenant      string `json:"tenant,omitempty"` // Tenant the key belongs to, if any
+	Month       string `json:"month"`
+	Endpoint    string `json:"endpoint"` // Route pattern, e.g. "POST /api/v1/swap", or gRPC method
+	Requests    int64  `json:"requests"`
+	EgressBytes int64  `json:"egressBytes"` // Response body bytes sent
+}
+
+// SwapVolume is the amount of one source token an API key swapped in a month
+type SwapVolume struct {
+	KeyID   string   `json:"keyId"`
+	Tenant  string   `json:"tenant,omitempty"`
+	Month   string   `json:"month"`
+	ChainID int64    `json:"chainId"`
+	Token   string   `json:"token"` // Source token symbol
+	Swaps   int64    `json:"swaps"`
+	Amount  *big.Int `json:"amount"` // In the token's smallest unit
+}
+
+// UsageReport is every API key's usage in a month
+type UsageReport struct {
+	Month      string       `json:"month"`
+	Endpoints  []APIUsage   `json:"endpoints"`
+	SwapVolume []SwapVolume `json:"swapVolume"`
+}
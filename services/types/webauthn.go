@@ -0,0 +1,56 @@
+package types
+
+import "time"
+
+// COSE algorithm identifiers of the passkey signature algorithms the server accepts
+const (
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+)
+
+// WebAuthnCredential is an enrolled passkey
+type WebAuthnCredential struct {
+	ID         string     `json:"id"`        // Base64url credential ID
+	Owner      string     `json:"owner"`     // Fingerprint of the admin API key the passkey was enrolled with
+	Name       string     `json:"name"`      // Operator-chosen label
+	PublicKey  []byte     `json:"publicKey"` // DER SubjectPublicKeyInfo
+	Algorithm  int        `json:"algorithm"` // COSE algorithm
+	SignCount  uint32     `json:"signCount"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// PublicKeyCredentialDescriptor identifies a credential in WebAuthn options
+type PublicKeyCredentialDescriptor struct {
+	Type string `json:"type"` // public-key
+	ID   string `json:"id"`   // Base64url
+}
+
+// WebAuthnRegistrationResponse is a browser's PublicKeyCredential.toJSON() after navigator.credentials.create()
+type WebAuthnRegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON     string   `json:"clientDataJSON"`
+		AuthenticatorData  string   `json:"authenticatorData"`
+		PublicKey          string   `json:"publicKey"`
+		PublicKeyAlgorithm int      `json:"publicKeyAlgorithm"`
+		AttestationObject  string   `json:"attestationObject,omitempty"`
+		Transports         []string `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// WebAuthnAssertionResponse is a browser's PublicKeyCredential.toJSON() after navigator.credentials.get()
+type WebAuthnAssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
@@ -0,0 +1,6 @@
+package types
+
+import "errors"
+
+// ErrWebhookSecretNotFound is returned when an API key has not registered a webhook secret
+var ErrWebhookSecretNotFound = errors.New("webhook secret not found")
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// UsageStore persists monthly API usage totals, so billing survives restarts and covers every server
+type UsageStore interface {
+	// AddUsage adds request counts and swap volume to the stored totals of their key, month, endpoint and token
+	AddUsage(ctx context.Context, usage []types.APIUsage, volume []types.SwapVolume) error
+	// MonthlyUsage returns every key's totals for a month
+	MonthlyUsage(ctx context.Context, month string) (*types.UsageReport, error)
+}
+
+// usageKey identifies one key's use of an endpoint in a month
+type usageKey struct {
+	keyID, month, endpoint string
+}
+
+// volumeKey identifies one key's swaps of a token in a month
+type volumeKey struct {
+	keyID, month string
+	chainID      int64
+	token        string
+}
+
+// InMemoryUsageStore is a UsageStore for running without a database
+type InMemoryUsageStore struct {
+	usage  map[usageKey]types.APIUsage
+	volume map[volumeKey]types.SwapVolume
+	mu     sync.RWMutex
+}
+
+// NewInMemoryUsageStore creates an empty usage store
+func NewInMemoryUsageStore() *InMemoryUsageStore {
+	return &InMemoryUsageStore{
+		usage:  make(map[usageKey]types.APIUsage),
+		volume: make(map[volumeKey]types.SwapVolume),
+	}
+}
+
+// AddUsage adds usage to the stored totals
+func (s *InMemoryUsageStore) AddUsage(ctx context.Context, usage []types.APIUsage, volume []types.SwapVolume) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range usage {
+		key := usageKey{u.KeyID, u.Month, u.Endpoint}
+		total := s.usage[key]
+		u.Requests += total.Requests
+		u.EgressBytes += total.EgressBytes
+		s.usage[key] = u
+	}
+	for _, v := range volume {
+		key := volumeKey{v.KeyID, v.Month, v.ChainID, v.Token}
+		total := s.volume[key]
+		v.Swaps += total.Swaps
+		if total.Amount != nil {
+			v.Amount = new(big.Int).Add(v.Amount, total.Amount)
+		}
+		s.volume[key] = v
+	}
+	return nil
+}
+
+// MonthlyUsage returns a month's totals ordered by key
+func (s *InMemoryUsageStore) MonthlyUsage(ctx context.Context, month string) (*types.UsageReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := &types.UsageReport{Month: month, Endpoints: []types.APIUsage{}, SwapVolume: []types.SwapVolume{}}
+	for key, u := range s.usage {
+		if key.month == month {
+			report.Endpoints = append(report.Endpoints, u)
+		}
+	}
+	for key, v := range s.volume {
+		if key.month == month {
+			v.Amount = new(big.Int).Set(v.Amount)
+			report.SwapVolume = append(report.SwapVolume, v)
+		}
+	}
+	sortUsageReport(report)
+	return report, nil
+}
+
+// sortUsageReport orders a report by key, then endpoint or token
+func sortUsageReport(report *types.UsageReport) {
+	sort.Slice(report.Endpoints, func(i, j int) bool {
+		a, b := report.Endpoints[i], report.Endpoints[j]
+		if a.KeyID != b.KeyID {
+			return a.KeyID < b.KeyID
+		}
+		return a.Endpoint < b.Endpoint
+	})
+	sort.Slice(report.SwapVolume, func(i, j int) bool {
+		a, b := report.SwapVolume[i], report.SwapVolume[j]
+		if a.KeyID != b.KeyID {
+			return a.KeyID < b.KeyID
+		}
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		return a.Token < b.Token
+	})
+}
+
+// UsageMeter counts API requests and swaps per key. Counts are kept in memory and added to the store when flushed,
+// so metering adds no database write to each request.
+type UsageMeter struct {
+	store   UsageStore
+	pending map[usageKey]types.APIUsage
+	volume  map[volumeKey]types.SwapVolume
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// NewUsageMeter creates a meter flushing its counts to store
+func NewUsageMeter(store UsageStore) *UsageMeter {
+	return &UsageMeter{
+		store:   store,
+		pending: make(map[usageKey]types.APIUsage),
+		volume:  make(map[volumeKey]types.SwapVolume),
+		now:     time.Now,
+	}
+}
+
+// SetStore sets the store counts are flushed to
+func (m *UsageMeter) SetStore(store UsageStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+}
+
+// RecordRequest counts a request to endpoint and the bytes of its response
+func (m *UsageMeter) RecordRequest(keyID, tenant, endpoint string, egressBytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	month := m.now().UTC().Format(types.UsageMonthFormat)
+	key := usageKey{keyID, month, endpoint}
+	usage := m.pending[key]
+	m.pending[key] = types.APIUsage{
+		KeyID:       keyID,
+		Tenant:      tenant,
+		Month:       month,
+		Endpoint:    endpoint,
+		Requests:    usage.Requests + 1,
+		EgressBytes: usage.EgressBytes + egressBytes,
+	}
+}
+
+// RecordSwap counts a swap of amount of token
+func (m *UsageMeter) RecordSwap(keyID, tenant string, token types.Token, amount *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	month := m.now().UTC().Format(types.UsageMonthFormat)
+	chainID := types.CanonicalChainID(token.ChainID)
+	key := volumeKey{keyID, month, chainID, token.Symbol}
+	volume := m.volume[key]
+	total := new(big.Int).Set(amount)
+	if volume.Amount != nil {
+		total.Add(total, volume.Amount)
+	}
+	m.volume[key] = types.SwapVolume{
+		KeyID:   keyID,
+		Tenant:  tenant,
+		Month:   month,
+		ChainID: chainID,
+		Token:   token.Symbol,
+		Swaps:   volume.Swaps + 1,
+		Amount:  total,
+	}
+}
+
+// Flush adds the counts recorded since the last flush to the store. Counts the store refuses are kept for the next flush.
+func (m *UsageMeter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	pending, volume, store := m.pending, m.volume, m.store
+	m.pending = make(map[usageKey]types.APIUsage)
+	m.volume = make(map[volumeKey]types.SwapVolume)
+	m.mu.Unlock()
+
+	if len(pending) == 0 && len(volume) == 0 {
+		return nil
+	}
+	usage := make([]types.APIUsage, 0, len(pending))
+	for _, u := range pending {
+		usage = append(usage, u)
+	}
+	volumes := make([]types.SwapVolume, 0, len(volume))
+	for _, v := range volume {
+		volumes = append(volumes, v)
+	}
+	if err := store.AddUsage(ctx, usage, volumes); err != nil {
+		m.mu.Lock()
+		for _, u := range usage {
+			m.recordUsage(u)
+		}
+		for _, v := range volumes {
+			m.recordVolume(v)
+		}
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// recordUsage adds unflushed usage back to the pending counts; the caller holds the lock
+func (m *UsageMeter) recordUsage(u types.APIUsage) {
+	key := usageKey{u.KeyID, u.Month, u.Endpoint}
+	pending := m.pending[key]
+	u.Requests += pending.Requests
+	u.EgressBytes += pending.EgressBytes
+	m.pending[key] = u
+}
+
+// recordVolume adds unflushed volume back to the pending counts; the caller holds the lock
+func (m *UsageMeter) recordVolume(v types.SwapVolume) {
+	key := volumeKey{v.KeyID, v.Month, v.ChainID, v.Token}
+	if pending, ok := m.volume[key]; ok {
+		v.Swaps += pending.Swaps
+		v.Amount = new(big.Int).Add(v.Amount, pending.Amount)
+	}
+	m.volume[key] = v
+}
+
+// StartFlushing flushes the meter every interval until ctx is done
+func (m *UsageMeter) StartFlushing(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Flush(ctx); err != nil {
+					log.Printf("Failed to flush API usage: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Report flushes the meter and returns every key's usage in month
+func (m *UsageMeter) Report(ctx context.Context, month string) (*types.UsageReport, error) {
+	if err := m.Flush(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	store := m.store
+	m.mu.Unlock()
+	return store.MonthlyUsage(ctx, month)
+}
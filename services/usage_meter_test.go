@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// failingUsageStore refuses usage until it is told to accept it
+type failingUsageStore struct {
+	*InMemoryUsageStore
+	fail bool
+}
+
+func (s *failingUsageStore) AddUsage(ctx context.Context, usage []types.APIUsage, volume []types.SwapVolume) error {
+	if s.fail {
+		return errors.New("database unavailable")
+	}
+	return s.InMemoryUsageStore.AddUsage(ctx, usage, volume)
+}
+
+func TestUsageMeter(t *testing.T) {
+	ctx := context.Background()
+	store := &failingUsageStore{InMemoryUsageStore: NewInMemoryUsageStore()}
+	meter := NewUsageMeter(store)
+	now := time.Date(2026, 10, 31, 23, 59, 0, 0, time.UTC)
+	meter.now = func() time.Time { return now }
+
+	eth := types.Token{Symbol: "ETH", ChainID: 1}
+	meter.RecordRequest("key-a", "acme", "POST /api/v1/swap", 100)
+	meter.RecordRequest("key-a", "acme", "POST /api/v1/swap", 50)
+	meter.RecordSwap("key-a", "acme", eth, big.NewInt(3))
+	if err := meter.Flush(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Counts the store refuses are kept for the next flush
+	store.fail = true
+	meter.RecordRequest("key-a", "acme", "POST /api/v1/swap", 25)
+	meter.RecordSwap("key-a", "acme", eth, big.NewInt(4))
+	if err := meter.Flush(ctx); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+	store.fail = false
+
+	// Usage is counted in the month it happened in
+	now = now.Add(time.Hour)
+	meter.RecordRequest("key-b", "", "GET /api/v1/tokens", 10)
+
+	report, err := meter.Report(ctx, "2026-10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.Endpoints) != 1 || report.Endpoints[0].Requests != 3 || report.Endpoints[0].EgressBytes != 175 {
+		t.Errorf("Expected 3 swap requests sending 175 bytes, got %+v", report.Endpoints)
+	}
+	if len(report.SwapVolume) != 1 || report.SwapVolume[0].Swaps != 2 || report.SwapVolume[0].Amount.Int64() != 7 {
+		t.Errorf("Expected 2 swaps of 7 ETH, got %+v", report.SwapVolume)
+	}
+
+	november, _ := meter.Report(ctx, "2026-11")
+	if len(november.Endpoints) != 1 || november.Endpoints[0].KeyID != "key-b" {
+		t.Errorf("Expected key-b's request in November, got %+v", november.Endpoints)
+	}
+}
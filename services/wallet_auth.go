@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/chains/signer"
+)
+
+// WalletAuthScheme is how a wallet signed a WalletAuth
+type WalletAuthScheme string
+
+// Wallet auth schemes
+const (
+	WalletAuthPersonalSign WalletAuthScheme = "eip191" // personal_sign of WalletAuthMessage
+	WalletAuthTypedData    WalletAuthScheme = "eip712" // eth_signTypedData_v4 of a SwapHistoryAccess struct
+)
+
+// MaxWalletAuthLifetime is how far ahead a wallet auth may expire, bounding how long a leaked signature can be
+// replayed
+const MaxWalletAuthLifetime = time.Hour
+
+// Wallet auth errors
+var (
+	ErrWalletAuthExpired = errors.New("wallet signature has expired")
+	ErrWalletAuthInvalid = errors.New("wallet signature is invalid")
+)
+
+// EIP-712 domain and type of wallet auths signed as typed data
+const (
+	walletAuthDomainName    = "Infinity DEX"
+	walletAuthDomainVersion = "1"
+	walletAuthDomainType    = "EIP712Domain(string name,string version)"
+	walletAuthStructType    = "SwapHistoryAccess(address account,uint256 expiresAt)"
+)
+
+// WalletAuth is a wallet owner's signature proving they control Address, valid until ExpiresAt
+type WalletAuth struct {
+	Address   string
+	ExpiresAt time.Time
+	Scheme    WalletAuthScheme
+	Signature []byte
+}
+
+// WalletAuthMessage returns the message a wallet signs with personal_sign to authenticate as address until
+// expiresAt
+func WalletAuthMessage(address string, expiresAt time.Time) string {
+	return fmt.Sprintf("Infinity DEX: view the swap history of %s until %d", strings.ToLower(address), expiresAt.Unix())
+}
+
+// WalletAuthTypedDataDigest returns the EIP-712 digest of the SwapHistoryAccess struct a wallet signs with
+// eth_signTypedData_v4 to authenticate as address until expiresAt
+func WalletAuthTypedDataDigest(address string, expiresAt time.Time) ([]byte, error) {
+	account, err := evmAddressBytes(address)
+	if err != nil {
+		return nil, err
+	}
+
+	domainSeparator := evm.Keccak256(
+		evm.Keccak256([]byte(walletAuthDomainType)),
+		evm.Keccak256([]byte(walletAuthDomainName)),
+		evm.Keccak256([]byte(walletAuthDomainVersion)),
+	)
+	structHash := evm.Keccak256(
+		evm.Keccak256([]byte(walletAuthStructType)),
+		leftPad32(account),
+		leftPad32(big.NewInt(expiresAt.Unix()).Bytes()),
+	)
+	return signer.TypedDataDigest(domainSeparator, structHash), nil
+}
+
+// VerifyWalletAuth checks that auth was signed by its address and is still valid at now
+func VerifyWalletAuth(auth WalletAuth, now time.Time) error {
+	if !now.Before(auth.ExpiresAt) {
+		return ErrWalletAuthExpired
+	}
+	if auth.ExpiresAt.After(now.Add(MaxWalletAuthLifetime)) {
+		return fmt.Errorf("%w: expires more than %s ahead", ErrWalletAuthInvalid, MaxWalletAuthLifetime)
+	}
+
+	var digest []byte
+	switch auth.Scheme {
+	case WalletAuthPersonalSign:
+		if _, err := evmAddressBytes(auth.Address); err != nil {
+			return err
+		}
+		digest = signer.PersonalMessageDigest([]byte(WalletAuthMessage(auth.Address, auth.ExpiresAt)))
+	case WalletAuthTypedData:
+		var err error
+		if digest, err = WalletAuthTypedDataDigest(auth.Address, auth.ExpiresAt); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown scheme %q", ErrWalletAuthInvalid, auth.Scheme)
+	}
+
+	recovered, err := signer.RecoverAddress(digest, auth.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWalletAuthInvalid, err)
+	}
+	if !strings.EqualFold(recovered, auth.Address) {
+		return fmt.Errorf("%w: signed by %s", ErrWalletAuthInvalid, recovered)
+	}
+	return nil
+}
+
+// evmAddressBytes decodes a 0x-prefixed 20-byte EVM address
+func evmAddressBytes(address string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil || len(raw) != 20 || !strings.HasPrefix(address, "0x") {
+		return nil, fmt.Errorf("%w: %q is not an EVM address", ErrWalletAuthInvalid, address)
+	}
+	return raw, nil
+}
+
+// leftPad32 returns b left-padded with zeros to an ABI word
+func leftPad32(b []byte) []byte {
+	out := make([]byte, 32)
+	copy(out[32-len(b):], b)
+	return out
+}
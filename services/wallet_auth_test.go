@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/chains/signer"
+)
+
+// signWalletAuth signs a wallet auth for key's address with scheme
+func signWalletAuth(t *testing.T, key *signer.PrivateKeySigner, scheme WalletAuthScheme, expiresAt time.Time) WalletAuth {
+	t.Helper()
+	digest := signer.PersonalMessageDigest([]byte(WalletAuthMessage(key.Address(), expiresAt)))
+	if scheme == WalletAuthTypedData {
+		var err error
+		if digest, err = WalletAuthTypedDataDigest(key.Address(), expiresAt); err != nil {
+			t.Fatalf("Failed to hash typed data: %v", err)
+		}
+	}
+	signature, err := key.SignDigest(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	raw := make([]byte, 65)
+	signature.R.FillBytes(raw[:32])
+	signature.S.FillBytes(raw[32:64])
+	raw[64] = 27 + signature.Recovery
+	return WalletAuth{Address: key.Address(), ExpiresAt: expiresAt, Scheme: scheme, Signature: raw}
+}
+
+func TestVerifyWalletAuth(t *testing.T) {
+	key, err := signer.NewPrivateKeySigner("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	other, _ := signer.NewPrivateKeySigner("0101010101010101010101010101010101010101010101010101010101010101")
+	now := time.Unix(1790000000, 0)
+	expiresAt := now.Add(10 * time.Minute)
+
+	for _, scheme := range []WalletAuthScheme{WalletAuthPersonalSign, WalletAuthTypedData} {
+		auth := signWalletAuth(t, key, scheme, expiresAt)
+		if err := VerifyWalletAuth(auth, now); err != nil {
+			t.Errorf("Expected a valid %s signature, got %v", scheme, err)
+		}
+
+		// The address is matched in any case
+		lower := auth
+		lower.Address = strings.ToLower(auth.Address)
+		if err := VerifyWalletAuth(lower, now); err != nil {
+			t.Errorf("Expected a %s signature to verify for the lower-case address, got %v", scheme, err)
+		}
+
+		if err := VerifyWalletAuth(auth, expiresAt); !errors.Is(err, ErrWalletAuthExpired) {
+			t.Errorf("Expected an expired %s signature to be rejected, got %v", scheme, err)
+		}
+
+		// The signature covers the expiry, so it can't be extended
+		extended := auth
+		extended.ExpiresAt = expiresAt.Add(time.Minute)
+		if err := VerifyWalletAuth(extended, now); !errors.Is(err, ErrWalletAuthInvalid) {
+			t.Errorf("Expected an extended %s signature to be rejected, got %v", scheme, err)
+		}
+
+		// Another wallet can't sign for the address
+		forged := signWalletAuth(t, other, scheme, expiresAt)
+		forged.Address = key.Address()
+		if err := VerifyWalletAuth(forged, now); !errors.Is(err, ErrWalletAuthInvalid) {
+			t.Errorf("Expected a %s signature by another wallet to be rejected, got %v", scheme, err)
+		}
+	}
+
+	// Signatures lasting too long would be replayable for too long
+	longLived := signWalletAuth(t, key, WalletAuthPersonalSign, now.Add(2*MaxWalletAuthLifetime))
+	if err := VerifyWalletAuth(longLived, now); !errors.Is(err, ErrWalletAuthInvalid) {
+		t.Errorf("Expected a long-lived signature to be rejected, got %v", err)
+	}
+
+	auth := signWalletAuth(t, key, WalletAuthPersonalSign, expiresAt)
+	auth.Scheme = "eip1271"
+	if err := VerifyWalletAuth(auth, now); !errors.Is(err, ErrWalletAuthInvalid) {
+		t.Errorf("Expected an unknown scheme to be rejected, got %v", err)
+	}
+	auth = signWalletAuth(t, key, WalletAuthPersonalSign, expiresAt)
+	auth.Address = "So11111111111111111111111111111111111111112"
+	if err := VerifyWalletAuth(auth, now); !errors.Is(err, ErrWalletAuthInvalid) {
+		t.Errorf("Expected a non-EVM address to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// Authenticator data flags
+const (
+	authFlagUserPresent      = 0x01
+	authFlagAttestedCredData = 0x40
+)
+
+// ErrSignCountRegressed is returned when an authenticator's signature counter went backwards,
+// which means the passkey may have been cloned
+var ErrSignCountRegressed = errors.New("authenticator signature counter went backwards")
+
+// WebAuthnVerifier checks passkey registrations and assertions for one relying party.
+// Only "none" attestation is supported: the authenticator's make and model are not verified.
+type WebAuthnVerifier struct {
+	rpID    string
+	origins []string
+	now     func() time.Time
+}
+
+// NewWebAuthnVerifier creates a verifier for the relying party rpID, accepting ceremonies from origins
+func NewWebAuthnVerifier(rpID string, origins []string) *WebAuthnVerifier {
+	return &WebAuthnVerifier{
+		rpID:    rpID,
+		origins: origins,
+		now:     time.Now,
+	}
+}
+
+// RPID returns the relying party ID credentials are scoped to
+func (v *WebAuthnVerifier) RPID() string {
+	return v.rpID
+}
+
+// collectedClientData is the client data the browser signs over
+type collectedClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// authenticatorData is the parsed authenticator data
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte // Only present in registrations
+}
+
+// VerifyRegistration checks a registration answered challenge and returns the new credential owned by owner
+func (v *WebAuthnVerifier) VerifyRegistration(challenge []byte, owner string, resp types.WebAuthnRegistrationResponse) (*types.WebAuthnCredential, error) {
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("unsupported credential type %q", resp.Type)
+	}
+	if _, err := v.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := decodeBase64URL(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authenticatorData: %w", err)
+	}
+	authData, err := v.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.credentialID == nil {
+		return nil, errors.New("authenticator data has no attested credential")
+	}
+
+	rawID, err := decodeBase64URL(resp.RawID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rawId: %w", err)
+	}
+	if !bytes.Equal(rawID, authData.credentialID) {
+		return nil, errors.New("credential ID does not match authenticator data")
+	}
+
+	publicKey, err := decodeBase64URL(resp.Response.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid publicKey: %w", err)
+	}
+	if _, err := parseCredentialPublicKey(publicKey, resp.Response.PublicKeyAlgorithm); err != nil {
+		return nil, err
+	}
+
+	return &types.WebAuthnCredential{
+		ID:        base64.RawURLEncoding.EncodeToString(rawID),
+		Owner:     owner,
+		PublicKey: publicKey,
+		Algorithm: resp.Response.PublicKeyAlgorithm,
+		SignCount: authData.signCount,
+		CreatedAt: v.now().UTC(),
+	}, nil
+}
+
+// VerifyAssertion checks an assertion answered challenge with credential, returning the authenticator's new signature count
+func (v *WebAuthnVerifier) VerifyAssertion(challenge []byte, credential types.WebAuthnCredential, resp types.WebAuthnAssertionResponse) (uint32, error) {
+	if resp.Type != "public-key" {
+		return 0, fmt.Errorf("unsupported credential type %q", resp.Type)
+	}
+	clientDataJSON, err := v.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := decodeBase64URL(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("invalid authenticatorData: %w", err)
+	}
+	authData, err := v.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	signature, err := decodeBase64URL(resp.Response.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("invalid signature: %w", err)
+	}
+	publicKey, err := parseCredentialPublicKey(credential.PublicKey, credential.Algorithm)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifySignature(publicKey, credential.Algorithm, signed, signature); err != nil {
+		return 0, err
+	}
+
+	// Authenticators that do not count signatures always report 0
+	if authData.signCount != 0 || credential.SignCount != 0 {
+		if authData.signCount <= credential.SignCount {
+			return 0, ErrSignCountRegressed
+		}
+	}
+
+	return authData.signCount, nil
+}
+
+// verifyClientData checks the client data's ceremony type, challenge and origin, returning its raw bytes
+func (v *WebAuthnVerifier) verifyClientData(encoded, ceremony string, challenge []byte) ([]byte, error) {
+	raw, err := decodeBase64URL(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+	var clientData collectedClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+
+	if clientData.Type != ceremony {
+		return nil, fmt.Errorf("expected a %s ceremony, got %q", ceremony, clientData.Type)
+	}
+	got, err := decodeBase64URL(clientData.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return nil, errors.New("challenge does not match")
+	}
+	if !slices.Contains(v.origins, clientData.Origin) {
+		return nil, fmt.Errorf("origin %q is not allowed", clientData.Origin)
+	}
+
+	return raw, nil
+}
+
+// parseAuthenticatorData parses authenticator data and checks it is for this relying party with the user present
+func (v *WebAuthnVerifier) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("authenticator data is too short")
+	}
+	data := &authenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	rpIDHash := sha256.Sum256([]byte(v.rpID))
+	if !bytes.Equal(data.rpIDHash, rpIDHash[:]) {
+		return nil, errors.New("credential is for another relying party")
+	}
+	if data.flags&authFlagUserPresent == 0 {
+		return nil, errors.New("user was not present")
+	}
+
+	if data.flags&authFlagAttestedCredData != 0 {
+		// AAGUID, then the length-prefixed credential ID
+		rest := raw[37:]
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data is too short")
+		}
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		if len(rest) < 18+idLen {
+			return nil, errors.New("attested credential data is too short")
+		}
+		data.credentialID = rest[18 : 18+idLen]
+	}
+
+	return data, nil
+}
+
+// parseCredentialPublicKey parses a DER public key and checks it suits the COSE algorithm
+func parseCredentialPublicKey(der []byte, algorithm int) (crypto.PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if algorithm == types.COSEAlgES256 && k.Curve == elliptic.P256() {
+			return k, nil
+		}
+	case ed25519.PublicKey:
+		if algorithm == types.COSEAlgEdDSA {
+			return k, nil
+		}
+	case *rsa.PublicKey:
+		if algorithm == types.COSEAlgRS256 {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported public key algorithm %d", algorithm)
+}
+
+// verifySignature checks an assertion signature
+func verifySignature(key crypto.PublicKey, algorithm int, signed, signature []byte) error {
+	digest := sha256.Sum256(signed)
+
+	var ok bool
+	switch algorithm {
+	case types.COSEAlgES256:
+		ok = ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), digest[:], signature)
+	case types.COSEAlgEdDSA:
+		ok = ed25519.Verify(key.(ed25519.PublicKey), signed, signature)
+	case types.COSEAlgRS256:
+		ok = rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	}
+	if !ok {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// decodeBase64URL decodes base64url with or without padding, as browsers and libraries differ
+func decodeBase64URL(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("empty value")
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// recoveryCodeCount is how many recovery codes are issued at a time
+const recoveryCodeCount = 10
+
+var (
+	// ErrCredentialNotFound is returned when a passkey is not enrolled
+	ErrCredentialNotFound = errors.New("credential not found")
+
+	// ErrLastCredential is returned when removing an owner's only passkey, which would let the
+	// API key alone enroll a new one
+	ErrLastCredential = errors.New("cannot remove the only enrolled passkey; enroll a replacement first")
+)
+
+// webAuthnState is the persisted passkey state
+type webAuthnState struct {
+	Credentials   []types.WebAuthnCredential `json:"credentials"`
+	RecoveryCodes map[string][]string        `json:"recoveryCodes"`                  // map[owner]SHA-256 of each unused code
+	UsedTokens    []string                   `json:"usedEnrollmentTokens,omitempty"` // SHA-256 of each enrollment token already used
+}
+
+// WebAuthnStore stores enrolled passkeys and recovery codes, persisting them to a JSON file
+type WebAuthnStore struct {
+	path  string // empty keeps state in memory only
+	state webAuthnState
+	mu    sync.RWMutex
+}
+
+// NewWebAuthnStore creates a store persisted at path, loading any existing state; an empty path keeps state in memory
+func NewWebAuthnStore(path string) (*WebAuthnStore, error) {
+	s := &WebAuthnStore{
+		path:  path,
+		state: webAuthnState{RecoveryCodes: make(map[string][]string)},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passkey store: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to parse passkey store: %w", err)
+	}
+	if s.state.RecoveryCodes == nil {
+		s.state.RecoveryCodes = make(map[string][]string)
+	}
+	return s, nil
+}
+
+// Credentials returns the passkeys enrolled by owner
+func (s *WebAuthnStore) Credentials(owner string) []types.WebAuthnCredential {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var credentials []types.WebAuthnCredential
+	for _, c := range s.state.Credentials {
+		if c.Owner == owner {
+			credentials = append(credentials, c)
+		}
+	}
+	return credentials
+}
+
+// Credential returns an owner's passkey by ID
+func (s *WebAuthnStore) Credential(owner, id string) (types.WebAuthnCredential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, c := range s.state.Credentials {
+		if c.Owner == owner && c.ID == id {
+			return c, nil
+		}
+	}
+	return types.WebAuthnCredential{}, ErrCredentialNotFound
+}
+
+// AddCredential enrolls a passkey
+func (s *WebAuthnStore) AddCredential(credential types.WebAuthnCredential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.state.Credentials {
+		if c.ID == credential.ID {
+			return errors.New("credential is already enrolled")
+		}
+	}
+	s.state.Credentials = append(s.state.Credentials, credential)
+	return s.save()
+}
+
+// RecordUse stores a passkey's new signature count and when it was used
+func (s *WebAuthnStore) RecordUse(owner, id string, signCount uint32, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.state.Credentials {
+		if c.Owner == owner && c.ID == id {
+			s.state.Credentials[i].SignCount = signCount
+			s.state.Credentials[i].LastUsedAt = &at
+			return s.save()
+		}
+	}
+	return ErrCredentialNotFound
+}
+
+// RemoveCredential removes an owner's passkey, refusing to remove their last one
+func (s *WebAuthnStore) RemoveCredential(owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, owned := -1, 0
+	for i, c := range s.state.Credentials {
+		if c.Owner != owner {
+			continue
+		}
+		owned++
+		if c.ID == id {
+			index = i
+		}
+	}
+	if index < 0 {
+		return ErrCredentialNotFound
+	}
+	if owned == 1 {
+		return ErrLastCredential
+	}
+	s.state.Credentials = append(s.state.Credentials[:index], s.state.Credentials[index+1:]...)
+	return s.save()
+}
+
+// EnrollmentTokenUsed reports whether an enrollment token has already been used
+func (s *WebAuthnStore) EnrollmentTokenUsed(token string) bool {
+	hash := HashEnrollmentToken(token)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, used := range s.state.UsedTokens {
+		if used == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// UseEnrollmentToken marks an enrollment token used, reporting false when it was used before
+func (s *WebAuthnStore) UseEnrollmentToken(token string) (bool, error) {
+	hash := HashEnrollmentToken(token)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, used := range s.state.UsedTokens {
+		if used == hash {
+			return false, nil
+		}
+	}
+	s.state.UsedTokens = append(s.state.UsedTokens, hash)
+	return true, s.save()
+}
+
+// HasRecoveryCodes reports whether owner has unused recovery codes
+func (s *WebAuthnStore) HasRecoveryCodes(owner string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.state.RecoveryCodes[owner]) > 0
+}
+
+// ReplaceRecoveryCodes issues a new set of recovery codes for owner, invalidating the old ones.
+// Only hashes are stored, so the returned codes cannot be shown again.
+func (s *WebAuthnStore) ReplaceRecoveryCodes(owner string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+		codes[i] = code[:8] + "-" + code[8:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.RecoveryCodes[owner] = hashes
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode consumes one of owner's recovery codes, reporting whether it was valid
+func (s *WebAuthnStore) UseRecoveryCode(owner, code string) (bool, error) {
+	hash := hashRecoveryCode(code)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hashes := s.state.RecoveryCodes[owner]
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			s.state.RecoveryCodes[owner] = append(hashes[:i:i], hashes[i+1:]...)
+			return true, s.save()
+		}
+	}
+	return false, nil
+}
+
+// save writes the state to the store's file; callers hold the lock
+func (s *WebAuthnStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode passkey store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create passkey store directory: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a torn file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write passkey store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write passkey store: %w", err)
+	}
+	return nil
+}
+
+// HashEnrollmentToken returns the hex SHA-256 of an enrollment token, the form tokens are configured in
+func HashEnrollmentToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case and surrounding whitespace
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// testAuthenticator is a software passkey
+type testAuthenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	rpID      string
+	origin    string
+	signCount uint32
+}
+
+func newTestAuthenticator(t *testing.T, rpID, origin string) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return &testAuthenticator{key: key, id: []byte("credential-" + origin), rpID: rpID, origin: origin}
+}
+
+func (a *testAuthenticator) clientData(ceremony string, challenge []byte) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	return data
+}
+
+func (a *testAuthenticator) authData(flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+		data = append(data, a.id...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) register(challenge []byte) types.WebAuthnRegistrationResponse {
+	publicKey, _ := x509.MarshalPKIXPublicKey(&a.key.PublicKey)
+
+	var resp types.WebAuthnRegistrationResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(a.id)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", challenge))
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(a.authData(authFlagUserPresent|authFlagAttestedCredData, true))
+	resp.Response.PublicKey = base64.RawURLEncoding.EncodeToString(publicKey)
+	resp.Response.PublicKeyAlgorithm = types.COSEAlgES256
+	return resp
+}
+
+func (a *testAuthenticator) assert(challenge []byte) types.WebAuthnAssertionResponse {
+	a.signCount++
+	clientData := a.clientData("webauthn.get", challenge)
+	authData := a.authData(authFlagUserPresent, false)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, _ := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+
+	var resp types.WebAuthnAssertionResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(a.id)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientData)
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+	return resp
+}
+
+func TestWebAuthnVerifier(t *testing.T) {
+	const origin = "https://admin.infinity-dex.test"
+	verifier := NewWebAuthnVerifier("infinity-dex.test", []string{origin})
+	authenticator := newTestAuthenticator(t, "infinity-dex.test", origin)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	credential, err := verifier.VerifyRegistration(challenge, "owner", authenticator.register(challenge))
+	if err != nil {
+		t.Fatalf("Expected registration to verify, got %v", err)
+	}
+
+	t.Run("Registration", func(t *testing.T) {
+		if credential.Owner != "owner" || credential.Algorithm != types.COSEAlgES256 {
+			t.Errorf("Unexpected credential %+v", credential)
+		}
+
+		if _, err := verifier.VerifyRegistration([]byte("other"), "owner", authenticator.register(challenge)); err == nil {
+			t.Error("Expected a registration for another challenge to fail")
+		}
+
+		phishing := newTestAuthenticator(t, "infinity-dex.test", "https://evil.test")
+		if _, err := verifier.VerifyRegistration(challenge, "owner", phishing.register(challenge)); err == nil {
+			t.Error("Expected a registration from another origin to fail")
+		}
+
+		otherRP := newTestAuthenticator(t, "evil.test", origin)
+		if _, err := verifier.VerifyRegistration(challenge, "owner", otherRP.register(challenge)); err == nil {
+			t.Error("Expected a registration for another relying party to fail")
+		}
+	})
+
+	t.Run("Assertion", func(t *testing.T) {
+		signCount, err := verifier.VerifyAssertion(challenge, *credential, authenticator.assert(challenge))
+		if err != nil {
+			t.Fatalf("Expected assertion to verify, got %v", err)
+		}
+		if signCount != 1 {
+			t.Errorf("Expected sign count 1, got %d", signCount)
+		}
+		credential.SignCount = signCount
+
+		resp := authenticator.assert(challenge)
+		resp.Response.Signature = base64.RawURLEncoding.EncodeToString([]byte("forged"))
+		if _, err := verifier.VerifyAssertion(challenge, *credential, resp); err == nil {
+			t.Error("Expected a forged signature to fail")
+		}
+
+		impostor := newTestAuthenticator(t, "infinity-dex.test", origin)
+		if _, err := verifier.VerifyAssertion(challenge, *credential, impostor.assert(challenge)); err == nil {
+			t.Error("Expected an assertion from another key to fail")
+		}
+	})
+
+	t.Run("ClonedAuthenticator", func(t *testing.T) {
+		clone := *authenticator
+		clone.signCount = 0
+		_, err := verifier.VerifyAssertion(challenge, *credential, clone.assert(challenge))
+		if !errors.Is(err, ErrSignCountRegressed) {
+			t.Errorf("Expected ErrSignCountRegressed, got %v", err)
+		}
+	})
+}
+
+func TestWebAuthnStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webauthn.json")
+	store, err := NewWebAuthnStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store.AddCredential(types.WebAuthnCredential{ID: "cred-1", Owner: "owner"}); err != nil {
+		t.Fatalf("Failed to add credential: %v", err)
+	}
+	codes, err := store.ReplaceRecoveryCodes("owner")
+	if err != nil || len(codes) != recoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d (%v)", recoveryCodeCount, len(codes), err)
+	}
+
+	t.Run("Persisted", func(t *testing.T) {
+		reloaded, err := NewWebAuthnStore(path)
+		if err != nil {
+			t.Fatalf("Failed to reload store: %v", err)
+		}
+		if len(reloaded.Credentials("owner")) != 1 || !reloaded.HasRecoveryCodes("owner") {
+			t.Error("Expected the credential and recovery codes to survive a reload")
+		}
+		if len(reloaded.Credentials("someone-else")) != 0 {
+			t.Error("Expected credentials to be scoped to their owner")
+		}
+	})
+
+	t.Run("RecoveryCodesWorkOnce", func(t *testing.T) {
+		if ok, _ := store.UseRecoveryCode("someone-else", codes[0]); ok {
+			t.Error("Expected another owner's code to be rejected")
+		}
+		if ok, _ := store.UseRecoveryCode("owner", " "+codes[0]+" "); !ok {
+			t.Error("Expected a valid code to be accepted")
+		}
+		if ok, _ := store.UseRecoveryCode("owner", codes[0]); ok {
+			t.Error("Expected a used code to be rejected")
+		}
+	})
+
+	t.Run("EnrollmentTokensWorkOnce", func(t *testing.T) {
+		if store.EnrollmentTokenUsed("token-1") {
+			t.Error("Expected a fresh token to be unused")
+		}
+		if ok, err := store.UseEnrollmentToken("token-1"); !ok || err != nil {
+			t.Errorf("Expected a fresh token to be accepted, got %v (%v)", ok, err)
+		}
+		if ok, _ := store.UseEnrollmentToken("token-1"); ok {
+			t.Error("Expected a used token to be rejected")
+		}
+		reloaded, _ := NewWebAuthnStore(path)
+		if !reloaded.EnrollmentTokenUsed("token-1") {
+			t.Error("Expected used tokens to survive a reload")
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		if err := store.RemoveCredential("someone-else", "cred-1"); !errors.Is(err, ErrCredentialNotFound) {
+			t.Errorf("Expected ErrCredentialNotFound, got %v", err)
+		}
+		if err := store.RemoveCredential("owner", "cred-1"); !errors.Is(err, ErrLastCredential) {
+			t.Errorf("Expected ErrLastCredential, got %v", err)
+		}
+		if err := store.AddCredential(types.WebAuthnCredential{ID: "cred-2", Owner: "owner"}); err != nil {
+			t.Fatalf("Failed to add credential: %v", err)
+		}
+		if err := store.RemoveCredential("owner", "cred-1"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// WebhookSignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">" so clients can check a
+// webhook came from us and is recent
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// Events of the webhooks POSTed to swaps' callback URLs
+const (
+	SwapCompletedWebhookEvent = "swap.completed"
+	SwapFailedWebhookEvent    = "swap.failed"
+	SwapPendingWebhookEvent   = "swap.pending" // Fast path swaps still settling when their workflow finished
+)
+
+// MinWebhookSecretLength is the shortest webhook secret a client may register
+const MinWebhookSecretLength = 32
+
+// ErrPrivateWebhookAddress is returned for webhook URLs that resolve to addresses outside the public internet
+var ErrPrivateWebhookAddress = errors.New("webhook URL must resolve to a public address")
+
+// SwapWebhook is the body POSTed to a swap's callback URL when the swap finishes
+type SwapWebhook struct {
+	Event     string           `json:"event"`
+	RequestID string           `json:"requestId"`
+	Result    types.SwapResult `json:"result"`
+}
+
+// NewSwapWebhook returns the webhook announcing a swap's result
+func NewSwapWebhook(result types.SwapResult) SwapWebhook {
+	webhook := SwapWebhook{Event: SwapFailedWebhookEvent, RequestID: result.RequestID, Result: result}
+	switch {
+	case result.Success:
+		webhook.Event = SwapCompletedWebhookEvent
+	case result.Status == types.SwapStatusPending:
+		webhook.Event = SwapPendingWebhookEvent
+	}
+	return webhook
+}
+
+// WebhookSecretStore stores the secrets clients registered for signing the webhooks sent to them, by API key ID
+type WebhookSecretStore interface {
+	// SetWebhookSecret registers a key's webhook secret, replacing any earlier one
+	SetWebhookSecret(ctx context.Context, keyID, secret string) error
+	// GetWebhookSecret returns a key's webhook secret, or types.ErrWebhookSecretNotFound
+	GetWebhookSecret(ctx context.Context, keyID string) (string, error)
+}
+
+// InMemoryWebhookSecretStore is a WebhookSecretStore for running without a database
+type InMemoryWebhookSecretStore struct {
+	secrets map[string]string // map[keyID]secret
+	mu      sync.RWMutex
+}
+
+// NewInMemoryWebhookSecretStore creates an empty in-memory webhook secret store
+func NewInMemoryWebhookSecretStore() *InMemoryWebhookSecretStore {
+	return &InMemoryWebhookSecretStore{
+		secrets: make(map[string]string),
+	}
+}
+
+// SetWebhookSecret registers a key's webhook secret
+func (s *InMemoryWebhookSecretStore) SetWebhookSecret(ctx context.Context, keyID, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secrets[keyID] = secret
+	return nil
+}
+
+// GetWebhookSecret returns a key's webhook secret
+func (s *InMemoryWebhookSecretStore) GetWebhookSecret(ctx context.Context, keyID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	secret, ok := s.secrets[keyID]
+	if !ok {
+		return "", types.ErrWebhookSecretNotFound
+	}
+	return secret, nil
+}
+
+// GenerateWebhookSecret returns a new random webhook secret
+func GenerateWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(raw), nil
+}
+
+// WebhookSecret returns the secret the key with keyID registered to sign its webhooks with. Keys don't share a
+// secret, so one tenant can't sign webhooks another accepts: it returns types.ErrWebhookSecretNotFound for a key
+// that registered none, and for no key at all.
+func WebhookSecret(ctx context.Context, store WebhookSecretStore, keyID string) (string, error) {
+	if keyID == "" {
+		return "", types.ErrWebhookSecretNotFound
+	}
+	return store.GetWebhookSecret(ctx, keyID)
+}
+
+// NewWebhookClient creates the client webhooks are sent with. It refuses to connect to non-public addresses,
+// checked on the resolved address of every connection so a webhook host cannot rebind to an internal one.
+func NewWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddress(addrPort.Addr()) {
+				return ErrPrivateWebhookAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		// Redirects could lead anywhere; a webhook must answer at the URL it was registered with
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPublicAddress reports whether an IP address is on the public internet
+func isPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast() &&
+		!netip.MustParsePrefix("100.64.0.0/10").Contains(addr) && // Carrier-grade NAT
+		!netip.MustParsePrefix("64:ff9b::/96").Contains(addr) // NAT64, which can reach IPv4 private ranges
+}
+
+// CheckWebhookURL checks a client's webhook URL is an https URL whose host resolves only to public addresses
+func CheckWebhookURL(ctx context.Context, webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" || parsed.User != nil {
+		return errors.New("invalid webhook URL: must be an absolute https URL")
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicAddress(addr) {
+			return ErrPrivateWebhookAddress
+		}
+	}
+	return nil
+}
+
+// SignWebhook returns the signature header value for a webhook body sent at a time
+func SignWebhook(secret string, body []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookStatusError is returned for webhook deliveries the client answered with a non-2xx status
+type WebhookStatusError struct {
+	StatusCode int
+}
+
+// Error describes the status the webhook was answered with
+func (e *WebhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+}
+
+// PostWebhook makes one attempt to deliver a webhook body, signed with secret; any 2xx response accepts it
+func PostWebhook(ctx context.Context, client *http.Client, webhookURL, secret string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, body, time.Now()))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &WebhookStatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
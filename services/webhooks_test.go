@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestNewSwapWebhook(t *testing.T) {
+	for _, tc := range []struct {
+		result types.SwapResult
+		event  string
+	}{
+		{types.SwapResult{RequestID: "swap-1", Success: true, Status: types.SwapStatusCompleted}, SwapCompletedWebhookEvent},
+		{types.SwapResult{RequestID: "swap-1", Status: types.SwapStatusFailed}, SwapFailedWebhookEvent},
+		{types.SwapResult{RequestID: "swap-1", Status: types.SwapStatusPending}, SwapPendingWebhookEvent},
+	} {
+		webhook := NewSwapWebhook(tc.result)
+		if webhook.Event != tc.event || webhook.RequestID != "swap-1" {
+			t.Errorf("Expected a %s webhook for %+v, got %+v", tc.event, tc.result, webhook)
+		}
+	}
+}
+
+func TestWebhookSecret(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryWebhookSecretStore()
+	if err := store.SetWebhookSecret(ctx, "key-1", "registered"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	if secret, err := WebhookSecret(ctx, store, "key-1"); err != nil || secret != "registered" {
+		t.Errorf("Expected the registered secret, got %q, %v", secret, err)
+	}
+	if _, err := WebhookSecret(ctx, store, "key-2"); !errors.Is(err, types.ErrWebhookSecretNotFound) {
+		t.Errorf("Expected ErrWebhookSecretNotFound for a key without a secret, got %v", err)
+	}
+	if _, err := WebhookSecret(ctx, store, ""); !errors.Is(err, types.ErrWebhookSecretNotFound) {
+		t.Errorf("Expected ErrWebhookSecretNotFound without a key, got %v", err)
+	}
+
+	generated, err := GenerateWebhookSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate secret: %v", err)
+	}
+	if !strings.HasPrefix(generated, "whsec_") || len(generated) < MinWebhookSecretLength {
+		t.Errorf("Unexpected generated secret %q", generated)
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	status := http.StatusNoContent
+	var signature, body string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(WebhookSignatureHeader)
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		w.WriteHeader(status)
+	}))
+	defer target.Close()
+
+	payload := []byte(`{"event":"swap.completed"}`)
+	if err := PostWebhook(context.Background(), target.Client(), target.URL, "secret", payload); err != nil {
+		t.Fatalf("Failed to post webhook: %v", err)
+	}
+	if body != string(payload) {
+		t.Errorf("Expected the body to be sent, got %q", body)
+	}
+	sentAt, err := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+	if err != nil {
+		t.Fatalf("Unexpected signature %q: %v", signature, err)
+	}
+	if signature != SignWebhook("secret", payload, time.Unix(sentAt, 0)) {
+		t.Errorf("Unexpected signature %q", signature)
+	}
+
+	status = http.StatusInternalServerError
+	err = PostWebhook(context.Background(), target.Client(), target.URL, "secret", payload)
+	var statusErr *WebhookStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	for _, webhookURL := range []string{"http://example.com/hook", "https://user@example.com/hook", "/hook"} {
+		if err := CheckWebhookURL(context.Background(), webhookURL); err == nil {
+			t.Errorf("Expected %q to be rejected", webhookURL)
+		}
+	}
+	if err := CheckWebhookURL(context.Background(), "https://127.0.0.1/hook"); !errors.Is(err, ErrPrivateWebhookAddress) {
+		t.Errorf("Expected a loopback URL to be rejected, got %v", err)
+	}
+}
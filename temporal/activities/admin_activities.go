@@ -0,0 +1,175 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// AdminActivities holds implementation of operator remediation activities
+type AdminActivities struct {
+	universalSDK       universalsdk.SDK
+	tokenService       TokenRegistry
+	transactionService AdminTransactionStore
+	auditLog           *AuditLog
+}
+
+// TokenRegistry defines the token operations needed to resync the registry
+type TokenRegistry interface {
+	UpsertToken(token types.Token) bool
+}
+
+// AdminTransactionStore defines the transaction operations needed by admin actions
+type AdminTransactionStore interface {
+	CreateTransaction(ctx context.Context, tx types.Transaction) (string, error)
+	GetTransaction(ctx context.Context, txID string) (*types.Transaction, error)
+	GetTransactionsByWorkflowID(ctx context.Context, workflowID string) ([]types.Transaction, error)
+	UpdateTransactionStatus(ctx context.Context, txID string, status string) error
+}
+
+// NewAdminActivities creates a new instance of admin activities
+func NewAdminActivities(sdk universalsdk.SDK, tokenService TokenRegistry, transactionService AdminTransactionStore, auditLog *AuditLog) *AdminActivities {
+	return &AdminActivities{
+		universalSDK:       sdk,
+		tokenService:       tokenService,
+		transactionService: transactionService,
+		auditLog:           auditLog,
+	}
+}
+
+// RecordAuditActivity appends an entry to the operator audit log
+func (a *AdminActivities) RecordAuditActivity(ctx context.Context, entry AuditEntry) error {
+	activity.GetLogger(ctx).Info("Recording admin action",
+		"actionID", entry.ActionID,
+		"action", entry.Action,
+		"operator", entry.Operator,
+		"status", entry.Status,
+	)
+
+	return a.auditLog.Record(entry)
+}
+
+// RefundSwapActivity marks a swap's transactions refunded and records a refund to the sender.
+// Swaps whose output was delivered are never refunded. It is idempotent: the refund is recorded under an ID
+// derived from the swap before anything is marked, so a retried or repeated refund returns the existing one.
+func (a *AdminActivities) RefundSwapActivity(ctx context.Context, requestID string) (*types.Transaction, error) {
+	activity.GetLogger(ctx).Info("Refunding swap", "requestID", requestID)
+
+	refundID := requestID + "-refund"
+	if refund, err := a.transactionService.GetTransaction(ctx, refundID); err == nil {
+		// Finish marking the swap in case an earlier attempt stopped after recording the refund
+		return refund, a.markRefunded(ctx, requestID)
+	}
+
+	txs, err := a.transactionService.GetTransactionsByWorkflowID(ctx, requestID)
+	if err != nil || len(txs) == 0 {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("No transactions found for swap %s", requestID),
+			"SWAP_NOT_FOUND",
+			err)
+	}
+
+	var source *types.Transaction
+	for i := range txs {
+		switch txs[i].Type {
+		case "swap_source":
+			source = &txs[i]
+		case "swap_dest":
+			if txs[i].Status == "completed" {
+				return nil, temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf("Swap %s delivered its output and can't be refunded", requestID),
+					"SWAP_COMPLETED",
+					errors.New("destination transaction completed"))
+			}
+		}
+	}
+	if source == nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Swap %s has no source transaction to refund", requestID),
+			"NOTHING_TO_REFUND",
+			errors.New("missing source transaction"))
+	}
+
+	amount := new(big.Int)
+	if source.Amount != nil {
+		amount.Set(source.Amount)
+	}
+	refund := types.Transaction{
+		ID:          refundID,
+		Type:        "refund",
+		Status:      "pending",
+		FromAddress: source.ToAddress,
+		ToAddress:   source.FromAddress,
+		SourceChain: source.SourceChain,
+		DestChain:   source.SourceChain,
+		SourceToken: source.SourceToken,
+		DestToken:   source.SourceToken,
+		Amount:      amount,
+		Value:       amount,
+		Timestamp:   time.Now(),
+		WorkflowID:  requestID,
+	}
+	if _, err := a.transactionService.CreateTransaction(ctx, refund); err != nil {
+		// A concurrent refund of the same swap recorded it first
+		if existing, getErr := a.transactionService.GetTransaction(ctx, refundID); getErr == nil {
+			return existing, a.markRefunded(ctx, requestID)
+		}
+		return nil, fmt.Errorf("failed to record refund: %w", err)
+	}
+	if err := a.markRefunded(ctx, requestID); err != nil {
+		return nil, err
+	}
+
+	activity.GetLogger(ctx).Info("Swap refunded", "requestID", requestID, "amount", amount.String(), "to", refund.ToAddress)
+	return &refund, nil
+}
+
+// markRefunded marks a swap's transactions, other than its refund, refunded
+func (a *AdminActivities) markRefunded(ctx context.Context, requestID string) error {
+	txs, err := a.transactionService.GetTransactionsByWorkflowID(ctx, requestID)
+	if err != nil {
+		return fmt.Errorf("failed to load transactions of swap %s: %w", requestID, err)
+	}
+	for _, tx := range txs {
+		if tx.Type == "refund" || tx.Status == "refunded" {
+			continue
+		}
+		if err := a.transactionService.UpdateTransactionStatus(ctx, tx.ID, "refunded"); err != nil {
+			return fmt.Errorf("failed to mark transaction %s refunded: %w", tx.ID, err)
+		}
+	}
+	return nil
+}
+
+// ResyncTokenRegistryActivity reloads the wrapped tokens of each chain from Universal, fetching every chain's tokens
+// in one call. It returns the number of tokens synced.
+func (a *AdminActivities) ResyncTokenRegistryActivity(ctx context.Context, chainIDs []int64) (int, error) {
+	activity.GetLogger(ctx).Info("Resyncing token registry", "chains", chainIDs)
+
+	allTokens, err := a.universalSDK.GetAllWrappedTokens(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wrapped tokens: %w", err)
+	}
+
+	synced := 0
+	for _, chainID := range chainIDs {
+		tokens, ok := allTokens[chainID]
+		if !ok {
+			return synced, fmt.Errorf("failed to get wrapped tokens for chain %d: chain not supported", chainID)
+		}
+		for _, token := range tokens {
+			a.tokenService.UpsertToken(token)
+			synced++
+		}
+	}
+
+	activity.GetLogger(ctx).Info("Token registry resynced", "tokens", synced)
+	return synced, nil
+}
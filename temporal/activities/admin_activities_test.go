@@ -0,0 +1,78 @@
+package temporal_activities
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestRefundSwapActivity(t *testing.T) {
+	ctx := context.Background()
+	transactions := services.NewTransactionService()
+	auditLog, err := NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to create audit log: %v", err)
+	}
+	activities := NewAdminActivities(nil, nil, transactions, auditLog)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.RefundSwapActivity)
+
+	swap := func(requestID, destStatus string) {
+		for _, tx := range []types.Transaction{
+			{ID: requestID + "-source", Type: "swap_source", Status: "completed", FromAddress: "0xsender", ToAddress: "0xpool", Amount: big.NewInt(100), WorkflowID: requestID},
+			{ID: requestID + "-dest", Type: "swap_dest", Status: destStatus, Amount: big.NewInt(0), WorkflowID: requestID},
+		} {
+			if _, err := transactions.CreateTransaction(ctx, tx); err != nil {
+				t.Fatalf("Failed to create transaction: %v", err)
+			}
+		}
+	}
+
+	t.Run("RefusesCompletedSwap", func(t *testing.T) {
+		swap("swap-done", "completed")
+		if _, err := env.ExecuteActivity(activities.RefundSwapActivity, "swap-done"); err == nil {
+			t.Fatal("Expected a completed swap to be refused")
+		}
+		if _, err := transactions.GetTransaction(ctx, "swap-done-refund"); err == nil {
+			t.Error("Expected no refund recorded for a completed swap")
+		}
+	})
+
+	t.Run("RefundsOnce", func(t *testing.T) {
+		swap("swap-failed", "failed")
+		for attempt := 0; attempt < 2; attempt++ {
+			value, err := env.ExecuteActivity(activities.RefundSwapActivity, "swap-failed")
+			if err != nil {
+				t.Fatalf("Unexpected error on attempt %d: %v", attempt, err)
+			}
+			var refund types.Transaction
+			if err := value.Get(&refund); err != nil {
+				t.Fatalf("Failed to decode refund: %v", err)
+			}
+			if refund.ID != "swap-failed-refund" || refund.ToAddress != "0xsender" || refund.Amount.Cmp(big.NewInt(100)) != 0 {
+				t.Errorf("Unexpected refund on attempt %d: %+v", attempt, refund)
+			}
+		}
+
+		txs, _ := transactions.GetTransactionsByWorkflowID(ctx, "swap-failed")
+		refunds := 0
+		for _, tx := range txs {
+			switch {
+			case tx.Type == "refund":
+				refunds++
+			case tx.Status != "refunded":
+				t.Errorf("Expected transaction %s refunded, got %s", tx.ID, tx.Status)
+			}
+		}
+		if refunds != 1 {
+			t.Errorf("Expected 1 refund, got %d", refunds)
+		}
+	})
+}
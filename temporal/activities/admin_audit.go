@@ -0,0 +1,129 @@
+package temporal_activities
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Audit entry statuses, in the order an admin action moves through them
+const (
+	AuditStatusRequested = "requested"
+	AuditStatusStarted   = "started"
+	AuditStatusCompleted = "completed"
+	AuditStatusFailed    = "failed"
+)
+
+// AuditEntry records one step of an operator action
+type AuditEntry struct {
+	ActionID   string            `json:"actionId"` // Workflow ID of the action
+	Action     string            `json:"action"`
+	Operator   string            `json:"operator"`
+	Reason     string            `json:"reason"`
+	Params     map[string]string `json:"params,omitempty"`
+	Status     string            `json:"status"`
+	Message    string            `json:"message,omitempty"`
+	RecordedAt time.Time         `json:"recordedAt"`
+}
+
+// AuditLog is an append-only JSON lines log of operator actions shared by the API server and workers
+type AuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultAuditLogPath returns the audit log path shared by the API server and workers
+func DefaultAuditLogPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".infinity-dex", "audit.log"), nil
+}
+
+// AuditLogPath returns the configured audit log path, or DefaultAuditLogPath when none is configured
+func AuditLogPath(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	return DefaultAuditLogPath()
+}
+
+// NewAuditLog creates an audit log appending to path
+func NewAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("unable to create audit log directory: %w", err)
+	}
+	return &AuditLog{path: path}, nil
+}
+
+// Record appends an entry to the log
+func (l *AuditLog) Record(entry AuditEntry) error {
+	if entry.RecordedAt.IsZero() {
+		entry.RecordedAt = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Each entry is a single append so writers in other processes never interleave within a line
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// List returns up to limit entries, newest first; limit <= 0 returns every entry
+func (l *AuditLog) List(limit int) ([]AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return []AuditEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip a line torn by a crash rather than hiding the rest of the log
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+
+	return entries, nil
+}
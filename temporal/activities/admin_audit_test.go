@@ -0,0 +1,52 @@
+package temporal_activities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.log")
+	log, err := NewAuditLog(path)
+	if err != nil {
+		t.Fatalf("Failed to create audit log: %v", err)
+	}
+
+	entries, err := log.List(0)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected empty log, got %v (err %v)", entries, err)
+	}
+
+	for _, status := range []string{AuditStatusRequested, AuditStatusStarted, AuditStatusCompleted} {
+		if err := log.Record(AuditEntry{ActionID: "admin-1", Action: "flush_price_cache", Status: status}); err != nil {
+			t.Fatalf("Failed to record entry: %v", err)
+		}
+	}
+
+	// A torn line from a crashed writer is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	f.WriteString(`{"actionId":"admin-2",`)
+	f.Close()
+
+	entries, err = log.List(0)
+	if err != nil {
+		t.Fatalf("Failed to list entries: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Status != AuditStatusCompleted || entries[2].Status != AuditStatusRequested {
+		t.Errorf("Expected newest first, got %+v", entries)
+	}
+	if entries[0].RecordedAt.IsZero() {
+		t.Error("Expected RecordedAt to be set")
+	}
+
+	if entries, _ := log.List(2); len(entries) != 2 {
+		t.Errorf("Expected limit to be applied, got %d entries", len(entries))
+	}
+}
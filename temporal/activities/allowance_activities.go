@@ -0,0 +1,99 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// AllowanceActivities lets each chain's contracts spend the executing account's ERC-20 tokens before they wrap or
+// swap them
+type AllowanceActivities struct {
+	transactionService TransactionServiceInterface
+	executor           *ChainExecutor // optional; without it wraps and swaps go through the SDK and need no allowance
+}
+
+// NewAllowanceActivities creates allowance activities recording approve transactions with transactionService
+func NewAllowanceActivities(transactionService TransactionServiceInterface) *AllowanceActivities {
+	return &AllowanceActivities{transactionService: transactionService}
+}
+
+// SetChainExecutor checks and approves allowances for the wraps and swaps executor sends on-chain
+func (a *AllowanceActivities) SetChainExecutor(executor *ChainExecutor) {
+	a.executor = executor
+}
+
+// CheckAndApproveAllowanceActivity checks the contract carrying out a wrap or swap on-chain may spend the
+// operation's amount of its token, and sends an approve transaction when it may not. The approval is recorded as a
+// transaction of type "approve". Operations not executed on-chain, and native tokens, need no allowance and are
+// skipped. Retries wait for the approval already sent instead of sending another.
+func (a *AllowanceActivities) CheckAndApproveAllowanceActivity(ctx context.Context, request types.AllowanceRequest) (*types.AllowanceResult, error) {
+	logger := activity.GetLogger(ctx)
+	if request.Amount == nil || request.Amount.Sign() <= 0 {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"Invalid amount",
+			"INVALID_AMOUNT",
+			errors.New("amount must be greater than zero"))
+	}
+
+	var spender string
+	if a.executor != nil {
+		spender = a.executor.Spender(request.Token.ChainID, request.Operation)
+	}
+	if spender == "" || request.Token.IsNative() {
+		return &types.AllowanceResult{Status: types.AllowanceSkipped}, nil
+	}
+
+	allowance, err := a.executor.Allowance(ctx, request.Token, spender)
+	if err != nil {
+		return nil, temporal.NewApplicationError(
+			fmt.Sprintf("Failed to read %s allowance: %v", request.Token.Symbol, err),
+			"ALLOWANCE_CHECK_FAILED")
+	}
+	if allowance.Cmp(request.Amount) >= 0 {
+		return &types.AllowanceResult{Status: types.AllowanceSufficient, Spender: spender, Allowance: allowance}, nil
+	}
+
+	logger.Info("Approving token allowance",
+		"requestID", request.RequestID,
+		"token", request.Token.Symbol,
+		"spender", spender,
+		"allowance", allowance.String(),
+		"amount", request.Amount.String())
+	receipt, approved, err := a.executor.Approve(ctx, request.RequestID, request.Token, spender, request.Amount, allowance)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := types.Transaction{
+		ID:          receipt.TxHash,
+		Type:        "approve",
+		Hash:        receipt.TxHash,
+		Status:      "completed",
+		FromAddress: a.executor.account,
+		ToAddress:   spender,
+		SourceChain: request.Token.ChainName,
+		SourceToken: request.Token,
+		Amount:      approved,
+		Value:       big.NewInt(0),
+		Gas:         new(big.Int).SetUint64(receipt.GasUsed),
+		GasPrice:    receipt.EffectiveGasPrice,
+		Timestamp:   time.Now(),
+		BlockNumber: receipt.BlockNumber,
+		WorkflowID:  request.RequestID,
+	}
+	if a.transactionService != nil {
+		// The approval is mined either way, so failing to record it does not hold up the operation
+		if _, err := a.transactionService.CreateTransaction(ctx, tx); err != nil {
+			logger.Warn("Failed to record approve transaction", "txHash", tx.Hash, "error", err)
+		}
+	}
+	logger.Info("Token allowance approved", "requestID", request.RequestID, "txHash", receipt.TxHash, "allowance", approved.String())
+	return &types.AllowanceResult{Status: types.AllowanceApproved, Spender: spender, Allowance: approved, Transaction: &tx}, nil
+}
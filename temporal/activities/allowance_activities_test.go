@@ -0,0 +1,125 @@
+package temporal_activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fakeAllowanceReader answers allowance calls with a fixed allowance
+type fakeAllowanceReader struct {
+	allowance *big.Int
+	calls     []string // calldata of each call
+}
+
+func (f *fakeAllowanceReader) Call(ctx context.Context, chainID int64, from, to, data string) ([]byte, error) {
+	f.calls = append(f.calls, data)
+	return f.allowance.FillBytes(make([]byte, 32)), nil
+}
+
+func TestCheckAndApproveAllowanceActivity(t *testing.T) {
+	const (
+		universal = "0x1111111111111111111111111111111111111111"
+		dex       = "0x2222222222222222222222222222222222222222"
+		account   = "0x1234567890abcdef1234567890abcdef12345678"
+	)
+	usdc := types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1, ChainName: "Ethereum", Address: "0x3333333333333333333333333333333333333333"}
+	request := types.AllowanceRequest{RequestID: "swap-1", Operation: types.AllowanceForSwap, Token: usdc, Amount: big.NewInt(1000)}
+
+	run := func(t *testing.T, allowance int64, exact bool, request types.AllowanceRequest) (*types.AllowanceResult, *fakeAdapter, *fakeAllowanceReader, *services.TransactionService) {
+		t.Helper()
+		adapter := &fakeAdapter{}
+		reader := &fakeAllowanceReader{allowance: big.NewInt(allowance)}
+		executor := NewChainExecutor(adapter, map[int64]ChainContracts{1: {Universal: universal, DEX: dex}}, time.Second)
+		executor.SetAllowanceReader(reader, account)
+		executor.SetExactApprovals(exact)
+		transactions := services.NewTransactionService()
+		activities := NewAllowanceActivities(transactions)
+		activities.SetChainExecutor(executor)
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.CheckAndApproveAllowanceActivity)
+		value, err := env.ExecuteActivity(activities.CheckAndApproveAllowanceActivity, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.AllowanceResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		return &result, adapter, reader, transactions
+	}
+
+	t.Run("Sufficient", func(t *testing.T) {
+		result, adapter, reader, _ := run(t, 1000, false, request)
+		if result.Status != types.AllowanceSufficient || result.Spender != dex || result.Allowance.Int64() != 1000 {
+			t.Errorf("Expected the DEX's allowance sufficient, got %+v", result)
+		}
+		if len(adapter.built) != 0 {
+			t.Errorf("Expected no approval, got %d transactions", len(adapter.built))
+		}
+		expected, _ := evm.EncodeCall(allowanceFunction, account, dex)
+		if len(reader.calls) != 1 || reader.calls[0] != "0x"+hex.EncodeToString(expected) {
+			t.Errorf("Expected the account's allowance to the DEX read, got %v", reader.calls)
+		}
+	})
+
+	t.Run("Approves", func(t *testing.T) {
+		result, adapter, _, transactions := run(t, 10, false, request)
+		if result.Status != types.AllowanceApproved || result.Allowance.Cmp(unlimitedAllowance) != 0 {
+			t.Errorf("Expected an unlimited approval, got %+v", result)
+		}
+		if len(adapter.built) != 1 || adapter.built[0].To != usdc.Address {
+			t.Fatalf("Expected one transaction to the token, got %+v", adapter.built)
+		}
+		expected, _ := evm.EncodeCall(approveFunction, dex, unlimitedAllowance)
+		if !bytes.Equal(adapter.built[0].Data, expected) {
+			t.Errorf("Expected approve calldata for the DEX, got %x", adapter.built[0].Data)
+		}
+
+		tx, err := transactions.GetTransaction(context.Background(), "0xsigned")
+		if err != nil {
+			t.Fatalf("Expected the approval recorded: %v", err)
+		}
+		if tx.Type != "approve" || tx.WorkflowID != "swap-1" || !strings.EqualFold(tx.FromAddress, account) || tx.ToAddress != dex {
+			t.Errorf("Unexpected approve transaction %+v", tx)
+		}
+	})
+
+	t.Run("ExactApprovals", func(t *testing.T) {
+		wrap := request
+		wrap.Operation = types.AllowanceForWrap
+		result, adapter, _, _ := run(t, 10, true, wrap)
+		if result.Status != types.AllowanceApproved || result.Spender != universal || result.Allowance.Int64() != 1000 {
+			t.Errorf("Expected the Universal contract approved for the amount, got %+v", result)
+		}
+		expected, _ := evm.EncodeCall(approveFunction, universal, big.NewInt(1000))
+		if len(adapter.built) != 1 || !bytes.Equal(adapter.built[0].Data, expected) {
+			t.Errorf("Expected an exact approval, got %+v", adapter.built)
+		}
+	})
+
+	t.Run("Skipped", func(t *testing.T) {
+		native := request
+		native.Token = types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1, Address: types.NativeTokenAddress}
+		elsewhere := request
+		elsewhere.Token.ChainID = 137
+
+		for name, request := range map[string]types.AllowanceRequest{"native token": native, "chain without contracts": elsewhere} {
+			result, adapter, reader, _ := run(t, 0, false, request)
+			if result.Status != types.AllowanceSkipped || len(adapter.built) != 0 || len(reader.calls) != 0 {
+				t.Errorf("Expected the %s skipped, got %+v", name, result)
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package temporal_activities
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+)
+
+// ArchiveActivities holds implementation of swap archival activities
+type ArchiveActivities struct {
+	store     services.SwapArchiveStore
+	analytics *services.AnalyticsService // nil when swaps aren't counted
+	bus       *events.Bus                // nil when finished swaps aren't announced
+}
+
+// NewArchiveActivities creates a new instance of archive activities
+func NewArchiveActivities(store services.SwapArchiveStore) *ArchiveActivities {
+	return &ArchiveActivities{store: store}
+}
+
+// SetAnalytics counts archived swaps in the swap statistics
+func (a *ArchiveActivities) SetAnalytics(analytics *services.AnalyticsService) {
+	a.analytics = analytics
+}
+
+// SetEventBus publishes each archived swap's completion or failure to bus
+func (a *ArchiveActivities) SetEventBus(bus *events.Bus) {
+	a.bus = bus
+}
+
+// ArchiveSwapActivity copies a finished swap out of Temporal, recording the workflow execution it ran in
+func (a *ArchiveActivities) ArchiveSwapActivity(ctx context.Context, swap types.ArchivedSwap) error {
+	info := activity.GetInfo(ctx)
+	swap.WorkflowID = info.WorkflowExecution.ID
+	swap.RunID = info.WorkflowExecution.RunID
+	swap.ArchivedAt = time.Now()
+
+	activity.GetLogger(ctx).Info("Archiving swap", "requestID", swap.RequestID, "workflowID", swap.WorkflowID, "success", swap.Result.Success)
+	if err := a.store.ArchiveSwap(ctx, swap); err != nil {
+		return fmt.Errorf("failed to archive swap %s: %w", swap.RequestID, err)
+	}
+	// Retries archive the swap again and replace its record, so neither is doubled
+	if a.analytics != nil {
+		if err := a.analytics.RecordSwap(ctx, swap); err != nil {
+			return fmt.Errorf("failed to record stats of swap %s: %w", swap.RequestID, err)
+		}
+	}
+	if a.bus != nil {
+		publishSwapOutcome(ctx, a.bus, swap)
+	}
+	return nil
+}
+
+// publishSwapOutcome announces that a swap completed or failed. Partially filled swaps announce completing with the
+// amounts they filled. Swaps still in progress, such as fast path swaps archived before they settled, and dry runs
+// announce nothing.
+func publishSwapOutcome(ctx context.Context, bus *events.Bus, swap types.ArchivedSwap) {
+	var err error
+	switch swap.Result.Status {
+	case types.SwapStatusCompleted, types.SwapStatusPartiallyFilled:
+		err = events.Publish(ctx, bus, events.SwapCompletedTopic, events.SwapCompleted{
+			RequestID:        swap.RequestID,
+			SourceToken:      swap.Request.SourceToken,
+			DestinationToken: swap.Request.DestinationToken,
+			InputAmount:      swap.Result.InputAmount,
+			OutputAmount:     swap.Result.OutputAmount,
+			Fee:              swap.Result.Fee,
+			CompletedAt:      swap.ClosedAt,
+		})
+	case types.SwapStatusFailed:
+		err = events.Publish(ctx, bus, events.SwapFailedTopic, events.SwapFailed{
+			RequestID:        swap.RequestID,
+			SourceToken:      swap.Request.SourceToken,
+			DestinationToken: swap.Request.DestinationToken,
+			Amount:           swap.Request.Amount,
+			ErrorCode:        swap.Result.ErrorCode,
+			ErrorMessage:     swap.Result.ErrorMessage,
+			FailedAt:         swap.ClosedAt,
+		})
+	}
+	if err != nil {
+		activity.GetLogger(ctx).Warn("Failed to publish swap outcome", "requestID", swap.RequestID, "error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package temporal_activities
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestArchiveSwapActivity(t *testing.T) {
+	archive := services.NewInMemorySwapArchive()
+	activities := NewArchiveActivities(archive)
+	analytics := services.NewAnalyticsService(nil)
+	activities.SetAnalytics(analytics)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.ArchiveSwapActivity)
+
+	swap := types.ArchivedSwap{
+		RequestID: "swap-1",
+		Request: types.SwapRequest{
+			RequestID:        "swap-1",
+			SourceToken:      types.Token{Symbol: "uETH", ChainID: 1},
+			DestinationToken: types.Token{Symbol: "USDC", ChainID: 1},
+			Amount:           big.NewInt(1000),
+		},
+		Result: types.SwapResult{RequestID: "swap-1", Success: true, OutputAmount: big.NewInt(990)},
+	}
+	if _, err := env.ExecuteActivity(activities.ArchiveSwapActivity, swap); err != nil {
+		t.Fatalf("Failed to archive swap: %v", err)
+	}
+
+	archived, err := archive.GetArchivedSwap(context.Background(), "swap-1")
+	if err != nil {
+		t.Fatalf("Expected the swap to be archived: %v", err)
+	}
+	if archived.WorkflowID == "" || archived.RunID == "" {
+		t.Errorf("Expected the workflow execution to be recorded, got %q/%q", archived.WorkflowID, archived.RunID)
+	}
+	if archived.ArchivedAt.IsZero() {
+		t.Error("Expected ArchivedAt to be set")
+	}
+	if !archived.Result.Success || archived.Result.OutputAmount.Cmp(big.NewInt(990)) != 0 {
+		t.Errorf("Unexpected archived result: %+v", archived.Result)
+	}
+
+	stats, err := analytics.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if day := stats.Windows[0]; day.Swaps != 1 || len(day.Pairs) != 1 || day.Pairs[0].Pair != "uETH/USDC" {
+		t.Errorf("Expected the swap to be counted, got %+v", day)
+	}
+
+	if _, err := archive.GetArchivedSwap(context.Background(), "swap-missing"); err != types.ErrArchivedSwapNotFound {
+		t.Errorf("Expected ErrArchivedSwapNotFound, got %v", err)
+	}
+}
+
+func TestArchiveSwapActivityPublishesOutcome(t *testing.T) {
+	activities := NewArchiveActivities(services.NewInMemorySwapArchive())
+	bus := events.NewBus()
+	activities.SetEventBus(bus)
+
+	completed := make(chan events.SwapCompleted, 2)
+	failed := make(chan events.SwapFailed, 2)
+	events.Subscribe(bus, events.SwapCompletedTopic, 2, events.Block, func(ctx context.Context, event events.SwapCompleted) {
+		completed <- event
+	})
+	events.Subscribe(bus, events.SwapFailedTopic, 2, events.Block, func(ctx context.Context, event events.SwapFailed) {
+		failed <- event
+	})
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.ArchiveSwapActivity)
+
+	for _, swap := range []types.ArchivedSwap{
+		{RequestID: "swap-done", Result: types.SwapResult{Success: true, Status: types.SwapStatusCompleted, OutputAmount: big.NewInt(990)}},
+		{RequestID: "swap-failed", Result: types.SwapResult{Status: types.SwapStatusFailed, ErrorCode: types.SwapErrorQuoteDrift}},
+		// Fast path swaps can finish their workflow before they settle
+		{RequestID: "swap-pending", Result: types.SwapResult{Status: types.SwapStatusPending}},
+	} {
+		if _, err := env.ExecuteActivity(activities.ArchiveSwapActivity, swap); err != nil {
+			t.Fatalf("Failed to archive %s: %v", swap.RequestID, err)
+		}
+	}
+	bus.Close()
+
+	if len(completed) != 1 || len(failed) != 1 {
+		t.Fatalf("Expected one completed and one failed swap, got %d and %d", len(completed), len(failed))
+	}
+	if event := <-completed; event.RequestID != "swap-done" || event.OutputAmount.Cmp(big.NewInt(990)) != 0 {
+		t.Errorf("Unexpected completion event: %+v", event)
+	}
+	if event := <-failed; event.RequestID != "swap-failed" || event.ErrorCode != types.SwapErrorQuoteDrift {
+		t.Errorf("Unexpected failure event: %+v", event)
+	}
+}
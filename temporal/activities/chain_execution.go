@@ -0,0 +1,602 @@
+package temporal_activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Contract functions the executor calls. Native tokens are passed as the zero address and sent as the call's value.
+const (
+	wrapFunction   = "wrap(address,uint256,address)"
+	unwrapFunction = "unwrap(address,uint256,address)"
+	swapFunction   = "swap(address,address,uint256,uint256,address)"
+
+	// Swaps pulling the source tokens from a permit's owner: tokenIn, tokenOut, amountIn, minOut and recipient as
+	// for swap, then the owner, the permitted value, the nonce (Permit2 only), the deadline and the signature
+	swapWithPermitFunction  = "swapWithPermit(address,address,uint256,uint256,address,address,uint256,uint256,bytes)"
+	swapWithPermit2Function = "swapWithPermit2(address,address,uint256,uint256,address,address,uint256,uint256,uint256,bytes)"
+)
+
+// ERC-20 functions allowances are read and granted with
+const (
+	allowanceFunction = "allowance(address,address)"
+	approveFunction   = "approve(address,uint256)"
+)
+
+// unlimitedAllowance is the largest uint256, approved when approvals aren't exact
+var unlimitedAllowance = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// ContractReader reads contract state with eth_call
+type ContractReader interface {
+	Call(ctx context.Context, chainID int64, from, to, data string) ([]byte, error)
+}
+
+// ChainContracts holds the addresses of the contracts transactions are sent to on a chain
+type ChainContracts struct {
+	Universal string // wraps and unwraps tokens
+	DEX       string // swaps wrapped tokens
+}
+
+// heartbeatInterval is how often an activity heartbeats while it waits for its transaction to be mined
+const heartbeatInterval = 10 * time.Second
+
+// maxSpeedUps is how many times a stuck transaction is replaced with higher fees, bounding what it can pay for gas
+const maxSpeedUps = 5
+
+// ChainExecutor submits wraps, unwraps and same-chain swaps as transactions to the contracts of each configured chain.
+// Signed transactions are stored before they are broadcast, keyed by the operation they carry out, so a retried
+// activity rebroadcasts the same transaction and waits for it instead of sending another. Swaps from smart accounts
+// carry the account's signed user operation, which is sent through a bundler instead.
+type ChainExecutor struct {
+	adapter        chains.ChainAdapter
+	contracts      map[int64]ChainContracts
+	receiptTimeout time.Duration
+	txs            services.SignedTxStore
+	bundler        chains.UserOperationSender // optional, sends swaps from smart accounts
+	speedUpAfter   time.Duration              // 0 never replaces stuck transactions
+	reader         ContractReader             // optional, reads the allowances the account granted
+	account        string                     // the adapter's account, which grants allowances
+	exactApprovals bool                       // approve each operation's amount rather than an unlimited allowance
+}
+
+// NewChainExecutor creates an executor sending transactions through adapter to each chain's contracts,
+// waiting up to receiptTimeout for them to be mined. Signed transactions are kept in memory until SetTxStore
+// is called.
+func NewChainExecutor(adapter chains.ChainAdapter, contracts map[int64]ChainContracts, receiptTimeout time.Duration) *ChainExecutor {
+	return &ChainExecutor{
+		adapter:        adapter,
+		contracts:      contracts,
+		receiptTimeout: receiptTimeout,
+		txs:            services.NewInMemorySignedTxStore(),
+	}
+}
+
+// SetBundler sends swaps carrying a user operation from their source smart account through bundler
+func (e *ChainExecutor) SetBundler(bundler chains.UserOperationSender) {
+	e.bundler = bundler
+}
+
+// SetSpeedUpAfter replaces transactions left unmined for after with higher fees, up to maxSpeedUps times
+func (e *ChainExecutor) SetSpeedUpAfter(after time.Duration) {
+	e.speedUpAfter = after
+}
+
+// SetTxStore stores signed transactions in store, so they are rebroadcast after a worker restart
+func (e *ChainExecutor) SetTxStore(store services.SignedTxStore) {
+	e.txs = store
+}
+
+// SetAllowanceReader reads the allowances account granted through reader, so they are checked before wraps and swaps.
+// account must be the adapter's account, which sends them.
+func (e *ChainExecutor) SetAllowanceReader(reader ContractReader, account string) {
+	e.reader = reader
+	e.account = account
+}
+
+// SetExactApprovals approves contracts for each operation's amount only, instead of an unlimited allowance
+func (e *ChainExecutor) SetExactApprovals(exact bool) {
+	e.exactApprovals = exact
+}
+
+// CanWrap reports whether wraps and unwraps on the chain are executed on-chain
+func (e *ChainExecutor) CanWrap(chainID int64) bool {
+	return e.contracts[types.CanonicalChainID(chainID)].Universal != ""
+}
+
+// CanSwap reports whether the swap is executed on-chain: it stays on one chain whose DEX contract is configured
+func (e *ChainExecutor) CanSwap(request types.SwapRequest) bool {
+	source := types.CanonicalChainID(request.SourceToken.ChainID)
+	return source == types.CanonicalChainID(request.DestinationToken.ChainID) && e.contracts[source].DEX != ""
+}
+
+// Executes reports whether any of a chain's contracts are configured, so operations on it are executed on-chain
+func (e *ChainExecutor) Executes(chainID int64) bool {
+	contracts := e.contracts[types.CanonicalChainID(chainID)]
+	return contracts.Universal != "" || contracts.DEX != ""
+}
+
+// Balance returns how much of token owner holds on the token's chain
+func (e *ChainExecutor) Balance(ctx context.Context, token types.Token, owner string) (*big.Int, error) {
+	address := token.Address
+	if token.IsNative() {
+		address = ""
+	}
+	return e.adapter.Balance(ctx, types.CanonicalChainID(token.ChainID), owner, address)
+}
+
+// GasCost returns the most units of gas cost on a chain at its current fees, in its native token's base units
+func (e *ChainExecutor) GasCost(ctx context.Context, chainID int64, units uint64) (*big.Int, error) {
+	return e.adapter.GasCost(ctx, types.CanonicalChainID(chainID), units)
+}
+
+// Wrap wraps amount of token into its Universal version for recipient, once per request ID
+func (e *ChainExecutor) Wrap(ctx context.Context, requestID string, token types.Token, amount *big.Int, recipient string) (*chains.Receipt, error) {
+	chainID := types.CanonicalChainID(token.ChainID)
+	data, err := evm.EncodeCall(wrapFunction, tokenAddress(token), amount, recipient)
+	if err != nil {
+		return nil, invalidCallError(err)
+	}
+	return e.execute(ctx, "wrap:"+requestID, chains.TxRequest{ChainID: chainID, To: e.contracts[chainID].Universal, Value: nativeValue(token, amount), Data: data})
+}
+
+// Unwrap unwraps amount of token's Universal version back to token for recipient, once per request ID
+func (e *ChainExecutor) Unwrap(ctx context.Context, requestID string, token types.Token, amount *big.Int, recipient string) (*chains.Receipt, error) {
+	chainID := types.CanonicalChainID(token.ChainID)
+	data, err := evm.EncodeCall(unwrapFunction, tokenAddress(token), amount, recipient)
+	if err != nil {
+		return nil, invalidCallError(err)
+	}
+	return e.execute(ctx, "unwrap:"+requestID, chains.TxRequest{ChainID: chainID, To: e.contracts[chainID].Universal, Data: data})
+}
+
+// Swap swaps the request's source token for its destination token, reverting if it would deliver less than minOutput.
+// Swaps carrying a user operation are sent from the source smart account through the chain's bundler.
+func (e *ChainExecutor) Swap(ctx context.Context, request types.SwapRequest, minOutput *big.Int) (*chains.Receipt, error) {
+	sub, err := e.submitSwap(ctx, request, minOutput)
+	if err != nil {
+		return nil, err
+	}
+	return e.confirm(ctx, sub, e.receiptTimeout)
+}
+
+// Spender returns the contract carrying out operation on a chain, which spends the operation's token; empty when
+// the operation isn't executed on-chain there or allowances can't be read
+func (e *ChainExecutor) Spender(chainID int64, operation string) string {
+	if e.reader == nil {
+		return ""
+	}
+	contracts := e.contracts[types.CanonicalChainID(chainID)]
+	switch operation {
+	case types.AllowanceForWrap:
+		return contracts.Universal
+	case types.AllowanceForSwap:
+		return contracts.DEX
+	default:
+		return ""
+	}
+}
+
+// Allowance returns how much of token spender may spend from the account
+func (e *ChainExecutor) Allowance(ctx context.Context, token types.Token, spender string) (*big.Int, error) {
+	data, err := evm.EncodeCall(allowanceFunction, e.account, spender)
+	if err != nil {
+		return nil, err
+	}
+	result, err := e.reader.Call(ctx, types.CanonicalChainID(token.ChainID), "", token.Address, "0x"+hex.EncodeToString(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) < 32 {
+		return nil, fmt.Errorf("invalid allowance %x of %s", result, token.Symbol)
+	}
+	return new(big.Int).SetBytes(result[:32]), nil
+}
+
+// Approve lets spender spend amount of token from the account, or an unlimited amount unless approvals are exact,
+// returning the mined approval and the allowance it granted. current is the allowance the approval replaces; each
+// request approves once per allowance, so a retry waits for its approval instead of sending another.
+func (e *ChainExecutor) Approve(ctx context.Context, requestID string, token types.Token, spender string, amount, current *big.Int) (*chains.Receipt, *big.Int, error) {
+	approved := unlimitedAllowance
+	if e.exactApprovals {
+		approved = amount
+	}
+	data, err := evm.EncodeCall(approveFunction, spender, approved)
+	if err != nil {
+		return nil, nil, invalidCallError(err)
+	}
+	chainID := types.CanonicalChainID(token.ChainID)
+	key := fmt.Sprintf("approve:%s:%s:%s", requestID, strings.ToLower(spender), current)
+	receipt, err := e.execute(ctx, key, chains.TxRequest{ChainID: chainID, To: token.Address, Data: data})
+	if err != nil {
+		return nil, nil, err
+	}
+	return receipt, approved, nil
+}
+
+// SwapCall returns the call to a chain's DEX contract swapping the request's tokens for its destination address,
+// private when the request asked for private execution. Swaps carrying a permit pull their source tokens from the
+// permit's owner with it.
+func SwapCall(contracts map[int64]ChainContracts, request types.SwapRequest, minOutput *big.Int) (chains.TxRequest, error) {
+	chainID := types.CanonicalChainID(request.SourceToken.ChainID)
+	args := []interface{}{
+		tokenAddress(request.SourceToken),
+		tokenAddress(request.DestinationToken),
+		request.Amount,
+		minOutput,
+		request.DestinationAddress,
+	}
+	function := swapFunction
+	if permit := request.Permit; permit != nil {
+		signature, err := hex.DecodeString(strings.TrimPrefix(permit.Signature, "0x"))
+		if err != nil {
+			return chains.TxRequest{}, fmt.Errorf("invalid permit signature: %w", err)
+		}
+		function = swapWithPermitFunction
+		args = append(args, permit.Owner, permit.Value)
+		if permit.Kind == types.PermitPermit2 {
+			function = swapWithPermit2Function
+			args = append(args, permit.Nonce)
+		}
+		args = append(args, big.NewInt(permit.Deadline), signature)
+	}
+	data, err := evm.EncodeCall(function, args...)
+	if err != nil {
+		return chains.TxRequest{}, err
+	}
+	return chains.TxRequest{ChainID: chainID, To: contracts[chainID].DEX, Value: nativeValue(request.SourceToken, request.Amount), Data: data, Private: request.PrivateExecution}, nil
+}
+
+// CheckSwapUserOperation checks a request's user operation is signed and makes its source account carry out the
+// request's swap, with the request's minimum output
+func CheckSwapUserOperation(contracts map[int64]ChainContracts, request types.SwapRequest) error {
+	op := request.UserOperation
+	switch {
+	case op == nil:
+		return errors.New("swap has no user operation")
+	case !strings.EqualFold(op.Sender, request.SourceAddress):
+		return fmt.Errorf("user operation is sent from %s, not the source address", op.Sender)
+	case request.MinOutputAmount == nil:
+		return errors.New("swaps from smart accounts need a minimum output")
+	case len(op.Signature) == 0:
+		return errors.New("user operation is not signed")
+	}
+
+	call, err := SwapCall(contracts, request, request.MinOutputAmount)
+	if err != nil {
+		return err
+	}
+	expected, err := evm.AccountCallData(call)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(op.CallData, expected) {
+		return errors.New("user operation does not carry out the swap")
+	}
+	return nil
+}
+
+// CheckSwapPermit checks a request's permit is signed by its source address and lets the chain's DEX contract pull
+// the swap's source tokens until at least now
+func CheckSwapPermit(contracts map[int64]ChainContracts, request types.SwapRequest, now time.Time) error {
+	permit := request.Permit
+	chainID := types.CanonicalChainID(request.SourceToken.ChainID)
+	switch {
+	case permit == nil:
+		return errors.New("swap has no permit")
+	case request.UserOperation != nil:
+		return errors.New("swaps from smart accounts can't carry a permit")
+	case chainID != types.CanonicalChainID(request.DestinationToken.ChainID) || contracts[chainID].DEX == "":
+		return errors.New("only same-chain swaps on chains with a DEX contract can use a permit")
+	case request.SourceToken.IsNative():
+		return errors.New("native tokens can't be permitted")
+	case !strings.EqualFold(permit.Owner, request.SourceAddress):
+		return fmt.Errorf("permit is signed for %s, not the source address", permit.Owner)
+	case !strings.EqualFold(permit.Spender, contracts[chainID].DEX):
+		return fmt.Errorf("permit lets %s spend, not the DEX contract %s", permit.Spender, contracts[chainID].DEX)
+	case permit.Value == nil || permit.Value.Cmp(request.Amount) < 0:
+		return fmt.Errorf("permit value %s is less than the swap amount %s", permit.Value, request.Amount)
+	}
+	return services.VerifyPermit(*permit, request.SourceToken, now)
+}
+
+// submission is a swap sent to a chain, waited for with wait
+type submission struct {
+	chainID int64
+	hash    string
+	wait    func(ctx context.Context) (*chains.Receipt, error)
+}
+
+// submitSwap sends the request's swap without waiting for it to be mined, once per request ID. A swap whose permit
+// has expired or is invalid fails without being sent.
+func (e *ChainExecutor) submitSwap(ctx context.Context, request types.SwapRequest, minOutput *big.Int) (*submission, error) {
+	if request.UserOperation != nil {
+		return e.submitUserOperation(ctx, request)
+	}
+
+	if request.Permit != nil {
+		if err := CheckSwapPermit(e.contracts, request, time.Now()); err != nil {
+			return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("Invalid permit: %v", err), "INVALID_PERMIT", err)
+		}
+	}
+	req, err := SwapCall(e.contracts, request, minOutput)
+	if err != nil {
+		return nil, invalidCallError(err)
+	}
+	key := "swap:" + request.RequestID
+	tx, err := e.submit(ctx, key, req)
+	if err != nil {
+		return nil, err
+	}
+	return e.transaction(key, tx), nil
+}
+
+// submitUserOperation sends a swap's signed user operation to the chain's bundler. The EntryPoint executes each
+// account nonce once, so an attempt after the first waits for the operation even when the bundler refuses it again.
+func (e *ChainExecutor) submitUserOperation(ctx context.Context, request types.SwapRequest) (*submission, error) {
+	if e.bundler == nil {
+		return nil, temporal.NewNonRetryableApplicationError("No bundler configured for user operations", "USER_OPERATION_UNSUPPORTED", nil)
+	}
+	if err := CheckSwapUserOperation(e.contracts, request); err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("Invalid user operation: %v", err), "INVALID_USER_OPERATION", err)
+	}
+
+	chainID := types.CanonicalChainID(request.SourceToken.ChainID)
+	hash, err := e.bundler.UserOperationHash(chainID, request.UserOperation)
+	if err != nil {
+		return nil, invalidCallError(err)
+	}
+	sub := &submission{chainID: chainID, hash: "0x" + hex.EncodeToString(hash)}
+	sub.wait = func(ctx context.Context) (*chains.Receipt, error) {
+		return e.bundler.WaitForUserOperation(ctx, chainID, sub.hash)
+	}
+
+	logger := activity.GetLogger(ctx)
+	if _, err := e.bundler.SendUserOperation(ctx, chainID, request.UserOperation); err != nil {
+		if activity.GetInfo(ctx).Attempt <= 1 {
+			return nil, temporal.NewApplicationError(fmt.Sprintf("Failed to send user operation: %v", err), "USER_OPERATION_SEND_FAILED")
+		}
+		// An earlier attempt may have sent it, so keep waiting for it
+		logger.Warn("Resending user operation failed", "userOpHash", sub.hash, "error", err)
+	}
+	logger.Info("User operation sent", "chainID", chainID, "userOpHash", sub.hash, "sender", request.UserOperation.Sender)
+	return sub, nil
+}
+
+// transaction returns the submission of the broadcast transaction carrying out the operation key names
+func (e *ChainExecutor) transaction(key string, tx *chains.Tx) *submission {
+	return &submission{
+		chainID: tx.ChainID,
+		hash:    tx.Hash,
+		wait: func(ctx context.Context) (*chains.Receipt, error) {
+			return e.waitForTx(ctx, key, tx)
+		},
+	}
+}
+
+// txOutcome is what waiting for one version of a transaction returned
+type txOutcome struct {
+	receipt *chains.Receipt
+	err     error
+}
+
+// waitForTx waits for any version of a transaction to be mined. Each time it stays unmined for speedUpAfter, it is
+// replaced with one paying higher fees, stored before it is broadcast like the original.
+func (e *ChainExecutor) waitForTx(ctx context.Context, key string, tx *chains.Tx) (*chains.Receipt, error) {
+	var waiting sync.WaitGroup
+	defer waiting.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chainID := tx.ChainID
+	outcomes := make(chan txOutcome)
+	wait := func(hash string) {
+		waiting.Add(1)
+		go func() {
+			defer waiting.Done()
+			receipt, err := e.adapter.WaitForReceipt(ctx, chainID, hash)
+			select {
+			case outcomes <- txOutcome{receipt, err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+	// Any earlier version may be the one mined
+	for _, hash := range append(append([]string(nil), tx.Replaces...), tx.Hash) {
+		wait(hash)
+	}
+
+	var speedUp <-chan time.Time
+	if e.speedUpAfter > 0 {
+		ticker := time.NewTicker(e.speedUpAfter)
+		defer ticker.Stop()
+		speedUp = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case outcome := <-outcomes:
+			return outcome.receipt, outcome.err
+		case <-speedUp:
+			if len(tx.Replaces) >= maxSpeedUps {
+				speedUp = nil
+				continue
+			}
+			replacement, err := e.replace(ctx, key, tx)
+			if err != nil {
+				activity.GetLogger(ctx).Warn("Failed to speed up transaction", "txHash", tx.Hash, "error", err)
+				continue
+			}
+			tx = replacement
+			wait(tx.Hash)
+		}
+	}
+}
+
+// replace signs, stores and broadcasts a replacement for a stuck transaction
+func (e *ChainExecutor) replace(ctx context.Context, key string, tx *chains.Tx) (*chains.Tx, error) {
+	replacement, err := e.adapter.ReplaceTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.txs.ReplaceSignedTx(ctx, key, *replacement); err != nil {
+		return nil, err
+	}
+
+	logger := activity.GetLogger(ctx)
+	if _, err := e.adapter.Broadcast(ctx, replacement); err != nil {
+		// The original may have been mined meanwhile, and is still waited for
+		logger.Warn("Replacement broadcast failed", "txHash", replacement.Hash, "error", err)
+	} else {
+		logger.Info("Transaction sped up", "chainID", tx.ChainID, "nonce", tx.Nonce, "replaced", tx.Hash, "txHash", replacement.Hash)
+	}
+	return replacement, nil
+}
+
+// execute submits a transaction and waits for its receipt
+func (e *ChainExecutor) execute(ctx context.Context, key string, req chains.TxRequest) (*chains.Receipt, error) {
+	tx, err := e.submit(ctx, key, req)
+	if err != nil {
+		return nil, err
+	}
+	return e.confirm(ctx, e.transaction(key, tx), e.receiptTimeout)
+}
+
+// submit builds, signs and broadcasts the transaction carrying out the operation key names. The signed transaction
+// is stored before it is broadcast; a transaction stored by an earlier attempt is rebroadcast instead.
+func (e *ChainExecutor) submit(ctx context.Context, key string, req chains.TxRequest) (*chains.Tx, error) {
+	logger := activity.GetLogger(ctx)
+
+	tx, err := e.txs.GetSignedTx(ctx, key)
+	if err != nil {
+		return nil, temporal.NewApplicationError(fmt.Sprintf("Failed to load signed transaction: %v", err), "TX_STORE_FAILED")
+	}
+	resumed := tx != nil
+
+	if !resumed {
+		built, err := e.adapter.BuildTx(ctx, req)
+		if errors.Is(err, chains.ErrNoPrivateRelay) {
+			return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("No private relay for chain %d", req.ChainID), "PRIVATE_RELAY_UNAVAILABLE", err)
+		}
+		if err != nil {
+			return nil, temporal.NewApplicationError(fmt.Sprintf("Failed to build transaction: %v", err), "TX_BUILD_FAILED")
+		}
+		signed, err := e.adapter.SignTx(ctx, built)
+		if err != nil {
+			e.adapter.DiscardTx(built)
+			return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("Failed to sign transaction: %v", err), "TX_SIGN_FAILED", err)
+		}
+		// A transaction that is never stored is never broadcast, so the next attempt can sign another
+		saved, err := e.txs.SaveSignedTx(ctx, key, *signed)
+		if err != nil {
+			return nil, temporal.NewApplicationError(fmt.Sprintf("Failed to store signed transaction: %v", err), "TX_STORE_FAILED")
+		}
+		if !saved {
+			// Another attempt stored its transaction first, so this one is never broadcast
+			e.adapter.DiscardTx(built)
+			if tx, err = e.txs.GetSignedTx(ctx, key); err != nil || tx == nil {
+				return nil, temporal.NewApplicationError(fmt.Sprintf("Failed to load signed transaction: %v", err), "TX_STORE_FAILED")
+			}
+		} else {
+			tx = signed
+		}
+	} else {
+		logger.Info("Resuming transaction", "key", key, "chainID", tx.ChainID, "txHash", tx.Hash)
+	}
+
+	if _, err := e.adapter.Broadcast(ctx, tx); err != nil {
+		if !resumed {
+			// The stored transaction is rebroadcast by the next attempt
+			return nil, temporal.NewApplicationError(fmt.Sprintf("Failed to broadcast transaction: %v", err), "TX_BROADCAST_FAILED")
+		}
+		// Nodes reject transactions they already have or that were mined, so keep waiting for the receipt
+		logger.Warn("Rebroadcast failed", "txHash", tx.Hash, "error", err)
+	}
+	logger.Info("Transaction broadcast", "chainID", tx.ChainID, "txHash", tx.Hash, "nonce", tx.Nonce, "private", tx.Private)
+	return tx, nil
+}
+
+// confirm waits up to timeout for a submission to be mined
+func (e *ChainExecutor) confirm(ctx context.Context, sub *submission, timeout time.Duration) (*chains.Receipt, error) {
+	receipt, err := awaitReceipt(ctx, sub, timeout)
+	if err != nil {
+		return nil, receiptError(sub, err)
+	}
+	return receipt, nil
+}
+
+// awaitReceipt waits up to timeout for a submission's receipt, heartbeating so the wait does not trip the
+// activity's heartbeat timeout
+func awaitReceipt(ctx context.Context, sub *submission, timeout time.Duration) (*chains.Receipt, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-waitCtx.Done():
+				return
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx, sub.hash)
+			}
+		}
+	}()
+	activity.RecordHeartbeat(ctx, sub.hash)
+	return sub.wait(waitCtx)
+}
+
+// receiptError reports a submission that reverted or could not be confirmed
+func receiptError(sub *submission, err error) error {
+	if errors.Is(err, chains.ErrTxReverted) {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("Transaction %s reverted", sub.hash), "TX_REVERTED", err)
+	}
+	return temporal.NewApplicationError(fmt.Sprintf("Failed to confirm transaction %s: %v", sub.hash, err), "TX_RECEIPT_FAILED")
+}
+
+// tokenAddress returns the address a token is passed to contracts as: the zero address for native tokens, however
+// they were given
+func tokenAddress(token types.Token) string {
+	if token.IsNative() {
+		return types.NativeTokenAddress
+	}
+	return token.Address
+}
+
+// nativeValue returns the value sent with a call spending amount of token: the amount for native tokens, else none
+func nativeValue(token types.Token, amount *big.Int) *big.Int {
+	if token.IsNative() {
+		return amount
+	}
+	return nil
+}
+
+// onChainFee returns the fee of a mined transaction, which only pays gas
+func onChainFee(receipt *chains.Receipt) types.Fee {
+	return types.Fee{
+		GasFee:      receipt.GasCost(),
+		ProtocolFee: big.NewInt(0),
+		NetworkFee:  big.NewInt(0),
+		BridgeFee:   big.NewInt(0),
+	}
+}
+
+// invalidCallError reports calldata that cannot be encoded, which no retry fixes
+func invalidCallError(err error) error {
+	return temporal.NewNonRetryableApplicationError(fmt.Sprintf("Invalid transaction: %v", err), "INVALID_TX", err)
+}
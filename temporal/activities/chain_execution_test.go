@@ -0,0 +1,566 @@
+package temporal_activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/chains/signer"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fakeAdapter records the transactions it is asked to send and mines them with a fixed outcome
+type fakeAdapter struct {
+	built     []chains.TxRequest
+	broadcast []string
+	discarded []uint64
+	reverted  bool
+	pending   bool                // transactions are never mined
+	mined     string              // when set, only this hash is mined
+	logs      []chains.Log        // emitted by every mined transaction
+	noRelay   bool                // private transactions are refused
+	balances  map[string]*big.Int // by token address, "" for the native token; unlisted tokens are empty
+	mu        sync.Mutex
+}
+
+func (f *fakeAdapter) BuildTx(ctx context.Context, req chains.TxRequest) (*chains.Tx, error) {
+	if req.Private && f.noRelay {
+		return nil, chains.ErrNoPrivateRelay
+	}
+	f.built = append(f.built, req)
+	return &chains.Tx{ChainID: req.ChainID, To: req.To, Value: req.Value, Data: req.Data, Private: req.Private, Nonce: uint64(len(f.built))}, nil
+}
+
+func (f *fakeAdapter) SignTx(ctx context.Context, tx *chains.Tx) (*chains.Tx, error) {
+	signed := *tx
+	signed.Raw = []byte{0x01}
+	signed.Hash = "0xsigned"
+	return &signed, nil
+}
+
+func (f *fakeAdapter) Broadcast(ctx context.Context, tx *chains.Tx) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.broadcast = append(f.broadcast, tx.Hash)
+	return tx.Hash, nil
+}
+
+func (f *fakeAdapter) ReplaceTx(ctx context.Context, tx *chains.Tx) (*chains.Tx, error) {
+	replacement := *tx
+	replacement.Replaces = append(append([]string(nil), tx.Replaces...), tx.Hash)
+	replacement.Hash = fmt.Sprintf("0xreplacement%d", len(replacement.Replaces))
+	return &replacement, nil
+}
+
+func (f *fakeAdapter) DiscardTx(tx *chains.Tx) {
+	f.discarded = append(f.discarded, tx.Nonce)
+}
+
+func (f *fakeAdapter) Balance(ctx context.Context, chainID int64, owner, token string) (*big.Int, error) {
+	if balance, ok := f.balances[token]; ok {
+		return balance, nil
+	}
+	return big.NewInt(0), nil
+}
+
+// GasCost prices gas at 10 wei
+func (f *fakeAdapter) GasCost(ctx context.Context, chainID int64, units uint64) (*big.Int, error) {
+	return new(big.Int).SetUint64(units * 10), nil
+}
+
+func (f *fakeAdapter) WaitForReceipt(ctx context.Context, chainID int64, hash string) (*chains.Receipt, error) {
+	if f.pending || (f.mined != "" && hash != f.mined) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	receipt := &chains.Receipt{TxHash: hash, BlockNumber: 100, GasUsed: 21000, EffectiveGasPrice: big.NewInt(10), Success: !f.reverted, Logs: f.logs}
+	if f.reverted {
+		return receipt, chains.ErrTxReverted
+	}
+	return receipt, nil
+}
+
+// fakeBundler records the user operations it is asked to send and includes them with a fixed outcome
+type fakeBundler struct {
+	sent    []*chains.UserOperation
+	refuses bool // sending fails, as when the operation was already sent
+	logs    []chains.Log
+}
+
+func (f *fakeBundler) AccountType(ctx context.Context, chainID int64, address string) (chains.AccountType, error) {
+	return chains.AccountTypeSmart, nil
+}
+
+func (f *fakeBundler) BuildUserOperation(ctx context.Context, sender string, req chains.TxRequest) (*chains.UserOperation, error) {
+	callData, err := evm.AccountCallData(req)
+	if err != nil {
+		return nil, err
+	}
+	return &chains.UserOperation{Sender: sender, Nonce: big.NewInt(0), CallData: callData}, nil
+}
+
+func (f *fakeBundler) UserOperationHash(chainID int64, op *chains.UserOperation) ([]byte, error) {
+	return evm.Keccak256(op.CallData), nil
+}
+
+func (f *fakeBundler) SendUserOperation(ctx context.Context, chainID int64, op *chains.UserOperation) (string, error) {
+	f.sent = append(f.sent, op)
+	if f.refuses {
+		return "", errors.New("AA25 invalid account nonce")
+	}
+	hash, _ := f.UserOperationHash(chainID, op)
+	return "0x" + hex.EncodeToString(hash), nil
+}
+
+func (f *fakeBundler) WaitForUserOperation(ctx context.Context, chainID int64, hash string) (*chains.Receipt, error) {
+	return &chains.Receipt{TxHash: "0xbundle", BlockNumber: 100, GasUsed: 100000, EffectiveGasPrice: big.NewInt(10), Success: true, Logs: f.logs}, nil
+}
+
+// racingTxStore hides stored transactions from the first lookup, as when another attempt stores one concurrently
+type racingTxStore struct {
+	*services.InMemorySignedTxStore
+	looked bool
+}
+
+func (s *racingTxStore) GetSignedTx(ctx context.Context, key string) (*chains.Tx, error) {
+	if !s.looked {
+		s.looked = true
+		return nil, nil
+	}
+	return s.InMemorySignedTxStore.GetSignedTx(ctx, key)
+}
+
+func TestChainExecutor(t *testing.T) {
+	const (
+		universal = "0x1111111111111111111111111111111111111111"
+		dex       = "0x2222222222222222222222222222222222222222"
+		user      = "0x1234567890abcdef1234567890abcdef12345678"
+	)
+	contracts := map[int64]ChainContracts{1: {Universal: universal, DEX: dex}}
+
+	swapRequest := types.SwapRequest{
+		SourceToken:        types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1, Address: "0x3333333333333333333333333333333333333333", IsWrapped: true},
+		DestinationToken:   types.Token{Symbol: "uUSDC", Decimals: 18, ChainID: 1, Address: "0x4444444444444444444444444444444444444444", IsWrapped: true},
+		Amount:             big.NewInt(1_000_000_000_000_000_000),
+		SourceAddress:      user,
+		DestinationAddress: user,
+		Slippage:           1,
+		RequestID:          "onchain-1",
+	}
+
+	// The DEX sends 2000 uUSDC to the user
+	output := []chains.Log{{
+		Address: swapRequest.DestinationToken.Address,
+		Topics: []string{
+			"0x" + hex.EncodeToString(evm.Keccak256([]byte("Transfer(address,address,uint256)"))),
+			"0x000000000000000000000000" + dex[2:],
+			"0x000000000000000000000000" + user[2:],
+		},
+		Data: big.NewInt(2000).FillBytes(make([]byte, 32)),
+	}}
+
+	newEnvWithStore := func(adapter *fakeAdapter, store services.SignedTxStore) (*testsuite.TestActivityEnvironment, *SwapActivities, *LiquidityActivities) {
+		executor := NewChainExecutor(adapter, contracts, time.Second)
+		executor.SetTxStore(store)
+		sandbox := services.NewSandboxService(new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil))
+		swapActivities := NewSwapActivities(nil, sandbox)
+		swapActivities.SetChainExecutor(executor)
+		liquidityActivities := NewLiquidityActivities(nil, nil, nil)
+		liquidityActivities.SetChainExecutor(executor)
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(swapActivities.ExecuteSwapActivity)
+		env.RegisterActivity(swapActivities.FastSwapActivity)
+		env.RegisterActivity(liquidityActivities.WrapLiquidityTokenActivity)
+		return env, swapActivities, liquidityActivities
+	}
+	newEnv := func(adapter *fakeAdapter) (*testsuite.TestActivityEnvironment, *SwapActivities, *LiquidityActivities) {
+		return newEnvWithStore(adapter, services.NewInMemorySignedTxStore())
+	}
+
+	t.Run("SwapsOnChain", func(t *testing.T) {
+		adapter := &fakeAdapter{logs: output}
+		env, activities, _ := newEnv(adapter)
+		value, err := env.ExecuteActivity(activities.ExecuteSwapActivity, swapRequest)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.SwapResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if !result.Success || result.SourceTx.Hash != "0xsigned" || result.SourceTx.BlockNumber != 100 {
+			t.Errorf("Expected the mined transaction in the result, got %+v", result.SourceTx)
+		}
+		if result.OutputAmount.Int64() != 2000 || result.Fee.GasFee.Int64() != 210000 || result.Fee.ProtocolFee.Sign() != 0 {
+			t.Errorf("Expected the output and gas in the receipt, got output %s, fee %+v", result.OutputAmount, result.Fee)
+		}
+		if len(adapter.built) != 1 || adapter.built[0].To != dex {
+			t.Fatalf("Expected one transaction to the DEX, got %+v", adapter.built)
+		}
+
+		quote, _ := activities.swapService.GetSwapQuote(context.Background(), swapRequest)
+		minOutput := services.ApplySlippage(quote.OutputAmount, 1)
+		expected, _ := evm.EncodeCall(swapFunction, swapRequest.SourceToken.Address, swapRequest.DestinationToken.Address, swapRequest.Amount, minOutput, user)
+		if string(adapter.built[0].Data) != string(expected) {
+			t.Errorf("Expected swap calldata with minimum output %s, got %x", minOutput, adapter.built[0].Data)
+		}
+	})
+
+	t.Run("PricesGasWhenMined", func(t *testing.T) {
+		env, activities, _ := newEnv(&fakeAdapter{logs: output})
+		activities.SetPricePolicy(services.NewPriceStalenessPolicy(fixedOracle{Symbol: "ETH", PriceUSD: 1000, LastUpdated: time.Now()}, time.Minute))
+		value, err := env.ExecuteActivity(activities.ExecuteSwapActivity, swapRequest)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.SwapResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		// 210000 wei of gas at $1000 per ETH
+		if fee := result.Fee; fee.PriceUSD != 1000 || fee.GasFeeUSD < 2.09e-10 || fee.GasFeeUSD > 2.11e-10 || fee.TotalFeeUSD != fee.GasFeeUSD {
+			t.Errorf("Expected the gas valued at the chain's native token price, got %+v", fee)
+		}
+	})
+
+	t.Run("PrivateExecution", func(t *testing.T) {
+		private := swapRequest
+		private.RequestID = "onchain-private"
+		private.PrivateExecution = true
+		adapter := &fakeAdapter{logs: output}
+		env, activities, _ := newEnv(adapter)
+		if _, err := env.ExecuteActivity(activities.ExecuteSwapActivity, private); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(adapter.built) != 1 || !adapter.built[0].Private {
+			t.Errorf("Expected a private transaction, got %+v", adapter.built)
+		}
+
+		// A chain without a relay fails the swap rather than expose it in the public mempool
+		private.RequestID = "onchain-private-no-relay"
+		env, activities, _ = newEnv(&fakeAdapter{noRelay: true})
+		_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, private)
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != "PRIVATE_RELAY_UNAVAILABLE" || !appErr.NonRetryable() {
+			t.Errorf("Expected a non-retryable PRIVATE_RELAY_UNAVAILABLE error, got %v", err)
+		}
+	})
+
+	t.Run("ResumesSignedTransaction", func(t *testing.T) {
+		adapter := &fakeAdapter{}
+		store := services.NewInMemorySignedTxStore()
+		store.SaveSignedTx(context.Background(), "swap:"+swapRequest.RequestID, chains.Tx{ChainID: 1, Raw: []byte{0x02}, Hash: "0xprevious"})
+		env, activities, _ := newEnvWithStore(adapter, store)
+		value, err := env.ExecuteActivity(activities.ExecuteSwapActivity, swapRequest)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.SwapResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if len(adapter.built) != 0 || result.SourceTx.Hash != "0xprevious" {
+			t.Errorf("Expected the previous transaction to be waited for, built %d, got %s", len(adapter.built), result.SourceTx.Hash)
+		}
+		if len(adapter.broadcast) != 1 || adapter.broadcast[0] != "0xprevious" {
+			t.Errorf("Expected the previous transaction to be rebroadcast, got %v", adapter.broadcast)
+		}
+	})
+
+	t.Run("StoresBeforeBroadcast", func(t *testing.T) {
+		adapter := &fakeAdapter{pending: true}
+		store := services.NewInMemorySignedTxStore()
+		env, activities, _ := newEnvWithStore(adapter, store)
+		if _, err := env.ExecuteActivity(activities.ExecuteSwapActivity, swapRequest); err == nil {
+			t.Fatal("Expected an unmined swap to fail the attempt")
+		}
+		if tx, _ := store.GetSignedTx(context.Background(), "swap:"+swapRequest.RequestID); tx == nil || tx.Hash != "0xsigned" {
+			t.Fatalf("Expected the signed transaction to be stored, got %+v", tx)
+		}
+
+		// The retry waits for the stored transaction instead of signing another
+		adapter.pending = false
+		if _, err := env.ExecuteActivity(activities.ExecuteSwapActivity, swapRequest); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(adapter.built) != 1 || len(adapter.broadcast) != 2 {
+			t.Errorf("Expected one transaction broadcast twice, built %d, broadcast %v", len(adapter.built), adapter.broadcast)
+		}
+	})
+
+	t.Run("SpeedsUpStuckTransaction", func(t *testing.T) {
+		stuck := swapRequest
+		stuck.RequestID = "onchain-stuck"
+		adapter := &fakeAdapter{mined: "0xreplacement2"}
+		store := services.NewInMemorySignedTxStore()
+		env, activities, _ := newEnvWithStore(adapter, store)
+		activities.executor.SetSpeedUpAfter(20 * time.Millisecond)
+
+		value, err := env.ExecuteActivity(activities.ExecuteSwapActivity, stuck)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.SwapResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if result.SourceTx.Hash != "0xreplacement2" {
+			t.Errorf("Expected the second replacement to be mined, got %s", result.SourceTx.Hash)
+		}
+		tx, _ := store.GetSignedTx(context.Background(), "swap:"+stuck.RequestID)
+		if tx == nil || tx.Hash != "0xreplacement2" || len(tx.Replaces) != 2 || tx.Replaces[0] != "0xsigned" {
+			t.Errorf("Expected the replacement stored with the versions it replaces, got %+v", tx)
+		}
+		if len(adapter.built) != 1 || len(adapter.broadcast) != 3 {
+			t.Errorf("Expected one transaction broadcast in three versions, built %d, broadcast %v", len(adapter.built), adapter.broadcast)
+		}
+	})
+
+	t.Run("DiscardsUnstoredTransaction", func(t *testing.T) {
+		adapter := &fakeAdapter{}
+		store := services.NewInMemorySignedTxStore()
+		store.SaveSignedTx(context.Background(), "wrap:raced", chains.Tx{ChainID: 1, Hash: "0xother"})
+		executor := NewChainExecutor(adapter, contracts, time.Second)
+		executor.SetTxStore(&racingTxStore{InMemorySignedTxStore: store})
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		submit := func(ctx context.Context) (string, error) {
+			tx, err := executor.submit(ctx, "wrap:raced", chains.TxRequest{ChainID: 1, To: universal})
+			if err != nil {
+				return "", err
+			}
+			return tx.Hash, nil
+		}
+		env.RegisterActivity(submit)
+		value, err := env.ExecuteActivity(submit)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var hash string
+		value.Get(&hash)
+		if hash != "0xother" || len(adapter.discarded) != 1 {
+			t.Errorf("Expected the stored transaction sent and the built one discarded, got %s, discarded %v", hash, adapter.discarded)
+		}
+	})
+
+	t.Run("FastPath", func(t *testing.T) {
+		fast := swapRequest
+		fast.RequestID = "onchain-fast"
+		fast.MinOutputAmount = big.NewInt(1500)
+
+		adapter := &fakeAdapter{logs: output}
+		env, activities, _ := newEnv(adapter)
+		value, err := env.ExecuteActivity(activities.FastSwapActivity, fast)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.SwapResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if result.Status != types.SwapStatusCompleted || result.OutputAmount.Int64() != 2000 || result.SourceTx.Hash != "0xsigned" {
+			t.Errorf("Expected the fast path swap mined on-chain, got %+v", result)
+		}
+		expected, _ := evm.EncodeCall(swapFunction, fast.SourceToken.Address, fast.DestinationToken.Address, fast.Amount, fast.MinOutputAmount, user)
+		if len(adapter.built) != 1 || string(adapter.built[0].Data) != string(expected) {
+			t.Errorf("Expected one swap with the request's minimum output, got %+v", adapter.built)
+		}
+
+		t.Run("Unmined", func(t *testing.T) {
+			fast.RequestID = "onchain-fast-pending"
+			env, activities, _ := newEnv(&fakeAdapter{pending: true})
+			value, err := env.ExecuteActivity(activities.FastSwapActivity, fast)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var result types.SwapResult
+			if err := value.Get(&result); err != nil {
+				t.Fatalf("Failed to decode result: %v", err)
+			}
+			if result.Status != types.SwapStatusPending || result.SourceTx.Hash != "0xsigned" {
+				t.Errorf("Expected the swap pending with its transaction, got %+v", result)
+			}
+		})
+	})
+
+	t.Run("Reverted", func(t *testing.T) {
+		env, activities, _ := newEnv(&fakeAdapter{reverted: true})
+		_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, swapRequest)
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != "TX_REVERTED" || !appErr.NonRetryable() {
+			t.Errorf("Expected a non-retryable TX_REVERTED error, got %v", err)
+		}
+	})
+
+	t.Run("WrapsNativeToken", func(t *testing.T) {
+		adapter := &fakeAdapter{}
+		env, _, activities := newEnv(adapter)
+		request := types.LiquidityRequest{
+			PoolID:      "pool-1",
+			UserAddress: user,
+			Token:       types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+			Amount:      big.NewInt(5000),
+			RequestID:   "wrap-1",
+		}
+		value, err := env.ExecuteActivity(activities.WrapLiquidityTokenActivity, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result struct {
+			TransactionHash string      `json:"transactionHash"`
+			WrappedToken    types.Token `json:"wrappedToken"`
+			Fee             types.Fee   `json:"fee"`
+		}
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if result.TransactionHash != "0xsigned" || result.WrappedToken.Symbol != "uETH" || result.Fee.GasFee.Int64() != 210000 {
+			t.Errorf("Unexpected wrap result %+v", result)
+		}
+		if len(adapter.built) != 1 || adapter.built[0].To != universal || adapter.built[0].Value.Int64() != 5000 {
+			t.Errorf("Expected ETH to be sent to the Universal contract, got %+v", adapter.built)
+		}
+	})
+
+	t.Run("UserOperation", func(t *testing.T) {
+		smart := swapRequest
+		smart.RequestID = "onchain-smart"
+		smart.MinOutputAmount = big.NewInt(1500)
+		call, err := SwapCall(contracts, smart, smart.MinOutputAmount)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bundler := &fakeBundler{logs: output}
+		op, _ := bundler.BuildUserOperation(context.Background(), user, call)
+		op.Signature = []byte{0x01}
+		smart.UserOperation = op
+
+		adapter := &fakeAdapter{}
+		env, activities, _ := newEnv(adapter)
+		activities.executor.SetBundler(bundler)
+		value, err := env.ExecuteActivity(activities.ExecuteSwapActivity, smart)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.SwapResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if !result.Success || result.SourceTx.Hash != "0xbundle" || result.OutputAmount.Int64() != 2000 {
+			t.Errorf("Expected the bundled swap in the result, got %+v", result)
+		}
+		if len(adapter.built) != 0 || len(bundler.sent) != 1 {
+			t.Errorf("Expected only the user operation to be sent, built %d, sent %d", len(adapter.built), len(bundler.sent))
+		}
+
+		t.Run("Tampered", func(t *testing.T) {
+			tampered := smart
+			tampered.DestinationAddress = "0x9999999999999999999999999999999999999999"
+			_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, tampered)
+			var appErr *temporal.ApplicationError
+			if !errors.As(err, &appErr) || appErr.Type() != "INVALID_USER_OPERATION" || !appErr.NonRetryable() {
+				t.Errorf("Expected a non-retryable INVALID_USER_OPERATION error, got %v", err)
+			}
+		})
+
+		t.Run("Refused", func(t *testing.T) {
+			bundler.refuses = true
+			_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, smart)
+			var appErr *temporal.ApplicationError
+			if !errors.As(err, &appErr) || appErr.Type() != "USER_OPERATION_SEND_FAILED" || appErr.NonRetryable() {
+				t.Errorf("Expected a retryable USER_OPERATION_SEND_FAILED error, got %v", err)
+			}
+		})
+
+		t.Run("NoBundler", func(t *testing.T) {
+			env, activities, _ := newEnv(&fakeAdapter{})
+			_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, smart)
+			var appErr *temporal.ApplicationError
+			if !errors.As(err, &appErr) || appErr.Type() != "USER_OPERATION_UNSUPPORTED" {
+				t.Errorf("Expected a USER_OPERATION_UNSUPPORTED error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("Permit", func(t *testing.T) {
+		key, _ := signer.NewPrivateKeySigner("4646464646464646464646464646464646464646464646464646464646464646")
+		permitted := swapRequest
+		permitted.SourceToken.Name = "Universal ETH"
+		permitted.SourceAddress = key.Address()
+		sign := func(kind string, deadline time.Time) *types.Permit {
+			permit := types.Permit{Kind: kind, Owner: key.Address(), Spender: dex, Value: permitted.Amount, Nonce: big.NewInt(3), Deadline: deadline.Unix()}
+			digest, err := services.PermitDigest(permit, permitted.SourceToken)
+			if err != nil {
+				t.Fatalf("Failed to hash permit: %v", err)
+			}
+			signature, _ := key.SignDigest(context.Background(), digest)
+			raw := make([]byte, 65)
+			signature.R.FillBytes(raw[:32])
+			signature.S.FillBytes(raw[32:64])
+			raw[64] = 27 + signature.Recovery
+			permit.Signature = "0x" + hex.EncodeToString(raw)
+			return &permit
+		}
+
+		for kind, function := range map[string]string{types.PermitEIP2612: swapWithPermitFunction, types.PermitPermit2: swapWithPermit2Function} {
+			request := permitted
+			request.RequestID = "onchain-" + kind
+			request.Permit = sign(kind, time.Now().Add(time.Hour))
+			adapter := &fakeAdapter{logs: output}
+			env, activities, _ := newEnv(adapter)
+			if _, err := env.ExecuteActivity(activities.ExecuteSwapActivity, request); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(adapter.built) != 1 || !bytes.HasPrefix(adapter.built[0].Data, evm.Keccak256([]byte(function))[:4]) {
+				t.Errorf("Expected a %s call to the DEX, got %+v", function, adapter.built)
+			}
+		}
+
+		// An expired permit fails the swap without sending it
+		expired := permitted
+		expired.RequestID = "onchain-expired-permit"
+		expired.Permit = sign(types.PermitEIP2612, time.Now().Add(-time.Minute))
+		adapter := &fakeAdapter{}
+		env, activities, _ := newEnv(adapter)
+		_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, expired)
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != "INVALID_PERMIT" || !appErr.NonRetryable() || len(adapter.built) != 0 {
+			t.Errorf("Expected a non-retryable INVALID_PERMIT error, got %v", err)
+		}
+
+		// Permits must come from the source address, for the DEX, covering the amount
+		stranger := permitted
+		stranger.SourceAddress = user
+		short := permitted
+		short.Amount = new(big.Int).Add(permitted.Amount, big.NewInt(1))
+		for name, request := range map[string]types.SwapRequest{"another owner": stranger, "less than the amount": short} {
+			request.Permit = sign(types.PermitEIP2612, time.Now().Add(time.Hour))
+			if err := CheckSwapPermit(contracts, request, time.Now()); err == nil {
+				t.Errorf("Expected a permit with %s to be refused", name)
+			}
+		}
+	})
+
+	t.Run("SkipsUnconfiguredChains", func(t *testing.T) {
+		executor := NewChainExecutor(&fakeAdapter{}, contracts, time.Second)
+		crossChain := swapRequest
+		crossChain.DestinationToken.ChainID = 137
+		if executor.CanSwap(crossChain) || executor.CanWrap(137) {
+			t.Error("Expected only chain 1 to execute on-chain")
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package temporal_activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ComplianceActivities holds implementation of KYC/AML policy activities
+type ComplianceActivities struct {
+	policyEngine services.PolicyEngine
+}
+
+// NewComplianceActivities creates a new instance of compliance activities
+func NewComplianceActivities(policyEngine services.PolicyEngine) *ComplianceActivities {
+	return &ComplianceActivities{
+		policyEngine: policyEngine,
+	}
+}
+
+// EvaluateSwapPolicyActivity evaluates the tenant's policy against a swap before it starts
+func (a *ComplianceActivities) EvaluateSwapPolicyActivity(ctx context.Context, request types.SwapRequest, compliance types.ComplianceContext) (*types.PolicyDecision, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Evaluating swap policy", "requestID", request.RequestID, "tenantID", compliance.TenantID, "country", compliance.Country)
+
+	decision, err := a.policyEngine.Evaluate(ctx, services.PolicyInput{
+		Compliance: compliance,
+		Request:    request,
+	})
+	if err != nil {
+		return nil, temporal.NewApplicationError(
+			fmt.Sprintf("Failed to evaluate policy: %v", err),
+			"POLICY_EVALUATION_FAILED")
+	}
+
+	logger.Info("Swap policy evaluated",
+		"requestID", request.RequestID,
+		"outcome", decision.Outcome,
+		"policyVersion", decision.PolicyVersion,
+		"reasons", len(decision.Reasons))
+	return &decision, nil
+}
@@ -2,26 +2,37 @@ package temporal_activities
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net"
+	"sort"
 	"time"
 
 	"github.com/infinity-dex/services/repository"
 	"github.com/infinity-dex/services/types"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // DBActivities contains activities for database operations
 type DBActivities struct {
 	priceRepo *repository.PriceRepository
+	outbox    *PriceOutbox // optional, queues price writes while the database is down
 }
 
-// NewDBActivities creates a new instance of DBActivities
-func NewDBActivities(pool *pgxpool.Pool) *DBActivities {
+// NewDBActivities creates a new instance of DBActivities; outbox may be nil
+func NewDBActivities(pool *pgxpool.Pool, outbox *PriceOutbox) *DBActivities {
 	return &DBActivities{
 		priceRepo: repository.NewPriceRepository(pool),
+		outbox:    outbox,
 	}
 }
 
+// SetBatchSize sets how many prices are upserted together; sizes below 1 restore the default
+func (a *DBActivities) SetBatchSize(size int) {
+	a.priceRepo.SetBatchSize(size)
+}
+
 // SavePricesToDatabaseActivity saves token prices to the database
 func (a *DBActivities) SavePricesToDatabaseActivity(ctx context.Context, prices []types.TokenPrice) error {
 	log.Printf("Saving %d token prices to database", len(prices))
@@ -30,17 +41,85 @@ func (a *DBActivities) SavePricesToDatabaseActivity(ctx context.Context, prices
 	dbCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	// Save prices to database
-	err := a.priceRepo.SaveTokenPrices(dbCtx, prices)
+	if a.outbox == nil {
+		stats, err := a.priceRepo.SaveTokenPricesWithStats(dbCtx, prices)
+		if err != nil {
+			log.Printf("Error saving token prices to database: %v", err)
+			return err
+		}
+		a.logSaved(stats)
+		return nil
+	}
+
+	// Replay queued batches first so the history stays in order
+	replayed, err := a.outbox.Replay(dbCtx, a.priceRepo.SaveTokenPrices, isTransientDBError)
+	if replayed > 0 {
+		log.Printf("Replayed %d queued price batches from outbox", replayed)
+	}
+	if err != nil {
+		// Queue behind the batches still waiting; a rejected one is dead-lettered after enough attempts
+		log.Printf("Unable to replay price outbox, queueing %d token prices: %v", len(prices), err)
+		return a.enqueue(prices, err)
+	}
+
+	stats, err := a.priceRepo.SaveTokenPricesWithStats(dbCtx, prices)
 	if err != nil {
-		log.Printf("Error saving token prices to database: %v", err)
-		return err
+		if !isTransientDBError(err) {
+			// Queueing would only replay the same failure, so surface it
+			log.Printf("Error saving token prices to database: %v", err)
+			return err
+		}
+		log.Printf("Database unavailable, queueing %d token prices in outbox: %v", len(prices), err)
+		return a.enqueue(prices, err)
 	}
 
-	log.Printf("Successfully saved %d token prices to database", len(prices))
+	a.logSaved(stats)
 	return nil
 }
 
+// logSaved logs what a save wrote and the rate prices are being saved at
+func (a *DBActivities) logSaved(stats repository.PriceWriteStats) {
+	total := a.priceRepo.WriteStats()
+	log.Printf("Saved %d token prices to database in %v (%d new, %d history rows, %.0f rows/s; %.0f rows/s overall)",
+		stats.Prices, stats.Duration.Round(time.Millisecond), stats.Rows, stats.HistoryRows, stats.RowsPerSecond(), total.RowsPerSecond())
+}
+
+// enqueue queues prices in the outbox, returning saveErr if they can't be queued
+func (a *DBActivities) enqueue(prices []types.TokenPrice, saveErr error) error {
+	if err := a.outbox.Enqueue(prices); err != nil {
+		log.Printf("Error queueing token prices in outbox: %v", err)
+		return saveErr
+	}
+	return nil
+}
+
+// isTransientDBError reports whether err means the database couldn't be reached, rather than that it rejected the write
+func isTransientDBError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) || pgconn.SafeToRetry(err) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case len(pgErr.Code) >= 2 && pgErr.Code[:2] == "08": // connection exception
+			return true
+		case pgErr.Code == "57P01", pgErr.Code == "57P03", pgErr.Code == "53300": // shutting down, starting up, too many connections
+			return true
+		case pgErr.Code == "42P01": // undefined table, while the schema waits for the database to come up
+			return true
+		}
+	}
+	return false
+}
+
 // GetLatestTokenPricesActivity retrieves the latest token prices from the database
 func (a *DBActivities) GetLatestTokenPricesActivity(ctx context.Context) ([]types.TokenPrice, error) {
 	log.Println("Retrieving latest token prices from database")
@@ -82,3 +161,27 @@ func (a *DBActivities) GetTokenPriceHistoryActivity(ctx context.Context, symbol
 	log.Printf("Successfully retrieved %d price history records for %s", len(history), symbol)
 	return history, nil
 }
+
+// RollupCandlesActivity rolls the price history saved since the last rollup up into OHLCV candles.
+// Each interval tracks the newest history row it rolled up; the first run backfills all history.
+func (a *DBActivities) RollupCandlesActivity(ctx context.Context) error {
+	dbCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	intervals := make([]string, 0, len(types.CandleIntervals))
+	for interval := range types.CandleIntervals {
+		intervals = append(intervals, interval)
+	}
+	sort.Strings(intervals)
+
+	for _, interval := range intervals {
+		written, err := a.priceRepo.RollupCandles(dbCtx, interval)
+		if err != nil {
+			log.Printf("Error rolling up %s candles: %v", interval, err)
+			return err
+		}
+		log.Printf("Rolled up %d %s candles", written, interval)
+	}
+
+	return nil
+}
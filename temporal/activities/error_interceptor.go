@@ -0,0 +1,64 @@
+package temporal_activities
+
+import (
+	"context"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrorReportingInterceptor reports the errors of every failed activity attempt and workflow a worker runs, by
+// activity and workflow type
+type ErrorReportingInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	reporter *services.ErrorReporter
+}
+
+// NewErrorReportingInterceptor creates a worker interceptor reporting to reporter
+func NewErrorReportingInterceptor(reporter *services.ErrorReporter) *ErrorReportingInterceptor {
+	return &ErrorReportingInterceptor{reporter: reporter}
+}
+
+// InterceptActivity reports the errors of an activity
+func (i *ErrorReportingInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	return &activityErrorReporter{ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next}, reporter: i.reporter}
+}
+
+// InterceptWorkflow reports the error a workflow fails with
+func (i *ErrorReportingInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	return &workflowErrorReporter{WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next}, reporter: i.reporter}
+}
+
+// activityErrorReporter reports the error of an activity attempt
+type activityErrorReporter struct {
+	interceptor.ActivityInboundInterceptorBase
+	reporter *services.ErrorReporter
+}
+
+// ExecuteActivity runs the activity, reporting its error
+func (a *activityErrorReporter) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	result, err := a.Next.ExecuteActivity(ctx, in)
+	if err != nil {
+		a.reporter.Report(types.ErrorOriginActivity, activity.GetInfo(ctx).ActivityType.Name, err)
+	}
+	return result, err
+}
+
+// workflowErrorReporter reports the error a workflow fails with
+type workflowErrorReporter struct {
+	interceptor.WorkflowInboundInterceptorBase
+	reporter *services.ErrorReporter
+}
+
+// ExecuteWorkflow runs the workflow, reporting its error. A failure seen again while replaying history was
+// reported when it happened.
+func (w *workflowErrorReporter) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	result, err := w.Next.ExecuteWorkflow(ctx, in)
+	if err != nil && !workflow.IsReplaying(ctx) {
+		w.reporter.Report(types.ErrorOriginWorkflow, workflow.GetInfo(ctx).WorkflowType.Name, err)
+	}
+	return result, err
+}
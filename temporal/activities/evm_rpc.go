@@ -0,0 +1,349 @@
+package temporal_activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/temporal"
+)
+
+// EVMRPCClient calls EVM chains over JSON-RPC, trying each endpoint of a chain in turn
+type EVMRPCClient struct {
+	httpClient *http.Client
+	rpcURLs    map[int64][]string
+	mu         sync.RWMutex
+}
+
+// NewEVMRPCClient creates a JSON-RPC client for the given endpoints of each chain
+func NewEVMRPCClient(httpClient *http.Client, rpcURLs map[int64][]string) *EVMRPCClient {
+	return &EVMRPCClient{
+		httpClient: httpClient,
+		rpcURLs:    rpcURLs,
+	}
+}
+
+// SetRPCURLs replaces the endpoints of every chain; calls already running finish on the old ones
+func (c *EVMRPCClient) SetRPCURLs(rpcURLs map[int64][]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rpcURLs = rpcURLs
+}
+
+// Call runs eth_call with hex calldata at the latest block; from may be empty
+func (c *EVMRPCClient) Call(ctx context.Context, chainID int64, from, to, data string) ([]byte, error) {
+	call := map[string]string{"to": to, "data": data}
+	if from != "" {
+		call["from"] = from
+	}
+	return c.request(ctx, chainID, "eth_call", []interface{}{call, "latest"})
+}
+
+// CallWithStorage runs eth_call with contract storage slots replaced through a state override set
+func (c *EVMRPCClient) CallWithStorage(ctx context.Context, chainID int64, from, to, data string, storage services.StorageOverrides) ([]byte, error) {
+	call := map[string]string{"to": to, "data": data}
+	if from != "" {
+		call["from"] = from
+	}
+	if len(storage) == 0 {
+		return c.request(ctx, chainID, "eth_call", []interface{}{call, "latest"})
+	}
+
+	overrides := make(map[string]interface{}, len(storage))
+	for contract, slots := range storage {
+		overrides[contract] = map[string]interface{}{"stateDiff": slots}
+	}
+	return c.request(ctx, chainID, "eth_call", []interface{}{call, "latest", overrides})
+}
+
+// GetCode returns the code deployed at address, which is empty for accounts without a contract
+func (c *EVMRPCClient) GetCode(ctx context.Context, chainID int64, address string) ([]byte, error) {
+	return c.request(ctx, chainID, "eth_getCode", []interface{}{address, "latest"})
+}
+
+// GasPrice returns the chain's legacy gas price in wei
+func (c *EVMRPCClient) GasPrice(ctx context.Context, chainID int64) (*big.Int, error) {
+	var result string
+	if err := c.call(ctx, chainID, "eth_gasPrice", []interface{}{}, &result); err != nil {
+		return nil, err
+	}
+	return parseQuantity(result)
+}
+
+// FeeHistory returns the base fee of the next block and the median priority fee over the last blocks, in wei
+func (c *EVMRPCClient) FeeHistory(ctx context.Context, chainID int64, blocks int) (baseFee, priorityFee *big.Int, err error) {
+	var result struct {
+		BaseFeePerGas []string   `json:"baseFeePerGas"`
+		Reward        [][]string `json:"reward"`
+	}
+	if err := c.call(ctx, chainID, "eth_feeHistory", []interface{}{fmt.Sprintf("0x%x", blocks), "latest", []int{50}}, &result); err != nil {
+		return nil, nil, err
+	}
+	if len(result.BaseFeePerGas) == 0 {
+		return nil, nil, errors.New("fee history has no base fees")
+	}
+
+	// The last base fee is the one the next block will charge
+	if baseFee, err = parseQuantity(result.BaseFeePerGas[len(result.BaseFeePerGas)-1]); err != nil {
+		return nil, nil, err
+	}
+	var rewards []*big.Int
+	for _, reward := range result.Reward {
+		if len(reward) == 0 {
+			continue
+		}
+		value, err := parseQuantity(reward[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		rewards = append(rewards, value)
+	}
+	priorityFee = new(big.Int)
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+		priorityFee = rewards[len(rewards)/2]
+	}
+	return baseFee, priorityFee, nil
+}
+
+// BlockTime returns the average time between the chain's last blocks
+func (c *EVMRPCClient) BlockTime(ctx context.Context, chainID int64, blocks int) (time.Duration, error) {
+	var latest struct {
+		Number    string `json:"number"`
+		Timestamp string `json:"timestamp"`
+	}
+	if err := c.call(ctx, chainID, "eth_getBlockByNumber", []interface{}{"latest", false}, &latest); err != nil {
+		return 0, err
+	}
+	number, err := parseQuantity(latest.Number)
+	if err != nil {
+		return 0, err
+	}
+	if number.Int64() < int64(blocks) {
+		blocks = int(number.Int64())
+	}
+	if blocks <= 0 {
+		return 0, errors.New("not enough blocks to measure block time")
+	}
+
+	var earlier struct {
+		Timestamp string `json:"timestamp"`
+	}
+	earlierNumber := fmt.Sprintf("0x%x", number.Int64()-int64(blocks))
+	if err := c.call(ctx, chainID, "eth_getBlockByNumber", []interface{}{earlierNumber, false}, &earlier); err != nil {
+		return 0, err
+	}
+	latestTime, err := parseQuantity(latest.Timestamp)
+	if err != nil {
+		return 0, err
+	}
+	earlierTime, err := parseQuantity(earlier.Timestamp)
+	if err != nil {
+		return 0, err
+	}
+	elapsed := time.Duration(latestTime.Int64()-earlierTime.Int64()) * time.Second
+	return elapsed / time.Duration(blocks), nil
+}
+
+// EstimateGas returns the gas a call would use; from may be empty
+func (c *EVMRPCClient) EstimateGas(ctx context.Context, chainID int64, from, to string, data []byte) (*big.Int, error) {
+	call := map[string]string{"to": to}
+	if from != "" {
+		call["from"] = from
+	}
+	if len(data) > 0 {
+		call["data"] = "0x" + hex.EncodeToString(data)
+	}
+	var result string
+	if err := c.call(ctx, chainID, "eth_estimateGas", []interface{}{call}, &result); err != nil {
+		return nil, err
+	}
+	return parseQuantity(result)
+}
+
+// transferTopic is the topic of ERC-20 Transfer(address,address,uint256) events
+const transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// BlockNumber returns the chain's latest block
+func (c *EVMRPCClient) BlockNumber(ctx context.Context, chainID int64) (uint64, error) {
+	var result string
+	if err := c.call(ctx, chainID, "eth_blockNumber", []interface{}{}, &result); err != nil {
+		return 0, err
+	}
+	number, err := parseQuantity(result)
+	if err != nil {
+		return 0, err
+	}
+	return number.Uint64(), nil
+}
+
+// TransferLogs returns the ERC-20 transfers of a token contract to an address within a block range, inclusive
+func (c *EVMRPCClient) TransferLogs(ctx context.Context, chainID int64, token, to string, fromBlock, toBlock uint64) ([]types.TokenTransfer, error) {
+	recipient, err := hex.DecodeString(strings.TrimPrefix(to, "0x"))
+	if err != nil || len(recipient) != 20 {
+		return nil, fmt.Errorf("invalid address %q", to)
+	}
+	filter := map[string]interface{}{
+		"address":   token,
+		"fromBlock": fmt.Sprintf("0x%x", fromBlock),
+		"toBlock":   fmt.Sprintf("0x%x", toBlock),
+		"topics":    []interface{}{transferTopic, nil, "0x" + strings.Repeat("00", 12) + hex.EncodeToString(recipient)},
+	}
+	var logs []struct {
+		Address         string   `json:"address"`
+		Topics          []string `json:"topics"`
+		Data            string   `json:"data"`
+		BlockNumber     string   `json:"blockNumber"`
+		TransactionHash string   `json:"transactionHash"`
+		LogIndex        string   `json:"logIndex"`
+		Removed         bool     `json:"removed"`
+	}
+	if err := c.call(ctx, chainID, "eth_getLogs", []interface{}{filter}, &logs); err != nil {
+		return nil, err
+	}
+
+	transfers := make([]types.TokenTransfer, 0, len(logs))
+	for _, entry := range logs {
+		if entry.Removed || len(entry.Topics) != 3 {
+			continue
+		}
+		amount, err := parseQuantity(entry.Data)
+		if err != nil {
+			return nil, err
+		}
+		block, err := parseQuantity(entry.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		index, err := parseQuantity(entry.LogIndex)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, types.TokenTransfer{
+			Token:       entry.Address,
+			TxHash:      entry.TransactionHash,
+			LogIndex:    index.Uint64(),
+			BlockNumber: block.Uint64(),
+			From:        topicAddress(entry.Topics[1]),
+			To:          topicAddress(entry.Topics[2]),
+			Amount:      amount,
+		})
+	}
+	return transfers, nil
+}
+
+// topicAddress returns the address in the last 20 bytes of an indexed event topic
+func topicAddress(topic string) string {
+	topic = strings.TrimPrefix(topic, "0x")
+	if len(topic) < 40 {
+		return "0x" + topic
+	}
+	return "0x" + topic[len(topic)-40:]
+}
+
+// Invoke sends a JSON-RPC request to each endpoint of the chain until one answers, decoding its result into out
+func (c *EVMRPCClient) Invoke(ctx context.Context, chainID int64, method string, params []interface{}, out interface{}) error {
+	return c.call(ctx, chainID, method, params, out)
+}
+
+// parseQuantity parses a JSON-RPC hex quantity such as 0x1a
+func parseQuantity(value string) (*big.Int, error) {
+	quantity, ok := new(big.Int).SetString(strings.TrimPrefix(value, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid RPC quantity %q", value)
+	}
+	return quantity, nil
+}
+
+// request sends a JSON-RPC request returning hex data to each endpoint of the chain until one answers
+func (c *EVMRPCClient) request(ctx context.Context, chainID int64, method string, params []interface{}) ([]byte, error) {
+	var result string
+	if err := c.call(ctx, chainID, method, params, &result); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(result, "0x"))
+}
+
+// call sends a JSON-RPC request to each endpoint of the chain until one answers, decoding its result into out
+func (c *EVMRPCClient) call(ctx context.Context, chainID int64, method string, params []interface{}, out interface{}) error {
+	c.mu.RLock()
+	urls := c.rpcURLs[types.CanonicalChainID(chainID)]
+	c.mu.RUnlock()
+	if len(urls) == 0 {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("No RPC endpoint configured for chain %d", chainID),
+			"MISSING_RPC",
+			errors.New("missing RPC endpoint"))
+	}
+
+	var lastErr error
+	for _, url := range urls {
+		err := c.send(ctx, os.ExpandEnv(url), method, params, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// send sends a single JSON-RPC request and decodes its result into out
+func (c *EVMRPCClient) send(ctx context.Context, url, method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("RPC request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("RPC returned status %d", resp.StatusCode)
+	}
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("invalid RPC response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("RPC error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return fmt.Errorf("invalid RPC result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeGasRPC serves the JSON-RPC methods the chain service polls for gas prices and block times,
+// and the ones the deposit watcher reads transfers with
+func fakeGasRPC(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Invalid RPC request: %v", err)
+			return
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "eth_gasPrice":
+			result = "0x6fc23ac00" // 30 gwei
+		case "eth_feeHistory":
+			result = map[string]interface{}{
+				"baseFeePerGas": []string{"0x3b9aca00", "0x4a817c800"}, // 1 gwei, then 20 gwei
+				"reward":        [][]string{{"0x3"}, {"0x1"}, {"0x2"}},
+			}
+		case "eth_getBlockByNumber":
+			var number string
+			json.Unmarshal(req.Params[0], &number)
+			if number == "latest" {
+				result = map[string]string{"number": "0x64", "timestamp": "0x4b0"} // block 100 at 1200s
+			} else {
+				result = map[string]string{"number": number, "timestamp": "0x0"}
+			}
+		case "eth_estimateGas":
+			result = "0x5208"
+		case "eth_blockNumber":
+			result = "0x64"
+		case "eth_getLogs":
+			result = []map[string]interface{}{
+				{
+					"address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+					"topics": []string{
+						"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+						"0x0000000000000000000000001234567890abcdef1234567890abcdef12345678",
+						"0x000000000000000000000000deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+					},
+					"data":            "0x00000000000000000000000000000000000000000000000000000000000f4240",
+					"blockNumber":     "0x63",
+					"transactionHash": "0xfeed",
+					"logIndex":        "0x2",
+				},
+				{"removed": true, "topics": []string{}},
+			}
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": -32601, "message": "method not found"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestEVMRPCClientGas(t *testing.T) {
+	server := fakeGasRPC(t)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewEVMRPCClient(server.Client(), map[int64][]string{1: {server.URL}})
+
+	t.Run("GasPrice", func(t *testing.T) {
+		gasPrice, err := client.GasPrice(ctx, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if gasPrice.Int64() != 30_000_000_000 {
+			t.Errorf("Expected 30 gwei, got %s", gasPrice)
+		}
+	})
+
+	t.Run("FeeHistory", func(t *testing.T) {
+		baseFee, priorityFee, err := client.FeeHistory(ctx, 1, 3)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if baseFee.Int64() != 20_000_000_000 {
+			t.Errorf("Expected the next block's base fee of 20 gwei, got %s", baseFee)
+		}
+		if priorityFee.Int64() != 2 {
+			t.Errorf("Expected the median priority fee of 2, got %s", priorityFee)
+		}
+	})
+
+	t.Run("BlockTime", func(t *testing.T) {
+		blockTime, err := client.BlockTime(ctx, 1, 100)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if blockTime != 12*time.Second {
+			t.Errorf("Expected 12s blocks, got %s", blockTime)
+		}
+	})
+
+	t.Run("EstimateGas", func(t *testing.T) {
+		gas, err := client.EstimateGas(ctx, 1, "", "0xto", []byte{0x01})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if gas.Int64() != 21000 {
+			t.Errorf("Expected 21000 gas, got %s", gas)
+		}
+	})
+
+	t.Run("MissingEndpoint", func(t *testing.T) {
+		if _, err := client.GasPrice(ctx, 137); err == nil {
+			t.Error("Expected an error for a chain without RPC endpoints")
+		}
+	})
+
+	t.Run("SetRPCURLs", func(t *testing.T) {
+		client := NewEVMRPCClient(server.Client(), map[int64][]string{1: {server.URL}})
+		client.SetRPCURLs(map[int64][]string{137: {server.URL}})
+		if _, err := client.GasPrice(ctx, 137); err != nil {
+			t.Errorf("Unexpected error on a chain added by SetRPCURLs: %v", err)
+		}
+		if _, err := client.GasPrice(ctx, 1); err == nil {
+			t.Error("Expected an error for a chain SetRPCURLs removed")
+		}
+	})
+}
+
+func TestEVMRPCClientTransfers(t *testing.T) {
+	server := fakeGasRPC(t)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewEVMRPCClient(server.Client(), map[int64][]string{1: {server.URL}})
+
+	block, err := client.BlockNumber(ctx, 1)
+	if err != nil || block != 100 {
+		t.Fatalf("Expected block 100, got %d, %v", block, err)
+	}
+
+	transfers, err := client.TransferLogs(ctx, 1, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef", 90, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(transfers) != 1 {
+		t.Fatalf("Expected removed logs to be skipped, got %d transfers", len(transfers))
+	}
+	transfer := transfers[0]
+	if transfer.From != "0x1234567890abcdef1234567890abcdef12345678" || transfer.To != "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef" {
+		t.Errorf("Unexpected addresses %s -> %s", transfer.From, transfer.To)
+	}
+	if transfer.Amount.Int64() != 1_000_000 || transfer.BlockNumber != 99 || transfer.LogIndex != 2 || transfer.TxHash != "0xfeed" {
+		t.Errorf("Unexpected transfer %+v", transfer)
+	}
+
+	if _, err := client.TransferLogs(ctx, 1, "0xa0b8", "0x12", 90, 100); err == nil {
+		t.Error("Expected an error for an invalid recipient")
+	}
+}
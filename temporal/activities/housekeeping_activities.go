@@ -0,0 +1,280 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	enumspb "go.temporal.io/api/enums/v1"
+	failurepb "go.temporal.io/api/failure/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// SwapWorkflowClient defines the Temporal operations the housekeeping activities inspect and stop swap workflows
+// with; client.Client satisfies it
+type SwapWorkflowClient interface {
+	ListWorkflow(ctx context.Context, request *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error)
+	DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error)
+	GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType enumspb.HistoryEventFilterType) client.HistoryEventIterator
+	TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error
+}
+
+// DeadLetterScan is what a dead-letter scan looks for
+type DeadLetterScan struct {
+	TaskQueue   string        // The region's swap task queue
+	StuckAfter  time.Duration // How long a swap may run before an activity retrying makes it stuck
+	FailedSince time.Time     // Swaps that failed earlier were covered by an earlier scan
+}
+
+// deadLetterPageSize is how many swap workflows a dead-letter scan lists at a time
+const deadLetterPageSize = 100
+
+// HousekeepingActivities holds implementation of the activities that find, record and resolve dead-lettered swaps
+type HousekeepingActivities struct {
+	workflows SwapWorkflowClient
+	namespace string
+	store     services.DeadLetterStore
+	reporter  *services.ErrorReporter // nil when dead letters aren't reported
+	bus       *events.Bus             // nil when dead letters aren't announced
+}
+
+// NewHousekeepingActivities creates a new instance of housekeeping activities
+func NewHousekeepingActivities(workflows SwapWorkflowClient, namespace string, store services.DeadLetterStore) *HousekeepingActivities {
+	return &HousekeepingActivities{
+		workflows: workflows,
+		namespace: namespace,
+		store:     store,
+	}
+}
+
+// SetErrorReporter reports each new dead letter, alerting operators through the error sink
+func (a *HousekeepingActivities) SetErrorReporter(reporter *services.ErrorReporter) {
+	a.reporter = reporter
+}
+
+// SetEventBus publishes each new dead letter to bus
+func (a *HousekeepingActivities) SetEventBus(bus *events.Bus) {
+	a.bus = bus
+}
+
+// FindDeadLettersActivity finds the swap workflows on the scan's task queue that are stuck, running past StuckAfter
+// with an activity retrying, or that failed since FailedSince because an activity ran out of retries
+func (a *HousekeepingActivities) FindDeadLettersActivity(ctx context.Context, scan DeadLetterScan) ([]types.DeadLetter, error) {
+	now := time.Now().UTC()
+	letters := []types.DeadLetter{}
+
+	running := fmt.Sprintf("WorkflowType = 'SwapWorkflow' AND ExecutionStatus = 'Running' AND TaskQueue = '%s' AND StartTime < '%s'",
+		scan.TaskQueue, now.Add(-scan.StuckAfter).Format(time.RFC3339))
+	err := a.listSwaps(ctx, running, func(execution *workflowpb.WorkflowExecutionInfo) error {
+		detail, err := a.stuckActivity(ctx, execution)
+		if err != nil || detail == "" {
+			return err
+		}
+		letters = append(letters, newDeadLetter(execution, types.DeadLetterStuck, detail, now))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	failed := fmt.Sprintf("WorkflowType = 'SwapWorkflow' AND ExecutionStatus = 'Failed' AND TaskQueue = '%s' AND CloseTime >= '%s'",
+		scan.TaskQueue, scan.FailedSince.UTC().Format(time.RFC3339))
+	err = a.listSwaps(ctx, failed, func(execution *workflowpb.WorkflowExecutionInfo) error {
+		detail, err := a.exhaustedRetries(ctx, execution)
+		if err != nil || detail == "" {
+			return err
+		}
+		letters = append(letters, newDeadLetter(execution, types.DeadLetterFailed, detail, now))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	activity.GetLogger(ctx).Info("Scanned for dead-lettered swaps", "taskQueue", scan.TaskQueue, "found", len(letters))
+	return letters, nil
+}
+
+// listSwaps calls visit with each swap workflow matching the visibility query
+func (a *HousekeepingActivities) listSwaps(ctx context.Context, query string, visit func(*workflowpb.WorkflowExecutionInfo) error) error {
+	var pageToken []byte
+	for {
+		resp, err := a.workflows.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     a.namespace,
+			PageSize:      deadLetterPageSize,
+			NextPageToken: pageToken,
+			Query:         query,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list swaps: %w", err)
+		}
+		for _, execution := range resp.GetExecutions() {
+			if err := visit(execution); err != nil {
+				return err
+			}
+		}
+		activity.RecordHeartbeat(ctx)
+
+		pageToken = resp.GetNextPageToken()
+		if len(pageToken) == 0 {
+			return nil
+		}
+	}
+}
+
+// stuckActivity describes the activity a running swap is retrying, or returns "" when none is
+func (a *HousekeepingActivities) stuckActivity(ctx context.Context, execution *workflowpb.WorkflowExecutionInfo) (string, error) {
+	desc, err := a.workflows.DescribeWorkflowExecution(ctx, execution.GetExecution().GetWorkflowId(), execution.GetExecution().GetRunId())
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to describe swap %s: %w", execution.GetExecution().GetWorkflowId(), err)
+	}
+
+	for _, pending := range desc.GetPendingActivities() {
+		if pending.GetAttempt() > 1 {
+			return fmt.Sprintf("%s on attempt %d: %s",
+				pending.GetActivityType().GetName(), pending.GetAttempt(), pending.GetLastFailure().GetMessage()), nil
+		}
+	}
+	return "", nil
+}
+
+// exhaustedRetries describes the activity failure that failed a swap after running out of retries, or returns ""
+// when the swap failed for another reason, such as a non-retryable error
+func (a *HousekeepingActivities) exhaustedRetries(ctx context.Context, execution *workflowpb.WorkflowExecutionInfo) (string, error) {
+	iter := a.workflows.GetWorkflowHistory(ctx, execution.GetExecution().GetWorkflowId(), execution.GetExecution().GetRunId(),
+		false, enumspb.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT)
+	var failure *failurepb.Failure
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return "", fmt.Errorf("failed to read history of swap %s: %w", execution.GetExecution().GetWorkflowId(), err)
+		}
+		if attrs := event.GetWorkflowExecutionFailedEventAttributes(); attrs != nil {
+			failure = attrs.GetFailure()
+		}
+	}
+
+	for f := failure; f != nil; f = f.GetCause() {
+		info := f.GetActivityFailureInfo()
+		if info == nil {
+			continue
+		}
+		switch info.GetRetryState() {
+		case enumspb.RETRY_STATE_MAXIMUM_ATTEMPTS_REACHED, enumspb.RETRY_STATE_TIMEOUT:
+			return fmt.Sprintf("%s ran out of retries: %s", info.GetActivityType().GetName(), rootFailureMessage(f)), nil
+		}
+		return "", nil
+	}
+	return "", nil
+}
+
+// rootFailureMessage returns the message of the innermost cause of a failure
+func rootFailureMessage(f *failurepb.Failure) string {
+	for f.GetCause() != nil {
+		f = f.GetCause()
+	}
+	return f.GetMessage()
+}
+
+// newDeadLetter creates an open dead letter for a swap workflow
+func newDeadLetter(execution *workflowpb.WorkflowExecutionInfo, reason types.DeadLetterReason, detail string, now time.Time) types.DeadLetter {
+	return types.DeadLetter{
+		RequestID:  execution.GetExecution().GetWorkflowId(),
+		RunID:      execution.GetExecution().GetRunId(),
+		Reason:     reason,
+		Detail:     detail,
+		StartedAt:  execution.GetStartTime().AsTime(),
+		DetectedAt: now,
+		Status:     types.DeadLetterOpen,
+	}
+}
+
+// RecordDeadLettersActivity records dead letters and alerts operators to each that is new, returning those. A
+// workflow run already recorded by an earlier scan or attempt is neither recorded nor alerted again.
+func (a *HousekeepingActivities) RecordDeadLettersActivity(ctx context.Context, letters []types.DeadLetter) ([]types.DeadLetter, error) {
+	recorded := []types.DeadLetter{}
+	for _, letter := range letters {
+		isNew, err := a.store.RecordDeadLetter(ctx, letter)
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record dead letter of swap %s: %w", letter.RequestID, err)
+		}
+		if !isNew {
+			continue
+		}
+		recorded = append(recorded, letter)
+		a.alert(ctx, letter)
+	}
+	return recorded, nil
+}
+
+// alert tells operators a swap was dead-lettered through the log, the error reporter and the event bus
+func (a *HousekeepingActivities) alert(ctx context.Context, letter types.DeadLetter) {
+	activity.GetLogger(ctx).Error("Swap dead-lettered",
+		"requestID", letter.RequestID,
+		"runID", letter.RunID,
+		"reason", letter.Reason,
+		"detail", letter.Detail,
+	)
+	if a.reporter != nil {
+		a.reporter.ReportCode(types.ErrorOriginWorkflow, "SwapWorkflow", "SWAP_DEAD_LETTERED",
+			fmt.Sprintf("swap %s %s: %s", letter.RequestID, letter.Reason, letter.Detail))
+	}
+	if a.bus != nil {
+		if err := events.Publish(ctx, a.bus, events.SwapDeadLetteredTopic, events.SwapDeadLettered{DeadLetter: letter}); err != nil {
+			activity.GetLogger(ctx).Warn("Failed to publish dead letter", "requestID", letter.RequestID, "error", err)
+		}
+	}
+}
+
+// TerminateStuckSwapActivity stops the run of a stuck swap before an operator requeues or refunds it, so its
+// retrying activity can't also complete the swap. A run that has already closed is left alone.
+func (a *HousekeepingActivities) TerminateStuckSwapActivity(ctx context.Context, letter types.DeadLetter, reason string) error {
+	if letter.Reason != types.DeadLetterStuck {
+		return nil
+	}
+	activity.GetLogger(ctx).Info("Terminating stuck swap", "requestID", letter.RequestID, "runID", letter.RunID)
+
+	err := a.workflows.TerminateWorkflow(ctx, letter.RequestID, letter.RunID, reason)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to terminate swap %s: %w", letter.RequestID, err)
+	}
+	return nil
+}
+
+// ResolveDeadLetterActivity marks a swap's open dead letter requeued or refunded by an admin action. A retry after
+// the action already resolved it succeeds.
+func (a *HousekeepingActivities) ResolveDeadLetterActivity(ctx context.Context, requestID string, status types.DeadLetterStatus, operator, actionID string) error {
+	activity.GetLogger(ctx).Info("Resolving dead letter", "requestID", requestID, "status", status, "actionID", actionID)
+
+	err := a.store.ResolveDeadLetter(ctx, requestID, status, operator, actionID, time.Now().UTC())
+	if errors.Is(err, types.ErrDeadLetterNotFound) {
+		if letter, getErr := a.store.GetDeadLetter(ctx, requestID); getErr == nil && letter.ActionID == actionID {
+			return nil
+		}
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Swap %s has no open dead letter", requestID),
+			"DEAD_LETTER_NOT_FOUND",
+			err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve dead letter of swap %s: %w", requestID, err)
+	}
+	return nil
+}
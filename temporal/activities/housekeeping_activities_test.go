@@ -0,0 +1,240 @@
+package temporal_activities
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	failurepb "go.temporal.io/api/failure/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/testsuite"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeSwapWorkflows serves swap workflows from fixed lists, answering running and failed queries separately
+type fakeSwapWorkflows struct {
+	running    []*workflowpb.WorkflowExecutionInfo
+	failed     []*workflowpb.WorkflowExecutionInfo
+	pending    map[string][]*workflowpb.PendingActivityInfo // map[workflowID]activities
+	failures   map[string]*failurepb.Failure                // map[workflowID]failure
+	queries    []string
+	terminated []string
+}
+
+func (f *fakeSwapWorkflows) ListWorkflow(ctx context.Context, request *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	f.queries = append(f.queries, request.Query)
+	if strings.Contains(request.Query, "'Running'") {
+		return &workflowservice.ListWorkflowExecutionsResponse{Executions: f.running}, nil
+	}
+	return &workflowservice.ListWorkflowExecutionsResponse{Executions: f.failed}, nil
+}
+
+func (f *fakeSwapWorkflows) DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	return &workflowservice.DescribeWorkflowExecutionResponse{PendingActivities: f.pending[workflowID]}, nil
+}
+
+func (f *fakeSwapWorkflows) GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType enumspb.HistoryEventFilterType) client.HistoryEventIterator {
+	return &closeEvent{event: &historypb.HistoryEvent{
+		Attributes: &historypb.HistoryEvent_WorkflowExecutionFailedEventAttributes{
+			WorkflowExecutionFailedEventAttributes: &historypb.WorkflowExecutionFailedEventAttributes{Failure: f.failures[workflowID]},
+		},
+	}}
+}
+
+func (f *fakeSwapWorkflows) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error {
+	if workflowID == "swap-closed" {
+		return serviceerror.NewNotFound("workflow execution already completed")
+	}
+	f.terminated = append(f.terminated, workflowID+"/"+runID)
+	return nil
+}
+
+// closeEvent iterates over a history of just its close event
+type closeEvent struct {
+	event *historypb.HistoryEvent
+}
+
+func (c *closeEvent) HasNext() bool {
+	return c.event != nil
+}
+
+func (c *closeEvent) Next() (*historypb.HistoryEvent, error) {
+	event := c.event
+	c.event = nil
+	return event, nil
+}
+
+// activityFailure is how a swap workflow fails when an activity error is returned wrapped
+func activityFailure(activityType string, retryState enumspb.RetryState, message string) *failurepb.Failure {
+	return &failurepb.Failure{
+		Message: "failed to execute swap: activity error",
+		Cause: &failurepb.Failure{
+			Message: "activity error",
+			FailureInfo: &failurepb.Failure_ActivityFailureInfo{ActivityFailureInfo: &failurepb.ActivityFailureInfo{
+				ActivityType: &commonpb.ActivityType{Name: activityType},
+				RetryState:   retryState,
+			}},
+			Cause: &failurepb.Failure{Message: message},
+		},
+	}
+}
+
+func TestFindDeadLettersActivity(t *testing.T) {
+	started := time.Now().Add(-time.Hour)
+	execution := func(id string) *workflowpb.WorkflowExecutionInfo {
+		return &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: id, RunId: id + "-run"},
+			StartTime: timestamppb.New(started),
+		}
+	}
+	workflows := &fakeSwapWorkflows{
+		running: []*workflowpb.WorkflowExecutionInfo{execution("swap-retrying"), execution("swap-waiting")},
+		failed:  []*workflowpb.WorkflowExecutionInfo{execution("swap-exhausted"), execution("swap-denied")},
+		pending: map[string][]*workflowpb.PendingActivityInfo{
+			"swap-retrying": {{
+				ActivityType: &commonpb.ActivityType{Name: "ExecuteSwapActivity"},
+				Attempt:      4,
+				LastFailure:  &failurepb.Failure{Message: "rpc timeout"},
+			}},
+			// A swap waiting on its first attempt, such as a bridge transfer, isn't stuck
+			"swap-waiting": {{ActivityType: &commonpb.ActivityType{Name: "WatchBridgeTransferActivity"}, Attempt: 1}},
+		},
+		failures: map[string]*failurepb.Failure{
+			"swap-exhausted": activityFailure("CheckBalanceActivity", enumspb.RETRY_STATE_MAXIMUM_ATTEMPTS_REACHED, "insufficient balance"),
+			// Non-retryable errors fail swaps on purpose
+			"swap-denied": activityFailure("EvaluateSwapPolicyActivity", enumspb.RETRY_STATE_NON_RETRYABLE_FAILURE, "denied"),
+		},
+	}
+	activities := NewHousekeepingActivities(workflows, "infinity-dex", services.NewInMemoryDeadLetterStore())
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.FindDeadLettersActivity)
+
+	scan := DeadLetterScan{TaskQueue: "swap-queue", StuckAfter: 30 * time.Second, FailedSince: started}
+	value, err := env.ExecuteActivity(activities.FindDeadLettersActivity, scan)
+	if err != nil {
+		t.Fatalf("Failed to scan for dead letters: %v", err)
+	}
+	var letters []types.DeadLetter
+	if err := value.Get(&letters); err != nil {
+		t.Fatalf("Failed to decode dead letters: %v", err)
+	}
+
+	if len(letters) != 2 {
+		t.Fatalf("Expected the retrying and exhausted swaps, got %+v", letters)
+	}
+	stuck, failed := letters[0], letters[1]
+	if stuck.RequestID != "swap-retrying" || stuck.RunID != "swap-retrying-run" || stuck.Reason != types.DeadLetterStuck {
+		t.Errorf("Unexpected stuck dead letter: %+v", stuck)
+	}
+	if stuck.Detail != "ExecuteSwapActivity on attempt 4: rpc timeout" || !stuck.StartedAt.Equal(started) || stuck.Status != types.DeadLetterOpen {
+		t.Errorf("Unexpected stuck dead letter: %+v", stuck)
+	}
+	if failed.RequestID != "swap-exhausted" || failed.Reason != types.DeadLetterFailed || failed.Detail != "CheckBalanceActivity ran out of retries: insufficient balance" {
+		t.Errorf("Unexpected failed dead letter: %+v", failed)
+	}
+
+	for _, query := range workflows.queries {
+		if !strings.Contains(query, "TaskQueue = 'swap-queue'") {
+			t.Errorf("Expected the scan to be limited to the task queue, got %q", query)
+		}
+	}
+}
+
+func TestRecordDeadLettersActivity(t *testing.T) {
+	store := services.NewInMemoryDeadLetterStore()
+	activities := NewHousekeepingActivities(&fakeSwapWorkflows{}, "infinity-dex", store)
+	reporter := services.NewErrorReporter(services.NewInMemoryErrorStore())
+	activities.SetErrorReporter(reporter)
+	bus := events.NewBus()
+	published := make(chan events.SwapDeadLettered, 2)
+	events.Subscribe(bus, events.SwapDeadLetteredTopic, 2, events.Block, func(ctx context.Context, event events.SwapDeadLettered) {
+		published <- event
+	})
+	activities.SetEventBus(bus)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.RecordDeadLettersActivity)
+
+	letter := types.DeadLetter{RequestID: "swap-1", RunID: "run-1", Reason: types.DeadLetterStuck, DetectedAt: time.Now(), Status: types.DeadLetterOpen}
+	for attempt := 0; attempt < 2; attempt++ {
+		if _, err := env.ExecuteActivity(activities.RecordDeadLettersActivity, []types.DeadLetter{letter}); err != nil {
+			t.Fatalf("Failed to record dead letters: %v", err)
+		}
+	}
+
+	// A run found by two scans is alerted once
+	bus.Close()
+	if len(published) != 1 {
+		t.Fatalf("Expected one dead letter published, got %d", len(published))
+	}
+	if event := <-published; event.DeadLetter.RequestID != "swap-1" {
+		t.Errorf("Unexpected published dead letter: %+v", event)
+	}
+	if err := reporter.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush errors: %v", err)
+	}
+	top, err := reporter.TopErrors(context.Background(), time.Now().Add(-time.Hour), 10)
+	if err != nil || len(top) != 1 || top[0].Code != "SWAP_DEAD_LETTERED" || top[0].Count != 1 {
+		t.Errorf("Expected one dead letter reported, got %+v, %v", top, err)
+	}
+	if _, err := store.GetDeadLetter(context.Background(), "swap-1"); err != nil {
+		t.Errorf("Expected the dead letter to be stored: %v", err)
+	}
+}
+
+func TestResolveDeadLetterActivities(t *testing.T) {
+	store := services.NewInMemoryDeadLetterStore()
+	workflows := &fakeSwapWorkflows{}
+	activities := NewHousekeepingActivities(workflows, "infinity-dex", store)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.TerminateStuckSwapActivity)
+	env.RegisterActivity(activities.ResolveDeadLetterActivity)
+
+	for _, letter := range []types.DeadLetter{
+		{RequestID: "swap-stuck", RunID: "run-1", Reason: types.DeadLetterStuck},
+		{RequestID: "swap-closed", RunID: "run-1", Reason: types.DeadLetterStuck},
+		{RequestID: "swap-failed", RunID: "run-1", Reason: types.DeadLetterFailed},
+	} {
+		if _, err := env.ExecuteActivity(activities.TerminateStuckSwapActivity, letter, "requeued"); err != nil {
+			t.Errorf("Failed to terminate %s: %v", letter.RequestID, err)
+		}
+	}
+	if len(workflows.terminated) != 1 || workflows.terminated[0] != "swap-stuck/run-1" {
+		t.Errorf("Expected only the stuck run to be terminated, got %v", workflows.terminated)
+	}
+
+	if _, err := store.RecordDeadLetter(context.Background(), types.DeadLetter{RequestID: "swap-stuck", RunID: "run-1", Status: types.DeadLetterOpen}); err != nil {
+		t.Fatal(err)
+	}
+	// A retried resolution by the same action succeeds
+	for attempt := 0; attempt < 2; attempt++ {
+		if _, err := env.ExecuteActivity(activities.ResolveDeadLetterActivity, "swap-stuck", types.DeadLetterRequeued, "alice", "admin-1"); err != nil {
+			t.Fatalf("Failed to resolve dead letter: %v", err)
+		}
+	}
+	letter, err := store.GetDeadLetter(context.Background(), "swap-stuck")
+	if err != nil || letter.Status != types.DeadLetterRequeued || letter.ResolvedBy != "alice" {
+		t.Errorf("Expected the dead letter to be requeued by alice, got %+v, %v", letter, err)
+	}
+
+	// Another action finds nothing open
+	_, err = env.ExecuteActivity(activities.ResolveDeadLetterActivity, "swap-stuck", types.DeadLetterRefunded, "bob", "admin-2")
+	if err == nil || !strings.Contains(err.Error(), "no open dead letter") {
+		t.Errorf("Expected no open dead letter, got %v", err)
+	}
+}
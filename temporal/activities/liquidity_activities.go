@@ -0,0 +1,366 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// LiquidityActivities holds implementation of liquidity-related activities
+type LiquidityActivities struct {
+	universalSDK       universalsdk.SDK
+	liquidityService   LiquidityServiceInterface
+	transactionService TransactionServiceInterface
+	executor           *ChainExecutor // optional, wraps and unwraps on-chain
+}
+
+// LiquidityServiceInterface defines the interface for liquidity service
+type LiquidityServiceInterface interface {
+	GetPool(ctx context.Context, poolID string) (*services.LiquidityPool, error)
+	AddLiquidityOnce(ctx context.Context, operationID string, poolID string, userAddress string, token string, amount *big.Int) (*services.LiquidityPosition, error)
+	RemoveLiquidityOnce(ctx context.Context, operationID string, poolID string, userAddress string, token string, amount *big.Int) error
+	PlanPoolMigration(ctx context.Context, sourcePoolID, targetPoolID string) (*types.PoolMigrationPlan, error)
+	DrainPoolOnce(ctx context.Context, operationID string, plan types.PoolMigrationPlan) error
+	CreditPoolOnce(ctx context.Context, operationID string, poolID string, plan types.PoolMigrationPlan) error
+}
+
+// TransactionServiceInterface defines the interface for transaction service
+type TransactionServiceInterface interface {
+	CreateTransaction(ctx context.Context, tx types.Transaction) (string, error)
+	GetTransaction(ctx context.Context, txID string) (*types.Transaction, error)
+}
+
+// NewLiquidityActivities creates a new instance of liquidity activities
+func NewLiquidityActivities(sdk universalsdk.SDK, liquidityService LiquidityServiceInterface, transactionService TransactionServiceInterface) *LiquidityActivities {
+	return &LiquidityActivities{
+		universalSDK:       sdk,
+		liquidityService:   liquidityService,
+		transactionService: transactionService,
+	}
+}
+
+// SetChainExecutor wraps and unwraps tokens with transactions on chains whose Universal contract is configured,
+// instead of through the SDK
+func (a *LiquidityActivities) SetChainExecutor(executor *ChainExecutor) {
+	a.executor = executor
+}
+
+// WrapLiquidityTokenActivity wraps the deposited token into its Universal version
+func (a *LiquidityActivities) WrapLiquidityTokenActivity(ctx context.Context, request types.LiquidityRequest) (*universalsdk.WrapResult, error) {
+	activity.GetLogger(ctx).Info("Wrapping liquidity token",
+		"token", request.Token.Symbol,
+		"amount", request.Amount.String(),
+		"requestID", request.RequestID,
+	)
+
+	if err := validateLiquidityRequest(request); err != nil {
+		return nil, err
+	}
+
+	// Already wrapped tokens are deposited as-is
+	if request.Token.IsWrapped {
+		return &universalsdk.WrapResult{
+			WrappedToken: request.Token,
+			Amount:       request.Amount,
+			Status:       "skipped",
+		}, nil
+	}
+
+	if a.executor != nil && a.executor.CanWrap(request.Token.ChainID) {
+		receipt, err := a.executor.Wrap(ctx, request.RequestID, request.Token, request.Amount, request.UserAddress)
+		if err != nil {
+			return nil, err
+		}
+		return &universalsdk.WrapResult{
+			TransactionID:   receipt.TxHash,
+			WrappedToken:    wrappedTokenOf(request.Token),
+			Amount:          request.Amount,
+			Fee:             onChainFee(receipt),
+			Status:          "completed",
+			TransactionHash: receipt.TxHash,
+		}, nil
+	}
+
+	result, err := a.universalSDK.WrapToken(ctx, universalsdk.WrapRequest{
+		Token:         request.Token,
+		Amount:        request.Amount,
+		SourceAddress: request.UserAddress,
+		TargetAddress: request.UserAddress,
+	})
+	if err != nil {
+		return nil, sdkError(err, "Failed to wrap token", "WRAP_FAILED")
+	}
+
+	activity.GetLogger(ctx).Info("Liquidity token wrapped",
+		"wrappedToken", result.WrappedToken.Symbol,
+		"amount", result.Amount.String(),
+		"txHash", result.TransactionHash,
+	)
+	return result, nil
+}
+
+// DepositLiquidityActivity records the deposit of wrapped tokens into the pool
+func (a *LiquidityActivities) DepositLiquidityActivity(ctx context.Context, request types.LiquidityRequest) (*types.Transaction, error) {
+	activity.GetLogger(ctx).Info("Depositing liquidity", "poolID", request.PoolID, "requestID", request.RequestID)
+
+	return a.recordLiquidityTransaction(ctx, request, "add_liquidity")
+}
+
+// MintLPPositionActivity mints or tops up the user's LP position, once per request
+func (a *LiquidityActivities) MintLPPositionActivity(ctx context.Context, request types.LiquidityRequest) (*types.LiquidityPosition, error) {
+	activity.GetLogger(ctx).Info("Minting LP position",
+		"poolID", request.PoolID,
+		"user", request.UserAddress,
+		"amount", request.Amount.String(),
+	)
+
+	position, err := a.liquidityService.AddLiquidityOnce(ctx, "mint:"+request.RequestID, request.PoolID, request.UserAddress, request.Token.Symbol, request.Amount)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Failed to mint LP position: %v", err),
+			"MINT_FAILED",
+			err)
+	}
+
+	result := types.LiquidityPosition(*position)
+	return &result, nil
+}
+
+// BurnLPPositionActivity burns LP tokens from the user's position, once per request
+func (a *LiquidityActivities) BurnLPPositionActivity(ctx context.Context, request types.LiquidityRequest) error {
+	activity.GetLogger(ctx).Info("Burning LP position",
+		"poolID", request.PoolID,
+		"user", request.UserAddress,
+		"amount", request.Amount.String(),
+	)
+
+	if err := validateLiquidityRequest(request); err != nil {
+		return err
+	}
+
+	if err := a.liquidityService.RemoveLiquidityOnce(ctx, "burn:"+request.RequestID, request.PoolID, request.UserAddress, request.Token.Symbol, request.Amount); err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Failed to burn LP position: %v", err),
+			"BURN_FAILED",
+			err)
+	}
+
+	return nil
+}
+
+// RestoreLPPositionActivity gives back the LP tokens a failed removal burned, once per request
+func (a *LiquidityActivities) RestoreLPPositionActivity(ctx context.Context, request types.LiquidityRequest) error {
+	activity.GetLogger(ctx).Info("Restoring LP position",
+		"poolID", request.PoolID,
+		"user", request.UserAddress,
+		"amount", request.Amount.String(),
+	)
+
+	if _, err := a.liquidityService.AddLiquidityOnce(ctx, "restore:"+request.RequestID, request.PoolID, request.UserAddress, request.Token.Symbol, request.Amount); err != nil {
+		return temporal.NewApplicationError(
+			fmt.Sprintf("Failed to restore LP position: %v", err),
+			"RESTORE_FAILED")
+	}
+
+	return nil
+}
+
+// WithdrawLiquidityActivity records the withdrawal of wrapped tokens from the pool
+func (a *LiquidityActivities) WithdrawLiquidityActivity(ctx context.Context, request types.LiquidityRequest) (*types.Transaction, error) {
+	activity.GetLogger(ctx).Info("Withdrawing liquidity", "poolID", request.PoolID, "requestID", request.RequestID)
+
+	return a.recordLiquidityTransaction(ctx, request, "remove_liquidity")
+}
+
+// UnwrapLiquidityTokenActivity unwraps withdrawn tokens back to the native token
+func (a *LiquidityActivities) UnwrapLiquidityTokenActivity(ctx context.Context, request types.LiquidityRequest) (*universalsdk.UnwrapResult, error) {
+	activity.GetLogger(ctx).Info("Unwrapping liquidity token",
+		"token", request.Token.Symbol,
+		"amount", request.Amount.String(),
+		"requestID", request.RequestID,
+	)
+
+	// Users withdrawing into a wrapped token keep it as-is
+	if request.Token.IsWrapped {
+		return &universalsdk.UnwrapResult{
+			NativeToken: request.Token,
+			Amount:      request.Amount,
+			Status:      "skipped",
+		}, nil
+	}
+
+	if a.executor != nil && a.executor.CanWrap(request.Token.ChainID) {
+		receipt, err := a.executor.Unwrap(ctx, request.RequestID, request.Token, request.Amount, request.UserAddress)
+		if err != nil {
+			return nil, err
+		}
+		return &universalsdk.UnwrapResult{
+			TransactionID:   receipt.TxHash,
+			NativeToken:     request.Token,
+			Amount:          request.Amount,
+			Fee:             onChainFee(receipt),
+			Status:          "completed",
+			TransactionHash: receipt.TxHash,
+		}, nil
+	}
+
+	wrappedToken := wrappedTokenOf(request.Token)
+	result, err := a.universalSDK.UnwrapToken(ctx, universalsdk.UnwrapRequest{
+		WrappedToken:       wrappedToken,
+		DestinationToken:   request.Token,
+		Amount:             request.Amount,
+		DestinationAddress: request.UserAddress,
+	})
+	if err != nil {
+		return nil, sdkError(err, "Failed to unwrap token", "UNWRAP_FAILED")
+	}
+
+	return result, nil
+}
+
+// PlanPoolMigrationActivity plans moving every position of the source pool into the target pool
+func (a *LiquidityActivities) PlanPoolMigrationActivity(ctx context.Context, sourcePoolID, targetPoolID string) (*types.PoolMigrationPlan, error) {
+	activity.GetLogger(ctx).Info("Planning pool migration", "sourcePoolID", sourcePoolID, "targetPoolID", targetPoolID)
+
+	plan, err := a.liquidityService.PlanPoolMigration(ctx, sourcePoolID, targetPoolID)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Failed to plan pool migration: %v", err),
+			"MIGRATION_NOT_POSSIBLE",
+			err)
+	}
+	return plan, nil
+}
+
+// DrainMigratedPoolActivity removes a migration's positions and reserves from its source pool, once per migration
+func (a *LiquidityActivities) DrainMigratedPoolActivity(ctx context.Context, migrationID string, plan types.PoolMigrationPlan) error {
+	activity.GetLogger(ctx).Info("Draining migrated pool",
+		"migrationID", migrationID,
+		"poolID", plan.SourcePoolID,
+		"positions", len(plan.Positions),
+	)
+
+	if err := a.liquidityService.DrainPoolOnce(ctx, "migrate-out:"+migrationID, plan); err != nil {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Failed to drain pool %s: %v", plan.SourcePoolID, err),
+			"DRAIN_FAILED",
+			err)
+	}
+	return nil
+}
+
+// CreditMigrationTargetActivity adds a migration's positions and reserves to its target pool, once per migration
+func (a *LiquidityActivities) CreditMigrationTargetActivity(ctx context.Context, migrationID string, plan types.PoolMigrationPlan) error {
+	activity.GetLogger(ctx).Info("Crediting migration target",
+		"migrationID", migrationID,
+		"poolID", plan.TargetPoolID,
+		"liquidity", plan.Liquidity.String(),
+	)
+
+	if err := a.liquidityService.CreditPoolOnce(ctx, "migrate-in:"+migrationID, plan.TargetPoolID, plan); err != nil {
+		return temporal.NewApplicationError(
+			fmt.Sprintf("Failed to credit pool %s: %v", plan.TargetPoolID, err),
+			"CREDIT_FAILED")
+	}
+	return nil
+}
+
+// RestoreMigratedPoolActivity gives a failed migration's positions and reserves back to its source pool, once per
+// migration
+func (a *LiquidityActivities) RestoreMigratedPoolActivity(ctx context.Context, migrationID string, plan types.PoolMigrationPlan) error {
+	activity.GetLogger(ctx).Info("Restoring migrated pool", "migrationID", migrationID, "poolID", plan.SourcePoolID)
+
+	if err := a.liquidityService.CreditPoolOnce(ctx, "migrate-restore:"+migrationID, plan.SourcePoolID, plan); err != nil {
+		return temporal.NewApplicationError(
+			fmt.Sprintf("Failed to restore pool %s: %v", plan.SourcePoolID, err),
+			"RESTORE_FAILED")
+	}
+	return nil
+}
+
+// recordLiquidityTransaction records a pool deposit or withdrawal, reusing the record on retries
+func (a *LiquidityActivities) recordLiquidityTransaction(ctx context.Context, request types.LiquidityRequest, txType string) (*types.Transaction, error) {
+	pool, err := a.liquidityService.GetPool(ctx, request.PoolID)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Pool %s not found", request.PoolID),
+			"POOL_NOT_FOUND",
+			err)
+	}
+
+	// Deterministic ID keeps the activity idempotent across retries
+	txID := fmt.Sprintf("%s-%s", request.RequestID, txType)
+	if existing, err := a.transactionService.GetTransaction(ctx, txID); err == nil {
+		return existing, nil
+	}
+
+	tx := types.Transaction{
+		ID:          txID,
+		Type:        txType,
+		Status:      "completed",
+		SourceChain: request.Token.ChainName,
+		DestChain:   request.Token.ChainName,
+		SourceToken: request.Token,
+		DestToken:   request.Token,
+		Amount:      request.Amount,
+		Value:       request.Amount,
+		Timestamp:   time.Now(),
+		WorkflowID:  request.RequestID,
+	}
+	if txType == "add_liquidity" {
+		tx.FromAddress = request.UserAddress
+		tx.ToAddress = pool.Address
+	} else {
+		tx.FromAddress = pool.Address
+		tx.ToAddress = request.UserAddress
+	}
+
+	if _, err := a.transactionService.CreateTransaction(ctx, tx); err != nil {
+		return nil, temporal.NewApplicationError(
+			fmt.Sprintf("Failed to record %s transaction: %v", txType, err),
+			"TRANSACTION_FAILED")
+	}
+
+	return &tx, nil
+}
+
+// validateLiquidityRequest checks the fields shared by all liquidity activities
+func validateLiquidityRequest(request types.LiquidityRequest) error {
+	if request.Amount == nil || request.Amount.Cmp(big.NewInt(0)) <= 0 {
+		return temporal.NewNonRetryableApplicationError(
+			"Invalid amount",
+			"INVALID_AMOUNT",
+			errors.New("amount must be greater than zero"))
+	}
+	if request.PoolID == "" {
+		return temporal.NewNonRetryableApplicationError(
+			"Invalid pool ID",
+			"INVALID_POOL_ID",
+			errors.New("pool ID cannot be empty"))
+	}
+	if err := types.CheckNativeToken(request.Token); err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "INVALID_TOKEN", err)
+	}
+	return nil
+}
+
+// wrappedTokenOf returns the Universal version of a native token. The Universal version of a chain's own native
+// token is a contract, so it doesn't keep the native placeholder address.
+func wrappedTokenOf(token types.Token) types.Token {
+	wrapped := token
+	wrapped.Symbol = "u" + token.Symbol
+	wrapped.Name = "Universal " + token.Name
+	wrapped.IsWrapped = true
+	if token.IsNative() {
+		wrapped.Address = ""
+	}
+	return wrapped
+}
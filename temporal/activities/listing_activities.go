@@ -0,0 +1,69 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ListingActivities holds implementation of token listing activities
+type ListingActivities struct {
+	checker *services.ListingChecker
+	catalog services.TokenCatalog
+	listed  services.ListedTokenStore
+}
+
+// NewListingActivities creates a new instance of listing activities
+func NewListingActivities(checker *services.ListingChecker, catalog services.TokenCatalog) *ListingActivities {
+	return &ListingActivities{
+		checker: checker,
+		catalog: catalog,
+		listed:  services.NewInMemoryListedTokenStore(),
+	}
+}
+
+// SetTokenStore sets the store approved tokens are saved to, so the API server lists them
+func (a *ListingActivities) SetTokenStore(store services.ListedTokenStore) {
+	a.listed = store
+}
+
+// RunListingChecksActivity runs the automated risk checks of a listing request
+func (a *ListingActivities) RunListingChecksActivity(ctx context.Context, listing types.ListingRequest) ([]types.ListingCheck, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Running listing risk checks", "listingID", listing.ID, "symbol", listing.Token.Symbol, "chainId", listing.Token.ChainID)
+
+	checks := a.checker.Check(ctx, listing)
+
+	failed := 0
+	for _, check := range checks {
+		if !check.Passed {
+			failed++
+		}
+	}
+	logger.Info("Listing risk checks completed", "listingID", listing.ID, "checks", len(checks), "failed", failed)
+	return checks, nil
+}
+
+// ListTokenActivity adds an approved listing's token to the registry
+func (a *ListingActivities) ListTokenActivity(ctx context.Context, listing types.ListingRequest) (*types.Token, error) {
+	activity.GetLogger(ctx).Info("Listing token", "listingID", listing.ID, "symbol", listing.Token.Symbol, "chainId", listing.Token.ChainID)
+
+	if err := services.ListToken(ctx, a.catalog, a.listed, listing); err != nil {
+		if errors.Is(err, services.ErrListedTokenNotSaved) {
+			// The token is in the registry; retrying saves it
+			return nil, err
+		}
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Failed to list token %s: %v", listing.Token.Symbol, err),
+			"LISTING_FAILED",
+			err)
+	}
+
+	token := listing.ListedToken()
+	return &token, nil
+}
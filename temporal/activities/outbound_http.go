@@ -0,0 +1,367 @@
+package temporal_activities
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// Built-in request budgets of the price APIs, within their free and paid plans' rate limits
+var (
+	coinGeckoPublicBudget = HostBudget{RequestsPerMinute: 10, Burst: 2} // Keyless public API
+	coinGeckoDemoBudget   = HostBudget{RequestsPerMinute: 30, Burst: 5} // Demo plan key
+	coinGeckoProBudget    = HostBudget{RequestsPerMinute: 500, Burst: 20}
+	jupiterBudget         = HostBudget{RequestsPerMinute: 60, Burst: 10} // Free tier
+)
+
+// outboundRetryDelay is the backoff after a 429 without Retry-After, doubling with each retry
+const outboundRetryDelay = time.Second
+
+// HostBudget is the rate requests are sent to a host at
+type HostBudget struct {
+	RequestsPerMinute int // 0 is unlimited
+	Burst             int // Requests sent at once after the host was idle; below 1 sends one at a time
+}
+
+// OutboundOptions configures an OutboundTransport
+type OutboundOptions struct {
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int // 0 is unlimited
+	IdleConnTimeout     time.Duration
+	MaxRetries          int                   // Times a 429 response is retried before it is returned
+	MaxRetryWait        time.Duration         // Longest wait a 429 is retried after; longer ones are returned to the caller
+	Budgets             map[string]HostBudget // By host name; hosts without one are unlimited
+	ProxyURL            string                // Empty uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	CABundle            string                // PEM file of CAs trusted besides the system's
+}
+
+// OutboundOptionsFromConfig returns the OUTBOUND options, with built-in budgets for CoinGecko, on the plan
+// PRICES.COINGECKO_API_KEY belongs to, and Jupiter unless OUTBOUND.HOSTS overrides them
+func OutboundOptionsFromConfig(cfg temporal_config.Config) OutboundOptions {
+	coinGecko := coinGeckoPublicBudget
+	if cfg.Prices.CoinGeckoAPIKey != "" {
+		coinGecko = coinGeckoDemoBudget
+	}
+	budgets := map[string]HostBudget{
+		"api.coingecko.com":     coinGecko,
+		"pro-api.coingecko.com": coinGeckoProBudget,
+		"api.jup.ag":            jupiterBudget,
+	}
+	for _, host := range cfg.Outbound.Hosts {
+		budgets[host.Host] = HostBudget{RequestsPerMinute: host.RequestsPerMinute, Burst: host.Burst}
+	}
+
+	return OutboundOptions{
+		MaxIdleConnsPerHost: cfg.Outbound.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.Outbound.MaxConnsPerHost,
+		IdleConnTimeout:     cfg.Outbound.IdleConnTimeout,
+		MaxRetries:          cfg.Outbound.MaxRetries,
+		MaxRetryWait:        cfg.Outbound.MaxRetryWait,
+		Budgets:             budgets,
+		ProxyURL:            cfg.Outbound.ProxyURL,
+		CABundle:            cfg.Outbound.CABundle,
+	}
+}
+
+// OutboundHostStats counts the requests sent to a host
+type OutboundHostStats struct {
+	Requests    int64         `json:"requests"`    // Sent, including retries
+	Throttled   int64         `json:"throttled"`   // Held back by the host's budget or a 429 backoff
+	Waited      time.Duration `json:"waited"`      // Time spent held back
+	RateLimited int64         `json:"rateLimited"` // Answered with 429
+	Retries     int64         `json:"retries"`
+	Errors      int64         `json:"errors"` // Failed without a response
+}
+
+// outboundHost is the budget and counts of one host
+type outboundHost struct {
+	budget    HostBudget
+	tokens    float64   // Requests that may be sent now; negative when later requests have been promised slots
+	updated   time.Time // When tokens was last refilled
+	backedOff time.Time // No requests are sent before this, after a 429
+	stats     OutboundHostStats
+}
+
+// OutboundTransport is the http.RoundTripper activities call external APIs through. It paces the requests to each
+// host within the host's budget, backs off a host that answers 429 and retries the request when its Retry-After is
+// short, keeps a tuned pool of connections to each host, and counts the requests sent to each. Behind a corporate
+// proxy it connects through the proxy and trusts the proxy's CA.
+type OutboundTransport struct {
+	base         http.RoundTripper
+	maxRetries   int
+	maxRetryWait time.Duration
+	retryDelay   time.Duration
+	budgets      map[string]HostBudget
+	hosts        map[string]*outboundHost
+	mu           sync.Mutex
+	now          func() time.Time
+}
+
+// NewOutboundTransport creates an outbound transport with its own connection pool, connecting through the proxy and
+// trusting the CAs of the options
+func NewOutboundTransport(options OutboundOptions) (*OutboundTransport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.MaxIdleConns = 0 // Bounded per host instead
+	base.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	base.MaxConnsPerHost = options.MaxConnsPerHost
+	if options.IdleConnTimeout > 0 {
+		base.IdleConnTimeout = options.IdleConnTimeout
+	}
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			// The URL may hold the proxy's credentials, so it isn't logged
+			return nil, errors.New("invalid proxy URL")
+		}
+		base.Proxy = http.ProxyURL(proxyURL)
+	}
+	if options.CABundle != "" {
+		roots, err := loadCABundle(options.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		base.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	return newOutboundTransport(base, options), nil
+}
+
+// loadCABundle returns the system's CAs together with the CAs of a PEM file
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return roots, nil
+}
+
+// newOutboundTransport creates an outbound transport sending requests through base
+func newOutboundTransport(base http.RoundTripper, options OutboundOptions) *OutboundTransport {
+	return &OutboundTransport{
+		base:         base,
+		maxRetries:   options.MaxRetries,
+		maxRetryWait: options.MaxRetryWait,
+		retryDelay:   outboundRetryDelay,
+		budgets:      options.Budgets,
+		hosts:        make(map[string]*outboundHost),
+		now:          time.Now,
+	}
+}
+
+// Client returns an HTTP client sending its requests through the transport, giving up on each after timeout,
+// including the time it is held back
+func (t *OutboundTransport) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: t, Timeout: timeout}
+}
+
+// RoundTrip sends a request once the host's budget allows, retrying it after a short 429 backoff
+func (t *OutboundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req.Context(), host); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			t.count(host, func(stats *OutboundHostStats) { stats.Errors++ })
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		delay := retryAfter(resp.Header.Get("Retry-After"), t.retryDelay<<attempt)
+		t.backOff(host, delay)
+		if attempt >= t.maxRetries || delay > t.maxRetryWait || !canResend(req) || !fitsDeadline(req.Context(), delay) {
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if req, err = resend(req); err != nil {
+			return nil, err
+		}
+		t.count(host, func(stats *OutboundHostStats) { stats.Retries++ })
+	}
+}
+
+// wait holds a request back until the host's budget and any 429 backoff allow it, failing without waiting when
+// ctx ends first
+func (t *OutboundTransport) wait(ctx context.Context, host string) error {
+	delay := t.reserve(host)
+	if delay <= 0 {
+		return nil
+	}
+	if !fitsDeadline(ctx, delay) {
+		t.release(host)
+		return fmt.Errorf("request to %s would wait %s for its rate limit, past the request's deadline", host, delay.Round(time.Millisecond))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		t.release(host)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a request slot from the host's budget, returning how long the request must wait for it
+func (t *OutboundTransport) reserve(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.host(host)
+	h.stats.Requests++
+	now := t.now()
+	var delay time.Duration
+	if rate := h.budget.RequestsPerMinute; rate > 0 {
+		burst := float64(max(h.budget.Burst, 1))
+		perRequest := time.Minute / time.Duration(rate)
+		h.tokens = min(burst, h.tokens+float64(now.Sub(h.updated))/float64(perRequest))
+		h.updated = now
+		h.tokens--
+		if h.tokens < 0 {
+			delay = time.Duration(-h.tokens * float64(perRequest))
+		}
+	}
+	if backoff := h.backedOff.Sub(now); backoff > delay {
+		delay = backoff
+	}
+	if delay > 0 {
+		h.stats.Throttled++
+		h.stats.Waited += delay
+	}
+	return delay
+}
+
+// release returns a slot reserved for a request that was never sent
+func (t *OutboundTransport) release(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.host(host)
+	h.stats.Requests--
+	if h.budget.RequestsPerMinute > 0 {
+		h.tokens++
+	}
+}
+
+// backOff holds back the host's requests for delay after it answered 429
+func (t *OutboundTransport) backOff(host string, delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.host(host)
+	h.stats.RateLimited++
+	if until := t.now().Add(delay); until.After(h.backedOff) {
+		h.backedOff = until
+	}
+}
+
+// count updates the host's counts
+func (t *OutboundTransport) count(host string, update func(stats *OutboundHostStats)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	update(&t.host(host).stats)
+}
+
+// host returns the budget and counts of a host, starting with a full burst; the caller holds t.mu
+func (t *OutboundTransport) host(name string) *outboundHost {
+	h, ok := t.hosts[name]
+	if !ok {
+		budget := t.budgets[name]
+		h = &outboundHost{budget: budget, tokens: float64(max(budget.Burst, 1)), updated: t.now()}
+		t.hosts[name] = h
+	}
+	return h
+}
+
+// Stats returns the counts of the requests sent to each host so far
+func (t *OutboundTransport) Stats() map[string]OutboundHostStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]OutboundHostStats, len(t.hosts))
+	for name, h := range t.hosts {
+		stats[name] = h.stats
+	}
+	return stats
+}
+
+// StartLogging logs the counts of each host every interval until ctx is done
+func (t *OutboundTransport) StartLogging(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.logStats()
+			}
+		}
+	}()
+}
+
+// logStats logs the counts of each host, in host order
+func (t *OutboundTransport) logStats() {
+	stats := t.Stats()
+	hosts := make([]string, 0, len(stats))
+	for host := range stats {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		s := stats[host]
+		log.Printf("Outbound requests to %s: %d sent, %d throttled for %v, %d rate limited, %d retried, %d failed",
+			host, s.Requests, s.Throttled, s.Waited.Round(time.Millisecond), s.RateLimited, s.Retries, s.Errors)
+	}
+}
+
+// canResend reports whether a request's body can be sent again
+func canResend(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// resend returns a copy of a request with a fresh body
+func resend(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// fitsDeadline reports whether waiting delay leaves ctx time before its deadline
+func fitsDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > delay
+}
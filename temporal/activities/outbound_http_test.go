@@ -0,0 +1,204 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// scriptedAPI answers requests with the queued status codes, then 200, keeping the bodies it received
+type scriptedAPI struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	bodies     []string
+}
+
+func (a *scriptedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bodies = append(a.bodies, string(body))
+	status := http.StatusOK
+	if len(a.statuses) > 0 {
+		status, a.statuses = a.statuses[0], a.statuses[1:]
+	}
+	if status == http.StatusTooManyRequests && a.retryAfter != "" {
+		w.Header().Set("Retry-After", a.retryAfter)
+	}
+	w.WriteHeader(status)
+}
+
+func TestOutboundTransportPacesHost(t *testing.T) {
+	api := &scriptedAPI{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	host := strings.Split(strings.TrimPrefix(server.URL, "http://"), ":")[0]
+
+	// A burst of 2, then one request every 50ms
+	transport := newOutboundTransport(http.DefaultTransport, OutboundOptions{
+		Budgets: map[string]HostBudget{host: {RequestsPerMinute: 1200, Burst: 2}},
+	})
+	client := transport.Client(5 * time.Second)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected the requests after the burst to be paced, took %v", elapsed)
+	}
+
+	stats := transport.Stats()[host]
+	if stats.Requests != 4 || stats.Throttled != 2 || stats.Waited <= 0 {
+		t.Errorf("Expected 2 of 4 requests throttled, got %+v", stats)
+	}
+}
+
+func TestOutboundTransportRetriesRateLimited(t *testing.T) {
+	api := &scriptedAPI{statuses: []int{http.StatusTooManyRequests}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	transport := newOutboundTransport(http.DefaultTransport, OutboundOptions{MaxRetries: 2, MaxRetryWait: time.Second})
+	transport.retryDelay = 10 * time.Millisecond
+	client := transport.Client(5 * time.Second)
+
+	// The body is sent again with the retry
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"id":1}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the retry to succeed, got %d", resp.StatusCode)
+	}
+	if len(api.bodies) != 2 || api.bodies[1] != `{"id":1}` {
+		t.Errorf("Expected the body sent twice, got %q", api.bodies)
+	}
+
+	stats := transport.Stats()
+	for _, s := range stats {
+		if s.Requests != 2 || s.RateLimited != 1 || s.Retries != 1 {
+			t.Errorf("Expected one rate limited request retried, got %+v", s)
+		}
+	}
+}
+
+func TestOutboundTransportReturnsLongBackoff(t *testing.T) {
+	api := &scriptedAPI{statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests}, retryAfter: "60"}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	transport := newOutboundTransport(http.DefaultTransport, OutboundOptions{MaxRetries: 2, MaxRetryWait: 5 * time.Second})
+	client := transport.Client(5 * time.Second)
+
+	// A Retry-After longer than MaxRetryWait is left to the activity's retry policy
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || len(api.bodies) != 1 {
+		t.Fatalf("Expected the 429 returned without a retry, got %d after %d requests", resp.StatusCode, len(api.bodies))
+	}
+
+	// The host is backed off, so a request that can't wait that long fails without being sent
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "past the request's deadline") {
+		t.Errorf("Expected the backed off request to fail fast, got %v", err)
+	}
+	if len(api.bodies) != 1 {
+		t.Errorf("Expected no request sent while backed off, got %d", len(api.bodies))
+	}
+}
+
+func TestOutboundTransportProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+	}))
+	defer proxy.Close()
+
+	transport, err := NewOutboundTransport(OutboundOptions{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	resp, err := transport.Client(5 * time.Second).Get("http://api.jup.ag/price/v2?ids=SOL")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if len(proxied) != 1 || proxied[0] != "http://api.jup.ag/price/v2?ids=SOL" {
+		t.Errorf("Expected the request sent through the proxy, got %v", proxied)
+	}
+}
+
+func TestOutboundTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The test server's certificate isn't trusted until its CA is in the bundle
+	untrusted, err := NewOutboundTransport(OutboundOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	if _, err := untrusted.Client(5 * time.Second).Get(server.URL); err == nil {
+		t.Fatal("Expected the unknown CA to be refused")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := NewOutboundTransport(OutboundOptions{CABundle: bundle})
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	resp, err := trusted.Client(5 * time.Second).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the bundled CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewOutboundTransport(OutboundOptions{CABundle: bundle}); err == nil {
+		t.Error("Expected a bundle without certificates to be refused")
+	}
+}
+
+func TestOutboundOptionsFromConfig(t *testing.T) {
+	cfg := temporal_config.DefaultConfig()
+	if budget := OutboundOptionsFromConfig(cfg).Budgets["api.coingecko.com"]; budget != coinGeckoPublicBudget {
+		t.Errorf("Expected the public CoinGecko budget without a key, got %+v", budget)
+	}
+
+	cfg.Prices.CoinGeckoAPIKey = "demo-key"
+	cfg.Outbound.Hosts = []temporal_config.HostBudgetConfig{{Host: "api.jup.ag", RequestsPerMinute: 600, Burst: 50}}
+	options := OutboundOptionsFromConfig(cfg)
+	if budget := options.Budgets["api.coingecko.com"]; budget != coinGeckoDemoBudget {
+		t.Errorf("Expected the demo CoinGecko budget with a key, got %+v", budget)
+	}
+	if budget := options.Budgets["api.jup.ag"]; budget != (HostBudget{RequestsPerMinute: 600, Burst: 50}) {
+		t.Errorf("Expected the configured Jupiter budget, got %+v", budget)
+	}
+}
@@ -0,0 +1,297 @@
+package temporal_activities
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// benchPriceLists builds one price list per source covering the same tokens
+func benchPriceLists(tokens int) [][]types.TokenPrice {
+	sources := []types.PriceSource{
+		types.PriceSourceCoinGecko,
+		types.PriceSourceUniversal,
+		types.PriceSourceJupiter,
+	}
+
+	now := time.Now()
+	lists := make([][]types.TokenPrice, 0, len(sources))
+	for _, source := range sources {
+		prices := make([]types.TokenPrice, 0, tokens)
+		for i := 0; i < tokens; i++ {
+			prices = append(prices, types.TokenPrice{
+				Symbol:        fmt.Sprintf("TKN%d", i),
+				ChainID:       int64(i%5 + 1),
+				PriceUSD:      float64(i) + 0.5,
+				LastUpdated:   now,
+				Source:        source,
+				JupiterVolume: 1000,
+			})
+		}
+		lists = append(lists, prices)
+	}
+	return lists
+}
+
+// testPriority ranks sources as the default registry does
+var testPriority = DefaultPriceSources(nil, nil).Priority
+
+// legacyMergePrices is the original map-based merge, kept as a reference for tests and benchmarks
+func legacyMergePrices(pricesList [][]types.TokenPrice) []types.TokenPrice {
+	mergedPrices := make(map[string]types.TokenPrice)
+	for _, prices := range pricesList {
+		for _, price := range prices {
+			key := types.GetPriceKey(price.Symbol, price.ChainID)
+
+			sourcePriority := map[types.PriceSource]int{
+				types.PriceSourceCoinGecko: 1,
+				types.PriceSourceUniversal: 2,
+				types.PriceSourceJupiter:   3,
+				types.PriceSourceFallback:  4,
+			}
+
+			if existing, ok := mergedPrices[key]; ok {
+				if sourcePriority[existing.Source] <= sourcePriority[price.Source] {
+					if price.Source == types.PriceSourceJupiter {
+						existing.JupiterVolume = price.JupiterVolume
+						existing.IsVerified = true
+						mergedPrices[key] = existing
+					}
+					continue
+				}
+			}
+
+			mergedPrices[key] = price
+		}
+	}
+
+	var result []types.TokenPrice
+	for _, price := range mergedPrices {
+		result = append(result, price)
+	}
+	return result
+}
+
+// sortPrices orders prices by symbol and chain so merges can be compared
+func sortPrices(prices []types.TokenPrice) {
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].Symbol != prices[j].Symbol {
+			return prices[i].Symbol < prices[j].Symbol
+		}
+		return prices[i].ChainID < prices[j].ChainID
+	})
+}
+
+func TestMergePrices(t *testing.T) {
+	now := time.Now()
+	lists := [][]types.TokenPrice{
+		{
+			{Symbol: "SOL", ChainID: 999, PriceUSD: 100, Source: types.PriceSourceJupiter, JupiterVolume: 5000, LastUpdated: now},
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, Source: types.PriceSourceUniversal, LastUpdated: now},
+		},
+		{
+			{Symbol: "SOL", ChainID: 999, PriceUSD: 101, Source: types.PriceSourceCoinGecko, LastUpdated: now},
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 2990, Source: types.PriceSourceFallback, LastUpdated: now},
+			{Symbol: "ETH", ChainID: 10, PriceUSD: 2995, Source: types.PriceSourceFallback, LastUpdated: now},
+		},
+		{
+			{Symbol: "SOL", ChainID: 999, PriceUSD: 99, Source: types.PriceSourceJupiter, JupiterVolume: 7000, LastUpdated: now},
+		},
+	}
+
+	merged := mergePrices(lists, testPriority)
+	if len(merged) != 3 {
+		t.Fatalf("Expected 3 merged prices, got %d", len(merged))
+	}
+
+	// First-seen order is preserved
+	if merged[0].Symbol != "SOL" || merged[1].Symbol != "ETH" || merged[2].ChainID != 10 {
+		t.Errorf("Unexpected merge order: %+v", merged)
+	}
+
+	// CoinGecko wins over Jupiter but keeps the Jupiter volume
+	if merged[0].PriceUSD != 101 || merged[0].Source != types.PriceSourceCoinGecko {
+		t.Errorf("Expected CoinGecko SOL price, got %+v", merged[0])
+	}
+	if merged[0].JupiterVolume != 7000 || !merged[0].IsVerified {
+		t.Errorf("Expected Jupiter volume to be merged, got %+v", merged[0])
+	}
+
+	// Universal wins over the fallback
+	if merged[1].PriceUSD != 3000 {
+		t.Errorf("Expected Universal ETH price, got %+v", merged[1])
+	}
+
+	t.Run("MatchesLegacy", func(t *testing.T) {
+		for _, lists := range [][][]types.TokenPrice{lists, benchPriceLists(500)} {
+			got := mergePrices(lists, testPriority)
+			want := legacyMergePrices(lists)
+			sortPrices(got)
+			sortPrices(want)
+
+			if len(got) != len(want) {
+				t.Fatalf("Expected %d prices, got %d", len(want), len(got))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("Price %d differs: got %+v, want %+v", i, got[i], want[i])
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkMergePrices(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		lists := benchPriceLists(size)
+
+		b.Run(fmt.Sprintf("tokens=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				mergePrices(lists, testPriority)
+			}
+		})
+
+		b.Run(fmt.Sprintf("tokens=%d/reused", size), func(b *testing.B) {
+			buf := make([]types.TokenPrice, 0, size)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf = mergePricesInto(buf[:0], lists, testPriority)
+			}
+		})
+
+		b.Run(fmt.Sprintf("tokens=%d/legacy", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				legacyMergePrices(lists)
+			}
+		})
+	}
+}
+
+func TestPriceCacheTTLs(t *testing.T) {
+	activities := NewPriceActivitiesWithSources(nil, t.TempDir(), NewPriceSourceRegistry())
+	activities.SetCacheTTLs(CacheTTLs{
+		Default: time.Hour,
+		Sources: map[types.PriceSource]time.Duration{types.PriceSourceChainlink: time.Nanosecond},
+		Tokens:  map[string]time.Duration{"DAI": time.Nanosecond},
+	})
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.SavePricesToCacheActivity)
+	env.RegisterActivity(activities.LoadFreshPricesFromCacheActivity)
+
+	load := func(t *testing.T, request types.PriceFetchRequest) types.PriceCacheLoad {
+		value, err := env.ExecuteActivity(activities.LoadFreshPricesFromCacheActivity, request)
+		if err != nil {
+			t.Fatalf("Failed to load prices from cache: %v", err)
+		}
+		var result types.PriceCacheLoad
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode cache load: %v", err)
+		}
+		return result
+	}
+
+	if _, err := env.ExecuteActivity(activities.LoadFreshPricesFromCacheActivity, types.PriceFetchRequest{}); err == nil {
+		t.Error("Expected an error loading a missing cache, got nil")
+	}
+
+	if _, err := env.ExecuteActivity(activities.SavePricesToCacheActivity, []types.TokenPrice{
+		{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, Source: types.PriceSourceChainlink},
+		{Symbol: "WBTC", ChainID: 1, PriceUSD: 60000, Source: types.PriceSourceCoinGecko},
+		{Symbol: "DAI", ChainID: 1, PriceUSD: 1, Source: types.PriceSourceCoinGecko},
+	}); err != nil {
+		t.Fatalf("Failed to save prices to cache: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	t.Run("PartiallyStale", func(t *testing.T) {
+		result := load(t, types.PriceFetchRequest{})
+		if len(result.Prices) != 1 || result.Prices[0].Symbol != "WBTC" {
+			t.Errorf("Expected only WBTC to be fresh, got %+v", result.Prices)
+		}
+		if fmt.Sprint(result.StaleSymbols) != "[DAI ETH]" {
+			t.Errorf("Expected DAI and ETH to be stale, got %v", result.StaleSymbols)
+		}
+	})
+
+	t.Run("MissingSymbolIsStale", func(t *testing.T) {
+		result := load(t, types.PriceFetchRequest{Symbols: []string{"wbtc", "sol"}})
+		if len(result.Prices) != 1 || fmt.Sprint(result.StaleSymbols) != "[SOL]" {
+			t.Errorf("Expected fresh WBTC and stale SOL, got %+v", result)
+		}
+	})
+
+	t.Run("PartialSaveKeepsFreshEntries", func(t *testing.T) {
+		if _, err := env.ExecuteActivity(activities.SavePricesToCacheActivity, []types.TokenPrice{
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 3100, Source: types.PriceSourceCoinGecko},
+		}); err != nil {
+			t.Fatalf("Failed to save prices to cache: %v", err)
+		}
+
+		result := load(t, types.PriceFetchRequest{})
+		if len(result.Prices) != 2 || fmt.Sprint(result.StaleSymbols) != "[DAI]" {
+			t.Errorf("Expected fresh ETH and WBTC with stale DAI, got %+v", result)
+		}
+	})
+
+	t.Run("EvictsLongExpiredEntries", func(t *testing.T) {
+		cache, err := ReadPriceCache(activities.cacheDir)
+		if err != nil {
+			t.Fatalf("Failed to read cache: %v", err)
+		}
+		key := types.GetPriceKey("DAI", 1)
+		cache.EntryExpiresAt[key] = time.Now().Add(-priceCacheEvictAfter - time.Minute)
+		data, _ := json.Marshal(cache)
+		if err := os.WriteFile(filepath.Join(activities.cacheDir, PriceCacheFile), data, 0644); err != nil {
+			t.Fatalf("Failed to write cache: %v", err)
+		}
+
+		if _, err := env.ExecuteActivity(activities.SavePricesToCacheActivity, []types.TokenPrice{
+			{Symbol: "ETH", ChainID: 1, PriceUSD: 3200, Source: types.PriceSourceCoinGecko},
+		}); err != nil {
+			t.Fatalf("Failed to save prices to cache: %v", err)
+		}
+		if cache, _ = ReadPriceCache(activities.cacheDir); len(cache.Prices) != 2 {
+			t.Errorf("Expected the long expired DAI price to be evicted, got %v", cache.Prices)
+		}
+	})
+
+	t.Run("LegacyLoadFailsOnExpiredPrices", func(t *testing.T) {
+		env.RegisterActivity(activities.LoadPricesFromCacheActivity)
+		if _, err := env.ExecuteActivity(activities.LoadPricesFromCacheActivity, types.PriceFetchRequest{Symbols: []string{"SOL"}}); err == nil {
+			t.Error("Expected the legacy load to fail when a requested price is not fresh")
+		}
+		value, err := env.ExecuteActivity(activities.LoadPricesFromCacheActivity, types.PriceFetchRequest{Symbols: []string{"WBTC"}})
+		if err != nil {
+			t.Fatalf("Failed to load prices from cache: %v", err)
+		}
+		var prices []types.TokenPrice
+		if err := value.Get(&prices); err != nil || len(prices) != 1 {
+			t.Errorf("Expected the fresh WBTC price in the legacy shape, got %+v (%v)", prices, err)
+		}
+	})
+
+	t.Run("LegacyCacheUsesExpiresAt", func(t *testing.T) {
+		cache, err := ReadPriceCache(activities.cacheDir)
+		if err != nil {
+			t.Fatalf("Failed to read cache: %v", err)
+		}
+		if key := types.GetPriceKey("WBTC", 1); !cache.EntryExpiry(key).Equal(cache.EntryExpiresAt[key]) {
+			t.Errorf("Expected the entry expiry of %s", key)
+		}
+		cache.EntryExpiresAt = nil
+		if !cache.EntryExpiry("unknown").Equal(cache.ExpiresAt) {
+			t.Error("Expected entries without an expiry to use the cache expiry")
+		}
+	})
+}
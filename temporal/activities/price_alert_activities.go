@@ -0,0 +1,94 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// PriceAlertActivities holds implementation of price alert activities
+type PriceAlertActivities struct {
+	alerts  services.PriceAlertStore
+	client  *http.Client
+	secrets services.WebhookSecretStore
+	mailer  services.EmailSender // nil leaves email alerts unsent
+}
+
+// NewPriceAlertActivities creates price alert activities evaluating the alerts in alerts, sending webhooks with
+// client signed like swap callbacks, and emails with mailer
+func NewPriceAlertActivities(alerts services.PriceAlertStore, client *http.Client, secrets services.WebhookSecretStore, mailer services.EmailSender) *PriceAlertActivities {
+	return &PriceAlertActivities{
+		alerts:  alerts,
+		client:  client,
+		secrets: secrets,
+		mailer:  mailer,
+	}
+}
+
+// EvaluatePriceAlertsActivity marks the active alerts the prices crossed as triggered and returns them to be notified
+func (a *PriceAlertActivities) EvaluatePriceAlertsActivity(ctx context.Context, prices []types.TokenPrice) ([]types.PriceAlert, error) {
+	triggered, err := a.alerts.TriggerPriceAlerts(ctx, prices, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate price alerts: %w", err)
+	}
+	activity.GetLogger(ctx).Info("Evaluated price alerts", "prices", len(prices), "triggered", len(triggered))
+	return triggered, nil
+}
+
+// HasActivePriceAlertsActivity reports whether any alert is still active, so price updates with nothing to evaluate
+// start no alert workflow
+func (a *PriceAlertActivities) HasActivePriceAlertsActivity(ctx context.Context) (bool, error) {
+	active, err := a.alerts.HasActivePriceAlerts(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up active price alerts: %w", err)
+	}
+	return active, nil
+}
+
+// NotifyPriceAlertActivity notifies a fired alert's owner on one channel. Failed deliveries fail the activity so they
+// are retried; an alert that can't be sent on the channel is not.
+func (a *PriceAlertActivities) NotifyPriceAlertActivity(ctx context.Context, alert types.PriceAlert, channel string) error {
+	switch channel {
+	case types.PriceAlertChannelWebhook:
+		secret, err := services.WebhookSecret(ctx, a.secrets, alert.Owner)
+		if errors.Is(err, types.ErrWebhookSecretNotFound) {
+			return temporal.NewNonRetryableApplicationError("No webhook secret to sign the alert with", "WEBHOOK_SECRET_NOT_FOUND", err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get webhook secret: %w", err)
+		}
+		body, err := json.Marshal(services.NewPriceAlertWebhook(alert))
+		if err != nil {
+			return temporal.NewNonRetryableApplicationError("Failed to encode price alert", "INVALID_PRICE_ALERT", err)
+		}
+		if err := services.PostWebhook(ctx, a.client, alert.WebhookURL, secret, body); err != nil {
+			return fmt.Errorf("failed to send webhook of price alert %s: %w", alert.ID, err)
+		}
+
+	case types.PriceAlertChannelEmail:
+		if a.mailer == nil {
+			return temporal.NewNonRetryableApplicationError("Email notifications are not configured", "EMAIL_DISABLED", services.ErrEmailDisabled)
+		}
+		subject, body := services.PriceAlertEmail(alert)
+		if err := a.mailer.Send(alert.Email, subject, body); err != nil {
+			if errors.Is(err, services.ErrEmailDisabled) {
+				return temporal.NewNonRetryableApplicationError("Email notifications are not configured", "EMAIL_DISABLED", err)
+			}
+			return fmt.Errorf("failed to email price alert %s: %w", alert.ID, err)
+		}
+
+	default:
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("Unknown price alert channel %q", channel), "INVALID_PRICE_ALERT", nil)
+	}
+
+	activity.GetLogger(ctx).Info("Sent price alert", "alertID", alert.ID, "symbol", alert.Symbol, "channel", channel)
+	return nil
+}
@@ -0,0 +1,86 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// recordingMailer records the emails it is asked to send
+type recordingMailer struct {
+	sent []string // recipients
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, to)
+	return nil
+}
+
+func TestPriceAlertActivities(t *testing.T) {
+	var received []services.PriceAlertWebhook
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var webhook services.PriceAlertWebhook
+		json.NewDecoder(r.Body).Decode(&webhook)
+		received = append(received, webhook)
+	}))
+	defer target.Close()
+
+	ctx := context.Background()
+	alerts := services.NewInMemoryPriceAlertStore()
+	alert, err := services.NewPriceAlert("key-1", types.PriceAlert{Symbol: "ETH", Threshold: 4000, Direction: types.PriceAlertAbove, WebhookURL: target.URL, Email: "ops@example.com"}, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create alert: %v", err)
+	}
+	if err := alerts.CreatePriceAlert(ctx, alert); err != nil {
+		t.Fatalf("Failed to save alert: %v", err)
+	}
+	secrets := services.NewInMemoryWebhookSecretStore()
+	if err := secrets.SetWebhookSecret(ctx, "key-1", "registered"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	mailer := &recordingMailer{}
+	activities := NewPriceAlertActivities(alerts, target.Client(), secrets, mailer)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.EvaluatePriceAlertsActivity)
+	env.RegisterActivity(activities.NotifyPriceAlertActivity)
+
+	value, err := env.ExecuteActivity(activities.EvaluatePriceAlertsActivity, []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 4100}})
+	if err != nil {
+		t.Fatalf("Failed to evaluate alerts: %v", err)
+	}
+	var triggered []types.PriceAlert
+	if err := value.Get(&triggered); err != nil {
+		t.Fatalf("Failed to get triggered alerts: %v", err)
+	}
+	if len(triggered) != 1 || triggered[0].Status != types.PriceAlertStatusTriggered {
+		t.Fatalf("Expected the alert triggered, got %+v", triggered)
+	}
+
+	for _, channel := range triggered[0].Channels() {
+		if _, err := env.ExecuteActivity(activities.NotifyPriceAlertActivity, triggered[0], channel); err != nil {
+			t.Fatalf("Failed to notify on %s: %v", channel, err)
+		}
+	}
+	if len(received) != 1 || received[0].Event != services.PriceAlertTriggeredWebhookEvent || received[0].Alert.ID != alert.ID {
+		t.Errorf("Expected a price_alert.triggered webhook, got %+v", received)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0] != "ops@example.com" {
+		t.Errorf("Expected an email to ops@example.com, got %v", mailer.sent)
+	}
+
+	// Without a mailer, email alerts fail without retrying
+	activities = NewPriceAlertActivities(alerts, target.Client(), secrets, nil)
+	env.RegisterActivity(activities.NotifyPriceAlertActivity)
+	if _, err := env.ExecuteActivity(activities.NotifyPriceAlertActivity, triggered[0], types.PriceAlertChannelEmail); err == nil {
+		t.Error("Expected an email alert without a mailer to fail")
+	}
+}
@@ -0,0 +1,38 @@
+package temporal_activities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// defaultPriceCacheTTL is how long cached prices stay fresh when no TTL is configured for them
+const defaultPriceCacheTTL = time.Hour
+
+// CacheTTLs configures how long cached prices stay fresh.
+// A token's TTL takes precedence over its source's, which takes precedence over the default.
+type CacheTTLs struct {
+	Default time.Duration
+	Sources map[types.PriceSource]time.Duration
+	Tokens  map[string]time.Duration // Keyed by uppercase symbol
+}
+
+// DefaultCacheTTLs returns the cache TTLs used when none are configured
+func DefaultCacheTTLs() CacheTTLs {
+	return CacheTTLs{Default: defaultPriceCacheTTL}
+}
+
+// TTL returns how long price stays fresh in the cache
+func (t CacheTTLs) TTL(price types.TokenPrice) time.Duration {
+	if ttl, ok := t.Tokens[strings.ToUpper(price.Symbol)]; ok && ttl > 0 {
+		return ttl
+	}
+	if ttl, ok := t.Sources[price.Source]; ok && ttl > 0 {
+		return ttl
+	}
+	if t.Default > 0 {
+		return t.Default
+	}
+	return defaultPriceCacheTTL
+}
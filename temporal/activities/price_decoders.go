@@ -0,0 +1,169 @@
+package temporal_activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+)
+
+// formatSampleLength is how much of an unrecognized response an alert quotes
+const formatSampleLength = 200
+
+// ErrUnrecognizedPriceFormat is returned when no decoder of a price source recognizes its response
+var ErrUnrecognizedPriceFormat = errors.New("unrecognized price source response format")
+
+// PriceFormatAlert reports a price source response in a format none of the source's decoders recognize,
+// which usually means the provider changed its API
+type PriceFormatAlert struct {
+	Source   types.PriceSource
+	Endpoint string // Which of the source's responses, such as "tokens" or "prices"
+	Sample   string // The start of the response
+}
+
+// PriceFormatAlertHandler is called with every price format alert raised while fetching prices
+type PriceFormatAlertHandler func(ctx context.Context, alert PriceFormatAlert)
+
+// priceDecoder decodes one version of a price source response. Decoders ignore fields they don't know and skip
+// entries they can't decode, so additions to a format and odd entries don't fail the fetch.
+type priceDecoder[T any] struct {
+	version string
+
+	// probe reports whether a response looks like this version
+	probe func(raw json.RawMessage) bool
+
+	// decode decodes a response, returning how many of its entries were skipped
+	decode func(raw json.RawMessage) (T, int, error)
+}
+
+// decodeVersioned decodes a price source response with the first decoder that recognizes it. A response no decoder
+// recognizes raises a price format alert and fails with ErrUnrecognizedPriceFormat, which sources treat as having
+// no prices rather than failing the fetch; the next scheduled fetch tries again.
+func decodeVersioned[T any](ctx context.Context, source types.PriceSource, endpoint string, body []byte, decoders []priceDecoder[T]) (T, error) {
+	logger := activity.GetLogger(ctx)
+
+	var zero T
+	raw := json.RawMessage(bytes.TrimSpace(body))
+	if json.Valid(raw) {
+		for _, decoder := range decoders {
+			if !decoder.probe(raw) {
+				continue
+			}
+			value, skipped, err := decoder.decode(raw)
+			if err != nil {
+				logger.Warn("Price response matched a format it couldn't be decoded as",
+					"source", source, "endpoint", endpoint, "version", decoder.version, "error", err)
+				continue
+			}
+			if skipped > 0 {
+				logger.Warn("Skipped undecodable price response entries",
+					"source", source, "endpoint", endpoint, "version", decoder.version, "skipped", skipped)
+			}
+			return value, nil
+		}
+	}
+
+	alert := PriceFormatAlert{Source: source, Endpoint: endpoint, Sample: string(body)}
+	if len(alert.Sample) > formatSampleLength {
+		alert.Sample = alert.Sample[:formatSampleLength] + "..."
+	}
+	logger.Error("ALERT: price source response in an unrecognized format",
+		"source", source, "endpoint", endpoint, "sample", alert.Sample)
+	if handler, ok := ctx.Value(priceFormatAlertKey{}).(PriceFormatAlertHandler); ok && handler != nil {
+		handler(ctx, alert)
+	}
+	return zero, fmt.Errorf("%w: %s %s", ErrUnrecognizedPriceFormat, source, endpoint)
+}
+
+// priceFormatAlertKey is the context key of the handler decodeVersioned raises alerts with
+type priceFormatAlertKey struct{}
+
+// withPriceFormatAlerts returns a context raising price format alerts with handler
+func withPriceFormatAlerts(ctx context.Context, handler PriceFormatAlertHandler) context.Context {
+	if handler == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, priceFormatAlertKey{}, handler)
+}
+
+// jsonKind returns the first byte of a JSON value: '{', '[', '"', 'n', 't', 'f' or a number's first digit
+func jsonKind(raw json.RawMessage) byte {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return 0
+	}
+	return raw[0]
+}
+
+// isDigit reports whether b is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// hasField reports whether a JSON object has a field
+func hasField(raw json.RawMessage, name string) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return false
+	}
+	_, ok := fields[name]
+	return ok
+}
+
+// firstElement returns the first element of a JSON array, or nil for an empty or invalid one
+func firstElement(raw json.RawMessage) json.RawMessage {
+	var elements []json.RawMessage
+	if json.Unmarshal(raw, &elements) != nil || len(elements) == 0 {
+		return nil
+	}
+	return elements[0]
+}
+
+// anyValue returns the value of a JSON object's first field in key order, or nil for an empty or invalid object
+func anyValue(raw json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return nil
+	}
+	var first string
+	var value json.RawMessage
+	for key, v := range fields {
+		if value == nil || key < first {
+			first, value = key, v
+		}
+	}
+	return value
+}
+
+// flexFloat is a number that providers send either as a JSON number or a numeric string. Null leaves it unset.
+type flexFloat struct {
+	Value float64
+	Set   bool
+}
+
+// UnmarshalJSON decodes a number, a numeric string or null
+func (f *flexFloat) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*f = flexFloat{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+	value, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("not a number: %s", data)
+	}
+	*f = flexFloat{Value: value, Set: true}
+	return nil
+}
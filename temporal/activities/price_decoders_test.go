@@ -0,0 +1,100 @@
+package temporal_activities
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// decodeWith decodes a response with the first of decoders that recognizes it, as decodeVersioned does,
+// returning the decoder's version
+func decodeWith[T any](t *testing.T, decoders []priceDecoder[T], body string) (T, string) {
+	t.Helper()
+
+	raw := json.RawMessage(body)
+	for _, decoder := range decoders {
+		if !decoder.probe(raw) {
+			continue
+		}
+		value, _, err := decoder.decode(raw)
+		if err == nil {
+			return value, decoder.version
+		}
+	}
+	var zero T
+	return zero, ""
+}
+
+func TestJupiterTokenDecoders(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		version string
+		tokens  int
+	}{
+		{"V1", `[{"address":"So11","symbol":"SOL","name":"Wrapped SOL","daily_volume":1000,"tags":["verified"]},{"address":"JUP1","symbol":"JUP","name":"Jupiter","daily_volume":null}]`, "v1", 2},
+		{"V1SkipsBrokenEntries", `[{"address":"So11","symbol":"SOL"},{"address":42},{"symbol":"NOADDR"}]`, "v1", 1},
+		{"V2", `[{"id":"So11","symbol":"SOL","name":"Wrapped SOL","stats24h":{"buyVolume":600,"sellVolume":"400"}}]`, "v2", 1},
+		{"Empty", `[]`, "v1", 0},
+		{"Unrecognized", `{"tokens":[]}`, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, version := decodeWith(t, jupiterTokenDecoders, tt.body)
+			if version != tt.version || len(tokens) != tt.tokens {
+				t.Fatalf("Expected %d tokens as %q, got %d as %q", tt.tokens, tt.version, len(tokens), version)
+			}
+			if tt.tokens > 0 && tokens[0].Address != "So11" {
+				t.Errorf("Unexpected first token %+v", tokens[0])
+			}
+			if tt.name == "V1" || tt.name == "V2" {
+				if tokens[0].Volume != 1000 {
+					t.Errorf("Expected SOL's daily volume to be 1000, got %v", tokens[0].Volume)
+				}
+			}
+		})
+	}
+}
+
+func TestJupiterPriceDecoders(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		version string
+		price   float64
+		change  float64
+		prices  int
+	}{
+		{"V1", `{"So11":148.5,"JUP1":0.9}`, "v1", 148.5, 0, 2},
+		{"V2", `{"data":{"So11":{"id":"So11","type":"derivedPrice","price":"148.5"},"JUP1":{"id":"JUP1","price":null}},"timeTaken":0.01}`, "v2", 148.5, 0, 1},
+		{"V3", `{"So11":{"usdPrice":148.5,"blockId":1,"decimals":9,"priceChange24h":-2.5},"JUP1":{"usdPrice":0}}`, "v3", 148.5, -2.5, 1},
+		{"Unrecognized", `{"error":"rate limited"}`, "", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotes, version := decodeWith(t, jupiterPriceDecoders, tt.body)
+			if version != tt.version || len(quotes) != tt.prices {
+				t.Fatalf("Expected %d prices as %q, got %d as %q", tt.prices, tt.version, len(quotes), version)
+			}
+			if tt.prices > 0 && (quotes["So11"].Price != tt.price || quotes["So11"].Change24h != tt.change) {
+				t.Errorf("Unexpected SOL quote %+v", quotes["So11"])
+			}
+		})
+	}
+}
+
+func TestFlexFloat(t *testing.T) {
+	for body, expected := range map[string]flexFloat{
+		`1.5`:    {Value: 1.5, Set: true},
+		`"2.25"`: {Value: 2.25, Set: true},
+		`null`:   {},
+	} {
+		var f flexFloat
+		if err := json.Unmarshal([]byte(body), &f); err != nil || f != expected {
+			t.Errorf("Expected %s to decode as %+v, got %+v (%v)", body, expected, f, err)
+		}
+	}
+	var f flexFloat
+	if err := json.Unmarshal([]byte(`"n/a"`), &f); err == nil {
+		t.Error("Expected a non-numeric string to be refused")
+	}
+}
@@ -0,0 +1,46 @@
+package temporal_activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+)
+
+// PriceCacheLookup reads token prices from the price oracle's cache
+type PriceCacheLookup struct {
+	cacheDir string
+}
+
+// NewPriceCacheLookup creates a price lookup reading the price cache in cacheDir
+func NewPriceCacheLookup(cacheDir string) *PriceCacheLookup {
+	return &PriceCacheLookup{cacheDir: cacheDir}
+}
+
+// PriceUSD returns the cached price of a token, preferring its price on chainID.
+// Tokens without a price on that chain use their price on the lowest chain ID they are listed on.
+func (l *PriceCacheLookup) PriceUSD(ctx context.Context, symbol string, chainID int64) (float64, error) {
+	price, err := l.OraclePrice(ctx, symbol, chainID)
+	if err != nil {
+		return 0, err
+	}
+	return price.PriceUSD, nil
+}
+
+// OraclePrice returns the cached price of a token with when it was observed, chosen as PriceUSD chooses it
+func (l *PriceCacheLookup) OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error) {
+	cache, err := ReadPriceCache(l.cacheDir)
+	if err != nil {
+		return types.TokenPrice{}, err
+	}
+
+	prices := make([]types.TokenPrice, 0, len(cache.Prices))
+	for _, price := range cache.Prices {
+		prices = append(prices, price)
+	}
+	if price, ok := services.BestTokenPrice(prices, symbol, chainID); ok {
+		return price, nil
+	}
+	return types.TokenPrice{}, fmt.Errorf("no price for %s", symbol)
+}
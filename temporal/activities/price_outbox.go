@@ -0,0 +1,173 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// DefaultOutboxMaxAttempts is how many times a queued batch may be rejected by the database before it is dead-lettered
+const DefaultOutboxMaxAttempts = 5
+
+// PriceOutbox queues price batches on disk while the database is unavailable
+// so they can be replayed in order once it is back
+type PriceOutbox struct {
+	dir         string
+	seq         int
+	maxAttempts int
+	mu          sync.Mutex
+}
+
+// NewPriceOutbox creates an outbox storing batches in dir
+func NewPriceOutbox(dir string) (*PriceOutbox, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create outbox directory: %w", err)
+	}
+	return &PriceOutbox{dir: dir, maxAttempts: DefaultOutboxMaxAttempts}, nil
+}
+
+// SetMaxAttempts sets how many times a queued batch may be rejected before it is dead-lettered
+func (o *PriceOutbox) SetMaxAttempts(maxAttempts int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.maxAttempts = maxAttempts
+}
+
+// Enqueue stores a batch of prices for later replay
+func (o *PriceOutbox) Enqueue(prices []types.TokenPrice) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	data, err := json.Marshal(prices)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a partial batch behind
+	o.seq++
+	name := fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), o.seq)
+	tmp := filepath.Join(o.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(o.dir, name))
+}
+
+// Pending returns the number of queued batches
+func (o *PriceOutbox) Pending() (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	batches, err := o.batches()
+	return len(batches), err
+}
+
+// Replay saves queued batches oldest first, removing each once saved, and returns the number of batches
+// replayed. It stops at the first failure, except that a batch rejected with an error retryable doesn't
+// accept is dead-lettered once it has been rejected maxAttempts times, so it can't block the queue forever.
+func (o *PriceOutbox) Replay(ctx context.Context, save func(context.Context, []types.TokenPrice) error, retryable func(error) bool) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	batches, err := o.batches()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, name := range batches {
+		path := filepath.Join(o.dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return i, err
+		}
+
+		var prices []types.TokenPrice
+		if err := json.Unmarshal(data, &prices); err != nil {
+			// A corrupt batch can never be replayed, so set it aside instead of blocking the queue
+			if renameErr := os.Rename(path, path+".corrupt"); renameErr != nil {
+				return i, renameErr
+			}
+			continue
+		}
+
+		if err := save(ctx, prices); err != nil {
+			if retryable(err) {
+				return i, err
+			}
+			attempts, countErr := o.recordAttempt(path)
+			if countErr != nil {
+				return i, countErr
+			}
+			if attempts < o.maxAttempts {
+				return i, err
+			}
+			if err := o.deadLetter(path); err != nil {
+				return i, err
+			}
+			log.Printf("Dead-lettered price batch %s after %d rejections: %v", name, attempts, err)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return i + 1, err
+		}
+		os.Remove(path + ".attempts")
+	}
+
+	return len(batches), nil
+}
+
+// DeadLettered returns the number of batches set aside after being rejected too many times
+func (o *PriceOutbox) DeadLettered() (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	dead, err := filepath.Glob(filepath.Join(o.dir, "*.json.dead"))
+	return len(dead), err
+}
+
+// recordAttempt counts a rejection of the batch at path, returning the rejections so far
+func (o *PriceOutbox) recordAttempt(path string) (int, error) {
+	attempts := 0
+	if data, err := os.ReadFile(path + ".attempts"); err == nil {
+		attempts, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+	attempts++
+	return attempts, os.WriteFile(path+".attempts", []byte(strconv.Itoa(attempts)), 0644)
+}
+
+// deadLetter sets the batch at path aside so later batches can be replayed
+func (o *PriceOutbox) deadLetter(path string) error {
+	if err := os.Rename(path, path+".dead"); err != nil {
+		return err
+	}
+	os.Remove(path + ".attempts")
+	return nil
+}
+
+// batches lists the queued batch files oldest first
+func (o *PriceOutbox) batches() ([]string, error) {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
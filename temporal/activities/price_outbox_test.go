@@ -0,0 +1,122 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestPriceOutbox(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	outbox, err := NewPriceOutbox(dir)
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+
+	for _, symbol := range []string{"ETH", "SOL", "BTC"} {
+		if err := outbox.Enqueue([]types.TokenPrice{{Symbol: symbol, ChainID: 1}}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	if pending, _ := outbox.Pending(); pending != 3 {
+		t.Fatalf("Expected 3 pending batches, got %d", pending)
+	}
+
+	t.Run("ReplayStopsAtFailure", func(t *testing.T) {
+		var saved []string
+		replayed, err := outbox.Replay(ctx, func(ctx context.Context, prices []types.TokenPrice) error {
+			if prices[0].Symbol == "SOL" {
+				return errors.New("database down")
+			}
+			saved = append(saved, prices[0].Symbol)
+			return nil
+		}, alwaysRetryable)
+		if err == nil {
+			t.Fatal("Expected replay error, got nil")
+		}
+		if replayed != 1 || len(saved) != 1 || saved[0] != "ETH" {
+			t.Errorf("Expected only ETH replayed, got %d %v", replayed, saved)
+		}
+		if pending, _ := outbox.Pending(); pending != 2 {
+			t.Errorf("Expected 2 pending batches, got %d", pending)
+		}
+	})
+
+	t.Run("ReplayInOrder", func(t *testing.T) {
+		// A corrupt batch is set aside instead of blocking the queue
+		if err := os.WriteFile(filepath.Join(dir, "99999999999999999999-000000.json"), []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var saved []string
+		replayed, err := outbox.Replay(ctx, func(ctx context.Context, prices []types.TokenPrice) error {
+			saved = append(saved, prices[0].Symbol)
+			return nil
+		}, alwaysRetryable)
+		if err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+		if replayed != 3 || len(saved) != 2 || saved[0] != "SOL" || saved[1] != "BTC" {
+			t.Errorf("Expected SOL then BTC replayed, got %d %v", replayed, saved)
+		}
+		if pending, _ := outbox.Pending(); pending != 0 {
+			t.Errorf("Expected empty outbox, got %d", pending)
+		}
+	})
+}
+
+func TestPriceOutboxDeadLetter(t *testing.T) {
+	ctx := context.Background()
+
+	outbox, err := NewPriceOutbox(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+	outbox.SetMaxAttempts(2)
+
+	for _, symbol := range []string{"BAD", "ETH"} {
+		if err := outbox.Enqueue([]types.TokenPrice{{Symbol: symbol, ChainID: 1}}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	var saved []string
+	save := func(ctx context.Context, prices []types.TokenPrice) error {
+		if prices[0].Symbol == "BAD" {
+			return errors.New("violates check constraint")
+		}
+		saved = append(saved, prices[0].Symbol)
+		return nil
+	}
+	neverRetryable := func(error) bool { return false }
+
+	// The rejected batch blocks the queue until it has used up its attempts
+	if _, err := outbox.Replay(ctx, save, neverRetryable); err == nil {
+		t.Fatal("Expected replay error on first attempt, got nil")
+	}
+	if len(saved) != 0 {
+		t.Errorf("Expected nothing replayed past the rejected batch, got %v", saved)
+	}
+
+	if _, err := outbox.Replay(ctx, save, neverRetryable); err != nil {
+		t.Fatalf("Expected rejected batch dead-lettered, got %v", err)
+	}
+	if len(saved) != 1 || saved[0] != "ETH" {
+		t.Errorf("Expected ETH replayed after dead-lettering, got %v", saved)
+	}
+	if pending, _ := outbox.Pending(); pending != 0 {
+		t.Errorf("Expected empty outbox, got %d", pending)
+	}
+	if dead, _ := outbox.DeadLettered(); dead != 1 {
+		t.Errorf("Expected 1 dead-lettered batch, got %d", dead)
+	}
+}
+
+func alwaysRetryable(error) bool { return true }
@@ -0,0 +1,75 @@
+package temporal_activities
+
+import (
+	"math"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// defaultMaxDeviationPct is how far a merged price may stray from its CEX reference by default
+const defaultMaxDeviationPct = 10.0
+
+// SanityBand bounds merged prices by their deviation from reference prices
+type SanityBand struct {
+	MaxDeviationPct float64 // Percent deviation from the reference allowed; 0 disables the band
+	Drop            bool    // Drop prices outside the band instead of flagging them
+}
+
+// DefaultSanityBand returns the sanity band used when none is configured
+func DefaultSanityBand() SanityBand {
+	return SanityBand{MaxDeviationPct: defaultMaxDeviationPct, Drop: true}
+}
+
+// splitReferencePrices separates the price lists of reference-only sources from those to merge
+func splitReferencePrices(pricesList [][]types.TokenPrice, isReference func(types.PriceSource) bool) (merge, references [][]types.TokenPrice) {
+	for _, prices := range pricesList {
+		// Each list comes from a single source
+		if len(prices) > 0 && isReference(prices[0].Source) {
+			references = append(references, prices)
+			continue
+		}
+		merge = append(merge, prices)
+	}
+	return merge, references
+}
+
+// applySanityBand records each price's deviation from its reference and drops or flags outliers.
+// Prices without a reference pass through unchanged. It returns the kept prices and the outliers.
+func applySanityBand(prices []types.TokenPrice, references [][]types.TokenPrice, band SanityBand) (kept, outliers []types.TokenPrice) {
+	if band.MaxDeviationPct <= 0 || len(references) == 0 {
+		return prices, nil
+	}
+
+	// The first reference source listing a symbol wins
+	referencePrices := make(map[string]float64)
+	for _, list := range references {
+		for _, ref := range list {
+			symbol := referenceSymbol(ref.Symbol)
+			if _, ok := referencePrices[symbol]; !ok && ref.PriceUSD > 0 {
+				referencePrices[symbol] = ref.PriceUSD
+			}
+		}
+	}
+
+	kept = prices[:0]
+	for _, price := range prices {
+		ref, ok := referencePrices[referenceSymbol(price.Symbol)]
+		if !ok {
+			kept = append(kept, price)
+			continue
+		}
+
+		price.ReferenceDeviation = math.Abs(price.PriceUSD-ref) / ref * 100
+		if price.ReferenceDeviation > band.MaxDeviationPct {
+			outliers = append(outliers, price)
+			if band.Drop {
+				continue
+			}
+			price.SanityFlagged = true
+			price.IsVerified = false
+		}
+		kept = append(kept, price)
+	}
+
+	return kept, outliers
+}
@@ -0,0 +1,180 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// binanceAPIURL is the Binance spot API base URL
+const binanceAPIURL = "https://api.binance.com"
+
+// binancePairs maps token symbols to the Binance USDT spot pairs used as their reference
+var binancePairs = map[string]string{
+	"ETH":   "ETHUSDT",
+	"BTC":   "BTCUSDT",
+	"SOL":   "SOLUSDT",
+	"USDC":  "USDCUSDT",
+	"MATIC": "POLUSDT", // MATIC trades as POL since the migration
+	"AVAX":  "AVAXUSDT",
+	"BNB":   "BNBUSDT",
+	"BONK":  "BONKUSDT",
+	"JUP":   "JUPUSDT",
+	"RAY":   "RAYUSDT",
+}
+
+// BinancePriceSource fetches spot prices from Binance.
+// It is a reference source: its prices bound the merged prices rather than being merged themselves.
+type BinancePriceSource struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewBinancePriceSource creates a new Binance price source
+func NewBinancePriceSource(httpClient *http.Client) *BinancePriceSource {
+	return &BinancePriceSource{httpClient: httpClient, baseURL: binanceAPIURL}
+}
+
+// SetBaseURL calls a mirror of the Binance API instead of api.binance.com
+func (s *BinancePriceSource) SetBaseURL(baseURL string) {
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// Name returns the source name
+func (s *BinancePriceSource) Name() types.PriceSource {
+	return types.PriceSourceBinance
+}
+
+// Priority ranks Binance after the on-chain and aggregator sources; it is only used as a reference
+func (s *BinancePriceSource) Priority() int {
+	return 10
+}
+
+// ReferenceOnly keeps Binance prices out of the merged prices
+func (s *BinancePriceSource) ReferenceOnly() bool {
+	return true
+}
+
+// binanceTicker is an entry of the Binance 24hr ticker response
+type binanceTicker struct {
+	Symbol             string `json:"symbol"`
+	LastPrice          string `json:"lastPrice"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	QuoteVolume        string `json:"quoteVolume"`
+}
+
+// Fetch fetches reference prices from the Binance 24hr ticker.
+// USDT quotes are treated as USD. Reference prices carry no chain since they apply on every chain.
+func (s *BinancePriceSource) Fetch(ctx context.Context, request types.PriceFetchRequest) ([]types.TokenPrice, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Fetching Binance reference prices", "symbols", request.Symbols)
+
+	// Binance rejects the whole request if any pair is unknown, so only ask for pairs we know
+	pairToSymbol := make(map[string]string)
+	if len(request.Symbols) == 0 {
+		for symbol, pair := range binancePairs {
+			pairToSymbol[pair] = symbol
+		}
+	} else {
+		for _, symbol := range request.Symbols {
+			symbol = referenceSymbol(symbol)
+			if pair, ok := binancePairs[symbol]; ok {
+				pairToSymbol[pair] = symbol
+			}
+		}
+	}
+	if len(pairToSymbol) == 0 {
+		return []types.TokenPrice{}, nil
+	}
+
+	pairs := make([]string, 0, len(pairToSymbol))
+	for pair := range pairToSymbol {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	pairsJSON, _ := json.Marshal(pairs)
+	reqURL := fmt.Sprintf("%s/api/v3/ticker/24hr?symbols=%s", s.baseURL, url.QueryEscape(string(pairsJSON)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Binance prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Binance API returned status %d", resp.StatusCode),
+			"BINANCE_API_ERROR",
+			fmt.Errorf("non-200 status code"))
+	}
+
+	var tickers []binanceTicker
+	if err := json.NewDecoder(resp.Body).Decode(&tickers); err != nil {
+		return nil, fmt.Errorf("invalid Binance response: %w", err)
+	}
+
+	prices := make([]types.TokenPrice, 0, len(tickers))
+	for _, ticker := range tickers {
+		symbol, ok := pairToSymbol[ticker.Symbol]
+		if !ok {
+			continue
+		}
+		price, err := strconv.ParseFloat(ticker.LastPrice, 64)
+		if err != nil || price <= 0 {
+			logger.Warn("Skipping invalid Binance price", "pair", ticker.Symbol, "price", ticker.LastPrice)
+			continue
+		}
+		change, _ := strconv.ParseFloat(ticker.PriceChangePercent, 64)
+		volume, _ := strconv.ParseFloat(ticker.QuoteVolume, 64)
+
+		prices = append(prices, types.TokenPrice{
+			Symbol:      symbol,
+			PriceUSD:    price,
+			Change24h:   change,
+			Volume24h:   volume,
+			LastUpdated: time.Now(),
+			Source:      types.PriceSourceBinance,
+		})
+	}
+
+	logger.Info("Fetched Binance reference prices", "count", len(prices))
+	return prices, nil
+}
+
+// referenceSymbol maps a token symbol to the symbol its reference price is listed under,
+// so wrapped tokens are checked against their underlying asset
+func referenceSymbol(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	switch symbol {
+	case "WETH", "UETH":
+		return "ETH"
+	case "WBTC", "UBTC":
+		return "BTC"
+	case "WSOL", "USOL":
+		return "SOL"
+	case "WMATIC", "UMATIC", "POL":
+		return "MATIC"
+	case "WAVAX", "UAVAX":
+		return "AVAX"
+	case "WBNB", "UBNB":
+		return "BNB"
+	case "UUSDC":
+		return "USDC"
+	}
+	return symbol
+}
@@ -0,0 +1,160 @@
+package temporal_activities
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestBinancePriceSource(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("symbols")), &requested); err != nil {
+			t.Errorf("Invalid symbols param: %v", err)
+		}
+		json.NewEncoder(w).Encode([]binanceTicker{
+			{Symbol: "ETHUSDT", LastPrice: "3000.50", PriceChangePercent: "1.5", QuoteVolume: "1000000"},
+			{Symbol: "POLUSDT", LastPrice: "0.25"},
+		})
+	}))
+	defer server.Close()
+
+	source := NewBinancePriceSource(server.Client())
+	source.baseURL = server.URL
+	registry := NewPriceSourceRegistry()
+	registry.MustRegister(source)
+	activities := NewPriceActivitiesWithSources(nil, t.TempDir(), registry)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.FetchPricesActivity)
+
+	value, err := env.ExecuteActivity(activities.FetchPricesActivity, string(types.PriceSourceBinance), types.PriceFetchRequest{
+		Symbols: []string{"WETH", "MATIC", "UNKNOWN"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var prices []types.TokenPrice
+	if err := value.Get(&prices); err != nil {
+		t.Fatalf("Failed to decode prices: %v", err)
+	}
+
+	// Unknown symbols are never sent, since Binance rejects the whole request for them
+	if len(requested) != 2 || requested[0] != "ETHUSDT" || requested[1] != "POLUSDT" {
+		t.Errorf("Unexpected pairs requested: %v", requested)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("Expected 2 prices, got %+v", prices)
+	}
+	if prices[0].Symbol != "ETH" || prices[0].PriceUSD != 3000.50 || prices[0].Change24h != 1.5 || prices[0].Source != types.PriceSourceBinance {
+		t.Errorf("Unexpected ETH price: %+v", prices[0])
+	}
+	if prices[1].Symbol != "MATIC" || prices[1].PriceUSD != 0.25 {
+		t.Errorf("Unexpected MATIC price: %+v", prices[1])
+	}
+}
+
+func TestMergePricesSanityBand(t *testing.T) {
+	merged := []types.TokenPrice{
+		{Symbol: "ETH", ChainID: 1, PriceUSD: 3050, Source: types.PriceSourceCoinGecko, IsVerified: true},
+		{Symbol: "WBTC", ChainID: 1, PriceUSD: 45000, Source: types.PriceSourceCoinGecko, IsVerified: true},
+		{Symbol: "BONK", ChainID: types.ChainIDSolana, PriceUSD: 0.00002, Source: types.PriceSourceJupiter},
+	}
+	reference := []types.TokenPrice{
+		{Symbol: "ETH", PriceUSD: 3000, Source: types.PriceSourceBinance},
+		{Symbol: "BTC", PriceUSD: 60000, Source: types.PriceSourceBinance},
+	}
+
+	merge := func(t *testing.T, band SanityBand) []types.TokenPrice {
+		activities := NewPriceActivitiesWithSources(nil, t.TempDir(), DefaultPriceSources(nil, nil))
+		activities.SetSanityBand(band)
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.MergePricesActivity)
+
+		input := [][]types.TokenPrice{append([]types.TokenPrice(nil), merged...), reference}
+		value, err := env.ExecuteActivity(activities.MergePricesActivity, input)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var prices []types.TokenPrice
+		if err := value.Get(&prices); err != nil {
+			t.Fatalf("Failed to decode prices: %v", err)
+		}
+		return prices
+	}
+
+	t.Run("Drop", func(t *testing.T) {
+		prices := merge(t, SanityBand{MaxDeviationPct: 5, Drop: true})
+
+		// WBTC is 25% under the BTC reference; BONK has no reference and passes through
+		if len(prices) != 2 || prices[0].Symbol != "ETH" || prices[1].Symbol != "BONK" {
+			t.Fatalf("Unexpected prices: %+v", prices)
+		}
+		for _, price := range prices {
+			if price.Source == types.PriceSourceBinance {
+				t.Errorf("Expected reference prices not to be merged, got %+v", price)
+			}
+		}
+		if deviation := prices[0].ReferenceDeviation; deviation < 1.66 || deviation > 1.67 {
+			t.Errorf("Expected ETH deviation of ~1.67%%, got %f", deviation)
+		}
+	})
+
+	t.Run("Flag", func(t *testing.T) {
+		prices := merge(t, SanityBand{MaxDeviationPct: 5})
+		if len(prices) != 3 {
+			t.Fatalf("Expected 3 prices, got %+v", prices)
+		}
+		if prices[0].SanityFlagged || !prices[1].SanityFlagged || prices[1].IsVerified {
+			t.Errorf("Expected only WBTC to be flagged and unverified, got %+v", prices)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		prices := merge(t, SanityBand{})
+		if len(prices) != 3 || prices[1].SanityFlagged {
+			t.Errorf("Expected every price to pass with the band disabled, got %+v", prices)
+		}
+	})
+}
+
+func TestMergePricesBandsEachSource(t *testing.T) {
+	// CoinGecko outranks Chainlink but is the outlier; Binance is down, so OKX is the reference
+	input := func() [][]types.TokenPrice {
+		return [][]types.TokenPrice{
+			{{Symbol: "ETH", ChainID: 1, PriceUSD: 4000, Source: types.PriceSourceCoinGecko}},
+			{{Symbol: "ETH", ChainID: 1, PriceUSD: 3010, Source: types.PriceSourceChainlink}},
+			{{Symbol: "ETH", PriceUSD: 3000, Source: types.PriceSourceOKX}},
+		}
+	}
+
+	for _, band := range []SanityBand{{MaxDeviationPct: 5, Drop: true}, {MaxDeviationPct: 5}} {
+		registry := DefaultPriceSources(nil, nil)
+		registry.MustRegister(NewChainlinkPriceSource(nil, nil, nil))
+		activities := NewPriceActivitiesWithSources(nil, t.TempDir(), registry)
+		activities.SetSanityBand(band)
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.MergePricesActivity)
+
+		value, err := env.ExecuteActivity(activities.MergePricesActivity, input())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var prices []types.TokenPrice
+		if err := value.Get(&prices); err != nil {
+			t.Fatalf("Failed to decode prices: %v", err)
+		}
+		if len(prices) != 1 || prices[0].Source != types.PriceSourceChainlink || prices[0].SanityFlagged {
+			t.Errorf("Expected the in-band Chainlink price with drop=%v, got %+v", band.Drop, prices)
+		}
+	}
+}
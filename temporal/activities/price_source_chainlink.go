@@ -0,0 +1,199 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	// chainlinkLatestRoundData is the selector of latestRoundData()
+	chainlinkLatestRoundData = "0xfeaf968c"
+
+	// chainlinkDecimals is the selector of decimals()
+	chainlinkDecimals = "0x313ce567"
+
+	// chainlinkMaxAnswerAge skips answers older than the longest standard feed heartbeat, with some slack
+	chainlinkMaxAnswerAge = 25 * time.Hour
+)
+
+// ChainlinkFeed is a Chainlink USD aggregator contract for a token
+type ChainlinkFeed struct {
+	Symbol  string
+	ChainID int64
+	Address string
+}
+
+// ChainlinkPriceSource reads prices from Chainlink aggregator contracts over JSON-RPC
+type ChainlinkPriceSource struct {
+	rpc      *EVMRPCClient
+	feeds    []ChainlinkFeed
+	decimals map[string]int // map[chainID-address]decimals, fixed per aggregator
+	mu       sync.Mutex
+}
+
+// NewChainlinkPriceSource creates a Chainlink price source reading feeds through the RPC endpoints of their chain
+func NewChainlinkPriceSource(httpClient *http.Client, rpcURLs map[int64][]string, feeds []ChainlinkFeed) *ChainlinkPriceSource {
+	return &ChainlinkPriceSource{
+		rpc:      NewEVMRPCClient(httpClient, rpcURLs),
+		feeds:    feeds,
+		decimals: make(map[string]int),
+	}
+}
+
+// Name returns the source name
+func (s *ChainlinkPriceSource) Name() types.PriceSource {
+	return types.PriceSourceChainlink
+}
+
+// Priority ranks Chainlink first since its prices are what on-chain contracts settle against
+func (s *ChainlinkPriceSource) Priority() int {
+	return 0
+}
+
+// Fetch reads the latest round of every configured feed.
+// Feeds that fail or are stale are skipped; it only fails if no feed could be read.
+func (s *ChainlinkPriceSource) Fetch(ctx context.Context, request types.PriceFetchRequest) ([]types.TokenPrice, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Fetching Chainlink feed prices", "feeds", len(s.feeds))
+
+	var prices []types.TokenPrice
+	var lastErr error
+	for _, feed := range s.feeds {
+		if !chainRequested(request.ChainIDs, feed.ChainID) || !symbolRequested(request.Symbols, feed.Symbol) {
+			continue
+		}
+
+		price, err := s.fetchFeed(ctx, feed)
+		if err != nil {
+			logger.Warn("Failed to read Chainlink feed", "symbol", feed.Symbol, "chainId", feed.ChainID, "error", err)
+			lastErr = err
+			continue
+		}
+		if time.Since(price.LastUpdated) > chainlinkMaxAnswerAge {
+			logger.Warn("Skipping stale Chainlink answer", "symbol", feed.Symbol, "chainId", feed.ChainID, "updatedAt", price.LastUpdated)
+			continue
+		}
+		prices = append(prices, price)
+	}
+
+	if len(prices) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to read any Chainlink feed: %w", lastErr)
+	}
+
+	logger.Info("Fetched Chainlink prices", "count", len(prices))
+	return prices, nil
+}
+
+// fetchFeed reads the latest answer of a single feed
+func (s *ChainlinkPriceSource) fetchFeed(ctx context.Context, feed ChainlinkFeed) (types.TokenPrice, error) {
+	decimals, err := s.feedDecimals(ctx, feed)
+	if err != nil {
+		return types.TokenPrice{}, err
+	}
+
+	result, err := s.call(ctx, feed, chainlinkLatestRoundData)
+	if err != nil {
+		return types.TokenPrice{}, err
+	}
+	// latestRoundData returns (uint80 roundId, int256 answer, uint256 startedAt, uint256 updatedAt, uint80 answeredInRound)
+	if len(result) < 5*32 {
+		return types.TokenPrice{}, fmt.Errorf("short latestRoundData result: %d bytes", len(result))
+	}
+
+	answer := new(big.Int).SetBytes(result[32:64])
+	if result[32]&0x80 != 0 {
+		return types.TokenPrice{}, errors.New("negative answer")
+	}
+	if answer.Sign() == 0 {
+		return types.TokenPrice{}, errors.New("zero answer")
+	}
+	updatedAt := new(big.Int).SetBytes(result[96:128])
+
+	priceUSD := types.NewAmount(answer, decimals).Float64()
+
+	chainName := ""
+	if chain, ok := types.GetChain(feed.ChainID); ok {
+		chainName = chain.Name
+	}
+
+	return types.TokenPrice{
+		Symbol:      strings.ToUpper(feed.Symbol),
+		Name:        strings.ToUpper(feed.Symbol),
+		ChainID:     feed.ChainID,
+		ChainName:   chainName,
+		PriceUSD:    priceUSD,
+		LastUpdated: time.Unix(updatedAt.Int64(), 0).UTC(),
+		Source:      types.PriceSourceChainlink,
+		IsVerified:  true,
+	}, nil
+}
+
+// feedDecimals returns the decimals of a feed's answers, reading them once per aggregator
+func (s *ChainlinkPriceSource) feedDecimals(ctx context.Context, feed ChainlinkFeed) (int, error) {
+	key := types.GetPriceKey(strings.ToLower(feed.Address), feed.ChainID)
+
+	s.mu.Lock()
+	decimals, ok := s.decimals[key]
+	s.mu.Unlock()
+	if ok {
+		return decimals, nil
+	}
+
+	result, err := s.call(ctx, feed, chainlinkDecimals)
+	if err != nil {
+		return 0, err
+	}
+	if len(result) < 32 {
+		return 0, fmt.Errorf("short decimals result: %d bytes", len(result))
+	}
+	decimals = int(new(big.Int).SetBytes(result[:32]).Int64())
+	if decimals > 36 {
+		return 0, fmt.Errorf("unexpected feed decimals: %d", decimals)
+	}
+
+	s.mu.Lock()
+	s.decimals[key] = decimals
+	s.mu.Unlock()
+
+	return decimals, nil
+}
+
+// call runs eth_call against the feed contract
+func (s *ChainlinkPriceSource) call(ctx context.Context, feed ChainlinkFeed, data string) ([]byte, error) {
+	return s.rpc.Call(ctx, feed.ChainID, "", feed.Address, data)
+}
+
+// chainRequested reports whether chainID is in chainIDs; an empty list requests every chain
+func chainRequested(chainIDs []int64, chainID int64) bool {
+	if len(chainIDs) == 0 {
+		return true
+	}
+	for _, id := range chainIDs {
+		if types.CanonicalChainID(id) == types.CanonicalChainID(chainID) {
+			return true
+		}
+	}
+	return false
+}
+
+// symbolRequested reports whether symbol is in symbols, ignoring case; an empty list requests every symbol
+func symbolRequested(symbols []string, symbol string) bool {
+	if len(symbols) == 0 {
+		return true
+	}
+	for _, s := range symbols {
+		if strings.EqualFold(s, symbol) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package temporal_activities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// word encodes v as a 32-byte ABI word
+func word(v *big.Int) string {
+	return fmt.Sprintf("%064x", v)
+}
+
+// fakeChainlinkRPC serves eth_call for aggregators keyed by lowercase address
+func fakeChainlinkRPC(t *testing.T, answers map[string]int64, updatedAt time.Time) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Invalid RPC request: %v", err)
+			return
+		}
+		var call struct {
+			To   string `json:"to"`
+			Data string `json:"data"`
+		}
+		json.Unmarshal(req.Params[0], &call)
+
+		answer, ok := answers[strings.ToLower(call.To)]
+		if !ok {
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": -32000, "message": "execution reverted"}})
+			return
+		}
+
+		var result string
+		switch call.Data {
+		case chainlinkDecimals:
+			result = word(big.NewInt(8))
+		case chainlinkLatestRoundData:
+			result = word(big.NewInt(1)) + word(big.NewInt(answer)) + word(big.NewInt(updatedAt.Unix())) +
+				word(big.NewInt(updatedAt.Unix())) + word(big.NewInt(1))
+		}
+		json.NewEncoder(w).Encode(map[string]string{"result": "0x" + result})
+	}))
+}
+
+func TestChainlinkPriceSource(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	server := fakeChainlinkRPC(t, map[string]int64{"0xeth": 300012345678}, now)
+	defer server.Close()
+
+	registry := NewPriceSourceRegistry()
+	registry.MustRegister(NewChainlinkPriceSource(server.Client(), map[int64][]string{1: {server.URL}}, []ChainlinkFeed{
+		{Symbol: "ETH", ChainID: 1, Address: "0xETH"},
+		{Symbol: "DAI", ChainID: 1, Address: "0xmissing"},
+		{Symbol: "MATIC", ChainID: 137, Address: "0xmatic"},
+	}))
+	activities := NewPriceActivitiesWithSources(nil, t.TempDir(), registry)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.FetchPricesActivity)
+
+	t.Run("Fetch", func(t *testing.T) {
+		value, err := env.ExecuteActivity(activities.FetchPricesActivity, string(types.PriceSourceChainlink), types.PriceFetchRequest{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var prices []types.TokenPrice
+		if err := value.Get(&prices); err != nil {
+			t.Fatalf("Failed to decode prices: %v", err)
+		}
+
+		// The reverted DAI feed and the MATIC feed without an RPC endpoint are skipped
+		if len(prices) != 1 {
+			t.Fatalf("Expected 1 price, got %+v", prices)
+		}
+		if prices[0].Symbol != "ETH" || prices[0].PriceUSD != 3000.12345678 || prices[0].Source != types.PriceSourceChainlink {
+			t.Errorf("Unexpected price: %+v", prices[0])
+		}
+		if !prices[0].LastUpdated.Equal(now) {
+			t.Errorf("Expected LastUpdated %v, got %v", now, prices[0].LastUpdated)
+		}
+	})
+
+	t.Run("AllFeedsFail", func(t *testing.T) {
+		if _, err := env.ExecuteActivity(activities.FetchPricesActivity, string(types.PriceSourceChainlink), types.PriceFetchRequest{Symbols: []string{"DAI"}}); err == nil {
+			t.Error("Expected error when no feed can be read, got nil")
+		}
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		stale := fakeChainlinkRPC(t, map[string]int64{"0xeth": 300000000000}, now.Add(-2*chainlinkMaxAnswerAge))
+		defer stale.Close()
+
+		source := NewChainlinkPriceSource(stale.Client(), map[int64][]string{1: {stale.URL}}, []ChainlinkFeed{{Symbol: "ETH", ChainID: 1, Address: "0xeth"}})
+		registry := NewPriceSourceRegistry()
+		registry.MustRegister(source)
+		staleActivities := NewPriceActivitiesWithSources(nil, t.TempDir(), registry)
+		staleEnv := suite.NewTestActivityEnvironment()
+		staleEnv.RegisterActivity(staleActivities.FetchPricesActivity)
+
+		value, err := staleEnv.ExecuteActivity(staleActivities.FetchPricesActivity, string(types.PriceSourceChainlink), types.PriceFetchRequest{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var prices []types.TokenPrice
+		value.Get(&prices)
+		if len(prices) != 0 {
+			t.Errorf("Expected stale answer to be skipped, got %+v", prices)
+		}
+	})
+}
@@ -0,0 +1,316 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	// coinGeckoAPIURL is the public and demo plan API base URL
+	coinGeckoAPIURL = "https://api.coingecko.com/api/v3"
+	// coinGeckoProAPIURL is the pro plan API base URL
+	coinGeckoProAPIURL = "https://pro-api.coingecko.com/api/v3"
+
+	// coinGeckoBatchSize is the most coin IDs requested at once; longer ID lists are truncated by CoinGecko
+	coinGeckoBatchSize = 100
+	// coinGeckoPerPage is the page size of the markets endpoint. It exceeds coinGeckoBatchSize, so a batch's
+	// markets always fit on one page.
+	coinGeckoPerPage = 250
+)
+
+// CoinGecko API plans, which select the API host and key header
+const (
+	CoinGeckoPlanDemo = "demo"
+	CoinGeckoPlanPro  = "pro"
+)
+
+// CoinGeckoPriceSource fetches market prices from the CoinGecko API
+type CoinGeckoPriceSource struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	keyHeader  string
+	retryDelay time.Duration // Wait before retrying after a 429 without Retry-After
+}
+
+// NewCoinGeckoPriceSource creates a new CoinGecko price source using the keyless public API
+func NewCoinGeckoPriceSource(httpClient *http.Client) *CoinGeckoPriceSource {
+	return &CoinGeckoPriceSource{
+		httpClient: httpClient,
+		baseURL:    coinGeckoAPIURL,
+		retryDelay: 10 * time.Second,
+	}
+}
+
+// SetAPIKey sets the API key of a demo or pro plan; an empty key keeps using the public API
+func (s *CoinGeckoPriceSource) SetAPIKey(plan, apiKey string) error {
+	switch {
+	case apiKey == "":
+		s.baseURL, s.apiKey, s.keyHeader = coinGeckoAPIURL, "", ""
+	case plan == CoinGeckoPlanPro:
+		s.baseURL, s.apiKey, s.keyHeader = coinGeckoProAPIURL, apiKey, "x-cg-pro-api-key"
+	case plan == CoinGeckoPlanDemo || plan == "":
+		s.baseURL, s.apiKey, s.keyHeader = coinGeckoAPIURL, apiKey, "x-cg-demo-api-key"
+	default:
+		return fmt.Errorf("unknown CoinGecko plan %q", plan)
+	}
+	return nil
+}
+
+// SetBaseURL calls a mirror of the CoinGecko API, including its /api/v3 path, instead of the plan's API. Call it
+// after SetAPIKey, which picks the plan's API.
+func (s *CoinGeckoPriceSource) SetBaseURL(baseURL string) {
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// Name returns the source name
+func (s *CoinGeckoPriceSource) Name() types.PriceSource {
+	return types.PriceSourceCoinGecko
+}
+
+// Priority ranks CoinGecko first since it has the broadest market data
+func (s *CoinGeckoPriceSource) Priority() int {
+	return 1
+}
+
+// coinGeckoMarket is an entry of the CoinGecko markets response
+type coinGeckoMarket struct {
+	ID           string  `json:"id"`
+	Symbol       string  `json:"symbol"`
+	Name         string  `json:"name"`
+	CurrentPrice float64 `json:"current_price"`
+	MarketCap    float64 `json:"market_cap"`
+	TotalVolume  float64 `json:"total_volume"`
+	Change24h    float64 `json:"price_change_percentage_24h"`
+}
+
+// Fetch fetches token prices from CoinGecko, in batches of at most coinGeckoBatchSize coin IDs
+func (s *CoinGeckoPriceSource) Fetch(ctx context.Context, request types.PriceFetchRequest) ([]types.TokenPrice, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Fetching CoinGecko token prices", "symbols", request.Symbols)
+
+	// Map of common symbols to CoinGecko IDs
+	symbolToID := map[string]string{
+		"eth":   "ethereum",
+		"btc":   "bitcoin",
+		"sol":   "solana",
+		"usdc":  "usd-coin",
+		"usdt":  "tether",
+		"dai":   "dai",
+		"matic": "matic-network",
+		"avax":  "avalanche-2",
+		"bonk":  "bonk",
+		"jup":   "jupiter",
+		"ray":   "raydium",
+	}
+
+	// Convert symbols to CoinGecko IDs
+	var coinGeckoIds []string
+	for _, symbol := range request.Symbols {
+		symbol = strings.ToLower(symbol)
+		if id, ok := symbolToID[symbol]; ok {
+			coinGeckoIds = append(coinGeckoIds, id)
+		} else {
+			coinGeckoIds = append(coinGeckoIds, symbol)
+		}
+	}
+
+	// If no symbols specified, use a default list
+	if len(coinGeckoIds) == 0 {
+		coinGeckoIds = []string{
+			"ethereum", "bitcoin", "solana", "usd-coin", "tether",
+			"dai", "matic-network", "avalanche-2", "bonk", "jupiter",
+		}
+	}
+
+	// Fetch the markets batch by batch
+	var markets []coinGeckoMarket
+	for start := 0; start < len(coinGeckoIds); start += coinGeckoBatchSize {
+		end := start + coinGeckoBatchSize
+		if end > len(coinGeckoIds) {
+			end = len(coinGeckoIds)
+		}
+		batch, err := s.fetchMarkets(ctx, coinGeckoIds[start:end])
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, batch...)
+	}
+
+	// Convert to our token price format
+	var prices []types.TokenPrice
+	for _, coin := range markets {
+		symbol := coin.Symbol
+
+		// Determine chain ID based on symbol (simplified)
+		var chainID int64
+		var chainName string
+		switch strings.ToLower(symbol) {
+		case "eth":
+			chainID = types.ChainIDEthereum
+			chainName = "Ethereum"
+		case "matic":
+			chainID = types.ChainIDPolygon
+			chainName = "Polygon"
+		case "avax":
+			chainID = types.ChainIDAvalanche
+			chainName = "Avalanche"
+		case "sol", "bonk", "jup", "ray":
+			chainID = types.ChainIDSolana
+			chainName = "Solana"
+		default:
+			chainID = types.ChainIDEthereum // Default to Ethereum
+			chainName = "Ethereum"
+		}
+
+		// Add to prices list
+		prices = append(prices, types.TokenPrice{
+			Symbol:       symbol,
+			Name:         coin.Name,
+			ChainID:      chainID,
+			ChainName:    chainName,
+			PriceUSD:     coin.CurrentPrice,
+			Change24h:    coin.Change24h,
+			Volume24h:    coin.TotalVolume,
+			MarketCapUSD: coin.MarketCap,
+			LastUpdated:  time.Now(),
+			Source:       types.PriceSourceCoinGecko,
+			IsVerified:   true,
+		})
+	}
+
+	logger.Info("Fetched CoinGecko token prices", "count", len(prices))
+	return prices, nil
+}
+
+// fetchMarkets fetches the markets of a batch of coin IDs
+func (s *CoinGeckoPriceSource) fetchMarkets(ctx context.Context, ids []string) ([]coinGeckoMarket, error) {
+	query := url.Values{}
+	query.Set("vs_currency", "usd")
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("order", "market_cap_desc")
+	query.Set("per_page", strconv.Itoa(coinGeckoPerPage))
+	query.Set("sparkline", "false")
+	query.Set("price_change_percentage", "24h")
+
+	body, err := s.getRaw(ctx, s.baseURL+"/coins/markets?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	markets, err := decodeVersioned(ctx, types.PriceSourceCoinGecko, "markets", body, coinGeckoMarketDecoders)
+	if errors.Is(err, ErrUnrecognizedPriceFormat) {
+		// The batch has no prices; the other batches and sources still do
+		return nil, nil
+	}
+	return markets, err
+}
+
+// coinGeckoMarketDecoders decode the v3 markets response, a list of markets
+var coinGeckoMarketDecoders = []priceDecoder[[]coinGeckoMarket]{
+	{
+		version: "v3",
+		probe: func(raw json.RawMessage) bool {
+			first := firstElement(raw)
+			return jsonKind(raw) == '[' && (first == nil || hasField(first, "current_price"))
+		},
+		decode: func(raw json.RawMessage) ([]coinGeckoMarket, int, error) {
+			var entries []json.RawMessage
+			if err := json.Unmarshal(raw, &entries); err != nil {
+				return nil, 0, err
+			}
+
+			markets := make([]coinGeckoMarket, 0, len(entries))
+			skipped := 0
+			for _, entry := range entries {
+				var market coinGeckoMarket
+				if err := json.Unmarshal(entry, &market); err != nil || market.Symbol == "" {
+					skipped++
+					continue
+				}
+				markets = append(markets, market)
+			}
+			return markets, skipped, nil
+		},
+	},
+}
+
+// get sends a GET request to the CoinGecko API and decodes the response into out
+func (s *CoinGeckoPriceSource) get(ctx context.Context, requestURL string, out interface{}) error {
+	body, err := s.getRaw(ctx, requestURL)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// getRaw sends a GET request to the CoinGecko API and returns the response body.
+// A rate-limited request fails with a retryable error asking the activity to be retried once Retry-After has passed,
+// so the wait doesn't count against the activity's timeout.
+func (s *CoinGeckoPriceSource) getRaw(ctx context.Context, requestURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set(s.keyHeader, s.apiKey)
+	}
+
+	// Make request to CoinGecko API
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"Failed to fetch CoinGecko prices",
+			"COINGECKO_API_ERROR",
+			err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryAfter(resp.Header.Get("Retry-After"), s.retryDelay)
+		activity.GetLogger(ctx).Warn("CoinGecko rate limited, retrying the activity later", "wait", wait)
+		return nil, temporal.NewApplicationErrorWithOptions(
+			"CoinGecko API rate limit exceeded",
+			"COINGECKO_RATE_LIMITED",
+			temporal.ApplicationErrorOptions{NextRetryDelay: wait})
+	}
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("CoinGecko API returned status %d", resp.StatusCode),
+			"COINGECKO_API_ERROR",
+			errors.New("non-200 status code"))
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// retryAfter returns the wait asked for by a Retry-After header, in seconds or as an HTTP date, or fallback without one
+func retryAfter(header string, fallback time.Duration) time.Duration {
+	if header == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return fallback
+}
@@ -0,0 +1,167 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestCoinGeckoPriceSource(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		batches     [][]string
+		rateLimited int // Requests left to answer with 429
+		apiKey      string
+		body        string // Answered instead of the markets when set
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		apiKey = r.Header.Get("x-cg-pro-api-key")
+		if rateLimited > 0 {
+			rateLimited--
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		ids := strings.Split(r.URL.Query().Get("ids"), ",")
+		batches = append(batches, ids)
+		if body != "" {
+			w.Write([]byte(body))
+			return
+		}
+
+		var markets []coinGeckoMarket
+		for _, id := range ids {
+			markets = append(markets, coinGeckoMarket{ID: id, Symbol: id, Name: id, CurrentPrice: 1})
+		}
+		json.NewEncoder(w).Encode(markets)
+	}))
+	defer server.Close()
+
+	source := NewCoinGeckoPriceSource(server.Client())
+	if err := source.SetAPIKey("enterprise", "key"); err == nil {
+		t.Error("Expected an unknown plan to be refused")
+	}
+	if err := source.SetAPIKey(CoinGeckoPlanPro, "pro-key"); err != nil || source.baseURL != coinGeckoProAPIURL {
+		t.Fatalf("Expected the pro API, got %s (%v)", source.baseURL, err)
+	}
+	source.baseURL = server.URL
+	registry := NewPriceSourceRegistry()
+	registry.MustRegister(source)
+	var alerts []PriceFormatAlert
+	registry.SetFormatAlertHandler(func(ctx context.Context, alert PriceFormatAlert) {
+		alerts = append(alerts, alert)
+	})
+	activities := NewPriceActivitiesWithSources(nil, t.TempDir(), registry)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.FetchPricesActivity)
+
+	fetch := func(symbols []string) ([]types.TokenPrice, error) {
+		value, err := env.ExecuteActivity(activities.FetchPricesActivity, string(types.PriceSourceCoinGecko), types.PriceFetchRequest{Symbols: symbols})
+		if err != nil {
+			return nil, err
+		}
+		var prices []types.TokenPrice
+		return prices, value.Get(&prices)
+	}
+
+	t.Run("Batches", func(t *testing.T) {
+		batches = nil
+		symbols := make([]string, 250)
+		for i := range symbols {
+			symbols[i] = fmt.Sprintf("tkn%d", i)
+		}
+
+		prices, err := fetch(symbols)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(prices) != len(symbols) {
+			t.Errorf("Expected %d prices, got %d", len(symbols), len(prices))
+		}
+		if len(batches) != 3 || len(batches[0]) != coinGeckoBatchSize || len(batches[2]) != 50 {
+			t.Errorf("Expected batches of 100, 100 and 50 IDs, got %d batches", len(batches))
+		}
+		if apiKey != "pro-key" {
+			t.Errorf("Expected the pro API key header, got %q", apiKey)
+		}
+	})
+
+	t.Run("RateLimitedRetriesAfterRetryAfter", func(t *testing.T) {
+		rateLimited = 1
+		_, err := fetch([]string{"ETH"})
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.NonRetryable() {
+			t.Fatalf("Expected a retryable application error, got %v", err)
+		}
+		if appErr.Type() != "COINGECKO_RATE_LIMITED" || appErr.NextRetryDelay() != 7*time.Second {
+			t.Errorf("Expected a retry after 7s, got %s after %v", appErr.Type(), appErr.NextRetryDelay())
+		}
+
+		prices, err := fetch([]string{"ETH"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(prices) != 1 || prices[0].Symbol != "ethereum" {
+			t.Errorf("Unexpected prices: %+v", prices)
+		}
+	})
+
+	t.Run("Formats", func(t *testing.T) {
+		defer func() { body = "" }()
+
+		// Unknown fields are ignored and undecodable entries skipped
+		body = `[{"id":"ethereum","symbol":"eth","current_price":3000,"ath":4800},{"id":"bad","symbol":"bad","current_price":"n/a"}]`
+		prices, err := fetch([]string{"ETH", "BAD"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(prices) != 1 || prices[0].PriceUSD != 3000 || len(alerts) != 0 {
+			t.Errorf("Expected the decodable entry without alerts, got %+v and %d alerts", prices, len(alerts))
+		}
+
+		// An unrecognized format yields no prices and an alert instead of failing the fetch
+		body = `{"data":{"ethereum":{"usd":3000}}}`
+		prices, err = fetch([]string{"ETH"})
+		if err != nil {
+			t.Fatalf("Expected an unrecognized format not to fail the fetch, got %v", err)
+		}
+		if len(prices) != 0 {
+			t.Errorf("Expected no prices, got %+v", prices)
+		}
+		if len(alerts) != 1 || alerts[0].Source != types.PriceSourceCoinGecko || alerts[0].Endpoint != "markets" || alerts[0].Sample != body {
+			t.Errorf("Expected a markets format alert, got %+v", alerts)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	if wait := retryAfter("", time.Second); wait != time.Second {
+		t.Errorf("Expected the fallback without Retry-After, got %v", wait)
+	}
+	if wait := retryAfter("3", time.Second); wait != 3*time.Second {
+		t.Errorf("Expected 3s, got %v", wait)
+	}
+	if wait := retryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), time.Second); wait != 0 {
+		t.Errorf("Expected no wait for a past date, got %v", wait)
+	}
+	if wait := retryAfter("soon", time.Second); wait != time.Second {
+		t.Errorf("Expected the fallback for an invalid Retry-After, got %v", wait)
+	}
+}
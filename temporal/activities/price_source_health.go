@@ -0,0 +1,82 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// PingablePriceSource is a price source that can check it is reachable without fetching prices
+type PingablePriceSource interface {
+	PriceSource
+
+	// Ping checks the source's API answers
+	Ping(ctx context.Context) error
+}
+
+// Ping checks the CoinGecko API answers, with the configured API key
+func (s *CoinGeckoPriceSource) Ping(ctx context.Context) error {
+	header := http.Header{}
+	if s.apiKey != "" {
+		header.Set(s.keyHeader, s.apiKey)
+	}
+	return pingPriceAPI(ctx, s.httpClient, s.baseURL+"/ping", header)
+}
+
+// Ping checks the Binance API answers
+func (s *BinancePriceSource) Ping(ctx context.Context) error {
+	return pingPriceAPI(ctx, s.httpClient, s.baseURL+"/api/v3/ping", nil)
+}
+
+// Ping checks the OKX API answers
+func (s *OKXPriceSource) Ping(ctx context.Context) error {
+	return pingPriceAPI(ctx, s.httpClient, s.baseURL+"/api/v5/public/time", nil)
+}
+
+// pingPriceAPI sends a GET to a price API's health endpoint, failing unless it answers 200
+func pingPriceAPI(ctx context.Context, httpClient *http.Client, requestURL string, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// PingAny pings the sources that can be pinged in priority order and returns the first that answers. Without one it
+// returns the error of every source pinged.
+func (r *PriceSourceRegistry) PingAny(ctx context.Context) (types.PriceSource, error) {
+	var errs []error
+	for _, source := range r.Sources() {
+		pingable, ok := source.(PingablePriceSource)
+		if !ok {
+			continue
+		}
+		err := pingable.Ping(ctx)
+		if err == nil {
+			return source.Name(), nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no price source can be pinged")
+	}
+	return "", errors.Join(errs...)
+}
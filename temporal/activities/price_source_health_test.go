@@ -0,0 +1,59 @@
+package temporal_activities
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestPriceSourceRegistryPingAny(t *testing.T) {
+	var pinged []string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pinged = append(pinged, r.URL.Path)
+		w.Write([]byte("{}"))
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pinged = append(pinged, r.URL.Path)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	coinGecko := NewCoinGeckoPriceSource(down.Client())
+	coinGecko.baseURL = down.URL
+	binance := NewBinancePriceSource(up.Client())
+	binance.baseURL = up.URL
+	okx := NewOKXPriceSource(up.Client())
+	okx.baseURL = up.URL
+
+	registry := NewPriceSourceRegistry()
+	registry.MustRegister(&stubPriceSource{name: types.PriceSourceUniversal, priority: 0})
+	registry.MustRegister(coinGecko)
+	registry.MustRegister(binance)
+	registry.MustRegister(okx)
+
+	// Sources without a ping are skipped, and pinging stops at the first that answers
+	source, err := registry.PingAny(context.Background())
+	if err != nil || source != types.PriceSourceBinance {
+		t.Fatalf("PingAny = %q, %v; want binance", source, err)
+	}
+	if len(pinged) != 2 || pinged[0] != "/ping" || pinged[1] != "/api/v3/ping" {
+		t.Errorf("Pinged %v, want CoinGecko then Binance", pinged)
+	}
+
+	binance.baseURL = down.URL
+	okx.baseURL = down.URL
+	if _, err := registry.PingAny(context.Background()); err == nil || !strings.Contains(err.Error(), "okx: status 503") {
+		t.Errorf("PingAny with every source down = %v, want each source's error", err)
+	}
+
+	empty := NewPriceSourceRegistry()
+	empty.MustRegister(&stubPriceSource{name: types.PriceSourceUniversal})
+	if _, err := empty.PingAny(context.Background()); err == nil {
+		t.Error("PingAny without a pingable source succeeded")
+	}
+}
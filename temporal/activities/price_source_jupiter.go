@@ -0,0 +1,394 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// jupiterAPIURL is the Jupiter API base URL
+const jupiterAPIURL = "https://api.jup.ag"
+
+// JupiterPriceSource fetches prices of verified Solana tokens from the Jupiter API
+type JupiterPriceSource struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewJupiterPriceSource creates a new Jupiter price source
+func NewJupiterPriceSource(httpClient *http.Client) *JupiterPriceSource {
+	return &JupiterPriceSource{httpClient: httpClient, baseURL: jupiterAPIURL}
+}
+
+// SetBaseURL calls a mirror of the Jupiter API, such as a self-hosted one, instead of api.jup.ag
+func (s *JupiterPriceSource) SetBaseURL(baseURL string) {
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// Name returns the source name
+func (s *JupiterPriceSource) Name() types.PriceSource {
+	return types.PriceSourceJupiter
+}
+
+// Priority ranks Jupiter after CoinGecko and Universal; its volume data is merged regardless
+func (s *JupiterPriceSource) Priority() int {
+	return 3
+}
+
+// Fetch fetches token prices from the Jupiter API
+func (s *JupiterPriceSource) Fetch(ctx context.Context, request types.PriceFetchRequest) ([]types.TokenPrice, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Fetching Jupiter token prices")
+
+	// Step 1: Get the list of verified tokens from Jupiter
+	tokensURL := s.baseURL + jupiterTokenListPath
+	logger.Info("Fetching verified tokens from Jupiter API", "url", tokensURL)
+
+	// Make request to Jupiter tokens API
+	tokensResp, err := s.httpClient.Get(tokensURL)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"Failed to fetch Jupiter tokens",
+			"JUPITER_API_ERROR",
+			err)
+	}
+	defer tokensResp.Body.Close()
+
+	// Check response status
+	if tokensResp.StatusCode != http.StatusOK {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Jupiter tokens API returned status %d", tokensResp.StatusCode),
+			"JUPITER_API_ERROR",
+			errors.New("non-200 status code"))
+	}
+
+	// Parse tokens response
+	tokensBody, err := ioutil.ReadAll(tokensResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	jupiterTokens, err := decodeVersioned(ctx, types.PriceSourceJupiter, "tokens", tokensBody, jupiterTokenDecoders)
+	if errors.Is(err, ErrUnrecognizedPriceFormat) {
+		// Without the token list no price can be attributed to a token; the other sources carry the fetch
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Received Jupiter verified tokens", "count", len(jupiterTokens))
+
+	// Create a slice to store token information for sorting
+	type TokenInfo struct {
+		Address string
+		Symbol  string
+		Name    string
+		Volume  float64
+	}
+	var tokenInfoList []TokenInfo
+
+	// Extract token information
+	for _, token := range jupiterTokens {
+		tokenInfoList = append(tokenInfoList, TokenInfo{
+			Address: token.Address,
+			Symbol:  token.Symbol,
+			Name:    token.Name,
+			Volume:  token.Volume,
+		})
+	}
+
+	logger.Info("Extracted token information", "count", len(tokenInfoList))
+
+	// TODO: Cache or store these tokens in the database for future use
+	// This would reduce external IO in later calls
+
+	// Step 2: Sort tokens by daily volume (descending) and get top 50
+	sort.Slice(tokenInfoList, func(i, j int) bool {
+		return tokenInfoList[i].Volume > tokenInfoList[j].Volume
+	})
+
+	// Get top 50 tokens or all if less than 50
+	topTokenCount := 50
+	if len(tokenInfoList) < topTokenCount {
+		topTokenCount = len(tokenInfoList)
+	}
+	topTokens := tokenInfoList[:topTokenCount]
+
+	// Create a map for quick lookup of token info
+	tokenInfoMap := make(map[string]TokenInfo)
+	var tokenIds []string
+	for _, token := range topTokens {
+		tokenIds = append(tokenIds, token.Address)
+		tokenInfoMap[token.Address] = token
+	}
+
+	logger.Info("Selected top tokens by volume", "count", len(tokenIds))
+
+	// Log a few top tokens for debugging
+	for i, token := range topTokens {
+		if i < 5 {
+			logger.Info("Top token",
+				"rank", i+1,
+				"symbol", token.Symbol,
+				"name", token.Name,
+				"address", token.Address,
+				"volume", token.Volume)
+		} else {
+			break
+		}
+	}
+
+	// Step 3: Fetch prices for the top tokens using the Jupiter price API
+	// The API supports up to 100 IDs, but we're using 50 as specified
+	priceURL := fmt.Sprintf("%s/price/v2?ids=%s", s.baseURL, strings.Join(tokenIds, ","))
+	logger.Info("Fetching Jupiter prices from API", "url", priceURL, "token_count", len(tokenIds))
+
+	// Make request to Jupiter Price API
+	priceResp, err := s.httpClient.Get(priceURL)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"Failed to fetch Jupiter prices",
+			"JUPITER_API_ERROR",
+			err)
+	}
+	defer priceResp.Body.Close()
+
+	// Check response status
+	if priceResp.StatusCode != http.StatusOK {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Jupiter Price API returned status %d", priceResp.StatusCode),
+			"JUPITER_API_ERROR",
+			errors.New("non-200 status code"))
+	}
+
+	// Parse price response
+	priceBody, err := ioutil.ReadAll(priceResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	jupiterPriceResp, err := decodeVersioned(ctx, types.PriceSourceJupiter, "prices", priceBody, jupiterPriceDecoders)
+	if errors.Is(err, ErrUnrecognizedPriceFormat) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Received Jupiter price data", "count", len(jupiterPriceResp))
+
+	// Convert to our token price format
+	var prices []types.TokenPrice
+	var matchedCount, skippedCount int
+
+	for mint, quote := range jupiterPriceResp {
+		// Skip if we don't have token info for this mint
+		info, exists := tokenInfoMap[mint]
+		if !exists {
+			skippedCount++
+			continue
+		}
+
+		matchedCount++
+
+		// Add to prices list
+		prices = append(prices, types.TokenPrice{
+			Symbol:        info.Symbol,
+			Name:          info.Name,
+			Address:       mint,
+			ChainID:       types.ChainIDSolana,
+			ChainName:     "Solana",
+			PriceUSD:      quote.Price,
+			Change24h:     quote.Change24h, // Only reported by the v3 price API
+			LastUpdated:   time.Now(),
+			Source:        types.PriceSourceJupiter,
+			IsVerified:    true,
+			JupiterVolume: info.Volume,
+		})
+	}
+
+	logger.Info("Processed Jupiter token prices",
+		"total_prices", len(jupiterPriceResp),
+		"matched", matchedCount,
+		"skipped", skippedCount,
+		"final_count", len(prices))
+
+	// Log a few price entries for debugging
+	for i, price := range prices {
+		if i < 5 {
+			logger.Info("Price entry",
+				"symbol", price.Symbol,
+				"price_usd", price.PriceUSD,
+				"volume", price.JupiterVolume)
+		} else {
+			break
+		}
+	}
+
+	logger.Info("Fetched Jupiter token prices", "count", len(prices))
+	return prices, nil
+}
+
+// jupiterVerifiedToken is a verified token listed by the Jupiter tokens API
+type jupiterVerifiedToken struct {
+	Address string
+	Symbol  string
+	Name    string
+	Volume  float64 // Daily trading volume in USD
+}
+
+// jupiterTokenEntry is an entry of any version of the Jupiter tokens response
+type jupiterTokenEntry struct {
+	Address     string    `json:"address"` // v1
+	ID          string    `json:"id"`      // v2
+	Symbol      string    `json:"symbol"`
+	Name        string    `json:"name"`
+	DailyVolume flexFloat `json:"daily_volume"` // v1
+	Stats24h    struct {
+		BuyVolume  flexFloat `json:"buyVolume"`
+		SellVolume flexFloat `json:"sellVolume"`
+	} `json:"stats24h"` // v2
+}
+
+// jupiterTokenDecoders decode the Jupiter tokens response: v1 lists tokens by address with their daily volume,
+// v2 by ID with buy and sell volumes
+var jupiterTokenDecoders = []priceDecoder[[]jupiterVerifiedToken]{
+	{
+		version: "v1",
+		probe: func(raw json.RawMessage) bool {
+			first := firstElement(raw)
+			return jsonKind(raw) == '[' && (first == nil || hasField(first, "address"))
+		},
+		decode: decodeJupiterTokens,
+	},
+	{
+		version: "v2",
+		probe: func(raw json.RawMessage) bool {
+			return hasField(firstElement(raw), "id")
+		},
+		decode: decodeJupiterTokens,
+	},
+}
+
+// decodeJupiterTokens decodes a Jupiter tokens response, skipping entries without an address or symbol
+func decodeJupiterTokens(raw json.RawMessage) ([]jupiterVerifiedToken, int, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	tokens := make([]jupiterVerifiedToken, 0, len(entries))
+	skipped := 0
+	for _, rawEntry := range entries {
+		var entry jupiterTokenEntry
+		if err := json.Unmarshal(rawEntry, &entry); err != nil {
+			skipped++
+			continue
+		}
+		token := jupiterVerifiedToken{Address: entry.Address, Symbol: entry.Symbol, Name: entry.Name, Volume: entry.DailyVolume.Value}
+		if token.Address == "" {
+			token.Address = entry.ID
+			token.Volume = entry.Stats24h.BuyVolume.Value + entry.Stats24h.SellVolume.Value
+		}
+		if token.Address == "" || token.Symbol == "" {
+			skipped++
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, skipped, nil
+}
+
+// jupiterQuote is the price of a token from the Jupiter price API
+type jupiterQuote struct {
+	Price     float64
+	Change24h float64
+}
+
+// jupiterPriceDecoders decode the Jupiter price response by mint address: v2 nests string prices under data, v3
+// reports a usdPrice and 24h change per mint, and v1 maps mints straight to prices
+var jupiterPriceDecoders = []priceDecoder[map[string]jupiterQuote]{
+	{
+		version: "v2",
+		probe: func(raw json.RawMessage) bool {
+			return jsonKind(raw) == '{' && hasField(raw, "data")
+		},
+		decode: func(raw json.RawMessage) (map[string]jupiterQuote, int, error) {
+			var resp struct {
+				Data map[string]json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(raw, &resp); err != nil {
+				return nil, 0, err
+			}
+			return decodeJupiterQuotes(resp.Data, func(entry json.RawMessage) (jupiterQuote, bool) {
+				var price struct {
+					Price flexFloat `json:"price"`
+				}
+				ok := json.Unmarshal(entry, &price) == nil && price.Price.Set
+				return jupiterQuote{Price: price.Price.Value}, ok
+			})
+		},
+	},
+	{
+		version: "v3",
+		probe: func(raw json.RawMessage) bool {
+			return hasField(anyValue(raw), "usdPrice")
+		},
+		decode: func(raw json.RawMessage) (map[string]jupiterQuote, int, error) {
+			var entries map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &entries); err != nil {
+				return nil, 0, err
+			}
+			return decodeJupiterQuotes(entries, func(entry json.RawMessage) (jupiterQuote, bool) {
+				var price struct {
+					USDPrice       flexFloat `json:"usdPrice"`
+					PriceChange24h flexFloat `json:"priceChange24h"`
+				}
+				ok := json.Unmarshal(entry, &price) == nil && price.USDPrice.Set
+				return jupiterQuote{Price: price.USDPrice.Value, Change24h: price.PriceChange24h.Value}, ok
+			})
+		},
+	},
+	{
+		version: "v1",
+		probe: func(raw json.RawMessage) bool {
+			value := anyValue(raw)
+			return jsonKind(raw) == '{' && (value == nil || jsonKind(value) == '-' || isDigit(jsonKind(value)))
+		},
+		decode: func(raw json.RawMessage) (map[string]jupiterQuote, int, error) {
+			var entries map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &entries); err != nil {
+				return nil, 0, err
+			}
+			return decodeJupiterQuotes(entries, func(entry json.RawMessage) (jupiterQuote, bool) {
+				var price flexFloat
+				ok := json.Unmarshal(entry, &price) == nil && price.Set
+				return jupiterQuote{Price: price.Value}, ok
+			})
+		},
+	},
+}
+
+// decodeJupiterQuotes decodes the price entries of a Jupiter price response by mint, skipping unpriced entries
+func decodeJupiterQuotes(entries map[string]json.RawMessage, decode func(json.RawMessage) (jupiterQuote, bool)) (map[string]jupiterQuote, int, error) {
+	quotes := make(map[string]jupiterQuote, len(entries))
+	skipped := 0
+	for mint, entry := range entries {
+		quote, ok := decode(entry)
+		if !ok || quote.Price <= 0 {
+			skipped++
+			continue
+		}
+		quotes[mint] = quote
+	}
+	return quotes, skipped, nil
+}
@@ -0,0 +1,163 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// okxAPIURL is the OKX API base URL
+const okxAPIURL = "https://www.okx.com"
+
+// okxInstruments maps token symbols to the OKX USDT spot instruments used as their reference
+var okxInstruments = map[string]string{
+	"ETH":   "ETH-USDT",
+	"BTC":   "BTC-USDT",
+	"SOL":   "SOL-USDT",
+	"USDC":  "USDC-USDT",
+	"MATIC": "POL-USDT", // MATIC trades as POL since the migration
+	"AVAX":  "AVAX-USDT",
+	"BNB":   "BNB-USDT",
+	"BONK":  "BONK-USDT",
+	"JUP":   "JUP-USDT",
+	"RAY":   "RAY-USDT",
+}
+
+// OKXPriceSource fetches spot prices from OKX.
+// Like Binance it is a reference source, ranked after Binance so it covers the symbols Binance lacks
+// and every symbol while Binance is unavailable.
+type OKXPriceSource struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewOKXPriceSource creates a new OKX price source
+func NewOKXPriceSource(httpClient *http.Client) *OKXPriceSource {
+	return &OKXPriceSource{httpClient: httpClient, baseURL: okxAPIURL}
+}
+
+// SetBaseURL calls a mirror of the OKX API instead of www.okx.com
+func (s *OKXPriceSource) SetBaseURL(baseURL string) {
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// Name returns the source name
+func (s *OKXPriceSource) Name() types.PriceSource {
+	return types.PriceSourceOKX
+}
+
+// Priority ranks OKX after Binance; it is only used as a reference
+func (s *OKXPriceSource) Priority() int {
+	return 11
+}
+
+// ReferenceOnly keeps OKX prices out of the merged prices
+func (s *OKXPriceSource) ReferenceOnly() bool {
+	return true
+}
+
+// okxTickersResponse is the OKX market tickers response
+type okxTickersResponse struct {
+	Code string      `json:"code"`
+	Msg  string      `json:"msg"`
+	Data []okxTicker `json:"data"`
+}
+
+// okxTicker is an entry of the OKX market tickers response
+type okxTicker struct {
+	InstID    string `json:"instId"`
+	Last      string `json:"last"`
+	Open24h   string `json:"open24h"`
+	VolCcy24h string `json:"volCcy24h"` // Quote currency volume for spot instruments
+}
+
+// Fetch fetches reference prices from the OKX spot tickers.
+// USDT quotes are treated as USD. Reference prices carry no chain since they apply on every chain.
+func (s *OKXPriceSource) Fetch(ctx context.Context, request types.PriceFetchRequest) ([]types.TokenPrice, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Fetching OKX reference prices", "symbols", request.Symbols)
+
+	instrumentToSymbol := make(map[string]string)
+	if len(request.Symbols) == 0 {
+		for symbol, instrument := range okxInstruments {
+			instrumentToSymbol[instrument] = symbol
+		}
+	} else {
+		for _, symbol := range request.Symbols {
+			symbol = referenceSymbol(symbol)
+			if instrument, ok := okxInstruments[symbol]; ok {
+				instrumentToSymbol[instrument] = symbol
+			}
+		}
+	}
+	if len(instrumentToSymbol) == 0 {
+		return []types.TokenPrice{}, nil
+	}
+
+	// OKX has no multi-instrument ticker query, so fetch every spot ticker in one request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/api/v5/market/tickers?instType=SPOT", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OKX prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("OKX API returned status %d", resp.StatusCode),
+			"OKX_API_ERROR",
+			fmt.Errorf("non-200 status code"))
+	}
+
+	var tickers okxTickersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tickers); err != nil {
+		return nil, fmt.Errorf("invalid OKX response: %w", err)
+	}
+	if tickers.Code != "0" {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("OKX API returned code %s: %s", tickers.Code, tickers.Msg),
+			"OKX_API_ERROR",
+			fmt.Errorf("non-zero response code"))
+	}
+
+	prices := make([]types.TokenPrice, 0, len(instrumentToSymbol))
+	for _, ticker := range tickers.Data {
+		symbol, ok := instrumentToSymbol[ticker.InstID]
+		if !ok {
+			continue
+		}
+		price, err := strconv.ParseFloat(ticker.Last, 64)
+		if err != nil || price <= 0 {
+			logger.Warn("Skipping invalid OKX price", "instrument", ticker.InstID, "price", ticker.Last)
+			continue
+		}
+		var change float64
+		if open, err := strconv.ParseFloat(ticker.Open24h, 64); err == nil && open > 0 {
+			change = (price - open) / open * 100
+		}
+		volume, _ := strconv.ParseFloat(ticker.VolCcy24h, 64)
+
+		prices = append(prices, types.TokenPrice{
+			Symbol:      symbol,
+			PriceUSD:    price,
+			Change24h:   change,
+			Volume24h:   volume,
+			LastUpdated: time.Now(),
+			Source:      types.PriceSourceOKX,
+		})
+	}
+
+	logger.Info("Fetched OKX reference prices", "count", len(prices))
+	return prices, nil
+}
@@ -0,0 +1,60 @@
+package temporal_activities
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestOKXPriceSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v5/market/tickers" || r.URL.Query().Get("instType") != "SPOT" {
+			t.Errorf("Unexpected request: %s", r.URL)
+		}
+		json.NewEncoder(w).Encode(okxTickersResponse{
+			Code: "0",
+			Data: []okxTicker{
+				{InstID: "ETH-USDT", Last: "3030", Open24h: "3000", VolCcy24h: "1000000"},
+				{InstID: "POL-USDT", Last: "0.25"},
+				{InstID: "DOGE-USDT", Last: "0.1"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	source := NewOKXPriceSource(server.Client())
+	source.baseURL = server.URL
+	registry := NewPriceSourceRegistry()
+	registry.MustRegister(source)
+	activities := NewPriceActivitiesWithSources(nil, t.TempDir(), registry)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.FetchPricesActivity)
+
+	value, err := env.ExecuteActivity(activities.FetchPricesActivity, string(types.PriceSourceOKX), types.PriceFetchRequest{
+		Symbols: []string{"WETH", "MATIC", "UNKNOWN"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var prices []types.TokenPrice
+	if err := value.Get(&prices); err != nil {
+		t.Fatalf("Failed to decode prices: %v", err)
+	}
+
+	// Tickers for symbols that weren't requested are skipped
+	if len(prices) != 2 {
+		t.Fatalf("Expected 2 prices, got %+v", prices)
+	}
+	if prices[0].Symbol != "ETH" || prices[0].PriceUSD != 3030 || prices[0].Change24h != 1 || prices[0].Volume24h != 1000000 || prices[0].Source != types.PriceSourceOKX {
+		t.Errorf("Unexpected ETH price: %+v", prices[0])
+	}
+	if prices[1].Symbol != "MATIC" || prices[1].PriceUSD != 0.25 {
+		t.Errorf("Unexpected MATIC price: %+v", prices[1])
+	}
+}
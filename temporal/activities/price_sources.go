@@ -0,0 +1,157 @@
+package temporal_activities
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+)
+
+// unregisteredSourcePriority ranks prices from unknown sources below every registered source
+const unregisteredSourcePriority = 1000
+
+// PriceSource is a provider of token prices that FetchPricesActivity can fetch from
+type PriceSource interface {
+	// Name identifies the source in fetch requests and on the prices it returns
+	Name() types.PriceSource
+
+	// Priority ranks the source when prices for the same token are merged; lower wins
+	Priority() int
+
+	// Fetch fetches the latest prices from the source
+	Fetch(ctx context.Context, request types.PriceFetchRequest) ([]types.TokenPrice, error)
+}
+
+// ReferencePriceSource is a price source whose prices sanity check the merged prices instead of being merged
+type ReferencePriceSource interface {
+	PriceSource
+
+	// ReferenceOnly reports whether the source's prices are only used as a reference
+	ReferenceOnly() bool
+}
+
+// PriceSourceRegistry holds the price sources available to the price activities
+type PriceSourceRegistry struct {
+	sources      map[types.PriceSource]PriceSource
+	formatAlerts PriceFormatAlertHandler
+	mu           sync.RWMutex
+}
+
+// NewPriceSourceRegistry creates an empty price source registry
+func NewPriceSourceRegistry() *PriceSourceRegistry {
+	return &PriceSourceRegistry{
+		sources: make(map[types.PriceSource]PriceSource),
+	}
+}
+
+// DefaultPriceSources creates a registry with the built-in CoinGecko, Universal and Jupiter sources,
+// plus Binance and OKX as sanity references
+func DefaultPriceSources(sdk universalsdk.SDK, httpClient *http.Client) *PriceSourceRegistry {
+	registry := NewPriceSourceRegistry()
+	registry.MustRegister(NewCoinGeckoPriceSource(httpClient))
+	registry.MustRegister(NewUniversalPriceSource(sdk))
+	registry.MustRegister(NewJupiterPriceSource(httpClient))
+	registry.MustRegister(NewBinancePriceSource(httpClient))
+	registry.MustRegister(NewOKXPriceSource(httpClient))
+	return registry
+}
+
+// Register adds a price source, failing if one with the same name is already registered
+func (r *PriceSourceRegistry) Register(source PriceSource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sources[source.Name()]; exists {
+		return fmt.Errorf("price source %q already registered", source.Name())
+	}
+	r.sources[source.Name()] = source
+	return nil
+}
+
+// MustRegister adds a price source and panics if it is already registered
+func (r *PriceSourceRegistry) MustRegister(source PriceSource) {
+	if err := r.Register(source); err != nil {
+		panic(err)
+	}
+}
+
+// SetFormatAlertHandler sets the handler called when a source's response is in a format it doesn't recognize.
+// Such alerts are logged as errors either way.
+func (r *PriceSourceRegistry) SetFormatAlertHandler(handler PriceFormatAlertHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.formatAlerts = handler
+}
+
+// formatAlertHandler returns the handler of price format alerts, if any
+func (r *PriceSourceRegistry) formatAlertHandler() PriceFormatAlertHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.formatAlerts
+}
+
+// Get returns the price source with the given name
+func (r *PriceSourceRegistry) Get(name types.PriceSource) (PriceSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	source, ok := r.sources[name]
+	return source, ok
+}
+
+// SetBaseURL points a registered source at a mirror of its API, failing if the source isn't registered or is
+// called through an SDK rather than a URL
+func (r *PriceSourceRegistry) SetBaseURL(name types.PriceSource, baseURL string) error {
+	source, ok := r.Get(name)
+	if !ok {
+		return fmt.Errorf("price source %q not registered", name)
+	}
+	mirrored, ok := source.(interface{ SetBaseURL(baseURL string) })
+	if !ok {
+		return fmt.Errorf("price source %q has no base URL", name)
+	}
+	mirrored.SetBaseURL(baseURL)
+	return nil
+}
+
+// Sources returns the registered sources ordered by priority
+func (r *PriceSourceRegistry) Sources() []PriceSource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sources := make([]PriceSource, 0, len(r.sources))
+	for _, source := range r.sources {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Priority() != sources[j].Priority() {
+			return sources[i].Priority() < sources[j].Priority()
+		}
+		return sources[i].Name() < sources[j].Name()
+	})
+	return sources
+}
+
+// Priority returns the merge priority of the named source; unregistered sources rank last
+func (r *PriceSourceRegistry) Priority(name types.PriceSource) int {
+	if source, ok := r.Get(name); ok {
+		return source.Priority()
+	}
+	return unregisteredSourcePriority
+}
+
+// IsReference reports whether the named source only provides reference prices
+func (r *PriceSourceRegistry) IsReference(name types.PriceSource) bool {
+	source, ok := r.Get(name)
+	if !ok {
+		return false
+	}
+	reference, ok := source.(ReferencePriceSource)
+	return ok && reference.ReferenceOnly()
+}
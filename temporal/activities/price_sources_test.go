@@ -0,0 +1,119 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// stubPriceSource is a PriceSource returning fixed prices
+type stubPriceSource struct {
+	name     types.PriceSource
+	priority int
+	prices   []types.TokenPrice
+	err      error
+}
+
+func (s *stubPriceSource) Name() types.PriceSource { return s.name }
+
+func (s *stubPriceSource) Priority() int { return s.priority }
+
+func (s *stubPriceSource) Fetch(ctx context.Context, request types.PriceFetchRequest) ([]types.TokenPrice, error) {
+	return s.prices, s.err
+}
+
+func TestPriceSourceRegistry(t *testing.T) {
+	registry := DefaultPriceSources(nil, nil)
+
+	t.Run("DefaultOrder", func(t *testing.T) {
+		expected := []types.PriceSource{types.PriceSourceCoinGecko, types.PriceSourceUniversal, types.PriceSourceJupiter, types.PriceSourceBinance, types.PriceSourceOKX}
+		sources := registry.Sources()
+		if len(sources) != len(expected) {
+			t.Fatalf("Expected %d sources, got %d", len(expected), len(sources))
+		}
+		for i, source := range sources {
+			if source.Name() != expected[i] {
+				t.Errorf("Expected source %d to be %s, got %s", i, expected[i], source.Name())
+			}
+		}
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		pyth := &stubPriceSource{name: "pyth", priority: 0}
+		if err := registry.Register(pyth); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := registry.Register(pyth); err == nil {
+			t.Error("Expected error registering a duplicate source, got nil")
+		}
+
+		if got, ok := registry.Get("pyth"); !ok || got != pyth {
+			t.Errorf("Expected to get the registered source, got %v", got)
+		}
+		if registry.Sources()[0].Name() != "pyth" {
+			t.Errorf("Expected pyth to rank first, got %s", registry.Sources()[0].Name())
+		}
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		if registry.Priority(types.PriceSourceCoinGecko) != 1 {
+			t.Errorf("Expected CoinGecko priority 1, got %d", registry.Priority(types.PriceSourceCoinGecko))
+		}
+		if registry.Priority(types.PriceSourceFallback) <= registry.Priority(types.PriceSourceJupiter) {
+			t.Error("Expected unregistered sources to rank after registered ones")
+		}
+	})
+
+	t.Run("SetBaseURL", func(t *testing.T) {
+		if err := registry.SetBaseURL(types.PriceSourceJupiter, "https://jupiter.internal/"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		source, _ := registry.Get(types.PriceSourceJupiter)
+		if baseURL := source.(*JupiterPriceSource).baseURL; baseURL != "https://jupiter.internal" {
+			t.Errorf("Expected the Jupiter mirror, got %s", baseURL)
+		}
+		if err := registry.SetBaseURL(types.PriceSourceUniversal, "https://universal.internal"); err == nil {
+			t.Error("Expected error setting the base URL of an SDK source, got nil")
+		}
+		if err := registry.SetBaseURL("kraken", "https://kraken.internal"); err == nil {
+			t.Error("Expected error setting the base URL of an unregistered source, got nil")
+		}
+	})
+
+	t.Run("IsReference", func(t *testing.T) {
+		if !registry.IsReference(types.PriceSourceBinance) {
+			t.Error("Expected Binance to be a reference source")
+		}
+		if registry.IsReference(types.PriceSourceCoinGecko) || registry.IsReference(types.PriceSourceFallback) {
+			t.Error("Expected merged and unregistered sources not to be reference sources")
+		}
+	})
+}
+
+func TestFetchPricesActivity(t *testing.T) {
+	registry := NewPriceSourceRegistry()
+	registry.MustRegister(&stubPriceSource{
+		name:   "pyth",
+		prices: []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 3000, Source: "pyth"}},
+	})
+	registry.MustRegister(&stubPriceSource{name: "broken", err: errors.New("unavailable")})
+
+	activities := NewPriceActivitiesWithSources(nil, t.TempDir(), registry)
+
+	prices, err := activities.FetchPricesActivity(context.Background(), "pyth", types.PriceFetchRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(prices) != 1 || prices[0].PriceUSD != 3000 {
+		t.Errorf("Unexpected prices: %+v", prices)
+	}
+
+	if _, err := activities.FetchPricesActivity(context.Background(), "broken", types.PriceFetchRequest{}); err == nil {
+		t.Error("Expected source error, got nil")
+	}
+	if _, err := activities.FetchPricesActivity(context.Background(), "chainlink", types.PriceFetchRequest{}); err == nil {
+		t.Error("Expected error for unregistered source, got nil")
+	}
+}
@@ -0,0 +1,22 @@
+package temporal_activities
+
+import (
+	"fmt"
+
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/temporal"
+)
+
+// sdkError converts a failed Universal SDK call into an activity error of errType. Failures retrying can't fix, such
+// as amounts too small to cover fees or unsupported chains, are non-retryable; rate limited calls are retried after
+// the wait Universal asked for, and other failures by the activity's retry policy.
+func sdkError(err error, message, errType string) error {
+	message = fmt.Sprintf("%s: %v", message, err)
+	if !universalsdk.Retryable(err) {
+		return temporal.NewNonRetryableApplicationError(message, errType, err)
+	}
+	if wait := universalsdk.RetryAfter(err); wait > 0 {
+		return temporal.NewApplicationErrorWithOptions(message, errType, temporal.ApplicationErrorOptions{NextRetryDelay: wait, Cause: err})
+	}
+	return temporal.NewApplicationErrorWithCause(message, errType, err)
+}
@@ -0,0 +1,43 @@
+package temporal_activities
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestSDKError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		nonRetryable bool
+		delay        time.Duration
+	}{
+		{"InsufficientAmount", &universalsdk.Error{Method: universalsdk.MethodWrapToken, Kind: universalsdk.ErrInsufficientAmount}, true, 0},
+		{"ChainUnsupported", universalsdk.ErrChainUnsupported, true, 0},
+		{"RateLimited", &universalsdk.Error{Kind: universalsdk.ErrRateLimited, RetryAfter: 30 * time.Second}, false, 30 * time.Second},
+		{"BridgeDown", &universalsdk.Error{Kind: universalsdk.ErrBridgeDown}, false, 0},
+		{"Untyped", errors.New("connection reset"), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var appErr *temporal.ApplicationError
+			err := sdkError(tt.err, "Failed to wrap token", "WRAP_FAILED")
+			if !errors.As(err, &appErr) || appErr.Type() != "WRAP_FAILED" {
+				t.Fatalf("Expected a WRAP_FAILED application error, got %v", err)
+			}
+			if appErr.NonRetryable() != tt.nonRetryable {
+				t.Errorf("Expected non-retryable %v, got %v", tt.nonRetryable, appErr.NonRetryable())
+			}
+			if appErr.NextRetryDelay() != tt.delay {
+				t.Errorf("Expected next retry delay %v, got %v", tt.delay, appErr.NextRetryDelay())
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("Expected the SDK error as the cause")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package temporal_activities
+
+import (
+	"time"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"github.com/infinity-dex/universalsdk"
+)
+
+// SDKMiddleware returns the Universal SDK middleware of the API server and workers, outermost first: metrics counting
+// every call, logging when cfg asks for it, the response cache and retries of failed reads
+func SDKMiddleware(cfg temporal_config.SDKMiddlewareConfig, metrics *universalsdk.CallMetrics) []universalsdk.Middleware {
+	middlewares := []universalsdk.Middleware{metrics.Middleware()}
+	if cfg.LogCalls {
+		middlewares = append(middlewares, universalsdk.Logging())
+	}
+	middlewares = append(middlewares, universalsdk.Cache(map[string]time.Duration{
+		universalsdk.MethodGetWrappedTokens:    cfg.WrappedTokensTTL,
+		universalsdk.MethodGetAllWrappedTokens: cfg.WrappedTokensTTL,
+		universalsdk.MethodGetFeeEstimate:      cfg.FeeEstimateTTL,
+	}))
+	if cfg.RetryAttempts > 1 {
+		middlewares = append(middlewares, universalsdk.Retry(cfg.RetryAttempts, cfg.RetryBackoff))
+	}
+	return middlewares
+}
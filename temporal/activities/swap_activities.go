@@ -7,22 +7,56 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/infinity-dex/chains"
+	"github.com/infinity-dex/chains/evm"
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
 	"github.com/infinity-dex/services/types"
 	"github.com/infinity-dex/universalsdk"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/temporal"
 )
 
+const (
+	// fastSwapSettleTime is how long FastSwapActivity waits for a swap to settle
+	fastSwapSettleTime = 2 * time.Second
+
+	// fastSwapPollInterval is how often FastSwapActivity checks whether the swap settled
+	fastSwapPollInterval = 100 * time.Millisecond
+)
+
 // SwapActivities holds implementation of swap-related activities
 type SwapActivities struct {
 	universalSDK universalsdk.SDK
 	swapService  SwapServiceInterface
+	pricePolicy  *services.PriceStalenessPolicy // optional, values fees with oracle prices
+	gasEstimator *services.GasEstimator         // optional, prices gas from live chain fees
+	gasSponsor   *services.GasSponsor           // optional, pays the destination chain gas of swaps asking it to
+	limiter      *services.SwapLimiter          // optional, holds swaps to the swap limits
+	rules        *services.RuleSet              // optional, applies operator fee overrides
+	executor     *ChainExecutor                 // optional, executes same-chain swaps on-chain
+	reliability  *services.BridgeReliability    // optional, records bridge outcomes
+	bus          *events.Bus                    // optional, announces the steps of executed swaps
 }
 
+// SwapSettlementFailed is the error type of a swap that was submitted but failed to settle
+const SwapSettlementFailed = "SWAP_SETTLEMENT_FAILED"
+
+// GasSponsorshipUnavailable is the error type of a swap asking for gas sponsorship its destination chain won't give
+const GasSponsorshipUnavailable = "GAS_SPONSORSHIP_UNAVAILABLE"
+
+// WorkerStopping is the error type of an activity given up because its worker is stopping; the retry, on another
+// worker, resumes from the activity's last heartbeat
+const WorkerStopping = "WORKER_STOPPING"
+
+// swapPollInterval is how often a submitted swap's status is polled
+const swapPollInterval = 2 * time.Second
+
 // SwapServiceInterface defines the interface for swap service
 type SwapServiceInterface interface {
 	GetSwapQuote(ctx context.Context, request types.SwapRequest) (*types.SwapQuote, error)
 	ExecuteSwap(ctx context.Context, request types.SwapRequest) (string, error)
+	SimulateSwap(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error)
 	GetSwapStatus(ctx context.Context, requestID string) (*types.SwapResult, error)
 	CancelSwap(ctx context.Context, requestID string) error
 }
@@ -35,6 +69,246 @@ func NewSwapActivities(sdk universalsdk.SDK, swapService SwapServiceInterface) *
 	}
 }
 
+// SetPricePolicy values fees with the price oracle, failing fee calculations whose prices are too old
+func (a *SwapActivities) SetPricePolicy(policy *services.PriceStalenessPolicy) {
+	a.pricePolicy = policy
+}
+
+// SetRules applies the circuit breaker and fee override rules to calculated fees
+func (a *SwapActivities) SetRules(rules *services.RuleSet) {
+	a.rules = rules
+}
+
+// SetGasEstimator replaces the SDK's fixed gas fee with one priced from each involved chain's live fees
+func (a *SwapActivities) SetGasEstimator(estimator *services.GasEstimator) {
+	a.gasEstimator = estimator
+}
+
+// SetGasSponsor sponsors the destination chain gas of swaps asking for it, recouping it through their network fee
+func (a *SwapActivities) SetGasSponsor(sponsor *services.GasSponsor) {
+	a.gasSponsor = sponsor
+}
+
+// SetSwapLimiter books each swap's value against the swap limits, refusing swaps worth more than they allow
+func (a *SwapActivities) SetSwapLimiter(limiter *services.SwapLimiter) {
+	a.limiter = limiter
+}
+
+// SetChainExecutor executes same-chain swaps with transactions to the chain's DEX contract when it is configured
+func (a *SwapActivities) SetChainExecutor(executor *ChainExecutor) {
+	a.executor = executor
+}
+
+// SetBridgeReliability records the bridge outcome of each settled cross-chain swap in reliability
+func (a *SwapActivities) SetBridgeReliability(reliability *services.BridgeReliability) {
+	a.reliability = reliability
+}
+
+// SetEventBus publishes the wrap and delivery steps of each swap the activities execute to bus
+func (a *SwapActivities) SetEventBus(bus *events.Bus) {
+	a.bus = bus
+}
+
+// CalculateFeeActivity estimates the fees of a swap, pricing gas from live chain fees when a gas estimator is set,
+// refusing swaps a circuit breaker rule holds for and applying fee override rules when rules are set and valuing the fees in USD with the oracle when a price policy is set
+func (a *SwapActivities) CalculateFeeActivity(ctx context.Context, request types.SwapRequest) (*types.Fee, error) {
+	fee, err := a.universalSDK.GetFeeEstimate(ctx, universalsdk.FeeEstimateRequest{
+		SourceToken:      request.SourceToken,
+		DestinationToken: request.DestinationToken,
+		Amount:           request.Amount,
+	})
+	if err != nil {
+		return nil, temporal.NewApplicationError(
+			fmt.Sprintf("Failed to get fee estimate: %v", err),
+			"FEE_ESTIMATE_FAILED")
+	}
+
+	// Keep the SDK's gas fee until every involved chain has gas readings
+	if a.gasEstimator != nil {
+		if gasFee, err := a.gasEstimator.GasFee(ctx, request); err == nil {
+			fee.GasFee = gasFee
+		} else {
+			activity.GetLogger(ctx).Warn("Falling back to the SDK gas fee", "requestID", request.RequestID, "error", err)
+		}
+	}
+
+	// Sponsored destination chain gas is recouped through the network fee
+	if request.SponsorGas {
+		sponsorship, err := a.quoteGasSponsorship(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		fee.NetworkFee = new(big.Int).Add(fee.NetworkFee, sponsorship.Recouped)
+	}
+
+	// Tripped circuit breakers stop the swap; a broken fee override leaves the standard fee in place
+	if a.rules != nil {
+		var sourcePriceUSD float64
+		if a.pricePolicy != nil {
+			if price, err := a.pricePolicy.Price(ctx, request.SourceToken); err == nil {
+				sourcePriceUSD = price.PriceUSD
+			}
+		}
+		broken, err := a.rules.CheckBreakers(ctx, request, sourcePriceUSD)
+		if broken != nil {
+			activity.GetLogger(ctx).Warn("Skipping broken circuit breaker rules", "requestID", request.RequestID, "error", broken)
+		}
+		if err != nil {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), "CIRCUIT_BREAKER_TRIPPED", err)
+		}
+		if err := a.rules.ApplyFeeOverride(ctx, request, sourcePriceUSD, fee); err != nil {
+			activity.GetLogger(ctx).Warn("Ignoring fee override", "requestID", request.RequestID, "error", err)
+		}
+	}
+
+	if a.pricePolicy != nil {
+		if err := a.pricePolicy.PriceFee(ctx, request, fee); err != nil {
+			if priceErr := priceError(err); priceErr != nil {
+				return nil, priceErr
+			}
+			return nil, err
+		}
+	}
+	return fee, nil
+}
+
+// quoteGasSponsorship prices the destination chain gas sponsored for a swap, failing with a non-retryable
+// GAS_SPONSORSHIP_UNAVAILABLE error when the chain won't sponsor it
+func (a *SwapActivities) quoteGasSponsorship(ctx context.Context, request types.SwapRequest) (*types.GasSponsorship, error) {
+	if a.gasSponsor == nil {
+		return nil, gasSponsorshipError(types.ErrGasSponsorshipUnavailable)
+	}
+	sponsorship, err := a.gasSponsor.Quote(ctx, request)
+	if err != nil {
+		return nil, gasSponsorshipError(err)
+	}
+	return sponsorship, nil
+}
+
+// sponsorGas books the destination chain gas sponsored for an on-chain swap asking for it before the swap is sent.
+// Retries book it once.
+func (a *SwapActivities) sponsorGas(ctx context.Context, request types.SwapRequest) error {
+	if !request.SponsorGas {
+		return nil
+	}
+	sponsorship, err := a.quoteGasSponsorship(ctx, request)
+	if err != nil {
+		return err
+	}
+	if err := a.gasSponsor.Record(ctx, *sponsorship); err != nil {
+		return gasSponsorshipError(err)
+	}
+	activity.GetLogger(ctx).Info("Sponsoring swap gas", "requestID", request.RequestID, "chainID", sponsorship.ChainID, "costUSD", sponsorship.CostUSD)
+	return nil
+}
+
+// gasSponsorshipError converts a gas sponsorship error into a non-retryable application error, or returns other
+// errors unchanged
+func gasSponsorshipError(err error) error {
+	if errors.Is(err, types.ErrGasSponsorshipUnavailable) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), GasSponsorshipUnavailable, err)
+	}
+	return err
+}
+
+// CheckQuoteDriftActivity fails with a non-retryable QUOTE_DRIFT error when the pair's oracle price moved against the
+// user by more than their slippage since the quote, so the swap is stopped before anything is submitted on-chain
+func (a *SwapActivities) CheckQuoteDriftActivity(ctx context.Context, request types.SwapRequest, quote types.SwapQuote) error {
+	if a.pricePolicy == nil {
+		return nil
+	}
+
+	err := a.pricePolicy.CheckDrift(ctx, request, quote)
+	if errors.Is(err, services.ErrQuoteDrift) {
+		activity.GetLogger(ctx).Warn("Quote drifted beyond slippage", "requestID", request.RequestID, "error", err)
+		return temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorQuoteDrift, err)
+	}
+	if priceErr := priceError(err); priceErr != nil {
+		return priceErr
+	}
+	return err
+}
+
+// CheckSwapLimitsActivity books the swap's USD value against the swap limits, failing with a non-retryable
+// LIMIT_EXCEEDED error, naming the allowance remaining, when the swap is worth more than one of them allows. Swaps the
+// API server already booked are not booked again.
+func (a *SwapActivities) CheckSwapLimitsActivity(ctx context.Context, request types.SwapRequest) error {
+	err := a.limiter.EnsureReserved(ctx, request)
+	if errors.Is(err, types.ErrSwapLimitExceeded) {
+		activity.GetLogger(ctx).Warn("Swap exceeds a swap limit", "requestID", request.RequestID, "error", err)
+		return temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorLimitExceeded, err)
+	}
+	if priceErr := priceError(err); priceErr != nil {
+		return priceErr
+	}
+	return err
+}
+
+// ReleaseSwapLimitsActivity deletes the booking of a swap that stopped before moving any funds, so it counts against
+// no swap limit
+func (a *SwapActivities) ReleaseSwapLimitsActivity(ctx context.Context, requestID string) error {
+	return a.limiter.Release(ctx, requestID)
+}
+
+// CheckBalanceActivity fails with a non-retryable INSUFFICIENT_BALANCE error when the swap's source address holds
+// less of the source token than the swap's amount, or less of its chain's native token than the gas it uses there.
+// A native source token pays both. Swaps not executed on-chain, funded by a deposit or without a source address on an
+// EVM chain are not checked.
+func (a *SwapActivities) CheckBalanceActivity(ctx context.Context, request types.SwapRequest) error {
+	source := types.CanonicalChainID(request.SourceToken.ChainID)
+	chain, ok := types.GetChain(source)
+	if a.executor == nil || !a.executor.Executes(source) || !ok || chain.Namespace != "eip155" ||
+		request.DepositAddress != "" || request.SourceAddress == "" || request.Amount == nil {
+		return nil
+	}
+
+	gas, err := a.executor.GasCost(ctx, source, services.SwapGasUnits(request)[source])
+	if err != nil {
+		return fmt.Errorf("failed to price gas: %w", err)
+	}
+	required := new(big.Int).Set(request.Amount)
+	if request.SourceToken.IsNative() {
+		required.Add(required, gas)
+	} else {
+		native, err := a.executor.Balance(ctx, types.Token{ChainID: source, Address: types.NativeTokenAddress}, request.SourceAddress)
+		if err != nil {
+			return fmt.Errorf("failed to read %s balance: %w", chain.GasToken, err)
+		}
+		if native.Cmp(gas) < 0 {
+			return insufficientBalanceError(request, chain.GasToken, native, gas)
+		}
+	}
+
+	balance, err := a.executor.Balance(ctx, request.SourceToken, request.SourceAddress)
+	if err != nil {
+		return fmt.Errorf("failed to read %s balance: %w", request.SourceToken.Symbol, err)
+	}
+	if balance.Cmp(required) < 0 {
+		return insufficientBalanceError(request, request.SourceToken.Symbol, balance, required)
+	}
+	return nil
+}
+
+// insufficientBalanceError is the non-retryable error of a swap whose source address holds balance of a token and
+// needs required
+func insufficientBalanceError(request types.SwapRequest, symbol string, balance, required *big.Int) error {
+	err := fmt.Errorf("%s holds %s %s, needs %s", request.SourceAddress, balance, symbol, required)
+	return temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorInsufficientBalance, err)
+}
+
+// priceError converts an oracle price error into an application error, or returns nil for other errors.
+// Both kinds are retryable since the price oracle refreshes prices within seconds.
+func priceError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrStalePrice):
+		return temporal.NewApplicationError(err.Error(), "PRICE_STALE")
+	case errors.Is(err, services.ErrPriceUnavailable):
+		return temporal.NewApplicationError(err.Error(), "PRICE_UNAVAILABLE")
+	default:
+		return nil
+	}
+}
+
 // CalculateSwapQuoteActivity calculates a quote for a swap
 func (a *SwapActivities) CalculateSwapQuoteActivity(ctx context.Context, request types.SwapRequest) (*types.SwapQuote, error) {
 	// Log activity start
@@ -55,6 +329,15 @@ func (a *SwapActivities) CalculateSwapQuoteActivity(ctx context.Context, request
 	// Get quote from swap service
 	quote, err := a.swapService.GetSwapQuote(ctx, request)
 	if err != nil {
+		if priceErr := priceError(err); priceErr != nil {
+			return nil, priceErr
+		}
+		if errors.Is(err, types.ErrGasSponsorshipUnavailable) {
+			return nil, gasSponsorshipError(err)
+		}
+		if errors.Is(err, services.ErrPriceImpactTooHigh) {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorPriceImpact, err)
+		}
 		return nil, temporal.NewApplicationError(
 			fmt.Sprintf("Failed to get swap quote: %v", err),
 			"QUOTE_FAILED")
@@ -71,6 +354,30 @@ func (a *SwapActivities) CalculateSwapQuoteActivity(ctx context.Context, request
 	return quote, nil
 }
 
+// SimulateSwapActivity projects the result of a dry-run swap from its quote, fees and the pool's liquidity,
+// without reserving liquidity or submitting anything. A swap that would fail fails with a non-retryable
+// SWAP_SIMULATION_FAILED error, or the retryable price errors while prices are stale.
+func (a *SwapActivities) SimulateSwapActivity(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error) {
+	activity.GetLogger(ctx).Info("Simulating swap",
+		"sourceToken", request.SourceToken.Symbol,
+		"destToken", request.DestinationToken.Symbol,
+		"amount", request.Amount.String(),
+		"requestID", request.RequestID,
+	)
+
+	result, err := a.swapService.SimulateSwap(ctx, request)
+	if err != nil {
+		if priceErr := priceError(err); priceErr != nil {
+			return nil, priceErr
+		}
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Swap would fail: %v", err),
+			"SWAP_SIMULATION_FAILED",
+			err)
+	}
+	return result, nil
+}
+
 // ExecuteSwapActivity executes a swap
 func (a *SwapActivities) ExecuteSwapActivity(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error) {
 	// Log activity start
@@ -88,22 +395,60 @@ func (a *SwapActivities) ExecuteSwapActivity(ctx context.Context, request types.
 			"INVALID_AMOUNT",
 			errors.New("amount must be greater than zero"))
 	}
+	// A deposit-funded swap can only spend what actually arrived
+	if request.DepositAddress != "" && (request.DepositedAmount == nil || request.DepositedAmount.Cmp(request.Amount) < 0) {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"Deposit does not cover the swap",
+			"DEPOSIT_SHORT",
+			fmt.Errorf("deposited %v, swap needs %s", request.DepositedAmount, request.Amount))
+	}
 
-	// Execute swap
-	requestID, err := a.swapService.ExecuteSwap(ctx, request)
-	if err != nil {
-		return nil, temporal.NewApplicationError(
-			fmt.Sprintf("Failed to execute swap: %v", err),
-			"SWAP_FAILED")
+	if a.executor != nil && a.executor.CanSwap(request) {
+		result, err := a.executeOnChain(ctx, request)
+		if err == nil {
+			a.priceResultFee(ctx, request, result, true)
+			a.publishSwapSteps(ctx, request, result, false)
+		}
+		return result, err
+	}
+
+	// A retried attempt resumes polling the swap its previous attempt submitted rather than submit it again
+	var requestID string
+	if activity.HasHeartbeatDetails(ctx) && activity.GetHeartbeatDetails(ctx, &requestID) == nil && requestID != "" {
+		activity.GetLogger(ctx).Info("Resuming submitted swap", "requestID", requestID)
+	} else {
+		submitted, err := a.swapService.ExecuteSwap(ctx, request)
+		if errors.Is(err, types.ErrGasSponsorshipUnavailable) {
+			return nil, gasSponsorshipError(err)
+		}
+		if errors.Is(err, services.ErrQuoteExpired) {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorQuoteExpired, err)
+		}
+		if errors.Is(err, services.ErrPriceImpactTooHigh) {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorPriceImpact, err)
+		}
+		if err != nil {
+			return nil, sdkError(err, "Failed to execute swap", "SWAP_FAILED")
+		}
+		requestID = submitted
+		activity.RecordHeartbeat(ctx, requestID)
 	}
 
 	// Poll for swap completion (with timeout)
 	startTime := time.Now()
 	timeout := 25 * time.Second // Slightly less than activity timeout to allow for clean return
+	crossChain := types.CanonicalChainID(request.SourceToken.ChainID) != types.CanonicalChainID(request.DestinationToken.ChainID)
 
+	var pending *types.SwapResult
 	for {
 		// Check if we've exceeded the timeout
 		if time.Since(startTime) > timeout {
+			// A cross-chain swap still bridging is on its way; the workflow waits for the bridge to deliver it
+			if crossChain && pending != nil {
+				activity.GetLogger(ctx).Info("Swap submitted, bridge transfer pending", "requestID", requestID)
+				a.priceResultFee(ctx, request, pending, false)
+				return pending, nil
+			}
 			return nil, temporal.NewApplicationError(
 				"Swap execution timed out",
 				"SWAP_TIMEOUT")
@@ -115,24 +460,374 @@ func (a *SwapActivities) ExecuteSwapActivity(ctx context.Context, request types.
 			activity.GetLogger(ctx).Error("Failed to get swap status", "error", err)
 			// Continue polling, don't fail the activity yet
 		} else {
-			if result.Success {
-				// Swap completed successfully
+			switch result.Status {
+			case types.SwapStatusCompleted:
 				activity.GetLogger(ctx).Info("Swap executed successfully",
 					"requestID", requestID,
 					"inputAmount", result.InputAmount.String(),
 					"outputAmount", result.OutputAmount.String(),
 				)
+				a.priceResultFee(ctx, request, result, false)
+				// Universal wraps source tokens that aren't already its own before swapping them
+				a.publishSwapSteps(ctx, request, result, !request.SourceToken.IsWrapped)
 				return result, nil
-			} else if result.ErrorMessage != "Swap in progress" {
-				// Swap failed with a specific error
+			case types.SwapStatusFailed:
 				return nil, temporal.NewApplicationError(
 					fmt.Sprintf("Swap failed: %s", result.ErrorMessage),
-					"SWAP_FAILED")
+					SwapSettlementFailed)
+			case types.SwapStatusPending:
+				pending = result
 			}
 		}
 
-		// Sleep before polling again
-		time.Sleep(2 * time.Second)
+		// Wait before polling again, giving up when the attempt is cancelled or its worker stops
+		activity.RecordHeartbeat(ctx, requestID)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-activity.GetWorkerStopChannel(ctx):
+			return nil, temporal.NewApplicationError("Worker stopping; swap polling resumes on retry", WorkerStopping, requestID)
+		case <-time.After(swapPollInterval):
+		}
+	}
+}
+
+// executeOnChain swaps through the chain's DEX contract. The transaction reverts rather than deliver less than the
+// request's minimum output, or the quoted output less the request's slippage.
+func (a *SwapActivities) executeOnChain(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error) {
+	minOutput, err := a.onChainMinOutput(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.sponsorGas(ctx, request); err != nil {
+		return nil, err
+	}
+
+	receipt, err := a.executor.Swap(ctx, request, minOutput)
+	if err != nil {
+		return nil, err
+	}
+	activity.GetLogger(ctx).Info("Swap executed on-chain", "requestID", request.RequestID, "txHash", receipt.TxHash, "block", receipt.BlockNumber)
+	return onChainSwapResult(ctx, request, receipt, minOutput), nil
+}
+
+// onChainMinOutput returns the least an on-chain swap may deliver: the request's minimum output, or else the
+// quoted output less the request's slippage
+func (a *SwapActivities) onChainMinOutput(ctx context.Context, request types.SwapRequest) (*big.Int, error) {
+	if request.MinOutputAmount != nil {
+		return request.MinOutputAmount, nil
+	}
+	quote, err := a.swapService.GetSwapQuote(ctx, request)
+	if err != nil {
+		return nil, temporal.NewApplicationError(
+			fmt.Sprintf("Failed to quote swap: %v", err),
+			"SWAP_FAILED")
+	}
+	return services.ApplySlippage(quote.OutputAmount, request.Slippage), nil
+}
+
+// onChainSwapResult reports a mined swap with the output its receipt shows reaching the recipient and the gas it paid.
+// Native tokens are delivered without a Transfer event, so their output is reported as the minOutput the contract
+// guaranteed.
+func onChainSwapResult(ctx context.Context, request types.SwapRequest, receipt *chains.Receipt, minOutput *big.Int) *types.SwapResult {
+	output := evm.TransferredTo(receipt.Logs, tokenAddress(request.DestinationToken), request.DestinationAddress)
+	if output.Sign() == 0 {
+		activity.GetLogger(ctx).Warn("No output transfer in swap receipt, reporting the minimum output", "requestID", request.RequestID, "txHash", receipt.TxHash)
+		output = minOutput
+	}
+
+	tx := types.Transaction{
+		ID:          receipt.TxHash,
+		Type:        "swap",
+		Hash:        receipt.TxHash,
+		Status:      "completed",
+		FromAddress: request.SourceAddress,
+		ToAddress:   request.DestinationAddress,
+		SourceChain: request.SourceToken.ChainName,
+		DestChain:   request.DestinationToken.ChainName,
+		SourceToken: request.SourceToken,
+		DestToken:   request.DestinationToken,
+		Amount:      request.Amount,
+		Value:       output,
+		Gas:         new(big.Int).SetUint64(receipt.GasUsed),
+		GasPrice:    receipt.EffectiveGasPrice,
+		Timestamp:   time.Now(),
+		BlockNumber: receipt.BlockNumber,
+		WorkflowID:  request.RequestID,
+	}
+	return &types.SwapResult{
+		RequestID:      request.RequestID,
+		Success:        true,
+		Status:         types.SwapStatusCompleted,
+		SourceTx:       tx,
+		DestinationTx:  tx,
+		InputAmount:    request.Amount,
+		OutputAmount:   output,
+		Fee:            onChainFee(receipt),
+		CompletionTime: time.Now(),
+	}
+}
+
+// fastSwapOnChain submits a fast path swap to the chain's DEX contract and waits briefly for it to be mined.
+// A swap still unmined is returned pending with its transaction; retries rebroadcast that transaction.
+func (a *SwapActivities) fastSwapOnChain(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error) {
+	if err := a.sponsorGas(ctx, request); err != nil {
+		return nil, err
+	}
+	sub, err := a.executor.submitSwap(ctx, request, request.MinOutputAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := awaitReceipt(ctx, sub, fastSwapSettleTime)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		activity.GetLogger(ctx).Info("Fast path swap submitted on-chain", "requestID", request.RequestID, "txHash", sub.hash)
+		pending := types.Transaction{
+			ID:          sub.hash,
+			Type:        "swap",
+			Hash:        sub.hash,
+			Status:      "pending",
+			FromAddress: request.SourceAddress,
+			ToAddress:   request.DestinationAddress,
+			SourceToken: request.SourceToken,
+			DestToken:   request.DestinationToken,
+			Amount:      request.Amount,
+			Timestamp:   time.Now(),
+			WorkflowID:  request.RequestID,
+		}
+		return &types.SwapResult{
+			RequestID:     request.RequestID,
+			Status:        types.SwapStatusPending,
+			SourceTx:      pending,
+			DestinationTx: pending,
+			InputAmount:   request.Amount,
+			OutputAmount:  request.MinOutputAmount,
+			ErrorMessage:  "Swap in progress",
+		}, nil
+	case err != nil:
+		return nil, receiptError(sub, err)
+	}
+	return onChainSwapResult(ctx, request, receipt, request.MinOutputAmount), nil
+}
+
+// FastSwapActivity quotes and executes a fast path swap in one step, for running as a local activity.
+// It waits briefly for the swap to settle and returns it still in progress rather than hold up the workflow.
+// Retries do not execute the swap twice.
+func (a *SwapActivities) FastSwapActivity(ctx context.Context, request types.SwapRequest) (*types.SwapResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Executing fast path swap",
+		"sourceToken", request.SourceToken.Symbol,
+		"destToken", request.DestinationToken.Symbol,
+		"amount", request.Amount.String(),
+		"minOutput", request.MinOutputAmount.String(),
+		"requestID", request.RequestID,
+	)
+
+	if request.Amount == nil || request.Amount.Cmp(big.NewInt(0)) <= 0 {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"Invalid amount",
+			"INVALID_AMOUNT",
+			errors.New("amount must be greater than zero"))
+	}
+	if !request.IsFastPath() {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"Swap is not eligible for the fast path",
+			"NOT_FAST_PATH",
+			errors.New("fast path swaps must be same-chain swaps of wrapped tokens with a minimum output"))
+	}
+
+	if a.executor != nil && a.executor.CanSwap(request) {
+		result, err := a.fastSwapOnChain(ctx, request)
+		if err == nil {
+			a.priceResultFee(ctx, request, result, true)
+			a.publishSwapSteps(ctx, request, result, false)
+		}
+		return result, err
+	}
+
+	// A retry after the swap was submitted only waits for it
+	if _, err := a.swapService.GetSwapStatus(ctx, request.RequestID); errors.Is(err, types.ErrSwapNotFound) {
+		if _, err := a.swapService.ExecuteSwap(ctx, request); err != nil {
+			if errors.Is(err, services.ErrOutputBelowMinimum) {
+				return nil, temporal.NewNonRetryableApplicationError(err.Error(), "SLIPPAGE_EXCEEDED", err)
+			}
+			if errors.Is(err, types.ErrGasSponsorshipUnavailable) {
+				return nil, gasSponsorshipError(err)
+			}
+			if errors.Is(err, services.ErrQuoteExpired) {
+				return nil, temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorQuoteExpired, err)
+			}
+			if errors.Is(err, services.ErrPriceImpactTooHigh) {
+				return nil, temporal.NewNonRetryableApplicationError(err.Error(), types.SwapErrorPriceImpact, err)
+			}
+			return nil, temporal.NewApplicationError(
+				fmt.Sprintf("Failed to execute swap: %v", err),
+				"SWAP_FAILED")
+		}
+	} else if err != nil {
+		// The swap may have been submitted; executing it again would duplicate it
+		return nil, temporal.NewApplicationError(
+			fmt.Sprintf("Failed to get swap status: %v", err),
+			"SWAP_FAILED")
+	}
+
+	deadline := time.Now().Add(fastSwapSettleTime)
+	for {
+		result, err := a.swapService.GetSwapStatus(ctx, request.RequestID)
+		if err != nil {
+			return nil, temporal.NewApplicationError(
+				fmt.Sprintf("Failed to get swap status: %v", err),
+				"SWAP_FAILED")
+		}
+		if result.Status == types.SwapStatusFailed {
+			return nil, temporal.NewApplicationError(
+				fmt.Sprintf("Swap failed: %s", result.ErrorMessage),
+				"SWAP_FAILED")
+		}
+		if result.Status == types.SwapStatusCompleted || time.Now().After(deadline) {
+			logger.Info("Fast path swap submitted", "requestID", request.RequestID, "status", result.Status, "outputAmount", result.OutputAmount.String())
+			a.priceResultFee(ctx, request, result, false)
+			a.publishSwapSteps(ctx, request, result, false)
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(fastSwapPollInterval):
+		}
+	}
+}
+
+// priceResultFee values an executed swap's fee at the oracle's prices when it executed. On-chain swaps only pay gas,
+// in their chain's native token; others are charged in the source token. A fee the oracle can't price keeps the
+// values it was quoted with, since the swap has already executed.
+func (a *SwapActivities) priceResultFee(ctx context.Context, request types.SwapRequest, result *types.SwapResult, onChain bool) {
+	if a.pricePolicy == nil || result == nil {
+		return
+	}
+	token := request.SourceToken
+	if onChain {
+		native, ok := types.NativeToken(types.CanonicalChainID(request.SourceToken.ChainID))
+		if !ok {
+			return
+		}
+		token = native
+	}
+	if err := a.pricePolicy.PriceFeeIn(ctx, token, &result.Fee); err != nil {
+		activity.GetLogger(ctx).Warn("Failed to price swap fee", "requestID", request.RequestID, "error", err)
+	}
+}
+
+// publishSwapSteps announces the steps of a completed swap: the wrap of its source tokens when wrapped is set, and
+// the delivery of its output. Swaps still in progress announce nothing. Retried activities may announce a step
+// again, so consumers should expect duplicates of a request ID.
+func (a *SwapActivities) publishSwapSteps(ctx context.Context, request types.SwapRequest, result *types.SwapResult, wrapped bool) {
+	if a.bus == nil || result.Status != types.SwapStatusCompleted {
+		return
+	}
+
+	if wrapped {
+		event := events.TokenWrapped{
+			RequestID: request.RequestID,
+			Token:     request.SourceToken,
+			Amount:    result.InputAmount,
+			TxHash:    result.SourceTx.Hash,
+			WrappedAt: result.SourceTx.Timestamp,
+		}
+		if err := events.Publish(ctx, a.bus, events.TokenWrappedTopic, event); err != nil {
+			activity.GetLogger(ctx).Warn("Failed to publish token wrap", "requestID", request.RequestID, "error", err)
+		}
+	}
+
+	event := events.TransferCompleted{
+		RequestID:          request.RequestID,
+		Token:              request.DestinationToken,
+		Amount:             result.OutputAmount,
+		DestinationAddress: request.DestinationAddress,
+		TxHash:             result.DestinationTx.Hash,
+		CompletedAt:        result.CompletionTime,
+	}
+	if err := events.Publish(ctx, a.bus, events.TransferCompletedTopic, event); err != nil {
+		activity.GetLogger(ctx).Warn("Failed to publish transfer completion", "requestID", request.RequestID, "error", err)
+	}
+}
+
+// RecordBridgeOutcomeActivity records how a cross-chain swap's bridge transfer went. Retries record it once.
+func (a *SwapActivities) RecordBridgeOutcomeActivity(ctx context.Context, outcome types.BridgeOutcome) error {
+	if a.reliability == nil {
+		return nil
+	}
+	recorded, err := a.reliability.Record(ctx, outcome)
+	if err != nil {
+		return fmt.Errorf("failed to record bridge outcome: %w", err)
+	}
+	if recorded {
+		activity.GetLogger(ctx).Info("Recorded bridge outcome", "requestID", outcome.RequestID, "bridge", outcome.Bridge, "success", outcome.Success, "duration", outcome.Duration)
+	}
+	return nil
+}
+
+// BridgeTransferStatusActivity returns the status of a cross-chain swap's bridge transfer, which Universal tracks
+// under the swap's request ID. Swaps started before bridge transfers were watched still poll it.
+func (a *SwapActivities) BridgeTransferStatusActivity(ctx context.Context, requestID string) (*universalsdk.TransactionStatus, error) {
+	if a.universalSDK == nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"No Universal SDK to track bridge transfers with",
+			"BRIDGE_STATUS_UNAVAILABLE", nil)
+	}
+
+	status, err := a.universalSDK.GetTransactionStatus(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bridge transfer status: %w", err)
+	}
+	return status, nil
+}
+
+// WatchBridgeTransferActivity watches a cross-chain swap's bridge transfer through the SDK until it reaches a final
+// status or the deadline, heartbeating while it waits. At the deadline it returns the last status it saw, so the
+// workflow decides whether the transfer timed out.
+func (a *SwapActivities) WatchBridgeTransferActivity(ctx context.Context, requestID string, deadline time.Time) (*universalsdk.TransactionStatus, error) {
+	if a.universalSDK == nil {
+		return nil, temporal.NewNonRetryableApplicationError(
+			"No Universal SDK to track bridge transfers with",
+			"BRIDGE_STATUS_UNAVAILABLE", nil)
+	}
+
+	watchCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	statuses, err := a.universalSDK.WatchTransaction(watchCtx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch bridge transfer: %w", err)
+	}
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	activity.RecordHeartbeat(ctx, requestID)
+	var last *universalsdk.TransactionStatus
+	for {
+		select {
+		case status, ok := <-statuses:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				if last == nil {
+					return nil, errors.New("bridge transfer watch ended without a status")
+				}
+				return last, nil
+			}
+			last = &status
+			if universalsdk.IsFinalStatus(status.Status) {
+				return last, nil
+			}
+			activity.RecordHeartbeat(ctx, requestID)
+		case <-ticker.C:
+			activity.RecordHeartbeat(ctx, requestID)
+		case <-activity.GetWorkerStopChannel(ctx):
+			return nil, temporal.NewApplicationError("Worker stopping; bridge transfer watch resumes on retry", WorkerStopping, requestID)
+		}
 	}
 }
 
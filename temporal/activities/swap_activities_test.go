@@ -0,0 +1,589 @@
+package temporal_activities
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestFastSwapActivity(t *testing.T) {
+	startingBalance := new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+	sandbox := services.NewSandboxService(startingBalance)
+	activities := NewSwapActivities(nil, sandbox)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.FastSwapActivity)
+
+	request := func(requestID string) types.SwapRequest {
+		return types.SwapRequest{
+			SourceToken:        types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1, ChainName: "Ethereum", IsWrapped: true},
+			DestinationToken:   types.Token{Symbol: "uUSDC", Decimals: 18, ChainID: 1, ChainName: "Ethereum", IsWrapped: true},
+			Amount:             big.NewInt(1_000_000_000_000_000_000),
+			SourceAddress:      "0x1234567890abcdef1234567890abcdef12345678",
+			DestinationAddress: "0x1234567890abcdef1234567890abcdef12345678",
+			RequestID:          requestID,
+			MinOutputAmount:    big.NewInt(1),
+		}
+	}
+
+	t.Run("Settles", func(t *testing.T) {
+		value, err := env.ExecuteActivity(activities.FastSwapActivity, request("fast-1"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result types.SwapResult
+		if err := value.Get(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if !result.Success {
+			t.Errorf("Expected the sandbox swap to settle, got %+v", result)
+		}
+	})
+
+	t.Run("RetryDoesNotSwapTwice", func(t *testing.T) {
+		before := sandbox.GetBalance("0x1234567890abcdef1234567890abcdef12345678", "uETH")
+		if _, err := env.ExecuteActivity(activities.FastSwapActivity, request("fast-1")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		after := sandbox.GetBalance("0x1234567890abcdef1234567890abcdef12345678", "uETH")
+		if before.Cmp(after) != 0 {
+			t.Errorf("Expected balance to stay %s on retry, got %s", before, after)
+		}
+	})
+
+	t.Run("BelowMinimumOutput", func(t *testing.T) {
+		req := request("fast-2")
+		req.MinOutputAmount = new(big.Int).Set(req.Amount)
+		if _, err := env.ExecuteActivity(activities.FastSwapActivity, req); err == nil {
+			t.Error("Expected a swap quoting below its minimum output to fail")
+		}
+	})
+
+	t.Run("NotEligible", func(t *testing.T) {
+		req := request("fast-3")
+		req.DestinationToken.ChainID = 137
+		if _, err := env.ExecuteActivity(activities.FastSwapActivity, req); err == nil {
+			t.Error("Expected a cross-chain swap to be refused")
+		}
+	})
+
+	t.Run("FailedSwap", func(t *testing.T) {
+		swaps := &statusSwapService{SwapServiceInterface: sandbox, result: &types.SwapResult{Status: types.SwapStatusFailed, ErrorMessage: "Swap failed"}}
+		env.RegisterActivity(NewSwapActivities(nil, swaps).FastSwapActivity)
+		if _, err := env.ExecuteActivity("FastSwapActivity", request("fast-4")); err == nil {
+			t.Error("Expected a failed swap to fail the activity")
+		}
+		if swaps.executed != 0 {
+			t.Errorf("Expected a submitted swap not to execute again, executed %d times", swaps.executed)
+		}
+	})
+
+	t.Run("StatusErrorDoesNotExecute", func(t *testing.T) {
+		swaps := &statusSwapService{SwapServiceInterface: sandbox, err: errors.New("failed to get fee estimate")}
+		env.RegisterActivity(NewSwapActivities(nil, swaps).FastSwapActivity)
+		if _, err := env.ExecuteActivity("FastSwapActivity", request("fast-5")); err == nil {
+			t.Error("Expected an unknown swap status to fail the activity")
+		}
+		if swaps.executed != 0 {
+			t.Errorf("Expected a swap whose status is unknown not to execute, executed %d times", swaps.executed)
+		}
+	})
+}
+
+// statusSwapService reports a fixed swap status and counts executions
+type statusSwapService struct {
+	SwapServiceInterface
+	result   *types.SwapResult
+	err      error
+	executed int
+}
+
+func (s *statusSwapService) ExecuteSwap(ctx context.Context, request types.SwapRequest) (string, error) {
+	s.executed++
+	return s.SwapServiceInterface.ExecuteSwap(ctx, request)
+}
+
+func (s *statusSwapService) GetSwapStatus(ctx context.Context, requestID string) (*types.SwapResult, error) {
+	return s.result, s.err
+}
+
+// fixedOracle serves one oracle price for every token
+type fixedOracle types.TokenPrice
+
+func (f fixedOracle) OraclePrice(ctx context.Context, symbol string, chainID int64) (types.TokenPrice, error) {
+	return types.TokenPrice(f), nil
+}
+
+func TestCalculateFeeActivity(t *testing.T) {
+	activities := NewSwapActivities(universalsdk.NewMockSDK(universalsdk.MockSDKConfig{}), nil)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.CalculateFeeActivity)
+
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1, IsWrapped: true},
+		DestinationToken: types.Token{Symbol: "uUSDC", Decimals: 18, ChainID: 1, IsWrapped: true},
+		Amount:           big.NewInt(1_000_000_000_000_000_000),
+	}
+
+	t.Run("ValuesFeesWithOracle", func(t *testing.T) {
+		activities.SetPricePolicy(services.NewPriceStalenessPolicy(fixedOracle{PriceUSD: 1000, LastUpdated: time.Now()}, time.Minute))
+		value, err := env.ExecuteActivity(activities.CalculateFeeActivity, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var fee types.Fee
+		if err := value.Get(&fee); err != nil {
+			t.Fatalf("Failed to decode fee: %v", err)
+		}
+		// 0.0017 ETH of fees at $1000
+		if fee.TotalFeeUSD < 1.69 || fee.TotalFeeUSD > 1.71 {
+			t.Errorf("Expected $1.70 of fees, got %f", fee.TotalFeeUSD)
+		}
+		// 0.001 ETH of it is gas
+		if fee.GasFeeUSD < 0.99 || fee.GasFeeUSD > 1.01 || fee.PriceUSD != 1000 {
+			t.Errorf("Expected $1 of gas at $1000, got $%f at $%f", fee.GasFeeUSD, fee.PriceUSD)
+		}
+	})
+
+	t.Run("PricesGasFromChainFees", func(t *testing.T) {
+		policy := services.NewPriceStalenessPolicy(fixedOracle{PriceUSD: 1000, LastUpdated: time.Now()}, time.Minute)
+		chains := services.NewChainService()
+		chains.AddChain(types.ChainStatus{Name: "ethereum", ChainID: 1, IsActive: true})
+		chains.UpdateGasPrice(1, big.NewInt(10_000_000_000))
+		activities.SetPricePolicy(policy)
+		activities.SetGasEstimator(services.NewGasEstimator(chains, policy, 1))
+
+		value, err := env.ExecuteActivity(activities.CalculateFeeActivity, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var fee types.Fee
+		if err := value.Get(&fee); err != nil {
+			t.Fatalf("Failed to decode fee: %v", err)
+		}
+		// A 180k gas swap at 10 gwei costs 0.0018 ETH
+		if fee.GasFee.Cmp(big.NewInt(1_800_000_000_000_000)) != 0 {
+			t.Errorf("Expected 0.0018 ETH of gas, got %s", fee.GasFee)
+		}
+		// Plus 0.0007 ETH of protocol and network fees, at $1000
+		if fee.TotalFeeUSD < 2.49 || fee.TotalFeeUSD > 2.51 {
+			t.Errorf("Expected $2.50 of fees, got %f", fee.TotalFeeUSD)
+		}
+	})
+
+	t.Run("RejectsStalePrices", func(t *testing.T) {
+		activities.SetPricePolicy(services.NewPriceStalenessPolicy(fixedOracle{PriceUSD: 1000, LastUpdated: time.Now().Add(-time.Hour)}, time.Minute))
+		_, err := env.ExecuteActivity(activities.CalculateFeeActivity, request)
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != "PRICE_STALE" {
+			t.Fatalf("Expected a PRICE_STALE error, got %v", err)
+		}
+		if appErr.NonRetryable() {
+			t.Error("Expected stale prices to be retryable")
+		}
+	})
+}
+
+func TestCheckQuoteDriftActivity(t *testing.T) {
+	activities := NewSwapActivities(nil, nil)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.CheckQuoteDriftActivity)
+
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1, IsWrapped: true},
+		DestinationToken: types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1, IsWrapped: true},
+		Amount:           big.NewInt(1_000_000_000_000_000_000),
+		Slippage:         0.5,
+	}
+
+	t.Run("PassesWithoutPolicy", func(t *testing.T) {
+		if _, err := env.ExecuteActivity(activities.CheckQuoteDriftActivity, request, types.SwapQuote{MidPrice: 2}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	// The fixed oracle prices both tokens alike, so the current mid price is 1
+	activities.SetPricePolicy(services.NewPriceStalenessPolicy(fixedOracle{PriceUSD: 1000, LastUpdated: time.Now()}, time.Minute))
+
+	t.Run("PassesWithinSlippage", func(t *testing.T) {
+		if _, err := env.ExecuteActivity(activities.CheckQuoteDriftActivity, request, types.SwapQuote{MidPrice: 1.004}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("FailsBeyondSlippage", func(t *testing.T) {
+		_, err := env.ExecuteActivity(activities.CheckQuoteDriftActivity, request, types.SwapQuote{MidPrice: 1.1})
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != "QUOTE_DRIFT" {
+			t.Fatalf("Expected a QUOTE_DRIFT error, got %v", err)
+		}
+		if !appErr.NonRetryable() {
+			t.Error("Expected quote drift to be non-retryable")
+		}
+	})
+}
+
+func TestCheckSwapLimitsActivity(t *testing.T) {
+	activities := NewSwapActivities(nil, nil)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.CheckSwapLimitsActivity)
+
+	// 1 uETH at $1000
+	request := types.SwapRequest{
+		RequestID:        "swap-1",
+		SourceToken:      types.Token{Symbol: "uETH", Decimals: 18, ChainID: 1, IsWrapped: true},
+		DestinationToken: types.Token{Symbol: "uUSDC", Decimals: 6, ChainID: 1, IsWrapped: true},
+		Amount:           big.NewInt(1_000_000_000_000_000_000),
+		SourceAddress:    "0xabc",
+	}
+
+	t.Run("PassesWithoutLimiter", func(t *testing.T) {
+		if _, err := env.ExecuteActivity(activities.CheckSwapLimitsActivity, request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	prices := services.NewPriceStalenessPolicy(fixedOracle{PriceUSD: 1000, LastUpdated: time.Now()}, time.Minute)
+	activities.SetSwapLimiter(services.NewSwapLimiter(types.SwapLimits{UserDailyUSD: 1500}, prices))
+
+	t.Run("PassesWithinLimit", func(t *testing.T) {
+		if _, err := env.ExecuteActivity(activities.CheckSwapLimitsActivity, request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("FailsOverLimit", func(t *testing.T) {
+		second := request
+		second.RequestID = "swap-2"
+		_, err := env.ExecuteActivity(activities.CheckSwapLimitsActivity, second)
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != types.SwapErrorLimitExceeded {
+			t.Fatalf("Expected a LIMIT_EXCEEDED error, got %v", err)
+		}
+		if !appErr.NonRetryable() {
+			t.Error("Expected an exceeded limit to be non-retryable")
+		}
+		if !strings.Contains(appErr.Error(), "$500.00") {
+			t.Errorf("Expected the error to name the $500.00 remaining, got %v", appErr)
+		}
+	})
+
+	t.Run("PassesAfterRelease", func(t *testing.T) {
+		env.RegisterActivity(activities.ReleaseSwapLimitsActivity)
+		if _, err := env.ExecuteActivity(activities.ReleaseSwapLimitsActivity, request.RequestID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		second := request
+		second.RequestID = "swap-2"
+		if _, err := env.ExecuteActivity(activities.CheckSwapLimitsActivity, second); err != nil {
+			t.Errorf("Expected the released swap not to count, got %v", err)
+		}
+	})
+}
+
+func TestCheckBalanceActivity(t *testing.T) {
+	usdc := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1, Address: usdc},
+		DestinationToken: types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+		Amount:           big.NewInt(1_000_000),
+		SourceAddress:    "0x3535353535353535353535353535353535353535",
+	}
+	// The fake adapter prices gas at 10 wei
+	gas := new(big.Int).SetUint64(services.SwapGasUnits(request)[1] * 10)
+
+	newEnv := func(balances map[string]*big.Int) (*testsuite.TestActivityEnvironment, *SwapActivities) {
+		activities := NewSwapActivities(nil, nil)
+		executor := NewChainExecutor(&fakeAdapter{balances: balances}, map[int64]ChainContracts{1: {Universal: "0x01", DEX: "0x02"}}, time.Second)
+		activities.SetChainExecutor(executor)
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.CheckBalanceActivity)
+		return env, activities
+	}
+	expectInsufficient := func(t *testing.T, err error) {
+		t.Helper()
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != types.SwapErrorInsufficientBalance {
+			t.Fatalf("Expected an INSUFFICIENT_BALANCE error, got %v", err)
+		}
+		if !appErr.NonRetryable() {
+			t.Error("Expected an insufficient balance to be non-retryable")
+		}
+	}
+
+	t.Run("Passes", func(t *testing.T) {
+		env, activities := newEnv(map[string]*big.Int{usdc: big.NewInt(1_000_000), "": gas})
+		if _, err := env.ExecuteActivity(activities.CheckBalanceActivity, request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("FailsWithoutToken", func(t *testing.T) {
+		env, activities := newEnv(map[string]*big.Int{usdc: big.NewInt(999_999), "": gas})
+		_, err := env.ExecuteActivity(activities.CheckBalanceActivity, request)
+		expectInsufficient(t, err)
+	})
+
+	t.Run("FailsWithoutGas", func(t *testing.T) {
+		env, activities := newEnv(map[string]*big.Int{usdc: big.NewInt(1_000_000)})
+		_, err := env.ExecuteActivity(activities.CheckBalanceActivity, request)
+		expectInsufficient(t, err)
+	})
+
+	t.Run("NativeTokenPaysAmountAndGas", func(t *testing.T) {
+		native := request
+		native.SourceToken = types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1, Address: types.NativeTokenAddress}
+		native.DestinationToken = types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1, Address: usdc}
+		required := new(big.Int).Add(native.Amount, new(big.Int).SetUint64(services.SwapGasUnits(native)[1]*10))
+
+		env, activities := newEnv(map[string]*big.Int{"": required})
+		if _, err := env.ExecuteActivity(activities.CheckBalanceActivity, native); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		env, activities = newEnv(map[string]*big.Int{"": new(big.Int).Sub(required, big.NewInt(1))})
+		_, err := env.ExecuteActivity(activities.CheckBalanceActivity, native)
+		expectInsufficient(t, err)
+	})
+
+	t.Run("SkipsSwapsNotCheckable", func(t *testing.T) {
+		env, activities := newEnv(nil)
+		deposit := request
+		deposit.DepositAddress = "0x4545454545454545454545454545454545454545"
+		noSource := request
+		noSource.SourceAddress = ""
+		offChain := request
+		offChain.SourceToken.ChainID = 137
+		offChain.DestinationToken.ChainID = 137
+		for _, skipped := range []types.SwapRequest{deposit, noSource, offChain} {
+			if _, err := env.ExecuteActivity(activities.CheckBalanceActivity, skipped); err != nil {
+				t.Errorf("Expected the check to be skipped, got %v", err)
+			}
+		}
+	})
+}
+
+func TestExecuteSwapActivityDeposit(t *testing.T) {
+	activities := NewSwapActivities(universalsdk.NewMockSDK(universalsdk.MockSDKConfig{}), nil)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.ExecuteSwapActivity)
+
+	request := types.SwapRequest{
+		SourceToken:      types.Token{Symbol: "USDC", Decimals: 6, ChainID: 1, Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"},
+		DestinationToken: types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1},
+		Amount:           big.NewInt(1_000_000),
+		DepositAddress:   "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+		DepositedAmount:  big.NewInt(999_999),
+		RequestID:        "deposit-1",
+	}
+	_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, request)
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != "DEPOSIT_SHORT" {
+		t.Errorf("Expected a DEPOSIT_SHORT error for a deposit below the swap amount, got %v", err)
+	}
+}
+
+func TestExecuteSwapActivityPolling(t *testing.T) {
+	request := types.SwapRequest{
+		SourceToken:        types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1, ChainName: "Ethereum"},
+		DestinationToken:   types.Token{Symbol: "uUSDC", Decimals: 18, ChainID: 1, ChainName: "Ethereum", IsWrapped: true},
+		Amount:             big.NewInt(1_000_000_000_000_000_000),
+		SourceAddress:      "0x1234567890abcdef1234567890abcdef12345678",
+		DestinationAddress: "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd",
+		RequestID:          "swap-polling",
+	}
+	sandbox := services.NewSandboxService(new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil))
+
+	t.Run("ResumesFromHeartbeat", func(t *testing.T) {
+		swaps := &statusSwapService{SwapServiceInterface: sandbox, result: &types.SwapResult{
+			RequestID:    "swap-polling",
+			Status:       types.SwapStatusCompleted,
+			InputAmount:  request.Amount,
+			OutputAmount: big.NewInt(3000),
+		}}
+		activities := NewSwapActivities(nil, swaps)
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.ExecuteSwapActivity)
+		env.SetHeartbeatDetails("swap-polling")
+
+		if _, err := env.ExecuteActivity(activities.ExecuteSwapActivity, request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if swaps.executed != 0 {
+			t.Errorf("Expected the retried attempt to poll the submitted swap, executed it %d times", swaps.executed)
+		}
+	})
+
+	t.Run("GivesUpWhenWorkerStops", func(t *testing.T) {
+		swaps := &statusSwapService{SwapServiceInterface: sandbox, result: &types.SwapResult{Status: types.SwapStatusPending}}
+		activities := NewSwapActivities(nil, swaps)
+
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.ExecuteSwapActivity)
+		stop := make(chan struct{})
+		close(stop)
+		env.SetWorkerStopChannel(stop)
+
+		started := time.Now()
+		_, err := env.ExecuteActivity(activities.ExecuteSwapActivity, request)
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != WorkerStopping || appErr.NonRetryable() {
+			t.Fatalf("Expected a retryable %s error, got %v", WorkerStopping, err)
+		}
+		if elapsed := time.Since(started); elapsed > time.Second {
+			t.Errorf("Expected polling to stop with the worker, took %s", elapsed)
+		}
+		if swaps.executed != 1 {
+			t.Errorf("Expected the swap to be submitted once, got %d", swaps.executed)
+		}
+	})
+}
+
+func TestExecuteSwapActivityPublishesSteps(t *testing.T) {
+	sandbox := services.NewSandboxService(new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil))
+	activities := NewSwapActivities(nil, sandbox)
+	bus := events.NewBus()
+	activities.SetEventBus(bus)
+
+	wrapped := make(chan events.TokenWrapped, 1)
+	transferred := make(chan events.TransferCompleted, 1)
+	events.Subscribe(bus, events.TokenWrappedTopic, 1, events.Block, func(ctx context.Context, event events.TokenWrapped) {
+		wrapped <- event
+	})
+	events.Subscribe(bus, events.TransferCompletedTopic, 1, events.Block, func(ctx context.Context, event events.TransferCompleted) {
+		transferred <- event
+	})
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.ExecuteSwapActivity)
+
+	request := types.SwapRequest{
+		SourceToken:        types.Token{Symbol: "ETH", Decimals: 18, ChainID: 1, ChainName: "Ethereum"},
+		DestinationToken:   types.Token{Symbol: "uUSDC", Decimals: 18, ChainID: 1, ChainName: "Ethereum", IsWrapped: true},
+		Amount:             big.NewInt(1_000_000_000_000_000_000),
+		SourceAddress:      "0x1234567890abcdef1234567890abcdef12345678",
+		DestinationAddress: "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd",
+		RequestID:          "swap-events",
+	}
+	if _, err := env.ExecuteActivity(activities.ExecuteSwapActivity, request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	select {
+	case event := <-wrapped:
+		if event.RequestID != "swap-events" || event.Token.Symbol != "ETH" || event.Amount.Cmp(request.Amount) != 0 || event.TxHash == "" {
+			t.Errorf("Unexpected wrap event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the wrap of the source tokens to be published")
+	}
+	select {
+	case event := <-transferred:
+		if event.RequestID != "swap-events" || event.Token.Symbol != "uUSDC" || event.DestinationAddress != request.DestinationAddress || event.Amount.Sign() <= 0 {
+			t.Errorf("Unexpected transfer event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the delivery of the output to be published")
+	}
+}
+
+// sequenceSDK is a Universal SDK returning transaction statuses one per lookup, repeating the last, and watching
+// them by polling
+type sequenceSDK struct {
+	universalsdk.SDK
+	mu       sync.Mutex
+	statuses []string
+	lookups  int
+}
+
+func (s *sequenceSDK) GetTransactionStatus(ctx context.Context, transactionID string) (*universalsdk.TransactionStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	return &universalsdk.TransactionStatus{TransactionID: transactionID, Status: s.statuses[min(s.lookups, len(s.statuses))-1]}, nil
+}
+
+func (s *sequenceSDK) WatchTransaction(ctx context.Context, transactionID string) (<-chan universalsdk.TransactionStatus, error) {
+	return universalsdk.PollTransaction(ctx, s, transactionID, 5*time.Millisecond)
+}
+
+func TestWatchBridgeTransferActivity(t *testing.T) {
+	t.Run("ReturnsFinalStatus", func(t *testing.T) {
+		activities := NewSwapActivities(&sequenceSDK{statuses: []string{"pending", "pending", "completed"}}, nil)
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.WatchBridgeTransferActivity)
+
+		value, err := env.ExecuteActivity(activities.WatchBridgeTransferActivity, "swap-bridge", time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var status universalsdk.TransactionStatus
+		if err := value.Get(&status); err != nil {
+			t.Fatalf("Failed to read status: %v", err)
+		}
+		if status.Status != "completed" {
+			t.Errorf("Expected the completed status, got %s", status.Status)
+		}
+	})
+
+	t.Run("ReturnsLastStatusAtDeadline", func(t *testing.T) {
+		activities := NewSwapActivities(&sequenceSDK{statuses: []string{"pending"}}, nil)
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.WatchBridgeTransferActivity)
+
+		value, err := env.ExecuteActivity(activities.WatchBridgeTransferActivity, "swap-bridge", time.Now().Add(50*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var status universalsdk.TransactionStatus
+		if err := value.Get(&status); err != nil {
+			t.Fatalf("Failed to read status: %v", err)
+		}
+		if status.Status != "pending" {
+			t.Errorf("Expected the pending status at the deadline, got %s", status.Status)
+		}
+	})
+
+	t.Run("GivesUpWhenWorkerStops", func(t *testing.T) {
+		activities := NewSwapActivities(&sequenceSDK{statuses: []string{"pending"}}, nil)
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		env.RegisterActivity(activities.WatchBridgeTransferActivity)
+		stop := make(chan struct{})
+		close(stop)
+		env.SetWorkerStopChannel(stop)
+
+		_, err := env.ExecuteActivity(activities.WatchBridgeTransferActivity, "swap-bridge", time.Now().Add(time.Minute))
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != WorkerStopping || appErr.NonRetryable() {
+			t.Fatalf("Expected a retryable %s error, got %v", WorkerStopping, err)
+		}
+	})
+}
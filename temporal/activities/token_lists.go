@@ -0,0 +1,242 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	// coinGeckoTokenListURL serves CoinGecko's token list of a platform, with %s the platform ID
+	coinGeckoTokenListURL = "https://tokens.coingecko.com/%s/all.json"
+	// jupiterTokenListURL serves Jupiter's list of verified Solana tokens
+	jupiterTokenListURL = jupiterAPIURL + jupiterTokenListPath
+	// jupiterTokenListPath is the path of the verified token list under the Jupiter API's base URL
+	jupiterTokenListPath = "/tokens/v1/tagged/verified"
+)
+
+// coinGeckoPlatforms maps chain IDs to CoinGecko asset platform IDs
+var coinGeckoPlatforms = map[int64]string{
+	types.ChainIDEthereum:  "ethereum",
+	types.ChainIDOptimism:  "optimistic-ethereum",
+	types.ChainIDBinance:   "binance-smart-chain",
+	types.ChainIDPolygon:   "polygon-pos",
+	types.ChainIDBase:      "base",
+	types.ChainIDArbitrum:  "arbitrum-one",
+	types.ChainIDAvalanche: "avalanche",
+	types.ChainIDSolana:    "solana",
+}
+
+// CoinGeckoTokenList fetches token metadata from CoinGecko's per-platform token lists.
+// Only tokens CoinGecko tracks are listed, so they are all marked verified.
+type CoinGeckoTokenList struct {
+	source  *CoinGeckoPriceSource
+	listURL string // Token list URL format, with %s the platform ID
+}
+
+// NewCoinGeckoTokenList creates a CoinGecko token list sharing the price source's HTTP client, API key and rate-limit retry delay
+func NewCoinGeckoTokenList(source *CoinGeckoPriceSource) *CoinGeckoTokenList {
+	return &CoinGeckoTokenList{
+		source:  source,
+		listURL: coinGeckoTokenListURL,
+	}
+}
+
+// Name returns the token list name
+func (l *CoinGeckoTokenList) Name() string {
+	return string(types.PriceSourceCoinGecko)
+}
+
+// tokenList is a token list in the Uniswap token list format
+type tokenList struct {
+	Tokens []struct {
+		ChainID  int64  `json:"chainId"`
+		Address  string `json:"address"`
+		Name     string `json:"name"`
+		Symbol   string `json:"symbol"`
+		Decimals int    `json:"decimals"`
+		LogoURI  string `json:"logoURI"`
+	} `json:"tokens"`
+}
+
+// coinGeckoCoin is a coin in the coin list, with its contract address per platform
+type coinGeckoCoin struct {
+	ID        string            `json:"id"`
+	Symbol    string            `json:"symbol"`
+	Platforms map[string]string `json:"platforms"`
+}
+
+// FetchTokenList fetches the token lists of the chains' platforms and the coin list to resolve CoinGecko IDs
+func (l *CoinGeckoTokenList) FetchTokenList(ctx context.Context, chainIDs []int64) ([]types.TokenMetadata, error) {
+	var coins []coinGeckoCoin
+	if err := l.source.get(ctx, l.source.baseURL+"/coins/list?include_platform=true", &coins); err != nil {
+		return nil, err
+	}
+	// map[platform]map[address]coinID
+	coinIDs := make(map[string]map[string]string)
+	for _, coin := range coins {
+		for platform, address := range coin.Platforms {
+			if address == "" {
+				continue
+			}
+			if coinIDs[platform] == nil {
+				coinIDs[platform] = make(map[string]string)
+			}
+			coinIDs[platform][address] = coin.ID
+		}
+	}
+
+	var result []types.TokenMetadata
+	for _, chainID := range chainIDs {
+		chainID = types.CanonicalChainID(chainID)
+		platform, ok := coinGeckoPlatforms[chainID]
+		if !ok {
+			continue
+		}
+
+		var list tokenList
+		if err := l.source.get(ctx, fmt.Sprintf(l.listURL, platform), &list); err != nil {
+			return nil, err
+		}
+
+		// The coin list keeps EVM addresses lowercase and Solana addresses as they are
+		ids := make(map[string]string, len(coinIDs[platform]))
+		for address, id := range coinIDs[platform] {
+			ids[types.NormalizeTokenAddress(chainID, address)] = id
+		}
+		for _, token := range list.Tokens {
+			address := types.NormalizeTokenAddress(chainID, token.Address)
+			result = append(result, types.TokenMetadata{
+				ChainID:     chainID,
+				Address:     address,
+				Symbol:      token.Symbol,
+				Name:        token.Name,
+				Decimals:    token.Decimals,
+				LogoURI:     token.LogoURI,
+				CoinGeckoID: ids[address],
+				Verified:    true,
+			})
+		}
+	}
+	return result, nil
+}
+
+// JupiterTokenList fetches metadata of verified Solana tokens from Jupiter
+type JupiterTokenList struct {
+	httpClient *http.Client
+	listURL    string
+}
+
+// NewJupiterTokenList creates a new Jupiter token list
+func NewJupiterTokenList(httpClient *http.Client) *JupiterTokenList {
+	return &JupiterTokenList{
+		httpClient: httpClient,
+		listURL:    jupiterTokenListURL,
+	}
+}
+
+// SetBaseURL fetches the token list from a mirror of the Jupiter API instead of api.jup.ag
+func (l *JupiterTokenList) SetBaseURL(baseURL string) {
+	l.listURL = strings.TrimSuffix(baseURL, "/") + jupiterTokenListPath
+}
+
+// Name returns the token list name
+func (l *JupiterTokenList) Name() string {
+	return string(types.PriceSourceJupiter)
+}
+
+// jupiterToken is a token in Jupiter's token list
+type jupiterToken struct {
+	Address    string   `json:"address"`
+	Name       string   `json:"name"`
+	Symbol     string   `json:"symbol"`
+	Decimals   int      `json:"decimals"`
+	LogoURI    string   `json:"logoURI"`
+	Tags       []string `json:"tags"`
+	Extensions struct {
+		CoinGeckoID string `json:"coingeckoId"`
+	} `json:"extensions"`
+}
+
+// FetchTokenList fetches Jupiter's verified tokens when Solana is among the chains
+func (l *JupiterTokenList) FetchTokenList(ctx context.Context, chainIDs []int64) ([]types.TokenMetadata, error) {
+	wanted := false
+	for _, chainID := range chainIDs {
+		if types.CanonicalChainID(chainID) == types.ChainIDSolana {
+			wanted = true
+		}
+	}
+	if !wanted {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.listURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return nil, temporal.NewApplicationError(
+			fmt.Sprintf("Failed to fetch Jupiter token list: %v", err),
+			"JUPITER_API_ERROR")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("Jupiter tokens API returned status %d", resp.StatusCode),
+			"JUPITER_API_ERROR",
+			errors.New("non-200 status code"))
+	}
+
+	var tokens []jupiterToken
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("invalid Jupiter token list: %w", err)
+	}
+
+	result := make([]types.TokenMetadata, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, types.TokenMetadata{
+			ChainID:     types.ChainIDSolana,
+			Address:     token.Address,
+			Symbol:      token.Symbol,
+			Name:        token.Name,
+			Decimals:    token.Decimals,
+			LogoURI:     token.LogoURI,
+			CoinGeckoID: token.Extensions.CoinGeckoID,
+			Verified:    true, // The list only holds tokens tagged verified
+		})
+	}
+	return result, nil
+}
+
+// TokenMetadataActivities holds implementation of token metadata activities
+type TokenMetadataActivities struct {
+	service *services.TokenMetadataService
+}
+
+// NewTokenMetadataActivities creates a new instance of token metadata activities
+func NewTokenMetadataActivities(service *services.TokenMetadataService) *TokenMetadataActivities {
+	return &TokenMetadataActivities{service: service}
+}
+
+// RefreshTokenMetadataActivity refreshes the stored token metadata of the chains from the token lists
+func (a *TokenMetadataActivities) RefreshTokenMetadataActivity(ctx context.Context, chainIDs []int64) (int, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Refreshing token metadata", "chains", chainIDs)
+
+	count, err := a.service.Refresh(ctx, chainIDs)
+	if err != nil {
+		return 0, err
+	}
+	logger.Info("Refreshed token metadata", "tokens", count)
+	return count, nil
+}
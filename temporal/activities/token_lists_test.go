@@ -0,0 +1,68 @@
+package temporal_activities
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestRefreshTokenMetadataActivity(t *testing.T) {
+	const usdc = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	const solUSDC = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/coins/list":
+			json.NewEncoder(w).Encode([]coinGeckoCoin{
+				{ID: "usd-coin", Symbol: "usdc", Platforms: map[string]string{"ethereum": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"}},
+			})
+		case "/ethereum/all.json":
+			w.Write([]byte(`{"tokens":[{"chainId":1,"address":"` + usdc + `","name":"USD Coin","symbol":"USDC","decimals":6,"logoURI":"https://assets.example.com/usdc.png"}]}`))
+		case "/solana/all.json":
+			w.Write([]byte(`{"tokens":[]}`))
+		case "/jupiter":
+			w.Write([]byte(`[{"address":"` + solUSDC + `","name":"USD Coin","symbol":"USDC","decimals":6,"logoURI":"https://assets.example.com/sol-usdc.png","tags":["verified"],"extensions":{"coingeckoId":"usd-coin"}}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	coinGecko := NewCoinGeckoPriceSource(server.Client())
+	coinGecko.baseURL = server.URL
+	coinGeckoList := NewCoinGeckoTokenList(coinGecko)
+	coinGeckoList.listURL = server.URL + "/%s/all.json"
+	jupiterList := NewJupiterTokenList(server.Client())
+	jupiterList.listURL = server.URL + "/jupiter"
+
+	store := services.NewInMemoryTokenMetadataStore()
+	activities := NewTokenMetadataActivities(services.NewTokenMetadataService(store, coinGeckoList, jupiterList))
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.RefreshTokenMetadataActivity)
+
+	// The legacy Solana ID is canonicalized; Solana tokens come from Jupiter
+	value, err := env.ExecuteActivity(activities.RefreshTokenMetadataActivity, []int64{types.ChainIDEthereum, types.LegacyChainIDSolana})
+	if err != nil {
+		t.Fatalf("Failed to refresh token metadata: %v", err)
+	}
+	var count int
+	if err := value.Get(&count); err != nil || count != 2 {
+		t.Fatalf("Expected 2 tokens, got %d (%v)", count, err)
+	}
+
+	found, _ := store.FindTokenMetadata(t.Context(), types.ChainIDEthereum, []string{"USDC"})
+	if len(found) != 1 || found[0].CoinGeckoID != "usd-coin" || found[0].LogoURI != "https://assets.example.com/usdc.png" || !found[0].Verified {
+		t.Errorf("Expected USDC metadata with its CoinGecko ID, got %+v", found)
+	}
+	found, _ = store.FindTokenMetadata(t.Context(), types.ChainIDSolana, []string{"USDC"})
+	if len(found) != 1 || found[0].Address != solUSDC || found[0].CoinGeckoID != "usd-coin" {
+		t.Errorf("Expected Solana USDC metadata with its case-sensitive address, got %+v", found)
+	}
+}
@@ -0,0 +1,52 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// WebhookActivities holds implementation of client webhook activities
+type WebhookActivities struct {
+	client  *http.Client
+	secrets services.WebhookSecretStore
+}
+
+// NewWebhookActivities creates webhook activities sending with client, signed with each key's secret in secrets
+func NewWebhookActivities(client *http.Client, secrets services.WebhookSecretStore) *WebhookActivities {
+	return &WebhookActivities{
+		client:  client,
+		secrets: secrets,
+	}
+}
+
+// NotifyWebhookActivity POSTs a finished swap's result to its callback URL. Failed deliveries fail the activity so
+// they are retried; a swap whose key has no secret to sign with is not sent.
+func (a *WebhookActivities) NotifyWebhookActivity(ctx context.Context, request types.SwapRequest, result types.SwapResult) error {
+	secret, err := services.WebhookSecret(ctx, a.secrets, request.CallbackKeyID)
+	if errors.Is(err, types.ErrWebhookSecretNotFound) {
+		return temporal.NewNonRetryableApplicationError("No webhook secret to sign the callback with", "WEBHOOK_SECRET_NOT_FOUND", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get webhook secret: %w", err)
+	}
+
+	webhook := services.NewSwapWebhook(result)
+	body, err := json.Marshal(webhook)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError("Failed to encode callback", "INVALID_CALLBACK", err)
+	}
+
+	if err := services.PostWebhook(ctx, a.client, request.CallbackURL, secret, body); err != nil {
+		return fmt.Errorf("failed to send callback of swap %s: %w", result.RequestID, err)
+	}
+	activity.GetLogger(ctx).Info("Sent swap callback", "requestID", result.RequestID, "event", webhook.Event)
+	return nil
+}
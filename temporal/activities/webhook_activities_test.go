@@ -0,0 +1,64 @@
+package temporal_activities
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestNotifyWebhookActivity(t *testing.T) {
+	var received []services.SwapWebhook
+	var signatures []string
+	status := http.StatusOK
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var webhook services.SwapWebhook
+		json.NewDecoder(r.Body).Decode(&webhook)
+		received = append(received, webhook)
+		signatures = append(signatures, r.Header.Get(services.WebhookSignatureHeader))
+		w.WriteHeader(status)
+	}))
+	defer target.Close()
+
+	secrets := services.NewInMemoryWebhookSecretStore()
+	if err := secrets.SetWebhookSecret(context.Background(), "key-1", "registered"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	activities := NewWebhookActivities(target.Client(), secrets)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.NotifyWebhookActivity)
+
+	request := types.SwapRequest{RequestID: "swap-1", CallbackURL: target.URL, CallbackKeyID: "key-1"}
+	result := types.SwapResult{RequestID: "swap-1", Success: true, Status: types.SwapStatusCompleted}
+	if _, err := env.ExecuteActivity(activities.NotifyWebhookActivity, request, result); err != nil {
+		t.Fatalf("Failed to send callback: %v", err)
+	}
+	if len(received) != 1 || received[0].Event != services.SwapCompletedWebhookEvent || received[0].RequestID != "swap-1" {
+		t.Fatalf("Expected a swap.completed callback, got %+v", received)
+	}
+	if signatures[0] == "" {
+		t.Error("Expected the callback to be signed")
+	}
+
+	// Refused deliveries fail the activity, so they are retried
+	status = http.StatusServiceUnavailable
+	if _, err := env.ExecuteActivity(activities.NotifyWebhookActivity, request, result); err == nil {
+		t.Error("Expected a refused callback to fail")
+	}
+
+	// Without a secret to sign with, the callback is not sent
+	request.CallbackKeyID = "key-2"
+	if _, err := env.ExecuteActivity(activities.NotifyWebhookActivity, request, result); err == nil {
+		t.Error("Expected a callback without a secret to fail")
+	}
+	if len(received) != 2 {
+		t.Errorf("Expected the unsigned callback not to be sent, got %d deliveries", len(received))
+	}
+}
@@ -0,0 +1,44 @@
+package temporal_activities
+
+import (
+	"context"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/worker"
+)
+
+// WorkerOptions returns the options of a swap or price worker: its concurrency and stop timeout from cfg, and the
+// interceptors, after one skipping activities whose context is already done
+func WorkerOptions(cfg temporal_config.WorkerConfig, interceptors ...interceptor.WorkerInterceptor) worker.Options {
+	return worker.Options{
+		MaxConcurrentActivityExecutionSize:     cfg.MaxConcurrentActivities,
+		MaxConcurrentWorkflowTaskExecutionSize: cfg.MaxConcurrentWorkflowTasks,
+		WorkerStopTimeout:                      cfg.StopTimeout,
+		Interceptors:                           append([]interceptor.WorkerInterceptor{&CancellationInterceptor{}}, interceptors...),
+	}
+}
+
+// CancellationInterceptor fails an activity attempt whose context is done before it starts, so a worker draining on
+// stop, or an attempt cancelled or timed out while queued, does no work the workflow no longer waits for
+type CancellationInterceptor struct {
+	interceptor.WorkerInterceptorBase
+}
+
+// InterceptActivity checks the activity's context before running it
+func (i *CancellationInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	return &activityCancellationCheck{ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next}}
+}
+
+// activityCancellationCheck runs an activity only while its context is live
+type activityCancellationCheck struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+// ExecuteActivity returns the context's error instead of running a cancelled activity
+func (a *activityCancellationCheck) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.Next.ExecuteActivity(ctx, in)
+}
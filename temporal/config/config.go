@@ -1,9 +1,20 @@
 package temporal_config
 
 import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/infinity-dex/services/types"
 	"github.com/spf13/viper"
 )
 
@@ -26,14 +37,99 @@ type Config struct {
 
 	// Swap configuration
 	Swap SwapConfig `mapstructure:"SWAP"`
+
+	// Bridges cross-chain swaps may use besides Universal and Across, and how each corridor chooses between them
+	Bridges BridgesConfig `mapstructure:"BRIDGES"`
+
+	// Developer sandbox configuration
+	Sandbox SandboxConfig `mapstructure:"SANDBOX"`
+
+	// Admin configuration
+	Admin AdminConfig `mapstructure:"ADMIN"`
+
+	// Price oracle configuration
+	Prices PricesConfig `mapstructure:"PRICES"`
+
+	// Database migration configuration; connections are configured by the DB_* environment variables
+	Database DatabaseConfig `mapstructure:"DATABASE"`
+
+	// Circuit breakers tripped by failing SDK calls and chain RPCs
+	Breakers BreakersConfig `mapstructure:"BREAKERS"`
+
+	// Activity retry policies and the retry budget of each swap
+	Retries RetriesConfig `mapstructure:"RETRIES"`
+
+	// KYC/AML policy configuration
+	Compliance ComplianceConfig `mapstructure:"COMPLIANCE"`
+
+	// Swap attestation configuration
+	Attestation AttestationConfig `mapstructure:"ATTESTATION"`
+
+	// Token listing request configuration
+	Listings ListingsConfig `mapstructure:"LISTINGS"`
+
+	// Multi-region deployment configuration
+	Region RegionConfig `mapstructure:"REGION"`
+
+	// On-chain transaction execution configuration
+	Execution ExecutionConfig `mapstructure:"EXECUTION"`
+
+	// Client webhook configuration
+	Webhooks WebhooksConfig `mapstructure:"WEBHOOKS"`
+
+	// Email notification configuration
+	Notifications NotificationsConfig `mapstructure:"NOTIFICATIONS"`
+
+	// In-process event bus configuration
+	Events EventsConfig `mapstructure:"EVENTS"`
+
+	// Error reporting configuration
+	Errors ErrorsConfig `mapstructure:"ERRORS"`
+
+	// Dead-lettered swap scan configuration
+	DeadLetters DeadLettersConfig `mapstructure:"DEAD_LETTERS"`
+
+	// Outbound HTTP client configuration
+	Outbound OutboundConfig `mapstructure:"OUTBOUND"`
+
+	// Liquidity pools every process creates on startup unless they exist
+	Pools []PoolConfig `mapstructure:"POOLS"`
 }
 
 // TemporalConfig contains Temporal-specific configuration
 type TemporalConfig struct {
-	HostPort    string        `mapstructure:"HOST_PORT"`
-	Namespace   string        `mapstructure:"NAMESPACE"`
-	TaskQueue   string        `mapstructure:"TASK_QUEUE"`
-	WorkflowTTL time.Duration `mapstructure:"WORKFLOW_TTL"`
+	HostPort    string            `mapstructure:"HOST_PORT"`
+	Namespace   string            `mapstructure:"NAMESPACE"`
+	TaskQueue   string            `mapstructure:"TASK_QUEUE"`
+	WorkflowTTL time.Duration     `mapstructure:"WORKFLOW_TTL"`
+	Payloads    PayloadsConfig    `mapstructure:"PAYLOADS"`
+	Worker      WorkerConfig      `mapstructure:"WORKER"`
+	ChainQueues ChainQueuesConfig `mapstructure:"CHAIN_QUEUES"`
+}
+
+// ChainQueuesConfig partitions swap activities that call a chain onto a task queue per chain, TASK_QUEUE suffixed
+// with the chain's name, so one chain's outage only backs up its own queue
+type ChainQueuesConfig struct {
+	Enabled bool     `mapstructure:"ENABLED"`
+	Chains  []string `mapstructure:"CHAINS"` // Chains, as named in CHAINS, given their own queue; empty gives every chain one
+	Sticky  bool     `mapstructure:"STICKY"` // Run all of a swap's chain activities on its source chain's queue
+}
+
+// WorkerConfig sizes the swap and price workers and bounds how long they drain running activities when stopped
+type WorkerConfig struct {
+	MaxConcurrentActivities    int           `mapstructure:"MAX_CONCURRENT_ACTIVITIES"`     // Activities a worker runs at once; 0 uses the SDK default
+	MaxConcurrentWorkflowTasks int           `mapstructure:"MAX_CONCURRENT_WORKFLOW_TASKS"` // Workflow tasks a worker runs at once; 0 uses the SDK default
+	StopTimeout                time.Duration `mapstructure:"STOP_TIMEOUT"`                  // How long a stopping worker waits for running activities before cancelling them
+}
+
+// PayloadsConfig sets how workflow and activity payloads are encoded. The API server and every worker must share
+// it: a process can only read payloads stored in a claim-check store it can reach.
+type PayloadsConfig struct {
+	CompressThreshold   int    `mapstructure:"COMPRESS_THRESHOLD"`    // Bytes from which payloads are gzipped; 0 never compresses
+	ClaimCheckThreshold int    `mapstructure:"CLAIM_CHECK_THRESHOLD"` // Bytes from which encoded payloads are stored in the claim-check store and passed by reference
+	MaxSize             int    `mapstructure:"MAX_SIZE"`              // Bytes over which payloads are refused; Temporal's own limit is 2 MiB
+	ClaimCheckDir       string `mapstructure:"CLAIM_CHECK_DIR"`       // Store claim-checked payloads in this shared directory
+	ClaimCheckURL       string `mapstructure:"CLAIM_CHECK_URL"`       // Or in this object storage bucket URL, with PUT and GET per object; only one store may be set
 }
 
 // UniversalConfig contains Universal.xyz-specific configuration
@@ -41,6 +137,19 @@ type UniversalConfig struct {
 	APIURL       string `mapstructure:"API_URL"`
 	APIKey       string `mapstructure:"API_KEY"`
 	MinTokenWrap string `mapstructure:"MIN_TOKEN_WRAP"`
+	MockScenario string `mapstructure:"MOCK_SCENARIO"` // YAML file of the seed, latency and faults the mock SDK replays; empty keeps its random failures
+
+	Middleware SDKMiddlewareConfig `mapstructure:"MIDDLEWARE"`
+}
+
+// SDKMiddlewareConfig holds the middleware every Universal SDK call of the API server and workers passes through
+type SDKMiddlewareConfig struct {
+	LogCalls         bool          `mapstructure:"LOG_CALLS"`          // Log each call with its latency
+	RetryAttempts    int           `mapstructure:"RETRY_ATTEMPTS"`     // Attempts of a failed read in all; wraps, unwraps and transfers are never retried
+	RetryBackoff     time.Duration `mapstructure:"RETRY_BACKOFF"`      // Wait before the first retry, doubling before each later one
+	WrappedTokensTTL time.Duration `mapstructure:"WRAPPED_TOKENS_TTL"` // How long each chain's wrapped tokens are cached; 0 disables the cache
+	FeeEstimateTTL   time.Duration `mapstructure:"FEE_ESTIMATE_TTL"`   // How long a fee estimate is cached per token pair and amount; 0 disables the cache
+	StatsInterval    time.Duration `mapstructure:"STATS_INTERVAL"`     // How often each method's call counts are logged; 0 never logs them
 }
 
 // ChainConfig holds blockchain-specific configuration
@@ -51,21 +160,551 @@ type ChainConfig struct {
 	ExplorerURL      string   `mapstructure:"EXPLORER_URL"`
 	UniversalAddress string   `mapstructure:"UNIVERSAL_ADDRESS"`
 	DEXAddress       string   `mapstructure:"DEX_ADDRESS"`
+	RouterAddress    string   `mapstructure:"ROUTER_ADDRESS"` // Uniswap V2-compatible router listing checks simulate sells through
 	WrappedTokens    []string `mapstructure:"WRAPPED_TOKENS"`
+	Confirmations    int      `mapstructure:"CONFIRMATIONS"`     // Blocks a deposit waits for before it is treated as final
+	DepositAddress   string   `mapstructure:"DEPOSIT_ADDRESS"`   // Address deposit-funded swaps send their source tokens to; empty disables them
+	Features         []string `mapstructure:"FEATURES"`          // Supported ChainFeature values; empty supports all of them
+	BundlerURL       string   `mapstructure:"BUNDLER_URL"`       // ERC-4337 bundler smart accounts swap through; empty refuses smart account swaps
+	PrivateRelayURL  string   `mapstructure:"PRIVATE_RELAY_URL"` // Flashbots Protect-style RPC private swaps are sent through; empty refuses them
+
+	// Gas sponsorship: the protocol pays the gas of swaps into this chain that ask it to, recouped from their output
+	SponsorGas         bool    `mapstructure:"SPONSOR_GAS"`
+	SponsorMaxSwapUSD  float64 `mapstructure:"SPONSOR_MAX_SWAP_USD"`  // Most gas sponsored for one swap; 0 is unlimited
+	SponsorDailyCapUSD float64 `mapstructure:"SPONSOR_DAILY_CAP_USD"` // Most gas sponsored over any 24 hours; 0 is unlimited
+
+	// Swap limits: the USD value of swaps from this chain; 0 is unlimited
+	SwapMaxUSD        float64 `mapstructure:"SWAP_MAX_USD"`         // Most one swap may be worth
+	SwapDailyLimitUSD float64 `mapstructure:"SWAP_DAILY_LIMIT_USD"` // Most the chain's swaps may be worth over any 24 hours
+
+	// PriceFeeds maps token symbols to Chainlink USD aggregator addresses on this chain
+	PriceFeeds map[string]string `mapstructure:"PRICE_FEEDS"`
+}
+
+// Chain features that can be enabled per chain
+const (
+	ChainFeatureWrap      = "wrap"
+	ChainFeatureSwap      = "swap"
+	ChainFeatureBridgeIn  = "bridge-in"
+	ChainFeatureBridgeOut = "bridge-out"
+)
+
+// AllChainFeatures lists every chain feature
+var AllChainFeatures = []string{ChainFeatureWrap, ChainFeatureSwap, ChainFeatureBridgeIn, ChainFeatureBridgeOut}
+
+// SupportedFeatures returns the chain's enabled features, all of them when none are configured
+func (c ChainConfig) SupportedFeatures() []string {
+	if len(c.Features) == 0 {
+		return append([]string(nil), AllChainFeatures...)
+	}
+	return c.Features
+}
+
+// CAIP2 returns the chain's CAIP-2 identifier
+func (c ChainConfig) CAIP2() string {
+	return types.ChainCAIP2(c.ChainID)
+}
+
+// RPCURLs returns the RPC endpoints of every configured chain by chain ID
+func (c Config) RPCURLs() map[int64][]string {
+	urls := make(map[int64][]string, len(c.Chains))
+	for _, chain := range c.Chains {
+		urls[chain.ChainID] = chain.RPC
+	}
+	return urls
+}
+
+// BundlerURLs returns the bundler endpoint of each chain that has one, by chain ID
+func (c Config) BundlerURLs() map[int64][]string {
+	urls := make(map[int64][]string, len(c.Chains))
+	for _, chain := range c.Chains {
+		if chain.BundlerURL != "" {
+			urls[chain.ChainID] = []string{chain.BundlerURL}
+		}
+	}
+	return urls
+}
+
+// PrivateRelayURLs returns the private relay endpoint of each chain that has one, by chain ID
+func (c Config) PrivateRelayURLs() map[int64][]string {
+	urls := make(map[int64][]string, len(c.Chains))
+	for _, chain := range c.Chains {
+		if chain.PrivateRelayURL != "" {
+			urls[chain.ChainID] = []string{chain.PrivateRelayURL}
+		}
+	}
+	return urls
+}
+
+// ChainTaskQueue returns the task queue of the activities calling a chain named as in CHAINS
+func (c Config) ChainTaskQueue(name string) string {
+	return c.Temporal.TaskQueue + "-" + name
+}
+
+// PartitionedChains returns the names of the chains whose activities run on their own task queue, sorted, or none
+// when TEMPORAL.CHAIN_QUEUES is off
+func (c Config) PartitionedChains() []string {
+	if !c.Temporal.ChainQueues.Enabled {
+		return nil
+	}
+	names := c.Temporal.ChainQueues.Chains
+	if len(names) == 0 {
+		names = make([]string, 0, len(c.Chains))
+		for name := range c.Chains {
+			names = append(names, name)
+		}
+	}
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	return names
+}
+
+// ChainTaskQueues returns the task queues swaps started in a region run their chain activities on, or nil when
+// TEMPORAL.CHAIN_QUEUES is off
+func (c Config) ChainTaskQueues(region string) *types.ChainTaskQueues {
+	names := c.PartitionedChains()
+	if len(names) == 0 {
+		return nil
+	}
+	queues := &types.ChainTaskQueues{Queues: make(map[int64]string, len(names)), Sticky: c.Temporal.ChainQueues.Sticky}
+	for _, name := range names {
+		queues.Queues[types.CanonicalChainID(c.Chains[name].ChainID)] = RegionScoped(c.ChainTaskQueue(name), region)
+	}
+	return queues
+}
+
+// ChainIDs returns the IDs of the chains named as in CHAINS
+func (c Config) ChainIDs(names []string) []int64 {
+	chainIDs := make([]int64, 0, len(names))
+	for _, name := range names {
+		chainIDs = append(chainIDs, c.Chains[name].ChainID)
+	}
+	return chainIDs
+}
+
+// RouterAddresses returns the configured router of each chain that has one
+func (c Config) RouterAddresses() map[int64]string {
+	routers := make(map[int64]string, len(c.Chains))
+	for _, chain := range c.Chains {
+		if chain.RouterAddress != "" {
+			routers[chain.ChainID] = chain.RouterAddress
+		}
+	}
+	return routers
+}
+
+// GasSponsorPolicies returns the gas sponsorship caps of each chain sponsoring gas, by chain ID
+func (c Config) GasSponsorPolicies() map[int64]types.GasSponsorPolicy {
+	policies := make(map[int64]types.GasSponsorPolicy)
+	for _, chain := range c.Chains {
+		if chain.SponsorGas {
+			policies[chain.ChainID] = types.GasSponsorPolicy{MaxSwapUSD: chain.SponsorMaxSwapUSD, DailyCapUSD: chain.SponsorDailyCapUSD}
+		}
+	}
+	return policies
+}
+
+// PriceImpactLimits returns the price impact limit of every pool, SWAP.MAX_PRICE_IMPACT for the pools without
+// their own
+func (c Config) PriceImpactLimits() types.PriceImpactLimits {
+	limits := types.PriceImpactLimits{Default: c.Swap.MaxPriceImpact, Pools: make(map[string]float64)}
+	for _, pool := range c.Pools {
+		if pool.MaxPriceImpact > 0 {
+			limits.Pools[pool.ID] = pool.MaxPriceImpact
+		}
+	}
+	return limits
+}
+
+// SwapLimits returns the limits on the USD value of swaps: SWAP.MAX_SWAP_AMOUNT, the daily limit of each source
+// address, and the limits of each pair and chain that has them
+func (c Config) SwapLimits() types.SwapLimits {
+	// LoadConfig has already refused a MAX_SWAP_AMOUNT that isn't a number
+	maxSwapUSD, _ := strconv.ParseFloat(strings.TrimSpace(c.Swap.MaxSwapAmount), 64)
+	limits := types.SwapLimits{
+		MaxSwapUSD:   maxSwapUSD,
+		UserDailyUSD: c.Swap.UserDailyLimitUSD,
+		Pairs:        make(map[string]types.SwapAmountLimit),
+		Chains:       make(map[int64]types.SwapAmountLimit),
+	}
+	for _, pair := range c.Swap.PairLimits {
+		if symbols := strings.Split(pair.Pair, "/"); len(symbols) == 2 {
+			limits.Pairs[types.SwapPair(symbols[0], symbols[1])] = types.SwapAmountLimit{MaxSwapUSD: pair.MaxSwapUSD, DailyUSD: pair.DailyLimitUSD}
+		}
+	}
+	for _, chain := range c.Chains {
+		if chain.SwapMaxUSD > 0 || chain.SwapDailyLimitUSD > 0 {
+			limits.Chains[chain.ChainID] = types.SwapAmountLimit{MaxSwapUSD: chain.SwapMaxUSD, DailyUSD: chain.SwapDailyLimitUSD}
+		}
+	}
+	return limits
 }
 
 // ServerConfig holds API server configuration
 type ServerConfig struct {
 	Port            int           `mapstructure:"PORT"`
+	GRPCPort        int           `mapstructure:"GRPC_PORT"` // Port of the gRPC API; 0 serves REST only
 	CORSAllowOrigin string        `mapstructure:"CORS_ALLOW_ORIGIN"`
 	Timeout         time.Duration `mapstructure:"TIMEOUT"`
+
+	ReadinessTimeout         time.Duration `mapstructure:"READINESS_TIMEOUT"`           // How long /readyz waits for each dependency
+	PriceSourceCheckInterval time.Duration `mapstructure:"PRICE_SOURCE_CHECK_INTERVAL"` // How long /readyz reuses its last check of the price sources, sparing their rate limits
+	TokenFetchTimeout        time.Duration `mapstructure:"TOKEN_FETCH_TIMEOUT"`         // How long /tokens waits for each chain's tokens before listing the others without them
+	TokenCacheTTL            time.Duration `mapstructure:"TOKEN_CACHE_TTL"`             // How long each chain's wrapped tokens are served from memory; 0 fetches them on every request
+	Compression              bool          `mapstructure:"COMPRESSION"`                 // Compress responses with gzip or deflate for clients accepting them
 }
 
 // SwapConfig holds swap-related configuration
 type SwapConfig struct {
 	DefaultSlippage float64       `mapstructure:"DEFAULT_SLIPPAGE"`
-	MaxSwapAmount   string        `mapstructure:"MAX_SWAP_AMOUNT"`
+	MaxSwapAmount   string        `mapstructure:"MAX_SWAP_AMOUNT"` // Most any swap may be worth, in USD; empty or 0 is unlimited
 	MaxSwapTime     time.Duration `mapstructure:"MAX_SWAP_TIME"`
+	MaxPriceAge     time.Duration `mapstructure:"MAX_PRICE_AGE"`    // Quotes are priced with the oracle and rejected when its prices are older; 0 quotes at demo rates
+	GasMultiplier   float64       `mapstructure:"GAS_MULTIPLIER"`   // Safety margin live gas estimates are multiplied by
+	AcrossAPIURL    string        `mapstructure:"ACROSS_API_URL"`   // Cross-chain swaps are quoted through Across as well as Universal; empty quotes Universal only
+	QuoteTTL        time.Duration `mapstructure:"QUOTE_TTL"`        // How long quotes signed with the attestation key stay firm; 0 disables firm quotes
+	MaxPriceImpact  float64       `mapstructure:"MAX_PRICE_IMPACT"` // Percent a swap may move its pool's price unless forced, for pools without their own limit; 0 is unlimited
+
+	// Shadow pricing compares our quotes for pool pairs against DEX aggregators' without affecting quoting
+	ParaSwapAPIURL      string        `mapstructure:"PARASWAP_API_URL"`      // Empty disables shadow pricing
+	ShadowPriceInterval time.Duration `mapstructure:"SHADOW_PRICE_INTERVAL"` // How often every pool pair is compared
+
+	// Large swaps may be split into tranches executed one after another, each quoted again
+	MaxTranches     int           `mapstructure:"MAX_TRANCHES"`      // Most tranches a swap may be split into
+	TrancheDelay    time.Duration `mapstructure:"TRANCHE_DELAY"`     // Wait between tranches when a swap doesn't choose one
+	MaxTrancheDelay time.Duration `mapstructure:"MAX_TRANCHE_DELAY"` // Longest wait between tranches a swap may choose
+
+	// Daily swap limits on the USD value swapped over any 24 hours, on top of MAX_SWAP_AMOUNT and each chain's limits
+	UserDailyLimitUSD float64               `mapstructure:"USER_DAILY_LIMIT_USD"` // Per source address; 0 is unlimited
+	PairLimits        []SwapPairLimitConfig `mapstructure:"PAIR_LIMITS"`
+}
+
+// SwapPairLimitConfig limits the USD value of swaps between a pair of tokens, whichever way round; 0 is unlimited
+type SwapPairLimitConfig struct {
+	Pair          string  `mapstructure:"PAIR"`            // Token symbols, e.g. ETH/USDC
+	MaxSwapUSD    float64 `mapstructure:"MAX_SWAP_USD"`    // Most one swap may be worth
+	DailyLimitUSD float64 `mapstructure:"DAILY_LIMIT_USD"` // Most the pair's swaps may be worth over any 24 hours
+}
+
+// BridgesConfig holds the bridge providers added to Universal and Across, and the corridors between chains that
+// choose their bridge other than by the lowest fee among every bridge
+type BridgesConfig struct {
+	Providers []BridgeProviderConfig `mapstructure:"PROVIDERS"`
+	Corridors []BridgeCorridorConfig `mapstructure:"CORRIDORS"`
+}
+
+// BridgeProviderConfig is a bridge, such as Wormhole, Axelar or LayerZero, quoted and tracked by a service speaking
+// the HTTP bridge protocol
+type BridgeProviderConfig struct {
+	Name   string   `mapstructure:"NAME"`   // Routed, scored and reported by this name
+	URL    string   `mapstructure:"URL"`    // The bridge protocol service
+	Chains []string `mapstructure:"CHAINS"` // Chains the bridge connects, named as in CHAINS
+}
+
+// BridgeCorridorConfig is how transfers from one chain to another choose their bridge
+type BridgeCorridorConfig struct {
+	From    string   `mapstructure:"FROM"`    // Source chain, named as in CHAINS
+	To      string   `mapstructure:"TO"`      // Destination chain, named as in CHAINS
+	Prefer  string   `mapstructure:"PREFER"`  // cheapest (default) or fastest
+	Bridges []string `mapstructure:"BRIDGES"` // Bridges the corridor may use; empty allows every bridge
+}
+
+// SandboxConfig holds developer sandbox configuration
+type SandboxConfig struct {
+	Enabled         bool     `mapstructure:"ENABLED"`
+	APIKeys         []string `mapstructure:"API_KEYS"`         // API keys whose requests run in the sandbox
+	StartingBalance string   `mapstructure:"STARTING_BALANCE"` // Fake balance credited per address and token
+}
+
+// AdminConfig holds operator admin API configuration
+type AdminConfig struct {
+	APIKeys      []string        `mapstructure:"API_KEYS"`       // API keys allowed to run admin actions, audited by key fingerprint
+	Operators    []AdminOperator `mapstructure:"OPERATORS"`      // Named operator keys, audited by name; no keys at all disables the admin API
+	AuditLogPath string          `mapstructure:"AUDIT_LOG_PATH"` // Defaults to ~/.infinity-dex/audit.log
+
+	// Passkey second factor required on top of the API key
+	WebAuthn WebAuthnConfig `mapstructure:"WEBAUTHN"`
+}
+
+// AdminOperator is an operator with their own admin API key
+type AdminOperator struct {
+	Name   string `mapstructure:"NAME"`    // Recorded as the operator of every action run with the key
+	APIKey string `mapstructure:"API_KEY"` // Admin API key
+}
+
+// WebAuthnConfig holds the admin API's passkey second factor configuration
+type WebAuthnConfig struct {
+	Enabled         bool          `mapstructure:"ENABLED"`
+	RPID            string        `mapstructure:"RP_ID"`            // Relying party ID, the domain passkeys are scoped to
+	RPName          string        `mapstructure:"RP_NAME"`          // Shown by the authenticator during enrollment
+	Origins         []string      `mapstructure:"ORIGINS"`          // Origins allowed to run ceremonies
+	CredentialsPath string        `mapstructure:"CREDENTIALS_PATH"` // Defaults to ~/.infinity-dex/webauthn.json
+	SessionTTL      time.Duration `mapstructure:"SESSION_TTL"`      // How long a passkey login lasts
+	// Hex SHA-256 of one-time tokens, handed to operators out of band, that enrolling an API key's first passkey needs
+	EnrollmentTokens []string `mapstructure:"ENROLLMENT_TOKENS"`
+}
+
+// PricesConfig holds price oracle configuration
+type PricesConfig struct {
+	SanityMaxDeviationPct float64 `mapstructure:"SANITY_MAX_DEVIATION_PCT"` // Max % a merged price may deviate from the CEX reference; 0 disables the check
+	SanityAction          string  `mapstructure:"SANITY_ACTION"`            // "drop" or "flag" prices outside the band
+
+	CoinGeckoAPIKey string `mapstructure:"COINGECKO_API_KEY"` // Empty uses the keyless public API
+	CoinGeckoPlan   string `mapstructure:"COINGECKO_PLAN"`    // "demo" or "pro", the plan CoinGeckoAPIKey belongs to
+
+	CacheTTL  time.Duration         `mapstructure:"CACHE_TTL"`  // How long cached prices stay fresh by default
+	CacheTTLs []PriceCacheTTLConfig `mapstructure:"CACHE_TTLS"` // Per-source and per-token overrides of CacheTTL
+
+	BaseURLs []PriceBaseURLConfig `mapstructure:"BASE_URLS"` // Mirrors price sources' APIs are called at instead
+
+	UpdateInterval         time.Duration `mapstructure:"UPDATE_INTERVAL"`           // Time between scheduled price updates
+	UpdateRunsPerExecution int           `mapstructure:"UPDATE_RUNS_PER_EXECUTION"` // Updates before the update workflow continues as new, bounding its history
+	UpdateSchedule         bool          `mapstructure:"UPDATE_SCHEDULE"`           // Run updates from a Temporal Schedule instead of the long-running update workflow
+
+	DBBatchSize int `mapstructure:"DB_BATCH_SIZE"` // Prices upserted together when saving to the database
+
+	MemoryCacheSize int           `mapstructure:"MEMORY_CACHE_SIZE"` // Token prices the server keeps in memory for quotes
+	MemoryCacheTTL  time.Duration `mapstructure:"MEMORY_CACHE_TTL"`  // How long the server keeps a price in memory; 0 disables the memory cache
+	RedisURL        string        `mapstructure:"REDIS_URL"`         // Redis the servers share prices through, e.g. redis://localhost:6379/0; empty skips it
+	RedisTTL        time.Duration `mapstructure:"REDIS_TTL"`         // How long a price stays in Redis
+	LiveFetch       bool          `mapstructure:"LIVE_FETCH"`        // Fetch prices missing from the caches and database through the price worker
+}
+
+// DatabaseConfig holds database migration configuration
+type DatabaseConfig struct {
+	AutoMigrate bool `mapstructure:"AUTO_MIGRATE"` // Apply pending migrations when the price worker starts; otherwise run `infctl migrate up`
+}
+
+// BreakersConfig holds the circuit breakers that reject swaps on chains whose SDK calls or RPCs keep failing
+type BreakersConfig struct {
+	Enabled bool          `mapstructure:"ENABLED"`
+	Chain   BreakerConfig `mapstructure:"CHAIN"`  // Each chain's breaker, tripped by its SDK calls and RPCs
+	Global  BreakerConfig `mapstructure:"GLOBAL"` // Tripped by SDK calls on any chain; rejects swaps on every chain
+}
+
+// BreakerConfig sets when a circuit breaker trips and how long it stays open
+type BreakerConfig struct {
+	Window         time.Duration `mapstructure:"WINDOW"`           // Calls are counted over this long
+	MinCalls       int           `mapstructure:"MIN_CALLS"`        // Calls in the window before the breaker can trip
+	FailureRatePct float64       `mapstructure:"FAILURE_RATE_PCT"` // % of the calls in the window that trips the breaker when they fail
+	Cooldown       time.Duration `mapstructure:"COOLDOWN"`         // How long a tripped breaker rejects swaps before letting calls through again
+}
+
+// RetriesConfig holds how each kind of activity is retried, and how long a swap's activities may take altogether
+type RetriesConfig struct {
+	Wrap       RetryConfig   `mapstructure:"WRAP"`        // Wrapping tokens into their Universal version
+	Transfer   RetryConfig   `mapstructure:"TRANSFER"`    // Moving tokens into and out of pools
+	Swap       RetryConfig   `mapstructure:"SWAP"`        // Quoting, checking and executing swaps
+	Unwrap     RetryConfig   `mapstructure:"UNWRAP"`      // Unwrapping Universal tokens
+	PriceFetch RetryConfig   `mapstructure:"PRICE_FETCH"` // Fetching, merging and saving prices
+	Budget     time.Duration `mapstructure:"BUDGET"`      // How long a swap's activities may take, retries included; 0 for no limit
+}
+
+// RetryConfig sets how an activity is retried
+type RetryConfig struct {
+	InitialInterval    time.Duration `mapstructure:"INITIAL_INTERVAL"`    // Wait before the first retry
+	BackoffCoefficient float64       `mapstructure:"BACKOFF_COEFFICIENT"` // Each wait is this many times the last
+	MaximumInterval    time.Duration `mapstructure:"MAXIMUM_INTERVAL"`    // Longest wait between retries
+	MaximumAttempts    int32         `mapstructure:"MAXIMUM_ATTEMPTS"`    // Attempts in all, the first included
+}
+
+// Policy returns the retry policy workflows are started with
+func (c RetryConfig) Policy() types.RetryPolicy {
+	return types.RetryPolicy{
+		InitialInterval:    c.InitialInterval,
+		BackoffCoefficient: c.BackoffCoefficient,
+		MaximumInterval:    c.MaximumInterval,
+		MaximumAttempts:    c.MaximumAttempts,
+	}
+}
+
+// Policies returns the retry policies and budget workflows are started with
+func (c RetriesConfig) Policies() *types.RetryPolicies {
+	return &types.RetryPolicies{
+		Wrap:       c.Wrap.Policy(),
+		Transfer:   c.Transfer.Policy(),
+		Swap:       c.Swap.Policy(),
+		Unwrap:     c.Unwrap.Policy(),
+		PriceFetch: c.PriceFetch.Policy(),
+		Budget:     c.Budget,
+	}
+}
+
+// PriceCacheTTLConfig overrides the cache TTL of one price source or token.
+// Set either Source or Symbol; a token's TTL takes precedence over its source's.
+type PriceCacheTTLConfig struct {
+	Source string        `mapstructure:"SOURCE"`
+	Symbol string        `mapstructure:"SYMBOL"`
+	TTL    time.Duration `mapstructure:"TTL"`
+}
+
+// PriceBaseURLConfig points a price source at a mirror of its API, such as a self-hosted Jupiter
+type PriceBaseURLConfig struct {
+	Source string `mapstructure:"SOURCE"` // coingecko, jupiter, binance or okx
+	URL    string `mapstructure:"URL"`    // Replaces the API's base URL, e.g. https://jupiter.internal for https://api.jup.ag
+}
+
+// ComplianceConfig holds KYC/AML policy configuration
+type ComplianceConfig struct {
+	Enabled        bool           `mapstructure:"ENABLED"`         // Evaluate tenant policies before swaps start
+	TrustedProxies []string       `mapstructure:"TRUSTED_PROXIES"` // CIDRs of the proxies whose forwarding and country headers are believed
+	CountryHeader  string         `mapstructure:"COUNTRY_HEADER"`  // Header a trusted proxy sets with the client's country code; empty geolocates the client address
+	GeoIPDatabase  string         `mapstructure:"GEOIP_DATABASE"`  // IP to country CSV client addresses are geolocated with
+	Tenants        []TenantConfig `mapstructure:"TENANTS"`
+}
+
+// TenantConfig maps API keys to the tenant whose policy applies to their requests
+type TenantConfig struct {
+	ID      string   `mapstructure:"ID"`
+	APIKeys []string `mapstructure:"API_KEYS"`
+}
+
+// AttestationConfig holds swap attestation export configuration
+type AttestationConfig struct {
+	// Hex-encoded 32-byte ed25519 seed attestations are signed with; falls back to ATTESTATION_SIGNING_KEY.
+	// Every replica must share the key. Empty disables attestations.
+	SigningKey string `mapstructure:"SIGNING_KEY"`
+}
+
+// ListingsConfig holds token listing request configuration
+type ListingsConfig struct {
+	MinLiquidityUSD float64 `mapstructure:"MIN_LIQUIDITY_USD"` // Listings committing less liquidity are rejected without review
+}
+
+// ExecutionConfig holds on-chain execution configuration. When enabled, wraps and unwraps on EVM chains with a
+// UNIVERSAL_ADDRESS and same-chain swaps on EVM chains with a DEX_ADDRESS are sent as transactions to those contracts.
+type ExecutionConfig struct {
+	Enabled          bool          `mapstructure:"ENABLED"`
+	Signer           string        `mapstructure:"SIGNER"`            // Where the sending account's key is held: env, keystore, aws-kms or gcp-kms
+	PrivateKey       string        `mapstructure:"PRIVATE_KEY"`       // Hex secp256k1 key, or a secret reference, for the env signer; falls back to EXECUTION_PRIVATE_KEY
+	KeystorePath     string        `mapstructure:"KEYSTORE_PATH"`     // Encrypted Web3 Secret Storage file for the keystore signer
+	KeystorePassword string        `mapstructure:"KEYSTORE_PASSWORD"` // Password of the keystore; falls back to EXECUTION_KEYSTORE_PASSWORD
+	KMSKeyID         string        `mapstructure:"KMS_KEY_ID"`        // AWS key ID, ARN or alias, or GCP key version resource name
+	KMSRegion        string        `mapstructure:"KMS_REGION"`        // AWS region of the key
+	KMSEndpoint      string        `mapstructure:"KMS_ENDPOINT"`      // Overrides the KMS API endpoint, e.g. for a VPC endpoint
+	ReceiptTimeout   time.Duration `mapstructure:"RECEIPT_TIMEOUT"`   // How long a transaction may take to be mined before the activity retries
+	SpeedUpAfter     time.Duration `mapstructure:"SPEED_UP_AFTER"`    // How long a transaction may stay unmined before it is replaced with higher fees; 0 never replaces it
+	ExactApprovals   bool          `mapstructure:"EXACT_APPROVALS"`   // Approve contracts for each operation's amount only, instead of an unlimited allowance
+}
+
+// WebhooksConfig holds the configuration of webhooks sent to clients, such as deposit notifications
+type WebhooksConfig struct {
+	// Secret deposit webhook bodies are signed with (HMAC-SHA256); falls back to WEBHOOK_SIGNING_SECRET. Empty disables
+	// deposit webhooks. Swap callbacks and price alerts are signed with each API key's own secret instead.
+	SigningSecret string `mapstructure:"SIGNING_SECRET"`
+}
+
+// NotificationsConfig holds the configuration of the emails sent to clients, such as price alerts
+type NotificationsConfig struct {
+	SMTPAddr     string `mapstructure:"SMTP_ADDR"`     // host:port of the SMTP server emails are sent through; empty disables email
+	SMTPFrom     string `mapstructure:"SMTP_FROM"`     // Sender address of the emails
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"` // Signs in with PLAIN auth when set
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"` // Falls back to SMTP_PASSWORD
+}
+
+// EventsConfig holds the configuration of the in-process event bus and the external bus its events are forwarded to
+type EventsConfig struct {
+	Buffer        int    `mapstructure:"BUFFER"`         // Events queued per subscriber, and for forwarding
+	NATSURL       string `mapstructure:"NATS_URL"`       // Forward events to this NATS server, e.g. nats://localhost:4222
+	KafkaRESTURL  string `mapstructure:"KAFKA_REST_URL"` // Forward events to Kafka through this REST Proxy; only one external bus may be set
+	SubjectPrefix string `mapstructure:"SUBJECT_PREFIX"` // Prepended to topic names to form NATS subjects and Kafka topics
+}
+
+// ErrorsConfig configures error reporting. Every process counts its errors by fingerprint and adds the counts to
+// the database, and to Sentry when a DSN is set, every flush interval.
+type ErrorsConfig struct {
+	FlushInterval time.Duration `mapstructure:"FLUSH_INTERVAL"` // How often error counts are flushed
+	SentryDSN     string        `mapstructure:"SENTRY_DSN"`     // Also send errors to this Sentry project, e.g. https://<key>@o123.ingest.sentry.io/456
+}
+
+// DeadLettersConfig configures the swap workers' scan for dead-lettered swaps: swaps still running past
+// SWAP.MAX_SWAP_TIME with an activity retrying, and swaps that failed after an activity ran out of retries. They are
+// recorded for operators to requeue or refund through the admin API.
+type DeadLettersConfig struct {
+	Enabled  bool          `mapstructure:"ENABLED"`
+	Lookback time.Duration `mapstructure:"LOOKBACK"` // How far back the first scan looks for failed swaps; later scans continue from the last
+}
+
+// OutboundConfig configures the HTTP client the workers' activities call external APIs through, such as price
+// sources, bridges and RPC endpoints. Requests to each host are paced within its budget, and a host that answers 429
+// is backed off.
+type OutboundConfig struct {
+	MaxIdleConnsPerHost int                `mapstructure:"MAX_IDLE_CONNS_PER_HOST"` // Idle connections kept open to each host
+	MaxConnsPerHost     int                `mapstructure:"MAX_CONNS_PER_HOST"`      // Connections open to each host at once; 0 is unlimited
+	IdleConnTimeout     time.Duration      `mapstructure:"IDLE_CONN_TIMEOUT"`       // How long an idle connection is kept open
+	MaxRetries          int                `mapstructure:"MAX_RETRIES"`             // Times a request answered 429 is retried before the 429 is returned
+	MaxRetryWait        time.Duration      `mapstructure:"MAX_RETRY_WAIT"`          // Longest Retry-After retried after; longer ones are returned to the caller
+	StatsInterval       time.Duration      `mapstructure:"STATS_INTERVAL"`          // How often each host's request counts are logged; 0 never logs them
+	Hosts               []HostBudgetConfig `mapstructure:"HOSTS"`                   // Budgets of hosts; a listed CoinGecko or Jupiter host replaces its built-in budget
+	ProxyURL            string             `mapstructure:"PROXY_URL"`               // HTTP(S) or SOCKS5 proxy requests go through; empty uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	CABundle            string             `mapstructure:"CA_BUNDLE"`               // PEM file of CAs trusted besides the system's, e.g. a proxy's TLS inspection CA
+}
+
+// HostBudgetConfig is the rate requests are sent to a host at
+type HostBudgetConfig struct {
+	Host              string `mapstructure:"HOST"`                // Host name, e.g. api.coingecko.com
+	RequestsPerMinute int    `mapstructure:"REQUESTS_PER_MINUTE"` // 0 is unlimited
+	Burst             int    `mapstructure:"BURST"`               // Requests sent at once after the host was idle; 0 sends one at a time
+}
+
+// PoolConfig describes a liquidity pool. The ID is fixed so the API server and the swap worker seed the same pool.
+type PoolConfig struct {
+	ID         string `mapstructure:"ID"`
+	BaseToken  string `mapstructure:"BASE_TOKEN"`  // Token symbol
+	QuoteToken string `mapstructure:"QUOTE_TOKEN"` // Token symbol
+	ChainID    int64  `mapstructure:"CHAIN_ID"`
+	FeeTier    int    `mapstructure:"FEE_TIER"` // In hundredths of a basis point, e.g. 3000 for 0.3%
+	Address    string `mapstructure:"ADDRESS"`
+
+	MaxPriceImpact float64 `mapstructure:"MAX_PRICE_IMPACT"` // Percent a swap may move the pool's price unless forced; 0 uses SWAP.MAX_PRICE_IMPACT
+}
+
+// RegionConfig identifies the region a deployment runs in and the regions its swaps fail over to.
+// Workers poll task queues suffixed with their region, so swaps started in a region run on co-located workers.
+// The price worker's workflows and schedule are suffixed too, so each region keeps its own price cache fresh.
+type RegionConfig struct {
+	ID string `mapstructure:"ID"` // e.g. us-east-1; empty runs a single region on the unsuffixed task queues
+	// Temporal namespace of this region; empty uses the client default. Failover regions are reached through
+	// this namespace, so it must be a global namespace replicated to them.
+	Namespace           string        `mapstructure:"NAMESPACE"`
+	Failover            []string      `mapstructure:"FAILOVER"`              // Regions to route swaps to, in order, while this region's swap workers are down
+	HealthCheckInterval time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"` // How often each region's swap workers are checked
+}
+
+// Scoped returns this region's name for a task queue, workflow ID or schedule ID
+func (c RegionConfig) Scoped(name string) string {
+	return RegionScoped(name, c.ID)
+}
+
+// Regions returns this region followed by its failover regions, without duplicates
+func (c RegionConfig) Regions() []string {
+	regions := []string{c.ID}
+	seen := map[string]bool{c.ID: true}
+	for _, region := range c.Failover {
+		if region != "" && !seen[region] {
+			regions = append(regions, region)
+			seen[region] = true
+		}
+	}
+	return regions
+}
+
+// RegionScoped returns a region's name for a task queue, workflow ID or schedule ID; an empty region keeps the name
+func RegionScoped(name, region string) string {
+	if region == "" {
+		return name
+	}
+	return name + "-" + region
+}
+
+// defaultRetryConfig is the retry policy every kind of activity defaults to: three attempts, one to ten seconds apart
+func defaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2,
+		MaximumInterval:    10 * time.Second,
+		MaximumAttempts:    3,
+	}
 }
 
 // DefaultConfig returns the default configuration
@@ -78,11 +717,29 @@ func DefaultConfig() Config {
 			Namespace:   "infinity-dex",
 			TaskQueue:   "dex-tasks",
 			WorkflowTTL: 24 * time.Hour,
+			Payloads: PayloadsConfig{
+				CompressThreshold:   4 << 10,
+				ClaimCheckThreshold: 256 << 10,
+				MaxSize:             2 << 20,
+			},
+			Worker: WorkerConfig{
+				StopTimeout: 30 * time.Second,
+			},
+			ChainQueues: ChainQueuesConfig{
+				Sticky: true,
+			},
 		},
 		Universal: UniversalConfig{
 			APIURL:       "https://api.universal.xyz",
 			APIKey:       "",
 			MinTokenWrap: "0.01",
+			Middleware: SDKMiddlewareConfig{
+				RetryAttempts:    2,
+				RetryBackoff:     200 * time.Millisecond,
+				WrappedTokensTTL: time.Minute,
+				FeeEstimateTTL:   10 * time.Second,
+				StatsInterval:    5 * time.Minute,
+			},
 		},
 		Chains: map[string]ChainConfig{
 			"ethereum": {
@@ -90,24 +747,36 @@ func DefaultConfig() Config {
 				RPC:              []string{"https://mainnet.infura.io/v3/${INFURA_KEY}"},
 				ChainID:          1,
 				ExplorerURL:      "https://etherscan.io",
+				Confirmations:    12,
 				UniversalAddress: "",
 				DEXAddress:       "",
+				PrivateRelayURL:  "https://rpc.flashbots.net/fast",
 				WrappedTokens:    []string{"uETH", "uUSDC", "uUSDT", "uDAI"},
+				PriceFeeds: map[string]string{
+					"ETH":  "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419",
+					"BTC":  "0xF4030086522a5bEEa4988F8cA5B36dbC97BeE88c",
+					"USDC": "0x8fFfFfd4AfB6115b954Bd326cbe7B4BA576818f6",
+				},
 			},
 			"polygon": {
 				Name:             "Polygon",
 				RPC:              []string{"https://polygon-rpc.com"},
 				ChainID:          137,
 				ExplorerURL:      "https://polygonscan.com",
+				Confirmations:    128,
 				UniversalAddress: "",
 				DEXAddress:       "",
 				WrappedTokens:    []string{"uMATIC", "uUSDC", "uUSDT", "uDAI"},
+				PriceFeeds: map[string]string{
+					"MATIC": "0xAB594600376Ec9fD91F8e885dADF0CE036862dE0",
+				},
 			},
 			"solana": {
 				Name:             "Solana",
 				RPC:              []string{"https://api.mainnet-beta.solana.com"},
-				ChainID:          0, // Not applicable for Solana
+				ChainID:          types.ChainIDSolana,
 				ExplorerURL:      "https://explorer.solana.com",
+				Confirmations:    32,
 				UniversalAddress: "",
 				DEXAddress:       "",
 				WrappedTokens:    []string{"uSOL", "uUSDC", "uUSDT"},
@@ -117,6 +786,7 @@ func DefaultConfig() Config {
 				RPC:              []string{"https://api.avax.network/ext/bc/C/rpc"},
 				ChainID:          43114,
 				ExplorerURL:      "https://snowtrace.io",
+				Confirmations:    1,
 				UniversalAddress: "",
 				DEXAddress:       "",
 				WrappedTokens:    []string{"uAVAX", "uUSDC", "uUSDT", "uDAI"},
@@ -126,6 +796,7 @@ func DefaultConfig() Config {
 				RPC:              []string{"https://bsc-dataseed.binance.org"},
 				ChainID:          56,
 				ExplorerURL:      "https://bscscan.com",
+				Confirmations:    15,
 				UniversalAddress: "",
 				DEXAddress:       "",
 				WrappedTokens:    []string{"uBNB", "uUSDC", "uUSDT", "uBUSD"},
@@ -133,13 +804,112 @@ func DefaultConfig() Config {
 		},
 		Server: ServerConfig{
 			Port:            8080,
+			GRPCPort:        9090,
 			CORSAllowOrigin: "*",
 			Timeout:         30 * time.Second,
+
+			ReadinessTimeout:         2 * time.Second,
+			PriceSourceCheckInterval: time.Minute,
+			TokenFetchTimeout:        5 * time.Second,
+			TokenCacheTTL:            5 * time.Minute,
+			Compression:              true,
 		},
 		Swap: SwapConfig{
 			DefaultSlippage: 0.5,
 			MaxSwapAmount:   "100000",
 			MaxSwapTime:     30 * time.Second,
+			MaxPriceAge:     5 * time.Minute,
+			GasMultiplier:   1.2,
+			QuoteTTL:        30 * time.Second,
+			MaxPriceImpact:  5,
+
+			ShadowPriceInterval: 5 * time.Minute,
+
+			MaxTranches:     20,
+			TrancheDelay:    30 * time.Second,
+			MaxTrancheDelay: 10 * time.Minute,
+		},
+		Sandbox: SandboxConfig{
+			StartingBalance: "1000000000000000000000",
+		},
+		Admin: AdminConfig{
+			WebAuthn: WebAuthnConfig{
+				RPID:       "localhost",
+				RPName:     "Infinity DEX Admin",
+				Origins:    []string{"http://localhost:3000"},
+				SessionTTL: 15 * time.Minute,
+			},
+		},
+		Prices: PricesConfig{
+			SanityMaxDeviationPct:  10,
+			SanityAction:           "drop",
+			CoinGeckoPlan:          "demo",
+			CacheTTL:               time.Hour,
+			UpdateInterval:         15 * time.Second,
+			UpdateRunsPerExecution: 500,
+			DBBatchSize:            500,
+			MemoryCacheSize:        1024,
+			MemoryCacheTTL:         5 * time.Second,
+			RedisTTL:               30 * time.Second,
+			LiveFetch:              true,
+		},
+		Database: DatabaseConfig{
+			AutoMigrate: true,
+		},
+		Breakers: BreakersConfig{
+			Enabled: true,
+			Chain: BreakerConfig{
+				Window:         time.Minute,
+				MinCalls:       10,
+				FailureRatePct: 50,
+				Cooldown:       30 * time.Second,
+			},
+			Global: BreakerConfig{
+				Window:         time.Minute,
+				MinCalls:       20,
+				FailureRatePct: 50,
+				Cooldown:       30 * time.Second,
+			},
+		},
+		Retries: RetriesConfig{
+			Wrap:       defaultRetryConfig(),
+			Transfer:   defaultRetryConfig(),
+			Swap:       defaultRetryConfig(),
+			Unwrap:     defaultRetryConfig(),
+			PriceFetch: defaultRetryConfig(),
+			Budget:     10 * time.Minute,
+		},
+		Compliance: ComplianceConfig{
+			Enabled: true,
+		},
+		Listings: ListingsConfig{
+			MinLiquidityUSD: 50000,
+		},
+		Region: RegionConfig{
+			HealthCheckInterval: 30 * time.Second,
+		},
+		Execution: ExecutionConfig{
+			Signer:         "env",
+			ReceiptTimeout: 2 * time.Minute,
+			SpeedUpAfter:   45 * time.Second,
+		},
+		Events: EventsConfig{
+			Buffer:        256,
+			SubjectPrefix: "infinity-dex.",
+		},
+		Errors: ErrorsConfig{
+			FlushInterval: time.Minute,
+		},
+		DeadLetters: DeadLettersConfig{
+			Enabled:  true,
+			Lookback: 24 * time.Hour,
+		},
+		Outbound: OutboundConfig{
+			MaxIdleConnsPerHost: 16,
+			IdleConnTimeout:     90 * time.Second,
+			MaxRetries:          2,
+			MaxRetryWait:        5 * time.Second,
+			StatsInterval:       5 * time.Minute,
 		},
 	}
 }
@@ -165,10 +935,310 @@ func LoadConfig(configPath string) (Config, error) {
 		}
 	}
 
+	// Accept legacy chain IDs in config files but always work with canonical ones
+	for name, chain := range config.Chains {
+		chain.ChainID = types.CanonicalChainID(chain.ChainID)
+		config.Chains[name] = chain
+
+		if chain.SponsorMaxSwapUSD < 0 || chain.SponsorDailyCapUSD < 0 {
+			return config, fmt.Errorf("CHAINS.%s.SPONSOR_MAX_SWAP_USD and SPONSOR_DAILY_CAP_USD must not be negative", name)
+		}
+		if chain.SwapMaxUSD < 0 || chain.SwapDailyLimitUSD < 0 {
+			return config, fmt.Errorf("CHAINS.%s.SWAP_MAX_USD and SWAP_DAILY_LIMIT_USD must not be negative", name)
+		}
+	}
+
+	// Refuse two external buses rather than forward events to only one of them
+	if config.Events.NATSURL != "" && config.Events.KafkaRESTURL != "" {
+		return config, fmt.Errorf("EVENTS.NATS_URL and EVENTS.KAFKA_REST_URL are both set; configure one external bus")
+	}
+
+	// Refuse serving gRPC on the REST port rather than fail to listen on it after startup
+	if config.Server.GRPCPort != 0 && config.Server.GRPCPort == config.Server.Port {
+		return config, fmt.Errorf("SERVER.GRPC_PORT and SERVER.PORT are both %d; serve gRPC on its own port", config.Server.Port)
+	}
+
+	// Refuse a readiness timeout that would fail every check before it starts
+	if config.Server.ReadinessTimeout <= 0 {
+		return config, fmt.Errorf("SERVER.READINESS_TIMEOUT must be positive, got %s", config.Server.ReadinessTimeout)
+	}
+	if config.Server.PriceSourceCheckInterval < 0 {
+		return config, fmt.Errorf("SERVER.PRICE_SOURCE_CHECK_INTERVAL must not be negative")
+	}
+	if config.Server.TokenFetchTimeout <= 0 {
+		return config, fmt.Errorf("SERVER.TOKEN_FETCH_TIMEOUT must be positive, got %s", config.Server.TokenFetchTimeout)
+	}
+	if config.Server.TokenCacheTTL < 0 {
+		return config, fmt.Errorf("SERVER.TOKEN_CACHE_TTL must not be negative")
+	}
+
+	if config.Swap.QuoteTTL < 0 {
+		return config, fmt.Errorf("SWAP.QUOTE_TTL must not be negative, got %s", config.Swap.QuoteTTL)
+	}
+
+	// Refuse price impact limits that aren't percentages
+	if config.Swap.MaxPriceImpact < 0 || config.Swap.MaxPriceImpact > 100 {
+		return config, fmt.Errorf("SWAP.MAX_PRICE_IMPACT must be a percentage from 0 to 100, got %g", config.Swap.MaxPriceImpact)
+	}
+	for _, pool := range config.Pools {
+		if pool.MaxPriceImpact < 0 || pool.MaxPriceImpact > 100 {
+			return config, fmt.Errorf("POOLS %s MAX_PRICE_IMPACT must be a percentage from 0 to 100, got %g", pool.ID, pool.MaxPriceImpact)
+		}
+	}
+
+	// Refuse swap limits that can't be enforced
+	if amount := strings.TrimSpace(config.Swap.MaxSwapAmount); amount != "" {
+		if maxSwapUSD, err := strconv.ParseFloat(amount, 64); err != nil || maxSwapUSD < 0 {
+			return config, fmt.Errorf("SWAP.MAX_SWAP_AMOUNT must be a USD amount of at least 0, got %q", config.Swap.MaxSwapAmount)
+		}
+	}
+	if config.Swap.UserDailyLimitUSD < 0 {
+		return config, fmt.Errorf("SWAP.USER_DAILY_LIMIT_USD must not be negative, got %g", config.Swap.UserDailyLimitUSD)
+	}
+	for _, pair := range config.Swap.PairLimits {
+		symbols := strings.Split(pair.Pair, "/")
+		if len(symbols) != 2 || strings.TrimSpace(symbols[0]) == "" || strings.TrimSpace(symbols[1]) == "" {
+			return config, fmt.Errorf("SWAP.PAIR_LIMITS PAIR must be two token symbols such as ETH/USDC, got %q", pair.Pair)
+		}
+		if pair.MaxSwapUSD < 0 || pair.DailyLimitUSD < 0 {
+			return config, fmt.Errorf("SWAP.PAIR_LIMITS %s MAX_SWAP_USD and DAILY_LIMIT_USD must not be negative", pair.Pair)
+		}
+	}
+
+	// Refuse tranche limits no tranched swap could meet
+	if config.Swap.MaxTranches <= 0 {
+		return config, fmt.Errorf("SWAP.MAX_TRANCHES must be positive, got %d", config.Swap.MaxTranches)
+	}
+	if config.Swap.TrancheDelay < 0 || config.Swap.TrancheDelay > config.Swap.MaxTrancheDelay {
+		return config, fmt.Errorf("SWAP.TRANCHE_DELAY must be between 0 and SWAP.MAX_TRANCHE_DELAY (%s), got %s", config.Swap.MaxTrancheDelay, config.Swap.TrancheDelay)
+	}
+
+	if err := validateBridges(config); err != nil {
+		return config, err
+	}
+
+	// Refuse two claim-check stores rather than write payloads to one and look for them in the other
+	if config.Temporal.Payloads.ClaimCheckDir != "" && config.Temporal.Payloads.ClaimCheckURL != "" {
+		return config, fmt.Errorf("TEMPORAL.PAYLOADS.CLAIM_CHECK_DIR and TEMPORAL.PAYLOADS.CLAIM_CHECK_URL are both set; configure one claim-check store")
+	}
+
+	if config.Temporal.Worker.MaxConcurrentActivities < 0 {
+		return config, fmt.Errorf("TEMPORAL.WORKER.MAX_CONCURRENT_ACTIVITIES must not be negative, got %d", config.Temporal.Worker.MaxConcurrentActivities)
+	}
+	if config.Temporal.Worker.MaxConcurrentWorkflowTasks < 0 {
+		return config, fmt.Errorf("TEMPORAL.WORKER.MAX_CONCURRENT_WORKFLOW_TASKS must not be negative, got %d", config.Temporal.Worker.MaxConcurrentWorkflowTasks)
+	}
+	if config.Temporal.Worker.StopTimeout < 0 {
+		return config, fmt.Errorf("TEMPORAL.WORKER.STOP_TIMEOUT must not be negative, got %s", config.Temporal.Worker.StopTimeout)
+	}
+
+	for _, chain := range config.Temporal.ChainQueues.Chains {
+		if _, ok := config.Chains[chain]; !ok {
+			return config, fmt.Errorf("TEMPORAL.CHAIN_QUEUES.CHAINS: unknown chain %q", chain)
+		}
+	}
+
+	// Refuse a signer that can't be built rather than find out when the first transaction is sent
+	switch config.Execution.Signer {
+	case "env":
+	case "keystore":
+		if config.Execution.KeystorePath == "" {
+			return config, fmt.Errorf("EXECUTION.SIGNER keystore requires EXECUTION.KEYSTORE_PATH")
+		}
+	case "aws-kms", "gcp-kms":
+		if config.Execution.KMSKeyID == "" {
+			return config, fmt.Errorf("EXECUTION.SIGNER %s requires EXECUTION.KMS_KEY_ID", config.Execution.Signer)
+		}
+		if config.Execution.Signer == "aws-kms" && config.Execution.KMSRegion == "" {
+			return config, fmt.Errorf("EXECUTION.SIGNER aws-kms requires EXECUTION.KMS_REGION")
+		}
+	default:
+		return config, fmt.Errorf("unknown EXECUTION.SIGNER %q, expected env, keystore, aws-kms or gcp-kms", config.Execution.Signer)
+	}
+
+	// Refuse breaker settings that would trip on the first call or never count one
+	if config.Breakers.Enabled {
+		for name, breaker := range map[string]BreakerConfig{"CHAIN": config.Breakers.Chain, "GLOBAL": config.Breakers.Global} {
+			if breaker.Window <= 0 || breaker.MinCalls <= 0 || breaker.FailureRatePct <= 0 || breaker.FailureRatePct > 100 {
+				return config, fmt.Errorf("BREAKERS.%s needs a positive WINDOW and MIN_CALLS and a FAILURE_RATE_PCT in (0, 100]", name)
+			}
+		}
+	}
+
+	// Refuse retry policies that would never retry or would retry without waiting
+	for name, retry := range map[string]RetryConfig{
+		"WRAP": config.Retries.Wrap, "TRANSFER": config.Retries.Transfer, "SWAP": config.Retries.Swap,
+		"UNWRAP": config.Retries.Unwrap, "PRICE_FETCH": config.Retries.PriceFetch,
+	} {
+		if retry.InitialInterval <= 0 || retry.BackoffCoefficient < 1 || retry.MaximumInterval < retry.InitialInterval || retry.MaximumAttempts <= 0 {
+			return config, fmt.Errorf("RETRIES.%s needs a positive INITIAL_INTERVAL and MAXIMUM_ATTEMPTS, a BACKOFF_COEFFICIENT of at least 1 and a MAXIMUM_INTERVAL no shorter than INITIAL_INTERVAL", name)
+		}
+	}
+	if config.Retries.Budget < 0 {
+		return config, fmt.Errorf("RETRIES.BUDGET must not be negative")
+	}
+
+	// Refuse a proxy range that can't be parsed rather than start trusting the wrong peers
+	for _, cidr := range config.Compliance.TrustedProxies {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return config, fmt.Errorf("invalid COMPLIANCE.TRUSTED_PROXIES entry: %w", err)
+		}
+	}
+
 	// Check for required environment variables
 	if config.Universal.APIKey == "" {
 		config.Universal.APIKey = os.Getenv("UNIVERSAL_API_KEY")
 	}
+	if config.Prices.CoinGeckoAPIKey == "" {
+		config.Prices.CoinGeckoAPIKey = os.Getenv("COINGECKO_API_KEY")
+	}
+	if config.Execution.KeystorePassword == "" {
+		config.Execution.KeystorePassword = os.Getenv("EXECUTION_KEYSTORE_PASSWORD")
+	}
+	if config.Webhooks.SigningSecret == "" {
+		config.Webhooks.SigningSecret = os.Getenv("WEBHOOK_SIGNING_SECRET")
+	}
+	if config.Attestation.SigningKey == "" {
+		config.Attestation.SigningKey = os.Getenv("ATTESTATION_SIGNING_KEY")
+	}
+	if config.Notifications.SMTPPassword == "" {
+		config.Notifications.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	}
+
+	// Read the secrets the configuration or environment references from their secret stores
+	if err := resolveSecrets(map[string]*string{
+		"UNIVERSAL.API_KEY":           &config.Universal.APIKey,
+		"PRICES.COINGECKO_API_KEY":    &config.Prices.CoinGeckoAPIKey,
+		"EXECUTION.PRIVATE_KEY":       &config.Execution.PrivateKey,
+		"EXECUTION.KEYSTORE_PASSWORD": &config.Execution.KeystorePassword,
+		"WEBHOOKS.SIGNING_SECRET":     &config.Webhooks.SigningSecret,
+		"ATTESTATION.SIGNING_KEY":     &config.Attestation.SigningKey,
+		"NOTIFICATIONS.SMTP_PASSWORD": &config.Notifications.SMTPPassword,
+		"OUTBOUND.PROXY_URL":          &config.Outbound.ProxyURL,
+	}); err != nil {
+		return config, err
+	}
+
+	// Refuse an error flush interval that isn't positive rather than panic when flushing starts
+	if config.Errors.FlushInterval <= 0 {
+		return config, fmt.Errorf("ERRORS.FLUSH_INTERVAL must be positive, got %s", config.Errors.FlushInterval)
+	}
+
+	// Refuse a dead letter lookback that would skip every failed swap
+	if config.DeadLetters.Enabled && config.DeadLetters.Lookback <= 0 {
+		return config, fmt.Errorf("DEAD_LETTERS.LOOKBACK must be positive, got %s", config.DeadLetters.Lookback)
+	}
+
+	if err := validateOutbound(config.Outbound); err != nil {
+		return config, err
+	}
+	if m := config.Universal.Middleware; m.RetryAttempts < 0 || m.RetryBackoff < 0 || m.WrappedTokensTTL < 0 || m.FeeEstimateTTL < 0 || m.StatsInterval < 0 {
+		return config, fmt.Errorf("UNIVERSAL.MIDDLEWARE settings must not be negative")
+	}
+	for i, baseURL := range config.Prices.BaseURLs {
+		if baseURL.Source == "" || !isHTTPURL(baseURL.URL, "http", "https") {
+			return config, fmt.Errorf("PRICES.BASE_URLS[%d] needs a SOURCE and an http(s) URL", i)
+		}
+	}
+
+	// Refuse a signing key that can't be used rather than sign with one other replicas don't share
+	if key := config.Attestation.SigningKey; key != "" {
+		if seed, err := hex.DecodeString(key); err != nil || len(seed) != ed25519.SeedSize {
+			return config, fmt.Errorf("invalid ATTESTATION.SIGNING_KEY, expected %d hex-encoded bytes", ed25519.SeedSize)
+		}
+	}
+
+	// Refuse an SMTP server emails can't be sent through rather than fail each notification
+	if addr := config.Notifications.SMTPAddr; addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return config, fmt.Errorf("invalid NOTIFICATIONS.SMTP_ADDR, expected host:port: %w", err)
+		}
+		if _, err := mail.ParseAddress(config.Notifications.SMTPFrom); err != nil {
+			return config, fmt.Errorf("invalid NOTIFICATIONS.SMTP_FROM: %w", err)
+		}
+	}
 
 	return config, nil
 }
+
+// validateBridges refuses bridge providers and corridors naming unknown chains or bridges, rather than quote
+// through a bridge that connects nothing or route a corridor no bridge may carry
+func validateBridges(config Config) error {
+	bridges := map[string]bool{"universal": true, "across": true}
+	for i, provider := range config.Bridges.Providers {
+		if provider.Name == "" || provider.URL == "" {
+			return fmt.Errorf("BRIDGES.PROVIDERS[%d] needs a NAME and a URL", i)
+		}
+		if bridges[provider.Name] {
+			return fmt.Errorf("BRIDGES.PROVIDERS[%d]: bridge %s is already configured", i, provider.Name)
+		}
+		bridges[provider.Name] = true
+		if len(provider.Chains) < 2 {
+			return fmt.Errorf("BRIDGES.PROVIDERS[%d]: bridge %s must connect at least two CHAINS", i, provider.Name)
+		}
+		for _, chain := range provider.Chains {
+			if _, ok := config.Chains[chain]; !ok {
+				return fmt.Errorf("BRIDGES.PROVIDERS[%d]: unknown chain %s", i, chain)
+			}
+		}
+	}
+
+	for i, corridor := range config.Bridges.Corridors {
+		for _, chain := range []string{corridor.From, corridor.To} {
+			if _, ok := config.Chains[chain]; !ok {
+				return fmt.Errorf("BRIDGES.CORRIDORS[%d]: unknown chain %q", i, chain)
+			}
+		}
+		if corridor.From == corridor.To {
+			return fmt.Errorf("BRIDGES.CORRIDORS[%d]: FROM and TO are both %s", i, corridor.From)
+		}
+		switch corridor.Prefer {
+		case "", "cheapest", "fastest":
+		default:
+			return fmt.Errorf("BRIDGES.CORRIDORS[%d]: PREFER must be cheapest or fastest, got %q", i, corridor.Prefer)
+		}
+		for _, bridge := range corridor.Bridges {
+			if !bridges[bridge] {
+				return fmt.Errorf("BRIDGES.CORRIDORS[%d]: unknown bridge %s", i, bridge)
+			}
+		}
+	}
+	return nil
+}
+
+// validateOutbound refuses negative outbound client settings and host budgets, rather than let the HTTP transport
+// read them as unlimited
+func validateOutbound(outbound OutboundConfig) error {
+	if outbound.MaxIdleConnsPerHost < 0 || outbound.MaxConnsPerHost < 0 || outbound.MaxRetries < 0 {
+		return fmt.Errorf("OUTBOUND connection and retry limits must not be negative")
+	}
+	if outbound.IdleConnTimeout < 0 || outbound.MaxRetryWait < 0 || outbound.StatsInterval < 0 {
+		return fmt.Errorf("OUTBOUND durations must not be negative")
+	}
+	if outbound.ProxyURL != "" && !isHTTPURL(outbound.ProxyURL, "http", "https", "socks5") {
+		return fmt.Errorf("invalid OUTBOUND.PROXY_URL, expected an http, https or socks5 URL")
+	}
+	for i, host := range outbound.Hosts {
+		if host.Host == "" {
+			return fmt.Errorf("OUTBOUND.HOSTS[%d] needs a HOST", i)
+		}
+		if host.RequestsPerMinute < 0 || host.Burst < 0 {
+			return fmt.Errorf("OUTBOUND.HOSTS[%d]: REQUESTS_PER_MINUTE and BURST must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// isHTTPURL reports whether rawURL is an absolute URL with a host and one of schemes
+func isHTTPURL(rawURL string, schemes ...string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
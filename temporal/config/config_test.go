@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/infinity-dex/services/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,10 +24,31 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, "infinity-dex", cfg.Temporal.Namespace)
 	assert.Equal(t, "dex-tasks", cfg.Temporal.TaskQueue)
 	assert.Equal(t, 24*time.Hour, cfg.Temporal.WorkflowTTL)
+	assert.Equal(t, 4<<10, cfg.Temporal.Payloads.CompressThreshold)
+	assert.Equal(t, 2<<20, cfg.Temporal.Payloads.MaxSize)
+	assert.Zero(t, cfg.Temporal.Worker.MaxConcurrentActivities)
+	assert.Equal(t, 30*time.Second, cfg.Temporal.Worker.StopTimeout)
+	assert.False(t, cfg.Temporal.ChainQueues.Enabled)
+	assert.Nil(t, cfg.ChainTaskQueues(""))
+	assert.Equal(t, time.Minute, cfg.Errors.FlushInterval)
+	assert.True(t, cfg.DeadLetters.Enabled)
+	assert.Equal(t, 24*time.Hour, cfg.DeadLetters.Lookback)
+	assert.Equal(t, 16, cfg.Outbound.MaxIdleConnsPerHost)
+	assert.Equal(t, 2, cfg.Outbound.MaxRetries)
+	assert.Equal(t, 5*time.Second, cfg.Outbound.MaxRetryWait)
+	assert.Equal(t, 5*time.Minute, cfg.Outbound.StatsInterval)
+	assert.Empty(t, cfg.Outbound.Hosts)
+	assert.Empty(t, cfg.Outbound.ProxyURL)
+	assert.Empty(t, cfg.Prices.BaseURLs)
 
 	// Verify universal config
 	assert.Equal(t, "https://api.universal.xyz", cfg.Universal.APIURL)
 	assert.Equal(t, "", cfg.Universal.APIKey) // Empty by default
+	assert.Empty(t, cfg.Universal.MockScenario)
+	assert.False(t, cfg.Universal.Middleware.LogCalls)
+	assert.Equal(t, 2, cfg.Universal.Middleware.RetryAttempts)
+	assert.Equal(t, time.Minute, cfg.Universal.Middleware.WrappedTokensTTL)
+	assert.Equal(t, 10*time.Second, cfg.Universal.Middleware.FeeEstimateTTL)
 	assert.Equal(t, "0.01", cfg.Universal.MinTokenWrap)
 
 	// Verify chain config
@@ -43,16 +65,82 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, int64(1), eth.ChainID)
 	assert.Contains(t, eth.WrappedTokens, "uETH")
 	assert.Contains(t, eth.WrappedTokens, "uUSDC")
+	assert.Equal(t, "eip155:1", eth.CAIP2())
+	assert.Equal(t, 12, eth.Confirmations)
+	assert.Equal(t, AllChainFeatures, eth.SupportedFeatures())
+	assert.Equal(t, map[int64][]string{1: {"https://rpc.flashbots.net/fast"}}, cfg.PrivateRelayURLs())
+	assert.Empty(t, cfg.GasSponsorPolicies(), "no chain sponsors gas by default")
+
+	// Solana uses its canonical chain ID
+	assert.Equal(t, int64(1399811149), cfg.Chains["solana"].ChainID)
 
 	// Verify server config
 	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, 9090, cfg.Server.GRPCPort)
 	assert.Equal(t, "*", cfg.Server.CORSAllowOrigin)
 	assert.Equal(t, 30*time.Second, cfg.Server.Timeout)
+	assert.Equal(t, 2*time.Second, cfg.Server.ReadinessTimeout)
+	assert.Equal(t, time.Minute, cfg.Server.PriceSourceCheckInterval)
+	assert.Equal(t, 5*time.Second, cfg.Server.TokenFetchTimeout)
+	assert.Equal(t, 5*time.Minute, cfg.Server.TokenCacheTTL)
+	assert.True(t, cfg.Server.Compression)
 
 	// Verify swap config
 	assert.Equal(t, 0.5, cfg.Swap.DefaultSlippage)
 	assert.Equal(t, "100000", cfg.Swap.MaxSwapAmount)
 	assert.Equal(t, 30*time.Second, cfg.Swap.MaxSwapTime)
+	assert.Equal(t, 5*time.Minute, cfg.Swap.MaxPriceAge)
+	assert.Equal(t, 1.2, cfg.Swap.GasMultiplier)
+	assert.Equal(t, 30*time.Second, cfg.Swap.QuoteTTL)
+	assert.Equal(t, 5.0, cfg.Swap.MaxPriceImpact)
+	assert.Equal(t, 20, cfg.Swap.MaxTranches)
+	assert.Equal(t, 30*time.Second, cfg.Swap.TrancheDelay)
+	assert.Equal(t, 10*time.Minute, cfg.Swap.MaxTrancheDelay)
+
+	// Verify price sanity band
+	assert.Equal(t, 10.0, cfg.Prices.SanityMaxDeviationPct)
+	assert.Equal(t, "drop", cfg.Prices.SanityAction)
+	assert.Equal(t, time.Hour, cfg.Prices.CacheTTL)
+	assert.Equal(t, "demo", cfg.Prices.CoinGeckoPlan)
+	assert.Equal(t, 15*time.Second, cfg.Prices.UpdateInterval)
+	assert.Equal(t, 500, cfg.Prices.UpdateRunsPerExecution)
+	assert.False(t, cfg.Prices.UpdateSchedule)
+	assert.Equal(t, 1024, cfg.Prices.MemoryCacheSize)
+	assert.Equal(t, 5*time.Second, cfg.Prices.MemoryCacheTTL)
+	assert.Empty(t, cfg.Prices.RedisURL)
+	assert.True(t, cfg.Prices.LiveFetch)
+	assert.True(t, cfg.Breakers.Enabled)
+	assert.Equal(t, 10, cfg.Breakers.Chain.MinCalls)
+	assert.Equal(t, 50.0, cfg.Breakers.Global.FailureRatePct)
+	assert.Equal(t, time.Second, cfg.Retries.Swap.InitialInterval)
+	assert.Equal(t, int32(3), cfg.Retries.Wrap.MaximumAttempts)
+	assert.Equal(t, 10*time.Second, cfg.Retries.PriceFetch.MaximumInterval)
+	assert.Equal(t, 10*time.Minute, cfg.Retries.Budget)
+
+	// Verify admin passkey config
+	assert.False(t, cfg.Admin.WebAuthn.Enabled)
+	assert.Equal(t, "localhost", cfg.Admin.WebAuthn.RPID)
+	assert.Equal(t, 15*time.Minute, cfg.Admin.WebAuthn.SessionTTL)
+
+	// Verify compliance config
+	assert.True(t, cfg.Compliance.Enabled)
+	assert.Empty(t, cfg.Compliance.CountryHeader)
+	assert.Empty(t, cfg.Compliance.TrustedProxies)
+	assert.Empty(t, cfg.Compliance.Tenants)
+	assert.Empty(t, cfg.Attestation.SigningKey)
+	assert.Equal(t, 50000.0, cfg.Listings.MinLiquidityUSD)
+	assert.Empty(t, cfg.Notifications.SMTPAddr, "email is disabled by default")
+
+	// Verify region config: a single region on the unsuffixed task queues
+	assert.Empty(t, cfg.Region.ID)
+	assert.Equal(t, "swap-queue", cfg.Region.Scoped("swap-queue"))
+	assert.Equal(t, []string{""}, cfg.Region.Regions())
+	assert.Equal(t, 30*time.Second, cfg.Region.HealthCheckInterval)
+
+	// Verify execution config: transactions are not sent by default
+	assert.False(t, cfg.Execution.Enabled)
+	assert.Equal(t, "env", cfg.Execution.Signer)
+	assert.Equal(t, 2*time.Minute, cfg.Execution.ReceiptTimeout)
 }
 
 func TestLoadConfig(t *testing.T) {
@@ -71,6 +159,14 @@ TEMPORAL:
   NAMESPACE: "prod-dex"
   TASK_QUEUE: "prod-tasks"
   WORKFLOW_TTL: "48h"
+  WORKER:
+    MAX_CONCURRENT_ACTIVITIES: 50
+    MAX_CONCURRENT_WORKFLOW_TASKS: 20
+    STOP_TIMEOUT: "2m"
+  CHAIN_QUEUES:
+    ENABLED: true
+    CHAINS: ["solana", "ethereum"]
+    STICKY: false
 
 UNIVERSAL:
   API_URL: "https://prod.universal.xyz"
@@ -79,13 +175,52 @@ UNIVERSAL:
 
 SERVER:
   PORT: 9090
+  GRPC_PORT: 9091
   CORS_ALLOW_ORIGIN: "https://app.example.com"
   TIMEOUT: "60s"
+  READINESS_TIMEOUT: "5s"
+  TOKEN_FETCH_TIMEOUT: "2s"
+  TOKEN_CACHE_TTL: "1m"
+  COMPRESSION: false
 
 SWAP:
   DEFAULT_SLIPPAGE: 1.0
   MAX_SWAP_AMOUNT: "500000"
   MAX_SWAP_TIME: "60s"
+  MAX_PRICE_AGE: "2m"
+  GAS_MULTIPLIER: 1.5
+  QUOTE_TTL: "1m"
+
+PRICES:
+  COINGECKO_API_KEY: "cg-pro-key"
+  COINGECKO_PLAN: "pro"
+  CACHE_TTL: "30m"
+  CACHE_TTLS:
+    - SOURCE: "chainlink"
+      TTL: "5m"
+    - SYMBOL: "USDC"
+      TTL: "6h"
+  UPDATE_INTERVAL: "1m"
+  UPDATE_RUNS_PER_EXECUTION: 100
+  UPDATE_SCHEDULE: true
+
+RETRIES:
+  SWAP:
+    MAXIMUM_ATTEMPTS: 5
+  BUDGET: "5m"
+
+REGION:
+  ID: "us-east-1"
+  NAMESPACE: "infinity-dex-global"
+  FAILOVER:
+    - "eu-west-1"
+    - "us-east-1"
+  HEALTH_CHECK_INTERVAL: "10s"
+
+EXECUTION:
+  ENABLED: true
+  PRIVATE_KEY: "0x4646464646464646464646464646464646464646464646464646464646464646"
+  RECEIPT_TIMEOUT: "5m"
 `
 	err = os.WriteFile(configPath, []byte(configContent), 0644)
 	require.NoError(t, err)
@@ -103,6 +238,14 @@ SWAP:
 	assert.Equal(t, "prod-dex", cfg.Temporal.Namespace)
 	assert.Equal(t, "prod-tasks", cfg.Temporal.TaskQueue)
 	assert.Equal(t, 48*time.Hour, cfg.Temporal.WorkflowTTL)
+	assert.Equal(t, 50, cfg.Temporal.Worker.MaxConcurrentActivities)
+	assert.Equal(t, 20, cfg.Temporal.Worker.MaxConcurrentWorkflowTasks)
+	assert.Equal(t, 2*time.Minute, cfg.Temporal.Worker.StopTimeout)
+	assert.Equal(t, []string{"ethereum", "solana"}, cfg.PartitionedChains())
+	assert.Equal(t, &types.ChainTaskQueues{Queues: map[int64]string{
+		types.ChainIDEthereum: "prod-tasks-ethereum-eu-west-1",
+		types.ChainIDSolana:   "prod-tasks-solana-eu-west-1",
+	}}, cfg.ChainTaskQueues("eu-west-1"))
 
 	// Verify universal config
 	assert.Equal(t, "https://prod.universal.xyz", cfg.Universal.APIURL)
@@ -111,13 +254,52 @@ SWAP:
 
 	// Verify server config
 	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, 9091, cfg.Server.GRPCPort)
 	assert.Equal(t, "https://app.example.com", cfg.Server.CORSAllowOrigin)
 	assert.Equal(t, 60*time.Second, cfg.Server.Timeout)
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadinessTimeout)
+	assert.Equal(t, time.Minute, cfg.Server.PriceSourceCheckInterval)
+	assert.Equal(t, 2*time.Second, cfg.Server.TokenFetchTimeout)
+	assert.Equal(t, time.Minute, cfg.Server.TokenCacheTTL)
+	assert.False(t, cfg.Server.Compression)
 
 	// Verify swap config
 	assert.Equal(t, 1.0, cfg.Swap.DefaultSlippage)
 	assert.Equal(t, "500000", cfg.Swap.MaxSwapAmount)
 	assert.Equal(t, 60*time.Second, cfg.Swap.MaxSwapTime)
+	assert.Equal(t, 2*time.Minute, cfg.Swap.MaxPriceAge)
+	assert.Equal(t, 1.5, cfg.Swap.GasMultiplier)
+	assert.Equal(t, time.Minute, cfg.Swap.QuoteTTL)
+
+	// Verify price config
+	assert.Equal(t, "cg-pro-key", cfg.Prices.CoinGeckoAPIKey)
+	assert.Equal(t, "pro", cfg.Prices.CoinGeckoPlan)
+	assert.Equal(t, 30*time.Minute, cfg.Prices.CacheTTL)
+	assert.Equal(t, []PriceCacheTTLConfig{
+		{Source: "chainlink", TTL: 5 * time.Minute},
+		{Symbol: "USDC", TTL: 6 * time.Hour},
+	}, cfg.Prices.CacheTTLs)
+	assert.Equal(t, time.Minute, cfg.Prices.UpdateInterval)
+	assert.Equal(t, 100, cfg.Prices.UpdateRunsPerExecution)
+	assert.True(t, cfg.Prices.UpdateSchedule)
+
+	// Verify retry config: the swap policy's other fields keep their defaults
+	assert.Equal(t, int32(5), cfg.Retries.Swap.MaximumAttempts)
+	assert.Equal(t, time.Second, cfg.Retries.Swap.InitialInterval)
+	assert.Equal(t, 2.0, cfg.Retries.Swap.BackoffCoefficient)
+	assert.Equal(t, 5*time.Minute, cfg.Retries.Policies().Budget)
+
+	// Verify region config
+	assert.Equal(t, "us-east-1", cfg.Region.ID)
+	assert.Equal(t, "infinity-dex-global", cfg.Region.Namespace)
+	assert.Equal(t, "swap-queue-us-east-1", cfg.Region.Scoped("swap-queue"))
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, cfg.Region.Regions())
+	assert.Equal(t, 10*time.Second, cfg.Region.HealthCheckInterval)
+
+	// Verify execution config
+	assert.True(t, cfg.Execution.Enabled)
+	assert.Equal(t, "0x4646464646464646464646464646464646464646464646464646464646464646", cfg.Execution.PrivateKey)
+	assert.Equal(t, 5*time.Minute, cfg.Execution.ReceiptTimeout)
 }
 
 func TestLoadConfigFromEnvironment(t *testing.T) {
@@ -136,6 +318,333 @@ func TestLoadConfigFromEnvironment(t *testing.T) {
 	assert.Equal(t, "env-api-key", cfg.Universal.APIKey)
 }
 
+func TestLoadConfigInvalidSigningKey(t *testing.T) {
+	originalKey := os.Getenv("ATTESTATION_SIGNING_KEY")
+	defer os.Setenv("ATTESTATION_SIGNING_KEY", originalKey)
+
+	// Too short to be an ed25519 seed
+	os.Setenv("ATTESTATION_SIGNING_KEY", "0001020304")
+
+	_, err := LoadConfig("")
+	assert.Error(t, err)
+}
+
+func TestLoadConfigInvalidSigner(t *testing.T) {
+	for name, execution := range map[string]string{
+		"Unknown":             "SIGNER: hsm",
+		"KeystoreWithoutPath": "SIGNER: keystore",
+		"KMSWithoutKey":       "SIGNER: gcp-kms",
+		"AWSWithoutRegion":    "SIGNER: aws-kms\n  KMS_KEY_ID: alias/swaps",
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte("EXECUTION:\n  "+execution+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigNotifications(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+NOTIFICATIONS:
+  SMTP_ADDR: "smtp.example.com:587"
+  SMTP_FROM: "alerts@example.com"
+`), 0644))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", cfg.Notifications.SMTPAddr)
+	assert.Equal(t, "alerts@example.com", cfg.Notifications.SMTPFrom)
+
+	for name, notifications := range map[string]string{
+		"AddrWithoutPort": "SMTP_ADDR: smtp.example.com\n  SMTP_FROM: alerts@example.com",
+		"WithoutFrom":     "SMTP_ADDR: smtp.example.com:587",
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(configPath, []byte("NOTIFICATIONS:\n  "+notifications+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigInvalidRetries(t *testing.T) {
+	for name, retries := range map[string]string{
+		"NoAttempts":      "SWAP:\n    MAXIMUM_ATTEMPTS: 0",
+		"ShrinkingWaits":  "WRAP:\n    BACKOFF_COEFFICIENT: 0.5",
+		"MaximumTooShort": "PRICE_FETCH:\n    INITIAL_INTERVAL: 1m",
+		"NegativeBudget":  "BUDGET: -1m",
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte("RETRIES:\n  "+retries+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigInvalidReadinessTimeout(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("SERVER:\n  READINESS_TIMEOUT: 0s\n"), 0644))
+
+	_, err := LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigInvalidTokenFetchTimeout(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("SERVER:\n  TOKEN_FETCH_TIMEOUT: 0s\n"), 0644))
+
+	_, err := LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigInvalidQuoteTTL(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("SWAP:\n  QUOTE_TTL: -1s\n"), 0644))
+
+	_, err := LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigInvalidTranches(t *testing.T) {
+	for name, swap := range map[string]string{
+		"no tranches":           "MAX_TRANCHES: 0",
+		"negative delay":        "TRANCHE_DELAY: -1s",
+		"delay above the limit": "TRANCHE_DELAY: 20m",
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte("SWAP:\n  "+swap+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigGasSponsorship(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+CHAINS:
+  polygon:
+    CHAIN_ID: 137
+    SPONSOR_GAS: true
+    SPONSOR_MAX_SWAP_USD: 0.5
+    SPONSOR_DAILY_CAP_USD: 100
+`), 0644))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]types.GasSponsorPolicy{137: {MaxSwapUSD: 0.5, DailyCapUSD: 100}}, cfg.GasSponsorPolicies())
+
+	require.NoError(t, os.WriteFile(configPath, []byte("CHAINS:\n  polygon:\n    SPONSOR_DAILY_CAP_USD: -1\n"), 0644))
+	_, err = LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigSwapLimits(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+CHAINS:
+  polygon:
+    CHAIN_ID: 137
+    SWAP_MAX_USD: 20000
+    SWAP_DAILY_LIMIT_USD: 1000000
+SWAP:
+  MAX_SWAP_AMOUNT: "250000"
+  USER_DAILY_LIMIT_USD: 50000
+  PAIR_LIMITS:
+    - PAIR: "usdc/ETH"
+      MAX_SWAP_USD: 10000
+      DAILY_LIMIT_USD: 200000
+`), 0644))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	limits := cfg.SwapLimits()
+	assert.Equal(t, 250000.0, limits.MaxSwapUSD)
+	assert.Equal(t, 50000.0, limits.UserDailyUSD)
+	assert.Equal(t, map[string]types.SwapAmountLimit{"ETH/USDC": {MaxSwapUSD: 10000, DailyUSD: 200000}}, limits.Pairs)
+	assert.Equal(t, types.SwapAmountLimit{MaxSwapUSD: 20000, DailyUSD: 1000000}, limits.Chains[137])
+
+	for name, content := range map[string]string{
+		"MaxSwapAmountNotANumber": "SWAP:\n  MAX_SWAP_AMOUNT: lots\n",
+		"NegativeUserLimit":       "SWAP:\n  USER_DAILY_LIMIT_USD: -1\n",
+		"PairWithoutTwoSymbols":   "SWAP:\n  PAIR_LIMITS:\n    - PAIR: ETH\n",
+		"NegativePairLimit":       "SWAP:\n  PAIR_LIMITS:\n    - PAIR: ETH/USDC\n      DAILY_LIMIT_USD: -5\n",
+		"NegativeChainLimit":      "CHAINS:\n  polygon:\n    SWAP_MAX_USD: -1\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigPriceImpactLimits(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+SWAP:
+  MAX_PRICE_IMPACT: 3
+POOLS:
+  - ID: "eth-usdc-3000"
+    MAX_PRICE_IMPACT: 1.5
+  - ID: "wbtc-usdc-3000"
+`), 0644))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	limits := cfg.PriceImpactLimits()
+	assert.Equal(t, 1.5, limits.Limit("eth-usdc-3000"))
+	assert.Equal(t, 3.0, limits.Limit("wbtc-usdc-3000"), "pools without a limit use SWAP.MAX_PRICE_IMPACT")
+
+	for _, content := range []string{
+		"SWAP:\n  MAX_PRICE_IMPACT: -1\n",
+		"POOLS:\n  - ID: \"eth-usdc-3000\"\n    MAX_PRICE_IMPACT: 101\n",
+	} {
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+		_, err = LoadConfig(configPath)
+		assert.Error(t, err, content)
+	}
+}
+
+func TestLoadConfigBridges(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `BRIDGES:
+  PROVIDERS:
+    - {NAME: wormhole, URL: "http://wormhole-quoter:8080", CHAINS: [ethereum, solana]}
+  CORRIDORS:
+    - {FROM: ethereum, TO: solana, PREFER: fastest, BRIDGES: [wormhole, universal]}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(yaml), 0644))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.Bridges.Providers, 1)
+	assert.Equal(t, []int64{1, 1399811149}, cfg.ChainIDs(cfg.Bridges.Providers[0].Chains))
+	require.Len(t, cfg.Bridges.Corridors, 1)
+	assert.Equal(t, "fastest", cfg.Bridges.Corridors[0].Prefer)
+	assert.Equal(t, []string{"wormhole", "universal"}, cfg.Bridges.Corridors[0].Bridges)
+}
+
+func TestLoadConfigInvalidBridges(t *testing.T) {
+	for name, bridges := range map[string]string{
+		"provider without a URL":  "PROVIDERS: [{NAME: wormhole, CHAINS: [ethereum, solana]}]",
+		"provider named across":   "PROVIDERS: [{NAME: across, URL: http://quoter, CHAINS: [ethereum, solana]}]",
+		"provider unknown chain":  "PROVIDERS: [{NAME: wormhole, URL: http://quoter, CHAINS: [ethereum, fantom]}]",
+		"provider one chain":      "PROVIDERS: [{NAME: wormhole, URL: http://quoter, CHAINS: [ethereum]}]",
+		"corridor unknown chain":  "CORRIDORS: [{FROM: ethereum, TO: fantom}]",
+		"corridor to itself":      "CORRIDORS: [{FROM: ethereum, TO: ethereum}]",
+		"corridor preference":     "CORRIDORS: [{FROM: ethereum, TO: polygon, PREFER: safest}]",
+		"corridor unknown bridge": "CORRIDORS: [{FROM: ethereum, TO: polygon, BRIDGES: [wormhole]}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte("BRIDGES:\n  "+bridges+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigTwoClaimCheckStores(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "TEMPORAL:\n  PAYLOADS:\n    CLAIM_CHECK_DIR: /var/lib/payloads\n    CLAIM_CHECK_URL: http://minio:9000/payloads\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(yaml), 0644))
+
+	_, err := LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigInvalidWorker(t *testing.T) {
+	for name, worker := range map[string]string{
+		"negative activities":     "MAX_CONCURRENT_ACTIVITIES: -1",
+		"negative workflow tasks": "MAX_CONCURRENT_WORKFLOW_TASKS: -1",
+		"negative stop timeout":   "STOP_TIMEOUT: -1s",
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte("TEMPORAL:\n  WORKER:\n    "+worker+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigUnknownChainQueue(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("TEMPORAL:\n  CHAIN_QUEUES:\n    CHAINS: [\"moonbeam\"]\n"), 0644))
+
+	_, err := LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigInvalidDeadLetterLookback(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("DEAD_LETTERS:\n  LOOKBACK: \"0s\"\n"), 0644))
+
+	_, err := LoadConfig(configPath)
+	assert.Error(t, err)
+
+	// A disabled scan needs no lookback
+	require.NoError(t, os.WriteFile(configPath, []byte("DEAD_LETTERS:\n  ENABLED: false\n  LOOKBACK: \"0s\"\n"), 0644))
+	_, err = LoadConfig(configPath)
+	assert.NoError(t, err)
+}
+
+func TestLoadConfigInvalidOutbound(t *testing.T) {
+	for name, outbound := range map[string]string{
+		"negative idle conns":  "MAX_IDLE_CONNS_PER_HOST: -1",
+		"negative retries":     "MAX_RETRIES: -1",
+		"negative retry wait":  "MAX_RETRY_WAIT: -1s",
+		"host without name":    "HOSTS:\n    - REQUESTS_PER_MINUTE: 30",
+		"negative host budget": "HOSTS:\n    - HOST: \"api.jup.ag\"\n      REQUESTS_PER_MINUTE: -1",
+		"relative proxy":       "PROXY_URL: \"proxy.corp:3128\"",
+		"ftp proxy":            "PROXY_URL: \"ftp://proxy.corp\"",
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte("OUTBOUND:\n  "+outbound+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigInvalidPriceBaseURL(t *testing.T) {
+	for name, baseURL := range map[string]string{
+		"missing source": "URL: \"https://jupiter.internal\"",
+		"relative url":   "SOURCE: \"jupiter\"\n      URL: \"jupiter.internal\"",
+	} {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte("PRICES:\n  BASE_URLS:\n    - "+baseURL+"\n"), 0644))
+
+			_, err := LoadConfig(configPath)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigInvalidSDKMiddleware(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("UNIVERSAL:\n  MIDDLEWARE:\n    FEE_ESTIMATE_TTL: \"-1s\"\n"), 0644))
+
+	_, err := LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
 func TestLoadConfigInvalidFile(t *testing.T) {
 	// Try to load config from a non-existent file
 	cfg, err := LoadConfig("non-existent-file.yaml")
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/infinity-dex/db/migrations"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -33,14 +34,47 @@ func DefaultDBConfig() DBConfig {
 	}
 }
 
+// LoadDBConfig returns the database configuration from the environment, reading DB_USER and DB_PASSWORD from
+// their secret stores when they reference a secret, such as DB_PASSWORD=vault:database/creds/dex#password
+func LoadDBConfig() (DBConfig, error) {
+	cfg := DefaultDBConfig()
+	if err := resolveSecrets(map[string]*string{"DB_USER": &cfg.User, "DB_PASSWORD": &cfg.Password}); err != nil {
+		return cfg, err
+	}
+	if _, set := os.LookupEnv("DB_PASSWORD"); !set {
+		log.Printf("DB_PASSWORD is not set, connecting to the database with the default password")
+	}
+	return cfg, nil
+}
+
 // ConnectionString returns a PostgreSQL connection string
 func (c DBConfig) ConnectionString() string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
 		c.User, c.Password, c.Host, c.Port, c.Database, c.SSLMode)
 }
 
-// NewDBPool creates a new database connection pool
+// NewDBPool creates a new database connection pool and checks that the database is reachable
 func NewDBPool(cfg DBConfig) (*pgxpool.Pool, error) {
+	pool, err := NewLazyDBPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test the connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to ping database: %w", err)
+	}
+
+	return pool, nil
+}
+
+// NewLazyDBPool creates a database connection pool without connecting.
+// Queries fail while the database is down and succeed again once it is back.
+func NewLazyDBPool(cfg DBConfig) (*pgxpool.Pool, error) {
 	poolConfig, err := pgxpool.ParseConfig(cfg.ConnectionString())
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse connection string: %w", err)
@@ -58,36 +92,63 @@ func NewDBPool(cfg DBConfig) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
 	}
 
-	// Test the connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := pool.Ping(ctx); err != nil {
-		return nil, fmt.Errorf("unable to ping database: %w", err)
-	}
-
 	return pool, nil
 }
 
-// InitDatabase initializes the database with the schema
-func InitDatabase(pool *pgxpool.Pool, schemaPath string) error {
-	// Read schema file
-	schema, err := os.ReadFile(schemaPath)
+// migrateTimeout bounds applying the schema and pending migrations
+const migrateTimeout = 5 * time.Minute
+
+// MigrateDatabase applies the schema and the embedded migrations the database hasn't applied yet
+func MigrateDatabase(pool *pgxpool.Pool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	if err := migrations.Up(ctx, pool); err != nil {
+		return fmt.Errorf("unable to migrate database: %w", err)
+	}
+	status, err := migrations.CurrentStatus(ctx, pool)
 	if err != nil {
-		return fmt.Errorf("unable to read schema file: %w", err)
+		return fmt.Errorf("unable to read migration version: %w", err)
 	}
+	log.Printf("Database migrated to version %d", status.Current)
+	return nil
+}
 
-	// Execute schema
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// CheckDatabaseVersion logs a warning when the database hasn't applied every embedded migration, for processes that
+// don't migrate it themselves
+func CheckDatabaseVersion(pool *pgxpool.Pool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err = pool.Exec(ctx, string(schema))
+	status, err := migrations.CurrentStatus(ctx, pool)
 	if err != nil {
-		return fmt.Errorf("unable to execute schema: %w", err)
+		log.Printf("Unable to read database migration version: %v", err)
+		return
 	}
+	if status.Pending() {
+		log.Printf("Database is at migration %d of %d; run `infctl migrate up` to apply the rest", status.Current, status.Latest)
+	}
+}
 
-	log.Println("Database schema initialized successfully")
-	return nil
+// InitDatabaseWhenAvailable migrates the database, retrying every interval until the database is reachable and
+// the migrations succeed, or ctx is done
+func InitDatabaseWhenAvailable(ctx context.Context, pool *pgxpool.Pool, interval time.Duration) error {
+	for {
+		err := pool.Ping(ctx)
+		if err == nil {
+			err = MigrateDatabase(pool)
+		}
+		if err == nil {
+			return nil
+		}
+		log.Printf("Database not initialized, retrying in %s: %v", interval, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
 }
 
 // ExecuteInTransaction executes a function within a transaction
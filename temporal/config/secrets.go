@@ -0,0 +1,260 @@
+package temporal_config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/chains/signer"
+)
+
+// Schemes of the secret references configuration values may hold in place of a secret
+const (
+	SecretSchemeEnv               = "env"    // env:NAME reads an environment variable
+	SecretSchemeFile              = "file"   // file:/run/secrets/name reads a file, such as a mounted Kubernetes secret
+	SecretSchemeVault             = "vault"  // vault:secret/data/dex#field reads a field of a Vault KV secret
+	SecretSchemeAWSSecretsManager = "aws-sm" // aws-sm:secret-id#field reads an AWS Secrets Manager secret or a field of its JSON
+)
+
+const (
+	secretResolveTimeout = 30 * time.Second // Bounds resolving every secret of a configuration
+	secretRequestTimeout = 10 * time.Second // Bounds each request to Vault or Secrets Manager
+	secretResponseLimit  = 1 << 20
+)
+
+// SecretProvider reads secrets from one secret store
+type SecretProvider interface {
+	// Secret returns the secret ref names in the store, ref being a reference without its scheme
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolver resolves configuration values that reference a secret through the provider registered for the
+// reference's scheme. Values without a registered scheme are plain secrets and are returned as they are.
+type SecretResolver struct {
+	mu        sync.RWMutex
+	providers map[string]SecretProvider
+}
+
+// NewSecretResolver creates a resolver without providers
+func NewSecretResolver() *SecretResolver {
+	return &SecretResolver{providers: make(map[string]SecretProvider)}
+}
+
+// DefaultSecretResolver creates a resolver of env, file, Vault and AWS Secrets Manager references. Vault is reached
+// at VAULT_ADDR with VAULT_TOKEN, and Secrets Manager in AWS_REGION with the AWS_* credentials.
+func DefaultSecretResolver(client *http.Client) *SecretResolver {
+	r := NewSecretResolver()
+	r.Register(SecretSchemeEnv, EnvSecrets{})
+	r.Register(SecretSchemeFile, FileSecrets{})
+	r.Register(SecretSchemeVault, &VaultSecrets{client: client})
+	r.Register(SecretSchemeAWSSecretsManager, &AWSSecretsManager{client: client, now: time.Now})
+	return r
+}
+
+// Register makes provider resolve references with scheme, replacing the scheme's previous provider
+func (r *SecretResolver) Register(scheme string, provider SecretProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[scheme] = provider
+}
+
+// Resolve returns the secret value references, or value itself when it isn't a reference
+func (r *SecretResolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+	r.mu.RLock()
+	provider, ok := r.providers[scheme]
+	r.mu.RUnlock()
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := provider.Secret(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s secret %s: %w", scheme, strings.SplitN(ref, "#", 2)[0], err)
+	}
+	return secret, nil
+}
+
+// secrets resolves the references in configuration files and the environment
+var secrets = DefaultSecretResolver(&http.Client{Timeout: secretRequestTimeout})
+
+// SetSecretResolver replaces the resolver LoadConfig and LoadDBConfig use, such as to register another provider
+func SetSecretResolver(resolver *SecretResolver) {
+	secrets = resolver
+}
+
+// resolveSecrets replaces each value that references a secret with the secret, naming the setting whose reference
+// failed
+func resolveSecrets(values map[string]*string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	for name, value := range values {
+		secret, err := secrets.Resolve(ctx, *value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*value = secret
+	}
+	return nil
+}
+
+// EnvSecrets reads secrets from environment variables
+type EnvSecrets struct{}
+
+// Secret returns the environment variable named name, which must be set
+func (EnvSecrets) Secret(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+	return value, nil
+}
+
+// FileSecrets reads secrets from files, without surrounding whitespace
+type FileSecrets struct{}
+
+// Secret returns the contents of the file at path
+func (FileSecrets) Secret(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// VaultSecrets reads fields of HashiCorp Vault KV secrets, version 1 or 2, from the Vault at VAULT_ADDR with the
+// token VAULT_TOKEN, in the namespace VAULT_NAMESPACE if set
+type VaultSecrets struct {
+	client *http.Client
+}
+
+// Secret reads the field of a ref like secret/data/dex#universal_api_key
+func (v *VaultSecrets) Secret(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || field == "" {
+		return "", fmt.Errorf("expected a reference like secret/data/dex#field")
+	}
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := doSecretRequest(v.client, req, "Vault", &secret); err != nil {
+		return "", err
+	}
+
+	// KV version 2 nests the secret's fields under data.data, next to its metadata
+	fields := secret.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+	value, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string field %s", field)
+	}
+	return value, nil
+}
+
+// AWSSecretsManager reads secrets from AWS Secrets Manager in AWS_REGION, or at AWS_ENDPOINT_URL_SECRETS_MANAGER,
+// with the credentials in AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+type AWSSecretsManager struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// Secret reads the secret string of a ref like infinity-dex/prod, or a field of its JSON with infinity-dex/prod#field
+func (m *AWSSecretsManager) Secret(ctx context.Context, ref string) (string, error) {
+	secretID, field, _ := strings.Cut(ref, "#")
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		return "", fmt.Errorf("AWS_REGION must be set")
+	}
+	credentials, err := signer.AWSCredentialsFromEnv()
+	if err != nil {
+		return "", err
+	}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signer.SignV4(req, body, credentials, region, "secretsmanager", m.now())
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := doSecretRequest(m.client, req, "Secrets Manager", &secret); err != nil {
+		return "", err
+	}
+	if secret.SecretString == nil {
+		return "", fmt.Errorf("secret is binary, expected a secret string")
+	}
+	if field == "" {
+		return *secret.SecretString, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(*secret.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret string is not a JSON object: %w", err)
+	}
+	value, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string field %s", field)
+	}
+	return value, nil
+}
+
+// doSecretRequest sends a request to a secret store and decodes its JSON response. Failures report only the
+// store's status, as its error bodies may echo the request.
+func doSecretRequest(client *http.Client, req *http.Request, store string, response interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, secretResponseLimit))
+		return fmt.Errorf("%s returned status %d", store, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, secretResponseLimit)).Decode(response)
+}
@@ -0,0 +1,153 @@
+package temporal_config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecretReferences(t *testing.T) {
+	resolver := DefaultSecretResolver(http.DefaultClient)
+	secretPath := filepath.Join(t.TempDir(), "api-key")
+	require.NoError(t, os.WriteFile(secretPath, []byte("file-api-key\n"), 0600))
+	t.Setenv("TEST_SECRET", "env-api-key")
+
+	for value, expected := range map[string]string{
+		"plain-api-key":      "plain-api-key",
+		"https://rpc.test":   "https://rpc.test",
+		"env:TEST_SECRET":    "env-api-key",
+		"file:" + secretPath: "file-api-key",
+	} {
+		secret, err := resolver.Resolve(context.Background(), value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, secret, value)
+	}
+
+	_, err := resolver.Resolve(context.Background(), "env:TEST_SECRET_UNSET")
+	assert.Error(t, err)
+	_, err = resolver.Resolve(context.Background(), "file:"+filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestVaultSecrets(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/dex": // KV version 2
+			w.Write([]byte(`{"data": {"data": {"api_key": "kv2-api-key"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/dex": // KV version 1
+			w.Write([]byte(`{"data": {"api_key": "kv1-api-key"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "vault-token")
+	resolver := DefaultSecretResolver(vault.Client())
+
+	secret, err := resolver.Resolve(context.Background(), "vault:secret/data/dex#api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "kv2-api-key", secret)
+	secret, err = resolver.Resolve(context.Background(), "vault:kv/dex#api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "kv1-api-key", secret)
+
+	for _, ref := range []string{"vault:secret/data/dex#missing", "vault:secret/data/other#api_key", "vault:secret/data/dex"} {
+		_, err := resolver.Resolve(context.Background(), ref)
+		assert.Error(t, err, ref)
+	}
+
+	t.Setenv("VAULT_TOKEN", "wrong-token")
+	_, err = resolver.Resolve(context.Background(), "vault:secret/data/dex#api_key")
+	assert.Error(t, err)
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	secretsManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.Contains(r.Header.Get("Authorization"), "/us-east-1/secretsmanager/aws4_request") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var request struct {
+			SecretId string
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch request.SecretId {
+		case "infinity-dex/prod":
+			w.Write([]byte(`{"Name": "infinity-dex/prod", "SecretString": "{\"db_password\": \"sm-password\"}"}`))
+		case "infinity-dex/api-key":
+			w.Write([]byte(`{"Name": "infinity-dex/api-key", "SecretString": "sm-api-key"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type": "ResourceNotFoundException"}`))
+		}
+	}))
+	defer secretsManager.Close()
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", secretsManager.URL)
+	resolver := DefaultSecretResolver(secretsManager.Client())
+
+	secret, err := resolver.Resolve(context.Background(), "aws-sm:infinity-dex/prod#db_password")
+	require.NoError(t, err)
+	assert.Equal(t, "sm-password", secret)
+	secret, err = resolver.Resolve(context.Background(), "aws-sm:infinity-dex/api-key")
+	require.NoError(t, err)
+	assert.Equal(t, "sm-api-key", secret)
+
+	for _, ref := range []string{"aws-sm:infinity-dex/missing", "aws-sm:infinity-dex/prod#missing", "aws-sm:infinity-dex/api-key#field"} {
+		_, err := resolver.Resolve(context.Background(), ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestLoadConfigResolvesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "universal-api-key")
+	require.NoError(t, os.WriteFile(secretPath, []byte("file-api-key\n"), 0600))
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("UNIVERSAL:\n  API_KEY: file:"+secretPath+"\n"), 0644))
+	t.Setenv("WEBHOOK_SIGNING_SECRET", "env:TEST_WEBHOOK_SECRET")
+	t.Setenv("TEST_WEBHOOK_SECRET", "webhook-secret")
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "file-api-key", cfg.Universal.APIKey)
+	assert.Equal(t, "webhook-secret", cfg.Webhooks.SigningSecret, "environment variables may reference secrets too")
+
+	// A reference that can't be read fails loading rather than leave the reference in place of the secret
+	require.NoError(t, os.WriteFile(configPath, []byte("EXECUTION:\n  KEYSTORE_PASSWORD: file:"+filepath.Join(dir, "missing")+"\n"), 0644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EXECUTION.KEYSTORE_PASSWORD")
+}
+
+func TestLoadDBConfig(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "db-password")
+	require.NoError(t, os.WriteFile(secretPath, []byte("db-secret\n"), 0600))
+	t.Setenv("DB_USER", "dex")
+	t.Setenv("DB_PASSWORD", "file:"+secretPath)
+
+	cfg, err := LoadDBConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "dex", cfg.User)
+	assert.Equal(t, "db-secret", cfg.Password)
+
+	t.Setenv("DB_PASSWORD", "env:TEST_DB_PASSWORD_UNSET")
+	_, err = LoadDBConfig()
+	assert.Error(t, err)
+}
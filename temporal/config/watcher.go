@@ -0,0 +1,69 @@
+package temporal_config
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Watcher holds the latest configuration loaded from a file, reloading it when the file changes or on Reload.
+// A reload that fails LoadConfig's checks keeps the previous configuration, so a bad edit never reaches a
+// running process.
+type Watcher struct {
+	path     string
+	current  atomic.Pointer[Config]
+	handlers []func(Config)
+	mu       sync.Mutex // Serializes reloads and the handlers they call
+}
+
+// NewWatcher creates a watcher of the file at path, which LoadConfig has already loaded into cfg; an empty path
+// searches for the file as LoadConfig does
+func NewWatcher(path string, cfg Config) *Watcher {
+	w := &Watcher{path: path}
+	w.current.Store(&cfg)
+	return w
+}
+
+// Config returns the latest configuration. Callers must not modify it; a reload replaces it rather than changing it.
+func (w *Watcher) Config() *Config {
+	return w.current.Load()
+}
+
+// OnReload registers a handler called with each configuration a reload loads, after it becomes current
+func (w *Watcher) OnReload(handler func(Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers = append(w.handlers, handler)
+}
+
+// Reload loads the file again and makes it the current configuration
+func (w *Watcher) Reload() (Config, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cfg, err := LoadConfig(w.path)
+	if err != nil {
+		return *w.current.Load(), err
+	}
+	w.current.Store(&cfg)
+	for _, handler := range w.handlers {
+		handler(cfg)
+	}
+	return cfg, nil
+}
+
+// Watch reloads the configuration whenever its file is written or, as Kubernetes updates ConfigMaps, replaced
+func (w *Watcher) Watch() {
+	viper.OnConfigChange(func(event fsnotify.Event) {
+		if _, err := w.Reload(); err != nil {
+			log.Printf("Keeping the previous configuration, failed to reload %s: %v", event.Name, err)
+			return
+		}
+		log.Printf("Reloaded configuration from %s", event.Name)
+	})
+	viper.WatchConfig()
+}
@@ -0,0 +1,38 @@
+package temporal_config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherReload(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("SWAP:\n  DEFAULT_SLIPPAGE: 0.5\n"), 0644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	watcher := NewWatcher(configPath, cfg)
+	var reloaded []float64
+	watcher.OnReload(func(cfg Config) {
+		reloaded = append(reloaded, cfg.Swap.DefaultSlippage)
+	})
+	assert.Equal(t, 0.5, watcher.Config().Swap.DefaultSlippage)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("SWAP:\n  DEFAULT_SLIPPAGE: 1.5\n"), 0644))
+	cfg, err = watcher.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, cfg.Swap.DefaultSlippage)
+	assert.Equal(t, 1.5, watcher.Config().Swap.DefaultSlippage)
+	assert.Equal(t, []float64{1.5}, reloaded)
+
+	// An edit LoadConfig refuses leaves the last good configuration in place
+	require.NoError(t, os.WriteFile(configPath, []byte("SWAP:\n  DEFAULT_SLIPPAGE: 2\nSERVER:\n  READINESS_TIMEOUT: 0s\n"), 0644))
+	_, err = watcher.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, 1.5, watcher.Config().Swap.DefaultSlippage)
+	assert.Equal(t, []float64{1.5}, reloaded)
+}
@@ -0,0 +1,236 @@
+package temporal_payloads
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	temporal_config "github.com/infinity-dex/temporal/config"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/proto"
+)
+
+// Encodings of the payloads the codecs produce, in the payload's "encoding" metadata
+const (
+	EncodingGzip       = "binary/gzip"
+	EncodingClaimCheck = "binary/claim-check"
+)
+
+// claimCheckTimeout bounds each read or write of the claim-check store
+const claimCheckTimeout = 10 * time.Second
+
+// ErrPayloadTooLarge is returned when a workflow or activity payload is larger than Temporal accepts, even after
+// compression, and no claim-check store is configured to hold it
+var ErrPayloadTooLarge = errors.New("payload too large")
+
+// ErrClaimCheckNotFound is returned when the claim-check store doesn't hold a payload a reference points to
+var ErrClaimCheckNotFound = errors.New("claim-checked payload not found")
+
+// BlobStore holds claim-checked payloads by key
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Options configure the data converter. Zero thresholds disable compression and claim checks.
+type Options struct {
+	CompressThreshold   int       // Bytes from which payloads are gzipped
+	ClaimCheckThreshold int       // Bytes from which encoded payloads are moved to Store
+	MaxSize             int       // Bytes over which payloads are refused; 0 accepts any size
+	Store               BlobStore // Claim-check store; nil disables claim checks
+}
+
+// OptionsFromConfig returns the options of a payloads configuration, with the claim-check store it names
+func OptionsFromConfig(cfg temporal_config.PayloadsConfig) Options {
+	opts := Options{
+		CompressThreshold:   cfg.CompressThreshold,
+		ClaimCheckThreshold: cfg.ClaimCheckThreshold,
+		MaxSize:             cfg.MaxSize,
+	}
+	switch {
+	case cfg.ClaimCheckDir != "":
+		opts.Store = NewFileStore(cfg.ClaimCheckDir)
+	case cfg.ClaimCheckURL != "":
+		opts.Store = NewHTTPStore(&http.Client{Timeout: claimCheckTimeout}, cfg.ClaimCheckURL)
+	}
+	return opts
+}
+
+// NewDataConverter returns a data converter encoding values as JSON like Temporal's default one, then gzipping
+// large payloads, moving those still large to the claim-check store and refusing any still too large with
+// ErrPayloadTooLarge. It reads payloads written by the default converter, so it can be rolled out with thresholds
+// left at zero before any process writes compressed or claim-checked payloads.
+func NewDataConverter(opts Options) converter.DataConverter {
+	return converter.NewCodecDataConverter(converter.GetDefaultDataConverter(),
+		&sizeGuardCodec{maxSize: opts.MaxSize},
+		&claimCheckCodec{threshold: opts.ClaimCheckThreshold, store: opts.Store},
+		&gzipCodec{threshold: opts.CompressThreshold},
+	)
+}
+
+// encoding returns a payload's encoding metadata
+func encoding(payload *commonpb.Payload) string {
+	return string(payload.GetMetadata()[converter.MetadataEncoding])
+}
+
+// gzipCodec gzips payloads of at least threshold bytes, keeping the original when compressing doesn't shrink it
+type gzipCodec struct {
+	threshold int
+}
+
+// Encode gzips large payloads
+func (c *gzipCodec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		result[i] = p
+		size := proto.Size(p)
+		if c.threshold <= 0 || size < c.threshold {
+			continue
+		}
+		raw, err := proto.Marshal(p)
+		if err != nil {
+			return payloads, err
+		}
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(raw); err != nil {
+			return payloads, err
+		}
+		if err := w.Close(); err != nil {
+			return payloads, err
+		}
+		if buf.Len() >= size {
+			continue
+		}
+		result[i] = &commonpb.Payload{
+			Metadata: map[string][]byte{converter.MetadataEncoding: []byte(EncodingGzip)},
+			Data:     buf.Bytes(),
+		}
+	}
+	return result, nil
+}
+
+// Decode decompresses gzipped payloads, passing others through
+func (c *gzipCodec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		result[i] = p
+		if encoding(p) != EncodingGzip {
+			continue
+		}
+		r, err := gzip.NewReader(bytes.NewReader(p.Data))
+		if err != nil {
+			return payloads, fmt.Errorf("gzipped payload: %w", err)
+		}
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return payloads, fmt.Errorf("gzipped payload: %w", err)
+		}
+		decoded := &commonpb.Payload{}
+		if err := proto.Unmarshal(raw, decoded); err != nil {
+			return payloads, fmt.Errorf("gzipped payload: %w", err)
+		}
+		result[i] = decoded
+	}
+	return result, nil
+}
+
+// claimCheckCodec moves payloads of at least threshold bytes to a store, replacing them with a reference: the
+// SHA-256 of the stored payload, so writing the same payload twice stores it once
+type claimCheckCodec struct {
+	threshold int
+	store     BlobStore
+}
+
+// Encode stores large payloads, passing references on in their place
+func (c *claimCheckCodec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		result[i] = p
+		if c.store == nil || c.threshold <= 0 || proto.Size(p) < c.threshold {
+			continue
+		}
+		raw, err := proto.Marshal(p)
+		if err != nil {
+			return payloads, err
+		}
+		sum := sha256.Sum256(raw)
+		key := hex.EncodeToString(sum[:])
+
+		ctx, cancel := context.WithTimeout(context.Background(), claimCheckTimeout)
+		err = c.store.Put(ctx, key, raw)
+		cancel()
+		if err != nil {
+			return payloads, fmt.Errorf("claim-check payload of %d bytes: %w", len(raw), err)
+		}
+		result[i] = &commonpb.Payload{
+			Metadata: map[string][]byte{converter.MetadataEncoding: []byte(EncodingClaimCheck)},
+			Data:     []byte(key),
+		}
+	}
+	return result, nil
+}
+
+// Decode fetches the payloads references point to, passing others through
+func (c *claimCheckCodec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		result[i] = p
+		if encoding(p) != EncodingClaimCheck {
+			continue
+		}
+		key := string(p.Data)
+		if c.store == nil {
+			return payloads, fmt.Errorf("claim-checked payload %s: no claim-check store configured", key)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), claimCheckTimeout)
+		raw, err := c.store.Get(ctx, key)
+		cancel()
+		if err != nil {
+			return payloads, fmt.Errorf("claim-checked payload %s: %w", key, err)
+		}
+		if sum := sha256.Sum256(raw); hex.EncodeToString(sum[:]) != key {
+			return payloads, fmt.Errorf("claim-checked payload %s: stored payload doesn't match its key", key)
+		}
+		decoded := &commonpb.Payload{}
+		if err := proto.Unmarshal(raw, decoded); err != nil {
+			return payloads, fmt.Errorf("claim-checked payload %s: %w", key, err)
+		}
+		result[i] = decoded
+	}
+	return result, nil
+}
+
+// sizeGuardCodec refuses payloads larger than maxSize bytes, so an oversized input or result fails where it is
+// written with its size rather than as a gRPC error from the Temporal server
+type sizeGuardCodec struct {
+	maxSize int
+}
+
+// Encode returns an error wrapping ErrPayloadTooLarge for the first payload over the limit
+func (c *sizeGuardCodec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	if c.maxSize <= 0 {
+		return payloads, nil
+	}
+	for _, p := range payloads {
+		if size := proto.Size(p); size > c.maxSize {
+			return payloads, fmt.Errorf("%w: %s payload of %d bytes exceeds the limit of %d bytes; configure a claim-check store or pass less data",
+				ErrPayloadTooLarge, encoding(p), size, c.maxSize)
+		}
+	}
+	return payloads, nil
+}
+
+// Decode passes payloads through; what was written is always read
+func (c *sizeGuardCodec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	return payloads, nil
+}
@@ -0,0 +1,178 @@
+package temporal_payloads
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// memoryStore is a claim-check store in memory
+type memoryStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (s *memoryStore) Put(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blobs == nil {
+		s.blobs = make(map[string][]byte)
+	}
+	s.blobs[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, ErrClaimCheckNotFound
+	}
+	return data, nil
+}
+
+type tokenList struct {
+	Tokens []string
+}
+
+// largeTokenList returns a compressible token list of about size bytes of JSON
+func largeTokenList(size int) tokenList {
+	var list tokenList
+	for n := 0; n < size; n += 48 {
+		list.Tokens = append(list.Tokens, "0x"+strings.Repeat("ab", 20))
+	}
+	return list
+}
+
+func TestDataConverter(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		size     int
+		encoding string
+	}{
+		{"SmallPassesThrough", Options{CompressThreshold: 4096}, 100, "json/plain"},
+		{"LargeIsCompressed", Options{CompressThreshold: 4096}, 64 << 10, EncodingGzip},
+		{"CompressedStillLargeIsClaimChecked", Options{CompressThreshold: 4096, ClaimCheckThreshold: 256, Store: &memoryStore{}}, 64 << 10, EncodingClaimCheck},
+		{"Disabled", Options{}, 64 << 10, "json/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := NewDataConverter(tt.opts)
+			want := largeTokenList(tt.size)
+			payloads, err := dc.ToPayloads(want)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if got := encoding(payloads.Payloads[0]); got != tt.encoding {
+				t.Errorf("Expected %s encoding, got %s", tt.encoding, got)
+			}
+
+			var got tokenList
+			if err := dc.FromPayloads(payloads, &got); err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if len(got.Tokens) != len(want.Tokens) {
+				t.Errorf("Expected %d tokens back, got %d", len(want.Tokens), len(got.Tokens))
+			}
+		})
+	}
+
+	t.Run("ReadsDefaultConverterPayloads", func(t *testing.T) {
+		payloads, err := converter.GetDefaultDataConverter().ToPayloads(largeTokenList(64 << 10))
+		if err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+		var got tokenList
+		if err := NewDataConverter(Options{CompressThreshold: 4096}).FromPayloads(payloads, &got); err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+	})
+}
+
+func TestPayloadSizeGuard(t *testing.T) {
+	// Random addresses don't compress below the limit
+	random := rand.New(rand.NewSource(1))
+	var list tokenList
+	for i := 0; i < 256; i++ {
+		address := make([]byte, 20)
+		random.Read(address)
+		list.Tokens = append(list.Tokens, "0x"+hex.EncodeToString(address))
+	}
+
+	_, err := NewDataConverter(Options{CompressThreshold: 4096, MaxSize: 1024}).ToPayloads(list)
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("Expected ErrPayloadTooLarge, got %v", err)
+	}
+
+	// A claim-check store takes what the limit refuses
+	store := &memoryStore{}
+	dc := NewDataConverter(Options{CompressThreshold: 4096, ClaimCheckThreshold: 512, MaxSize: 1024, Store: store})
+	payloads, err := dc.ToPayloads(list)
+	if err != nil {
+		t.Fatalf("Expected the payload to be claim-checked, got %v", err)
+	}
+	if len(store.blobs) != 1 {
+		t.Errorf("Expected one stored payload, got %d", len(store.blobs))
+	}
+
+	// A reference whose payload is gone fails to decode rather than decoding as empty
+	store.blobs = nil
+	var got tokenList
+	if err := dc.FromPayloads(payloads, &got); !errors.Is(err, ErrClaimCheckNotFound) {
+		t.Errorf("Expected ErrClaimCheckNotFound, got %v", err)
+	}
+}
+
+func TestBlobStores(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	bucket := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = data
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer bucket.Close()
+
+	stores := map[string]BlobStore{
+		"File": NewFileStore(t.TempDir()),
+		"HTTP": NewHTTPStore(bucket.Client(), bucket.URL+"/payloads/"),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrClaimCheckNotFound) {
+				t.Errorf("Expected ErrClaimCheckNotFound, got %v", err)
+			}
+			if err := store.Put(ctx, "key", []byte("payload")); err != nil {
+				t.Fatalf("Failed to put: %v", err)
+			}
+			data, err := store.Get(ctx, "key")
+			if err != nil || string(data) != "payload" {
+				t.Errorf("Expected the payload back, got %q, %v", data, err)
+			}
+		})
+	}
+}
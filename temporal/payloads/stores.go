@@ -0,0 +1,104 @@
+package temporal_payloads
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore keeps claim-checked payloads as files in a directory every process shares
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a store keeping payloads in dir, which is created on first write
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Put writes a payload, replacing the file atomically so readers never see part of it
+func (s *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	key = filepath.Base(key)
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, key))
+}
+
+// Get reads a payload, failing with ErrClaimCheckNotFound when there is none under key
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.Base(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrClaimCheckNotFound
+	}
+	return data, err
+}
+
+// HTTPStore keeps claim-checked payloads in an object storage bucket, writing each with a PUT to the bucket URL
+// and the key and reading it back with a GET
+type HTTPStore struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewHTTPStore returns a store keeping payloads under baseURL
+func NewHTTPStore(client *http.Client, baseURL string) *HTTPStore {
+	return &HTTPStore{client: client, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Put uploads a payload
+func (s *HTTPStore) Put(ctx context.Context, key string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.baseURL+"/"+key, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("claim-check store returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Get downloads a payload, failing with ErrClaimCheckNotFound when the bucket doesn't hold it
+func (s *HTTPStore) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/"+key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrClaimCheckNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("claim-check store returned %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -2,42 +2,75 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/repository"
 	"github.com/infinity-dex/services/types"
 	temporal_activities "github.com/infinity-dex/temporal/activities"
 	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_payloads "github.com/infinity-dex/temporal/payloads"
 	temporal_workflows "github.com/infinity-dex/temporal/workflows"
 	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/worker"
 )
 
 const (
 	// PriceOracleTaskQueue is the name of the task queue used for price oracle workflows
 	PriceOracleTaskQueue = "price-oracle-queue"
+
+	// PriceUpdateWorkflowID is the ID of the long-running workflow updating prices
+	PriceUpdateWorkflowID = "scheduled-price-update-v2"
+	// PriceUpdateScheduleID is the ID of the Temporal Schedule updating prices when PRICES.UPDATE_SCHEDULE is set
+	PriceUpdateScheduleID = "price-update-schedule"
+
+	// TokenMetadataWorkflowID is the ID of the cron workflow refreshing token metadata
+	TokenMetadataWorkflowID = "refresh-token-metadata"
+	// TokenMetadataCronSchedule runs the token metadata refresh daily
+	TokenMetadataCronSchedule = "0 3 * * *"
 )
 
 // RunPriceWorker starts the price oracle worker
 func RunPriceWorker() {
 	log.Println("Starting Price Oracle Worker...")
 
+	// Load Temporal, region and price source configuration
+	cfg, err := temporal_config.LoadConfig(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Printf("Failed to load config, using defaults: %v", err)
+		cfg = temporal_config.DefaultConfig()
+	}
+
 	// Create a Temporal client
 	c, err := client.Dial(client.Options{
-		HostPort: "localhost:7233", // Local Temporal server
+		HostPort:      cfg.Temporal.HostPort,
+		Namespace:     cfg.Region.Namespace,
+		DataConverter: temporal_payloads.NewDataConverter(temporal_payloads.OptionsFromConfig(cfg.Temporal.Payloads)),
 	})
 	if err != nil {
 		log.Fatalf("Failed to create Temporal client: %v", err)
 	}
 	defer c.Close()
 
-	// Create a worker
-	w := worker.New(c, PriceOracleTaskQueue, worker.Options{})
+	// Create a worker polling this region's price oracle queue
+	// Report the errors of its workflows, activities and SDK calls
+	errorReporter := newErrorReporter(cfg)
+	w := worker.New(c, cfg.Region.Scoped(PriceOracleTaskQueue),
+		temporal_activities.WorkerOptions(cfg.Temporal.Worker, temporal_activities.NewErrorReportingInterceptor(errorReporter)))
 
 	// Initialize Universal SDK with mock configuration
 	sdkConfig := universalsdk.MockSDKConfig{
@@ -45,49 +78,166 @@ func RunPriceWorker() {
 		Latency:       100 * time.Millisecond,
 		FailureRate:   0.05, // 5% failure rate for testing
 	}
-	sdk := universalsdk.NewMockSDK(sdkConfig)
+	if path := cfg.Universal.MockScenario; path != "" {
+		scenario, err := universalsdk.LoadScenario(path)
+		if err != nil {
+			log.Fatalf("Failed to load mock SDK scenario: %v", err)
+		}
+		sdkConfig = scenario.Apply(sdkConfig)
+	}
+	// Calls are counted, cached and retried around the reporting of each attempt's error
+	sdkMetrics := universalsdk.NewCallMetrics()
+	sdk := universalsdk.Chain(services.NewReportingSDK(universalsdk.NewMockSDK(sdkConfig), errorReporter),
+		temporal_activities.SDKMiddleware(cfg.Universal.Middleware, sdkMetrics)...)
 
 	// Set up cache directory
-	homeDir, err := os.UserHomeDir()
+	cacheDir, err := temporal_activities.DefaultPriceCacheDir()
 	if err != nil {
 		log.Fatalf("Failed to get user home directory: %v", err)
 	}
-	cacheDir := filepath.Join(homeDir, ".infinity-dex", "price-cache")
 
 	// Initialize database connection
-	dbConfig := temporal_config.DefaultDBConfig()
+	dbConfig, err := temporal_config.LoadDBConfig()
+	if err != nil {
+		log.Fatalf("Failed to load database config: %v", err)
+	}
 	dbPool, err := temporal_config.NewDBPool(dbConfig)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		// Keep serving prices from the cache; writes go to the outbox until the database is back
+		log.Printf("Database unavailable, queueing price writes in outbox: %v", err)
+		dbPool, err = temporal_config.NewLazyDBPool(dbConfig)
+		if err != nil {
+			log.Fatalf("Failed to create database pool: %v", err)
+		}
+		if cfg.Database.AutoMigrate {
+			// Migrate the database once it comes up
+			initCtx, cancelInit := context.WithCancel(context.Background())
+			defer cancelInit()
+			go temporal_config.InitDatabaseWhenAvailable(initCtx, dbPool, 30*time.Second)
+		}
+	} else if cfg.Database.AutoMigrate {
+		if err := temporal_config.MigrateDatabase(dbPool); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	} else {
+		temporal_config.CheckDatabaseVersion(dbPool)
 	}
 	defer dbPool.Close()
 
-	// Initialize database schema
-	schemaPath := filepath.Join("db", "schema.sql")
-	if err := temporal_config.InitDatabase(dbPool, schemaPath); err != nil {
-		log.Fatalf("Failed to initialize database schema: %v", err)
+	// Count errors in the database, alongside the API server's
+	errorReporter.SetStore(repository.NewErrorRepository(dbPool))
+	flushCtx, stopFlushing := context.WithCancel(context.Background())
+	defer stopFlushing()
+	errorReporter.StartFlushing(flushCtx, cfg.Errors.FlushInterval)
+	if cfg.Universal.Middleware.StatsInterval > 0 {
+		sdkMetrics.StartLogging(flushCtx, cfg.Universal.Middleware.StatsInterval)
+	}
+
+	outbox, err := temporal_activities.NewPriceOutbox(filepath.Join(cacheDir, "outbox"))
+	if err != nil {
+		log.Fatalf("Failed to create price outbox: %v", err)
+	}
+
+	// Price APIs are called through one transport pacing each within its rate limit
+	outbound, err := temporal_activities.NewOutboundTransport(temporal_activities.OutboundOptionsFromConfig(cfg))
+	if err != nil {
+		log.Fatalf("Invalid outbound HTTP config: %v", err)
+	}
+	if cfg.Outbound.StatsInterval > 0 {
+		outbound.StartLogging(flushCtx, cfg.Outbound.StatsInterval)
 	}
 
 	// Initialize activities
-	priceActivities := temporal_activities.NewPriceActivities(sdk, cacheDir)
-	dbActivities := temporal_activities.NewDBActivities(dbPool)
+	httpClient := outbound.Client(10 * time.Second)
+	priceSources := temporal_activities.DefaultPriceSources(sdk, httpClient)
+	if source, ok := priceSources.Get(types.PriceSourceCoinGecko); ok {
+		if err := source.(*temporal_activities.CoinGeckoPriceSource).SetAPIKey(cfg.Prices.CoinGeckoPlan, cfg.Prices.CoinGeckoAPIKey); err != nil {
+			log.Fatalf("Invalid CoinGecko config: %v", err)
+		}
+	}
+	for _, mirror := range cfg.Prices.BaseURLs {
+		if err := priceSources.SetBaseURL(types.PriceSource(mirror.Source), mirror.URL); err != nil {
+			log.Fatalf("Invalid price source base URL: %v", err)
+		}
+	}
+	rpcURLs, feeds := chainlinkFeeds(cfg)
+	priceSources.MustRegister(temporal_activities.NewChainlinkPriceSource(httpClient, rpcURLs, feeds))
+	priceActivities := temporal_activities.NewPriceActivitiesWithSources(sdk, cacheDir, priceSources)
+	priceActivities.SetSanityBand(temporal_activities.SanityBand{
+		MaxDeviationPct: cfg.Prices.SanityMaxDeviationPct,
+		Drop:            cfg.Prices.SanityAction != "flag",
+	})
+	priceActivities.SetCacheTTLs(cacheTTLs(cfg.Prices))
+	dbActivities := temporal_activities.NewDBActivities(dbPool, outbox)
+	dbActivities.SetBatchSize(cfg.Prices.DBBatchSize)
+
+	// Token lists are read in priority order: CoinGecko, then Jupiter for Solana tokens CoinGecko lacks
+	var tokenLists []services.TokenListSource
+	if source, ok := priceSources.Get(types.PriceSourceCoinGecko); ok {
+		tokenLists = append(tokenLists, temporal_activities.NewCoinGeckoTokenList(source.(*temporal_activities.CoinGeckoPriceSource)))
+	}
+	jupiterTokens := temporal_activities.NewJupiterTokenList(httpClient)
+	for _, mirror := range cfg.Prices.BaseURLs {
+		if types.PriceSource(mirror.Source) == types.PriceSourceJupiter {
+			jupiterTokens.SetBaseURL(mirror.URL)
+		}
+	}
+	tokenLists = append(tokenLists, jupiterTokens)
+	tokenMetadataActivities := temporal_activities.NewTokenMetadataActivities(
+		services.NewTokenMetadataService(repository.NewTokenMetadataRepository(dbPool), tokenLists...))
+
+	// Fired price alerts are sent as webhooks signed with the secrets clients register with the API server, and as
+	// emails when an SMTP server is configured
+	var mailer services.EmailSender
+	if n := cfg.Notifications; n.SMTPAddr != "" {
+		mailer = services.NewSMTPMailer(n.SMTPAddr, n.SMTPFrom, n.SMTPUsername, n.SMTPPassword)
+	}
+	priceAlertActivities := temporal_activities.NewPriceAlertActivities(
+		repository.NewPriceAlertRepository(dbPool),
+		services.NewWebhookClient(),
+		repository.NewWebhookSecretRepository(dbPool),
+		mailer,
+	)
+
+	auditLogPath, err := temporal_activities.AuditLogPath(cfg.Admin.AuditLogPath)
+	if err != nil {
+		log.Fatalf("Failed to get user home directory: %v", err)
+	}
+	auditLog, err := temporal_activities.NewAuditLog(auditLogPath)
+	if err != nil {
+		log.Fatalf("Failed to open audit log: %v", err)
+	}
+	// The price worker only runs price admin actions, so it needs no token or transaction services
+	adminActivities := temporal_activities.NewAdminActivities(sdk, nil, nil, auditLog)
 
 	// Register workflows
 	w.RegisterWorkflow(temporal_workflows.PriceOracleWorkflow)
 	w.RegisterWorkflow(temporal_workflows.ScheduledPriceUpdateWorkflow)
+	w.RegisterWorkflow(temporal_workflows.FlushPriceCacheWorkflow)
+	w.RegisterWorkflow(temporal_workflows.RefreshTokenMetadataWorkflow)
+	w.RegisterWorkflow(temporal_workflows.PriceAlertWorkflow)
 
 	// Register activities
+	w.RegisterActivity(priceActivities.FetchPricesActivity)
 	w.RegisterActivity(priceActivities.FetchUniversalPricesActivity)
 	w.RegisterActivity(priceActivities.FetchCoinGeckoPricesActivity)
 	w.RegisterActivity(priceActivities.FetchJupiterPricesActivity)
 	w.RegisterActivity(priceActivities.SavePricesToCacheActivity)
 	w.RegisterActivity(priceActivities.LoadPricesFromCacheActivity)
+	w.RegisterActivity(priceActivities.LoadFreshPricesFromCacheActivity)
 	w.RegisterActivity(priceActivities.MergePricesActivity)
+	w.RegisterActivity(priceActivities.FlushPriceCacheActivity)
+	w.RegisterActivity(adminActivities.RecordAuditActivity)
+	w.RegisterActivity(tokenMetadataActivities.RefreshTokenMetadataActivity)
+	w.RegisterActivity(priceAlertActivities.HasActivePriceAlertsActivity)
+	w.RegisterActivity(priceAlertActivities.EvaluatePriceAlertsActivity)
+	w.RegisterActivity(priceAlertActivities.NotifyPriceAlertActivity)
 
 	// Register database activities
 	w.RegisterActivity(dbActivities.SavePricesToDatabaseActivity)
 	w.RegisterActivity(dbActivities.GetLatestTokenPricesActivity)
 	w.RegisterActivity(dbActivities.GetTokenPriceHistoryActivity)
+	w.RegisterActivity(dbActivities.RollupCandlesActivity)
 
 	// Start the worker
 	err = w.Start()
@@ -95,32 +245,201 @@ func RunPriceWorker() {
 		log.Fatalf("Failed to start worker: %v", err)
 	}
 
-	// Start the scheduled workflow with a new ID to avoid nondeterminism issues
-	workflowOptions := client.StartWorkflowOptions{
-		ID:        "scheduled-price-update-v2", // New workflow ID
-		TaskQueue: PriceOracleTaskQueue,
+	if err := startPriceUpdates(context.Background(), c, cfg.Prices, cfg.Retries.PriceFetch.Policy(), cfg.Region); err != nil {
+		log.Fatalf("Failed to start scheduled price updates: %v", err)
 	}
 
-	we, err := c.ExecuteWorkflow(
+	// Refresh token metadata daily; a failed start only leaves the stored metadata older
+	var chainIDs []int64
+	for _, chain := range cfg.Chains {
+		chainIDs = append(chainIDs, chain.ChainID)
+	}
+	sort.Slice(chainIDs, func(i, j int) bool { return chainIDs[i] < chainIDs[j] })
+	metadataRun, err := c.ExecuteWorkflow(
 		context.Background(),
-		workflowOptions,
-		temporal_workflows.ScheduledPriceUpdateWorkflow,
+		client.StartWorkflowOptions{
+			ID:           cfg.Region.Scoped(TokenMetadataWorkflowID),
+			TaskQueue:    cfg.Region.Scoped(PriceOracleTaskQueue),
+			CronSchedule: TokenMetadataCronSchedule,
+		},
+		temporal_workflows.RefreshTokenMetadataWorkflow,
+		chainIDs,
 	)
 	if err != nil {
-		log.Fatalf("Failed to start scheduled workflow: %v", err)
+		log.Printf("Failed to start token metadata workflow: %v", err)
+	} else {
+		log.Printf("Started token metadata workflow with ID: %s and Run ID: %s", metadataRun.GetID(), metadataRun.GetRunID())
 	}
 
-	log.Printf("Started scheduled workflow with ID: %s and Run ID: %s", we.GetID(), we.GetRunID())
-
 	// Wait for termination signal
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 	<-signalChan
 
-	log.Println("Shutting down worker...")
+	// Stop polling, then wait up to TEMPORAL.WORKER.STOP_TIMEOUT for running activities to finish before cancelling them
+	log.Printf("Shutting down worker, draining activities for up to %s...", cfg.Temporal.Worker.StopTimeout)
+	w.Stop()
+	// Keep the errors counted since the last flush
+	if err := errorReporter.Flush(context.Background()); err != nil {
+		log.Printf("Failed to flush error counts: %v", err)
+	}
+}
+
+// newErrorReporter creates the reporter counting the worker's errors, sending them to Sentry when a DSN is
+// configured
+func newErrorReporter(cfg temporal_config.Config) *services.ErrorReporter {
+	reporter := services.NewErrorReporter(services.NewInMemoryErrorStore())
+	if cfg.Errors.SentryDSN != "" {
+		sink, err := services.NewSentrySink(&http.Client{Timeout: 5 * time.Second}, cfg.Errors.SentryDSN, cfg.Environment)
+		if err != nil {
+			log.Printf("Sending errors to Sentry disabled: %v", err)
+			return reporter
+		}
+		reporter.SetSink(sink)
+	}
+	return reporter
 }
 
 // Main function to be called from other packages
 func main() {
 	RunPriceWorker()
 }
+
+// startPriceUpdates starts the scheduled price updates, either as a Temporal Schedule or as the long-running update
+// workflow, and stops the other kind so prices are not updated twice. Each region runs its own updates, retrying
+// their fetches by retry.
+func startPriceUpdates(ctx context.Context, c client.Client, cfg temporal_config.PricesConfig, retry types.RetryPolicy, region temporal_config.RegionConfig) error {
+	workflowID := region.Scoped(PriceUpdateWorkflowID)
+	scheduleID := region.Scoped(PriceUpdateScheduleID)
+	if cfg.UpdateSchedule {
+		if err := c.CancelWorkflow(ctx, workflowID, ""); err != nil && !isNotFound(err) {
+			log.Printf("Failed to cancel price update workflow %s: %v", workflowID, err)
+		}
+		return upsertPriceSchedule(ctx, c, cfg.UpdateInterval, retry, region)
+	}
+
+	if err := c.ScheduleClient().GetHandle(ctx, scheduleID).Delete(ctx); err != nil && !isNotFound(err) {
+		log.Printf("Failed to delete price update schedule %s: %v", scheduleID, err)
+	}
+
+	// Restart a running update workflow so it picks up the current interval
+	we, err := c.ExecuteWorkflow(
+		ctx,
+		client.StartWorkflowOptions{
+			ID:                       workflowID,
+			TaskQueue:                region.Scoped(PriceOracleTaskQueue),
+			WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_TERMINATE_EXISTING,
+		},
+		temporal_workflows.ScheduledPriceUpdateWorkflow,
+		temporal_workflows.ScheduledPriceUpdateInput{
+			Interval:         cfg.UpdateInterval,
+			RunsPerExecution: cfg.UpdateRunsPerExecution,
+			Retry:            &retry,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	log.Printf("Started scheduled workflow with ID: %s and Run ID: %s", we.GetID(), we.GetRunID())
+	return nil
+}
+
+// upsertPriceSchedule creates the price update schedule, or updates its interval and retry policy if it already
+// exists
+func upsertPriceSchedule(ctx context.Context, c client.Client, interval time.Duration, retry types.RetryPolicy, region temporal_config.RegionConfig) error {
+	if interval <= 0 {
+		return fmt.Errorf("price update interval must be positive, got %s", interval)
+	}
+	spec := client.ScheduleSpec{
+		Intervals: []client.ScheduleIntervalSpec{{Every: interval}},
+	}
+	request := temporal_workflows.ScheduledPriceFetchRequest()
+	request.Retry = &retry
+	action := &client.ScheduleWorkflowAction{
+		ID:                 region.Scoped("scheduled-price-oracle"),
+		Workflow:           temporal_workflows.PriceOracleWorkflow,
+		Args:               []interface{}{request},
+		TaskQueue:          region.Scoped(PriceOracleTaskQueue),
+		WorkflowRunTimeout: 2 * time.Minute,
+	}
+
+	scheduleID := region.Scoped(PriceUpdateScheduleID)
+	schedules := c.ScheduleClient()
+	_, err := schedules.Create(ctx, client.ScheduleOptions{
+		ID:     scheduleID,
+		Spec:   spec,
+		Action: action,
+		// A slow update is not worth a second one racing it
+		Overlap: enums.SCHEDULE_OVERLAP_POLICY_SKIP,
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		err = schedules.GetHandle(ctx, scheduleID).Update(ctx, client.ScheduleUpdateOptions{
+			DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+				schedule := input.Description.Schedule
+				schedule.Spec = &spec
+				schedule.Action = action
+				return &client.ScheduleUpdate{Schedule: &schedule}, nil
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Price updates run from schedule %s every %s", scheduleID, interval)
+	return nil
+}
+
+// isNotFound reports whether a Temporal service call failed because its workflow or schedule does not exist
+func isNotFound(err error) bool {
+	var notFound *serviceerror.NotFound
+	return errors.As(err, &notFound)
+}
+
+// cacheTTLs builds the price cache TTLs from the price oracle configuration
+func cacheTTLs(cfg temporal_config.PricesConfig) temporal_activities.CacheTTLs {
+	ttls := temporal_activities.CacheTTLs{
+		Default: cfg.CacheTTL,
+		Sources: make(map[types.PriceSource]time.Duration),
+		Tokens:  make(map[string]time.Duration),
+	}
+	for _, override := range cfg.CacheTTLs {
+		switch {
+		case override.Symbol != "":
+			ttls.Tokens[strings.ToUpper(override.Symbol)] = override.TTL
+		case override.Source != "":
+			ttls.Sources[types.PriceSource(override.Source)] = override.TTL
+		default:
+			log.Printf("Ignoring price cache TTL without a source or symbol")
+		}
+	}
+	return ttls
+}
+
+// chainlinkFeeds collects the Chainlink feeds and RPC endpoints of the configured chains
+func chainlinkFeeds(cfg temporal_config.Config) (map[int64][]string, []temporal_activities.ChainlinkFeed) {
+	rpcURLs := make(map[int64][]string)
+	var feeds []temporal_activities.ChainlinkFeed
+	for _, chain := range cfg.Chains {
+		if len(chain.PriceFeeds) == 0 {
+			continue
+		}
+		rpcURLs[chain.ChainID] = chain.RPC
+		for symbol, address := range chain.PriceFeeds {
+			feeds = append(feeds, temporal_activities.ChainlinkFeed{
+				Symbol:  strings.ToUpper(symbol),
+				ChainID: chain.ChainID,
+				Address: address,
+			})
+		}
+	}
+
+	// Map iteration order is random; keep fetch order stable
+	sort.Slice(feeds, func(i, j int) bool {
+		if feeds[i].ChainID != feeds[j].ChainID {
+			return feeds[i].ChainID < feeds[j].ChainID
+		}
+		return feeds[i].Symbol < feeds[j].Symbol
+	})
+
+	return rpcURLs, feeds
+}
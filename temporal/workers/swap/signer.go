@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/infinity-dex/chains/signer"
+	temporal_config "github.com/infinity-dex/temporal/config"
+)
+
+// newSigner creates the signer of the account sending transactions from the configured backend
+func newSigner(ctx context.Context, cfg temporal_config.ExecutionConfig) (signer.Signer, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch cfg.Signer {
+	case "keystore":
+		return signer.LoadKeystore(cfg.KeystorePath, cfg.KeystorePassword)
+	case "aws-kms":
+		credentials, err := signer.AWSCredentialsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return signer.NewAWSKMSSigner(ctx, client, cfg.KMSEndpoint, cfg.KMSRegion, cfg.KMSKeyID, credentials)
+	case "gcp-kms":
+		return signer.NewGCPKMSSigner(ctx, client, cfg.KMSEndpoint, cfg.KMSKeyID, signer.MetadataToken(client))
+	case "env", "":
+		// A key not set in the config is read from EXECUTION_PRIVATE_KEY, which is cleared once the signer holds it
+		if cfg.PrivateKey != "" {
+			return signer.NewPrivateKeySigner(cfg.PrivateKey)
+		}
+		return signer.FromEnv("EXECUTION_PRIVATE_KEY")
+	default:
+		return nil, fmt.Errorf("unknown signer %q", cfg.Signer)
+	}
+}
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/infinity-dex/chains/evm"
 	"github.com/infinity-dex/services"
+	"github.com/infinity-dex/services/events"
+	"github.com/infinity-dex/services/repository"
 	"github.com/infinity-dex/services/types"
 	temporal_activities "github.com/infinity-dex/temporal/activities"
 	temporal_config "github.com/infinity-dex/temporal/config"
+	temporal_payloads "github.com/infinity-dex/temporal/payloads"
 	temporal_workflows "github.com/infinity-dex/temporal/workflows"
 	"github.com/infinity-dex/universalsdk"
 	"go.temporal.io/sdk/client"
@@ -19,23 +27,42 @@ import (
 const (
 	// SwapTaskQueue is the name of the task queue used for swap workflows
 	SwapTaskQueue = "swap-queue"
+	// gasPollInterval is how often chain gas prices are read from the RPC endpoints
+	gasPollInterval = 15 * time.Second
+
+	// DeadLetterScanWorkflowID is the ID of the cron workflow scanning for dead-lettered swaps
+	DeadLetterScanWorkflowID = "swap-dead-letter-scan"
+	// DeadLetterScanCronSchedule runs the dead-letter scan every five minutes
+	DeadLetterScanCronSchedule = "*/5 * * * *"
 )
 
 // RunSwapWorker starts the swap worker
 func RunSwapWorker() {
 	log.Println("Starting Swap Worker...")
 
+	// Load Temporal, region and chain configuration
+	cfg, err := temporal_config.LoadConfig(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Printf("Failed to load config, using defaults: %v", err)
+		cfg = temporal_config.DefaultConfig()
+	}
+
 	// Create a Temporal client
 	c, err := client.Dial(client.Options{
-		HostPort: "localhost:7233", // Local Temporal server
+		HostPort:      cfg.Temporal.HostPort,
+		Namespace:     cfg.Region.Namespace,
+		DataConverter: temporal_payloads.NewDataConverter(temporal_payloads.OptionsFromConfig(cfg.Temporal.Payloads)),
 	})
 	if err != nil {
 		log.Fatalf("Failed to create Temporal client: %v", err)
 	}
 	defer c.Close()
 
-	// Create a worker
-	w := worker.New(c, SwapTaskQueue, worker.Options{})
+	// Create a worker polling this region's swap queue, so swaps started in this region run here
+	// Report the errors of its workflows, activities and SDK calls
+	errorReporter := newErrorReporter(cfg)
+	w := worker.New(c, cfg.Region.Scoped(SwapTaskQueue),
+		temporal_activities.WorkerOptions(cfg.Temporal.Worker, temporal_activities.NewErrorReportingInterceptor(errorReporter)))
 
 	// Initialize Universal SDK with mock configuration
 	sdkConfig := universalsdk.MockSDKConfig{
@@ -43,44 +70,380 @@ func RunSwapWorker() {
 		Latency:       100,
 		FailureRate:   0.05, // 5% failure rate for testing
 	}
-	sdk := universalsdk.NewMockSDK(sdkConfig)
+	if path := cfg.Universal.MockScenario; path != "" {
+		scenario, err := universalsdk.LoadScenario(path)
+		if err != nil {
+			log.Fatalf("Failed to load mock SDK scenario: %v", err)
+		}
+		sdkConfig = scenario.Apply(sdkConfig)
+	}
+	// Calls are counted, cached and retried around the reporting of each attempt's error
+	sdkMetrics := universalsdk.NewCallMetrics()
+	sdk := universalsdk.Chain(services.NewReportingSDK(universalsdk.NewMockSDK(sdkConfig), errorReporter),
+		temporal_activities.SDKMiddleware(cfg.Universal.Middleware, sdkMetrics)...)
 
 	// Initialize database connection
-	dbConfig := temporal_config.DefaultDBConfig()
+	dbConfig, err := temporal_config.LoadDBConfig()
+	if err != nil {
+		log.Fatalf("Failed to load database config: %v", err)
+	}
 	dbPool, err := temporal_config.NewDBPool(dbConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer dbPool.Close()
 
+	// Count errors in the database, alongside the API server's
+	errorReporter.SetStore(repository.NewErrorRepository(dbPool))
+	flushCtx, stopFlushing := context.WithCancel(context.Background())
+	defer stopFlushing()
+	errorReporter.StartFlushing(flushCtx, cfg.Errors.FlushInterval)
+	if cfg.Universal.Middleware.StatsInterval > 0 {
+		sdkMetrics.StartLogging(flushCtx, cfg.Universal.Middleware.StatsInterval)
+	}
+
+	// Chains, bridges and relays are called through one transport pacing each host within its budget
+	outbound, err := temporal_activities.NewOutboundTransport(temporal_activities.OutboundOptionsFromConfig(cfg))
+	if err != nil {
+		log.Fatalf("Invalid outbound HTTP config: %v", err)
+	}
+	if cfg.Outbound.StatsInterval > 0 {
+		outbound.StartLogging(flushCtx, cfg.Outbound.StatsInterval)
+	}
+
 	// Initialize services
 	tokenService := services.NewTokenService()
 	transactionService := services.NewTransactionService()
 	swapService := services.NewSwapService(tokenService, transactionService, sdk)
+	// Pools live in the database, shared with the API server
+	liquidityService := services.NewLiquidityService()
+	liquidityService.SetStore(repository.NewPoolRepository(dbPool))
+	seedPools(context.Background(), liquidityService, cfg.Pools)
+	swapService.SetLiquidityService(liquidityService)
+	// Bridge outcomes live in the database, shared with the API server
+	bridgeReliability := services.NewBridgeReliability(0)
+	bridgeReliability.SetStore(repository.NewBridgeOutcomeRepository(dbPool))
+	bridgeRouter := services.NewBridgeRouter(bridgeReliability)
+	for _, corridor := range cfg.Bridges.Corridors {
+		bridgeRouter.SetCorridorPolicy(
+			services.BridgeCorridor{SourceChainID: cfg.Chains[corridor.From].ChainID, DestinationChainID: cfg.Chains[corridor.To].ChainID},
+			services.CorridorPolicy{Prefer: services.BridgePreference(corridor.Prefer), Bridges: corridor.Bridges})
+	}
+	swapService.SetBridgeRouter(bridgeRouter)
+	if cfg.Swap.AcrossAPIURL != "" {
+		swapService.AddBridge(services.NewAcrossBridge(outbound.Client(5*time.Second), cfg.Swap.AcrossAPIURL))
+	}
+	for _, provider := range cfg.Bridges.Providers {
+		swapService.AddBridge(services.NewHTTPBridge(outbound.Client(5*time.Second), provider.Name, provider.URL, cfg.ChainIDs(provider.Chains)))
+	}
+
+	auditLogPath, err := temporal_activities.AuditLogPath(cfg.Admin.AuditLogPath)
+	if err != nil {
+		log.Fatalf("Failed to get user home directory: %v", err)
+	}
+	auditLog, err := temporal_activities.NewAuditLog(auditLogPath)
+	if err != nil {
+		log.Fatalf("Failed to open audit log: %v", err)
+	}
+
+	rpcClient := temporal_activities.NewEVMRPCClient(outbound.Client(10*time.Second), cfg.RPCURLs())
 
 	// Initialize activities
 	swapActivities := temporal_activities.NewSwapActivities(sdk, swapService)
+	swapActivities.SetBridgeReliability(bridgeReliability)
+	liquidityActivities := temporal_activities.NewLiquidityActivities(sdk, liquidityService, transactionService)
+	allowanceActivities := temporal_activities.NewAllowanceActivities(transactionService)
+	adminActivities := temporal_activities.NewAdminActivities(sdk, tokenService, transactionService, auditLog)
+
+	// Tenant policies live in the database; swaps are valued with the price oracle's cache
+	cacheDir, err := temporal_activities.DefaultPriceCacheDir()
+	if err != nil {
+		log.Fatalf("Failed to get user home directory: %v", err)
+	}
+	priceLookup := temporal_activities.NewPriceCacheLookup(cacheDir)
+	policyEngine := services.NewDocumentPolicyEngine(repository.NewPolicyRepository(dbPool), priceLookup)
+	if cfg.Swap.MaxPriceAge > 0 {
+		pricePolicy := services.NewPriceStalenessPolicy(priceLookup, cfg.Swap.MaxPriceAge)
+		swapService.SetPricePolicy(pricePolicy)
+		swapActivities.SetPricePolicy(pricePolicy)
+
+		// Price gas from each chain's live fees, read from its RPC endpoints
+		chainService := services.NewChainService()
+		chainService.SetGasReader(rpcClient)
+		for _, chain := range cfg.Chains {
+			chainService.AddChain(types.ChainStatus{Name: chain.Name, ChainID: chain.ChainID, IsActive: true})
+		}
+		chainService.StartGasPolling(context.Background(), gasPollInterval)
+		gasEstimator := services.NewGasEstimator(chainService, pricePolicy, cfg.Swap.GasMultiplier)
+		swapService.SetGasEstimator(gasEstimator)
+		swapActivities.SetGasEstimator(gasEstimator)
+
+		// Sponsored gas is booked against caps shared with the API servers
+		gasSponsor := services.NewGasSponsor(gasEstimator, cfg.GasSponsorPolicies())
+		gasSponsor.SetStore(repository.NewGasSponsorshipRepository(dbPool))
+		swapService.SetGasSponsor(gasSponsor)
+		swapActivities.SetGasSponsor(gasSponsor)
+
+		// Swap values are booked against limits shared with the API servers
+		limiter := services.NewSwapLimiter(cfg.SwapLimits(), pricePolicy)
+		limiter.SetStore(repository.NewSwapVolumeRepository(dbPool))
+		swapActivities.SetSwapLimiter(limiter)
+	}
+	// Fee overrides are rules in the parameter store
+	rules := services.NewRuleSet(repository.NewParameterRepository(dbPool))
+	swapService.SetRules(rules)
+	swapActivities.SetRules(rules)
+	swapService.SetPriceImpactLimits(cfg.PriceImpactLimits())
+	complianceActivities := temporal_activities.NewComplianceActivities(policyEngine)
+	listingChecker := services.NewListingChecker(rpcClient, tokenService, cfg.Listings.MinLiquidityUSD)
+	listingChecker.SetRouters(cfg.RouterAddresses())
+	listedTokens := repository.NewListedTokenRepository(dbPool)
+	if _, err := services.RestoreListedTokens(context.Background(), listedTokens, tokenService); err != nil {
+		log.Printf("Failed to restore listed tokens: %v", err)
+	}
+	listingActivities := temporal_activities.NewListingActivities(listingChecker, tokenService)
+	listingActivities.SetTokenStore(listedTokens)
+	archiveActivities := temporal_activities.NewArchiveActivities(repository.NewSwapArchiveRepository(dbPool))
+	// Archived swaps are counted in the swap statistics the API server reports
+	analytics := services.NewAnalyticsService(priceLookup)
+	analytics.SetStore(repository.NewSwapStatsRepository(dbPool))
+	archiveActivities.SetAnalytics(analytics)
+	// Swap callbacks are signed with the secrets clients register with the API server
+	webhookActivities := temporal_activities.NewWebhookActivities(services.NewWebhookClient(), repository.NewWebhookSecretRepository(dbPool))
+	// Swap steps and outcomes are forwarded to the external bus for downstream consumers, such as accounting
+	eventBus := newEventBus(cfg.Events)
+	swapActivities.SetEventBus(eventBus)
+	archiveActivities.SetEventBus(eventBus)
+	// Dead-lettered swaps are shared with the API servers operators resolve them through, and alerted like errors
+	housekeepingActivities := temporal_activities.NewHousekeepingActivities(c, cfg.Region.Namespace, repository.NewDeadLetterRepository(dbPool))
+	housekeepingActivities.SetErrorReporter(errorReporter)
+	housekeepingActivities.SetEventBus(eventBus)
+
+	// Send wraps, unwraps and same-chain swaps as transactions to each EVM chain's contracts
+	if cfg.Execution.Enabled {
+		key, err := newSigner(context.Background(), cfg.Execution)
+		if err != nil {
+			log.Fatalf("Failed to create %s signer: %v", cfg.Execution.Signer, err)
+		}
+		if cfg.Execution.ReceiptTimeout >= temporal_workflows.OnChainActivityTimeout {
+			log.Fatalf("Execution receipt timeout %s must be shorter than the %s on-chain activity timeout", cfg.Execution.ReceiptTimeout, temporal_workflows.OnChainActivityTimeout)
+		}
+		contracts := make(map[int64]temporal_activities.ChainContracts)
+		for _, chain := range cfg.Chains {
+			if info, ok := types.GetChain(chain.ChainID); ok && info.Namespace == "eip155" {
+				contracts[chain.ChainID] = temporal_activities.ChainContracts{Universal: chain.UniversalAddress, DEX: chain.DEXAddress}
+			}
+		}
+		adapter := evm.NewAdapter(rpcClient, key)
+		// Swaps asking for private execution are sent through each chain's private relay
+		relayURLs := cfg.PrivateRelayURLs()
+		relays := temporal_activities.NewEVMRPCClient(outbound.Client(10*time.Second), relayURLs)
+		for chainID := range relayURLs {
+			adapter.SetPrivateRelay(chainID, relays)
+		}
+		executor := temporal_activities.NewChainExecutor(adapter, contracts, cfg.Execution.ReceiptTimeout)
+		executor.SetTxStore(repository.NewSignedTxRepository(dbPool))
+		executor.SetSpeedUpAfter(cfg.Execution.SpeedUpAfter)
+		// Swaps from smart accounts are sent as user operations through each chain's bundler
+		executor.SetBundler(evm.NewBundler(rpcClient, temporal_activities.NewEVMRPCClient(outbound.Client(10*time.Second), cfg.BundlerURLs())))
+		// ERC-20 wraps and swaps first approve the contract spending the tokens
+		executor.SetAllowanceReader(rpcClient, adapter.Address())
+		executor.SetExactApprovals(cfg.Execution.ExactApprovals)
+		swapActivities.SetChainExecutor(executor)
+		liquidityActivities.SetChainExecutor(executor)
+		allowanceActivities.SetChainExecutor(executor)
+		log.Printf("Executing transactions on-chain from %s with the %s signer", key.Address(), cfg.Execution.Signer)
+	}
 
 	// Register workflows
 	w.RegisterWorkflow(temporal_workflows.SwapWorkflow)
+	w.RegisterWorkflow(temporal_workflows.AddLiquidityWorkflow)
+	w.RegisterWorkflow(temporal_workflows.RemoveLiquidityWorkflow)
+	w.RegisterWorkflow(temporal_workflows.RetrySwapWorkflow)
+	w.RegisterWorkflow(temporal_workflows.ForceRefundWorkflow)
+	w.RegisterWorkflow(temporal_workflows.ResyncTokenRegistryWorkflow)
+	w.RegisterWorkflow(temporal_workflows.MigratePoolWorkflow)
+	w.RegisterWorkflow(temporal_workflows.TokenListingWorkflow)
+	w.RegisterWorkflow(temporal_workflows.DeadLetterScanWorkflow)
+	w.RegisterWorkflow(temporal_workflows.ResolveDeadLetterWorkflow)
 
 	// Register activities
 	w.RegisterActivity(swapActivities.CalculateSwapQuoteActivity)
+	w.RegisterActivity(swapActivities.CalculateFeeActivity)
+	w.RegisterActivity(swapActivities.CheckSwapLimitsActivity)
+	w.RegisterActivity(swapActivities.ReleaseSwapLimitsActivity)
+	w.RegisterActivity(swapActivities.CheckBalanceActivity)
+	w.RegisterActivity(swapActivities.CheckQuoteDriftActivity)
 	w.RegisterActivity(swapActivities.ExecuteSwapActivity)
+	w.RegisterActivity(swapActivities.SimulateSwapActivity)
 	w.RegisterActivity(swapActivities.CancelSwapActivity)
+	w.RegisterActivity(swapActivities.FastSwapActivity)
+	w.RegisterActivity(swapActivities.RecordBridgeOutcomeActivity)
+	w.RegisterActivity(swapActivities.BridgeTransferStatusActivity)
+	w.RegisterActivity(swapActivities.WatchBridgeTransferActivity)
+	w.RegisterActivity(archiveActivities.ArchiveSwapActivity)
+	w.RegisterActivity(webhookActivities.NotifyWebhookActivity)
+
+	// Register liquidity activities
+	w.RegisterActivity(liquidityActivities.WrapLiquidityTokenActivity)
+	w.RegisterActivity(liquidityActivities.DepositLiquidityActivity)
+	w.RegisterActivity(liquidityActivities.MintLPPositionActivity)
+	w.RegisterActivity(liquidityActivities.BurnLPPositionActivity)
+	w.RegisterActivity(liquidityActivities.RestoreLPPositionActivity)
+	w.RegisterActivity(liquidityActivities.WithdrawLiquidityActivity)
+	w.RegisterActivity(liquidityActivities.UnwrapLiquidityTokenActivity)
+	w.RegisterActivity(liquidityActivities.PlanPoolMigrationActivity)
+	w.RegisterActivity(liquidityActivities.DrainMigratedPoolActivity)
+	w.RegisterActivity(liquidityActivities.CreditMigrationTargetActivity)
+	w.RegisterActivity(liquidityActivities.RestoreMigratedPoolActivity)
+
+	// Register allowance activities
+	w.RegisterActivity(allowanceActivities.CheckAndApproveAllowanceActivity)
+
+	// Register compliance activities
+	w.RegisterActivity(complianceActivities.EvaluateSwapPolicyActivity)
+
+	// Register listing activities
+	w.RegisterActivity(listingActivities.RunListingChecksActivity)
+	w.RegisterActivity(listingActivities.ListTokenActivity)
+
+	// Register admin activities
+	w.RegisterActivity(adminActivities.RecordAuditActivity)
+	w.RegisterActivity(adminActivities.RefundSwapActivity)
+	w.RegisterActivity(adminActivities.ResyncTokenRegistryActivity)
+
+	// Register housekeeping activities
+	w.RegisterActivity(housekeepingActivities.FindDeadLettersActivity)
+	w.RegisterActivity(housekeepingActivities.RecordDeadLettersActivity)
+	w.RegisterActivity(housekeepingActivities.TerminateStuckSwapActivity)
+	w.RegisterActivity(housekeepingActivities.ResolveDeadLetterActivity)
+
+	// Poll each partitioned chain's task queue with a worker of its own, so activities stuck on one chain only hold
+	// that worker's slots
+	var chainWorkers []worker.Worker
+	for _, name := range cfg.PartitionedChains() {
+		options := temporal_activities.WorkerOptions(cfg.Temporal.Worker, temporal_activities.NewErrorReportingInterceptor(errorReporter))
+		options.DisableWorkflowWorker = true
+		chainWorker := worker.New(c, cfg.Region.Scoped(cfg.ChainTaskQueue(name)), options)
+		registerChainActivities(chainWorker, swapActivities, allowanceActivities)
+		chainWorkers = append(chainWorkers, chainWorker)
+	}
 
 	// Start the worker
 	err = w.Start()
 	if err != nil {
 		log.Fatalf("Failed to start worker: %v", err)
 	}
+	for _, chainWorker := range chainWorkers {
+		if err := chainWorker.Start(); err != nil {
+			log.Fatalf("Failed to start chain worker: %v", err)
+		}
+	}
+
+	// Scan this region's swaps for dead letters on a cron schedule
+	if cfg.DeadLetters.Enabled {
+		scanRun, err := c.ExecuteWorkflow(
+			context.Background(),
+			client.StartWorkflowOptions{
+				ID:           cfg.Region.Scoped(DeadLetterScanWorkflowID),
+				TaskQueue:    cfg.Region.Scoped(SwapTaskQueue),
+				CronSchedule: DeadLetterScanCronSchedule,
+			},
+			temporal_workflows.DeadLetterScanWorkflow,
+			temporal_workflows.DeadLetterScanInput{
+				TaskQueue:  cfg.Region.Scoped(SwapTaskQueue),
+				StuckAfter: cfg.Swap.MaxSwapTime,
+				Lookback:   cfg.DeadLetters.Lookback,
+			},
+		)
+		if err != nil {
+			log.Printf("Failed to start dead letter scan workflow: %v", err)
+		} else {
+			log.Printf("Started dead letter scan workflow with ID: %s and Run ID: %s", scanRun.GetID(), scanRun.GetRunID())
+		}
+	}
 
 	// Wait for termination signal
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 	<-signalChan
 
-	log.Println("Shutting down worker...")
+	// Stop polling, then wait up to TEMPORAL.WORKER.STOP_TIMEOUT for running activities to finish before cancelling them
+	log.Printf("Shutting down worker, draining activities for up to %s...", cfg.Temporal.Worker.StopTimeout)
+	var stopping sync.WaitGroup
+	for _, chainWorker := range chainWorkers {
+		stopping.Add(1)
+		go func() {
+			defer stopping.Done()
+			chainWorker.Stop()
+		}()
+	}
+	w.Stop()
+	stopping.Wait()
+	// Forward the events already published
+	eventBus.Close()
+	// Keep the errors counted since the last flush
+	if err := errorReporter.Flush(context.Background()); err != nil {
+		log.Printf("Failed to flush error counts: %v", err)
+	}
+}
+
+// registerChainActivities registers the swap activities that call a chain, which run on the chain's task queue when
+// TEMPORAL.CHAIN_QUEUES partitions it
+func registerChainActivities(w worker.Worker, swapActivities *temporal_activities.SwapActivities, allowanceActivities *temporal_activities.AllowanceActivities) {
+	w.RegisterActivity(swapActivities.CheckBalanceActivity)
+	w.RegisterActivity(swapActivities.CalculateSwapQuoteActivity)
+	w.RegisterActivity(swapActivities.CheckQuoteDriftActivity)
+	w.RegisterActivity(swapActivities.ExecuteSwapActivity)
+	w.RegisterActivity(swapActivities.SimulateSwapActivity)
+	w.RegisterActivity(swapActivities.BridgeTransferStatusActivity)
+	w.RegisterActivity(swapActivities.WatchBridgeTransferActivity)
+	w.RegisterActivity(allowanceActivities.CheckAndApproveAllowanceActivity)
+}
+
+// newErrorReporter creates the reporter counting the worker's errors, sending them to Sentry when a DSN is
+// configured
+func newErrorReporter(cfg temporal_config.Config) *services.ErrorReporter {
+	reporter := services.NewErrorReporter(services.NewInMemoryErrorStore())
+	if cfg.Errors.SentryDSN != "" {
+		sink, err := services.NewSentrySink(&http.Client{Timeout: 5 * time.Second}, cfg.Errors.SentryDSN, cfg.Environment)
+		if err != nil {
+			log.Printf("Sending errors to Sentry disabled: %v", err)
+			return reporter
+		}
+		reporter.SetSink(sink)
+	}
+	return reporter
+}
+
+// newEventBus creates the bus swap events are published to, forwarding them to the configured external bus
+func newEventBus(cfg temporal_config.EventsConfig) *events.Bus {
+	bus := events.NewBus()
+	switch {
+	case cfg.NATSURL != "":
+		forwarder, err := events.NewNATSForwarder(cfg.NATSURL)
+		if err != nil {
+			log.Printf("Event forwarding disabled: %v", err)
+			return bus
+		}
+		bus.SetForwarder(forwarder, cfg.SubjectPrefix, cfg.Buffer)
+	case cfg.KafkaRESTURL != "":
+		bus.SetForwarder(events.NewKafkaRESTForwarder(&http.Client{Timeout: 5 * time.Second}, cfg.KafkaRESTURL), cfg.SubjectPrefix, cfg.Buffer)
+	}
+	return bus
+}
+
+// seedPools creates the configured pools that don't exist yet
+func seedPools(ctx context.Context, liquidityService *services.LiquidityService, pools []temporal_config.PoolConfig) {
+	for _, pool := range pools {
+		pair := services.TokenPair{
+			BaseToken:  services.Token{Symbol: pool.BaseToken, ChainID: pool.ChainID},
+			QuoteToken: services.Token{Symbol: pool.QuoteToken, ChainID: pool.ChainID},
+		}
+		if err := liquidityService.SeedPool(ctx, pool.ID, pair, pool.FeeTier, pool.Address); err != nil {
+			log.Fatalf("Failed to seed pool %s: %v", pool.ID, err)
+		}
+	}
 }
 
 // Main function to be called from other packages
@@ -0,0 +1,236 @@
+package temporal_workflows
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Admin actions operators can run from the admin API
+const (
+	AdminActionRetrySwap            = "retry_swap"
+	AdminActionForceRefund          = "force_refund"
+	AdminActionResyncTokenRegistry  = "resync_token_registry"
+	AdminActionFlushPriceCache      = "flush_price_cache"
+	AdminActionReopenCircuitBreaker = "reopen_circuit_breaker"
+	AdminActionMigratePool          = "migrate_pool"
+	AdminActionResolveDeadLetter    = "resolve_dead_letter"
+)
+
+// Swap steps RetrySwapWorkflow can resume from
+const (
+	SwapStepQuote   = "quote"
+	SwapStepExecute = "execute"
+)
+
+// AdminActionInput represents the input for the admin action workflows
+type AdminActionInput struct {
+	Action   string
+	Operator string
+	Reason   string
+	Params   map[string]string
+
+	// Swap is the original swap input, for swap retries and requeued dead letters
+	Swap *SwapWorkflowInput
+
+	// DeadLetter is the open dead letter to resolve, for dead letter resolutions
+	DeadLetter *types.DeadLetter
+
+	// ChainIDs are the chains to resync, for token registry resyncs
+	ChainIDs []int64
+}
+
+// AdminActionResult represents the outcome of an admin action
+type AdminActionResult struct {
+	ActionID    string    `json:"actionId"`
+	Action      string    `json:"action"`
+	Success     bool      `json:"success"`
+	Message     string    `json:"message"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// RetrySwapWorkflow re-runs a swap from the given step without waiting for user confirmation.
+// Retrying from the quote step re-prices the swap before executing it.
+func RetrySwapWorkflow(ctx workflow.Context, input AdminActionInput) (*AdminActionResult, error) {
+	return runAdminAction(ctx, input, func(ctx workflow.Context) (string, error) {
+		return retrySwap(ctx, input)
+	})
+}
+
+// retrySwap re-runs input.Swap from the step in input.Params
+func retrySwap(ctx workflow.Context, input AdminActionInput) (string, error) {
+	if input.Swap == nil {
+		return "", temporal.NewNonRetryableApplicationError("Missing swap to retry", "INVALID_INPUT", errors.New("swap input is required"))
+	}
+	request := input.Swap.Request
+
+	step := input.Params["step"]
+	switch step {
+	case "", SwapStepExecute:
+		step = SwapStepExecute
+	case SwapStepQuote:
+		var quote types.SwapQuote
+		if err := workflow.ExecuteActivity(ctx, "CalculateSwapQuoteActivity", request).Get(ctx, &quote); err != nil {
+			return "", fmt.Errorf("failed to recalculate quote: %w", err)
+		}
+	default:
+		return "", temporal.NewNonRetryableApplicationError(fmt.Sprintf("Unknown swap step %q", step), "INVALID_INPUT", errors.New("invalid step"))
+	}
+
+	if allowance, ok := swapAllowance(request); ok && workflow.GetVersion(ctx, allowanceChange, workflow.DefaultVersion, 1) == 1 {
+		if err := workflow.ExecuteActivity(ctx, "CheckAndApproveAllowanceActivity", allowance).Get(ctx, nil); err != nil {
+			return "", fmt.Errorf("failed to approve token: %w", err)
+		}
+	}
+
+	var result types.SwapResult
+	if err := workflow.ExecuteActivity(ctx, "ExecuteSwapActivity", request).Get(ctx, &result); err != nil {
+		return "", fmt.Errorf("failed to execute swap: %w", err)
+	}
+	return fmt.Sprintf("Swap %s retried from %s step, output %s", request.RequestID, step, result.OutputAmount), nil
+}
+
+// ForceRefundWorkflow refunds a swap's input to the sender
+func ForceRefundWorkflow(ctx workflow.Context, input AdminActionInput) (*AdminActionResult, error) {
+	return runAdminAction(ctx, input, func(ctx workflow.Context) (string, error) {
+		return refundSwap(ctx, input.Params["requestId"])
+	})
+}
+
+// refundSwap refunds a swap's input to the sender
+func refundSwap(ctx workflow.Context, requestID string) (string, error) {
+	var refund types.Transaction
+	if err := workflow.ExecuteActivity(ctx, "RefundSwapActivity", requestID).Get(ctx, &refund); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Refund %s of %s %s to %s recorded", refund.ID, refund.Amount, refund.SourceToken.Symbol, refund.ToAddress), nil
+}
+
+// ResyncTokenRegistryWorkflow reloads the wrapped token registry from Universal
+func ResyncTokenRegistryWorkflow(ctx workflow.Context, input AdminActionInput) (*AdminActionResult, error) {
+	return runAdminAction(ctx, input, func(ctx workflow.Context) (string, error) {
+		var synced int
+		if err := workflow.ExecuteActivity(ctx, "ResyncTokenRegistryActivity", input.ChainIDs).Get(ctx, &synced); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Synced %d tokens across %d chains", synced, len(input.ChainIDs)), nil
+	})
+}
+
+// FlushPriceCacheWorkflow deletes the price cache so the next price update refetches every source
+func FlushPriceCacheWorkflow(ctx workflow.Context, input AdminActionInput) (*AdminActionResult, error) {
+	return runAdminAction(ctx, input, func(ctx workflow.Context) (string, error) {
+		var flushed bool
+		if err := workflow.ExecuteActivity(ctx, "FlushPriceCacheActivity").Get(ctx, &flushed); err != nil {
+			return "", err
+		}
+		if !flushed {
+			return "Price cache was already empty", nil
+		}
+		return "Price cache flushed", nil
+	})
+}
+
+// MigratePoolWorkflow moves every position and reserve of a pool into another pool holding the same tokens,
+// e.g. one with a different fee tier. The migration is planned again when it runs; the source is drained only if
+// it still matches that plan. If the target can't be credited, the source gets its positions back.
+func MigratePoolWorkflow(ctx workflow.Context, input AdminActionInput) (*AdminActionResult, error) {
+	return runAdminAction(ctx, input, func(ctx workflow.Context) (string, error) {
+		migrationID := workflow.GetInfo(ctx).WorkflowExecution.ID
+
+		var plan types.PoolMigrationPlan
+		if err := workflow.ExecuteActivity(ctx, "PlanPoolMigrationActivity", input.Params["sourcePoolId"], input.Params["targetPoolId"]).Get(ctx, &plan); err != nil {
+			return "", err
+		}
+
+		if err := workflow.ExecuteActivity(ctx, "DrainMigratedPoolActivity", migrationID, plan).Get(ctx, nil); err != nil {
+			return "", err
+		}
+
+		if err := workflow.ExecuteActivity(ctx, "CreditMigrationTargetActivity", migrationID, plan).Get(ctx, nil); err != nil {
+			// The drained positions must not be lost, so restoring them retries for longer and survives cancellation
+			restoreCtx, cancel := workflow.NewDisconnectedContext(ctx)
+			defer cancel()
+			restoreCtx = workflow.WithActivityOptions(restoreCtx, workflow.ActivityOptions{
+				StartToCloseTimeout: 30 * time.Second,
+				RetryPolicy: &temporal.RetryPolicy{
+					InitialInterval:    time.Second,
+					BackoffCoefficient: 2.0,
+					MaximumInterval:    time.Minute,
+					MaximumAttempts:    10,
+				},
+			})
+			if restoreErr := workflow.ExecuteActivity(restoreCtx, "RestoreMigratedPoolActivity", migrationID, plan).Get(restoreCtx, nil); restoreErr != nil {
+				return "", fmt.Errorf("failed to credit pool %s: %w; restoring pool %s also failed: %v", plan.TargetPoolID, err, plan.SourcePoolID, restoreErr)
+			}
+			return "", fmt.Errorf("failed to credit pool %s, pool %s was restored: %w", plan.TargetPoolID, plan.SourcePoolID, err)
+		}
+
+		return fmt.Sprintf("Migrated %d positions with %s LP tokens from %s to %s",
+			len(plan.Positions), plan.Liquidity, plan.SourcePoolID, plan.TargetPoolID), nil
+	})
+}
+
+// runAdminAction runs an action between started and completed/failed audit entries
+func runAdminAction(ctx workflow.Context, input AdminActionInput, action func(ctx workflow.Context) (string, error)) (*AdminActionResult, error) {
+	logger := workflow.GetLogger(ctx)
+	actionID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	logger.Info("Admin action started", "actionID", actionID, "action", input.Action, "operator", input.Operator)
+
+	ctx = workflow.WithActivityOptions(ctx, adminActivityOptions())
+
+	audit := func(status, message string) {
+		entry := temporal_activities.AuditEntry{
+			ActionID:   actionID,
+			Action:     input.Action,
+			Operator:   input.Operator,
+			Reason:     input.Reason,
+			Params:     input.Params,
+			Status:     status,
+			Message:    message,
+			RecordedAt: workflow.Now(ctx),
+		}
+		// The action itself matters more than its audit trail, so only log audit failures
+		if err := workflow.ExecuteActivity(ctx, "RecordAuditActivity", entry).Get(ctx, nil); err != nil {
+			logger.Error("Failed to record admin audit entry", "actionID", actionID, "status", status, "error", err)
+		}
+	}
+
+	audit(temporal_activities.AuditStatusStarted, "")
+
+	result := &AdminActionResult{ActionID: actionID, Action: input.Action}
+	message, err := action(ctx)
+	result.CompletedAt = workflow.Now(ctx)
+	if err != nil {
+		logger.Error("Admin action failed", "actionID", actionID, "action", input.Action, "error", err)
+		result.Message = err.Error()
+		audit(temporal_activities.AuditStatusFailed, result.Message)
+		return result, err
+	}
+
+	result.Success = true
+	result.Message = message
+	audit(temporal_activities.AuditStatusCompleted, message)
+
+	logger.Info("Admin action completed", "actionID", actionID, "action", input.Action, "message", message)
+	return result, nil
+}
+
+// adminActivityOptions returns the activity options shared by the admin workflows.
+// Remediations should fail fast and be rerun by the operator rather than retry for long.
+func adminActivityOptions() workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    5 * time.Second,
+			MaximumAttempts:    2,
+		},
+	}
+}
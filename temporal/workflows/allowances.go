@@ -0,0 +1,34 @@
+package temporal_workflows
+
+import (
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/workflow"
+)
+
+// allowanceChange versions checking allowances before the wraps of liquidity workflows and admin swap retries;
+// swap workflows check them in their execute, tranche and fast path steps
+const allowanceChange = "allowance-approval"
+
+// swapAllowance returns the allowance executing a swap on-chain needs, or false for swaps that spend none of the
+// executing account's tokens: cross-chain swaps, which Universal carries, swaps sent from smart accounts, and swaps
+// whose permit lets the DEX contract pull the source address's tokens
+func swapAllowance(request types.SwapRequest) (types.AllowanceRequest, bool) {
+	if types.CanonicalChainID(request.SourceToken.ChainID) != types.CanonicalChainID(request.DestinationToken.ChainID) || request.UserOperation != nil || request.Permit != nil {
+		return types.AllowanceRequest{}, false
+	}
+	return types.AllowanceRequest{
+		RequestID: request.RequestID,
+		Operation: types.AllowanceForSwap,
+		Token:     request.SourceToken,
+		Amount:    request.Amount,
+	}, true
+}
+
+// approveSwapAllowance lets the chain's DEX contract spend a swap's source tokens before the swap executes
+func approveSwapAllowance(ctx workflow.Context, steps swapSteps, request types.SwapRequest) error {
+	allowance, ok := swapAllowance(request)
+	if !ok {
+		return nil
+	}
+	return steps.budget.execute(ctx, steps.forChain(request.SourceToken.ChainID).onChainOptions(), "CheckAndApproveAllowanceActivity", nil, allowance)
+}
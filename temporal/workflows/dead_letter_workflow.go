@@ -0,0 +1,129 @@
+package temporal_workflows
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Resolutions of a dead-lettered swap ResolveDeadLetterWorkflow offers
+const (
+	DeadLetterRequeue = "requeue" // Execute the swap again, like a swap retry
+	DeadLetterRefund  = "refund"  // Refund the swap's input to the sender, like a forced refund
+)
+
+// DeadLetterScanInput represents the input for DeadLetterScanWorkflow
+type DeadLetterScanInput struct {
+	TaskQueue  string        // The region's swap task queue
+	StuckAfter time.Duration // How long a swap may run before an activity retrying makes it stuck
+	Lookback   time.Duration // How far back the first scan looks for failed swaps
+}
+
+// DeadLetterScanResult is the outcome of a dead-letter scan
+type DeadLetterScanResult struct {
+	ScannedAt time.Time `json:"scannedAt"` // The next scan looks for swaps failed since
+	Found     int       `json:"found"`
+	Recorded  int       `json:"recorded"` // Found swaps not recorded by an earlier scan
+}
+
+// DeadLetterScanWorkflow finds swaps stuck past StuckAfter or failed after running out of retries, records them as
+// dead letters and alerts operators. The swap workers run it on a cron schedule; each run looks for swaps failed
+// since the last successful run, or within Lookback for the first.
+func DeadLetterScanWorkflow(ctx workflow.Context, input DeadLetterScanInput) (*DeadLetterScanResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	result := &DeadLetterScanResult{ScannedAt: workflow.Now(ctx)}
+	since := result.ScannedAt.Add(-input.Lookback)
+	if workflow.HasLastCompletionResult(ctx) {
+		var last DeadLetterScanResult
+		if err := workflow.GetLastCompletionResult(ctx, &last); err == nil && !last.ScannedAt.IsZero() {
+			since = last.ScannedAt
+		}
+	}
+
+	options := workflow.ActivityOptions{
+		// Scans list and describe every swap in the window, heartbeating after each page
+		StartToCloseTimeout: 5 * time.Minute,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    5 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    3,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, options)
+
+	scan := temporal_activities.DeadLetterScan{
+		TaskQueue:   input.TaskQueue,
+		StuckAfter:  input.StuckAfter,
+		FailedSince: since,
+	}
+	var found []types.DeadLetter
+	if err := workflow.ExecuteActivity(ctx, "FindDeadLettersActivity", scan).Get(ctx, &found); err != nil {
+		logger.Error("Failed to scan for dead-lettered swaps", "error", err)
+		return nil, err
+	}
+	result.Found = len(found)
+
+	if len(found) > 0 {
+		var recorded []types.DeadLetter
+		if err := workflow.ExecuteActivity(ctx, "RecordDeadLettersActivity", found).Get(ctx, &recorded); err != nil {
+			logger.Error("Failed to record dead-lettered swaps", "error", err)
+			return nil, err
+		}
+		result.Recorded = len(recorded)
+	}
+
+	logger.Info("DeadLetterScanWorkflow completed", "since", since, "found", result.Found, "recorded", result.Recorded)
+	return result, nil
+}
+
+// ResolveDeadLetterWorkflow requeues or refunds a dead-lettered swap, as Params["resolution"] says, and marks its
+// dead letter resolved. A stuck swap's run is terminated first so it can't also complete the swap.
+func ResolveDeadLetterWorkflow(ctx workflow.Context, input AdminActionInput) (*AdminActionResult, error) {
+	return runAdminAction(ctx, input, func(ctx workflow.Context) (string, error) {
+		if input.DeadLetter == nil {
+			return "", temporal.NewNonRetryableApplicationError("Missing dead letter to resolve", "INVALID_INPUT", errors.New("dead letter is required"))
+		}
+		letter := *input.DeadLetter
+		actionID := workflow.GetInfo(ctx).WorkflowExecution.ID
+
+		var status types.DeadLetterStatus
+		resolution := input.Params["resolution"]
+		switch resolution {
+		case DeadLetterRequeue:
+			status = types.DeadLetterRequeued
+		case DeadLetterRefund:
+			status = types.DeadLetterRefunded
+		default:
+			return "", temporal.NewNonRetryableApplicationError(fmt.Sprintf("Unknown resolution %q", resolution), "INVALID_INPUT", errors.New("invalid resolution"))
+		}
+
+		reason := fmt.Sprintf("dead letter %s by %s in %s", status, input.Operator, actionID)
+		if err := workflow.ExecuteActivity(ctx, "TerminateStuckSwapActivity", letter, reason).Get(ctx, nil); err != nil {
+			return "", err
+		}
+
+		var message string
+		var err error
+		if resolution == DeadLetterRequeue {
+			message, err = retrySwap(ctx, input)
+		} else {
+			message, err = refundSwap(ctx, letter.RequestID)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if err := workflow.ExecuteActivity(ctx, "ResolveDeadLetterActivity", letter.RequestID, status, input.Operator, actionID).Get(ctx, nil); err != nil {
+			return "", fmt.Errorf("%s, but marking its dead letter %s failed: %w", message, status, err)
+		}
+		return message, nil
+	})
+}
@@ -0,0 +1,118 @@
+package temporal_workflows
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	temporal_activities "github.com/infinity-dex/temporal/activities"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestDeadLetterScanWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	start := time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC)
+	env.SetStartTime(start)
+
+	found := []types.DeadLetter{{RequestID: "swap-1", RunID: "run-1"}, {RequestID: "swap-2", RunID: "run-1"}}
+	env.RegisterActivityWithOptions(func(ctx context.Context, scan temporal_activities.DeadLetterScan) ([]types.DeadLetter, error) {
+		// The first scan looks back the whole lookback for failed swaps
+		if scan.TaskQueue != "swap-queue" || scan.StuckAfter != 30*time.Second || !scan.FailedSince.Equal(start.Add(-24*time.Hour)) {
+			t.Errorf("Unexpected scan: %+v", scan)
+		}
+		return found, nil
+	}, activity.RegisterOptions{Name: "FindDeadLettersActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, letters []types.DeadLetter) ([]types.DeadLetter, error) {
+		// swap-2 was recorded by an earlier scan
+		return letters[:1], nil
+	}, activity.RegisterOptions{Name: "RecordDeadLettersActivity"})
+
+	env.ExecuteWorkflow(DeadLetterScanWorkflow, DeadLetterScanInput{TaskQueue: "swap-queue", StuckAfter: 30 * time.Second, Lookback: 24 * time.Hour})
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Expected the workflow to complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Expected the workflow to succeed, got %v", err)
+	}
+	var result DeadLetterScanResult
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("Failed to get result: %v", err)
+	}
+	if !result.ScannedAt.Equal(start) || result.Found != 2 || result.Recorded != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestResolveDeadLetterWorkflow(t *testing.T) {
+	letter := types.DeadLetter{RequestID: "swap-1", RunID: "run-1", Reason: types.DeadLetterStuck, Status: types.DeadLetterOpen}
+
+	for _, tc := range []struct {
+		resolution string
+		status     types.DeadLetterStatus
+		activity   string
+	}{
+		{DeadLetterRequeue, types.DeadLetterRequeued, "ExecuteSwapActivity"},
+		{DeadLetterRefund, types.DeadLetterRefunded, "RefundSwapActivity"},
+	} {
+		t.Run(tc.resolution, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+
+			var calls []string
+			env.RegisterActivityWithOptions(func(ctx context.Context, entry temporal_activities.AuditEntry) error {
+				return nil
+			}, activity.RegisterOptions{Name: "RecordAuditActivity"})
+			env.RegisterActivityWithOptions(func(ctx context.Context, terminated types.DeadLetter, reason string) error {
+				if terminated.RunID != "run-1" {
+					t.Errorf("Expected the stuck run terminated, got %+v", terminated)
+				}
+				calls = append(calls, "TerminateStuckSwapActivity")
+				return nil
+			}, activity.RegisterOptions{Name: "TerminateStuckSwapActivity"})
+			env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) (types.SwapResult, error) {
+				calls = append(calls, "ExecuteSwapActivity")
+				return types.SwapResult{RequestID: request.RequestID, Success: true, OutputAmount: big.NewInt(990)}, nil
+			}, activity.RegisterOptions{Name: "ExecuteSwapActivity"})
+			env.RegisterActivityWithOptions(func(ctx context.Context, requestID string) (types.Transaction, error) {
+				calls = append(calls, "RefundSwapActivity")
+				return types.Transaction{ID: requestID + "-refund", Amount: big.NewInt(1000)}, nil
+			}, activity.RegisterOptions{Name: "RefundSwapActivity"})
+			env.RegisterActivityWithOptions(func(ctx context.Context, requestID string, status types.DeadLetterStatus, operator, actionID string) error {
+				if requestID != "swap-1" || status != tc.status || operator != "alice" {
+					t.Errorf("Unexpected resolution of %s: %s by %s", requestID, status, operator)
+				}
+				calls = append(calls, "ResolveDeadLetterActivity")
+				return nil
+			}, activity.RegisterOptions{Name: "ResolveDeadLetterActivity"})
+
+			env.ExecuteWorkflow(ResolveDeadLetterWorkflow, AdminActionInput{
+				Action:   AdminActionResolveDeadLetter,
+				Operator: "alice",
+				Params:   map[string]string{"requestId": "swap-1", "resolution": tc.resolution},
+				Swap: &SwapWorkflowInput{Request: types.SwapRequest{
+					RequestID:        "swap-1",
+					SourceToken:      types.Token{Symbol: "ETH", ChainID: 1},
+					DestinationToken: types.Token{Symbol: "USDC", ChainID: 137},
+				}},
+				DeadLetter: &letter,
+			})
+			if err := env.GetWorkflowError(); err != nil {
+				t.Fatalf("Expected the workflow to succeed, got %v", err)
+			}
+
+			want := []string{"TerminateStuckSwapActivity", tc.activity, "ResolveDeadLetterActivity"}
+			if len(calls) != len(want) {
+				t.Fatalf("Expected %v, got %v", want, calls)
+			}
+			for i := range want {
+				if calls[i] != want[i] {
+					t.Errorf("Expected %v, got %v", want, calls)
+				}
+			}
+		})
+	}
+}
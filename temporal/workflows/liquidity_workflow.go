@@ -0,0 +1,231 @@
+package temporal_workflows
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// LiquidityWorkflowInput represents the input for the liquidity workflows
+type LiquidityWorkflowInput struct {
+	Request types.LiquidityRequest
+
+	// Retries are how the workflow's wraps, pool transfers and unwraps are retried; without them the defaults apply
+	Retries *types.RetryPolicies
+}
+
+// liquidityCompensationChange versions undoing the completed steps of a failed liquidity workflow
+const liquidityCompensationChange = "liquidity-compensation"
+
+// liquidityCompensation is an activity undoing a completed step of a liquidity workflow
+type liquidityCompensation struct {
+	activity string
+	request  types.LiquidityRequest
+}
+
+// AddLiquidityWorkflow is the workflow definition for adding liquidity to a pool
+// It orchestrates the following steps:
+// 1. Wrap the deposited token into its Universal version
+// 2. Deposit the wrapped tokens into the pool
+// 3. Mint the user's LP position
+// 4. Return the result
+// When a step fails, the steps before it are undone: the deposit is withdrawn and the wrapped tokens unwrapped.
+func AddLiquidityWorkflow(ctx workflow.Context, input LiquidityWorkflowInput) (*types.LiquidityResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("AddLiquidityWorkflow started", "poolID", input.Request.PoolID, "token", input.Request.Token.Symbol)
+
+	request := input.Request
+	if request.RequestID == "" {
+		request.RequestID = fmt.Sprintf("add-liquidity-%s", workflow.GetInfo(ctx).WorkflowExecution.ID)
+	}
+
+	result := &types.LiquidityResult{
+		RequestID: request.RequestID,
+		PoolID:    request.PoolID,
+		Action:    "add",
+		Amount:    request.Amount,
+	}
+
+	policies := retryPolicies(input.Retries)
+	ctx = workflow.WithActivityOptions(ctx, liquidityActivityOptions(policies.Transfer))
+
+	// Step 1: Wrap the token, once the Universal contract may spend it
+	var wrapResult universalsdk.WrapResult
+	wrapCtx := workflow.WithActivityOptions(ctx, liquidityActivityOptions(policies.Wrap))
+	if !request.Token.IsWrapped && workflow.GetVersion(ctx, allowanceChange, workflow.DefaultVersion, 1) == 1 {
+		allowance := types.AllowanceRequest{RequestID: request.RequestID, Operation: types.AllowanceForWrap, Token: request.Token, Amount: request.Amount}
+		if err := workflow.ExecuteActivity(wrapCtx, "CheckAndApproveAllowanceActivity", allowance).Get(ctx, nil); err != nil {
+			logger.Error("Failed to approve liquidity token allowance", "error", err)
+			return failLiquidityResult(ctx, result, "Failed to approve token", err), err
+		}
+	}
+	err := workflow.ExecuteActivity(wrapCtx, "WrapLiquidityTokenActivity", request).Get(ctx, &wrapResult)
+	if err != nil {
+		logger.Error("Failed to wrap liquidity token", "error", err)
+		return failLiquidityResult(ctx, result, "Failed to wrap token", err), err
+	}
+	result.WrapTxHash = wrapResult.TransactionHash
+
+	// Deposit what the wrap actually produced
+	request.Token = wrapResult.WrappedToken
+	request.Amount = wrapResult.Amount
+	result.Amount = wrapResult.Amount
+
+	// Undoing the wrap unwraps what it produced back into the deposited token
+	unwrap := input.Request
+	unwrap.RequestID = request.RequestID
+	unwrap.Amount = wrapResult.Amount
+	compensations := []liquidityCompensation{{activity: "UnwrapLiquidityTokenActivity", request: unwrap}}
+
+	// Step 2: Deposit into the pool
+	var tx types.Transaction
+	err = workflow.ExecuteActivity(ctx, "DepositLiquidityActivity", request).Get(ctx, &tx)
+	if err != nil {
+		logger.Error("Failed to deposit liquidity", "error", err)
+		compensateLiquidity(ctx, compensations)
+		return failLiquidityResult(ctx, result, "Failed to deposit liquidity", err), err
+	}
+	result.Transaction = tx
+	compensations = append(compensations, liquidityCompensation{activity: "WithdrawLiquidityActivity", request: request})
+
+	// Step 3: Mint the LP position
+	var position types.LiquidityPosition
+	err = workflow.ExecuteActivity(ctx, "MintLPPositionActivity", request).Get(ctx, &position)
+	if err != nil {
+		logger.Error("Failed to mint LP position", "error", err)
+		compensateLiquidity(ctx, compensations)
+		return failLiquidityResult(ctx, result, "Failed to mint LP position", err), err
+	}
+
+	result.Position = &position
+	result.Success = true
+	result.CompletionTime = workflow.Now(ctx)
+
+	logger.Info("AddLiquidityWorkflow completed successfully",
+		"requestID", request.RequestID,
+		"poolID", request.PoolID,
+		"amount", request.Amount.String())
+
+	return result, nil
+}
+
+// RemoveLiquidityWorkflow is the workflow definition for removing liquidity from a pool
+// It orchestrates the following steps:
+// 1. Burn LP tokens from the user's position
+// 2. Withdraw the wrapped tokens from the pool
+// 3. Unwrap the tokens back to the requested native token
+// 4. Return the result
+// When a step fails, the steps before it are undone: the tokens are deposited again and the LP position restored.
+func RemoveLiquidityWorkflow(ctx workflow.Context, input LiquidityWorkflowInput) (*types.LiquidityResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("RemoveLiquidityWorkflow started", "poolID", input.Request.PoolID, "token", input.Request.Token.Symbol)
+
+	request := input.Request
+	if request.RequestID == "" {
+		request.RequestID = fmt.Sprintf("remove-liquidity-%s", workflow.GetInfo(ctx).WorkflowExecution.ID)
+	}
+
+	result := &types.LiquidityResult{
+		RequestID: request.RequestID,
+		PoolID:    request.PoolID,
+		Action:    "remove",
+		Amount:    request.Amount,
+	}
+
+	policies := retryPolicies(input.Retries)
+	ctx = workflow.WithActivityOptions(ctx, liquidityActivityOptions(policies.Transfer))
+
+	// Step 1: Burn the LP position
+	err := workflow.ExecuteActivity(ctx, "BurnLPPositionActivity", request).Get(ctx, nil)
+	if err != nil {
+		logger.Error("Failed to burn LP position", "error", err)
+		return failLiquidityResult(ctx, result, "Failed to burn LP position", err), err
+	}
+
+	compensations := []liquidityCompensation{{activity: "RestoreLPPositionActivity", request: request}}
+
+	// Step 2: Withdraw from the pool
+	var tx types.Transaction
+	err = workflow.ExecuteActivity(ctx, "WithdrawLiquidityActivity", request).Get(ctx, &tx)
+	if err != nil {
+		logger.Error("Failed to withdraw liquidity", "error", err)
+		compensateLiquidity(ctx, compensations)
+		return failLiquidityResult(ctx, result, "Failed to withdraw liquidity", err), err
+	}
+	result.Transaction = tx
+	compensations = append(compensations, liquidityCompensation{activity: "DepositLiquidityActivity", request: request})
+
+	// Step 3: Unwrap the withdrawn tokens
+	var unwrapResult universalsdk.UnwrapResult
+	unwrapCtx := workflow.WithActivityOptions(ctx, liquidityActivityOptions(policies.Unwrap))
+	err = workflow.ExecuteActivity(unwrapCtx, "UnwrapLiquidityTokenActivity", request).Get(ctx, &unwrapResult)
+	if err != nil {
+		logger.Error("Failed to unwrap liquidity token", "error", err)
+		compensateLiquidity(ctx, compensations)
+		return failLiquidityResult(ctx, result, "Failed to unwrap token", err), err
+	}
+
+	result.Amount = unwrapResult.Amount
+	result.Success = true
+	result.CompletionTime = workflow.Now(ctx)
+
+	logger.Info("RemoveLiquidityWorkflow completed successfully",
+		"requestID", request.RequestID,
+		"poolID", request.PoolID,
+		"amount", result.Amount.String())
+
+	return result, nil
+}
+
+// compensateLiquidity undoes the completed steps of a failed liquidity workflow, latest first. It runs even when
+// the workflow was cancelled. A compensation that keeps failing is logged and the remaining ones still run.
+func compensateLiquidity(ctx workflow.Context, compensations []liquidityCompensation) {
+	if workflow.GetVersion(ctx, liquidityCompensationChange, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return
+	}
+
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: OnChainActivityTimeout,
+		HeartbeatTimeout:    onChainHeartbeatTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    10,
+		},
+	})
+
+	for i := len(compensations) - 1; i >= 0; i-- {
+		compensation := compensations[i]
+		if err := workflow.ExecuteActivity(ctx, compensation.activity, compensation.request).Get(ctx, nil); err != nil {
+			workflow.GetLogger(ctx).Error("Failed to undo liquidity step",
+				"activity", compensation.activity,
+				"requestID", compensation.request.RequestID,
+				"error", err)
+		}
+	}
+}
+
+// liquidityActivityOptions returns the activity options shared by the liquidity workflows, retried by policy.
+// Wraps and unwraps may wait for their transactions to be mined.
+func liquidityActivityOptions(policy types.RetryPolicy) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: OnChainActivityTimeout,
+		HeartbeatTimeout:    onChainHeartbeatTimeout,
+		RetryPolicy:         retryPolicy(policy),
+	}
+}
+
+// Helper function to mark a liquidity result as failed
+func failLiquidityResult(ctx workflow.Context, result *types.LiquidityResult, message string, err error) *types.LiquidityResult {
+	result.Success = false
+	result.ErrorMessage = fmt.Sprintf("%s: %v", message, err)
+	result.CompletionTime = workflow.Now(ctx)
+	return result
+}
@@ -0,0 +1,98 @@
+package temporal_workflows
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ListingReviewSignal is the signal operators send to approve or reject a listing request
+const ListingReviewSignal = "listing_review"
+
+// ListingStatusQuery returns the current state of a listing request
+const ListingStatusQuery = "listing_status"
+
+// listingReviewTimeout is how long a listing request waits in the review queue before it is rejected
+const listingReviewTimeout = 14 * 24 * time.Hour
+
+// TokenListingWorkflow takes a token listing request through risk checks and admin review.
+// It orchestrates the following steps:
+// 1. Run the automated risk checks, rejecting the request if a blocking check fails
+// 2. Wait in the review queue for an operator's decision
+// 3. Add the token to the registry on approval
+func TokenListingWorkflow(ctx workflow.Context, listing types.ListingRequest) (*types.ListingRequest, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("TokenListingWorkflow started", "listingID", listing.ID, "symbol", listing.Token.Symbol, "chainId", listing.Token.ChainID)
+
+	if err := workflow.SetQueryHandler(ctx, ListingStatusQuery, func() (types.ListingRequest, error) {
+		return listing, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	options := workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    10 * time.Second,
+			MaximumAttempts:    3,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, options)
+
+	// Step 1: Run the risk checks
+	var checks []types.ListingCheck
+	if err := workflow.ExecuteActivity(ctx, "RunListingChecksActivity", listing).Get(ctx, &checks); err != nil {
+		logger.Error("Failed to run listing risk checks", "listingID", listing.ID, "error", err)
+		listing.Status = types.ListingStatusRejected
+		listing.Message = fmt.Sprintf("Failed to run risk checks: %v", err)
+		listing.UpdatedAt = workflow.Now(ctx)
+		return &listing, err
+	}
+	listing.ApplyChecks(checks, workflow.Now(ctx))
+	if listing.Status == types.ListingStatusRejected {
+		logger.Info("Listing rejected by risk checks", "listingID", listing.ID, "message", listing.Message)
+		return &listing, nil
+	}
+
+	// Step 2: Wait for review
+	logger.Info("Listing queued for review", "listingID", listing.ID)
+	var review types.ListingReview
+	reviewed := false
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(workflow.GetSignalChannel(ctx, ListingReviewSignal), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &review)
+		reviewed = true
+	})
+	selector.AddFuture(workflow.NewTimer(ctx, listingReviewTimeout), func(f workflow.Future) {})
+	selector.Select(ctx)
+
+	if !reviewed {
+		listing.Status = types.ListingStatusRejected
+		listing.Message = "Review timed out"
+		listing.UpdatedAt = workflow.Now(ctx)
+		return &listing, nil
+	}
+	review.ReviewedAt = workflow.Now(ctx)
+	logger.Info("Listing reviewed", "listingID", listing.ID, "approved", review.Approved, "operator", review.Operator)
+
+	// Step 3: Add the token to the registry
+	if review.Approved {
+		if err := workflow.ExecuteActivity(ctx, "ListTokenActivity", listing).Get(ctx, nil); err != nil {
+			logger.Error("Failed to list token", "listingID", listing.ID, "error", err)
+			listing.Review = &review
+			listing.Status = types.ListingStatusRejected
+			listing.Message = fmt.Sprintf("Approved but failed to list token: %v", err)
+			listing.UpdatedAt = workflow.Now(ctx)
+			return &listing, err
+		}
+	}
+	listing.ApplyReview(review)
+
+	logger.Info("TokenListingWorkflow completed", "listingID", listing.ID, "status", listing.Status)
+	return &listing, nil
+}
@@ -0,0 +1,78 @@
+package temporal_workflows
+
+import (
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// priceAlertsChange versions evaluating the price alerts against each update's fetched prices: 2 first checks any
+// alert is active, starting no alert workflow when none is
+const priceAlertsChange = "price-alerts"
+
+// PriceAlertWorkflow evaluates the registered price alerts against freshly fetched prices and notifies the owners of
+// the alerts that fired.
+// It orchestrates the following steps:
+// 1. Mark the active alerts the prices crossed as triggered, so each fires once
+// 2. Notify every fired alert on each of its channels, webhook and email, in parallel
+// A failed notification is logged and does not fail the others.
+func PriceAlertWorkflow(ctx workflow.Context, prices []types.TokenPrice) ([]types.PriceAlert, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("PriceAlertWorkflow started", "priceCount", len(prices))
+
+	evaluateCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    10 * time.Second,
+			MaximumAttempts:    3,
+		},
+	})
+
+	// Step 1: Evaluate the alerts
+	var triggered []types.PriceAlert
+	if err := workflow.ExecuteActivity(evaluateCtx, "EvaluatePriceAlertsActivity", prices).Get(ctx, &triggered); err != nil {
+		logger.Error("Failed to evaluate price alerts", "error", err)
+		return nil, err
+	}
+	if len(triggered) == 0 {
+		return nil, nil
+	}
+
+	// Step 2: Notify each fired alert; the alert is already triggered, so deliveries are retried for longer
+	notifyCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    5 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    5 * time.Minute,
+			MaximumAttempts:    8,
+		},
+	})
+	type notification struct {
+		alert   types.PriceAlert
+		channel string
+		future  workflow.Future
+	}
+	var notifications []notification
+	for _, alert := range triggered {
+		for _, channel := range alert.Channels() {
+			notifications = append(notifications, notification{
+				alert:   alert,
+				channel: channel,
+				future:  workflow.ExecuteActivity(notifyCtx, "NotifyPriceAlertActivity", alert, channel),
+			})
+		}
+	}
+	for _, n := range notifications {
+		if err := n.future.Get(ctx, nil); err != nil {
+			logger.Error("Failed to notify price alert", "alertID", n.alert.ID, "channel", n.channel, "error", err)
+		}
+	}
+
+	logger.Info("PriceAlertWorkflow completed", "triggered", len(triggered))
+	return triggered, nil
+}
@@ -0,0 +1,65 @@
+package temporal_workflows
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestPriceAlertWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+
+	prices := []types.TokenPrice{{Symbol: "ETH", ChainID: 1, PriceUSD: 4100}}
+	env.RegisterActivityWithOptions(func(ctx context.Context, evaluated []types.TokenPrice) ([]types.PriceAlert, error) {
+		if len(evaluated) != 1 || evaluated[0].PriceUSD != 4100 {
+			t.Errorf("Expected the fetched prices evaluated, got %+v", evaluated)
+		}
+		return []types.PriceAlert{
+			{ID: "alert-1", Symbol: "ETH", WebhookURL: "https://example.com/hook", Email: "ops@example.com", Status: types.PriceAlertStatusTriggered},
+			{ID: "alert-2", Symbol: "ETH", Email: "desk@example.com", Status: types.PriceAlertStatusTriggered},
+		}, nil
+	}, activity.RegisterOptions{Name: "EvaluatePriceAlertsActivity"})
+
+	// The notifications run in parallel
+	var mu sync.Mutex
+	notified := make(map[string]int)
+	env.RegisterActivityWithOptions(func(ctx context.Context, alert types.PriceAlert, channel string) error {
+		mu.Lock()
+		notified[alert.ID+"/"+channel]++
+		mu.Unlock()
+		if channel == types.PriceAlertChannelWebhook {
+			return temporal.NewNonRetryableApplicationError("no secret", "WEBHOOK_SECRET_NOT_FOUND", nil)
+		}
+		return nil
+	}, activity.RegisterOptions{Name: "NotifyPriceAlertActivity"})
+
+	env.ExecuteWorkflow(PriceAlertWorkflow, prices)
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Expected the workflow to complete")
+	}
+	// A failed delivery doesn't fail the others or the workflow
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Expected the workflow to succeed, got %v", err)
+	}
+	var triggered []types.PriceAlert
+	if err := env.GetWorkflowResult(&triggered); err != nil {
+		t.Fatalf("Failed to get result: %v", err)
+	}
+	if len(triggered) != 2 {
+		t.Errorf("Expected both fired alerts returned, got %d", len(triggered))
+	}
+	for _, key := range []string{"alert-1/webhook", "alert-1/email", "alert-2/email"} {
+		if notified[key] != 1 {
+			t.Errorf("Expected %s notified once, got %d", key, notified[key])
+		}
+	}
+	if len(notified) != 3 {
+		t.Errorf("Expected 3 notifications, got %v", notified)
+	}
+}
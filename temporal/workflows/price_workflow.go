@@ -5,17 +5,39 @@ import (
 	"time"
 
 	"github.com/infinity-dex/services/types"
-	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/workflow"
 )
 
+// fetchPricesActivityChange versions fetching every source through FetchPricesActivity
+// in place of the per-source activities
+const fetchPricesActivityChange = "fetch-prices-activity"
+
+// okxReferenceChange versions fetching OKX reference prices by default
+const okxReferenceChange = "okx-reference"
+
+// priceCacheLoadChange versions loading the fresh cached prices through LoadFreshPricesFromCacheActivity and
+// refetching only the stale ones, in place of LoadPricesFromCacheActivity's all-or-nothing load
+const priceCacheLoadChange = "price-cache-load"
+
+// candleRollupChange versions rolling the saved prices up into candles after each update
+const candleRollupChange = "candle-rollup"
+
+// legacyFetchActivities are the per-source activities scheduled before fetchPricesActivityChange
+var legacyFetchActivities = map[string]string{
+	string(types.PriceSourceUniversal): "FetchUniversalPricesActivity",
+	string(types.PriceSourceCoinGecko): "FetchCoinGeckoPricesActivity",
+	string(types.PriceSourceJupiter):   "FetchJupiterPricesActivity",
+}
+
 // PriceOracleWorkflow is the workflow definition for fetching and caching token prices
 // It orchestrates the following steps:
 // 1. Try to load prices from cache
-// 2. If cache is expired or missing, fetch prices from all sources
+// 2. Fetch the prices that are missing or expired in the cache from all sources
 // 3. Merge prices from different sources
 // 4. Save merged prices to cache and database
-// 5. Return the prices
+// 5. Evaluate the price alerts against the fetched prices
+// 6. Return the prices
 func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest) (*types.PriceFetchResult, error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("PriceOracleWorkflow started", "requestID", request.RequestID)
@@ -40,19 +62,21 @@ func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest)
 	}
 
 	// Define retry policy for activities
+	var policy types.RetryPolicy
+	if request.Retry != nil {
+		policy = *request.Retry
+	}
 	options := workflow.ActivityOptions{
 		StartToCloseTimeout: 30 * time.Second,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    10 * time.Second,
-			MaximumAttempts:    3,
-		},
+		RetryPolicy:         retryPolicy(policy),
 	}
 	ctx = workflow.WithActivityOptions(ctx, options)
 
 	// 1. Try to load prices from cache if not forcing sync
-	if !request.ForceSync {
+	stepVersion(ctx, priceCacheStep)
+	fullRequest := request
+	var freshPrices []types.TokenPrice
+	if !request.ForceSync && workflow.GetVersion(ctx, priceCacheLoadChange, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
 		var cachedPrices []types.TokenPrice
 		err := workflow.ExecuteActivity(ctx, "LoadPricesFromCacheActivity", request).Get(ctx, &cachedPrices)
 
@@ -71,13 +95,41 @@ func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest)
 		} else {
 			logger.Info("Cache miss", "reason", "empty cache")
 		}
+	} else if !request.ForceSync {
+		var cached types.PriceCacheLoad
+		err := workflow.ExecuteActivity(ctx, "LoadFreshPricesFromCacheActivity", request).Get(ctx, &cached)
+
+		switch {
+		case err != nil:
+			logger.Info("Cache miss", "reason", err.Error())
+		case len(cached.Prices) == 0:
+			logger.Info("Cache miss", "reason", "no fresh prices")
+		case len(cached.StaleSymbols) == 0:
+			// Every requested price is fresh, return cached prices
+			logger.Info("Using cached prices", "count", len(cached.Prices))
+			result.Prices = cached.Prices
+			result.CacheHit = true
+			result.SuccessSources = []string{"cache"}
+			return result, nil
+		default:
+			// Only re-fetch the prices that expired
+			logger.Info("Partial cache hit", "fresh", len(cached.Prices), "staleSymbols", cached.StaleSymbols)
+			freshPrices = cached.Prices
+			request.Symbols = cached.StaleSymbols
+		}
 	}
 
 	// 2. Fetch prices from all sources in parallel
+	stepVersion(ctx, priceFetchStep)
 	// Define sources to fetch from
 	sources := []string{
 		string(types.PriceSourceCoinGecko),
 		string(types.PriceSourceJupiter),
+		string(types.PriceSourceChainlink),
+		string(types.PriceSourceBinance), // Reference only, bounds the merged prices
+	}
+	if workflow.GetVersion(ctx, okxReferenceChange, workflow.DefaultVersion, 1) == 1 {
+		sources = append(sources, string(types.PriceSourceOKX)) // Reference only, used when Binance lacks a symbol or fails
 	}
 
 	// If Universal SDK is available, add it to sources
@@ -85,25 +137,31 @@ func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest)
 		request.Sources = sources
 	}
 
-	// Create futures for each source
-	futures := make(map[string]workflow.Future)
-	for _, source := range request.Sources {
-		switch source {
-		case string(types.PriceSourceUniversal):
-			futures[source] = workflow.ExecuteActivity(ctx, "FetchUniversalPricesActivity", request)
-		case string(types.PriceSourceCoinGecko):
-			futures[source] = workflow.ExecuteActivity(ctx, "FetchCoinGeckoPricesActivity", request)
-		case string(types.PriceSourceJupiter):
-			futures[source] = workflow.ExecuteActivity(ctx, "FetchJupiterPricesActivity", request)
+	// Create futures for each source; every source is fetched through the
+	// registry-backed FetchPricesActivity, so new sources need no workflow changes.
+	// Runs started before it fetch the sources that had their own activity and skip the rest.
+	fetchVersion := workflow.GetVersion(ctx, fetchPricesActivityChange, workflow.DefaultVersion, 1)
+	futures := make([]workflow.Future, len(request.Sources))
+	for i, source := range request.Sources {
+		if fetchVersion == workflow.DefaultVersion {
+			if name, ok := legacyFetchActivities[source]; ok {
+				futures[i] = workflow.ExecuteActivity(ctx, name, request)
+			}
+			continue
 		}
+		futures[i] = workflow.ExecuteActivity(ctx, "FetchPricesActivity", source, request)
 	}
 
-	// Wait for all futures to complete
+	// Wait for all futures to complete, in request order so replays are deterministic
 	var successSources []string
 	var failedSources []string
 	var pricesList [][]types.TokenPrice
 
-	for source, future := range futures {
+	for i, future := range futures {
+		if future == nil {
+			continue
+		}
+		source := request.Sources[i]
 		var prices []types.TokenPrice
 		err := future.Get(ctx, &prices)
 		if err != nil {
@@ -121,9 +179,10 @@ func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest)
 	if len(pricesList) == 0 {
 		result.ErrorMessage = "Failed to fetch prices from any source"
 		result.FailedSources = failedSources
-		return result, workflow.NewContinueAsNewError(ctx, "PriceOracleWorkflow", request)
+		return result, workflow.NewContinueAsNewError(ctx, "PriceOracleWorkflow", fullRequest)
 	}
 
+	stepVersion(ctx, priceMergeStep)
 	var mergedPrices []types.TokenPrice
 	err := workflow.ExecuteActivity(ctx, "MergePricesActivity", pricesList).Get(ctx, &mergedPrices)
 	if err != nil {
@@ -134,6 +193,7 @@ func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest)
 	}
 
 	// 4. Save merged prices to cache
+	stepVersion(ctx, priceSaveStep)
 	err = workflow.ExecuteActivity(ctx, "SavePricesToCacheActivity", mergedPrices).Get(ctx, nil)
 	if err != nil {
 		logger.Error("Failed to save prices to cache", "error", err)
@@ -147,8 +207,34 @@ func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest)
 		// Continue anyway, just log the error
 	}
 
-	// 6. Return the prices
-	result.Prices = mergedPrices
+	// 6. Roll the new history up into candles
+	if workflow.GetVersion(ctx, candleRollupChange, workflow.DefaultVersion, 1) == 1 {
+		err = workflow.ExecuteActivity(ctx, "RollupCandlesActivity").Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to roll up price candles", "error", err)
+			// Continue anyway, the next run catches up
+		}
+	}
+
+	// 7. Evaluate the price alerts against the fetched prices, when any is active. The alerts are notified in a child
+	// workflow left running once started, so slow deliveries don't hold up the update.
+	if alertsVersion := workflow.GetVersion(ctx, priceAlertsChange, workflow.DefaultVersion, 2); alertsVersion >= 1 && (alertsVersion < 2 || hasActivePriceAlerts(ctx)) {
+		alertCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+			WorkflowRunTimeout: time.Hour,
+			ParentClosePolicy:  enums.PARENT_CLOSE_POLICY_ABANDON,
+		})
+		alerts := workflow.ExecuteChildWorkflow(alertCtx, "PriceAlertWorkflow", mergedPrices)
+		if err := alerts.GetChildWorkflowExecution().Get(ctx, nil); err != nil {
+			logger.Error("Failed to start price alert workflow", "error", err)
+			// Continue anyway, the alerts are evaluated again by the next update
+		}
+	}
+
+	// 8. Return the prices, along with the fresh cached prices on a partial refresh
+	result.Prices = mergeFreshPrices(freshPrices, mergedPrices)
+	if len(freshPrices) > 0 {
+		successSources = append(successSources, "cache")
+	}
 	result.SuccessSources = successSources
 	result.FailedSources = failedSources
 
@@ -161,27 +247,83 @@ func PriceOracleWorkflow(ctx workflow.Context, request types.PriceFetchRequest)
 	return result, nil
 }
 
-// ScheduledPriceUpdateWorkflow is a workflow that runs on a schedule to update the price cache
-func ScheduledPriceUpdateWorkflow(ctx workflow.Context) error {
-	logger := workflow.GetLogger(ctx)
-	logger.Info("ScheduledPriceUpdateWorkflow started")
+// hasActivePriceAlerts reports whether any price alert is active. When that can't be told it reports true, so the
+// alerts are still evaluated.
+func hasActivePriceAlerts(ctx workflow.Context) bool {
+	var active bool
+	if err := workflow.ExecuteActivity(ctx, "HasActivePriceAlertsActivity").Get(ctx, &active); err != nil {
+		workflow.GetLogger(ctx).Error("Failed to look up active price alerts", "error", err)
+		return true
+	}
+	return active
+}
 
-	// No need to define cronSchedule here since we're using a fixed interval
+// mergeFreshPrices combines fresh cached prices with refetched ones, preferring the refetched price of a token
+func mergeFreshPrices(cached, fetched []types.TokenPrice) []types.TokenPrice {
+	if len(cached) == 0 {
+		return fetched
+	}
 
-	// Counter for deterministic child workflow IDs
-	var runCounter int
+	refetched := make(map[string]bool, len(fetched))
+	for _, price := range fetched {
+		refetched[types.GetPriceKey(price.Symbol, price.ChainID)] = true
+	}
 
-	for {
-		// Create a request to fetch all prices
-		request := types.PriceFetchRequest{
-			RequestID: fmt.Sprintf("req-%d", runCounter), // Deterministic ID based on counter
-			Timestamp: workflow.Now(ctx),                 // Use workflow.Now instead of time.Now
-			ForceSync: true,
-			Sources:   []string{string(types.PriceSourceCoinGecko), string(types.PriceSourceJupiter)},
+	prices := make([]types.TokenPrice, 0, len(cached)+len(fetched))
+	for _, price := range cached {
+		if !refetched[types.GetPriceKey(price.Symbol, price.ChainID)] {
+			prices = append(prices, price)
 		}
+	}
+	return append(prices, fetched...)
+}
+
+const (
+	// defaultPriceUpdateInterval is the time between scheduled price updates when none is configured
+	defaultPriceUpdateInterval = 15 * time.Second
+	// defaultPriceUpdateRunsPerExecution is how many updates run before the update workflow continues as new
+	defaultPriceUpdateRunsPerExecution = 500
+)
+
+// ScheduledPriceUpdateInput configures ScheduledPriceUpdateWorkflow; zero values use the defaults
+type ScheduledPriceUpdateInput struct {
+	Interval         time.Duration // Time between updates
+	RunsPerExecution int           // Updates before continuing as new, bounding the history size
+	RunCounter       int           // Updates run by earlier executions, carried over to keep child workflow IDs unique
+
+	// Retry is how each update's price fetches are retried; nil uses the default policy
+	Retry *types.RetryPolicy
+}
+
+// ScheduledPriceFetchRequest returns the request every scheduled price update fetches with
+func ScheduledPriceFetchRequest() types.PriceFetchRequest {
+	return types.PriceFetchRequest{
+		ForceSync: true,
+		Sources:   []string{string(types.PriceSourceCoinGecko), string(types.PriceSourceJupiter), string(types.PriceSourceChainlink), string(types.PriceSourceBinance), string(types.PriceSourceOKX)},
+	}
+}
+
+// ScheduledPriceUpdateWorkflow is a workflow that runs on a schedule to update the price cache.
+// It continues as new every RunsPerExecution updates so its history stays bounded.
+func ScheduledPriceUpdateWorkflow(ctx workflow.Context, input ScheduledPriceUpdateInput) error {
+	logger := workflow.GetLogger(ctx)
+	if input.Interval <= 0 {
+		input.Interval = defaultPriceUpdateInterval
+	}
+	if input.RunsPerExecution <= 0 {
+		input.RunsPerExecution = defaultPriceUpdateRunsPerExecution
+	}
+	logger.Info("ScheduledPriceUpdateWorkflow started", "interval", input.Interval, "runsPerExecution", input.RunsPerExecution, "runCounter", input.RunCounter)
+
+	for run := 0; run < input.RunsPerExecution; run++ {
+		// Create a request to fetch all prices
+		request := ScheduledPriceFetchRequest()
+		request.Retry = input.Retry
+		request.RequestID = fmt.Sprintf("req-%d", input.RunCounter) // Deterministic ID based on counter
+		request.Timestamp = workflow.Now(ctx)                       // Use workflow.Now instead of time.Now
 
 		// Create a deterministic child workflow ID
-		childWorkflowID := fmt.Sprintf("price-oracle-run-%d", runCounter)
+		childWorkflowID := fmt.Sprintf("price-oracle-run-%d", input.RunCounter)
 
 		// Execute the price oracle workflow
 		childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
@@ -201,14 +343,14 @@ func ScheduledPriceUpdateWorkflow(ctx workflow.Context) error {
 		}
 
 		// Increment counter for next run
-		runCounter++
-
-		// Sleep for 15 seconds before the next run
-		sleepDuration := 15 * time.Second
-		logger.Info("Sleeping until next scheduled run", "duration", sleepDuration)
+		input.RunCounter++
 
-		if err := workflow.Sleep(ctx, sleepDuration); err != nil {
+		logger.Info("Sleeping until next scheduled run", "duration", input.Interval)
+		if err := workflow.Sleep(ctx, input.Interval); err != nil {
 			return err
 		}
 	}
+
+	logger.Info("Continuing ScheduledPriceUpdateWorkflow as new", "runCounter", input.RunCounter)
+	return workflow.NewContinueAsNewError(ctx, ScheduledPriceUpdateWorkflow, input)
 }
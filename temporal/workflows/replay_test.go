@@ -0,0 +1,83 @@
+package temporal_workflows
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+// newReplayer returns a replayer with every workflow a recorded history may run registered
+func newReplayer() worker.WorkflowReplayer {
+	replayer := worker.NewWorkflowReplayer()
+	replayer.RegisterWorkflow(SwapWorkflow)
+	replayer.RegisterWorkflow(PriceOracleWorkflow)
+	replayer.RegisterWorkflow(ScheduledPriceUpdateWorkflow)
+	return replayer
+}
+
+// loadHistory reads a history recorded with `temporal workflow show --output json`
+func loadHistory(t *testing.T, file string) *history.History {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", file, err)
+	}
+	defer f.Close()
+
+	h, err := client.HistoryFromJSON(f, client.HistoryJSONOptions{})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", file, err)
+	}
+	if len(h.GetEvents()) == 0 {
+		t.Fatalf("History %s has no events", file)
+	}
+	return h
+}
+
+// TestReplayHistories replays the recorded histories in testdata/histories against the current workflow code, so a
+// change that would break the replay of executions already running fails here first. Record a history with
+// `temporal workflow show --workflow-id <id> --output json`.
+func TestReplayHistories(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "histories", "*.json"))
+	if err != nil {
+		t.Fatalf("Failed to list histories: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("No recorded histories to replay")
+	}
+
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			h := loadHistory(t, file)
+			if err := newReplayer().ReplayWorkflowHistory(nil, h); err != nil {
+				t.Errorf("Failed to replay %s: %v", file, err)
+			}
+		})
+	}
+}
+
+// TestReplayDetectsNondeterminism checks the replay fails when the code no longer schedules what a history recorded,
+// so a passing TestReplayHistories means the histories matched rather than went unchecked
+func TestReplayDetectsNondeterminism(t *testing.T) {
+	h := loadHistory(t, filepath.Join("testdata", "histories", "swap-confirmed.json"))
+
+	changed := false
+	for _, event := range h.GetEvents() {
+		if attrs := event.GetActivityTaskScheduledEventAttributes(); attrs != nil && attrs.GetActivityType().GetName() == "ExecuteSwapActivity" {
+			attrs.ActivityType.Name = "ExecuteSwapActivityV0"
+			changed = true
+		}
+	}
+	if !changed {
+		t.Fatal("History has no ExecuteSwapActivity to change")
+	}
+
+	if err := newReplayer().ReplayWorkflowHistory(nil, h); err == nil {
+		t.Error("Expected the replay of a changed history to fail")
+	}
+}
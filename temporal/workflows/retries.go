@@ -0,0 +1,79 @@
+package temporal_workflows
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// RetryBudgetExhausted is the error type of a swap step that was not run because the swap's activities already
+// took its whole retry budget
+const RetryBudgetExhausted = "RETRY_BUDGET_EXHAUSTED"
+
+// defaultRetryPolicy retries activities whose workflow input sets no policy, and fills the zero fields of those
+// that do
+var defaultRetryPolicy = types.RetryPolicy{
+	InitialInterval:    time.Second,
+	BackoffCoefficient: 2.0,
+	MaximumInterval:    10 * time.Second,
+	MaximumAttempts:    3,
+}
+
+// retryPolicies returns the retry policies of a workflow input, the defaults when it has none
+func retryPolicies(policies *types.RetryPolicies) types.RetryPolicies {
+	if policies == nil {
+		return types.RetryPolicies{}
+	}
+	return *policies
+}
+
+// retryPolicy converts a retry policy to Temporal's, filling its zero fields from defaultRetryPolicy
+func retryPolicy(policy types.RetryPolicy) *temporal.RetryPolicy {
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = defaultRetryPolicy.InitialInterval
+	}
+	if policy.BackoffCoefficient < 1 {
+		policy.BackoffCoefficient = defaultRetryPolicy.BackoffCoefficient
+	}
+	if policy.MaximumInterval <= 0 {
+		policy.MaximumInterval = defaultRetryPolicy.MaximumInterval
+	}
+	if policy.MaximumAttempts <= 0 {
+		policy.MaximumAttempts = defaultRetryPolicy.MaximumAttempts
+	}
+	return &temporal.RetryPolicy{
+		InitialInterval:    policy.InitialInterval,
+		BackoffCoefficient: policy.BackoffCoefficient,
+		MaximumInterval:    policy.MaximumInterval,
+		MaximumAttempts:    policy.MaximumAttempts,
+	}
+}
+
+// retryBudget bounds how long the activities of one swap may take altogether, so retries across its steps can't
+// keep it running long after each step's own policy looked reasonable
+type retryBudget struct {
+	limit time.Duration // Zero leaves the swap unbounded
+	spent time.Duration
+}
+
+// execute runs an activity with options, within what is left of the budget: its schedule-to-close timeout is cut
+// to the time left, though never below one attempt's start-to-close timeout. Once the budget is spent it fails with
+// RetryBudgetExhausted without scheduling the activity.
+func (b *retryBudget) execute(ctx workflow.Context, options workflow.ActivityOptions, activity string, result interface{}, args ...interface{}) error {
+	if b.limit > 0 {
+		remaining := b.limit - b.spent
+		if remaining <= 0 {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("Swap activities took their whole %s retry budget", b.limit), RetryBudgetExhausted, nil)
+		}
+		options.ScheduleToCloseTimeout = max(remaining, options.StartToCloseTimeout)
+	}
+
+	startedAt := workflow.Now(ctx)
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, options), activity, args...).Get(ctx, result)
+	b.spent += workflow.Now(ctx).Sub(startedAt)
+	return err
+}
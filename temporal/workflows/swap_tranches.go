@@ -0,0 +1,226 @@
+package temporal_workflows
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// trancheRequestID names a tranche's own swap, so its transactions and records don't collide with the other tranches'
+func trancheRequestID(requestID string, index int) string {
+	return fmt.Sprintf("%s-tranche-%d", requestID, index)
+}
+
+// executeTranchedSwap executes a confirmed swap split into tranches, one after another. Each tranche is quoted again
+// and checked against the price the user confirmed before it runs. A tranche that fails, or the user cancelling
+// between tranches, stops the swap; the tranches already filled stay filled and the result totals them.
+func executeTranchedSwap(ctx workflow.Context, steps swapSteps, input SwapWorkflowInput, quote types.SwapQuote, state SwapWorkflowState) (*types.SwapResult, error) {
+	logger := workflow.GetLogger(ctx)
+	version := stepVersion(ctx, swapTrancheStep)
+
+	request := input.Request
+	options := *request.Tranches
+	amounts := options.Amounts(request.Amount)
+	fills := make([]types.TrancheFill, len(amounts))
+	for i, amount := range amounts {
+		fills[i] = types.TrancheFill{Index: i + 1, RequestID: trancheRequestID(state.RequestID, i+1), InputAmount: amount, Status: types.SwapStatusSkipped}
+	}
+	logger.Info("Executing swap in tranches", "requestID", state.RequestID, "tranches", len(fills), "delay", options.Delay)
+
+	// The tranches spend the allowance approved for the whole swap
+	if version >= 3 {
+		if err := approveSwapAllowance(ctx, steps, request); err != nil {
+			logger.Error("Failed to approve swap allowance", "error", err)
+			state.ErrorMessage = fmt.Sprintf("Failed to approve token: %v", err)
+			var appErr *temporal.ApplicationError
+			if errors.As(err, &appErr) {
+				state.ErrorCode = appErr.Type()
+			}
+			return tranchedResult(ctx, state, fills, nil), err
+		}
+	}
+
+	cancelled := workflow.GetSignalChannel(ctx, "cancel_swap")
+	var last *types.SwapResult
+	var executeErr error
+	for i := range fills {
+		if i > 0 && !waitForNextTranche(ctx, cancelled, options.Delay) {
+			state.ErrorMessage = fmt.Sprintf("Swap cancelled by user after %d of %d tranches", i, len(fills))
+			break
+		}
+
+		tranche := request
+		tranche.RequestID = fills[i].RequestID
+		tranche.Amount = fills[i].InputAmount
+		tranche.Tranches = nil
+		if request.MinOutputAmount != nil {
+			// Each tranche must deliver its share of the minimum the whole swap accepted
+			tranche.MinOutputAmount = new(big.Int).Mul(request.MinOutputAmount, tranche.Amount)
+			tranche.MinOutputAmount.Quo(tranche.MinOutputAmount, request.Amount)
+		}
+
+		result, err := executeTranche(ctx, steps, version, tranche, quote, &fills[i])
+		if fills[i].Status != types.SwapStatusCompleted {
+			logger.Info("Swap tranche failed, skipping the rest", "requestID", tranche.RequestID, "error", fills[i].ErrorMessage)
+			state.ErrorMessage = fmt.Sprintf("Tranche %d of %d failed: %s", i+1, len(fills), fills[i].ErrorMessage)
+			state.ErrorCode = fills[i].ErrorCode
+			executeErr = err
+			break
+		}
+		last = result
+	}
+
+	result := tranchedResult(ctx, state, fills, last)
+	if result.Status == types.SwapStatusFailed {
+		// Nothing was filled, so the swap fails as an unsplit one would
+		return result, executeErr
+	}
+	logger.Info("SwapWorkflow completed in tranches",
+		"requestID", state.RequestID,
+		"status", result.Status,
+		"inputAmount", result.InputAmount.String(),
+		"outputAmount", result.OutputAmount.String())
+	return result, nil
+}
+
+// executeTranche quotes a tranche, checks the price hasn't drifted past the slippage since the swap's confirmed
+// quote, and executes it, recording the outcome in fill. It returns the tranche's result, and the error of an
+// execution that failed, which fails the workflow when no tranche was filled.
+func executeTranche(ctx workflow.Context, steps swapSteps, version workflow.Version, tranche types.SwapRequest, confirmed types.SwapQuote, fill *types.TrancheFill) (*types.SwapResult, error) {
+	fail := func(message string, err error) {
+		fill.Status = types.SwapStatusFailed
+		fill.ErrorMessage = fmt.Sprintf("%s: %v", message, err)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) {
+			fill.ErrorCode = appErr.Type()
+		}
+	}
+
+	var quote types.SwapQuote
+	if err := steps.forChain(tranche.SourceToken.ChainID).execute(ctx, "CalculateSwapQuoteActivity", &quote, tranche); err != nil {
+		fail("Failed to quote tranche", err)
+		return nil, nil
+	}
+	fill.QuotedOutput = quote.OutputAmount
+	if err := steps.forChain(tranche.SourceToken.ChainID).execute(ctx, "CheckQuoteDriftActivity", nil, tranche, confirmed); err != nil {
+		fail("Price check failed", err)
+		return nil, nil
+	}
+
+	submittedAt := workflow.Now(ctx)
+	var result types.SwapResult
+	err := steps.budget.execute(ctx, steps.forChain(tranche.SourceToken.ChainID).onChainOptions(), "ExecuteSwapActivity", &result, tranche)
+	if err == nil && version >= 2 {
+		err = awaitBridgeTransfer(ctx, steps, tranche, quote, &result)
+	}
+	if quote.Bridge != "" {
+		recordBridgeOutcome(ctx, tranche.RequestID, quote, result, err, submittedAt)
+	}
+	if err != nil {
+		fail("Failed to execute tranche", err)
+		return nil, err
+	}
+
+	result.RequestID = tranche.RequestID
+	fill.Status = types.SwapStatusCompleted
+	fill.OutputAmount = result.OutputAmount
+	fill.Fee = &result.Fee
+	fill.TxHash = result.SourceTx.Hash
+	executedAt := workflow.Now(ctx)
+	fill.ExecutedAt = &executedAt
+	return &result, nil
+}
+
+// waitForNextTranche waits delay before the next tranche, reporting false when the user cancels the swap meanwhile
+func waitForNextTranche(ctx workflow.Context, cancelled workflow.ReceiveChannel, delay time.Duration) bool {
+	timer := workflow.NewTimer(ctx, delay)
+	for {
+		cancel, elapsed := false, false
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(cancelled, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &cancel)
+		})
+		selector.AddFuture(timer, func(f workflow.Future) {
+			elapsed = true
+		})
+		selector.Select(ctx)
+
+		if cancel {
+			return false
+		}
+		if elapsed {
+			return true
+		}
+	}
+}
+
+// tranchedResult totals the filled tranches of a swap. The swap completed when every tranche filled, was partially
+// filled when some did, and failed when none did. Its transactions are those of the last filled tranche.
+func tranchedResult(ctx workflow.Context, state SwapWorkflowState, fills []types.TrancheFill, last *types.SwapResult) *types.SwapResult {
+	result := &types.SwapResult{
+		RequestID:      state.RequestID,
+		InputAmount:    big.NewInt(0),
+		OutputAmount:   big.NewInt(0),
+		Fee:            types.Fee{GasFee: big.NewInt(0), ProtocolFee: big.NewInt(0), NetworkFee: big.NewInt(0), BridgeFee: big.NewInt(0)},
+		CompletionTime: workflow.Now(ctx),
+		ErrorMessage:   state.ErrorMessage,
+		ErrorCode:      state.ErrorCode,
+		Tranches:       fills,
+	}
+	if last != nil {
+		result.SourceTx = last.SourceTx
+		result.DestinationTx = last.DestinationTx
+		result.BridgeTx = last.BridgeTx
+	}
+
+	filled := 0
+	for _, fill := range fills {
+		if fill.Status != types.SwapStatusCompleted {
+			continue
+		}
+		filled++
+		result.InputAmount.Add(result.InputAmount, fill.InputAmount)
+		if fill.OutputAmount != nil {
+			result.OutputAmount.Add(result.OutputAmount, fill.OutputAmount)
+		}
+		if fill.Fee != nil {
+			addFee(&result.Fee, *fill.Fee)
+		}
+	}
+
+	switch filled {
+	case len(fills):
+		result.Success = true
+		result.Status = types.SwapStatusCompleted
+	case 0:
+		result.Status = types.SwapStatusFailed
+	default:
+		result.Success = true
+		result.Status = types.SwapStatusPartiallyFilled
+	}
+	return result
+}
+
+// addFee adds fee to total
+func addFee(total *types.Fee, fee types.Fee) {
+	for _, part := range []struct{ total, fee *big.Int }{
+		{total.GasFee, fee.GasFee},
+		{total.ProtocolFee, fee.ProtocolFee},
+		{total.NetworkFee, fee.NetworkFee},
+		{total.BridgeFee, fee.BridgeFee},
+	} {
+		if part.fee != nil {
+			part.total.Add(part.total, part.fee)
+		}
+	}
+	total.TotalFeeUSD += fee.TotalFeeUSD
+	total.GasFeeUSD += fee.GasFeeUSD
+	total.ProtocolFeeUSD += fee.ProtocolFeeUSD
+	total.NetworkFeeUSD += fee.NetworkFeeUSD
+	total.BridgeFeeUSD += fee.BridgeFeeUSD
+}
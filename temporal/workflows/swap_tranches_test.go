@@ -0,0 +1,168 @@
+package temporal_workflows
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// runTranchedSwap executes a confirmed tranched swap against activities that fill every tranche at a 2:1 rate,
+// except failing executions of the tranches in failing
+func runTranchedSwap(t *testing.T, request types.SwapRequest, failing map[string]bool, configure func(*testsuite.TestWorkflowEnvironment)) (*types.SwapResult, []types.SwapRequest, error) {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+
+	var executed []types.SwapRequest
+	env.RegisterActivityWithOptions(func(ctx context.Context, allowance types.AllowanceRequest) (*types.AllowanceResult, error) {
+		// The whole swap's allowance is approved once, before any tranche executes
+		if len(executed) > 0 || allowance.Amount.Cmp(request.Amount) != 0 {
+			t.Errorf("Expected the swap's %v approved before its tranches, got %v after %d", request.Amount, allowance.Amount, len(executed))
+		}
+		return &types.AllowanceResult{Status: types.AllowanceApproved}, nil
+	}, activity.RegisterOptions{Name: "CheckAndApproveAllowanceActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) (types.SwapQuote, error) {
+		return types.SwapQuote{InputAmount: request.Amount, OutputAmount: new(big.Int).Mul(request.Amount, big.NewInt(2))}, nil
+	}, activity.RegisterOptions{Name: "CalculateSwapQuoteActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest, quote types.SwapQuote) error {
+		return nil
+	}, activity.RegisterOptions{Name: "CheckQuoteDriftActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) (types.SwapResult, error) {
+		executed = append(executed, request)
+		if failing[request.RequestID] {
+			return types.SwapResult{}, temporal.NewNonRetryableApplicationError("reverted", "SWAP_REVERTED", nil)
+		}
+		return types.SwapResult{
+			RequestID:    request.RequestID,
+			Success:      true,
+			InputAmount:  request.Amount,
+			OutputAmount: new(big.Int).Mul(request.Amount, big.NewInt(2)),
+			Fee:          types.Fee{GasFee: big.NewInt(10), ProtocolFee: big.NewInt(1), NetworkFee: big.NewInt(0), BridgeFee: big.NewInt(0), TotalFeeUSD: 0.5},
+			SourceTx:     types.Transaction{Hash: "0x" + request.RequestID},
+		}, nil
+	}, activity.RegisterOptions{Name: "ExecuteSwapActivity"})
+	if configure != nil {
+		configure(env)
+	}
+
+	env.ExecuteWorkflow(func(ctx workflow.Context) (*types.SwapResult, error) {
+		steps := swapSteps{
+			options: workflow.ActivityOptions{StartToCloseTimeout: 30 * time.Second},
+			budget:  &retryBudget{},
+		}
+		input := SwapWorkflowInput{Request: request}
+		return executeConfirmedSwap(ctx, steps, input, types.SwapQuote{}, SwapWorkflowState{RequestID: request.RequestID})
+	})
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Workflow did not complete")
+	}
+	var result *types.SwapResult
+	err := env.GetWorkflowError()
+	if err == nil {
+		if err := env.GetWorkflowResult(&result); err != nil {
+			t.Fatalf("Failed to read result: %v", err)
+		}
+	}
+	return result, executed, err
+}
+
+func tranchedRequest() types.SwapRequest {
+	return types.SwapRequest{
+		RequestID:       "swap-1",
+		Amount:          big.NewInt(1000),
+		MinOutputAmount: big.NewInt(1800),
+		Tranches:        &types.TrancheOptions{Size: big.NewInt(400), Delay: time.Minute},
+	}
+}
+
+func TestTranchedSwap(t *testing.T) {
+	result, executed, err := runTranchedSwap(t, tranchedRequest(), nil, nil)
+	if err != nil {
+		t.Fatalf("Tranched swap failed: %v", err)
+	}
+
+	if len(executed) != 3 {
+		t.Fatalf("Expected 3 tranches executed, got %d", len(executed))
+	}
+	for i, amount := range []int64{400, 400, 200} {
+		if executed[i].Amount.Int64() != amount || executed[i].Tranches != nil {
+			t.Errorf("Tranche %d executed %v, expected %d on its own", i+1, executed[i].Amount, amount)
+		}
+		if minOutput := executed[i].MinOutputAmount.Int64(); minOutput != amount*18/10 {
+			t.Errorf("Tranche %d minimum output %d, expected its share %d", i+1, minOutput, amount*18/10)
+		}
+	}
+	if executed[1].RequestID != "swap-1-tranche-2" {
+		t.Errorf("Unexpected tranche request ID %s", executed[1].RequestID)
+	}
+
+	if result.Status != types.SwapStatusCompleted || !result.Success {
+		t.Errorf("Expected a completed swap, got %s", result.Status)
+	}
+	if result.InputAmount.Int64() != 1000 || result.OutputAmount.Int64() != 2000 {
+		t.Errorf("Expected 1000 in and 2000 out, got %v and %v", result.InputAmount, result.OutputAmount)
+	}
+	if result.Fee.GasFee.Int64() != 30 || result.Fee.TotalFeeUSD != 1.5 {
+		t.Errorf("Expected the tranches' fees totalled, got %v gas and $%.2f", result.Fee.GasFee, result.Fee.TotalFeeUSD)
+	}
+	if len(result.Tranches) != 3 || result.Tranches[2].QuotedOutput.Int64() != 400 || result.Tranches[2].ExecutedAt == nil {
+		t.Errorf("Expected each tranche's fill reported, got %+v", result.Tranches)
+	}
+	if result.SourceTx.Hash != "0xswap-1-tranche-3" {
+		t.Errorf("Expected the last tranche's transaction, got %s", result.SourceTx.Hash)
+	}
+}
+
+func TestTranchedSwapPartiallyFilled(t *testing.T) {
+	result, executed, err := runTranchedSwap(t, tranchedRequest(), map[string]bool{"swap-1-tranche-2": true}, nil)
+	if err != nil {
+		t.Fatalf("A partially filled swap should not fail its workflow: %v", err)
+	}
+
+	if len(executed) != 2 {
+		t.Errorf("Expected the tranches after the failed one skipped, got %d executed", len(executed))
+	}
+	if result.Status != types.SwapStatusPartiallyFilled || result.ErrorCode != "SWAP_REVERTED" {
+		t.Errorf("Expected a partially filled swap stopped by SWAP_REVERTED, got %s %s", result.Status, result.ErrorCode)
+	}
+	if result.InputAmount.Int64() != 400 || result.OutputAmount.Int64() != 800 {
+		t.Errorf("Expected only the first tranche totalled, got %v and %v", result.InputAmount, result.OutputAmount)
+	}
+	for i, status := range []types.SwapStatus{types.SwapStatusCompleted, types.SwapStatusFailed, types.SwapStatusSkipped} {
+		if result.Tranches[i].Status != status {
+			t.Errorf("Tranche %d is %s, expected %s", i+1, result.Tranches[i].Status, status)
+		}
+	}
+
+	// A swap whose first tranche fails fails like an unsplit swap
+	_, _, err = runTranchedSwap(t, tranchedRequest(), map[string]bool{"swap-1-tranche-1": true}, nil)
+	if err == nil {
+		t.Error("Expected the swap to fail when no tranche filled")
+	}
+}
+
+func TestTranchedSwapCancelled(t *testing.T) {
+	result, executed, err := runTranchedSwap(t, tranchedRequest(), nil, func(env *testsuite.TestWorkflowEnvironment) {
+		// Cancel while waiting for the second tranche
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow("cancel_swap", true)
+		}, 30*time.Second)
+	})
+	if err != nil {
+		t.Fatalf("Cancelled tranched swap failed: %v", err)
+	}
+
+	if len(executed) != 1 {
+		t.Errorf("Expected only the first tranche executed, got %d", len(executed))
+	}
+	if result.Status != types.SwapStatusPartiallyFilled || result.Tranches[1].Status != types.SwapStatusSkipped {
+		t.Errorf("Expected a partially filled swap with the rest skipped, got %s", result.Status)
+	}
+}
@@ -1,11 +1,14 @@
 package temporal_workflows
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
@@ -13,24 +16,247 @@ import (
 // SwapWorkflowInput represents the input for the swap workflow
 type SwapWorkflowInput struct {
 	Request types.SwapRequest
+
+	// Compliance is who requested the swap and from where; swaps without it skip policy evaluation
+	Compliance *types.ComplianceContext
+
+	// Retries are how the swap's activities are retried and its retry budget; swaps without them use the default
+	// policies and have no budget
+	Retries *types.RetryPolicies
+
+	// ChainQueues are the task queues of the swap's chain activities; swaps without them run every activity on the
+	// workflow's task queue
+	ChainQueues *types.ChainTaskQueues
 }
 
 // SwapWorkflowState represents the current state of the swap workflow
 type SwapWorkflowState struct {
-	RequestID    string
-	Quote        *types.SwapQuote
-	Status       string
-	ErrorMessage string
-	Timestamp    time.Time
+	RequestID      string
+	Quote          *types.SwapQuote
+	Status         string
+	ErrorMessage   string
+	ErrorCode      string
+	Timestamp      time.Time
+	PolicyDecision *types.PolicyDecision
+	Deposit        *types.Deposit
+}
+
+// PolicyReviewSignal is the signal operators send to resolve a swap held for policy review
+const PolicyReviewSignal = "policy_review"
+
+// policyReviewTimeout is how long a swap waits for a policy review before it is rejected
+const policyReviewTimeout = 24 * time.Hour
+
+// DepositReceivedSignal is the signal the deposit watcher sends with a swap's deposit once it is confirmed on-chain
+const DepositReceivedSignal = "deposit_received"
+
+// DepositTimeout is how long a deposit-funded swap waits for its deposit
+const DepositTimeout = time.Hour
+
+// depositFundedSwapChange versions the wait for a deposit in place of the confirmation signal
+const depositFundedSwapChange = "deposit-funded-swap"
+
+// bridgeOutcomeChange versions recording each cross-chain swap's bridge outcome once the swap settles
+const bridgeOutcomeChange = "bridge-outcome"
+
+// bridgeWatchChange versions watching a cross-chain swap's bridge transfer through the SDK instead of polling it
+const bridgeWatchChange = "bridge-watch"
+
+// quoteDriftCheckChange versions checking the price of a confirmed swap against its quote before executing it
+const quoteDriftCheckChange = "quote-drift-check"
+
+// firmQuoteChange versions executing swaps started from a firm quote without quoting or confirming them again
+const firmQuoteChange = "firm-quote"
+
+// archiveSwapChange versions archiving each swap once its workflow finishes
+const archiveSwapChange = "archive-swap"
+
+// swapCallbackChange versions POSTing each finished swap's result to its callback URL
+const swapCallbackChange = "swap-callback"
+
+// swapLimitsChange versions booking each swap's value against the swap limits before it is quoted or executed
+const swapLimitsChange = "swap-limits"
+
+// swapLimitsReleaseChange versions releasing the swap limits booking of each swap that stopped before moving funds
+const swapLimitsReleaseChange = "swap-limits-release"
+
+// balanceCheckChange versions checking the source address can pay for a swap before anything is quoted or executed
+const balanceCheckChange = "balance-check"
+
+// OnChainActivityTimeout bounds activities that may send a transaction and wait for it to be mined. It must exceed
+// the execution receipt timeout, so an activity still waiting for its transaction is not retried.
+const OnChainActivityTimeout = 5 * time.Minute
+
+// onChainHeartbeatTimeout is how long an activity waiting for its transaction may go without heartbeating before it
+// is retried on another worker, which rebroadcasts the stored transaction
+const onChainHeartbeatTimeout = 30 * time.Second
+
+// swapSettlementFailed is the error type ExecuteSwapActivity fails with when a submitted swap fails to settle
+const swapSettlementFailed = "SWAP_SETTLEMENT_FAILED"
+
+// A cross-chain swap's bridge transfer is polled from bridgeStatusInitialInterval apart, doubling up to
+// bridgeStatusMaxInterval, for bridgeStatusTimeoutFactor times the time its bridge is expected to take, though no
+// less than bridgeStatusMinTimeout
+const (
+	bridgeStatusInitialInterval = 5 * time.Second
+	bridgeStatusMaxInterval     = 2 * time.Minute
+	bridgeStatusTimeoutFactor   = 3
+	bridgeStatusMinTimeout      = 10 * time.Minute
+)
+
+// BridgeTransferTimeout is the error type of a cross-chain swap whose bridge transfer was still pending when polling
+// it timed out
+const BridgeTransferTimeout = "BRIDGE_TRANSFER_TIMEOUT"
+
+// swapSteps runs a swap's activities with its retry policy, within its retry budget
+type swapSteps struct {
+	options workflow.ActivityOptions
+	budget  *retryBudget
+	queues  *types.ChainTaskQueues
+}
+
+// execute runs one of the swap's activities
+func (s swapSteps) execute(ctx workflow.Context, activity string, result interface{}, args ...interface{}) error {
+	return s.budget.execute(ctx, s.options, activity, result, args...)
+}
+
+// onChainOptions are the options of activities that may send a transaction and wait for it to be mined
+func (s swapSteps) onChainOptions() workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		TaskQueue:           s.options.TaskQueue,
+		StartToCloseTimeout: OnChainActivityTimeout,
+		HeartbeatTimeout:    onChainHeartbeatTimeout,
+		RetryPolicy:         s.options.RetryPolicy,
+	}
+}
+
+// forChain returns the steps running activities that call a chain, on the chain's task queue when it has its own
+func (s swapSteps) forChain(chainID int64) swapSteps {
+	if queue := s.queues.Queue(chainID); queue != "" {
+		s.options.TaskQueue = queue
+	}
+	return s
+}
+
+// PolicyReview is an operator's resolution of a swap held for policy review
+type PolicyReview struct {
+	Approved bool
+	Operator string
+	Reason   string
 }
 
 // SwapWorkflow is the workflow definition for executing token swaps
 // It orchestrates the following steps:
 // 1. Calculate and return a quote for the swap
-// 2. Wait for user confirmation
-// 3. Execute the swap with a timeout
-// 4. Return the result
+// 2. Wait for user confirmation, or for the deposit funding the swap
+// 3. Check the price has not moved past the user's slippage since the quote
+// 4. Execute the swap with a timeout, or each of its tranches in turn, quoted and checked again
+// 5. Archive and return the result, POSTing it to the swap's callback URL when it has one
+// Fast path swaps skip steps 1 to 4 and are quoted and executed in one local activity.
+// Swaps started from a firm quote skip steps 1 to 3 and execute at no worse than the quote until it expires.
+// Dry runs evaluate the policy and then project the swap's result instead of steps 1 to 5.
+// Other swaps are first booked against the swap limits, failing with LIMIT_EXCEEDED when they are worth more than the
+// limits allow, and check their source address holds their amount and gas, failing with INSUFFICIENT_BALANCE when it
+// does not. Swaps failing before any of them executes release their booking.
 func SwapWorkflow(ctx workflow.Context, input SwapWorkflowInput) (*types.SwapResult, error) {
+	startedAt := workflow.Now(ctx)
+	result, err := executeSwapWorkflow(ctx, input)
+	if result != nil && !input.Request.DryRun && workflow.GetVersion(ctx, archiveSwapChange, workflow.DefaultVersion, 1) == 1 {
+		archiveSwap(ctx, input.Request, *result, startedAt)
+	}
+	if result != nil && !input.Request.DryRun && input.Request.CallbackURL != "" && workflow.GetVersion(ctx, swapCallbackChange, workflow.DefaultVersion, 1) == 1 {
+		notifyCallback(ctx, input.Request, *result)
+	}
+	if err == nil && result != nil && !input.Request.DryRun && movedNothing(*result) && workflow.GetVersion(ctx, swapLimitsReleaseChange, workflow.DefaultVersion, 1) == 1 {
+		releaseSwapLimits(ctx, result.RequestID)
+	}
+	return result, err
+}
+
+// movedNothing reports whether a swap failed before any of it executed: it was refused, cancelled or left
+// unconfirmed. Swaps whose execution failed return an error too, as their funds may be in flight.
+func movedNothing(result types.SwapResult) bool {
+	if result.Status != types.SwapStatusFailed || result.SourceTx.Hash != "" {
+		return false
+	}
+	for _, tranche := range result.Tranches {
+		if tranche.TxHash != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseSwapLimits deletes the swap limits booking of a swap that moved nothing, so it counts against no limit. It
+// runs even when the workflow was cancelled, and a failure to release does not fail the swap.
+func releaseSwapLimits(ctx workflow.Context, requestID string) {
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    10,
+		},
+	})
+
+	if err := workflow.ExecuteActivity(ctx, "ReleaseSwapLimitsActivity", requestID).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Failed to release swap limits", "requestID", requestID, "error", err)
+	}
+}
+
+// notifyCallback POSTs the finished swap's result to its callback URL, making up to 10 delivery attempts with
+// backoff. It runs even when the workflow was cancelled, and a failure to deliver does not fail the swap.
+func notifyCallback(ctx workflow.Context, request types.SwapRequest, result types.SwapResult) {
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 15 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    2 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    15 * time.Minute,
+			MaximumAttempts:    10,
+		},
+	})
+
+	if err := workflow.ExecuteActivity(ctx, "NotifyWebhookActivity", request, result).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Failed to send swap callback", "requestID", result.RequestID, "error", err)
+	}
+}
+
+// archiveSwap copies the finished swap out of Temporal so it can be looked up after history retention deletes it.
+// It runs even when the workflow was cancelled, and a failure to archive does not fail the swap.
+func archiveSwap(ctx workflow.Context, request types.SwapRequest, result types.SwapResult, startedAt time.Time) {
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    10,
+		},
+	})
+
+	request.RequestID = result.RequestID
+	swap := types.ArchivedSwap{
+		RequestID: result.RequestID,
+		Request:   request,
+		Result:    result,
+		StartedAt: startedAt,
+		ClosedAt:  workflow.Now(ctx),
+	}
+	if err := workflow.ExecuteActivity(ctx, "ArchiveSwapActivity", swap).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Failed to archive swap", "requestID", result.RequestID, "error", err)
+	}
+}
+
+// executeSwapWorkflow runs a swap from policy evaluation to its result
+func executeSwapWorkflow(ctx workflow.Context, input SwapWorkflowInput) (*types.SwapResult, error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("SwapWorkflow started", "sourceToken", input.Request.SourceToken.Symbol, "destToken", input.Request.DestinationToken.Symbol)
 
@@ -47,19 +273,72 @@ func SwapWorkflow(ctx workflow.Context, input SwapWorkflowInput) (*types.SwapRes
 		input.Request.RequestID = state.RequestID
 	}
 
-	// Define retry policy for activities
-	options := workflow.ActivityOptions{
-		StartToCloseTimeout: 30 * time.Second,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    10 * time.Second,
-			MaximumAttempts:    3,
+	// Define retry policy for activities, bounded across the swap by its retry budget
+	policies := retryPolicies(input.Retries)
+	steps := swapSteps{
+		options: workflow.ActivityOptions{
+			StartToCloseTimeout: 30 * time.Second,
+			RetryPolicy:         retryPolicy(policies.Swap),
 		},
+		budget: &retryBudget{limit: policies.Budget},
+		queues: input.ChainQueues,
+	}
+	ctx = workflow.WithActivityOptions(ctx, steps.options)
+
+	// Step 0: Evaluate the tenant's KYC/AML policy before anything else
+	if input.Compliance != nil {
+		stepVersion(ctx, swapPolicyStep)
+		if !evaluateSwapPolicy(ctx, steps, input, &state) {
+			logger.Info("Swap stopped by policy", "status", state.Status, "error", state.ErrorMessage)
+			return createFailedResult(state), nil
+		}
+	}
+
+	// Nothing is confirmed, funded or executed for a dry run
+	if input.Request.DryRun {
+		return simulateSwap(ctx, steps, input.Request, state)
+	}
+
+	// Refuse swaps worth more than the swap limits allow
+	if workflow.GetVersion(ctx, swapLimitsChange, workflow.DefaultVersion, 1) == 1 {
+		if err := steps.execute(ctx, "CheckSwapLimitsActivity", nil, input.Request); err != nil {
+			logger.Info("Swap stopped by swap limits", "error", err)
+			state.Status = "failed"
+			state.ErrorMessage = fmt.Sprintf("Swap limit check failed: %v", err)
+			var appErr *temporal.ApplicationError
+			if errors.As(err, &appErr) {
+				state.ErrorCode = appErr.Type()
+			}
+			return createFailedResult(state), nil
+		}
+	}
+
+	// Fail fast when the source address can't pay the swap's amount and gas, rather than midway through the wrap
+	if workflow.GetVersion(ctx, balanceCheckChange, workflow.DefaultVersion, 1) == 1 {
+		if err := steps.forChain(input.Request.SourceToken.ChainID).execute(ctx, "CheckBalanceActivity", nil, input.Request); err != nil {
+			logger.Info("Swap stopped by balance check", "error", err)
+			state.Status = "failed"
+			state.ErrorMessage = fmt.Sprintf("Balance check failed: %v", err)
+			var appErr *temporal.ApplicationError
+			if errors.As(err, &appErr) {
+				state.ErrorCode = appErr.Type()
+			}
+			return createFailedResult(state), nil
+		}
+	}
+
+	// The caller already accepted a firm quote, so there is nothing to quote or confirm
+	if input.Request.FirmQuote != nil && workflow.GetVersion(ctx, firmQuoteChange, workflow.DefaultVersion, 1) == 1 {
+		return executeFirmQuoteSwap(ctx, steps, input, state)
+	}
+
+	// The caller already accepted a minimum output, so there is nothing to confirm
+	if input.Request.IsFastPath() {
+		return executeFastPathSwap(ctx, steps, input.Request, state)
 	}
-	ctx = workflow.WithActivityOptions(ctx, options)
 
 	// Step 1: Calculate swap quote
+	stepVersion(ctx, swapQuoteStep)
 	var quote types.SwapQuote
 	// err := workflow.ExecuteActivity(ctx, "CalculateFeeActivity", input.Request).Get(ctx, &quote.Fee)
 	// if err != nil {
@@ -70,7 +349,7 @@ func SwapWorkflow(ctx workflow.Context, input SwapWorkflowInput) (*types.SwapRes
 	// }
 
 	// Calculate output amount and other quote details
-	err := workflow.ExecuteActivity(ctx, "CalculateSwapQuoteActivity", input.Request).Get(ctx, &quote)
+	err := steps.forChain(input.Request.SourceToken.ChainID).execute(ctx, "CalculateSwapQuoteActivity", &quote, input.Request)
 	if err != nil {
 		logger.Error("Failed to calculate swap quote", "error", err)
 		state.Status = "failed"
@@ -82,6 +361,20 @@ func SwapWorkflow(ctx workflow.Context, input SwapWorkflowInput) (*types.SwapRes
 	state.Quote = &quote
 	state.Status = "quote_ready"
 
+	// Step 2: Wait for the deposit or the user's confirmation
+	stepVersion(ctx, swapConfirmStep)
+
+	// Deposit-funded swaps are confirmed by their deposit arriving
+	if input.Request.DepositAddress != "" && workflow.GetVersion(ctx, depositFundedSwapChange, workflow.DefaultVersion, 1) == 1 {
+		if !waitForDeposit(ctx, &state) {
+			logger.Info("Swap deposit not received", "status", state.Status)
+			return createFailedResult(state), nil
+		}
+		// The swap spends the deposit, which the execution checks covers it
+		input.Request.DepositedAmount = state.Deposit.Amount
+		return executeConfirmedSwap(ctx, steps, input, quote, state)
+	}
+
 	// Create a channel to receive the confirmation signal
 	confirmationSignal := workflow.GetSignalChannel(ctx, "confirm_swap")
 	cancelSignal := workflow.GetSignalChannel(ctx, "cancel_swap")
@@ -124,21 +417,74 @@ func SwapWorkflow(ctx workflow.Context, input SwapWorkflowInput) (*types.SwapRes
 		return createFailedResult(state), nil
 	}
 
-	// Step 3: Execute the swap with a timeout
-	activityOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: 30 * time.Second,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    10 * time.Second,
-			MaximumAttempts:    3,
-		},
+	return executeConfirmedSwap(ctx, steps, input, quote, state)
+}
+
+// executeFirmQuoteSwap executes a swap at no worse than its firm quote, failing with QUOTE_EXPIRED once the quote has
+// expired. The quote's output becomes the swap's minimum output, so the price isn't checked for drift.
+func executeFirmQuoteSwap(ctx workflow.Context, steps swapSteps, input SwapWorkflowInput, state SwapWorkflowState) (*types.SwapResult, error) {
+	quote := *input.Request.FirmQuote
+	state.Quote = &quote
+	if !workflow.Now(ctx).Before(quote.ExpiresAt) {
+		workflow.GetLogger(ctx).Info("Swap quote expired", "quoteID", quote.QuoteID, "expiresAt", quote.ExpiresAt)
+		state.Status = "failed"
+		state.ErrorCode = types.SwapErrorQuoteExpired
+		state.ErrorMessage = fmt.Sprintf("Quote %s expired at %s", quote.QuoteID, quote.ExpiresAt.Format(time.RFC3339))
+		return createFailedResult(state), nil
+	}
+
+	if input.Request.MinOutputAmount == nil || input.Request.MinOutputAmount.Cmp(quote.OutputAmount) < 0 {
+		input.Request.MinOutputAmount = quote.OutputAmount
+	}
+	state.Status = "confirmed"
+	return executeConfirmedSwap(ctx, steps, input, quote, state)
+}
+
+// executeConfirmedSwap checks the price of a confirmed swap has not drifted from its quote and executes it. Swaps
+// split into tranches check and execute each tranche in turn instead.
+func executeConfirmedSwap(ctx workflow.Context, steps swapSteps, input SwapWorkflowInput, quote types.SwapQuote, state SwapWorkflowState) (*types.SwapResult, error) {
+	logger := workflow.GetLogger(ctx)
+	if input.Request.Tranches != nil {
+		return executeTranchedSwap(ctx, steps, input, quote, state)
+	}
+
+	// Step 3: Stop before submitting anything if the price moved against the user since the quote. A firm quote's
+	// output is guaranteed instead.
+	if input.Request.FirmQuote == nil && workflow.GetVersion(ctx, quoteDriftCheckChange, workflow.DefaultVersion, 1) == 1 {
+		if err := steps.forChain(input.Request.SourceToken.ChainID).execute(ctx, "CheckQuoteDriftActivity", nil, input.Request, quote); err != nil {
+			logger.Info("Swap stopped by quote drift check", "error", err)
+			state.Status = "failed"
+			state.ErrorMessage = fmt.Sprintf("Price check failed: %v", err)
+			var appErr *temporal.ApplicationError
+			if errors.As(err, &appErr) {
+				state.ErrorCode = appErr.Type()
+			}
+			return createFailedResult(state), nil
+		}
+	}
+
+	// Step 4: Execute the swap with a timeout
+	executeVersion := stepVersion(ctx, swapExecuteStep)
+
+	if executeVersion >= 3 {
+		if err := approveSwapAllowance(ctx, steps, input.Request); err != nil {
+			logger.Error("Failed to approve swap allowance", "error", err)
+			state.Status = "failed"
+			state.ErrorMessage = fmt.Sprintf("Failed to approve token: %v", err)
+			return createFailedResult(state), err
+		}
 	}
-	swapCtx := workflow.WithActivityOptions(ctx, activityOptions)
 
 	// Execute the swap
+	submittedAt := workflow.Now(ctx)
 	var result types.SwapResult
-	err = workflow.ExecuteActivity(swapCtx, "ExecuteSwapActivity", input.Request).Get(ctx, &result)
+	err := steps.budget.execute(ctx, steps.forChain(input.Request.SourceToken.ChainID).onChainOptions(), "ExecuteSwapActivity", &result, input.Request)
+	if err == nil && executeVersion >= 2 {
+		err = awaitBridgeTransfer(ctx, steps, input.Request, quote, &result)
+	}
+	if quote.Bridge != "" && workflow.GetVersion(ctx, bridgeOutcomeChange, workflow.DefaultVersion, 1) == 1 {
+		recordBridgeOutcome(ctx, state.RequestID, quote, result, err, submittedAt)
+	}
 	if err != nil {
 		logger.Error("Failed to execute swap", "error", err)
 		state.Status = "failed"
@@ -159,12 +505,287 @@ func SwapWorkflow(ctx workflow.Context, input SwapWorkflowInput) (*types.SwapRes
 	return &result, nil
 }
 
+// recordBridgeOutcome records how a cross-chain swap's bridge transfer went, timed from submission to settlement.
+// Swaps that failed before reaching the bridge, or timed out still in flight, record nothing, and a failure to
+// record does not fail the swap.
+func recordBridgeOutcome(ctx workflow.Context, requestID string, quote types.SwapQuote, result types.SwapResult, err error, submittedAt time.Time) {
+	outcome := types.BridgeOutcome{
+		RequestID: requestID,
+		Bridge:    quote.Bridge,
+		QuotedFee: quote.Fee.BridgeFee,
+		At:        workflow.Now(ctx),
+	}
+	outcome.Duration = outcome.At.Sub(submittedAt)
+
+	var appErr *temporal.ApplicationError
+	switch {
+	case err == nil:
+		outcome.Success = result.Success
+		outcome.ActualFee = result.Fee.BridgeFee
+	case errors.As(err, &appErr) && appErr.Type() == swapSettlementFailed:
+		// The swap failed on its way through the bridge, which charged nothing
+	default:
+		return
+	}
+
+	if err := workflow.ExecuteActivity(ctx, "RecordBridgeOutcomeActivity", outcome).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Error("Failed to record bridge outcome", "requestID", requestID, "error", err)
+	}
+}
+
+// awaitBridgeTransfer waits for the bridge to deliver a cross-chain swap that ExecuteSwapActivity returned pending,
+// watching the transfer's status through WatchBridgeTransferActivity. The swap completes when the bridge reports the
+// transfer completed, and fails when it reports it failed or it is still pending after the bridge's expected time,
+// several times over. Swaps that aren't pending return at once. Swaps started before transfers were watched poll
+// their status with exponential backoff instead.
+func awaitBridgeTransfer(ctx workflow.Context, steps swapSteps, request types.SwapRequest, quote types.SwapQuote, result *types.SwapResult) error {
+	if result.Status != types.SwapStatusPending {
+		return nil
+	}
+	logger := workflow.GetLogger(ctx)
+
+	timeout := max(quote.BridgeTime*bridgeStatusTimeoutFactor, bridgeStatusMinTimeout)
+	deadline := workflow.Now(ctx).Add(timeout)
+	interval := bridgeStatusInitialInterval
+	// The bridge delivers on the destination chain; sticky routing polls it from the source chain's queue instead
+	chainID := request.DestinationToken.ChainID
+	if steps.queues != nil && steps.queues.Sticky {
+		chainID = request.SourceToken.ChainID
+	}
+	polling := steps.forChain(chainID)
+	watch := workflow.GetVersion(ctx, bridgeWatchChange, workflow.DefaultVersion, 1) == 1
+	for {
+		var status universalsdk.TransactionStatus
+		var err error
+		if watch {
+			// The watch returns before the deadline only with a final status, or when it fails
+			options := polling.options
+			options.StartToCloseTimeout = deadline.Sub(workflow.Now(ctx)) + onChainHeartbeatTimeout
+			options.HeartbeatTimeout = onChainHeartbeatTimeout
+			err = polling.budget.execute(ctx, options, "WatchBridgeTransferActivity", &status, request.RequestID, deadline)
+		} else {
+			err = polling.execute(ctx, "BridgeTransferStatusActivity", &status, request.RequestID)
+		}
+		switch {
+		case err != nil:
+			// The transfer is in flight whatever its status lookups do, so keep polling until the deadline
+			logger.Error("Failed to get bridge transfer status", "requestID", request.RequestID, "error", err)
+		case status.Status == "completed":
+			completeBridgeTransfer(request, status, result)
+			logger.Info("Bridge transfer completed", "requestID", request.RequestID, "bridgeTx", status.BridgeTxHash)
+			return nil
+		case status.Status == "failed":
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("Bridge transfer failed: %s", status.ErrorMessage), swapSettlementFailed, nil)
+		}
+
+		remaining := deadline.Sub(workflow.Now(ctx))
+		if remaining <= 0 {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("Bridge transfer still pending after %s", timeout), BridgeTransferTimeout, nil)
+		}
+		if err := workflow.Sleep(ctx, min(interval, remaining)); err != nil {
+			return err
+		}
+		interval = min(interval*2, bridgeStatusMaxInterval)
+	}
+}
+
+// completeBridgeTransfer marks a pending cross-chain swap's result completed with the bridge's transactions
+func completeBridgeTransfer(request types.SwapRequest, status universalsdk.TransactionStatus, result *types.SwapResult) {
+	result.Success = true
+	result.Status = types.SwapStatusCompleted
+	result.ErrorMessage = ""
+	result.BridgeTx = types.Transaction{
+		Type:        "bridge",
+		Hash:        status.BridgeTxHash,
+		Status:      "completed",
+		SourceChain: request.SourceToken.ChainName,
+		DestChain:   request.DestinationToken.ChainName,
+		SourceToken: request.SourceToken,
+		DestToken:   request.DestinationToken,
+		Amount:      request.Amount,
+		Timestamp:   status.CompletionTime,
+	}
+	if status.DestTxHash != "" {
+		result.DestinationTx.Hash = status.DestTxHash
+	}
+	result.DestinationTx.Status = "completed"
+	if !status.CompletionTime.IsZero() {
+		result.CompletionTime = status.CompletionTime
+	}
+}
+
+// executeFastPathSwap quotes and executes a swap in one local activity, which runs on the workflow
+// worker without a round trip through the task queue
+func executeFastPathSwap(ctx workflow.Context, steps swapSteps, request types.SwapRequest, state SwapWorkflowState) (*types.SwapResult, error) {
+	logger := workflow.GetLogger(ctx)
+	if stepVersion(ctx, swapFastPathStep) >= 2 {
+		if err := approveSwapAllowance(ctx, steps, request); err != nil {
+			logger.Error("Failed to approve fast path swap allowance", "error", err)
+			state.Status = "failed"
+			state.ErrorMessage = fmt.Sprintf("Failed to approve token: %v", err)
+			return createFailedResult(state), err
+		}
+	}
+
+	ctx = workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+		StartToCloseTimeout: 5 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    100 * time.Millisecond,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Second,
+			MaximumAttempts:    3,
+		},
+	})
+
+	var result types.SwapResult
+	if err := workflow.ExecuteLocalActivity(ctx, "FastSwapActivity", request).Get(ctx, &result); err != nil {
+		logger.Error("Failed to execute fast path swap", "error", err)
+		state.Status = "failed"
+		state.ErrorMessage = fmt.Sprintf("Failed to execute swap: %v", err)
+		return createFailedResult(state), err
+	}
+	result.RequestID = state.RequestID
+
+	logger.Info("SwapWorkflow completed on the fast path",
+		"requestID", state.RequestID,
+		"sourceToken", request.SourceToken.Symbol,
+		"destToken", request.DestinationToken.Symbol,
+		"inputAmount", request.Amount.String(),
+		"outputAmount", result.OutputAmount.String())
+
+	return &result, nil
+}
+
+// simulateSwap projects the result of a dry-run swap. A swap that would fail returns a failed result carrying
+// why, rather than failing the workflow.
+func simulateSwap(ctx workflow.Context, steps swapSteps, request types.SwapRequest, state SwapWorkflowState) (*types.SwapResult, error) {
+	stepVersion(ctx, swapSimulateStep)
+	var result types.SwapResult
+	if err := steps.forChain(request.SourceToken.ChainID).execute(ctx, "SimulateSwapActivity", &result, request); err != nil {
+		workflow.GetLogger(ctx).Info("Simulated swap would fail", "requestID", state.RequestID, "error", err)
+		state.Status = "failed"
+		state.ErrorMessage = err.Error()
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) {
+			state.ErrorMessage = appErr.Error()
+			state.ErrorCode = appErr.Type()
+		}
+		return createFailedResult(state), nil
+	}
+	result.RequestID = state.RequestID
+	return &result, nil
+}
+
+// waitForDeposit waits for the deposit funding the swap, or until the user cancels or the deposit times out.
+// It reports whether the deposit arrived; otherwise state records why not.
+func waitForDeposit(ctx workflow.Context, state *SwapWorkflowState) bool {
+	state.Status = "awaiting_deposit"
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(workflow.GetSignalChannel(ctx, DepositReceivedSignal), func(c workflow.ReceiveChannel, more bool) {
+		var deposit types.Deposit
+		c.Receive(ctx, &deposit)
+		state.Deposit = &deposit
+		state.Status = "confirmed"
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, "cancel_swap"), func(c workflow.ReceiveChannel, more bool) {
+		var cancelled bool
+		c.Receive(ctx, &cancelled)
+		if cancelled {
+			state.Status = "cancelled"
+			state.ErrorMessage = "Swap cancelled by user"
+		}
+	})
+	selector.AddFuture(workflow.NewTimer(ctx, DepositTimeout), func(f workflow.Future) {
+		state.Status = "timeout"
+		state.ErrorMessage = "Deposit not received"
+	})
+	selector.Select(ctx)
+
+	if state.Deposit != nil {
+		workflow.GetLogger(ctx).Info("Swap deposit received", "requestID", state.RequestID, "txHash", state.Deposit.TxHash)
+	}
+	return state.Status == "confirmed"
+}
+
+// evaluateSwapPolicy applies the policy decision for the swap, holding it for review when required.
+// It reports whether the swap may proceed; otherwise state records why not.
+func evaluateSwapPolicy(ctx workflow.Context, steps swapSteps, input SwapWorkflowInput, state *SwapWorkflowState) bool {
+	logger := workflow.GetLogger(ctx)
+
+	var decision types.PolicyDecision
+	if err := steps.execute(ctx, "EvaluateSwapPolicyActivity", &decision, input.Request, *input.Compliance); err != nil {
+		// Fail closed: a swap whose policy cannot be evaluated does not run
+		logger.Error("Failed to evaluate swap policy", "error", err)
+		state.Status = "failed"
+		state.ErrorMessage = fmt.Sprintf("Failed to evaluate policy: %v", err)
+		return false
+	}
+	state.PolicyDecision = &decision
+
+	switch decision.Outcome {
+	case types.PolicyAllow:
+		return true
+
+	case types.PolicyReview:
+		if input.Request.DryRun {
+			state.Status = "failed"
+			state.ErrorMessage = fmt.Sprintf("Swap would be held for policy review: %s", policyReasons(decision))
+			return false
+		}
+		state.Status = "pending_review"
+		logger.Info("Swap held for policy review", "requestID", state.RequestID, "reasons", decision.Reasons)
+
+		var review PolicyReview
+		reviewed := false
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(workflow.GetSignalChannel(ctx, PolicyReviewSignal), func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &review)
+			reviewed = true
+		})
+		selector.AddFuture(workflow.NewTimer(ctx, policyReviewTimeout), func(f workflow.Future) {})
+		selector.Select(ctx)
+
+		if !reviewed {
+			state.Status = "rejected"
+			state.ErrorMessage = "Policy review timed out"
+			return false
+		}
+		logger.Info("Swap policy review resolved", "requestID", state.RequestID, "approved", review.Approved, "operator", review.Operator)
+		if !review.Approved {
+			state.Status = "rejected"
+			state.ErrorMessage = fmt.Sprintf("Swap rejected in policy review: %s", review.Reason)
+			return false
+		}
+		state.Status = "initiated"
+		return true
+
+	default:
+		state.Status = "denied"
+		state.ErrorMessage = fmt.Sprintf("Swap denied by policy: %s", policyReasons(decision))
+		return false
+	}
+}
+
+// policyReasons joins the messages of a decision's reasons
+func policyReasons(decision types.PolicyDecision) string {
+	messages := make([]string, 0, len(decision.Reasons))
+	for _, reason := range decision.Reasons {
+		messages = append(messages, reason.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
 // Helper function to create a failed result
 func createFailedResult(state SwapWorkflowState) *types.SwapResult {
 	return &types.SwapResult{
 		RequestID:      state.RequestID,
 		Success:        false,
+		Status:         types.SwapStatusFailed,
 		ErrorMessage:   state.ErrorMessage,
+		ErrorCode:      state.ErrorCode,
 		CompletionTime: state.Timestamp,
 	}
 }
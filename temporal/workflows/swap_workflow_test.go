@@ -0,0 +1,375 @@
+package temporal_workflows
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+	"github.com/infinity-dex/universalsdk"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// runBridgeTransfer waits for a pending cross-chain swap whose bridge reports statuses, one per poll or watch,
+// repeating the last. Swaps started before transfers were watched poll them when watch is false. It returns the
+// swap's result, the polls' times since the start and the error awaiting failed with.
+func runBridgeTransfer(t *testing.T, watch bool, bridgeTime time.Duration, statuses ...string) (types.SwapResult, []time.Duration, error) {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+
+	start := env.Now()
+	var polls []time.Duration
+	status := func(requestID string) (*universalsdk.TransactionStatus, error) {
+		polls = append(polls, env.Now().Sub(start))
+		status := statuses[min(len(polls), len(statuses))-1]
+		if status == "" {
+			return nil, errors.New("universal unavailable")
+		}
+		return &universalsdk.TransactionStatus{
+			TransactionID: requestID,
+			Status:        status,
+			BridgeTxHash:  "0xbridge",
+			DestTxHash:    "0xdest",
+			ErrorMessage:  "relayer reverted",
+		}, nil
+	}
+	if watch {
+		env.RegisterActivityWithOptions(func(ctx context.Context, requestID string, deadline time.Time) (*universalsdk.TransactionStatus, error) {
+			if expected := start.Add(max(bridgeTime*bridgeStatusTimeoutFactor, bridgeStatusMinTimeout)); !deadline.Equal(expected) {
+				t.Errorf("Expected the watch to end at %s, got %s", expected, deadline)
+			}
+			if info := activity.GetInfo(ctx); info.HeartbeatTimeout != onChainHeartbeatTimeout {
+				t.Errorf("Expected a %s heartbeat timeout, got %s", onChainHeartbeatTimeout, info.HeartbeatTimeout)
+			}
+			return status(requestID)
+		}, activity.RegisterOptions{Name: "WatchBridgeTransferActivity"})
+	} else {
+		env.OnGetVersion(bridgeWatchChange, workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+		env.RegisterActivityWithOptions(func(ctx context.Context, requestID string) (*universalsdk.TransactionStatus, error) {
+			return status(requestID)
+		}, activity.RegisterOptions{Name: "BridgeTransferStatusActivity"})
+	}
+
+	request := types.SwapRequest{
+		RequestID:        "swap-1",
+		SourceToken:      types.Token{Symbol: "USDC", ChainID: types.ChainIDEthereum, ChainName: "Ethereum"},
+		DestinationToken: types.Token{Symbol: "USDC", ChainID: types.ChainIDPolygon, ChainName: "Polygon"},
+	}
+	quote := types.SwapQuote{Bridge: "universal", BridgeTime: bridgeTime}
+	env.ExecuteWorkflow(func(ctx workflow.Context) (types.SwapResult, error) {
+		steps := swapSteps{
+			options: workflow.ActivityOptions{
+				StartToCloseTimeout: 30 * time.Second,
+				RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+			},
+			budget: &retryBudget{},
+		}
+		result := types.SwapResult{RequestID: request.RequestID, Status: types.SwapStatusPending, ErrorMessage: "Swap in progress"}
+		err := awaitBridgeTransfer(ctx, steps, request, quote, &result)
+		return result, err
+	})
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Workflow did not complete")
+	}
+	var result types.SwapResult
+	err := env.GetWorkflowError()
+	if err == nil {
+		if err := env.GetWorkflowResult(&result); err != nil {
+			t.Fatalf("Failed to read result: %v", err)
+		}
+	}
+	return result, polls, err
+}
+
+func TestAwaitBridgeTransfer(t *testing.T) {
+	result, polls, err := runBridgeTransfer(t, false, 10*time.Minute, "pending", "", "pending", "completed")
+	if err != nil {
+		t.Fatalf("Bridge transfer failed: %v", err)
+	}
+
+	if len(polls) != 4 {
+		t.Fatalf("Expected 4 polls, got %d", len(polls))
+	}
+	for i, expected := range []time.Duration{0, 5 * time.Second, 15 * time.Second, 35 * time.Second} {
+		if polls[i] != expected {
+			t.Errorf("Poll %d at %s, expected %s with exponential backoff", i+1, polls[i], expected)
+		}
+	}
+	if !result.Success || result.Status != types.SwapStatusCompleted || result.ErrorMessage != "" {
+		t.Errorf("Expected a completed swap, got %s: %s", result.Status, result.ErrorMessage)
+	}
+	if result.BridgeTx.Hash != "0xbridge" || result.DestinationTx.Hash != "0xdest" {
+		t.Errorf("Expected the bridge's transactions, got %s and %s", result.BridgeTx.Hash, result.DestinationTx.Hash)
+	}
+}
+
+func TestAwaitBridgeTransferFailed(t *testing.T) {
+	_, _, err := runBridgeTransfer(t, false, 10*time.Minute, "pending", "failed")
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != swapSettlementFailed {
+		t.Fatalf("Expected a %s error, got %v", swapSettlementFailed, err)
+	}
+}
+
+func TestAwaitBridgeTransferTimeout(t *testing.T) {
+	_, polls, err := runBridgeTransfer(t, false, 20*time.Minute, "pending")
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != BridgeTransferTimeout {
+		t.Fatalf("Expected a %s error, got %v", BridgeTransferTimeout, err)
+	}
+
+	// Polling lasts three times the bridge's expected time, backing off to two minutes between polls
+	last := polls[len(polls)-1]
+	if last != time.Hour {
+		t.Errorf("Expected the last poll at the one hour deadline, got %s", last)
+	}
+	if gap := polls[len(polls)-2] - polls[len(polls)-3]; gap != bridgeStatusMaxInterval {
+		t.Errorf("Expected polls %s apart once backed off, got %s", bridgeStatusMaxInterval, gap)
+	}
+}
+
+func TestWatchBridgeTransfer(t *testing.T) {
+	// A failed watch is started again after a pause, as the transfer is in flight whatever the watch does
+	result, polls, err := runBridgeTransfer(t, true, 10*time.Minute, "", "completed")
+	if err != nil {
+		t.Fatalf("Bridge transfer failed: %v", err)
+	}
+	if len(polls) != 2 || polls[1] != bridgeStatusInitialInterval {
+		t.Errorf("Expected the watch to start again after %s, got watches at %v", bridgeStatusInitialInterval, polls)
+	}
+	if !result.Success || result.Status != types.SwapStatusCompleted || result.BridgeTx.Hash != "0xbridge" {
+		t.Errorf("Expected a completed swap with the bridge's transaction, got %s: %s", result.Status, result.ErrorMessage)
+	}
+
+	_, _, err = runBridgeTransfer(t, true, 10*time.Minute, "failed")
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != swapSettlementFailed {
+		t.Fatalf("Expected a %s error, got %v", swapSettlementFailed, err)
+	}
+
+	_, _, err = runBridgeTransfer(t, true, 10*time.Minute, "pending")
+	if !errors.As(err, &appErr) || appErr.Type() != BridgeTransferTimeout {
+		t.Fatalf("Expected a %s error, got %v", BridgeTransferTimeout, err)
+	}
+}
+
+// runFirmQuoteSwap executes a swap started from a firm quote at start, against activities that deliver output. It
+// returns the swap's result and the requests executed.
+func TestSwapChainQueues(t *testing.T) {
+	queues := map[int64]string{types.ChainIDEthereum: "dex-tasks-ethereum", types.ChainIDPolygon: "dex-tasks-polygon"}
+	for name, test := range map[string]struct {
+		queues *types.ChainTaskQueues
+		expect []string // Task queues of the execution and the bridge polling
+	}{
+		"Unpartitioned": {expect: []string{"default-test-taskqueue", "default-test-taskqueue"}},
+		"PerChain":      {queues: &types.ChainTaskQueues{Queues: queues}, expect: []string{"dex-tasks-ethereum", "dex-tasks-polygon"}},
+		"Sticky":        {queues: &types.ChainTaskQueues{Queues: queues, Sticky: true}, expect: []string{"dex-tasks-ethereum", "dex-tasks-ethereum"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+
+			var taskQueues []string
+			env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) (types.SwapResult, error) {
+				taskQueues = append(taskQueues, activity.GetInfo(ctx).TaskQueue)
+				return types.SwapResult{RequestID: request.RequestID, Status: types.SwapStatusPending}, nil
+			}, activity.RegisterOptions{Name: "ExecuteSwapActivity"})
+			env.RegisterActivityWithOptions(func(ctx context.Context, requestID string, deadline time.Time) (*universalsdk.TransactionStatus, error) {
+				taskQueues = append(taskQueues, activity.GetInfo(ctx).TaskQueue)
+				return &universalsdk.TransactionStatus{TransactionID: requestID, Status: "completed"}, nil
+			}, activity.RegisterOptions{Name: "WatchBridgeTransferActivity"})
+
+			request := types.SwapRequest{
+				RequestID:        "swap-queues",
+				SourceToken:      types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDEthereum},
+				DestinationToken: types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDPolygon},
+				Amount:           big.NewInt(1000),
+			}
+			env.ExecuteWorkflow(func(ctx workflow.Context) error {
+				steps := swapSteps{
+					options: workflow.ActivityOptions{StartToCloseTimeout: 30 * time.Second},
+					budget:  &retryBudget{},
+					queues:  test.queues,
+				}
+				var result types.SwapResult
+				if err := steps.budget.execute(ctx, steps.forChain(request.SourceToken.ChainID).onChainOptions(), "ExecuteSwapActivity", &result, request); err != nil {
+					return err
+				}
+				return awaitBridgeTransfer(ctx, steps, request, types.SwapQuote{}, &result)
+			})
+			if !env.IsWorkflowCompleted() {
+				t.Fatal("Workflow did not complete")
+			}
+			if err := env.GetWorkflowError(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(taskQueues) != len(test.expect) {
+				t.Fatalf("Expected activities on %v, got %v", test.expect, taskQueues)
+			}
+			for i := range test.expect {
+				if taskQueues[i] != test.expect[i] {
+					t.Errorf("Expected activities on %v, got %v", test.expect, taskQueues)
+					break
+				}
+			}
+		})
+	}
+}
+
+func runFirmQuoteSwap(t *testing.T, quote types.SwapQuote, start time.Time, output *big.Int) (*types.SwapResult, []types.SwapRequest) {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetStartTime(start)
+
+	var executed []types.SwapRequest
+	env.RegisterActivityWithOptions(func(ctx context.Context, allowance types.AllowanceRequest) (*types.AllowanceResult, error) {
+		return &types.AllowanceResult{Status: types.AllowanceApproved}, nil
+	}, activity.RegisterOptions{Name: "CheckAndApproveAllowanceActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest, quote types.SwapQuote) error {
+		t.Error("Expected a firm quote's swap not to be checked for drift")
+		return nil
+	}, activity.RegisterOptions{Name: "CheckQuoteDriftActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) (types.SwapResult, error) {
+		executed = append(executed, request)
+		return types.SwapResult{RequestID: request.RequestID, Success: true, InputAmount: request.Amount, OutputAmount: output}, nil
+	}, activity.RegisterOptions{Name: "ExecuteSwapActivity"})
+
+	request := types.SwapRequest{
+		RequestID:        "firm-swap",
+		SourceToken:      types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDEthereum},
+		DestinationToken: types.Token{Symbol: "DAI", Decimals: 18, ChainID: types.ChainIDEthereum},
+		Amount:           quote.InputAmount,
+		FirmQuote:        &quote,
+	}
+	env.ExecuteWorkflow(func(ctx workflow.Context) (*types.SwapResult, error) {
+		steps := swapSteps{
+			options: workflow.ActivityOptions{StartToCloseTimeout: 30 * time.Second},
+			budget:  &retryBudget{},
+		}
+		return executeFirmQuoteSwap(ctx, steps, SwapWorkflowInput{Request: request}, SwapWorkflowState{RequestID: request.RequestID})
+	})
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Workflow did not complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var result *types.SwapResult
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("Failed to read result: %v", err)
+	}
+	return result, executed
+}
+
+func TestFirmQuoteSwap(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	quote := types.SwapQuote{
+		QuoteID:      "quote-1",
+		InputAmount:  big.NewInt(1000),
+		OutputAmount: big.NewInt(990),
+		ExpiresAt:    start.Add(30 * time.Second),
+	}
+
+	result, executed := runFirmQuoteSwap(t, quote, start, big.NewInt(995))
+	if !result.Success {
+		t.Fatalf("Expected the swap to succeed, got %s", result.ErrorMessage)
+	}
+	if len(executed) != 1 || executed[0].MinOutputAmount.Cmp(quote.OutputAmount) != 0 {
+		t.Fatalf("Expected the swap executed with the quote's output as its minimum, got %v", executed)
+	}
+
+	// Once the quote expires the swap fails without executing
+	result, executed = runFirmQuoteSwap(t, quote, quote.ExpiresAt, big.NewInt(995))
+	if result.Success || result.ErrorCode != types.SwapErrorQuoteExpired {
+		t.Errorf("Expected the swap to fail with %s, got %+v", types.SwapErrorQuoteExpired, result)
+	}
+	if len(executed) != 0 {
+		t.Errorf("Expected an expired quote's swap not to execute, got %d executions", len(executed))
+	}
+}
+
+// runCheckedSwap runs a swap whose limits and balance checks fail with the given errors, either of which may be nil.
+// It returns the swap's result, the swaps archived and the request IDs whose swap limits were released.
+func runCheckedSwap(t *testing.T, limitsErr, balanceErr error) (*types.SwapResult, []types.ArchivedSwap, []string) {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) error {
+		return limitsErr
+	}, activity.RegisterOptions{Name: "CheckSwapLimitsActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) error {
+		return balanceErr
+	}, activity.RegisterOptions{Name: "CheckBalanceActivity"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, request types.SwapRequest) (types.SwapQuote, error) {
+		t.Error("Expected a swap failing its checks not to be quoted")
+		return types.SwapQuote{}, nil
+	}, activity.RegisterOptions{Name: "CalculateSwapQuoteActivity"})
+	var archived []types.ArchivedSwap
+	env.RegisterActivityWithOptions(func(ctx context.Context, swap types.ArchivedSwap) error {
+		archived = append(archived, swap)
+		return nil
+	}, activity.RegisterOptions{Name: "ArchiveSwapActivity"})
+	var released []string
+	env.RegisterActivityWithOptions(func(ctx context.Context, requestID string) error {
+		released = append(released, requestID)
+		return nil
+	}, activity.RegisterOptions{Name: "ReleaseSwapLimitsActivity"})
+
+	env.ExecuteWorkflow(SwapWorkflow, SwapWorkflowInput{Request: types.SwapRequest{
+		RequestID:        "swap-checked",
+		SourceToken:      types.Token{Symbol: "USDC", Decimals: 6, ChainID: types.ChainIDEthereum},
+		DestinationToken: types.Token{Symbol: "DAI", Decimals: 18, ChainID: types.ChainIDEthereum},
+		Amount:           big.NewInt(1000),
+		SourceAddress:    "0xabc",
+	}})
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Workflow did not complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var result *types.SwapResult
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("Failed to read result: %v", err)
+	}
+	return result, archived, released
+}
+
+func TestSwapWorkflowChecks(t *testing.T) {
+	limitErr := &types.SwapLimitError{Limit: types.SwapLimitUser, Daily: true, LimitUSD: 5000, AmountUSD: 1000, RemainingUSD: 200}
+	balanceErr := errors.New("0xabc holds 5 USDC, needs 1000")
+	for name, test := range map[string]struct {
+		limitsErr, balanceErr error
+		code                  string
+	}{
+		"LimitExceeded": {
+			limitsErr: temporal.NewNonRetryableApplicationError(limitErr.Error(), types.SwapErrorLimitExceeded, limitErr),
+			code:      types.SwapErrorLimitExceeded,
+		},
+		"InsufficientBalance": {
+			balanceErr: temporal.NewNonRetryableApplicationError(balanceErr.Error(), types.SwapErrorInsufficientBalance, balanceErr),
+			code:       types.SwapErrorInsufficientBalance,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, archived, released := runCheckedSwap(t, test.limitsErr, test.balanceErr)
+			if result.Success || result.Status != types.SwapStatusFailed || result.ErrorCode != test.code {
+				t.Errorf("Expected the swap to fail with %s, got %+v", test.code, result)
+			}
+			if len(archived) != 1 || archived[0].Result.ErrorCode != test.code {
+				t.Errorf("Expected the failed swap to be archived, got %v", archived)
+			}
+			if len(released) != 1 || released[0] != "swap-checked" {
+				t.Errorf("Expected the swap's limits to be released, got %v", released)
+			}
+		})
+	}
+}
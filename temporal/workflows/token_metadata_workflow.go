@@ -0,0 +1,36 @@
+package temporal_workflows
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// RefreshTokenMetadataWorkflow refreshes the stored token metadata of the chains from the token lists.
+// The price worker runs it on a cron schedule; token lists change slowly, so a daily refresh is enough.
+func RefreshTokenMetadataWorkflow(ctx workflow.Context, chainIDs []int64) (int, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("RefreshTokenMetadataWorkflow started", "chains", chainIDs)
+
+	options := workflow.ActivityOptions{
+		// Token lists hold thousands of tokens and CoinGecko may rate limit the requests
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    10 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    5 * time.Minute,
+			MaximumAttempts:    5,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, options)
+
+	var count int
+	if err := workflow.ExecuteActivity(ctx, "RefreshTokenMetadataActivity", chainIDs).Get(ctx, &count); err != nil {
+		logger.Error("Failed to refresh token metadata", "error", err)
+		return 0, err
+	}
+
+	logger.Info("RefreshTokenMetadataWorkflow completed", "tokens", count)
+	return count, nil
+}
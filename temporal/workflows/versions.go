@@ -0,0 +1,54 @@
+package temporal_workflows
+
+import "go.temporal.io/sdk/workflow"
+
+// Step versions checkpoint each step of SwapWorkflow and PriceOracleWorkflow, so the logic of a step can change
+// without breaking the replay of executions that already ran it. Every execution records the version of each step
+// it runs in a marker the first time it reaches the step, and its replays read that version back. Executions started
+// before a step was checkpointed have no marker and run it at workflow.DefaultVersion.
+//
+// To change a step:
+//  1. Raise the step's version in stepVersions.
+//  2. Branch on the version stepVersion returns, keeping the old code for lower versions.
+//  3. Add a history recorded with the new code to testdata/histories, next to the old ones, so
+//     TestReplayHistories replays executions of both.
+//  4. Once no execution of an older version is running or retained, delete its branch and its histories. Never
+//     lower a step's version or reuse one.
+//
+// Changes outside a checkpointed step get their own change ID, as quoteDriftCheckChange and archiveSwapChange do.
+const (
+	swapPolicyStep   = "swap-policy-step"   // Evaluating the tenant's KYC/AML policy
+	swapSimulateStep = "swap-simulate-step" // Projecting a dry run's result
+	swapFastPathStep = "swap-fast-path-step"
+	swapQuoteStep    = "swap-quote-step"
+	swapConfirmStep  = "swap-confirm-step" // Waiting for the user's confirmation or the deposit funding the swap
+	swapExecuteStep  = "swap-execute-step"
+	swapTrancheStep  = "swap-tranche-step" // Executing a swap split into tranches
+
+	priceCacheStep = "price-cache-step" // Loading the cached prices
+	priceFetchStep = "price-fetch-step" // Fetching prices from every source
+	priceMergeStep = "price-merge-step"
+	priceSaveStep  = "price-save-step" // Saving the merged prices to the cache, database and candles
+)
+
+// stepVersions are the latest version of each checkpointed step, which new executions run
+var stepVersions = map[string]workflow.Version{
+	swapPolicyStep:   1,
+	swapSimulateStep: 1,
+	swapFastPathStep: 2, // 2 approves the DEX's allowance first
+	swapQuoteStep:    1,
+	swapConfirmStep:  1,
+	swapExecuteStep:  3, // 2 waits for the bridge to deliver cross-chain swaps; 3 approves the DEX's allowance first
+	swapTrancheStep:  3, // 2 waits for the bridge to deliver cross-chain tranches; 3 approves the DEX's allowance first
+
+	priceCacheStep: 1,
+	priceFetchStep: 1,
+	priceMergeStep: 1,
+	priceSaveStep:  1,
+}
+
+// stepVersion returns the version of a step the execution runs: the latest for a new execution, and the recorded
+// one, or workflow.DefaultVersion when there is none, for a replay
+func stepVersion(ctx workflow.Context, step string) workflow.Version {
+	return workflow.GetVersion(ctx, step, workflow.DefaultVersion, stepVersions[step])
+}
@@ -0,0 +1,66 @@
+package universalsdk
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kinds of SDK failure. Errors returned by the SDK match one of them with errors.Is; errors.As gives the *Error.
+var (
+	ErrInsufficientAmount = errors.New("amount too small to cover fees")
+	ErrChainUnsupported   = errors.New("chain not supported")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrBridgeDown         = errors.New("bridge unavailable")
+	ErrNetwork            = errors.New("network error")
+)
+
+// errorKinds are the kinds of failure by the names scenarios give them
+var errorKinds = map[string]error{
+	"insufficient_amount": ErrInsufficientAmount,
+	"chain_unsupported":   ErrChainUnsupported,
+	"rate_limited":        ErrRateLimited,
+	"bridge_down":         ErrBridgeDown,
+	"network":             ErrNetwork,
+}
+
+// Error is a failed SDK call: the method, the kind of failure and what went wrong
+type Error struct {
+	Method     string        // e.g. MethodWrapToken
+	Kind       error         // One of the Err* kinds
+	Message    string        // Details; the kind's own message when empty
+	RetryAfter time.Duration // How long a rate limited caller should wait, when Universal said
+}
+
+// Error returns the message, or the kind's when there's none
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Kind.Error()
+}
+
+// Unwrap returns the kind, so errors.Is matches it
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// newError creates an error of a kind for a call of method
+func newError(method string, kind error, format string, args ...interface{}) *Error {
+	return &Error{Method: method, Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Retryable reports whether a failed call may succeed when made again. Amounts too small and unsupported chains fail
+// however often they're tried; other failures, including errors of no known kind, are taken to be transient.
+func Retryable(err error) bool {
+	return err != nil && !errors.Is(err, ErrInsufficientAmount) && !errors.Is(err, ErrChainUnsupported)
+}
+
+// RetryAfter returns how long a rate limited call asked its caller to wait, or 0
+func RetryAfter(err error) time.Duration {
+	var sdkErr *Error
+	if errors.As(err, &sdkErr) && errors.Is(sdkErr.Kind, ErrRateLimited) {
+		return sdkErr.RetryAfter
+	}
+	return 0
+}
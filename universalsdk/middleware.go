@@ -0,0 +1,128 @@
+package universalsdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// Call is one SDK call passing through middleware
+type Call struct {
+	Method   string  // e.g. MethodWrapToken
+	ChainIDs []int64 // Chains the call involves; none for GetAllWrappedTokens, GetTransactionStatus and WatchTransaction
+	// Request is the call's argument: a WrapRequest, UnwrapRequest, TransferRequest or FeeEstimateRequest, the
+	// chain ID of GetWrappedTokens or the transaction ID of GetTransactionStatus and WatchTransaction.
+	// GetAllWrappedTokens has none.
+	Request interface{}
+}
+
+// Invoker makes a call, returning the method's result
+type Invoker func(ctx context.Context, call Call) (interface{}, error)
+
+// Middleware wraps an invoker with a concern shared by every call, such as logging, metrics, retries or caching
+type Middleware func(next Invoker) Invoker
+
+// chainedSDK is a Universal SDK passing every call through middleware
+type chainedSDK struct {
+	sdk    SDK
+	invoke Invoker
+}
+
+// Chain wraps an SDK so each call passes through the middlewares, the first one outermost
+func Chain(sdk SDK, middlewares ...Middleware) SDK {
+	s := &chainedSDK{sdk: sdk}
+	s.invoke = s.call
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		s.invoke = middlewares[i](s.invoke)
+	}
+	return s
+}
+
+// call makes a call on the wrapped SDK
+func (s *chainedSDK) call(ctx context.Context, call Call) (interface{}, error) {
+	switch req := call.Request.(type) {
+	case WrapRequest:
+		return s.sdk.WrapToken(ctx, req)
+	case UnwrapRequest:
+		return s.sdk.UnwrapToken(ctx, req)
+	case TransferRequest:
+		return s.sdk.TransferToken(ctx, req)
+	case int64:
+		return s.sdk.GetWrappedTokens(ctx, req)
+	case nil:
+		if call.Method != MethodGetAllWrappedTokens {
+			return nil, fmt.Errorf("Universal SDK %s called without a request", call.Method)
+		}
+		return s.sdk.GetAllWrappedTokens(ctx)
+	case FeeEstimateRequest:
+		return s.sdk.GetFeeEstimate(ctx, req)
+	case string:
+		if call.Method == MethodWatchTransaction {
+			return s.sdk.WatchTransaction(ctx, req)
+		}
+		return s.sdk.GetTransactionStatus(ctx, req)
+	default:
+		return nil, fmt.Errorf("unknown Universal SDK request %T of %s", call.Request, call.Method)
+	}
+}
+
+// WrapToken wraps a native token into a Universal token
+func (s *chainedSDK) WrapToken(ctx context.Context, req WrapRequest) (*WrapResult, error) {
+	result, err := s.invoke(ctx, Call{Method: MethodWrapToken, ChainIDs: []int64{req.Token.ChainID}, Request: req})
+	wrapped, _ := result.(*WrapResult)
+	return wrapped, err
+}
+
+// UnwrapToken unwraps a Universal token back to a native token
+func (s *chainedSDK) UnwrapToken(ctx context.Context, req UnwrapRequest) (*UnwrapResult, error) {
+	chainIDs := []int64{req.WrappedToken.ChainID, req.DestinationToken.ChainID}
+	result, err := s.invoke(ctx, Call{Method: MethodUnwrapToken, ChainIDs: chainIDs, Request: req})
+	unwrapped, _ := result.(*UnwrapResult)
+	return unwrapped, err
+}
+
+// TransferToken transfers a Universal token across chains
+func (s *chainedSDK) TransferToken(ctx context.Context, req TransferRequest) (*TransferResult, error) {
+	chainIDs := []int64{req.SourceChainID, req.DestChainID}
+	result, err := s.invoke(ctx, Call{Method: MethodTransferToken, ChainIDs: chainIDs, Request: req})
+	transfer, _ := result.(*TransferResult)
+	return transfer, err
+}
+
+// GetWrappedTokens returns the list of available wrapped tokens
+func (s *chainedSDK) GetWrappedTokens(ctx context.Context, chainID int64) ([]types.Token, error) {
+	result, err := s.invoke(ctx, Call{Method: MethodGetWrappedTokens, ChainIDs: []int64{chainID}, Request: chainID})
+	tokens, _ := result.([]types.Token)
+	return tokens, err
+}
+
+// GetAllWrappedTokens returns the wrapped tokens of every supported chain
+func (s *chainedSDK) GetAllWrappedTokens(ctx context.Context) (map[int64][]types.Token, error) {
+	result, err := s.invoke(ctx, Call{Method: MethodGetAllWrappedTokens})
+	tokens, _ := result.(map[int64][]types.Token)
+	return tokens, err
+}
+
+// GetFeeEstimate returns an estimate of the fees for a swap operation
+func (s *chainedSDK) GetFeeEstimate(ctx context.Context, req FeeEstimateRequest) (*types.Fee, error) {
+	chainIDs := []int64{req.SourceToken.ChainID, req.DestinationToken.ChainID}
+	result, err := s.invoke(ctx, Call{Method: MethodGetFeeEstimate, ChainIDs: chainIDs, Request: req})
+	fee, _ := result.(*types.Fee)
+	return fee, err
+}
+
+// GetTransactionStatus returns the status of a transaction
+func (s *chainedSDK) GetTransactionStatus(ctx context.Context, transactionID string) (*TransactionStatus, error) {
+	result, err := s.invoke(ctx, Call{Method: MethodGetTransactionStatus, Request: transactionID})
+	status, _ := result.(*TransactionStatus)
+	return status, err
+}
+
+// WatchTransaction streams a transaction's status as it changes. Middleware sees the call that starts the watch,
+// not the statuses streamed after it.
+func (s *chainedSDK) WatchTransaction(ctx context.Context, transactionID string) (<-chan TransactionStatus, error) {
+	result, err := s.invoke(ctx, Call{Method: MethodWatchTransaction, Request: transactionID})
+	statuses, _ := result.(<-chan TransactionStatus)
+	return statuses, err
+}
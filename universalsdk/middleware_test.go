@@ -0,0 +1,130 @@
+package universalsdk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// recordCalls is a middleware appending each call's method, prefixed with name, to calls
+func recordCalls(name string, calls *[]string) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call Call) (interface{}, error) {
+			*calls = append(*calls, name+":"+call.Method)
+			return next(ctx, call)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	tokens := map[int64][]types.Token{1: {{Symbol: "uETH", ChainID: 1}}}
+	var calls []string
+	sdk := Chain(NewMockSDK(MockSDKConfig{WrappedTokens: tokens}), recordCalls("outer", &calls), recordCalls("inner", &calls))
+
+	got, err := sdk.GetWrappedTokens(context.Background(), 1)
+	if err != nil || len(got) != 1 || got[0].Symbol != "uETH" {
+		t.Fatalf("Expected the wrapped SDK's tokens, got %+v, %v", got, err)
+	}
+	result, err := sdk.WrapToken(context.Background(), WrapRequest{Token: types.Token{Symbol: "ETH", ChainID: 1}, Amount: big.NewInt(1e18)})
+	if err != nil || result.WrappedToken.Symbol != "uETH" {
+		t.Fatalf("Expected the wrapped SDK's result, got %+v, %v", result, err)
+	}
+
+	all, err := sdk.GetAllWrappedTokens(context.Background())
+	if err != nil || len(all) != 1 || len(all[1]) != 1 {
+		t.Fatalf("Expected every chain's tokens, got %+v, %v", all, err)
+	}
+
+	want := []string{"outer:GetWrappedTokens", "inner:GetWrappedTokens", "outer:WrapToken", "inner:WrapToken",
+		"outer:GetAllWrappedTokens", "inner:GetAllWrappedTokens"}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, calls)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	mock := NewMockSDK(MockSDKConfig{}).(*MockUniversalSDK)
+	metrics := NewCallMetrics()
+	sdk := Chain(mock, Retry(3, time.Millisecond), metrics.Middleware())
+
+	// A read failing twice succeeds on its third attempt
+	mock.FailNext(MethodGetTransactionStatus, 2, "status unavailable")
+	if _, err := sdk.GetTransactionStatus(context.Background(), "tx-1"); err != nil {
+		t.Errorf("Expected the read to be retried, got %v", err)
+	}
+
+	// A transfer moves funds, so its failure is returned at once
+	mock.FailNext(MethodTransferToken, 1, "transfer transaction failed: network error")
+	if _, err := sdk.TransferToken(context.Background(), TransferRequest{Amount: big.NewInt(1e18)}); err == nil {
+		t.Error("Expected the transfer to fail without a retry")
+	}
+
+	// A read that can't succeed isn't retried
+	if _, err := sdk.GetWrappedTokens(context.Background(), 56); !errors.Is(err, ErrChainUnsupported) || Retryable(err) {
+		t.Errorf("Expected an unsupported chain, got %v", err)
+	}
+
+	stats := metrics.Stats()
+	if s := stats[MethodGetWrappedTokens]; s.Calls != 1 {
+		t.Errorf("Expected 1 wrapped tokens attempt, got %+v", s)
+	}
+	if s := stats[MethodGetTransactionStatus]; s.Calls != 3 || s.Errors != 2 {
+		t.Errorf("Expected 3 status attempts, 2 failed, got %+v", s)
+	}
+	if s := stats[MethodTransferToken]; s.Calls != 1 || s.Errors != 1 {
+		t.Errorf("Expected 1 failed transfer attempt, got %+v", s)
+	}
+}
+
+func TestCache(t *testing.T) {
+	metrics := NewCallMetrics()
+	cache := newResponseCache(map[string]time.Duration{MethodGetFeeEstimate: time.Minute, MethodTransferToken: time.Minute})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	sdk := Chain(NewMockSDK(MockSDKConfig{}), cache.middleware, metrics.Middleware())
+
+	eth := types.Token{Symbol: "ETH", ChainID: 1}
+	usdc := types.Token{Symbol: "USDC", ChainID: 137}
+	estimate := func(amount int64) *types.Fee {
+		fee, err := sdk.GetFeeEstimate(context.Background(), FeeEstimateRequest{SourceToken: eth, DestinationToken: usdc, Amount: big.NewInt(amount)})
+		if err != nil {
+			t.Fatalf("Failed to estimate fee: %v", err)
+		}
+		return fee
+	}
+
+	first := estimate(1000)
+	first.GasFee.SetInt64(0) // Callers can't change the cached estimate
+	if second := estimate(1000); second.GasFee.Sign() == 0 {
+		t.Error("Expected the cached estimate to be a copy")
+	}
+	estimate(2000) // Another amount is another estimate
+	if calls := metrics.Stats()[MethodGetFeeEstimate].Calls; calls != 2 {
+		t.Errorf("Expected 2 estimates fetched, got %d", calls)
+	}
+
+	now = now.Add(time.Minute)
+	estimate(1000)
+	if calls := metrics.Stats()[MethodGetFeeEstimate].Calls; calls != 3 {
+		t.Errorf("Expected the expired estimate fetched again, got %d fetches", calls)
+	}
+
+	// Only reads are cached
+	for i := 0; i < 2; i++ {
+		if _, err := sdk.TransferToken(context.Background(), TransferRequest{Amount: big.NewInt(1e18)}); err != nil {
+			t.Fatalf("Failed to transfer: %v", err)
+		}
+	}
+	if calls := metrics.Stats()[MethodTransferToken].Calls; calls != 2 {
+		t.Errorf("Expected every transfer sent, got %d", calls)
+	}
+}
@@ -0,0 +1,284 @@
+package universalsdk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+// readMethods are the methods that change nothing, so retrying or caching them is safe
+var readMethods = map[string]bool{
+	MethodGetWrappedTokens:     true,
+	MethodGetAllWrappedTokens:  true,
+	MethodGetFeeEstimate:       true,
+	MethodGetTransactionStatus: true,
+	MethodWatchTransaction:     true,
+}
+
+// Logging logs each call with how long it took, and its error when it failed
+func Logging() Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call Call) (interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, call)
+			took := time.Since(start).Round(time.Millisecond)
+			if err != nil {
+				log.Printf("Universal SDK %s failed after %v: %v", call.Method, took, err)
+			} else {
+				log.Printf("Universal SDK %s took %v", call.Method, took)
+			}
+			return result, err
+		}
+	}
+}
+
+// Retry makes a failed read up to attempts times in all, waiting backoff before the first retry and doubling it
+// before each later one, or longer when a rate limited call asks to. Wraps, unwraps and transfers move funds, so they
+// are never retried here, and neither are failures that aren't Retryable.
+func Retry(attempts int, backoff time.Duration) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call Call) (interface{}, error) {
+			result, err := next(ctx, call)
+			if !readMethods[call.Method] {
+				return result, err
+			}
+			wait := backoff
+			for attempt := 1; Retryable(err) && attempt < attempts && ctx.Err() == nil; attempt++ {
+				timer := time.NewTimer(max(wait, RetryAfter(err)))
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return result, err
+				}
+				wait *= 2
+				result, err = next(ctx, call)
+			}
+			return result, err
+		}
+	}
+}
+
+// CallStats counts the calls of one SDK method
+type CallStats struct {
+	Calls      int64         `json:"calls"`
+	Errors     int64         `json:"errors"`
+	Latency    time.Duration `json:"latency"` // Spent in all calls together
+	MaxLatency time.Duration `json:"maxLatency"`
+}
+
+// CallMetrics counts the calls of each SDK method passing through its middleware
+type CallMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*CallStats
+}
+
+// NewCallMetrics creates metrics without any calls
+func NewCallMetrics() *CallMetrics {
+	return &CallMetrics{stats: make(map[string]*CallStats)}
+}
+
+// Middleware counts each call's outcome and latency
+func (m *CallMetrics) Middleware() Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call Call) (interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, call)
+			m.record(call.Method, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// record counts a call of method
+func (m *CallMetrics) record(method string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.stats[method]
+	if !ok {
+		stats = &CallStats{}
+		m.stats[method] = stats
+	}
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.Latency += latency
+	if latency > stats.MaxLatency {
+		stats.MaxLatency = latency
+	}
+}
+
+// Stats returns the counts of each method called so far
+func (m *CallMetrics) Stats() map[string]CallStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[string]CallStats, len(m.stats))
+	for method, s := range m.stats {
+		stats[method] = *s
+	}
+	return stats
+}
+
+// StartLogging logs the counts of each method every interval until ctx is done
+func (m *CallMetrics) StartLogging(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.logStats()
+			}
+		}
+	}()
+}
+
+// logStats logs the counts of each method, in method order
+func (m *CallMetrics) logStats() {
+	stats := m.Stats()
+	methods := make([]string, 0, len(stats))
+	for method := range stats {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		s := stats[method]
+		log.Printf("Universal SDK %s: %d calls, %d failed, %v average, %v max",
+			method, s.Calls, s.Errors, (s.Latency / time.Duration(s.Calls)).Round(time.Millisecond), s.MaxLatency.Round(time.Millisecond))
+	}
+}
+
+// maxCachedResponses bounds the responses a cache keeps; fee estimates are cached per amount
+const maxCachedResponses = 1024
+
+// cachedResponse is a response and when it expires
+type cachedResponse struct {
+	result  interface{}
+	expires time.Time
+}
+
+// responseCache keeps the responses of reads for their method's TTL
+type responseCache struct {
+	mu        sync.Mutex
+	ttls      map[string]time.Duration
+	responses map[string]cachedResponse
+	now       func() time.Time
+}
+
+// Cache answers repeated GetWrappedTokens, GetAllWrappedTokens and GetFeeEstimate calls from the last response for the method's TTL.
+// Methods without a TTL, and failed calls, aren't cached. Callers get copies, so they can't change a cached response.
+func Cache(ttls map[string]time.Duration) Middleware {
+	return newResponseCache(ttls).middleware
+}
+
+// newResponseCache creates a cache with the TTLs of the cacheable methods
+func newResponseCache(ttls map[string]time.Duration) *responseCache {
+	c := &responseCache{ttls: make(map[string]time.Duration), responses: make(map[string]cachedResponse), now: time.Now}
+	for method, ttl := range ttls {
+		if (method == MethodGetWrappedTokens || method == MethodGetFeeEstimate) && ttl > 0 {
+			c.ttls[method] = ttl
+		}
+	}
+	return c
+}
+
+// middleware answers calls from the cache
+func (c *responseCache) middleware(next Invoker) Invoker {
+	return func(ctx context.Context, call Call) (interface{}, error) {
+		ttl, ok := c.ttls[call.Method]
+		if !ok {
+			return next(ctx, call)
+		}
+		key := cacheKey(call)
+		if result, ok := c.get(key); ok {
+			return copyResponse(result), nil
+		}
+
+		result, err := next(ctx, call)
+		if err != nil {
+			return result, err
+		}
+		c.put(key, copyResponse(result), ttl)
+		return result, nil
+	}
+}
+
+// get returns the unexpired response of a key
+func (c *responseCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, ok := c.responses[key]
+	if !ok || !c.now().Before(response.expires) {
+		return nil, false
+	}
+	return response.result, true
+}
+
+// put keeps a response for ttl, dropping the expired ones, or all of them, when the cache is full
+func (c *responseCache) put(key string, result interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.responses) >= maxCachedResponses {
+		for k, response := range c.responses {
+			if !now.Before(response.expires) {
+				delete(c.responses, k)
+			}
+		}
+		if len(c.responses) >= maxCachedResponses {
+			c.responses = make(map[string]cachedResponse)
+		}
+	}
+	c.responses[key] = cachedResponse{result: result, expires: now.Add(ttl)}
+}
+
+// cacheKey identifies a read by its method and request
+func cacheKey(call Call) string {
+	switch req := call.Request.(type) {
+	case FeeEstimateRequest:
+		return fmt.Sprintf("%s/%d:%s:%s/%d:%s:%s/%s", call.Method,
+			req.SourceToken.ChainID, req.SourceToken.Address, req.SourceToken.Symbol,
+			req.DestinationToken.ChainID, req.DestinationToken.Address, req.DestinationToken.Symbol, req.Amount)
+	default:
+		return fmt.Sprintf("%s/%v", call.Method, req)
+	}
+}
+
+// copyResponse copies a cacheable response, so the cached one can't be changed through the copy
+func copyResponse(result interface{}) interface{} {
+	switch r := result.(type) {
+	case []types.Token:
+		return append([]types.Token(nil), r...)
+	case map[int64][]types.Token:
+		tokens := make(map[int64][]types.Token, len(r))
+		for chainID, chainTokens := range r {
+			tokens[chainID] = append([]types.Token(nil), chainTokens...)
+		}
+		return tokens
+	case *types.Fee:
+		if r == nil {
+			return r
+		}
+		fee := *r
+		for _, amount := range []**big.Int{&fee.GasFee, &fee.ProtocolFee, &fee.NetworkFee, &fee.BridgeFee} {
+			if *amount != nil {
+				*amount = new(big.Int).Set(*amount)
+			}
+		}
+		return &fee
+	default:
+		return result
+	}
+}
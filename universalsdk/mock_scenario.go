@@ -0,0 +1,154 @@
+package universalsdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// mockMethods are the methods faults can be injected into
+var mockMethods = map[string]bool{
+	MethodWrapToken:            true,
+	MethodUnwrapToken:          true,
+	MethodTransferToken:        true,
+	MethodGetWrappedTokens:     true,
+	MethodGetAllWrappedTokens:  true,
+	MethodGetFeeEstimate:       true,
+	MethodGetTransactionStatus: true,
+	MethodWatchTransaction:     true,
+}
+
+// Fault delays or fails calls of one SDK method. Calls are counted from when the fault is added: the first After
+// calls are left alone, then the next Times calls are delayed by Delay and, when Error or Kind is set, fail with an
+// *Error of that message and kind.
+type Fault struct {
+	Method string        `mapstructure:"METHOD"` // e.g. WrapToken
+	After  int           `mapstructure:"AFTER"`  // Calls let through before the fault starts
+	Times  int           `mapstructure:"TIMES"`  // Calls the fault applies to; 0 applies it to every later call
+	Delay  time.Duration `mapstructure:"DELAY"`  // Added to each affected call, ending early when the call's context does
+	Error  string        `mapstructure:"ERROR"`  // Message each affected call fails with; the kind's when empty
+	// Kind of failure: insufficient_amount, chain_unsupported, rate_limited, bridge_down or network, the default.
+	// Without Error or Kind, the fault only delays calls.
+	Kind string `mapstructure:"KIND"`
+}
+
+// mockFault is a fault with the calls it has seen
+type mockFault struct {
+	Fault
+	calls int
+}
+
+// AddFault injects a fault into the mock's calls of a method
+func (m *MockUniversalSDK) AddFault(fault Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = append(m.faults, &mockFault{Fault: fault})
+}
+
+// FailNext fails the next n calls of method with a network error of message
+func (m *MockUniversalSDK) FailNext(method string, n int, message string) {
+	m.AddFault(Fault{Method: method, Times: n, Error: message})
+}
+
+// Delay delays every later call of method by delay
+func (m *MockUniversalSDK) Delay(method string, delay time.Duration) {
+	m.AddFault(Fault{Method: method, Delay: delay})
+}
+
+// inject applies the faults of a method to a call, returning the error the call fails with
+func (m *MockUniversalSDK) inject(ctx context.Context, method string) error {
+	m.mu.Lock()
+	var delay time.Duration
+	var failure *Fault
+	for _, fault := range m.faults {
+		if fault.Method != method {
+			continue
+		}
+		fault.calls++
+		if fault.calls <= fault.After || (fault.Times > 0 && fault.calls > fault.After+fault.Times) {
+			continue
+		}
+		delay += fault.Delay
+		if failure == nil && (fault.Error != "" || fault.Kind != "") {
+			failure = &fault.Fault
+		}
+	}
+	m.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if failure == nil {
+		return nil
+	}
+	kind, ok := errorKinds[failure.Kind]
+	if !ok {
+		kind = ErrNetwork
+	}
+	return &Error{Method: method, Kind: kind, Message: failure.Error}
+}
+
+// Scenario is a reproducible run of the mock SDK: its random source, latency, failure rate and injected faults
+type Scenario struct {
+	Seed        int64         `mapstructure:"SEED"`
+	Latency     time.Duration `mapstructure:"LATENCY"`
+	FailureRate float64       `mapstructure:"FAILURE_RATE"`
+	Faults      []Fault       `mapstructure:"FAULTS"`
+}
+
+// LoadScenario reads a scenario from a YAML file
+func LoadScenario(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("failed to read mock SDK scenario: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario parses a scenario from YAML, refusing faults of unknown methods
+func ParseScenario(data []byte) (Scenario, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return Scenario{}, fmt.Errorf("failed to parse mock SDK scenario: %w", err)
+	}
+	var scenario Scenario
+	if err := v.Unmarshal(&scenario); err != nil {
+		return Scenario{}, fmt.Errorf("failed to parse mock SDK scenario: %w", err)
+	}
+
+	if scenario.FailureRate < 0 || scenario.FailureRate > 1 {
+		return Scenario{}, fmt.Errorf("FAILURE_RATE must be between 0 and 1, got %v", scenario.FailureRate)
+	}
+	for i, fault := range scenario.Faults {
+		if !mockMethods[fault.Method] {
+			return Scenario{}, fmt.Errorf("FAULTS[%d]: unknown SDK method %q", i, fault.Method)
+		}
+		if fault.After < 0 || fault.Times < 0 || fault.Delay < 0 {
+			return Scenario{}, fmt.Errorf("FAULTS[%d]: AFTER, TIMES and DELAY must not be negative", i)
+		}
+		if _, ok := errorKinds[fault.Kind]; fault.Kind != "" && !ok {
+			return Scenario{}, fmt.Errorf("FAULTS[%d]: unknown error KIND %q", i, fault.Kind)
+		}
+	}
+	return scenario, nil
+}
+
+// Apply returns config running the scenario, keeping its wrapped tokens
+func (s Scenario) Apply(config MockSDKConfig) MockSDKConfig {
+	config.Seed = s.Seed
+	config.Latency = s.Latency
+	config.FailureRate = s.FailureRate
+	config.Faults = append(append([]Fault(nil), config.Faults...), s.Faults...)
+	return config
+}
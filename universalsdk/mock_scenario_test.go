@@ -0,0 +1,109 @@
+package universalsdk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/infinity-dex/services/types"
+)
+
+func TestMockSDKSeed(t *testing.T) {
+	ctx := context.Background()
+	transfer := TransferRequest{WrappedToken: types.Token{Symbol: "uETH"}, SourceChainID: 1, DestChainID: 137, Amount: big.NewInt(1e18)}
+
+	// Two mocks with the same seed fail, answer and name transactions alike
+	run := func() []string {
+		sdk := NewMockSDK(MockSDKConfig{Seed: 7, FailureRate: 0.5})
+		var outcomes []string
+		for i := 0; i < 10; i++ {
+			result, err := sdk.TransferToken(ctx, transfer)
+			if err != nil {
+				outcomes = append(outcomes, err.Error())
+				continue
+			}
+			outcomes = append(outcomes, result.TransactionID+result.SourceTxHash+result.DestTxHash)
+			status, _ := sdk.GetTransactionStatus(ctx, result.TransactionID)
+			outcomes = append(outcomes, status.Status)
+		}
+		return outcomes
+	}
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected seeded runs to match, differed at %d: %q and %q", i, first[i], second[i])
+		}
+	}
+}
+
+func TestMockSDKFaults(t *testing.T) {
+	sdk := NewMockSDK(MockSDKConfig{}).(*MockUniversalSDK)
+	sdk.FailNext(MethodWrapToken, 2, "wrap transaction failed: rpc timeout")
+	wrap := WrapRequest{Token: types.Token{Symbol: "ETH"}, Amount: big.NewInt(1e18)}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		_, err := sdk.WrapToken(context.Background(), wrap)
+		if attempt <= 2 && (err == nil || err.Error() != "wrap transaction failed: rpc timeout" || !errors.Is(err, ErrNetwork)) {
+			t.Errorf("Expected attempt %d to fail with a network error, got %v", attempt, err)
+		}
+		if attempt == 3 && err != nil {
+			t.Errorf("Expected attempt 3 to succeed, got %v", err)
+		}
+	}
+	// Other methods are left alone
+	if _, err := sdk.UnwrapToken(context.Background(), UnwrapRequest{Amount: big.NewInt(1e18)}); err != nil {
+		t.Errorf("Expected unwrap to succeed, got %v", err)
+	}
+
+	// Faults fail calls with errors of their kind
+	sdk.AddFault(Fault{Method: MethodGetFeeEstimate, Times: 1, Kind: "rate_limited"})
+	_, err := sdk.GetFeeEstimate(context.Background(), FeeEstimateRequest{Amount: big.NewInt(1e18)})
+	var sdkErr *Error
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &sdkErr) || sdkErr.Method != MethodGetFeeEstimate {
+		t.Errorf("Expected a rate limited fee estimate, got %v", err)
+	}
+
+	// A delayed call ends with its context
+	sdk.Delay(MethodTransferToken, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := sdk.TransferToken(ctx, TransferRequest{Amount: big.NewInt(1e18)}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the delayed transfer to time out, got %v", err)
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	scenario, err := LoadScenario("testdata/wrap_outage.yaml")
+	if err != nil {
+		t.Fatalf("Failed to load scenario: %v", err)
+	}
+	if scenario.Seed != 42 || len(scenario.Faults) != 2 {
+		t.Fatalf("Unexpected scenario: %+v", scenario)
+	}
+	wrap, transfer := scenario.Faults[0], scenario.Faults[1]
+	if wrap.Method != MethodWrapToken || wrap.Times != 2 || wrap.Error == "" {
+		t.Errorf("Unexpected wrap fault: %+v", wrap)
+	}
+	if transfer.Method != MethodTransferToken || transfer.Delay != 2*time.Second || transfer.Times != 0 {
+		t.Errorf("Unexpected transfer fault: %+v", transfer)
+	}
+
+	tokens := map[int64][]types.Token{1: {{Symbol: "uETH"}}}
+	config := scenario.Apply(MockSDKConfig{WrappedTokens: tokens, FailureRate: 0.05})
+	if config.Seed != 42 || config.FailureRate != 0 || len(config.Faults) != 2 || len(config.WrappedTokens[1]) != 1 {
+		t.Errorf("Expected the scenario applied over the wrapped tokens, got %+v", config)
+	}
+
+	for name, data := range map[string]string{
+		"unknown method":   "FAULTS:\n  - METHOD: \"SwapToken\"\n    TIMES: 1\n",
+		"negative times":   "FAULTS:\n  - METHOD: \"WrapToken\"\n    TIMES: -1\n",
+		"failure rate > 1": "FAILURE_RATE: 2\n",
+		"unknown kind":     "FAULTS:\n  - METHOD: \"WrapToken\"\n    KIND: \"gremlins\"\n",
+	} {
+		if _, err := ParseScenario([]byte(data)); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+}
@@ -2,10 +2,10 @@ package universalsdk
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,13 +26,32 @@ type SDK interface {
 	// GetWrappedTokens returns the list of available wrapped tokens
 	GetWrappedTokens(ctx context.Context, chainID int64) ([]types.Token, error)
 
+	// GetAllWrappedTokens returns the wrapped tokens of every supported chain, by chain ID, in one call
+	GetAllWrappedTokens(ctx context.Context) (map[int64][]types.Token, error)
+
 	// GetFeeEstimate returns an estimate of the fees for a swap operation
 	GetFeeEstimate(ctx context.Context, req FeeEstimateRequest) (*types.Fee, error)
 
 	// GetTransactionStatus returns the status of a transaction
 	GetTransactionStatus(ctx context.Context, transactionID string) (*TransactionStatus, error)
+
+	// WatchTransaction streams a transaction's status as it changes. The channel receives the current status first
+	// and is closed after a final status, completed or failed, or when ctx is done.
+	WatchTransaction(ctx context.Context, transactionID string) (<-chan TransactionStatus, error)
 }
 
+// Names of the SDK's methods, as middleware and injected faults see them
+const (
+	MethodWrapToken            = "WrapToken"
+	MethodUnwrapToken          = "UnwrapToken"
+	MethodTransferToken        = "TransferToken"
+	MethodGetWrappedTokens     = "GetWrappedTokens"
+	MethodGetAllWrappedTokens  = "GetAllWrappedTokens"
+	MethodGetFeeEstimate       = "GetFeeEstimate"
+	MethodGetTransactionStatus = "GetTransactionStatus"
+	MethodWatchTransaction     = "WatchTransaction"
+)
+
 // WrapRequest represents a request to wrap a native token
 type WrapRequest struct {
 	Token         types.Token `json:"token"`
@@ -114,6 +133,10 @@ type TransactionStatus struct {
 type MockUniversalSDK struct {
 	// Configuration
 	config MockSDKConfig
+
+	mu     sync.Mutex
+	rng    *rand.Rand // Seeded source of failures, statuses and IDs; nil uses the global source
+	faults []*mockFault
 }
 
 // MockSDKConfig holds configuration for the mock SDK
@@ -121,28 +144,77 @@ type MockSDKConfig struct {
 	WrappedTokens map[int64][]types.Token
 	Latency       time.Duration
 	FailureRate   float64 // 0.0 to 1.0, probability of transaction failure
+	Seed          int64   // Non-zero makes random failures, statuses, IDs and hashes repeat from run to run
+	Faults        []Fault // Failures and delays injected into specific calls
+	// WatchInterval is how often WatchTransaction polls a transaction's status; 0 polls every DefaultWatchInterval
+	WatchInterval time.Duration
 }
 
 // NewMockSDK creates a new mock Universal SDK for testing
 func NewMockSDK(config MockSDKConfig) SDK {
-	return &MockUniversalSDK{
+	m := &MockUniversalSDK{
 		config: config,
 	}
+	if config.Seed != 0 {
+		m.rng = rand.New(rand.NewSource(config.Seed))
+	}
+	for _, fault := range config.Faults {
+		m.AddFault(fault)
+	}
+	return m
+}
+
+// float64 returns a random number in [0.0, 1.0) from the mock's source
+func (m *MockUniversalSDK) float64() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rng == nil {
+		return rand.Float64()
+	}
+	return m.rng.Float64()
+}
+
+// intn returns a random number in [0, n) from the mock's source
+func (m *MockUniversalSDK) intn(n int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rng == nil {
+		return rand.Intn(n)
+	}
+	return m.rng.Intn(n)
+}
+
+// newID returns a random UUID from the mock's source
+func (m *MockUniversalSDK) newID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rng == nil {
+		return uuid.New().String()
+	}
+	return uuid.Must(uuid.NewRandomFromReader(m.rng)).String()
+}
+
+// newTxHash returns a random transaction hash from the mock's source
+func (m *MockUniversalSDK) newTxHash() string {
+	return fmt.Sprintf("0x%s", m.newID()[:32])
 }
 
 // WrapToken implements the SDK interface for mocking token wrapping
 func (m *MockUniversalSDK) WrapToken(ctx context.Context, req WrapRequest) (*WrapResult, error) {
 	// Simulate network latency
 	time.Sleep(m.config.Latency)
+	if err := m.inject(ctx, MethodWrapToken); err != nil {
+		return nil, err
+	}
 
 	// Simulate potential failures
-	if rand.Float64() < m.config.FailureRate {
-		return nil, errors.New("wrap transaction failed: network error")
+	if m.float64() < m.config.FailureRate {
+		return nil, newError(MethodWrapToken, ErrNetwork, "wrap transaction failed: network error")
 	}
 
 	// Mock successful wrap
-	txID := uuid.New().String()
-	txHash := fmt.Sprintf("0x%s", uuid.New().String()[:32])
+	txID := m.newID()
+	txHash := m.newTxHash()
 
 	// Create a wrapped token based on the source token
 	wrappedToken := req.Token
@@ -163,7 +235,7 @@ func (m *MockUniversalSDK) WrapToken(ctx context.Context, req WrapRequest) (*Wra
 	amount := new(big.Int).Set(req.Amount)
 	amount.Sub(amount, new(big.Int).Add(fee.GasFee, fee.ProtocolFee))
 	if amount.Cmp(big.NewInt(0)) <= 0 {
-		return nil, errors.New("amount too small to cover fees")
+		return nil, &Error{Method: MethodWrapToken, Kind: ErrInsufficientAmount}
 	}
 
 	return &WrapResult{
@@ -180,15 +252,18 @@ func (m *MockUniversalSDK) WrapToken(ctx context.Context, req WrapRequest) (*Wra
 func (m *MockUniversalSDK) UnwrapToken(ctx context.Context, req UnwrapRequest) (*UnwrapResult, error) {
 	// Simulate network latency
 	time.Sleep(m.config.Latency)
+	if err := m.inject(ctx, MethodUnwrapToken); err != nil {
+		return nil, err
+	}
 
 	// Simulate potential failures
-	if rand.Float64() < m.config.FailureRate {
-		return nil, errors.New("unwrap transaction failed: network error")
+	if m.float64() < m.config.FailureRate {
+		return nil, newError(MethodUnwrapToken, ErrNetwork, "unwrap transaction failed: network error")
 	}
 
 	// Mock successful unwrap
-	txID := uuid.New().String()
-	txHash := fmt.Sprintf("0x%s", uuid.New().String()[:32])
+	txID := m.newID()
+	txHash := m.newTxHash()
 
 	// Mock fee calculation
 	fee := types.Fee{
@@ -203,7 +278,7 @@ func (m *MockUniversalSDK) UnwrapToken(ctx context.Context, req UnwrapRequest) (
 	amount := new(big.Int).Set(req.Amount)
 	amount.Sub(amount, new(big.Int).Add(fee.GasFee, fee.ProtocolFee))
 	if amount.Cmp(big.NewInt(0)) <= 0 {
-		return nil, errors.New("amount too small to cover fees")
+		return nil, &Error{Method: MethodUnwrapToken, Kind: ErrInsufficientAmount}
 	}
 
 	return &UnwrapResult{
@@ -220,20 +295,23 @@ func (m *MockUniversalSDK) UnwrapToken(ctx context.Context, req UnwrapRequest) (
 func (m *MockUniversalSDK) TransferToken(ctx context.Context, req TransferRequest) (*TransferResult, error) {
 	// Simulate network latency
 	time.Sleep(m.config.Latency)
+	if err := m.inject(ctx, MethodTransferToken); err != nil {
+		return nil, err
+	}
 
 	// Simulate potential failures
-	if rand.Float64() < m.config.FailureRate {
-		return nil, errors.New("transfer transaction failed: network error")
+	if m.float64() < m.config.FailureRate {
+		return nil, newError(MethodTransferToken, ErrBridgeDown, "transfer transaction failed: bridge unavailable")
 	}
 
 	// Mock successful transfer
-	txID := uuid.New().String()
-	sourceTxHash := fmt.Sprintf("0x%s", uuid.New().String()[:32])
+	txID := m.newID()
+	sourceTxHash := m.newTxHash()
 
 	// Dest tx might not be available immediately
 	var destTxHash string
-	if rand.Float64() > 0.3 {
-		destTxHash = fmt.Sprintf("0x%s", uuid.New().String()[:32])
+	if m.float64() > 0.3 {
+		destTxHash = m.newTxHash()
 	}
 
 	// Mock fee calculation
@@ -252,7 +330,7 @@ func (m *MockUniversalSDK) TransferToken(ctx context.Context, req TransferReques
 		new(big.Int).Add(fee.NetworkFee, fee.BridgeFee),
 	))
 	if amount.Cmp(big.NewInt(0)) <= 0 {
-		return nil, errors.New("amount too small to cover fees")
+		return nil, &Error{Method: MethodTransferToken, Kind: ErrInsufficientAmount}
 	}
 
 	// Estimate completion time based on chains
@@ -276,19 +354,40 @@ func (m *MockUniversalSDK) TransferToken(ctx context.Context, req TransferReques
 func (m *MockUniversalSDK) GetWrappedTokens(ctx context.Context, chainID int64) ([]types.Token, error) {
 	// Simulate network latency
 	time.Sleep(m.config.Latency / 2) // Faster lookup operation
+	if err := m.inject(ctx, MethodGetWrappedTokens); err != nil {
+		return nil, err
+	}
 
 	tokens, exists := m.config.WrappedTokens[chainID]
 	if !exists {
-		return nil, fmt.Errorf("chain ID %d not supported", chainID)
+		return nil, newError(MethodGetWrappedTokens, ErrChainUnsupported, "chain ID %d not supported", chainID)
 	}
 
 	return tokens, nil
 }
 
+// GetAllWrappedTokens implements the SDK interface for retrieving the wrapped tokens of every supported chain
+func (m *MockUniversalSDK) GetAllWrappedTokens(ctx context.Context) (map[int64][]types.Token, error) {
+	// Simulate network latency; one lookup however many chains there are
+	time.Sleep(m.config.Latency / 2)
+	if err := m.inject(ctx, MethodGetAllWrappedTokens); err != nil {
+		return nil, err
+	}
+
+	tokens := make(map[int64][]types.Token, len(m.config.WrappedTokens))
+	for chainID, chainTokens := range m.config.WrappedTokens {
+		tokens[chainID] = chainTokens
+	}
+	return tokens, nil
+}
+
 // GetFeeEstimate implements the SDK interface for fee estimation
 func (m *MockUniversalSDK) GetFeeEstimate(ctx context.Context, req FeeEstimateRequest) (*types.Fee, error) {
 	// Simulate network latency
 	time.Sleep(m.config.Latency / 2) // Faster lookup operation
+	if err := m.inject(ctx, MethodGetFeeEstimate); err != nil {
+		return nil, err
+	}
 
 	// Check if tokens are on different chains
 	isCrossChain := req.SourceToken.ChainID != req.DestinationToken.ChainID
@@ -312,28 +411,12 @@ func (m *MockUniversalSDK) GetFeeEstimate(ctx context.Context, req FeeEstimateRe
 		protocolFee.Mul(protocolFee, new(big.Int).Add(factor, big.NewInt(1)))
 	}
 
-	// Calculate total USD fee (simplified conversion)
-	totalFeeUSD := 0.0
-	totalFeeWei := new(big.Int).Add(
-		new(big.Int).Add(gasFee, protocolFee),
-		new(big.Int).Add(networkFee, bridgeFee),
-	)
-
-	// Simplified conversion: 1 ETH = $2000
-	ethToUSD := 2000.0
-	totalFeeETH := new(big.Float).Quo(
-		new(big.Float).SetInt(totalFeeWei),
-		new(big.Float).SetInt(big.NewInt(1000000000000000000)),
-	)
-	totalFeeETHFloat, _ := totalFeeETH.Float64()
-	totalFeeUSD = totalFeeETHFloat * ethToUSD
-
+	// The mock has no prices, so it leaves the fee's USD value to the caller's price oracle
 	return &types.Fee{
 		GasFee:      gasFee,
 		ProtocolFee: protocolFee,
 		NetworkFee:  networkFee,
 		BridgeFee:   bridgeFee,
-		TotalFeeUSD: totalFeeUSD,
 	}, nil
 }
 
@@ -341,22 +424,25 @@ func (m *MockUniversalSDK) GetFeeEstimate(ctx context.Context, req FeeEstimateRe
 func (m *MockUniversalSDK) GetTransactionStatus(ctx context.Context, transactionID string) (*TransactionStatus, error) {
 	// Simulate network latency
 	time.Sleep(m.config.Latency / 2) // Faster lookup operation
+	if err := m.inject(ctx, MethodGetTransactionStatus); err != nil {
+		return nil, err
+	}
 
 	// Simulate random transaction state
 	statuses := []string{"pending", "completed", "failed"}
-	statusIndex := rand.Intn(len(statuses))
+	statusIndex := m.intn(len(statuses))
 	status := statuses[statusIndex]
 
 	// Generate mock transaction hashes
-	sourceTxHash := fmt.Sprintf("0x%s", uuid.New().String()[:32])
+	sourceTxHash := m.newTxHash()
 
 	var destTxHash, bridgeTxHash string
 	var completionTime time.Time
 	var errorMessage string
 
 	if status == "completed" {
-		destTxHash = fmt.Sprintf("0x%s", uuid.New().String()[:32])
-		bridgeTxHash = fmt.Sprintf("0x%s", uuid.New().String()[:32])
+		destTxHash = m.newTxHash()
+		bridgeTxHash = m.newTxHash()
 		completionTime = time.Now()
 	} else if status == "failed" {
 		errorMessage = "Transaction failed due to network congestion"
@@ -372,3 +458,11 @@ func (m *MockUniversalSDK) GetTransactionStatus(ctx context.Context, transaction
 		ErrorMessage:   errorMessage,
 	}, nil
 }
+
+// WatchTransaction implements the SDK interface by polling the transaction's status every WatchInterval
+func (m *MockUniversalSDK) WatchTransaction(ctx context.Context, transactionID string) (<-chan TransactionStatus, error) {
+	if err := m.inject(ctx, MethodWatchTransaction); err != nil {
+		return nil, err
+	}
+	return PollTransaction(ctx, m, transactionID, m.config.WatchInterval)
+}
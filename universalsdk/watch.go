@@ -0,0 +1,55 @@
+package universalsdk
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchInterval is how often PollTransaction looks up a watched transaction's status when not told otherwise
+const DefaultWatchInterval = 5 * time.Second
+
+// IsFinalStatus reports whether a transaction status is one it never leaves
+func IsFinalStatus(status string) bool {
+	return status == "completed" || status == "failed"
+}
+
+// PollTransaction watches a transaction by looking up its status every interval, for SDKs without a push
+// subscription. The first lookup is made before it returns, and its error is returned, so an unknown transaction
+// fails at once. The channel then receives the first status and every status change after it, and is closed after
+// a final status or when ctx is done. Lookups failing later are skipped, as the transaction moves on regardless.
+func PollTransaction(ctx context.Context, sdk SDK, transactionID string, interval time.Duration) (<-chan TransactionStatus, error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	status, err := sdk.GetTransactionStatus(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(chan TransactionStatus, 1)
+	statuses <- *status
+	go func() {
+		defer close(statuses)
+		last := status.Status
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for !IsFinalStatus(last) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			status, err := sdk.GetTransactionStatus(ctx, transactionID)
+			if err != nil || status.Status == last {
+				continue
+			}
+			last = status.Status
+			select {
+			case statuses <- *status:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return statuses, nil
+}
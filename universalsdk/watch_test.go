@@ -0,0 +1,73 @@
+package universalsdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sequenceSDK answers status lookups with its statuses in turn, repeating the last one
+type sequenceSDK struct {
+	SDK
+	mu       sync.Mutex
+	statuses []string
+	lookups  int
+}
+
+func (s *sequenceSDK) GetTransactionStatus(ctx context.Context, transactionID string) (*TransactionStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.statuses) == 0 {
+		return nil, errors.New("transaction not found")
+	}
+	status := s.statuses[min(s.lookups, len(s.statuses)-1)]
+	s.lookups++
+	if status == "error" {
+		return nil, errors.New("status unavailable")
+	}
+	return &TransactionStatus{TransactionID: transactionID, Status: status}, nil
+}
+
+func TestPollTransaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Repeated statuses and failed lookups are skipped, and the watch ends with the final status
+	sdk := &sequenceSDK{statuses: []string{"pending", "pending", "error", "bridging", "completed"}}
+	statuses, err := PollTransaction(ctx, sdk, "tx-1", time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+	var got []string
+	for status := range statuses {
+		got = append(got, status.Status)
+	}
+	want := []string{"pending", "bridging", "completed"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+
+	// An unknown transaction fails at once
+	if _, err := PollTransaction(ctx, &sequenceSDK{}, "tx-2", time.Millisecond); err == nil {
+		t.Error("Expected watching an unknown transaction to fail")
+	}
+
+	// A watch ends with its context
+	watchCtx, stop := context.WithCancel(ctx)
+	statuses, err = PollTransaction(watchCtx, &sequenceSDK{statuses: []string{"pending"}}, "tx-3", time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+	<-statuses
+	stop()
+	if _, ok := <-statuses; ok {
+		t.Error("Expected the watch to end with its context")
+	}
+}